@@ -3,8 +3,13 @@
 // The analytics worker is a background consumer that reads click events
 // from a Redis Stream (published by the redirect-service) and increments
 // per-URL click counters in PostgreSQL. It runs as part of a Redis consumer
-// group, which means multiple instances can share the workload and pick up
-// where a crashed peer left off.
+// group (ANALYTICS_CONSUMER_GROUP), which means multiple instances can share
+// the workload and pick up where a crashed peer left off. The pipeline-worker
+// reads the same stream through a group of its own, so every click reaches
+// both workers.
+//
+// With CLICK_SIGNING_ENABLED, events whose signature does not match are
+// not counted but moved to CLICK_DEAD_LETTER_STREAM.
 //
 // This worker handles the lightweight "aggregate counts" path. For the
 // richer enrichment pipeline (GeoIP lookup, user-agent parsing, ClickHouse
@@ -18,10 +23,13 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Varun5711/shorternit/internal/clickdelta"
 	"github.com/Varun5711/shorternit/internal/config"
 	"github.com/Varun5711/shorternit/internal/database"
+	"github.com/Varun5711/shorternit/internal/events"
 	"github.com/Varun5711/shorternit/internal/logger"
 	"github.com/Varun5711/shorternit/internal/redis"
+	"github.com/Varun5711/shorternit/internal/retry"
 	"github.com/Varun5711/shorternit/internal/tracing"
 	redislib "github.com/redis/go-redis/v9"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
@@ -38,6 +46,7 @@ type WorkerParams struct {
 	BatchSize     int
 	PollInterval  time.Duration
 	BlockTime     time.Duration
+	DeadLetters   string // stream for events failing the signature check
 }
 
 // provideConfig loads the unified application configuration from environment
@@ -55,25 +64,36 @@ func provideLogger() *logger.Logger {
 // provideRedisClient connects to the shared Redis instance. Redis is the
 // source of click events (via Streams) that this worker consumes.
 func provideRedisClient(cfg *config.Config) (*redis.RedisClient, error) {
-	return redis.NewRedisClient(context.Background(), redis.Config{
-		Addr:     cfg.Redis.Addr,
-		Password: cfg.Redis.Password,
-		DB:       cfg.Redis.DB,
+	var client *redis.RedisClient
+	err := retry.Dial(context.Background(), "Redis", cfg.Startup.ConnectAttempts, cfg.Startup.ConnectBackoff, func() (err error) {
+		client, err = redis.NewRedisClient(context.Background(), redis.Config{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		return err
 	})
+	return client, err
 }
 
 // provideDBManager sets up a PostgreSQL connection pool. The analytics
 // worker only writes to the primary: it increments click counters in the
 // urls table via batch UPDATE statements.
 func provideDBManager(cfg *config.Config) (*database.DBManager, error) {
-	return database.NewDBManager(context.Background(), database.Config{
-		PrimaryDSN:      cfg.Database.PrimaryDSN,
-		ReplicaDSNs:     cfg.Database.ReplicaDSNs,
-		MaxConns:        cfg.Database.MaxConns,
-		MinConns:        cfg.Database.MinConns,
-		MaxConnLifetime: cfg.Database.MaxConnLifetime,
-		MaxConnIdleTime: cfg.Database.MaxConnIdleTime,
+	var db *database.DBManager
+	err := retry.Dial(context.Background(), "PostgreSQL", cfg.Startup.ConnectAttempts, cfg.Startup.ConnectBackoff, func() (err error) {
+		db, err = database.NewDBManager(context.Background(), database.Config{
+			PrimaryDSN:      cfg.Database.PrimaryDSN,
+			ReplicaDSNs:     cfg.Database.ReplicaDSNs,
+			MaxConns:        cfg.Database.MaxConns,
+			MinConns:        cfg.Database.MinConns,
+			MaxConnLifetime: cfg.Database.MaxConnLifetime,
+			MaxConnIdleTime: cfg.Database.MaxConnIdleTime,
+			QueryTimeout:    cfg.Database.QueryTimeout,
+		})
+		return err
 	})
+	return db, err
 }
 
 // provideTracerProvider initializes OpenTelemetry distributed tracing and
@@ -101,20 +121,31 @@ func provideWorkerParams(cfg *config.Config) WorkerParams {
 		BatchSize:     cfg.Analytics.BatchSize,
 		PollInterval:  cfg.Analytics.PollInterval,
 		BlockTime:     cfg.Analytics.BlockTime,
+		DeadLetters:   cfg.ClickSigning.DeadLetterStream,
 	}
 }
 
+// provideClickSigner returns the Signer that verifies click events, or nil
+// when CLICK_SIGNING_ENABLED is off. Signing without a secret fails startup.
+func provideClickSigner(cfg *config.Config) (*events.Signer, error) {
+	if !cfg.ClickSigning.Enabled {
+		return nil, nil
+	}
+	return events.NewSigner(cfg.ClickSigning.Secret)
+}
+
 // registerLifecycle wires the worker into the FX lifecycle. On start, it
 // ensures the Redis consumer group exists (creating the stream if needed),
 // then launches the event processing loop in a background goroutine. On
-// stop, it cancels the worker context and waits for the goroutine to drain,
-// ensuring no events are lost mid-batch before closing infrastructure
-// connections.
+// stop, it cancels the worker context and waits for the goroutine to
+// commit and acknowledge the batch in hand (see batchFlushTimeout) before
+// closing infrastructure connections.
 func registerLifecycle(
 	lc fx.Lifecycle,
 	redisClient *redis.RedisClient,
 	tp *sdktrace.TracerProvider,
 	dbManager *database.DBManager,
+	signer *events.Signer,
 	params WorkerParams,
 	log *logger.Logger,
 ) {
@@ -132,7 +163,10 @@ func registerLifecycle(
 
 			go func() {
 				defer wg.Done()
-				processEvents(workerCtx, client, dbManager, params, log)
+				addClicks := func(ctx context.Context, clickCounts map[string]int) error {
+					return updateClickCounts(ctx, dbManager, clickCounts)
+				}
+				processEvents(workerCtx, client, addClicks, clickdelta.NewCounter(client, 0), signer, params, log)
 			}()
 
 			log.Info("Processing click events")
@@ -153,12 +187,26 @@ func registerLifecycle(
 	})
 }
 
+// clickSink adds a batch's clicks, counted per short code, to the links'
+// stored click counts.
+type clickSink func(ctx context.Context, clickCounts map[string]int) error
+
+// batchFlushTimeout bounds how long a batch read before shutdown may take to
+// be committed and acknowledged. It is shorter than FX's default stop
+// timeout, so the flush finishes before the process is torn down.
+const batchFlushTimeout = 10 * time.Second
+
 // processEvents is the main event loop. It performs a blocking XREADGROUP
-// on the Redis Stream, batches messages by short code to minimize database
-// round-trips, updates click counts in a single transaction, and
-// acknowledges consumed messages. On transient errors it backs off by
-// PollInterval before retrying.
-func processEvents(ctx context.Context, client *redislib.Client, dbManager *database.DBManager, params WorkerParams, log *logger.Logger) {
+// on the Redis Stream and hands each batch to handleBatch. It starts with
+// the messages this consumer read before a crash or restart but never
+// acknowledged, then moves on to new ones. When ctx is cancelled it
+// returns after the batch in hand, if any, is flushed. addClicks commits a
+// batch's click counts; in production it is updateClickCounts. On
+// transient errors it backs off by PollInterval before retrying.
+func processEvents(ctx context.Context, client *redislib.Client, addClicks clickSink, deltas *clickdelta.Counter, signer *events.Signer, params WorkerParams, log *logger.Logger) {
+	// Pending messages are read from after the last one seen, so a message
+	// that cannot be counted is passed over rather than re-read forever.
+	next := "0"
 	for {
 		select {
 		case <-ctx.Done():
@@ -169,7 +217,7 @@ func processEvents(ctx context.Context, client *redislib.Client, dbManager *data
 		messages, err := client.XReadGroup(ctx, &redislib.XReadGroupArgs{
 			Group:    params.ConsumerGroup,
 			Consumer: params.ConsumerName,
-			Streams:  []string{params.StreamName, ">"},
+			Streams:  []string{params.StreamName, next},
 			Count:    int64(params.BatchSize),
 			Block:    params.BlockTime,
 		}).Result()
@@ -184,36 +232,79 @@ func processEvents(ctx context.Context, client *redislib.Client, dbManager *data
 		}
 
 		for _, stream := range messages {
+			if next != ">" {
+				if len(stream.Messages) == 0 {
+					next = ">"
+					continue
+				}
+				next = stream.Messages[len(stream.Messages)-1].ID
+			}
 			if len(stream.Messages) == 0 {
 				continue
 			}
 
-			clickCounts := make(map[string]int)
-			messageIDs := make([]string, 0, len(stream.Messages))
+			// The batch has been read, so it is flushed even if shutdown
+			// began meanwhile: cancelling the commit would leave its
+			// clicks uncounted until the consumer next starts.
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), batchFlushTimeout)
+			handleBatch(flushCtx, client, addClicks, deltas, signer, params, stream.Messages, log)
+			cancel()
+		}
+	}
+}
 
-			for _, msg := range stream.Messages {
-				shortCode, ok := msg.Values["short_code"].(string)
-				if !ok {
-					log.Warn("Invalid message format: %v", msg.ID)
-					continue
-				}
-				clickCounts[shortCode]++
-				messageIDs = append(messageIDs, msg.ID)
-			}
+// handleBatch counts a batch of click events towards their links in one
+// transaction, settles them against the Redis click deltas, acknowledges
+// them and records the flush for GET /api/admin/pipeline. A message is only
+// acknowledged once its click is committed: when the commit fails the batch
+// stays pending, to be retried when the consumer next starts. Messages
+// failing the signature check are moved to the dead-letter stream uncounted;
+// one that cannot be moved stays pending.
+func handleBatch(ctx context.Context, client *redislib.Client, addClicks clickSink, deltas *clickdelta.Counter, signer *events.Signer, params WorkerParams, messages []redislib.XMessage, log *logger.Logger) {
+	clickCounts := make(map[string]int)
+	messageIDs := make([]string, 0, len(messages))
 
-			if len(clickCounts) > 0 {
-				if err := updateClickCounts(ctx, dbManager, clickCounts); err != nil {
-					log.Error("Failed to update database: %v", err)
-					continue
-				}
-				log.Debug("Processed %d events for %d URLs", len(messageIDs), len(clickCounts))
+	for _, msg := range messages {
+		if err := signer.Verify(msg.Values); err != nil {
+			if err := events.DeadLetter(ctx, client, params.DeadLetters, msg, params.ConsumerGroup, err.Error()); err != nil {
+				log.Error("%v", err)
+				continue
 			}
-
-			if len(messageIDs) > 0 {
-				if err := client.XAck(ctx, params.StreamName, params.ConsumerGroup, messageIDs...).Err(); err != nil {
-					log.Error("Failed to acknowledge messages: %v", err)
-				}
+			log.Warn("Dead-lettered click event %s: %v", msg.ID, err)
+			if err := client.XAck(ctx, params.StreamName, params.ConsumerGroup, msg.ID).Err(); err != nil {
+				log.Error("Failed to acknowledge message: %v", err)
 			}
+			continue
+		}
+		shortCode, ok := msg.Values["short_code"].(string)
+		if !ok {
+			log.Warn("Invalid message format: %v", msg.ID)
+			continue
+		}
+		clickCounts[shortCode]++
+		messageIDs = append(messageIDs, msg.ID)
+	}
+
+	if len(clickCounts) > 0 {
+		if err := addClicks(ctx, clickCounts); err != nil {
+			log.Error("Failed to update database: %v", err)
+			return
+		}
+		// The flushed clicks are in the database count now; take them off
+		// the near-real-time delta so they are not reported twice.
+		if err := deltas.Settle(ctx, clickCounts); err != nil {
+			log.Warn("%v", err)
+		}
+		log.Debug("Processed %d events for %d URLs", len(messageIDs), len(clickCounts))
+	}
+
+	if len(messageIDs) > 0 {
+		if err := client.XAck(ctx, params.StreamName, params.ConsumerGroup, messageIDs...).Err(); err != nil {
+			log.Error("Failed to acknowledge messages: %v", err)
+			return
+		}
+		if err := events.RecordFlush(ctx, client, params.StreamName, params.ConsumerGroup, params.ConsumerName); err != nil {
+			log.Warn("%v", err)
 		}
 	}
 }
@@ -257,6 +348,7 @@ func main() {
 			provideRedisClient,
 			provideDBManager,
 			provideWorkerParams,
+			provideClickSigner,
 		),
 		fx.Invoke(registerLifecycle),
 	).Run()
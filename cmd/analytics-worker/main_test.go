@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clickdelta"
+	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeStream answers the commands of one consumer from memory instead of a
+// Redis server: XREADGROUP ">" delivers queued messages and makes them
+// pending, XREADGROUP from an ID re-reads pending ones after it, and XACK
+// clears them. Recorded flushes are counted. Scripts (the click delta
+// settlement) succeed and do nothing.
+type fakeStream struct {
+	mu      sync.Mutex
+	queued  []redis.XMessage
+	pending []redis.XMessage
+	acked   []string
+	flushes int // flushes recorded
+}
+
+func (s *fakeStream) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (s *fakeStream) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		s.process(cmd)
+		return cmd.Err()
+	}
+}
+
+func (s *fakeStream) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			s.process(cmd)
+		}
+		return nil
+	}
+}
+
+func (s *fakeStream) process(cmd redis.Cmder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	args := cmd.Args()
+	switch c := cmd.(type) {
+	case *redis.XStreamSliceCmd: // XREADGROUP ... STREAMS stream id
+		stream, id := args[len(args)-2].(string), args[len(args)-1].(string)
+		var msgs []redis.XMessage
+		if id == ">" {
+			if len(s.queued) == 0 {
+				time.Sleep(time.Millisecond) // as if blocking
+				c.SetErr(redis.Nil)
+				return
+			}
+			msgs, s.queued = s.queued, nil
+			s.pending = append(s.pending, msgs...)
+		} else {
+			for _, msg := range s.pending {
+				if id == "0" || msg.ID > id {
+					msgs = append(msgs, msg)
+				}
+			}
+		}
+		c.SetVal([]redis.XStream{{Stream: stream, Messages: msgs}})
+	case *redis.IntCmd: // XACK stream group id..., or HSET of a flush
+		if cmd.Name() == "hset" {
+			s.flushes++
+			c.SetVal(1)
+			return
+		}
+		for _, id := range args[3:] {
+			s.acked = append(s.acked, id.(string))
+			for i, msg := range s.pending {
+				if msg.ID == id {
+					s.pending = append(s.pending[:i], s.pending[i+1:]...)
+					break
+				}
+			}
+		}
+		c.SetVal(int64(len(args) - 3))
+	case *redis.Cmd:
+		c.SetVal(int64(0))
+	default:
+		cmd.SetErr(fmt.Errorf("unexpected command %v", args))
+	}
+}
+
+func newFakeStream(codes ...string) (*redis.Client, *fakeStream) {
+	s := &fakeStream{}
+	for i, code := range codes {
+		s.queued = append(s.queued, redis.XMessage{
+			ID:     fmt.Sprintf("%d-0", i+1),
+			Values: map[string]interface{}{"short_code": code},
+		})
+	}
+	client := redis.NewClient(&redis.Options{})
+	client.AddHook(s)
+	return client, s
+}
+
+// runWorker runs processEvents until addClicks has been called and ctx is
+// cancelled, failing the test if it does not return.
+func runWorker(t *testing.T, ctx context.Context, client *redis.Client, addClicks clickSink) {
+	t.Helper()
+	params := WorkerParams{StreamName: "clicks", ConsumerGroup: "analytics", ConsumerName: "worker-1", BatchSize: 100}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		processEvents(ctx, client, addClicks, clickdelta.NewCounter(client, 0), nil, params, logger.New("analytics-test"))
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected processEvents to return after shutdown")
+	}
+}
+
+// TestProcessEvents_ShutdownMidBatchFlushes cancels the worker while a
+// batch is being committed: the commit still runs to completion and every
+// message of the batch is acknowledged before processEvents returns.
+func TestProcessEvents_ShutdownMidBatchFlushes(t *testing.T) {
+	client, stream := newFakeStream("abc", "abc", "xyz")
+	ctx, shutdown := context.WithCancel(context.Background())
+	defer shutdown()
+
+	var committed map[string]int
+	runWorker(t, ctx, client, func(ctx context.Context, clickCounts map[string]int) error {
+		shutdown()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		committed = clickCounts
+		return nil
+	})
+
+	if committed["abc"] != 2 || committed["xyz"] != 1 {
+		t.Errorf("expected abc=2 and xyz=1 committed, got %v", committed)
+	}
+	if len(stream.acked) != 3 || len(stream.pending) != 0 {
+		t.Errorf("expected all 3 messages acknowledged, got acked %v and pending %v", stream.acked, stream.pending)
+	}
+	if stream.flushes != 1 {
+		t.Errorf("expected the flush recorded once, got %d", stream.flushes)
+	}
+}
+
+// TestProcessEvents_FailedCommitStaysPending verifies that a batch whose
+// commit fails is not acknowledged, and that the consumer counts it when
+// it next starts.
+func TestProcessEvents_FailedCommitStaysPending(t *testing.T) {
+	client, stream := newFakeStream("abc", "abc")
+	ctx, shutdown := context.WithCancel(context.Background())
+	runWorker(t, ctx, client, func(ctx context.Context, clickCounts map[string]int) error {
+		shutdown()
+		return errors.New("database unavailable")
+	})
+	if len(stream.acked) != 0 || len(stream.pending) != 2 || stream.flushes != 0 {
+		t.Fatalf("expected nothing acknowledged or flushed without a commit, got acked %v and pending %v", stream.acked, stream.pending)
+	}
+
+	ctx, shutdown = context.WithCancel(context.Background())
+	defer shutdown()
+	var committed map[string]int
+	runWorker(t, ctx, client, func(ctx context.Context, clickCounts map[string]int) error {
+		shutdown()
+		committed = clickCounts
+		return nil
+	})
+	if committed["abc"] != 2 || len(stream.acked) != 2 || len(stream.pending) != 0 {
+		t.Errorf("expected the pending batch counted and acknowledged on restart, got %v, acked %v", committed, stream.acked)
+	}
+}
@@ -13,9 +13,8 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"net/http"
-	"os"
+	"net/netip"
 	"strings"
 	"time"
 
@@ -24,17 +23,22 @@ import (
 	"github.com/Varun5711/shorternit/internal/config"
 	"github.com/Varun5711/shorternit/internal/database"
 	es "github.com/Varun5711/shorternit/internal/elasticsearch"
+	"github.com/Varun5711/shorternit/internal/events"
+	grpcClient "github.com/Varun5711/shorternit/internal/grpc"
 	"github.com/Varun5711/shorternit/internal/handlers"
 	"github.com/Varun5711/shorternit/internal/logger"
 	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/Varun5711/shorternit/internal/qrcode"
 	"github.com/Varun5711/shorternit/internal/redis"
+	"github.com/Varun5711/shorternit/internal/retry"
+	"github.com/Varun5711/shorternit/internal/sharelink"
 	"github.com/Varun5711/shorternit/internal/tracing"
 	userpb "github.com/Varun5711/shorternit/proto/user"
 	redislib "github.com/redis/go-redis/v9"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/fx"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 // ---------------------------------------------------------------------------
@@ -58,44 +62,56 @@ func provideLogger() *logger.Logger {
 // Redis is used by the gateway for rate limiting (via the raw client) and
 // health-check pings.
 func provideRedisClient(cfg *config.Config) (*redis.RedisClient, error) {
-	return redis.NewRedisClient(context.Background(), redis.Config{
-		Addr:     cfg.Redis.Addr,
-		Password: cfg.Redis.Password,
-		DB:       cfg.Redis.DB,
+	var client *redis.RedisClient
+	err := retry.Dial(context.Background(), "Redis", cfg.Startup.ConnectAttempts, cfg.Startup.ConnectBackoff, func() (err error) {
+		client, err = redis.NewRedisClient(context.Background(), redis.Config{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		return err
 	})
+	return client, err
 }
 
 // provideDBManager sets up a PostgreSQL connection pool with primary/replica
 // topology. The gateway needs direct DB access for the analytics service,
 // which queries click-count aggregates stored in PostgreSQL.
 func provideDBManager(cfg *config.Config) (*database.DBManager, error) {
-	return database.NewDBManager(context.Background(), database.Config{
-		PrimaryDSN:      cfg.Database.PrimaryDSN,
-		ReplicaDSNs:     cfg.Database.ReplicaDSNs,
-		MaxConns:        cfg.Database.MaxConns,
-		MinConns:        cfg.Database.MinConns,
-		MaxConnLifetime: cfg.Database.MaxConnLifetime,
-		MaxConnIdleTime: cfg.Database.MaxConnIdleTime,
+	var db *database.DBManager
+	err := retry.Dial(context.Background(), "PostgreSQL", cfg.Startup.ConnectAttempts, cfg.Startup.ConnectBackoff, func() (err error) {
+		db, err = database.NewDBManager(context.Background(), database.Config{
+			PrimaryDSN:      cfg.Database.PrimaryDSN,
+			ReplicaDSNs:     cfg.Database.ReplicaDSNs,
+			MaxConns:        cfg.Database.MaxConns,
+			MinConns:        cfg.Database.MinConns,
+			MaxConnLifetime: cfg.Database.MaxConnLifetime,
+			MaxConnIdleTime: cfg.Database.MaxConnIdleTime,
+			QueryTimeout:    cfg.Database.QueryTimeout,
+		})
+		return err
 	})
+	return db, err
 }
 
 // provideClickHouseClient connects to ClickHouse for high-volume click
 // analytics queries (timeline, geo, device breakdown). ClickHouse is the
 // OLAP store that the pipeline-worker writes enriched events into.
 func provideClickHouseClient(cfg *config.Config) (*clickhouse.Client, error) {
-	return clickhouse.NewClient(cfg.ClickHouse)
+	var client *clickhouse.Client
+	err := retry.Dial(context.Background(), "ClickHouse", cfg.Startup.ConnectAttempts, cfg.Startup.ConnectBackoff, func() (err error) {
+		client, err = clickhouse.NewClient(cfg.ClickHouse)
+		return err
+	})
+	return client, err
 }
 
 // provideUserGRPCConn dials the user-service gRPC endpoint. The address is
 // read from USER_SERVICE_ADDR and defaults to localhost:50052 for local
-// development. Insecure credentials are used because services communicate
-// over an internal network (TLS termination happens at the edge).
-func provideUserGRPCConn() (*grpc.ClientConn, error) {
-	addr := os.Getenv("USER_SERVICE_ADDR")
-	if addr == "" {
-		addr = "localhost:50052"
-	}
-	return grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// development. The connection keeps itself alive and reconnects as
+// configured by cfg.GRPC (see grpcClient.Dial).
+func provideUserGRPCConn(cfg *config.Config) (*grpc.ClientConn, error) {
+	return grpcClient.Dial(cfg.Services.UserServiceAddr, cfg.GRPC)
 }
 
 // provideRawRedisClient unwraps the internal RedisClient to expose the
@@ -123,29 +139,57 @@ func provideUserServiceClient(conn *grpc.ClientConn) userpb.UserServiceClient {
 // provideHTTPHandler creates the URL CRUD handler that proxies requests to
 // the url-service over gRPC. It also receives the Elasticsearch client for
 // URL search functionality; if ES is nil, search endpoints return 501.
-func provideHTTPHandler(cfg *config.Config, esClient *es.Client) (*handlers.HTTPHandler, error) {
-	return handlers.NewHTTPHandler(cfg.Services.URLServiceAddr, cfg.Services.BaseURL, esClient)
+func provideHTTPHandler(cfg *config.Config, esClient *es.Client, qrStore qrcode.Store, rc *redislib.Client) (*handlers.HTTPHandler, error) {
+	// QR codes rendered on demand go to the object store alongside the
+	// uploaded ones, or are cached in Redis when there is none.
+	var qrCache qrcode.Store = qrcode.NewRedisStore(rc, cfg.QRCode.CacheTTL)
+	if qrStore != nil {
+		qrCache = qrStore
+	}
+	return handlers.NewHTTPHandler(cfg.Services.URLServiceAddr, cfg.GRPC, cfg.Services.BaseURL, esClient, qrStore, qrCache, cfg.Anonymous.LinkTTL)
+}
+
+// provideQRStore opens the object storage the URL service uploads QR codes
+// to, so GET /api/urls/{code}/qr.png can serve them. It returns nil for the
+// default "db" backend.
+func provideQRStore(cfg *config.Config) (qrcode.Store, error) {
+	return qrcode.NewStore(cfg.QRCode)
 }
 
 // provideAuthHandler creates the handler for /api/auth/* endpoints
 // (register, login, profile). It delegates all authentication logic to the
 // user-service via gRPC, keeping the gateway stateless.
-func provideAuthHandler(userClient userpb.UserServiceClient) *handlers.AuthHandler {
-	return handlers.NewAuthHandler(userClient)
+func provideAuthHandler(userClient userpb.UserServiceClient, trustedProxies []netip.Prefix) *handlers.AuthHandler {
+	return handlers.NewAuthHandler(userClient, trustedProxies)
 }
 
 // provideAnalyticsService creates the PostgreSQL-backed analytics service
 // that reads pre-aggregated click counts. This complements the ClickHouse
-// analytics with lightweight summary queries.
-func provideAnalyticsService(db *database.DBManager) *analytics.Service {
-	return analytics.NewService(db)
+// analytics with lightweight summary queries. Per-link stats are cached in
+// Redis for ANALYTICS_STATS_CACHE_TTL to spare the clicks table from
+// dashboards that poll.
+func provideAnalyticsService(cfg *config.Config, db *database.DBManager, rc *redislib.Client) *analytics.Service {
+	return analytics.NewService(db, analytics.NewPostgresStatsStore(db), rc, cfg.Analytics.StatsCacheTTL)
 }
 
 // provideAnalyticsHandler wires together the PostgreSQL analytics service
 // and ClickHouse client into a single handler that serves all
-// /api/analytics/* endpoints (stats, timeline, geo, devices, referrers).
-func provideAnalyticsHandler(svc *analytics.Service, ch *clickhouse.Client) *handlers.AnalyticsHandler {
-	return handlers.NewAnalyticsHandler(svc, ch)
+// /api/analytics/* endpoints (stats, timeline, geo, heatmap, devices,
+// referrers). Per-link analytics are readable by the link's owner, by
+// admins, whose role the auth middleware re-checks with the user service,
+// and by holders of its share tokens, kept in Redis by the url-service, or
+// by anyone with ANALYTICS_PUBLIC.
+func provideAnalyticsHandler(cfg *config.Config, svc *analytics.Service, ch *clickhouse.Client, rc *redislib.Client, auth *middleware.AuthMiddleware) *handlers.AnalyticsHandler {
+	return handlers.NewAnalyticsHandler(svc, ch, cfg.Analytics.HeatmapPrecision, sharelink.NewStore(rc), auth, cfg.Analytics.Public)
+}
+
+// provideStatsHandler creates the handler for GET /api/admin/stats, which
+// reports the connection pools and how far the workers are behind on the
+// click stream named by REDIS_STREAM_NAME, and GET /api/admin/pipeline,
+// which checks that backlog against the PIPELINE_ALERT_* thresholds.
+func provideStatsHandler(cfg *config.Config, db *database.DBManager, rc *redis.RedisClient, svc *analytics.Service, ch *clickhouse.Client) *handlers.StatsHandler {
+	stream := events.NewStream(rc.GetClient(), cfg.Redis.StreamName)
+	return handlers.NewStatsHandler(db, rc, stream, svc, ch, cfg.PipelineAlert)
 }
 
 // provideAuthMiddleware creates JWT-validation middleware that calls the
@@ -156,11 +200,42 @@ func provideAuthMiddleware(userClient userpb.UserServiceClient) *middleware.Auth
 	return middleware.NewAuthMiddleware(userClient)
 }
 
-// provideRateLimiter builds a Redis-backed sliding-window rate limiter.
-// Limits are configured per-IP and enforced globally across gateway
-// instances because state is stored in Redis, not in-process memory.
-func provideRateLimiter(cfg *config.Config, rc *redislib.Client) *middleware.RateLimiter {
-	return middleware.NewRateLimiter(rc, cfg.RateLimit.Requests, cfg.RateLimit.Window)
+// provideTrustedProxies parses TRUSTED_PROXIES, the load balancers whose
+// X-Forwarded-For headers the rate limiter believes when identifying the
+// client. A malformed entry fails startup. Unless TRUST_PROXY is set none
+// are returned, so forwarding headers are ignored.
+func provideTrustedProxies(cfg *config.Config) ([]netip.Prefix, error) {
+	prefixes, err := middleware.ParseTrustedProxies(cfg.Services.TrustedProxies)
+	if err != nil || !cfg.Services.TrustProxy {
+		return nil, err
+	}
+	return prefixes, nil
+}
+
+// provideRateLimiter builds a Redis-backed rate limiter using
+// RATE_LIMIT_ALGORITHM, by default the exact sliding window. Limits are
+// enforced globally across gateway instances because state is stored in
+// Redis, not in-process memory. The default per-IP limit is tightened for
+// login and registration, which are the targets of credential stuffing, for
+// the analytics endpoints, which are limited per user, and for anonymous
+// link creation, which anyone can reach.
+func provideRateLimiter(cfg *config.Config, rc *redislib.Client, trustedProxies []netip.Prefix) (*middleware.RateLimiter, error) {
+	rl, err := middleware.NewRateLimiter(rc, cfg.RateLimit.Algorithm, cfg.RateLimit.Requests, cfg.RateLimit.Window, trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	rl.Limit("/api/auth/login", cfg.RateLimit.AuthRequests, cfg.RateLimit.AuthWindow).
+		Limit("/api/auth/register", cfg.RateLimit.AuthRequests, cfg.RateLimit.AuthWindow).
+		Limit("/api/urls/anonymous", cfg.RateLimit.AnonymousRequests, cfg.RateLimit.AnonymousWindow).
+		LimitPerUser("/api/analytics/", cfg.RateLimit.AnalyticsRequests, cfg.RateLimit.AnalyticsWindow)
+	return rl, nil
+}
+
+// provideIdempotency builds the Idempotency-Key middleware for URL
+// creation. Stored responses and the per-key locks live in Redis, so a retry
+// is recognised whichever gateway instance it lands on.
+func provideIdempotency(cfg *config.Config, rc *redislib.Client) *middleware.Idempotency {
+	return middleware.NewIdempotency(rc, cfg.Idempotency.TTL)
 }
 
 // provideTracerProvider initializes OpenTelemetry distributed tracing and
@@ -197,8 +272,9 @@ func provideESClient(cfg *config.Config, log *logger.Logger) *es.Client {
 	return client
 }
 
-// provideSwaggerHandler serves the OpenAPI spec and Swagger UI, enabling
-// interactive API exploration at /swagger/*.
+// provideSwaggerHandler serves the OpenAPI spec at /openapi.yaml and the
+// Swagger UI at /docs. The path is relative to the working directory; the
+// container image ships the spec under /app/api/openapi.
 func provideSwaggerHandler() *handlers.SwaggerHandler {
 	return handlers.NewSwaggerHandler("api/openapi/api-gateway.yaml")
 }
@@ -208,12 +284,14 @@ func provideSwaggerHandler() *handlers.SwaggerHandler {
 // ---------------------------------------------------------------------------
 
 // provideMux assembles the HTTP routing table. Routes are grouped into:
-//   - /api/auth/*     -- authentication (register, login, profile)
+//   - /api/auth/*     -- authentication (register, login, profile, sessions)
 //   - /api/urls/*     -- URL CRUD (create, list, custom aliases)
 //   - /api/search     -- full-text URL search via Elasticsearch
 //   - /api/analytics/* -- click analytics (stats, timeline, geo, devices)
+//   - /api/admin/*    -- admin-only: every user's links, stats, and accounts,
+//     pool and stream-lag stats, and click pipeline health
 //   - /health         -- liveness probe that pings both Postgres and Redis
-//   - /swagger/*      -- interactive API documentation
+//   - /docs, /openapi.yaml -- Swagger UI and the OpenAPI spec it renders
 func provideMux(
 	cfg *config.Config,
 	dbManager *database.DBManager,
@@ -221,8 +299,10 @@ func provideMux(
 	httpHandler *handlers.HTTPHandler,
 	authHandler *handlers.AuthHandler,
 	analyticsHandler *handlers.AnalyticsHandler,
+	statsHandler *handlers.StatsHandler,
 	authMiddleware *middleware.AuthMiddleware,
 	rateLimiter *middleware.RateLimiter,
+	idempotency *middleware.Idempotency,
 	swaggerHandler *handlers.SwaggerHandler,
 	log *logger.Logger,
 ) *http.ServeMux {
@@ -231,41 +311,144 @@ func provideMux(
 	// Auth routes
 	mux.HandleFunc("/api/auth/register", authHandler.Register)
 	mux.HandleFunc("/api/auth/login", authHandler.Login)
-	mux.HandleFunc("/api/auth/profile", authMiddleware.RequireAuth(authHandler.GetProfile))
+	mux.HandleFunc("GET /api/auth/sessions", authMiddleware.RequireAuth(authHandler.ListSessions))
+	mux.HandleFunc("POST /api/auth/sessions/revoke-all", authMiddleware.RequireAuth(authHandler.RevokeAllSessions))
+	mux.HandleFunc("/api/auth/profile", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			authMiddleware.RequireAuth(authHandler.GetProfile)(w, r)
+		case http.MethodPut:
+			authMiddleware.RequireAuth(authHandler.UpdateProfile)(w, r)
+		default:
+			middleware.WriteError(w, r, models.ErrCodeMethodNotAllowed, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	})
 
-	// URL routes
+	// URL routes. Creating links re-checks the account, so one an admin has
+	// disabled cannot keep creating them with a token it already holds.
 	mux.HandleFunc("/api/urls", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPost:
-			authMiddleware.RequireAuth(httpHandler.CreateURL)(w, r)
+			authMiddleware.RequireFreshAuth(idempotency.Wrap(httpHandler.CreateURL))(w, r)
 		case http.MethodGet:
 			authMiddleware.RequireAuth(httpHandler.ListURLs)(w, r)
 		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			middleware.WriteError(w, r, models.ErrCodeMethodNotAllowed, http.StatusMethodNotAllowed, "Method not allowed")
 		}
 	})
 
-	mux.HandleFunc("/api/urls/custom", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodPost {
-			authMiddleware.RequireAuth(httpHandler.CreateCustomURL)(w, r)
+	// Method-scoped so they do not conflict with DELETE /api/urls/{code};
+	// other methods get 405 from the mux.
+	mux.HandleFunc("POST /api/urls/custom", authMiddleware.RequireFreshAuth(idempotency.Wrap(httpHandler.CreateCustomURL)))
+	mux.HandleFunc("GET /api/urls/export", authMiddleware.RequireAuth(httpHandler.ExportURLs))
+	mux.HandleFunc("POST /api/urls/import", authMiddleware.RequireFreshAuth(httpHandler.ImportURLs))
+	mux.HandleFunc("POST /api/urls/bulk-delete", authMiddleware.RequireAuth(httpHandler.BulkDeleteURLs))
+
+	// A public shortener also lets anyone create links, without an owner;
+	// the rate limiter holds this route to its own, stricter per-IP limit.
+	if cfg.Anonymous.Enabled {
+		mux.HandleFunc("POST /api/urls/anonymous", httpHandler.CreateAnonymousURL)
+	}
+
+	// Method- and wildcard-scoped so it leaves the rest of /api/urls/{code}
+	// free for other routes; other methods get 405 from the mux.
+	mux.HandleFunc("PUT /api/urls/{code}/tags", authMiddleware.RequireAuth(httpHandler.UpdateURLTags))
+	mux.HandleFunc("GET /api/urls/{code}", authMiddleware.RequireAuth(httpHandler.GetURL))
+	mux.HandleFunc("DELETE /api/urls/{code}", authMiddleware.RequireAuth(httpHandler.DeleteURL))
+	mux.HandleFunc("GET /api/urls/{code}/history", authMiddleware.RequireAuth(httpHandler.GetURLHistory))
+	mux.HandleFunc("PUT /api/urls/{code}/active", authMiddleware.RequireAuth(httpHandler.SetURLActive))
+	mux.HandleFunc("POST /api/urls/{code}/reactivate", authMiddleware.RequireFreshAuth(httpHandler.ReactivateURL))
+	mux.HandleFunc("POST /api/urls/{code}/qr/regenerate", authMiddleware.RequireAuth(httpHandler.RegenerateQRCode))
+	mux.HandleFunc("POST /api/urls/{code}/analytics/shares", authMiddleware.RequireAuth(httpHandler.CreateAnalyticsShare))
+	mux.HandleFunc("GET /api/urls/{code}/analytics/shares", authMiddleware.RequireAuth(httpHandler.ListAnalyticsShares))
+	mux.HandleFunc("DELETE /api/urls/{code}/analytics/shares", authMiddleware.RequireAuth(httpHandler.RevokeAnalyticsShare))
+	mux.HandleFunc("DELETE /api/urls/{code}/analytics/shares/{token}", authMiddleware.RequireAuth(httpHandler.RevokeAnalyticsShare))
+	// Public, like the redirect the QR code points at. /qr is the original
+	// path, kept for links handed out before the .png one.
+	mux.HandleFunc("GET /api/urls/{code}/qr.png", httpHandler.GetQRCode)
+	mux.HandleFunc("GET /api/urls/{code}/qr", httpHandler.GetQRCode)
+
+	mux.HandleFunc("/api/tags", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			authMiddleware.RequireAuth(httpHandler.GetTags)(w, r)
 		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			middleware.WriteError(w, r, models.ErrCodeMethodNotAllowed, http.StatusMethodNotAllowed, "Method not allowed")
 		}
 	})
 
+	// Custom domain routes
+	mux.HandleFunc("/api/domains", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			authMiddleware.RequireAuth(httpHandler.RegisterDomain)(w, r)
+		case http.MethodGet:
+			authMiddleware.RequireAuth(httpHandler.ListDomains)(w, r)
+		default:
+			middleware.WriteError(w, r, models.ErrCodeMethodNotAllowed, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	})
+
+	mux.HandleFunc("POST /api/domains/{domain}/verify", authMiddleware.RequireAuth(httpHandler.VerifyDomain))
+
+	// Wildcard alias routes
+	mux.HandleFunc("/api/wildcards", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			authMiddleware.RequireAuth(httpHandler.CreateWildcardRule)(w, r)
+		case http.MethodGet:
+			authMiddleware.RequireAuth(httpHandler.ListWildcardRules)(w, r)
+		default:
+			middleware.WriteError(w, r, models.ErrCodeMethodNotAllowed, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	})
+
+	mux.HandleFunc("DELETE /api/wildcards/{prefix}", authMiddleware.RequireAuth(httpHandler.DeleteWildcardRule))
+
+	// Webhook routes
+	mux.HandleFunc("/api/webhooks", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			authMiddleware.RequireAuth(httpHandler.CreateWebhook)(w, r)
+		case http.MethodGet:
+			authMiddleware.RequireAuth(httpHandler.ListWebhooks)(w, r)
+		default:
+			middleware.WriteError(w, r, models.ErrCodeMethodNotAllowed, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	})
+
+	mux.HandleFunc("/api/webhooks/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			authMiddleware.RequireAuth(httpHandler.DeleteWebhook)(w, r)
+		} else {
+			middleware.WriteError(w, r, models.ErrCodeMethodNotAllowed, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	})
+
+	// Admin routes. RequireRole re-checks the admin role against the
+	// database on every request, so a demotion takes effect at once.
+	admin := authMiddleware.RequireRole("admin")
+	mux.HandleFunc("GET /api/admin/urls", admin(httpHandler.AdminListURLs))
+	mux.HandleFunc("DELETE /api/admin/urls/{code}", admin(httpHandler.AdminDeleteURL))
+	mux.HandleFunc("GET /api/admin/urls/{code}/history", admin(httpHandler.AdminGetURLHistory))
+	mux.HandleFunc("GET /api/admin/users/{id}/stats", admin(httpHandler.AdminUserStats))
+	mux.HandleFunc("POST /api/admin/users/{id}/disable", admin(authHandler.DisableUser))
+	mux.HandleFunc("POST /api/admin/users/{id}/enable", admin(authHandler.EnableUser))
+	mux.HandleFunc("GET /api/admin/stats", admin(statsHandler.GetStats))
+	mux.HandleFunc("GET /api/admin/pipeline", admin(statsHandler.GetPipeline))
+
 	// Health check — pings both DB and Redis
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
 		if err := dbManager.Primary().Ping(ctx); err != nil {
 			log.Error("health: DB ping failed: %v", err)
-			http.Error(w, fmt.Sprintf("DB unavailable: %v", err), http.StatusServiceUnavailable)
+			middleware.WriteError(w, r, models.ErrCodeUnavailable, http.StatusServiceUnavailable, "database unavailable")
 			return
 		}
 
 		if err := redisClient.GetClient().Ping(ctx).Err(); err != nil {
 			log.Error("health: Redis ping failed: %v", err)
-			http.Error(w, fmt.Sprintf("Redis unavailable: %v", err), http.StatusServiceUnavailable)
+			middleware.WriteError(w, r, models.ErrCodeUnavailable, http.StatusServiceUnavailable, "redis unavailable")
 			return
 		}
 
@@ -279,26 +462,38 @@ func provideMux(
 	// Swagger
 	swaggerHandler.RegisterRoutes(mux)
 
-	// Analytics routes
-	mux.HandleFunc("/api/analytics/clicks", authMiddleware.RequireAuth(analyticsHandler.GetClickEvents))
+	// Analytics routes. They are rate limited per user, so the limiter sits
+	// behind the auth middleware; the per-link ones resolve a token if
+	// given, and RequireLinkAccess admits the link's owner or a holder of
+	// one of its share tokens.
+	mux.HandleFunc("/api/analytics/clicks", authMiddleware.RequireAuth(rateLimiter.PerUser(analyticsHandler.GetClickEvents)))
 
-	mux.HandleFunc("/api/analytics/", func(w http.ResponseWriter, r *http.Request) {
+	perLink := analyticsHandler.RequireLinkAccess
+	mux.HandleFunc("/api/analytics/", authMiddleware.OptionalAuth(rateLimiter.PerUser(func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
 		switch {
 		case strings.HasSuffix(path, "/stats"):
-			analyticsHandler.GetStats(w, r)
+			// A cache-bypassing refresh is only for the link's owner, not
+			// for share tokens, so it needs an authenticated user.
+			if r.URL.Query().Has("force_refresh") {
+				authMiddleware.RequireAuth(analyticsHandler.GetStats)(w, r)
+				return
+			}
+			perLink(analyticsHandler.GetStats)(w, r)
 		case strings.HasSuffix(path, "/timeline"):
-			analyticsHandler.GetTimeline(w, r)
+			perLink(analyticsHandler.GetTimeline)(w, r)
 		case strings.HasSuffix(path, "/geo"):
-			analyticsHandler.GetGeoStats(w, r)
+			perLink(analyticsHandler.GetGeoStats)(w, r)
+		case strings.HasSuffix(path, "/heatmap"):
+			perLink(analyticsHandler.GetHeatmap)(w, r)
 		case strings.HasSuffix(path, "/devices"):
-			analyticsHandler.GetDeviceStats(w, r)
+			perLink(analyticsHandler.GetDeviceStats)(w, r)
 		case strings.HasSuffix(path, "/referrers"):
-			analyticsHandler.GetReferrers(w, r)
+			perLink(analyticsHandler.GetReferrers)(w, r)
 		default:
 			http.NotFound(w, r)
 		}
-	})
+	})))
 
 	return mux
 }
@@ -307,7 +502,7 @@ func provideMux(
 // server timeouts. Middleware is applied in reverse order (outermost runs
 // first):
 //   - Rate limiter -- rejects excess requests before any work is done
-//   - Recovery -- catches panics and returns 500 instead of crashing
+//   - Recovery -- catches panics and returns a JSON 500 instead of crashing
 //   - Request ID -- attaches a unique ID for correlation in logs/traces
 //   - Tracing -- creates an OpenTelemetry span for each HTTP request
 //   - CORS -- adds cross-origin headers for browser clients
@@ -322,7 +517,7 @@ func provideHTTPServer(
 	handler := middleware.CORS(cfg.CORS.AllowedOrigins)(mux)
 	handler = middleware.Tracing("api-gateway")(handler)
 	handler = middleware.RequestID(handler)
-	handler = middleware.Recovery(log)(handler)
+	handler = middleware.Recovery(log, cfg.Services.Debug)(handler)
 	handler = rateLimiter.Middleware(handler)
 
 	return &http.Server{
@@ -420,6 +615,7 @@ func main() {
 			provideDBManager,
 			provideClickHouseClient,
 			provideESClient,
+			provideQRStore,
 			provideUserGRPCConn,
 			provideRawRedisClient,
 		),
@@ -435,7 +631,10 @@ func main() {
 			provideAuthHandler,
 			provideAnalyticsService,
 			provideAnalyticsHandler,
+			provideStatsHandler,
 			provideAuthMiddleware,
+			provideIdempotency,
+			provideTrustedProxies,
 			provideRateLimiter,
 			provideSwaggerHandler,
 		),
@@ -4,26 +4,28 @@
 // URLs from PostgreSQL. URLs can have an optional TTL set at creation time;
 // once expired, they should no longer resolve and their storage can be
 // reclaimed. The worker runs a single cleanup pass immediately on startup,
-// then repeats every 24 hours.
+// then repeats every CLEANUP_INTERVAL (24 hours by default).
 //
-// A multi-tier cache (in-process LRU + Redis) is injected so that future
-// enhancements can invalidate cached entries for deleted URLs. Currently
-// the cache is available but cache eviction on cleanup is not yet
-// implemented -- stale entries will expire naturally via their TTL.
+// A multi-tier cache (in-process LRU + Redis) is injected so the cached
+// redirect entries of deleted URLs are evicted along with their rows,
+// instead of resolving until their Redis TTL lapses.
 //
 // Dependency injection is managed by Uber FX.
 package main
 
 import (
 	"context"
+	"fmt"
+	"os"
 	"sync"
-	"time"
 
 	"github.com/Varun5711/shorternit/internal/cache"
+	"github.com/Varun5711/shorternit/internal/cleanup"
 	"github.com/Varun5711/shorternit/internal/config"
 	"github.com/Varun5711/shorternit/internal/database"
 	"github.com/Varun5711/shorternit/internal/logger"
 	"github.com/Varun5711/shorternit/internal/redis"
+	"github.com/Varun5711/shorternit/internal/retry"
 	"github.com/Varun5711/shorternit/internal/storage"
 	"github.com/Varun5711/shorternit/internal/tracing"
 	redislib "github.com/redis/go-redis/v9"
@@ -44,14 +46,19 @@ func provideLogger() *logger.Logger {
 }
 
 // provideRedisClient connects to the shared Redis instance. Redis is needed
-// here to back the L2 cache tier; the cleanup worker may evict cached
-// entries for URLs it deletes.
+// here to back the L2 cache tier; the cleanup worker evicts cached entries
+// for URLs it deletes.
 func provideRedisClient(cfg *config.Config) (*redis.RedisClient, error) {
-	return redis.NewRedisClient(context.Background(), redis.Config{
-		Addr:     cfg.Redis.Addr,
-		Password: cfg.Redis.Password,
-		DB:       cfg.Redis.DB,
+	var client *redis.RedisClient
+	err := retry.Dial(context.Background(), "Redis", cfg.Startup.ConnectAttempts, cfg.Startup.ConnectBackoff, func() (err error) {
+		client, err = redis.NewRedisClient(context.Background(), redis.Config{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		return err
 	})
+	return client, err
 }
 
 // provideRawRedisClient unwraps the internal RedisClient to expose the
@@ -62,20 +69,30 @@ func provideRawRedisClient(rc *redis.RedisClient) *redislib.Client {
 
 // provideDBManager sets up a PostgreSQL connection pool. The cleanup worker
 // writes to the primary: it runs DELETE statements against expired rows in
-// the urls table.
+// the urls table. With STORAGE_BACKEND=memory it returns nil and no
+// connection is made.
 func provideDBManager(cfg *config.Config) (*database.DBManager, error) {
-	return database.NewDBManager(context.Background(), database.Config{
-		PrimaryDSN:      cfg.Database.PrimaryDSN,
-		ReplicaDSNs:     cfg.Database.ReplicaDSNs,
-		MaxConns:        cfg.Database.MaxConns,
-		MinConns:        cfg.Database.MinConns,
-		MaxConnLifetime: cfg.Database.MaxConnLifetime,
-		MaxConnIdleTime: cfg.Database.MaxConnIdleTime,
+	if cfg.Database.Backend == "memory" {
+		return nil, nil
+	}
+	var db *database.DBManager
+	err := retry.Dial(context.Background(), "PostgreSQL", cfg.Startup.ConnectAttempts, cfg.Startup.ConnectBackoff, func() (err error) {
+		db, err = database.NewDBManager(context.Background(), database.Config{
+			PrimaryDSN:      cfg.Database.PrimaryDSN,
+			ReplicaDSNs:     cfg.Database.ReplicaDSNs,
+			MaxConns:        cfg.Database.MaxConns,
+			MinConns:        cfg.Database.MinConns,
+			MaxConnLifetime: cfg.Database.MaxConnLifetime,
+			MaxConnIdleTime: cfg.Database.MaxConnIdleTime,
+			QueryTimeout:    cfg.Database.QueryTimeout,
+		})
+		return err
 	})
+	return db, err
 }
 
 // provideCache builds a two-tier cache (in-process LRU + Redis) so deleted
-// URLs can be evicted from the cache in future enhancements.
+// URLs can be evicted from the cache.
 func provideCache(cfg *config.Config, rc *redislib.Client) *cache.Cache {
 	return cache.NewMultiTierCache(cfg.Cache.L1Capacity, rc, cfg.Cache.L2TTL)
 }
@@ -93,20 +110,43 @@ func provideTracerProvider(cfg *config.Config) (*sdktrace.TracerProvider, error)
 	})
 }
 
-// provideStorage creates the PostgreSQL-backed URL storage layer, which
-// exposes the DeleteExpiredURLs method used by the cleanup loop.
-func provideStorage(db *database.DBManager) *storage.PostgresStorage {
-	return storage.NewPostgresStorage(db)
+// provideStorage creates the URL storage layer selected by STORAGE_BACKEND,
+// which exposes the DeleteExpiredURLs method used by the cleanup loop. A
+// MemoryStorage belongs to this process alone, so with "memory" the worker
+// runs its passes against a store nothing else writes to; the url-service
+// hides expired links from reads on its own.
+func provideStorage(cfg *config.Config, db *database.DBManager) (cleanup.ExpiredURLDeleter, error) {
+	switch cfg.Database.Backend {
+	case "", "postgres":
+		return storage.NewPostgresStorage(db), nil
+	case "memory":
+		return storage.NewMemoryStorage(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (want postgres or memory)", cfg.Database.Backend)
+	}
+}
+
+// provideCleaner creates the cleaner that deletes expired URLs from storage
+// and evicts them from the cache every CLEANUP_INTERVAL. Replicas share the
+// work through a Redis leader lease and identify themselves by hostname (the
+// pod name in Kubernetes).
+func provideCleaner(cfg *config.Config, store cleanup.ExpiredURLDeleter, urlCache *cache.Cache, rc *redislib.Client, log *logger.Logger) *cleanup.Cleaner {
+	instance, err := os.Hostname()
+	if err != nil {
+		instance = "unknown"
+	}
+	return cleanup.NewCleaner(store, urlCache, rc, cfg.Cleanup.Interval, instance, log)
 }
 
 // registerLifecycle wires the cleanup loop into the FX lifecycle. On start,
 // it launches a goroutine that runs an immediate cleanup pass followed by
-// a 24-hour ticker loop. On stop, it cancels the context and waits for the
-// goroutine to finish, then closes tracing, Redis, and database connections.
+// one every CLEANUP_INTERVAL. On stop, it cancels the context and waits for
+// the goroutine to finish, then closes tracing, Redis, and database
+// connections.
 func registerLifecycle(
 	lc fx.Lifecycle,
-	store *storage.PostgresStorage,
-	urlCache *cache.Cache,
+	cfg *config.Config,
+	cleaner *cleanup.Cleaner,
 	tp *sdktrace.TracerProvider,
 	redisClient *redis.RedisClient,
 	dbManager *database.DBManager,
@@ -120,10 +160,10 @@ func registerLifecycle(
 
 			go func() {
 				defer wg.Done()
-				runCleanupLoop(workerCtx, store, urlCache, log)
+				cleaner.Run(workerCtx)
 			}()
 
-			log.Info("Cleanup worker started, running every 24 hours")
+			log.Info("Cleanup worker started, running every %s", cfg.Cleanup.Interval)
 
 			lc.Append(fx.Hook{
 				OnStop: func(ctx context.Context) error {
@@ -132,7 +172,9 @@ func registerLifecycle(
 					wg.Wait()
 					_ = tracing.ShutdownTracer(ctx, tp)
 					_ = redisClient.Close()
-					dbManager.Close()
+					if dbManager != nil {
+						dbManager.Close()
+					}
 					return nil
 				},
 			})
@@ -141,50 +183,11 @@ func registerLifecycle(
 	})
 }
 
-// runCleanupLoop runs an immediate cleanup pass on startup, then repeats
-// every 24 hours. The immediate pass ensures newly deployed instances
-// catch up on any backlog of expired URLs without waiting a full day.
-func runCleanupLoop(ctx context.Context, store *storage.PostgresStorage, urlCache *cache.Cache, log *logger.Logger) {
-	runCleanup(ctx, store, urlCache, log)
-
-	ticker := time.NewTicker(24 * time.Hour)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			runCleanup(ctx, store, urlCache, log)
-		}
-	}
-}
-
-// runCleanup performs a single cleanup pass: it deletes all URLs whose
-// expires_at timestamp is in the past and logs how many were removed.
-// Errors are logged but do not crash the worker -- the next tick will
-// retry automatically.
-func runCleanup(ctx context.Context, store *storage.PostgresStorage, urlCache *cache.Cache, log *logger.Logger) {
-	log.Info("Starting cleanup of expired URLs...")
-
-	deletedCount, err := store.DeleteExpiredURLs(ctx)
-	if err != nil {
-		log.Error("Failed to delete expired URLs: %v", err)
-		return
-	}
-
-	if deletedCount > 0 {
-		log.Info("Deleted %d expired URLs from database", deletedCount)
-	} else {
-		log.Info("No expired URLs found")
-	}
-}
-
 // main assembles the complete FX dependency graph for the cleanup worker.
 //
 // The graph connects config, logging, tracing, Redis (for the cache tier),
 // Postgres (for URL deletion), and the storage layer. There is no HTTP or
-// gRPC server -- the worker is a pure background job on a 24-hour timer.
+// gRPC server -- the worker is a pure background job on a timer.
 // fx.Invoke(registerLifecycle) triggers graph construction and starts the
 // cleanup loop. Run() blocks until a termination signal is received.
 func main() {
@@ -198,6 +201,7 @@ func main() {
 			provideDBManager,
 			provideCache,
 			provideStorage,
+			provideCleaner,
 		),
 		fx.Invoke(registerLifecycle),
 	).Run()
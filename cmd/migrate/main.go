@@ -0,0 +1,96 @@
+// Package main implements the schema migration tool for the Tiny URL
+// shortener.
+//
+// It applies the PostgreSQL migrations embedded from migrations/postgres to
+// the primary named by DB_PRIMARY_DSN, for deployments that migrate as a
+// separate step (a Kubernetes Job or a pre-deploy hook) rather than with
+// DB_AUTO_MIGRATE on the url-service. Versions are tracked in the same
+// schema_migrations table the golang-migrate CLI uses.
+//
+// Usage:
+//
+//	migrate [up]      apply pending migrations
+//	migrate version   print the current version
+//	migrate force N   record version N as applied without running anything
+//
+// "force" baselines a database whose schema was created outside the
+// migrations, or clears a dirty flag after a manual repair.
+//
+// ClickHouse migrations are applied by "pipeline-worker migrate".
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/Varun5711/shorternit/internal/config"
+	"github.com/Varun5711/shorternit/internal/database/migrate"
+	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/Varun5711/shorternit/internal/retry"
+	"github.com/Varun5711/shorternit/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func main() {
+	log := logger.New("migrate")
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load config: %v", err)
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, cfg.Database.PrimaryDSN)
+	if err != nil {
+		log.Fatal("Failed to connect to PostgreSQL: %v", err)
+	}
+	defer pool.Close()
+	// pgxpool connects lazily; wait here for a database still starting up.
+	err = retry.Dial(ctx, "PostgreSQL", cfg.Startup.ConnectAttempts, cfg.Startup.ConnectBackoff, func() error {
+		return pool.Ping(ctx)
+	})
+	if err != nil {
+		log.Fatal("Failed to connect to PostgreSQL: %v", err)
+	}
+
+	runner := migrate.NewRunner(pool, log)
+
+	cmd := "up"
+	if len(os.Args) > 1 {
+		cmd = os.Args[1]
+	}
+	switch cmd {
+	case "up":
+		all, err := migrations.Load(migrations.Postgres, "postgres")
+		if err != nil {
+			log.Fatal("Failed to load migrations: %v", err)
+		}
+		applied, err := runner.Up(ctx, all)
+		if err != nil {
+			log.Fatal("Migration failed: %v", err)
+		}
+		log.Info("PostgreSQL schema up to date (%d migrations applied)", applied)
+	case "version":
+		version, err := runner.Version(ctx)
+		if err != nil {
+			log.Fatal("Failed to read version: %v", err)
+		}
+		fmt.Println(version)
+	case "force":
+		if len(os.Args) != 3 {
+			log.Fatal("usage: migrate force VERSION")
+		}
+		version, err := strconv.ParseUint(os.Args[2], 10, 64)
+		if err != nil {
+			log.Fatal("Invalid version %q: %v", os.Args[2], err)
+		}
+		if err := runner.Force(ctx, version); err != nil {
+			log.Fatal("Force failed: %v", err)
+		}
+		log.Info("Schema version set to %d", version)
+	default:
+		log.Fatal("Unknown command %q (want up, version or force)", cmd)
+	}
+}
@@ -4,19 +4,33 @@
 // from the same Redis Stream as the analytics-worker but performs a richer
 // enrichment pipeline before storing the results:
 //
+//  0. Signature check (optional) -- with CLICK_SIGNING_ENABLED, events
+//     whose HMAC does not match are moved to CLICK_DEAD_LETTER_STREAM.
 //  1. GeoIP lookup -- resolves the client IP address to country, region,
 //     city, and coordinates using a local MaxMind database.
 //  2. User-agent parsing -- extracts browser, OS, and device type from
 //     the raw UA string.
-//  3. ClickHouse insertion -- writes the fully enriched event into the
+//  3. Repeat-click dedup (optional) -- collapses, or flags in raw mode,
+//     repeat clicks from the same visitor within CLICK_DEDUP_WINDOW.
+//  4. Sampling (optional) -- stores only one in CLICK_SAMPLE_RATE events
+//     of a link, each recording the rate so analytics scale it back up.
+//  5. ClickHouse insertion -- writes the fully enriched event into the
 //     OLAP store for fast analytical queries (timeline, geo heatmaps,
 //     device breakdowns).
-//  4. Elasticsearch indexing (optional) -- indexes click events for
+//  6. Elasticsearch indexing (optional) -- indexes click events for
 //     full-text search and ad-hoc exploration.
+//  7. Webhook delivery (optional) -- POSTs signed, enriched click payloads
+//     to user-registered webhooks for the clicked links, sampled or not.
 //
-// This worker operates as a Redis consumer group member, so it can scale
-// horizontally and will automatically re-process pending events after a
-// crash.
+// On startup the worker applies pending ClickHouse schema migrations unless
+// CLICKHOUSE_AUTO_MIGRATE=false; "pipeline-worker migrate" applies them and
+// exits, for deployments that migrate as a separate step.
+//
+// This worker operates as a member of its own Redis consumer group
+// (PIPELINE_CONSUMER_GROUP), so it can scale horizontally and will
+// automatically re-process pending events after a crash. The group is not
+// shared with the analytics-worker: each group receives every event, so
+// both workers see every click.
 //
 // Dependency injection is managed by Uber FX.
 package main
@@ -24,17 +38,28 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/Varun5711/shorternit/internal/clickdedup"
 	"github.com/Varun5711/shorternit/internal/clickhouse"
+	"github.com/Varun5711/shorternit/internal/clickhouse/migrate"
+	"github.com/Varun5711/shorternit/internal/clicksample"
 	"github.com/Varun5711/shorternit/internal/config"
+	"github.com/Varun5711/shorternit/internal/database"
 	es "github.com/Varun5711/shorternit/internal/elasticsearch"
 	"github.com/Varun5711/shorternit/internal/enrichment"
+	"github.com/Varun5711/shorternit/internal/events"
 	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/Varun5711/shorternit/internal/retry"
+	"github.com/Varun5711/shorternit/internal/storage"
 	"github.com/Varun5711/shorternit/internal/tracing"
-	"github.com/google/uuid"
+	"github.com/Varun5711/shorternit/internal/webhook"
+	"github.com/Varun5711/shorternit/migrations"
 	"github.com/redis/go-redis/v9"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/fx"
@@ -62,17 +87,66 @@ func provideRedisClient(cfg *config.Config) (*redis.Client, error) {
 		Password: cfg.Redis.Password,
 		DB:       cfg.Redis.DB,
 	})
-	if err := client.Ping(context.Background()).Err(); err != nil {
-		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	err := retry.Dial(context.Background(), "Redis", cfg.Startup.ConnectAttempts, cfg.Startup.ConnectBackoff, func() error {
+		if err := client.Ping(context.Background()).Err(); err != nil {
+			return fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		_ = client.Close()
+		return nil, err
 	}
 	return client, nil
 }
 
 // provideClickHouseClient connects to ClickHouse, the columnar OLAP store
 // where enriched click events are inserted in batches. ClickHouse powers
-// the analytics dashboards served by the API gateway.
-func provideClickHouseClient(cfg *config.Config) (*clickhouse.Client, error) {
-	return clickhouse.NewClient(cfg.ClickHouse)
+// the analytics dashboards served by the API gateway. With
+// CLICKHOUSE_AUTO_MIGRATE it first brings the schema up to date.
+func provideClickHouseClient(cfg *config.Config, log *logger.Logger) (*clickhouse.Client, error) {
+	if cfg.ClickHouse.AutoMigrate {
+		if err := runMigrations(context.Background(), cfg, log); err != nil {
+			return nil, err
+		}
+	}
+	return dialClickHouse(cfg, cfg.ClickHouse)
+}
+
+// dialClickHouse connects to the ClickHouse server of chCfg, retrying as
+// the STARTUP_CONNECT_* settings allow.
+func dialClickHouse(cfg *config.Config, chCfg config.ClickHouseConfig) (*clickhouse.Client, error) {
+	var client *clickhouse.Client
+	err := retry.Dial(context.Background(), "ClickHouse", cfg.Startup.ConnectAttempts, cfg.Startup.ConnectBackoff, func() (err error) {
+		client, err = clickhouse.NewClient(chCfg)
+		return err
+	})
+	return client, err
+}
+
+// runMigrations applies the ClickHouse migrations embedded in the binary.
+// It connects to the server's default database, since the analytics
+// database may not exist yet.
+func runMigrations(ctx context.Context, cfg *config.Config, log *logger.Logger) error {
+	all, err := migrations.Load(migrations.ClickHouse, "clickhouse")
+	if err != nil {
+		return err
+	}
+
+	chCfg := cfg.ClickHouse
+	chCfg.Database = "default"
+	client, err := dialClickHouse(cfg, chCfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	applied, err := migrate.NewRunner(client.Conn(), cfg.ClickHouse.Database, log).Up(ctx, all)
+	if err != nil {
+		return err
+	}
+	log.Info("ClickHouse schema up to date (%d migrations applied)", applied)
+	return nil
 }
 
 // provideTracerProvider initializes OpenTelemetry distributed tracing and
@@ -108,56 +182,171 @@ func provideESClient(cfg *config.Config, log *logger.Logger) *es.Client {
 	return client
 }
 
+// provideDBManager sets up a PostgreSQL connection pool. The pipeline worker
+// only needs it to look up active webhooks per click batch and to record
+// delivery outcomes, so the pool is kept small.
+func provideDBManager(cfg *config.Config) (*database.DBManager, error) {
+	var db *database.DBManager
+	err := retry.Dial(context.Background(), "PostgreSQL", cfg.Startup.ConnectAttempts, cfg.Startup.ConnectBackoff, func() (err error) {
+		db, err = database.NewDBManager(context.Background(), database.Config{
+			PrimaryDSN:      cfg.Database.PrimaryDSN,
+			ReplicaDSNs:     cfg.Database.ReplicaDSNs,
+			MaxConns:        cfg.Database.MaxConns,
+			MinConns:        cfg.Database.MinConns,
+			MaxConnLifetime: cfg.Database.MaxConnLifetime,
+			MaxConnIdleTime: cfg.Database.MaxConnIdleTime,
+			QueryTimeout:    cfg.Database.QueryTimeout,
+		})
+		return err
+	})
+	return db, err
+}
+
+// provideWebhookStorage creates the PostgreSQL-backed webhook storage used
+// to find which webhooks a click batch should trigger.
+func provideWebhookStorage(db *database.DBManager) *storage.WebhookStorage {
+	return storage.NewWebhookStorage(db)
+}
+
+// provideWebhookDispatcher builds the bounded delivery pool for click
+// webhooks. Returns nil when WEBHOOKS_ENABLED=false, which turns webhook
+// delivery off without affecting ingestion.
+func provideWebhookDispatcher(cfg *config.Config, store *storage.WebhookStorage, redisClient *redis.Client, log *logger.Logger) *webhook.Dispatcher {
+	if !cfg.Webhooks.Enabled {
+		return nil
+	}
+	return webhook.NewDispatcher(store, redisClient, webhook.Config{
+		Workers:        cfg.Webhooks.Workers,
+		QueueSize:      cfg.Webhooks.QueueSize,
+		MaxRetries:     cfg.Webhooks.MaxRetries,
+		InitialBackoff: cfg.Webhooks.InitialBackoff,
+		Timeout:        cfg.Webhooks.Timeout,
+		MaxFailures:    cfg.Webhooks.MaxFailures,
+	}, log)
+}
+
 // provideGeoEnricher creates a GeoIP enricher backed by a local MaxMind
 // database. IP-to-location resolution happens entirely in-process, avoiding
-// external API calls and keeping enrichment fast.
-func provideGeoEnricher() *enrichment.GeoIPEnricher {
-	return enrichment.NewGeoIPEnricher()
+// external API calls and keeping enrichment fast. An LRU cache of
+// GEOIP_CACHE_SIZE entries spares repeated IPs the database read.
+func provideGeoEnricher(cfg *config.Config) *enrichment.GeoIPEnricher {
+	return enrichment.NewGeoIPEnricher(cfg.GeoIP.CacheSize)
 }
 
 // providePipelineWorker assembles the worker with all its dependencies:
 // Redis for event consumption, ClickHouse and Elasticsearch for storage,
-// and the GeoIP enricher for IP resolution. Configuration values control
-// batch size, poll interval, and consumer group identity.
+// the GeoIP enricher for IP resolution, and the webhook storage and
+// dispatcher for click notifications.
+//
+// Configuration values control batch size, poll interval, consumer group
+// identity, enrichment concurrency, repeat-click dedup, sampling, signature
+// checks, how visitors' IPs are stored, and how long stored events are
+// remembered so redeliveries are skipped (PIPELINE_PROCESSED_TTL).
+//
+// Startup fails on an unknown CLICK_DEDUP_MODE or ANALYTICS_IP_MODE, a
+// malformed CLICK_SAMPLE_LINK_RATES entry, hashing IPs without a salt, or
+// signing without a secret. It also fails when the consumer group is the
+// analytics-worker's, which would split the events between the two workers.
 func providePipelineWorker(
 	redisClient *redis.Client,
 	chClient *clickhouse.Client,
 	esClient *es.Client,
 	geoEnricher *enrichment.GeoIPEnricher,
+	webhookStore *storage.WebhookStorage,
+	dispatcher *webhook.Dispatcher,
 	cfg *config.Config,
-) *PipelineWorker {
+) (*PipelineWorker, error) {
+	if err := clickdedup.CheckMode(cfg.ClickDedup.Mode); err != nil {
+		return nil, err
+	}
+	linkRates, err := clicksample.ParseLinkRates(cfg.ClickSample.LinkRates)
+	if err != nil {
+		return nil, err
+	}
+	ipMasker, err := enrichment.NewIPMasker(cfg.Analytics.IPMode, cfg.Analytics.IPHashSalt)
+	if err != nil {
+		return nil, err
+	}
+	analyticsFields, err := enrichment.ParseFields(cfg.Analytics.Fields)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Analytics.PipelineConsumerGroup == cfg.Analytics.ConsumerGroup {
+		return nil, fmt.Errorf("PIPELINE_CONSUMER_GROUP must differ from ANALYTICS_CONSUMER_GROUP (both %q)", cfg.Analytics.ConsumerGroup)
+	}
+	var signer *events.Signer
+	if cfg.ClickSigning.Enabled {
+		if signer, err = events.NewSigner(cfg.ClickSigning.Secret); err != nil {
+			return nil, err
+		}
+	}
+	var processed *events.ProcessedSet
+	if cfg.Analytics.ProcessedTTL > 0 {
+		processed = events.NewProcessedSet(redisClient, cfg.Redis.StreamName, cfg.Analytics.PipelineConsumerGroup, cfg.Analytics.ProcessedTTL)
+	}
 	return &PipelineWorker{
 		redisClient:   redisClient,
 		chClient:      chClient,
 		esClient:      esClient,
 		geoEnricher:   geoEnricher,
+		webhookStore:  webhookStore,
+		dispatcher:    dispatcher,
 		streamName:    cfg.Redis.StreamName,
-		consumerGroup: cfg.Analytics.ConsumerGroup,
+		consumerGroup: cfg.Analytics.PipelineConsumerGroup,
 		consumerName:  cfg.Analytics.ConsumerName,
 		batchSize:     cfg.Analytics.BatchSize,
 		pollInterval:  cfg.Analytics.PollInterval,
 		blockTime:     cfg.Analytics.BlockTime,
+		enrichWorkers: cfg.Analytics.EnrichWorkers,
+		dedupWindow:   cfg.ClickDedup.Window,
+		dedupMode:     cfg.ClickDedup.Mode,
+		sampler:       clicksample.NewSampler(cfg.ClickSample.Rate, linkRates),
+		signer:        signer,
+		deadLetters:   cfg.ClickSigning.DeadLetterStream,
+		ipMasker:      ipMasker,
+		fields:        analyticsFields,
+		processed:     processed,
+	}, nil
+}
+
+// provideRetention creates the job deleting click events older than
+// ANALYTICS_RETENTION_DAYS, or returns nil when they are kept forever.
+func provideRetention(cfg *config.Config, chClient *clickhouse.Client, redisClient *redis.Client, log *logger.Logger) *clickhouse.Retention {
+	if cfg.Analytics.RetentionDays <= 0 {
+		return nil
 	}
+	return clickhouse.NewRetention(chClient, cfg.Analytics.RetentionDays, redisClient, log)
 }
 
+// webhookDrainTimeout bounds how long shutdown waits for queued webhook
+// deliveries, leaving the rest of the FX stop budget for closing connections.
+const webhookDrainTimeout = 5 * time.Second
+
 // registerLifecycle wires the pipeline worker into the FX lifecycle. On
 // start, it ensures the Redis consumer group exists, then launches the
-// worker loop in a background goroutine. On stop, it cancels the context,
-// waits for the goroutine to finish its current batch, then closes
-// tracing, GeoIP database, ClickHouse, and Redis connections in order.
+// webhook dispatcher, the worker loop and, unless click events are kept
+// forever, the daily retention job in background goroutines. On stop,
+// it cancels the worker's context, waits for the goroutine to finish its
+// current batch, and only then stops the dispatcher, giving queued webhook
+// deliveries up to webhookDrainTimeout to go out. It then closes tracing,
+// logs the GeoIP cache hit rate, and closes the GeoIP database, ClickHouse,
+// PostgreSQL, and Redis connections in order.
 func registerLifecycle(
 	lc fx.Lifecycle,
 	worker *PipelineWorker,
+	dispatcher *webhook.Dispatcher,
+	retention *clickhouse.Retention,
 	tp *sdktrace.TracerProvider,
 	redisClient *redis.Client,
 	chClient *clickhouse.Client,
+	dbManager *database.DBManager,
 	geoEnricher *enrichment.GeoIPEnricher,
 	cfg *config.Config,
 	log *logger.Logger,
 ) {
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			err := redisClient.XGroupCreateMkStream(ctx, cfg.Redis.StreamName, cfg.Analytics.ConsumerGroup, "0").Err()
+			err := redisClient.XGroupCreateMkStream(ctx, cfg.Redis.StreamName, cfg.Analytics.PipelineConsumerGroup, "0").Err()
 			if err != nil && err.Error() != "BUSYGROUP Consumer Group name already exists" {
 				return err
 			}
@@ -166,10 +355,25 @@ func registerLifecycle(
 			var wg sync.WaitGroup
 			wg.Add(1)
 
+			// The dispatcher gets its own context: cancelling it makes the
+			// delivery goroutines drain the queue and exit, which must not
+			// happen while the worker can still enqueue.
+			dispatcherCtx, cancelDispatcher := context.WithCancel(context.Background())
+			if dispatcher != nil {
+				dispatcher.Start(dispatcherCtx)
+			}
+
 			go func() {
 				defer wg.Done()
 				worker.Start(workerCtx, log)
 			}()
+			if retention != nil {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					retention.Run(workerCtx)
+				}()
+			}
 
 			log.Info("Pipeline worker started")
 
@@ -178,9 +382,21 @@ func registerLifecycle(
 					log.Info("Shutting down pipeline worker...")
 					cancel()
 					wg.Wait()
+					cancelDispatcher()
+					if dispatcher != nil {
+						// The worker has stopped enqueueing; give queued
+						// webhook deliveries a bounded window to go out.
+						drainCtx, cancelDrain := context.WithTimeout(ctx, webhookDrainTimeout)
+						if err := dispatcher.Shutdown(drainCtx); err != nil {
+							log.Warn("Webhook queue not drained before shutdown: %v", err)
+						}
+						cancelDrain()
+					}
 					_ = tracing.ShutdownTracer(ctx, tp)
+					log.Info("GeoIP cache: %v", geoEnricher.Stats())
 					_ = geoEnricher.Close()
 					_ = chClient.Close()
+					dbManager.Close()
 					_ = redisClient.Close()
 					return nil
 				},
@@ -193,44 +409,74 @@ func registerLifecycle(
 // PipelineWorker holds the dependencies and configuration for the click
 // enrichment pipeline. It consumes raw click events from a Redis Stream,
 // enriches them with GeoIP and user-agent data, and writes the results
-// to ClickHouse (and optionally Elasticsearch).
+// to ClickHouse (and optionally Elasticsearch), then hands each event to
+// the webhook dispatcher.
 type PipelineWorker struct {
 	redisClient   *redis.Client
-	chClient      *clickhouse.Client
+	chClient      clickStore
 	esClient      *es.Client
-	geoEnricher   *enrichment.GeoIPEnricher
+	geoEnricher   geoLocator
+	webhookStore  *storage.WebhookStorage
+	dispatcher    *webhook.Dispatcher // nil when webhooks are disabled
 	streamName    string
 	consumerGroup string
 	consumerName  string
 	batchSize     int
 	pollInterval  time.Duration
 	blockTime     time.Duration
+	enrichWorkers int           // events of a batch enriched concurrently
+	dedupWindow   time.Duration // zero disables repeat-click dedup
+	dedupMode     string        // clickdedup.ModeCollapse or clickdedup.ModeRaw
+	sampler       *clicksample.Sampler
+	signer        *events.Signer       // nil accepts unsigned events
+	deadLetters   string               // stream for events failing the signature check
+	ipMasker      *enrichment.IPMasker // nil stores IPs as received
+	fields        enrichment.Fields    // click-event fields stored; the rest are left blank
+	processed     *events.ProcessedSet // nil stores redelivered entries again
+}
+
+// clickStore writes enriched click events. It is satisfied by
+// *clickhouse.Client; the tests substitute a recording store.
+type clickStore interface {
+	InsertClickEvents(ctx context.Context, events []clickhouse.ClickEvent) error
+}
+
+// geoLocator resolves client IPs to locations. It is satisfied by
+// *enrichment.GeoIPEnricher; the benchmarks substitute a slow lookup.
+type geoLocator interface {
+	Lookup(ipAddress string) *enrichment.GeoInfo
 }
 
-// Start runs the main processing loop on a configurable ticker. Each tick
-// calls processBatch, which reads, enriches, and stores one batch of
-// events. The loop exits when the context is cancelled during shutdown.
+// Start runs the main processing loop. Each iteration calls processBatch,
+// whose XReadGroup blocks for up to blockTime until events arrive, and the
+// next read only starts once the batch is stored. A slow ClickHouse
+// therefore slows reading instead of piling up work in the worker: unread
+// events wait in the stream. After a failed batch the loop waits
+// pollInterval before retrying. The loop exits when the context is cancelled
+// during shutdown.
 func (w *PipelineWorker) Start(ctx context.Context, log *logger.Logger) {
-	ticker := time.NewTicker(w.pollInterval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			if err := w.processBatch(ctx, log); err != nil {
-				log.Error("Failed to process batch: %v", err)
+	for ctx.Err() == nil {
+		if err := w.processBatch(ctx, log); err != nil {
+			log.Error("Failed to process batch: %v", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(w.pollInterval):
 			}
 		}
 	}
 }
 
-// processBatch reads up to batchSize messages from the Redis Stream,
-// enriches each event (GeoIP + UA parsing), batch-inserts into ClickHouse,
-// optionally bulk-indexes into Elasticsearch, and acknowledges consumed
-// messages. Errors during ClickHouse insertion halt the batch so messages
-// remain unacknowledged and will be redelivered on the next attempt.
+// processBatch reads up to batchSize messages from the Redis Stream and
+// dead-letters those failing the signature check. It enriches the rest
+// (GeoIP and UA parsing) on a bounded pool, collapses repeat clicks within
+// the dedup window, and samples the events to store. Those are
+// batch-inserted into ClickHouse and optionally bulk-indexed into
+// Elasticsearch. Webhook deliveries are queued for every click, the consumed
+// messages are acknowledged, and the flush is recorded for
+// GET /api/admin/pipeline.
+//
+// An error during the ClickHouse insert halts the batch, so its messages
+// remain unacknowledged and are redelivered on the next attempt.
 func (w *PipelineWorker) processBatch(ctx context.Context, log *logger.Logger) error {
 	streams, err := w.redisClient.XReadGroup(ctx, &redis.XReadGroupArgs{
 		Group:    w.consumerGroup,
@@ -251,33 +497,38 @@ func (w *PipelineWorker) processBatch(ctx context.Context, log *logger.Logger) e
 		return nil
 	}
 
-	messages := streams[0].Messages
+	messages := w.skipProcessed(ctx, streams[0].Messages, log)
+	if len(messages) == 0 {
+		return nil
+	}
 	log.Info("Processing batch of %d events", len(messages))
 
-	var clickEvents []clickhouse.ClickEvent
-	var messageIDs []string
-
-	for _, msg := range messages {
-		event, err := w.enrichEvent(msg.Values)
-		if err != nil {
-			log.Error("Failed to enrich event %s: %v", msg.ID, err)
-			continue
-		}
-		clickEvents = append(clickEvents, *event)
-		messageIDs = append(messageIDs, msg.ID)
+	clickEvents, messageIDs, rejected := w.enrichBatch(messages, log)
+	w.deadLetter(ctx, rejected, log)
+	if len(clickEvents) == 0 {
+		return nil
 	}
 
+	clickEvents = clickdedup.CollapseBatch(clickEvents, w.dedupWindow, w.dedupMode)
 	if len(clickEvents) == 0 {
+		w.ack(ctx, messageIDs, log)
+		w.recordFlush(ctx, log)
 		return nil
 	}
 
-	if err := w.chClient.InsertClickEvents(ctx, clickEvents); err != nil {
+	stored := w.sampler.SampleBatch(clickEvents)
+	if err := w.chClient.InsertClickEvents(ctx, stored); err != nil {
 		return fmt.Errorf("failed to insert events to ClickHouse: %w", err)
 	}
+	// Marked as soon as they are stored, to keep the window in which a
+	// redelivery would store them again short.
+	if err := w.processed.Mark(ctx, messageIDs); err != nil {
+		log.Error("%v", err)
+	}
 
-	if w.esClient != nil {
-		esDocs := make([]es.ClickEventDocument, len(clickEvents))
-		for i, ce := range clickEvents {
+	if w.esClient != nil && len(stored) > 0 {
+		esDocs := make([]es.ClickEventDocument, len(stored))
+		for i, ce := range stored {
 			esDocs[i] = es.ClickEventDocument{
 				EventID:     ce.EventID,
 				ShortCode:   ce.ShortCode,
@@ -302,21 +553,201 @@ func (w *PipelineWorker) processBatch(ctx context.Context, log *logger.Logger) e
 		}
 	}
 
+	w.dispatchWebhooks(ctx, clickEvents, log)
+	w.ack(ctx, messageIDs, log)
+	w.recordFlush(ctx, log)
+
+	log.Info("Successfully processed %d events (%d stored)", len(clickEvents), len(stored))
+	return nil
+}
+
+// skipProcessed acknowledges and drops the messages whose events were
+// stored by an earlier delivery, returning the rest. If the processed set
+// cannot be read every message is kept: storing a click twice is the
+// lesser harm than losing it.
+func (w *PipelineWorker) skipProcessed(ctx context.Context, messages []redis.XMessage, log *logger.Logger) []redis.XMessage {
+	ids := make([]string, len(messages))
+	for i, msg := range messages {
+		ids[i] = msg.ID
+	}
+	seen, err := w.processed.Seen(ctx, ids)
+	if err != nil {
+		log.Error("%v", err)
+		return messages
+	}
+	if len(seen) == 0 {
+		return messages
+	}
+
+	fresh := make([]redis.XMessage, 0, len(messages))
+	var skipped []string
+	for _, msg := range messages {
+		if seen[msg.ID] {
+			skipped = append(skipped, msg.ID)
+			continue
+		}
+		fresh = append(fresh, msg)
+	}
+	log.Warn("Skipped %d click events already stored", len(skipped))
+	w.ack(ctx, skipped, log)
+	return fresh
+}
+
+// deadLetter moves messages that failed the signature check to the
+// dead-letter stream, acknowledging each once it is copied there. One that
+// cannot be copied stays pending, to be checked again on redelivery.
+func (w *PipelineWorker) deadLetter(ctx context.Context, messages []redis.XMessage, log *logger.Logger) {
+	moved := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		if err := events.DeadLetter(ctx, w.redisClient, w.deadLetters, msg, w.consumerGroup, events.ErrBadSignature.Error()); err != nil {
+			log.Error("%v", err)
+			continue
+		}
+		moved = append(moved, msg.ID)
+	}
+	if len(moved) > 0 {
+		log.Warn("Dead-lettered %d click events with a bad signature", len(moved))
+		w.ack(ctx, moved, log)
+	}
+}
+
+// ack acknowledges consumed messages, including those whose events were
+// collapsed as repeat clicks, so they are not redelivered.
+func (w *PipelineWorker) ack(ctx context.Context, messageIDs []string, log *logger.Logger) {
 	for _, msgID := range messageIDs {
 		if err := w.redisClient.XAck(ctx, w.streamName, w.consumerGroup, msgID).Err(); err != nil {
 			log.Error("Failed to ack message %s: %v", msgID, err)
 		}
 	}
+}
 
-	log.Info("Successfully processed %d events", len(clickEvents))
-	return nil
+// recordFlush records that this worker has just flushed a batch. Failing
+// to is only logged: it costs the admin endpoint a timestamp, not a click.
+func (w *PipelineWorker) recordFlush(ctx context.Context, log *logger.Logger) {
+	if err := events.RecordFlush(ctx, w.redisClient, w.streamName, w.consumerGroup, w.consumerName); err != nil {
+		log.Warn("%v", err)
+	}
+}
+
+// enrichBatch enriches messages on up to enrichWorkers goroutines, since
+// GeoIP lookups and user-agent parsing dominate the cost of a batch. The
+// returned events and message IDs keep the stream order of messages, so
+// each batch is still inserted in order. Messages that fail the signature
+// check are returned as rejected; others that fail to enrich are logged and
+// left out.
+func (w *PipelineWorker) enrichBatch(messages []redis.XMessage, log *logger.Logger) ([]clickhouse.ClickEvent, []string, []redis.XMessage) {
+	enriched := make([]*clickhouse.ClickEvent, len(messages))
+	badSignature := make([]bool, len(messages))
+	workers := min(max(w.enrichWorkers, 1), len(messages))
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				event, err := w.enrichEvent(messages[i].ID, messages[i].Values)
+				if errors.Is(err, events.ErrBadSignature) {
+					badSignature[i] = true
+					continue
+				}
+				if err != nil {
+					log.Error("Failed to enrich event %s: %v", messages[i].ID, err)
+					continue
+				}
+				enriched[i] = event
+			}
+		}()
+	}
+	for i := range messages {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	clickEvents := make([]clickhouse.ClickEvent, 0, len(messages))
+	messageIDs := make([]string, 0, len(messages))
+	var rejected []redis.XMessage
+	for i, event := range enriched {
+		switch {
+		case event != nil:
+			clickEvents = append(clickEvents, *event)
+			messageIDs = append(messageIDs, messages[i].ID)
+		case badSignature[i]:
+			rejected = append(rejected, messages[i])
+		}
+	}
+	return clickEvents, messageIDs, rejected
+}
+
+// dispatchWebhooks looks up the active webhooks for every short code in the
+// batch with a single query and queues one delivery per (event, webhook)
+// pair. Deliveries run asynchronously on the dispatcher's pool, so a slow
+// receiver never delays acknowledgement of the batch. Lookup failures are
+// logged and skipped: webhooks are best-effort and must not cause the batch
+// to be redelivered (which would double-insert into ClickHouse).
+func (w *PipelineWorker) dispatchWebhooks(ctx context.Context, events []clickhouse.ClickEvent, log *logger.Logger) {
+	if w.dispatcher == nil || w.webhookStore == nil {
+		return
+	}
+
+	seen := make(map[string]bool)
+	var shortCodes []string
+	for _, ev := range events {
+		if !seen[ev.ShortCode] {
+			seen[ev.ShortCode] = true
+			shortCodes = append(shortCodes, ev.ShortCode)
+		}
+	}
+
+	hooks, err := w.webhookStore.ListActiveByShortCodes(ctx, shortCodes)
+	if err != nil {
+		log.Error("Failed to load webhooks: %v", err)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	for _, ev := range events {
+		for _, wh := range hooks[ev.ShortCode] {
+			w.dispatcher.Enqueue(ctx, wh, webhook.Payload{
+				EventID:     ev.EventID,
+				ShortCode:   ev.ShortCode,
+				OriginalURL: ev.OriginalURL,
+				ClickedAt:   ev.ClickedAt,
+				Country:     ev.Country,
+				CountryCode: ev.CountryCode,
+				Region:      ev.Region,
+				City:        ev.City,
+				Browser:     ev.Browser,
+				OS:          ev.OS,
+				DeviceType:  ev.DeviceType,
+				Referer:     ev.Referer,
+			})
+		}
+	}
 }
 
 // enrichEvent transforms a raw Redis Stream message into a fully populated
-// ClickEvent. It extracts fields from the message map, resolves the IP to
-// a geographic location via GeoIP, parses the user-agent string into
-// browser/OS/device components, and assigns a UUID as the event ID.
-func (w *PipelineWorker) enrichEvent(fields map[string]interface{}) (*clickhouse.ClickEvent, error) {
+// ClickEvent. It first checks the message's signature, returning
+// events.ErrBadSignature if it does not match. It then extracts the fields
+// from the message map, resolves the IP to a geographic location via GeoIP,
+// and parses the user-agent string into browser/OS/device components. The
+// event ID is derived from msgID (see events.EventID), so a redelivered
+// message keeps its event ID.
+//
+// The IP is located, and the event's DedupKey computed, before ipMasker
+// anonymizes or hashes the address, so the stored event keeps its location
+// and repeat clicks are still told apart by visitor, but the address itself
+// is not kept. Fields the worker is not configured to keep (see
+// enrichment.Fields) are neither looked up nor parsed, and are stored blank.
+func (w *PipelineWorker) enrichEvent(msgID string, fields map[string]interface{}) (*clickhouse.ClickEvent, error) {
+	if err := w.signer.Verify(fields); err != nil {
+		return nil, err
+	}
+
 	shortCode, _ := fields["short_code"].(string)
 	timestamp, _ := fields["timestamp"].(string)
 	ipAddress, _ := fields["ip"].(string)
@@ -324,6 +755,17 @@ func (w *PipelineWorker) enrichEvent(fields map[string]interface{}) (*clickhouse
 	originalURL, _ := fields["original_url"].(string)
 	referer, _ := fields["referer"].(string)
 	queryParams, _ := fields["query_params"].(string)
+	variantField, _ := fields["variant"].(string)
+	variant, _ := strconv.ParseUint(variantField, 10, 16)
+	geoRule, _ := fields["geo_rule"].(string)
+	var isDuplicate uint8
+	if duplicate, _ := fields["duplicate"].(string); duplicate == "1" {
+		isDuplicate = 1
+	}
+	var isFailover uint8
+	if failover, _ := fields["failover"].(string); failover == "1" {
+		isFailover = 1
+	}
 
 	var clickedAt time.Time
 	if timestamp != "" {
@@ -337,31 +779,57 @@ func (w *PipelineWorker) enrichEvent(fields map[string]interface{}) (*clickhouse
 		clickedAt = time.Now()
 	}
 
-	geoInfo := w.geoEnricher.Lookup(ipAddress)
-	uaInfo := enrichment.ParseUserAgent(userAgent)
+	geoInfo := &enrichment.GeoInfo{}
+	if w.fields.Keeps(enrichment.FieldGeo) {
+		geoInfo = w.geoEnricher.Lookup(ipAddress)
+	}
 
+	uaInfo := &enrichment.UAInfo{}
 	var isMobile, isTablet, isDesktop, isBot uint8
-	switch uaInfo.DeviceType {
-	case "mobile":
-		isMobile = 1
-	case "tablet":
-		isTablet = 1
-	case "bot":
-		isBot = 1
-	default:
-		isDesktop = 1
+	if w.fields.Keeps(enrichment.FieldDevice) {
+		uaInfo = enrichment.ParseUserAgent(userAgent)
+		switch uaInfo.DeviceType {
+		case "mobile":
+			isMobile = 1
+		case "tablet":
+			isTablet = 1
+		case "bot":
+			isBot = 1
+		default:
+			isDesktop = 1
+		}
+
+		if uaInfo.IsTablet {
+			isTablet = 1
+		}
+	}
+
+	// Repeat clicks are recognised by the visitor as received: masking or
+	// leaving out the address below must not merge or hide visitors.
+	var dedupKey string
+	if ipAddress != "" {
+		dedupKey = clickdedup.Key(shortCode, ipAddress, userAgent)
 	}
 
-	if uaInfo.IsTablet {
-		isTablet = 1
+	if !w.fields.Keeps(enrichment.FieldIP) {
+		ipAddress = ""
+	}
+	if !w.fields.Keeps(enrichment.FieldUserAgent) {
+		userAgent = ""
+	}
+	if !w.fields.Keeps(enrichment.FieldReferer) {
+		referer = ""
+	}
+	if !w.fields.Keeps(enrichment.FieldQuery) {
+		queryParams = ""
 	}
 
 	return &clickhouse.ClickEvent{
-		EventID:        uuid.New().String(),
+		EventID:        events.EventID(w.streamName, msgID),
 		ShortCode:      shortCode,
 		OriginalURL:    originalURL,
 		ClickedAt:      clickedAt,
-		IPAddress:      ipAddress,
+		IPAddress:      w.ipMasker.Mask(ipAddress),
 		Country:        geoInfo.Country,
 		CountryCode:    geoInfo.CountryCode,
 		Region:         geoInfo.Region,
@@ -383,6 +851,11 @@ func (w *PipelineWorker) enrichEvent(fields map[string]interface{}) (*clickhouse
 		IsBot:          isBot,
 		Referer:        referer,
 		QueryParams:    queryParams,
+		Variant:        uint16(variant),
+		GeoRule:        geoRule,
+		IsDuplicate:    isDuplicate,
+		IsFailover:     isFailover,
+		DedupKey:       dedupKey,
 	}, nil
 }
 
@@ -393,7 +866,21 @@ func (w *PipelineWorker) enrichEvent(fields map[string]interface{}) (*clickhouse
 // started by registerLifecycle. There is no HTTP or gRPC server -- this is
 // a pure stream consumer. Run() blocks until a termination signal is
 // received.
+//
+// "pipeline-worker migrate" only applies the ClickHouse migrations.
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		log := provideLogger()
+		cfg, err := provideConfig()
+		if err != nil {
+			log.Fatal("Failed to load config: %v", err)
+		}
+		if err := runMigrations(context.Background(), cfg, log); err != nil {
+			log.Fatal("Migration failed: %v", err)
+		}
+		return
+	}
+
 	fx.New(
 		fx.Provide(
 			provideConfig,
@@ -402,8 +889,12 @@ func main() {
 			provideRedisClient,
 			provideClickHouseClient,
 			provideESClient,
+			provideDBManager,
+			provideWebhookStorage,
+			provideWebhookDispatcher,
 			provideGeoEnricher,
 			providePipelineWorker,
+			provideRetention,
 		),
 		fx.Invoke(registerLifecycle),
 	).Run()
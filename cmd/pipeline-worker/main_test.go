@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clickdedup"
+	"github.com/Varun5711/shorternit/internal/clickhouse"
+	"github.com/Varun5711/shorternit/internal/clicksample"
+	"github.com/Varun5711/shorternit/internal/enrichment"
+	"github.com/Varun5711/shorternit/internal/events"
+	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// slowGeo stands in for a GeoIP database whose lookups take delay each.
+type slowGeo struct {
+	delay time.Duration
+}
+
+func (g slowGeo) Lookup(ipAddress string) *enrichment.GeoInfo {
+	time.Sleep(g.delay)
+	return &enrichment.GeoInfo{Country: "Unknown", CountryCode: "XX"}
+}
+
+// clickMessages returns n stream messages from distinct visitors.
+func clickMessages(n int) []redis.XMessage {
+	messages := make([]redis.XMessage, n)
+	for i := range messages {
+		messages[i] = redis.XMessage{
+			ID: fmt.Sprintf("%d-0", i+1),
+			Values: map[string]interface{}{
+				"short_code": "abc",
+				"timestamp":  strconv.FormatInt(time.Now().Unix(), 10),
+				"ip":         fmt.Sprintf("198.51.100.%d", i%250),
+				"user_agent": "Mozilla/5.0 (X11; Linux x86_64) Firefox/120.0",
+			},
+		}
+	}
+	return messages
+}
+
+// TestEnrichBatch_KeepsStreamOrder verifies that concurrent enrichment
+// returns events and IDs in the order the messages were read.
+func TestEnrichBatch_KeepsStreamOrder(t *testing.T) {
+	w := &PipelineWorker{geoEnricher: slowGeo{}, enrichWorkers: 8}
+	messages := clickMessages(100)
+
+	events, ids, _ := w.enrichBatch(messages, logger.New("pipeline-test"))
+	if len(events) != len(messages) || len(ids) != len(messages) {
+		t.Fatalf("expected %d events, got %d events and %d ids", len(messages), len(events), len(ids))
+	}
+	for i, msg := range messages {
+		if ids[i] != msg.ID || events[i].IPAddress != msg.Values["ip"] {
+			t.Fatalf("event %d out of order: id %s, ip %s", i, ids[i], events[i].IPAddress)
+		}
+	}
+}
+
+// TestEnrichBatch_RejectsBadSignatures verifies that with signing on, a
+// signed event is enriched while a tampered or unsigned one is rejected for
+// dead-lettering.
+func TestEnrichBatch_RejectsBadSignatures(t *testing.T) {
+	signer, err := events.NewSigner("shared-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := clickMessages(3)
+	signer.Sign(messages[0].Values)
+	signer.Sign(messages[1].Values)
+	messages[1].Values["short_code"] = "forged"
+
+	w := &PipelineWorker{geoEnricher: slowGeo{}, enrichWorkers: 2, signer: signer}
+	enriched, ids, rejected := w.enrichBatch(messages, logger.New("pipeline-test"))
+	if len(enriched) != 1 || ids[0] != messages[0].ID {
+		t.Errorf("expected only the signed event to be enriched, got ids %v", ids)
+	}
+	if len(rejected) != 2 || rejected[0].ID != messages[1].ID || rejected[1].ID != messages[2].ID {
+		t.Errorf("expected the tampered and unsigned events to be rejected, got %v", rejected)
+	}
+}
+
+// recordingGeo locates every address in one country and remembers the
+// addresses it was asked about.
+type recordingGeo struct {
+	mu     sync.Mutex
+	lookup []string
+}
+
+func (g *recordingGeo) Lookup(ipAddress string) *enrichment.GeoInfo {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.lookup = append(g.lookup, ipAddress)
+	return &enrichment.GeoInfo{Country: "Netherlands", CountryCode: "NL"}
+}
+
+// TestEnrichBatch_MasksIPAfterGeoLookup verifies that the GeoIP lookup sees
+// the visitor's real address while the stored event gets the masked one.
+func TestEnrichBatch_MasksIPAfterGeoLookup(t *testing.T) {
+	masker, err := enrichment.NewIPMasker(enrichment.IPModeAnonymize, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	geo := &recordingGeo{}
+	w := &PipelineWorker{geoEnricher: geo, enrichWorkers: 1, ipMasker: masker}
+
+	messages := clickMessages(1)
+	messages[0].Values["ip"] = "198.51.100.77"
+	events, _, _ := w.enrichBatch(messages, logger.New("pipeline-test"))
+	if len(events) != 1 || events[0].IPAddress != "198.51.100.0" || events[0].CountryCode != "NL" {
+		t.Fatalf("expected an anonymized, located event, got %+v", events)
+	}
+	if len(geo.lookup) != 1 || geo.lookup[0] != "198.51.100.77" {
+		t.Errorf("expected the original address located, got %v", geo.lookup)
+	}
+}
+
+// TestEnrichBatch_DisabledFieldsBlank verifies that the fields
+// ANALYTICS_FIELDS leaves out are stored blank, and that a dropped address
+// is still located when geo is kept.
+func TestEnrichBatch_DisabledFieldsBlank(t *testing.T) {
+	fields, err := enrichment.ParseFields([]string{enrichment.FieldGeo, enrichment.FieldReferer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	geo := &recordingGeo{}
+	w := &PipelineWorker{geoEnricher: geo, enrichWorkers: 1, fields: fields}
+
+	messages := clickMessages(1)
+	messages[0].Values["ip"] = "198.51.100.77"
+	messages[0].Values["referer"] = "https://news.example"
+	messages[0].Values["query_params"] = "utm_source=mail"
+	events, _, _ := w.enrichBatch(messages, logger.New("pipeline-test"))
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	ev := events[0]
+	if ev.IPAddress != "" || ev.UserAgent != "" || ev.QueryParams != "" {
+		t.Errorf("expected the address, User-Agent and query blank, got %q, %q, %q", ev.IPAddress, ev.UserAgent, ev.QueryParams)
+	}
+	if ev.Browser != "" || ev.OS != "" || ev.DeviceType != "" || ev.IsDesktop != 0 {
+		t.Errorf("expected no device fields, got %+v", ev)
+	}
+	if ev.CountryCode != "NL" || ev.Referer != "https://news.example" {
+		t.Errorf("expected the location and referer kept, got %q and %q", ev.CountryCode, ev.Referer)
+	}
+	if len(geo.lookup) != 1 || geo.lookup[0] != "198.51.100.77" {
+		t.Errorf("expected the address located, got %v", geo.lookup)
+	}
+
+	w.fields, _ = enrichment.ParseFields([]string{enrichment.FieldsNone})
+	geo.lookup = nil
+	events, _, _ = w.enrichBatch(messages, logger.New("pipeline-test"))
+	if ev := events[0]; ev.CountryCode != "" || ev.Referer != "" || ev.ShortCode != "abc" {
+		t.Errorf("expected only the short code kept, got %+v", ev)
+	}
+	if len(geo.lookup) != 0 {
+		t.Errorf("expected no geo lookup, got %v", geo.lookup)
+	}
+}
+
+// TestEnrichBatch_DedupsVisitorsAsReceived verifies that repeat clicks are
+// collapsed by the visitor's own address whatever is stored: visitors
+// sharing an anonymized /24 still count separately, and repeats are still
+// caught when ANALYTICS_FIELDS leaves the address out.
+func TestEnrichBatch_DedupsVisitorsAsReceived(t *testing.T) {
+	masker, err := enrichment.NewIPMasker(enrichment.IPModeAnonymize, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	noIP, err := enrichment.ParseFields([]string{enrichment.FieldGeo, enrichment.FieldDevice})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		w    *PipelineWorker
+	}{
+		{"anonymize", &PipelineWorker{geoEnricher: slowGeo{}, enrichWorkers: 1, ipMasker: masker}},
+		{"no ip field", &PipelineWorker{geoEnricher: slowGeo{}, enrichWorkers: 1, fields: noIP}},
+	} {
+		// Two visitors in one /24, the first clicking twice.
+		messages := clickMessages(3)
+		messages[0].Values["ip"] = "198.51.100.7"
+		messages[1].Values["ip"] = "198.51.100.8"
+		messages[2].Values["ip"] = "198.51.100.7"
+
+		enriched, _, _ := tc.w.enrichBatch(messages, logger.New("pipeline-test"))
+		if len(enriched) != 3 || enriched[0].IPAddress != enriched[1].IPAddress {
+			t.Fatalf("%s: expected both visitors stored alike, got %+v", tc.name, enriched)
+		}
+		got := clickdedup.CollapseBatch(enriched, 5*time.Second, clickdedup.ModeCollapse)
+		if len(got) != 2 || got[0].EventID != enriched[0].EventID || got[1].EventID != enriched[1].EventID {
+			t.Errorf("%s: expected one click from each visitor, got %+v", tc.name, got)
+		}
+	}
+}
+
+// BenchmarkEnrichBatch enriches a large batch with a GeoIP lookup that
+// takes 100µs, one goroutine against the pool.
+func BenchmarkEnrichBatch(b *testing.B) {
+	messages := clickMessages(1000)
+	log := logger.New("pipeline-bench")
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			w := &PipelineWorker{geoEnricher: slowGeo{delay: 100 * time.Microsecond}, enrichWorkers: workers}
+			for b.Loop() {
+				w.enrichBatch(messages, log)
+			}
+		})
+	}
+}
+
+// redeliveringStream answers the commands processBatch sends instead of a
+// Redis server, delivering the same messages on every read as a stream does
+// when a worker stops before acknowledging them.
+type redeliveringStream struct {
+	mu       sync.Mutex
+	messages []redis.XMessage
+	keys     map[string]bool
+	acked    []string
+	flushes  int
+}
+
+func (s *redeliveringStream) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (s *redeliveringStream) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		return s.process(cmd)
+	}
+}
+
+func (s *redeliveringStream) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			if err := s.process(cmd); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (s *redeliveringStream) process(cmd redis.Cmder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	args := cmd.Args()
+	switch c := cmd.(type) {
+	case *redis.XStreamSliceCmd: // XREADGROUP
+		c.SetVal([]redis.XStream{{Stream: "clicks", Messages: s.messages}})
+	case *redis.IntCmd: // XACK, or HSET of a flush
+		if cmd.Name() == "hset" {
+			s.flushes++
+			c.SetVal(1)
+			return nil
+		}
+		s.acked = append(s.acked, args[3].(string))
+		c.SetVal(1)
+	case *redis.SliceCmd: // MGET
+		values := make([]interface{}, len(args)-1)
+		for i, key := range args[1:] {
+			if s.keys[key.(string)] {
+				values[i] = "1"
+			}
+		}
+		c.SetVal(values)
+	case *redis.StatusCmd: // SET
+		s.keys[args[1].(string)] = true
+		c.SetVal("OK")
+	default:
+		cmd.SetErr(fmt.Errorf("unexpected command %v", args))
+		return cmd.Err()
+	}
+	return nil
+}
+
+// recordingStore remembers the click events inserted.
+type recordingStore struct {
+	inserted []clickhouse.ClickEvent
+}
+
+func (s *recordingStore) InsertClickEvents(ctx context.Context, events []clickhouse.ClickEvent) error {
+	s.inserted = append(s.inserted, events...)
+	return nil
+}
+
+// TestProcessBatch_SkipsRedeliveredMessages verifies that a message
+// delivered again after being stored is acknowledged without being
+// inserted a second time.
+func TestProcessBatch_SkipsRedeliveredMessages(t *testing.T) {
+	stream := &redeliveringStream{messages: clickMessages(3), keys: map[string]bool{}}
+	client := redis.NewClient(&redis.Options{})
+	client.AddHook(stream)
+	store := &recordingStore{}
+	w := &PipelineWorker{
+		redisClient:   client,
+		chClient:      store,
+		geoEnricher:   slowGeo{},
+		streamName:    "clicks",
+		consumerGroup: "pipeline-group",
+		batchSize:     10,
+		enrichWorkers: 2,
+		sampler:       clicksample.NewSampler(1, nil),
+		processed:     events.NewProcessedSet(client, "clicks", "pipeline-group", time.Hour),
+	}
+	log := logger.New("pipeline-test")
+
+	for range 2 {
+		if err := w.processBatch(context.Background(), log); err != nil {
+			t.Fatalf("processBatch: %v", err)
+		}
+	}
+
+	if len(store.inserted) != 3 {
+		t.Fatalf("expected 3 events inserted once, got %d", len(store.inserted))
+	}
+	for i, ev := range store.inserted {
+		if want := events.EventID("clicks", stream.messages[i].ID); ev.EventID != want {
+			t.Errorf("event %d: expected ID %s derived from the message, got %s", i, want, ev.EventID)
+		}
+	}
+	if len(stream.acked) != 6 {
+		t.Errorf("expected every delivery acknowledged, got %v", stream.acked)
+	}
+	if stream.flushes != 1 {
+		t.Errorf("expected only the batch that was stored recorded as a flush, got %d", stream.flushes)
+	}
+}
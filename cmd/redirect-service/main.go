@@ -18,16 +18,27 @@ package main
 import (
 	"context"
 	"net/http"
+	"net/netip"
 	"time"
 
 	"github.com/Varun5711/shorternit/internal/cache"
+	"github.com/Varun5711/shorternit/internal/clickdedup"
+	"github.com/Varun5711/shorternit/internal/clickdelta"
+	"github.com/Varun5711/shorternit/internal/clicklimit"
 	"github.com/Varun5711/shorternit/internal/config"
+	"github.com/Varun5711/shorternit/internal/database"
+	"github.com/Varun5711/shorternit/internal/enrichment"
 	"github.com/Varun5711/shorternit/internal/events"
+	"github.com/Varun5711/shorternit/internal/failover"
+	grpcClient "github.com/Varun5711/shorternit/internal/grpc"
 	"github.com/Varun5711/shorternit/internal/handlers"
 	"github.com/Varun5711/shorternit/internal/logger"
 	"github.com/Varun5711/shorternit/internal/middleware"
 	"github.com/Varun5711/shorternit/internal/redis"
+	"github.com/Varun5711/shorternit/internal/retry"
+	"github.com/Varun5711/shorternit/internal/storage"
 	"github.com/Varun5711/shorternit/internal/tracing"
+	userpb "github.com/Varun5711/shorternit/proto/user"
 	redislib "github.com/redis/go-redis/v9"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/fx"
@@ -49,11 +60,16 @@ func provideLogger() *logger.Logger {
 // as the L2 cache tier for URL lookups and as the transport for click
 // event publishing via Streams.
 func provideRedisClient(cfg *config.Config) (*redis.RedisClient, error) {
-	return redis.NewRedisClient(context.Background(), redis.Config{
-		Addr:     cfg.Redis.Addr,
-		Password: cfg.Redis.Password,
-		DB:       cfg.Redis.DB,
+	var client *redis.RedisClient
+	err := retry.Dial(context.Background(), "Redis", cfg.Startup.ConnectAttempts, cfg.Startup.ConnectBackoff, func() (err error) {
+		client, err = redis.NewRedisClient(context.Background(), redis.Config{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		return err
 	})
+	return client, err
 }
 
 // provideRawRedisClient unwraps the internal RedisClient to expose the
@@ -72,17 +88,139 @@ func provideCache(cfg *config.Config, rc *redislib.Client) *cache.Cache {
 // provideClickProducer creates an event producer that writes click events
 // to a Redis Stream. The stream is consumed by the analytics-worker (for
 // aggregate click counts in Postgres) and the pipeline-worker (for
-// enriched events in ClickHouse/Elasticsearch).
-func provideClickProducer(rc *redislib.Client, cfg *config.Config) *events.ClickProducer {
-	return events.NewClickProducer(rc, cfg.Redis.StreamName)
+// enriched events in ClickHouse/Elasticsearch). REDIS_STREAM_MAXLEN bounds
+// the stream's length if the workers fall behind. With
+// CLICK_SIGNING_ENABLED every event is signed with CLICK_SIGNING_SECRET,
+// and a missing secret fails startup.
+func provideClickProducer(rc *redislib.Client, cfg *config.Config) (*events.ClickProducer, error) {
+	var signer *events.Signer
+	if cfg.ClickSigning.Enabled {
+		var err error
+		if signer, err = events.NewSigner(cfg.ClickSigning.Secret); err != nil {
+			return nil, err
+		}
+	}
+	return events.NewClickProducer(rc, cfg.Redis.StreamName, cfg.Redis.StreamMaxLen, signer), nil
+}
+
+// provideClickCounter creates the Redis counter that enforces max_clicks on
+// burn-after-N links. It has to live on the redirect path because the
+// database click count is only updated asynchronously by the workers.
+func provideClickCounter(rc *redislib.Client) *clicklimit.Counter {
+	return clicklimit.NewCounter(rc, 0)
+}
+
+// provideClickDeltas creates the Redis counter of clicks the
+// analytics-worker has not flushed to the database yet, which the
+// url-service adds to the click counts it reports.
+func provideClickDeltas(rc *redislib.Client) *clickdelta.Counter {
+	return clickdelta.NewCounter(rc, 0)
+}
+
+// provideClickDeduper creates the Redis gate that recognises repeat clicks
+// from the same visitor within CLICK_DEDUP_WINDOW. It returns a nil
+// ClickDeduper when the window is zero, so every click is recorded, and
+// fails startup on an unknown CLICK_DEDUP_MODE.
+func provideClickDeduper(cfg *config.Config, rc *redislib.Client) (handlers.ClickDeduper, error) {
+	if err := clickdedup.CheckMode(cfg.ClickDedup.Mode); err != nil {
+		return nil, err
+	}
+	if cfg.ClickDedup.Window <= 0 {
+		return nil, nil
+	}
+	return clickdedup.NewGate(rc, cfg.ClickDedup.Window), nil
+}
+
+// provideGeoEnricher creates the GeoIP enricher used to pick per-country
+// destinations for geo-targeted links. Lookups are in-process, so they add no
+// network round-trip to the redirect path, and repeat visitors are answered
+// from its GEOIP_CACHE_SIZE-entry cache.
+func provideGeoEnricher(cfg *config.Config) *enrichment.GeoIPEnricher {
+	return enrichment.NewGeoIPEnricher(cfg.GeoIP.CacheSize)
+}
+
+// provideDestinationHealth creates the Redis lookup that tells when the
+// primary of a link with a backup destination is down. It returns a nil
+// DestinationHealth when FAILOVER_PROBE_INTERVAL is zero, so every link
+// redirects to its primary.
+func provideDestinationHealth(cfg *config.Config, rc *redislib.Client) handlers.DestinationHealth {
+	if cfg.Failover.ProbeInterval <= 0 {
+		return nil
+	}
+	return failover.NewHealth(rc)
+}
+
+// provideHealthChecker creates the background prober that keeps the
+// primaries' down flags, one replica probing per FAILOVER_PROBE_INTERVAL.
+// It returns nil when failover is disabled.
+func provideHealthChecker(cfg *config.Config, rc *redislib.Client, log *logger.Logger) *failover.DestinationHealthChecker {
+	if cfg.Failover.ProbeInterval <= 0 {
+		return nil
+	}
+	return failover.NewDestinationHealthChecker(rc, cfg.Failover.ProbeInterval, cfg.Failover.ProbeTimeout, log)
+}
+
+// provideTokenValidator dials the user-service, which validates the tokens
+// of owners refreshing their link's cached entry with Cache-Control:
+// no-cache. Like the URL service, it need not be up yet: until it is, a
+// refresh is refused and the cached entry served.
+func provideTokenValidator(cfg *config.Config) (handlers.TokenValidator, error) {
+	conn, err := grpcClient.Dial(cfg.Services.UserServiceAddr, cfg.GRPC)
+	if err != nil {
+		return nil, err
+	}
+	return userpb.NewUserServiceClient(conn), nil
+}
+
+// provideTrustedProxies parses TRUSTED_PROXIES, the load balancers whose
+// X-Forwarded-For headers the rate limiter and redirect handler believe when
+// identifying the visitor. A malformed entry fails startup. Unless
+// TRUST_PROXY is set none are returned, so forwarding headers are ignored.
+func provideTrustedProxies(cfg *config.Config) ([]netip.Prefix, error) {
+	prefixes, err := middleware.ParseTrustedProxies(cfg.Services.TrustedProxies)
+	if err != nil || !cfg.Services.TrustProxy {
+		return nil, err
+	}
+	return prefixes, nil
+}
+
+// provideRedirectPages loads the branded 404, expired-link and landing page
+// templates named by REDIRECT_*_TEMPLATE. A template that fails to parse
+// fails startup.
+func provideRedirectPages(cfg *config.Config, log *logger.Logger) (*handlers.RedirectPages, error) {
+	return handlers.LoadRedirectPages(cfg.RedirectPages, log)
 }
 
 // provideRedirectHandler creates the HTTP handler that resolves short codes
 // and issues redirects. It first checks the multi-tier cache, then falls
 // back to the url-service via gRPC if the code is not cached. On every
-// hit it fires a click event asynchronously.
-func provideRedirectHandler(cfg *config.Config, producer *events.ClickProducer, urlCache *cache.Cache) (*handlers.RedirectHandler, error) {
-	return handlers.NewRedirectHandler(cfg.Services.URLServiceAddr, producer, urlCache)
+// hit it fires a click event asynchronously, unless it is a repeat click
+// being collapsed. A code that is not a link is matched against its
+// domain's wildcard aliases, cached for WILDCARD_CACHE_TTL. Links with a
+// backup destination are sent to it while health reports their primary down.
+// A link's owner can bypass the cache, with their token validated by owners.
+func provideRedirectHandler(cfg *config.Config, producer *events.ClickProducer, urlCache *cache.Cache, clickCounter *clicklimit.Counter, clickDeltas *clickdelta.Counter, geoEnricher *enrichment.GeoIPEnricher, health handlers.DestinationHealth, owners handlers.TokenValidator, dedup handlers.ClickDeduper, trustedProxies []netip.Prefix, pages *handlers.RedirectPages) (*handlers.RedirectHandler, error) {
+	fields, err := enrichment.ParseFields(cfg.Analytics.Fields)
+	if err != nil {
+		return nil, err
+	}
+	return handlers.NewRedirectHandler(cfg.Services.URLServiceAddr, cfg.GRPC, handlers.RedirectOptions{
+		Producer:       producer,
+		Cache:          urlCache,
+		ClickCounter:   clickCounter,
+		ClickDeltas:    clickDeltas,
+		Geo:            geoEnricher,
+		Health:         health,
+		Owners:         owners,
+		Dedup:          dedup,
+		KeepRepeats:    cfg.ClickDedup.Mode == clickdedup.ModeRaw,
+		Fields:         fields,
+		BaseURL:        cfg.Services.BaseURL,
+		TrustedProxies: trustedProxies,
+		Pages:          pages,
+		MaxAge:         cfg.Services.RedirectMaxAge,
+		WildcardTTL:    cfg.Services.WildcardCacheTTL,
+	})
 }
 
 // provideTracerProvider initializes OpenTelemetry distributed tracing and
@@ -98,11 +236,13 @@ func provideTracerProvider(cfg *config.Config) (*sdktrace.TracerProvider, error)
 	})
 }
 
-// provideRateLimiter builds a Redis-backed sliding-window rate limiter.
-// Rate limiting on the redirect path prevents abuse (link-bombing) and
-// protects the url-service from thundering-herd cache misses.
-func provideRateLimiter(cfg *config.Config, rc *redislib.Client) *middleware.RateLimiter {
-	return middleware.NewRateLimiter(rc, cfg.RateLimit.Requests, cfg.RateLimit.Window)
+// provideRateLimiter builds a Redis-backed rate limiter using
+// REDIRECT_RATE_LIMIT_ALGORITHM, by default the fixed window, which costs
+// the hot path a single round trip. Rate limiting on the redirect path
+// prevents abuse (link-bombing) and protects the url-service from
+// thundering-herd cache misses.
+func provideRateLimiter(cfg *config.Config, rc *redislib.Client, trustedProxies []netip.Prefix) (*middleware.RateLimiter, error) {
+	return middleware.NewRateLimiter(rc, cfg.RateLimit.RedirectAlgorithm, cfg.RateLimit.Requests, cfg.RateLimit.Window, trustedProxies)
 }
 
 // provideHTTPServer assembles the HTTP server with its routing table and
@@ -111,7 +251,8 @@ func provideRateLimiter(cfg *config.Config, rc *redislib.Client) *middleware.Rat
 //   - "/health" -- liveness probe that pings Redis
 //
 // Middleware is layered in reverse order: rate limiting runs first (outermost),
-// then panic recovery, then distributed tracing (innermost before the handler).
+// then panic recovery, then request-ID assignment, then distributed tracing
+// (innermost before the handler), which records the request ID on its span.
 func provideHTTPServer(
 	cfg *config.Config,
 	redirectHandler *handlers.RedirectHandler,
@@ -131,7 +272,8 @@ func provideHTTPServer(
 	})
 
 	handler := middleware.Tracing("redirect-service")(mux)
-	handler = middleware.Recovery(log)(handler)
+	handler = middleware.RequestID(handler)
+	handler = middleware.Recovery(log, cfg.Services.Debug)(handler)
 	handler = rateLimiter.Middleware(handler)
 
 	return &http.Server{
@@ -143,19 +285,72 @@ func provideHTTPServer(
 	}
 }
 
+// warmCache loads the most-clicked links into the cache before the server
+// starts taking traffic, so a fresh deploy does not send its hottest short
+// codes through a round of misses. It opens a database pool only for the
+// duration of the warmup -- steady-state redirects still never touch
+// Postgres -- and treats any failure as non-fatal.
+func warmCache(ctx context.Context, cfg *config.Config, urlCache *cache.Cache, log *logger.Logger) {
+	if cfg.Cache.WarmTopN <= 0 {
+		return
+	}
+
+	// Stay well inside FX's default 15s start timeout, which also bounds ctx.
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	db, err := database.NewDBManager(ctx, database.Config{
+		PrimaryDSN:      cfg.Database.PrimaryDSN,
+		ReplicaDSNs:     cfg.Database.ReplicaDSNs,
+		MaxConns:        2,
+		MinConns:        0,
+		MaxConnLifetime: cfg.Database.MaxConnLifetime,
+		MaxConnIdleTime: cfg.Database.MaxConnIdleTime,
+		QueryTimeout:    cfg.Database.QueryTimeout,
+	})
+	if err != nil {
+		log.Warn("Skipping cache warmup: %v", err)
+		return
+	}
+	defer db.Close()
+
+	warmed, err := urlCache.Warm(ctx, storage.NewPostgresStorage(db), cfg.Cache.WarmTopN)
+	if err != nil {
+		log.Warn("Cache warmup failed: %v", err)
+		return
+	}
+	log.Info("Warmed cache with %d hot links", warmed)
+}
+
 // registerLifecycle hooks the HTTP server and Redis client into the FX
-// lifecycle. On start, the server begins accepting redirect requests in a
-// background goroutine. On stop, it drains in-flight requests, flushes
-// the tracer, and closes the Redis connection.
+// lifecycle. On start, the cache begins listening for invalidations (see
+// cache.ListenInvalidations) and is warmed (when CACHE_WARM_TOP_N is set),
+// the destination health checker starts probing (when failover is enabled)
+// and the server begins accepting redirect requests in a background
+// goroutine. On stop, it stops the listener and the checker, drains
+// in-flight requests, flushes the tracer, and closes the GeoIP database and
+// the Redis connection.
 func registerLifecycle(
 	lc fx.Lifecycle,
+	cfg *config.Config,
 	server *http.Server,
+	urlCache *cache.Cache,
 	tp *sdktrace.TracerProvider,
+	geoEnricher *enrichment.GeoIPEnricher,
+	checker *failover.DestinationHealthChecker,
 	redisClient *redis.RedisClient,
 	log *logger.Logger,
 ) {
+	checkerCtx, stopChecker := context.WithCancel(context.Background())
+	listenCtx, stopListening := context.WithCancel(context.Background())
+
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
+			go urlCache.ListenInvalidations(listenCtx)
+			warmCache(ctx, cfg, urlCache, log)
+			if checker != nil {
+				go checker.Run(checkerCtx)
+			}
 			log.Info("Listening on %s", server.Addr)
 			go func() {
 				if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -166,10 +361,13 @@ func registerLifecycle(
 		},
 		OnStop: func(ctx context.Context) error {
 			log.Info("Shutting down redirect-service...")
+			stopListening()
+			stopChecker()
 			if err := server.Shutdown(ctx); err != nil {
 				log.Error("Shutdown error: %v", err)
 			}
 			_ = tracing.ShutdownTracer(ctx, tp)
+			_ = geoEnricher.Close()
 			_ = redisClient.Close()
 			return nil
 		},
@@ -195,6 +393,15 @@ func main() {
 			provideRawRedisClient,
 			provideCache,
 			provideClickProducer,
+			provideClickCounter,
+			provideClickDeltas,
+			provideClickDeduper,
+			provideGeoEnricher,
+			provideDestinationHealth,
+			provideHealthChecker,
+			provideTokenValidator,
+			provideTrustedProxies,
+			provideRedirectPages,
 			provideRedirectHandler,
 			provideRateLimiter,
 			provideHTTPServer,
@@ -1,7 +1,11 @@
 // Package client provides gRPC client wrappers used by the TUI to communicate
 // with the Tiny backend services. Each wrapper manages its own connection
 // lifecycle and applies per-call timeouts so that the TUI remains responsive
-// even when a backend is slow or unavailable.
+// even when a backend is slow or unavailable. Every call also takes the
+// caller's context, so a call the user gives up on can be cancelled; a call
+// that ends either way returns ErrTimeout or ErrCanceled. A call that finds
+// the connection dropped waits for it to come back and is made once more,
+// returning ErrUnavailable if it does not (see invoke).
 package client
 
 import (
@@ -10,7 +14,7 @@ import (
 
 	userpb "github.com/Varun5711/shorternit/proto/user"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/credentials"
 )
 
 // AuthClient wraps the gRPC UserService for authentication operations
@@ -19,20 +23,24 @@ import (
 type AuthClient struct {
 	conn    *grpc.ClientConn
 	service userpb.UserServiceClient
+	notify  ReconnectFunc // told while a call waits to reconnect; may be nil
 }
 
 // NewAuthClient dials the auth service at addr with a 5-second connection
-// timeout. WithBlock ensures the constructor does not return until the
-// connection is ready or the timeout fires, giving the TUI a clear
-// startup-time error rather than a deferred failure on the first RPC.
-func NewAuthClient(addr string) (*AuthClient, error) {
+// timeout, using creds for transport security. WithBlock ensures the
+// constructor does not return until the connection is ready or the timeout
+// fires, giving the TUI a clear startup-time error rather than a deferred
+// failure on the first RPC. A connection that drops later is re-established;
+// see invoke.
+func NewAuthClient(addr string, creds credentials.TransportCredentials) (*AuthClient, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	conn, err := grpc.DialContext(
 		ctx,
 		addr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(creds),
+		grpc.WithConnectParams(reconnectParams),
 		grpc.WithBlock(),
 	)
 	if err != nil {
@@ -45,6 +53,12 @@ func NewAuthClient(addr string) (*AuthClient, error) {
 	}, nil
 }
 
+// SetReconnectFunc sets the function told while a call waits for the
+// connection to come back.
+func (c *AuthClient) SetReconnectFunc(f ReconnectFunc) {
+	c.notify = f
+}
+
 // Close shuts down the underlying gRPC connection. It is safe to call
 // even if the connection is nil (e.g., if construction failed mid-way).
 func (c *AuthClient) Close() error {
@@ -56,43 +70,40 @@ func (c *AuthClient) Close() error {
 
 // Register creates a new user account via the auth service. The 10-second
 // timeout accommodates potential password-hashing latency on the server side.
-func (c *AuthClient) Register(email, password, name string) (*userpb.RegisterResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
+func (c *AuthClient) Register(ctx context.Context, email, password, name string) (*userpb.RegisterResponse, error) {
 	req := &userpb.RegisterRequest{
 		Email:    email,
 		Password: password,
 		Name:     name,
 	}
 
-	return c.service.Register(ctx, req)
+	return invoke(ctx, c.conn, c.notify, longCallTimeout, func(ctx context.Context) (*userpb.RegisterResponse, error) {
+		return c.service.Register(ctx, req)
+	})
 }
 
 // Login authenticates existing credentials and returns a JWT token on success.
-func (c *AuthClient) Login(email, password string) (*userpb.LoginResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
+func (c *AuthClient) Login(ctx context.Context, email, password string) (*userpb.LoginResponse, error) {
 	req := &userpb.LoginRequest{
 		Email:    email,
 		Password: password,
 	}
 
-	return c.service.Login(ctx, req)
+	return invoke(ctx, c.conn, c.notify, longCallTimeout, func(ctx context.Context) (*userpb.LoginResponse, error) {
+		return c.service.Login(ctx, req)
+	})
 }
 
 // ValidateToken checks whether a JWT is still valid. This is used at TUI
 // startup to verify a persisted session token before skipping the login view.
-func (c *AuthClient) ValidateToken(token string) (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
+func (c *AuthClient) ValidateToken(ctx context.Context, token string) (bool, error) {
 	req := &userpb.ValidateTokenRequest{
 		Token: token,
 	}
 
-	resp, err := c.service.ValidateToken(ctx, req)
+	resp, err := invoke(ctx, c.conn, c.notify, shortCallTimeout, func(ctx context.Context) (*userpb.ValidateTokenResponse, error) {
+		return c.service.ValidateToken(ctx, req)
+	})
 	if err != nil {
 		return false, err
 	}
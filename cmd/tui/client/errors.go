@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Per-call timeouts. Calls that write, hash a password or list a page get
+// the longer one.
+const (
+	shortCallTimeout = 5 * time.Second
+	longCallTimeout  = 10 * time.Second
+)
+
+// ErrTimeout is returned by a call the server did not answer in time, so
+// the TUI can tell it apart from the server refusing the request.
+var ErrTimeout = errors.New("request timed out")
+
+// ErrCanceled is returned by a call whose context was cancelled, as the
+// TUI does when the user presses esc.
+var ErrCanceled = errors.New("request cancelled")
+
+// callError returns err, the error of an RPC made with ctx, as ErrTimeout
+// or ErrCanceled if the call ended for either reason, and unchanged
+// otherwise.
+func callError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled) || status.Code(err) == codes.Canceled:
+		return ErrCanceled
+	case errors.Is(ctx.Err(), context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded:
+		return ErrTimeout
+	}
+	return err
+}
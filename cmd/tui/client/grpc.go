@@ -4,9 +4,10 @@ import (
 	"context"
 	"time"
 
+	"github.com/Varun5711/shorternit/internal/shorturl"
 	pb "github.com/Varun5711/shorternit/proto/url"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/credentials"
 )
 
 // Client wraps the gRPC URLService for URL CRUD operations. It carries
@@ -18,18 +19,23 @@ type Client struct {
 	service pb.URLServiceClient
 	token   string
 	userID  string
+	baseURL string        // BASE_URL, for short URLs the service did not return
+	notify  ReconnectFunc // told while a call waits to reconnect; may be nil
 }
 
-// NewClient dials the URL service at addr with a 5-second blocking timeout.
-// See NewAuthClient for rationale on why the connection is blocking.
-func NewClient(addr string) (*Client, error) {
+// NewClient dials the URL service at addr with a 5-second blocking timeout,
+// using creds for transport security. See NewAuthClient for rationale on why
+// the connection is blocking, and for what happens when it drops. baseURL
+// is the public base URL short codes are served under.
+func NewClient(addr, baseURL string, creds credentials.TransportCredentials) (*Client, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	conn, err := grpc.DialContext(
 		ctx,
 		addr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(creds),
+		grpc.WithConnectParams(reconnectParams),
 		grpc.WithBlock(),
 	)
 	if err != nil {
@@ -39,9 +45,19 @@ func NewClient(addr string) (*Client, error) {
 	return &Client{
 		conn:    conn,
 		service: pb.NewURLServiceClient(conn),
+		baseURL: baseURL,
 	}, nil
 }
 
+// ShortURL returns served, the short URL the service returned for code, or
+// builds it from the base URL when the service left it empty.
+func (c *Client) ShortURL(served, code string) string {
+	if served != "" {
+		return served
+	}
+	return shorturl.BuildShortURL(c.baseURL, code)
+}
+
 // SetAuth stores the JWT token and user ID obtained after login or signup.
 // Subsequent RPC calls embed the userID in request payloads so the URL
 // service can enforce per-user ownership.
@@ -50,6 +66,12 @@ func (c *Client) SetAuth(token, userID string) {
 	c.userID = userID
 }
 
+// SetReconnectFunc sets the function told while a call waits for the
+// connection to come back.
+func (c *Client) SetReconnectFunc(f ReconnectFunc) {
+	c.notify = f
+}
+
 // Close shuts down the gRPC connection. Safe to call on a nil conn.
 func (c *Client) Close() error {
 	if c.conn != nil {
@@ -60,25 +82,21 @@ func (c *Client) Close() error {
 
 // CreateURL shortens a long URL using a server-generated short code.
 // The expiresAt timestamp is a Unix epoch; the TUI defaults to 3 days.
-func (c *Client) CreateURL(longURL string, expiresAt int64) (*pb.CreateURLResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
+func (c *Client) CreateURL(ctx context.Context, longURL string, expiresAt int64) (*pb.CreateURLResponse, error) {
 	req := &pb.CreateURLRequest{
 		LongUrl:   longURL,
 		UserId:    c.userID,
 		ExpiresAt: expiresAt,
 	}
 
-	return c.service.CreateURL(ctx, req)
+	return invoke(ctx, c.conn, c.notify, longCallTimeout, func(ctx context.Context) (*pb.CreateURLResponse, error) {
+		return c.service.CreateURL(ctx, req)
+	})
 }
 
 // CreateCustomURL shortens a long URL using a user-chosen alias instead
 // of a generated code. The alias is validated server-side as well.
-func (c *Client) CreateCustomURL(alias, longURL string, expiresAt int64) (*pb.CreateCustomURLResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
+func (c *Client) CreateCustomURL(ctx context.Context, alias, longURL string, expiresAt int64) (*pb.CreateCustomURLResponse, error) {
 	req := &pb.CreateCustomURLRequest{
 		Alias:     alias,
 		LongUrl:   longURL,
@@ -86,33 +104,59 @@ func (c *Client) CreateCustomURL(alias, longURL string, expiresAt int64) (*pb.Cr
 		ExpiresAt: expiresAt,
 	}
 
-	return c.service.CreateCustomURL(ctx, req)
+	return invoke(ctx, c.conn, c.notify, longCallTimeout, func(ctx context.Context) (*pb.CreateCustomURLResponse, error) {
+		return c.service.CreateCustomURL(ctx, req)
+	})
 }
 
-// ListURLs fetches a paginated list of the authenticated user's short URLs.
-// The TUI currently fetches up to 100 URLs in one call and handles
-// pagination client-side for simplicity.
-func (c *Client) ListURLs(limit, offset int32) (*pb.ListURLsResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
+// ListURLs fetches a paginated list of the authenticated user's short URLs,
+// restricted to those carrying tag when it is non-empty. The TUI currently
+// fetches up to 100 URLs in one call and handles pagination client-side for
+// simplicity.
+func (c *Client) ListURLs(ctx context.Context, limit, offset int32, tag string) (*pb.ListURLsResponse, error) {
 	req := &pb.ListURLsRequest{
 		Limit:  limit,
 		Offset: offset,
 		UserId: c.userID,
+		Tag:    tag,
 	}
 
-	return c.service.ListURLs(ctx, req)
+	return invoke(ctx, c.conn, c.notify, longCallTimeout, func(ctx context.Context) (*pb.ListURLsResponse, error) {
+		return c.service.ListURLs(ctx, req)
+	})
 }
 
-// GetURL retrieves the details of a single short URL by its code.
-func (c *Client) GetURL(shortCode string) (*pb.GetURLResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// GetTags returns the user's distinct tags with usage counts, most used
+// first. The list view cycles through them as filters.
+func (c *Client) GetTags(ctx context.Context) (*pb.GetTagsResponse, error) {
+	return invoke(ctx, c.conn, c.notify, shortCallTimeout, func(ctx context.Context) (*pb.GetTagsResponse, error) {
+		return c.service.GetTags(ctx, &pb.GetTagsRequest{UserId: c.userID})
+	})
+}
 
+// GetURL retrieves the details of a single short URL by its code.
+func (c *Client) GetURL(ctx context.Context, shortCode string) (*pb.GetURLResponse, error) {
 	req := &pb.GetURLRequest{
 		ShortCode: shortCode,
 	}
 
-	return c.service.GetURL(ctx, req)
+	return invoke(ctx, c.conn, c.notify, shortCallTimeout, func(ctx context.Context) (*pb.GetURLResponse, error) {
+		return c.service.GetURL(ctx, req)
+	})
+}
+
+// SetURLActive disables one of the user's links, or enables it again when
+// active is true. A disabled link is kept but its visitors get 410 Gone.
+func (c *Client) SetURLActive(ctx context.Context, shortCode string, active bool) (*pb.SetURLActiveResponse, error) {
+	return invoke(ctx, c.conn, c.notify, shortCallTimeout, func(ctx context.Context) (*pb.SetURLActiveResponse, error) {
+		return c.service.SetURLActive(ctx, &pb.SetURLActiveRequest{ShortCode: shortCode, UserId: c.userID, Active: active})
+	})
+}
+
+// GetURLStats retrieves the click count and timestamps of one of the user's
+// links. It reads the link's row alone, so it is cheap enough to poll.
+func (c *Client) GetURLStats(ctx context.Context, shortCode string) (*pb.GetURLStatsResponse, error) {
+	return invoke(ctx, c.conn, c.notify, shortCallTimeout, func(ctx context.Context) (*pb.GetURLStatsResponse, error) {
+		return c.service.GetURLStats(ctx, &pb.GetURLStatsRequest{ShortCode: shortCode, UserId: c.userID})
+	})
 }
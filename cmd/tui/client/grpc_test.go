@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// hangingURLService never answers ListURLs or CreateURL until the call
+// ends, as a hung server would, and refuses GetURL outright.
+type hangingURLService struct {
+	pb.UnimplementedURLServiceServer
+}
+
+func (hangingURLService) ListURLs(ctx context.Context, _ *pb.ListURLsRequest) (*pb.ListURLsResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (hangingURLService) CreateURL(ctx context.Context, _ *pb.CreateURLRequest) (*pb.CreateURLResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (hangingURLService) GetURL(context.Context, *pb.GetURLRequest) (*pb.GetURLResponse, error) {
+	return nil, status.Error(codes.NotFound, "short URL not found")
+}
+
+// newTestClient returns a Client talking to hangingURLService in-process.
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	pb.RegisterURLServiceServer(srv, hangingURLService{})
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("grpc.NewClient: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return &Client{conn: conn, service: pb.NewURLServiceClient(conn)}
+}
+
+func TestClient_CancelledContext(t *testing.T) {
+	c := newTestClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := c.ListURLs(ctx, 10, 0, "")
+	if !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the call to end on cancel, took %s", elapsed)
+	}
+
+	// A context cancelled before the call never reaches the server.
+	if _, err := c.CreateURL(ctx, "https://example.com", 0); !errors.Is(err, ErrCanceled) {
+		t.Errorf("expected ErrCanceled for an already cancelled context, got %v", err)
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	c := newTestClient(t)
+
+	// The caller's deadline applies when it is shorter than the call's own.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.CreateURL(ctx, "https://example.com", 0); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+}
+
+func TestClient_ServerErrorPassesThrough(t *testing.T) {
+	c := newTestClient(t)
+
+	_, err := c.GetURL(context.Background(), "missing")
+	if errors.Is(err, ErrTimeout) || errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected the server's error, got %v", err)
+	}
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+)
+
+// reconnectParams replace gRPC's default reconnect backoff, which grows
+// to two minutes between attempts, with one capped at a few seconds: a TUI
+// left open across a server restart or a laptop sleep should be usable
+// again soon after the server is.
+var reconnectParams = grpc.ConnectParams{
+	Backoff: backoff.Config{
+		BaseDelay:  250 * time.Millisecond,
+		Multiplier: 1.6,
+		Jitter:     0.2,
+		MaxDelay:   3 * time.Second,
+	},
+	MinConnectTimeout: 3 * time.Second,
+}
+
+// reconnectTimeout bounds how long a call waits for a dropped connection to
+// come back before it gives up with ErrUnavailable.
+const reconnectTimeout = 10 * time.Second
+
+// readySettleTime is how long waitReady lets a connection that still looks
+// ready take to notice it has dropped.
+const readySettleTime = 250 * time.Millisecond
+
+// ErrUnavailable is returned by a call that found the server unreachable
+// and could not reconnect to it in time.
+var ErrUnavailable = errors.New("server unreachable")
+
+// ReconnectFunc is told when a client starts waiting for a dropped
+// connection to come back (true) and when it stops (false), so the TUI can
+// show that it is reconnecting.
+type ReconnectFunc func(reconnecting bool)
+
+// invoke makes an RPC with ctx, giving it timeout. gRPC re-dials a dropped
+// connection in the background, but a call made meanwhile fails at once
+// with Unavailable; invoke then waits for conn to be ready again, telling
+// notify, and makes the call once more. Calls are retried whatever they
+// do: an Unavailable call almost always failed before reaching the server.
+// conn and notify may be nil, as in tests, which turns the retry off.
+func invoke[T any](ctx context.Context, conn *grpc.ClientConn, notify ReconnectFunc, timeout time.Duration, rpc func(context.Context) (T, error)) (T, error) {
+	resp, err := attempt(ctx, timeout, rpc)
+	if conn == nil || status.Code(err) != codes.Unavailable {
+		return resp, err
+	}
+
+	if notify != nil {
+		notify(true)
+	}
+	err = waitReady(ctx, conn)
+	if notify != nil {
+		notify(false)
+	}
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return attempt(ctx, timeout, rpc)
+}
+
+// attempt makes one call of rpc with timeout.
+func attempt[T any](ctx context.Context, timeout time.Duration, rpc func(context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	resp, err := rpc(ctx)
+	return resp, callError(ctx, err)
+}
+
+// waitReady asks conn to reconnect and waits up to reconnectTimeout for it
+// to be ready. It returns ErrUnavailable if it is not, and ErrCanceled or
+// ErrTimeout if parent ends first.
+func waitReady(parent context.Context, conn *grpc.ClientConn) error {
+	ctx, cancel := context.WithTimeout(parent, reconnectTimeout)
+	defer cancel()
+
+	// A call on a connection that just dropped can fail before the
+	// channel has noticed, while it still reports Ready; give that a
+	// moment to change.
+	if conn.GetState() == connectivity.Ready {
+		settle, cancelSettle := context.WithTimeout(ctx, readySettleTime)
+		conn.WaitForStateChange(settle, connectivity.Ready)
+		cancelSettle()
+	}
+
+	conn.Connect()
+	for {
+		state := conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return nil
+		case connectivity.Shutdown:
+			return ErrUnavailable
+		case connectivity.Idle:
+			conn.Connect()
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			if err := callError(parent, parent.Err()); err != nil {
+				return err
+			}
+			return ErrUnavailable
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// countingURLService answers GetURL, counting the calls that reach it.
+type countingURLService struct {
+	pb.UnimplementedURLServiceServer
+	calls atomic.Int32
+}
+
+func (s *countingURLService) GetURL(_ context.Context, req *pb.GetURLRequest) (*pb.GetURLResponse, error) {
+	s.calls.Add(1)
+	if req.ShortCode == "missing" {
+		return nil, status.Error(codes.NotFound, "short URL not found")
+	}
+	return &pb.GetURLResponse{Found: true}, nil
+}
+
+// flakyServer serves a URLService on a fixed local address that tests
+// take down and bring back, as a restarting server would.
+type flakyServer struct {
+	t       *testing.T
+	addr    string
+	service *countingURLService
+	srv     *grpc.Server
+}
+
+func newFlakyServer(t *testing.T) *flakyServer {
+	s := &flakyServer{t: t, addr: "127.0.0.1:0", service: &countingURLService{}}
+	s.start()
+	t.Cleanup(s.stop)
+	return s
+}
+
+// start serves on the server's address, the one it had before if any.
+func (s *flakyServer) start() {
+	lis, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		s.t.Fatalf("listen: %v", err)
+	}
+	s.addr = lis.Addr().String()
+	s.srv = grpc.NewServer()
+	pb.RegisterURLServiceServer(s.srv, s.service)
+	go func() { _ = s.srv.Serve(lis) }()
+}
+
+// stop drops every connection and stops listening.
+func (s *flakyServer) stop() {
+	s.srv.Stop()
+}
+
+// recordedNotify is a ReconnectFunc that records what it is told.
+type recordedNotify struct {
+	mu   sync.Mutex
+	told []bool
+}
+
+func (r *recordedNotify) notify(reconnecting bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.told = append(r.told, reconnecting)
+}
+
+func (r *recordedNotify) get() []bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]bool(nil), r.told...)
+}
+
+// newReconnectingClient returns a Client of s that reconnects as the TUI's
+// does.
+func newReconnectingClient(t *testing.T, s *flakyServer) (*Client, *recordedNotify) {
+	t.Helper()
+	conn, err := grpc.NewClient("passthrough:///"+s.addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithConnectParams(reconnectParams),
+	)
+	if err != nil {
+		t.Fatalf("grpc.NewClient: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	notified := &recordedNotify{}
+	c := &Client{conn: conn, service: pb.NewURLServiceClient(conn)}
+	c.SetReconnectFunc(notified.notify)
+	return c, notified
+}
+
+func TestClient_ReconnectsAfterServerRestart(t *testing.T) {
+	s := newFlakyServer(t)
+	c, notified := newReconnectingClient(t, s)
+	ctx := context.Background()
+
+	if _, err := c.GetURL(ctx, "abc"); err != nil {
+		t.Fatalf("expected the first call to succeed, got %v", err)
+	}
+
+	s.stop()
+	time.AfterFunc(500*time.Millisecond, s.start)
+
+	resp, err := c.GetURL(ctx, "abc")
+	if err != nil {
+		t.Fatalf("expected the call to succeed once the server was back, got %v", err)
+	}
+	if !resp.Found {
+		t.Errorf("expected the retried call's response, got %+v", resp)
+	}
+	if got := notified.get(); len(got) != 2 || !got[0] || got[1] {
+		t.Errorf("expected to be told reconnecting, then reconnected; got %v", got)
+	}
+	if calls := s.service.calls.Load(); calls != 2 {
+		t.Errorf("expected the call to reach the server once, got %d calls in all", calls)
+	}
+}
+
+func TestClient_ReconnectCancelled(t *testing.T) {
+	s := newFlakyServer(t)
+	c, notified := newReconnectingClient(t, s)
+	s.stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	if _, err := c.GetURL(ctx, "abc"); !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected ErrCanceled while reconnecting, got %v", err)
+	}
+	if got := notified.get(); len(got) != 2 || got[1] {
+		t.Errorf("expected the reconnecting state to be cleared, got %v", got)
+	}
+}
+
+func TestClient_ServerErrorNotRetried(t *testing.T) {
+	s := newFlakyServer(t)
+	c, notified := newReconnectingClient(t, s)
+
+	if _, err := c.GetURL(context.Background(), "missing"); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+	if calls := s.service.calls.Load(); calls != 1 {
+		t.Errorf("expected a refused call not to be retried, got %d calls", calls)
+	}
+	if got := notified.get(); len(got) != 0 {
+		t.Errorf("expected no reconnect, got %v", got)
+	}
+}
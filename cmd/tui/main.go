@@ -12,22 +12,37 @@ import (
 
 	"github.com/Varun5711/shorternit/cmd/tui/client"
 	"github.com/Varun5711/shorternit/cmd/tui/ui"
+	"github.com/Varun5711/shorternit/internal/config"
+	grpcClient "github.com/Varun5711/shorternit/internal/grpc"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func main() {
+	// The services' GRPC_TLS_* settings decide whether the TUI dials them
+	// over TLS, so the TUI reads the same environment (and .env).
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	creds, err := grpcClient.ClientCredentials(cfg.GRPC)
+	if err != nil {
+		fmt.Printf("Failed to load gRPC credentials: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Establish blocking gRPC connections to both backend services.
 	// The TUI requires both to be reachable before it can render any
 	// authenticated view, so we fail fast here rather than showing a
 	// broken UI.
-	grpcClient, err := client.NewClient("localhost:50051")
+	urlClient, err := client.NewClient("localhost:50051", cfg.Services.BaseURL, creds)
 	if err != nil {
 		fmt.Printf("Failed to connect to URL service: %v\n", err)
 		os.Exit(1)
 	}
-	defer grpcClient.Close()
+	defer urlClient.Close()
 
-	authClient, err := client.NewAuthClient("localhost:50052")
+	authClient, err := client.NewAuthClient("localhost:50052", creds)
 	if err != nil {
 		fmt.Printf("Failed to connect to auth service: %v\n", err)
 		os.Exit(1)
@@ -37,10 +52,16 @@ func main() {
 	// WithAltScreen switches the terminal to the alternate buffer so
 	// the user's scrollback is preserved when the TUI exits.
 	p := tea.NewProgram(
-		ui.NewModel(grpcClient, authClient),
+		ui.NewModel(urlClient, authClient),
 		tea.WithAltScreen(),
 	)
 
+	// A call that finds its connection dropped waits for it to come back;
+	// the model shows that it is reconnecting meanwhile.
+	reconnecting := func(r bool) { p.Send(ui.ReconnectingMsg(r)) }
+	urlClient.SetReconnectFunc(reconnecting)
+	authClient.SetReconnectFunc(reconnecting)
+
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
@@ -1,7 +1,9 @@
 package ui
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -35,11 +37,13 @@ type ClickEvent struct {
 
 // clickEventsSuccessMsg carries the fetched click events back to the model.
 type clickEventsSuccessMsg struct {
+	id     int
 	events []ClickEvent
 }
 
 // clickEventsErrorMsg carries an analytics fetch failure.
 type clickEventsErrorMsg struct {
+	id  int
 	err error
 }
 
@@ -53,6 +57,7 @@ type AnalyticsModel struct {
 	page    int
 	perPage int
 	loading bool
+	req     request
 	err     error
 	client  *client.Client
 	loaded  bool
@@ -79,43 +84,63 @@ func (m *AnalyticsModel) SetToken(token string) {
 	m.token = token
 }
 
+// clickEventsTimeout bounds the whole analytics fetch, reading the body
+// included.
+const clickEventsTimeout = 10 * time.Second
+
 // fetchClickEventsCmd fetches up to 50 recent click events from the
 // analytics REST endpoint. It uses the standard net/http client rather
 // than gRPC because the analytics API is exposed only over HTTP through
-// the API gateway.
-func fetchClickEventsCmd(token string) tea.Cmd {
+// the API gateway. Like the gRPC calls, it reports running out of time or
+// being cancelled through ctx as client.ErrTimeout or client.ErrCanceled.
+func fetchClickEventsCmd(ctx context.Context, id int, token string) tea.Cmd {
 	return func() tea.Msg {
-
-		req, err := http.NewRequest("GET", "http://localhost:8080/api/analytics/clicks?limit=50", nil)
-		if err != nil {
-			return clickEventsErrorMsg{err: err}
+		ctx, cancel := context.WithTimeout(ctx, clickEventsTimeout)
+		defer cancel()
+
+		events, err := fetchClickEvents(ctx, token)
+		switch {
+		case errors.Is(ctx.Err(), context.Canceled):
+			return clickEventsErrorMsg{id: id, err: client.ErrCanceled}
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			return clickEventsErrorMsg{id: id, err: client.ErrTimeout}
+		case err != nil:
+			return clickEventsErrorMsg{id: id, err: err}
 		}
+		return clickEventsSuccessMsg{id: id, events: events}
+	}
+}
 
-		req.Header.Set("Authorization", "Bearer "+token)
+// fetchClickEvents performs the request of fetchClickEventsCmd.
+func fetchClickEvents(ctx context.Context, token string) ([]ClickEvent, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", "http://localhost:8080/api/analytics/clicks?limit=50", nil)
+	if err != nil {
+		return nil, err
+	}
 
-		client := &http.Client{Timeout: 10 * time.Second}
-		resp, err := client.Do(req)
-		if err != nil {
-			return clickEventsErrorMsg{err: err}
-		}
-		defer func() { _ = resp.Body.Close() }()
+	req.Header.Set("Authorization", "Bearer "+token)
 
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			return clickEventsErrorMsg{err: fmt.Errorf("API error: %s", string(body))}
-		}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
 
-		var result struct {
-			Clicks []ClickEvent `json:"clicks"`
-			Total  int          `json:"total"`
-		}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API error: %s", string(body))
+	}
 
-		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-			return clickEventsErrorMsg{err: err}
-		}
+	var result struct {
+		Clicks []ClickEvent `json:"clicks"`
+		Total  int          `json:"total"`
+	}
 
-		return clickEventsSuccessMsg{events: result.Clicks}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
 	}
+
+	return result.Clicks, nil
 }
 
 // Init satisfies the tea.Model interface; no startup command is needed.
@@ -125,17 +150,26 @@ func (m *AnalyticsModel) Init() tea.Cmd {
 
 // Update handles analytics table navigation (up/down cursor, left/right
 // paging, r to refresh). Auto-fetches on first render when the view has
-// not yet loaded and a token is available.
+// not yet loaded and a token is available. Replies to a fetch that was
+// cancelled are ignored, and the events shown before stay.
 func (m *AnalyticsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case clickEventsSuccessMsg:
+		if !m.req.finish(msg.id) {
+			return m, nil
+		}
 		m.loading = false
 		m.events = msg.events
+		m.page = 0
+		m.cursor = 0
 		m.err = nil
 		m.loaded = true
 		return m, nil
 
 	case clickEventsErrorMsg:
+		if !m.req.finish(msg.id) {
+			return m, nil
+		}
 		m.loading = false
 		m.err = msg.err
 		m.loaded = true
@@ -167,23 +201,35 @@ func (m *AnalyticsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case "r":
 			if !m.loading && m.token != "" {
-				m.loading = true
-				m.err = nil
-				m.page = 0
-				m.cursor = 0
-				return m, fetchClickEventsCmd(m.token)
+				return m, m.fetch()
 			}
 		}
 	}
 
 	if !m.loaded && !m.loading && m.token != "" {
-		m.loading = true
-		return m, fetchClickEventsCmd(m.token)
+		return m, m.fetch()
 	}
 
 	return m, nil
 }
 
+// fetch requests the click events from the server.
+func (m *AnalyticsModel) fetch() tea.Cmd {
+	m.loading = true
+	m.err = nil
+	ctx, id := m.req.start()
+	return fetchClickEventsCmd(ctx, id, m.token)
+}
+
+// cancel cancels the fetch in flight and reports whether there was one.
+func (m *AnalyticsModel) cancel() bool {
+	if !m.req.stop() {
+		return false
+	}
+	m.loading = false
+	return true
+}
+
 // View renders the analytics data as a multi-column table with IP address,
 // short code, original URL, timestamp, location, browser, and device type.
 // The selected row is highlighted with accent styling.
@@ -203,11 +249,11 @@ func (m *AnalyticsModel) View() string {
 	if m.loading {
 		loading := lipgloss.NewStyle().
 			Foreground(Accent).
-			Render("⏳ Loading click events...")
+			Render("⏳ Loading click events...  (esc to cancel)")
 		b.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).MarginTop(2).Render(loading))
 		b.WriteString("\n")
 	} else if m.err != nil {
-		errMsg := ErrorStyle.Render("❌ " + m.err.Error())
+		errMsg := errorView("❌ ", m.err)
 		b.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).MarginTop(2).Render(errMsg))
 		b.WriteString("\n")
 	} else if len(m.events) == 0 {
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"os/exec"
@@ -15,13 +16,18 @@ import (
 )
 
 // createURLSuccessMsg is dispatched when the gRPC CreateURL or
-// CreateCustomURL RPC succeeds. It carries the generated short URL.
+// CreateCustomURL RPC succeeds. It carries the generated short URL and its
+// bare short code.
 type createURLSuccessMsg struct {
-	shortURL string
+	id        int
+	shortURL  string
+	shortCode string
 }
 
-// copySuccessMsg signals that the short URL was copied to the clipboard.
-type copySuccessMsg struct{}
+// copySuccessMsg signals that text was copied to the clipboard.
+type copySuccessMsg struct {
+	text string
+}
 
 // copyErrorMsg carries a clipboard-copy failure.
 type copyErrorMsg struct {
@@ -30,20 +36,27 @@ type copyErrorMsg struct {
 
 // createURLErrorMsg carries a URL creation failure.
 type createURLErrorMsg struct {
+	id  int
 	err error
 }
 
 // CreateModel manages the URL creation form: a long-URL input, an optional
-// custom alias input, and post-creation state (the result URL, clipboard
-// copy status). It handles both auto-generated and custom short codes by
-// branching on whether the alias field is empty.
+// custom alias input, and post-creation state (the result URL and its QR
+// code, clipboard copy status). It handles both auto-generated and custom
+// short codes by branching on whether the alias field is empty.
 type CreateModel struct {
 	urlInput     string
 	aliasInput   string
 	focusedInput int // 0 = URL input, 1 = alias input
 	loading      bool
+	req          request
 	result       string // the short URL returned after successful creation
-	copied       bool   // whether the result has been copied to clipboard
+	shortCode    string // the result's bare short code
+	qr           string // the result's QR code (see renderQR); "" if it could not be encoded
+	copiedText   string // what was last copied to the clipboard since the result; "" for nothing
+	drawsQR      bool   // whether the terminal can draw the QR code (see terminalDrawsQR)
+	height       int    // the terminal's height in lines; 0 until known
+	copy         func(text string) tea.Cmd
 	err          error
 	client       *client.Client
 }
@@ -57,6 +70,8 @@ func (m *CreateModel) Init() tea.Cmd {
 func NewCreateModel() *CreateModel {
 	return &CreateModel{
 		focusedInput: 0,
+		drawsQR:      terminalDrawsQR(),
+		copy:         copyToClipboard,
 	}
 }
 
@@ -121,7 +136,7 @@ func validateAlias(alias string) error {
 // createURLCmd returns a Bubble Tea Cmd that calls either CreateCustomURL
 // (when an alias is provided) or CreateURL (for auto-generated codes) in a
 // background goroutine. The default expiration is set to 3 days from now.
-func createURLCmd(c *client.Client, longURL, alias string) tea.Cmd {
+func createURLCmd(ctx context.Context, id int, c *client.Client, longURL, alias string) tea.Cmd {
 	return func() tea.Msg {
 		expiresAt := time.Now().Add(3 * 24 * time.Hour).Unix()
 
@@ -129,25 +144,27 @@ func createURLCmd(c *client.Client, longURL, alias string) tea.Cmd {
 		var err error
 
 		if alias != "" {
-			resp, err = c.CreateCustomURL(alias, longURL, expiresAt)
+			resp, err = c.CreateCustomURL(ctx, alias, longURL, expiresAt)
 		} else {
-			resp, err = c.CreateURL(longURL, expiresAt)
+			resp, err = c.CreateURL(ctx, longURL, expiresAt)
 		}
 
 		if err != nil {
-			return createURLErrorMsg{err: err}
+			return createURLErrorMsg{id: id, err: err}
 		}
 
-		var shortURL string
+		var shortURL, shortCode string
 		switch r := resp.(type) {
 		case *pb.CreateURLResponse:
-			shortURL = r.ShortUrl
+			shortURL, shortCode = c.ShortURL(r.ShortUrl, r.ShortCode), r.ShortCode
 		case *pb.CreateCustomURLResponse:
-			shortURL = r.ShortUrl
+			shortURL, shortCode = c.ShortURL(r.ShortUrl, r.ShortCode), r.ShortCode
 		}
 
 		return createURLSuccessMsg{
-			shortURL: shortURL,
+			id:        id,
+			shortURL:  shortURL,
+			shortCode: shortCode,
 		}
 	}
 }
@@ -191,31 +208,38 @@ func copyToClipboard(text string) tea.Cmd {
 			return copyErrorMsg{err: err}
 		}
 
-		return copySuccessMsg{}
+		return copySuccessMsg{text: text}
 	}
 }
 
 // Update handles the create-URL form interaction: Tab to switch fields,
-// Enter to validate and submit, Shift+C to copy the result, and ctrl+l
-// to clear all fields and start over.
+// Enter to validate and submit, Shift+C to copy the result, ctrl+y to copy
+// just its short code, and ctrl+l to clear all fields and start over.
 func (m *CreateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case createURLSuccessMsg:
+		if !m.req.finish(msg.id) {
+			return m, nil
+		}
 		m.loading = false
 		m.result = msg.shortURL
-		m.copied = false
+		m.shortCode = msg.shortCode
+		m.qr, _ = renderQR(msg.shortURL)
+		m.copiedText = ""
 		m.err = nil
 		return m, nil
 
 	case createURLErrorMsg:
+		if !m.req.finish(msg.id) {
+			return m, nil
+		}
 		m.loading = false
 		m.err = msg.err
-		m.result = ""
-		m.copied = false
+		m.clearResult()
 		return m, nil
 
 	case copySuccessMsg:
-		m.copied = true
+		m.copiedText = msg.text
 		return m, nil
 
 	case copyErrorMsg:
@@ -244,8 +268,9 @@ func (m *CreateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.client != nil {
 				m.loading = true
 				m.err = nil
-				m.result = ""
-				return m, createURLCmd(m.client, m.urlInput, m.aliasInput)
+				m.clearResult()
+				ctx, id := m.req.start()
+				return m, createURLCmd(ctx, id, m.client, m.urlInput, m.aliasInput)
 			} else {
 				m.err = fmt.Errorf("client not connected")
 			}
@@ -258,13 +283,16 @@ func (m *CreateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "C":
 
 			if m.result != "" {
-				return m, copyToClipboard(m.result)
+				return m, m.copy(m.result)
+			}
+		case "ctrl+y":
+			if m.shortCode != "" {
+				return m, m.copy(m.shortCode)
 			}
 		case "ctrl+l":
 			m.urlInput = ""
 			m.aliasInput = ""
-			m.result = ""
-			m.copied = false
+			m.clearResult()
 			m.err = nil
 		default:
 			if len(msg.String()) == 1 {
@@ -279,9 +307,28 @@ func (m *CreateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// clearResult forgets the link created last.
+func (m *CreateModel) clearResult() {
+	m.result = ""
+	m.shortCode = ""
+	m.qr = ""
+	m.copiedText = ""
+}
+
+// cancel cancels the creation in flight, leaving the form as it was, and
+// reports whether there was one. A link the server had already created by
+// then is kept.
+func (m *CreateModel) cancel() bool {
+	if !m.req.stop() {
+		return false
+	}
+	m.loading = false
+	return true
+}
+
 // View renders the URL creation form with the long-URL and optional alias
-// inputs, a loading spinner, the resulting short URL (with copy hint), and
-// any validation or server errors.
+// inputs, a loading spinner, the resulting short URL (with copy hint) and
+// its QR code, and any validation or server errors.
 func (m *CreateModel) View() string {
 	var b strings.Builder
 
@@ -321,11 +368,24 @@ func (m *CreateModel) View() string {
 	b.WriteString("\n\n")
 
 	if m.loading {
-		loading := InfoStyle.Render("Creating short URL...")
+		loading := InfoStyle.Render("Creating short URL...  (esc to cancel)")
 		b.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).Render(loading))
 		b.WriteString("\n")
 	}
 
+	// The error and help lines come below the result, but are rendered
+	// first so the QR code knows how much room they leave it.
+	var tail strings.Builder
+	if m.err != nil {
+		errMsg := errorView("Error: ", m.err)
+		tail.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).Render(errMsg))
+		tail.WriteString("\n")
+	}
+
+	help := InfoStyle.Render("tab switch  •  enter submit  •  ctrl+l clear  •  q back")
+	tail.WriteString("\n")
+	tail.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).Render(help))
+
 	if m.result != "" {
 
 		label := SuccessStyle.Render("✓ Short URL created:")
@@ -338,26 +398,48 @@ func (m *CreateModel) View() string {
 		b.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).Render(result))
 		b.WriteString("\n\n")
 
-		if m.copied {
-			copied := InfoStyle.Render("✓ Copied to clipboard!")
+		if m.copiedText != "" {
+			copied := InfoStyle.Render("✓ Copied " + m.copiedText + " to clipboard!")
 			b.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).Render(copied))
 			b.WriteString("\n")
 		} else {
-			copyHint := InfoStyle.Render("Shift+C to copy  •  cmd+click to open")
+			copyHint := InfoStyle.Render("Shift+C copy URL  •  ctrl+y copy code  •  cmd+click to open")
 			b.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).Render(copyHint))
 			b.WriteString("\n")
 		}
-	}
 
-	if m.err != nil {
-		errMsg := ErrorStyle.Render("Error: " + m.err.Error())
-		b.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).Render(errMsg))
-		b.WriteString("\n")
+		room := -1
+		if m.height > 0 {
+			room = m.height - createChromeLines - lipgloss.Height(b.String()+tail.String()) - 1
+		}
+		if qr := m.qrView(room); qr != "" {
+			b.WriteString("\n")
+			b.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).Render(qr))
+			b.WriteString("\n")
+		}
 	}
 
-	help := InfoStyle.Render("tab switch  •  enter submit  •  ctrl+l clear  •  q back")
-	b.WriteString("\n")
-	b.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).Render(help))
+	b.WriteString(tail.String())
 
 	return BoxStyle.Width(116).Render(b.String())
 }
+
+// createChromeLines is how many lines of the screen the create view does
+// not draw itself: its box's border, padding and margin, and the status
+// bar above it.
+const createChromeLines = 8
+
+// qrView returns the result's QR code, or a line saying why it is not
+// shown: the terminal cannot draw it, or has not the room lines it needs.
+// A negative room is unknown, and assumed to be enough.
+func (m *CreateModel) qrView(room int) string {
+	switch {
+	case m.qr == "":
+		return ""
+	case !m.drawsQR:
+		return InfoStyle.Render("QR code not shown: this terminal cannot draw it")
+	case room >= 0 && lipgloss.Height(m.qr) > room:
+		return InfoStyle.Render("QR code hidden: enlarge the terminal to show it")
+	}
+	return m.qr
+}
@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newCopyTestModel returns a CreateModel showing a created link, whose
+// clipboard copies succeed without touching the system clipboard.
+func newCopyTestModel() *CreateModel {
+	m := NewCreateModel()
+	m.copy = func(text string) tea.Cmd {
+		return func() tea.Msg { return copySuccessMsg{text: text} }
+	}
+	_, id := m.req.start()
+	m.Update(createURLSuccessMsg{id: id, shortURL: "https://tiny.io/abc123", shortCode: "abc123"})
+	return m
+}
+
+// copyWith presses key and delivers the copy it starts, returning what was
+// copied; "" if the key started none.
+func copyWith(t *testing.T, m *CreateModel, key tea.KeyMsg) string {
+	t.Helper()
+	_, cmd := m.Update(key)
+	if cmd == nil {
+		return ""
+	}
+	msg, ok := cmd().(copySuccessMsg)
+	if !ok {
+		t.Fatalf("%s: expected a copy, got %T", key, cmd())
+	}
+	m.Update(msg)
+	return msg.text
+}
+
+// TestCreateModel_CopyShortCode verifies that ctrl+y copies the bare short
+// code while Shift+C still copies the whole short URL, and that the view
+// says which was copied.
+func TestCreateModel_CopyShortCode(t *testing.T) {
+	m := newCopyTestModel()
+
+	if got := copyWith(t, m, tea.KeyMsg{Type: tea.KeyCtrlY}); got != "abc123" {
+		t.Fatalf("expected ctrl+y to copy the short code, got %q", got)
+	}
+	if !strings.Contains(m.View(), "Copied abc123 to clipboard") {
+		t.Error("expected the view to confirm the code was copied")
+	}
+
+	if got := copyWith(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("C")}); got != "https://tiny.io/abc123" {
+		t.Errorf("expected Shift+C to copy the short URL, got %q", got)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlL})
+	if got := copyWith(t, m, tea.KeyMsg{Type: tea.KeyCtrlY}); got != "" {
+		t.Errorf("expected nothing to copy once cleared, got %q", got)
+	}
+}
+
+// TestCreateModel_QRCode verifies that the created link's QR code is shown
+// when the terminal can draw it and has room, and a note otherwise.
+func TestCreateModel_QRCode(t *testing.T) {
+	m := newCopyTestModel()
+	if m.qr == "" {
+		t.Fatal("expected the short URL to be encoded")
+	}
+	if lines := strings.Split(m.qr, "\n"); len(lines) < 10 || len(lines) > 25 {
+		t.Errorf("expected a QR code of two modules per line, got %d lines", len(lines))
+	}
+
+	m.drawsQR = true
+	if got := m.qrView(-1); got != m.qr {
+		t.Error("expected the QR code with no height known")
+	}
+	if got := m.qrView(5); !strings.Contains(got, "enlarge the terminal") {
+		t.Errorf("expected a note in a short terminal, got %q", got)
+	}
+	m.drawsQR = false
+	if got := m.qrView(-1); !strings.Contains(got, "cannot draw it") {
+		t.Errorf("expected a note in a terminal that cannot draw it, got %q", got)
+	}
+}
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -17,34 +18,72 @@ type URLItem struct {
 	ShortCode string
 	ShortURL  string
 	LongURL   string
+	Title     string // destination page's title, if its preview was fetched
 	Clicks    int64
 	CreatedAt string // human-readable relative time
 	ExpiresIn string // human-readable time until expiry, or "Never"/"Expired"
+	Tags      []string
+	Disabled  bool // disabled by the user: kept, but its visitors get 410
 }
 
-// listURLsSuccessMsg carries the fetched URL list back to the ListModel.
+// listURLsSuccessMsg carries one fetched page of URLs back to the
+// ListModel, with the total number of matching URLs and the user's tags for
+// the filter (nil if they could not be fetched).
 type listURLsSuccessMsg struct {
-	urls []URLItem
+	id    int
+	urls  []URLItem
+	total int
+	tags  []string
 }
 
 // listURLsErrorMsg carries a list-fetch failure.
 type listURLsErrorMsg struct {
+	id  int
 	err error
 }
 
-// ListModel manages the paginated URL list view. It fetches all the user's
-// URLs in one gRPC call (up to 100) and paginates client-side with a
-// configurable page size (currently 3 cards per page). This avoids repeated
-// network calls when the user pages back and forth.
+// toggleActiveMsg carries the reply to disabling or enabling the link
+// shortCode: on success it is now active, or disabled.
+type toggleActiveMsg struct {
+	id        int
+	shortCode string
+	active    bool
+	err       error
+}
+
+// listPosition is the page, cursor and tag filter of the list, kept while
+// another page loads so that cancelling the load can go back to them.
+type listPosition struct {
+	page      int
+	cursor    int
+	tagFilter string
+}
+
+// ListModel manages the paginated URL list view. It pages on the server:
+// each page (currently 3 cards) is fetched with its own gRPC call using the
+// limit and offset of ListURLs, and the total the server reports tells how
+// many pages there are, so a user with thousands of links can reach all of
+// them. Pressing t cycles a tag filter through the user's tags (most used
+// first) and back to all URLs; each step refetches from the first page.
+// Pressing esc while a page loads cancels the load and returns to the page
+// shown before. Pressing a disables the selected link, or enables it again;
+// its card shows which it is.
 type ListModel struct {
-	urls    []URLItem
-	cursor  int
-	page    int
-	perPage int
-	loading bool
-	err     error
-	client  *client.Client
-	loaded  bool // prevents re-fetching when navigating back to this view
+	urls      []URLItem // the current page
+	total     int       // matching URLs on the server
+	tags      []string  // the user's tags, used as filter choices
+	tagFilter string    // active tag filter ("" = all URLs)
+	cursor    int
+	page      int
+	perPage   int
+	loading   bool
+	req       request
+	toggle    request      // disabling or enabling a link
+	toggleErr error        // why the last toggle failed, shown under the list
+	previous  listPosition // restored if the load in flight is cancelled
+	err       error
+	client    *client.Client
+	loaded    bool // prevents re-fetching when navigating back to this view
 }
 
 // Init satisfies the tea.Model interface; no startup command is needed.
@@ -76,13 +115,23 @@ func truncate(s string, maxLen int) string {
 	return s[:maxLen-3] + "..."
 }
 
-// listURLsCmd fetches the user's URLs via gRPC and transforms the protobuf
-// response into display-ready URLItem structs with human-readable timestamps.
-func listURLsCmd(c *client.Client) tea.Cmd {
+// listURLsCmd fetches page (0-based) of the user's URLs, perPage at a time
+// and only those tagged tag if set, via gRPC and transforms the protobuf
+// response into display-ready URLItem structs with human-readable
+// timestamps. The tag list is refreshed in the same command; failing to load
+// it only disables the filter.
+func listURLsCmd(ctx context.Context, id int, c *client.Client, tag string, page, perPage int) tea.Cmd {
 	return func() tea.Msg {
-		resp, err := c.ListURLs(100, 0)
+		resp, err := c.ListURLs(ctx, int32(perPage), int32(page*perPage), tag)
 		if err != nil {
-			return listURLsErrorMsg{err: err}
+			return listURLsErrorMsg{id: id, err: err}
+		}
+
+		var tags []string
+		if tagsResp, err := c.GetTags(ctx); err == nil {
+			for _, tc := range tagsResp.Tags {
+				tags = append(tags, tc.Tag)
+			}
 		}
 
 		urls := make([]URLItem, 0, len(resp.Urls))
@@ -119,82 +168,182 @@ func listURLsCmd(c *client.Client) tea.Cmd {
 
 			urls = append(urls, URLItem{
 				ShortCode: u.ShortCode,
-				ShortURL:  u.ShortUrl,
+				ShortURL:  c.ShortURL(u.ShortUrl, u.ShortCode),
 				LongURL:   u.LongUrl,
+				Title:     u.Title,
 				Clicks:    u.Clicks,
 				CreatedAt: timeStr,
 				ExpiresIn: expiresStr,
+				Tags:      u.Tags,
+				Disabled:  u.Disabled,
 			})
 		}
 
-		return listURLsSuccessMsg{urls: urls}
+		return listURLsSuccessMsg{id: id, urls: urls, total: int(resp.Total), tags: tags}
+	}
+}
+
+// setActiveCmd disables the link shortCode, or enables it again when active
+// is true.
+func setActiveCmd(ctx context.Context, id int, c *client.Client, shortCode string, active bool) tea.Cmd {
+	return func() tea.Msg {
+		_, err := c.SetURLActive(ctx, shortCode, active)
+		return toggleActiveMsg{id: id, shortCode: shortCode, active: active, err: err}
 	}
 }
 
 // Update handles list navigation (up/down to move cursor, left/right to
-// change page, r to refresh). The list auto-fetches on first render when
-// loaded is false and a client is available.
+// fetch the previous or next page, r to refresh, t to cycle the tag filter,
+// a to disable or enable the selected link).
+// Replies to a load that was cancelled or replaced are ignored.
+// The list auto-fetches on first render when loaded is false and a client is
+// available.
 func (m *ListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case listURLsSuccessMsg:
+		if !m.req.finish(msg.id) {
+			return m, nil
+		}
 		m.loading = false
+		if len(msg.urls) == 0 && m.page > 0 && msg.total > 0 {
+			// Links were deleted since the page count was worked out;
+			// show the last page that still has any.
+			m.total = msg.total
+			return m, m.fetchPage(m.totalPages() - 1)
+		}
 		m.urls = msg.urls
+		m.total = msg.total
+		m.tags = msg.tags
 		m.err = nil
 		m.loaded = true
 		return m, nil
 
+	case toggleActiveMsg:
+		if !m.toggle.finish(msg.id) {
+			return m, nil
+		}
+		m.toggleErr = msg.err
+		if msg.err == nil {
+			for i := range m.urls {
+				if m.urls[i].ShortCode == msg.shortCode {
+					m.urls[i].Disabled = !msg.active
+				}
+			}
+		}
+		return m, nil
+
 	case listURLsErrorMsg:
+		if !m.req.finish(msg.id) {
+			return m, nil
+		}
 		m.loading = false
 		m.err = msg.err
 		m.loaded = true
 		return m, nil
 
 	case tea.KeyMsg:
-		totalPages := (len(m.urls) + m.perPage - 1) / m.perPage
 		switch msg.String() {
 		case "left", "h":
-			if m.page > 0 {
-				m.page--
-				m.cursor = 0
+			if !m.loading && m.page > 0 {
+				return m, m.fetchPage(m.page - 1)
 			}
 		case "right", "l":
-			if m.page < totalPages-1 {
-				m.page++
-				m.cursor = 0
+			if !m.loading && m.page < m.totalPages()-1 {
+				return m, m.fetchPage(m.page + 1)
 			}
 		case "up", "k":
 			if m.cursor > 0 {
 				m.cursor--
 			}
 		case "down", "j":
-			start := m.page * m.perPage
-			end := min(start+m.perPage, len(m.urls))
-			pageSize := end - start
-			if m.cursor < pageSize-1 {
+			if m.cursor < len(m.urls)-1 {
 				m.cursor++
 			}
 		case "r":
 			if !m.loading {
-				m.loading = true
-				m.err = nil
-				m.page = 0
-				m.cursor = 0
-				return m, listURLsCmd(m.client)
+				return m, m.fetchPage(0)
+			}
+		case "t":
+			if !m.loading && (len(m.tags) > 0 || m.tagFilter != "") {
+				return m, m.fetch(0, nextTagFilter(m.tags, m.tagFilter))
+			}
+		case "a":
+			if !m.loading && m.cursor < len(m.urls) {
+				url := m.urls[m.cursor]
+				ctx, id := m.toggle.start()
+				return m, setActiveCmd(ctx, id, m.client, url.ShortCode, url.Disabled)
 			}
 		}
 	}
 
 	if !m.loaded && !m.loading && m.client != nil {
-		m.loading = true
-		return m, listURLsCmd(m.client)
+		return m, m.fetchPage(m.page)
 	}
 
 	return m, nil
 }
 
+// fetchPage moves to page and requests it from the server.
+func (m *ListModel) fetchPage(page int) tea.Cmd {
+	return m.fetch(page, m.tagFilter)
+}
+
+// fetch moves to page of the URLs tagged tag and requests it from the
+// server, remembering the current position for cancel.
+func (m *ListModel) fetch(page int, tag string) tea.Cmd {
+	if !m.loading {
+		m.previous = listPosition{page: m.page, cursor: m.cursor, tagFilter: m.tagFilter}
+	}
+	m.loading = true
+	m.err = nil
+	m.toggleErr = nil
+	m.page = page
+	m.cursor = 0
+	m.tagFilter = tag
+	ctx, id := m.req.start()
+	return listURLsCmd(ctx, id, m.client, tag, page, m.perPage)
+}
+
+// cancel cancels the load in flight, going back to the page shown before,
+// and reports whether there was one.
+func (m *ListModel) cancel() bool {
+	if !m.req.stop() {
+		return false
+	}
+	m.loading = false
+	m.page = m.previous.page
+	m.cursor = m.previous.cursor
+	m.tagFilter = m.previous.tagFilter
+	return true
+}
+
+// totalPages returns how many pages the server's total makes, at least 1.
+func (m *ListModel) totalPages() int {
+	return max(1, (m.total+m.perPage-1)/m.perPage)
+}
+
+// nextTagFilter returns the filter after current in the cycle
+// "" -> tags[0] -> ... -> tags[n-1] -> "". A current tag that no longer
+// exists resets the filter to all URLs.
+func nextTagFilter(tags []string, current string) string {
+	if current == "" {
+		if len(tags) == 0 {
+			return ""
+		}
+		return tags[0]
+	}
+	for i, tag := range tags {
+		if tag == current && i+1 < len(tags) {
+			return tags[i+1]
+		}
+	}
+	return ""
+}
+
 // View renders the URL list as a stack of card-style panels, each showing
-// the short URL, original URL (truncated), click count, creation time, and
-// expiry status. The currently selected card has an accent-colored border.
+// the short URL with an active or disabled badge, original URL (truncated),
+// click count, creation time, and expiry status. The currently selected card
+// has an accent-colored border.
 func (m *ListModel) View() string {
 	var b strings.Builder
 
@@ -208,14 +357,21 @@ func (m *ListModel) View() string {
 		Render(header))
 	b.WriteString("\n\n")
 
+	if m.tagFilter != "" {
+		filter := lipgloss.NewStyle().Foreground(Secondary).Render("🏷  Tag: ") +
+			lipgloss.NewStyle().Foreground(Accent).Bold(true).Render(m.tagFilter)
+		b.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).Render(filter))
+		b.WriteString("\n\n")
+	}
+
 	if m.loading {
 		loading := lipgloss.NewStyle().
 			Foreground(Accent).
-			Render("⏳ Loading URLs...")
+			Render("⏳ Loading URLs...  (esc to cancel)")
 		b.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).MarginTop(2).Render(loading))
 		b.WriteString("\n")
 	} else if m.err != nil {
-		errMsg := ErrorStyle.Render("❌ " + m.err.Error())
+		errMsg := errorView("❌ ", m.err)
 		b.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).MarginTop(2).Render(errMsg))
 		b.WriteString("\n")
 	} else if len(m.urls) == 0 {
@@ -226,15 +382,9 @@ func (m *ListModel) View() string {
 		b.WriteString("\n")
 	} else {
 
-		start := m.page * m.perPage
-		end := min(start+m.perPage, len(m.urls))
-
-		for i := start; i < end; i++ {
-			url := m.urls[i]
-			relativeIndex := i - start
-
+		for i, url := range m.urls {
 			var cardStyle lipgloss.Style
-			if relativeIndex == m.cursor {
+			if i == m.cursor {
 				cardStyle = lipgloss.NewStyle().
 					Border(lipgloss.RoundedBorder()).
 					BorderForeground(Accent).
@@ -252,7 +402,13 @@ func (m *ListModel) View() string {
 
 			shortURLLabel := lipgloss.NewStyle().Foreground(Accent).Bold(true).Render("🔗 Short URL: ")
 			shortURLValue := lipgloss.NewStyle().Foreground(Success).Render(url.ShortURL)
-			shortURLLine := shortURLLabel + shortURLValue
+			var badge string
+			if url.Disabled {
+				badge = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true).Render("  ⏸ DISABLED")
+			} else {
+				badge = lipgloss.NewStyle().Foreground(Success).Render("  ● active")
+			}
+			shortURLLine := shortURLLabel + shortURLValue + badge
 
 			longURLLabel := lipgloss.NewStyle().Foreground(Secondary).Render("📎 Original: ")
 			longURLValue := lipgloss.NewStyle().Foreground(Text).Render(truncate(url.LongURL, 50))
@@ -274,29 +430,40 @@ func (m *ListModel) View() string {
 			}
 			expiresLine := expiresLabel + expiresValue
 
-			cardContent := lipgloss.JoinVertical(lipgloss.Left,
-				shortURLLine,
-				longURLLine,
-				statsLine,
-				expiresLine,
-			)
+			lines := []string{shortURLLine}
+			if url.Title != "" {
+				titleLabel := lipgloss.NewStyle().Foreground(Secondary).Render("📰 Title: ")
+				titleValue := lipgloss.NewStyle().Foreground(Text).Bold(true).Render(truncate(url.Title, 50))
+				lines = append(lines, titleLabel+titleValue)
+			}
+			lines = append(lines, longURLLine, statsLine, expiresLine)
+			if len(url.Tags) > 0 {
+				tagsLabel := lipgloss.NewStyle().Foreground(Secondary).Render("🏷  Tags: ")
+				tagsValue := lipgloss.NewStyle().Foreground(Accent).Render(strings.Join(url.Tags, ", "))
+				lines = append(lines, tagsLabel+tagsValue)
+			}
+
+			cardContent := lipgloss.JoinVertical(lipgloss.Left, lines...)
 
 			card := cardStyle.Render(cardContent)
 			b.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).Render(card))
 		}
 
 		b.WriteString("\n")
-		totalPages := (len(m.urls) + m.perPage - 1) / m.perPage
-		if totalPages == 0 {
-			totalPages = 1
-		}
+		start := m.page * m.perPage
 		pagination := InfoStyle.Render(fmt.Sprintf("Page %d/%d  •  Showing %d-%d of %d URLs",
-			m.page+1, totalPages, start+1, end, len(m.urls)))
+			m.page+1, m.totalPages(), start+1, start+len(m.urls), m.total))
 		b.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).Render(pagination))
 	}
 
+	if m.toggleErr != nil {
+		b.WriteString("\n")
+		errMsg := errorView("❌ ", m.toggleErr)
+		b.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).Render(errMsg))
+	}
+
 	b.WriteString("\n")
-	help := InfoStyle.Render("↑/↓ navigate  •  ←/→ page  •  r refresh  •  q back")
+	help := InfoStyle.Render("↑/↓ navigate  •  ←/→ page  •  a disable/enable  •  t filter by tag  •  r refresh  •  q back")
 	b.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).Render(help))
 
 	return BoxStyle.Width(116).Render(b.String())
@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TestListModel_CancelRestoresPage verifies that cancelling a page load
+// goes back to the page shown before, and that the cancelled load's reply
+// is dropped when it arrives.
+func TestListModel_CancelRestoresPage(t *testing.T) {
+	m := NewListModel()
+	shown := []URLItem{{ShortCode: "abc"}, {ShortCode: "def"}}
+	m.fetchPage(0)
+	m.Update(listURLsSuccessMsg{id: m.req.id, urls: shown, total: 9})
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRight})
+	if !m.loading || m.page != 1 {
+		t.Fatalf("expected page 2 to be loading, got page %d, loading %v", m.page+1, m.loading)
+	}
+	stale := m.req.id
+
+	if !m.cancel() {
+		t.Fatal("expected a load to cancel")
+	}
+	if m.loading || m.page != 0 || m.cursor != 1 {
+		t.Errorf("expected page 1 with the cursor on its second URL, got page %d, cursor %d, loading %v", m.page+1, m.cursor, m.loading)
+	}
+	if m.cancel() {
+		t.Error("expected nothing left to cancel")
+	}
+
+	m.Update(listURLsSuccessMsg{id: stale, urls: []URLItem{{ShortCode: "xyz"}}, total: 9})
+	if len(m.urls) != 2 || m.urls[0].ShortCode != "abc" {
+		t.Errorf("expected the cancelled page to be dropped, got %+v", m.urls)
+	}
+}
+
+// TestListModel_ToggleActive verifies that a disables the selected link and
+// enables a disabled one, that the card's state follows the reply, and that
+// a failed toggle leaves it as it was.
+func TestListModel_ToggleActive(t *testing.T) {
+	m := NewListModel()
+	m.fetchPage(0)
+	m.Update(listURLsSuccessMsg{id: m.req.id, urls: []URLItem{{ShortCode: "abc"}, {ShortCode: "def", Disabled: true}}, total: 2})
+
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")}); cmd == nil {
+		t.Fatal("expected a toggle to start")
+	}
+	m.Update(toggleActiveMsg{id: m.toggle.id, shortCode: "abc", active: false})
+	if !m.urls[0].Disabled || !strings.Contains(m.View(), "DISABLED") {
+		t.Errorf("expected abc shown disabled, got %+v", m.urls[0])
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	m.Update(toggleActiveMsg{id: m.toggle.id, shortCode: "def", active: true, err: errors.New("boom")})
+	if !m.urls[1].Disabled || m.toggleErr == nil {
+		t.Errorf("expected def still disabled after a failed toggle, got %+v", m.urls[1])
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	m.Update(toggleActiveMsg{id: m.toggle.id, shortCode: "def", active: true})
+	if m.urls[1].Disabled || m.toggleErr != nil {
+		t.Errorf("expected def enabled, got %+v", m.urls[1])
+	}
+}
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -12,6 +13,7 @@ import (
 // loginSuccessMsg is dispatched when the gRPC Login RPC succeeds. The parent
 // Model intercepts it to update global auth state and persist the session.
 type loginSuccessMsg struct {
+	id     int
 	token  string
 	userID string
 	email  string
@@ -21,6 +23,7 @@ type loginSuccessMsg struct {
 // loginErrorMsg carries a login failure back to the LoginModel so it can
 // display the error inline without crashing.
 type loginErrorMsg struct {
+	id  int
 	err error
 }
 
@@ -34,6 +37,7 @@ type LoginModel struct {
 	passwordInput string
 	focusedInput  int // 0 = email, 1 = password
 	loading       bool
+	req           request
 	err           error
 	authClient    *client.AuthClient
 }
@@ -58,14 +62,15 @@ func (m *LoginModel) Init() tea.Cmd {
 // loginCmd returns a Bubble Tea Cmd that performs the gRPC login call in a
 // background goroutine. On completion it dispatches either loginSuccessMsg
 // or loginErrorMsg back into the Update loop.
-func loginCmd(c *client.AuthClient, email, password string) tea.Cmd {
+func loginCmd(ctx context.Context, id int, c *client.AuthClient, email, password string) tea.Cmd {
 	return func() tea.Msg {
-		resp, err := c.Login(email, password)
+		resp, err := c.Login(ctx, email, password)
 		if err != nil {
-			return loginErrorMsg{err: err}
+			return loginErrorMsg{id: id, err: err}
 		}
 
 		return loginSuccessMsg{
+			id:     id,
 			token:  resp.Token,
 			userID: resp.UserId,
 			email:  resp.Email,
@@ -86,6 +91,9 @@ func (m *LoginModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, func() tea.Msg { return msg }
 
 	case loginErrorMsg:
+		if !m.req.finish(msg.id) {
+			return m, nil
+		}
 		m.loading = false
 		m.err = msg.err
 		return m, nil
@@ -111,7 +119,8 @@ func (m *LoginModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.authClient != nil {
 				m.loading = true
 				m.err = nil
-				return m, loginCmd(m.authClient, m.emailInput, m.passwordInput)
+				ctx, id := m.req.start()
+				return m, loginCmd(ctx, id, m.authClient, m.emailInput, m.passwordInput)
 			} else {
 				m.err = fmt.Errorf("auth client not connected")
 			}
@@ -138,6 +147,16 @@ func (m *LoginModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// cancel cancels the login in flight, leaving the form as it was, and
+// reports whether there was one.
+func (m *LoginModel) cancel() bool {
+	if !m.req.stop() {
+		return false
+	}
+	m.loading = false
+	return true
+}
+
 // View renders the login form inside a rounded border with email and
 // password fields, a loading indicator, inline error display, and a
 // help bar showing available keyboard shortcuts.
@@ -193,13 +212,13 @@ func (m *LoginModel) View() string {
 	b.WriteString("\n\n")
 
 	if m.loading {
-		loading := InfoStyle.Render("🔄 Logging in...")
+		loading := InfoStyle.Render("🔄 Logging in...  (esc to cancel)")
 		b.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).Render(loading))
 		b.WriteString("\n")
 	}
 
 	if m.err != nil {
-		errMsg := ErrorStyle.Render("❌ " + m.err.Error())
+		errMsg := errorView("❌ ", m.err)
 		b.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).Render(errMsg))
 		b.WriteString("\n")
 	}
@@ -7,8 +7,9 @@
 //
 // Navigation works as a simple state machine: the currentView field
 // determines which sub-model receives updates and which View() is rendered.
-// Global key bindings (ctrl+c to quit, q to go back, ctrl+s to toggle
-// login/signup) are handled at the top level before delegation.
+// Global key bindings (ctrl+c to quit, q to go back, esc to cancel a
+// request in flight, ctrl+s to toggle login/signup) are handled at the top
+// level before delegation.
 package ui
 
 import (
@@ -87,6 +88,11 @@ func clearSession() error {
 	return os.Remove(sessionPath)
 }
 
+// ReconnectingMsg tells the model that a call has started (true) or stopped
+// (false) waiting for a dropped connection to a backend to come back. The
+// clients report it through their ReconnectFunc.
+type ReconnectingMsg bool
+
 // Model is the top-level Bubble Tea model. It owns every sub-model and
 // routes messages to the currently active view. Auth state (token, userID,
 // etc.) lives here rather than in individual views so that view transitions
@@ -103,6 +109,7 @@ type Model struct {
 	authClient  *client.AuthClient
 	width       int
 	height      int
+	reconnects  int // calls waiting for a dropped connection to come back
 	err         error
 
 	// Auth state
@@ -171,16 +178,30 @@ func (m Model) Init() tea.Cmd {
 //  1. Window resize events -- stored for responsive layout.
 //  2. Auth success messages -- bubble up from login/signup sub-models to
 //     update the top-level auth state and persist the session.
-//  3. Key events -- global shortcuts (quit, back, toggle login/signup)
-//     are intercepted before delegating to the active sub-model.
+//  3. Key events -- global shortcuts (quit, back, cancel, toggle
+//     login/signup) are intercepted before delegating to the active
+//     sub-model.
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		m.create.height = msg.Height
+		return m, nil
+
+	case ReconnectingMsg:
+		if msg {
+			m.reconnects++
+		} else {
+			m.reconnects = max(m.reconnects-1, 0)
+		}
 		return m, nil
 
 	case loginSuccessMsg:
+		if !m.login.req.finish(msg.id) {
+			return m, nil
+		}
+		m.login.loading = false
 		m.isAuthenticated = true
 		m.token = msg.token
 		m.userID = msg.userID
@@ -201,6 +222,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case signupSuccessMsg:
+		if !m.signup.req.finish(msg.id) {
+			return m, nil
+		}
+		m.signup.loading = false
 		m.isAuthenticated = true
 		m.token = msg.token
 		m.userID = msg.userID
@@ -230,9 +255,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, tea.Quit
 			}
 
+			m.cancelRequest()
 			m.currentView = MenuView
 			return m, nil
 
+		case "esc":
+			// A view that had nothing to show before its request goes
+			// back to the menu; the others go back to what they showed.
+			if m.cancelRequest() {
+				if (m.currentView == ListView && !m.list.loaded) ||
+					(m.currentView == AnalyticsView && !m.analytics.loaded) {
+					m.currentView = MenuView
+				}
+				return m, nil
+			}
+
 		case "ctrl+s":
 
 			if m.currentView == LoginView {
@@ -322,10 +359,29 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// cancelRequest cancels the request in flight of the current view and
+// reports whether there was one.
+func (m Model) cancelRequest() bool {
+	switch m.currentView {
+	case LoginView:
+		return m.login.cancel()
+	case SignupView:
+		return m.signup.cancel()
+	case CreateView:
+		return m.create.cancel()
+	case ListView:
+		return m.list.cancel()
+	case AnalyticsView:
+		return m.analytics.cancel()
+	}
+	return false
+}
+
 // View renders the active screen with an optional status bar showing the
 // logged-in user's name and email. The status bar appears on all
 // authenticated views but is hidden on login/signup to keep those screens
-// clean.
+// clean. A line above the screen tells the user while the connection to a
+// backend is being re-established.
 func (m Model) View() string {
 	// Status bar (shown when authenticated)
 	var statusBar string
@@ -363,6 +419,16 @@ func (m Model) View() string {
 		mainContent = m.analytics.View()
 	}
 
+	// While a call waits for a dropped connection, say so above the view,
+	// which still shows the call as loading.
+	if m.reconnects > 0 {
+		reconnecting := lipgloss.NewStyle().
+			Foreground(Warning).
+			Bold(true).
+			Render("⟳ Connection lost, reconnecting…")
+		mainContent = lipgloss.JoinVertical(lipgloss.Left, reconnecting, mainContent)
+	}
+
 	if statusBar != "" {
 		return lipgloss.JoinVertical(lipgloss.Left, statusBar, "\n", mainContent)
 	}
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/skip2/go-qrcode"
+)
+
+// qrStyle draws QR codes dark on light whatever the terminal's theme: most
+// scanners cannot read the inverted code a dark theme would otherwise give.
+var qrStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#000000")).
+	Background(lipgloss.Color("#FFFFFF"))
+
+// renderQR encodes text as a QR code drawn with Unicode half blocks, each
+// character holding two modules stacked, so the code is about square in a
+// monospaced font and half as tall as one drawn a module per line. The
+// code is generated here rather than decoded from the server's PNG, which
+// is only returned inline when the server keeps no QR code store; both
+// encode the same short URL.
+func renderQR(text string) (string, error) {
+	qr, err := qrcode.New(text, qrcode.Low)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate QR code: %w", err)
+	}
+	bitmap := qr.Bitmap() // includes the quiet zone scanners need
+
+	lines := make([]string, 0, (len(bitmap)+1)/2)
+	for y := 0; y < len(bitmap); y += 2 {
+		var line strings.Builder
+		for x := range bitmap[y] {
+			top := bitmap[y][x]
+			bottom := y+1 < len(bitmap) && bitmap[y+1][x]
+			switch {
+			case top && bottom:
+				line.WriteString("█")
+			case top:
+				line.WriteString("▀")
+			case bottom:
+				line.WriteString("▄")
+			default:
+				line.WriteString(" ")
+			}
+		}
+		lines = append(lines, qrStyle.Render(line.String()))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// terminalDrawsQR reports whether the terminal can show a QR code: one
+// without colors, such as TERM=dumb or output that is not a terminal,
+// could draw it inverted or not at all.
+func terminalDrawsQR() bool {
+	return lipgloss.ColorProfile() != termenv.Ascii
+}
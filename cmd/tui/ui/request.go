@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Varun5711/shorternit/cmd/tui/client"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// request tracks the one backend call a view may have in flight, so that
+// esc can cancel it and the reply to a call that was cancelled or replaced
+// is dropped instead of shown. Each reply message carries the ID its call
+// was started with.
+type request struct {
+	id     int
+	cancel context.CancelFunc
+}
+
+// start cancels the call in flight, if any, and returns the context and ID
+// of a new one.
+func (r *request) start() (context.Context, int) {
+	r.stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	r.id++
+	r.cancel = cancel
+	return ctx, r.id
+}
+
+// stop cancels the call in flight and reports whether there was one.
+func (r *request) stop() bool {
+	if r.cancel == nil {
+		return false
+	}
+	r.cancel()
+	r.cancel = nil
+	return true
+}
+
+// finish reports whether id is the call in flight, and releases it if so.
+func (r *request) finish(id int) bool {
+	if r.cancel == nil || id != r.id {
+		return false
+	}
+	r.cancel()
+	r.cancel = nil
+	return true
+}
+
+// errorView renders err after prefix. A timeout or a lost connection is
+// set apart in the warning colour, since unlike a refused request it may
+// well succeed when tried again.
+func errorView(prefix string, err error) string {
+	switch {
+	case errors.Is(err, client.ErrTimeout):
+		return lipgloss.NewStyle().Foreground(Warning).Bold(true).
+			Render("⏱  The server did not answer in time. Try again.")
+	case errors.Is(err, client.ErrUnavailable):
+		return lipgloss.NewStyle().Foreground(Warning).Bold(true).
+			Render("🔌 Cannot reach the server. Try again once it is back.")
+	}
+	return ErrorStyle.Render(prefix + err.Error())
+}
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -13,6 +14,7 @@ import (
 // The parent Model intercepts it to update auth state, persist the
 // session, and navigate to the menu view.
 type signupSuccessMsg struct {
+	id     int
 	token  string
 	userID string
 	email  string
@@ -21,6 +23,7 @@ type signupSuccessMsg struct {
 
 // signupErrorMsg carries a registration failure back to the SignupModel.
 type signupErrorMsg struct {
+	id  int
 	err error
 }
 
@@ -33,6 +36,7 @@ type SignupModel struct {
 	passwordInput string
 	focusedInput  int // 0 = name, 1 = email, 2 = password
 	loading       bool
+	req           request
 	err           error
 	authClient    *client.AuthClient
 }
@@ -56,14 +60,15 @@ func (m *SignupModel) Init() tea.Cmd {
 
 // signupCmd returns a Bubble Tea Cmd that calls the gRPC Register RPC in
 // the background and dispatches a success or error message on completion.
-func signupCmd(c *client.AuthClient, email, password, name string) tea.Cmd {
+func signupCmd(ctx context.Context, id int, c *client.AuthClient, email, password, name string) tea.Cmd {
 	return func() tea.Msg {
-		resp, err := c.Register(email, password, name)
+		resp, err := c.Register(ctx, email, password, name)
 		if err != nil {
-			return signupErrorMsg{err: err}
+			return signupErrorMsg{id: id, err: err}
 		}
 
 		return signupSuccessMsg{
+			id:     id,
 			token:  resp.Token,
 			userID: resp.UserId,
 			email:  resp.Email,
@@ -84,6 +89,9 @@ func (m *SignupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, func() tea.Msg { return msg }
 
 	case signupErrorMsg:
+		if !m.req.finish(msg.id) {
+			return m, nil
+		}
 		m.loading = false
 		m.err = msg.err
 		return m, nil
@@ -117,7 +125,8 @@ func (m *SignupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.authClient != nil {
 				m.loading = true
 				m.err = nil
-				return m, signupCmd(m.authClient, m.emailInput, m.passwordInput, m.nameInput)
+				ctx, id := m.req.start()
+				return m, signupCmd(ctx, id, m.authClient, m.emailInput, m.passwordInput, m.nameInput)
 			} else {
 				m.err = fmt.Errorf("auth client not connected")
 			}
@@ -149,6 +158,16 @@ func (m *SignupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// cancel cancels the registration in flight, leaving the form as it was, and
+// reports whether there was one.
+func (m *SignupModel) cancel() bool {
+	if !m.req.stop() {
+		return false
+	}
+	m.loading = false
+	return true
+}
+
 // View renders the signup form with name, email, and password fields,
 // a password-length hint, loading indicator, and inline error display.
 func (m *SignupModel) View() string {
@@ -219,13 +238,13 @@ func (m *SignupModel) View() string {
 	b.WriteString("\n\n")
 
 	if m.loading {
-		loading := InfoStyle.Render("🔄 Creating account...")
+		loading := InfoStyle.Render("🔄 Creating account...  (esc to cancel)")
 		b.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).Render(loading))
 		b.WriteString("\n")
 	}
 
 	if m.err != nil {
-		errMsg := ErrorStyle.Render("❌ " + m.err.Error())
+		errMsg := errorView("❌ ", m.err)
 		b.WriteString(lipgloss.NewStyle().Width(120).Align(lipgloss.Center).Render(errMsg))
 		b.WriteString("\n")
 	}
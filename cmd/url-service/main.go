@@ -17,26 +17,44 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"net"
+	"sync"
+	"time"
 
+	"github.com/Varun5711/shorternit/internal/bloom"
 	"github.com/Varun5711/shorternit/internal/cache"
 	"github.com/Varun5711/shorternit/internal/config"
 	"github.com/Varun5711/shorternit/internal/database"
+	"github.com/Varun5711/shorternit/internal/database/migrate"
 	es "github.com/Varun5711/shorternit/internal/elasticsearch"
+	grpcClient "github.com/Varun5711/shorternit/internal/grpc"
 	"github.com/Varun5711/shorternit/internal/idgen"
 	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/Varun5711/shorternit/internal/preview"
+	"github.com/Varun5711/shorternit/internal/qrcode"
+	"github.com/Varun5711/shorternit/internal/quota"
 	"github.com/Varun5711/shorternit/internal/redis"
+	"github.com/Varun5711/shorternit/internal/retry"
 	"github.com/Varun5711/shorternit/internal/service"
 	"github.com/Varun5711/shorternit/internal/storage"
 	"github.com/Varun5711/shorternit/internal/tracing"
+	"github.com/Varun5711/shorternit/migrations"
 	pb "github.com/Varun5711/shorternit/proto/url"
 	redislib "github.com/redis/go-redis/v9"
-	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/fx"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
+// shutdownTimeout bounds how long the service waits for in-flight RPCs to
+// drain on SIGTERM before forcing the gRPC server closed. It should stay
+// below the Kubernetes terminationGracePeriodSeconds (30s by default).
+const shutdownTimeout = 25 * time.Second
+
 // provideConfig loads the unified application configuration from environment
 // variables and config files.
 func provideConfig() (*config.Config, error) {
@@ -53,32 +71,113 @@ func provideLogger() *logger.Logger {
 // the L2 tier of the URL lookup cache and as the backing store for the raw
 // client used by the URL service to publish events.
 func provideRedisClient(cfg *config.Config) (*redis.RedisClient, error) {
-	return redis.NewRedisClient(context.Background(), redis.Config{
-		Addr:     cfg.Redis.Addr,
-		Password: cfg.Redis.Password,
-		DB:       cfg.Redis.DB,
+	var client *redis.RedisClient
+	err := retry.Dial(context.Background(), "Redis", cfg.Startup.ConnectAttempts, cfg.Startup.ConnectBackoff, func() (err error) {
+		client, err = redis.NewRedisClient(context.Background(), redis.Config{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		return err
 	})
+	return client, err
 }
 
 // provideDBManager sets up a PostgreSQL connection pool with primary/replica
 // topology. The primary handles writes (create, delete); replicas serve
-// read-heavy operations like listing a user's URLs.
-func provideDBManager(cfg *config.Config) (*database.DBManager, error) {
-	return database.NewDBManager(context.Background(), database.Config{
-		PrimaryDSN:      cfg.Database.PrimaryDSN,
-		ReplicaDSNs:     cfg.Database.ReplicaDSNs,
-		MaxConns:        cfg.Database.MaxConns,
-		MinConns:        cfg.Database.MinConns,
-		MaxConnLifetime: cfg.Database.MaxConnLifetime,
-		MaxConnIdleTime: cfg.Database.MaxConnIdleTime,
+// read-heavy operations like listing a user's URLs. With
+// STORAGE_BACKEND=memory it returns nil and no connection is made.
+//
+// With DB_AUTO_MIGRATE=true, pending schema migrations are applied to the
+// primary before the service starts; replicas pick them up by replication.
+// Replicas starting together are serialised by the runner's advisory lock.
+func provideDBManager(cfg *config.Config, log *logger.Logger) (*database.DBManager, error) {
+	if cfg.Database.Backend == "memory" {
+		log.Warn("STORAGE_BACKEND=memory: URLs are kept in this process only and lost on restart")
+		return nil, nil
+	}
+	var db *database.DBManager
+	err := retry.Dial(context.Background(), "PostgreSQL", cfg.Startup.ConnectAttempts, cfg.Startup.ConnectBackoff, func() (err error) {
+		db, err = database.NewDBManager(context.Background(), database.Config{
+			PrimaryDSN:      cfg.Database.PrimaryDSN,
+			ReplicaDSNs:     cfg.Database.ReplicaDSNs,
+			MaxConns:        cfg.Database.MaxConns,
+			MinConns:        cfg.Database.MinConns,
+			MaxConnLifetime: cfg.Database.MaxConnLifetime,
+			MaxConnIdleTime: cfg.Database.MaxConnIdleTime,
+			QueryTimeout:    cfg.Database.QueryTimeout,
+		})
+		return err
 	})
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Database.AutoMigrate {
+		if err := runMigrations(context.Background(), db, log); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// runMigrations brings the PostgreSQL schema up to date.
+func runMigrations(ctx context.Context, db *database.DBManager, log *logger.Logger) error {
+	all, err := migrations.Load(migrations.Postgres, "postgres")
+	if err != nil {
+		return err
+	}
+	applied, err := migrate.NewRunner(db.Primary(), log).Up(ctx, all)
+	if err != nil {
+		return err
+	}
+	log.Info("PostgreSQL schema up to date (%d migrations applied)", applied)
+	return nil
+}
+
+// provideWorkerLease leases a Snowflake worker ID in Redis when
+// SNOWFLAKE_WORKER_ID_SOURCE=redis, so replicas of one Deployment, which
+// share their configuration, still get distinct IDs. It returns nil
+// otherwise, and also when no ID could be leased, in which case the static
+// SNOWFLAKE_WORKER_ID is used instead.
+func provideWorkerLease(cfg *config.Config, rc *redislib.Client, log *logger.Logger) *idgen.WorkerLease {
+	if cfg.Snowflake.WorkerIDSource != "redis" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	lease, err := idgen.AllocateWorkerID(ctx, rc, cfg.Snowflake.DatacenterID, cfg.Snowflake.LeaseTTL)
+	if err != nil {
+		log.Warn("Could not lease a Snowflake worker ID, falling back to SNOWFLAKE_WORKER_ID=%d: %v", cfg.Snowflake.WorkerID, err)
+		return nil
+	}
+	go func() {
+		<-lease.Lost()
+		log.Error("Snowflake worker ID %d was leased by another instance; generated IDs may collide until restart", lease.ID())
+	}()
+	return lease
 }
 
 // provideIDGenerator creates a Snowflake ID generator configured with a
 // unique datacenter/worker pair. Snowflake IDs are base62-encoded to produce
 // short, URL-safe codes without requiring a centralized sequence counter.
-func provideIDGenerator(cfg *config.Config) (*idgen.Generator, error) {
-	return idgen.NewGenerator(cfg.Snowflake.DatacenterID, cfg.Snowflake.WorkerID)
+// The worker ID comes from the lease when there is one, or from the pod
+// name's ordinal with SNOWFLAKE_WORKER_ID_SOURCE=pod, and otherwise from
+// SNOWFLAKE_WORKER_ID.
+func provideIDGenerator(cfg *config.Config, lease *idgen.WorkerLease, log *logger.Logger) (*idgen.Generator, error) {
+	workerID := cfg.Snowflake.WorkerID
+	switch {
+	case lease != nil:
+		workerID = lease.ID()
+	case cfg.Snowflake.WorkerIDSource == "pod":
+		if ordinal, ok := idgen.PodOrdinal(cfg.Snowflake.PodName); ok {
+			workerID = ordinal
+		} else {
+			log.Warn("POD_NAME %q has no ordinal, falling back to SNOWFLAKE_WORKER_ID=%d", cfg.Snowflake.PodName, workerID)
+		}
+	}
+	log.Info("Snowflake datacenter %d, worker %d", cfg.Snowflake.DatacenterID, workerID)
+	return idgen.NewGenerator(cfg.Snowflake.DatacenterID, workerID)
 }
 
 // provideRawRedisClient unwraps the internal RedisClient to expose the
@@ -95,11 +194,59 @@ func provideCache(cfg *config.Config, rc *redislib.Client) *cache.Cache {
 	return cache.NewMultiTierCache(cfg.Cache.L1Capacity, rc, cfg.Cache.L2TTL)
 }
 
-// provideStorage creates the PostgreSQL-backed URL storage layer. All SQL
-// queries for URL CRUD are encapsulated here, keeping the service layer
-// free of database concerns.
-func provideStorage(db *database.DBManager) *storage.PostgresStorage {
-	return storage.NewPostgresStorage(db)
+// urlStore is what the service needs of its URL storage: the Storage
+// interface plus the code scan behind the alias filter, the active-link
+// count behind quotas and saving link previews. PostgresStorage and
+// MemoryStorage both satisfy it.
+type urlStore interface {
+	storage.Storage
+	bloom.CodeSource
+	quota.ActiveCounter
+	preview.Store
+}
+
+// provideStorage creates the URL storage layer selected by STORAGE_BACKEND:
+// PostgreSQL, where all SQL queries for URL CRUD are encapsulated, or the
+// in-process MemoryStorage. Either way the service layer stays free of
+// database concerns.
+func provideStorage(cfg *config.Config, db *database.DBManager) (urlStore, error) {
+	switch cfg.Database.Backend {
+	case "", "postgres":
+		return storage.NewPostgresStorage(db), nil
+	case "memory":
+		return storage.NewMemoryStorage(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (want postgres or memory)", cfg.Database.Backend)
+	}
+}
+
+// provideWebhookStorage creates the PostgreSQL-backed storage for per-link
+// webhooks, which the URL service exposes through the webhook RPCs. Without
+// a database it returns nil, which disables those RPCs.
+func provideWebhookStorage(db *database.DBManager) *storage.WebhookStorage {
+	if db == nil {
+		return nil
+	}
+	return storage.NewWebhookStorage(db)
+}
+
+// provideDomainStorage creates the PostgreSQL-backed storage for users'
+// custom domains, used by the domain RPCs and to validate a link's domain.
+// Without a database it returns nil, which disables custom domains.
+func provideDomainStorage(db *database.DBManager) *storage.DomainStorage {
+	if db == nil {
+		return nil
+	}
+	return storage.NewDomainStorage(db)
+}
+
+// provideWildcardStorage creates the PostgreSQL-backed storage for users'
+// wildcard aliases. Without a database it returns nil, which disables them.
+func provideWildcardStorage(db *database.DBManager) *storage.WildcardStorage {
+	if db == nil {
+		return nil
+	}
+	return storage.NewWildcardStorage(db)
 }
 
 // provideESClient optionally connects to Elasticsearch for indexing new
@@ -122,19 +269,135 @@ func provideESClient(cfg *config.Config, log *logger.Logger) *es.Client {
 	return client
 }
 
+// provideAliasFilter builds the Bloom filter of existing short codes and
+// loads it from storage before the server accepts traffic. The returned
+// Syncer keeps it current with codes created by other replicas once the
+// server is running. A failed load is not fatal: the service logs a warning
+// and runs without the filter, so every custom alias takes the slower
+// lock-and-check path instead.
+func provideAliasFilter(cfg *config.Config, store urlStore, log *logger.Logger) (*bloom.Filter, *bloom.Syncer) {
+	if !cfg.AliasFilter.Enabled {
+		return nil, nil
+	}
+
+	filter := bloom.New(cfg.AliasFilter.ExpectedItems, cfg.AliasFilter.FalsePositiveRate)
+	syncer := bloom.NewSyncer(filter, store)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	if _, err := syncer.Sync(ctx); err != nil {
+		log.Warn("Failed to load alias filter, running without it: %v", err)
+		return nil, nil
+	}
+
+	log.Info("Alias filter loaded with %d short codes", filter.Count())
+	return filter, syncer
+}
+
+// runAliasFilterSync adds the short codes created by other replicas to the
+// alias filter every interval until ctx is cancelled. A failed pass is logged
+// and retried on the next tick from the same point, so no code is missed.
+func runAliasFilterSync(ctx context.Context, syncer *bloom.Syncer, interval time.Duration, log *logger.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := syncer.Sync(ctx); err != nil && ctx.Err() == nil {
+				log.Warn("Failed to sync alias filter: %v", err)
+			}
+		}
+	}
+}
+
+// provideQRStore opens the object storage QR code images are uploaded to,
+// selected by QR_STORE. It returns nil for the default "db" backend, which
+// keeps the images inline in the urls table.
+func provideQRStore(cfg *config.Config) (qrcode.Store, error) {
+	return qrcode.NewStore(cfg.QRCode)
+}
+
+// provideQuotaEnforcer builds the per-user link quota from QUOTA_*. Plans are
+// looked up in the users table, which the url-service shares with the
+// user-service. An unparseable QUOTA_PLANS fails startup, as does setting it
+// without a database to look the plans up in.
+func provideQuotaEnforcer(cfg *config.Config, rc *redislib.Client, store urlStore, db *database.DBManager) (*quota.Enforcer, error) {
+	byPlan, err := quota.ParsePlans(cfg.Quota.Plans)
+	if err != nil {
+		return nil, err
+	}
+	var plans quota.PlanSource
+	if db != nil {
+		plans = storage.NewUserStorage(db)
+	} else if len(byPlan) > 0 {
+		return nil, fmt.Errorf("QUOTA_PLANS needs STORAGE_BACKEND=postgres")
+	}
+	defaults := quota.Limits{
+		MaxActive: int64(cfg.Quota.MaxActiveURLs),
+		Daily:     int64(cfg.Quota.DailyCreates),
+	}
+	return quota.NewEnforcer(rc, store, plans, defaults, byPlan), nil
+}
+
+// providePreviewQueue builds the background fetcher of link previews, or
+// returns nil when PREVIEW_ENABLED is off so the service makes no outbound
+// requests to link destinations.
+func providePreviewQueue(cfg *config.Config, store urlStore, log *logger.Logger) *preview.Queue {
+	if !cfg.Preview.Enabled {
+		return nil
+	}
+	fetcher := preview.NewFetcher(preview.Config{
+		Timeout:  cfg.Preview.Timeout,
+		MaxBytes: cfg.Preview.MaxBytes,
+	})
+	return preview.NewQueue(fetcher, store, cfg.Preview.Workers, 0, log)
+}
+
 // provideURLService assembles the core business logic layer. It combines
 // storage, ID generation, caching, Redis Streams (for click event
 // publishing), and Elasticsearch indexing into a single gRPC-compatible
-// service implementation.
+// service implementation. Users' own default link expiries are read from
+// PostgreSQL, so with another storage backend DEFAULT_URL_TTL applies to all.
 func provideURLService(
-	store *storage.PostgresStorage,
+	store urlStore,
 	idGen *idgen.Generator,
 	urlCache *cache.Cache,
 	rc *redislib.Client,
 	esClient *es.Client,
+	aliasFilter *bloom.Filter,
+	webhooks *storage.WebhookStorage,
+	domains *storage.DomainStorage,
+	wildcards *storage.WildcardStorage,
+	qrStore qrcode.Store,
+	quotas *quota.Enforcer,
+	previews *preview.Queue,
+	db *database.DBManager,
 	cfg *config.Config,
 ) *service.URLService {
-	return service.NewURLService(store, idGen, urlCache, rc, esClient, cfg.Services.BaseURL, cfg.Services.DefaultURLTTL)
+	var users *storage.UserStorage
+	if db != nil {
+		users = storage.NewUserStorage(db)
+	}
+	return service.NewURLService(store, idGen, urlCache, rc, service.URLServiceOptions{
+		ESClient:    esClient,
+		AliasFilter: aliasFilter,
+		Webhooks:    webhooks,
+		Domains:     domains,
+		Wildcards:   wildcards,
+		QRStore:     qrStore,
+		Quotas:      quotas,
+		Previews:    previews,
+		Users:       users,
+		BaseURL:     cfg.Services.BaseURL,
+		MinCodeLen:  cfg.Services.ShortCodeMinLength,
+		DefaultTTL:  cfg.Services.DefaultURLTTL,
+		AllowChain:  cfg.Services.AllowLinkChaining,
+		CodeTries:   cfg.Services.ShortCodeAttempts,
+	})
 }
 
 // provideTracerProvider initializes OpenTelemetry distributed tracing and
@@ -151,10 +414,28 @@ func provideTracerProvider(cfg *config.Config) (*sdktrace.TracerProvider, error)
 }
 
 // provideGRPCServer creates a gRPC server with OpenTelemetry instrumentation.
-// The otelgrpc stats handler automatically creates spans for every inbound
-// RPC and propagates trace context from the caller.
-func provideGRPCServer() *grpc.Server {
-	return grpc.NewServer(grpc.StatsHandler(otelgrpc.NewServerHandler()))
+// WaitForHandlers makes a forced Stop block until cancelled handlers have
+// returned, so shutdown never closes the pools underneath a running RPC.
+// Credentials (TLS when GRPC_TLS_ENABLED), keepalive and the otelgrpc stats
+// handler, which creates spans for every inbound RPC and propagates trace
+// context from the caller, come from grpcClient.ServerOptions.
+func provideGRPCServer(cfg *config.Config) (*grpc.Server, error) {
+	opts, err := grpcClient.ServerOptions(cfg.GRPC)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, grpc.WaitForHandlers(true))
+	return grpc.NewServer(opts...), nil
+}
+
+// provideHealthServer creates the standard gRPC health service so
+// grpc_health_probe, Kubernetes gRPC probes, and service meshes can check
+// readiness. It starts out NOT_SERVING; registerLifecycle flips it once the
+// dependency pings pass.
+func provideHealthServer() *health.Server {
+	hs := health.NewServer()
+	hs.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	return hs
 }
 
 // provideListener binds a TCP listener on port 50051, the well-known port
@@ -165,21 +446,45 @@ func provideListener() (net.Listener, error) {
 }
 
 // registerLifecycle wires the gRPC server into the FX lifecycle. On start,
-// it registers the URLService implementation and begins serving RPCs in a
-// background goroutine. On stop, it performs a graceful shutdown: the gRPC
-// server drains in-flight requests, then the tracer, Redis, and database
-// connections are closed in order.
+// it registers the URLService implementation alongside the gRPC health and
+// reflection services, begins serving RPCs in a background goroutine, and
+// starts a watcher that reports SERVING once Redis and (unless
+// STORAGE_BACKEND=memory) PostgreSQL answer pings, plus the alias filter
+// sync loop and the link preview workers when they are enabled.
+//
+// On stop (FX traps SIGINT/SIGTERM), health flips to NOT_SERVING so load
+// balancers stop routing new RPCs, then GracefulStop drains in-flight
+// requests -- including custom-alias creations that still have to release
+// their distributed lock. If draining outlives the FX stop deadline the
+// server is force-stopped with Stop, which cancels the remaining RPCs and
+// waits for their handlers to return (see provideGRPCServer). Only then are
+// the sync loop and preview workers stopped, the Snowflake worker ID lease
+// released, and the tracer, Redis, and database connections closed. The
+// tracer flush gets its own timeout because the stop context may already be
+// spent by then.
 func registerLifecycle(
 	lc fx.Lifecycle,
 	grpcServer *grpc.Server,
+	healthServer *health.Server,
 	urlService *service.URLService,
 	listener net.Listener,
 	tp *sdktrace.TracerProvider,
 	redisClient *redis.RedisClient,
 	dbManager *database.DBManager,
+	aliasSyncer *bloom.Syncer,
+	previews *preview.Queue,
+	workerLease *idgen.WorkerLease,
+	cfg *config.Config,
 	log *logger.Logger,
 ) {
 	pb.RegisterURLServiceServer(grpcServer, urlService)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	reflection.Register(grpcServer)
+
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+
+	syncCtx, cancelSync := context.WithCancel(context.Background())
+	var syncWG sync.WaitGroup
 
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
@@ -189,14 +494,59 @@ func registerLifecycle(
 					log.Error("Server error: %v", err)
 				}
 			}()
+			checks := []grpcClient.HealthCheck{redisClient.Ping}
+			if dbManager != nil {
+				checks = append(checks, func(ctx context.Context) error { return dbManager.Primary().Ping(ctx) })
+			}
+			go grpcClient.WatchHealth(healthCtx, healthServer, pb.URLService_ServiceDesc.ServiceName, 5*time.Second, log, checks...)
+			if aliasSyncer != nil {
+				syncWG.Add(1)
+				go func() {
+					defer syncWG.Done()
+					runAliasFilterSync(syncCtx, aliasSyncer, cfg.AliasFilter.SyncInterval, log)
+				}()
+			}
+			if previews != nil {
+				syncWG.Add(1)
+				go func() {
+					defer syncWG.Done()
+					previews.Run(syncCtx)
+				}()
+			}
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
 			log.Info("Shutting down url-service...")
-			grpcServer.GracefulStop()
-			_ = tracing.ShutdownTracer(ctx, tp)
+			stopHealth()
+			healthServer.Shutdown()
+
+			stopped := make(chan struct{})
+			go func() {
+				grpcServer.GracefulStop()
+				close(stopped)
+			}()
+
+			select {
+			case <-stopped:
+				log.Info("gRPC server drained")
+			case <-ctx.Done():
+				log.Warn("Graceful stop timed out, forcing shutdown")
+				grpcServer.Stop()
+			}
+
+			cancelSync()
+			syncWG.Wait()
+
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := workerLease.Release(flushCtx); err != nil {
+				log.Warn("%v", err)
+			}
+			_ = tracing.ShutdownTracer(flushCtx, tp)
 			_ = redisClient.Close()
-			dbManager.Close()
+			if dbManager != nil {
+				dbManager.Close()
+			}
 			return nil
 		},
 	})
@@ -218,15 +568,25 @@ func main() {
 			provideTracerProvider,
 			provideRedisClient,
 			provideDBManager,
-			provideIDGenerator,
 			provideRawRedisClient,
+			provideWorkerLease,
+			provideIDGenerator,
 			provideCache,
 			provideStorage,
+			provideWebhookStorage,
+			provideDomainStorage,
+			provideWildcardStorage,
+			provideQRStore,
+			provideQuotaEnforcer,
+			providePreviewQueue,
 			provideESClient,
+			provideAliasFilter,
 			provideURLService,
 			provideGRPCServer,
+			provideHealthServer,
 			provideListener,
 		),
 		fx.Invoke(registerLifecycle),
+		fx.StopTimeout(shutdownTimeout),
 	).Run()
 }
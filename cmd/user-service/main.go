@@ -17,19 +17,26 @@ import (
 	"context"
 	"net"
 	"os"
+	"time"
 
 	"github.com/Varun5711/shorternit/internal/auth"
 	"github.com/Varun5711/shorternit/internal/config"
 	"github.com/Varun5711/shorternit/internal/database"
+	grpcClient "github.com/Varun5711/shorternit/internal/grpc"
 	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/redis"
+	"github.com/Varun5711/shorternit/internal/retry"
 	"github.com/Varun5711/shorternit/internal/service"
 	"github.com/Varun5711/shorternit/internal/storage"
 	"github.com/Varun5711/shorternit/internal/tracing"
 	pb "github.com/Varun5711/shorternit/proto/user"
-	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/fx"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
 // provideConfig loads the unified application configuration from environment
@@ -48,38 +55,107 @@ func provideLogger() *logger.Logger {
 // topology. User writes (registration) go to the primary; reads (login
 // lookups, profile fetches) can be served by replicas.
 func provideDBManager(cfg *config.Config) (*database.DBManager, error) {
-	return database.NewDBManager(context.Background(), database.Config{
-		PrimaryDSN:      cfg.Database.PrimaryDSN,
-		ReplicaDSNs:     cfg.Database.ReplicaDSNs,
-		MaxConns:        cfg.Database.MaxConns,
-		MinConns:        cfg.Database.MinConns,
-		MaxConnLifetime: cfg.Database.MaxConnLifetime,
-		MaxConnIdleTime: cfg.Database.MaxConnIdleTime,
+	var db *database.DBManager
+	err := retry.Dial(context.Background(), "PostgreSQL", cfg.Startup.ConnectAttempts, cfg.Startup.ConnectBackoff, func() (err error) {
+		db, err = database.NewDBManager(context.Background(), database.Config{
+			PrimaryDSN:      cfg.Database.PrimaryDSN,
+			ReplicaDSNs:     cfg.Database.ReplicaDSNs,
+			MaxConns:        cfg.Database.MaxConns,
+			MinConns:        cfg.Database.MinConns,
+			MaxConnLifetime: cfg.Database.MaxConnLifetime,
+			MaxConnIdleTime: cfg.Database.MaxConnIdleTime,
+			QueryTimeout:    cfg.Database.QueryTimeout,
+		})
+		return err
 	})
+	return db, err
 }
 
 // provideJWTManager creates the JWT token manager used to sign and verify
 // authentication tokens. The secret is required at startup because the
 // entire auth flow depends on it; a missing secret is a fatal configuration
-// error rather than a runtime surprise.
+// error rather than a runtime surprise. A weak or placeholder secret only
+// draws a warning, and only when tokens live longer than
+// weakSecretMaxAccessTTL, so local setups keep working.
 func provideJWTManager(cfg *config.Config, log *logger.Logger) *auth.JWTManager {
 	if cfg.JWT.Secret == "" {
 		log.Fatal("JWT_SECRET must be set")
 	}
-	return auth.NewJWTManager(cfg.JWT.Secret, cfg.JWT.TokenDuration)
+	if cfg.JWT.AccessTTL <= 0 {
+		log.Fatal("JWT_ACCESS_TTL must be positive")
+	}
+	if auth.WeakSecret(cfg.JWT.Secret) && cfg.JWT.AccessTTL > weakSecretMaxAccessTTL {
+		log.Warn("JWT_SECRET is shorter than 32 bytes or a placeholder, and tokens signed with it are valid for %v (JWT_ACCESS_TTL); set a random secret before going to production", cfg.JWT.AccessTTL)
+	}
+	return auth.NewJWTManager(cfg.JWT.Secret, cfg.JWT.AccessTTL)
 }
 
+// weakSecretMaxAccessTTL is the longest access token lifetime a weak
+// JWT_SECRET is accepted with silently.
+const weakSecretMaxAccessTTL = time.Hour
+
 // provideUserStorage creates the PostgreSQL-backed user storage layer. All
 // SQL queries for user CRUD are encapsulated here.
 func provideUserStorage(db *database.DBManager) *storage.UserStorage {
 	return storage.NewUserStorage(db)
 }
 
+// provideRedisClient connects to Redis, where failed login attempts,
+// lockouts and sessions are tracked so they apply across user-service
+// replicas.
+func provideRedisClient(cfg *config.Config) (*redis.RedisClient, error) {
+	var client *redis.RedisClient
+	err := retry.Dial(context.Background(), "Redis", cfg.Startup.ConnectAttempts, cfg.Startup.ConnectBackoff, func() (err error) {
+		client, err = redis.NewRedisClient(context.Background(), redis.Config{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		return err
+	})
+	return client, err
+}
+
+// provideLoginGuard creates the lockout of repeated failed logins, with
+// thresholds from the LOGIN_* settings.
+func provideLoginGuard(cfg *config.Config, rc *redis.RedisClient) *auth.LoginGuard {
+	return auth.NewLoginGuard(rc.GetClient(), auth.LockoutPolicy{
+		MaxFailures:   cfg.Login.MaxFailures,
+		MaxIPFailures: cfg.Login.MaxIPFailures,
+		Window:        cfg.Login.FailureWindow,
+		BaseLockout:   cfg.Login.LockoutBase,
+		MaxLockout:    cfg.Login.LockoutMax,
+	})
+}
+
+// provideSessionTracker creates the record of users' sessions and the
+// per-user generations that sign them out everywhere.
+func provideSessionTracker(rc *redis.RedisClient) *auth.SessionTracker {
+	return auth.NewSessionTracker(rc.GetClient())
+}
+
+// provideGatewayTrust identifies the API gateway, whose forwarded client IP
+// the login lockout believes. Under mutual TLS the gateway is recognised by
+// its certificate (LOGIN_GATEWAY_IDENTITIES), since any pod can connect from
+// a cluster address; without it, by LOGIN_GATEWAY_ADDRS. A malformed address
+// fails startup.
+func provideGatewayTrust(cfg *config.Config) (*auth.GatewayTrust, error) {
+	if cfg.GRPC.TLSEnabled && cfg.GRPC.TLSMutual {
+		return &auth.GatewayTrust{Identities: cfg.Login.GatewayIdentities}, nil
+	}
+	prefixes, err := middleware.ParseTrustedProxies(cfg.Login.GatewayAddrs)
+	if err != nil {
+		return nil, err
+	}
+	return &auth.GatewayTrust{Prefixes: prefixes}, nil
+}
+
 // provideUserService assembles the core user business logic. It combines
 // persistent storage with JWT management to implement the Register, Login,
-// and ValidateToken RPCs defined in proto/user.
-func provideUserService(us *storage.UserStorage, jwt *auth.JWTManager) *service.UserService {
-	return service.NewUserService(us, jwt)
+// and ValidateToken RPCs defined in proto/user, with Login guarded against
+// credential stuffing and sessions revocable.
+func provideUserService(us *storage.UserStorage, jwt *auth.JWTManager, guard *auth.LoginGuard, gateways *auth.GatewayTrust, sessions *auth.SessionTracker) *service.UserService {
+	return service.NewUserService(us, jwt, guard, gateways, sessions)
 }
 
 // provideTracerProvider initializes OpenTelemetry distributed tracing and
@@ -97,10 +173,25 @@ func provideTracerProvider(cfg *config.Config) (*sdktrace.TracerProvider, error)
 }
 
 // provideGRPCServer creates a gRPC server with OpenTelemetry instrumentation.
-// The otelgrpc stats handler automatically creates spans for every inbound
-// RPC.
-func provideGRPCServer() *grpc.Server {
-	return grpc.NewServer(grpc.StatsHandler(otelgrpc.NewServerHandler()))
+// Credentials (TLS when GRPC_TLS_ENABLED), keepalive and the otelgrpc stats
+// handler, which creates spans for every inbound RPC, come from
+// grpcClient.ServerOptions.
+func provideGRPCServer(cfg *config.Config) (*grpc.Server, error) {
+	opts, err := grpcClient.ServerOptions(cfg.GRPC)
+	if err != nil {
+		return nil, err
+	}
+	return grpc.NewServer(opts...), nil
+}
+
+// provideHealthServer creates the standard gRPC health service so
+// grpc_health_probe, Kubernetes gRPC probes, and service meshes can check
+// readiness. It starts out NOT_SERVING; registerLifecycle flips it once the
+// dependency pings pass.
+func provideHealthServer() *health.Server {
+	hs := health.NewServer()
+	hs.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	return hs
 }
 
 // provideListener binds a TCP listener on the port specified by
@@ -115,19 +206,27 @@ func provideListener() (net.Listener, error) {
 }
 
 // registerLifecycle wires the gRPC server into the FX lifecycle. On start,
-// it registers the UserService implementation and serves RPCs in a
-// background goroutine. On stop, it drains in-flight RPCs via GracefulStop,
-// then shuts down tracing and the database pool.
+// it registers the UserService implementation alongside the gRPC health and
+// reflection services, serves RPCs in a background goroutine, and starts a
+// watcher that reports SERVING once PostgreSQL answers pings. On stop, health
+// flips to NOT_SERVING, in-flight RPCs drain via GracefulStop, then tracing,
+// Redis and the database pool shut down.
 func registerLifecycle(
 	lc fx.Lifecycle,
 	grpcServer *grpc.Server,
+	healthServer *health.Server,
 	userService *service.UserService,
 	listener net.Listener,
 	tp *sdktrace.TracerProvider,
 	dbManager *database.DBManager,
+	redisClient *redis.RedisClient,
 	log *logger.Logger,
 ) {
 	pb.RegisterUserServiceServer(grpcServer, userService)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	reflection.Register(grpcServer)
+
+	healthCtx, stopHealth := context.WithCancel(context.Background())
 
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
@@ -137,12 +236,18 @@ func registerLifecycle(
 					log.Error("Server error: %v", err)
 				}
 			}()
+			go grpcClient.WatchHealth(healthCtx, healthServer, pb.UserService_ServiceDesc.ServiceName, 5*time.Second, log,
+				func(ctx context.Context) error { return dbManager.Primary().Ping(ctx) },
+			)
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
 			log.Info("Shutting down user-service...")
+			stopHealth()
+			healthServer.Shutdown()
 			grpcServer.GracefulStop()
 			_ = tracing.ShutdownTracer(ctx, tp)
+			_ = redisClient.Close()
 			dbManager.Close()
 			return nil
 		},
@@ -163,10 +268,15 @@ func main() {
 			provideLogger,
 			provideTracerProvider,
 			provideDBManager,
+			provideRedisClient,
+			provideLoginGuard,
+			provideSessionTracker,
+			provideGatewayTrust,
 			provideJWTManager,
 			provideUserStorage,
 			provideUserService,
 			provideGRPCServer,
+			provideHealthServer,
 			provideListener,
 		),
 		fx.Invoke(registerLifecycle),
@@ -12,9 +12,22 @@ package analytics
 
 import (
 	"context"
+	"errors"
+	"sync/atomic"
 	"time"
 
+	"github.com/Varun5711/shorternit/internal/clock"
 	"github.com/Varun5711/shorternit/internal/database"
+	"github.com/Varun5711/shorternit/internal/timeseries"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrLinkNotFound is returned by RefreshURLStats for an unknown short code.
+	ErrLinkNotFound = errors.New("short code not found")
+	// ErrNotLinkOwner is returned by RefreshURLStats when the caller does not
+	// own the short code.
+	ErrNotLinkOwner = errors.New("you do not own this short code")
 )
 
 // Service is the analytics query layer. It holds a reference to the database
@@ -23,12 +36,42 @@ import (
 // because the database is better at aggregation and the click table can grow
 // very large.
 type Service struct {
-	db *database.DBManager
+	db         *database.DBManager
+	store      StatsStore    // computes per-link stats and looks up link owners
+	statsCache StatsCache    // short-lived cache for GetURLStats; nil disables caching
+	statsTTL   time.Duration // how long a cached URLStats entry is served
+	clock      clock.Clock   // ends open ranges and timelines; nil means the wall clock
+
+	// Stats cache lookups that were and were not served from the cache,
+	// reported by CacheStats.
+	cacheHits   atomic.Int64
+	cacheMisses atomic.Int64
 }
 
-// NewService creates an analytics Service backed by the given DBManager.
-func NewService(db *database.DBManager) *Service {
-	return &Service{db: db}
+// NewService creates an analytics Service backed by the given DBManager, with
+// per-link stats computed by store. When redisClient is non-nil and statsTTL
+// is positive, GetURLStats results are cached in Redis for statsTTL;
+// otherwise every call hits the database.
+func NewService(db *database.DBManager, store StatsStore, redisClient *redis.Client, statsTTL time.Duration) *Service {
+	s := &Service{
+		db:       db,
+		store:    store,
+		statsTTL: statsTTL,
+		clock:    clock.Real{},
+	}
+	if redisClient != nil && statsTTL > 0 {
+		s.statsCache = NewRedisStatsCache(redisClient)
+	}
+	return s
+}
+
+// now returns the current time by the service's clock, or the wall clock
+// when it has none.
+func (s *Service) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+	return s.clock.Now()
 }
 
 // URLStats holds the high-level click metrics for a single short URL.
@@ -43,59 +86,110 @@ type URLStats struct {
 	Last30Days     int64
 }
 
-// GetURLStats computes aggregate click metrics for a short code.
-// Total clicks and unique visitors come from one query; the three time-window
-// counts are fetched separately. If a time-window query fails (e.g., on a
-// fresh database with no clicks), that counter defaults to zero rather than
-// failing the entire call, because the total/unique data is still valuable.
-func (s *Service) GetURLStats(ctx context.Context, shortCode string) (*URLStats, error) {
-	conn := s.db.Read()
-
-	var stats URLStats
-	stats.ShortCode = shortCode
-
-	err := conn.QueryRow(ctx, `
-		SELECT
-			COUNT(*) as total_clicks,
-			COUNT(DISTINCT ip_address) as unique_visitors
-		FROM clicks
-		WHERE short_code = $1
-	`, shortCode).Scan(&stats.TotalClicks, &stats.UniqueVisitors)
+// GetURLStats returns aggregate click metrics for a short code, serving them
+// from the stats cache when a fresh entry exists. Passing forceRefresh skips
+// the cache lookup (but still repopulates it), so a user who just shared a
+// link can see up-to-the-second numbers on demand.
+func (s *Service) GetURLStats(ctx context.Context, shortCode string, forceRefresh bool) (*URLStats, error) {
+	key := statsCacheKey(shortCode)
+
+	if s.statsCache != nil && !forceRefresh {
+		if stats, ok := s.statsCache.Get(ctx, key); ok {
+			s.cacheHits.Add(1)
+			return stats, nil
+		}
+		s.cacheMisses.Add(1)
+	}
 
+	stats, err := s.store.URLStats(ctx, shortCode)
 	if err != nil {
 		return nil, err
 	}
 
-	now := time.Now()
+	if s.statsCache != nil {
+		s.statsCache.Set(ctx, key, stats, s.statsTTL)
+	}
 
-	// Each time-window query is independent; a failure in one should not
-	// prevent the others from populating, so errors are swallowed and the
-	// count defaults to zero.
-	err = conn.QueryRow(ctx, `
-		SELECT COUNT(*) FROM clicks
-		WHERE short_code = $1 AND clicked_at > $2
-	`, shortCode, now.Add(-24*time.Hour)).Scan(&stats.Last24Hours)
-	if err != nil {
-		stats.Last24Hours = 0
+	return stats, nil
+}
+
+// CacheStats reports how often GetURLStats was served from the stats cache
+// since the service started. Forced refreshes skip the lookup and are not
+// counted; hit_rate is 0 until there has been a lookup.
+func (s *Service) CacheStats() map[string]interface{} {
+	hits, misses := s.cacheHits.Load(), s.cacheMisses.Load()
+	hitRate := 0.0
+	if hits+misses > 0 {
+		hitRate = float64(hits) / float64(hits+misses)
+	}
+	return map[string]interface{}{
+		"enabled":  s.statsCache != nil,
+		"hits":     hits,
+		"misses":   misses,
+		"hit_rate": hitRate,
+	}
+}
+
+// RangeStats holds a short URL's clicks within one time range. Its To is
+// when the range ended, or the time of the query for an open-ended one.
+type RangeStats struct {
+	ShortCode      string     `json:"short_code"`
+	From           *time.Time `json:"from,omitempty"`
+	To             time.Time  `json:"to"`
+	Clicks         int64      `json:"clicks"`
+	UniqueVisitors int64      `json:"unique_visitors"`
+}
+
+// GetRangeStats counts the clicks and unique visitors of a short code within
+// r. Unlike GetURLStats it is not cached: ranges ending now move with every
+// request.
+func (s *Service) GetRangeStats(ctx context.Context, shortCode string, r TimeRange) (*RangeStats, error) {
+	stats := &RangeStats{ShortCode: shortCode, To: r.To}
+	if stats.To.IsZero() {
+		stats.To = s.now().UTC()
+		r.To = stats.To
+	}
+	if !r.From.IsZero() {
+		stats.From = &r.From
 	}
 
-	err = conn.QueryRow(ctx, `
-		SELECT COUNT(*) FROM clicks
-		WHERE short_code = $1 AND clicked_at > $2
-	`, shortCode, now.Add(-7*24*time.Hour)).Scan(&stats.Last7Days)
+	cond, args := r.where([]interface{}{shortCode})
+	err := s.db.Read().QueryRow(ctx, `
+		SELECT
+			COUNT(*) as clicks,
+			COUNT(DISTINCT ip_address) as unique_visitors
+		FROM clicks
+		WHERE short_code = $1`+cond,
+		args...).Scan(&stats.Clicks, &stats.UniqueVisitors)
 	if err != nil {
-		stats.Last7Days = 0
+		return nil, err
 	}
+	return stats, nil
+}
 
-	err = conn.QueryRow(ctx, `
-		SELECT COUNT(*) FROM clicks
-		WHERE short_code = $1 AND clicked_at > $2
-	`, shortCode, now.Add(-30*24*time.Hour)).Scan(&stats.Last30Days)
+// RefreshURLStats recomputes a link's stats, bypassing the cache, on behalf
+// of userID. Because every forced refresh costs a full set of aggregate
+// queries, only the link's owner may trigger one; anyone else gets
+// ErrNotLinkOwner, and an unknown code ErrLinkNotFound.
+func (s *Service) RefreshURLStats(ctx context.Context, shortCode, userID string) (*URLStats, error) {
+	owner, found, err := s.store.LinkOwner(ctx, shortCode)
 	if err != nil {
-		stats.Last30Days = 0
+		return nil, err
+	}
+	if !found {
+		return nil, ErrLinkNotFound
 	}
+	if owner == "" || owner != userID {
+		return nil, ErrNotLinkOwner
+	}
+
+	return s.GetURLStats(ctx, shortCode, true)
+}
 
-	return &stats, nil
+// LinkOwner returns the owner of shortCode, "" for an anonymous link, and
+// whether it exists.
+func (s *Service) LinkOwner(ctx context.Context, shortCode string) (string, bool, error) {
+	return s.store.LinkOwner(ctx, shortCode)
 }
 
 // TimelinePoint represents a single data point in the click timeline chart,
@@ -106,24 +200,25 @@ type TimelinePoint struct {
 }
 
 // GetClickTimeline returns daily click counts for the given short code over
-// the last N days. It uses PostgreSQL's time_bucket function (from the
-// TimescaleDB extension if available, or a compatible shim) to aggregate
+// r, which must have a From. It uses PostgreSQL's time_bucket function (from
+// the TimescaleDB extension if available, or a compatible shim) to aggregate
 // clicks into 1-day buckets. Results are ordered chronologically so the
-// frontend can render them directly as a time-series chart.
-func (s *Service) GetClickTimeline(ctx context.Context, shortCode string, days int) ([]TimelinePoint, error) {
+// frontend can render them directly as a time-series chart. Only days with
+// clicks are returned unless fill is set, in which case every day r touches
+// is, with zero clicks for the quiet ones; an open-ended r runs until now.
+func (s *Service) GetClickTimeline(ctx context.Context, shortCode string, r TimeRange, fill bool) ([]TimelinePoint, error) {
 	conn := s.db.Read()
 
-	startDate := time.Now().Add(-time.Duration(days) * 24 * time.Hour)
-
+	cond, args := r.where([]interface{}{shortCode})
 	rows, err := conn.Query(ctx, `
 		SELECT
 			time_bucket('1 day', clicked_at) as bucket,
 			COUNT(*) as clicks
 		FROM clicks
-		WHERE short_code = $1 AND clicked_at > $2
+		WHERE short_code = $1`+cond+`
 		GROUP BY bucket
 		ORDER BY bucket ASC
-	`, shortCode, startDate)
+	`, args...)
 
 	if err != nil {
 		return nil, err
@@ -138,10 +233,31 @@ func (s *Service) GetClickTimeline(ctx context.Context, shortCode string, days i
 		}
 		timeline = append(timeline, point)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	return timeline, rows.Err()
+	if fill {
+		end := s.now().UTC()
+		if !r.To.IsZero() {
+			end = r.To.Add(-time.Nanosecond) // To itself is outside r
+		}
+		timeline = timeseries.Fill(timeline, r.From, end, day,
+			func(p TimelinePoint) time.Time { return p.Timestamp },
+			func(t time.Time) TimelinePoint { return TimelinePoint{Timestamp: t} })
+	}
+	return timeline, nil
 }
 
+// LastDays returns the range of the last days days, today (UTC) included,
+// the default window of GetClickTimeline.
+func LastDays(days int, now time.Time) TimeRange {
+	return TimeRange{From: now.UTC().Truncate(day).AddDate(0, 0, 1-days)}
+}
+
+// day is the width of a timeline bucket.
+const day = 24 * time.Hour
+
 // GeoStat pairs a country name with its click count for geographic
 // breakdown charts.
 type GeoStat struct {
@@ -152,20 +268,21 @@ type GeoStat struct {
 // GetGeoStats returns the top 10 countries by click count for a short code.
 // The LIMIT 10 keeps the response compact for dashboard rendering; NULL
 // countries (clicks with no geo enrichment) are excluded to avoid a confusing
-// blank entry in the chart.
-func (s *Service) GetGeoStats(ctx context.Context, shortCode string) ([]GeoStat, error) {
+// blank entry in the chart. Only clicks within r are counted.
+func (s *Service) GetGeoStats(ctx context.Context, shortCode string, r TimeRange) ([]GeoStat, error) {
 	conn := s.db.Read()
 
+	cond, args := r.where([]interface{}{shortCode})
 	rows, err := conn.Query(ctx, `
 		SELECT
 			country,
 			COUNT(*) as clicks
 		FROM clicks
-		WHERE short_code = $1 AND country IS NOT NULL
+		WHERE short_code = $1 AND country IS NOT NULL`+cond+`
 		GROUP BY country
 		ORDER BY clicks DESC
 		LIMIT 10
-	`, shortCode)
+	`, args...)
 
 	if err != nil {
 		return nil, err
@@ -200,18 +317,19 @@ type DeviceStats struct {
 // The query groups by the device_type column populated during click
 // enrichment. Unknown device types still contribute to Total even though
 // they are not individually surfaced, ensuring Total always matches the
-// sum of all clicks.
-func (s *Service) GetDeviceStats(ctx context.Context, shortCode string) (*DeviceStats, error) {
+// sum of all clicks within r.
+func (s *Service) GetDeviceStats(ctx context.Context, shortCode string, r TimeRange) (*DeviceStats, error) {
 	conn := s.db.Read()
 
+	cond, args := r.where([]interface{}{shortCode})
 	rows, err := conn.Query(ctx, `
 		SELECT
 			device_type,
 			COUNT(*) as clicks
 		FROM clicks
-		WHERE short_code = $1
+		WHERE short_code = $1`+cond+`
 		GROUP BY device_type
-	`, shortCode)
+	`, args...)
 
 	if err != nil {
 		return nil, err
@@ -250,20 +368,21 @@ type RefererStat struct {
 // GetTopReferrers returns the highest-traffic referrer sources for a short
 // code, limited to the specified count. NULL referrers are coalesced to the
 // string "direct" so the frontend always has a displayable label. The result
-// is ordered by click count descending.
-func (s *Service) GetTopReferrers(ctx context.Context, shortCode string, limit int) ([]RefererStat, error) {
+// is ordered by click count descending. Only clicks within r are counted.
+func (s *Service) GetTopReferrers(ctx context.Context, shortCode string, limit int, r TimeRange) ([]RefererStat, error) {
 	conn := s.db.Read()
 
+	cond, args := r.where([]interface{}{shortCode, limit})
 	rows, err := conn.Query(ctx, `
 		SELECT
 			COALESCE(referer, 'direct') as referer,
 			COUNT(*) as clicks
 		FROM clicks
-		WHERE short_code = $1
+		WHERE short_code = $1`+cond+`
 		GROUP BY referer
 		ORDER BY clicks DESC
 		LIMIT $2
-	`, shortCode, limit)
+	`, args...)
 
 	if err != nil {
 		return nil, err
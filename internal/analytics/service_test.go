@@ -0,0 +1,220 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// memoryStatsCache is an in-process StatsCache that honours TTLs against a
+// controllable clock, so tests can exercise expiry without sleeping.
+type memoryStatsCache struct {
+	now     time.Time
+	entries map[string]memoryStatsEntry
+}
+
+type memoryStatsEntry struct {
+	stats     URLStats
+	expiresAt time.Time
+}
+
+func newMemoryStatsCache() *memoryStatsCache {
+	return &memoryStatsCache{
+		now:     time.Now(),
+		entries: make(map[string]memoryStatsEntry),
+	}
+}
+
+func (c *memoryStatsCache) Get(ctx context.Context, key string) (*URLStats, bool) {
+	entry, ok := c.entries[key]
+	if !ok || !c.now.Before(entry.expiresAt) {
+		return nil, false
+	}
+	stats := entry.stats
+	return &stats, true
+}
+
+func (c *memoryStatsCache) Set(ctx context.Context, key string, stats *URLStats, ttl time.Duration) {
+	c.entries[key] = memoryStatsEntry{stats: *stats, expiresAt: c.now.Add(ttl)}
+}
+
+// countingStore is a StatsStore that counts URLStats calls, returning a
+// TotalClicks value equal to the call number, and answers LinkOwner from
+// owners.
+type countingStore struct {
+	calls  int
+	owners map[string]string
+}
+
+func (c *countingStore) URLStats(ctx context.Context, shortCode string) (*URLStats, error) {
+	c.calls++
+	return &URLStats{ShortCode: shortCode, TotalClicks: int64(c.calls)}, nil
+}
+
+func (c *countingStore) LinkOwner(ctx context.Context, shortCode string) (string, bool, error) {
+	owner, ok := c.owners[shortCode]
+	return owner, ok, nil
+}
+
+// newCountingService builds a Service over a new countingStore.
+func newCountingService(cache StatsCache) (*Service, *countingStore) {
+	store := &countingStore{}
+	s := &Service{
+		store:      store,
+		statsCache: cache,
+		statsTTL:   time.Minute,
+	}
+	return s, store
+}
+
+// TestGetURLStats_CachedWithinTTL verifies that a second call inside the TTL
+// is served from the cache and does not query the database.
+func TestGetURLStats_CachedWithinTTL(t *testing.T) {
+	cache := newMemoryStatsCache()
+	s, store := newCountingService(cache)
+	ctx := context.Background()
+
+	first, err := s.GetURLStats(ctx, "abc123", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cache.now = cache.now.Add(30 * time.Second)
+
+	second, err := s.GetURLStats(ctx, "abc123", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if store.calls != 1 {
+		t.Errorf("expected 1 database query, got %d", store.calls)
+	}
+	if second.TotalClicks != first.TotalClicks {
+		t.Errorf("expected cached TotalClicks %d, got %d", first.TotalClicks, second.TotalClicks)
+	}
+}
+
+// TestGetURLStats_ExpiresAfterTTL ensures the cached entry is recomputed once
+// the TTL has elapsed.
+func TestGetURLStats_ExpiresAfterTTL(t *testing.T) {
+	cache := newMemoryStatsCache()
+	s, store := newCountingService(cache)
+	ctx := context.Background()
+
+	_, _ = s.GetURLStats(ctx, "abc123", false)
+	cache.now = cache.now.Add(61 * time.Second)
+	_, _ = s.GetURLStats(ctx, "abc123", false)
+
+	if store.calls != 2 {
+		t.Errorf("expected 2 database queries after expiry, got %d", store.calls)
+	}
+}
+
+// TestGetURLStats_ForceRefresh confirms that forceRefresh bypasses a fresh
+// cache entry and stores the recomputed value for subsequent callers.
+func TestGetURLStats_ForceRefresh(t *testing.T) {
+	cache := newMemoryStatsCache()
+	s, store := newCountingService(cache)
+	ctx := context.Background()
+
+	_, _ = s.GetURLStats(ctx, "abc123", false)
+	refreshed, _ := s.GetURLStats(ctx, "abc123", true)
+	cached, _ := s.GetURLStats(ctx, "abc123", false)
+
+	if store.calls != 2 {
+		t.Errorf("expected 2 database queries, got %d", store.calls)
+	}
+	if cached.TotalClicks != refreshed.TotalClicks {
+		t.Errorf("expected refreshed value %d to be cached, got %d", refreshed.TotalClicks, cached.TotalClicks)
+	}
+}
+
+// TestCacheStats counts lookups served from the cache and those that were
+// not, leaving forced refreshes out.
+func TestCacheStats(t *testing.T) {
+	s, _ := newCountingService(newMemoryStatsCache())
+	ctx := context.Background()
+
+	if rate := s.CacheStats()["hit_rate"]; rate != 0.0 {
+		t.Errorf("expected a hit rate of 0 before any lookup, got %v", rate)
+	}
+
+	_, _ = s.GetURLStats(ctx, "abc123", false) // miss
+	_, _ = s.GetURLStats(ctx, "abc123", false) // hit
+	_, _ = s.GetURLStats(ctx, "abc123", false) // hit
+	_, _ = s.GetURLStats(ctx, "abc123", true)  // not a lookup
+
+	stats := s.CacheStats()
+	if stats["hits"] != int64(2) || stats["misses"] != int64(1) {
+		t.Errorf("expected 2 hits and 1 miss, got %v", stats)
+	}
+	if rate := stats["hit_rate"].(float64); rate < 0.66 || rate > 0.67 {
+		t.Errorf("expected a hit rate of 2/3, got %v", rate)
+	}
+}
+
+// TestGetURLStats_KeyedByShortCode checks that different short codes do not
+// share a cache entry.
+func TestGetURLStats_KeyedByShortCode(t *testing.T) {
+	cache := newMemoryStatsCache()
+	s, store := newCountingService(cache)
+	ctx := context.Background()
+
+	_, _ = s.GetURLStats(ctx, "abc123", false)
+	other, _ := s.GetURLStats(ctx, "xyz789", false)
+
+	if store.calls != 2 {
+		t.Errorf("expected 2 database queries, got %d", store.calls)
+	}
+	if other.ShortCode != "xyz789" {
+		t.Errorf("expected short code xyz789, got %s", other.ShortCode)
+	}
+}
+
+// TestGetURLStats_NoCache verifies that a Service without a cache queries the
+// database on every call.
+func TestGetURLStats_NoCache(t *testing.T) {
+	s, store := newCountingService(nil)
+	ctx := context.Background()
+
+	_, _ = s.GetURLStats(ctx, "abc123", false)
+	_, _ = s.GetURLStats(ctx, "abc123", false)
+
+	if store.calls != 2 {
+		t.Errorf("expected 2 database queries, got %d", store.calls)
+	}
+}
+
+// TestRefreshURLStats_OwnerOnly verifies that a forced refresh is refused for
+// unknown links and non-owners, and bypasses the cache for the owner.
+func TestRefreshURLStats_OwnerOnly(t *testing.T) {
+	cache := newMemoryStatsCache()
+	s, store := newCountingService(cache)
+	store.owners = map[string]string{"mine": "alice", "anon": ""}
+	ctx := context.Background()
+
+	if _, err := s.RefreshURLStats(ctx, "missing", "alice"); !errors.Is(err, ErrLinkNotFound) {
+		t.Errorf("expected ErrLinkNotFound, got %v", err)
+	}
+	if _, err := s.RefreshURLStats(ctx, "mine", "bob"); !errors.Is(err, ErrNotLinkOwner) {
+		t.Errorf("expected ErrNotLinkOwner for another user, got %v", err)
+	}
+	if _, err := s.RefreshURLStats(ctx, "anon", ""); !errors.Is(err, ErrNotLinkOwner) {
+		t.Errorf("expected ErrNotLinkOwner for an anonymous link, got %v", err)
+	}
+	if store.calls != 0 {
+		t.Fatalf("expected no database queries for refused refreshes, got %d", store.calls)
+	}
+
+	if _, err := s.GetURLStats(ctx, "mine", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stats, err := s.RefreshURLStats(ctx, "mine", "alice")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.calls != 2 || stats.TotalClicks != 2 {
+		t.Errorf("expected the owner's refresh to bypass the cache, got %d queries", store.calls)
+	}
+}
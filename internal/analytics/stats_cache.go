@@ -0,0 +1,67 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// StatsCache memoises computed URLStats for a short time so dashboards that
+// poll the stats endpoint do not re-run the COUNT queries on every refresh.
+// Entries are never explicitly invalidated; they simply expire after the TTL,
+// which bounds how stale a cached counter can be.
+type StatsCache interface {
+	// Get returns the cached stats for key, or (nil, false) on a miss or any
+	// backend error. A cache failure must never fail the stats request.
+	Get(ctx context.Context, key string) (*URLStats, bool)
+
+	// Set stores stats under key for ttl. Errors are swallowed for the same
+	// reason as in Get.
+	Set(ctx context.Context, key string, stats *URLStats, ttl time.Duration)
+}
+
+// RedisStatsCache is the production StatsCache, storing JSON-encoded URLStats
+// in Redis with SET EX. Redis (rather than the in-process LRU) is used so all
+// gateway replicas share the same cached value and the TTL is enforced by
+// Redis itself.
+type RedisStatsCache struct {
+	client *redis.Client
+}
+
+// NewRedisStatsCache creates a RedisStatsCache on top of the shared Redis
+// connection.
+func NewRedisStatsCache(client *redis.Client) *RedisStatsCache {
+	return &RedisStatsCache{client: client}
+}
+
+// Get fetches and decodes a cached URLStats entry.
+func (c *RedisStatsCache) Get(ctx context.Context, key string) (*URLStats, bool) {
+	data, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil, false
+	}
+
+	var stats URLStats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil, false
+	}
+	return &stats, true
+}
+
+// Set encodes and stores a URLStats entry with the given TTL.
+func (c *RedisStatsCache) Set(ctx context.Context, key string, stats *URLStats, ttl time.Duration) {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return
+	}
+	_ = c.client.Set(ctx, key, data, ttl).Err()
+}
+
+// statsCacheKey builds the Redis key for a short code's stats. The "all"
+// segment names the time range covered (lifetime plus the fixed rolling
+// windows); range-scoped stats can add their own segment without colliding.
+func statsCacheKey(shortCode string) string {
+	return "analytics:stats:" + shortCode + ":all"
+}
@@ -0,0 +1,104 @@
+package analytics
+
+import (
+	"context"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clock"
+	"github.com/Varun5711/shorternit/internal/database"
+	"github.com/jackc/pgx/v5"
+)
+
+// StatsStore computes the per-link figures behind GetURLStats and
+// RefreshURLStats. It is satisfied by *PostgresStatsStore; the tests use a
+// counting in-memory store to observe how often the cache falls through.
+type StatsStore interface {
+	// URLStats computes aggregate click metrics for a short code.
+	URLStats(ctx context.Context, shortCode string) (*URLStats, error)
+
+	// LinkOwner returns the owner of a short code and whether it exists.
+	LinkOwner(ctx context.Context, shortCode string) (owner string, found bool, err error)
+}
+
+// PostgresStatsStore is the production StatsStore, querying the urls and
+// clicks tables on the read replica.
+type PostgresStatsStore struct {
+	db    *database.DBManager
+	clock clock.Clock // ends the rolling windows
+}
+
+// NewPostgresStatsStore returns a StatsStore backed by db.
+func NewPostgresStatsStore(db *database.DBManager) *PostgresStatsStore {
+	return &PostgresStatsStore{db: db, clock: clock.Real{}}
+}
+
+// LinkOwner looks up the user_id of a short code. Anonymous links have no
+// owner and come back as "".
+func (s *PostgresStatsStore) LinkOwner(ctx context.Context, shortCode string) (string, bool, error) {
+	var owner string
+	err := s.db.Read().QueryRow(ctx, `
+		SELECT COALESCE(user_id, '') FROM urls WHERE short_code = $1
+	`, shortCode).Scan(&owner)
+	if err == pgx.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return owner, true, nil
+}
+
+// URLStats computes aggregate click metrics for a short code.
+// Total clicks and unique visitors come from one query; the three time-window
+// counts are fetched separately. If a time-window query fails (e.g., on a
+// fresh database with no clicks), that counter defaults to zero rather than
+// failing the entire call, because the total/unique data is still valuable.
+func (s *PostgresStatsStore) URLStats(ctx context.Context, shortCode string) (*URLStats, error) {
+	conn := s.db.Read()
+
+	var stats URLStats
+	stats.ShortCode = shortCode
+
+	err := conn.QueryRow(ctx, `
+		SELECT
+			COUNT(*) as total_clicks,
+			COUNT(DISTINCT ip_address) as unique_visitors
+		FROM clicks
+		WHERE short_code = $1
+	`, shortCode).Scan(&stats.TotalClicks, &stats.UniqueVisitors)
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+
+	// Each time-window query is independent; a failure in one should not
+	// prevent the others from populating, so errors are swallowed and the
+	// count defaults to zero.
+	err = conn.QueryRow(ctx, `
+		SELECT COUNT(*) FROM clicks
+		WHERE short_code = $1 AND clicked_at > $2
+	`, shortCode, now.Add(-24*time.Hour)).Scan(&stats.Last24Hours)
+	if err != nil {
+		stats.Last24Hours = 0
+	}
+
+	err = conn.QueryRow(ctx, `
+		SELECT COUNT(*) FROM clicks
+		WHERE short_code = $1 AND clicked_at > $2
+	`, shortCode, now.Add(-7*24*time.Hour)).Scan(&stats.Last7Days)
+	if err != nil {
+		stats.Last7Days = 0
+	}
+
+	err = conn.QueryRow(ctx, `
+		SELECT COUNT(*) FROM clicks
+		WHERE short_code = $1 AND clicked_at > $2
+	`, shortCode, now.Add(-30*24*time.Hour)).Scan(&stats.Last30Days)
+	if err != nil {
+		stats.Last30Days = 0
+	}
+
+	return &stats, nil
+}
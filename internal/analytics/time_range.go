@@ -0,0 +1,57 @@
+package analytics
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeRange limits an analytics query to the clicks made at or after From
+// and before To. A zero From or To leaves that side open, so the zero
+// TimeRange covers every click.
+type TimeRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// IsZero reports whether r covers every click.
+func (r TimeRange) IsZero() bool {
+	return r.From.IsZero() && r.To.IsZero()
+}
+
+// namedRanges are the "recent" windows a caller can ask for by name instead
+// of working out dates, each ending now.
+var namedRanges = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+// NamedRangeNames lists the names NamedRange accepts, shortest first, for
+// error messages and documentation.
+var NamedRangeNames = []string{"1h", "24h", "7d", "30d"}
+
+// NamedRange returns the window called name that ends at now, such as the
+// last hour for "1h". Names outside NamedRangeNames are an error.
+func NamedRange(name string, now time.Time) (TimeRange, error) {
+	d, ok := namedRanges[name]
+	if !ok {
+		return TimeRange{}, fmt.Errorf("unknown range %q", name)
+	}
+	return TimeRange{From: now.Add(-d), To: now}, nil
+}
+
+// where returns the SQL conditions on clicked_at that restrict a query to
+// r, each starting with AND, with args extended by their parameters.
+func (r TimeRange) where(args []interface{}) (string, []interface{}) {
+	var cond string
+	if !r.From.IsZero() {
+		args = append(args, r.From)
+		cond += fmt.Sprintf(" AND clicked_at >= $%d", len(args))
+	}
+	if !r.To.IsZero() {
+		args = append(args, r.To)
+		cond += fmt.Sprintf(" AND clicked_at < $%d", len(args))
+	}
+	return cond, args
+}
@@ -0,0 +1,58 @@
+package analytics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestNamedRange maps each named range to the interval ending now.
+func TestNamedRange(t *testing.T) {
+	now := time.Date(2025, time.March, 10, 15, 30, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name string
+		from time.Time
+	}{
+		{"1h", time.Date(2025, time.March, 10, 14, 30, 0, 0, time.UTC)},
+		{"24h", time.Date(2025, time.March, 9, 15, 30, 0, 0, time.UTC)},
+		{"7d", time.Date(2025, time.March, 3, 15, 30, 0, 0, time.UTC)},
+		{"30d", time.Date(2025, time.February, 8, 15, 30, 0, 0, time.UTC)},
+	} {
+		r, err := NamedRange(tc.name, now)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !r.From.Equal(tc.from) || !r.To.Equal(now) {
+			t.Errorf("%s: expected %v to %v, got %v to %v", tc.name, tc.from, now, r.From, r.To)
+		}
+	}
+	if len(NamedRangeNames) != len(namedRanges) {
+		t.Errorf("expected NamedRangeNames to list all %d ranges, got %v", len(namedRanges), NamedRangeNames)
+	}
+
+	for _, name := range []string{"", "2h", "1d", "7D", "all"} {
+		if _, err := NamedRange(name, now); err == nil {
+			t.Errorf("%q: expected an error", name)
+		}
+	}
+}
+
+func TestTimeRange_Where(t *testing.T) {
+	from := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	for _, tc := range []struct {
+		r    TimeRange
+		cond string
+		args []interface{}
+	}{
+		{TimeRange{}, "", []interface{}{"abc", 10}},
+		{TimeRange{From: from}, " AND clicked_at >= $3", []interface{}{"abc", 10, from}},
+		{TimeRange{To: to}, " AND clicked_at < $3", []interface{}{"abc", 10, to}},
+		{TimeRange{From: from, To: to}, " AND clicked_at >= $3 AND clicked_at < $4", []interface{}{"abc", 10, from, to}},
+	} {
+		cond, args := tc.r.where([]interface{}{"abc", 10})
+		if cond != tc.cond || !reflect.DeepEqual(args, tc.args) {
+			t.Errorf("%+v: expected %q %v, got %q %v", tc.r, tc.cond, tc.args, cond, args)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"slices"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// GatewayTrust decides whose ClientIPMetadataKey metadata the user-service
+// believes. Any caller can set the metadata, so taking it at face value
+// would let an attacker rotate a made-up address on every attempt and never
+// reach the per-IP lockout. It is only read from an authenticated gateway:
+// a peer whose mutual-TLS client certificate names one of Identities, or
+// failing mTLS, a peer connecting from one of Prefixes. Every other caller
+// is identified by the address of its own connection.
+type GatewayTrust struct {
+	Identities []string       // Certificate common names or DNS SANs of the gateway.
+	Prefixes   []netip.Prefix // Addresses the gateway connects from.
+}
+
+// ClientIP returns the address a login attempt counts against: the one the
+// gateway forwarded if the caller is a trusted gateway, otherwise the
+// caller's own. It returns "" when neither is known, such as for a call
+// made without a network connection.
+func (g *GatewayTrust) ClientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	peerIP := peerAddr(p)
+	own := ""
+	if peerIP.IsValid() {
+		own = peerIP.String()
+	}
+
+	if g == nil || !g.trusts(p, peerIP) {
+		return own
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(ClientIPMetadataKey); len(values) > 0 {
+			if forwarded, err := netip.ParseAddr(values[0]); err == nil {
+				return forwarded.Unmap().String()
+			}
+		}
+	}
+	return own
+}
+
+// trusts reports whether p is an authenticated gateway.
+func (g *GatewayTrust) trusts(p *peer.Peer, peerIP netip.Addr) bool {
+	if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.VerifiedChains) > 0 {
+		leaf := tlsInfo.State.VerifiedChains[0][0]
+		for _, name := range append([]string{leaf.Subject.CommonName}, leaf.DNSNames...) {
+			if name != "" && slices.Contains(g.Identities, name) {
+				return true
+			}
+		}
+	}
+	for _, prefix := range g.Prefixes {
+		if prefix.Contains(peerIP) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerAddr returns the IP of p's connection, or the zero Addr if it has
+// none (such as an in-process connection).
+func peerAddr(p *peer.Peer) netip.Addr {
+	if p.Addr == nil {
+		return netip.Addr{}
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
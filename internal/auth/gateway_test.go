@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/netip"
+	"testing"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// loginCall returns the context of a Login call from addr, carrying authInfo
+// and, if forwarded is non-empty, the client IP the gateway forwards.
+func loginCall(addr string, authInfo credentials.AuthInfo, forwarded string) context.Context {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr:     &net.TCPAddr{IP: net.ParseIP(addr), Port: 50000},
+		AuthInfo: authInfo,
+	})
+	if forwarded != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(ClientIPMetadataKey, forwarded))
+	}
+	return ctx
+}
+
+// verifiedClient is the AuthInfo of an mTLS peer whose verified certificate
+// has the given common name.
+func verifiedClient(commonName string) credentials.TLSInfo {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+	return credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}}
+}
+
+func TestGatewayTrust_IgnoresMetadataFromOtherCallers(t *testing.T) {
+	g := &GatewayTrust{
+		Identities: []string{"api-gateway"},
+		Prefixes:   []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+	}
+
+	// A caller outside the allow-list cannot pick the address it is
+	// locked out as.
+	if got := g.ClientIP(loginCall("203.0.113.5", nil, "198.51.100.1")); got != "203.0.113.5" {
+		t.Errorf("expected the caller's own address, got %q", got)
+	}
+	// Nor can an mTLS client with another service's certificate.
+	if got := g.ClientIP(loginCall("203.0.113.5", verifiedClient("url-service"), "198.51.100.1")); got != "203.0.113.5" {
+		t.Errorf("expected the caller's own address for another identity, got %q", got)
+	}
+	// With no GatewayTrust at all, nobody is believed.
+	var none *GatewayTrust
+	if got := none.ClientIP(loginCall("10.1.2.3", nil, "198.51.100.1")); got != "10.1.2.3" {
+		t.Errorf("expected the caller's own address without a GatewayTrust, got %q", got)
+	}
+}
+
+func TestGatewayTrust_BelievesTheGateway(t *testing.T) {
+	g := &GatewayTrust{
+		Identities: []string{"api-gateway"},
+		Prefixes:   []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+	}
+
+	if got := g.ClientIP(loginCall("10.1.2.3", nil, "198.51.100.1")); got != "198.51.100.1" {
+		t.Errorf("expected the address forwarded from an allow-listed peer, got %q", got)
+	}
+	if got := g.ClientIP(loginCall("203.0.113.5", verifiedClient("api-gateway"), "198.51.100.1")); got != "198.51.100.1" {
+		t.Errorf("expected the address forwarded by the gateway's certificate, got %q", got)
+	}
+	// A gateway that forwards nothing usable is counted by its own address.
+	if got := g.ClientIP(loginCall("10.1.2.3", nil, "not-an-ip")); got != "10.1.2.3" {
+		t.Errorf("expected the peer address for a malformed forwarded IP, got %q", got)
+	}
+}
+
+func TestGatewayTrust_NoPeer(t *testing.T) {
+	g := &GatewayTrust{Prefixes: []netip.Prefix{netip.MustParsePrefix("0.0.0.0/0")}}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(ClientIPMetadataKey, "198.51.100.1"))
+	if got := g.ClientIP(ctx); got != "" {
+		t.Errorf("expected no address without a peer, got %q", got)
+	}
+}
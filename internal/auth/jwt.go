@@ -6,15 +6,18 @@ package auth
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/Varun5711/shorternit/internal/clock"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // Claims represents the custom JWT payload embedded in every access token.
 // It extends the standard RegisteredClaims with application-specific fields
-// for user identification. The UserID and Email are extracted from the token
-// on each authenticated request to avoid a database lookup per request.
+// for user identification. The UserID, Email and Role are extracted from the
+// token on each authenticated request to avoid a database lookup per request.
 type Claims struct {
 	// UserID is the unique identifier for the authenticated user.
 	UserID string `json:"user_id"`
@@ -23,6 +26,19 @@ type Claims struct {
 	// downstream handlers can personalize responses without a DB query.
 	Email string `json:"email"`
 
+	// Role is the user's role when the token was issued, so the gateway
+	// can authorize role-gated routes without a database lookup. Tokens
+	// issued before roles existed carry none and are treated as "user".
+	// A role change only reaches the claim at the next login, so actions
+	// that must see it sooner re-check the database.
+	Role string `json:"role,omitempty"`
+
+	// Generation is the user's session generation when the token was
+	// issued. Signing out everywhere bumps the generation, and a token
+	// from an earlier one is refused; see SessionTracker. Tokens issued
+	// before sessions were tracked carry 0.
+	Generation int64 `json:"gen,omitempty"`
+
 	// RegisteredClaims embeds standard JWT fields: ExpiresAt, IssuedAt,
 	// Issuer, Subject, etc. The jwt/v5 library automatically validates
 	// the expiration time during parsing.
@@ -40,49 +56,91 @@ type JWTManager struct {
 	// the JWT_SECRET environment variable.
 	secretKey string
 
-	// tokenDuration controls how long generated tokens remain valid.
-	// After this duration the token's ExpiresAt claim will be in the past
-	// and ValidateToken will reject it.
+	// tokenDuration controls how long generated access tokens remain
+	// valid. After this duration the token's ExpiresAt claim will be in
+	// the past and ValidateToken will reject it.
 	tokenDuration time.Duration
+
+	// clock stamps issued tokens and is the time their expiry is checked
+	// against.
+	clock clock.Clock
 }
 
-// NewJWTManager creates a JWTManager with the given signing key and token
-// lifetime. The secretKey must be kept confidential; if it is leaked,
+// NewJWTManager creates a JWTManager with the given signing key and access
+// token lifetime. The secretKey must be kept confidential; if it is leaked,
 // attackers can forge valid tokens for any user.
-func NewJWTManager(secretKey string, tokenDuration time.Duration) *JWTManager {
+func NewJWTManager(secretKey string, accessTTL time.Duration) *JWTManager {
 	return &JWTManager{
 		secretKey:     secretKey,
-		tokenDuration: tokenDuration,
+		tokenDuration: accessTTL,
+		clock:         clock.Real{},
 	}
 }
 
-// GenerateToken creates a signed JWT containing the given user ID and email.
+// AccessTTL returns how long the tokens GenerateToken issues are valid.
+func (m *JWTManager) AccessTTL() time.Duration {
+	return m.tokenDuration
+}
+
+// minSecretLength is the shortest secret WeakSecret accepts: 32 bytes, the
+// output size of the HMAC-SHA256 the tokens are signed with.
+const minSecretLength = 32
+
+// WeakSecret reports whether secret is too short to resist brute force or
+// is a placeholder left from an example configuration, such as the
+// "CHANGE_THIS_..." value in the Kubernetes manifests. A token signed with
+// such a secret can be forged, and the longer tokens live the longer a
+// forged one is useful.
+func WeakSecret(secret string) bool {
+	upper := strings.ToUpper(secret)
+	return len(secret) < minSecretLength ||
+		strings.Contains(upper, "CHANGE_THIS") ||
+		strings.Contains(upper, "CHANGEME")
+}
+
+// GenerateToken creates a signed JWT containing the given user ID, email and
+// role.
 // It returns the compact serialized token string, the expiration timestamp
 // (useful for setting cookie MaxAge or returning in API responses), and any
-// signing error.
+// signing error. The token is issued at session generation 0; IssueToken
+// issues one at the user's current generation.
 //
 // The token uses HS256 (HMAC-SHA256), which is a symmetric algorithm: the
 // same secret is used for signing and verification. This is fast and avoids
 // the complexity of RSA/ECDSA key management.
-func (m *JWTManager) GenerateToken(userID, email string) (string, time.Time, error) {
-	expiresAt := time.Now().Add(m.tokenDuration)
+func (m *JWTManager) GenerateToken(userID, email, role string) (string, time.Time, error) {
+	token, claims, err := m.IssueToken(userID, email, role, 0)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, claims.ExpiresAt.Time, nil
+}
 
-	claims := Claims{
-		UserID: userID,
-		Email:  email,
+// IssueToken is GenerateToken for a user at session generation generation.
+// It returns the signed claims as well as the token, so the caller can
+// record the session under its ID (the "jti" claim).
+func (m *JWTManager) IssueToken(userID, email, role string, generation int64) (string, *Claims, error) {
+	now := m.clock.Now()
+
+	claims := &Claims{
+		UserID:     userID,
+		Email:      email,
+		Role:       role,
+		Generation: generation,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expiresAt),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(m.tokenDuration)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString([]byte(m.secretKey))
 	if err != nil {
-		return "", time.Time{}, fmt.Errorf("failed to sign token: %w", err)
+		return "", nil, fmt.Errorf("failed to sign token: %w", err)
 	}
 
-	return tokenString, expiresAt, nil
+	return tokenString, claims, nil
 }
 
 // ValidateToken parses and validates a JWT string, returning the embedded
@@ -105,7 +163,7 @@ func (m *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return []byte(m.secretKey), nil
-	})
+	}, jwt.WithTimeFunc(m.clock.Now))
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -3,10 +3,21 @@ package auth
 import (
 	"testing"
 	"time"
+
+	"github.com/Varun5711/shorternit/internal/clock/clocktest"
 )
 
+// newClockedJWTManager returns a JWTManager reading the time from a fake
+// clock, and the clock.
+func newClockedJWTManager(accessTTL time.Duration) (*JWTManager, *clocktest.Fake) {
+	clk := clocktest.NewFake(time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC))
+	manager := NewJWTManager("test-secret-key", accessTTL)
+	manager.clock = clk
+	return manager, clk
+}
+
 // TestNewJWTManager verifies that the constructor correctly stores the secret
-// key and token duration in the returned manager instance.
+// key and access token lifetime in the returned manager instance.
 func TestNewJWTManager(t *testing.T) {
 	manager := NewJWTManager("test-secret", time.Hour)
 
@@ -16,18 +27,71 @@ func TestNewJWTManager(t *testing.T) {
 	if manager.secretKey != "test-secret" {
 		t.Errorf("expected secretKey 'test-secret', got '%s'", manager.secretKey)
 	}
-	if manager.tokenDuration != time.Hour {
-		t.Errorf("expected tokenDuration 1h, got %v", manager.tokenDuration)
+	if manager.AccessTTL() != time.Hour {
+		t.Errorf("expected access TTL 1h, got %v", manager.AccessTTL())
+	}
+}
+
+// TestGenerateToken_ExpiresAtAccessTTL verifies that a token's exp claim,
+// and the expiry GenerateToken reports, are the configured access TTL after
+// it was issued, and that it is rejected once that has passed.
+func TestGenerateToken_ExpiresAtAccessTTL(t *testing.T) {
+	for _, ttl := range []time.Duration{15 * time.Minute, time.Hour, 24 * time.Hour} {
+		manager := NewJWTManager("test-secret-key", ttl)
+
+		token, expiresAt, err := manager.GenerateToken("user-123", "test@example.com", "user")
+		if err != nil {
+			t.Fatalf("%v: failed to generate token: %v", ttl, err)
+		}
+		claims, err := manager.ValidateToken(token)
+		if err != nil {
+			t.Fatalf("%v: unexpected error validating token: %v", ttl, err)
+		}
+		if got := claims.ExpiresAt.Sub(claims.IssuedAt.Time); got != ttl {
+			t.Errorf("%v: expected exp %v after iat, got %v", ttl, ttl, got)
+		}
+		if !claims.ExpiresAt.Equal(expiresAt.Truncate(time.Second)) {
+			t.Errorf("%v: expected exp %v to match the reported expiry %v", ttl, claims.ExpiresAt.Time, expiresAt)
+		}
+	}
+
+	manager, clk := newClockedJWTManager(time.Hour)
+	token, _, err := manager.GenerateToken("user-123", "test@example.com", "user")
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	clk.Advance(time.Hour - time.Second)
+	if _, err := manager.ValidateToken(token); err != nil {
+		t.Errorf("expected a token within its access TTL to validate, got %v", err)
+	}
+	clk.Advance(time.Second)
+	if _, err := manager.ValidateToken(token); err == nil {
+		t.Error("expected a token past its access TTL to be rejected")
+	}
+}
+
+func TestWeakSecret(t *testing.T) {
+	for _, tc := range []struct {
+		secret string
+		weak   bool
+	}{
+		{"secret", true},
+		{"CHANGE_THIS_TO_A_SECURE_64_CHAR_SECRET_KEY_GENERATED_WITH_OPENSSL", true},
+		{"changeme-changeme-changeme-changeme", true},
+		{"3q9ZbK1v8pYx0LrT7mWc2HnF5dJs4GeA", false},
+	} {
+		if got := WeakSecret(tc.secret); got != tc.weak {
+			t.Errorf("WeakSecret(%q): expected %v, got %v", tc.secret, tc.weak, got)
+		}
 	}
 }
 
 // TestGenerateToken verifies that GenerateToken produces a non-empty token
-// string and an expiration time that is approximately tokenDuration from now
-// (with a 1-minute tolerance to account for test execution time).
+// string and an expiration time exactly tokenDuration from now.
 func TestGenerateToken(t *testing.T) {
-	manager := NewJWTManager("test-secret-key", time.Hour)
+	manager, clk := newClockedJWTManager(time.Hour)
 
-	token, expiresAt, err := manager.GenerateToken("user-123", "test@example.com")
+	token, expiresAt, err := manager.GenerateToken("user-123", "test@example.com", "user")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -36,9 +100,8 @@ func TestGenerateToken(t *testing.T) {
 		t.Error("expected non-empty token")
 	}
 
-	expectedExpiry := time.Now().Add(time.Hour)
-	if expiresAt.Before(expectedExpiry.Add(-time.Minute)) || expiresAt.After(expectedExpiry.Add(time.Minute)) {
-		t.Errorf("expiry time not within expected range")
+	if want := clk.Now().Add(time.Hour); !expiresAt.Equal(want) {
+		t.Errorf("expected expiry %v, got %v", want, expiresAt)
 	}
 }
 
@@ -47,7 +110,7 @@ func TestGenerateToken(t *testing.T) {
 func TestValidateToken_Valid(t *testing.T) {
 	manager := NewJWTManager("test-secret-key", time.Hour)
 
-	token, _, err := manager.GenerateToken("user-123", "test@example.com")
+	token, _, err := manager.GenerateToken("user-123", "test@example.com", "user")
 	if err != nil {
 		t.Fatalf("failed to generate token: %v", err)
 	}
@@ -65,16 +128,48 @@ func TestValidateToken_Valid(t *testing.T) {
 	}
 }
 
+// TestValidateToken_CarriesRole verifies that the role the token was issued
+// with comes back from validation, and that a token issued without one
+// (as before roles existed) validates with an empty role.
+func TestValidateToken_CarriesRole(t *testing.T) {
+	manager := NewJWTManager("test-secret-key", time.Hour)
+
+	token, _, err := manager.GenerateToken("admin-1", "admin@example.com", "admin")
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	claims, err := manager.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("unexpected error validating token: %v", err)
+	}
+	if claims.Role != "admin" {
+		t.Errorf("expected Role 'admin', got '%s'", claims.Role)
+	}
+
+	token, _, err = manager.GenerateToken("user-123", "test@example.com", "")
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	claims, err = manager.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("unexpected error validating token: %v", err)
+	}
+	if claims.Role != "" {
+		t.Errorf("expected no role, got '%s'", claims.Role)
+	}
+}
+
 // TestValidateToken_Expired verifies that tokens past their expiration time
-// are rejected. A negative duration (-1h) is used to create an already-expired
-// token without needing to manipulate the system clock.
+// are rejected. The manager's fake clock is moved past the token's expiry,
+// so no wall time needs to pass.
 func TestValidateToken_Expired(t *testing.T) {
-	manager := NewJWTManager("test-secret-key", -time.Hour)
+	manager, clk := newClockedJWTManager(time.Hour)
 
-	token, _, err := manager.GenerateToken("user-123", "test@example.com")
+	token, _, err := manager.GenerateToken("user-123", "test@example.com", "user")
 	if err != nil {
 		t.Fatalf("failed to generate token: %v", err)
 	}
+	clk.Advance(2 * time.Hour)
 
 	_, err = manager.ValidateToken(token)
 	if err == nil {
@@ -89,7 +184,7 @@ func TestValidateToken_InvalidSignature(t *testing.T) {
 	manager1 := NewJWTManager("secret-key-1", time.Hour)
 	manager2 := NewJWTManager("secret-key-2", time.Hour)
 
-	token, _, err := manager1.GenerateToken("user-123", "test@example.com")
+	token, _, err := manager1.GenerateToken("user-123", "test@example.com", "user")
 	if err != nil {
 		t.Fatalf("failed to generate token: %v", err)
 	}
@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ClientIPMetadataKey is the gRPC metadata key under which the API gateway
+// forwards the address of the client attempting to log in. The user-service
+// reads it through GatewayTrust, which ignores it from other callers.
+const ClientIPMetadataKey = "x-client-ip"
+
+// strikeTTL is how long past lockouts count towards the backoff. An email
+// or IP that goes a day without being locked out starts again from
+// LockoutPolicy.BaseLockout.
+const strikeTTL = 24 * time.Hour
+
+// LockoutPolicy sets when failed logins lock an account or client out.
+type LockoutPolicy struct {
+	// MaxFailures is the number of failed logins for one email within
+	// Window that locks it.
+	MaxFailures int
+
+	// MaxIPFailures is the same threshold for one client IP across all
+	// emails. It is higher than MaxFailures because many users can share
+	// an address behind a NAT.
+	MaxIPFailures int
+
+	// Window is how long a failed login counts towards the thresholds.
+	Window time.Duration
+
+	// BaseLockout is the length of a first lockout. Each further lockout
+	// within strikeTTL doubles it, up to MaxLockout.
+	BaseLockout time.Duration
+	MaxLockout  time.Duration
+}
+
+// attemptStore holds the counters and locks behind LoginGuard. It is
+// satisfied by redisAttemptStore; tests substitute an in-process
+// implementation.
+type attemptStore interface {
+	// ttl returns how long key has left to live, or zero if it is absent.
+	ttl(ctx context.Context, key string) (time.Duration, error)
+	// incr increments key, setting it to expire after ttl when it is new.
+	incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	set(ctx context.Context, key string, ttl time.Duration) error
+	del(ctx context.Context, keys ...string) error
+}
+
+// LoginGuard slows credential stuffing by tracking failed logins per email
+// and per client IP and locking either out once it crosses its threshold.
+//
+// The email is counted whether or not an account exists for it, so a
+// lockout reveals nothing about which emails are registered. Like the rate
+// limiter it fails open: if Redis is unavailable logins proceed unguarded.
+type LoginGuard struct {
+	store  attemptStore
+	policy LockoutPolicy
+}
+
+// NewLoginGuard creates a LoginGuard that keeps its state in Redis.
+func NewLoginGuard(redisClient *redis.Client, policy LockoutPolicy) *LoginGuard {
+	return &LoginGuard{store: redisAttemptStore{client: redisClient}, policy: policy}
+}
+
+// ErrLoginLocked is returned by CheckLocked while the email or IP is locked
+// out.
+var ErrLoginLocked = errors.New("too many failed login attempts")
+
+// CheckLocked returns ErrLoginLocked and how long remains if email or ip is
+// locked out. ip may be empty when the caller's address is unknown.
+func (g *LoginGuard) CheckLocked(ctx context.Context, email, ip string) (time.Duration, error) {
+	var remaining time.Duration
+	for _, subject := range subjects(email, ip) {
+		d, err := g.store.ttl(ctx, "login:lock:"+subject)
+		if err != nil {
+			continue
+		}
+		remaining = max(remaining, d)
+	}
+	if remaining > 0 {
+		return remaining, ErrLoginLocked
+	}
+	return 0, nil
+}
+
+// RecordFailure counts a failed login for email and ip, locking out either
+// that has reached its threshold. It returns the longest lockout it started,
+// or zero.
+func (g *LoginGuard) RecordFailure(ctx context.Context, email, ip string) time.Duration {
+	var lockout time.Duration
+	for _, subject := range subjects(email, ip) {
+		threshold := g.policy.MaxFailures
+		if strings.HasPrefix(subject, "ip:") {
+			threshold = g.policy.MaxIPFailures
+		}
+
+		failures, err := g.store.incr(ctx, "login:failures:"+subject, g.policy.Window)
+		if err != nil || failures < int64(threshold) {
+			continue
+		}
+
+		strikes, err := g.store.incr(ctx, "login:strikes:"+subject, strikeTTL)
+		if err != nil {
+			continue
+		}
+		d := g.lockoutFor(strikes)
+		if err := g.store.set(ctx, "login:lock:"+subject, d); err != nil {
+			continue
+		}
+		// Start counting afresh once the lockout lapses.
+		_ = g.store.del(ctx, "login:failures:"+subject)
+		lockout = max(lockout, d)
+	}
+	return lockout
+}
+
+// RecordSuccess clears the failed-login history of email. The client IP's
+// counter is left alone: otherwise an attacker could reset it between
+// guesses by logging in to an account of their own.
+func (g *LoginGuard) RecordSuccess(ctx context.Context, email string) {
+	subject := emailSubject(email)
+	_ = g.store.del(ctx, "login:failures:"+subject, "login:strikes:"+subject)
+}
+
+// lockoutFor returns the length of the strikes-th lockout: BaseLockout,
+// doubled for each earlier strike, capped at MaxLockout.
+func (g *LoginGuard) lockoutFor(strikes int64) time.Duration {
+	d := g.policy.BaseLockout
+	for i := int64(1); i < strikes && d < g.policy.MaxLockout; i++ {
+		d *= 2
+	}
+	return min(d, g.policy.MaxLockout)
+}
+
+func subjects(email, ip string) []string {
+	s := []string{emailSubject(email)}
+	if ip != "" {
+		s = append(s, "ip:"+ip)
+	}
+	return s
+}
+
+// emailSubject normalises email so that case and padding variations share
+// one counter.
+func emailSubject(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// redisAttemptStore keeps the LoginGuard state as plain Redis keys.
+type redisAttemptStore struct {
+	client *redis.Client
+}
+
+func (s redisAttemptStore) ttl(ctx context.Context, key string) (time.Duration, error) {
+	d, err := s.client.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// PTTL reports a missing key as -2 and a key without expiry as -1.
+	if d < 0 {
+		return 0, nil
+	}
+	return d, nil
+}
+
+func (s redisAttemptStore) incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	pipe := s.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func (s redisAttemptStore) set(ctx context.Context, key string, ttl time.Duration) error {
+	return s.client.Set(ctx, key, 1, ttl).Err()
+}
+
+func (s redisAttemptStore) del(ctx context.Context, keys ...string) error {
+	return s.client.Del(ctx, keys...).Err()
+}
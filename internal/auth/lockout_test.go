@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryAttemptStore is an in-process attemptStore.
+type memoryAttemptStore struct {
+	mu      sync.Mutex
+	values  map[string]int64
+	expires map[string]time.Time
+}
+
+func newMemoryAttemptStore() *memoryAttemptStore {
+	return &memoryAttemptStore{values: map[string]int64{}, expires: map[string]time.Time{}}
+}
+
+func (s *memoryAttemptStore) live(key string) bool {
+	exp, ok := s.expires[key]
+	if ok && time.Now().After(exp) {
+		delete(s.values, key)
+		delete(s.expires, key)
+		return false
+	}
+	_, ok = s.values[key]
+	return ok
+}
+
+func (s *memoryAttemptStore) ttl(ctx context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.live(key) {
+		return 0, nil
+	}
+	return time.Until(s.expires[key]), nil
+}
+
+func (s *memoryAttemptStore) incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.live(key) {
+		s.expires[key] = time.Now().Add(ttl)
+	}
+	s.values[key]++
+	return s.values[key], nil
+}
+
+func (s *memoryAttemptStore) set(ctx context.Context, key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = 1
+	s.expires[key] = time.Now().Add(ttl)
+	return nil
+}
+
+func (s *memoryAttemptStore) del(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		delete(s.values, key)
+		delete(s.expires, key)
+	}
+	return nil
+}
+
+// expire ends key's TTL now, as if time had passed.
+func (s *memoryAttemptStore) expire(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expires[key] = time.Now().Add(-time.Second)
+}
+
+func newTestLoginGuard() (*LoginGuard, *memoryAttemptStore) {
+	store := newMemoryAttemptStore()
+	return &LoginGuard{store: store, policy: LockoutPolicy{
+		MaxFailures:   3,
+		MaxIPFailures: 5,
+		Window:        15 * time.Minute,
+		BaseLockout:   time.Minute,
+		MaxLockout:    5 * time.Minute,
+	}}, store
+}
+
+// TestLoginGuard_LocksEmailAfterMaxFailures verifies that the lockout starts
+// on the MaxFailures-th failure and applies to every spelling of the email.
+func TestLoginGuard_LocksEmailAfterMaxFailures(t *testing.T) {
+	g, _ := newTestLoginGuard()
+	ctx := context.Background()
+
+	for i := 1; i < 3; i++ {
+		if d := g.RecordFailure(ctx, "user@example.com", ""); d != 0 {
+			t.Fatalf("failure %d: expected no lockout yet, got %v", i, d)
+		}
+		if _, err := g.CheckLocked(ctx, "user@example.com", ""); err != nil {
+			t.Fatalf("failure %d: expected not locked, got %v", i, err)
+		}
+	}
+	if d := g.RecordFailure(ctx, "user@example.com", ""); d != time.Minute {
+		t.Fatalf("expected a 1m lockout on the third failure, got %v", d)
+	}
+
+	remaining, err := g.CheckLocked(ctx, " User@Example.com", "")
+	if !errors.Is(err, ErrLoginLocked) {
+		t.Fatalf("expected ErrLoginLocked, got %v", err)
+	}
+	if remaining <= 0 || remaining > time.Minute {
+		t.Errorf("expected up to 1m remaining, got %v", remaining)
+	}
+	if _, err := g.CheckLocked(ctx, "other@example.com", ""); err != nil {
+		t.Errorf("expected other emails to be unaffected, got %v", err)
+	}
+}
+
+// TestLoginGuard_BacksOffExponentially verifies that each lockout within the
+// strike window doubles the last, up to MaxLockout.
+func TestLoginGuard_BacksOffExponentially(t *testing.T) {
+	g, store := newTestLoginGuard()
+	ctx := context.Background()
+
+	want := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for round, expected := range want {
+		var got time.Duration
+		for i := 0; i < 3; i++ {
+			got = g.RecordFailure(ctx, "user@example.com", "")
+		}
+		if got != expected {
+			t.Errorf("lockout %d: expected %v, got %v", round+1, expected, got)
+		}
+		store.expire("login:lock:email:user@example.com")
+	}
+}
+
+// TestLoginGuard_LocksIPAcrossEmails verifies that an IP spraying different
+// emails is locked out at MaxIPFailures even though no single email is.
+func TestLoginGuard_LocksIPAcrossEmails(t *testing.T) {
+	g, _ := newTestLoginGuard()
+	ctx := context.Background()
+
+	for _, email := range []string{"a@x.com", "b@x.com", "c@x.com", "d@x.com", "e@x.com"} {
+		g.RecordFailure(ctx, email, "192.0.2.1")
+	}
+
+	if _, err := g.CheckLocked(ctx, "fresh@x.com", "192.0.2.1"); !errors.Is(err, ErrLoginLocked) {
+		t.Errorf("expected the IP to be locked, got %v", err)
+	}
+	if _, err := g.CheckLocked(ctx, "fresh@x.com", "192.0.2.2"); err != nil {
+		t.Errorf("expected another IP to be allowed, got %v", err)
+	}
+}
+
+// TestLoginGuard_SuccessResetsEmail verifies that a successful login clears
+// the email's failures and backoff, but not the IP's failures.
+func TestLoginGuard_SuccessResetsEmail(t *testing.T) {
+	g, store := newTestLoginGuard()
+	ctx := context.Background()
+
+	// Earn one lockout so the backoff has a strike to forget.
+	for i := 0; i < 3; i++ {
+		g.RecordFailure(ctx, "user@example.com", "192.0.2.1")
+	}
+	store.expire("login:lock:email:user@example.com")
+	g.RecordFailure(ctx, "user@example.com", "192.0.2.1")
+
+	g.RecordSuccess(ctx, "user@example.com")
+
+	// Two more failures stay under the threshold again, and the next
+	// lockout is back to the base length.
+	for i := 0; i < 2; i++ {
+		if d := g.RecordFailure(ctx, "user@example.com", ""); d != 0 {
+			t.Fatalf("expected the failure count to have been reset, got a %v lockout", d)
+		}
+	}
+	if d := g.RecordFailure(ctx, "user@example.com", ""); d != time.Minute {
+		t.Errorf("expected the backoff to have been reset to 1m, got %v", d)
+	}
+
+	if n, _ := store.incr(ctx, "login:failures:ip:192.0.2.1", time.Minute); n != 5 {
+		t.Errorf("expected the IP's 4 failures to survive the success, got %d", n-1)
+	}
+}
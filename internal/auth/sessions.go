@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clock"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/metadata"
+)
+
+// ClientDeviceMetadataKey is the gRPC metadata key under which the API
+// gateway forwards the User-Agent of the client logging in or registering,
+// recorded as the session's device. Unlike ClientIPMetadataKey it is
+// believed from any caller: it only labels the caller's own session.
+const ClientDeviceMetadataKey = "x-client-device"
+
+// maxDeviceLength caps the User-Agent stored per session.
+const maxDeviceLength = 256
+
+// ClientDevice returns the device forwarded in ClientDeviceMetadataKey
+// metadata, truncated to maxDeviceLength, or "" if there is none.
+func ClientDevice(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(ClientDeviceMetadataKey)
+	if len(values) == 0 {
+		return ""
+	}
+	device := strings.TrimSpace(values[0])
+	if len(device) > maxDeviceLength {
+		device = device[:maxDeviceLength]
+	}
+	return device
+}
+
+// Session is a token issued at login or registration, as listed to its
+// user.
+type Session struct {
+	ID         string    `json:"id"` // The token's "jti" claim.
+	Device     string    `json:"device,omitempty"`
+	IP         string    `json:"ip,omitempty"`
+	IssuedAt   time.Time `json:"issued_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Generation int64     `json:"generation"`
+}
+
+// sessionStore holds the state behind SessionTracker. It is satisfied by
+// redisSessionStore; tests substitute an in-process implementation.
+type sessionStore interface {
+	// generation returns userID's session generation, 0 if it has none.
+	generation(ctx context.Context, userID string) (int64, error)
+	// add stores s among userID's sessions, keeping them for at least ttl.
+	add(ctx context.Context, userID string, s Session, ttl time.Duration) error
+	all(ctx context.Context, userID string) ([]Session, error)
+	remove(ctx context.Context, userID string, ids ...string) error
+	// revoke bumps userID's generation and forgets their sessions in one
+	// step, returning the new generation.
+	revoke(ctx context.Context, userID string) (int64, error)
+}
+
+// SessionTracker records the sessions users are signed in with and signs
+// them out everywhere.
+//
+// Access tokens are stateless JWTs, so signing out cannot delete them.
+// Instead every user has a generation number, embedded in the tokens
+// issued to them (Claims.Generation). RevokeAll bumps it, and a token from
+// an earlier generation is refused even though its signature and expiry
+// are still good. The generation never expires: if it fell back to 0,
+// tokens revoked at generation 0 would be accepted again.
+type SessionTracker struct {
+	store sessionStore
+	clock clock.Clock // tells which sessions have expired
+}
+
+// NewSessionTracker creates a SessionTracker that keeps its state in Redis.
+func NewSessionTracker(redisClient *redis.Client) *SessionTracker {
+	return &SessionTracker{store: redisSessionStore{client: redisClient}, clock: clock.Real{}}
+}
+
+// Generation returns the generation new tokens for userID are issued at.
+// A token from an earlier one has been revoked.
+func (t *SessionTracker) Generation(ctx context.Context, userID string) (int64, error) {
+	return t.store.generation(ctx, userID)
+}
+
+// Record adds s to userID's sessions until it expires.
+func (t *SessionTracker) Record(ctx context.Context, userID string, s Session) error {
+	return t.store.add(ctx, userID, s, s.ExpiresAt.Sub(t.clock.Now()))
+}
+
+// List returns userID's sessions, newest first. Sessions that have expired
+// or were revoked are left out and forgotten.
+func (t *SessionTracker) List(ctx context.Context, userID string) ([]Session, error) {
+	generation, err := t.store.generation(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := t.store.all(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := t.clock.Now()
+	live := sessions[:0]
+	var stale []string
+	for _, s := range sessions {
+		if s.Generation < generation || !s.ExpiresAt.After(now) {
+			stale = append(stale, s.ID)
+			continue
+		}
+		live = append(live, s)
+	}
+	if len(stale) > 0 {
+		_ = t.store.remove(ctx, userID, stale...)
+	}
+
+	slices.SortFunc(live, func(a, b Session) int {
+		return b.IssuedAt.Compare(a.IssuedAt)
+	})
+	return live, nil
+}
+
+// RevokeAll signs userID out everywhere by bumping their generation, and
+// returns how many sessions were listed beforehand.
+func (t *SessionTracker) RevokeAll(ctx context.Context, userID string) (int, error) {
+	sessions, err := t.List(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := t.store.revoke(ctx, userID); err != nil {
+		return 0, err
+	}
+	return len(sessions), nil
+}
+
+// redisSessionStore keeps each user's generation in a plain key and their
+// sessions as JSON in a hash keyed by session ID.
+type redisSessionStore struct {
+	client *redis.Client
+}
+
+func generationKey(userID string) string { return "session:gen:" + userID }
+func sessionsKey(userID string) string   { return "session:list:" + userID }
+
+func (s redisSessionStore) generation(ctx context.Context, userID string) (int64, error) {
+	n, err := s.client.Get(ctx, generationKey(userID)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return n, err
+}
+
+func (s redisSessionStore) add(ctx context.Context, userID string, session Session, ttl time.Duration) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	key := sessionsKey(userID)
+	pipe := s.client.TxPipeline()
+	pipe.HSet(ctx, key, session.ID, data)
+	// The hash lives as long as its longest-lived session.
+	pipe.ExpireNX(ctx, key, ttl)
+	pipe.ExpireGT(ctx, key, ttl)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (s redisSessionStore) all(ctx context.Context, userID string) ([]Session, error) {
+	fields, err := s.client.HGetAll(ctx, sessionsKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]Session, 0, len(fields))
+	for _, data := range fields {
+		var session Session
+		if err := json.Unmarshal([]byte(data), &session); err != nil {
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+func (s redisSessionStore) remove(ctx context.Context, userID string, ids ...string) error {
+	return s.client.HDel(ctx, sessionsKey(userID), ids...).Err()
+}
+
+func (s redisSessionStore) revoke(ctx context.Context, userID string) (int64, error) {
+	pipe := s.client.TxPipeline()
+	incr := pipe.Incr(ctx, generationKey(userID))
+	pipe.Del(ctx, sessionsKey(userID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clock/clocktest"
+	"google.golang.org/grpc/metadata"
+)
+
+// memorySessionStore is an in-process sessionStore. Key expiry is not
+// modelled; List drops expired sessions itself.
+type memorySessionStore struct {
+	mu          sync.Mutex
+	generations map[string]int64
+	sessions    map[string]map[string]Session
+}
+
+func newMemorySessionStore() *memorySessionStore {
+	return &memorySessionStore{generations: map[string]int64{}, sessions: map[string]map[string]Session{}}
+}
+
+func (s *memorySessionStore) generation(ctx context.Context, userID string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generations[userID], nil
+}
+
+func (s *memorySessionStore) add(ctx context.Context, userID string, session Session, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sessions[userID] == nil {
+		s.sessions[userID] = map[string]Session{}
+	}
+	s.sessions[userID][session.ID] = session
+	return nil
+}
+
+func (s *memorySessionStore) all(ctx context.Context, userID string) ([]Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var sessions []Session
+	for _, session := range s.sessions[userID] {
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+func (s *memorySessionStore) remove(ctx context.Context, userID string, ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		delete(s.sessions[userID], id)
+	}
+	return nil
+}
+
+func (s *memorySessionStore) revoke(ctx context.Context, userID string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.generations[userID]++
+	delete(s.sessions, userID)
+	return s.generations[userID], nil
+}
+
+func TestSessionTracker_ListsLiveSessionsNewestFirst(t *testing.T) {
+	store := newMemorySessionStore()
+	clk := clocktest.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	tracker := &SessionTracker{store: store, clock: clk}
+	ctx := context.Background()
+
+	// Each session is recorded when it is issued.
+	for _, s := range []Session{
+		{ID: "expired", ExpiresAt: clk.Now().Add(2 * time.Hour)},
+		{ID: "old", ExpiresAt: clk.Now().Add(4 * time.Hour)},
+		{ID: "new", ExpiresAt: clk.Now().Add(5 * time.Hour)},
+	} {
+		s.IssuedAt = clk.Now()
+		if err := tracker.Record(ctx, "alice", s); err != nil {
+			t.Fatalf("Record(%s): %v", s.ID, err)
+		}
+		clk.Advance(time.Hour)
+	}
+
+	sessions, err := tracker.List(ctx, "alice")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != "new" || sessions[1].ID != "old" {
+		t.Fatalf("expected [new old], got %+v", sessions)
+	}
+	if _, ok := store.sessions["alice"]["expired"]; ok {
+		t.Error("expected the expired session to be forgotten")
+	}
+}
+
+func TestSessionTracker_RevokeAllBumpsGeneration(t *testing.T) {
+	tracker := &SessionTracker{store: newMemorySessionStore(), clock: clocktest.NewFake(time.Now())}
+	ctx := context.Background()
+	now := tracker.clock.Now()
+
+	_ = tracker.Record(ctx, "alice", Session{ID: "a", IssuedAt: now, ExpiresAt: now.Add(time.Hour)})
+	_ = tracker.Record(ctx, "alice", Session{ID: "b", IssuedAt: now, ExpiresAt: now.Add(time.Hour)})
+	_ = tracker.Record(ctx, "bob", Session{ID: "c", IssuedAt: now, ExpiresAt: now.Add(time.Hour)})
+
+	revoked, err := tracker.RevokeAll(ctx, "alice")
+	if err != nil {
+		t.Fatalf("RevokeAll: %v", err)
+	}
+	if revoked != 2 {
+		t.Errorf("expected 2 sessions revoked, got %d", revoked)
+	}
+	if gen, _ := tracker.Generation(ctx, "alice"); gen != 1 {
+		t.Errorf("expected generation 1, got %d", gen)
+	}
+	if sessions, _ := tracker.List(ctx, "alice"); len(sessions) != 0 {
+		t.Errorf("expected no sessions left, got %+v", sessions)
+	}
+	if sessions, _ := tracker.List(ctx, "bob"); len(sessions) != 1 {
+		t.Errorf("expected bob's session to survive, got %+v", sessions)
+	}
+
+	// A session recorded at the old generation by a login racing the
+	// revocation is not listed.
+	_ = tracker.Record(ctx, "alice", Session{ID: "late", IssuedAt: now, ExpiresAt: now.Add(time.Hour)})
+	if sessions, _ := tracker.List(ctx, "alice"); len(sessions) != 0 {
+		t.Errorf("expected the stale session to be hidden, got %+v", sessions)
+	}
+}
+
+func TestClientDevice(t *testing.T) {
+	if got := ClientDevice(context.Background()); got != "" {
+		t.Errorf("expected no device without metadata, got %q", got)
+	}
+	long := make([]byte, 2*maxDeviceLength)
+	for i := range long {
+		long[i] = 'x'
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(ClientDeviceMetadataKey, string(long)))
+	if got := ClientDevice(ctx); len(got) != maxDeviceLength {
+		t.Errorf("expected the device truncated to %d bytes, got %d", maxDeviceLength, len(got))
+	}
+}
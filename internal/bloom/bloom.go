@@ -0,0 +1,143 @@
+// Package bloom implements a space-efficient probabilistic set used to
+// short-circuit short-code existence checks.
+//
+// A Bloom filter answers "is this element in the set?" with one of two
+// results:
+//
+//   - "definitely not present": no false negatives are possible, so the
+//     caller can skip the authoritative lookup entirely.
+//   - "maybe present": the element was probably added, but a false positive
+//     is possible. The caller must fall back to the exact check.
+//
+// In the URL shortener the filter is loaded with every existing short code
+// at url-service startup and updated on each create. Custom-alias creation
+// consults it before taking the distributed lock and querying the primary
+// database, so the common case of a genuinely free alias costs a few bit
+// lookups instead of two network round-trips. The database unique constraint
+// on short_code remains the source of truth: the filter only ever lets the
+// caller skip work, never skip enforcement.
+//
+// Each url-service replica keeps its own in-process filter, and a Syncer adds
+// the codes created by other replicas at a fixed interval. Until the next
+// pass, the local filter can answer "definitely not present" for such a code.
+// That case is handled by the INSERT failing on the unique constraint, which
+// the storage layer already reports as storage.ErrShortCodeTaken.
+package bloom
+
+import (
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+// Filter is a thread-safe, fixed-size Bloom filter over strings. The bit
+// array size (m) and the number of hash functions (k) are chosen once at
+// construction time from the expected element count and the target false
+// positive rate; the filter never grows.
+type Filter struct {
+	mu    sync.RWMutex
+	bits  []uint64 // bit array packed into 64-bit words
+	m     uint64   // total number of bits
+	k     uint64   // number of hash functions applied per element
+	count uint64   // number of Add calls, used for reporting only
+}
+
+// New creates a Filter sized to hold expectedItems elements while keeping
+// the false positive probability at or below falsePositiveRate. Degenerate
+// inputs are clamped (at least one item, a rate inside (0, 1)) so a
+// misconfigured environment variable yields a working, if oversized, filter
+// instead of a panic.
+func New(expectedItems int, falsePositiveRate float64) *Filter {
+	if expectedItems < 1 {
+		expectedItems = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.01
+	}
+
+	m, k := OptimalParameters(uint64(expectedItems), falsePositiveRate)
+
+	return &Filter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+// OptimalParameters returns the bit-array size m and hash count k that
+// minimise memory for n elements at false positive rate p, using the
+// standard formulas:
+//
+//	m = -n * ln(p) / (ln 2)^2
+//	k = (m / n) * ln 2
+//
+// For one million short codes at 1% this works out to roughly 1.2 MB and
+// seven hash functions.
+func OptimalParameters(n uint64, p float64) (m, k uint64) {
+	m = uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	if m == 0 {
+		m = 1
+	}
+	k = uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k == 0 {
+		k = 1
+	}
+	return m, k
+}
+
+// Add inserts an element into the filter. Adding the same element twice is
+// harmless.
+func (f *Filter) Add(item string) {
+	h1, h2 := hashes(item)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := uint64(0); i < f.k; i++ {
+		pos := (h1 + i*h2) % f.m
+		f.bits[pos/64] |= 1 << (pos % 64)
+	}
+	f.count++
+}
+
+// MightContain reports whether the element may have been added. A false
+// return is definitive; a true return must be confirmed against the source
+// of truth because it may be a false positive.
+func (f *Filter) MightContain(item string) bool {
+	h1, h2 := hashes(item)
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for i := uint64(0); i < f.k; i++ {
+		pos := (h1 + i*h2) % f.m
+		if f.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Count returns the number of Add calls made against the filter. Duplicate
+// additions are counted each time, so this is an upper bound on the number
+// of distinct elements.
+func (f *Filter) Count() uint64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.count
+}
+
+// hashes derives the two base hashes used for Kirsch-Mitzenmacher double
+// hashing: the i-th probe position is h1 + i*h2 (mod m). This gives k
+// well-distributed positions from a single 64-bit FNV-1a digest instead of
+// computing k independent hash functions. h2 is forced odd so successive
+// probes never collapse onto the same position when m is a power of two.
+func hashes(item string) (uint64, uint64) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(item))
+	sum := h.Sum64()
+
+	h1 := sum & 0xffffffff
+	h2 := (sum >> 32) | 1
+	return h1, h2
+}
@@ -0,0 +1,122 @@
+package bloom
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestFilter_NoFalseNegatives verifies the core Bloom filter guarantee: every
+// element that was added must be reported as possibly present. A false
+// negative here would let custom-alias creation skip the exact check for a
+// code that exists.
+func TestFilter_NoFalseNegatives(t *testing.T) {
+	f := New(1000, 0.01)
+
+	for i := 0; i < 1000; i++ {
+		f.Add(fmt.Sprintf("code-%d", i))
+	}
+
+	for i := 0; i < 1000; i++ {
+		item := fmt.Sprintf("code-%d", i)
+		if !f.MightContain(item) {
+			t.Errorf("expected %q to be reported as present", item)
+		}
+	}
+}
+
+// TestFilter_EmptyReportsAbsent ensures an empty filter answers "definitely
+// not present" for everything, which is the fast path for new aliases.
+func TestFilter_EmptyReportsAbsent(t *testing.T) {
+	f := New(100, 0.01)
+
+	if f.MightContain("my-link") {
+		t.Error("expected empty filter to report absent")
+	}
+}
+
+// TestFilter_FalsePositiveRate fills the filter to its design capacity and
+// measures how often unseen elements are reported as present. The observed
+// rate must stay within a reasonable margin of the configured target.
+func TestFilter_FalsePositiveRate(t *testing.T) {
+	const n = 10000
+	const target = 0.01
+
+	f := New(n, target)
+	for i := 0; i < n; i++ {
+		f.Add(fmt.Sprintf("present-%d", i))
+	}
+
+	falsePositives := 0
+	const probes = 100000
+	for i := 0; i < probes; i++ {
+		if f.MightContain(fmt.Sprintf("absent-%d", i)) {
+			falsePositives++
+		}
+	}
+
+	rate := float64(falsePositives) / probes
+	if rate > target*2 {
+		t.Errorf("false positive rate %.4f exceeds twice the target %.4f", rate, target)
+	}
+}
+
+// TestFilter_SaturatedReportsMaybePresent deliberately overloads a tiny
+// filter so that unseen elements collide with set bits. Callers must treat
+// these "maybe present" answers as a prompt for the exact check, not as
+// proof of existence.
+func TestFilter_SaturatedReportsMaybePresent(t *testing.T) {
+	f := New(1, 0.5)
+	for i := 0; i < 100; i++ {
+		f.Add(fmt.Sprintf("item-%d", i))
+	}
+
+	falsePositives := 0
+	for i := 0; i < 100; i++ {
+		if f.MightContain(fmt.Sprintf("unseen-%d", i)) {
+			falsePositives++
+		}
+	}
+
+	if falsePositives == 0 {
+		t.Error("expected a saturated filter to produce false positives")
+	}
+}
+
+// TestNew_ClampsInvalidParameters checks that nonsensical sizing inputs still
+// produce a usable filter rather than a zero-length bit array.
+func TestNew_ClampsInvalidParameters(t *testing.T) {
+	f := New(0, 2.0)
+
+	f.Add("abc")
+	if !f.MightContain("abc") {
+		t.Error("expected clamped filter to contain added item")
+	}
+	if f.m == 0 || f.k == 0 {
+		t.Errorf("expected positive m and k, got m=%d k=%d", f.m, f.k)
+	}
+}
+
+// TestOptimalParameters spot-checks the sizing formula against the
+// well-known values for one million items at a 1% false positive rate.
+func TestOptimalParameters(t *testing.T) {
+	m, k := OptimalParameters(1000000, 0.01)
+
+	if m < 9500000 || m > 9700000 {
+		t.Errorf("expected m around 9.59M bits, got %d", m)
+	}
+	if k != 7 {
+		t.Errorf("expected k = 7, got %d", k)
+	}
+}
+
+// TestFilter_Count verifies that Count tracks the number of Add calls.
+func TestFilter_Count(t *testing.T) {
+	f := New(10, 0.01)
+	f.Add("a")
+	f.Add("b")
+	f.Add("a")
+
+	if f.Count() != 3 {
+		t.Errorf("expected count 3, got %d", f.Count())
+	}
+}
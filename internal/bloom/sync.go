@@ -0,0 +1,60 @@
+package bloom
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// syncOverlap is how far back each incremental scan reaches before the start
+// of the previous one. It absorbs clock skew between replicas and the
+// database, inserts that commit after the scan that should have seen them,
+// and read-replica lag. Re-adding a code is harmless, so the overlap only
+// costs a few repeated rows per pass.
+const syncOverlap = time.Minute
+
+// CodeSource streams the short codes created at or after a point in time. It
+// is satisfied by *storage.PostgresStorage.
+type CodeSource interface {
+	ScanShortCodes(ctx context.Context, since time.Time, fn func(shortCode string) error) error
+}
+
+// Syncer keeps a Filter up to date with codes created by other replicas. The
+// first Sync loads every existing code; each later one only scans codes
+// created since the previous pass, so a replica's filter lags the database by
+// at most one sync interval instead of drifting further until restart.
+type Syncer struct {
+	filter *Filter
+	src    CodeSource
+
+	mu    sync.Mutex
+	since time.Time // zero until the first successful pass
+}
+
+// NewSyncer returns a Syncer that loads codes from src into filter.
+func NewSyncer(filter *Filter, src CodeSource) *Syncer {
+	return &Syncer{filter: filter, src: src}
+}
+
+// Sync adds every code created since the previous successful pass (or every
+// code, on the first call) to the filter and returns how many were added.
+// After a failed pass the next call rescans from the same point, so no code
+// is skipped.
+func (s *Syncer) Sync(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := time.Now()
+	added := 0
+	err := s.src.ScanShortCodes(ctx, s.since, func(shortCode string) error {
+		s.filter.Add(shortCode)
+		added++
+		return nil
+	})
+	if err != nil {
+		return added, err
+	}
+
+	s.since = start.Add(-syncOverlap)
+	return added, nil
+}
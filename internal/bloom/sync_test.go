@@ -0,0 +1,90 @@
+package bloom
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// timedCode is a short code with the time its row was created.
+type timedCode struct {
+	code      string
+	createdAt time.Time
+}
+
+// tableSource is a CodeSource over an in-memory urls table. It records the
+// since argument of every scan and fails scans while err is set.
+type tableSource struct {
+	rows  []timedCode
+	err   error
+	scans []time.Time
+}
+
+func (s *tableSource) ScanShortCodes(ctx context.Context, since time.Time, fn func(shortCode string) error) error {
+	s.scans = append(s.scans, since)
+	if s.err != nil {
+		return s.err
+	}
+	for _, r := range s.rows {
+		if !r.createdAt.Before(since) {
+			if err := fn(r.code); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// TestSyncer_PicksUpCodesCreatedElsewhere verifies that the first Sync loads
+// the whole table, and that a code inserted afterwards by another replica is
+// added by the next, incremental Sync.
+func TestSyncer_PicksUpCodesCreatedElsewhere(t *testing.T) {
+	src := &tableSource{rows: []timedCode{{"old", time.Now().Add(-24 * time.Hour)}}}
+	f := New(100, 0.01)
+	s := NewSyncer(f, src)
+	ctx := context.Background()
+
+	if added, err := s.Sync(ctx); err != nil || added != 1 {
+		t.Fatalf("expected the initial load to add 1 code, got %d, %v", added, err)
+	}
+	if !src.scans[0].IsZero() {
+		t.Errorf("expected the initial load to scan the whole table, got since %v", src.scans[0])
+	}
+
+	src.rows = append(src.rows, timedCode{"remote", time.Now()})
+	if _, err := s.Sync(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !f.MightContain("remote") {
+		t.Error("expected the code created elsewhere to be added")
+	}
+	if !src.scans[1].After(src.rows[0].createdAt) {
+		t.Errorf("expected the incremental scan to skip old rows, got since %v", src.scans[1])
+	}
+}
+
+// TestSyncer_RetriesFromSameCursor verifies that a failed pass does not move
+// the cursor, so codes created before the failure are still picked up.
+func TestSyncer_RetriesFromSameCursor(t *testing.T) {
+	src := &tableSource{}
+	s := NewSyncer(New(100, 0.01), src)
+	ctx := context.Background()
+
+	if _, err := s.Sync(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	src.err = errors.New("replica unavailable")
+	if _, err := s.Sync(ctx); err == nil {
+		t.Fatal("expected the scan error to be returned")
+	}
+
+	src.err = nil
+	if _, err := s.Sync(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !src.scans[2].Equal(src.scans[1]) {
+		t.Errorf("expected the retry to rescan from %v, got %v", src.scans[1], src.scans[2])
+	}
+}
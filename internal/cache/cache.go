@@ -25,8 +25,16 @@ import (
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracer starts a span around each Get, Set and Delete, so a request's
+// trace shows which tier served it and what the Redis round-trip cost.
+var tracer = otel.Tracer("github.com/Varun5711/shorternit/internal/cache")
+
 // Cache is a multi-tier cache combining a fast in-process L1 (LRU) with a
 // shared L2 (Redis). The two tiers are kept consistent on writes; reads
 // cascade from L1 to L2, backfilling L1 on an L2 hit so subsequent reads
@@ -58,16 +66,25 @@ func NewMultiTierCache(l1Capacity int, redisClient *redis.Client, l2TTL time.Dur
 // first request after a cold start pays the Redis RTT, but every subsequent
 // request for the same key is served from process memory.
 func (c *Cache) Get(ctx context.Context, key string) (string, bool) {
+	ctx, span := tracer.Start(ctx, "cache.Get", trace.WithAttributes(attribute.String("cache.key", key)))
+	defer span.End()
+
 	if val, found := c.l1Cache.Get(key); found {
+		span.SetAttributes(attribute.String("cache.tier", "l1"), attribute.Bool("cache.hit", true))
 		return val.(string), true
 	}
 
 	val, err := c.l2Cache.Get(ctx, key).Result()
 	if err == nil {
 		c.l1Cache.Set(key, val)
+		span.SetAttributes(attribute.String("cache.tier", "l2"), attribute.Bool("cache.hit", true))
 		return val, true
 	}
 
+	span.SetAttributes(attribute.Bool("cache.hit", false))
+	if err != redis.Nil {
+		span.RecordError(err)
+	}
 	return "", false
 }
 
@@ -76,16 +93,31 @@ func (c *Cache) Get(ctx context.Context, key string) (string, bool) {
 // The L2 write applies the configured TTL so stale entries are eventually
 // reaped even if the application never explicitly deletes them.
 func (c *Cache) Set(ctx context.Context, key string, value string) error {
+	ctx, span := tracer.Start(ctx, "cache.Set", trace.WithAttributes(attribute.String("cache.key", key)))
+	defer span.End()
+
 	c.l1Cache.Set(key, value)
-	return c.l2Cache.Set(ctx, key, value, c.l2TTL).Err()
+	return recordError(span, c.l2Cache.Set(ctx, key, value, c.l2TTL).Err())
 }
 
 // Delete removes a key from both tiers. Both tiers are invalidated even if one
 // fails, because serving stale data after an explicit delete is worse than a
 // cache miss.
 func (c *Cache) Delete(ctx context.Context, key string) error {
+	ctx, span := tracer.Start(ctx, "cache.Delete", trace.WithAttributes(attribute.String("cache.key", key)))
+	defer span.End()
+
 	c.l1Cache.Delete(key)
-	return c.l2Cache.Del(ctx, key).Err()
+	return recordError(span, c.l2Cache.Del(ctx, key).Err())
+}
+
+// recordError marks span failed if err is non-nil, and returns err.
+func recordError(span trace.Span, err error) error {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
 }
 
 // GetJSON is a convenience wrapper around Get that deserializes the cached
@@ -0,0 +1,26 @@
+// Package cachetest builds caches for tests that run without Redis.
+package cachetest
+
+import (
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+// UnreachableRedis returns a client for an address nothing listens on. Every
+// command fails fast, so code that treats Redis as best-effort carries on
+// without it.
+func UnreachableRedis() *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:        "127.0.0.1:1",
+		MaxRetries:  -1,
+		DialTimeout: 50 * time.Millisecond,
+	})
+}
+
+// NewL1Only returns a Cache whose Redis tier is unreachable, so entries live
+// only in L1 and every hit must come from there.
+func NewL1Only() *cache.Cache {
+	return cache.NewMultiTierCache(100, UnreachableRedis(), time.Minute)
+}
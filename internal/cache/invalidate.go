@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// InvalidationChannel is the Redis Pub/Sub channel carrying the keys that
+// replicas must drop from their L1. L1 entries never expire on their own, so
+// a change that must reach every replica at once, such as an owner disabling
+// a link, is published here after L2 has been rewritten.
+const InvalidationChannel = "cache:invalidate"
+
+// Invalidate tells every Cache running ListenInvalidations to drop key from
+// its L1. Call it after rewriting or deleting key in L2, so that the next
+// lookup on each replica reads the new entry from L2. Only replicas that are
+// listening at the time hear it; see ListenInvalidations for those that are
+// not.
+func (c *Cache) Invalidate(ctx context.Context, key string) error {
+	return c.l2Cache.Publish(ctx, InvalidationChannel, key).Err()
+}
+
+// ListenInvalidations drops from L1 every key published by Invalidate, until
+// ctx is cancelled. Messages published while the subscription is down are
+// lost, so each time it is re-established L1 is emptied and refills from L2.
+func (c *Cache) ListenInvalidations(ctx context.Context) {
+	sub := c.l2Cache.Subscribe(ctx, InvalidationChannel)
+	defer sub.Close()
+	c.dropInvalidated(ctx, sub.ChannelWithSubscriptions())
+}
+
+// dropInvalidated applies the messages of an InvalidationChannel
+// subscription to L1: a published key is dropped, and any subscription
+// confirmation after the first one, which marks a reconnect, empties L1.
+func (c *Cache) dropInvalidated(ctx context.Context, msgs <-chan interface{}) {
+	subscribed := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				return
+			}
+			switch msg := msg.(type) {
+			case *redis.Subscription:
+				if msg.Kind != "subscribe" {
+					continue
+				}
+				if subscribed {
+					c.l1Cache.Clear()
+				}
+				subscribed = true
+			case *redis.Message:
+				c.l1Cache.Delete(msg.Payload)
+			}
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TestDropInvalidated verifies that a published key is dropped from L1 while
+// other entries stay, and that a reconnect empties L1, since invalidations
+// published while the subscription was down never arrive.
+func TestDropInvalidated(t *testing.T) {
+	c := &Cache{l1Cache: NewLRUCache(10)}
+	c.l1Cache.Set("url:off", "{}")
+	c.l1Cache.Set("url:hot", "{}")
+
+	msgs := make(chan interface{})
+	done := make(chan struct{})
+	go func() {
+		c.dropInvalidated(context.Background(), msgs)
+		close(done)
+	}()
+
+	msgs <- &redis.Subscription{Kind: "subscribe", Channel: InvalidationChannel, Count: 1}
+	msgs <- &redis.Message{Channel: InvalidationChannel, Payload: "url:off"}
+	msgs <- &redis.Message{Channel: InvalidationChannel, Payload: "url:unknown"}
+	// The channel is unbuffered: once the last send returns, the messages
+	// before it have been applied.
+	if _, ok := c.l1Cache.Get("url:off"); ok {
+		t.Error("expected the invalidated key dropped from L1")
+	}
+	if _, ok := c.l1Cache.Get("url:hot"); !ok {
+		t.Error("expected other keys kept in L1")
+	}
+
+	msgs <- &redis.Subscription{Kind: "subscribe", Channel: InvalidationChannel, Count: 1}
+	close(msgs)
+	<-done
+	if n := c.l1Cache.Len(); n != 0 {
+		t.Errorf("expected L1 emptied after a reconnect, %d entries left", n)
+	}
+}
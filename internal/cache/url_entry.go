@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// URLEntry is the value cached under "url:<code>" for redirect resolution.
+// Besides the destination it carries the per-link rules the redirect fast path
+// has to enforce without a gRPC round-trip, so a cache hit is never more
+// permissive than a database read.
+type URLEntry struct {
+	LongURL    string            `json:"long_url"`
+	MaxClicks  int64             `json:"max_clicks,omitempty"`  // 0 = unlimited
+	ActiveFrom int64             `json:"active_from,omitempty"` // Unix seconds, 0 = active immediately
+	ExpiresAt  int64             `json:"expires_at,omitempty"`  // Unix seconds, 0 = never expires
+	Domain     string            `json:"domain,omitempty"`      // custom domain the link is served on, "" = default
+	Variants   []URLVariant      `json:"variants,omitempty"`    // weighted A/B destinations, empty = always LongURL
+	GeoRules   map[string]string `json:"geo_rules,omitempty"`   // country code -> destination, checked before Variants
+	Preview    bool              `json:"preview,omitempty"`     // show the interstitial page instead of redirecting
+	BackupURL  string            `json:"backup_url,omitempty"`  // served while LongURL is down, "" = none
+	UserID     string            `json:"user_id,omitempty"`     // owner, who may force a fresh lookup; "" = nobody
+	Disabled   bool              `json:"disabled,omitempty"`    // the owner has disabled the link: answer 410 Gone
+}
+
+// URLVariant is one weighted destination of an A/B split link.
+type URLVariant struct {
+	LongURL string `json:"long_url"`
+	Weight  int32  `json:"weight"`
+}
+
+// GetURL looks up a redirect entry. Entries written before URLEntry existed
+// are bare long-URL strings; they are still accepted and decoded as an entry
+// with no limits, so a deploy does not require flushing the cache.
+func (c *Cache) GetURL(ctx context.Context, key string) (*URLEntry, bool) {
+	val, found := c.Get(ctx, key)
+	if !found {
+		return nil, false
+	}
+
+	if !strings.HasPrefix(val, "{") {
+		return &URLEntry{LongURL: val}, true
+	}
+
+	var entry URLEntry
+	if err := json.Unmarshal([]byte(val), &entry); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// SetURL stores a redirect entry in both tiers as JSON.
+func (c *Cache) SetURL(ctx context.Context, key string, entry URLEntry) error {
+	return c.SetJSON(ctx, key, entry)
+}
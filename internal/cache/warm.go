@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Varun5711/shorternit/internal/models"
+)
+
+// HotURLSource lists the most-clicked links. It is satisfied by
+// *storage.PostgresStorage.
+type HotURLSource interface {
+	ListTopByClicks(ctx context.Context, limit int) ([]*models.URL, error)
+}
+
+// Warm loads the topN most-clicked non-expired links from store into both
+// tiers, so the hottest short codes hit L1 straight after a deploy instead
+// of each paying a Redis or gRPC round-trip first. It returns the number of
+// entries written. A topN of zero or less disables warming.
+func (c *Cache) Warm(ctx context.Context, store HotURLSource, topN int) (int, error) {
+	if topN <= 0 {
+		return 0, nil
+	}
+
+	urls, err := store.ListTopByClicks(ctx, topN)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load hot URLs: %w", err)
+	}
+
+	warmed := 0
+	for _, u := range urls {
+		// An L2 failure still leaves the entry in L1, which is what
+		// matters for this replica.
+		_ = c.SetURL(ctx, "url:"+u.ShortCode, EntryFromURL(u))
+		warmed++
+	}
+	return warmed, nil
+}
+
+// EntryFromURL builds the redirect entry cached for u.
+func EntryFromURL(u *models.URL) URLEntry {
+	entry := URLEntry{
+		LongURL:   u.LongURL,
+		MaxClicks: u.MaxClicks,
+		Domain:    u.Domain,
+		Preview:   u.Preview,
+		BackupURL: u.BackupURL,
+		UserID:    u.UserID,
+		Disabled:  u.Disabled,
+	}
+	if u.ActiveFrom != nil {
+		entry.ActiveFrom = u.ActiveFrom.Unix()
+	}
+	if u.ExpiresAt != nil {
+		entry.ExpiresAt = u.ExpiresAt.Unix()
+	}
+	for _, v := range u.Variants {
+		entry.Variants = append(entry.Variants, URLVariant{LongURL: v.LongURL, Weight: v.Weight})
+	}
+	if len(u.GeoRules) > 0 {
+		entry.GeoRules = make(map[string]string, len(u.GeoRules))
+		for _, rule := range u.GeoRules {
+			entry.GeoRules[rule.CountryCode] = rule.LongURL
+		}
+	}
+	return entry
+}
@@ -0,0 +1,78 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/cache/cachetest"
+	"github.com/Varun5711/shorternit/internal/models"
+)
+
+// staticHotURLs is a HotURLSource over a fixed, already ordered list.
+type staticHotURLs []*models.URL
+
+func (s staticHotURLs) ListTopByClicks(ctx context.Context, limit int) ([]*models.URL, error) {
+	return s[:min(limit, len(s))], nil
+}
+
+// TestWarm_PopulatesCache verifies that warmed links are served from the
+// cache, with their redirect rules, on the next lookup.
+func TestWarm_PopulatesCache(t *testing.T) {
+	activeFrom := time.Unix(1700000000, 0)
+	store := staticHotURLs{
+		{
+			ShortCode:  "hot1",
+			LongURL:    "https://example.com/a",
+			UserID:     "alice",
+			MaxClicks:  5,
+			ActiveFrom: &activeFrom,
+			Domain:     "go.example.com",
+			Variants:   []models.URLVariant{{LongURL: "https://example.com/a", Weight: 70}, {LongURL: "https://example.com/b", Weight: 30}},
+			GeoRules:   []models.GeoRule{{CountryCode: "DE", LongURL: "https://example.de"}},
+		},
+		{ShortCode: "hot2", LongURL: "https://example.com/c"},
+		{ShortCode: "hot3", LongURL: "https://example.com/d"},
+	}
+	c := cachetest.NewL1Only()
+	ctx := context.Background()
+
+	warmed, err := c.Warm(ctx, store, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if warmed != 2 {
+		t.Errorf("expected 2 warmed entries, got %d", warmed)
+	}
+
+	entry, ok := c.GetURL(ctx, "url:hot1")
+	if !ok {
+		t.Fatal("expected url:hot1 to be a cache hit after warming")
+	}
+	if entry.LongURL != "https://example.com/a" || entry.MaxClicks != 5 || entry.ActiveFrom != activeFrom.Unix() || entry.Domain != "go.example.com" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+	if len(entry.Variants) != 2 || entry.Variants[1].Weight != 30 {
+		t.Errorf("expected the variants to be cached, got %+v", entry.Variants)
+	}
+	if entry.GeoRules["DE"] != "https://example.de" {
+		t.Errorf("expected the geo rules to be cached, got %+v", entry.GeoRules)
+	}
+	if entry.UserID != "alice" {
+		t.Errorf("expected the owner to be cached for the no-cache bypass, got %q", entry.UserID)
+	}
+	if _, ok := c.GetURL(ctx, "url:hot2"); !ok {
+		t.Error("expected url:hot2 to be a cache hit after warming")
+	}
+	if _, ok := c.GetURL(ctx, "url:hot3"); ok {
+		t.Error("expected url:hot3, outside the top 2, not to be warmed")
+	}
+}
+
+// TestWarm_Disabled verifies that a non-positive topN skips the store.
+func TestWarm_Disabled(t *testing.T) {
+	warmed, err := cachetest.NewL1Only().Warm(context.Background(), nil, 0)
+	if err != nil || warmed != 0 {
+		t.Errorf("expected a no-op, got %d, %v", warmed, err)
+	}
+}
@@ -0,0 +1,146 @@
+// Package cleanup implements the cleanup-worker's job: periodically deleting
+// expired URLs from PostgreSQL and evicting them from the cache.
+//
+// Deleting the rows alone is not enough. A redirect entry cached in Redis
+// keeps resolving its short code until the L2 TTL lapses, so an expired link
+// would go on redirecting after its row was gone. The cleaner therefore
+// collects the short codes it deletes and removes their cache entries and
+// click-limit counters, exactly as an explicit DeleteURL does.
+//
+// Click events in ClickHouse are left alone: the pipeline-worker deletes them
+// after ANALYTICS_RETENTION_DAYS, and analytics for a link remain meaningful
+// after the link itself has expired.
+//
+// The worker may run as several replicas, each on its own timer. Leadership
+// is a lease: the replica that runs a pass takes a Redis lock whose TTL is
+// the cleanup interval and never releases it, so no other replica can run a
+// pass until a full interval has gone by. Releasing the lock when the pass
+// finished would let a replica whose timer fires a few minutes later run the
+// same bulk DELETE again in the same interval.
+package cleanup
+
+import (
+	"context"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/cache"
+	"github.com/Varun5711/shorternit/internal/clicklimit"
+	"github.com/Varun5711/shorternit/internal/lock"
+	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// ExpiredURLDeleter deletes expired URLs and reports their short codes. It is
+// satisfied by *storage.PostgresStorage and *storage.MemoryStorage.
+type ExpiredURLDeleter interface {
+	DeleteExpiredURLs(ctx context.Context) ([]string, error)
+}
+
+// leaderLockKey is the Redis key the replicas compete for.
+const leaderLockKey = "lock:cleanup-worker"
+
+// Cleaner runs cleanup passes.
+type Cleaner struct {
+	store    ExpiredURLDeleter
+	cache    *cache.Cache
+	newLock  func() lock.Locker // nil runs every pass without election
+	interval time.Duration      // time between passes, and the leader lease
+	instance string             // identifies this replica in the logs
+	log      *logger.Logger
+}
+
+// NewCleaner creates a Cleaner that deletes expired URLs from store and
+// evicts them from urlCache every interval. Passes are coordinated through a
+// leader lease in redisClient, taken under the name instance (typically the
+// pod's hostname).
+func NewCleaner(store ExpiredURLDeleter, urlCache *cache.Cache, redisClient *redis.Client, interval time.Duration, instance string, log *logger.Logger) *Cleaner {
+	return &Cleaner{
+		store: store,
+		cache: urlCache,
+		newLock: func() lock.Locker {
+			return lock.NewDistributedLock(redisClient, leaderLockKey, interval)
+		},
+		interval: interval,
+		instance: instance,
+		log:      log,
+	}
+}
+
+// Run performs a cleanup pass immediately and then every interval until ctx
+// is cancelled. The immediate pass ensures newly deployed instances catch up
+// on any backlog of expired URLs without waiting a full interval.
+func (c *Cleaner) Run(ctx context.Context) {
+	c.RunOnce(ctx)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce performs a single cleanup pass: it deletes all URLs whose
+// expires_at timestamp is in the past, evicts their cache entries and
+// click-limit counters, and returns the short codes removed. Errors are
+// logged but do not crash the worker -- the next pass will retry
+// automatically.
+//
+// Eviction reaches the shared Redis tier; the in-process L1 tiers of the
+// redirect replicas are not reached, as with DeleteURL.
+//
+// The pass only runs on the replica that takes the leader lease; the others
+// log that they are skipping it and return nil. The lease is left to expire
+// rather than released, so it covers the whole interval.
+func (c *Cleaner) RunOnce(ctx context.Context) []string {
+	if c.newLock != nil {
+		acquired, err := c.newLock().Acquire(ctx)
+		if err != nil {
+			c.log.Error("Failed to acquire cleanup leader lease, skipping this cycle: %v", err)
+			return nil
+		}
+		if !acquired {
+			c.log.Info("Another instance holds the cleanup lease for this interval, skipping this cycle")
+			return nil
+		}
+		c.log.Info("Instance %s took the cleanup lease for the next %s", c.instance, c.interval)
+	}
+
+	return c.clean(ctx)
+}
+
+// clean deletes the expired URLs and evicts them.
+func (c *Cleaner) clean(ctx context.Context) []string {
+	c.log.Info("Starting cleanup of expired URLs...")
+
+	shortCodes, err := c.store.DeleteExpiredURLs(ctx)
+	if err != nil {
+		c.log.Error("Failed to delete expired URLs: %v", err)
+		return nil
+	}
+	if len(shortCodes) == 0 {
+		c.log.Info("No expired URLs found")
+		return nil
+	}
+
+	failed := 0
+	for _, shortCode := range shortCodes {
+		// Cache.Delete removes the key from Redis whichever component wrote
+		// it, so it also clears the redirect-service's click-limit counter.
+		if err := c.cache.Delete(ctx, "url:"+shortCode); err != nil {
+			failed++
+		}
+		_ = c.cache.Delete(ctx, clicklimit.Key(shortCode))
+	}
+
+	c.log.Info("Deleted %d expired URLs from database", len(shortCodes))
+	if failed > 0 {
+		c.log.Warn("Failed to evict %d of them from the cache; they will expire by TTL", failed)
+	}
+	return shortCodes
+}
@@ -0,0 +1,163 @@
+package cleanup
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/cache"
+	"github.com/Varun5711/shorternit/internal/cache/cachetest"
+	"github.com/Varun5711/shorternit/internal/lock"
+	"github.com/Varun5711/shorternit/internal/lock/locktest"
+	"github.com/Varun5711/shorternit/internal/logger"
+)
+
+// fakeDeleter reports a fixed set of deleted short codes, taking delay to
+// do so.
+type fakeDeleter struct {
+	codes []string
+	err   error
+	delay time.Duration
+	calls atomic.Int32
+}
+
+func (f *fakeDeleter) DeleteExpiredURLs(ctx context.Context) ([]string, error) {
+	f.calls.Add(1)
+	time.Sleep(f.delay)
+	return f.codes, f.err
+}
+
+// newReplica returns a Cleaner taking its lease from locks, as one worker
+// replica running every interval.
+func newReplica(store ExpiredURLDeleter, locks *locktest.Table, interval time.Duration, name string) *Cleaner {
+	c := newTestCleaner(store, cachetest.NewL1Only())
+	c.newLock = func() lock.Locker { return locks.LockWithTTL(leaderLockKey, interval) }
+	c.interval = interval
+	c.instance = name
+	return c
+}
+
+// newTestCleaner returns a Cleaner that runs every pass without election.
+func newTestCleaner(store ExpiredURLDeleter, urlCache *cache.Cache) *Cleaner {
+	return &Cleaner{store: store, cache: urlCache, log: logger.New("cleanup-test")}
+}
+
+// TestRunOnce_EvictsDeletedCodes verifies that the cache entries of deleted
+// URLs are evicted while other entries are kept.
+func TestRunOnce_EvictsDeletedCodes(t *testing.T) {
+	ctx := context.Background()
+	urlCache := cachetest.NewL1Only()
+	for _, code := range []string{"gone1", "gone2", "kept"} {
+		_ = urlCache.SetURL(ctx, "url:"+code, cache.URLEntry{LongURL: "https://example.com/" + code})
+	}
+	store := &fakeDeleter{codes: []string{"gone1", "gone2"}}
+
+	deleted := newTestCleaner(store, urlCache).RunOnce(ctx)
+
+	if len(deleted) != 2 {
+		t.Errorf("expected 2 deleted codes, got %v", deleted)
+	}
+	for _, code := range []string{"gone1", "gone2"} {
+		if _, ok := urlCache.GetURL(ctx, "url:"+code); ok {
+			t.Errorf("expected url:%s to be evicted", code)
+		}
+	}
+	if _, ok := urlCache.GetURL(ctx, "url:kept"); !ok {
+		t.Error("expected url:kept to stay cached")
+	}
+}
+
+// TestRunOnce_DeleteError verifies that a failed delete evicts nothing.
+func TestRunOnce_DeleteError(t *testing.T) {
+	ctx := context.Background()
+	urlCache := cachetest.NewL1Only()
+	_ = urlCache.SetURL(ctx, "url:gone1", cache.URLEntry{LongURL: "https://example.com"})
+	store := &fakeDeleter{codes: []string{"gone1"}, err: errors.New("connection refused")}
+
+	if deleted := newTestCleaner(store, urlCache).RunOnce(ctx); deleted != nil {
+		t.Errorf("expected no deleted codes, got %v", deleted)
+	}
+	if _, ok := urlCache.GetURL(ctx, "url:gone1"); !ok {
+		t.Error("expected url:gone1 to stay cached when the delete failed")
+	}
+}
+
+// TestRun_RepeatsEveryInterval verifies the immediate pass and the
+// interval-driven ones, and that Run returns once ctx is cancelled.
+func TestRun_RepeatsEveryInterval(t *testing.T) {
+	store := &fakeDeleter{}
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+	defer cancel()
+
+	c := newTestCleaner(store, cachetest.NewL1Only())
+	c.interval = 20 * time.Millisecond
+	c.Run(ctx)
+
+	if got := store.calls.Load(); got < 2 {
+		t.Errorf("expected at least 2 passes, got %d", got)
+	}
+}
+
+// TestRunOnce_OnlyLeaderDeletes verifies that of two replicas running a
+// cycle at the same time only the lease holder performs the delete.
+func TestRunOnce_OnlyLeaderDeletes(t *testing.T) {
+	store := &fakeDeleter{codes: []string{"gone1"}, delay: 20 * time.Millisecond}
+	locks := locktest.NewTable()
+	workers := []*Cleaner{
+		newReplica(store, locks, time.Hour, "worker-a"),
+		newReplica(store, locks, time.Hour, "worker-b"),
+	}
+
+	results := make([][]string, len(workers))
+	var wg sync.WaitGroup
+	for i, w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = w.RunOnce(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	if got := store.calls.Load(); got != 1 {
+		t.Fatalf("expected exactly one replica to delete, got %d deletes", got)
+	}
+	if (results[0] == nil) == (results[1] == nil) {
+		t.Errorf("expected exactly one replica to report deleted codes, got %v", results)
+	}
+	if !locks.Held(leaderLockKey) {
+		t.Error("expected the leader to keep its lease after the pass")
+	}
+}
+
+// TestRunOnce_StaggeredReplicasPassOncePerInterval verifies that two
+// replicas whose timers fire 20 minutes apart run one pass per hourly
+// interval between them, and that the other replica takes over once the
+// leader stops.
+func TestRunOnce_StaggeredReplicasPassOncePerInterval(t *testing.T) {
+	store := &fakeDeleter{}
+	locks := locktest.NewTable()
+	a := newReplica(store, locks, time.Hour, "worker-a")
+	b := newReplica(store, locks, time.Hour, "worker-b")
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		a.RunOnce(ctx)
+		locks.Advance(20 * time.Minute)
+		b.RunOnce(ctx)
+		locks.Advance(40 * time.Minute)
+	}
+	if got := store.calls.Load(); got != 3 {
+		t.Fatalf("expected 3 passes in 3 intervals, got %d", got)
+	}
+
+	// worker-a stops; its last lease has expired, so worker-b leads.
+	locks.Advance(20 * time.Minute)
+	b.RunOnce(ctx)
+	if got := store.calls.Load(); got != 4 {
+		t.Errorf("expected worker-b to take over, got %d passes", got)
+	}
+}
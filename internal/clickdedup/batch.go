@@ -0,0 +1,52 @@
+package clickdedup
+
+import (
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clickhouse"
+)
+
+// CollapseBatch applies the dedup window to a batch of click events on the
+// pipeline-worker side. The redirect-service Gate fails open, so a burst it
+// could not check (Redis briefly unreachable, or a replica still running
+// without dedup) reaches the stream unflagged; CollapseBatch catches such
+// repeats when they land in the same batch, using each event's click time.
+//
+// A repeat is an event already flagged IsDuplicate, or one clicked within
+// window of the first click of its visitor on the same link earlier in the
+// batch. The visitor is the event's DedupKey, which the pipeline-worker
+// derives from the address and User-Agent as received: the stored IPAddress
+// may be masked, so that visitors sharing a /24 would look alike, or blank
+// when ANALYTICS_FIELDS leaves it out. An event without a DedupKey, sent
+// without an address, cannot be told apart from other visitors' and is only
+// a repeat if flagged.
+//
+// In ModeCollapse repeats are dropped; in ModeRaw they are kept with
+// IsDuplicate set. The result reuses the backing array of events. A zero
+// window returns events unchanged.
+func CollapseBatch(events []clickhouse.ClickEvent, window time.Duration, mode string) []clickhouse.ClickEvent {
+	if window <= 0 {
+		return events
+	}
+
+	firstSeen := make(map[string]time.Time)
+	kept := events[:0]
+	for _, ev := range events {
+		repeat := ev.IsDuplicate == 1
+		if !repeat && ev.DedupKey != "" {
+			if first, seen := firstSeen[ev.DedupKey]; seen && ev.ClickedAt.Sub(first) < window {
+				repeat = true
+			} else {
+				firstSeen[ev.DedupKey] = ev.ClickedAt
+			}
+		}
+		if repeat {
+			if mode != ModeRaw {
+				continue
+			}
+			ev.IsDuplicate = 1
+		}
+		kept = append(kept, ev)
+	}
+	return kept
+}
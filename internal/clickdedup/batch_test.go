@@ -0,0 +1,126 @@
+package clickdedup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clickhouse"
+)
+
+// click is an event on shortCode from the visitor at ip with userAgent,
+// offset seconds into the batch, keyed as the pipeline-worker keys it.
+func click(shortCode, ip, userAgent string, offset int) clickhouse.ClickEvent {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ev := clickhouse.ClickEvent{
+		ShortCode: shortCode,
+		IPAddress: ip,
+		UserAgent: userAgent,
+		ClickedAt: base.Add(time.Duration(offset) * time.Second),
+	}
+	if ip != "" {
+		ev.DedupKey = Key(shortCode, ip, userAgent)
+	}
+	return ev
+}
+
+// TestCollapseBatch_RapidRepeatsCountedOnce simulates a double-click and a
+// prefetch arriving in one batch: the visitor is counted once, while another
+// visitor and a click after the window still count.
+func TestCollapseBatch_RapidRepeatsCountedOnce(t *testing.T) {
+	events := []clickhouse.ClickEvent{
+		click("abc", "198.51.100.1", "Firefox", 0),
+		click("abc", "198.51.100.1", "Firefox", 0),
+		click("abc", "198.51.100.1", "Firefox", 1),
+		click("abc", "198.51.100.1", "Chrome", 1),  // same NAT, other browser
+		click("xyz", "198.51.100.1", "Firefox", 1), // other link
+		click("abc", "198.51.100.1", "Firefox", 5), // window over
+	}
+
+	got := CollapseBatch(events, 5*time.Second, ModeCollapse)
+	if len(got) != 4 {
+		t.Fatalf("expected 4 clicks after collapsing, got %d: %+v", len(got), got)
+	}
+	for _, ev := range got {
+		if ev.IsDuplicate != 0 {
+			t.Errorf("expected no duplicates kept in collapse mode, got %+v", ev)
+		}
+	}
+}
+
+// TestCollapseBatch_RawKeepsFlaggedRepeats verifies that raw mode keeps every
+// click and flags the repeats, including one the redirect-service already
+// flagged.
+func TestCollapseBatch_RawKeepsFlaggedRepeats(t *testing.T) {
+	flagged := click("abc", "198.51.100.2", "Safari", 0)
+	flagged.IsDuplicate = 1
+	events := []clickhouse.ClickEvent{
+		click("abc", "198.51.100.1", "Firefox", 0),
+		click("abc", "198.51.100.1", "Firefox", 1),
+		flagged,
+	}
+
+	got := CollapseBatch(events, 5*time.Second, ModeRaw)
+	if len(got) != 3 {
+		t.Fatalf("expected every click kept in raw mode, got %d", len(got))
+	}
+	want := []uint8{0, 1, 1}
+	for i, ev := range got {
+		if ev.IsDuplicate != want[i] {
+			t.Errorf("click %d: expected is_duplicate=%d, got %d", i, want[i], ev.IsDuplicate)
+		}
+	}
+}
+
+// TestCollapseBatch_Disabled verifies that a zero window leaves the batch
+// alone.
+func TestCollapseBatch_Disabled(t *testing.T) {
+	events := []clickhouse.ClickEvent{
+		click("abc", "198.51.100.1", "Firefox", 0),
+		click("abc", "198.51.100.1", "Firefox", 0),
+	}
+	if got := CollapseBatch(events, 0, ModeCollapse); len(got) != 2 {
+		t.Errorf("expected both clicks with dedup disabled, got %d", len(got))
+	}
+}
+
+// TestCollapseBatch_NoAddressNotCollapsed verifies that clicks stored
+// without an address are not taken for one visitor's repeats, while a
+// click the redirect-service flagged still is.
+func TestCollapseBatch_NoAddressNotCollapsed(t *testing.T) {
+	flagged := click("abc", "", "", 1)
+	flagged.IsDuplicate = 1
+	events := []clickhouse.ClickEvent{
+		click("abc", "", "", 0),
+		click("abc", "", "", 0),
+		flagged,
+	}
+
+	got := CollapseBatch(events, 5*time.Second, ModeCollapse)
+	if len(got) != 2 {
+		t.Fatalf("expected the 2 unflagged clicks kept, got %d: %+v", len(got), got)
+	}
+}
+
+// TestCollapseBatch_KeyedByVisitorAsReceived verifies that repeats are
+// recognised by DedupKey, not by the stored address: visitors whose masked
+// addresses match still count separately, and a visitor whose address is
+// not stored is still collapsed.
+func TestCollapseBatch_KeyedByVisitorAsReceived(t *testing.T) {
+	masked := func(ev clickhouse.ClickEvent, stored string) clickhouse.ClickEvent {
+		ev.IPAddress = stored
+		return ev
+	}
+	events := []clickhouse.ClickEvent{
+		masked(click("abc", "198.51.100.1", "Firefox", 0), "198.51.100.0"),
+		masked(click("abc", "198.51.100.2", "Firefox", 0), "198.51.100.0"),
+		masked(click("abc", "198.51.100.3", "Firefox", 0), ""),
+		masked(click("abc", "198.51.100.3", "Firefox", 1), ""),
+	}
+	got := CollapseBatch(events, 5*time.Second, ModeRaw)
+	want := []uint8{0, 0, 0, 1}
+	for i, ev := range got {
+		if ev.IsDuplicate != want[i] {
+			t.Errorf("click %d: expected is_duplicate=%d, got %d", i, want[i], ev.IsDuplicate)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Package clickdedup recognises repeat clicks from the same visitor.
+//
+// Double-clicks, browser prefetchers and link-preview bots often request the
+// same short link several times within a second or two, and each request
+// would otherwise be recorded as a click. The redirect-service asks a Gate
+// before publishing a click event: the first click from a visitor opens a
+// window during which further clicks on the same link from the same visitor
+// are reported as repeats.
+//
+// A visitor is identified by client IP and User-Agent, so two people behind
+// one NAT using different browsers still count separately. The gate is a
+// Redis SET NX with a TTL, which makes it shared by every redirect replica.
+// The pipeline-worker applies the same window again with CollapseBatch, for
+// repeats that reached the stream without passing the gate.
+package clickdedup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Modes select what happens to repeat clicks.
+const (
+	// ModeCollapse drops repeats, so a burst is recorded as one click.
+	ModeCollapse = "collapse"
+	// ModeRaw keeps every click and marks repeats as duplicates.
+	ModeRaw = "raw"
+)
+
+// CheckMode returns an error unless mode is ModeCollapse or ModeRaw.
+func CheckMode(mode string) error {
+	switch mode {
+	case ModeCollapse, ModeRaw:
+		return nil
+	}
+	return fmt.Errorf("unknown click dedup mode %q (want %s or %s)", mode, ModeCollapse, ModeRaw)
+}
+
+// keyPrefix namespaces the per-visitor markers
+// ("clickdedup:<code>:<visitor hash>").
+const keyPrefix = "clickdedup:"
+
+// Gate reports whether a click is the first from its visitor within the
+// window.
+type Gate struct {
+	client *redis.Client
+	window time.Duration
+}
+
+// NewGate creates a Gate that treats clicks within window of a visitor's
+// first click on a link as repeats.
+func NewGate(client *redis.Client, window time.Duration) *Gate {
+	return &Gate{client: client, window: window}
+}
+
+// First records a click on shortCode from the visitor identified by ip and
+// userAgent, and reports whether it is the first within the window. The
+// window runs from the first click: repeats do not extend it, so a visitor
+// clicking steadily is counted once per window.
+func (g *Gate) First(ctx context.Context, shortCode, ip, userAgent string) (bool, error) {
+	ok, err := g.client.SetNX(ctx, Key(shortCode, ip, userAgent), 1, g.window).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check click dedup window: %w", err)
+	}
+	return ok, nil
+}
+
+// Key returns the Redis key marking a visitor's recent click on shortCode.
+// The IP and User-Agent are hashed to keep keys short and free of raw
+// visitor data.
+func Key(shortCode, ip, userAgent string) string {
+	sum := sha256.Sum256([]byte(ip + "\x00" + userAgent))
+	return keyPrefix + shortCode + ":" + hex.EncodeToString(sum[:8])
+}
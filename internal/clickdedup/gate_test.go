@@ -0,0 +1,38 @@
+package clickdedup
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestKey_IdentifiesVisitorPerLink verifies that the key separates links and
+// visitors and carries no raw visitor data.
+func TestKey_IdentifiesVisitorPerLink(t *testing.T) {
+	k := Key("abc", "198.51.100.1", "Firefox")
+	if k != Key("abc", "198.51.100.1", "Firefox") {
+		t.Error("expected the same visitor to map to the same key")
+	}
+	for _, other := range []string{
+		Key("xyz", "198.51.100.1", "Firefox"),
+		Key("abc", "198.51.100.2", "Firefox"),
+		Key("abc", "198.51.100.1", "Chrome"),
+	} {
+		if other == k {
+			t.Errorf("expected a distinct key, got %q twice", k)
+		}
+	}
+	if strings.Contains(k, "198.51.100.1") || strings.Contains(k, "Firefox") {
+		t.Errorf("expected the visitor to be hashed, got %q", k)
+	}
+}
+
+func TestCheckMode(t *testing.T) {
+	for _, mode := range []string{ModeCollapse, ModeRaw} {
+		if err := CheckMode(mode); err != nil {
+			t.Errorf("CheckMode(%q): unexpected error: %v", mode, err)
+		}
+	}
+	if err := CheckMode("sample"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
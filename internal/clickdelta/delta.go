@@ -0,0 +1,126 @@
+// Package clickdelta keeps near-real-time click counts in Redis.
+//
+// The clicks column in PostgreSQL is only updated when the analytics-worker
+// flushes a batch from the click stream, so a count read right after a
+// click is stale. The redirect-service therefore also INCRs a per-link
+// delta ("clicks:rt:<code>") for every click it publishes, and the
+// url-service reports db_clicks + delta. Once the worker has added a batch
+// to PostgreSQL it settles the delta by the clicks it flushed, so each click
+// is counted exactly once in the sum.
+//
+// The delta is a best-effort overlay: a TTL bounds how long a click whose
+// event never reached the database, say because the stream was trimmed,
+// can inflate the count, and a delta that cannot be read leaves the
+// database count alone.
+package clickdelta
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix namespaces the per-link deltas ("clicks:rt:<code>").
+const keyPrefix = "clicks:rt:"
+
+// incrScript increments the delta and refreshes its TTL in one round-trip.
+const incrScript = `
+	local n = redis.call("INCR", KEYS[1])
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+	return n
+`
+
+// settleScript subtracts the flushed clicks from the delta and deletes it
+// once nothing is pending. A delta that has already expired is left absent
+// rather than driven negative, which would hide later clicks.
+const settleScript = `
+	if redis.call("EXISTS", KEYS[1]) == 0 then
+		return 0
+	end
+	local n = redis.call("DECRBY", KEYS[1], ARGV[1])
+	if n <= 0 then
+		redis.call("DEL", KEYS[1])
+		return 0
+	end
+	return n
+`
+
+// Counter maintains the Redis-side click deltas.
+type Counter struct {
+	client *redis.Client
+	ttl    time.Duration // how long a delta lives after its last click
+}
+
+// NewCounter creates a Counter. ttl bounds how long an unsettled delta is
+// kept; it defaults to 24 hours when zero.
+func NewCounter(client *redis.Client, ttl time.Duration) *Counter {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return &Counter{
+		client: client,
+		ttl:    ttl,
+	}
+}
+
+// Key returns the Redis key of shortCode's delta.
+func Key(shortCode string) string {
+	return keyPrefix + shortCode
+}
+
+// Incr records one click of shortCode that has not reached the database
+// yet.
+func (c *Counter) Incr(ctx context.Context, shortCode string) error {
+	if err := c.client.Eval(ctx, incrScript, []string{Key(shortCode)}, c.ttl.Milliseconds()).Err(); err != nil {
+		return fmt.Errorf("failed to increment click delta: %w", err)
+	}
+	return nil
+}
+
+// Get returns the pending clicks of each of shortCodes that has any, in a
+// single MGET.
+func (c *Counter) Get(ctx context.Context, shortCodes ...string) (map[string]int64, error) {
+	deltas := make(map[string]int64)
+	if len(shortCodes) == 0 {
+		return deltas, nil
+	}
+	keys := make([]string, len(shortCodes))
+	for i, code := range shortCodes {
+		keys[i] = Key(code)
+	}
+	vals, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read click deltas: %w", err)
+	}
+	for i, v := range vals {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil && n > 0 {
+			deltas[shortCodes[i]] = n
+		}
+	}
+	return deltas, nil
+}
+
+// Settle subtracts clicks that have been added to the database from their
+// links' deltas, in one pipeline.
+func (c *Counter) Settle(ctx context.Context, flushed map[string]int) error {
+	if len(flushed) == 0 {
+		return nil
+	}
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for code, n := range flushed {
+			pipe.Eval(ctx, settleScript, []string{Key(code)}, n)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to settle click deltas: %w", err)
+	}
+	return nil
+}
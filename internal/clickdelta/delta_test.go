@@ -0,0 +1,129 @@
+package clickdelta
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// deltaStore answers the commands Counter sends from a map instead of a
+// Redis server, running each script's logic in Go. TTLs are not kept.
+type deltaStore struct {
+	mu   sync.Mutex
+	keys map[string]int64
+}
+
+func (s *deltaStore) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (s *deltaStore) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		s.process(cmd)
+		return cmd.Err()
+	}
+}
+
+func (s *deltaStore) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			s.process(cmd)
+		}
+		return nil
+	}
+}
+
+func (s *deltaStore) process(cmd redis.Cmder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	args := cmd.Args()
+	switch c := cmd.(type) {
+	case *redis.SliceCmd: // MGET key...
+		vals := make([]interface{}, len(args)-1)
+		for i, key := range args[1:] {
+			if n, ok := s.keys[key.(string)]; ok {
+				vals[i] = strconv.FormatInt(n, 10)
+			}
+		}
+		c.SetVal(vals)
+	case *redis.Cmd: // EVAL script 1 key arg
+		key := args[3].(string)
+		switch args[1] {
+		case incrScript:
+			s.keys[key]++
+			c.SetVal(s.keys[key])
+		case settleScript:
+			n, ok := s.keys[key]
+			if ok {
+				n -= int64(args[4].(int))
+				s.keys[key] = n
+				if n <= 0 {
+					delete(s.keys, key)
+				}
+			}
+			c.SetVal(max(n, 0))
+		}
+	default:
+		cmd.SetErr(fmt.Errorf("unexpected command %v", args))
+	}
+}
+
+func newTestCounter() (*Counter, *deltaStore) {
+	store := &deltaStore{keys: make(map[string]int64)}
+	client := redis.NewClient(&redis.Options{})
+	client.AddHook(store)
+	return NewCounter(client, 0), store
+}
+
+// TestCounter_PendingUntilSettled follows clicks from the redirect to the
+// worker's flush: they are pending until settled, and clicks made while a
+// batch was in flight stay pending afterwards.
+func TestCounter_PendingUntilSettled(t *testing.T) {
+	ctx := context.Background()
+	c, store := newTestCounter()
+
+	for i := 0; i < 3; i++ {
+		if err := c.Incr(ctx, "abc"); err != nil {
+			t.Fatalf("Incr: %v", err)
+		}
+	}
+	_ = c.Incr(ctx, "xyz")
+
+	got, err := c.Get(ctx, "abc", "xyz", "none")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got["abc"] != 3 || got["xyz"] != 1 || len(got) != 2 {
+		t.Errorf("expected abc=3 and xyz=1 pending, got %v", got)
+	}
+
+	if err := c.Settle(ctx, map[string]int{"abc": 2, "xyz": 1}); err != nil {
+		t.Fatalf("Settle: %v", err)
+	}
+	if got, _ := c.Get(ctx, "abc", "xyz"); got["abc"] != 1 || len(got) != 1 {
+		t.Errorf("expected the one unflushed click of abc left, got %v", got)
+	}
+	if _, ok := store.keys[Key("xyz")]; ok {
+		t.Error("expected a fully settled delta to be deleted")
+	}
+}
+
+// TestCounter_SettleExpired verifies that flushing clicks whose delta has
+// expired does not leave a negative delta that would hide later clicks.
+func TestCounter_SettleExpired(t *testing.T) {
+	ctx := context.Background()
+	c, store := newTestCounter()
+
+	if err := c.Settle(ctx, map[string]int{"abc": 5}); err != nil {
+		t.Fatalf("Settle: %v", err)
+	}
+	if _, ok := store.keys[Key("abc")]; ok {
+		t.Error("expected no delta created by settling")
+	}
+	_ = c.Incr(ctx, "abc")
+	if got, _ := c.Get(ctx, "abc"); got["abc"] != 1 {
+		t.Errorf("expected the next click counted, got %v", got)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"github.com/Varun5711/shorternit/internal/timeseries"
 )
 
 // CountryStats holds aggregated click counts broken down by country.
@@ -101,7 +103,9 @@ func (c *Client) GetCountryStats(ctx context.Context, shortCode string, startDat
 //
 // The percentage is computed inline via a scalar subquery against the same
 // materialized view, so the entire result set (counts + percentages) comes
-// back in a single query.
+// back in a single query. Clicks stored without a device (see
+// enrichment.Fields) are left out of the view, so their share is not
+// reported as an unknown device.
 func (c *Client) GetDeviceStats(ctx context.Context, shortCode string, startDate, endDate time.Time) ([]DeviceStats, error) {
 	query := `
   		SELECT
@@ -145,8 +149,9 @@ func (c *Client) GetDeviceStats(ctx context.Context, shortCode string, startDate
 // Because SummingMergeTree may not have fully collapsed all parts yet, the
 // query uses sum() to ensure correctness even when multiple partial rows
 // exist for the same hour. Results are ordered chronologically for direct
-// use in time-series charts.
-func (c *Client) GetHourlyTimeSeries(ctx context.Context, shortCode string, startDate time.Time, endDate time.Time) ([]TimeSeriesPoint, error) {
+// use in time-series charts. With fill set, hours without clicks are
+// included with zero counts.
+func (c *Client) GetHourlyTimeSeries(ctx context.Context, shortCode string, startDate time.Time, endDate time.Time, fill bool) ([]TimeSeriesPoint, error) {
 	query := `
   		SELECT
   			clicked_hour,
@@ -174,6 +179,9 @@ func (c *Client) GetHourlyTimeSeries(ctx context.Context, shortCode string, star
 		points = append(points, p)
 	}
 
+	if fill {
+		points = fillTimeSeries(points, startDate, endDate, time.Hour)
+	}
 	return points, nil
 }
 
@@ -184,8 +192,9 @@ func (c *Client) GetHourlyTimeSeries(ctx context.Context, shortCode string, star
 // granularity would produce too many data points.
 //
 // Like all SummingMergeTree-backed views, the sum() aggregation in the query
-// is necessary to handle not-yet-merged parts correctly.
-func (c *Client) GetDailyTimeSeries(ctx context.Context, shortCode string, startDate, endDate time.Time) ([]TimeSeriesPoint, error) {
+// is necessary to handle not-yet-merged parts correctly. With fill set, days
+// without clicks are included with zero counts.
+func (c *Client) GetDailyTimeSeries(ctx context.Context, shortCode string, startDate, endDate time.Time, fill bool) ([]TimeSeriesPoint, error) {
 	query := `
   		SELECT
   			clicked_date,
@@ -215,9 +224,20 @@ func (c *Client) GetDailyTimeSeries(ctx context.Context, shortCode string, start
 		points = append(points, p)
 	}
 
+	if fill {
+		points = fillTimeSeries(points, startDate, endDate, 24*time.Hour)
+	}
 	return points, nil
 }
 
+// fillTimeSeries adds a zero point for every step-sized bucket between
+// startDate and endDate that the query returned no row for.
+func fillTimeSeries(points []TimeSeriesPoint, startDate, endDate time.Time, step time.Duration) []TimeSeriesPoint {
+	return timeseries.Fill(points, startDate, endDate, step,
+		func(p TimeSeriesPoint) time.Time { return p.Timestamp },
+		func(t time.Time) TimeSeriesPoint { return TimeSeriesPoint{Timestamp: t} })
+}
+
 // GetURLStats returns lifetime aggregate statistics for a single short code
 // by scanning the raw analytics.click_events table. Unlike the time-series
 // methods that read materialized views, this queries the raw table directly
@@ -226,12 +246,17 @@ func (c *Client) GetDailyTimeSeries(ctx context.Context, shortCode string, start
 // pre-summed counts, not distinct sets). For URLs with very high traffic,
 // consider adding a dedicated materialized view with AggregatingMergeTree
 // and an uniqState column to avoid full-table scans.
+//
+// Clicks are summed by sample_rate so sampled links report their real
+// traffic. Unique visitors can only be counted among the stored events, so
+// for a sampled link they are a lower bound, and clicks stored without an
+// address (see enrichment.Fields) are not counted as visitors at all.
 func (c *Client) GetURLStats(ctx context.Context, shortCode string) (*URLStats, error) {
 	query := `
   		SELECT
   			short_code,
-  			count() AS total_clicks,
-  			uniq(ip_address) AS unique_visitors,
+  			sum(sample_rate) AS total_clicks,
+  			uniqIf(ip_address, ip_address != '') AS unique_visitors,
   			max(clicked_at) AS last_clicked
   		FROM analytics.click_events
   		WHERE short_code = ?
@@ -255,7 +280,7 @@ func (c *Client) GetURLStats(ctx context.Context, shortCode string) (*URLStats,
 // The query scans the raw click_events table (filtered by clicked_date for
 // partition pruning) because referrer cardinality is too high and too
 // unpredictable for a practical materialized view. Empty referrers (direct
-// traffic) are excluded from results.
+// traffic) are excluded from results. Each event counts sample_rate clicks.
 func (c *Client) GetTopReferrers(ctx context.Context, shortCode string, startDate, endDate time.Time,
 	limit int) ([]struct {
 	Referer    string
@@ -264,7 +289,7 @@ func (c *Client) GetTopReferrers(ctx context.Context, shortCode string, startDat
 	query := `
   		SELECT
   			referer,
-  			count() AS click_count
+  			sum(sample_rate) AS click_count
   		FROM analytics.click_events
   		WHERE short_code = ?
   			AND clicked_date BETWEEN ? AND ?
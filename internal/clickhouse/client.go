@@ -68,12 +68,30 @@ func NewClient(cfg config.ClickHouseConfig) (*Client, error) {
 	pingCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	if err := conn.Ping(pingCtx); err != nil {
+		_ = conn.Close()
 		return nil, fmt.Errorf("failed to ping clickhouse: %w", err)
 	}
 
 	return &Client{conn: conn}, nil
 }
 
+// Conn returns the underlying connection, for running schema migrations.
+func (c *Client) Conn() driver.Conn {
+	return c.conn
+}
+
+// Stats returns a snapshot of the connection pool, in the same shape as the
+// PostgreSQL and Redis pool stats, for the admin stats endpoint.
+func (c *Client) Stats() map[string]interface{} {
+	stats := c.conn.Stats()
+	return map[string]interface{}{
+		"open_conns":     stats.Open,
+		"idle_conns":     stats.Idle,
+		"max_open_conns": stats.MaxOpenConns,
+		"max_idle_conns": stats.MaxIdleConns,
+	}
+}
+
 // Close releases the underlying ClickHouse connection pool.
 func (c *Client) Close() error {
 	return c.conn.Close()
@@ -81,8 +99,9 @@ func (c *Client) Close() error {
 
 // ClickEvent represents a single URL redirect event with full geo-IP and
 // user-agent metadata. This struct maps 1:1 to the analytics.click_events
-// table columns. Boolean-like fields (IsMobile, IsTablet, etc.) use uint8
-// because ClickHouse's UInt8 type is the idiomatic way to store booleans.
+// table columns, apart from DedupKey, which is not stored. Boolean-like
+// fields (IsMobile, IsTablet, etc.) use uint8 because ClickHouse's UInt8
+// type is the idiomatic way to store booleans.
 type ClickEvent struct {
 	EventID     string
 	ShortCode   string
@@ -115,6 +134,29 @@ type ClickEvent struct {
 
 	Referer     string
 	QueryParams string
+
+	// Variant is the 1-based A/B variant that was served, 0 when the link
+	// has a single destination.
+	Variant uint16
+	// GeoRule is the country code of the geo rule that chose the
+	// destination, empty when none matched.
+	GeoRule string
+	// IsDuplicate marks a repeat click from the same visitor within the
+	// dedup window. Only set when click dedup runs in raw mode; in collapse
+	// mode repeats are never recorded.
+	IsDuplicate uint8
+	// SampleRate is how many clicks this event stands for: N when only one
+	// in N of the link's events is stored. Zero is stored as 1.
+	SampleRate uint32
+	// IsFailover marks a click sent to the link's backup destination, in
+	// OriginalURL, because its primary was failing its health checks.
+	IsFailover uint8
+
+	// DedupKey identifies the visitor to the pipeline-worker's repeat-click
+	// check (see clickdedup.CollapseBatch). It is derived from the address
+	// and User-Agent as received, before they are masked or left out, and
+	// is empty when the click carried no address. It is not stored.
+	DedupKey string
 }
 
 // InsertClickEvents writes a batch of click events to the analytics.click_events
@@ -128,7 +170,8 @@ type ClickEvent struct {
 // (clicks_by_country, clicks_by_device, hourly_clicks, daily_clicks_by_url)
 // automatically process the new data and update their pre-aggregated
 // SummingMergeTree tables. This happens at insert time with no extra code
-// needed here.
+// needed here. The views sum sample_rate rather than counting rows, so
+// sampled links are scaled back to their real click counts.
 func (c *Client) InsertClickEvents(ctx context.Context, events []ClickEvent) error {
 	if len(events) == 0 {
 		return nil
@@ -142,7 +185,7 @@ func (c *Client) InsertClickEvents(ctx context.Context, events []ClickEvent) err
 		user_agent, browser, browser_version, os, os_version,
 		device_type, device_brand, device_model,
 		is_mobile, is_tablet, is_desktop, is_bot,
-		referer, query_params
+		referer, query_params, variant, geo_rule, is_duplicate, sample_rate, is_failover
 	)`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare batch: %w", err)
@@ -176,6 +219,11 @@ func (c *Client) InsertClickEvents(ctx context.Context, events []ClickEvent) err
 			event.IsBot,
 			event.Referer,
 			event.QueryParams,
+			event.Variant,
+			event.GeoRule,
+			event.IsDuplicate,
+			max(event.SampleRate, 1),
+			event.IsFailover,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to append event: %w", err)
@@ -0,0 +1,42 @@
+package clickhouse
+
+import "testing"
+
+// TestClusterGeoPoints verifies that locations within a grid cell merge into
+// one point weighted by their combined clicks, while a distant one stays
+// apart.
+func TestClusterGeoPoints(t *testing.T) {
+	points := []GeoPoint{
+		{Latitude: 52.5200, Longitude: 13.4050, ClickCount: 3}, // Berlin
+		{Latitude: 52.4800, Longitude: 13.3600, ClickCount: 2}, // Berlin, Schöneberg
+		{Latitude: 48.8566, Longitude: 2.3522, ClickCount: 4},  // Paris
+	}
+
+	got := clusterGeoPoints(points, 1)
+	want := []GeoPoint{
+		{Latitude: 52.5, Longitude: 13.4, ClickCount: 5},
+		{Latitude: 48.9, Longitude: 2.4, ClickCount: 4},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d points, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("point %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+// TestClusterGeoPoints_ClampsPrecision verifies that a precision finer than
+// MaxGeoPrecision does not return exact coordinates, and that a negative one
+// clusters by whole degrees.
+func TestClusterGeoPoints_ClampsPrecision(t *testing.T) {
+	points := []GeoPoint{{Latitude: 52.52437, Longitude: 13.41053, ClickCount: 1}}
+
+	if got := clusterGeoPoints(points, 6)[0]; got.Latitude != 52.52 || got.Longitude != 13.41 {
+		t.Errorf("expected precision to be capped at %d places, got %+v", MaxGeoPrecision, got)
+	}
+	if got := clusterGeoPoints(points, -1)[0]; got.Latitude != 53 || got.Longitude != 13 {
+		t.Errorf("expected whole degrees for a negative precision, got %+v", got)
+	}
+}
@@ -0,0 +1,155 @@
+// Package migrate applies the ClickHouse schema migrations in
+// migrations/clickhouse.
+//
+// Migrations are applied in version order, and each applied version is
+// recorded in a schema_migrations table, so a run only executes the files
+// added since the last one.
+//
+// ClickHouse has no transactional DDL: a migration that fails halfway leaves
+// the statements before the failure applied, and its version unrecorded. It
+// is therefore retried in full on the next run, so every statement must be
+// idempotent (CREATE ... IF NOT EXISTS, ADD COLUMN IF NOT EXISTS). The same
+// property makes it safe for several pipeline-worker replicas to migrate at
+// once on startup.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/Varun5711/shorternit/migrations"
+)
+
+// Conn is the part of a ClickHouse connection the runner needs. It is
+// satisfied by driver.Conn from clickhouse-go; tests substitute a recorder.
+type Conn interface {
+	Exec(ctx context.Context, query string, args ...any) error
+	Select(ctx context.Context, dest any, query string, args ...any) error
+}
+
+// Runner applies migrations over a connection, tracking them in
+// database.schema_migrations.
+type Runner struct {
+	conn     Conn
+	database string
+	log      *logger.Logger
+}
+
+// NewRunner creates a Runner that records applied versions in the
+// schema_migrations table of database, creating both if needed.
+func NewRunner(conn Conn, database string, log *logger.Logger) *Runner {
+	return &Runner{conn: conn, database: database, log: log}
+}
+
+// Up applies the migrations whose versions are not yet recorded, in order,
+// and returns how many it applied. It stops at the first failure.
+func (r *Runner) Up(ctx context.Context, all []migrations.Migration) (int, error) {
+	if err := r.ensureTable(ctx); err != nil {
+		return 0, err
+	}
+	applied, err := r.appliedVersions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, m := range all {
+		if applied[m.Version] {
+			continue
+		}
+		for _, stmt := range splitStatements(m.SQL) {
+			if err := r.conn.Exec(ctx, stmt); err != nil {
+				return n, fmt.Errorf("migration %s failed: %w", m.Name, err)
+			}
+		}
+		if err := r.conn.Exec(ctx, "INSERT INTO "+r.table()+" (version, name) VALUES (?, ?)", m.Version, m.Name); err != nil {
+			return n, fmt.Errorf("failed to record migration %s: %w", m.Name, err)
+		}
+		r.log.Info("Applied ClickHouse migration %s", m.Name)
+		n++
+	}
+	return n, nil
+}
+
+func (r *Runner) table() string {
+	return r.database + ".schema_migrations"
+}
+
+// ensureTable creates the database and the schema_migrations table.
+func (r *Runner) ensureTable(ctx context.Context) error {
+	if err := r.conn.Exec(ctx, "CREATE DATABASE IF NOT EXISTS "+r.database); err != nil {
+		return fmt.Errorf("failed to create database %s: %w", r.database, err)
+	}
+	err := r.conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS `+r.table()+` (
+		version UInt64,
+		name String,
+		applied_at DateTime64(3) DEFAULT now64()
+	) ENGINE = MergeTree() ORDER BY version`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// appliedVersions returns the recorded versions. A version recorded twice,
+// by replicas migrating at the same time, counts once.
+func (r *Runner) appliedVersions(ctx context.Context) (map[uint64]bool, error) {
+	var rows []struct {
+		Version uint64 `ch:"version"`
+	}
+	if err := r.conn.Select(ctx, &rows, "SELECT DISTINCT version FROM "+r.table()); err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	applied := make(map[uint64]bool, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = true
+	}
+	return applied, nil
+}
+
+// splitStatements splits a migration file into its statements, since the
+// native protocol runs one statement per query. Statements end at a
+// semicolon outside quotes and comments; "--" comments are dropped, and
+// statements left empty are skipped.
+func splitStatements(sql string) []string {
+	var stmts []string
+	var cur strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(cur.String()); s != "" {
+			stmts = append(stmts, s)
+		}
+		cur.Reset()
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			cur.WriteByte('\n')
+		case c == '\'' || c == '"' || c == '`':
+			// Copy the quoted string through its closing quote, honouring
+			// backslash escapes.
+			cur.WriteByte(c)
+			for i++; i < len(sql); i++ {
+				cur.WriteByte(sql[i])
+				if sql[i] == '\\' && i+1 < len(sql) {
+					i++
+					cur.WriteByte(sql[i])
+				} else if sql[i] == c {
+					break
+				}
+			}
+		case c == ';':
+			flush()
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	flush()
+	return stmts
+}
@@ -0,0 +1,190 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/Varun5711/shorternit/migrations"
+)
+
+// fakeConn records executed statements and keeps the versions inserted into
+// schema_migrations. Statements containing failOn fail.
+type fakeConn struct {
+	executed []string
+	recorded []uint64
+	failOn   string
+}
+
+func (c *fakeConn) Exec(ctx context.Context, query string, args ...any) error {
+	if c.failOn != "" && strings.Contains(query, c.failOn) {
+		return errors.New("statement failed")
+	}
+	if strings.HasPrefix(query, "INSERT INTO") {
+		c.recorded = append(c.recorded, args[0].(uint64))
+		return nil
+	}
+	if !strings.HasPrefix(query, "CREATE DATABASE") && !strings.Contains(query, "schema_migrations") {
+		c.executed = append(c.executed, query)
+	}
+	return nil
+}
+
+// Select fills dest, a slice of one-field structs, with the recorded
+// versions, as SELECT DISTINCT version would.
+func (c *fakeConn) Select(ctx context.Context, dest any, query string, args ...any) error {
+	rows := reflect.ValueOf(dest).Elem()
+	for _, v := range c.recorded {
+		row := reflect.New(rows.Type().Elem()).Elem()
+		row.Field(0).SetUint(v)
+		rows.Set(reflect.Append(rows, row))
+	}
+	return nil
+}
+
+func testMigrations() fstest.MapFS {
+	return fstest.MapFS{
+		"ch/000002_add_column.up.sql": {Data: []byte("ALTER TABLE db.t ADD COLUMN IF NOT EXISTS b UInt8;")},
+		"ch/000001_init.up.sql":       {Data: []byte("CREATE TABLE IF NOT EXISTS db.t (a UInt8) ENGINE = Memory;\nCREATE TABLE IF NOT EXISTS db.u (a UInt8) ENGINE = Memory;")},
+		"ch/000001_init.down.sql":     {Data: []byte("DROP TABLE db.t;")},
+		"ch/README.md":                {Data: []byte("notes")},
+		"ch/000003_add_view.up.sql":   {Data: []byte("-- a view\nCREATE VIEW IF NOT EXISTS db.v AS SELECT a FROM db.t;")},
+	}
+}
+
+// TestRepoMigrationsSplit checks that every embedded ClickHouse migration
+// splits into statements to run.
+func TestRepoMigrationsSplit(t *testing.T) {
+	all, err := migrations.Load(migrations.ClickHouse, "clickhouse")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, m := range all {
+		if len(splitStatements(m.SQL)) == 0 {
+			t.Errorf("%s has no statements", m.Name)
+		}
+	}
+}
+
+func TestUp_AppliesPendingMigrationsOnce(t *testing.T) {
+	all, _ := migrations.Load(testMigrations(), "ch")
+	conn := &fakeConn{}
+	r := NewRunner(conn, "db", logger.New("migrate-test"))
+	ctx := context.Background()
+
+	n, err := r.Up(ctx, all)
+	if err != nil || n != 3 {
+		t.Fatalf("expected 3 migrations applied, got %d, %v", n, err)
+	}
+	if len(conn.executed) != 4 {
+		t.Fatalf("expected 4 statements, got %d: %q", len(conn.executed), conn.executed)
+	}
+
+	// A second run, as on every later startup, does nothing.
+	n, err = r.Up(ctx, all)
+	if err != nil || n != 0 {
+		t.Errorf("expected no migrations on the second run, got %d, %v", n, err)
+	}
+	if len(conn.executed) != 4 {
+		t.Errorf("expected no statements on the second run, got %q", conn.executed[4:])
+	}
+}
+
+// TestUp_RetriesFailedMigration verifies that a migration that fails is not
+// recorded, stops the run, and is applied on the next one.
+func TestUp_RetriesFailedMigration(t *testing.T) {
+	all, _ := migrations.Load(testMigrations(), "ch")
+	conn := &fakeConn{failOn: "ADD COLUMN"}
+	r := NewRunner(conn, "db", logger.New("migrate-test"))
+	ctx := context.Background()
+
+	n, err := r.Up(ctx, all)
+	if err == nil || n != 1 {
+		t.Fatalf("expected the run to stop after 1 migration with an error, got %d, %v", n, err)
+	}
+	if !reflect.DeepEqual(conn.recorded, []uint64{1}) {
+		t.Errorf("expected only version 1 recorded, got %v", conn.recorded)
+	}
+
+	conn.failOn = ""
+	if n, err := r.Up(ctx, all); err != nil || n != 2 {
+		t.Errorf("expected the remaining 2 migrations on retry, got %d, %v", n, err)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	sql := `-- header; not a statement
+CREATE TABLE t (s String DEFAULT 'a;b', q String DEFAULT 'it\'s; fine');
+
+-- trailing comment
+INSERT INTO t VALUES ('x')`
+	got := splitStatements(sql)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 statements, got %d: %q", len(got), got)
+	}
+	if !strings.Contains(got[0], `'a;b'`) || !strings.Contains(got[0], `'it\'s; fine'`) {
+		t.Errorf("expected quoted semicolons kept, got %q", got[0])
+	}
+	if got[1] != "INSERT INTO t VALUES ('x')" {
+		t.Errorf("expected the last statement without a semicolon, got %q", got[1])
+	}
+}
+
+// TestUp_LiveClickHouse applies the repository's migrations to a real
+// server twice: the first run must succeed and the second must be a no-op.
+// It runs only when CLICKHOUSE_TEST_ADDR points at a disposable ClickHouse,
+// since the migrations create the analytics database there.
+func TestUp_LiveClickHouse(t *testing.T) {
+	addr := os.Getenv("CLICKHOUSE_TEST_ADDR")
+	if addr == "" {
+		t.Skip("CLICKHOUSE_TEST_ADDR not set")
+	}
+	conn, err := clickhouse.Open(&clickhouse.Options{
+		Addr: []string{addr},
+		Auth: clickhouse.Auth{
+			Database: "default",
+			Username: os.Getenv("CLICKHOUSE_TEST_USERNAME"),
+			Password: os.Getenv("CLICKHOUSE_TEST_PASSWORD"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	all, err := migrations.Load(migrations.ClickHouse, "clickhouse")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Track this run in a database of its own, so the test does not depend
+	// on what earlier runs recorded.
+	tracking := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	defer func() { _ = conn.Exec(context.Background(), "DROP DATABASE IF EXISTS "+tracking) }()
+	r := NewRunner(conn, tracking, logger.New("migrate-test"))
+
+	if n, err := r.Up(ctx, all); err != nil || n != len(all) {
+		t.Fatalf("expected all %d migrations applied, got %d, %v", len(all), n, err)
+	}
+	if n, err := r.Up(ctx, all); err != nil || n != 0 {
+		t.Fatalf("expected the second run to apply nothing, got %d, %v", n, err)
+	}
+
+	var cols []struct {
+		Name string `ch:"name"`
+	}
+	err = conn.Select(ctx, &cols, "SELECT name FROM system.columns WHERE database = 'analytics' AND table = 'click_events' AND name = 'is_duplicate'")
+	if err != nil || len(cols) != 1 {
+		t.Errorf("expected click_events to have the latest column, got %v, %v", cols, err)
+	}
+}
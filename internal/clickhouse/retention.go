@@ -0,0 +1,116 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clock"
+	"github.com/Varun5711/shorternit/internal/lock"
+	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// RetentionInterval is how often the pipeline-worker deletes expired click
+// events, and the leader lease its replicas take to do so.
+const RetentionInterval = 24 * time.Hour
+
+// retentionLockKey is the Redis key the pipeline-worker replicas compete for.
+const retentionLockKey = "lock:clickhouse-retention"
+
+// deleteClicksQuery deletes the raw click events dated before a day. Only
+// click_events is touched: the materialized views store their rollups in
+// tables of their own, which a mutation of the source table does not reach,
+// so the aggregated analytics outlive the raw events.
+const deleteClicksQuery = "ALTER TABLE click_events DELETE WHERE clicked_date < toDate(?)"
+
+// RetentionCutoff returns the first day, in UTC, whose click events are kept
+// when keeping days days as of now: events from before it are deleted. The
+// current day is not counted, so a day's events are kept for days full days
+// after it.
+func RetentionCutoff(now time.Time, days int) time.Time {
+	y, m, d := now.UTC().Date()
+	return time.Date(y, m, d-days, 0, 0, 0, 0, time.UTC)
+}
+
+// DeleteClicksBefore deletes the raw click events dated before cutoff's day.
+// ClickHouse runs the deletion as a mutation in the background; the call
+// returns once it is scheduled.
+func (c *Client) DeleteClicksBefore(ctx context.Context, cutoff time.Time) error {
+	if err := c.conn.Exec(ctx, deleteClicksQuery, cutoff.Format(time.DateOnly)); err != nil {
+		return fmt.Errorf("failed to delete click events before %s: %w", cutoff.Format(time.DateOnly), err)
+	}
+	return nil
+}
+
+// clickDeleter deletes old click events. It is satisfied by *Client; the
+// tests substitute a recorder.
+type clickDeleter interface {
+	DeleteClicksBefore(ctx context.Context, cutoff time.Time) error
+}
+
+// Retention enforces ANALYTICS_RETENTION_DAYS, deleting the raw click events
+// older than that once a day. Like the cleanup-worker's passes, a run is
+// leased through Redis for a full interval, so only one pipeline-worker
+// replica schedules the mutation each day.
+type Retention struct {
+	store   clickDeleter
+	days    int
+	clock   clock.Clock
+	newLock func() lock.Locker // nil runs every pass without election
+	log     *logger.Logger
+}
+
+// NewRetention creates a Retention keeping days days of click events in
+// client's database, coordinating its runs through a lease in redisClient.
+func NewRetention(client *Client, days int, redisClient *redis.Client, log *logger.Logger) *Retention {
+	return &Retention{
+		store: client,
+		days:  days,
+		clock: clock.Real{},
+		newLock: func() lock.Locker {
+			return lock.NewDistributedLock(redisClient, retentionLockKey, RetentionInterval)
+		},
+		log: log,
+	}
+}
+
+// Run deletes expired click events immediately and then every
+// RetentionInterval until ctx is cancelled.
+func (r *Retention) Run(ctx context.Context) {
+	r.RunOnce(ctx)
+
+	ticker := time.NewTicker(RetentionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce deletes the click events from before RetentionCutoff, if this
+// replica takes the lease. Errors are logged; the next run retries.
+func (r *Retention) RunOnce(ctx context.Context) {
+	if r.newLock != nil {
+		acquired, err := r.newLock().Acquire(ctx)
+		if err != nil {
+			r.log.Error("Failed to acquire retention lease, skipping this run: %v", err)
+			return
+		}
+		if !acquired {
+			return
+		}
+	}
+
+	cutoff := RetentionCutoff(r.clock.Now(), r.days)
+	if err := r.store.DeleteClicksBefore(ctx, cutoff); err != nil {
+		r.log.Error("%v", err)
+		return
+	}
+	r.log.Info("Deleting click events from before %s (ANALYTICS_RETENTION_DAYS=%d)", cutoff.Format(time.DateOnly), r.days)
+}
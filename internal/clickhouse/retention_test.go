@@ -0,0 +1,67 @@
+package clickhouse
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/Varun5711/shorternit/internal/clock/clocktest"
+	"github.com/Varun5711/shorternit/internal/logger"
+)
+
+// execRecorder is a connection that records the statements executed on it.
+type execRecorder struct {
+	driver.Conn
+	queries []string
+	args    [][]any
+}
+
+func (c *execRecorder) Exec(ctx context.Context, query string, args ...any) error {
+	c.queries = append(c.queries, query)
+	c.args = append(c.args, args)
+	return nil
+}
+
+func TestRetentionCutoff(t *testing.T) {
+	for _, tc := range []struct {
+		now  time.Time
+		days int
+		want string
+	}{
+		{time.Date(2030, 3, 15, 0, 0, 0, 0, time.UTC), 30, "2030-02-13"},
+		{time.Date(2030, 3, 15, 23, 59, 59, 0, time.UTC), 30, "2030-02-13"},
+		{time.Date(2030, 3, 1, 12, 0, 0, 0, time.UTC), 1, "2030-02-28"},
+		{time.Date(2030, 1, 10, 12, 0, 0, 0, time.UTC), 180, "2029-07-14"},
+		// clicked_date is the UTC day, whatever the worker's zone.
+		{time.Date(2030, 3, 15, 1, 0, 0, 0, time.FixedZone("UTC+3", 3*3600)), 30, "2030-02-12"},
+	} {
+		if got := RetentionCutoff(tc.now, tc.days).Format(time.DateOnly); got != tc.want {
+			t.Errorf("%s keeping %d days: expected cutoff %s, got %s", tc.now, tc.days, tc.want, got)
+		}
+	}
+}
+
+// TestRetention_RunOnce verifies that a run deletes the raw click events
+// from before the cutoff day, and nothing from the rollups.
+func TestRetention_RunOnce(t *testing.T) {
+	conn := &execRecorder{}
+	r := &Retention{
+		store: &Client{conn: conn},
+		days:  90,
+		clock: clocktest.NewFake(time.Date(2030, 6, 1, 8, 30, 0, 0, time.UTC)),
+		log:   logger.New("retention-test"),
+	}
+
+	r.RunOnce(context.Background())
+
+	if len(conn.queries) != 1 {
+		t.Fatalf("expected one statement, got %q", conn.queries)
+	}
+	if conn.queries[0] != "ALTER TABLE click_events DELETE WHERE clicked_date < toDate(?)" {
+		t.Errorf("expected a deletion from click_events alone, got %q", conn.queries[0])
+	}
+	if len(conn.args[0]) != 1 || conn.args[0][0] != "2030-03-03" {
+		t.Errorf("expected the cutoff 2030-03-03, got %v", conn.args[0])
+	}
+}
@@ -0,0 +1,105 @@
+// Package clicklimit enforces per-link click caps ("burn after N reads") on
+// the redirect path.
+//
+// The authoritative click count in PostgreSQL is updated asynchronously by
+// the analytics-worker, so by the time it reflects a click several more
+// redirects may already have been served. A one-time link must not leak to a
+// second visitor during that window, so the redirect-service keeps its own
+// counter in Redis and increments it synchronously with INCR before every
+// redirect of a capped link. INCR is atomic across all redirect replicas,
+// which makes the Nth click the last one that succeeds no matter how many
+// requests race for it.
+//
+// The Redis counter is reconciled with the database: when it does not exist
+// (first capped redirect, Redis restart or eviction, or TTL expiry) it is
+// seeded from the URL's database click count before incrementing. The TTL
+// bounds how far the two can drift -- once it lapses the next redirect
+// re-reads the database, which by then has caught up with the stream. That
+// only holds because every redirect this counter counts is published: the
+// redirect-service publishes the repeat clicks of capped links, flagged as
+// duplicates, even when dedup drops them for other links.
+package clicklimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix namespaces the per-link counters ("clicklimit:<code>").
+const keyPrefix = "clicklimit:"
+
+// incrIfExists increments the counter only when it already exists and returns
+// -1 otherwise. Doing the existence check and the INCR in one script stops a
+// plain INCR from silently creating a counter at 1 and skipping the seed.
+var incrIfExists = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("INCR", KEYS[1])
+end
+return -1
+`)
+
+// SeedFunc returns the current authoritative (database) click count for a
+// short code. It is only called when the Redis counter is missing.
+type SeedFunc func(ctx context.Context) (int64, error)
+
+// Counter maintains the Redis-side click counters for capped links.
+type Counter struct {
+	client *redis.Client
+	ttl    time.Duration // lifetime of a seeded counter before it is re-read from the database
+}
+
+// NewCounter creates a Counter. ttl controls how long a counter lives before
+// it is reseeded from the database; it defaults to 24 hours when zero.
+func NewCounter(client *redis.Client, ttl time.Duration) *Counter {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return &Counter{
+		client: client,
+		ttl:    ttl,
+	}
+}
+
+// Incr records one redirect attempt for shortCode and returns the resulting
+// count, including this attempt. Callers compare the result against the
+// link's cap: a count equal to the cap is the last allowed redirect.
+//
+// When the counter is missing, seed supplies the database count. SETNX makes
+// concurrent seeders agree on a single starting value; whichever replica wins,
+// every replica then INCRs the same key.
+func (c *Counter) Incr(ctx context.Context, shortCode string, seed SeedFunc) (int64, error) {
+	key := Key(shortCode)
+
+	n, err := incrIfExists.Run(ctx, c.client, []string{key}).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment click counter: %w", err)
+	}
+	if n >= 0 {
+		return n, nil
+	}
+
+	base, err := seed(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to seed click counter: %w", err)
+	}
+
+	if err := c.client.SetNX(ctx, key, base, c.ttl).Err(); err != nil {
+		return 0, fmt.Errorf("failed to seed click counter: %w", err)
+	}
+
+	n, err = c.client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment click counter: %w", err)
+	}
+	return n, nil
+}
+
+// Key returns the Redis key of shortCode's counter. The url-service deletes it
+// alongside the cache entry when a link is deleted, so a new link that reuses
+// the alias starts from its own database count.
+func Key(shortCode string) string {
+	return keyPrefix + shortCode
+}
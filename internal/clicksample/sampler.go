@@ -0,0 +1,95 @@
+// Package clicksample thins out the raw click events stored for very busy
+// links.
+//
+// A link clicked millions of times costs as many rows in ClickHouse, while
+// what its owner looks at are counts and breakdowns. A Sampler keeps only
+// one in every N enriched events of a link and records N on each kept event
+// (ClickEvent.SampleRate), so every analytics query sums sample_rate instead
+// of counting rows and the breakdowns stay scaled to the real traffic.
+//
+// Sampling happens in the pipeline-worker, just before events are stored.
+// The exact click count in PostgreSQL is kept by the analytics-worker from
+// the same stream and never sampled, and webhooks still fire for every
+// click.
+package clicksample
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Varun5711/shorternit/internal/clickhouse"
+)
+
+// Sampler decides which click events are stored. The rate for a link is its
+// entry in the per-link rates if it has one, otherwise the global rate; a
+// rate of 1 or less stores every event. A Sampler is not safe for concurrent
+// use; the pipeline-worker samples its batches one at a time.
+type Sampler struct {
+	rate      int
+	linkRates map[string]int
+
+	// seen counts each link's events modulo its rate. A link is dropped from
+	// the map whenever its count wraps to 0, so it only holds links partway
+	// through a cycle.
+	seen map[string]int
+}
+
+// NewSampler returns a Sampler storing one in rate events of every link, or
+// one in linkRates[code] for the links listed there.
+func NewSampler(rate int, linkRates map[string]int) *Sampler {
+	return &Sampler{rate: rate, linkRates: linkRates, seen: make(map[string]int)}
+}
+
+// ParseLinkRates parses per-link rates given as "short_code=N" entries, as
+// in CLICK_SAMPLE_LINK_RATES. Every N must be a positive integer.
+func ParseLinkRates(entries []string) (map[string]int, error) {
+	rates := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		code, n, ok := strings.Cut(entry, "=")
+		code = strings.TrimSpace(code)
+		rate, err := strconv.Atoi(strings.TrimSpace(n))
+		if !ok || code == "" || err != nil || rate < 1 {
+			return nil, fmt.Errorf("invalid click sample rate %q: expected short_code=N with N >= 1", entry)
+		}
+		rates[code] = rate
+	}
+	return rates, nil
+}
+
+// Rate returns how many clicks on shortCode each stored event stands for.
+func (s *Sampler) Rate(shortCode string) int {
+	rate, ok := s.linkRates[shortCode]
+	if !ok {
+		rate = s.rate
+	}
+	return max(rate, 1)
+}
+
+// SampleBatch returns the events of the batch to store, in order, with
+// SampleRate set on each. For each link the first of every N of its events
+// is kept, counting across batches, so over a whole number of cycles the
+// kept events' sample rates add up to exactly the clicks seen. events is not
+// modified.
+func (s *Sampler) SampleBatch(events []clickhouse.ClickEvent) []clickhouse.ClickEvent {
+	kept := make([]clickhouse.ClickEvent, 0, len(events))
+	for _, ev := range events {
+		rate := s.Rate(ev.ShortCode)
+		ev.SampleRate = uint32(rate)
+		if rate == 1 {
+			kept = append(kept, ev)
+			continue
+		}
+
+		n := s.seen[ev.ShortCode]
+		if n == 0 {
+			kept = append(kept, ev)
+		}
+		if n = (n + 1) % rate; n == 0 {
+			delete(s.seen, ev.ShortCode)
+		} else {
+			s.seen[ev.ShortCode] = n
+		}
+	}
+	return kept
+}
@@ -0,0 +1,91 @@
+package clicksample
+
+import (
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/clickhouse"
+)
+
+// batch returns n click events on shortCode.
+func batch(shortCode string, n int) []clickhouse.ClickEvent {
+	events := make([]clickhouse.ClickEvent, n)
+	for i := range events {
+		events[i] = clickhouse.ClickEvent{ShortCode: shortCode}
+	}
+	return events
+}
+
+// TestSampleBatch_KeepsCountsExact streams 10,000 clicks on a hot link
+// sampled 1 in 100 alongside a quiet link, in batches that do not line up
+// with the rate: the hot link stores 100 rows whose sample rates still add
+// up to every click, and the quiet link stores all of its clicks.
+func TestSampleBatch_KeepsCountsExact(t *testing.T) {
+	s := NewSampler(1, map[string]int{"hot": 100})
+
+	rows := make(map[string]int)
+	clicks := make(map[string]uint64)
+	for range 40 {
+		events := append(batch("hot", 250), batch("quiet", 3)...)
+		for _, ev := range s.SampleBatch(events) {
+			rows[ev.ShortCode]++
+			clicks[ev.ShortCode] += uint64(ev.SampleRate)
+		}
+	}
+
+	if rows["hot"] != 100 || clicks["hot"] != 10000 {
+		t.Errorf("hot link: expected 100 rows counting 10000 clicks, got %d rows counting %d", rows["hot"], clicks["hot"])
+	}
+	if rows["quiet"] != 120 || clicks["quiet"] != 120 {
+		t.Errorf("quiet link: expected all 120 clicks stored, got %d rows counting %d", rows["quiet"], clicks["quiet"])
+	}
+	if len(s.seen) != 0 {
+		t.Errorf("expected no links mid-cycle after whole cycles, got %v", s.seen)
+	}
+}
+
+// TestSampleBatch_DefaultStoresEverything verifies that the default rate of
+// 1 keeps every event, marked as standing for one click, without touching
+// the input.
+func TestSampleBatch_DefaultStoresEverything(t *testing.T) {
+	events := batch("abc", 5)
+	got := NewSampler(1, nil).SampleBatch(events)
+	if len(got) != 5 {
+		t.Fatalf("expected every event kept, got %d", len(got))
+	}
+	for i, ev := range got {
+		if ev.SampleRate != 1 {
+			t.Errorf("event %d: expected sample rate 1, got %d", i, ev.SampleRate)
+		}
+		if events[i].SampleRate != 0 {
+			t.Errorf("event %d: expected the input batch to be left alone", i)
+		}
+	}
+}
+
+// TestSampleBatch_GlobalRateAndOverride verifies that the global rate
+// applies to every link without its own rate, and a per-link rate of 1
+// exempts a link from it.
+func TestSampleBatch_GlobalRateAndOverride(t *testing.T) {
+	s := NewSampler(10, map[string]int{"exempt": 1})
+	got := s.SampleBatch(append(batch("busy", 30), batch("exempt", 30)...))
+
+	rows := make(map[string]int)
+	for _, ev := range got {
+		rows[ev.ShortCode]++
+	}
+	if rows["busy"] != 3 || rows["exempt"] != 30 {
+		t.Errorf("expected 3 busy and 30 exempt rows, got %v", rows)
+	}
+}
+
+func TestParseLinkRates(t *testing.T) {
+	rates, err := ParseLinkRates([]string{"hot=100", " promo = 10 "})
+	if err != nil || rates["hot"] != 100 || rates["promo"] != 10 {
+		t.Fatalf("expected hot=100 and promo=10, got %v, %v", rates, err)
+	}
+	for _, bad := range []string{"hot", "=5", "hot=0", "hot=-3", "hot=many"} {
+		if _, err := ParseLinkRates([]string{bad}); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
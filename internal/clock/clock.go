@@ -0,0 +1,24 @@
+// Package clock abstracts reading the current time.
+//
+// Code whose behaviour depends on the time -- token expiry, link expiry and
+// scheduling, rate-limit windows, rolling analytics windows -- reads it from
+// a Clock rather than calling time.Now, so tests can run it at a chosen
+// instant and move time forward with clocktest.Fake instead of sleeping or
+// building already-expired fixtures with negative durations. Production
+// code uses Real.
+package clock
+
+import "time"
+
+// Clock tells the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// Real is the Clock of the system's wall time.
+type Real struct{}
+
+// Now returns time.Now().
+func (Real) Now() time.Time {
+	return time.Now()
+}
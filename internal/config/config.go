@@ -28,12 +28,30 @@ type Config struct {
 	Elasticsearch ElasticsearchConfig
 	Tracing       TracingConfig
 	Services      ServicesConfig
+	GRPC          GRPCConfig
 	Analytics     AnalyticsConfig
 	Snowflake     SnowflakeConfig
 	Cache         CacheConfig
+	AliasFilter   AliasFilterConfig
+	Webhooks      WebhookConfig
+	Preview       PreviewConfig
 	RateLimit     RateLimitConfig
+	Idempotency   IdempotencyConfig
+	Anonymous     AnonymousConfig
+	Quota         QuotaConfig
+	Cleanup       CleanupConfig
+	Login         LoginConfig
+	QRCode        QRCodeConfig
+	GeoIP         GeoIPConfig
+	ClickDedup    ClickDedupConfig
+	ClickSample   ClickSampleConfig
+	ClickSigning  ClickSigningConfig
 	CORS          CORSConfig
+	RedirectPages RedirectPagesConfig
 	JWT           JWTConfig
+	Startup       StartupConfig
+	PipelineAlert PipelineAlertConfig
+	Failover      FailoverConfig
 }
 
 // TracingConfig holds settings for distributed tracing via OpenTelemetry/Jaeger.
@@ -56,25 +74,63 @@ type ElasticsearchConfig struct {
 	Enabled     bool
 }
 
+// StartupConfig sets how services wait for Redis, PostgreSQL and
+// ClickHouse when they are not yet reachable at startup, as during an
+// ordered deploy. Each connection is tried up to ConnectAttempts times,
+// waiting ConnectBackoff after the first failure and twice as long after
+// each further one (see retry.Dial).
+type StartupConfig struct {
+	ConnectAttempts int
+	ConnectBackoff  time.Duration
+}
+
+// PipelineAlertConfig sets when GET /api/admin/pipeline reports a consumer
+// group of the click stream as degraded: more than MaxPending entries
+// delivered but unacknowledged, an oldest pending entry older than
+// MaxPendingAge, or, while the group has entries to process, no worker of it
+// having flushed a batch for MaxFlushAge. A zero value disables its check.
+type PipelineAlertConfig struct {
+	MaxPending    int64
+	MaxPendingAge time.Duration
+	MaxFlushAge   time.Duration
+}
+
+// FailoverConfig drives the redirect service's health checks of links with a
+// backup destination. Each primary is probed every ProbeInterval, allowing
+// ProbeTimeout for an answer; a zero ProbeInterval disables failover, and
+// every link then redirects to its primary.
+type FailoverConfig struct {
+	ProbeInterval time.Duration
+	ProbeTimeout  time.Duration
+}
+
 // CORSConfig specifies which origins are allowed to make cross-origin requests
 // to the API gateway. In production this should be set to the frontend domain(s).
 type CORSConfig struct {
 	AllowedOrigins []string
 }
 
-// JWTConfig holds the HMAC-SHA256 secret and token lifetime for the auth
-// package's JWTManager. The Secret must be kept confidential; the
-// TokenDuration controls how long access tokens remain valid before the
-// client must re-authenticate.
+// JWTConfig holds the HMAC-SHA256 secret and token lifetimes for the auth
+// package's JWTManager. The Secret must be kept confidential; AccessTTL
+// controls how long access tokens remain valid before the client must
+// re-authenticate.
 type JWTConfig struct {
-	Secret        string
-	TokenDuration time.Duration
+	Secret    string
+	AccessTTL time.Duration
 }
 
 // DatabaseConfig holds PostgreSQL connection parameters for both the primary
 // (read-write) instance and up to N read replicas. The connection pool
 // settings (MaxConns, MinConns, lifetimes) apply uniformly to all pools.
 type DatabaseConfig struct {
+	// Backend selects where the url-service and cleanup-worker keep URLs:
+	// "postgres" (the default) or "memory", an in-process store that needs
+	// no database and is lost on restart. With "memory" no PostgreSQL
+	// connection is made, so the features stored only there (webhooks,
+	// custom domains, per-plan quotas) are unavailable, and each process
+	// has its own store.
+	Backend string
+
 	// PrimaryDSN is the PostgreSQL connection string for the read-write primary.
 	PrimaryDSN string
 
@@ -102,25 +158,41 @@ type DatabaseConfig struct {
 	// before being closed. This prevents accumulation of stale connections
 	// during low-traffic periods.
 	MaxConnIdleTime time.Duration
+
+	// QueryTimeout caps each storage call, so a stuck query cannot hold a
+	// request or a connection indefinitely. 0 leaves calls bounded only by
+	// their request's context.
+	QueryTimeout time.Duration
+
+	// AutoMigrate makes the url-service apply pending schema migrations to
+	// the primary on startup. Off by default; cmd/migrate does the same as a
+	// separate deployment step.
+	AutoMigrate bool
 }
 
 // RedisConfig holds connection parameters for the Redis instance used for
 // caching (L2 cache layer) and as a message broker (click event streams).
+// StreamMaxLen caps the click stream at about that many entries, trimmed on
+// every publish; 0 leaves it unbounded.
 type RedisConfig struct {
-	Addr       string
-	Password   string
-	DB         int
-	StreamName string
+	Addr         string
+	Password     string
+	DB           int
+	StreamName   string
+	StreamMaxLen int64
 }
 
 // ClickHouseConfig holds connection parameters for the ClickHouse analytics
 // database, which stores aggregated click event data for reporting.
+// AutoMigrate makes the pipeline-worker apply pending schema migrations on
+// startup.
 type ClickHouseConfig struct {
-	Addr     string
-	Database string
-	Username string
-	Password string
-	MaxConns int
+	Addr        string
+	Database    string
+	Username    string
+	Password    string
+	MaxConns    int
+	AutoMigrate bool
 }
 
 // ServicesConfig holds addresses, ports, and settings for inter-service
@@ -129,6 +201,10 @@ type ServicesConfig struct {
 	// URLServiceAddr is the gRPC address of the URL shortening service.
 	URLServiceAddr string
 
+	// UserServiceAddr is the gRPC address of the user service, which
+	// validates tokens for the API gateway and the redirect service.
+	UserServiceAddr string
+
 	// APIGatewayPort is the HTTP port the API gateway listens on.
 	APIGatewayPort string
 
@@ -142,24 +218,136 @@ type ServicesConfig struct {
 	// DefaultURLTTL is the default time-to-live for shortened URLs when
 	// the user does not specify a custom expiration.
 	DefaultURLTTL time.Duration
+
+	// RedirectMaxAge is how long browsers may cache a redirect; 0 forbids
+	// caching redirects at all.
+	RedirectMaxAge time.Duration
+
+	// WildcardCacheTTL is how long the redirect service keeps a domain's
+	// wildcard aliases before fetching them again; 0 disables wildcards.
+	WildcardCacheTTL time.Duration
+
+	// ShortCodeMinLength is the minimum length of generated short codes;
+	// shorter encodings are left-padded with '0'. 0 disables padding.
+	ShortCodeMinLength int
+
+	// ShortCodeAttempts is how many generated short codes are tried for one
+	// link when they collide with existing ones before the create fails
+	// with a retryable error.
+	ShortCodeAttempts int
+
+	// AllowLinkChaining accepts destinations on the shortener's own
+	// hosts, such as another short link. Off, they are refused so links
+	// cannot loop or hide their real destination behind a second hop.
+	AllowLinkChaining bool
+
+	// TrustProxy turns on forwarding headers. Off, as when the services are
+	// exposed directly, every client is identified by the address of its
+	// connection and TrustedProxies is ignored.
+	TrustProxy bool
+
+	// TrustedProxies lists the CIDR prefixes (or single addresses) of the
+	// load balancers and ingress controllers in front of the HTTP services.
+	// With TrustProxy on, forwarding headers are only honoured on
+	// connections from these, so clients cannot spoof the IP they are rate
+	// limited and geo-targeted as.
+	TrustedProxies []string
+
+	// Debug exposes internal detail, such as recovered panic messages and
+	// stack traces, in HTTP error responses. Never enable it in production.
+	Debug bool
 }
 
-// AnalyticsConfig holds settings for the Redis Streams consumer that
-// processes click events and writes them to ClickHouse in batches.
+// GRPCConfig tunes the gRPC connections between services. Clients ping idle
+// connections so load balancers and NAT gateways do not silently drop them,
+// and reconnect with exponential backoff when a connection breaks.
+//
+// With TLSEnabled, servers present TLSCertFile/TLSKeyFile and clients verify
+// them against TLSCAFile; with TLSMutual as well, servers also require a
+// client certificate signed by TLSCAFile and clients present
+// TLSCertFile/TLSKeyFile as theirs. TLS is off by default so local
+// development needs no certificates.
+type GRPCConfig struct {
+	// DialTimeout bounds how long a client waits at startup for its
+	// connection to become ready. A service that is not up yet is logged
+	// and retried in the background rather than failing startup.
+	DialTimeout time.Duration
+
+	// KeepaliveTime is how long a connection may sit idle before the client
+	// pings it. Servers reject pings more frequent than 10 seconds.
+	KeepaliveTime time.Duration
+
+	// KeepaliveTimeout is how long the client waits for a ping ack before
+	// closing the connection and reconnecting.
+	KeepaliveTimeout time.Duration
+
+	// MaxBackoff caps the delay between reconnection attempts.
+	MaxBackoff time.Duration
+
+	// CallTimeout is the deadline given to a unary call whose context has
+	// none, so a hung backend cannot hold the caller forever. Zero disables
+	// it.
+	CallTimeout time.Duration
+
+	// RedirectPoolSize is the number of url-service connections the
+	// redirect service spreads its lookups over.
+	RedirectPoolSize int
+
+	TLSEnabled  bool
+	TLSMutual   bool
+	TLSCertFile string // PEM certificate presented by servers (and clients under mTLS)
+	TLSKeyFile  string // PEM private key for TLSCertFile
+	TLSCAFile   string // PEM CA bundle that signs peer certificates; "" = system roots for clients
+
+	// TLSServerName overrides the name clients verify the server
+	// certificate against. Empty means the host part of the dialed address.
+	TLSServerName string
+}
+
+// AnalyticsConfig holds settings for the Redis Streams consumers that
+// process click events in batches, plus the cache TTL the API gateway
+// applies to per-link stats (0 disables caching).
+//
+// The analytics-worker (Postgres click counts) and the pipeline-worker
+// (ClickHouse) each read the whole click stream, so each needs its own
+// consumer group: two workers in one group would split the events between
+// them. ConsumerName identifies a replica within its group. EnrichWorkers
+// bounds how many events of a batch the pipeline-worker enriches at once.
+// HeatmapPrecision is how many decimal places of a degree the gateway's
+// click heatmap rounds locations to (see clickhouse.GetGeoPoints).
 type AnalyticsConfig struct {
-	ConsumerGroup string
-	ConsumerName  string
-	BatchSize     int
-	PollInterval  time.Duration
-	BlockTime     time.Duration
+	ConsumerGroup         string // analytics-worker's group
+	PipelineConsumerGroup string // pipeline-worker's group
+	ConsumerName          string
+	BatchSize             int
+	PollInterval          time.Duration
+	BlockTime             time.Duration
+	StatsCacheTTL         time.Duration
+	EnrichWorkers         int
+	HeatmapPrecision      int
+	IPMode                string        // enrichment.IPMode*: how much of a visitor's IP the pipeline-worker stores
+	IPHashSalt            string        // salt of the "hash" IP mode
+	Fields                []string      // enrichment.Field* click-event fields recorded; the rest are stored blank
+	ProcessedTTL          time.Duration // how long the pipeline-worker remembers stored entries to skip redeliveries; 0 disables
+	Public                bool          // the gateway serves every link's analytics to anyone, not just its owner and share tokens
+	RetentionDays         int           // days of raw click events the pipeline-worker keeps in ClickHouse; 0 keeps them forever
 }
 
 // SnowflakeConfig holds the datacenter and worker IDs passed to the
 // Snowflake ID generator. Each deployment instance must have a unique
 // (DatacenterID, WorkerID) pair to guarantee globally unique IDs.
+//
+// WorkerIDSource picks where the worker ID comes from: "static" (the
+// default) uses WorkerID as is; "pod" takes the ordinal of PodName, for
+// StatefulSet pods such as "url-service-2"; "redis" leases a free ID in
+// Redis for LeaseTTL at a time, renewed while the process runs and released
+// on shutdown. When "pod" or "redis" cannot provide an ID, WorkerID is used.
 type SnowflakeConfig struct {
-	DatacenterID int64
-	WorkerID     int64
+	DatacenterID   int64
+	WorkerID       int64
+	WorkerIDSource string
+	PodName        string
+	LeaseTTL       time.Duration
 }
 
 // CacheConfig controls the two-tier caching layer. L1 is an in-process LRU
@@ -171,13 +359,207 @@ type CacheConfig struct {
 
 	// L2TTL is the time-to-live for entries in the Redis L2 cache.
 	L2TTL time.Duration
+
+	// WarmTopN is how many of the most-clicked links the redirect service
+	// loads into the cache at startup. 0 disables warming.
+	WarmTopN int
+}
+
+// AliasFilterConfig sizes the in-process Bloom filter the url-service uses to
+// skip availability checks for custom aliases that have never been seen.
+// ExpectedItems should comfortably exceed the number of rows in the urls
+// table; once exceeded, the false positive rate climbs and more requests fall
+// back to the lock-and-check path. SyncInterval is how often each replica
+// adds the codes created elsewhere since its last pass. Setting Enabled to
+// false disables the filter entirely.
+type AliasFilterConfig struct {
+	Enabled           bool
+	ExpectedItems     int
+	FalsePositiveRate float64
+	SyncInterval      time.Duration
+}
+
+// WebhookConfig controls how the pipeline-worker delivers click webhooks:
+// delivery concurrency, the retry schedule for a single delivery, and how
+// many consecutive failed deliveries disable a webhook.
+type WebhookConfig struct {
+	Enabled        bool
+	Workers        int
+	QueueSize      int
+	MaxRetries     int
+	InitialBackoff time.Duration
+	Timeout        time.Duration
+	MaxFailures    int
+}
+
+// PreviewConfig controls link previews: the url-service fetching each new
+// link's destination for its title, description and image. It makes
+// outbound requests to arbitrary sites, so it is off unless Enabled.
+// Timeout bounds each fetch, robots.txt included, MaxBytes how much of a
+// page is read, and Workers how many fetches run at once.
+type PreviewConfig struct {
+	Enabled  bool
+	Timeout  time.Duration
+	MaxBytes int64
+	Workers  int
 }
 
-// RateLimitConfig controls the sliding-window rate limiter applied to API
-// requests. Requests is the maximum allowed count within the Window duration.
+// RateLimitConfig controls the rate limiter applied to API requests and
+// redirects. Requests is the maximum allowed count within the Window
+// duration. Algorithm is how the api-gateway counts them and
+// RedirectAlgorithm how the redirect service does: "sliding_window", exact,
+// or "fixed_window", cheaper but allowing bursts of up to twice the limit
+// across two windows.
+// The gateway overrides it for login and registration, limited per IP by
+// AuthRequests per AuthWindow to slow credential stuffing, for the
+// analytics endpoints, limited per user by AnalyticsRequests per
+// AnalyticsWindow since their ClickHouse queries are the most expensive,
+// and for anonymous link creation, limited per IP by AnonymousRequests per
+// AnonymousWindow.
 type RateLimitConfig struct {
-	Requests int
-	Window   time.Duration
+	Requests          int
+	Window            time.Duration
+	Algorithm         string
+	RedirectAlgorithm string
+	AuthRequests      int
+	AuthWindow        time.Duration
+	AnalyticsRequests int
+	AnalyticsWindow   time.Duration
+	AnonymousRequests int
+	AnonymousWindow   time.Duration
+}
+
+// AnonymousConfig controls link creation without an account, for
+// deployments run as a public shortener. With Enabled the gateway serves
+// POST /api/urls/anonymous without authentication. Anonymous links have no
+// owner to manage or delete them, so each expires within LinkTTL (0 leaves
+// them unbounded) and the cleanup-worker removes it like any expired link.
+type AnonymousConfig struct {
+	Enabled bool
+	LinkTTL time.Duration
+}
+
+// CleanupConfig controls the cleanup-worker, which deletes expired URLs and
+// evicts them from the cache every Interval.
+type CleanupConfig struct {
+	Interval time.Duration
+}
+
+// LoginConfig controls the user-service's lockout of repeated failed
+// logins. An email is locked after MaxFailures failures within
+// FailureWindow, a client IP after MaxIPFailures. The first lockout lasts
+// LockoutBase and each further one doubles it, up to LockoutMax.
+//
+// The client IP the gateway forwards is only believed from the gateway
+// itself: under mutual TLS, a peer whose certificate names one of
+// GatewayIdentities; otherwise, a peer connecting from GatewayAddrs (CIDR
+// prefixes or single addresses). Other callers count against their own
+// address.
+type LoginConfig struct {
+	MaxFailures       int
+	MaxIPFailures     int
+	FailureWindow     time.Duration
+	LockoutBase       time.Duration
+	LockoutMax        time.Duration
+	GatewayIdentities []string
+	GatewayAddrs      []string
+}
+
+// QRCodeConfig selects where QR code images are kept. Backend "db" (the
+// default) stores them inline in the urls table as base64 data URIs;
+// "local" writes them under LocalDir and "s3" uploads them to S3Bucket on an
+// S3-compatible endpoint such as MinIO, leaving only the object key in the
+// table. PublicURL, if set, is where the stored images can be fetched
+// directly; otherwise the gateway proxies them. QR codes the gateway renders
+// on demand are kept in the object store, or with the "db" backend cached in
+// Redis for CacheTTL.
+type QRCodeConfig struct {
+	Backend     string
+	LocalDir    string
+	S3Endpoint  string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3Region    string
+	S3UseSSL    bool
+	PublicURL   string
+	CacheTTL    time.Duration
+}
+
+// GeoIPConfig controls the GeoIP enricher used by the redirect-service for
+// geo rules and by the pipeline-worker for click enrichment. CacheSize is
+// how many IPs' locations are kept in its in-process LRU cache; 0 disables
+// the cache.
+type GeoIPConfig struct {
+	CacheSize int
+}
+
+// ClickDedupConfig controls how the redirect-service and pipeline-worker
+// treat repeat clicks from the same visitor (same short code, IP and
+// User-Agent) within Window, such as double-clicks, prefetchers and
+// link-preview bots. A zero Window disables deduplication. Mode "collapse"
+// records only the first click of a burst; "raw" records every click as
+// before but flags the repeats as duplicates in ClickHouse.
+type ClickDedupConfig struct {
+	Window time.Duration
+	Mode   string
+}
+
+// ClickSampleConfig controls how many of a link's raw click events the
+// pipeline-worker stores in ClickHouse: one in Rate, or one in the N given
+// for the link by a "short_code=N" entry in LinkRates. The default Rate of 1
+// stores every event. Click counts stay exact either way.
+type ClickSampleConfig struct {
+	Rate      int
+	LinkRates []string
+}
+
+// ClickSigningConfig turns on HMAC signing of click events. When Enabled,
+// the redirect-service signs every event it publishes with Secret, and the
+// workers move events whose signature is missing or wrong to
+// DeadLetterStream instead of counting them. Every service must share the
+// same Secret; enabling signing without one fails startup.
+type ClickSigningConfig struct {
+	Enabled          bool
+	Secret           string
+	DeadLetterStream string
+}
+
+// IdempotencyConfig controls Idempotency-Key handling on URL creation. TTL is
+// how long a key's response is remembered and replayed to retries.
+type IdempotencyConfig struct {
+	TTL time.Duration
+}
+
+// RedirectPagesConfig brands the redirect service's own pages. Each template
+// is a path to an html/template file executed with the requested short code
+// and host; an empty path serves plain text instead, or for PreviewTemplate
+// a built-in page. PreviewDelay is how long the preview page waits before
+// following the link itself; 0 leaves it to the visitor. RootURL, when set,
+// redirects the root path there rather than serving LandingTemplate.
+// FaviconFile and RobotsFile are served as-is at /favicon.ico and
+// /robots.txt; without them the favicon is 204 No Content and robots.txt
+// allows everything.
+type RedirectPagesConfig struct {
+	NotFoundTemplate string
+	ExpiredTemplate  string
+	LandingTemplate  string
+	PreviewTemplate  string
+	PreviewDelay     time.Duration
+	RootURL          string
+	FaviconFile      string
+	RobotsFile       string
+}
+
+// QuotaConfig limits the links each signed-in user may have and create.
+// MaxActiveURLs caps their links that have not expired and DailyCreates
+// their creations per UTC day; 0 means unlimited. Plans overrides both for
+// users whose users.plan column names an entry, written as
+// "plan:maxActive:daily" (e.g. "pro:10000:1000").
+type QuotaConfig struct {
+	MaxActiveURLs int
+	DailyCreates  int
+	Plans         []string
 }
 
 // Load reads configuration from environment variables, with optional .env
@@ -194,6 +576,7 @@ func Load() (*Config, error) {
 
 	cfg := &Config{
 		Database: DatabaseConfig{
+			Backend:    getEnv("STORAGE_BACKEND", "postgres"),
 			PrimaryDSN: getEnv("DB_PRIMARY_DSN", ""),
 			ReplicaDSNs: []string{
 				getEnv("DB_REPLICA1_DSN", ""),
@@ -204,45 +587,190 @@ func Load() (*Config, error) {
 			MinConns:        int32(getEnvAsInt("DB_MIN_CONNS", 5)),
 			MaxConnLifetime: getEnvAsDuration("DB_MAX_CONN_LIFETIME", time.Hour),
 			MaxConnIdleTime: getEnvAsDuration("DB_MAX_CONN_IDLE_TIME", 30*time.Minute),
+			QueryTimeout:    getEnvAsDuration("DB_QUERY_TIMEOUT", 5*time.Second),
+			AutoMigrate:     getEnv("DB_AUTO_MIGRATE", "false") == "true",
 		},
 		Redis: RedisConfig{
-			Addr:       getEnv("REDIS_ADDR", "localhost:6379"),
-			Password:   getEnv("REDIS_PASSWORD", ""),
-			DB:         getEnvAsInt("REDIS_DB", 0),
-			StreamName: getEnv("REDIS_STREAM_NAME", "clicks:stream"),
+			Addr:         getEnv("REDIS_ADDR", "localhost:6379"),
+			Password:     getEnv("REDIS_PASSWORD", ""),
+			DB:           getEnvAsInt("REDIS_DB", 0),
+			StreamName:   getEnv("REDIS_STREAM_NAME", "clicks:stream"),
+			StreamMaxLen: int64(getEnvAsInt("REDIS_STREAM_MAXLEN", 0)),
 		},
 		Services: ServicesConfig{
 			URLServiceAddr:      getEnv("URL_SERVICE_ADDR", "localhost:50051"),
+			UserServiceAddr:     getEnv("USER_SERVICE_ADDR", "localhost:50052"),
 			APIGatewayPort:      getEnv("API_GATEWAY_PORT", "8080"),
 			RedirectServicePort: getEnv("REDIRECT_SERVICE_PORT", "8081"),
 			BaseURL:             getEnv("BASE_URL", "http://localhost:8081"),
 			DefaultURLTTL:       getEnvAsDuration("DEFAULT_URL_TTL", 3*24*time.Hour),
+			ShortCodeMinLength:  getEnvAsInt("SHORT_CODE_MIN_LENGTH", 6),
+			ShortCodeAttempts:   getEnvAsInt("SHORT_CODE_MAX_ATTEMPTS", 3),
+			AllowLinkChaining:   getEnv("ALLOW_SHORT_LINK_CHAINING", "false") == "true",
+			RedirectMaxAge:      getEnvAsDuration("REDIRECT_CACHE_MAX_AGE", 5*time.Minute),
+			WildcardCacheTTL:    getEnvAsDuration("WILDCARD_CACHE_TTL", 30*time.Second),
+			Debug:               getEnv("DEBUG", "false") == "true",
+			TrustProxy:          getEnv("TRUST_PROXY", "false") == "true",
+			TrustedProxies: getEnvAsSlice("TRUSTED_PROXIES", []string{
+				"127.0.0.0/8", "::1/128", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7",
+			}),
+		},
+		GRPC: GRPCConfig{
+			DialTimeout:      getEnvAsDuration("GRPC_DIAL_TIMEOUT", 5*time.Second),
+			KeepaliveTime:    getEnvAsDuration("GRPC_KEEPALIVE_TIME", 30*time.Second),
+			KeepaliveTimeout: getEnvAsDuration("GRPC_KEEPALIVE_TIMEOUT", 10*time.Second),
+			MaxBackoff:       getEnvAsDuration("GRPC_MAX_BACKOFF", 10*time.Second),
+			CallTimeout:      getEnvAsDuration("GRPC_CALL_TIMEOUT", 10*time.Second),
+			RedirectPoolSize: getEnvAsInt("GRPC_REDIRECT_POOL_SIZE", 4),
+			TLSEnabled:       getEnv("GRPC_TLS_ENABLED", "false") == "true",
+			TLSMutual:        getEnv("GRPC_TLS_MUTUAL", "false") == "true",
+			TLSCertFile:      getEnv("GRPC_TLS_CERT_FILE", ""),
+			TLSKeyFile:       getEnv("GRPC_TLS_KEY_FILE", ""),
+			TLSCAFile:        getEnv("GRPC_TLS_CA_FILE", ""),
+			TLSServerName:    getEnv("GRPC_TLS_SERVER_NAME", ""),
 		},
 		Analytics: AnalyticsConfig{
-			ConsumerGroup: getEnv("ANALYTICS_CONSUMER_GROUP", "analytics-group"),
-			ConsumerName:  getEnv("ANALYTICS_CONSUMER_NAME", "worker-1"),
-			BatchSize:     getEnvAsInt("ANALYTICS_BATCH_SIZE", 100),
-			PollInterval:  getEnvAsDuration("ANALYTICS_POLL_INTERVAL", time.Second),
-			BlockTime:     getEnvAsDuration("ANALYTICS_BLOCK_TIME", 5*time.Second),
+			ConsumerGroup:         getEnv("ANALYTICS_CONSUMER_GROUP", "analytics-group"),
+			PipelineConsumerGroup: getEnv("PIPELINE_CONSUMER_GROUP", "pipeline-group"),
+			ConsumerName:          getEnv("ANALYTICS_CONSUMER_NAME", "worker-1"),
+			BatchSize:             getEnvAsInt("ANALYTICS_BATCH_SIZE", 100),
+			PollInterval:          getEnvAsDuration("ANALYTICS_POLL_INTERVAL", time.Second),
+			BlockTime:             getEnvAsDuration("ANALYTICS_BLOCK_TIME", 5*time.Second),
+			StatsCacheTTL:         getEnvAsDuration("ANALYTICS_STATS_CACHE_TTL", time.Minute),
+			EnrichWorkers:         getEnvAsInt("PIPELINE_ENRICH_WORKERS", 4),
+			HeatmapPrecision:      getEnvAsInt("ANALYTICS_HEATMAP_PRECISION", 1),
+			IPMode:                getEnv("ANALYTICS_IP_MODE", "full"),
+			IPHashSalt:            getEnv("ANALYTICS_IP_HASH_SALT", ""),
+			Fields:                getEnvAsSlice("ANALYTICS_FIELDS", []string{"ip", "geo", "user_agent", "device", "referer", "query"}),
+			ProcessedTTL:          getEnvAsDuration("PIPELINE_PROCESSED_TTL", 24*time.Hour),
+			Public:                getEnv("ANALYTICS_PUBLIC", "false") == "true",
+			RetentionDays:         getEnvAsInt("ANALYTICS_RETENTION_DAYS", 180),
 		},
 		ClickHouse: ClickHouseConfig{
-			Addr:     getEnv("CLICKHOUSE_ADDR", "localhost:9000"),
-			Database: getEnv("CLICKHOUSE_DATABASE", "analytics"),
-			Username: getEnv("CLICKHOUSE_USERNAME", "clickhouse"),
-			Password: getEnv("CLICKHOUSE_PASSWORD", ""),
-			MaxConns: getEnvAsInt("CLICKHOUSE_MAX_CONNS", 10),
+			Addr:        getEnv("CLICKHOUSE_ADDR", "localhost:9000"),
+			Database:    getEnv("CLICKHOUSE_DATABASE", "analytics"),
+			Username:    getEnv("CLICKHOUSE_USERNAME", "clickhouse"),
+			Password:    getEnv("CLICKHOUSE_PASSWORD", ""),
+			MaxConns:    getEnvAsInt("CLICKHOUSE_MAX_CONNS", 10),
+			AutoMigrate: getEnv("CLICKHOUSE_AUTO_MIGRATE", "true") == "true",
 		},
 		Cache: CacheConfig{
 			L1Capacity: getEnvAsInt("CACHE_L1_CAPACITY", 10000),
 			L2TTL:      getEnvAsDuration("CACHE_L2_TTL", time.Hour),
+			WarmTopN:   getEnvAsInt("CACHE_WARM_TOP_N", 0),
+		},
+		AliasFilter: AliasFilterConfig{
+			Enabled:           getEnv("ALIAS_FILTER_ENABLED", "true") == "true",
+			ExpectedItems:     getEnvAsInt("ALIAS_FILTER_EXPECTED_ITEMS", 1000000),
+			FalsePositiveRate: getEnvAsFloat("ALIAS_FILTER_FALSE_POSITIVE_RATE", 0.01),
+			SyncInterval:      getEnvAsDuration("ALIAS_FILTER_SYNC_INTERVAL", 30*time.Second),
+		},
+		Webhooks: WebhookConfig{
+			Enabled:        getEnv("WEBHOOKS_ENABLED", "true") == "true",
+			Workers:        getEnvAsInt("WEBHOOK_WORKERS", 4),
+			QueueSize:      getEnvAsInt("WEBHOOK_QUEUE_SIZE", 1000),
+			MaxRetries:     getEnvAsInt("WEBHOOK_MAX_RETRIES", 3),
+			InitialBackoff: getEnvAsDuration("WEBHOOK_INITIAL_BACKOFF", 500*time.Millisecond),
+			Timeout:        getEnvAsDuration("WEBHOOK_TIMEOUT", 5*time.Second),
+			MaxFailures:    getEnvAsInt("WEBHOOK_MAX_FAILURES", 10),
+		},
+		Preview: PreviewConfig{
+			Enabled:  getEnv("PREVIEW_ENABLED", "false") == "true",
+			Timeout:  getEnvAsDuration("PREVIEW_TIMEOUT", 5*time.Second),
+			MaxBytes: int64(getEnvAsInt("PREVIEW_MAX_BYTES", 512*1024)),
+			Workers:  getEnvAsInt("PREVIEW_WORKERS", 4),
 		},
 		RateLimit: RateLimitConfig{
-			Requests: getEnvAsInt("RATE_LIMIT_REQUESTS", 100),
-			Window:   getEnvAsDuration("RATE_LIMIT_WINDOW", time.Minute),
+			Requests:          getEnvAsInt("RATE_LIMIT_REQUESTS", 100),
+			Window:            getEnvAsDuration("RATE_LIMIT_WINDOW", time.Minute),
+			Algorithm:         getEnv("RATE_LIMIT_ALGORITHM", "sliding_window"),
+			RedirectAlgorithm: getEnv("REDIRECT_RATE_LIMIT_ALGORITHM", "fixed_window"),
+			AuthRequests:      getEnvAsInt("RATE_LIMIT_AUTH_REQUESTS", 5),
+			AuthWindow:        getEnvAsDuration("RATE_LIMIT_AUTH_WINDOW", time.Minute),
+			AnalyticsRequests: getEnvAsInt("RATE_LIMIT_ANALYTICS_REQUESTS", 30),
+			AnalyticsWindow:   getEnvAsDuration("RATE_LIMIT_ANALYTICS_WINDOW", time.Minute),
+			AnonymousRequests: getEnvAsInt("RATE_LIMIT_ANONYMOUS_REQUESTS", 10),
+			AnonymousWindow:   getEnvAsDuration("RATE_LIMIT_ANONYMOUS_WINDOW", time.Hour),
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: getEnvAsDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		},
+		Anonymous: AnonymousConfig{
+			Enabled: getEnv("ALLOW_ANONYMOUS_CREATE", "false") == "true",
+			LinkTTL: getEnvAsDuration("ANONYMOUS_LINK_TTL", 30*24*time.Hour),
+		},
+		Quota: QuotaConfig{
+			MaxActiveURLs: getEnvAsInt("QUOTA_MAX_ACTIVE_URLS", 10000),
+			DailyCreates:  getEnvAsInt("QUOTA_DAILY_CREATES", 1000),
+			Plans:         getEnvAsSlice("QUOTA_PLANS", nil),
+		},
+		Login: LoginConfig{
+			MaxFailures:       getEnvAsInt("LOGIN_MAX_FAILURES", 5),
+			MaxIPFailures:     getEnvAsInt("LOGIN_MAX_IP_FAILURES", 20),
+			FailureWindow:     getEnvAsDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+			LockoutBase:       getEnvAsDuration("LOGIN_LOCKOUT_BASE", time.Minute),
+			LockoutMax:        getEnvAsDuration("LOGIN_LOCKOUT_MAX", time.Hour),
+			GatewayIdentities: getEnvAsSlice("LOGIN_GATEWAY_IDENTITIES", []string{"api-gateway"}),
+			GatewayAddrs: getEnvAsSlice("LOGIN_GATEWAY_ADDRS", []string{
+				"127.0.0.0/8", "::1/128", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7",
+			}),
+		},
+		QRCode: QRCodeConfig{
+			Backend:     getEnv("QR_STORE", "db"),
+			LocalDir:    getEnv("QR_LOCAL_DIR", ""),
+			S3Endpoint:  getEnv("QR_S3_ENDPOINT", ""),
+			S3Bucket:    getEnv("QR_S3_BUCKET", ""),
+			S3AccessKey: getEnv("QR_S3_ACCESS_KEY", ""),
+			S3SecretKey: getEnv("QR_S3_SECRET_KEY", ""),
+			S3Region:    getEnv("QR_S3_REGION", ""),
+			S3UseSSL:    getEnv("QR_S3_USE_SSL", "true") == "true",
+			PublicURL:   getEnv("QR_PUBLIC_URL", ""),
+			CacheTTL:    getEnvAsDuration("QR_CACHE_TTL", 24*time.Hour),
+		},
+		GeoIP: GeoIPConfig{
+			CacheSize: getEnvAsInt("GEOIP_CACHE_SIZE", 10000),
+		},
+		ClickDedup: ClickDedupConfig{
+			Window: getEnvAsDuration("CLICK_DEDUP_WINDOW", 0),
+			Mode:   getEnv("CLICK_DEDUP_MODE", "collapse"),
+		},
+		ClickSample: ClickSampleConfig{
+			Rate:      getEnvAsInt("CLICK_SAMPLE_RATE", 1),
+			LinkRates: getEnvAsSlice("CLICK_SAMPLE_LINK_RATES", nil),
+		},
+		ClickSigning: ClickSigningConfig{
+			Enabled:          getEnv("CLICK_SIGNING_ENABLED", "false") == "true",
+			Secret:           getEnv("CLICK_SIGNING_SECRET", ""),
+			DeadLetterStream: getEnv("CLICK_DEAD_LETTER_STREAM", "clicks:dead"),
+		},
+		Cleanup: CleanupConfig{
+			Interval: getEnvAsDuration("CLEANUP_INTERVAL", 24*time.Hour),
 		},
 		Snowflake: SnowflakeConfig{
-			DatacenterID: int64(getEnvAsInt("SNOWFLAKE_DATACENTER_ID", 1)),
-			WorkerID:     int64(getEnvAsInt("SNOWFLAKE_WORKER_ID", 1)),
+			DatacenterID:   int64(getEnvAsInt("SNOWFLAKE_DATACENTER_ID", 1)),
+			WorkerID:       int64(getEnvAsInt("SNOWFLAKE_WORKER_ID", 1)),
+			WorkerIDSource: getEnv("SNOWFLAKE_WORKER_ID_SOURCE", "static"),
+			PodName:        getEnv("POD_NAME", ""),
+			LeaseTTL:       getEnvAsDuration("SNOWFLAKE_WORKER_LEASE_TTL", 30*time.Second),
+		},
+		RedirectPages: RedirectPagesConfig{
+			NotFoundTemplate: getEnv("REDIRECT_NOT_FOUND_TEMPLATE", ""),
+			ExpiredTemplate:  getEnv("REDIRECT_EXPIRED_TEMPLATE", ""),
+			LandingTemplate:  getEnv("REDIRECT_LANDING_TEMPLATE", ""),
+			PreviewTemplate:  getEnv("REDIRECT_PREVIEW_TEMPLATE", ""),
+			PreviewDelay:     getEnvAsDuration("REDIRECT_PREVIEW_DELAY", 0),
+			RootURL:          getEnv("REDIRECT_ROOT_URL", ""),
+			FaviconFile:      getEnv("REDIRECT_FAVICON", ""),
+			RobotsFile:       getEnv("REDIRECT_ROBOTS_TXT", ""),
+		},
+		PipelineAlert: PipelineAlertConfig{
+			MaxPending:    int64(getEnvAsInt("PIPELINE_ALERT_MAX_PENDING", 10000)),
+			MaxPendingAge: getEnvAsDuration("PIPELINE_ALERT_MAX_PENDING_AGE", 5*time.Minute),
+			MaxFlushAge:   getEnvAsDuration("PIPELINE_ALERT_MAX_FLUSH_AGE", 5*time.Minute),
+		},
+		Failover: FailoverConfig{
+			ProbeInterval: getEnvAsDuration("FAILOVER_PROBE_INTERVAL", 30*time.Second),
+			ProbeTimeout:  getEnvAsDuration("FAILOVER_PROBE_TIMEOUT", 5*time.Second),
 		},
 		CORS: CORSConfig{
 			AllowedOrigins: getEnvAsSlice("CORS_ALLOWED_ORIGINS", []string{"http://localhost:3000"}),
@@ -260,8 +788,13 @@ func Load() (*Config, error) {
 			Enabled:     getEnv("ES_ENABLED", "false") == "true",
 		},
 		JWT: JWTConfig{
-			Secret:        getEnv("JWT_SECRET", ""),
-			TokenDuration: getEnvAsDuration("JWT_TOKEN_DURATION", 7*24*time.Hour),
+			Secret: getEnv("JWT_SECRET", ""),
+			// JWT_TOKEN_DURATION is the variable's former name.
+			AccessTTL: getEnvAsDuration("JWT_ACCESS_TTL", getEnvAsDuration("JWT_TOKEN_DURATION", 24*time.Hour)),
+		},
+		Startup: StartupConfig{
+			ConnectAttempts: getEnvAsInt("STARTUP_CONNECT_ATTEMPTS", 5),
+			ConnectBackoff:  getEnvAsDuration("STARTUP_CONNECT_BACKOFF", time.Second),
 		},
 	}
 
@@ -37,6 +37,10 @@ type DBManager struct {
 	// The counter is allowed to wrap around naturally; the modulo operation
 	// in Read() handles the wrap correctly.
 	replicaIndex uint32
+
+	// queryTimeout caps each call made through QueryContext. Zero leaves
+	// calls bounded by their caller's context alone.
+	queryTimeout time.Duration
 }
 
 // Config holds the connection parameters for initializing a DBManager.
@@ -68,6 +72,10 @@ type Config struct {
 	// MaxConnIdleTime is the maximum time a connection can remain idle
 	// before being closed, preventing resource waste during low traffic.
 	MaxConnIdleTime time.Duration
+
+	// QueryTimeout is the longest a single storage call may run (see
+	// QueryContext). Zero disables the cap.
+	QueryTimeout time.Duration
 }
 
 // NewDBManager creates a DBManager by establishing connection pools to the
@@ -87,6 +95,7 @@ func NewDBManager(ctx context.Context, cfg Config) (*DBManager, error) {
 	poolConfig.MinConns = cfg.MinConns
 	poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
 	poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	poolConfig.ConnConfig.Tracer = newQueryTracer()
 
 	primaryPool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
@@ -114,6 +123,7 @@ func NewDBManager(ctx context.Context, cfg Config) (*DBManager, error) {
 		replicaConfig.MinConns = cfg.MinConns
 		replicaConfig.MaxConnLifetime = cfg.MaxConnLifetime
 		replicaConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+		replicaConfig.ConnConfig.Tracer = newQueryTracer()
 
 		replicaPool, err := pgxpool.NewWithConfig(ctx, replicaConfig)
 		if err != nil {
@@ -136,6 +146,7 @@ func NewDBManager(ctx context.Context, cfg Config) (*DBManager, error) {
 		primary:      primaryPool,
 		replicas:     replicas,
 		replicaIndex: 0,
+		queryTimeout: cfg.QueryTimeout,
 	}, nil
 }
 
@@ -163,6 +174,18 @@ func (m *DBManager) Read() *pgxpool.Pool {
 	return m.replicas[idx]
 }
 
+// QueryContext derives the context of one storage call from the caller's
+// ctx, capped at the configured query timeout. A caller with an earlier
+// deadline keeps it, and cancelling ctx -- as the HTTP server does when a
+// client disconnects -- aborts the query in flight. Callers must defer the
+// returned cancel function.
+func (m *DBManager) QueryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if m.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, m.queryTimeout)
+}
+
 // closeReplicas is a helper that closes all replica pools in the slice.
 // It is called during cleanup when NewDBManager encounters an error after
 // some replicas have already been connected. Nil-safe for each pool entry.
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newStalledManager returns a DBManager whose primary accepts connections
+// but never answers, like a database stuck on a lock or an overloaded host.
+func newStalledManager(t *testing.T, queryTimeout time.Duration) *DBManager {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var conns []net.Conn
+		defer func() {
+			for _, conn := range conns {
+				_ = conn.Close()
+			}
+		}()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+	t.Cleanup(func() {
+		_ = ln.Close()
+		<-done
+	})
+
+	pool, err := pgxpool.New(context.Background(), "postgres://tiny@"+ln.Addr().String()+"/tiny?sslmode=disable&connect_timeout=60")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	return &DBManager{primary: pool, queryTimeout: queryTimeout}
+}
+
+// query runs a trivial query through QueryContext and reports how long it
+// took to fail.
+func query(m *DBManager, ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	ctx, cancel := m.QueryContext(ctx)
+	defer cancel()
+	var one int
+	err := m.Read().QueryRow(ctx, "SELECT 1").Scan(&one)
+	return time.Since(start), err
+}
+
+func TestQueryContext_CancelledContextAbortsQuery(t *testing.T) {
+	m := newStalledManager(t, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel) // the client disconnects
+
+	took, err := query(m, ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the query to be cancelled, got %v", err)
+	}
+	if took > 5*time.Second {
+		t.Errorf("expected the query to stop soon after the cancel, took %v", took)
+	}
+}
+
+func TestQueryContext_CapsQueries(t *testing.T) {
+	m := newStalledManager(t, 100*time.Millisecond)
+
+	took, err := query(m, context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the query timeout to apply, got %v", err)
+	}
+	if took > 5*time.Second {
+		t.Errorf("expected the query to stop at the timeout, took %v", took)
+	}
+
+	// A caller's earlier deadline wins over the cap.
+	m.queryTimeout = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := query(m, ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the caller's deadline to apply, got %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+// Package migrate applies the PostgreSQL schema migrations in
+// migrations/postgres.
+//
+// Applied migrations are tracked in the schema_migrations table used by the
+// golang-migrate CLI (one row: the current version and a dirty flag), so a
+// database migrated by hand with that tool is picked up where it left off,
+// and the tool keeps working on databases this package migrated.
+//
+// Each migration runs in its own transaction together with the version
+// update, so a failed migration leaves no trace and is retried in full on
+// the next run. A session advisory lock serialises runners, so replicas of
+// a service migrating on startup do not apply the same migration twice.
+//
+// A database whose tables were created some other way (for example from
+// scripts/databases/schema.sql) has no schema_migrations table. Running
+// 000001 there would drop the existing tables, so Up refuses instead; mark
+// the version the schema corresponds to with Force first.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/Varun5711/shorternit/migrations"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// advisoryLockKey identifies the migration lock among the database's
+// advisory locks.
+const advisoryLockKey = 0x74696e79 // "tiny"
+
+// ErrNotBaselined is returned by Up for a database that has tables but no
+// migration history.
+var ErrNotBaselined = errors.New("database has tables but no schema_migrations; run Force with the version its schema matches")
+
+// ErrDirty is returned when the golang-migrate CLI left a migration half
+// applied. Repair the schema, then Force the version it now matches.
+var ErrDirty = errors.New("schema_migrations is marked dirty")
+
+// Runner applies migrations to one database.
+type Runner struct {
+	pool *pgxpool.Pool
+	log  *logger.Logger
+}
+
+// NewRunner creates a Runner for the database behind pool, which must
+// connect to the primary.
+func NewRunner(pool *pgxpool.Pool, log *logger.Logger) *Runner {
+	return &Runner{pool: pool, log: log}
+}
+
+// Up applies, in order, the migrations newer than the database's current
+// version and returns how many it applied.
+func (r *Runner) Up(ctx context.Context, all []migrations.Migration) (int, error) {
+	n := 0
+	err := r.locked(ctx, func(conn *pgxpool.Conn) error {
+		version, err := r.current(ctx, conn, true)
+		if err != nil {
+			return err
+		}
+		for _, m := range all {
+			if m.Version <= version {
+				continue
+			}
+			if err := apply(ctx, conn, m); err != nil {
+				return err
+			}
+			r.log.Info("Applied migration %s", m.Name)
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// Version returns the current version, or 0 for a database without
+// migration history.
+func (r *Runner) Version(ctx context.Context) (uint64, error) {
+	var version uint64
+	err := r.locked(ctx, func(conn *pgxpool.Conn) error {
+		var err error
+		version, err = r.current(ctx, conn, false)
+		return err
+	})
+	return version, err
+}
+
+// Force records version as applied and clean without running anything. It
+// baselines a database created outside the migrations, or clears a dirty
+// flag once the schema has been repaired by hand.
+func (r *Runner) Force(ctx context.Context, version uint64) error {
+	return r.locked(ctx, func(conn *pgxpool.Conn) error {
+		if err := ensureTable(ctx, conn); err != nil {
+			return err
+		}
+		return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			return setVersion(ctx, tx, version)
+		})
+	})
+}
+
+// locked runs fn on one connection while holding the migration lock.
+func (r *Runner) locked(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", advisoryLockKey); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	defer func() { _, _ = conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", advisoryLockKey) }()
+
+	return fn(conn)
+}
+
+// current returns the recorded version. With forUp it creates the tracking
+// table for a fresh database, and refuses one that has tables but no
+// history.
+func (r *Runner) current(ctx context.Context, conn *pgxpool.Conn, forUp bool) (uint64, error) {
+	var tracked, hasURLs bool
+	err := conn.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL, to_regclass('urls') IS NOT NULL`).Scan(&tracked, &hasURLs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect schema: %w", err)
+	}
+	if !tracked {
+		if !forUp {
+			return 0, nil
+		}
+		if hasURLs {
+			return 0, ErrNotBaselined
+		}
+		return 0, ensureTable(ctx, conn)
+	}
+
+	var version int64
+	var dirty bool
+	err = conn.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w at version %d", ErrDirty, version)
+	}
+	return uint64(version), nil
+}
+
+// apply runs m and records its version in one transaction.
+func apply(ctx context.Context, conn *pgxpool.Conn, m migrations.Migration) error {
+	err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		// Without arguments the file is sent as one simple-protocol
+		// query, which may hold several statements.
+		if _, err := tx.Exec(ctx, m.SQL); err != nil {
+			return err
+		}
+		return setVersion(ctx, tx, m.Version)
+	})
+	if err != nil {
+		return fmt.Errorf("migration %s failed: %w", m.Name, err)
+	}
+	return nil
+}
+
+func ensureTable(ctx context.Context, conn *pgxpool.Conn) error {
+	_, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL PRIMARY KEY,
+		dirty BOOLEAN NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+func setVersion(ctx context.Context, tx pgx.Tx, version uint64) error {
+	if _, err := tx.Exec(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return fmt.Errorf("failed to update schema_migrations: %w", err)
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)", int64(version)); err != nil {
+		return fmt.Errorf("failed to update schema_migrations: %w", err)
+	}
+	return nil
+}
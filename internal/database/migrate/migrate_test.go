@@ -0,0 +1,116 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/Varun5711/shorternit/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// throwawayDB creates an empty database on the server behind
+// POSTGRES_TEST_DSN, which must name a role allowed to create databases,
+// and returns a pool connected to it. The database is dropped when the test
+// ends.
+func throwawayDB(t *testing.T, ctx context.Context) *pgxpool.Pool {
+	t.Helper()
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+	admin, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(admin.Close)
+
+	name := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(ctx, "CREATE DATABASE "+name); err != nil {
+		t.Fatalf("failed to create %s: %v", name, err)
+	}
+
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg.ConnConfig.Database = name
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("failed to connect to %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		pool.Close()
+		_, _ = admin.Exec(context.Background(), "DROP DATABASE IF EXISTS "+name)
+	})
+	return pool
+}
+
+func TestUp_AppliesAllMigrations(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	pool := throwawayDB(t, ctx)
+
+	all, err := migrations.Load(migrations.Postgres, "postgres")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := NewRunner(pool, logger.New("migrate-test"))
+
+	if n, err := r.Up(ctx, all); err != nil || n != len(all) {
+		t.Fatalf("expected all %d migrations applied, got %d, %v", len(all), n, err)
+	}
+	if n, err := r.Up(ctx, all); err != nil || n != 0 {
+		t.Fatalf("expected the second run to apply nothing, got %d, %v", n, err)
+	}
+	if v, err := r.Version(ctx); err != nil || v != all[len(all)-1].Version {
+		t.Errorf("expected version %d, got %d, %v", all[len(all)-1].Version, v, err)
+	}
+
+	// The columns the storage layer relies on exist.
+	for _, col := range []struct{ table, column string }{
+		{"urls", "user_id"},
+		{"urls", "tags"},
+		{"urls", "max_clicks"},
+		{"users", "email"},
+	} {
+		var n int
+		err := pool.QueryRow(ctx, "SELECT count(*) FROM information_schema.columns WHERE table_name = $1 AND column_name = $2", col.table, col.column).Scan(&n)
+		if err != nil || n != 1 {
+			t.Errorf("expected column %s.%s, got %d, %v", col.table, col.column, n, err)
+		}
+	}
+}
+
+// TestUp_RefusesUntrackedSchema verifies that a database created without the
+// migrations is left alone until it is baselined with Force.
+func TestUp_RefusesUntrackedSchema(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	pool := throwawayDB(t, ctx)
+
+	all, err := migrations.Load(migrations.Postgres, "postgres")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := pool.Exec(ctx, "CREATE TABLE urls (short_code TEXT PRIMARY KEY)"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := NewRunner(pool, logger.New("migrate-test"))
+
+	if _, err := r.Up(ctx, all); !errors.Is(err, ErrNotBaselined) {
+		t.Fatalf("expected ErrNotBaselined, got %v", err)
+	}
+
+	latest := all[len(all)-1].Version
+	if err := r.Force(ctx, latest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n, err := r.Up(ctx, all); err != nil || n != 0 {
+		t.Errorf("expected a baselined database to need nothing, got %d, %v", n, err)
+	}
+}
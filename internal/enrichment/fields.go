@@ -0,0 +1,98 @@
+package enrichment
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Click-event fields that ANALYTICS_FIELDS can keep or drop. The short
+// code, timestamp and destination are always recorded.
+const (
+	// FieldIP is the visitor's address, as the IP mode stores it.
+	FieldIP = "ip"
+	// FieldGeo is the country, region, city and coordinates looked up from
+	// the address.
+	FieldGeo = "geo"
+	// FieldUserAgent is the raw User-Agent header.
+	FieldUserAgent = "user_agent"
+	// FieldDevice is the browser, OS and device type parsed from the
+	// User-Agent.
+	FieldDevice = "device"
+	// FieldReferer is the Referer header.
+	FieldReferer = "referer"
+	// FieldQuery is the query string of the short link as followed.
+	FieldQuery = "query"
+
+	// FieldsNone keeps none of the fields above.
+	FieldsNone = "none"
+)
+
+// AllFields lists every field, in the order they are documented.
+var AllFields = []string{FieldIP, FieldGeo, FieldUserAgent, FieldDevice, FieldReferer, FieldQuery}
+
+// Fields is the set of click-event fields an operator lets analytics
+// record. A dropped field is stored blank: its column stays in place, so
+// queries keep working and simply see no data for it. The zero value keeps
+// every field.
+type Fields struct {
+	dropped map[string]bool
+}
+
+// ParseFields returns the set keeping exactly names, each one of the
+// Field* values, or an error naming one that is not. FieldsNone on its own
+// drops them all.
+func ParseFields(names []string) (Fields, error) {
+	kept := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch {
+		case name == "":
+		case name == FieldsNone && len(names) == 1:
+		case slices.Contains(AllFields, name):
+			kept[name] = true
+		default:
+			return Fields{}, fmt.Errorf("unknown analytics field %q (want %s, or %s alone)",
+				name, strings.Join(AllFields, ", "), FieldsNone)
+		}
+	}
+
+	dropped := make(map[string]bool)
+	for _, name := range AllFields {
+		if !kept[name] {
+			dropped[name] = true
+		}
+	}
+	return Fields{dropped: dropped}, nil
+}
+
+// Keeps reports whether field is recorded.
+func (f Fields) Keeps(field string) bool {
+	return !f.dropped[field]
+}
+
+// NeedsIP reports whether the visitor's address is needed, either to be
+// stored or to look up its location.
+func (f Fields) NeedsIP() bool {
+	return f.Keeps(FieldIP) || f.Keeps(FieldGeo)
+}
+
+// NeedsUserAgent reports whether the User-Agent is needed, either to be
+// stored or to parse the device from.
+func (f Fields) NeedsUserAgent() bool {
+	return f.Keeps(FieldUserAgent) || f.Keeps(FieldDevice)
+}
+
+// String lists the kept fields, comma-separated, or FieldsNone.
+func (f Fields) String() string {
+	var kept []string
+	for _, name := range AllFields {
+		if f.Keeps(name) {
+			kept = append(kept, name)
+		}
+	}
+	if len(kept) == 0 {
+		return FieldsNone
+	}
+	return strings.Join(kept, ",")
+}
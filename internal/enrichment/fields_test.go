@@ -0,0 +1,50 @@
+package enrichment
+
+import "testing"
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  string
+	}{
+		{AllFields, "ip,geo,user_agent,device,referer,query"},
+		{[]string{"geo", " Device ", "query"}, "geo,device,query"},
+		{[]string{FieldsNone}, FieldsNone},
+		{[]string{}, FieldsNone},
+	}
+	for _, tt := range tests {
+		f, err := ParseFields(tt.names)
+		if err != nil {
+			t.Fatalf("ParseFields(%v): %v", tt.names, err)
+		}
+		if got := f.String(); got != tt.want {
+			t.Errorf("ParseFields(%v) = %s, want %s", tt.names, got, tt.want)
+		}
+	}
+
+	for _, names := range [][]string{{"ip", "email"}, {"none", "geo"}} {
+		if _, err := ParseFields(names); err == nil {
+			t.Errorf("ParseFields(%v): expected an error", names)
+		}
+	}
+}
+
+func TestFields_Needs(t *testing.T) {
+	var all Fields
+	if !all.Keeps(FieldReferer) || !all.NeedsIP() || !all.NeedsUserAgent() {
+		t.Error("expected the zero value to keep every field")
+	}
+
+	geoOnly, _ := ParseFields([]string{FieldGeo})
+	if geoOnly.Keeps(FieldIP) || !geoOnly.NeedsIP() {
+		t.Error("expected geo alone to need the address without keeping it")
+	}
+	if geoOnly.NeedsUserAgent() {
+		t.Error("expected geo alone not to need the User-Agent")
+	}
+
+	deviceOnly, _ := ParseFields([]string{FieldDevice})
+	if deviceOnly.Keeps(FieldUserAgent) || !deviceOnly.NeedsUserAgent() || deviceOnly.NeedsIP() {
+		t.Error("expected device alone to need only the User-Agent")
+	}
+}
@@ -7,6 +7,9 @@ package enrichment
 
 import (
 	"net"
+	"sync/atomic"
+
+	"github.com/Varun5711/shorternit/internal/cache"
 )
 
 // GeoInfo holds the geographic attributes resolved from an IP address.
@@ -27,26 +30,73 @@ type GeoInfo struct {
 // This is currently a stub implementation that returns placeholder values.
 // The struct is intentionally left empty so that a future integration with
 // MaxMind GeoLite2 (or a similar MMDB provider) only needs to add a
-// *maxminddb.Reader field and swap the Lookup body -- the public API
+// *maxminddb.Reader field and swap the lookup body -- the public API
 // surface stays the same, so no callers need to change.
+//
+// Results are kept in an LRU cache keyed by IP. Clicks arrive in bursts from
+// the same addresses (a link shared in one office, a crawler, a busy NAT),
+// so within and across batches most lookups repeat one made moments before.
 type GeoIPEnricher struct {
+	cache  *cache.LRUCache // IP -> GeoInfo; nil disables caching
+	hits   atomic.Uint64
+	misses atomic.Uint64
 }
 
-// NewGeoIPEnricher constructs a ready-to-use GeoIPEnricher.
+// NewGeoIPEnricher constructs a ready-to-use GeoIPEnricher that caches the
+// locations of up to cacheSize IPs; 0 disables the cache.
 // When a real GeoLite2 database is integrated, this constructor will accept
 // a file path to the .mmdb file and return an error if it cannot be opened.
-func NewGeoIPEnricher() *GeoIPEnricher {
-	return &GeoIPEnricher{}
+func NewGeoIPEnricher(cacheSize int) *GeoIPEnricher {
+	g := &GeoIPEnricher{}
+	if cacheSize > 0 {
+		g.cache = cache.NewLRUCache(cacheSize)
+	}
+	return g
 }
 
-// Lookup resolves an IP address string into geographic information.
+// Lookup resolves an IP address string into geographic information, from
+// the cache when the IP was looked up recently. The result is the caller's
+// own copy.
+func (g *GeoIPEnricher) Lookup(ipAddress string) *GeoInfo {
+	if g.cache == nil {
+		return g.lookup(ipAddress)
+	}
+	if cached, ok := g.cache.Get(ipAddress); ok {
+		g.hits.Add(1)
+		info := cached.(GeoInfo)
+		return &info
+	}
+	g.misses.Add(1)
+	info := g.lookup(ipAddress)
+	g.cache.Set(ipAddress, *info)
+	return info
+}
+
+// Stats reports the lookup cache's effectiveness, for logs and monitoring:
+//   - cache_hits: lookups answered from the cache
+//   - cache_misses: lookups that read the database
+//   - cache_hit_rate: cache_hits as a fraction of all lookups (0 before any)
+func (g *GeoIPEnricher) Stats() map[string]interface{} {
+	hits, misses := g.hits.Load(), g.misses.Load()
+	rate := 0.0
+	if total := hits + misses; total > 0 {
+		rate = float64(hits) / float64(total)
+	}
+	return map[string]interface{}{
+		"cache_hits":     hits,
+		"cache_misses":   misses,
+		"cache_hit_rate": rate,
+	}
+}
+
+// lookup resolves an IP address without the cache.
 // It handles three cases defensively:
 //  1. Unparseable IPs -- returns "Unknown" to avoid crashing on bad input.
 //  2. Loopback / private IPs -- returns "Local" because geo-lookup is
 //     meaningless for RFC-1918 addresses or 127.0.0.1.
 //  3. All other IPs -- returns "Unknown" until a real GeoIP database
 //     is wired in.
-func (g *GeoIPEnricher) Lookup(ipAddress string) *GeoInfo {
+func (g *GeoIPEnricher) lookup(ipAddress string) *GeoInfo {
 	ip := net.ParseIP(ipAddress)
 	if ip == nil {
 		return &GeoInfo{
@@ -0,0 +1,39 @@
+package enrichment
+
+import "testing"
+
+func TestGeoIPEnricher_RepeatLookupHitsCache(t *testing.T) {
+	g := NewGeoIPEnricher(10)
+
+	first := g.Lookup("203.0.113.7")
+	second := g.Lookup("203.0.113.7")
+
+	stats := g.Stats()
+	if stats["cache_misses"] != uint64(1) || stats["cache_hits"] != uint64(1) {
+		t.Fatalf("expected one miss then one hit, got %v", stats)
+	}
+	if stats["cache_hit_rate"] != 0.5 {
+		t.Errorf("expected a hit rate of 0.5, got %v", stats["cache_hit_rate"])
+	}
+	if *first != *second {
+		t.Errorf("expected the cached location %+v, got %+v", *first, *second)
+	}
+
+	// Callers get their own copy, so changing one result cannot corrupt
+	// the cache.
+	second.City = "Changed"
+	if g.Lookup("203.0.113.7").City == "Changed" {
+		t.Error("expected the cached location to be unaffected by callers")
+	}
+}
+
+func TestGeoIPEnricher_CacheDisabled(t *testing.T) {
+	g := NewGeoIPEnricher(0)
+
+	g.Lookup("203.0.113.7")
+	g.Lookup("203.0.113.7")
+
+	if stats := g.Stats(); stats["cache_hits"] != uint64(0) || stats["cache_misses"] != uint64(0) {
+		t.Errorf("expected no cache activity with the cache disabled, got %v", stats)
+	}
+}
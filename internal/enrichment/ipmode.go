@@ -0,0 +1,74 @@
+package enrichment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+// IP modes select how much of a visitor's address is kept in analytics.
+const (
+	// IPModeFull stores the address as received.
+	IPModeFull = "full"
+	// IPModeAnonymize zeroes the last octet of an IPv4 address and the last
+	// 80 bits of an IPv6 one, leaving the network but not the host.
+	IPModeAnonymize = "anonymize"
+	// IPModeHash replaces the address with a salted SHA-256 digest: the same
+	// visitor still counts once, but the address cannot be read back.
+	IPModeHash = "hash"
+)
+
+// IPMasker applies an IP mode to the addresses of click events. Geo
+// enrichment must run on the original address first, since neither a
+// truncated nor a hashed one locates the visitor. Every stored use of the
+// address, including unique-visitor counts (uniq(ip_address)), then sees
+// the masked value. A nil *IPMasker keeps addresses as they are.
+type IPMasker struct {
+	mode string
+	salt string // IPModeHash only
+}
+
+// NewIPMasker returns a masker for mode, one of the IPMode* values, or an
+// error for an unknown mode or IPModeHash without a salt.
+func NewIPMasker(mode, salt string) (*IPMasker, error) {
+	switch mode {
+	case IPModeFull, IPModeAnonymize:
+	case IPModeHash:
+		if salt == "" {
+			return nil, errors.New("ANALYTICS_IP_HASH_SALT is required when ANALYTICS_IP_MODE is hash")
+		}
+	default:
+		return nil, fmt.Errorf("unknown analytics IP mode %q (want %s, %s or %s)", mode, IPModeFull, IPModeAnonymize, IPModeHash)
+	}
+	return &IPMasker{mode: mode, salt: salt}, nil
+}
+
+// Mask returns ip as the masker's mode stores it. An IPv4 address mapped
+// into IPv6 is treated as IPv4, and equivalent spellings of one address
+// mask alike. Outside IPModeFull, a value that is not an IP address is
+// dropped ("") rather than stored as is, and "" stays "".
+func (m *IPMasker) Mask(ip string) string {
+	if m == nil || m.mode == IPModeFull || ip == "" {
+		return ip
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap().WithZone("")
+
+	switch m.mode {
+	case IPModeAnonymize:
+		bits := 48 // keep the first 48 of 128 bits
+		if addr.Is4() {
+			bits = 24
+		}
+		prefix, _ := addr.Prefix(bits)
+		return prefix.Addr().String()
+	default: // IPModeHash
+		sum := sha256.Sum256([]byte(m.salt + addr.String()))
+		return hex.EncodeToString(sum[:])
+	}
+}
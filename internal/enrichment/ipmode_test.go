@@ -0,0 +1,70 @@
+package enrichment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestIPMasker_Modes(t *testing.T) {
+	hashOf := func(ip string) string {
+		sum := sha256.Sum256([]byte("pepper" + ip))
+		return hex.EncodeToString(sum[:])
+	}
+
+	tests := []struct {
+		mode string
+		ip   string
+		want string
+	}{
+		{IPModeFull, "203.0.113.77", "203.0.113.77"},
+		{IPModeFull, "2001:db8:85a3:8d3:1319:8a2e:370:7348", "2001:db8:85a3:8d3:1319:8a2e:370:7348"},
+		{IPModeFull, "not-an-ip", "not-an-ip"},
+
+		{IPModeAnonymize, "203.0.113.77", "203.0.113.0"},
+		{IPModeAnonymize, "::ffff:203.0.113.77", "203.0.113.0"},
+		{IPModeAnonymize, "2001:db8:85a3:8d3:1319:8a2e:370:7348", "2001:db8:85a3::"},
+		{IPModeAnonymize, "fe80::1%eth0", "fe80::"},
+		{IPModeAnonymize, "not-an-ip", ""},
+		{IPModeAnonymize, "", ""},
+
+		{IPModeHash, "203.0.113.77", hashOf("203.0.113.77")},
+		{IPModeHash, "::ffff:203.0.113.77", hashOf("203.0.113.77")},
+		{IPModeHash, "2001:DB8::1", hashOf("2001:db8::1")},
+		{IPModeHash, "not-an-ip", ""},
+		{IPModeHash, "", ""},
+	}
+	for _, tt := range tests {
+		m, err := NewIPMasker(tt.mode, "pepper")
+		if err != nil {
+			t.Fatalf("NewIPMasker(%s): %v", tt.mode, err)
+		}
+		if got := m.Mask(tt.ip); got != tt.want {
+			t.Errorf("%s %q: expected %q, got %q", tt.mode, tt.ip, tt.want, got)
+		}
+	}
+}
+
+func TestIPMasker_HashDependsOnSalt(t *testing.T) {
+	a, _ := NewIPMasker(IPModeHash, "salt-a")
+	b, _ := NewIPMasker(IPModeHash, "salt-b")
+	if a.Mask("203.0.113.77") == b.Mask("203.0.113.77") {
+		t.Error("expected different salts to give different hashes")
+	}
+	if a.Mask("203.0.113.77") == a.Mask("203.0.113.78") {
+		t.Error("expected different addresses to give different hashes")
+	}
+}
+
+func TestNewIPMasker_Rejects(t *testing.T) {
+	if _, err := NewIPMasker("truncate", ""); err == nil {
+		t.Error("expected an unknown mode to be refused")
+	}
+	if _, err := NewIPMasker(IPModeHash, ""); err == nil {
+		t.Error("expected hashing without a salt to be refused")
+	}
+	var m *IPMasker
+	if got := m.Mask("203.0.113.77"); got != "203.0.113.77" {
+		t.Errorf("expected a nil masker to keep the address, got %q", got)
+	}
+}
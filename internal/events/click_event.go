@@ -30,4 +30,8 @@ type ClickEvent struct {
 	OriginalURL string // the long URL the short code resolved to
 	Referer     string // HTTP Referer header, indicates where the click came from
 	QueryParams string // raw query string forwarded from the short link
+	Variant     int    // 1-based A/B variant served, 0 for a single-destination link
+	GeoRule     string // country code of the geo rule that chose OriginalURL, empty if none matched
+	Duplicate   bool   // repeat click from the same visitor within the dedup window (raw dedup mode only)
+	Failover    bool   // OriginalURL is the link's backup, served because its primary was down
 }
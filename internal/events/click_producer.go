@@ -16,50 +16,41 @@ import (
 //
 // Empty optional fields are omitted from the stream entry to save memory in
 // Redis, since streams store each field name per entry.
+//
+// With a maxLen, every XADD also trims the stream to about that many entries
+// (MAXLEN ~), bounding the memory it takes if the workers fall behind or
+// stop. The trim is approximate so Redis only drops whole internal nodes,
+// which keeps it O(1); the stream may briefly hold up to a node (100
+// entries by default) more. Trimming does not look at consumer groups: a
+// worker lagging by more than maxLen entries loses the oldest ones, pending
+// or not, so maxLen should cover the longest backlog worth recovering.
+//
+// With a Signer every entry carries an HMAC of its fields, which the
+// workers verify before trusting the event.
 type ClickProducer struct {
 	client     *redis.Client // shared Redis connection
 	streamName string        // Redis Stream key (e.g., "clicks")
+	maxLen     int64         // approximate cap on the stream's length; 0 leaves it unbounded
+	signer     *Signer       // nil publishes unsigned entries
 }
 
-// NewClickProducer creates a producer that writes to the given Redis Stream.
-// The streamName is typically a constant like "clicks" defined in the
-// application config; Redis auto-creates the stream on the first XADD.
-func NewClickProducer(client *redis.Client, streamName string) *ClickProducer {
+// NewClickProducer creates a producer that writes to the given Redis Stream,
+// trimming it to about maxLen entries, or never if maxLen is 0, and signing
+// them with signer unless it is nil. The streamName is typically a constant
+// like "clicks" defined in the application config; Redis auto-creates the
+// stream on the first XADD.
+func NewClickProducer(client *redis.Client, streamName string, maxLen int64, signer *Signer) *ClickProducer {
 	return &ClickProducer{
 		client:     client,
 		streamName: streamName,
+		maxLen:     maxLen,
+		signer:     signer,
 	}
 }
 
-// Publish writes a single ClickEvent to the Redis Stream via XADD. Only
-// non-empty optional fields are included in the entry to minimise per-entry
-// storage overhead in the stream.
+// Publish writes a single ClickEvent to the Redis Stream via XADD.
 func (p *ClickProducer) Publish(ctx context.Context, event *ClickEvent) error {
-	fields := map[string]interface{}{
-		"short_code": event.ShortCode,
-		"timestamp":  event.Timestamp,
-	}
-
-	if event.IP != "" {
-		fields["ip"] = event.IP
-	}
-	if event.UserAgent != "" {
-		fields["user_agent"] = event.UserAgent
-	}
-	if event.OriginalURL != "" {
-		fields["original_url"] = event.OriginalURL
-	}
-	if event.Referer != "" {
-		fields["referer"] = event.Referer
-	}
-	if event.QueryParams != "" {
-		fields["query_params"] = event.QueryParams
-	}
-
-	result := p.client.XAdd(ctx, &redis.XAddArgs{
-		Stream: p.streamName,
-		Values: fields,
-	})
+	result := p.client.XAdd(ctx, p.xAddArgs(p.fields(event)))
 
 	if err := result.Err(); err != nil {
 		return fmt.Errorf("failed to publish click event: %w", err)
@@ -77,31 +68,7 @@ func (p *ClickProducer) PublishBatch(ctx context.Context, events []*ClickEvent)
 	pipe := p.client.Pipeline()
 
 	for _, event := range events {
-		fields := map[string]interface{}{
-			"short_code": event.ShortCode,
-			"timestamp":  event.Timestamp,
-		}
-
-		if event.IP != "" {
-			fields["ip"] = event.IP
-		}
-		if event.UserAgent != "" {
-			fields["user_agent"] = event.UserAgent
-		}
-		if event.OriginalURL != "" {
-			fields["original_url"] = event.OriginalURL
-		}
-		if event.Referer != "" {
-			fields["referer"] = event.Referer
-		}
-		if event.QueryParams != "" {
-			fields["query_params"] = event.QueryParams
-		}
-
-		pipe.XAdd(ctx, &redis.XAddArgs{
-			Stream: p.streamName,
-			Values: fields,
-		})
+		pipe.XAdd(ctx, p.xAddArgs(p.fields(event)))
 	}
 
 	_, err := pipe.Exec(ctx)
@@ -112,6 +79,58 @@ func (p *ClickProducer) PublishBatch(ctx context.Context, events []*ClickEvent)
 	return nil
 }
 
+// fields returns the stream entry of event. Only non-empty optional fields
+// are included to minimise per-entry storage overhead in the stream. With a
+// signer the entry also carries its signature.
+func (p *ClickProducer) fields(event *ClickEvent) map[string]interface{} {
+	fields := map[string]interface{}{
+		"short_code": event.ShortCode,
+		"timestamp":  event.Timestamp,
+	}
+
+	if event.IP != "" {
+		fields["ip"] = event.IP
+	}
+	if event.UserAgent != "" {
+		fields["user_agent"] = event.UserAgent
+	}
+	if event.OriginalURL != "" {
+		fields["original_url"] = event.OriginalURL
+	}
+	if event.Referer != "" {
+		fields["referer"] = event.Referer
+	}
+	if event.QueryParams != "" {
+		fields["query_params"] = event.QueryParams
+	}
+	if event.Variant > 0 {
+		fields["variant"] = event.Variant
+	}
+	if event.GeoRule != "" {
+		fields["geo_rule"] = event.GeoRule
+	}
+	if event.Duplicate {
+		fields["duplicate"] = 1
+	}
+	if event.Failover {
+		fields["failover"] = 1
+	}
+
+	p.signer.Sign(fields)
+	return fields
+}
+
+// xAddArgs returns the XADD of an entry with the given fields, trimming the
+// stream if the producer has a maxLen.
+func (p *ClickProducer) xAddArgs(fields map[string]interface{}) *redis.XAddArgs {
+	return &redis.XAddArgs{
+		Stream: p.streamName,
+		MaxLen: p.maxLen,
+		Approx: p.maxLen > 0,
+		Values: fields,
+	}
+}
+
 // StreamInfo returns metadata about the underlying Redis Stream, including its
 // length, first and last entries, and the number of consumer groups. This is
 // intended for admin/debug endpoints that need to inspect stream health.
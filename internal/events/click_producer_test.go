@@ -0,0 +1,115 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// trimmingStream answers XADD and XLEN like Redis does for a single stream,
+// including MAXLEN ~ trimming: it drops only whole nodes of nodeSize
+// entries, so an approximately trimmed stream can run up to a node over.
+type trimmingStream struct {
+	length   int64
+	longest  int64
+	nodeSize int64
+}
+
+func (s *trimmingStream) xadd(args []interface{}) {
+	s.length++
+	for i := 0; i+2 < len(args); i++ {
+		if args[i] != "maxlen" || args[i+1] != "~" {
+			continue
+		}
+		maxLen, _ := strconv.ParseInt(fmt.Sprint(args[i+2]), 10, 64)
+		for s.length-s.nodeSize >= maxLen {
+			s.length -= s.nodeSize
+		}
+	}
+	s.longest = max(s.longest, s.length)
+}
+
+func (s *trimmingStream) process(cmd redis.Cmder) error {
+	switch c := cmd.(type) {
+	case *redis.StringCmd: // XADD
+		s.xadd(cmd.Args())
+		c.SetVal(fmt.Sprintf("%d-0", s.length))
+	case *redis.IntCmd: // XLEN
+		c.SetVal(s.length)
+	default:
+		cmd.SetErr(fmt.Errorf("unexpected command %v", cmd.Args()))
+		return cmd.Err()
+	}
+	return nil
+}
+
+func (s *trimmingStream) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (s *trimmingStream) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error { return s.process(cmd) }
+}
+
+func (s *trimmingStream) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			if err := s.process(cmd); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func newTrimmingProducer(maxLen int64) (*ClickProducer, *trimmingStream) {
+	stream := &trimmingStream{nodeSize: 100}
+	client := redis.NewClient(&redis.Options{})
+	client.AddHook(stream)
+	return NewClickProducer(client, "clicks:stream", maxLen, nil), stream
+}
+
+// publish sends n clicks, half one at a time and half in batches of 50.
+func publish(t *testing.T, p *ClickProducer, n int) {
+	t.Helper()
+	ctx := context.Background()
+	for i := 0; i < n/2; i++ {
+		if err := p.Publish(ctx, &ClickEvent{ShortCode: "abc123", Timestamp: int64(i)}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+	batch := make([]*ClickEvent, 50)
+	for i := range batch {
+		batch[i] = &ClickEvent{ShortCode: "abc123"}
+	}
+	for i := 0; i < n/2; i += len(batch) {
+		if err := p.PublishBatch(ctx, batch); err != nil {
+			t.Fatalf("PublishBatch: %v", err)
+		}
+	}
+}
+
+// TestClickProducer_MaxLenBoundsStream publishes well past the cap and
+// checks the stream never grew more than a node beyond it.
+func TestClickProducer_MaxLenBoundsStream(t *testing.T) {
+	p, stream := newTrimmingProducer(1000)
+	publish(t, p, 10000)
+
+	if stream.longest > 1000+stream.nodeSize {
+		t.Errorf("expected the stream to stay within a node of 1000 entries, it reached %d", stream.longest)
+	}
+	n, err := p.StreamLength(context.Background())
+	if err != nil || n < 1000 {
+		t.Errorf("expected at least 1000 entries to be kept, got %d, %v", n, err)
+	}
+}
+
+func TestClickProducer_UnboundedByDefault(t *testing.T) {
+	p, _ := newTrimmingProducer(0)
+	publish(t, p, 2000)
+
+	if n, err := p.StreamLength(context.Background()); err != nil || n != 2000 {
+		t.Errorf("expected all 2000 entries to be kept, got %d, %v", n, err)
+	}
+}
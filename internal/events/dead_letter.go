@@ -0,0 +1,28 @@
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DeadLetter copies a click stream entry a worker refused to process to the
+// Redis Stream called stream, adding the entry's original ID, the consumer
+// group that refused it and why, so it can be inspected or replayed. The
+// caller acknowledges the original only once this succeeds; on an error it
+// stays pending and is delivered again.
+func DeadLetter(ctx context.Context, client *redis.Client, stream string, msg redis.XMessage, group, reason string) error {
+	fields := make(map[string]interface{}, len(msg.Values)+3)
+	for k, v := range msg.Values {
+		fields[k] = v
+	}
+	fields["source_id"] = msg.ID
+	fields["consumer_group"] = group
+	fields["reason"] = reason
+
+	if err := client.XAdd(ctx, &redis.XAddArgs{Stream: stream, Values: fields}).Err(); err != nil {
+		return fmt.Errorf("failed to dead-letter click event %s: %w", msg.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// flushKey is the hash holding when each consumer of group last flushed a
+// batch read from stream, keyed by consumer name.
+func flushKey(stream, group string) string {
+	return fmt.Sprintf("%s:flushes:%s", stream, group)
+}
+
+// RecordFlush records that consumer of group has just stored and
+// acknowledged a batch read from stream. The workers call it after every
+// successful flush, so an admin endpoint can tell a worker that is stuck
+// from one that is keeping up. The hash does not expire; a consumer that is
+// gone keeps its last entry until it is removed with HDEL.
+func RecordFlush(ctx context.Context, client *redis.Client, stream, group, consumer string) error {
+	if err := client.HSet(ctx, flushKey(stream, group), consumer, time.Now().UnixMilli()).Err(); err != nil {
+		return fmt.Errorf("failed to record flush: %w", err)
+	}
+	return nil
+}
+
+// LastFlushes returns when each consumer of group last recorded a flush, an
+// empty map if none has.
+func (s *Stream) LastFlushes(ctx context.Context, group string) (map[string]time.Time, error) {
+	fields, err := s.client.HGetAll(ctx, flushKey(s.name, group)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last flushes for %s: %w", group, err)
+	}
+	flushes := make(map[string]time.Time, len(fields))
+	for consumer, ms := range fields {
+		n, err := strconv.ParseInt(ms, 10, 64)
+		if err != nil {
+			continue
+		}
+		flushes[consumer] = time.UnixMilli(n)
+	}
+	return flushes, nil
+}
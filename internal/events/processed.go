@@ -0,0 +1,81 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// clickEventNamespace is the UUID namespace of EventID.
+var clickEventNamespace = uuid.MustParse("0b6f3c1e-6a52-4f4e-9d8a-2f1f6a7c9e41")
+
+// EventID returns the ID of the click event read from the stream entry
+// msgID of stream. It is derived from the entry rather than random, so an
+// entry delivered twice yields the same event ID and the copies can be
+// recognised downstream (Elasticsearch already indexes clicks by it).
+func EventID(stream, msgID string) string {
+	return uuid.NewSHA1(clickEventNamespace, []byte(stream+"/"+msgID)).String()
+}
+
+// ProcessedSet remembers which stream entries a consumer group has stored,
+// so that one delivered again, because the worker stopped before
+// acknowledging it or another consumer claimed it, is skipped instead of
+// stored twice. Each entry is remembered for ttl in its own Redis key.
+//
+// An entry is marked once its event is stored and before it is
+// acknowledged; a worker stopping between the two is the one case that
+// still stores an event twice. A nil *ProcessedSet remembers nothing.
+type ProcessedSet struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+// NewProcessedSet creates a ProcessedSet for group's entries of stream.
+func NewProcessedSet(client *redis.Client, stream, group string, ttl time.Duration) *ProcessedSet {
+	return &ProcessedSet{
+		client: client,
+		prefix: fmt.Sprintf("%s:processed:%s:", stream, group),
+		ttl:    ttl,
+	}
+}
+
+// Seen reports which of msgIDs are marked as processed.
+func (p *ProcessedSet) Seen(ctx context.Context, msgIDs []string) (map[string]bool, error) {
+	if p == nil || len(msgIDs) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, len(msgIDs))
+	for i, id := range msgIDs {
+		keys[i] = p.prefix + id
+	}
+	values, err := p.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check processed click events: %w", err)
+	}
+	seen := make(map[string]bool)
+	for i, v := range values {
+		if v != nil {
+			seen[msgIDs[i]] = true
+		}
+	}
+	return seen, nil
+}
+
+// Mark records msgIDs as processed.
+func (p *ProcessedSet) Mark(ctx context.Context, msgIDs []string) error {
+	if p == nil || len(msgIDs) == 0 {
+		return nil
+	}
+	pipe := p.client.Pipeline()
+	for _, id := range msgIDs {
+		pipe.Set(ctx, p.prefix+id, 1, p.ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to mark click events processed: %w", err)
+	}
+	return nil
+}
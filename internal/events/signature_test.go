@@ -0,0 +1,75 @@
+package events
+
+import (
+	"fmt"
+	"testing"
+)
+
+// readBack returns the entry the producer would publish for event as a
+// consumer reads it from Redis, where every value comes back a string.
+func readBack(p *ClickProducer, event *ClickEvent) map[string]interface{} {
+	values := make(map[string]interface{})
+	for k, v := range p.fields(event) {
+		values[k] = fmt.Sprint(v)
+	}
+	return values
+}
+
+func signedEvent() *ClickEvent {
+	return &ClickEvent{
+		ShortCode:   "abc123",
+		Timestamp:   1700000000,
+		IP:          "198.51.100.7",
+		OriginalURL: "https://example.com",
+		Variant:     2,
+		Duplicate:   true,
+	}
+}
+
+func TestSigner_AcceptsPublishedEvent(t *testing.T) {
+	signer, err := NewSigner("shared-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewClickProducer(nil, "clicks:stream", 0, signer)
+
+	if err := signer.Verify(readBack(p, signedEvent())); err != nil {
+		t.Errorf("expected a published event to verify, got %v", err)
+	}
+}
+
+func TestSigner_RejectsTamperedEvent(t *testing.T) {
+	signer, _ := NewSigner("shared-secret")
+	other, _ := NewSigner("another-secret")
+	p := NewClickProducer(nil, "clicks:stream", 0, signer)
+
+	for name, tamper := range map[string]func(map[string]interface{}){
+		"changed field":     func(v map[string]interface{}) { v["short_code"] = "xyz789" },
+		"added field":       func(v map[string]interface{}) { v["geo_rule"] = "US" },
+		"removed field":     func(v map[string]interface{}) { delete(v, "duplicate") },
+		"missing signature": func(v map[string]interface{}) { delete(v, SignatureField) },
+		"wrong secret":      func(v map[string]interface{}) { other.Sign(v) },
+	} {
+		values := readBack(p, signedEvent())
+		tamper(values)
+		if err := signer.Verify(values); err != ErrBadSignature {
+			t.Errorf("%s: expected ErrBadSignature, got %v", name, err)
+		}
+	}
+}
+
+func TestSigner_DisabledWhenNil(t *testing.T) {
+	var signer *Signer
+	p := NewClickProducer(nil, "clicks:stream", 0, signer)
+
+	values := readBack(p, signedEvent())
+	if _, ok := values[SignatureField]; ok {
+		t.Error("expected no signature without a signer")
+	}
+	if err := signer.Verify(values); err != nil {
+		t.Errorf("expected a nil signer to accept every event, got %v", err)
+	}
+	if _, err := NewSigner(""); err == nil {
+		t.Error("expected an error for an empty secret")
+	}
+}
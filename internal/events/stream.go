@@ -0,0 +1,129 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clock"
+	"github.com/redis/go-redis/v9"
+)
+
+// Stream reports on the backlog of the click stream: how long it is and how
+// far behind each consumer group reading it has fallen. It only reads, with
+// XLEN, XINFO GROUPS, XPENDING, XRANGE and HGETALL, so it is safe to call from an
+// admin endpoint while the workers are consuming.
+type Stream struct {
+	client *redis.Client
+	name   string
+	clock  clock.Clock // for the age of the oldest pending entry
+}
+
+// NewStream returns a Stream for the Redis Stream called name.
+func NewStream(client *redis.Client, name string) *Stream {
+	return &Stream{client: client, name: name, clock: clock.Real{}}
+}
+
+// Name returns the stream's key.
+func (s *Stream) Name() string {
+	return s.name
+}
+
+// GroupLag is how far one consumer group is behind the stream. Pending
+// entries were delivered to a consumer but not yet acknowledged; undelivered
+// ones were added after the last entry the group read. Their sum, Lag, is
+// the work the group still has to do.
+type GroupLag struct {
+	Group       string `json:"group"`
+	Consumers   int64  `json:"consumers"`
+	Pending     int64  `json:"pending"`
+	Undelivered int64  `json:"undelivered"`
+	Lag         int64  `json:"lag"`
+
+	// OldestPendingSeconds is how long ago the oldest pending entry was
+	// added, or 0 if none is pending. A value that keeps growing points to
+	// a consumer that died holding entries.
+	OldestPendingSeconds int64 `json:"oldest_pending_seconds"`
+}
+
+// Length returns the number of entries in the stream, 0 if it does not
+// exist yet.
+func (s *Stream) Length(ctx context.Context) (int64, error) {
+	n, err := s.client.XLen(ctx, s.name).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stream length: %w", err)
+	}
+	return n, nil
+}
+
+// Lag returns the backlog of every consumer group on the stream, in the
+// order XINFO GROUPS lists them. A stream that does not exist yet has no
+// groups.
+//
+// Redis reports the undelivered count itself, except after entries were
+// deleted or trimmed from the middle of the stream, when it cannot tell; Lag
+// then counts the entries after the group's last-delivered ID.
+func (s *Stream) Lag(ctx context.Context) ([]GroupLag, error) {
+	groups, err := s.client.XInfoGroups(ctx, s.name).Result()
+	if err != nil {
+		if strings.Contains(err.Error(), "no such key") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get consumer groups: %w", err)
+	}
+
+	lags := make([]GroupLag, 0, len(groups))
+	for _, g := range groups {
+		lag := GroupLag{Group: g.Name, Consumers: g.Consumers, Pending: g.Pending, Undelivered: g.Lag}
+		if lag.Undelivered < 0 {
+			after, err := s.client.XRange(ctx, s.name, "("+g.LastDeliveredID, "+").Result()
+			if err != nil {
+				return nil, fmt.Errorf("failed to count undelivered entries for %s: %w", g.Name, err)
+			}
+			lag.Undelivered = int64(len(after))
+		}
+		lag.Lag = lag.Pending + lag.Undelivered
+
+		if lag.Pending > 0 {
+			age, err := s.OldestPendingAge(ctx, g.Name)
+			if err != nil {
+				return nil, err
+			}
+			lag.OldestPendingSeconds = int64(age / time.Second)
+		}
+		lags = append(lags, lag)
+	}
+	return lags, nil
+}
+
+// OldestPendingAge returns how long ago the oldest entry delivered to group
+// but not yet acknowledged was added to the stream, or 0 if none is pending.
+// The age is read from the entry's ID, so it counts from when the click was
+// published, not from when a consumer read it.
+func (s *Stream) OldestPendingAge(ctx context.Context, group string) (time.Duration, error) {
+	pending, err := s.client.XPending(ctx, s.name, group).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pending entries for %s: %w", group, err)
+	}
+	if pending.Count == 0 {
+		return 0, nil
+	}
+	added, ok := entryTime(pending.Lower)
+	if !ok {
+		return 0, nil
+	}
+	return max(s.clock.Now().Sub(added), 0), nil
+}
+
+// entryTime returns when the entry with the given ID was added, read from
+// the millisecond timestamp Redis puts before the dash.
+func entryTime(id string) (time.Time, bool) {
+	ms, _, _ := strings.Cut(id, "-")
+	n, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(n), true
+}
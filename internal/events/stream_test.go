@@ -0,0 +1,163 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clock/clocktest"
+	"github.com/redis/go-redis/v9"
+)
+
+// seededStream answers the commands Stream sends from fixed data instead of
+// a Redis server: entries added one a second from base, and the consumer
+// groups reading them.
+type seededStream struct {
+	base    time.Time
+	entries int
+	groups  []redis.XInfoGroup
+	pending map[string]string            // group -> ID of its oldest pending entry
+	flushes map[string]map[string]string // flush hash key -> consumer -> Unix ms
+}
+
+func (s *seededStream) id(i int) string {
+	return fmt.Sprintf("%d-0", s.base.Add(time.Duration(i)*time.Second).UnixMilli())
+}
+
+func (s *seededStream) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (s *seededStream) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		args := cmd.Args()
+		switch c := cmd.(type) {
+		case *redis.IntCmd: // XLEN
+			c.SetVal(int64(s.entries))
+		case *redis.XInfoGroupsCmd:
+			if s.groups == nil {
+				c.SetErr(errors.New("ERR no such key"))
+				return c.Err()
+			}
+			c.SetVal(s.groups)
+		case *redis.XMessageSliceCmd: // XRANGE key (after +
+			after := strings.TrimPrefix(args[2].(string), "(")
+			var msgs []redis.XMessage
+			for i := 0; i < s.entries; i++ {
+				if s.id(i) > after {
+					msgs = append(msgs, redis.XMessage{ID: s.id(i)})
+				}
+			}
+			c.SetVal(msgs)
+		case *redis.XPendingCmd:
+			if lower, ok := s.pending[args[2].(string)]; ok {
+				c.SetVal(&redis.XPending{Count: 1, Lower: lower, Higher: lower})
+			} else {
+				c.SetVal(&redis.XPending{})
+			}
+		case *redis.MapStringStringCmd: // HGETALL
+			c.SetVal(s.flushes[args[1].(string)])
+		default:
+			cmd.SetErr(fmt.Errorf("unexpected command %v", args))
+			return cmd.Err()
+		}
+		return nil
+	}
+}
+
+func (s *seededStream) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func newSeededStream(seed *seededStream, now time.Time) *Stream {
+	client := redis.NewClient(&redis.Options{})
+	client.AddHook(seed)
+	s := NewStream(client, "clicks:stream")
+	s.clock = clocktest.NewFake(now)
+	return s
+}
+
+func TestStream_Lag(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	seed := &seededStream{base: base, entries: 10}
+	seed.groups = []redis.XInfoGroup{
+		// Caught up: everything read and acknowledged.
+		{Name: "analytics-group", Consumers: 2, LastDeliveredID: seed.id(9), Lag: 0},
+		// Read up to entry 6, with 2 entries still unacknowledged, the
+		// oldest being entry 5.
+		{Name: "pipeline-group", Consumers: 1, Pending: 2, LastDeliveredID: seed.id(6), Lag: 3},
+		// Redis could not tell the lag: it is counted from entry 3 on.
+		{Name: "replay-group", Consumers: 1, LastDeliveredID: seed.id(2), Lag: -1},
+	}
+	seed.pending = map[string]string{"pipeline-group": seed.id(5)}
+	stream := newSeededStream(seed, base.Add(time.Minute))
+
+	lags, err := stream.Lag(context.Background())
+	if err != nil {
+		t.Fatalf("Lag: %v", err)
+	}
+	want := []GroupLag{
+		{Group: "analytics-group", Consumers: 2},
+		{Group: "pipeline-group", Consumers: 1, Pending: 2, Undelivered: 3, Lag: 5, OldestPendingSeconds: 55},
+		{Group: "replay-group", Consumers: 1, Undelivered: 7, Lag: 7},
+	}
+	if len(lags) != len(want) {
+		t.Fatalf("expected %d groups, got %+v", len(want), lags)
+	}
+	for i := range want {
+		if lags[i] != want[i] {
+			t.Errorf("group %d: expected %+v, got %+v", i, want[i], lags[i])
+		}
+	}
+
+	if n, err := stream.Length(context.Background()); err != nil || n != 10 {
+		t.Errorf("expected a length of 10, got %d, %v", n, err)
+	}
+}
+
+func TestStream_LagWithoutStream(t *testing.T) {
+	stream := newSeededStream(&seededStream{}, time.Now())
+
+	lags, err := stream.Lag(context.Background())
+	if err != nil || len(lags) != 0 {
+		t.Errorf("expected no groups for a missing stream, got %v, %v", lags, err)
+	}
+}
+
+func TestStream_OldestPendingAge(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	seed := &seededStream{base: base, entries: 10}
+	seed.pending = map[string]string{"pipeline-group": seed.id(3)}
+	stream := newSeededStream(seed, base.Add(2*time.Minute))
+
+	age, err := stream.OldestPendingAge(context.Background(), "pipeline-group")
+	if err != nil || age != 2*time.Minute-3*time.Second {
+		t.Errorf("expected 1m57s, got %v, %v", age, err)
+	}
+	if age, err := stream.OldestPendingAge(context.Background(), "analytics-group"); err != nil || age != 0 {
+		t.Errorf("expected 0 with nothing pending, got %v, %v", age, err)
+	}
+}
+
+func TestStream_LastFlushes(t *testing.T) {
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	seed := &seededStream{flushes: map[string]map[string]string{
+		"clicks:stream:flushes:pipeline-group": {
+			"worker-1": fmt.Sprint(at.UnixMilli()),
+			"worker-2": "garbage",
+		},
+	}}
+	stream := newSeededStream(seed, at)
+
+	flushes, err := stream.LastFlushes(context.Background(), "pipeline-group")
+	if err != nil {
+		t.Fatalf("LastFlushes: %v", err)
+	}
+	if len(flushes) != 1 || !flushes["worker-1"].Equal(at) {
+		t.Errorf("expected worker-1 at %v only, got %v", at, flushes)
+	}
+	if flushes, err := stream.LastFlushes(context.Background(), "analytics-group"); err != nil || len(flushes) != 0 {
+		t.Errorf("expected no flushes for a group without any, got %v, %v", flushes, err)
+	}
+}
@@ -0,0 +1,177 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/lock"
+	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/Varun5711/shorternit/internal/netguard"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// leaderLockKey is the Redis key the redirect replicas compete for;
+	// one prober per interval is enough, as the flags are shared.
+	leaderLockKey = "lock:failover-checker"
+
+	// maxConcurrentProbes bounds the probes in flight during a pass.
+	maxConcurrentProbes = 16
+
+	// flagIntervals is how many probe intervals a down flag outlives the
+	// probe that set it.
+	flagIntervals = 3
+
+	userAgent = "TinyHealthCheck/1.0"
+)
+
+// DestinationHealthChecker probes the watched primaries and keeps their
+// down flags.
+type DestinationHealthChecker struct {
+	state    stateStore
+	probe    func(ctx context.Context, target string) error
+	newLock  func() lock.Locker // nil runs every pass without election
+	interval time.Duration      // time between passes, and the leader lease
+	timeout  time.Duration      // limit on a single probe
+	log      *logger.Logger
+}
+
+// NewDestinationHealthChecker creates a checker that probes the primaries
+// recorded in client every interval, giving each probe timeout to answer.
+func NewDestinationHealthChecker(client *redis.Client, interval, timeout time.Duration, log *logger.Logger) *DestinationHealthChecker {
+	httpClient := &http.Client{
+		Transport: netguard.NewTransport(timeout, 1),
+		// The primary answering with a redirect is the primary up.
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return &DestinationHealthChecker{
+		state: redisState{client: client},
+		probe: newProbe(httpClient),
+		newLock: func() lock.Locker {
+			return lock.NewDistributedLock(client, leaderLockKey, interval)
+		},
+		interval: interval,
+		timeout:  timeout,
+		log:      log,
+	}
+}
+
+// newProbe returns a probe that GETs the target with client. The primary is
+// down when the request fails or it answers with a 5xx status; anything
+// else, including a 4xx, means the server is up and serving.
+func newProbe(client *http.Client) func(ctx context.Context, target string) error {
+	return func(ctx context.Context, target string) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("User-Agent", userAgent)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		// Drain a little of the body so the connection can be reused, but
+		// never read a whole page.
+		_, _ = io.CopyN(io.Discard, resp.Body, 4<<10)
+		resp.Body.Close()
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("status %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// Run performs a pass immediately and then every interval until ctx is
+// cancelled.
+func (c *DestinationHealthChecker) Run(ctx context.Context) {
+	c.RunOnce(ctx)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce probes every watched primary once and updates its flag. It only
+// runs on the replica that takes the leader lease, which is left to expire
+// so that it covers the whole interval. Errors are logged; the next pass
+// retries.
+func (c *DestinationHealthChecker) RunOnce(ctx context.Context) {
+	if c.newLock != nil {
+		acquired, err := c.newLock().Acquire(ctx)
+		if err != nil {
+			c.log.Error("Failed to acquire failover checker lease, skipping this pass: %v", err)
+			return
+		}
+		if !acquired {
+			return
+		}
+	}
+
+	primaries, err := c.state.Watched(ctx, time.Now().Add(-watchRetention))
+	if err != nil {
+		c.log.Error("Failed to list watched destinations: %v", err)
+		return
+	}
+
+	sem := make(chan struct{}, maxConcurrentProbes)
+	var wg sync.WaitGroup
+	for _, primary := range primaries {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			c.check(ctx, primary)
+		}()
+	}
+	wg.Wait()
+}
+
+// check probes one primary and records the outcome.
+func (c *DestinationHealthChecker) check(ctx context.Context, primary string) {
+	probeCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	err := c.probe(probeCtx, primary)
+	cancel()
+
+	switch {
+	case errors.Is(err, netguard.ErrForbiddenAddress):
+		// A primary on a non-public address is never probed, so its
+		// state is left unchanged.
+		return
+	case err != nil:
+		wasDown, markErr := c.state.MarkDown(ctx, primary, flagIntervals*c.interval)
+		if markErr != nil {
+			c.log.Error("Failed to flag %s as down: %v", primary, markErr)
+			return
+		}
+		if !wasDown {
+			c.log.Warn("Destination %s is down, failing over to backups: %v", primary, err)
+		}
+	default:
+		wasDown, markErr := c.state.MarkUp(ctx, primary)
+		if markErr != nil {
+			c.log.Error("Failed to clear the down flag of %s: %v", primary, markErr)
+			return
+		}
+		if wasDown {
+			c.log.Info("Destination %s has recovered", primary)
+		}
+	}
+}
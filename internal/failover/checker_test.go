@@ -0,0 +1,179 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/Varun5711/shorternit/internal/netguard"
+)
+
+// memState is an in-memory stateStore. Flags do not expire.
+type memState struct {
+	mu      sync.Mutex
+	watched []string
+	down    map[string]time.Duration
+}
+
+func newMemState(watched ...string) *memState {
+	return &memState{watched: watched, down: make(map[string]time.Duration)}
+}
+
+func (s *memState) Watched(ctx context.Context, since time.Time) ([]string, error) {
+	return s.watched, nil
+}
+
+func (s *memState) MarkDown(ctx context.Context, primary string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, was := s.down[primary]
+	s.down[primary] = ttl
+	return was, nil
+}
+
+func (s *memState) MarkUp(ctx context.Context, primary string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, was := s.down[primary]
+	delete(s.down, primary)
+	return was, nil
+}
+
+func (s *memState) isDown(primary string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.down[primary]
+	return ok
+}
+
+// stubProbe answers each target with the error set for it.
+type stubProbe struct {
+	mu      sync.Mutex
+	results map[string]error
+}
+
+func (p *stubProbe) set(target string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.results[target] = err
+}
+
+func (p *stubProbe) probe(ctx context.Context, target string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.results[target]
+}
+
+func newTestChecker(state stateStore, probe func(context.Context, string) error) *DestinationHealthChecker {
+	return &DestinationHealthChecker{
+		state:    state,
+		probe:    probe,
+		interval: 30 * time.Second,
+		timeout:  time.Second,
+		log:      logger.New("failover-test"),
+	}
+}
+
+// TestRunOnce_UpDownTransitions verifies that a primary is flagged while its
+// probes fail and cleared once they succeed again.
+func TestRunOnce_UpDownTransitions(t *testing.T) {
+	ctx := context.Background()
+	const primary, other = "https://primary.example.com", "https://other.example.com"
+	state := newMemState(primary, other)
+	probe := &stubProbe{results: map[string]error{}}
+	c := newTestChecker(state, probe.probe)
+
+	c.RunOnce(ctx)
+	if state.isDown(primary) || state.isDown(other) {
+		t.Fatal("expected healthy primaries to stay up")
+	}
+
+	probe.set(primary, errors.New("connection refused"))
+	c.RunOnce(ctx)
+	if !state.isDown(primary) {
+		t.Fatal("expected a failing primary to be flagged down")
+	}
+	if state.isDown(other) {
+		t.Error("expected the healthy primary to stay up")
+	}
+	if ttl := state.down[primary]; ttl != flagIntervals*c.interval {
+		t.Errorf("expected the flag to last %s, got %s", flagIntervals*c.interval, ttl)
+	}
+
+	probe.set(primary, nil)
+	c.RunOnce(ctx)
+	if state.isDown(primary) {
+		t.Error("expected a recovered primary to be cleared")
+	}
+}
+
+// TestRunOnce_ForbiddenAddressKeepsState verifies that a primary the prober
+// refuses to dial is neither flagged nor cleared.
+func TestRunOnce_ForbiddenAddressKeepsState(t *testing.T) {
+	ctx := context.Background()
+	const primary = "http://10.0.0.1"
+	state := newMemState(primary)
+	c := newTestChecker(state, func(context.Context, string) error { return netguard.ErrForbiddenAddress })
+
+	c.RunOnce(ctx)
+	if state.isDown(primary) {
+		t.Error("expected a forbidden primary not to be flagged")
+	}
+
+	state.down[primary] = time.Minute
+	c.RunOnce(ctx)
+	if !state.isDown(primary) {
+		t.Error("expected a forbidden primary's flag to be left alone")
+	}
+}
+
+// TestProbe_Status verifies how the HTTP probe classifies responses.
+func TestProbe_Status(t *testing.T) {
+	tests := []struct {
+		status int
+		down   bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusFound, false},
+		{http.StatusNotFound, false},
+		{http.StatusInternalServerError, true},
+		{http.StatusServiceUnavailable, true},
+	}
+
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("User-Agent") != userAgent {
+				t.Errorf("unexpected User-Agent %q", r.Header.Get("User-Agent"))
+			}
+			if tt.status == http.StatusFound {
+				w.Header().Set("Location", "/elsewhere")
+			}
+			w.WriteHeader(tt.status)
+		}))
+		client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}}
+
+		err := newProbe(client)(context.Background(), srv.URL)
+		if down := err != nil; down != tt.down {
+			t.Errorf("status %d: expected down=%v, got err=%v", tt.status, tt.down, err)
+		}
+		srv.Close()
+	}
+}
+
+// TestProbe_Unreachable verifies that a server that does not answer is down.
+func TestProbe_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	target := srv.URL
+	srv.Close()
+
+	if err := newProbe(http.DefaultClient)(context.Background(), target); err == nil {
+		t.Error("expected a closed server to be down")
+	}
+}
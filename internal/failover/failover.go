@@ -0,0 +1,111 @@
+// Package failover lets a link fall back to a backup destination while its
+// primary is down.
+//
+// A link created with a backup_url keeps both destinations. The redirect
+// service asks Health whether the primary is down before redirecting, and
+// sends the visitor to the backup when it is. The answer is a flag in Redis
+// set by the DestinationHealthChecker, which probes the primaries in the
+// background; no probe ever runs on the redirect path.
+//
+// The checker learns which primaries to probe from the redirects
+// themselves: every Health lookup records the primary in a Redis sorted set
+// with the time it was last seen, and primaries no redirect has asked about
+// for a day drop out of it. Links that nobody visits are not probed.
+//
+// Down flags expire after a few probe intervals, so a checker that stops
+// running leaves every primary assumed up rather than pinning links to
+// their backups.
+package failover
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// watchedKey is the sorted set of primaries to probe, scored by the
+	// Unix time a redirect last asked about them.
+	watchedKey = "failover:watched"
+	// downKeyPrefix prefixes the flag set while a primary is down.
+	downKeyPrefix = "failover:down:"
+
+	// watchRetention is how long a primary stays probed after the last
+	// redirect that asked about it.
+	watchRetention = 24 * time.Hour
+)
+
+// downKey returns the flag key for primary. URLs are hashed so that the key
+// length is bounded whatever the destination.
+func downKey(primary string) string {
+	sum := sha256.Sum256([]byte(primary))
+	return downKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+// Health answers whether a primary destination is down.
+type Health struct {
+	client *redis.Client
+}
+
+// NewHealth creates a Health reading the flags the checker keeps in client.
+func NewHealth(client *redis.Client) *Health {
+	return &Health{client: client}
+}
+
+// Down reports whether the checker has found primary down. It also marks
+// primary as watched, so the checker starts probing it; both happen in one
+// round trip.
+func (h *Health) Down(ctx context.Context, primary string) (bool, error) {
+	pipe := h.client.Pipeline()
+	pipe.ZAdd(ctx, watchedKey, redis.Z{Score: float64(time.Now().Unix()), Member: primary})
+	exists := pipe.Exists(ctx, downKey(primary))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	return exists.Val() > 0, nil
+}
+
+// stateStore holds the checker's view of the primaries. redisState is the
+// production implementation; tests use an in-memory one.
+type stateStore interface {
+	// Watched prunes the primaries last seen before since and returns the
+	// rest.
+	Watched(ctx context.Context, since time.Time) ([]string, error)
+	// MarkDown sets primary's down flag for ttl and reports whether it was
+	// already set.
+	MarkDown(ctx context.Context, primary string, ttl time.Duration) (bool, error)
+	// MarkUp clears primary's down flag and reports whether it was set.
+	MarkUp(ctx context.Context, primary string) (bool, error)
+}
+
+// redisState is the stateStore shared with Health.
+type redisState struct {
+	client *redis.Client
+}
+
+func (s redisState) Watched(ctx context.Context, since time.Time) ([]string, error) {
+	cutoff := "(" + strconv.FormatInt(since.Unix(), 10)
+	if err := s.client.ZRemRangeByScore(ctx, watchedKey, "-inf", cutoff).Err(); err != nil {
+		return nil, err
+	}
+	return s.client.ZRange(ctx, watchedKey, 0, -1).Result()
+}
+
+func (s redisState) MarkDown(ctx context.Context, primary string, ttl time.Duration) (bool, error) {
+	pipe := s.client.Pipeline()
+	existed := pipe.Exists(ctx, downKey(primary))
+	pipe.Set(ctx, downKey(primary), 1, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	return existed.Val() > 0, nil
+}
+
+func (s redisState) MarkUp(ctx context.Context, primary string) (bool, error) {
+	n, err := s.client.Del(ctx, downKey(primary)).Result()
+	return n > 0, err
+}
@@ -6,32 +6,192 @@
 package grpc
 
 import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/config"
+	"github.com/Varun5711/shorternit/internal/logger"
 	pb "github.com/Varun5711/shorternit/proto/url"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/keepalive"
 )
 
-// NewURLServiceClient creates a gRPC client for the URL shortening service.
+// MinKeepaliveTime is the most frequent keepalive ping servers accept (see
+// ServerOptions). Clients configured to ping more often are raised to it,
+// since a server answers over-eager pings by closing the connection.
+const MinKeepaliveTime = 10 * time.Second
+
+// Dial creates a client connection to address and waits up to
+// cfg.DialTimeout for it to become ready.
 //
-// The connection uses insecure (plaintext) transport because the services
-// communicate over an internal Docker network where TLS termination happens
-// at the API-gateway level. The otelgrpc StatsHandler is attached so that
-// every outgoing RPC automatically creates a child span linked to the
-// caller's trace context, enabling end-to-end distributed tracing in Jaeger.
+// The connection is plaintext unless cfg.TLSEnabled, in which case it uses
+// the TLS (or mutual TLS) credentials from ClientCredentials. The otelgrpc
+// StatsHandler is attached so that every outgoing RPC automatically creates
+// a child span linked to the caller's trace context, enabling end-to-end
+// distributed tracing in Jaeger, and ClientRequestID forwards the caller's
+// X-Request-ID.
 //
-// Note: the returned client holds an open connection. Callers that need to
-// shut down gracefully should keep a reference to the underlying *grpc.ClientConn
-// (currently encapsulated) and close it. A future refactor may return the
-// conn alongside the client for this purpose.
-func NewURLServiceClient(address string) (pb.URLServiceClient, error) {
-	conn, err := grpc.NewClient(address,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+// Idle connections are pinged every cfg.KeepaliveTime so intermediaries do
+// not silently drop them, and a broken connection is re-established with
+// exponential backoff capped at cfg.MaxBackoff. Unary calls made without a
+// deadline get cfg.CallTimeout (see CallTimeout). A server that is not ready
+// within the dial timeout is logged rather than treated as fatal: the
+// connection keeps retrying in the background, so services can start in any
+// order.
+func Dial(address string, cfg config.GRPCConfig) (*grpc.ClientConn, error) {
+	conn, err := newClientConn(address, cfg)
+	if err != nil {
+		return nil, err
+	}
+	waitForReady(address, cfg.DialTimeout, conn)
+	return conn, nil
+}
+
+// newClientConn creates the connection Dial describes without waiting for it.
+func newClientConn(address string, cfg config.GRPCConfig) (*grpc.ClientConn, error) {
+	creds, err := ClientCredentials(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	keepaliveTime := max(cfg.KeepaliveTime, MinKeepaliveTime)
+	backoffConfig := backoff.DefaultConfig
+	if cfg.MaxBackoff > 0 {
+		backoffConfig.MaxDelay = cfg.MaxBackoff
+		backoffConfig.BaseDelay = min(backoffConfig.BaseDelay, cfg.MaxBackoff)
+	}
+
+	return grpc.NewClient(address,
+		grpc.WithTransportCredentials(creds),
 		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                keepaliveTime,
+			Timeout:             cfg.KeepaliveTimeout,
+			PermitWithoutStream: true,
+		}),
+		grpc.WithConnectParams(grpc.ConnectParams{Backoff: backoffConfig}),
+		grpc.WithChainUnaryInterceptor(CallTimeout(cfg.CallTimeout), ClientRequestID()),
 	)
+}
+
+// CallTimeout returns an interceptor that gives each unary call whose
+// context has no deadline one of timeout. gRPC sends the deadline to the
+// server, where it bounds the handler's context and so its storage queries;
+// a call that runs past it fails with DeadlineExceeded, which the gateway
+// answers with 504. A timeout of zero or less leaves calls unbounded.
+func CallTimeout(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// waitForReady waits up to timeout (if positive) for all conns to become
+// ready and logs a warning when they do not; the connections keep retrying.
+func waitForReady(address string, timeout time.Duration, conns ...*grpc.ClientConn) {
+	if timeout <= 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	for _, conn := range conns {
+		if err := WaitForReady(ctx, conn); err != nil {
+			logger.New("grpc").Warn("%s not ready after %s, retrying in the background: %v", address, timeout, err)
+			return
+		}
+	}
+}
+
+// WaitForReady starts connecting conn and blocks until it is ready or ctx is
+// done, in which case ctx's error is returned.
+func WaitForReady(ctx context.Context, conn *grpc.ClientConn) error {
+	conn.Connect()
+	for {
+		state := conn.GetState()
+		if state == connectivity.Ready {
+			return nil
+		}
+		if state == connectivity.Shutdown {
+			return errors.New("connection closed")
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return ctx.Err()
+		}
+	}
+}
+
+// NewURLServiceClient creates a gRPC client for the URL shortening service
+// whose calls are spread round-robin over poolSize connections (at least
+// one), configured as by Dial. The pool waits once, up to cfg.DialTimeout,
+// for all of its connections to become ready. A pool lets the hot redirect
+// path use several HTTP/2 connections instead of funnelling every lookup
+// through one.
+//
+// Note: the returned client holds open connections. Callers that need to
+// shut down gracefully should keep a reference to the underlying pool
+// (currently encapsulated) and close it. A future refactor may return the
+// pool alongside the client for this purpose.
+func NewURLServiceClient(address string, cfg config.GRPCConfig, poolSize int) (pb.URLServiceClient, error) {
+	pool, err := newConnPool(address, cfg, max(poolSize, 1))
 	if err != nil {
 		return nil, err
 	}
+	return pb.NewURLServiceClient(pool), nil
+}
+
+// connPool is a grpc.ClientConnInterface that hands each call to the next of
+// its connections in turn.
+type connPool struct {
+	conns []*grpc.ClientConn
+	next  atomic.Uint32
+}
+
+// newConnPool creates size connections to address, closing the ones already
+// created if any fails.
+func newConnPool(address string, cfg config.GRPCConfig, size int) (*connPool, error) {
+	p := &connPool{conns: make([]*grpc.ClientConn, 0, size)}
+	for range size {
+		conn, err := newClientConn(address, cfg)
+		if err != nil {
+			_ = p.Close()
+			return nil, err
+		}
+		p.conns = append(p.conns, conn)
+	}
+	waitForReady(address, cfg.DialTimeout, p.conns...)
+	return p, nil
+}
+
+// pick returns the connection for the next call.
+func (p *connPool) pick() *grpc.ClientConn {
+	n := p.next.Add(1) - 1
+	return p.conns[n%uint32(len(p.conns))]
+}
 
-	return pb.NewURLServiceClient(conn), nil
+// Invoke performs a unary RPC on the next connection.
+func (p *connPool) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	return p.pick().Invoke(ctx, method, args, reply, opts...)
+}
+
+// NewStream opens a streaming RPC on the next connection.
+func (p *connPool) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return p.pick().NewStream(ctx, desc, method, opts...)
+}
+
+// Close closes every connection in the pool.
+func (p *connPool) Close() error {
+	var errs []error
+	for _, conn := range p.conns {
+		errs = append(errs, conn.Close())
+	}
+	return errors.Join(errs...)
 }
@@ -0,0 +1,138 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+var testConfig = config.GRPCConfig{
+	DialTimeout:      2 * time.Second,
+	KeepaliveTime:    30 * time.Second,
+	KeepaliveTimeout: 10 * time.Second,
+	MaxBackoff:       100 * time.Millisecond,
+}
+
+// startServer serves the gRPC health service on addr ("127.0.0.1:0" for any
+// free port) and returns the address it listens on.
+func startServer(t *testing.T, addr string) (string, *grpc.Server) {
+	t.Helper()
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	opts, err := ServerOptions(config.GRPCConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	srv := grpc.NewServer(opts...)
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String(), srv
+}
+
+// TestDial_WaitsUntilReady verifies that Dial returns a connection that is
+// already ready to serve calls.
+func TestDial_WaitsUntilReady(t *testing.T) {
+	addr, _ := startServer(t, "127.0.0.1:0")
+
+	conn, err := Dial(addr, testConfig)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer conn.Close()
+
+	if state := conn.GetState(); state != connectivity.Ready {
+		t.Errorf("expected a ready connection, got %s", state)
+	}
+}
+
+// TestDial_UnreachableServerTimesOut verifies that Dial gives up waiting
+// after the dial timeout instead of blocking, and still returns a usable
+// connection.
+func TestDial_UnreachableServerTimesOut(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := lis.Addr().String()
+	_ = lis.Close()
+
+	cfg := testConfig
+	cfg.DialTimeout = 200 * time.Millisecond
+	start := time.Now()
+	conn, err := Dial(addr, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer conn.Close()
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected Dial to return after the dial timeout, took %s", elapsed)
+	}
+	if state := conn.GetState(); state == connectivity.Ready {
+		t.Error("expected the connection not to be ready")
+	}
+}
+
+// TestDial_ReconnectsAfterServerRestart verifies that a connection recovers
+// on its own once a restarted server is listening again.
+func TestDial_ReconnectsAfterServerRestart(t *testing.T) {
+	addr, srv := startServer(t, "127.0.0.1:0")
+
+	conn, err := Dial(addr, testConfig)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	srv.Stop()
+	if !conn.WaitForStateChange(ctx, connectivity.Ready) {
+		t.Fatal("expected the connection to notice the server going away")
+	}
+	startServer(t, addr)
+
+	if err := WaitForReady(ctx, conn); err != nil {
+		t.Fatalf("expected the connection to recover: %v", err)
+	}
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Errorf("expected a call after reconnecting to succeed, got %v", err)
+	}
+}
+
+// TestConnPool_RoundRobin verifies that pooled calls rotate through every
+// connection and succeed.
+func TestConnPool_RoundRobin(t *testing.T) {
+	addr, _ := startServer(t, "127.0.0.1:0")
+
+	pool, err := newConnPool(addr, testConfig, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer pool.Close()
+
+	for i := 0; i < 6; i++ {
+		if got := pool.pick(); got != pool.conns[i%3] {
+			t.Errorf("call %d: expected connection %d", i, i%3)
+		}
+	}
+
+	client := healthpb.NewHealthClient(pool)
+	for i := 0; i < 3; i++ {
+		if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/logger"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// HealthCheck is a dependency probe (e.g. a database or Redis ping) whose
+// error marks the service as NOT_SERVING.
+type HealthCheck func(ctx context.Context) error
+
+// WatchHealth keeps the standard grpc_health_v1 status of a server in sync
+// with its backing dependencies. Every interval it runs all checks and sets
+// both the overall status ("") and the named service status to SERVING when
+// every check passes, or NOT_SERVING otherwise.
+//
+// The first round runs immediately so a healthy service reports SERVING as
+// soon as it starts, while a service whose database is still coming up stays
+// NOT_SERVING and is kept out of rotation by Kubernetes probes and service
+// meshes until the pings succeed. The loop exits when ctx is cancelled;
+// callers should then call hs.Shutdown() so in-flight health watchers see
+// NOT_SERVING during the drain.
+func WatchHealth(ctx context.Context, hs *health.Server, service string, interval time.Duration, log *logger.Logger, checks ...HealthCheck) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		servingStatus := healthpb.HealthCheckResponse_SERVING
+		for _, check := range checks {
+			checkCtx, cancel := context.WithTimeout(ctx, interval)
+			err := check(checkCtx)
+			cancel()
+			if err != nil {
+				servingStatus = healthpb.HealthCheckResponse_NOT_SERVING
+				if last != servingStatus {
+					log.Warn("Health check failed: %v", err)
+				}
+				break
+			}
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if servingStatus != last {
+			log.Info("Health status changed to %s", servingStatus)
+			last = servingStatus
+		}
+		hs.SetServingStatus("", servingStatus)
+		hs.SetServingStatus(service, servingStatus)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// requestIDHeader is the metadata key carrying an HTTP request's
+// X-Request-ID to the services it calls.
+const requestIDHeader = "x-request-id"
+
+// ClientRequestID returns an interceptor that forwards the request ID that
+// middleware.RequestID stored in the call's context as x-request-id
+// metadata. Calls made outside an HTTP request carry none.
+func ClientRequestID() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if id := middleware.GetRequestID(ctx); id != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, requestIDHeader, id)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// ServerRequestID returns an interceptor that reads the x-request-id sent by
+// ClientRequestID, tags the RPC's span with it as middleware.Tracing tags the
+// HTTP request's, and stores it in the handler's context, where
+// middleware.GetRequestID finds it as it would in the gateway.
+func ServerRequestID() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if ids := md.Get(requestIDHeader); len(ids) > 0 && ids[0] != "" {
+			trace.SpanFromContext(ctx).SetAttributes(middleware.RequestIDAttribute.String(ids[0]))
+			ctx = context.WithValue(ctx, middleware.RequestIDKey, ids[0])
+		}
+		return handler(ctx, req)
+	}
+}
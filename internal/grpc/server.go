@@ -0,0 +1,39 @@
+package grpc
+
+import (
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/config"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// ServerOptions returns the transport settings every Tiny gRPC server uses:
+// the credentials from ServerCredentials, the keepalive configuration, and
+// the otelgrpc stats handler, which continues the caller's trace in a span
+// for every inbound RPC, with ServerRequestID tagging it.
+// The enforcement policy admits the client pings configured by Dial (the
+// gRPC default only allows one every five minutes and answers more with
+// GOAWAY), and the server pings idle clients itself so half-open connections
+// are noticed and closed.
+func ServerOptions(cfg config.GRPCConfig) ([]grpc.ServerOption, error) {
+	creds, err := ServerCredentials(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return []grpc.ServerOption{
+		grpc.Creds(creds),
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(ServerRequestID()),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             MinKeepaliveTime,
+			PermitWithoutStream: true,
+		}),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    2 * time.Minute,
+			Timeout: 20 * time.Second,
+		}),
+	}, nil
+}
@@ -0,0 +1,223 @@
+package grpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// testPKI holds the paths of a throwaway CA and the server and client
+// certificates it signed.
+type testPKI struct {
+	caFile                string
+	serverCert, serverKey string
+	clientCert, clientKey string
+	otherCert, otherKey   string // client certificate from an unrelated CA
+}
+
+// newTestPKI writes a self-signed CA, a server certificate for 127.0.0.1 and
+// a client certificate into a temporary directory.
+func newTestPKI(t *testing.T) testPKI {
+	t.Helper()
+	dir := t.TempDir()
+
+	caCert, caKey := newCA(t)
+	otherCA, otherKey := newCA(t)
+	writePEM(t, filepath.Join(dir, "ca.pem"), "CERTIFICATE", caCert.Raw)
+
+	pki := testPKI{caFile: filepath.Join(dir, "ca.pem")}
+	pki.serverCert, pki.serverKey = issue(t, dir, "server", caCert, caKey, &x509.Certificate{
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+	})
+	pki.clientCert, pki.clientKey = issue(t, dir, "client", caCert, caKey, &x509.Certificate{
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	pki.otherCert, pki.otherKey = issue(t, dir, "other", otherCA, otherKey, &x509.Certificate{
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return pki
+}
+
+func newCA(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "tiny test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse CA: %v", err)
+	}
+	return cert, key
+}
+
+// issue signs tmpl with the CA and writes the certificate and key as
+// <name>.pem and <name>-key.pem.
+func issue(t *testing.T, dir, name string, ca *x509.Certificate, caKey *ecdsa.PrivateKey, tmpl *x509.Certificate) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	tmpl.SerialNumber = big.NewInt(time.Now().UnixNano())
+	tmpl.Subject = pkix.Name{CommonName: name}
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create %s certificate: %v", name, err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	certFile := filepath.Join(dir, name+".pem")
+	keyFile := filepath.Join(dir, name+"-key.pem")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+// startTLSServer serves the health service with the server settings of cfg.
+func startTLSServer(t *testing.T, cfg config.GRPCConfig) string {
+	t.Helper()
+	opts, err := ServerOptions(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	srv := grpc.NewServer(opts...)
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+// check dials addr with cfg and makes one health check call.
+func check(t *testing.T, addr string, cfg config.GRPCConfig) error {
+	t.Helper()
+	cfg.DialTimeout = 0
+	conn, err := Dial(addr, cfg)
+	if err != nil {
+		t.Fatalf("unexpected dial error: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	return err
+}
+
+// TestTLS_HandshakeAndPlaintextRejected verifies that a client trusting the
+// CA completes the TLS handshake, while plaintext clients and clients that
+// do not trust the server certificate are turned away.
+func TestTLS_HandshakeAndPlaintextRejected(t *testing.T) {
+	pki := newTestPKI(t)
+	addr := startTLSServer(t, config.GRPCConfig{
+		TLSEnabled:  true,
+		TLSCertFile: pki.serverCert,
+		TLSKeyFile:  pki.serverKey,
+	})
+
+	if err := check(t, addr, config.GRPCConfig{TLSEnabled: true, TLSCAFile: pki.caFile}); err != nil {
+		t.Errorf("expected the TLS call to succeed, got %v", err)
+	}
+	if err := check(t, addr, config.GRPCConfig{}); err == nil {
+		t.Error("expected a plaintext client to be rejected")
+	}
+	if err := check(t, addr, config.GRPCConfig{TLSEnabled: true}); err == nil {
+		t.Error("expected a client without the CA to reject the server certificate")
+	}
+}
+
+// TestTLS_Mutual verifies that under mutual TLS only clients presenting a
+// certificate signed by the configured CA are accepted.
+func TestTLS_Mutual(t *testing.T) {
+	pki := newTestPKI(t)
+	addr := startTLSServer(t, config.GRPCConfig{
+		TLSEnabled:  true,
+		TLSMutual:   true,
+		TLSCertFile: pki.serverCert,
+		TLSKeyFile:  pki.serverKey,
+		TLSCAFile:   pki.caFile,
+	})
+
+	client := config.GRPCConfig{
+		TLSEnabled:  true,
+		TLSMutual:   true,
+		TLSCertFile: pki.clientCert,
+		TLSKeyFile:  pki.clientKey,
+		TLSCAFile:   pki.caFile,
+	}
+	if err := check(t, addr, client); err != nil {
+		t.Errorf("expected the mTLS call to succeed, got %v", err)
+	}
+
+	if err := check(t, addr, config.GRPCConfig{TLSEnabled: true, TLSCAFile: pki.caFile}); err == nil {
+		t.Error("expected a client without a certificate to be rejected")
+	}
+
+	client.TLSCertFile, client.TLSKeyFile = pki.otherCert, pki.otherKey
+	if err := check(t, addr, client); err == nil {
+		t.Error("expected a client certificate from another CA to be rejected")
+	}
+}
+
+// TestServerCredentials_RequiresFiles verifies that enabling TLS without the
+// needed files fails at startup instead of serving plaintext.
+func TestServerCredentials_RequiresFiles(t *testing.T) {
+	pki := newTestPKI(t)
+	cases := map[string]config.GRPCConfig{
+		"no certificate": {TLSEnabled: true},
+		"mutual no CA":   {TLSEnabled: true, TLSMutual: true, TLSCertFile: pki.serverCert, TLSKeyFile: pki.serverKey},
+		"missing file":   {TLSEnabled: true, TLSCertFile: "/nonexistent.pem", TLSKeyFile: pki.serverKey},
+	}
+	for name, cfg := range cases {
+		if _, err := ServerCredentials(cfg); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+// SetURLActive handles PUT /api/urls/{code}/active, disabling or enabling
+// one of the authenticated user's links without deleting it. A disabled
+// link keeps its code, clicks and analytics, and its visitors get 410 Gone
+// until it is enabled again. It answers 404 if there is no such live link,
+// or 403 if it belongs to someone else.
+func (h *HTTPHandler) SetURLActive(w http.ResponseWriter, r *http.Request) {
+	shortCode := r.PathValue("code")
+	if shortCode == "" {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "short code is required")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	var req models.SetURLActiveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, models.ErrCodeInvalidJSON, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if req.Active == nil {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "active is required")
+		return
+	}
+
+	grpcResp, err := h.grpcClient.SetURLActive(r.Context(), &pb.SetURLActiveRequest{
+		ShortCode: shortCode,
+		UserId:    middleware.GetUserID(r.Context()),
+		Active:    *req.Active,
+	})
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to update URL")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.SetURLActiveResponse{
+		ShortCode: grpcResp.ShortCode,
+		Active:    grpcResp.Active,
+	})
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// activeClient toggles alice's links and refuses anyone else's, remembering
+// the last request.
+type activeClient struct {
+	pb.URLServiceClient
+	last *pb.SetURLActiveRequest
+}
+
+func (c *activeClient) SetURLActive(ctx context.Context, in *pb.SetURLActiveRequest, opts ...grpc.CallOption) (*pb.SetURLActiveResponse, error) {
+	c.last = in
+	if in.UserId != "alice" {
+		return nil, status.Error(codes.PermissionDenied, "you do not own this short code")
+	}
+	return &pb.SetURLActiveResponse{ShortCode: in.ShortCode, Active: in.Active}, nil
+}
+
+func putActive(h *HTTPHandler, userID, code, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPut, "/api/urls/"+code+"/active", strings.NewReader(body))
+	req.SetPathValue("code", code)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, userID))
+	rec := httptest.NewRecorder()
+	h.SetURLActive(rec, req)
+	return rec
+}
+
+func TestSetURLActive(t *testing.T) {
+	client := &activeClient{}
+	h := &HTTPHandler{grpcClient: client}
+
+	rec := putActive(h, "alice", "mine", `{"active":false}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var resp models.SetURLActiveResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.ShortCode != "mine" || resp.Active {
+		t.Errorf("expected mine disabled, got %+v", resp)
+	}
+	if client.last.UserId != "alice" || client.last.Active {
+		t.Errorf("expected alice's request to disable, got %+v", client.last)
+	}
+
+	for _, tc := range []struct {
+		name string
+		user string
+		body string
+		want int
+	}{
+		{"enable", "alice", `{"active":true}`, http.StatusOK},
+		{"missing active", "alice", `{}`, http.StatusBadRequest},
+		{"invalid JSON", "alice", "not json", http.StatusBadRequest},
+		{"not the owner", "bob", `{"active":false}`, http.StatusForbidden},
+	} {
+		if rec := putActive(h, tc.user, "mine", tc.body); rec.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", tc.name, tc.want, rec.Code, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	userpb "github.com/Varun5711/shorternit/proto/user"
+)
+
+// The admin endpoints act on every user's links and accounts. The gateway
+// mounts them behind AuthMiddleware.RequireRole("admin"), so none of them
+// scope by the caller's user ID; it is passed on only for the audit trail.
+
+// AdminListURLs handles GET /api/admin/urls, listing every user's links,
+// newest first. ?user_id= narrows the list to one user, and ?limit= (default
+// 100, at most 1000) and ?offset= page through it.
+func (h *HTTPHandler) AdminListURLs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	limit := int32(100)
+	if l := q.Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 1000 {
+			limit = int32(parsed)
+		}
+	}
+
+	offset := int32(0)
+	if o := q.Get("offset"); o != "" {
+		if parsed, err := strconv.ParseInt(o, 10, 32); err == nil && parsed >= 0 {
+			offset = int32(parsed)
+		}
+	}
+
+	grpcResp, err := h.grpcClient.ListURLs(r.Context(), &pb.ListURLsRequest{
+		Limit:  limit,
+		Offset: offset,
+		UserId: q.Get("user_id"),
+	})
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to list URLs")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, listedURLs(grpcResp))
+}
+
+// AdminDeleteURL handles DELETE /api/admin/urls/{code}, removing any user's
+// link. It answers 204, or 404 if there is no such link. The admin is
+// recorded as the actor in the link's audit trail.
+func (h *HTTPHandler) AdminDeleteURL(w http.ResponseWriter, r *http.Request) {
+	grpcResp, err := h.grpcClient.DeleteURL(r.Context(), &pb.DeleteURLRequest{
+		ShortCode: r.PathValue("code"),
+		UserId:    middleware.GetUserID(r.Context()),
+		Admin:     true,
+	})
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to delete URL")
+		return
+	}
+	if !grpcResp.Success {
+		writeError(w, r, models.ErrCodeURLNotFound, http.StatusNotFound, "URL not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdminGetURLHistory handles GET /api/admin/urls/{code}/history, the audit
+// trail of any user's link. Unlike the owner's view it also covers deleted
+// links and earlier ones that used the same short code; 404 if there never
+// was such a link.
+func (h *HTTPHandler) AdminGetURLHistory(w http.ResponseWriter, r *http.Request) {
+	h.respondURLHistory(w, r, &pb.GetURLHistoryRequest{
+		ShortCode: r.PathValue("code"),
+		UserId:    middleware.GetUserID(r.Context()),
+		Admin:     true,
+	})
+}
+
+// adminStatsPage is how many links AdminUserStats reads per ListURLs call,
+// the most the URL service returns at once.
+const adminStatsPage = 1000
+
+// AdminUserStats handles GET /api/admin/users/{id}/stats, summarizing a
+// user's links: how many they own, how many are live, and the clicks across
+// them. A user without links gets zeros rather than 404, as the URL service
+// does not know which user IDs exist.
+func (h *HTTPHandler) AdminUserStats(w http.ResponseWriter, r *http.Request) {
+	stats := models.UserURLStats{UserID: r.PathValue("id")}
+
+	for offset := int32(0); ; offset += adminStatsPage {
+		grpcResp, err := h.grpcClient.ListURLs(r.Context(), &pb.ListURLsRequest{
+			Limit:  adminStatsPage,
+			Offset: offset,
+			UserId: stats.UserID,
+		})
+		if err != nil {
+			respondGRPCError(w, r, err, "failed to get user stats")
+			return
+		}
+		for _, u := range grpcResp.Urls {
+			stats.Clicks += u.Clicks
+			if u.IsActive {
+				stats.Active++
+			}
+		}
+		stats.URLs = grpcResp.Total
+		if !grpcResp.HasMore || len(grpcResp.Urls) == 0 {
+			break
+		}
+	}
+
+	respondJSON(w, http.StatusOK, stats)
+}
+
+// DisableUser handles POST /api/admin/users/{id}/disable, disabling an
+// abusive account: it can no longer log in, and link creation and other
+// re-checked actions refuse the tokens it holds. It returns the account.
+func (h *AuthHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	h.setUserDisabled(w, r, true)
+}
+
+// EnableUser handles POST /api/admin/users/{id}/enable, reversing
+// DisableUser.
+func (h *AuthHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	h.setUserDisabled(w, r, false)
+}
+
+// setUserDisabled forwards the admin's own token to the user service, which
+// re-checks the admin role itself rather than trusting the gateway.
+func (h *AuthHandler) setUserDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	resp, err := h.userClient.SetUserDisabled(ctx, &userpb.SetUserDisabledRequest{
+		Token:    bearerToken(r),
+		UserId:   r.PathValue("id"),
+		Disabled: disabled,
+	})
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to update user")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(profileFromPB(resp.User))
+}
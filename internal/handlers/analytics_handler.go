@@ -1,52 +1,231 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Varun5711/shorternit/internal/analytics"
 	"github.com/Varun5711/shorternit/internal/clickhouse"
+	"github.com/Varun5711/shorternit/internal/clock"
 	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	usermodel "github.com/Varun5711/shorternit/internal/models/user"
+	"github.com/Varun5711/shorternit/internal/sharelink"
 )
 
 // AnalyticsHandler serves click-analytics endpoints that read from ClickHouse.
 // It exposes aggregate stats (total clicks, timelines, geographic distribution,
 // device breakdowns, top referrers) as well as raw click event listings. All
-// queries are scoped by short code so users can only see analytics for their
-// own URLs (access control is enforced upstream by the auth middleware).
+// queries are scoped by short code, and RequireLinkAccess decides who may
+// read a given link's analytics.
 type AnalyticsHandler struct {
 	analyticsService *analytics.Service
 	clickhouse       *clickhouse.Client
+	heatmapPrecision int           // decimal places heatmap locations are rounded to
+	owners           linkOwners    // who owns each link; nil lets everyone read analytics
+	shares           shareResolver // resolves ?share= tokens; may be nil
+	roles            roleChecker   // re-checks an admin's role; nil refuses the admin exception
+	public           bool          // per-link analytics are readable by anyone
+	clock            clock.Clock   // ends the lookback windows; nil means the wall clock
 	log              *logger.Logger
 }
 
+// linkOwners looks up the owner of a link. It is satisfied by
+// *analytics.Service; tests substitute a map.
+type linkOwners interface {
+	LinkOwner(ctx context.Context, shortCode string) (owner string, found bool, err error)
+}
+
+// shareResolver resolves a share token to the link it grants access to. It
+// is satisfied by *sharelink.Store.
+type shareResolver interface {
+	Resolve(ctx context.Context, token string) (string, error)
+}
+
+// roleChecker re-checks the role of the user behind a request against the
+// user store. It is satisfied by *middleware.AuthMiddleware.
+type roleChecker interface {
+	HasRole(r *http.Request, role string) bool
+}
+
 // NewAnalyticsHandler creates an AnalyticsHandler. The analytics.Service
 // provides pre-aggregated query methods, while the ClickHouse client is used
-// directly for raw click event retrieval.
-func NewAnalyticsHandler(service *analytics.Service, ch *clickhouse.Client) *AnalyticsHandler {
-	return &AnalyticsHandler{
+// directly for raw click event retrieval and the click heatmap, whose points
+// are clustered at heatmapPrecision decimal places of a degree. shares
+// resolves the share tokens accepted by RequireLinkAccess, which are refused
+// if it is nil; roles re-checks that an admin reading someone else's link is
+// still one, and public opens every link's analytics to anyone, as they
+// were before links had to be shared.
+func NewAnalyticsHandler(service *analytics.Service, ch *clickhouse.Client, heatmapPrecision int, shares *sharelink.Store, roles *middleware.AuthMiddleware, public bool) *AnalyticsHandler {
+	h := &AnalyticsHandler{
 		analyticsService: service,
 		clickhouse:       ch,
+		heatmapPrecision: heatmapPrecision,
+		public:           public,
+		clock:            clock.Real{},
 		log:              logger.New("analytics-handler"),
 	}
+	// Assign only non-nil pointers so the interface fields stay nil-comparable.
+	if service != nil {
+		h.owners = service
+	}
+	if shares != nil {
+		h.shares = shares
+	}
+	if roles != nil {
+		h.roles = roles
+	}
+	return h
+}
+
+// RequireLinkAccess wraps next, a per-link analytics endpoint under
+// /api/analytics/{short_code}/, so that it only serves those allowed to read
+// the link's analytics: its owner and admins, anyone if the link has no
+// owner (anonymous links have no account to sign in with) or the handler is
+// public, and anyone holding a share token for the link, passed as
+// "?share=". A share token is checked first and must be for this very
+// link; one that is unknown, expired, revoked or for another link is
+// refused with 403 rather than falling back to the caller's own access.
+func (h *AnalyticsHandler) RequireLinkAccess(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		shortCode := extractShortCode(r.URL.Path)
+		if shortCode == "" {
+			writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "short_code required")
+			return
+		}
+
+		if token := r.URL.Query().Get("share"); token != "" {
+			if h.shares == nil {
+				writeError(w, r, models.ErrCodeForbidden, http.StatusForbidden, "share token is not valid for this link")
+				return
+			}
+			code, err := h.shares.Resolve(r.Context(), token)
+			if err != nil && !errors.Is(err, sharelink.ErrNotFound) {
+				h.log.Error("Failed to resolve share token: %v", err)
+				writeError(w, r, models.ErrCodeInternal, http.StatusInternalServerError, "Internal server error")
+				return
+			}
+			if err != nil || code != shortCode {
+				writeError(w, r, models.ErrCodeForbidden, http.StatusForbidden, "share token is not valid for this link")
+				return
+			}
+			next(w, r)
+			return
+		}
+
+		if h.public || h.owners == nil {
+			next(w, r)
+			return
+		}
+		owner, found, err := h.owners.LinkOwner(r.Context(), shortCode)
+		if err != nil {
+			h.log.Error("Failed to look up link owner: %v", err)
+			writeError(w, r, models.ErrCodeInternal, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		if !found {
+			writeError(w, r, models.ErrCodeURLNotFound, http.StatusNotFound, analytics.ErrLinkNotFound.Error())
+			return
+		}
+		if owner == "" {
+			next(w, r)
+			return
+		}
+
+		userID := middleware.GetUserID(r.Context())
+		switch {
+		case userID == "":
+			writeError(w, r, models.ErrCodeUnauthorized, http.StatusUnauthorized, "authentication or a share token is required")
+		case userID != owner && !h.isAdmin(r):
+			writeError(w, r, models.ErrCodeForbidden, http.StatusForbidden, analytics.ErrNotLinkOwner.Error())
+		default:
+			next(w, r)
+		}
+	}
+}
+
+// isAdmin reports whether r was made by an admin. The route only passed
+// OptionalAuth, so the role in the token's claims merely rules out
+// everyone else cheaply; an admin's role is then re-checked against the
+// user store, so a demoted admin's still-valid token is refused.
+func (h *AnalyticsHandler) isAdmin(r *http.Request) bool {
+	if middleware.GetRole(r.Context()) != usermodel.RoleAdmin {
+		return false
+	}
+	return h.roles != nil && h.roles.HasRole(r, usermodel.RoleAdmin)
 }
 
 // GetStats returns aggregate click statistics (total clicks, unique visitors,
 // etc.) for the given short code. The short code is extracted from the URL
 // path segment at position 2 (e.g. /api/analytics/{short_code}/stats).
+// Results are briefly cached; "force_refresh=true" bypasses the cache but is
+// only honoured for the authenticated owner of the link (the gateway routes
+// such requests through RequireAuth).
+//
+// With a time range (see parseTimeRange) the clicks and unique visitors
+// within it are returned instead, uncached, as analytics.RangeStats.
 func (h *AnalyticsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 	shortCode := extractShortCode(r.URL.Path)
 	if shortCode == "" {
-		http.Error(w, "short_code required", http.StatusBadRequest)
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "short_code required")
+		return
+	}
+	tr, ok := h.timeRange(w, r)
+	if !ok {
+		return
+	}
+	if !tr.IsZero() {
+		stats, err := h.analyticsService.GetRangeStats(r.Context(), shortCode, tr)
+		if err != nil {
+			h.log.Error("Failed to get range stats: %v", err)
+			writeError(w, r, models.ErrCodeInternal, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		respondAnalyticsJSON(w, stats)
 		return
 	}
 
-	stats, err := h.analyticsService.GetURLStats(r.Context(), shortCode)
+	forceRefresh := false
+	if v := r.URL.Query().Get("force_refresh"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "force_refresh must be a boolean")
+			return
+		}
+		forceRefresh = b
+	}
+
+	var stats *analytics.URLStats
+	var err error
+	if forceRefresh {
+		userID := middleware.GetUserID(r.Context())
+		if userID == "" {
+			writeError(w, r, models.ErrCodeUnauthorized, http.StatusUnauthorized, "force_refresh requires authentication")
+			return
+		}
+		stats, err = h.analyticsService.RefreshURLStats(r.Context(), shortCode, userID)
+		switch {
+		case errors.Is(err, analytics.ErrLinkNotFound):
+			writeError(w, r, models.ErrCodeURLNotFound, http.StatusNotFound, err.Error())
+			return
+		case errors.Is(err, analytics.ErrNotLinkOwner):
+			writeError(w, r, models.ErrCodeForbidden, http.StatusForbidden, err.Error())
+			return
+		}
+	} else {
+		stats, err = h.analyticsService.GetURLStats(r.Context(), shortCode, false)
+	}
 	if err != nil {
 		h.log.Error("Failed to get stats: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeError(w, r, models.ErrCodeInternal, http.StatusInternalServerError, "Internal server error")
 		return
 	}
 
@@ -54,12 +233,20 @@ func (h *AnalyticsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetTimeline returns a day-by-day click count series for the given short code.
-// The optional "days" query parameter controls the lookback window (default 7).
+// The optional "days" query parameter controls the lookback window (default 7),
+// unless a time range (see parseTimeRange) is given; a range without a start
+// still reaches back "days" days from its end.
+// With "fill=true" every day in the window is returned, quiet days with zero
+// clicks, and the window is capped at maxFilledDays.
 // This powers the click-over-time chart in the dashboard.
 func (h *AnalyticsHandler) GetTimeline(w http.ResponseWriter, r *http.Request) {
 	shortCode := extractShortCode(r.URL.Path)
 	if shortCode == "" {
-		http.Error(w, "short_code required", http.StatusBadRequest)
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "short_code required")
+		return
+	}
+	tr, ok := h.timeRange(w, r)
+	if !ok {
 		return
 	}
 
@@ -69,31 +256,55 @@ func (h *AnalyticsHandler) GetTimeline(w http.ResponseWriter, r *http.Request) {
 			days = d
 		}
 	}
+	fill, _ := strconv.ParseBool(r.URL.Query().Get("fill"))
+	if fill && days > maxFilledDays {
+		days = maxFilledDays
+	}
+	end := h.now()
+	if !tr.To.IsZero() {
+		end = tr.To.Add(-time.Nanosecond) // the last instant in the range
+	}
+	if tr.From.IsZero() {
+		tr.From = analytics.LastDays(days, end).From
+	}
+	if fill && tr.From.Before(end.AddDate(0, 0, -maxFilledDays)) {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, fmt.Sprintf("fill=true covers at most %d days", maxFilledDays))
+		return
+	}
 
-	timeline, err := h.analyticsService.GetClickTimeline(r.Context(), shortCode, days)
+	timeline, err := h.analyticsService.GetClickTimeline(r.Context(), shortCode, tr, fill)
 	if err != nil {
 		h.log.Error("Failed to get timeline: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeError(w, r, models.ErrCodeInternal, http.StatusInternalServerError, "Internal server error")
 		return
 	}
 
 	respondAnalyticsJSON(w, timeline)
 }
 
+// maxFilledDays bounds a gap-filled timeline, which has a point for every
+// day however few have clicks.
+const maxFilledDays = 366
+
 // GetGeoStats returns click counts grouped by country/region for the given
 // short code. Geographic data is derived from IP-based GeoIP lookups
-// performed during click event ingestion.
+// performed during click event ingestion. A time range (see parseTimeRange)
+// limits the clicks counted.
 func (h *AnalyticsHandler) GetGeoStats(w http.ResponseWriter, r *http.Request) {
 	shortCode := extractShortCode(r.URL.Path)
 	if shortCode == "" {
-		http.Error(w, "short_code required", http.StatusBadRequest)
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "short_code required")
+		return
+	}
+	tr, ok := h.timeRange(w, r)
+	if !ok {
 		return
 	}
 
-	geoStats, err := h.analyticsService.GetGeoStats(r.Context(), shortCode)
+	geoStats, err := h.analyticsService.GetGeoStats(r.Context(), shortCode, tr)
 	if err != nil {
 		h.log.Error("Failed to get geo stats: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeError(w, r, models.ErrCodeInternal, http.StatusInternalServerError, "Internal server error")
 		return
 	}
 
@@ -102,18 +313,23 @@ func (h *AnalyticsHandler) GetGeoStats(w http.ResponseWriter, r *http.Request) {
 
 // GetDeviceStats returns click counts grouped by browser, OS, and device type
 // for the given short code. User-Agent parsing is done at ingestion time by
-// the click event consumer.
+// the click event consumer. A time range (see parseTimeRange) limits the
+// clicks counted.
 func (h *AnalyticsHandler) GetDeviceStats(w http.ResponseWriter, r *http.Request) {
 	shortCode := extractShortCode(r.URL.Path)
 	if shortCode == "" {
-		http.Error(w, "short_code required", http.StatusBadRequest)
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "short_code required")
+		return
+	}
+	tr, ok := h.timeRange(w, r)
+	if !ok {
 		return
 	}
 
-	deviceStats, err := h.analyticsService.GetDeviceStats(r.Context(), shortCode)
+	deviceStats, err := h.analyticsService.GetDeviceStats(r.Context(), shortCode, tr)
 	if err != nil {
 		h.log.Error("Failed to get device stats: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeError(w, r, models.ErrCodeInternal, http.StatusInternalServerError, "Internal server error")
 		return
 	}
 
@@ -122,11 +338,12 @@ func (h *AnalyticsHandler) GetDeviceStats(w http.ResponseWriter, r *http.Request
 
 // GetReferrers returns the top referring domains for the given short code,
 // ranked by click count. The optional "limit" query parameter controls how
-// many referrers to return (default 10).
+// many referrers to return (default 10), and a time range (see
+// parseTimeRange) limits the clicks counted.
 func (h *AnalyticsHandler) GetReferrers(w http.ResponseWriter, r *http.Request) {
 	shortCode := extractShortCode(r.URL.Path)
 	if shortCode == "" {
-		http.Error(w, "short_code required", http.StatusBadRequest)
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "short_code required")
 		return
 	}
 
@@ -137,16 +354,165 @@ func (h *AnalyticsHandler) GetReferrers(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	referrers, err := h.analyticsService.GetTopReferrers(r.Context(), shortCode, limit)
+	tr, ok := h.timeRange(w, r)
+	if !ok {
+		return
+	}
+
+	referrers, err := h.analyticsService.GetTopReferrers(r.Context(), shortCode, limit, tr)
 	if err != nil {
 		h.log.Error("Failed to get referrers: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeError(w, r, models.ErrCodeInternal, http.StatusInternalServerError, "Internal server error")
 		return
 	}
 
 	respondAnalyticsJSON(w, referrers)
 }
 
+// GetHeatmap returns where the given short code was clicked from as a GeoJSON
+// FeatureCollection of points, each weighted by its "clicks" property, for
+// rendering as a map heatmap. Locations are clustered by rounding to the
+// configured precision, so nearby clicks become one point and no visitor's
+// exact location is returned. The optional "days" query parameter controls
+// the lookback window (default 30, at most maxFilledDays) and "limit" the
+// number of points (default 500, at most 5000), busiest first.
+func (h *AnalyticsHandler) GetHeatmap(w http.ResponseWriter, r *http.Request) {
+	shortCode := extractShortCode(r.URL.Path)
+	if shortCode == "" {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "short_code required")
+		return
+	}
+
+	days := 30
+	if daysParam := r.URL.Query().Get("days"); daysParam != "" {
+		if d, err := strconv.Atoi(daysParam); err == nil && d > 0 {
+			days = min(d, maxFilledDays)
+		}
+	}
+	limit := 500
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		if l, err := strconv.Atoi(limitParam); err == nil && l > 0 && l <= 5000 {
+			limit = l
+		}
+	}
+
+	endDate := h.now().UTC()
+	points, err := h.clickhouse.GetGeoPoints(r.Context(), shortCode, endDate.AddDate(0, 0, -days), endDate, h.heatmapPrecision, limit)
+	if err != nil {
+		h.log.Error("Failed to get geo points: %v", err)
+		writeError(w, r, models.ErrCodeInternal, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	_ = json.NewEncoder(w).Encode(heatmapGeoJSON(points))
+}
+
+// geoJSONFeature is a GeoJSON Point feature; Coordinates are
+// [longitude, latitude], in that order.
+type geoJSONFeature struct {
+	Type     string `json:"type"`
+	Geometry struct {
+		Type        string     `json:"type"`
+		Coordinates [2]float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties struct {
+		Clicks uint64 `json:"clicks"`
+	} `json:"properties"`
+}
+
+// geoJSONFeatureCollection is the body of GetHeatmap.
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+// heatmapGeoJSON converts heatmap points into a FeatureCollection, an empty
+// one if there are none.
+func heatmapGeoJSON(points []clickhouse.GeoPoint) geoJSONFeatureCollection {
+	fc := geoJSONFeatureCollection{Type: "FeatureCollection", Features: make([]geoJSONFeature, len(points))}
+	for i, p := range points {
+		f := &fc.Features[i]
+		f.Type = "Feature"
+		f.Geometry.Type = "Point"
+		f.Geometry.Coordinates = [2]float64{p.Longitude, p.Latitude}
+		f.Properties.Clicks = p.ClickCount
+	}
+	return fc
+}
+
+// timeRange parses the request's time range, writing a 400 and reporting
+// false if it is invalid.
+func (h *AnalyticsHandler) timeRange(w http.ResponseWriter, r *http.Request) (analytics.TimeRange, bool) {
+	tr, err := parseTimeRange(r.URL.Query(), h.now().UTC())
+	if err != nil {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, err.Error())
+		return analytics.TimeRange{}, false
+	}
+	return tr, true
+}
+
+// now returns the current time from h's clock.
+func (h *AnalyticsHandler) now() time.Time {
+	if h.clock == nil {
+		return time.Now()
+	}
+	return h.clock.Now()
+}
+
+// parseTimeRange reads the clicks an analytics query covers from q: either
+// "range", one of analytics.NamedRangeNames such as "24h" for the last day
+// before now, or "from" and "to", each optional, as RFC 3339 times or
+// YYYY-MM-DD dates. "to" is exclusive for a time and inclusive for a date,
+// so from=2025-01-01&to=2025-01-31 is all of January (UTC). Neither gives
+// the zero TimeRange, every click.
+func parseTimeRange(q url.Values, now time.Time) (analytics.TimeRange, error) {
+	name, from, to := q.Get("range"), q.Get("from"), q.Get("to")
+	if name != "" {
+		if from != "" || to != "" {
+			return analytics.TimeRange{}, errors.New("range cannot be combined with from or to")
+		}
+		tr, err := analytics.NamedRange(name, now)
+		if err != nil {
+			return analytics.TimeRange{}, fmt.Errorf("range must be one of %s", strings.Join(analytics.NamedRangeNames, ", "))
+		}
+		return tr, nil
+	}
+
+	var tr analytics.TimeRange
+	var err error
+	if from != "" {
+		if tr.From, err = parseRangeBound(from, false); err != nil {
+			return analytics.TimeRange{}, fmt.Errorf("from %v", err)
+		}
+	}
+	if to != "" {
+		if tr.To, err = parseRangeBound(to, true); err != nil {
+			return analytics.TimeRange{}, fmt.Errorf("to %v", err)
+		}
+	}
+	if !tr.From.IsZero() && !tr.To.IsZero() && !tr.From.Before(tr.To) {
+		return analytics.TimeRange{}, errors.New("from must be before to")
+	}
+	return tr, nil
+}
+
+// parseRangeBound parses a "from" or "to" value. A date stands for its
+// start, or with end set for the start of the next day.
+func parseRangeBound(v string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return time.Time{}, errors.New("must be an RFC 3339 time or a YYYY-MM-DD date")
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
 // extractShortCode pulls the short code from a URL path like
 // "/api/analytics/{short_code}/..." by splitting on "/" and returning
 // the segment at index 2. Returns "" if the path is too short.
@@ -197,7 +563,7 @@ func (h *AnalyticsHandler) GetClickEvents(w http.ResponseWriter, r *http.Request
 
 	if err != nil {
 		h.log.Error("Failed to fetch click events: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeError(w, r, models.ErrCodeInternal, http.StatusInternalServerError, "Internal server error")
 		return
 	}
 
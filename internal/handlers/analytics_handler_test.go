@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/analytics"
+	"github.com/Varun5711/shorternit/internal/clock/clocktest"
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/Varun5711/shorternit/internal/sharelink"
+	userpb "github.com/Varun5711/shorternit/proto/user"
+	"google.golang.org/grpc"
+)
+
+func TestParseTimeRange(t *testing.T) {
+	now := time.Date(2025, time.March, 10, 15, 30, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		query    string
+		from, to time.Time
+	}{
+		{"", time.Time{}, time.Time{}},
+		{"range=1h", now.Add(-time.Hour), now},
+		{"range=24h", now.Add(-24 * time.Hour), now},
+		{"range=7d", now.AddDate(0, 0, -7), now},
+		{"range=30d", now.AddDate(0, 0, -30), now},
+		{"from=2025-01-01&to=2025-01-31", time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"from=2025-03-01T12:00:00%2B02:00", time.Date(2025, time.March, 1, 10, 0, 0, 0, time.UTC), time.Time{}},
+		{"to=2025-03-01T12:00:00Z", time.Time{}, time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)},
+	} {
+		q, _ := url.ParseQuery(tc.query)
+		tr, err := parseTimeRange(q, now)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.query, err)
+		}
+		if !tr.From.Equal(tc.from) || !tr.To.Equal(tc.to) {
+			t.Errorf("%q: expected %v to %v, got %v to %v", tc.query, tc.from, tc.to, tr.From, tr.To)
+		}
+	}
+}
+
+func TestParseTimeRange_Invalid(t *testing.T) {
+	for _, query := range []string{
+		"range=2h",
+		"range=all",
+		"range=24h&from=2025-01-01",
+		"from=yesterday",
+		"to=2025-13-01",
+		"from=2025-02-01&to=2025-01-31",
+		"from=2025-01-01T00:00:00Z&to=2025-01-01T00:00:00Z",
+	} {
+		q, _ := url.ParseQuery(query)
+		if _, err := parseTimeRange(q, time.Now()); err == nil {
+			t.Errorf("%q: expected an error", query)
+		}
+	}
+}
+
+// TestAnalyticsHandler_RejectsBadRange checks that every ranged endpoint
+// validates the range before querying anything.
+func TestAnalyticsHandler_RejectsBadRange(t *testing.T) {
+	h := NewAnalyticsHandler(nil, nil, 1, nil, nil, false)
+	for _, tc := range []struct {
+		path    string
+		handler http.HandlerFunc
+	}{
+		{"/api/analytics/abc/stats", h.GetStats},
+		{"/api/analytics/abc/timeline", h.GetTimeline},
+		{"/api/analytics/abc/geo", h.GetGeoStats},
+		{"/api/analytics/abc/devices", h.GetDeviceStats},
+		{"/api/analytics/abc/referrers", h.GetReferrers},
+	} {
+		rec := httptest.NewRecorder()
+		tc.handler(rec, httptest.NewRequest(http.MethodGet, tc.path+"?range=2h", nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", tc.path, rec.Code)
+		}
+		if body := decodeError(t, rec); body.Code != models.ErrCodeInvalidRequest || body.Message != "range must be one of 1h, 24h, 7d, 30d" {
+			t.Errorf("%s: unexpected error %+v", tc.path, body)
+		}
+	}
+}
+
+// TestAnalyticsHandler_WindowsEndAtClock verifies that the named ranges and
+// the gap-filled timeline's lookback limit are measured back from the
+// handler's clock.
+func TestAnalyticsHandler_WindowsEndAtClock(t *testing.T) {
+	now := time.Date(2040, time.June, 30, 12, 0, 0, 0, time.UTC)
+	clk := clocktest.NewFake(now)
+	h := NewAnalyticsHandler(nil, nil, 1, nil, nil, false)
+	h.clock = clk
+
+	rangeAt := func(query string) analytics.TimeRange {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		tr, ok := h.timeRange(rec, httptest.NewRequest(http.MethodGet, "/api/analytics/abc/stats?"+query, nil))
+		if !ok {
+			t.Fatalf("%q: rejected with %d", query, rec.Code)
+		}
+		return tr
+	}
+	if tr := rangeAt("range=24h"); !tr.From.Equal(now.Add(-24*time.Hour)) || !tr.To.Equal(now) {
+		t.Errorf("expected the last 24h to end now, got %v to %v", tr.From, tr.To)
+	}
+	clk.Advance(time.Hour)
+	if tr := rangeAt("range=1h"); !tr.From.Equal(now) || !tr.To.Equal(now.Add(time.Hour)) {
+		t.Errorf("expected the last hour to follow the clock, got %v to %v", tr.From, tr.To)
+	}
+
+	// One day further back than a filled timeline may reach from now.
+	from := clk.Now().AddDate(0, 0, -maxFilledDays-1).Format(time.DateOnly)
+	rec := httptest.NewRecorder()
+	h.GetTimeline(rec, httptest.NewRequest(http.MethodGet, "/api/analytics/abc/timeline?fill=true&from="+from, nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected a filled timeline from %s to be refused, got %d", from, rec.Code)
+	}
+}
+
+// ownerMap is a linkOwners keyed by short code; "" marks an anonymous link.
+type ownerMap map[string]string
+
+func (m ownerMap) LinkOwner(ctx context.Context, shortCode string) (string, bool, error) {
+	owner, ok := m[shortCode]
+	return owner, ok, nil
+}
+
+// shareMap is a shareResolver keyed by token.
+type shareMap map[string]string
+
+func (m shareMap) Resolve(ctx context.Context, token string) (string, error) {
+	code, ok := m[token]
+	if !ok {
+		return "", sharelink.ErrNotFound
+	}
+	return code, nil
+}
+
+// TestRequireLinkAccess_ShareTokenScopedToItsLink verifies that a share
+// token opens its own link's analytics without signing in, but not another
+// link's, even one owned by the same user.
+func TestRequireLinkAccess_ShareTokenScopedToItsLink(t *testing.T) {
+	h := &AnalyticsHandler{
+		owners: ownerMap{"abc": "alice", "xyz": "alice"},
+		shares: shareMap{"tok-abc": "abc"},
+	}
+	served := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	for _, tc := range []struct {
+		path string
+		want int
+	}{
+		{"/api/analytics/abc/stats?share=tok-abc", http.StatusOK},
+		{"/api/analytics/abc/heatmap?share=tok-abc", http.StatusOK},
+		{"/api/analytics/xyz/stats?share=tok-abc", http.StatusForbidden},
+		{"/api/analytics/abc/stats?share=revoked", http.StatusForbidden},
+		{"/api/analytics/abc/stats", http.StatusUnauthorized},
+	} {
+		rec := httptest.NewRecorder()
+		h.RequireLinkAccess(served)(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if rec.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.path, tc.want, rec.Code)
+		}
+	}
+}
+
+// roleMap is a roleChecker holding each user's current role, keyed by the
+// user ID in the request's context.
+type roleMap map[string]string
+
+func (m roleMap) HasRole(r *http.Request, role string) bool {
+	return m[middleware.GetUserID(r.Context())] == role
+}
+
+// TestRequireLinkAccess_Owner verifies who can read a link's analytics
+// without a share token.
+func TestRequireLinkAccess_Owner(t *testing.T) {
+	h := &AnalyticsHandler{
+		owners: ownerMap{"abc": "alice", "anon": ""},
+		roles:  roleMap{"carol": "admin"},
+	}
+	served := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	for _, tc := range []struct {
+		path, userID, role string
+		want               int
+	}{
+		{"/api/analytics/abc/stats", "alice", "", http.StatusOK},
+		{"/api/analytics/abc/stats", "bob", "", http.StatusForbidden},
+		{"/api/analytics/abc/stats", "carol", "admin", http.StatusOK},
+		{"/api/analytics/anon/stats", "", "", http.StatusOK},
+		{"/api/analytics/missing/stats", "alice", "", http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		ctx := context.WithValue(req.Context(), middleware.UserIDKey, tc.userID)
+		ctx = context.WithValue(ctx, middleware.RoleKey, tc.role)
+		rec := httptest.NewRecorder()
+		h.RequireLinkAccess(served)(rec, req.WithContext(ctx))
+		if rec.Code != tc.want {
+			t.Errorf("%s as %q: expected %d, got %d", tc.path, tc.userID, tc.want, rec.Code)
+		}
+	}
+
+	h.public = true
+	rec := httptest.NewRecorder()
+	h.RequireLinkAccess(served)(rec, httptest.NewRequest(http.MethodGet, "/api/analytics/abc/stats", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected public analytics to be served to anyone, got %d", rec.Code)
+	}
+}
+
+// demotedClient is a UserServiceClient for a single token issued to an
+// admin who has since been demoted: its claims still name the admin role,
+// but a re-check against the database finds a plain user.
+type demotedClient struct {
+	userpb.UserServiceClient
+	rechecks int
+}
+
+func (c *demotedClient) ValidateToken(ctx context.Context, in *userpb.ValidateTokenRequest, opts ...grpc.CallOption) (*userpb.ValidateTokenResponse, error) {
+	if !in.Recheck {
+		return &userpb.ValidateTokenResponse{Valid: true, UserId: "dave", Role: "admin"}, nil
+	}
+	c.rechecks++
+	return &userpb.ValidateTokenResponse{Valid: true, UserId: "dave", Role: "user"}, nil
+}
+
+// TestRequireLinkAccess_DemotedAdmin verifies that the admin exception is
+// re-checked against the user store rather than granted on the role in a
+// token that only passed OptionalAuth.
+func TestRequireLinkAccess_DemotedAdmin(t *testing.T) {
+	client := &demotedClient{}
+	auth := middleware.NewAuthMiddleware(client)
+	h := NewAnalyticsHandler(nil, nil, 1, nil, auth, false)
+	h.owners = ownerMap{"abc": "alice"}
+	served := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	req := httptest.NewRequest(http.MethodGet, "/api/analytics/abc/stats", nil)
+	req.Header.Set("Authorization", "Bearer stale-admin-token")
+	rec := httptest.NewRecorder()
+	auth.OptionalAuth(h.RequireLinkAccess(served))(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected a demoted admin to be refused, got %d", rec.Code)
+	}
+	if client.rechecks != 1 {
+		t.Errorf("expected the role to be re-checked once, got %d re-checks", client.rechecks)
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+// CreateAnalyticsShare handles POST /api/urls/{code}/analytics/shares,
+// minting a share token for one of the authenticated user's links. The
+// optional body sets how long it works (see
+// models.CreateAnalyticsShareRequest).
+func (h *HTTPHandler) CreateAnalyticsShare(w http.ResponseWriter, r *http.Request) {
+	shortCode := r.PathValue("code")
+	if shortCode == "" {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "short code is required")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	var req models.CreateAnalyticsShareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, r, models.ErrCodeInvalidJSON, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if req.TTLSeconds < 0 {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "ttl_seconds must not be negative")
+		return
+	}
+
+	resp, err := h.grpcClient.GenerateAnalyticsToken(r.Context(), &pb.GenerateAnalyticsTokenRequest{
+		ShortCode:  shortCode,
+		UserId:     middleware.GetUserID(r.Context()),
+		TtlSeconds: req.TTLSeconds,
+	})
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to create share token")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, analyticsShareFromProto(resp.Token))
+}
+
+// ListAnalyticsShares handles GET /api/urls/{code}/analytics/shares,
+// listing the share tokens of one of the authenticated user's links that
+// still work.
+func (h *HTTPHandler) ListAnalyticsShares(w http.ResponseWriter, r *http.Request) {
+	shortCode := r.PathValue("code")
+	if shortCode == "" {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "short code is required")
+		return
+	}
+
+	resp, err := h.grpcClient.ListAnalyticsTokens(r.Context(), &pb.ListAnalyticsTokensRequest{
+		ShortCode: shortCode,
+		UserId:    middleware.GetUserID(r.Context()),
+	})
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to list share tokens")
+		return
+	}
+
+	shares := make([]*models.AnalyticsShare, len(resp.Tokens))
+	for i, token := range resp.Tokens {
+		shares[i] = analyticsShareFromProto(token)
+	}
+	respondJSON(w, http.StatusOK, models.ListAnalyticsSharesResponse{Shares: shares})
+}
+
+// RevokeAnalyticsShare handles DELETE
+// /api/urls/{code}/analytics/shares/{token}, revoking one share token of one
+// of the authenticated user's links, and DELETE
+// /api/urls/{code}/analytics/shares, revoking all of them.
+func (h *HTTPHandler) RevokeAnalyticsShare(w http.ResponseWriter, r *http.Request) {
+	shortCode := r.PathValue("code")
+	if shortCode == "" {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "short code is required")
+		return
+	}
+	token := r.PathValue("token")
+
+	resp, err := h.grpcClient.RevokeAnalyticsToken(r.Context(), &pb.RevokeAnalyticsTokenRequest{
+		ShortCode: shortCode,
+		UserId:    middleware.GetUserID(r.Context()),
+		Token:     token,
+	})
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to revoke share token")
+		return
+	}
+
+	if token != "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	respondJSON(w, http.StatusOK, models.RevokeAnalyticsSharesResponse{Revoked: resp.Revoked})
+}
+
+// analyticsShareFromProto converts a protobuf AnalyticsToken into the JSON
+// response model.
+func analyticsShareFromProto(t *pb.AnalyticsToken) *models.AnalyticsShare {
+	var expiresAt *time.Time
+	if t.ExpiresAt > 0 {
+		e := time.Unix(t.ExpiresAt, 0)
+		expiresAt = &e
+	}
+	return &models.AnalyticsShare{
+		Token:     t.Token,
+		ShortCode: t.ShortCode,
+		CreatedAt: time.Unix(t.CreatedAt, 0),
+		ExpiresAt: expiresAt,
+	}
+}
@@ -4,10 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"net/netip"
+	"strings"
 	"time"
 
+	"github.com/Varun5711/shorternit/internal/auth"
 	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/Varun5711/shorternit/internal/validation"
 	pb "github.com/Varun5711/shorternit/proto/user"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 // AuthHandler exposes user authentication endpoints (register, login, profile).
@@ -15,36 +24,54 @@ import (
 // serialization happen here, while credential hashing, token generation, and
 // user persistence are handled by the backend User gRPC service.
 type AuthHandler struct {
-	userClient pb.UserServiceClient
-	log        *logger.Logger
+	userClient     pb.UserServiceClient
+	trustedProxies []netip.Prefix // Proxies whose forwarding headers identify the client (see middleware.ClientIP).
+	log            *logger.Logger
 }
 
 // NewAuthHandler creates an AuthHandler backed by the given gRPC user service
 // client. The caller is responsible for establishing and managing the gRPC
-// connection lifecycle.
-func NewAuthHandler(userClient pb.UserServiceClient) *AuthHandler {
+// connection lifecycle. Login forwards the client's address, resolved with
+// the given trusted proxies, so the user service can lock out an IP that
+// keeps failing; it and Register also forward the address and User-Agent
+// to label the session they start.
+func NewAuthHandler(userClient pb.UserServiceClient, trustedProxies []netip.Prefix) *AuthHandler {
 	return &AuthHandler{
-		userClient: userClient,
-		log:        logger.New("auth-handler"),
+		userClient:     userClient,
+		trustedProxies: trustedProxies,
+		log:            logger.New("auth-handler"),
 	}
 }
 
-// RegisterRequest is the JSON body expected by the Register endpoint.
+// RegisterRequest is the body expected by the Register endpoint, as JSON or
+// form-encoded (see decodeBody).
 type RegisterRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 	Name     string `json:"name"`
 }
 
-// LoginRequest is the JSON body expected by the Login endpoint.
+// LoginRequest is the body expected by the Login endpoint, as JSON or
+// form-encoded.
 type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
+// UpdateProfileRequest is the JSON body expected by the UpdateProfile
+// endpoint. An omitted field keeps its current value. DefaultURLTTL is how
+// long the caller's links live when created without an expiry: a duration
+// such as "168h", "never", or "default" for the global DEFAULT_URL_TTL.
+type UpdateProfileRequest struct {
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	DefaultURLTTL string `json:"default_url_ttl"`
+}
+
 // AuthResponse is the JSON body returned after a successful register or login.
 // It includes a JWT token that clients must send in subsequent authenticated
-// requests via the Authorization header.
+// requests via the Authorization header. Browsers can instead rely on the
+// same token being set in the middleware.TokenCookie cookie.
 type AuthResponse struct {
 	UserID    string `json:"user_id"`
 	Email     string `json:"email"`
@@ -53,30 +80,51 @@ type AuthResponse struct {
 	ExpiresAt int64  `json:"expires_at,omitempty"`
 }
 
-// ProfileResponse is the JSON body returned by the GetProfile endpoint.
+// ProfileResponse is the JSON body returned by the GetProfile endpoint and
+// by the admin endpoints that disable and re-enable an account.
 type ProfileResponse struct {
-	UserID    string `json:"user_id"`
-	Email     string `json:"email"`
-	Name      string `json:"name"`
-	CreatedAt int64  `json:"created_at"`
-	UpdatedAt int64  `json:"updated_at"`
+	UserID        string `json:"user_id"`
+	Email         string `json:"email"`
+	Name          string `json:"name"`
+	Role          string `json:"role,omitempty"`
+	DisabledAt    int64  `json:"disabled_at,omitempty"`
+	CreatedAt     int64  `json:"created_at"`
+	UpdatedAt     int64  `json:"updated_at"`
+	DefaultURLTTL string `json:"default_url_ttl,omitempty"` // empty when the global default applies
+}
+
+// profileFromPB maps a user message to its JSON body.
+func profileFromPB(u *pb.User) ProfileResponse {
+	return ProfileResponse{
+		UserID:        u.Id,
+		Email:         u.Email,
+		Name:          u.Name,
+		Role:          u.Role,
+		DisabledAt:    u.DisabledAt,
+		CreatedAt:     u.CreatedAt,
+		UpdatedAt:     u.UpdatedAt,
+		DefaultURLTTL: u.DefaultUrlTtl,
+	}
 }
 
 // Register handles POST /auth/register. It creates a new user account via the
 // gRPC user service and returns a JWT token on success, so the client can
-// immediately make authenticated requests without a separate login step.
+// immediately make authenticated requests without a separate login step. The
+// token is also set as a session cookie for browsers.
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, r, models.ErrCodeMethodNotAllowed, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	// Cap the request body to prevent abuse via oversized payloads.
-	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
 	var req RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeBody(w, r, &req); err != nil {
 		h.log.Error("Failed to decode request: %v", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, r, models.ErrCodeInvalidJSON, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if res := validateRegister(req); !res.Valid() {
+		writeValidationError(w, r, res)
 		return
 	}
 
@@ -84,6 +132,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	// does not block the HTTP connection indefinitely.
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
+	ctx = h.withClient(ctx, r)
 
 	resp, err := h.userClient.Register(ctx, &pb.RegisterRequest{
 		Email:    req.Email,
@@ -92,7 +141,9 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	})
 	if err != nil {
 		h.log.Error("Failed to register user: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		// A taken email is AlreadyExists (409), a weak password
+		// InvalidArgument (400).
+		respondGRPCError(w, r, err, "failed to register user")
 		return
 	}
 
@@ -103,30 +154,36 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		Token:  resp.Token,
 	}
 
+	middleware.SetTokenCookie(w, resp.Token, 0)
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(authResp)
 }
 
 // Login handles POST /auth/login. It verifies credentials via the gRPC user
-// service and returns a JWT token with an expiration timestamp. The error
-// message is deliberately vague ("Invalid email or password") to avoid leaking
-// whether a given email address is registered.
+// service and returns a JWT token with an expiration timestamp, also set as
+// a cookie that expires with the token. The error message is deliberately
+// vague ("Invalid email or password") to avoid leaking whether a given email
+// address is registered.
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, r, models.ErrCodeMethodNotAllowed, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
 	var req LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeBody(w, r, &req); err != nil {
 		h.log.Error("Failed to decode request: %v", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, r, models.ErrCodeInvalidJSON, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if res := validateLogin(req); !res.Valid() {
+		writeValidationError(w, r, res)
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
+	ctx = h.withClient(ctx, r)
 
 	resp, err := h.userClient.Login(ctx, &pb.LoginRequest{
 		Email:    req.Email,
@@ -134,7 +191,19 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	})
 	if err != nil {
 		h.log.Error("Failed to login: %v", err)
-		http.Error(w, "Invalid email or password", http.StatusUnauthorized)
+		// A lockout is reported as such so clients back off, but carries
+		// nothing about whether the account exists.
+		if st, ok := status.FromError(err); ok && st.Code() == codes.ResourceExhausted {
+			setRetryAfter(w, st)
+			writeError(w, r, models.ErrCodeLoginLocked, http.StatusTooManyRequests, "Too many failed login attempts, try again later")
+			return
+		}
+		// Only reported once the password has matched.
+		if status.Code(err) == codes.PermissionDenied {
+			writeError(w, r, models.ErrCodeAccountDisabled, http.StatusForbidden, "Account disabled")
+			return
+		}
+		writeError(w, r, models.ErrCodeInvalidCredentials, http.StatusUnauthorized, "Invalid email or password")
 		return
 	}
 
@@ -146,33 +215,28 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		ExpiresAt: resp.ExpiresAt,
 	}
 
+	middleware.SetTokenCookie(w, resp.Token, resp.ExpiresAt)
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(authResp)
 }
 
-// GetProfile handles GET /auth/profile. It extracts the Bearer token from the
-// Authorization header and asks the gRPC user service to resolve it into a
-// user profile. Unlike the other auth endpoints, this one performs its own
-// token extraction instead of relying on the auth middleware, so it can be
-// mounted on routes that do not use RequireAuth.
+// GetProfile handles GET /auth/profile. It extracts the token from the
+// Authorization header or the token cookie and asks the gRPC user service to
+// resolve it into a user profile. Unlike the other auth endpoints, this one
+// performs its own token extraction instead of relying on the auth
+// middleware, so it can be mounted on routes that do not use RequireAuth.
 func (h *AuthHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, r, models.ErrCodeMethodNotAllowed, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	token := r.Header.Get("Authorization")
+	token := bearerToken(r)
 	if token == "" {
-		http.Error(w, "Authorization header required", http.StatusUnauthorized)
+		writeError(w, r, models.ErrCodeUnauthorized, http.StatusUnauthorized, "Authentication required")
 		return
 	}
 
-	// Strip the "Bearer " prefix if present, accepting both prefixed and
-	// bare tokens for flexibility with different client implementations.
-	if len(token) > 7 && token[:7] == "Bearer " {
-		token = token[7:]
-	}
-
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
@@ -181,18 +245,153 @@ func (h *AuthHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	})
 	if err != nil {
 		h.log.Error("Failed to get profile: %v", err)
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeError(w, r, models.ErrCodeUnauthorized, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	profile := ProfileResponse{
-		UserID:    resp.User.Id,
-		Email:     resp.User.Email,
-		Name:      resp.User.Name,
-		CreatedAt: resp.User.CreatedAt,
-		UpdatedAt: resp.User.UpdatedAt,
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(profileFromPB(resp.User))
+}
+
+// UpdateProfile handles PUT /auth/profile, changing the caller's name, email
+// and/or default link lifetime and returning the updated profile. The email
+// and lifetime are validated here so a malformed one is a 400 without a
+// round-trip; one already used by another account is a 409 from the user
+// service. Tokens issued before the change keep the old email in their
+// claims until they expire.
+func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeError(w, r, models.ErrCodeMethodNotAllowed, http.StatusMethodNotAllowed, "Method not allowed")
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(profile)
+	token := bearerToken(r)
+	if token == "" {
+		writeError(w, r, models.ErrCodeUnauthorized, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	var req UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, models.ErrCodeInvalidJSON, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	req.Email = strings.TrimSpace(req.Email)
+	req.DefaultURLTTL = strings.TrimSpace(req.DefaultURLTTL)
+	if req.Name == "" && req.Email == "" && req.DefaultURLTTL == "" {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "name, email or default_url_ttl is required")
+		return
+	}
+	if req.DefaultURLTTL != "" {
+		if _, err := validation.ParseDefaultURLTTL(req.DefaultURLTTL); err != nil {
+			writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if req.Email != "" {
+		if err := validation.ValidateEmail(req.Email); err != nil {
+			writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	resp, err := h.userClient.UpdateProfile(ctx, &pb.UpdateProfileRequest{
+		Token:         token,
+		Name:          req.Name,
+		Email:         req.Email,
+		DefaultUrlTtl: req.DefaultURLTTL,
+	})
+	if err != nil {
+		h.log.Error("Failed to update profile: %v", err)
+		respondGRPCError(w, r, err, "failed to update profile")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, profileFromPB(resp.User))
+}
+
+// SessionResponse is one entry of the ListSessions response.
+type SessionResponse struct {
+	ID        string `json:"id"`
+	Device    string `json:"device,omitempty"`
+	IPAddress string `json:"ip_address,omitempty"`
+	IssuedAt  int64  `json:"issued_at"`
+	ExpiresAt int64  `json:"expires_at"`
+	Current   bool   `json:"current"` // the session of the request's token
+}
+
+// SessionsResponse is the JSON body returned by the ListSessions endpoint.
+type SessionsResponse struct {
+	Sessions []SessionResponse `json:"sessions"`
+}
+
+// RevokeSessionsResponse is the JSON body returned by the RevokeAllSessions
+// endpoint.
+type RevokeSessionsResponse struct {
+	Revoked int32 `json:"revoked"`
+}
+
+// ListSessions handles GET /auth/sessions, listing the devices the caller
+// is signed in on, newest first.
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	resp, err := h.userClient.ListSessions(ctx, &pb.ListSessionsRequest{Token: bearerToken(r)})
+	if err != nil {
+		h.log.Error("Failed to list sessions: %v", err)
+		respondGRPCError(w, r, err, "failed to list sessions")
+		return
+	}
+
+	sessions := make([]SessionResponse, 0, len(resp.Sessions))
+	for _, s := range resp.Sessions {
+		sessions = append(sessions, SessionResponse{
+			ID:        s.Id,
+			Device:    s.Device,
+			IPAddress: s.IpAddress,
+			IssuedAt:  s.IssuedAt,
+			ExpiresAt: s.ExpiresAt,
+			Current:   s.Current,
+		})
+	}
+	respondJSON(w, http.StatusOK, SessionsResponse{Sessions: sessions})
+}
+
+// RevokeAllSessions handles POST /auth/sessions/revoke-all, signing the
+// caller out everywhere. The caller's own token is revoked too, so its
+// cookie is cleared; signing in again starts a new session.
+func (h *AuthHandler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	resp, err := h.userClient.RevokeAllSessions(ctx, &pb.RevokeAllSessionsRequest{Token: bearerToken(r)})
+	if err != nil {
+		h.log.Error("Failed to revoke sessions: %v", err)
+		respondGRPCError(w, r, err, "failed to revoke sessions")
+		return
+	}
+
+	middleware.ClearTokenCookie(w)
+	respondJSON(w, http.StatusOK, RevokeSessionsResponse{Revoked: resp.Revoked})
+}
+
+// withClient forwards the client's address and User-Agent to the user
+// service in outgoing metadata.
+func (h *AuthHandler) withClient(ctx context.Context, r *http.Request) context.Context {
+	return metadata.AppendToOutgoingContext(ctx,
+		auth.ClientIPMetadataKey, middleware.ClientIP(r, h.trustedProxies),
+		auth.ClientDeviceMetadataKey, r.UserAgent(),
+	)
+}
+
+// bearerToken returns the request's token, from the Authorization header or
+// the token cookie, as the auth middleware reads it.
+func bearerToken(r *http.Request) string {
+	return middleware.Token(r)
 }
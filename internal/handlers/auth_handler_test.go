@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/auth"
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/user"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// profileClient is a UserServiceClient whose UpdateProfile applies the
+// request to a single user, refusing emails listed in taken. The other
+// methods are left to the embedded nil interface.
+type profileClient struct {
+	pb.UserServiceClient
+	user  *pb.User
+	taken map[string]bool
+	calls int
+	token string
+}
+
+func (c *profileClient) UpdateProfile(ctx context.Context, in *pb.UpdateProfileRequest, opts ...grpc.CallOption) (*pb.UpdateProfileResponse, error) {
+	c.calls++
+	c.token = in.Token
+	if c.taken[in.Email] {
+		return nil, status.Error(codes.AlreadyExists, "user with this email already exists")
+	}
+	if in.Name != "" {
+		c.user.Name = in.Name
+	}
+	if in.Email != "" {
+		c.user.Email = in.Email
+	}
+	if in.DefaultUrlTtl != "" {
+		c.user.DefaultUrlTtl = in.DefaultUrlTtl
+	}
+	return &pb.UpdateProfileResponse{User: c.user}, nil
+}
+
+func updateProfile(h *AuthHandler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPut, "/api/auth/profile", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer tok")
+	rec := httptest.NewRecorder()
+	h.UpdateProfile(rec, req)
+	return rec
+}
+
+func TestUpdateProfile(t *testing.T) {
+	client := &profileClient{
+		user:  &pb.User{Id: "u1", Name: "Jane", Email: "jane@example.com"},
+		taken: map[string]bool{"bob@example.com": true},
+	}
+	h := NewAuthHandler(client, nil)
+
+	rec := updateProfile(h, `{"name":" Jane Doe ","email":"jane.doe@example.com"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var profile ProfileResponse
+	if err := json.NewDecoder(rec.Body).Decode(&profile); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if profile.Name != "Jane Doe" || profile.Email != "jane.doe@example.com" {
+		t.Errorf("expected the updated profile, got %+v", profile)
+	}
+	if client.token != "tok" {
+		t.Errorf("expected the caller's token to be forwarded, got %q", client.token)
+	}
+
+	rec = updateProfile(h, `{"default_url_ttl":"168h"}`)
+	profile = ProfileResponse{}
+	if err := json.NewDecoder(rec.Body).Decode(&profile); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if profile.DefaultURLTTL != "168h" {
+		t.Errorf("expected default_url_ttl 168h, got %q", profile.DefaultURLTTL)
+	}
+
+	if rec := updateProfile(h, `{"email":"bob@example.com"}`); rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 for an email in use, got %d", rec.Code)
+	}
+
+	calls := client.calls
+	for _, body := range []string{`{"email":"not-an-email"}`, `{"name":"  "}`, `{"default_url_ttl":"30s"}`, `{"default_url_ttl":"forever"}`, `{}`, `not json`} {
+		if rec := updateProfile(h, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rec.Code)
+		}
+	}
+	if client.calls != calls {
+		t.Error("expected invalid requests not to reach the user service")
+	}
+}
+
+// loginClient refuses every Login with err.
+type loginClient struct {
+	pb.UserServiceClient
+	err error
+}
+
+func (c *loginClient) Login(ctx context.Context, in *pb.LoginRequest, opts ...grpc.CallOption) (*pb.LoginResponse, error) {
+	return nil, c.err
+}
+
+// TestLogin_ErrorCodes pins the status and error code of each way a login
+// can fail.
+func TestLogin_ErrorCodes(t *testing.T) {
+	login := func(err error, method, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		NewAuthHandler(&loginClient{err: err}, nil).Login(rec, httptest.NewRequest(method, "/api/auth/login", strings.NewReader(body)))
+		return rec
+	}
+	creds := `{"email":"jane@example.com","password":"secret"}`
+
+	for _, tc := range []struct {
+		name       string
+		rec        *httptest.ResponseRecorder
+		wantStatus int
+		wantCode   string
+	}{
+		{"wrong method", login(nil, http.MethodGet, ""), http.StatusMethodNotAllowed, models.ErrCodeMethodNotAllowed},
+		{"invalid JSON", login(nil, http.MethodPost, "{"), http.StatusBadRequest, models.ErrCodeInvalidJSON},
+		{"unknown email", login(status.Error(codes.NotFound, "invalid email or password"), http.MethodPost, creds), http.StatusUnauthorized, models.ErrCodeInvalidCredentials},
+		{"wrong password", login(status.Error(codes.Unauthenticated, "invalid email or password"), http.MethodPost, creds), http.StatusUnauthorized, models.ErrCodeInvalidCredentials},
+		{"disabled", login(status.Error(codes.PermissionDenied, "account disabled"), http.MethodPost, creds), http.StatusForbidden, models.ErrCodeAccountDisabled},
+		{"locked out", login(status.Error(codes.ResourceExhausted, "too many failed login attempts"), http.MethodPost, creds), http.StatusTooManyRequests, models.ErrCodeLoginLocked},
+	} {
+		if tc.rec.Code != tc.wantStatus {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.wantStatus, tc.rec.Code)
+		}
+		if body := decodeError(t, tc.rec); body.Code != tc.wantCode {
+			t.Errorf("%s: expected code %s, got %q", tc.name, tc.wantCode, body.Code)
+		}
+	}
+}
+
+// tokenClient logs every user in with a fixed token.
+type tokenClient struct {
+	pb.UserServiceClient
+}
+
+func (c *tokenClient) Login(ctx context.Context, in *pb.LoginRequest, opts ...grpc.CallOption) (*pb.LoginResponse, error) {
+	return &pb.LoginResponse{UserId: "u1", Email: in.Email, Token: "tok", ExpiresAt: 1767225600}, nil
+}
+
+func TestLogin_SetsTokenCookie(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewAuthHandler(&tokenClient{}, nil).Login(rec, httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(`{"email":"jane@example.com","password":"secret"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected one cookie, got %v", cookies)
+	}
+	c := cookies[0]
+	if c.Name != middleware.TokenCookie || c.Value != "tok" {
+		t.Errorf("expected the token in %s, got %s=%s", middleware.TokenCookie, c.Name, c.Value)
+	}
+	if !c.HttpOnly || !c.Secure || c.SameSite != http.SameSiteLaxMode {
+		t.Errorf("expected an HttpOnly, Secure, SameSite=Lax cookie, got %+v", c)
+	}
+	if c.Expires.Unix() != 1767225600 {
+		t.Errorf("expected the cookie to expire with the token, got %v", c.Expires)
+	}
+}
+
+// sessionClient records the metadata Login receives and signs the caller
+// out of a fixed number of sessions.
+type sessionClient struct {
+	pb.UserServiceClient
+	md    metadata.MD
+	token string
+}
+
+func (c *sessionClient) Login(ctx context.Context, in *pb.LoginRequest, opts ...grpc.CallOption) (*pb.LoginResponse, error) {
+	c.md, _ = metadata.FromOutgoingContext(ctx)
+	return &pb.LoginResponse{UserId: "u1", Token: "tok"}, nil
+}
+
+func (c *sessionClient) RevokeAllSessions(ctx context.Context, in *pb.RevokeAllSessionsRequest, opts ...grpc.CallOption) (*pb.RevokeAllSessionsResponse, error) {
+	c.token = in.Token
+	return &pb.RevokeAllSessionsResponse{Revoked: 3}, nil
+}
+
+func TestLogin_ForwardsDevice(t *testing.T) {
+	client := &sessionClient{}
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(`{"email":"jane@example.com","password":"secret"}`))
+	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64)")
+	req.RemoteAddr = "203.0.113.7:4321"
+	NewAuthHandler(client, nil).Login(httptest.NewRecorder(), req)
+
+	if got := client.md.Get(auth.ClientDeviceMetadataKey); len(got) != 1 || got[0] != "Mozilla/5.0 (X11; Linux x86_64)" {
+		t.Errorf("expected the User-Agent to be forwarded, got %v", got)
+	}
+	if got := client.md.Get(auth.ClientIPMetadataKey); len(got) != 1 || got[0] != "203.0.113.7" {
+		t.Errorf("expected the client IP to be forwarded, got %v", got)
+	}
+}
+
+func TestRevokeAllSessions_ClearsCookie(t *testing.T) {
+	client := &sessionClient{}
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/sessions/revoke-all", nil)
+	req.Header.Set("Authorization", "Bearer tok")
+	rec := httptest.NewRecorder()
+	NewAuthHandler(client, nil).RevokeAllSessions(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if client.token != "tok" {
+		t.Errorf("expected the caller's token to be forwarded, got %q", client.token)
+	}
+	var resp RevokeSessionsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Revoked != 3 {
+		t.Errorf("expected 3 sessions revoked, got %+v (%v)", resp, err)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != middleware.TokenCookie || cookies[0].MaxAge >= 0 {
+		t.Errorf("expected the token cookie to be cleared, got %v", cookies)
+	}
+}
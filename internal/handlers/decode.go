@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBodyBytes caps request bodies so oversized payloads cannot exhaust
+// memory.
+const maxBodyBytes = 1 << 20
+
+// decodeBody reads the body of r into v, a pointer to a request struct. JSON
+// is the default; a body sent as application/x-www-form-urlencoded, as by a
+// plain HTML form or curl --data, is read into the same struct instead (see
+// decodeForm).
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/x-www-form-urlencoded" {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+		return decodeForm(r.PostForm, v)
+	}
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+// timeType is the type of the timestamps request structs take.
+var timeType = reflect.TypeOf(time.Time{})
+
+// decodeForm fills the struct v points to from form, matching keys to the
+// fields' JSON names. The values are converted to their JSON equivalents
+// and decoded as JSON, so a form field means exactly what the same JSON
+// field would: a timestamp is RFC 3339, and a repeated key fills a list
+// (tags=a&tags=b). A checkbox's "on" is true. Empty values are left unset,
+// as an HTML form sends every input whether filled in or not. Fields with
+// no flat form (A/B variants, geo rules) cannot be set this way; like
+// unknown keys, they are ignored.
+func decodeForm(form url.Values, v any) error {
+	t := reflect.TypeOf(v)
+	if t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		return errors.New("decodeForm: v must point to a struct")
+	}
+	t = t.Elem()
+
+	fields := make(map[string]any)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		values := form[name]
+		if len(values) == 0 || values[0] == "" {
+			continue
+		}
+
+		ft := field.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		switch {
+		case ft.Kind() == reflect.String, ft == timeType:
+			fields[name] = values[0]
+		case ft.Kind() == reflect.Bool:
+			b, err := parseFormBool(values[0])
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			fields[name] = b
+		case ft.Kind() >= reflect.Int && ft.Kind() <= reflect.Float64:
+			if _, err := strconv.ParseFloat(values[0], 64); err != nil {
+				return fmt.Errorf("%s: not a number", name)
+			}
+			fields[name] = json.Number(values[0])
+		case ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.String:
+			fields[name] = values
+		}
+	}
+
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// parseFormBool reads a boolean form value: a checkbox's "on", or anything
+// strconv.ParseBool accepts.
+func parseFormBool(s string) (bool, error) {
+	if s == "on" {
+		return true, nil
+	}
+	return strconv.ParseBool(s)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/models"
+	urlpb "github.com/Varun5711/shorternit/proto/url"
+	userpb "github.com/Varun5711/shorternit/proto/user"
+	"google.golang.org/grpc"
+)
+
+// formRequest builds a form-encoded POST of form to target.
+func formRequest(target string, form url.Values) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=UTF-8")
+	return req
+}
+
+// registerRecorder records the Register request it receives.
+type registerRecorder struct {
+	userpb.UserServiceClient
+	got *userpb.RegisterRequest
+}
+
+func (c *registerRecorder) Register(ctx context.Context, in *userpb.RegisterRequest, opts ...grpc.CallOption) (*userpb.RegisterResponse, error) {
+	c.got = in
+	return &userpb.RegisterResponse{UserId: "u1", Email: in.Email, Name: in.Name, Token: "tok"}, nil
+}
+
+// TestRegister_FormEncoded verifies that an HTML form can sign up.
+func TestRegister_FormEncoded(t *testing.T) {
+	client := &registerRecorder{}
+	rec := httptest.NewRecorder()
+	NewAuthHandler(client, nil).Register(rec, formRequest("/api/auth/register", url.Values{
+		"name":     {"Jane"},
+		"email":    {"jane@example.com"},
+		"password": {"correct-horse-battery"},
+	}))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if client.got == nil || client.got.Name != "Jane" || client.got.Email != "jane@example.com" || client.got.Password != "correct-horse-battery" {
+		t.Errorf("expected the form's fields to be registered, got %+v", client.got)
+	}
+}
+
+// createRecorder records the CreateURL request it receives.
+type createRecorder struct {
+	urlpb.URLServiceClient
+	got *urlpb.CreateURLRequest
+}
+
+func (c *createRecorder) CreateURL(ctx context.Context, in *urlpb.CreateURLRequest, opts ...grpc.CallOption) (*urlpb.CreateURLResponse, error) {
+	c.got = in
+	return &urlpb.CreateURLResponse{ShortCode: "abc123", LongUrl: in.LongUrl, Tags: in.Tags}, nil
+}
+
+// TestCreateURL_FormEncoded verifies that a form-encoded body creates the
+// same link its JSON equivalent would, and that its problems are reported
+// the same way.
+func TestCreateURL_FormEncoded(t *testing.T) {
+	client := &createRecorder{}
+	h := &HTTPHandler{grpcClient: client}
+
+	rec := httptest.NewRecorder()
+	h.CreateURL(rec, formRequest("/api/urls", url.Values{
+		"long_url":   {"https://example.com/page"},
+		"expires_at": {"2030-01-02T15:04:05Z"},
+		"max_clicks": {"5"},
+		"tags":       {"launch", "q3"},
+		"preview":    {"on"},
+		"domain":     {""},
+	}))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	got := client.got
+	want := time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC).Unix()
+	if got.LongUrl != "https://example.com/page" || got.ExpiresAt != want || got.MaxClicks != 5 || !got.Preview || got.Domain != "" {
+		t.Errorf("unexpected request %+v", got)
+	}
+	if strings.Join(got.Tags, ",") != "launch,q3" {
+		t.Errorf("expected tags [launch q3], got %v", got.Tags)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		form     url.Values
+		wantCode string
+	}{
+		{"invalid URL", url.Values{"long_url": {"ftp://example.com"}}, models.ErrCodeInvalidURL},
+		{"bad number", url.Values{"long_url": {"https://example.com"}, "max_clicks": {"many"}}, models.ErrCodeInvalidJSON},
+		{"bad time", url.Values{"long_url": {"https://example.com"}, "expires_at": {"tomorrow"}}, models.ErrCodeInvalidJSON},
+	} {
+		rec := httptest.NewRecorder()
+		h.CreateURL(rec, formRequest("/api/urls", tc.form))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", tc.name, rec.Code)
+			continue
+		}
+		if body := decodeError(t, rec); body.Code != tc.wantCode {
+			t.Errorf("%s: expected code %s, got %s", tc.name, tc.wantCode, body.Code)
+		}
+	}
+}
+
+// TestDecodeBody_DefaultsToJSON verifies that a body without a form content
+// type is read as JSON, as before forms were accepted.
+func TestDecodeBody_DefaultsToJSON(t *testing.T) {
+	for _, contentType := range []string{"", "application/json", "text/plain"} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"email":"jane@example.com","password":"pw"}`))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		var got LoginRequest
+		if err := decodeBody(httptest.NewRecorder(), req, &got); err != nil || got.Email != "jane@example.com" || got.Password != "pw" {
+			t.Errorf("%q: expected the JSON body, got %+v, %v", contentType, got, err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+// RegisterDomain handles POST /api/domains. It claims a custom domain for the
+// authenticated user and returns the TXT record to publish; links can use the
+// domain once POST /api/domains/{domain}/verify succeeds.
+func (h *HTTPHandler) RegisterDomain(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	var req models.RegisterDomainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, models.ErrCodeInvalidJSON, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	if req.Domain == "" {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "domain is required")
+		return
+	}
+
+	grpcResp, err := h.grpcClient.RegisterDomain(r.Context(), &pb.RegisterDomainRequest{
+		UserId: middleware.GetUserID(r.Context()),
+		Domain: req.Domain,
+	})
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to register domain")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, domainFromProto(grpcResp.Domain))
+}
+
+// ListDomains handles GET /api/domains, returning the authenticated user's
+// custom domains with their verification state.
+func (h *HTTPHandler) ListDomains(w http.ResponseWriter, r *http.Request) {
+	grpcResp, err := h.grpcClient.ListDomains(r.Context(), &pb.ListDomainsRequest{
+		UserId: middleware.GetUserID(r.Context()),
+	})
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to list domains")
+		return
+	}
+
+	domains := make([]*models.Domain, len(grpcResp.Domains))
+	for i, d := range grpcResp.Domains {
+		domains[i] = domainFromProto(d)
+	}
+
+	respondJSON(w, http.StatusOK, models.ListDomainsResponse{Domains: domains})
+}
+
+// VerifyDomain handles POST /api/domains/{domain}/verify. It checks the
+// domain's TXT record and answers 422 while the record is missing.
+func (h *HTTPHandler) VerifyDomain(w http.ResponseWriter, r *http.Request) {
+	domain := r.PathValue("domain")
+	if domain == "" {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "domain is required")
+		return
+	}
+
+	grpcResp, err := h.grpcClient.VerifyDomain(r.Context(), &pb.VerifyDomainRequest{
+		UserId: middleware.GetUserID(r.Context()),
+		Domain: domain,
+	})
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to verify domain")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, domainFromProto(grpcResp.Domain))
+}
+
+// domainFromProto converts a protobuf Domain into the JSON response model.
+func domainFromProto(d *pb.Domain) *models.Domain {
+	var verifiedAt *time.Time
+	if d.VerifiedAt > 0 {
+		t := time.Unix(d.VerifiedAt, 0)
+		verifiedAt = &t
+	}
+	return &models.Domain{
+		Domain:             d.Domain,
+		Verified:           d.Verified,
+		VerificationRecord: d.VerificationRecord,
+		VerificationToken:  d.VerificationToken,
+		CreatedAt:          time.Unix(d.CreatedAt, 0),
+		VerifiedAt:         verifiedAt,
+	}
+}
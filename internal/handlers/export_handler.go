@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/Varun5711/shorternit/internal/shorturl"
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+// exportPageSize is how many URLs are requested from the URL service per
+// round-trip while streaming an export.
+const exportPageSize = 500
+
+// exportWriteTimeout is the write deadline granted per page. It is extended
+// after every page so a large export is not cut off by the server-wide
+// WriteTimeout while it is still making progress.
+const exportWriteTimeout = 15 * time.Second
+
+// exportCSVHeader lists the CSV columns in the order of models.ExportedURL.
+var exportCSVHeader = []string{"short_code", "short_url", "long_url", "clicks", "created_at", "expires_at", "tags"}
+
+// ExportURLs handles GET /api/urls/export?format=json|csv. It streams every
+// URL owned by the authenticated user as a downloadable file, paging through
+// the URL service with a keyset cursor so neither the gateway nor the service
+// ever holds the whole account in memory. JSON is the default format and is
+// written as a single array; CSV starts with a header row. Expired links are
+// not exported.
+//
+// Errors on the first page are reported as a normal JSON error. Once the body
+// has started the status can no longer change, so a later failure aborts the
+// connection rather than leaving a truncated file that looks complete.
+func (h *HTTPHandler) ExportURLs(w http.ResponseWriter, r *http.Request) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "format must be json or csv")
+		return
+	}
+
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	page, err := h.grpcClient.ExportURLs(ctx, &pb.ExportURLsRequest{
+		UserId: userID,
+		Limit:  exportPageSize,
+	})
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to export URLs")
+		return
+	}
+
+	var out exportEncoder
+	filename := fmt.Sprintf("urls-%s.%s", time.Now().UTC().Format("20060102"), format)
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		out = newCSVExportEncoder(w)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		out = newJSONExportEncoder(w)
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	for {
+		_ = rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+
+		for _, u := range page.Urls {
+			if err := out.Encode(h.exportedURL(u)); err != nil {
+				// The client went away; nothing left to tell it.
+				return
+			}
+		}
+		_ = rc.Flush()
+
+		if page.NextCursor == "" {
+			break
+		}
+
+		page, err = h.grpcClient.ExportURLs(ctx, &pb.ExportURLsRequest{
+			UserId: userID,
+			Cursor: page.NextCursor,
+			Limit:  exportPageSize,
+		})
+		if err != nil {
+			panic(http.ErrAbortHandler)
+		}
+	}
+
+	if err := out.Close(); err != nil {
+		return
+	}
+	_ = rc.Flush()
+}
+
+// exportedURL converts a protobuf URL into an export record.
+func (h *HTTPHandler) exportedURL(u *pb.URL) models.ExportedURL {
+	shortURL := u.ShortUrl
+	if shortURL == "" {
+		shortURL = shorturl.BuildDomainShortURL(h.baseURL, u.Domain, u.ShortCode)
+	}
+
+	var expiresAt *time.Time
+	if u.ExpiresAt > 0 {
+		t := time.Unix(u.ExpiresAt, 0).UTC()
+		expiresAt = &t
+	}
+
+	return models.ExportedURL{
+		ShortCode: u.ShortCode,
+		ShortURL:  shortURL,
+		LongURL:   u.LongUrl,
+		Clicks:    u.Clicks,
+		CreatedAt: time.Unix(u.CreatedAt, 0).UTC(),
+		ExpiresAt: expiresAt,
+		Tags:      u.Tags,
+	}
+}
+
+// exportEncoder writes export records one at a time in a specific format.
+// Close finishes the document (e.g. the closing bracket of a JSON array).
+type exportEncoder interface {
+	Encode(u models.ExportedURL) error
+	Close() error
+}
+
+// jsonExportEncoder streams records as the elements of one JSON array.
+type jsonExportEncoder struct {
+	w     http.ResponseWriter
+	count int
+}
+
+func newJSONExportEncoder(w http.ResponseWriter) *jsonExportEncoder {
+	return &jsonExportEncoder{w: w}
+}
+
+func (e *jsonExportEncoder) Encode(u models.ExportedURL) error {
+	sep := ","
+	if e.count == 0 {
+		sep = "["
+	}
+	b, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	if _, err := e.w.Write(append([]byte(sep), b...)); err != nil {
+		return err
+	}
+	e.count++
+	return nil
+}
+
+func (e *jsonExportEncoder) Close() error {
+	end := "]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+	_, err := e.w.Write([]byte(end))
+	return err
+}
+
+// csvExportEncoder streams records as CSV rows after a header row. RFC 3339
+// timestamps are used so the file re-imports without locale ambiguity, and
+// tags are joined with ";" into a single column. Text cells go through
+// csvSafeCell because the file is meant to be opened in spreadsheets.
+type csvExportEncoder struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func newCSVExportEncoder(w http.ResponseWriter) *csvExportEncoder {
+	return &csvExportEncoder{w: csv.NewWriter(w)}
+}
+
+func (e *csvExportEncoder) Encode(u models.ExportedURL) error {
+	if !e.wroteHeader {
+		if err := e.w.Write(exportCSVHeader); err != nil {
+			return err
+		}
+		e.wroteHeader = true
+	}
+
+	var expiresAt string
+	if u.ExpiresAt != nil {
+		expiresAt = u.ExpiresAt.Format(time.RFC3339)
+	}
+
+	if err := e.w.Write([]string{
+		csvSafeCell(u.ShortCode),
+		csvSafeCell(u.ShortURL),
+		csvSafeCell(u.LongURL),
+		strconv.FormatInt(u.Clicks, 10),
+		u.CreatedAt.Format(time.RFC3339),
+		expiresAt,
+		csvSafeCell(strings.Join(u.Tags, ";")),
+	}); err != nil {
+		return err
+	}
+	// csv.Writer buffers internally; flush per record so memory stays flat
+	// and the ResponseController flush after each page reaches the client.
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExportEncoder) Close() error {
+	if !e.wroteHeader {
+		if err := e.w.Write(exportCSVHeader); err != nil {
+			return err
+		}
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// csvFormulaPrefixes are the leading characters that make Excel, Sheets and
+// LibreOffice evaluate a cell as a formula (tab and CR are included because
+// some of them strip those before deciding).
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// csvSafeCell neutralizes CSV formula injection: a user-controlled value such
+// as a long URL or alias that starts with a formula character is prefixed
+// with a single quote so spreadsheets show it as text. Import strips the
+// quote again (see importCell).
+func csvSafeCell(v string) string {
+	if v != "" && strings.ContainsRune(csvFormulaPrefixes, rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
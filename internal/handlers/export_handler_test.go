@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc"
+)
+
+// pagedExportClient serves ExportURLs from a fixed list, pageSize URLs at a
+// time, using the index of the next URL as the cursor.
+type pagedExportClient struct {
+	pb.URLServiceClient
+	urls     []*pb.URL
+	pageSize int
+	calls    int
+}
+
+func (c *pagedExportClient) ExportURLs(ctx context.Context, in *pb.ExportURLsRequest, opts ...grpc.CallOption) (*pb.ExportURLsResponse, error) {
+	c.calls++
+	start := 0
+	if in.Cursor != "" {
+		start, _ = strconv.Atoi(in.Cursor)
+	}
+	end := start + c.pageSize
+	if end > len(c.urls) {
+		end = len(c.urls)
+	}
+
+	resp := &pb.ExportURLsResponse{Urls: c.urls[start:end]}
+	if end < len(c.urls) {
+		resp.NextCursor = strconv.Itoa(end)
+	}
+	return resp, nil
+}
+
+func newExportTestHandler(n, pageSize int) (*HTTPHandler, *pagedExportClient) {
+	client := &pagedExportClient{pageSize: pageSize}
+	for i := 0; i < n; i++ {
+		code := "c" + strconv.Itoa(i)
+		client.urls = append(client.urls, &pb.URL{
+			ShortCode: code,
+			LongUrl:   "https://example.com/" + code,
+			Clicks:    int64(i),
+			CreatedAt: 1700000000,
+			Tags:      []string{"work", "q3"},
+		})
+	}
+	return &HTTPHandler{grpcClient: client, baseURL: "http://sho.rt"}, client
+}
+
+func export(h *HTTPHandler, format string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ExportURLs(rec, httptest.NewRequest(http.MethodGet, "/api/urls/export?format="+format, nil))
+	return rec
+}
+
+// TestExportURLs_JSONStreamsAllPages verifies the JSON export walks every page
+// and produces a single well-formed array.
+func TestExportURLs_JSONStreamsAllPages(t *testing.T) {
+	h, client := newExportTestHandler(5, 2)
+
+	rec := export(h, "json")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if !strings.HasPrefix(rec.Header().Get("Content-Disposition"), "attachment;") {
+		t.Errorf("expected attachment Content-Disposition, got %q", rec.Header().Get("Content-Disposition"))
+	}
+
+	var got []models.ExportedURL
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, rec.Body.String())
+	}
+	if len(got) != 5 {
+		t.Fatalf("expected 5 URLs, got %d", len(got))
+	}
+	if got[4].ShortURL != "http://sho.rt/c4" {
+		t.Errorf("expected short URL built from base URL, got %q", got[4].ShortURL)
+	}
+	if client.calls != 3 {
+		t.Errorf("expected 3 page requests, got %d", client.calls)
+	}
+}
+
+// TestExportURLs_CSV checks the CSV export has a header plus one row per URL.
+func TestExportURLs_CSV(t *testing.T) {
+	h, _ := newExportTestHandler(3, 2)
+
+	rec := export(h, "csv")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("expected header + 3 rows, got %d", len(rows))
+	}
+	if rows[0][0] != "short_code" || rows[2][2] != "https://example.com/c1" || rows[2][6] != "work;q3" {
+		t.Errorf("unexpected CSV content: %v", rows)
+	}
+}
+
+// TestExportURLs_Empty ensures an account without URLs still yields a valid
+// document in both formats.
+func TestExportURLs_Empty(t *testing.T) {
+	h, _ := newExportTestHandler(0, 2)
+
+	if body := strings.TrimSpace(export(h, "json").Body.String()); body != "[]" {
+		t.Errorf("expected empty JSON array, got %q", body)
+	}
+	if body := strings.TrimSpace(export(h, "csv").Body.String()); body != strings.Join(exportCSVHeader, ",") {
+		t.Errorf("expected only the CSV header, got %q", body)
+	}
+}
+
+// TestExportURLs_RejectsUnknownFormat verifies unsupported formats get 400.
+func TestExportURLs_RejectsUnknownFormat(t *testing.T) {
+	h, _ := newExportTestHandler(1, 2)
+
+	if code := export(h, "xml").Code; code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", code)
+	}
+}
+
+// TestCSVSafeCell checks that values spreadsheets would evaluate as formulas
+// are quoted and ordinary values pass through unchanged.
+func TestCSVSafeCell(t *testing.T) {
+	cases := map[string]string{
+		"=HYPERLINK(\"http://evil\")": "'=HYPERLINK(\"http://evil\")",
+		"+1":                          "'+1",
+		"-abc":                        "'-abc",
+		"@SUM(A1)":                    "'@SUM(A1)",
+		"\tcmd":                       "'\tcmd",
+		"https://example.com":         "https://example.com",
+		"":                            "",
+	}
+
+	for in, want := range cases {
+		if got := csvSafeCell(in); got != want {
+			t.Errorf("csvSafeCell(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// clientErrorStatus pins the HTTP status for each gRPC code the backend
+// services return on purpose, to describe a problem with the request. Their
+// messages are written for the client and are passed through.
+var clientErrorStatus = map[codes.Code]int{
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.FailedPrecondition: http.StatusUnprocessableEntity,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.Unimplemented:      http.StatusNotImplemented, // a feature disabled by configuration
+}
+
+// grpcErrorCodes is the error code reported for each gRPC code when the
+// service did not name a more specific one; see grpcErrorCode.
+var grpcErrorCodes = map[codes.Code]string{
+	codes.InvalidArgument:    models.ErrCodeInvalidRequest,
+	codes.Unauthenticated:    models.ErrCodeUnauthorized,
+	codes.PermissionDenied:   models.ErrCodeForbidden,
+	codes.NotFound:           models.ErrCodeNotFound,
+	codes.AlreadyExists:      models.ErrCodeConflict,
+	codes.FailedPrecondition: models.ErrCodePreconditionFailed,
+	codes.ResourceExhausted:  models.ErrCodeRateLimited,
+	codes.Unimplemented:      models.ErrCodeNotImplemented,
+	codes.Unavailable:        models.ErrCodeUnavailable,
+	codes.DeadlineExceeded:   models.ErrCodeTimeout,
+}
+
+// grpcErrorCode returns the error code to report for st: the reason of its
+// ErrorInfo detail when the service attached one (ALIAS_TAKEN,
+// URL_NOT_FOUND, QUOTA_EXCEEDED), else the default for its gRPC code, and
+// INTERNAL for anything unexpected.
+func grpcErrorCode(st *status.Status) string {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.Reason != "" {
+			return info.Reason
+		}
+	}
+	if code, ok := grpcErrorCodes[st.Code()]; ok {
+		return code
+	}
+	return models.ErrCodeInternal
+}
+
+// grpcErrorToHTTP maps an error from a gRPC call to the HTTP status to
+// answer with and the service's human-readable message, read from the
+// status rather than matched in err.Error() so that rewording a message
+// cannot change the status. Any other code, and an error that is not a
+// gRPC status at all, is a server-side failure: it maps to 503 or 504 when
+// the service was unavailable or too slow and to 500 otherwise, with an
+// empty message since its text may expose internals.
+func grpcErrorToHTTP(err error) (int, string) {
+	st := status.Convert(err)
+	if httpStatus, ok := clientErrorStatus[st.Code()]; ok {
+		return httpStatus, st.Message()
+	}
+	switch st.Code() {
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable, ""
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout, ""
+	default:
+		return http.StatusInternalServerError, ""
+	}
+}
+
+// grpcErrorSuggestions returns the alternatives a service attached to err,
+// such as free aliases close to a taken one.
+func grpcErrorSuggestions(err error) []string {
+	for _, detail := range status.Convert(err).Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.Metadata["suggestions"] == "" {
+			continue
+		}
+		return strings.Split(info.Metadata["suggestions"], ",")
+	}
+	return nil
+}
+
+// setRetryAfter sets the Retry-After header to the wait in st's RetryInfo
+// detail, such as the rest of a login lockout or the time until a daily
+// quota resets. It does nothing if st has none.
+func setRetryAfter(w http.ResponseWriter, st *status.Status) {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			if d := info.GetRetryDelay().AsDuration(); d > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+			}
+			return
+		}
+	}
+}
+
+// respondGRPCError writes the error envelope for a failed gRPC call, as
+// mapped by grpcErrorToHTTP and grpcErrorCode, with a Retry-After header
+// when the service said how long to wait. Server-side failures are reported
+// with the given fallback message.
+func respondGRPCError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	httpStatus, message := grpcErrorToHTTP(err)
+	if message == "" {
+		message = fallback
+	}
+	st := status.Convert(err)
+	setRetryAfter(w, st)
+	middleware.WriteErrorResponse(w, r, httpStatus, models.ErrorResponse{
+		Code:        grpcErrorCode(st),
+		Message:     message,
+		Suggestions: grpcErrorSuggestions(err),
+	})
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/models"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// TestGRPCErrorToHTTP pins the HTTP status for each gRPC code, and checks
+// that only client errors pass their message through.
+func TestGRPCErrorToHTTP(t *testing.T) {
+	cases := []struct {
+		code        codes.Code
+		wantStatus  int
+		wantMessage string
+	}{
+		{codes.InvalidArgument, http.StatusBadRequest, "boom"},
+		{codes.Unauthenticated, http.StatusUnauthorized, "boom"},
+		{codes.PermissionDenied, http.StatusForbidden, "boom"},
+		{codes.NotFound, http.StatusNotFound, "boom"},
+		{codes.AlreadyExists, http.StatusConflict, "boom"},
+		{codes.FailedPrecondition, http.StatusUnprocessableEntity, "boom"},
+		{codes.ResourceExhausted, http.StatusTooManyRequests, "boom"},
+		{codes.Unimplemented, http.StatusNotImplemented, "boom"},
+		{codes.Unavailable, http.StatusServiceUnavailable, ""},
+		{codes.DeadlineExceeded, http.StatusGatewayTimeout, ""},
+		{codes.Internal, http.StatusInternalServerError, ""},
+		{codes.Unknown, http.StatusInternalServerError, ""},
+	}
+
+	for _, tc := range cases {
+		gotStatus, gotMessage := grpcErrorToHTTP(status.Error(tc.code, "boom"))
+		if gotStatus != tc.wantStatus || gotMessage != tc.wantMessage {
+			t.Errorf("%v: expected (%d, %q), got (%d, %q)", tc.code, tc.wantStatus, tc.wantMessage, gotStatus, gotMessage)
+		}
+	}
+}
+
+// TestGRPCErrorToHTTP_IgnoresMessageText verifies that the status comes from
+// the code alone: a message mentioning another code's name does not sway it.
+func TestGRPCErrorToHTTP_IgnoresMessageText(t *testing.T) {
+	gotStatus, _ := grpcErrorToHTTP(status.Error(codes.Internal, "alias already taken: AlreadyExists"))
+	if gotStatus != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", gotStatus)
+	}
+
+	gotStatus, gotMessage := grpcErrorToHTTP(errors.New("connection reset"))
+	if gotStatus != http.StatusInternalServerError || gotMessage != "" {
+		t.Errorf("expected a non-status error to be an opaque 500, got (%d, %q)", gotStatus, gotMessage)
+	}
+}
+
+// TestRespondGRPCError_Suggestions verifies that alternatives attached by the
+// service reach the client alongside its message.
+func TestRespondGRPCError_Suggestions(t *testing.T) {
+	st, err := status.New(codes.AlreadyExists, "alias 'promo' is already taken").WithDetails(&errdetails.ErrorInfo{
+		Reason:   "ALIAS_TAKEN",
+		Metadata: map[string]string{"suggestions": "promo-1,promo-2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	respondGRPCError(rec, httptest.NewRequest(http.MethodPost, "/api/urls", nil), st.Err(), "failed")
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", rec.Code)
+	}
+	var body models.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Code != models.ErrCodeAliasTaken {
+		t.Errorf("expected the service's reason as the code, got %q", body.Code)
+	}
+	if body.Message != "alias 'promo' is already taken" {
+		t.Errorf("expected the service's message, got %q", body.Message)
+	}
+	if !reflect.DeepEqual(body.Suggestions, []string{"promo-1", "promo-2"}) {
+		t.Errorf("expected the suggestions, got %v", body.Suggestions)
+	}
+}
+
+// TestRespondGRPCError_Fallback verifies that server-side failures are
+// reported with the handler's message rather than the internal error text.
+func TestRespondGRPCError_Fallback(t *testing.T) {
+	rec := httptest.NewRecorder()
+	respondGRPCError(rec, httptest.NewRequest(http.MethodPost, "/api/urls", nil), status.Error(codes.Internal, "pq: connection refused"), "failed to create URL")
+
+	var body models.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusInternalServerError || body.Code != models.ErrCodeInternal || body.Message != "failed to create URL" {
+		t.Errorf("expected 500 INTERNAL with the fallback message, got %d %s %q", rec.Code, body.Code, body.Message)
+	}
+	if body.RequestID == "" || body.RequestID != rec.Header().Get("X-Request-ID") {
+		t.Errorf("expected the request ID in the body and header, got %q and %q", body.RequestID, rec.Header().Get("X-Request-ID"))
+	}
+	if body.Suggestions != nil {
+		t.Errorf("expected no suggestions, got %v", body.Suggestions)
+	}
+}
+
+// TestRespondGRPCError_RetryAfter verifies that a wait attached by the
+// service, such as the time until a daily quota resets, becomes Retry-After.
+func TestRespondGRPCError_RetryAfter(t *testing.T) {
+	st, err := status.New(codes.ResourceExhausted, "daily link quota reached").WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(90*time.Minute + 500*time.Millisecond),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	respondGRPCError(rec, httptest.NewRequest(http.MethodPost, "/api/urls", nil), st.Err(), "failed")
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "5401" {
+		t.Errorf("expected Retry-After rounded up to 5401, got %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	respondGRPCError(rec, httptest.NewRequest(http.MethodPost, "/api/urls", nil), status.Error(codes.ResourceExhausted, "link quota reached"), "failed")
+	if got := rec.Header().Get("Retry-After"); got != "" {
+		t.Errorf("expected no Retry-After without RetryInfo, got %q", got)
+	}
+}
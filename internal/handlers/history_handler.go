@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+// GetURLHistory handles GET /api/urls/{code}/history, the audit trail of one
+// of the authenticated user's links, oldest event first. It answers 404 if
+// there is no such link, or 403 if it belongs to someone else.
+func (h *HTTPHandler) GetURLHistory(w http.ResponseWriter, r *http.Request) {
+	h.respondURLHistory(w, r, &pb.GetURLHistoryRequest{
+		ShortCode: r.PathValue("code"),
+		UserId:    middleware.GetUserID(r.Context()),
+	})
+}
+
+// respondURLHistory fetches the trail req asks for and writes it as a
+// models.URLHistoryResponse.
+func (h *HTTPHandler) respondURLHistory(w http.ResponseWriter, r *http.Request, req *pb.GetURLHistoryRequest) {
+	grpcResp, err := h.grpcClient.GetURLHistory(r.Context(), req)
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to get URL history")
+		return
+	}
+
+	events := make([]models.URLEvent, len(grpcResp.Events))
+	for i, ev := range grpcResp.Events {
+		events[i] = models.URLEvent{
+			ID:         ev.Id,
+			ShortCode:  ev.ShortCode,
+			Action:     ev.Action,
+			ActorID:    ev.ActorId,
+			BeforeURL:  ev.BeforeUrl,
+			AfterURL:   ev.AfterUrl,
+			OccurredAt: time.Unix(ev.OccurredAt, 0),
+		}
+	}
+
+	respondJSON(w, http.StatusOK, models.URLHistoryResponse{
+		ShortCode: req.ShortCode,
+		Events:    events,
+	})
+}
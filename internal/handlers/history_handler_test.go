@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// historyClient answers GetURLHistory for "abc", owned by alice, and
+// remembers the last request.
+type historyClient struct {
+	pb.URLServiceClient
+	last *pb.GetURLHistoryRequest
+}
+
+func (c *historyClient) GetURLHistory(ctx context.Context, in *pb.GetURLHistoryRequest, opts ...grpc.CallOption) (*pb.GetURLHistoryResponse, error) {
+	c.last = in
+	if in.ShortCode != "abc" {
+		return nil, status.Error(codes.NotFound, "short code not found")
+	}
+	if in.UserId != "alice" && !in.Admin {
+		return nil, status.Error(codes.PermissionDenied, "you do not own this URL")
+	}
+	return &pb.GetURLHistoryResponse{Events: []*pb.URLEvent{
+		{Id: 1, ShortCode: "abc", Action: "create", ActorId: "alice", AfterUrl: "https://example.com", OccurredAt: 1700000000},
+		{Id: 2, ShortCode: "abc", Action: "update", ActorId: "alice", BeforeUrl: "https://example.com", AfterUrl: "https://example.com", OccurredAt: 1700000060},
+	}}, nil
+}
+
+func getHistory(handler http.HandlerFunc, code, userID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/urls/"+code+"/history", nil)
+	req.SetPathValue("code", code)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, userID))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+// TestGetURLHistory_ReturnsEventsInOrder verifies that the owner gets the
+// trail as JSON, oldest first, with Unix times turned into timestamps.
+func TestGetURLHistory_ReturnsEventsInOrder(t *testing.T) {
+	client := &historyClient{}
+	h := &HTTPHandler{grpcClient: client}
+
+	rec := getHistory(h.GetURLHistory, "abc", "alice")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if client.last.UserId != "alice" || client.last.Admin {
+		t.Errorf("expected an owner request from alice, got %+v", client.last)
+	}
+
+	var got models.URLHistoryResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got.ShortCode != "abc" || len(got.Events) != 2 {
+		t.Fatalf("expected 2 events for abc, got %+v", got)
+	}
+	if got.Events[0].Action != "create" || got.Events[1].Action != "update" {
+		t.Errorf("expected create then update, got %+v", got.Events)
+	}
+	if got.Events[0].OccurredAt.Unix() != 1700000000 || got.Events[0].BeforeURL != "" {
+		t.Errorf("unexpected create event %+v", got.Events[0])
+	}
+}
+
+// TestGetURLHistory_Access verifies the status codes for another user's link
+// and a missing one, and that the admin route asks for the full trail.
+func TestGetURLHistory_Access(t *testing.T) {
+	client := &historyClient{}
+	h := &HTTPHandler{grpcClient: client}
+
+	if rec := getHistory(h.GetURLHistory, "abc", "bob"); rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for another user's link, got %d", rec.Code)
+	}
+	if rec := getHistory(h.GetURLHistory, "nope", "alice"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing link, got %d", rec.Code)
+	}
+
+	if rec := getHistory(h.AdminGetURLHistory, "abc", "admin-id"); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for an admin, got %d", rec.Code)
+	}
+	if !client.last.Admin || client.last.UserId != "admin-id" {
+		t.Errorf("expected an admin request from admin-id, got %+v", client.last)
+	}
+}
@@ -7,16 +7,20 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Varun5711/shorternit/internal/clock"
+	"github.com/Varun5711/shorternit/internal/config"
 	es "github.com/Varun5711/shorternit/internal/elasticsearch"
 	grpcClient "github.com/Varun5711/shorternit/internal/grpc"
 	"github.com/Varun5711/shorternit/internal/middleware"
 	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/Varun5711/shorternit/internal/qrcode"
 	pb "github.com/Varun5711/shorternit/proto/url"
 )
 
@@ -26,15 +30,26 @@ import (
 type HTTPHandler struct {
 	grpcClient pb.URLServiceClient
 	esClient   *es.Client
-	baseURL    string // baseURL is the public-facing prefix used to construct short URLs (e.g. "https://tiny.io").
+	qrStore    qrcode.Store // qrStore holds uploaded QR code images; nil when they are kept in the database.
+	qrCache    qrcode.Store // qrCache keeps QR codes rendered on demand; may be nil.
+	baseURL    string       // baseURL is the public-facing prefix used to construct short URLs (e.g. "https://tiny.io").
+	clock      clock.Clock  // clock caps anonymous links and checks imported expiries; nil means the wall clock.
+
+	// anonymousLinkTTL caps how long links created by CreateAnonymousURL
+	// live; 0 leaves them unbounded.
+	anonymousLinkTTL time.Duration
 }
 
 // NewHTTPHandler creates an HTTPHandler by dialing the URL gRPC service at
-// urlServiceAddr. The baseURL is prepended to short codes when building the
-// full short URL returned to clients. esClient may be nil if Elasticsearch
-// is not configured, in which case the search endpoint returns 503.
-func NewHTTPHandler(urlServiceAddr string, baseURL string, esClient *es.Client) (*HTTPHandler, error) {
-	client, err := grpcClient.NewURLServiceClient(urlServiceAddr)
+// urlServiceAddr with the connection settings in grpcCfg. The baseURL is
+// prepended to short codes when building the full short URL returned to
+// clients. esClient may be nil if Elasticsearch is not configured, in which
+// case the search endpoint returns 503. qrStore is the object store the URL
+// service uploads QR codes to, or nil, and qrCache is where QR codes
+// rendered on demand are kept, or nil to render them on every request.
+// anonymousLinkTTL is the longest an anonymous link may live.
+func NewHTTPHandler(urlServiceAddr string, grpcCfg config.GRPCConfig, baseURL string, esClient *es.Client, qrStore, qrCache qrcode.Store, anonymousLinkTTL time.Duration) (*HTTPHandler, error) {
+	client, err := grpcClient.NewURLServiceClient(urlServiceAddr, grpcCfg, 1)
 	if err != nil {
 		return nil, err
 	}
@@ -42,124 +57,359 @@ func NewHTTPHandler(urlServiceAddr string, baseURL string, esClient *es.Client)
 	return &HTTPHandler{
 		grpcClient: client,
 		esClient:   esClient,
+		qrStore:    qrStore,
+		qrCache:    qrCache,
 		baseURL:    baseURL,
+		clock:      clock.Real{},
+
+		anonymousLinkTTL: anonymousLinkTTL,
 	}, nil
 }
 
 // CreateURL handles POST requests to shorten a new URL. It validates the
 // request body, extracts the authenticated user ID from the context (set by
 // the auth middleware), and delegates to the URL gRPC service. The response
-// includes the generated short code, the fully qualified short URL, and an
-// optional QR code image.
+// includes the generated short code, the fully qualified short URL, and where
+// to fetch the link's QR code (the image itself when generate_qr asked for it
+// to be rendered at creation and it is kept in the database). The body may be
+// JSON or form-encoded (see decodeBody).
 func (h *HTTPHandler) CreateURL(w http.ResponseWriter, r *http.Request) {
-	// Cap the body at 1 MiB to prevent oversized payloads from consuming memory.
-	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	h.createURL(w, r, false)
+}
+
+// CreateAnonymousURL handles POST /api/urls/anonymous, which the gateway
+// mounts without authentication when ALLOW_ANONYMOUS_CREATE is set. It
+// creates a link like CreateURL but with no owner, whatever token is sent,
+// so nobody can list, edit or delete it. Such a link therefore expires within
+// anonymousLinkTTL: an expires_at that is missing or later is brought
+// forward. A custom domain needs an owner and is refused.
+func (h *HTTPHandler) CreateAnonymousURL(w http.ResponseWriter, r *http.Request) {
+	h.createURL(w, r, true)
+}
+
+// createURL implements CreateURL and CreateAnonymousURL.
+func (h *HTTPHandler) createURL(w http.ResponseWriter, r *http.Request, anonymous bool) {
 	var req models.CreateURLRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid JSON")
+	if err := decodeBody(w, r, &req); err != nil {
+		writeError(w, r, models.ErrCodeInvalidJSON, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
-	if req.LongURL == "" {
-		respondError(w, http.StatusBadRequest, "long_url is required")
+	// Reject non-HTTP(S) URLs early to avoid storing unusable destinations,
+	// reporting every invalid field at once.
+	result := validateCreateURL(req)
+	if anonymous && req.Domain != "" {
+		result.Add("domain", "anonymous links cannot use a custom domain")
+	}
+	if !result.Valid() {
+		writeValidationError(w, r, result)
 		return
 	}
 
-	// Reject non-HTTP(S) URLs early to avoid storing unusable destinations.
-	if !isValidURL(req.LongURL) {
-		respondError(w, http.StatusBadRequest, "invalid URL format")
-		return
+	variants := make([]*pb.URLVariant, len(req.Variants))
+	for i, v := range req.Variants {
+		variants[i] = &pb.URLVariant{LongUrl: v.LongURL, Weight: v.Weight}
+	}
+	geoRules := make([]*pb.GeoRule, len(req.GeoRules))
+	for i, rule := range req.GeoRules {
+		geoRules[i] = &pb.GeoRule{CountryCode: rule.CountryCode, LongUrl: rule.LongURL}
 	}
 
 	// The user ID is injected into the context by the auth middleware; an
 	// empty string here means the request is unauthenticated (anonymous shortening).
 	userID := middleware.GetUserID(r.Context())
+	if anonymous {
+		userID = ""
+		if h.anonymousLinkTTL > 0 {
+			if latest := h.now().Add(h.anonymousLinkTTL); req.ExpiresAt == nil || req.ExpiresAt.After(latest) {
+				req.ExpiresAt = &latest
+			}
+		}
+	}
 
 	grpcReq := &pb.CreateURLRequest{
-		LongUrl: req.LongURL,
-		UserId:  userID,
+		LongUrl:    req.LongURL,
+		UserId:     userID,
+		MaxClicks:  req.MaxClicks,
+		Tags:       req.Tags,
+		Domain:     req.Domain,
+		Variants:   variants,
+		GeoRules:   geoRules,
+		Preview:    req.Preview,
+		GenerateQr: req.GenerateQR,
+		BackupUrl:  req.BackupURL,
 	}
 
 	if req.ExpiresAt != nil {
 		grpcReq.ExpiresAt = req.ExpiresAt.Unix()
 	}
+	if req.ActiveFrom != nil {
+		grpcReq.ActiveFrom = req.ActiveFrom.Unix()
+	}
 
 	ctx := r.Context()
 	grpcResp, err := h.grpcClient.CreateURL(ctx, grpcReq)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "failed to create URL")
+		respondGRPCError(w, r, err, "failed to create URL")
 		return
 	}
 
-	// Convert the Unix timestamp back to a time pointer; zero means no expiry.
+	// Convert the Unix timestamps back to time pointers; zero means unset.
 	var expiresAt *time.Time
 	if grpcResp.ExpiresAt > 0 {
 		t := time.Unix(grpcResp.ExpiresAt, 0)
 		expiresAt = &t
 	}
-
-	// Build the public-facing short URL by combining the configured base URL
-	// with the generated short code (e.g. "https://tiny.io/abc123").
-	shortURL := h.baseURL + "/" + grpcResp.ShortCode
+	var activeFrom *time.Time
+	if grpcResp.ActiveFrom > 0 {
+		t := time.Unix(grpcResp.ActiveFrom, 0)
+		activeFrom = &t
+	}
 
 	res := models.CreateURLResponse{
-		ShortCode: grpcResp.ShortCode,
-		ShortURL:  shortURL,
-		LongURL:   grpcResp.LongUrl,
-		CreatedAt: time.Unix(grpcResp.CreatedAt, 0),
-		ExpiresAt: expiresAt,
-		QRCode:    grpcResp.QrCode,
+		ShortCode:  grpcResp.ShortCode,
+		ShortURL:   grpcResp.ShortUrl,
+		LongURL:    grpcResp.LongUrl,
+		CreatedAt:  time.Unix(grpcResp.CreatedAt, 0),
+		ExpiresAt:  expiresAt,
+		MaxClicks:  grpcResp.MaxClicks,
+		ActiveFrom: activeFrom,
+		Tags:       grpcResp.Tags,
+		QRCode:     h.qrCodeValue(grpcResp.ShortCode, req.Domain, grpcResp.QrCode),
+		Variants:   variantsToModel(grpcResp.Variants),
+		GeoRules:   geoRulesToModel(grpcResp.GeoRules),
+		Preview:    grpcResp.Preview,
+		BackupURL:  grpcResp.BackupUrl,
 	}
 
 	respondJSON(w, http.StatusCreated, res)
 }
 
-// ListURLs handles GET requests to retrieve all URLs owned by the authenticated
-// user. Results are currently hard-capped at 100 items with no client-side
-// pagination; the HasMore flag indicates whether additional records exist.
+// Page sizes for GET /api/urls. maxListLimit matches the cap the URL service
+// applies to ListURLs.
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+// ListURLs handles GET requests to retrieve a page of the URLs owned by the
+// authenticated user, optionally only those carrying ?tag=<tag>. Query
+// parameters:
+//   - limit  (optional) - page size, default 100, clamped to 1..1000
+//   - offset (optional) - how many URLs to skip, default 0
+//
+// A value that is not an integer is a 400. The total number of matching URLs
+// is sent in X-Total-Count and the neighbouring pages in a Link header, next
+// to the has_more flag of the body.
 func (h *HTTPHandler) ListURLs(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserID(r.Context())
 
+	limit, offset, err := listPageParams(r.URL.Query())
+	if err != nil {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	grpcReq := &pb.ListURLsRequest{
-		Limit:  100,
-		Offset: 0,
+		Limit:  limit,
+		Offset: offset,
 		UserId: userID,
+		Tag:    r.URL.Query().Get("tag"),
 	}
 
 	ctx := r.Context()
 	grpcResp, err := h.grpcClient.ListURLs(ctx, grpcReq)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "failed to list URLs")
+		// An invalid ?tag= comes back as InvalidArgument and maps to 400.
+		respondGRPCError(w, r, err, "failed to list URLs")
 		return
 	}
 
-	// Map protobuf URL messages to the JSON response model, converting
-	// Unix timestamps to Go time values for consistent JSON serialization.
+	w.Header().Set("X-Total-Count", strconv.Itoa(int(grpcResp.Total)))
+	if link := paginationLinks(r.URL, limit, offset, grpcResp.Total); link != "" {
+		w.Header().Set("Link", link)
+	}
+	respondJSON(w, http.StatusOK, listedURLs(grpcResp))
+}
+
+// listPageParams reads ?limit= and ?offset=, clamping the limit to
+// 1..maxListLimit and a negative offset to 0.
+func listPageParams(query url.Values) (limit, offset int32, err error) {
+	limit = defaultListLimit
+	if l := query.Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil {
+			return 0, 0, fmt.Errorf("limit must be an integer")
+		}
+		limit = int32(max(1, min(parsed, maxListLimit)))
+	}
+	if o := query.Get("offset"); o != "" {
+		parsed, err := strconv.ParseInt(o, 10, 32)
+		if err != nil {
+			return 0, 0, fmt.Errorf("offset must be an integer")
+		}
+		offset = int32(max(0, parsed))
+	}
+	return limit, offset, nil
+}
+
+// paginationLinks builds an RFC 8288 Link header value with the first,
+// previous, next and last pages of a listing of total items, as relative
+// references to the requested path with its other query parameters kept.
+// Pages that do not exist are left out.
+func paginationLinks(u *url.URL, limit, offset, total int32) string {
+	page := func(rel string, at int32) string {
+		query := u.Query()
+		query.Set("limit", strconv.Itoa(int(limit)))
+		query.Set("offset", strconv.Itoa(int(at)))
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, u.Path, query.Encode(), rel)
+	}
+
+	var links []string
+	if offset > 0 {
+		links = append(links, page("first", 0), page("prev", max(0, offset-limit)))
+	}
+	if offset+limit < total {
+		last := (total - 1) / limit * limit
+		links = append(links, page("next", offset+limit), page("last", last))
+	}
+	return strings.Join(links, ", ")
+}
+
+// listedURLs maps a page of protobuf URL messages to the JSON response
+// model, converting Unix timestamps to Go time values for consistent JSON
+// serialization.
+func listedURLs(grpcResp *pb.ListURLsResponse) models.ListURLsResponse {
 	urlsList := make([]models.URL, len(grpcResp.Urls))
 	for i, pbURL := range grpcResp.Urls {
-		var expiresAt *time.Time
-		if pbURL.ExpiresAt > 0 {
-			t := time.Unix(pbURL.ExpiresAt, 0)
-			expiresAt = &t
-		}
+		urlsList[i] = urlToModel(pbURL)
+	}
 
-		urlsList[i] = models.URL{
-			ShortCode: pbURL.ShortCode,
-			ShortURL:  pbURL.ShortUrl,
-			LongURL:   pbURL.LongUrl,
-			Clicks:    pbURL.Clicks,
-			CreatedAt: time.Unix(pbURL.CreatedAt, 0),
-			ExpiresAt: expiresAt,
-		}
+	return models.ListURLsResponse{
+		URLs:         urlsList,
+		Total:        grpcResp.Total,
+		HasMore:      grpcResp.HasMore,
+		TotalClicks:  grpcResp.TotalClicks,
+		ActiveCount:  grpcResp.ActiveCount,
+		ExpiredCount: grpcResp.ExpiredCount,
 	}
+}
 
-	res := models.ListURLsResponse{
-		URLs:    urlsList,
-		Total:   grpcResp.Total,
-		HasMore: grpcResp.HasMore,
+// urlToModel maps a protobuf URL message to the JSON response model, with
+// the fields a listing shows.
+func urlToModel(pbURL *pb.URL) models.URL {
+	var expiresAt *time.Time
+	if pbURL.ExpiresAt > 0 {
+		t := time.Unix(pbURL.ExpiresAt, 0)
+		expiresAt = &t
+	}
+	var activeFrom *time.Time
+	if pbURL.ActiveFrom > 0 {
+		t := time.Unix(pbURL.ActiveFrom, 0)
+		activeFrom = &t
 	}
 
-	respondJSON(w, http.StatusOK, res)
+	return models.URL{
+		ShortCode:   pbURL.ShortCode,
+		ShortURL:    pbURL.ShortUrl,
+		LongURL:     pbURL.LongUrl,
+		Clicks:      pbURL.Clicks,
+		MaxClicks:   pbURL.MaxClicks,
+		CreatedAt:   time.Unix(pbURL.CreatedAt, 0),
+		ActiveFrom:  activeFrom,
+		ExpiresAt:   expiresAt,
+		Tags:        pbURL.Tags,
+		Domain:      pbURL.Domain,
+		Preview:     pbURL.Preview,
+		BackupURL:   pbURL.BackupUrl,
+		Disabled:    pbURL.Disabled,
+		Title:       pbURL.Title,
+		Description: pbURL.Description,
+		ImageURL:    pbURL.ImageUrl,
+	}
+}
+
+// GetURL handles GET /api/urls/{code}, the full details of one of the
+// authenticated user's links for a detail or edit page: its destinations,
+// clicks, schedule, tags and QR code. It answers 404 if there is no such
+// live link, or 403 if it belongs to someone else; the URL service checks
+// ownership. A link on a custom domain is found without naming the domain.
+func (h *HTTPHandler) GetURL(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	grpcResp, err := h.grpcClient.GetURL(r.Context(), &pb.GetURLRequest{
+		ShortCode: r.PathValue("code"),
+		UserId:    userID,
+	})
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to get URL")
+		return
+	}
+	if !grpcResp.Found || grpcResp.Url == nil {
+		writeError(w, r, models.ErrCodeURLNotFound, http.StatusNotFound, "URL not found")
+		return
+	}
+
+	u := urlToModel(grpcResp.Url)
+	u.UserID = userID
+	u.Variants = variantsToModel(grpcResp.Url.Variants)
+	u.GeoRules = geoRulesToModel(grpcResp.Url.GeoRules)
+	u.QRCode = h.qrCodeValue(u.ShortCode, u.Domain, grpcResp.Url.QrCode)
+	respondJSON(w, http.StatusOK, u)
+}
+
+// DeleteURL handles DELETE /api/urls/{code}, removing one of the
+// authenticated user's links. It answers 204, 404 if there is no such link,
+// or 403 if it belongs to someone else; the URL service checks ownership.
+func (h *HTTPHandler) DeleteURL(w http.ResponseWriter, r *http.Request) {
+	grpcResp, err := h.grpcClient.DeleteURL(r.Context(), &pb.DeleteURLRequest{
+		ShortCode: r.PathValue("code"),
+		UserId:    middleware.GetUserID(r.Context()),
+	})
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to delete URL")
+		return
+	}
+	if !grpcResp.Success {
+		writeError(w, r, models.ErrCodeURLNotFound, http.StatusNotFound, "URL not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// BulkDeleteURLs handles POST /api/urls/bulk-delete, deleting many of the
+// authenticated user's links at once. It answers 200 with a result per
+// code even when some could not be deleted: codes of other users' links are
+// reported as forbidden and left alone, and unknown codes as not_found.
+func (h *HTTPHandler) BulkDeleteURLs(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	var req models.BulkDeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, models.ErrCodeInvalidJSON, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if len(req.ShortCodes) == 0 {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "short_codes is required")
+		return
+	}
+
+	grpcResp, err := h.grpcClient.DeleteURLs(r.Context(), &pb.DeleteURLsRequest{
+		UserId:     middleware.GetUserID(r.Context()),
+		ShortCodes: req.ShortCodes,
+	})
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to delete URLs")
+		return
+	}
+
+	resp := models.BulkDeleteResponse{Results: make([]models.BulkDeleteResult, len(grpcResp.Results))}
+	for i, result := range grpcResp.Results {
+		resp.Results[i] = models.BulkDeleteResult{ShortCode: result.ShortCode, Status: result.Status}
+		if result.Status == "deleted" {
+			resp.Deleted++
+		}
+	}
+	respondJSON(w, http.StatusOK, resp)
 }
 
 // respondJSON serializes data as JSON and writes it to the response with the
@@ -171,15 +421,12 @@ func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	_ = json.NewEncoder(w).Encode(data)
 }
 
-// respondError writes a standardized JSON error envelope. All handler error
-// paths use this so that API consumers see a uniform { "error", "message" }
-// shape regardless of which endpoint failed.
-func respondError(w http.ResponseWriter, status int, message string) {
-	errResp := models.ErrorResponse{
-		Error:   "error",
-		Message: message,
-	}
-	respondJSON(w, status, errResp)
+// writeError writes the JSON error envelope {code, message, request_id}
+// through middleware.WriteError, the writer the gateway's middleware uses
+// too, so API consumers see one shape whichever layer or endpoint failed.
+// code is one of the models.ErrCode* values.
+func writeError(w http.ResponseWriter, r *http.Request, code string, status int, message string) {
+	middleware.WriteError(w, r, code, status, message)
 }
 
 // CreateCustomURL handles POST requests to create a URL with a user-chosen
@@ -188,55 +435,44 @@ func respondError(w http.ResponseWriter, status int, message string) {
 // uniqueness, returning InvalidArgument or AlreadyExists errors that this
 // handler maps to the appropriate HTTP status codes.
 func (h *HTTPHandler) CreateCustomURL(w http.ResponseWriter, r *http.Request) {
-	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
 	var req models.CreateCustomURLRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid JSON")
-		return
-	}
-
-	if req.Alias == "" {
-		respondError(w, http.StatusBadRequest, "alias is required")
+	if err := decodeBody(w, r, &req); err != nil {
+		writeError(w, r, models.ErrCodeInvalidJSON, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
-	if req.LongURL == "" {
-		respondError(w, http.StatusBadRequest, "long_url is required")
-		return
-	}
-
-	if !isValidURL(req.LongURL) {
-		respondError(w, http.StatusBadRequest, "invalid URL format")
+	if res := validateCreateCustomURL(req); !res.Valid() {
+		writeValidationError(w, r, res)
 		return
 	}
 
 	userID := middleware.GetUserID(r.Context())
 
 	grpcReq := &pb.CreateCustomURLRequest{
-		Alias:   req.Alias,
-		LongUrl: req.LongURL,
-		UserId:  userID,
+		Alias:      req.Alias,
+		LongUrl:    req.LongURL,
+		UserId:     userID,
+		MaxClicks:  req.MaxClicks,
+		Tags:       req.Tags,
+		Domain:     req.Domain,
+		Preview:    req.Preview,
+		GenerateQr: req.GenerateQR,
+		BackupUrl:  req.BackupURL,
 	}
 
 	if req.ExpiresAt != nil {
 		grpcReq.ExpiresAt = req.ExpiresAt.Unix()
 	}
+	if req.ActiveFrom != nil {
+		grpcReq.ActiveFrom = req.ActiveFrom.Unix()
+	}
 
 	ctx := r.Context()
 	grpcResp, err := h.grpcClient.CreateCustomURL(ctx, grpcReq)
 	if err != nil {
-		// Map gRPC error codes to HTTP status codes. The gRPC service returns
-		// structured errors, but we match on message substrings because the
-		// Go gRPC client wraps them into generic status errors.
-		if strings.Contains(err.Error(), "invalid alias") || strings.Contains(err.Error(), "InvalidArgument") {
-			respondError(w, http.StatusBadRequest, err.Error())
-			return
-		}
-		if strings.Contains(err.Error(), "already taken") || strings.Contains(err.Error(), "AlreadyExists") {
-			respondError(w, http.StatusConflict, err.Error())
-			return
-		}
-		respondError(w, http.StatusInternalServerError, "failed to create custom URL")
+		// A taken alias comes back as AlreadyExists (409) with suggested
+		// alternatives attached.
+		respondGRPCError(w, r, err, "failed to create custom URL")
 		return
 	}
 
@@ -245,14 +481,24 @@ func (h *HTTPHandler) CreateCustomURL(w http.ResponseWriter, r *http.Request) {
 		t := time.Unix(grpcResp.ExpiresAt, 0)
 		expiresAt = &t
 	}
+	var activeFrom *time.Time
+	if grpcResp.ActiveFrom > 0 {
+		t := time.Unix(grpcResp.ActiveFrom, 0)
+		activeFrom = &t
+	}
 
 	res := models.CreateCustomURLResponse{
-		ShortCode: grpcResp.ShortCode,
-		ShortURL:  grpcResp.ShortUrl,
-		LongURL:   grpcResp.LongUrl,
-		CreatedAt: time.Unix(grpcResp.CreatedAt, 0),
-		ExpiresAt: expiresAt,
-		QRCode:    grpcResp.QrCode,
+		ShortCode:  grpcResp.ShortCode,
+		ShortURL:   grpcResp.ShortUrl,
+		LongURL:    grpcResp.LongUrl,
+		CreatedAt:  time.Unix(grpcResp.CreatedAt, 0),
+		ExpiresAt:  expiresAt,
+		MaxClicks:  grpcResp.MaxClicks,
+		ActiveFrom: activeFrom,
+		Tags:       grpcResp.Tags,
+		QRCode:     h.qrCodeValue(grpcResp.ShortCode, req.Domain, grpcResp.QrCode),
+		Preview:    grpcResp.Preview,
+		BackupURL:  grpcResp.BackupUrl,
 	}
 
 	respondJSON(w, http.StatusCreated, res)
@@ -268,13 +514,13 @@ func (h *HTTPHandler) CreateCustomURL(w http.ResponseWriter, r *http.Request) {
 func (h *HTTPHandler) SearchURLs(w http.ResponseWriter, r *http.Request) {
 	// Gracefully degrade when Elasticsearch is not wired up, rather than panicking.
 	if h.esClient == nil {
-		respondError(w, http.StatusServiceUnavailable, "search is not available")
+		writeError(w, r, models.ErrCodeUnavailable, http.StatusServiceUnavailable, "search is not available")
 		return
 	}
 
 	query := r.URL.Query().Get("q")
 	if query == "" {
-		respondError(w, http.StatusBadRequest, "query parameter 'q' is required")
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "query parameter 'q' is required")
 		return
 	}
 
@@ -294,7 +540,7 @@ func (h *HTTPHandler) SearchURLs(w http.ResponseWriter, r *http.Request) {
 
 	result, err := h.esClient.SearchURLs(r.Context(), query, limit, offset)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "search failed")
+		writeError(w, r, models.ErrCodeInternal, http.StatusInternalServerError, "search failed")
 		return
 	}
 
@@ -311,3 +557,37 @@ func isValidURL(str string) bool {
 	}
 	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
 }
+
+// variantsToModel converts a link's protobuf A/B variants into the JSON
+// response model. Single-destination links have none.
+func variantsToModel(variants []*pb.URLVariant) []models.URLVariant {
+	if len(variants) == 0 {
+		return nil
+	}
+	out := make([]models.URLVariant, len(variants))
+	for i, v := range variants {
+		out[i] = models.URLVariant{LongURL: v.LongUrl, Weight: v.Weight}
+	}
+	return out
+}
+
+// geoRulesToModel converts a link's protobuf geo rules into the JSON response
+// model.
+func geoRulesToModel(rules []*pb.GeoRule) []models.GeoRule {
+	if len(rules) == 0 {
+		return nil
+	}
+	out := make([]models.GeoRule, len(rules))
+	for i, rule := range rules {
+		out[i] = models.GeoRule{CountryCode: rule.CountryCode, LongURL: rule.LongUrl}
+	}
+	return out
+}
+
+// now returns the current time from h's clock.
+func (h *HTTPHandler) now() time.Time {
+	if h.clock == nil {
+		return time.Now()
+	}
+	return h.clock.Now()
+}
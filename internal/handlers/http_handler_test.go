@@ -0,0 +1,434 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/config"
+	grpcClient "github.com/Varun5711/shorternit/internal/grpc"
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// refusingClient refuses every CreateURL with err and reports every
+// DeleteURL as a missing link.
+type refusingClient struct {
+	pb.URLServiceClient
+	err error
+}
+
+func (c *refusingClient) CreateURL(ctx context.Context, in *pb.CreateURLRequest, opts ...grpc.CallOption) (*pb.CreateURLResponse, error) {
+	return nil, c.err
+}
+
+func (c *refusingClient) DeleteURL(ctx context.Context, in *pb.DeleteURLRequest, opts ...grpc.CallOption) (*pb.DeleteURLResponse, error) {
+	return &pb.DeleteURLResponse{Success: false}, nil
+}
+
+// decodeError decodes rec's body as the error envelope.
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) models.ErrorResponse {
+	t.Helper()
+	var body models.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode error body %q: %v", rec.Body, err)
+	}
+	return body
+}
+
+// TestHTTPHandler_ErrorCodes pins the status and error code of each way
+// creating or deleting a link can fail.
+func TestHTTPHandler_ErrorCodes(t *testing.T) {
+	quota, err := status.New(codes.ResourceExhausted, "link quota reached").WithDetails(&errdetails.ErrorInfo{Reason: models.ErrCodeQuotaExceeded})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &HTTPHandler{grpcClient: &refusingClient{err: quota.Err()}}
+
+	create := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.CreateURL(rec, httptest.NewRequest(http.MethodPost, "/api/urls", strings.NewReader(body)))
+		return rec
+	}
+	deleteURL := func(code string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/api/urls/"+code, nil)
+		req.SetPathValue("code", code)
+		rec := httptest.NewRecorder()
+		h.DeleteURL(rec, req)
+		return rec
+	}
+
+	for _, tc := range []struct {
+		name       string
+		rec        *httptest.ResponseRecorder
+		wantStatus int
+		wantCode   string
+	}{
+		{"invalid JSON", create(`{`), http.StatusBadRequest, models.ErrCodeInvalidJSON},
+		{"missing long_url", create(`{}`), http.StatusBadRequest, models.ErrCodeInvalidRequest},
+		{"invalid URL", create(`{"long_url":"ftp://example.com"}`), http.StatusBadRequest, models.ErrCodeInvalidURL},
+		{"over quota", create(`{"long_url":"https://example.com"}`), http.StatusTooManyRequests, models.ErrCodeQuotaExceeded},
+		{"missing link", deleteURL("nope"), http.StatusNotFound, models.ErrCodeURLNotFound},
+	} {
+		if tc.rec.Code != tc.wantStatus {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.wantStatus, tc.rec.Code)
+		}
+		body := decodeError(t, tc.rec)
+		if body.Code != tc.wantCode || body.Message == "" {
+			t.Errorf("%s: expected code %s with a message, got %+v", tc.name, tc.wantCode, body)
+		}
+		if body.RequestID == "" {
+			t.Errorf("%s: expected a request ID", tc.name)
+		}
+	}
+}
+
+// recordingClient creates every link it is asked to, remembering the last
+// request.
+type recordingClient struct {
+	pb.URLServiceClient
+	req *pb.CreateURLRequest
+}
+
+func (c *recordingClient) CreateURL(ctx context.Context, in *pb.CreateURLRequest, opts ...grpc.CallOption) (*pb.CreateURLResponse, error) {
+	c.req = in
+	return &pb.CreateURLResponse{ShortCode: "abc123", LongUrl: in.LongUrl, ExpiresAt: in.ExpiresAt}, nil
+}
+
+// TestCreateURL_AnonymousAndAuthenticated creates links through both routes
+// as a signed-in user: the authenticated one keeps the owner and the
+// requested expiry, the anonymous one drops the owner and caps the expiry.
+func TestCreateURL_AnonymousAndAuthenticated(t *testing.T) {
+	client := &recordingClient{}
+	h := &HTTPHandler{grpcClient: client, anonymousLinkTTL: 24 * time.Hour}
+	farOff := time.Now().Add(365 * 24 * time.Hour).UTC().Format(time.RFC3339)
+
+	create := func(handler http.HandlerFunc, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/urls", strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "u1"))
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	rec := create(h.CreateURL, `{"long_url":"https://example.com","expires_at":"`+farOff+`"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("authenticated: expected 201, got %d", rec.Code)
+	}
+	if client.req.UserId != "u1" || time.Until(time.Unix(client.req.ExpiresAt, 0)) < 300*24*time.Hour {
+		t.Errorf("authenticated: expected owner u1 and the requested expiry, got %q and %d", client.req.UserId, client.req.ExpiresAt)
+	}
+
+	for _, body := range []string{
+		`{"long_url":"https://example.com"}`,
+		`{"long_url":"https://example.com","expires_at":"` + farOff + `"}`,
+	} {
+		rec = create(h.CreateAnonymousURL, body)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("anonymous %s: expected 201, got %d", body, rec.Code)
+		}
+		if client.req.UserId != "" {
+			t.Errorf("anonymous %s: expected no owner, got %q", body, client.req.UserId)
+		}
+		if left := time.Until(time.Unix(client.req.ExpiresAt, 0)); left <= 0 || left > 24*time.Hour {
+			t.Errorf("anonymous %s: expected an expiry within a day, got %v", body, left)
+		}
+	}
+
+	rec = create(h.CreateAnonymousURL, `{"long_url":"https://example.com","domain":"go.example.com"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("anonymous with a domain: expected 400, got %d", rec.Code)
+	}
+}
+
+// pagingClient lists total links, answering each ListURLs with the page its
+// limit and offset select, and remembers the last request.
+type pagingClient struct {
+	pb.URLServiceClient
+	total int32
+	req   *pb.ListURLsRequest
+}
+
+func (c *pagingClient) ListURLs(ctx context.Context, in *pb.ListURLsRequest, opts ...grpc.CallOption) (*pb.ListURLsResponse, error) {
+	c.req = in
+	var urls []*pb.URL
+	for i := in.Offset; i < min(in.Offset+in.Limit, c.total); i++ {
+		urls = append(urls, &pb.URL{ShortCode: fmt.Sprintf("c%d", i)})
+	}
+	return &pb.ListURLsResponse{Urls: urls, Total: c.total, HasMore: in.Offset+in.Limit < c.total}, nil
+}
+
+// TestListURLs_ClampsPageParams verifies the limit and offset passed to the
+// URL service: defaults when absent, clamped when out of range, and a 400
+// when not a number.
+func TestListURLs_ClampsPageParams(t *testing.T) {
+	client := &pagingClient{total: 5}
+	h := &HTTPHandler{grpcClient: client}
+
+	for _, tc := range []struct {
+		query      string
+		wantStatus int
+		wantLimit  int32
+		wantOffset int32
+	}{
+		{"", http.StatusOK, defaultListLimit, 0},
+		{"?limit=2&offset=3", http.StatusOK, 2, 3},
+		{"?limit=0", http.StatusOK, 1, 0},
+		{"?limit=-5&offset=-1", http.StatusOK, 1, 0},
+		{"?limit=50000", http.StatusOK, maxListLimit, 0},
+		{"?limit=ten", http.StatusBadRequest, 0, 0},
+		{"?offset=1.5", http.StatusBadRequest, 0, 0},
+		{"?offset=99999999999", http.StatusBadRequest, 0, 0},
+	} {
+		client.req = nil
+		rec := httptest.NewRecorder()
+		h.ListURLs(rec, httptest.NewRequest(http.MethodGet, "/api/urls"+tc.query, nil))
+		if rec.Code != tc.wantStatus {
+			t.Errorf("%q: expected %d, got %d", tc.query, tc.wantStatus, rec.Code)
+			continue
+		}
+		if tc.wantStatus != http.StatusOK {
+			if client.req != nil {
+				t.Errorf("%q: expected the URL service not to be called", tc.query)
+			}
+			continue
+		}
+		if client.req.Limit != tc.wantLimit || client.req.Offset != tc.wantOffset {
+			t.Errorf("%q: expected limit %d offset %d, got %d and %d", tc.query, tc.wantLimit, tc.wantOffset, client.req.Limit, client.req.Offset)
+		}
+	}
+}
+
+// TestListURLs_PaginationHeaders verifies X-Total-Count and the Link header
+// on the first, a middle and the last page, and that other query
+// parameters survive into the links.
+func TestListURLs_PaginationHeaders(t *testing.T) {
+	h := &HTTPHandler{grpcClient: &pagingClient{total: 7}}
+
+	list := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ListURLs(rec, httptest.NewRequest(http.MethodGet, "/api/urls"+query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%q: expected 200, got %d", query, rec.Code)
+		}
+		if got := rec.Header().Get("X-Total-Count"); got != "7" {
+			t.Errorf("%q: expected X-Total-Count 7, got %q", query, got)
+		}
+		return rec
+	}
+
+	for _, tc := range []struct {
+		query    string
+		wantLink string
+		wantMore bool
+	}{
+		{"?limit=3", `</api/urls?limit=3&offset=3>; rel="next", </api/urls?limit=3&offset=6>; rel="last"`, true},
+		{"?limit=3&offset=3&tag=work", `</api/urls?limit=3&offset=0&tag=work>; rel="first", </api/urls?limit=3&offset=0&tag=work>; rel="prev", ` +
+			`</api/urls?limit=3&offset=6&tag=work>; rel="next", </api/urls?limit=3&offset=6&tag=work>; rel="last"`, true},
+		{"?limit=3&offset=6", `</api/urls?limit=3&offset=0>; rel="first", </api/urls?limit=3&offset=3>; rel="prev"`, false},
+		{"", "", false},
+	} {
+		rec := list(tc.query)
+		if got := rec.Header().Get("Link"); got != tc.wantLink {
+			t.Errorf("%q: expected Link\n%s\ngot\n%s", tc.query, tc.wantLink, got)
+		}
+		var body models.ListURLsResponse
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("%q: failed to decode body: %v", tc.query, err)
+		}
+		if body.HasMore != tc.wantMore || body.Total != 7 {
+			t.Errorf("%q: expected has_more=%v of 7, got %v of %d", tc.query, tc.wantMore, body.HasMore, body.Total)
+		}
+	}
+}
+
+// detailClient answers GetURL for "abc", owned by alice, the way the URL
+// service does for an owner lookup, and remembers the last request.
+type detailClient struct {
+	pb.URLServiceClient
+	last *pb.GetURLRequest
+}
+
+func (c *detailClient) GetURL(ctx context.Context, in *pb.GetURLRequest, opts ...grpc.CallOption) (*pb.GetURLResponse, error) {
+	c.last = in
+	switch {
+	case in.ShortCode != "abc":
+		return &pb.GetURLResponse{Found: false}, nil
+	case in.UserId != "alice":
+		return nil, status.Error(codes.PermissionDenied, "you do not own this short code")
+	}
+	return &pb.GetURLResponse{Found: true, Url: &pb.URL{
+		ShortCode: "abc",
+		ShortUrl:  "http://tiny.test/abc",
+		LongUrl:   "https://example.com",
+		Clicks:    42,
+		CreatedAt: 1700000000,
+		ExpiresAt: 1800000000,
+		Tags:      []string{"promo"},
+		Variants:  []*pb.URLVariant{{LongUrl: "https://a.example", Weight: 1}, {LongUrl: "https://b.example", Weight: 1}},
+		QrCode:    "data:image/png;base64,iVBORw0KGgo=",
+	}}, nil
+}
+
+// TestGetURL_Detail pins the detail endpoint's answers for the owner,
+// another user and an unknown code.
+func TestGetURL_Detail(t *testing.T) {
+	client := &detailClient{}
+	h := &HTTPHandler{grpcClient: client}
+
+	get := func(code, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/urls/"+code, nil)
+		req.SetPathValue("code", code)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, userID))
+		rec := httptest.NewRecorder()
+		h.GetURL(rec, req)
+		return rec
+	}
+
+	rec := get("abc", "alice")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if client.last.UserId != "alice" || client.last.Domain != "" {
+		t.Errorf("expected an owner lookup by alice, got %+v", client.last)
+	}
+	var got models.URL
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got.LongURL != "https://example.com" || got.Clicks != 42 || got.UserID != "alice" ||
+		got.ExpiresAt == nil || got.ExpiresAt.Unix() != 1800000000 || len(got.Tags) != 1 || len(got.Variants) != 2 ||
+		got.QRCode != "data:image/png;base64,iVBORw0KGgo=" {
+		t.Errorf("unexpected detail %+v", got)
+	}
+
+	rec = get("abc", "bob")
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for another user's link, got %d", rec.Code)
+	}
+	rec = get("nope", "alice")
+	if rec.Code != http.StatusNotFound || decodeError(t, rec).Code != models.ErrCodeURLNotFound {
+		t.Errorf("expected 404 URL_NOT_FOUND for an unknown code, got %d", rec.Code)
+	}
+}
+
+// bulkDeleteClient answers DeleteURLs as if the caller owned only the
+// codes starting with "mine", and remembers the last request.
+type bulkDeleteClient struct {
+	pb.URLServiceClient
+	last *pb.DeleteURLsRequest
+}
+
+func (c *bulkDeleteClient) DeleteURLs(ctx context.Context, in *pb.DeleteURLsRequest, opts ...grpc.CallOption) (*pb.DeleteURLsResponse, error) {
+	c.last = in
+	resp := &pb.DeleteURLsResponse{}
+	for _, code := range in.ShortCodes {
+		result := &pb.DeleteURLsResult{ShortCode: code, Status: "forbidden"}
+		if strings.HasPrefix(code, "mine") {
+			result.Status = "deleted"
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return resp, nil
+}
+
+// TestBulkDeleteURLs verifies that the gateway passes the caller and codes
+// through, reports each code's status and counts the deleted ones.
+func TestBulkDeleteURLs(t *testing.T) {
+	client := &bulkDeleteClient{}
+	h := &HTTPHandler{grpcClient: client}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/urls/bulk-delete", strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "alice"))
+		rec := httptest.NewRecorder()
+		h.BulkDeleteURLs(rec, req)
+		return rec
+	}
+
+	rec := post(`{"short_codes":["mine1","theirs","mine2"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if client.last.UserId != "alice" || len(client.last.ShortCodes) != 3 {
+		t.Errorf("expected alice's three codes forwarded, got %+v", client.last)
+	}
+	var got models.BulkDeleteResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got.Deleted != 2 || len(got.Results) != 3 || got.Results[1].ShortCode != "theirs" || got.Results[1].Status != "forbidden" {
+		t.Errorf("unexpected response %+v", got)
+	}
+
+	rec = post(`{"short_codes":[]}`)
+	if rec.Code != http.StatusBadRequest || decodeError(t, rec).Code != models.ErrCodeInvalidRequest {
+		t.Errorf("expected 400 INVALID_REQUEST without codes, got %d", rec.Code)
+	}
+}
+
+// slowURLService is a url-service whose GetURL hangs until the call's
+// context ends, reporting the deadline it was given.
+type slowURLService struct {
+	pb.UnimplementedURLServiceServer
+	deadline chan bool
+}
+
+func (s *slowURLService) GetURL(ctx context.Context, req *pb.GetURLRequest) (*pb.GetURLResponse, error) {
+	_, ok := ctx.Deadline()
+	s.deadline <- ok
+	<-ctx.Done()
+	return nil, status.FromContextError(ctx.Err()).Err()
+}
+
+// TestGetURL_SlowBackendTimesOut verifies that a gateway call to a hung
+// url-service gets the default deadline, which reaches the server, and is
+// answered with 504 once it passes.
+func TestGetURL_SlowBackendTimesOut(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	backend := &slowURLService{deadline: make(chan bool, 1)}
+	srv := grpc.NewServer()
+	pb.RegisterURLServiceServer(srv, backend)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	client, err := grpcClient.NewURLServiceClient(lis.Addr().String(), config.GRPCConfig{
+		DialTimeout: 2 * time.Second,
+		CallTimeout: 100 * time.Millisecond,
+	}, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := &HTTPHandler{grpcClient: client}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/urls/abc", nil)
+	req.SetPathValue("code", "abc")
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "alice"))
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	h.GetURL(rec, req)
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected the call cut off at its deadline, took %s", elapsed)
+	}
+	if rec.Code != http.StatusGatewayTimeout || decodeError(t, rec).Code != models.ErrCodeTimeout {
+		t.Errorf("expected 504 TIMEOUT, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !<-backend.deadline {
+		t.Error("expected the deadline to reach the url-service")
+	}
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/Varun5711/shorternit/internal/shorturl"
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+const (
+	// maxImportRows caps the data rows accepted by one import request.
+	maxImportRows = 1000
+	// maxImportBytes caps the uploaded CSV size.
+	maxImportBytes = 5 << 20
+	// importBatchSize is the number of rows sent per BatchCreateURLs call; it
+	// matches the URL service's per-call limit.
+	importBatchSize = 500
+)
+
+// importColumns holds where each recognised column sits in a CSV row; -1
+// marks a column the file does not have.
+type importColumns struct {
+	longURL, alias, expiresAt, tags int
+}
+
+// positionalImportColumns is the layout of a file without a header row:
+// long_url[,alias][,expires_at][,tags].
+var positionalImportColumns = importColumns{longURL: 0, alias: 1, expiresAt: 2, tags: 3}
+
+// ImportURLs handles POST /api/urls/import. The request body is a CSV file
+// with the columns long_url[,alias][,expires_at][,tags] (expires_at in RFC
+// 3339, tags separated by ";"). A header row naming a long_url column is
+// optional; when present, columns are matched by name instead of position
+// and unknown ones are ignored, with short_code accepted in place of alias.
+// That makes a CSV export re-importable as is: it recreates the links under
+// the same codes, with their expiry and tags.
+//
+// The body is parsed one record at a time, so memory is bounded by the row
+// cap rather than by the upload. Rows are validated and deduplicated here,
+// then created in batches through the URL service. Each row gets its own
+// result, so one bad row does not fail the import: the response is 201 when
+// every row was created and 207 Multi-Status otherwise.
+func (h *HTTPHandler) ImportURLs(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+
+	reader := csv.NewReader(r.Body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	var results []models.ImportRowResult
+	var items []*pb.BatchCreateURLItem
+	var itemRows []int // index into results for each item
+
+	// firstRow remembers where each long URL (without alias) or alias was
+	// first seen, so repeats within the file are reported instead of created.
+	firstRow := make(map[string]int)
+	dataRows := 0
+	cols := positionalImportColumns
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				writeError(w, r, models.ErrCodePayloadTooLarge, http.StatusRequestEntityTooLarge, fmt.Sprintf("import file must be at most %d bytes", maxImportBytes))
+				return
+			}
+			writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "invalid CSV: "+err.Error())
+			return
+		}
+
+		line, _ := reader.FieldPos(0)
+		if dataRows == 0 {
+			if header, ok := importColumnsFromHeader(record); ok {
+				cols = header
+				continue
+			}
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue // blank line
+		}
+
+		dataRows++
+		if dataRows > maxImportRows {
+			writeError(w, r, models.ErrCodePayloadTooLarge, http.StatusRequestEntityTooLarge, fmt.Sprintf("import is limited to %d rows", maxImportRows))
+			return
+		}
+
+		longURL := importCell(record, cols.longURL)
+		alias := importCell(record, cols.alias)
+		res := models.ImportRowResult{Row: line, LongURL: longURL, Alias: alias}
+
+		item, rowErr := parseImportRow(longURL, alias, importCell(record, cols.expiresAt), importCell(record, cols.tags), h.now())
+		if rowErr == "" {
+			key := "url:" + longURL
+			if alias != "" {
+				key = "alias:" + alias
+			}
+			if prev, ok := firstRow[key]; ok {
+				if alias != "" {
+					rowErr = fmt.Sprintf("alias '%s' already used on row %d", alias, prev)
+				} else {
+					rowErr = fmt.Sprintf("duplicate of row %d", prev)
+				}
+			} else {
+				firstRow[key] = line
+			}
+		}
+
+		if rowErr != "" {
+			res.Error = rowErr
+		} else {
+			items = append(items, item)
+			itemRows = append(itemRows, len(results))
+		}
+		results = append(results, res)
+	}
+
+	if len(results) == 0 {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "no rows to import")
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	for start := 0; start < len(items); start += importBatchSize {
+		end := start + importBatchSize
+		if end > len(items) {
+			end = len(items)
+		}
+
+		grpcResp, err := h.grpcClient.BatchCreateURLs(r.Context(), &pb.BatchCreateURLsRequest{
+			UserId: userID,
+			Items:  items[start:end],
+		})
+		for j := start; j < end; j++ {
+			res := &results[itemRows[j]]
+			switch {
+			case err != nil:
+				res.Error = "failed to create URL"
+			case j-start >= len(grpcResp.Results):
+				// A short response would otherwise index out of range; the
+				// rows without a result cannot be assumed created.
+				res.Error = "no result returned for this row"
+			case grpcResp.Results[j-start].Error != "":
+				res.Error = grpcResp.Results[j-start].Error
+			default:
+				res.ShortCode = grpcResp.Results[j-start].ShortCode
+				res.ShortURL = grpcResp.Results[j-start].ShortUrl
+				if res.ShortURL == "" {
+					res.ShortURL = shorturl.BuildShortURL(h.baseURL, res.ShortCode)
+				}
+			}
+		}
+	}
+
+	resp := models.ImportURLsResponse{Results: results}
+	for _, res := range results {
+		if res.Error != "" {
+			resp.Failed++
+		} else {
+			resp.Created++
+		}
+	}
+
+	code := http.StatusCreated
+	if resp.Failed > 0 {
+		code = http.StatusMultiStatus
+	}
+	respondJSON(w, code, resp)
+}
+
+// importColumnsFromHeader reports whether record is a header row, i.e. names
+// a long_url column, and if so where each recognised column is.
+func importColumnsFromHeader(record []string) (importColumns, bool) {
+	cols := importColumns{longURL: -1, alias: -1, expiresAt: -1, tags: -1}
+	shortCode := -1
+	for i, name := range record {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "long_url":
+			cols.longURL = i
+		case "alias":
+			cols.alias = i
+		case "short_code":
+			shortCode = i
+		case "expires_at":
+			cols.expiresAt = i
+		case "tags":
+			cols.tags = i
+		}
+	}
+	if cols.longURL < 0 {
+		return cols, false
+	}
+	if cols.alias < 0 {
+		cols.alias = shortCode
+	}
+	return cols, true
+}
+
+// importCell returns the trimmed value of column col, or "" when the column
+// is absent or the row is short. A quote added by csvSafeCell on export is
+// removed again.
+func importCell(record []string, col int) string {
+	if col < 0 || col >= len(record) {
+		return ""
+	}
+	v := strings.TrimSpace(record[col])
+	if len(v) > 1 && v[0] == '\'' && strings.ContainsRune(csvFormulaPrefixes, rune(v[1])) {
+		return v[1:]
+	}
+	return v
+}
+
+// parseImportRow validates one CSV row and converts it to a batch item. It
+// returns a user-facing error message instead of an error value because the
+// message goes straight into that row's result. An expiry must be after now.
+func parseImportRow(longURL, alias, expires, tags string, now time.Time) (*pb.BatchCreateURLItem, string) {
+	if longURL == "" {
+		return nil, "long_url is required"
+	}
+	if !isValidURL(longURL) {
+		return nil, "invalid URL format"
+	}
+
+	item := &pb.BatchCreateURLItem{LongUrl: longURL, Alias: alias}
+	for _, tag := range strings.Split(tags, ";") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			item.Tags = append(item.Tags, tag)
+		}
+	}
+	if expires != "" {
+		t, err := time.Parse(time.RFC3339, expires)
+		if err != nil {
+			return nil, "expires_at must be an RFC 3339 timestamp"
+		}
+		if !t.After(now) {
+			return nil, "expires_at must be in the future"
+		}
+		item.ExpiresAt = t.Unix()
+	}
+	return item, ""
+}
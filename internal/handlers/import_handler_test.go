@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc"
+)
+
+// batchCreateClient records BatchCreateURLs calls and rejects one alias as
+// already taken. truncate drops that many results from the end of each
+// response to simulate a misbehaving service.
+type batchCreateClient struct {
+	pb.URLServiceClient
+	taken    string
+	truncate int
+	items    int
+	received []*pb.BatchCreateURLItem
+}
+
+func (c *batchCreateClient) BatchCreateURLs(ctx context.Context, in *pb.BatchCreateURLsRequest, opts ...grpc.CallOption) (*pb.BatchCreateURLsResponse, error) {
+	resp := &pb.BatchCreateURLsResponse{}
+	defer func() { resp.Results = resp.Results[:len(resp.Results)-c.truncate] }()
+	for _, item := range in.Items {
+		c.items++
+		c.received = append(c.received, item)
+		code := item.Alias
+		if code == "" {
+			code = "gen" + string(rune('a'+c.items))
+		}
+		if code == c.taken {
+			resp.Results = append(resp.Results, &pb.BatchCreateURLResult{Error: "alias 'taken' is already taken"})
+			continue
+		}
+		resp.Results = append(resp.Results, &pb.BatchCreateURLResult{ShortCode: code})
+	}
+	return resp, nil
+}
+
+func importCSV(h *HTTPHandler, body string) (*httptest.ResponseRecorder, models.ImportURLsResponse) {
+	rec := httptest.NewRecorder()
+	h.ImportURLs(rec, httptest.NewRequest(http.MethodPost, "/api/urls/import", strings.NewReader(body)))
+
+	var resp models.ImportURLsResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec, resp
+}
+
+// TestImportURLs_AllCreated verifies a clean file is created in full with 201
+// and that the optional header row is skipped.
+func TestImportURLs_AllCreated(t *testing.T) {
+	client := &batchCreateClient{}
+	h := &HTTPHandler{grpcClient: client, baseURL: "http://sho.rt"}
+
+	rec, resp := importCSV(h, "long_url,alias,expires_at\nhttps://example.com/a,brand\nhttps://example.com/b\n")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if resp.Created != 2 || resp.Failed != 0 {
+		t.Fatalf("expected 2 created, got %+v", resp)
+	}
+	if resp.Results[0].Row != 2 || resp.Results[0].ShortURL != "http://sho.rt/brand" {
+		t.Errorf("unexpected first result: %+v", resp.Results[0])
+	}
+}
+
+// TestImportURLs_PartialFailure checks that bad, duplicate and taken rows are
+// reported individually with 207 while the valid rows are still created.
+func TestImportURLs_PartialFailure(t *testing.T) {
+	client := &batchCreateClient{taken: "taken"}
+	h := &HTTPHandler{grpcClient: client, baseURL: "http://sho.rt"}
+
+	body := strings.Join([]string{
+		"https://example.com/ok",
+		"not-a-url",
+		"https://example.com/ok",
+		"https://example.com/x,taken",
+		"https://example.com/y,brand,yesterday",
+	}, "\n")
+
+	rec, resp := importCSV(h, body)
+	if rec.Code != http.StatusMultiStatus {
+		t.Fatalf("expected 207, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if resp.Created != 1 || resp.Failed != 4 {
+		t.Fatalf("expected 1 created and 4 failed, got %+v", resp)
+	}
+	if !strings.Contains(resp.Results[2].Error, "duplicate of row 1") {
+		t.Errorf("expected duplicate error on row 3, got %q", resp.Results[2].Error)
+	}
+	if client.items != 2 {
+		t.Errorf("expected only the 2 valid rows sent to the service, got %d", client.items)
+	}
+}
+
+// TestImportURLs_RowCap ensures files over the row limit are rejected before
+// anything is created.
+func TestImportURLs_RowCap(t *testing.T) {
+	client := &batchCreateClient{}
+	h := &HTTPHandler{grpcClient: client, baseURL: "http://sho.rt"}
+
+	body := strings.Repeat("https://example.com/\n", maxImportRows+1)
+	rec, _ := importCSV(h, body)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d", rec.Code)
+	}
+	if client.items != 0 {
+		t.Errorf("expected no rows created, got %d", client.items)
+	}
+}
+
+// TestImportURLs_Empty verifies an empty upload is a 400.
+func TestImportURLs_Empty(t *testing.T) {
+	h := &HTTPHandler{grpcClient: &batchCreateClient{}}
+
+	if rec, _ := importCSV(h, "long_url\n"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rec.Code)
+	}
+}
+
+// TestImportURLs_ExportHeader verifies that a CSV export imports as is:
+// columns are matched by name, short_code becomes the alias, tags are split
+// and a formula-escaped value is unquoted.
+func TestImportURLs_ExportHeader(t *testing.T) {
+	client := &batchCreateClient{}
+	h := &HTTPHandler{grpcClient: client, baseURL: "http://sho.rt"}
+
+	body := strings.Join([]string{
+		"short_code,short_url,long_url,clicks,created_at,expires_at,tags",
+		"brand,http://old.host/brand,https://example.com/a,42,2025-01-01T12:00:00Z,2999-01-01T00:00:00Z,work;q3",
+		"'-dash,http://old.host/-dash,https://example.com/b,0,2025-01-01T12:00:00Z,,",
+	}, "\n")
+
+	rec, resp := importCSV(h, body)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if resp.Created != 2 || len(client.received) != 2 {
+		t.Fatalf("expected 2 rows created, got %+v", resp)
+	}
+
+	first, second := client.received[0], client.received[1]
+	if first.Alias != "brand" || first.LongUrl != "https://example.com/a" || first.ExpiresAt == 0 {
+		t.Errorf("unexpected first item: %+v", first)
+	}
+	if strings.Join(first.Tags, ",") != "work,q3" {
+		t.Errorf("expected tags [work q3], got %v", first.Tags)
+	}
+	if second.Alias != "-dash" || len(second.Tags) != 0 {
+		t.Errorf("expected unescaped alias -dash without tags, got %+v", second)
+	}
+}
+
+// TestImportURLs_ShortServiceResponse ensures rows the service returned no
+// result for are reported as failed rather than panicking or counting as
+// created.
+func TestImportURLs_ShortServiceResponse(t *testing.T) {
+	client := &batchCreateClient{truncate: 1}
+	h := &HTTPHandler{grpcClient: client, baseURL: "http://sho.rt"}
+
+	rec, resp := importCSV(h, "https://example.com/a\nhttps://example.com/b\n")
+	if rec.Code != http.StatusMultiStatus {
+		t.Fatalf("expected 207, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if resp.Created != 1 || resp.Failed != 1 || resp.Results[1].Error == "" {
+		t.Errorf("expected the second row to fail, got %+v", resp)
+	}
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/Varun5711/shorternit/internal/qrcode"
+	"github.com/Varun5711/shorternit/internal/shorturl"
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+// GetQRCode handles GET /api/urls/{code}/qr.png (also served at
+// /api/urls/{code}/qr), serving a link's QR code as a PNG. Links on a custom
+// domain are looked up with ?domain=<domain>.
+//
+// An image rendered at creation is served from wherever it was kept: decoded
+// from its inline data URI, or from the object store, with a redirect when
+// the store is publicly reachable. Any other link's image is rendered on its
+// first request and kept in qrCache, so later requests are served from there.
+func (h *HTTPHandler) GetQRCode(w http.ResponseWriter, r *http.Request) {
+	shortCode := r.PathValue("code")
+	if shortCode == "" {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "short code is required")
+		return
+	}
+	domain := r.URL.Query().Get("domain")
+
+	resp, err := h.grpcClient.GetURL(r.Context(), &pb.GetURLRequest{
+		ShortCode: shortCode,
+		Domain:    domain,
+	})
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to get QR code")
+		return
+	}
+	if !resp.Found || resp.Url == nil {
+		writeError(w, r, models.ErrCodeURLNotFound, http.StatusNotFound, "URL not found")
+		return
+	}
+
+	stored := resp.Url.QrCode
+	switch {
+	case qrcode.IsDataURI(stored):
+		png, err := qrcode.DecodeDataURI(stored)
+		if err == nil {
+			writePNG(w, r, png)
+			return
+		}
+	case stored != "" && h.qrStore != nil:
+		if location := h.qrStore.URL(stored); location != "" {
+			http.Redirect(w, r, location, http.StatusFound)
+			return
+		}
+		png, err := h.qrStore.Get(r.Context(), stored)
+		if err == nil {
+			writePNG(w, r, png)
+			return
+		}
+		if !errors.Is(err, qrcode.ErrNotFound) {
+			writeError(w, r, models.ErrCodeBadGateway, http.StatusBadGateway, "failed to fetch QR code")
+			return
+		}
+	}
+
+	// The cache is best-effort: when it cannot be read or written the image
+	// is simply rendered again.
+	key := qrcode.RenderedKey(domain, shortCode)
+	if h.qrCache != nil {
+		if png, err := h.qrCache.Get(r.Context(), key); err == nil {
+			if location := h.qrCache.URL(key); location != "" {
+				http.Redirect(w, r, location, http.StatusFound)
+				return
+			}
+			writePNG(w, r, png)
+			return
+		}
+	}
+
+	png, err := qrcode.GeneratePNG(h.shortURL(resp.Url))
+	if err != nil {
+		writeError(w, r, models.ErrCodeInternal, http.StatusInternalServerError, "failed to generate QR code")
+		return
+	}
+	if h.qrCache != nil {
+		_ = h.qrCache.Put(r.Context(), key, png)
+	}
+	writePNG(w, r, png)
+}
+
+// RegenerateQRCode handles POST /api/urls/{code}/qr/regenerate, rendering
+// the QR code of one of the authenticated user's links again with the size
+// and colors in the optional body. The url-service stores the new image in
+// place of the old one; the copy rendered on demand is dropped from qrCache
+// too, so GET /api/urls/{code}/qr.png serves the new image with a new ETag.
+// It answers with the new image's address, as qr_code is given elsewhere.
+func (h *HTTPHandler) RegenerateQRCode(w http.ResponseWriter, r *http.Request) {
+	shortCode := r.PathValue("code")
+	if shortCode == "" {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "short code is required")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	var req models.RegenerateQRRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, r, models.ErrCodeInvalidJSON, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if req.Size < 0 || req.Size > qrcode.MaxSize {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "size is out of range")
+		return
+	}
+
+	resp, err := h.grpcClient.RegenerateQR(r.Context(), &pb.RegenerateQRRequest{
+		ShortCode:  shortCode,
+		UserId:     middleware.GetUserID(r.Context()),
+		Size:       int32(req.Size),
+		Foreground: req.Foreground,
+		Background: req.Background,
+	})
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to regenerate QR code")
+		return
+	}
+	if h.qrCache != nil {
+		_ = h.qrCache.Delete(r.Context(), qrcode.RenderedKey(resp.Domain, resp.ShortCode))
+	}
+
+	respondJSON(w, http.StatusOK, models.RegenerateQRResponse{
+		ShortCode: resp.ShortCode,
+		QRCode:    h.qrCodeValue(resp.ShortCode, resp.Domain, resp.QrCode),
+	})
+}
+
+// qrCodeValue returns the qr_code field for a response about shortCode: an
+// inline data URI as stored, the object store address of an uploaded image
+// when it is publicly reachable, or else the QR code endpoint, which also
+// renders images that were not generated at creation.
+func (h *HTTPHandler) qrCodeValue(shortCode, domain, stored string) string {
+	if qrcode.IsDataURI(stored) {
+		return stored
+	}
+	if stored != "" && h.qrStore != nil {
+		if location := h.qrStore.URL(stored); location != "" {
+			return location
+		}
+	}
+	path := "/api/urls/" + url.PathEscape(shortCode) + "/qr.png"
+	if domain != "" {
+		path += "?domain=" + url.QueryEscape(domain)
+	}
+	return path
+}
+
+// shortURL returns the short URL a QR code encodes, matching the one the URL
+// service generated it from.
+func (h *HTTPHandler) shortURL(u *pb.URL) string {
+	if u.ShortUrl != "" {
+		return u.ShortUrl
+	}
+	return shorturl.BuildDomainShortURL(h.baseURL, u.Domain, u.ShortCode)
+}
+
+// writePNG serves png. Its ETag is a hash of the content, and a request
+// whose If-None-Match names it gets 304 without the image. Since the image
+// changes when its owner regenerates it, caches must revalidate it on every
+// use, which the ETag keeps down to an empty 304.
+func writePNG(w http.ResponseWriter, r *http.Request, png []byte) {
+	etag := pngETag(png)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(png)
+}
+
+// pngETag returns the strong ETag of an image: the first 128 bits of its
+// SHA-256, quoted.
+func pngETag(png []byte) string {
+	sum := sha256.Sum256(png)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header names etag. As RFC
+// 9110 prescribes for If-None-Match, weak tags compare equal to their strong
+// counterparts, and "*" matches any.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
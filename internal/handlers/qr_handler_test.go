@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/Varun5711/shorternit/internal/qrcode"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// serveQRCode requests the QR code of code from h.
+func serveQRCode(h *HTTPHandler, code string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/urls/{code}/qr.png", h.GetQRCode)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/urls/"+code+"/qr.png", nil))
+	return rec
+}
+
+// newQRHandler returns an HTTPHandler over client and the given stores.
+func newQRHandler(client *fakeURLClient, qrStore, qrCache qrcode.Store) *HTTPHandler {
+	return &HTTPHandler{grpcClient: client, qrStore: qrStore, qrCache: qrCache, baseURL: "https://tiny.io"}
+}
+
+// TestGetQRCode_Sources verifies that the image is served from wherever it is
+// kept, and regenerated when it is missing.
+func TestGetQRCode_Sources(t *testing.T) {
+	png, err := qrcode.GeneratePNG("https://tiny.io/abc")
+	if err != nil {
+		t.Fatalf("GeneratePNG failed: %v", err)
+	}
+	store, err := qrcode.NewLocalStore(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	if err := store.Put(context.Background(), "abc/1.png", []byte("stored image")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		stored string
+		want   []byte
+	}{
+		{"inline data URI", qrcode.EncodeDataURI([]byte("inline image")), []byte("inline image")},
+		{"object store", "abc/1.png", []byte("stored image")},
+		{"missing object", "abc/2.png", png},
+		{"no QR code", "", png},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &fakeURLClient{urls: map[string]*pb.URL{
+				"abc": {ShortCode: "abc", LongUrl: "https://example.com", QrCode: tc.stored},
+			}}
+			rec := serveQRCode(newQRHandler(client, store, nil), "abc")
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "image/png" {
+				t.Errorf("expected image/png, got %q", ct)
+			}
+			if !bytes.Equal(rec.Body.Bytes(), tc.want) {
+				t.Errorf("unexpected image body %.40q", rec.Body.Bytes())
+			}
+		})
+	}
+}
+
+// countingStore wraps a Store and counts the images written to it.
+type countingStore struct {
+	qrcode.Store
+	puts int
+}
+
+func (s *countingStore) Put(ctx context.Context, key string, png []byte) error {
+	s.puts++
+	return s.Store.Put(ctx, key, png)
+}
+
+// TestGetQRCode_RendersOnceThenCaches verifies that a QR code not rendered at
+// creation is generated on its first request and served from the cache on
+// the next.
+func TestGetQRCode_RendersOnceThenCaches(t *testing.T) {
+	local, err := qrcode.NewLocalStore(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	cache := &countingStore{Store: local}
+	client := &fakeURLClient{urls: map[string]*pb.URL{"abc": {ShortCode: "abc"}}}
+	h := newQRHandler(client, nil, cache)
+
+	first := serveQRCode(h, "abc")
+	if first.Code != http.StatusOK || cache.puts != 1 {
+		t.Fatalf("expected the first request to render and cache the image, got %d with %d writes", first.Code, cache.puts)
+	}
+	cached, err := local.Get(context.Background(), qrcode.RenderedKey("", "abc"))
+	if err != nil {
+		t.Fatalf("expected the image under its rendered key, got %v", err)
+	}
+
+	second := serveQRCode(h, "abc")
+	if second.Code != http.StatusOK || cache.puts != 1 {
+		t.Fatalf("expected the second request to be served from the cache, got %d with %d writes", second.Code, cache.puts)
+	}
+	if !bytes.Equal(second.Body.Bytes(), cached) || !bytes.Equal(first.Body.Bytes(), cached) {
+		t.Error("expected both responses to carry the cached image")
+	}
+}
+
+// TestGetQRCode_RedirectsToPublicStore verifies that an image in a publicly
+// reachable store is answered with a redirect rather than proxied.
+func TestGetQRCode_RedirectsToPublicStore(t *testing.T) {
+	store, err := qrcode.NewLocalStore(t.TempDir(), "https://cdn.example.com/qr")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	client := &fakeURLClient{urls: map[string]*pb.URL{
+		"abc": {ShortCode: "abc", QrCode: "abc/1.png"},
+	}}
+
+	rec := serveQRCode(newQRHandler(client, store, nil), "abc")
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected 302, got %d", rec.Code)
+	}
+	if loc := rec.Header().Get("Location"); loc != "https://cdn.example.com/qr/abc/1.png" {
+		t.Errorf("unexpected Location %q", loc)
+	}
+}
+
+// TestGetQRCode_NotFound verifies that an unknown code is a 404.
+func TestGetQRCode_NotFound(t *testing.T) {
+	rec := serveQRCode(newQRHandler(&fakeURLClient{urls: map[string]*pb.URL{}}, nil, nil), "nope")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+}
+
+// TestQRCodeValue verifies the qr_code value returned to clients for each
+// way an image can be kept.
+func TestQRCodeValue(t *testing.T) {
+	public, err := qrcode.NewLocalStore(t.TempDir(), "https://cdn.example.com/qr")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	private, err := qrcode.NewLocalStore(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	inline := qrcode.EncodeDataURI([]byte("image"))
+
+	cases := []struct {
+		name   string
+		store  qrcode.Store
+		domain string
+		stored string
+		want   string
+	}{
+		{"inline", public, "", inline, inline},
+		{"not rendered", public, "", "", "/api/urls/abc/qr.png"},
+		{"public store", public, "", "abc/1.png", "https://cdn.example.com/qr/abc/1.png"},
+		{"private store", private, "", "abc/1.png", "/api/urls/abc/qr.png"},
+		{"custom domain", private, "go.acme.com", "abc/1.png", "/api/urls/abc/qr.png?domain=go.acme.com"},
+	}
+	for _, tc := range cases {
+		h := &HTTPHandler{qrStore: tc.store}
+		if got := h.qrCodeValue("abc", tc.domain, tc.stored); got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
+
+// TestGetQRCode_ConditionalGet verifies that the image carries a content
+// ETag and must be revalidated, and that a request naming the ETag gets 304.
+func TestGetQRCode_ConditionalGet(t *testing.T) {
+	client := &fakeURLClient{urls: map[string]*pb.URL{
+		"abc": {ShortCode: "abc", QrCode: qrcode.EncodeDataURI([]byte("inline image"))},
+	}}
+	h := newQRHandler(client, nil, nil)
+
+	first := serveQRCode(h, "abc")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected 200 with an ETag, got %d %q", first.Code, etag)
+	}
+	if etag != pngETag([]byte("inline image")) {
+		t.Errorf("expected the ETag to be derived from the image, got %q", etag)
+	}
+	if cc := first.Header().Get("Cache-Control"); cc != "public, no-cache" {
+		t.Errorf("unexpected Cache-Control %q", cc)
+	}
+
+	for _, tc := range []struct {
+		ifNoneMatch string
+		want        int
+	}{
+		{etag, http.StatusNotModified},
+		{"W/" + etag, http.StatusNotModified},
+		{`"other", ` + etag, http.StatusNotModified},
+		{"*", http.StatusNotModified},
+		{`"other"`, http.StatusOK},
+	} {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /api/urls/{code}/qr.png", h.GetQRCode)
+		req := httptest.NewRequest(http.MethodGet, "/api/urls/abc/qr.png", nil)
+		req.Header.Set("If-None-Match", tc.ifNoneMatch)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		if rec.Code != tc.want {
+			t.Errorf("If-None-Match %s: expected %d, got %d", tc.ifNoneMatch, tc.want, rec.Code)
+		}
+		if tc.want == http.StatusNotModified && rec.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: expected no body with 304, got %d bytes", tc.ifNoneMatch, rec.Body.Len())
+		}
+		if rec.Header().Get("ETag") != etag {
+			t.Errorf("If-None-Match %s: expected the ETag on every response", tc.ifNoneMatch)
+		}
+	}
+}
+
+// regeneratingClient answers RegenerateQR like the url-service, rendering
+// the image inline with the options requested, for links owned by alice.
+type regeneratingClient struct {
+	*fakeURLClient
+}
+
+func (c regeneratingClient) RegenerateQR(ctx context.Context, in *pb.RegenerateQRRequest, opts ...grpc.CallOption) (*pb.RegenerateQRResponse, error) {
+	u, ok := c.urls[in.ShortCode]
+	if !ok || in.UserId != "alice" {
+		return nil, status.Error(codes.NotFound, "URL not found")
+	}
+	png, err := qrcode.GeneratePNGWithOptions("https://tiny.io/"+u.ShortCode, qrcode.Options{
+		Size: int(in.Size), Foreground: in.Foreground, Background: in.Background,
+	})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	u.QrCode = qrcode.EncodeDataURI(png)
+	return &pb.RegenerateQRResponse{ShortCode: u.ShortCode, QrCode: u.QrCode}, nil
+}
+
+// TestRegenerateQRCode_ChangesImageAndETag verifies that regenerating with
+// new options replaces the image rendered on demand, so the next request
+// gets a different image under a new ETag, even when revalidating the old
+// one.
+func TestRegenerateQRCode_ChangesImageAndETag(t *testing.T) {
+	cache, err := qrcode.NewLocalStore(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	client := regeneratingClient{&fakeURLClient{urls: map[string]*pb.URL{"abc": {ShortCode: "abc"}}}}
+	h := &HTTPHandler{grpcClient: client, qrCache: cache, baseURL: "https://tiny.io"}
+
+	before := serveQRCode(h, "abc")
+	oldETag := before.Header().Get("ETag")
+	if before.Code != http.StatusOK || oldETag == "" {
+		t.Fatalf("expected the rendered image, got %d", before.Code)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/urls/{code}/qr/regenerate", h.RegenerateQRCode)
+	req := httptest.NewRequest(http.MethodPost, "/api/urls/abc/qr/regenerate", strings.NewReader(`{"size":512,"foreground":"#336699"}`))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "alice"))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp models.RegenerateQRResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.ShortCode != "abc" || !qrcode.IsDataURI(resp.QRCode) {
+		t.Fatalf("expected the new inline image, got %+v (%v)", resp, err)
+	}
+	if _, err := cache.Get(context.Background(), qrcode.RenderedKey("", "abc")); !errors.Is(err, qrcode.ErrNotFound) {
+		t.Errorf("expected the rendered copy dropped, got %v", err)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/urls/abc/qr.png", nil)
+	req.SetPathValue("code", "abc")
+	req.Header.Set("If-None-Match", oldETag)
+	after := httptest.NewRecorder()
+	h.GetQRCode(after, req)
+	if after.Code != http.StatusOK || after.Header().Get("ETag") == oldETag {
+		t.Fatalf("expected a new image under a new ETag, got %d %q", after.Code, after.Header().Get("ETag"))
+	}
+	if bytes.Equal(after.Body.Bytes(), before.Body.Bytes()) {
+		t.Error("expected the new options to change the image")
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/api/urls/abc/qr/regenerate", strings.NewReader(`{"size":5000}`))
+	mux.ServeHTTP(rec, req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "alice")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an oversized image, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+// ReactivateURL handles POST /api/urls/{code}/reactivate, bringing back one
+// of the authenticated user's links after it expired or was deleted. The
+// body is optional. It answers 404 if the user had no such link, 422 if it
+// has not expired, and 409 ALIAS_RECLAIMED, with suggestions, if someone
+// else has claimed its short code since.
+func (h *HTTPHandler) ReactivateURL(w http.ResponseWriter, r *http.Request) {
+	shortCode := r.PathValue("code")
+	if shortCode == "" {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "short code is required")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	var req models.ReactivateURLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, r, models.ErrCodeInvalidJSON, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	grpcReq := &pb.ReactivateURLRequest{
+		ShortCode: shortCode,
+		UserId:    middleware.GetUserID(r.Context()),
+	}
+	if req.ExpiresAt != nil {
+		grpcReq.ExpiresAt = req.ExpiresAt.Unix()
+	}
+
+	grpcResp, err := h.grpcClient.ReactivateURL(r.Context(), grpcReq)
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to reactivate URL")
+		return
+	}
+
+	var expiresAt *time.Time
+	if grpcResp.ExpiresAt > 0 {
+		t := time.Unix(grpcResp.ExpiresAt, 0)
+		expiresAt = &t
+	}
+	respondJSON(w, http.StatusOK, models.ReactivateURLResponse{
+		ShortCode: grpcResp.ShortCode,
+		ShortURL:  grpcResp.ShortUrl,
+		LongURL:   grpcResp.LongUrl,
+		ExpiresAt: expiresAt,
+	})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// reactivateClient brings back "mine" and reports "launch" as reclaimed,
+// remembering the last request.
+type reactivateClient struct {
+	pb.URLServiceClient
+	last *pb.ReactivateURLRequest
+}
+
+func (c *reactivateClient) ReactivateURL(ctx context.Context, in *pb.ReactivateURLRequest, opts ...grpc.CallOption) (*pb.ReactivateURLResponse, error) {
+	c.last = in
+	if in.ShortCode == "launch" {
+		st, _ := status.New(codes.AlreadyExists, "alias 'launch' has been claimed by another user since your link ended").WithDetails(&errdetails.ErrorInfo{
+			Reason:   models.ErrCodeAliasReclaimed,
+			Metadata: map[string]string{"suggestions": "launch-1,launch-2"},
+		})
+		return nil, st.Err()
+	}
+	return &pb.ReactivateURLResponse{ShortCode: in.ShortCode, ShortUrl: "https://tiny.link/" + in.ShortCode, LongUrl: "https://example.com", ExpiresAt: in.ExpiresAt}, nil
+}
+
+func postReactivate(h *HTTPHandler, code, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/urls/"+code+"/reactivate", strings.NewReader(body))
+	req.SetPathValue("code", code)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "alice"))
+	rec := httptest.NewRecorder()
+	h.ReactivateURL(rec, req)
+	return rec
+}
+
+func TestReactivateURL(t *testing.T) {
+	client := &reactivateClient{}
+	h := &HTTPHandler{grpcClient: client}
+
+	rec := postReactivate(h, "mine", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 without a body, got %d: %s", rec.Code, rec.Body)
+	}
+	var resp models.ReactivateURLResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.ShortURL != "https://tiny.link/mine" || resp.ExpiresAt != nil {
+		t.Errorf("expected the link back without an expiry, got %+v", resp)
+	}
+	if client.last.UserId != "alice" || client.last.ExpiresAt != 0 {
+		t.Errorf("expected alice's request with the default expiry, got %+v", client.last)
+	}
+
+	if rec := postReactivate(h, "mine", `{"expires_at":"2030-01-01T00:00:00Z"}`); rec.Code != http.StatusOK || client.last.ExpiresAt != 1893456000 {
+		t.Errorf("expected expires_at to be forwarded, got %d and %d", rec.Code, client.last.ExpiresAt)
+	}
+	if rec := postReactivate(h, "mine", "not json"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid JSON, got %d", rec.Code)
+	}
+
+	rec = postReactivate(h, "launch", "")
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", rec.Code)
+	}
+	body := decodeError(t, rec)
+	if body.Code != models.ErrCodeAliasReclaimed || len(body.Suggestions) != 2 {
+		t.Errorf("expected %s with suggestions, got %+v", models.ErrCodeAliasReclaimed, body)
+	}
+}
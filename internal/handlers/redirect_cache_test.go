@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clock/clocktest"
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+// TestHandleRedirect_CachingHeaders verifies that plain links may be cached
+// privately until their expiry, and links that change per click may not.
+func TestHandleRedirect_CachingHeaders(t *testing.T) {
+	soon := time.Now().Add(90 * time.Second).Unix()
+	h, _ := newLimitTestHandler(map[string]*pb.URL{
+		"plain":  {ShortCode: "plain", LongUrl: "https://example.com"},
+		"soon":   {ShortCode: "soon", LongUrl: "https://example.com", ExpiresAt: soon},
+		"capped": {ShortCode: "capped", LongUrl: "https://example.com", MaxClicks: 100},
+		"split":  {ShortCode: "split", LongUrl: "https://a.example.com", Variants: []*pb.URLVariant{{LongUrl: "https://a.example.com", Weight: 1}, {LongUrl: "https://b.example.com", Weight: 1}}},
+		"geo":    {ShortCode: "geo", LongUrl: "https://example.com", GeoRules: []*pb.GeoRule{{CountryCode: "DE", LongUrl: "https://example.de"}}},
+	})
+	h.maxAge = 5 * time.Minute
+
+	for _, tc := range []struct {
+		code     string
+		want     string
+		expiring bool
+	}{
+		{"plain", "private, max-age=300", true},
+		{"capped", "no-store", false},
+		{"split", "no-store", false},
+		{"geo", "no-store", false},
+	} {
+		rec := get(h, "/"+tc.code)
+		if rec.Code != http.StatusFound {
+			t.Fatalf("%s: expected 302, got %d", tc.code, rec.Code)
+		}
+		if cc := rec.Header().Get("Cache-Control"); cc != tc.want {
+			t.Errorf("%s: expected Cache-Control %q, got %q", tc.code, tc.want, cc)
+		}
+		if got := rec.Header().Get("Expires") != ""; got != tc.expiring {
+			t.Errorf("%s: expected an Expires header: %v, got %q", tc.code, tc.expiring, rec.Header().Get("Expires"))
+		}
+	}
+
+	// A link expiring before maxAge is up is cached only until it expires.
+	rec := get(h, "/soon")
+	var maxAge int
+	if _, err := fmt.Sscanf(rec.Header().Get("Cache-Control"), "private, max-age=%d", &maxAge); err != nil || maxAge <= 0 || maxAge > 90 {
+		t.Errorf("expected max-age capped at the link's expiry, got %q", rec.Header().Get("Cache-Control"))
+	}
+	expires, err := http.ParseTime(rec.Header().Get("Expires"))
+	if err != nil || expires.After(time.Unix(soon, 0)) {
+		t.Errorf("expected Expires no later than the link's expiry, got %q", rec.Header().Get("Expires"))
+	}
+
+	h.maxAge = 0
+	if cc := get(h, "/plain").Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("expected no caching with a zero max age, got %q", cc)
+	}
+}
+
+// TestHandleRedirect_CachedEntryExpires verifies that a link cached before
+// its expiry redirects up to the last second before it and stops at its
+// expiry time, without asking the URL service.
+func TestHandleRedirect_CachedEntryExpires(t *testing.T) {
+	clk := clocktest.NewFake(time.Now().Truncate(time.Second))
+	expiresAt := clk.Now().Add(time.Hour)
+	client := &fakeURLClient{urls: map[string]*pb.URL{
+		"abc": {ShortCode: "abc", LongUrl: "https://example.com", ExpiresAt: expiresAt.Unix()},
+	}}
+	h, _ := newLimitTestHandler(nil)
+	h.grpcClient = client
+	h.clock = clk
+	if code := redirect(h, "abc"); code != http.StatusFound {
+		t.Fatalf("expected 302, got %d", code)
+	}
+
+	clk.Set(expiresAt.Add(-time.Second))
+	if code := redirect(h, "abc"); code != http.StatusFound {
+		t.Fatalf("expected 302 a second before expiry, got %d", code)
+	}
+	clk.Set(expiresAt)
+	if code := redirect(h, "abc"); code != http.StatusGone {
+		t.Errorf("expected 410 for an expired cached link, got %d", code)
+	}
+	if client.calls != 1 {
+		t.Errorf("expected only the first redirect to ask the URL service, got %d calls", client.calls)
+	}
+}
+
+// TestHandleRedirect_DisabledLink verifies that a link its owner disables
+// stops redirecting at once, from a cached entry as from the URL service,
+// and redirects again once enabled.
+func TestHandleRedirect_DisabledLink(t *testing.T) {
+	urls := map[string]*pb.URL{
+		"abc": {ShortCode: "abc", LongUrl: "https://example.com"},
+		"off": {ShortCode: "off", LongUrl: "https://example.com", Disabled: true},
+	}
+	h, _ := newLimitTestHandler(urls)
+	client := h.grpcClient.(*fakeURLClient)
+
+	if code := redirect(h, "abc"); code != http.StatusFound {
+		t.Fatalf("expected 302, got %d", code)
+	}
+	// SetURLActive rewrites the cached entry as disabled.
+	entry, ok := h.cache.GetURL(t.Context(), "url:abc")
+	if !ok {
+		t.Fatal("expected the link to be cached")
+	}
+	entry.Disabled = true
+	_ = h.cache.SetURL(t.Context(), "url:abc", *entry)
+
+	calls := client.calls
+	if code := redirect(h, "abc"); code != http.StatusGone {
+		t.Errorf("expected 410 for a disabled cached link, got %d", code)
+	}
+	if client.calls != calls {
+		t.Error("expected the disabled cached link answered without the URL service")
+	}
+
+	// A disabled link from the URL service is refused and cached as such.
+	if code := redirect(h, "off"); code != http.StatusGone {
+		t.Errorf("expected 410 for a disabled link, got %d", code)
+	}
+	calls = client.calls
+	if code := redirect(h, "off"); code != http.StatusGone || client.calls != calls {
+		t.Errorf("expected a cached 410 for a disabled link, got %d after %d calls", code, client.calls-calls)
+	}
+
+	entry, _ = h.cache.GetURL(t.Context(), "url:off")
+	entry.Disabled = false
+	_ = h.cache.SetURL(t.Context(), "url:off", *entry)
+	if code := redirect(h, "off"); code != http.StatusFound {
+		t.Errorf("expected 302 once enabled again, got %d", code)
+	}
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/clickdedup"
+	"github.com/Varun5711/shorternit/internal/events"
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+// memoryDeduper mimics clickdedup.Gate with a set of open windows that never
+// close, or fails every check while err is set.
+type memoryDeduper struct {
+	mu   sync.Mutex
+	seen map[string]bool
+	err  error
+}
+
+func (d *memoryDeduper) First(ctx context.Context, shortCode, ip, userAgent string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err != nil {
+		return false, d.err
+	}
+	key := clickdedup.Key(shortCode, ip, userAgent)
+	if d.seen[key] {
+		return false, nil
+	}
+	d.seen[key] = true
+	return true, nil
+}
+
+// recordingPublisher keeps the click events published.
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []*events.ClickEvent
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event *events.ClickEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+func newDedupTestHandler(keepRepeats bool) (*RedirectHandler, *memoryDeduper, *recordingPublisher) {
+	h, _ := newLimitTestHandler(map[string]*pb.URL{
+		"abc": {ShortCode: "abc", LongUrl: "https://example.com"},
+	})
+	dedup := &memoryDeduper{seen: make(map[string]bool)}
+	published := &recordingPublisher{}
+	h.dedup = dedup
+	h.keepRepeats = keepRepeats
+	h.clickProducer = published
+	return h, dedup, published
+}
+
+// clickAs redirects abc for the visitor with the given User-Agent.
+func clickAs(h *RedirectHandler, userAgent string) int {
+	req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+	req.Header.Set("User-Agent", userAgent)
+	rec := httptest.NewRecorder()
+	h.HandleRedirect(rec, req)
+	return rec.Code
+}
+
+// TestHandleRedirect_RapidRepeatsCountedOnce simulates a visitor clicking a
+// link three times in quick succession: every click redirects, but only the
+// first is published, while another browser on the same IP counts on its
+// own.
+func TestHandleRedirect_RapidRepeatsCountedOnce(t *testing.T) {
+	h, _, published := newDedupTestHandler(false)
+
+	for i := 0; i < 3; i++ {
+		if code := clickAs(h, "Firefox"); code != http.StatusFound {
+			t.Fatalf("click %d: expected 302, got %d", i+1, code)
+		}
+	}
+	if code := clickAs(h, "Chrome"); code != http.StatusFound {
+		t.Fatalf("expected 302, got %d", code)
+	}
+
+	if len(published.events) != 2 {
+		t.Errorf("expected 2 click events, got %d", len(published.events))
+	}
+}
+
+// TestHandleRedirect_RawModeFlagsRepeats verifies that with keepRepeats every
+// click is published and the repeats are flagged.
+func TestHandleRedirect_RawModeFlagsRepeats(t *testing.T) {
+	h, _, published := newDedupTestHandler(true)
+
+	for i := 0; i < 3; i++ {
+		clickAs(h, "Firefox")
+	}
+
+	if len(published.events) != 3 {
+		t.Fatalf("expected every click published in raw mode, got %d", len(published.events))
+	}
+	for i, ev := range published.events {
+		if want := i > 0; ev.Duplicate != want {
+			t.Errorf("click %d: expected Duplicate=%v, got %v", i+1, want, ev.Duplicate)
+		}
+	}
+}
+
+// TestHandleRedirect_DedupFailsOpen verifies that clicks are still counted
+// when the dedup gate cannot be reached.
+func TestHandleRedirect_DedupFailsOpen(t *testing.T) {
+	h, dedup, published := newDedupTestHandler(false)
+	dedup.err = errors.New("redis unavailable")
+
+	for i := 0; i < 2; i++ {
+		clickAs(h, "Firefox")
+	}
+
+	if len(published.events) != 2 {
+		t.Errorf("expected both clicks published while the gate is down, got %d", len(published.events))
+	}
+}
+
+// memoryTally mimics clickdelta.Counter's pending click counts.
+type memoryTally struct {
+	mu      sync.Mutex
+	pending map[string]int
+}
+
+func (m *memoryTally) Incr(ctx context.Context, shortCode string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pending[shortCode]++
+	return nil
+}
+
+func (m *memoryTally) Settle(ctx context.Context, flushed map[string]int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for code, n := range flushed {
+		m.pending[code] -= n
+	}
+	return nil
+}
+
+// TestHandleRedirect_TalliesPublishedClicks verifies that every published
+// click raises the link's pending count, repeats that are dropped do not,
+// and a click whose event cannot be published is taken back out.
+func TestHandleRedirect_TalliesPublishedClicks(t *testing.T) {
+	h, _, _ := newDedupTestHandler(false)
+	tally := &memoryTally{pending: make(map[string]int)}
+	h.clickDeltas = tally
+
+	clickAs(h, "Firefox")
+	clickAs(h, "Firefox")
+	clickAs(h, "Chrome")
+	if tally.pending["abc"] != 2 {
+		t.Errorf("expected 2 pending clicks, got %d", tally.pending["abc"])
+	}
+
+	unpublished, _ := newLimitTestHandler(map[string]*pb.URL{
+		"abc": {ShortCode: "abc", LongUrl: "https://example.com"},
+	})
+	unpublished.clickDeltas = tally
+	redirect(unpublished, "abc")
+	if tally.pending["abc"] != 2 {
+		t.Errorf("expected an unpublished click not to stay counted, got %d", tally.pending["abc"])
+	}
+}
+
+// TestHandleRedirect_CappedRepeatsKeepTheCap replays repeat clicks on a
+// capped link, lets the worker flush the published ones into the database
+// count, expires the Redis counter and checks that the reseeded counter
+// still stops the link at its cap.
+func TestHandleRedirect_CappedRepeatsKeepTheCap(t *testing.T) {
+	h, counter := newLimitTestHandler(map[string]*pb.URL{
+		"abc": {ShortCode: "abc", LongUrl: "https://example.com", MaxClicks: 3},
+	})
+	h.dedup = &memoryDeduper{seen: make(map[string]bool)}
+	published := &recordingPublisher{}
+	h.clickProducer = published
+
+	for i := 0; i < 2; i++ {
+		if code := clickAs(h, "Firefox"); code != http.StatusFound {
+			t.Fatalf("click %d: expected 302, got %d", i+1, code)
+		}
+	}
+	if len(published.events) != 2 || !published.events[1].Duplicate {
+		t.Fatalf("expected the repeat published flagged as a duplicate, got %d events", len(published.events))
+	}
+
+	// The analytics-worker counts every published click, then the counter
+	// expires and is reseeded from that count.
+	h.grpcClient.(*fakeURLClient).urls["abc"].Clicks = int64(len(published.events))
+	delete(counter.counts, "abc")
+
+	if code := clickAs(h, "Firefox"); code != http.StatusFound {
+		t.Fatalf("third click: expected 302, got %d", code)
+	}
+	if code := clickAs(h, "Firefox"); code != http.StatusGone {
+		t.Errorf("click past the cap after reseeding: expected 410, got %d", code)
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/cache"
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+func redirectOnHost(h *RedirectHandler, host, code string) int {
+	req := httptest.NewRequest(http.MethodGet, "/"+code, nil)
+	req.Host = host
+	rec := httptest.NewRecorder()
+	h.HandleRedirect(rec, req)
+	return rec.Code
+}
+
+// TestHandleRedirect_ScopedByHost verifies that a custom-domain link only
+// redirects on its own domain and a default link only on the default host,
+// through both the gRPC path and the cache fast path.
+func TestHandleRedirect_ScopedByHost(t *testing.T) {
+	h, _ := newLimitTestHandler(map[string]*pb.URL{
+		"brand": {ShortCode: "brand", LongUrl: "https://example.com", Domain: "go.acme.com"},
+		"plain": {ShortCode: "plain", LongUrl: "https://example.org"},
+	})
+	h.defaultHost = "tiny.link"
+
+	cases := []struct {
+		host string
+		code string
+		want int
+	}{
+		{"go.acme.com", "brand", http.StatusFound},
+		{"GO.ACME.COM:443", "brand", http.StatusFound},
+		{"tiny.link", "brand", http.StatusNotFound},
+		{"evil.com", "brand", http.StatusNotFound},
+		{"tiny.link", "plain", http.StatusFound},
+		{"localhost:8081", "plain", http.StatusFound},
+		{"127.0.0.1:8081", "plain", http.StatusFound},
+		{"go.acme.com", "plain", http.StatusNotFound},
+	}
+	// Run twice: the first pass fills the cache, the second is served from it.
+	for pass := 0; pass < 2; pass++ {
+		for _, tc := range cases {
+			if got := redirectOnHost(h, tc.host, tc.code); got != tc.want {
+				t.Errorf("pass %d: %s on %s: expected %d, got %d", pass, tc.code, tc.host, tc.want, got)
+			}
+		}
+	}
+}
+
+// TestHandleRedirect_CachedEntryWrongDomain ensures a cached entry for a
+// custom-domain link is not served on the default host.
+func TestHandleRedirect_CachedEntryWrongDomain(t *testing.T) {
+	client := &fakeURLClient{}
+	h, _ := newLimitTestHandler(nil)
+	h.grpcClient = client
+	h.defaultHost = "tiny.link"
+
+	_ = h.cache.SetURL(context.Background(), "url:brand", cache.URLEntry{
+		LongURL: "https://example.com",
+		Domain:  "go.acme.com",
+	})
+
+	if code := redirectOnHost(h, "tiny.link", "brand"); code != http.StatusNotFound {
+		t.Errorf("expected 404 on the default host, got %d", code)
+	}
+	if code := redirectOnHost(h, "go.acme.com", "brand"); code != http.StatusFound {
+		t.Errorf("expected 302 on the custom domain, got %d", code)
+	}
+	if client.calls != 0 {
+		t.Errorf("expected the cache to answer without gRPC, got %d calls", client.calls)
+	}
+}
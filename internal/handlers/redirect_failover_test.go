@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+// stubHealth is a DestinationHealth whose answers tests flip; it records
+// the primaries asked about.
+type stubHealth struct {
+	mu    sync.Mutex
+	down  map[string]bool
+	err   error
+	asked []string
+}
+
+func (s *stubHealth) Down(ctx context.Context, primary string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.asked = append(s.asked, primary)
+	return s.down[primary], s.err
+}
+
+func (s *stubHealth) set(primary string, down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down[primary] = down
+}
+
+func newFailoverTestHandler() (*RedirectHandler, *stubHealth, *recordingPublisher) {
+	h, _ := newLimitTestHandler(map[string]*pb.URL{
+		"crit":  {ShortCode: "crit", LongUrl: "https://primary.example", BackupUrl: "https://backup.example"},
+		"plain": {ShortCode: "plain", LongUrl: "https://primary.example"},
+	})
+	health := &stubHealth{down: make(map[string]bool)}
+	published := &recordingPublisher{}
+	h.health = health
+	h.clickProducer = published
+	return h, health, published
+}
+
+// followFailover redirects code and returns the Location it was sent to.
+func followFailover(t *testing.T, h *RedirectHandler, code string) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.HandleRedirect(rec, httptest.NewRequest(http.MethodGet, "/"+code, nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("%s: expected 302, got %d", code, rec.Code)
+	}
+	if cc := rec.Header().Get("Cache-Control"); code == "crit" && cc != "no-store" {
+		t.Errorf("expected a failover link never to be cached, got %q", cc)
+	}
+	return rec.Header().Get("Location")
+}
+
+// TestHandleRedirect_FailoverTransitions verifies that a link follows its
+// primary's health: the primary while up, the backup while down, and the
+// primary again once it recovers, with each click recording the target.
+func TestHandleRedirect_FailoverTransitions(t *testing.T) {
+	h, health, published := newFailoverTestHandler()
+
+	steps := []struct {
+		down     bool
+		want     string
+		failover bool
+	}{
+		{false, "https://primary.example", false},
+		{true, "https://backup.example", true},
+		{true, "https://backup.example", true},
+		{false, "https://primary.example", false},
+	}
+	for i, step := range steps {
+		health.set("https://primary.example", step.down)
+		if got := followFailover(t, h, "crit"); got != step.want {
+			t.Errorf("step %d: expected redirect to %s, got %s", i, step.want, got)
+		}
+	}
+
+	if len(published.events) != len(steps) {
+		t.Fatalf("expected %d click events, got %d", len(steps), len(published.events))
+	}
+	for i, ev := range published.events {
+		if ev.Failover != steps[i].failover || ev.OriginalURL != steps[i].want {
+			t.Errorf("click %d: expected failover=%v to %s, got failover=%v to %s",
+				i, steps[i].failover, steps[i].want, ev.Failover, ev.OriginalURL)
+		}
+	}
+}
+
+// TestHandleRedirect_FailoverOnlyForBackupLinks verifies that links without
+// a backup never consult the health state, even when their destination is
+// flagged down.
+func TestHandleRedirect_FailoverOnlyForBackupLinks(t *testing.T) {
+	h, health, _ := newFailoverTestHandler()
+	health.set("https://primary.example", true)
+
+	if got := followFailover(t, h, "plain"); got != "https://primary.example" {
+		t.Errorf("expected redirect to the primary, got %s", got)
+	}
+	if len(health.asked) != 0 {
+		t.Errorf("expected no health lookup, got %v", health.asked)
+	}
+}
+
+// TestHandleRedirect_FailoverFailsOpen verifies that an unreadable health
+// state, or none at all, redirects to the primary.
+func TestHandleRedirect_FailoverFailsOpen(t *testing.T) {
+	h, health, _ := newFailoverTestHandler()
+	health.set("https://primary.example", true)
+	health.err = errors.New("redis unavailable")
+
+	if got := followFailover(t, h, "crit"); got != "https://primary.example" {
+		t.Errorf("expected redirect to the primary on a lookup error, got %s", got)
+	}
+
+	h.health = nil
+	if got := followFailover(t, h, "crit"); got != "https://primary.example" {
+		t.Errorf("expected redirect to the primary without failover, got %s", got)
+	}
+}
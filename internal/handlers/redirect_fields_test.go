@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/enrichment"
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+// TestHandleRedirect_PublishesOnlyRecordedFields verifies that a click
+// event leaves out what no recorded analytics field needs, while keeping
+// the address for geo and the User-Agent for the device.
+func TestHandleRedirect_PublishesOnlyRecordedFields(t *testing.T) {
+	for _, tc := range []struct {
+		fields                             []string
+		wantIP, wantUA, wantRef, wantQuery bool
+	}{
+		{enrichment.AllFields, true, true, true, true},
+		{[]string{enrichment.FieldGeo, enrichment.FieldDevice}, true, true, false, false},
+		{[]string{enrichment.FieldReferer, enrichment.FieldQuery}, false, false, true, true},
+		{[]string{enrichment.FieldsNone}, false, false, false, false},
+	} {
+		h, _ := newLimitTestHandler(map[string]*pb.URL{
+			"abc": {ShortCode: "abc", LongUrl: "https://example.com"},
+		})
+		published := &recordingPublisher{}
+		h.clickProducer = published
+		var err error
+		if h.fields, err = enrichment.ParseFields(tc.fields); err != nil {
+			t.Fatal(err)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/abc?utm_source=mail", nil)
+		req.Header.Set("User-Agent", "Firefox")
+		req.Header.Set("Referer", "https://news.example")
+		rec := httptest.NewRecorder()
+		h.HandleRedirect(rec, req)
+		if rec.Code != http.StatusFound || len(published.events) != 1 {
+			t.Fatalf("%v: expected a published redirect, got %d with %d events", tc.fields, rec.Code, len(published.events))
+		}
+
+		ev := published.events[0]
+		if (ev.IP != "") != tc.wantIP || (ev.UserAgent != "") != tc.wantUA ||
+			(ev.Referer != "") != tc.wantRef || (ev.QueryParams != "") != tc.wantQuery {
+			t.Errorf("%v: unexpected event %+v", tc.fields, ev)
+		}
+		if ev.ShortCode != "abc" || ev.OriginalURL != "https://example.com" {
+			t.Errorf("%v: expected the link always recorded, got %+v", tc.fields, ev)
+		}
+	}
+}
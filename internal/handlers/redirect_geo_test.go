@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/cache"
+	"github.com/Varun5711/shorternit/internal/enrichment"
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+// staticGeo is a CountryLookup that places each known IP in a fixed country
+// and every other IP in the enricher's "XX" unknown country.
+type staticGeo map[string]string
+
+func (g staticGeo) Lookup(ipAddress string) *enrichment.GeoInfo {
+	if code, ok := g[ipAddress]; ok {
+		return &enrichment.GeoInfo{CountryCode: code}
+	}
+	return &enrichment.GeoInfo{CountryCode: "XX"}
+}
+
+var testGeo = staticGeo{
+	"203.0.113.7":  "DE",
+	"198.51.100.9": "US",
+}
+
+// TestHandleRedirect_GeoRules verifies that a visitor from a country with a
+// geo rule is sent to that rule's destination and everyone else to the
+// default, both on the gRPC path and from cache.
+func TestHandleRedirect_GeoRules(t *testing.T) {
+	h, _ := newLimitTestHandler(map[string]*pb.URL{
+		"geo": {
+			ShortCode: "geo",
+			LongUrl:   "https://example.com",
+			GeoRules:  []*pb.GeoRule{{CountryCode: "DE", LongUrl: "https://example.com/gdpr"}},
+		},
+	})
+	h.geo = testGeo
+
+	cases := []struct {
+		ip   string
+		want string
+	}{
+		{"203.0.113.7", "https://example.com/gdpr"},
+		{"198.51.100.9", "https://example.com"},
+		{"192.0.2.1", "https://example.com"},
+		{"203.0.113.7", "https://example.com/gdpr"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/geo", nil)
+		req.Header.Set("X-Forwarded-For", tc.ip)
+		rec := httptest.NewRecorder()
+		h.HandleRedirect(rec, req)
+
+		if rec.Code != http.StatusFound {
+			t.Fatalf("%s: expected 302, got %d", tc.ip, rec.Code)
+		}
+		if got := rec.Header().Get("Location"); got != tc.want {
+			t.Errorf("%s: expected redirect to %s, got %s", tc.ip, tc.want, got)
+		}
+	}
+}
+
+// TestChooseDestination_GeoRule verifies the matched rule reported for the
+// click event, that a geo rule takes precedence over an A/B split, and that
+// geo rules are ignored without a country lookup.
+func TestChooseDestination_GeoRule(t *testing.T) {
+	h := &RedirectHandler{geo: testGeo}
+	entry := cache.URLEntry{
+		LongURL: "https://a.example",
+		Variants: []cache.URLVariant{
+			{LongURL: "https://a.example", Weight: 1},
+			{LongURL: "https://b.example", Weight: 1},
+		},
+		GeoRules: map[string]string{"DE": "https://de.example"},
+	}
+
+	longURL, variant, geoRule := h.chooseDestination(entry, "203.0.113.7")
+	if longURL != "https://de.example" || variant != 0 || geoRule != "DE" {
+		t.Errorf("expected the DE rule to win, got (%s, %d, %q)", longURL, variant, geoRule)
+	}
+
+	longURL, variant, geoRule = h.chooseDestination(entry, "198.51.100.9")
+	if geoRule != "" || variant == 0 || longURL != entry.Variants[variant-1].LongURL {
+		t.Errorf("expected an unmatched visitor to get a variant, got (%s, %d, %q)", longURL, variant, geoRule)
+	}
+
+	h.geo = nil
+	if _, _, geoRule := h.chooseDestination(entry, "203.0.113.7"); geoRule != "" {
+		t.Errorf("expected no geo rule without a lookup, got %q", geoRule)
+	}
+}
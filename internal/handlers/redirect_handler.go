@@ -1,15 +1,28 @@
 package handlers
 
 import (
+	"context"
+	"math/rand/v2"
 	"net"
 	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/Varun5711/shorternit/internal/cache"
+	"github.com/Varun5711/shorternit/internal/clicklimit"
+	"github.com/Varun5711/shorternit/internal/clock"
+	"github.com/Varun5711/shorternit/internal/config"
+	"github.com/Varun5711/shorternit/internal/enrichment"
 	"github.com/Varun5711/shorternit/internal/events"
 	grpcClient "github.com/Varun5711/shorternit/internal/grpc"
 	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/wildcard"
 	pb "github.com/Varun5711/shorternit/proto/url"
 )
 
@@ -19,69 +32,210 @@ import (
 //  1. L1/L2 in-process + Redis cache (via the cache.Cache abstraction)
 //  2. gRPC call to the URL service (authoritative source of truth)
 //
-// Every successful redirect asynchronously publishes a click event to Kafka
-// for downstream analytics processing.
+// Every successful redirect of a visit asynchronously publishes a click event
+// to Kafka for downstream analytics processing; HEAD requests and prefetches
+// are redirected without one.
+//
+// Links are scoped by the Host they are requested on: a request for any host
+// other than the default base URL's is resolved as a custom-domain lookup.
 type RedirectHandler struct {
-	grpcClient    pb.URLServiceClient
-	clickProducer *events.ClickProducer
-	cache         *cache.Cache
-	log           *logger.Logger
-}
-
-// NewRedirectHandler creates a RedirectHandler by dialing the URL gRPC service.
-// The producer is used to publish click events to Kafka, and urlCache provides
-// the multi-level cache for short code resolution. Both may be nil in
-// degraded-mode configurations, though analytics and caching will be skipped.
-func NewRedirectHandler(urlServiceAddr string, producer *events.ClickProducer, urlCache *cache.Cache) (*RedirectHandler, error) {
-	client, err := grpcClient.NewURLServiceClient(urlServiceAddr)
+	grpcClient     pb.URLServiceClient
+	clickProducer  ClickPublisher
+	cache          *cache.Cache
+	clickCounter   ClickCounter      // enforces max_clicks on capped links
+	clickDeltas    ClickTally        // counts published clicks until the worker flushes them; nil skips it
+	geo            CountryLookup     // resolves visitor countries for geo rules; nil ignores them
+	dedup          ClickDeduper      // recognises repeat clicks; nil records every click
+	keepRepeats    bool              // publish repeats flagged as duplicates instead of dropping them
+	fields         enrichment.Fields // click-event fields analytics records; the rest are not published
+	defaultHost    string            // host of the default base URL; "" disables custom domains
+	trustedProxies []netip.Prefix    // proxies whose forwarding headers identify the visitor
+	pages          *RedirectPages    // branded 404, expired and landing pages; nil serves plain text
+	maxAge         time.Duration     // how long browsers may cache a redirect; 0 forbids it
+	wildcards      *wildcard.Cache   // domains' wildcard aliases; nil disables them
+	health         DestinationHealth // tells when a link's primary is down; nil disables failover
+	owners         TokenValidator    // validates owners asking for a fresh lookup; nil disables refreshes
+	clock          clock.Clock       // judges activation and expiry and stamps clicks; nil means the wall clock
+	log            *logger.Logger
+}
+
+// ClickCounter atomically counts redirects of capped links. It is satisfied by
+// *clicklimit.Counter; tests substitute an in-memory implementation.
+type ClickCounter interface {
+	Incr(ctx context.Context, shortCode string, seed clicklimit.SeedFunc) (int64, error)
+}
+
+// ClickTally keeps the near-real-time count of clicks published but not yet
+// flushed to the database. It is satisfied by *clickdelta.Counter.
+type ClickTally interface {
+	Incr(ctx context.Context, shortCode string) error
+	Settle(ctx context.Context, flushed map[string]int) error
+}
+
+// ClickPublisher records click events for the analytics workers. It is
+// satisfied by *events.ClickProducer; tests substitute a recorder.
+type ClickPublisher interface {
+	Publish(ctx context.Context, event *events.ClickEvent) error
+}
+
+// ClickDeduper reports whether a click is the first from its visitor within
+// the dedup window. It is satisfied by *clickdedup.Gate; tests substitute an
+// in-memory implementation.
+type ClickDeduper interface {
+	First(ctx context.Context, shortCode, ip, userAgent string) (bool, error)
+}
+
+// CountryLookup resolves a client IP to its location for geo-targeted links.
+// It is satisfied by *enrichment.GeoIPEnricher; tests substitute a fixed
+// answer.
+type CountryLookup interface {
+	Lookup(ipAddress string) *enrichment.GeoInfo
+}
+
+// DestinationHealth reports whether a link's primary destination is down,
+// so its backup is served instead. It is satisfied by *failover.Health;
+// tests substitute a fixed answer.
+type DestinationHealth interface {
+	Down(ctx context.Context, primary string) (bool, error)
+}
+
+// RedirectOptions are the dependencies and settings of a RedirectHandler
+// other than its URL service connection. A dependency whose comment says
+// what nil does may be left nil.
+type RedirectOptions struct {
+	Producer       *events.ClickProducer // publishes click events to Kafka; nil skips analytics
+	Cache          *cache.Cache          // multi-level short code cache; nil skips caching
+	ClickCounter   ClickCounter          // enforces max_clicks on capped links
+	ClickDeltas    ClickTally            // counts published clicks until the worker flushes them
+	Geo            CountryLookup         // resolves visitor countries for geo rules
+	Health         DestinationHealth     // tells when a primary is down; nil disables failover
+	Owners         TokenValidator        // validates owners asking for a fresh lookup; nil disables refreshes
+	Dedup          ClickDeduper          // recognises repeat clicks; nil records every click
+	KeepRepeats    bool                  // publish repeats flagged as duplicates instead of dropping them
+	Fields         enrichment.Fields     // click-event fields analytics records; the rest are not published
+	BaseURL        string                // default short link base URL; other hosts are custom domains
+	TrustedProxies []netip.Prefix        // proxies whose forwarding headers identify the visitor
+	Pages          *RedirectPages        // branded 404, expired and landing pages
+	MaxAge         time.Duration         // how long browsers may cache a redirect; 0 forbids it
+	WildcardTTL    time.Duration         // how long wildcard aliases are kept; 0 disables them
+}
+
+// NewRedirectHandler creates a RedirectHandler by dialing the URL gRPC
+// service at urlServiceAddr over a pool of grpcCfg.RedirectPoolSize
+// connections. Each domain's wildcard aliases are fetched from the URL
+// service when first needed and kept for opts.WildcardTTL.
+func NewRedirectHandler(urlServiceAddr string, grpcCfg config.GRPCConfig, opts RedirectOptions) (*RedirectHandler, error) {
+	client, err := grpcClient.NewURLServiceClient(urlServiceAddr, grpcCfg, grpcCfg.RedirectPoolSize)
 	if err != nil {
 		return nil, err
 	}
 
+	var defaultHost string
+	if u, err := url.Parse(opts.BaseURL); err == nil {
+		defaultHost = strings.ToLower(u.Hostname())
+	}
+
+	var wildcards *wildcard.Cache
+	if opts.WildcardTTL > 0 {
+		wildcards = wildcard.NewCache(wildcardRules(client), opts.WildcardTTL)
+	}
+
 	return &RedirectHandler{
-		grpcClient:    client,
-		clickProducer: producer,
-		cache:         urlCache,
-		log:           logger.New("redirect"),
+		grpcClient:     client,
+		clickProducer:  opts.Producer,
+		cache:          opts.Cache,
+		clickCounter:   opts.ClickCounter,
+		clickDeltas:    opts.ClickDeltas,
+		geo:            opts.Geo,
+		dedup:          opts.Dedup,
+		keepRepeats:    opts.KeepRepeats,
+		fields:         opts.Fields,
+		defaultHost:    defaultHost,
+		trustedProxies: opts.TrustedProxies,
+		pages:          opts.Pages,
+		maxAge:         opts.MaxAge,
+		wildcards:      wildcards,
+		health:         opts.Health,
+		owners:         opts.Owners,
+		clock:          clock.Real{},
+		log:            logger.New("redirect"),
 	}, nil
 }
 
 // HandleRedirect is the hot path of the entire application. It extracts the
-// short code from the URL path, resolves the destination via a cache-then-gRPC
-// cascade, publishes a click event for analytics, and issues a 302 redirect.
+// short code from the URL path, resolves the destination via a
+// cache-then-gRPC cascade, publishes a click event for analytics, and issues
+// a 302 redirect.
 //
 // Resolution order:
-//  1. Check the multi-level cache (in-process LRU backed by Redis).
-//  2. On cache miss, fall through to the URL gRPC service (backed by PostgreSQL).
+//  1. Check the multi-level cache (in-process LRU backed by Redis), unless
+//     the link's owner asks for a fresh copy (see ownerRefresh).
+//  2. On cache miss, fall through to the URL gRPC service (backed by
+//     PostgreSQL), and for an unknown code to the domain's wildcard aliases
+//     (see matchWildcard).
 //  3. On gRPC success, populate the cache so subsequent hits are fast.
 //
-// The click event is published asynchronously; a Kafka failure does not block
-// the redirect, because user-perceived latency is more important than perfect
-// analytics delivery (events can be recovered from access logs if needed).
+// A link that cannot be visited gets the 404 or 410 page and no click. The
+// click event is published asynchronously; a Kafka failure does not block
+// the redirect, because user-perceived latency is more important than
+// perfect analytics delivery (events can be recovered from access logs if
+// needed).
 func (h *RedirectHandler) HandleRedirect(w http.ResponseWriter, r *http.Request) {
-	// Strip the leading "/" to get the raw short code.
-	shortCode := r.URL.Path[1:]
-	if shortCode == "" {
-		http.NotFound(w, r)
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodOptions:
+		w.Header().Set("Allow", redirectMethods)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		w.Header().Set("Allow", redirectMethods)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// The root, the favicon and robots.txt are never short codes; answer
+	// them here so browser and crawler requests skip the lookup entirely.
+	switch r.URL.Path {
+	case "/":
+		h.pages.Root(w, r)
+		return
+	case "/favicon.ico":
+		h.pages.Favicon(w, r)
+		return
+	case "/robots.txt":
+		h.pages.Robots(w, r)
 		return
 	}
 
+	// Strip the leading "/" to get the raw short code.
+	shortCode := r.URL.Path[1:]
+
 	ctx := r.Context()
-	var longURL string
+	domain := h.requestDomain(r)
+	var entry cache.URLEntry
+	var dbClicks int64
+	var dbTitle string
+	fromDB := false
 
 	// --- Cache lookup (L1 in-process + L2 Redis) ---
 	cacheKey := "url:" + shortCode
-	cachedURL, found := h.cache.Get(ctx, cacheKey)
+	cached, found := h.cache.GetURL(ctx, cacheKey)
+	refresh := found && h.ownerRefresh(r, cached)
 
-	if found {
-		longURL = cachedURL
-		h.log.Debug("Cache hit for %s", shortCode)
+	if found && !refresh {
+		entry = *cached
+		h.log.DebugSampled("redirect cache hit", "Cache hit for %s", shortCode)
 	} else {
 		// --- gRPC fallback (authoritative store) ---
-		h.log.Debug("Cache miss for %s", shortCode)
+		if refresh {
+			h.log.Info("Refreshing %s for its owner", shortCode)
+		} else {
+			h.log.DebugSampled("redirect cache miss", "Cache miss for %s", shortCode)
+		}
 
 		grpcReq := &pb.GetURLRequest{
 			ShortCode: shortCode,
+			Domain:    domain,
 		}
 
 		grpcResp, err := h.grpcClient.GetURL(ctx, grpcReq)
@@ -92,65 +246,533 @@ func (h *RedirectHandler) HandleRedirect(w http.ResponseWriter, r *http.Request)
 		}
 
 		if !grpcResp.Found || grpcResp.Url == nil {
-			http.NotFound(w, r)
+			if refresh {
+				// The owner deleted, expired or rescheduled the link since
+				// it was cached; stop serving the stale entry.
+				if err := h.cache.Delete(ctx, cacheKey); err != nil {
+					h.log.Warn("Failed to drop cached URL: %v", err)
+				}
+			}
+			if grpcResp.Expired {
+				h.pages.Expired(w, r, shortCode, "This link has expired")
+				return
+			}
+			// --- Wildcard aliases ---
+			// Not cached as a link: a deleted rule stops matching once
+			// the rule cache expires.
+			wildcardEntry, ok, err := h.matchWildcard(ctx, domain, shortCode)
+			if err != nil {
+				h.log.Error("Failed to get wildcard rules: %v", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			if !ok {
+				h.pages.NotFound(w, r, shortCode)
+				return
+			}
+			entry = wildcardEntry
+		} else {
+			entry = cache.URLEntry{
+				LongURL:    grpcResp.Url.LongUrl,
+				MaxClicks:  grpcResp.Url.MaxClicks,
+				ActiveFrom: grpcResp.Url.ActiveFrom,
+				ExpiresAt:  grpcResp.Url.ExpiresAt,
+				Domain:     grpcResp.Url.Domain,
+				Variants:   variantsFromProto(grpcResp.Url.Variants),
+				GeoRules:   geoRulesFromProto(grpcResp.Url.GeoRules),
+				Preview:    grpcResp.Url.Preview,
+				BackupURL:  grpcResp.Url.BackupUrl,
+				UserID:     grpcResp.Url.UserId,
+				Disabled:   grpcResp.Url.Disabled,
+			}
+			dbClicks = grpcResp.Url.Clicks
+			dbTitle = grpcResp.Url.Title
+
+			// Back-fill the cache so subsequent redirects for this code are fast.
+			if err := h.cache.SetURL(ctx, cacheKey, entry); err != nil {
+				h.log.Warn("Failed to cache URL: %v", err)
+			}
+		}
+		fromDB = true
+	}
+
+	// --- Domain scoping ---
+	// A link created on a custom domain is 404 everywhere else, and a
+	// default-domain link is 404 on custom domains (see requestDomain).
+	if entry.Domain != domain {
+		h.pages.NotFound(w, r, shortCode)
+		return
+	}
+
+	// --- Disabled by the owner (cached like any entry) ---
+	if entry.Disabled {
+		h.pages.Expired(w, r, shortCode, "This link has been disabled")
+		return
+	}
+
+	// --- Scheduled activation and expiry ---
+	// A link not active yet is 404, exactly as if it did not exist. The URL
+	// service already reports such links as not found; this covers entries
+	// cached at creation time.
+	now := h.now()
+	if entry.ActiveFrom > 0 && now.Unix() < entry.ActiveFrom {
+		h.pages.NotFound(w, r, shortCode)
+		return
+	}
+	if entry.ExpiresAt > 0 && now.Unix() >= entry.ExpiresAt {
+		// Only a cached entry can get here: the URL service does not
+		// return expired links.
+		h.pages.Expired(w, r, shortCode, "This link has expired")
+		return
+	}
+
+	// HEAD requests and prefetches are not visits (see isPrefetch).
+	counted := r.Method == http.MethodGet && !isPrefetch(r.Header)
+
+	// --- Click cap (burn-after-N links) ---
+	// Checked before dedup, so every redirect of a capped link uses up a
+	// click, even a repeat.
+	if entry.MaxClicks > 0 {
+		if !counted {
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		seed := func(ctx context.Context) (int64, error) {
+			if fromDB {
+				return dbClicks, nil
+			}
+			return h.fetchClicks(ctx, shortCode, domain)
+		}
+
+		count, err := h.clickCounter.Incr(ctx, shortCode, seed)
+		if err != nil {
+			// Fail closed: a one-time link that leaks to a second visitor
+			// because Redis hiccuped is worse than a retryable error.
+			h.log.Error("Failed to check click limit for %s: %v", shortCode, err)
+			http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
 			return
 		}
+		if count > entry.MaxClicks {
+			h.pages.Expired(w, r, shortCode, "This link has reached its click limit")
+			return
+		}
+	}
 
-		longURL = grpcResp.Url.LongUrl
+	// --- Destination (geo rule, A/B split or long URL) ---
+	clientIP := middleware.ClientIP(r, h.trustedProxies)
+	longURL, variant, geoRule := h.chooseDestination(entry, clientIP)
+	failover := false
+	if entry.BackupURL != "" && h.primaryDown(ctx, longURL) {
+		longURL, failover = entry.BackupURL, true
+	}
 
-		// Back-fill the cache so subsequent redirects for this code are fast.
-		if err := h.cache.Set(ctx, cacheKey, longURL); err != nil {
-			h.log.Warn("Failed to cache URL: %v", err)
+	// --- Preview page ---
+	preview := entry.Preview || previewRequested(r)
+	var title string
+	if preview && longURL == entry.LongURL {
+		// The stored title is the long URL's, not a variant's or geo rule's.
+		title = dbTitle
+		if !fromDB {
+			title = h.fetchTitle(ctx, shortCode, domain)
 		}
 	}
 
+	if !counted {
+		// A prefetched redirect that the browser cached would be followed
+		// without ever reaching us, so the visit itself would go uncounted.
+		if isPrefetch(r.Header) {
+			w.Header().Set("Cache-Control", "no-store")
+		} else {
+			h.setRedirectCaching(w, entry, now)
+		}
+		h.sendToDestination(w, r, shortCode, longURL, preview, title)
+		return
+	}
+
+	// --- Repeat-click dedup ---
+	userAgent := sanitizeHeaderValue(r.UserAgent(), maxUserAgentLen)
+	duplicate := h.isRepeatClick(ctx, shortCode, clientIP, userAgent)
+	h.setRedirectCaching(w, entry, now)
+	// A capped link's repeats are still published, flagged, because the
+	// database click count its Redis counter is reseeded from is built
+	// from published events alone.
+	if duplicate && !h.keepRepeats && entry.MaxClicks == 0 {
+		h.sendToDestination(w, r, shortCode, longURL, preview, title)
+		return
+	}
+
 	// --- Publish click event for analytics ---
 	// This is fire-and-forget: we log a warning on failure but never block
 	// the redirect response, prioritizing end-user latency.
 	clickEvent := &events.ClickEvent{
 		ShortCode:   shortCode,
-		Timestamp:   time.Now().Unix(),
-		IP:          getClientIP(r),
-		UserAgent:   r.UserAgent(),
+		Timestamp:   now.Unix(),
+		IP:          clientIP,
+		UserAgent:   userAgent,
 		OriginalURL: longURL,
-		Referer:     r.Header.Get("Referer"),
-		QueryParams: r.URL.RawQuery,
+		Referer:     sanitizeHeaderValue(r.Header.Get("Referer"), maxRefererLen),
+		QueryParams: sanitizeHeaderValue(r.URL.RawQuery, maxQueryParamsLen),
+		Variant:     variant,
+		GeoRule:     geoRule,
+		Duplicate:   duplicate,
+		Failover:    failover,
 	}
-	if err := h.clickProducer.Publish(ctx, clickEvent); err != nil {
-		h.log.Warn("Failed to publish click event: %v", err)
+	h.dropUnrecorded(clickEvent)
+	h.publishClick(ctx, clickEvent)
+
+	h.sendToDestination(w, r, shortCode, longURL, preview, title)
+}
+
+// matchWildcard returns the entry a code that is not a link resolves to on
+// domain through the domain's wildcard aliases, and whether one matched.
+// A rule whose destination cannot take the code's rest matches nothing.
+//
+// HandleRedirect only tries it once the URL service has not found the
+// code, so a link always wins over a wildcard, even one matching it. A
+// match is redirected to the rule's destination with the rest of the code
+// added, and counted as a click of the code.
+func (h *RedirectHandler) matchWildcard(ctx context.Context, domain, shortCode string) (cache.URLEntry, bool, error) {
+	rule, suffix, ok, err := h.wildcards.Match(ctx, domain, shortCode)
+	if err != nil || !ok {
+		return cache.URLEntry{}, false, err
+	}
+	longURL, err := rule.Expand(suffix)
+	if err != nil {
+		h.log.Warn("Wildcard %s* has an invalid destination: %v", rule.Prefix, err)
+		return cache.URLEntry{}, false, nil
 	}
+	return cache.URLEntry{LongURL: longURL, Domain: domain}, true, nil
+}
 
+// wildcardRules returns the wildcard.LoadFunc fetching a domain's rules
+// from the URL service.
+func wildcardRules(client pb.URLServiceClient) wildcard.LoadFunc {
+	return func(ctx context.Context, domain string) ([]wildcard.Rule, error) {
+		resp, err := client.GetWildcardRules(ctx, &pb.GetWildcardRulesRequest{Domain: domain})
+		if err != nil {
+			return nil, err
+		}
+		rules := make([]wildcard.Rule, len(resp.Rules))
+		for i, r := range resp.Rules {
+			rules[i] = wildcard.Rule{
+				Prefix:      r.Prefix,
+				Destination: r.Destination,
+				SuffixMode:  r.SuffixMode,
+				QueryParam:  r.QueryParam,
+			}
+		}
+		return rules, nil
+	}
+}
+
+// sendToDestination answers a visit with a 302 to longURL, or with the
+// preview page naming it when preview is set.
+func (h *RedirectHandler) sendToDestination(w http.ResponseWriter, r *http.Request, shortCode, longURL string, preview bool, title string) {
+	if preview {
+		h.pages.Preview(w, r, shortCode, longURL, title)
+		return
+	}
 	http.Redirect(w, r, longURL, http.StatusFound)
 }
 
-// getClientIP extracts the real client IP address from the request, respecting
-// reverse-proxy headers in priority order: X-Forwarded-For (first entry),
-// X-Real-IP, then RemoteAddr as a last resort. IPv6 loopback (::1) is
-// normalized to 127.0.0.1 for consistent analytics storage.
-func getClientIP(r *http.Request) string {
-	// X-Forwarded-For may contain a comma-separated chain of proxies;
-	// the first entry is the original client IP.
-	forwarded := r.Header.Get("X-Forwarded-For")
-	if forwarded != "" {
-		ips := strings.Split(forwarded, ",")
-		return strings.TrimSpace(ips[0])
+// previewRequested reports whether the visitor asked for the preview page
+// of a link that does not show one by default, with ?preview=1 or
+// ?preview=true.
+func previewRequested(r *http.Request) bool {
+	if r.URL.RawQuery == "" {
+		return false
+	}
+	v, err := strconv.ParseBool(r.URL.Query().Get("preview"))
+	return err == nil && v
+}
+
+// dropUnrecorded blanks what event carries only for analytics fields the
+// pipeline-worker would not store, so they never enter the stream. The
+// address is kept while geo is recorded, and the User-Agent while the
+// device is: the worker derives those fields from them and then blanks
+// them itself.
+func (h *RedirectHandler) dropUnrecorded(event *events.ClickEvent) {
+	if !h.fields.NeedsIP() {
+		event.IP = ""
+	}
+	if !h.fields.NeedsUserAgent() {
+		event.UserAgent = ""
+	}
+	if !h.fields.Keeps(enrichment.FieldReferer) {
+		event.Referer = ""
+	}
+	if !h.fields.Keeps(enrichment.FieldQuery) {
+		event.QueryParams = ""
+	}
+}
+
+// publishClick publishes event and counts it towards the link's
+// near-real-time click count. The count is raised first so that the
+// analytics-worker, settling it once the event is flushed, never settles a
+// click that has not been counted yet; a click whose event cannot be
+// published is taken back out.
+func (h *RedirectHandler) publishClick(ctx context.Context, event *events.ClickEvent) {
+	counted := false
+	if h.clickDeltas != nil {
+		if err := h.clickDeltas.Incr(ctx, event.ShortCode); err != nil {
+			h.log.Warn("Failed to count click of %s: %v", event.ShortCode, err)
+		} else {
+			counted = true
+		}
+	}
+	if err := h.clickProducer.Publish(ctx, event); err != nil {
+		h.log.Warn("Failed to publish click event: %v", err)
+		if counted {
+			_ = h.clickDeltas.Settle(ctx, map[string]int{event.ShortCode: 1})
+		}
+	}
+}
+
+// setRedirectCaching sets the caching headers of a redirect. A browser may
+// reuse it for up to maxAge, cut short by the link's expiry, so repeat
+// visits within that time are neither redirected by this service nor
+// counted. The response is private: a CDN caching it would serve every
+// visitor the same destination and hide their clicks, and the link may be
+// deleted meanwhile. Links whose destination or availability changes per
+// click -- capped, A/B split, geo-targeted or failover ones -- are never
+// cached.
+func (h *RedirectHandler) setRedirectCaching(w http.ResponseWriter, entry cache.URLEntry, now time.Time) {
+	maxAge := h.maxAge
+	if entry.ExpiresAt > 0 {
+		maxAge = min(maxAge, time.Unix(entry.ExpiresAt, 0).Sub(now))
+	}
+	if maxAge < time.Second || entry.MaxClicks > 0 || len(entry.Variants) > 1 || len(entry.GeoRules) > 0 || entry.BackupURL != "" {
+		w.Header().Set("Cache-Control", "no-store")
+		return
+	}
+	seconds := int64(maxAge / time.Second)
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.FormatInt(seconds, 10))
+	w.Header().Set("Expires", now.Add(time.Duration(seconds)*time.Second).UTC().Format(http.TimeFormat))
+}
+
+// redirectMethods is the Allow header of the redirect service's answers to
+// OPTIONS and to unsupported methods.
+const redirectMethods = "GET, HEAD, OPTIONS"
+
+// isPrefetch reports whether a request is a browser prefetch or a link
+// preview rather than a visit: Chrome sends Sec-Purpose (formerly Purpose)
+// and Firefox X-Moz, all with "prefetch" in the value, and Safari
+// X-Purpose: preview.
+//
+// Like HEAD requests, prefetches are redirected without a click event and
+// without touching the dedup window. A capped link answers them 204 with no
+// Location, so they neither use up a click nor reveal a destination the cap
+// may have retired.
+func isPrefetch(header http.Header) bool {
+	for _, name := range []string{"Sec-Purpose", "Purpose", "X-Moz", "X-Purpose"} {
+		v := strings.ToLower(header.Get(name))
+		if strings.Contains(v, "prefetch") || v == "preview" {
+			return true
+		}
+	}
+	return false
+}
+
+// Limits, in bytes, on the client-supplied values a click event carries.
+// Without them a client could send megabyte headers that bloat the Redis
+// stream and ClickHouse.
+const (
+	maxUserAgentLen   = 512
+	maxRefererLen     = 2048
+	maxQueryParamsLen = 2048
+)
+
+// sanitizeHeaderValue prepares a client-supplied value for storage: it drops
+// control characters and invalid UTF-8, then cuts the result to at most max
+// bytes without splitting a character.
+func sanitizeHeaderValue(s string, max int) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, s)
+	if len(s) <= max {
+		return s
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut]
+}
+
+// now returns the current time from h's clock.
+func (h *RedirectHandler) now() time.Time {
+	if h.clock == nil {
+		return time.Now()
+	}
+	return h.clock.Now()
+}
+
+// isRepeatClick reports whether the visitor already clicked shortCode within
+// the dedup window. It fails open: if the gate cannot be reached the click is
+// treated as a first click, since over-counting is better than losing clicks.
+//
+// A repeat still redirects, but its event is either not published or
+// published flagged as a duplicate (keepRepeats). A capped link's repeats are
+// always published, flagged, since its click counter is reseeded from the
+// published clicks.
+func (h *RedirectHandler) isRepeatClick(ctx context.Context, shortCode, clientIP, userAgent string) bool {
+	if h.dedup == nil {
+		return false
 	}
+	first, err := h.dedup.First(ctx, shortCode, clientIP, userAgent)
+	if err != nil {
+		h.log.Warn("Failed to check repeat click for %s: %v", shortCode, err)
+		return false
+	}
+	return !first
+}
 
-	realIP := r.Header.Get("X-Real-IP")
-	if realIP != "" {
-		return realIP
+// fetchClicks reads the authoritative click count for shortCode from the URL
+// service. It seeds the Redis click counter when a capped link is served from
+// cache but its counter has expired or been evicted.
+func (h *RedirectHandler) fetchClicks(ctx context.Context, shortCode, domain string) (int64, error) {
+	resp, err := h.grpcClient.GetURL(ctx, &pb.GetURLRequest{ShortCode: shortCode, Domain: domain})
+	if err != nil {
+		return 0, err
+	}
+	if !resp.Found || resp.Url == nil {
+		return 0, nil
 	}
+	return resp.Url.Clicks, nil
+}
 
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+// fetchTitle reads the destination page title of shortCode from the URL
+// service, for a preview page served from a cached entry, which does not
+// carry it. The title is a nicety: it is "" if the lookup fails.
+func (h *RedirectHandler) fetchTitle(ctx context.Context, shortCode, domain string) string {
+	resp, err := h.grpcClient.GetURL(ctx, &pb.GetURLRequest{ShortCode: shortCode, Domain: domain})
 	if err != nil {
-		return r.RemoteAddr
+		h.log.Warn("Failed to fetch title of %s: %v", shortCode, err)
+		return ""
+	}
+	if !resp.Found || resp.Url == nil {
+		return ""
+	}
+	return resp.Url.Title
+}
+
+// chooseDestination picks where a redirect goes. A geo rule for the visitor's
+// country wins; otherwise a split link draws a variant by weight and any other
+// link goes to its long URL. Alongside the destination it returns the 1-based
+// variant served (0 if none) and the country code of the matched geo rule (""
+// if none). The GeoIP lookup only runs for links that have geo rules, so other
+// redirects pay nothing for it.
+//
+// HandleRedirect then sends a link with a backup destination to the backup
+// instead while its primary is down, flagging the click as a failover. A
+// preview link, or any link requested with ?preview=1, gets the preview page
+// naming the destination instead of a 302; the page is the visit, and its
+// click is recorded exactly as a redirect's would be.
+func (h *RedirectHandler) chooseDestination(entry cache.URLEntry, clientIP string) (string, int, string) {
+	if len(entry.GeoRules) > 0 && h.geo != nil {
+		if info := h.geo.Lookup(clientIP); info != nil {
+			country := strings.ToUpper(info.CountryCode)
+			if longURL, ok := entry.GeoRules[country]; ok {
+				return longURL, 0, country
+			}
+		}
+	}
+
+	if len(entry.Variants) > 1 {
+		variant := pickVariant(entry.Variants, rand.IntN)
+		return entry.Variants[variant-1].LongURL, variant, ""
 	}
+	return entry.LongURL, 0, ""
+}
 
-	// Normalize IPv6 loopback to IPv4 so downstream GeoIP lookups and
-	// analytics grouping treat local requests consistently.
-	if ip == "::1" {
-		return "127.0.0.1"
+// primaryDown reports whether health has found primary down. A failed
+// lookup counts as up: the primary is the link's own destination, and the
+// backup is only for outages the checker has seen.
+func (h *RedirectHandler) primaryDown(ctx context.Context, primary string) bool {
+	if h.health == nil {
+		return false
 	}
+	down, err := h.health.Down(ctx, primary)
+	if err != nil {
+		h.log.Warn("Failed to read the health of %s, redirecting to it: %v", primary, err)
+		return false
+	}
+	return down
+}
 
-	return ip
+// geoRulesFromProto converts a link's protobuf geo rules into their cached
+// form, keyed by country code.
+func geoRulesFromProto(rules []*pb.GeoRule) map[string]string {
+	if len(rules) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(rules))
+	for _, rule := range rules {
+		out[rule.CountryCode] = rule.LongUrl
+	}
+	return out
+}
+
+// variantsFromProto converts a link's protobuf variants into their cached
+// form. Single-destination links have none.
+func variantsFromProto(variants []*pb.URLVariant) []cache.URLVariant {
+	if len(variants) == 0 {
+		return nil
+	}
+	out := make([]cache.URLVariant, len(variants))
+	for i, v := range variants {
+		out[i] = cache.URLVariant{LongURL: v.LongUrl, Weight: v.Weight}
+	}
+	return out
+}
+
+// pickVariant chooses one of variants with probability proportional to its
+// weight and returns its 1-based position. intN must return a uniformly
+// distributed integer in [0, n); the redirect path passes math/rand/v2's
+// IntN, which is safe for concurrent use and randomly seeded, so every
+// request gets an independent draw.
+func pickVariant(variants []cache.URLVariant, intN func(n int) int) int {
+	total := 0
+	for _, v := range variants {
+		total += int(v.Weight)
+	}
+	if total <= 0 {
+		return 1
+	}
+
+	r := intN(total)
+	for i, v := range variants {
+		r -= int(v.Weight)
+		if r < 0 {
+			return i + 1
+		}
+	}
+	return len(variants)
+}
+
+// requestDomain returns the custom domain a request arrived on, or "" for the
+// default domain. The Host header's port and trailing dot are dropped. The
+// default host, IP literals and single-label names (e.g. "localhost" or a
+// cluster-internal service name) all count as the default domain, since none
+// of them can be registered as a custom domain; so does every host when no
+// default host is configured.
+func (h *RedirectHandler) requestDomain(r *http.Request) string {
+	host := r.Host
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	host = strings.TrimSuffix(strings.ToLower(strings.Trim(host, "[]")), ".")
+
+	if h.defaultHost == "" || host == h.defaultHost || !strings.Contains(host, ".") {
+		return ""
+	}
+	if _, err := netip.ParseAddr(host); err == nil {
+		return ""
+	}
+	return host
 }
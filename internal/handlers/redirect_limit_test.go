@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/cache"
+	"github.com/Varun5711/shorternit/internal/cache/cachetest"
+	"github.com/Varun5711/shorternit/internal/clicklimit"
+	"github.com/Varun5711/shorternit/internal/events"
+	"github.com/Varun5711/shorternit/internal/logger"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc"
+)
+
+// fakeURLClient serves GetURL from a fixed map and counts calls. The other
+// URLServiceClient methods are left to the embedded nil interface.
+type fakeURLClient struct {
+	pb.URLServiceClient
+	urls  map[string]*pb.URL
+	calls int
+}
+
+func (f *fakeURLClient) GetURL(ctx context.Context, in *pb.GetURLRequest, opts ...grpc.CallOption) (*pb.GetURLResponse, error) {
+	f.calls++
+	u, ok := f.urls[in.ShortCode]
+	// Like URLService.GetURL, expired links are not found but flagged, and
+	// links on another domain or not active yet are not found.
+	if ok && u.ExpiresAt > 0 && time.Now().Unix() >= u.ExpiresAt {
+		return &pb.GetURLResponse{Found: false, Expired: true}, nil
+	}
+	if !ok || u.Domain != in.Domain || (u.ActiveFrom > 0 && time.Now().Unix() < u.ActiveFrom) {
+		return &pb.GetURLResponse{Found: false}, nil
+	}
+	return &pb.GetURLResponse{Url: u, Found: true}, nil
+}
+
+// memoryCounter mimics clicklimit.Counter: a missing counter is seeded once,
+// then every call increments it.
+type memoryCounter struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func (c *memoryCounter) Incr(ctx context.Context, shortCode string, seed clicklimit.SeedFunc) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n, ok := c.counts[shortCode]
+	if !ok {
+		base, err := seed(ctx)
+		if err != nil {
+			return 0, err
+		}
+		n = base
+	}
+	n++
+	c.counts[shortCode] = n
+	return n, nil
+}
+
+// newLimitTestHandler builds a RedirectHandler whose Redis is unreachable, so
+// the cache runs on its in-process L1 tier alone and click publishing fails
+// harmlessly.
+func newLimitTestHandler(urls map[string]*pb.URL) (*RedirectHandler, *memoryCounter) {
+	counter := &memoryCounter{counts: make(map[string]int64)}
+	return &RedirectHandler{
+		grpcClient:    &fakeURLClient{urls: urls},
+		clickProducer: events.NewClickProducer(cachetest.UnreachableRedis(), "clicks", 0, nil),
+		cache:         cachetest.NewL1Only(),
+		clickCounter:  counter,
+		// httptest requests come from 192.0.2.1, so tests may set
+		// X-Forwarded-For as a proxy in front of the service would.
+		trustedProxies: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")},
+		log:            logger.New("redirect-test"),
+	}, counter
+}
+
+func redirect(h *RedirectHandler, code string) int {
+	rec := httptest.NewRecorder()
+	h.HandleRedirect(rec, httptest.NewRequest(http.MethodGet, "/"+code, nil))
+	return rec.Code
+}
+
+// TestHandleRedirect_ClickLimitExactlyAtLimit verifies that a link capped at
+// N clicks redirects exactly N times: the Nth click still succeeds.
+func TestHandleRedirect_ClickLimitExactlyAtLimit(t *testing.T) {
+	h, _ := newLimitTestHandler(map[string]*pb.URL{
+		"abc": {ShortCode: "abc", LongUrl: "https://example.com", MaxClicks: 3},
+	})
+
+	for i := 1; i <= 3; i++ {
+		if code := redirect(h, "abc"); code != http.StatusFound {
+			t.Fatalf("click %d: expected 302, got %d", i, code)
+		}
+	}
+}
+
+// TestHandleRedirect_ClickLimitOverLimit verifies that every click past the
+// cap gets 410 Gone, including when the link is served from cache.
+func TestHandleRedirect_ClickLimitOverLimit(t *testing.T) {
+	h, _ := newLimitTestHandler(map[string]*pb.URL{
+		"once": {ShortCode: "once", LongUrl: "https://example.com", MaxClicks: 1},
+	})
+
+	if code := redirect(h, "once"); code != http.StatusFound {
+		t.Fatalf("first click: expected 302, got %d", code)
+	}
+	for i := 0; i < 2; i++ {
+		if code := redirect(h, "once"); code != http.StatusGone {
+			t.Errorf("click past limit: expected 410, got %d", code)
+		}
+	}
+}
+
+// TestHandleRedirect_ClickLimitSeededFromDatabase checks reconciliation: a
+// counter missing from Redis starts at the database click count, so a link
+// whose clicks already reached the cap is refused immediately.
+func TestHandleRedirect_ClickLimitSeededFromDatabase(t *testing.T) {
+	h, _ := newLimitTestHandler(map[string]*pb.URL{
+		"used": {ShortCode: "used", LongUrl: "https://example.com", Clicks: 5, MaxClicks: 5},
+	})
+
+	if code := redirect(h, "used"); code != http.StatusGone {
+		t.Errorf("expected 410 for link already at its limit, got %d", code)
+	}
+}
+
+// TestHandleRedirect_ClickLimitCacheHitReseeds ensures that when a capped
+// link is served from cache but its counter is gone, the seed comes from a
+// fresh gRPC read of the database count.
+func TestHandleRedirect_ClickLimitCacheHitReseeds(t *testing.T) {
+	client := &fakeURLClient{urls: map[string]*pb.URL{
+		"abc": {ShortCode: "abc", LongUrl: "https://example.com", Clicks: 1, MaxClicks: 2},
+	}}
+	h, counter := newLimitTestHandler(nil)
+	h.grpcClient = client
+	_ = h.cache.SetURL(context.Background(), "url:abc", cache.URLEntry{LongURL: "https://example.com", MaxClicks: 2})
+
+	if code := redirect(h, "abc"); code != http.StatusFound {
+		t.Fatalf("expected 302, got %d", code)
+	}
+	if client.calls != 1 {
+		t.Errorf("expected one gRPC call to seed the counter, got %d", client.calls)
+	}
+	if counter.counts["abc"] != 2 {
+		t.Errorf("expected counter seeded at 1 and incremented to 2, got %d", counter.counts["abc"])
+	}
+	if code := redirect(h, "abc"); code != http.StatusGone {
+		t.Errorf("expected 410 after reaching limit, got %d", code)
+	}
+}
+
+// TestHandleRedirect_UnlimitedSkipsCounter confirms links without max_clicks
+// never touch the click counter.
+func TestHandleRedirect_UnlimitedSkipsCounter(t *testing.T) {
+	h, counter := newLimitTestHandler(map[string]*pb.URL{
+		"free": {ShortCode: "free", LongUrl: "https://example.com"},
+	})
+
+	for i := 0; i < 3; i++ {
+		if code := redirect(h, "free"); code != http.StatusFound {
+			t.Fatalf("expected 302, got %d", code)
+		}
+	}
+	if len(counter.counts) != 0 {
+		t.Errorf("expected no counters for unlimited link, got %v", counter.counts)
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+// request sends method to path with the given headers.
+func request(h *RedirectHandler, method, path string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.HandleRedirect(rec, req)
+	return rec
+}
+
+// TestHandleRedirect_HeadIsNotAClick verifies that HEAD gets the redirect
+// without publishing a click or opening the visitor's dedup window, so the
+// visit that follows is still counted.
+func TestHandleRedirect_HeadIsNotAClick(t *testing.T) {
+	h, _, published := newDedupTestHandler(false)
+	h.maxAge = time.Minute
+
+	rec := request(h, http.MethodHead, "/abc", nil)
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "https://example.com" {
+		t.Fatalf("expected a 302 to https://example.com, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "private, max-age=60" {
+		t.Errorf("expected the caching headers of a GET, got %q", cc)
+	}
+	if len(published.events) != 0 {
+		t.Fatalf("expected no click for HEAD, got %d", len(published.events))
+	}
+
+	if rec := request(h, http.MethodGet, "/abc", nil); rec.Code != http.StatusFound {
+		t.Fatalf("expected 302, got %d", rec.Code)
+	}
+	if len(published.events) != 1 || published.events[0].Duplicate {
+		t.Errorf("expected the GET after a HEAD to count as a first click, got %+v", published.events)
+	}
+}
+
+// TestHandleRedirect_OptionsListsMethods verifies that OPTIONS is answered
+// without a lookup or a click, and that other methods are refused.
+func TestHandleRedirect_OptionsListsMethods(t *testing.T) {
+	h, _, published := newDedupTestHandler(false)
+	client := h.grpcClient.(*fakeURLClient)
+
+	rec := request(h, http.MethodOptions, "/abc", nil)
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Allow") != "GET, HEAD, OPTIONS" {
+		t.Errorf("expected 204 with the allowed methods, got %d %q", rec.Code, rec.Header().Get("Allow"))
+	}
+	if client.calls != 0 || len(published.events) != 0 {
+		t.Errorf("expected no lookup and no click, got %d lookups and %d clicks", client.calls, len(published.events))
+	}
+
+	rec = request(h, http.MethodPost, "/abc", nil)
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, HEAD, OPTIONS" {
+		t.Errorf("expected 405 with the allowed methods, got %d %q", rec.Code, rec.Header().Get("Allow"))
+	}
+	if len(published.events) != 0 {
+		t.Errorf("expected no click for POST, got %d", len(published.events))
+	}
+}
+
+// TestHandleRedirect_PrefetchIsNotAClick verifies that each browser's
+// prefetch header skips the click and forbids caching, so the real
+// navigation comes back and is counted.
+func TestHandleRedirect_PrefetchIsNotAClick(t *testing.T) {
+	for _, header := range []map[string]string{
+		{"Sec-Purpose": "prefetch;prerender"},
+		{"Purpose": "prefetch"},
+		{"X-Moz": "prefetch"},
+		{"X-Purpose": "preview"},
+	} {
+		h, _, published := newDedupTestHandler(false)
+		h.maxAge = time.Minute
+
+		rec := request(h, http.MethodGet, "/abc", header)
+		if rec.Code != http.StatusFound {
+			t.Fatalf("%v: expected 302, got %d", header, rec.Code)
+		}
+		if cc := rec.Header().Get("Cache-Control"); cc != "no-store" {
+			t.Errorf("%v: expected no-store, got %q", header, cc)
+		}
+		if len(published.events) != 0 {
+			t.Errorf("%v: expected no click, got %d", header, len(published.events))
+		}
+
+		request(h, http.MethodGet, "/abc", nil)
+		if len(published.events) != 1 || published.events[0].Duplicate {
+			t.Errorf("%v: expected the navigation to count as a first click, got %+v", header, published.events)
+		}
+	}
+
+	if isPrefetch(http.Header{"Purpose": {"navigate"}}) {
+		t.Error("expected an unrelated Purpose not to count as a prefetch")
+	}
+}
+
+// TestHandleRedirect_HeadKeepsClickCap verifies that HEAD on a capped link
+// neither uses up a click nor reveals the destination.
+func TestHandleRedirect_HeadKeepsClickCap(t *testing.T) {
+	h, counter := newLimitTestHandler(map[string]*pb.URL{
+		"once": {ShortCode: "once", LongUrl: "https://example.com", MaxClicks: 1},
+	})
+
+	rec := request(h, http.MethodHead, "/once", nil)
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Location") != "" {
+		t.Errorf("expected 204 without a Location, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if _, ok := counter.counts["once"]; ok {
+		t.Error("expected HEAD not to touch the click counter")
+	}
+
+	if rec := request(h, http.MethodGet, "/once", nil); rec.Code != http.StatusFound {
+		t.Errorf("expected the one click to remain for a visit, got %d", rec.Code)
+	}
+}
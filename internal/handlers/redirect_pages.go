@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/config"
+	"github.com/Varun5711/shorternit/internal/logger"
+)
+
+// RedirectPages renders the redirect service's own pages: the 404 for an
+// unknown short code, the page for an expired or used-up link, the landing
+// page at the root path, and the preview page shown instead of a redirect.
+// Each is an html/template, so the short code a visitor typed is escaped
+// rather than injected; any page without a template falls back to plain
+// text, except the preview page, which has a built-in one. It also serves
+// /favicon.ico and /robots.txt, which browsers and crawlers ask for on their
+// own. A nil *RedirectPages serves only the fallbacks.
+type RedirectPages struct {
+	notFound     *template.Template
+	expired      *template.Template
+	landing      *template.Template
+	preview      *template.Template
+	previewDelay time.Duration // how long the preview page waits before following the link; 0 waits for the visitor
+	rootURL      string        // where the root path redirects; overrides landing
+	favicon      []byte        // nil answers /favicon.ico with 204 No Content
+	faviconType  string
+	robots       []byte
+	log          *logger.Logger
+}
+
+// defaultRobots lets crawlers in: following a short link only leads them
+// to its destination, and disallowing everything would hide the landing
+// page too.
+var defaultRobots = []byte("User-agent: *\nDisallow:\n")
+
+// PageData is what the page templates are executed with.
+type PageData struct {
+	ShortCode string // the code requested, empty on the landing page
+	Host      string // the host the request was made to
+}
+
+// PreviewData is what the preview page template is executed with.
+type PreviewData struct {
+	PageData
+	Destination     string // where the link leads
+	DestinationHost string // Destination's host name, e.g. "example.com"
+	Title           string // the destination page's title, "" if not known
+	FaviconURL      string // the destination site's /favicon.ico, "" unless Destination is http(s)
+	Delay           int    // seconds until the page follows the link itself; 0 waits for the visitor
+}
+
+// defaultPreview is the preview page served when none is configured. It
+// names the destination and waits for the visitor to continue, or follows
+// the link itself after Delay seconds. html/template escapes the title,
+// which comes from the destination page, and refuses a destination that is
+// not a safe URL in both the link and the refresh.
+var defaultPreview = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+{{- if .Delay}}
+<meta http-equiv="refresh" content="{{.Delay}}; url={{.Destination}}">
+{{- end}}
+<title>Leaving {{.Host}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 36rem; margin: 4rem auto; padding: 0 1rem; color: #222; }
+.destination { word-break: break-all; color: #555; }
+.button { display: inline-block; padding: .6rem 1.2rem; background: #2563eb; color: #fff; border-radius: .3rem; text-decoration: none; }
+</style>
+</head>
+<body>
+<p>This link takes you to</p>
+<h1>{{if .FaviconURL}}<img src="{{.FaviconURL}}" alt="" width="24" height="24"> {{end}}{{if .DestinationHost}}{{.DestinationHost}}{{else}}another site{{end}}</h1>
+{{- if .Title}}
+<p><strong>{{.Title}}</strong></p>
+{{- end}}
+<p class="destination">{{.Destination}}</p>
+<p><a class="button" href="{{.Destination}}">Continue</a></p>
+{{- if .Delay}}
+<p>You will be taken there in {{.Delay}} seconds.</p>
+{{- end}}
+</body>
+</html>
+`))
+
+// LoadRedirectPages parses the templates and reads the favicon and
+// robots.txt files cfg names. Empty paths leave those pages on their
+// fallback; a path that cannot be read or parsed is an error, so a typo
+// fails startup instead of silently unbranding.
+func LoadRedirectPages(cfg config.RedirectPagesConfig, log *logger.Logger) (*RedirectPages, error) {
+	p := &RedirectPages{
+		preview:      defaultPreview,
+		previewDelay: cfg.PreviewDelay,
+		rootURL:      cfg.RootURL,
+		robots:       defaultRobots,
+		log:          log,
+	}
+	if cfg.FaviconFile != "" {
+		icon, err := os.ReadFile(cfg.FaviconFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load favicon: %w", err)
+		}
+		p.favicon = icon
+		p.faviconType = faviconType(cfg.FaviconFile, icon)
+	}
+	if cfg.RobotsFile != "" {
+		robots, err := os.ReadFile(cfg.RobotsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load robots.txt: %w", err)
+		}
+		p.robots = robots
+	}
+	for _, t := range []struct {
+		path string
+		dst  **template.Template
+	}{
+		{cfg.NotFoundTemplate, &p.notFound},
+		{cfg.ExpiredTemplate, &p.expired},
+		{cfg.LandingTemplate, &p.landing},
+		{cfg.PreviewTemplate, &p.preview},
+	} {
+		if t.path == "" {
+			continue
+		}
+		tmpl, err := template.ParseFiles(t.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load page template: %w", err)
+		}
+		*t.dst = tmpl
+	}
+	return p, nil
+}
+
+// NotFound answers 404 for a short code that does not exist.
+func (p *RedirectPages) NotFound(w http.ResponseWriter, r *http.Request, shortCode string) {
+	if p == nil || p.notFound == nil {
+		http.NotFound(w, r)
+		return
+	}
+	p.render(w, r, p.notFound, http.StatusNotFound, shortCode, "404 page not found")
+}
+
+// Expired answers 410 for a link that existed but can no longer be followed;
+// message is the plain-text fallback saying why.
+func (p *RedirectPages) Expired(w http.ResponseWriter, r *http.Request, shortCode, message string) {
+	if p == nil || p.expired == nil {
+		http.Error(w, message, http.StatusGone)
+		return
+	}
+	p.render(w, r, p.expired, http.StatusGone, shortCode, message)
+}
+
+// Root answers the root path: a redirect to the configured marketing URL,
+// else the landing page, else a 404.
+func (p *RedirectPages) Root(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case p != nil && p.rootURL != "":
+		http.Redirect(w, r, p.rootURL, http.StatusFound)
+	case p != nil && p.landing != nil:
+		p.render(w, r, p.landing, http.StatusOK, "", "404 page not found")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// Preview answers 200 with the preview page for a link to destination, in
+// place of a redirect there. title is the destination page's title, or ""
+// if it is not known. A destination that is not http(s) is shown without
+// its favicon and is never followed automatically. Should the page fail to
+// render, the visitor is redirected as usual.
+func (p *RedirectPages) Preview(w http.ResponseWriter, r *http.Request, shortCode, destination, title string) {
+	tmpl, delay := defaultPreview, time.Duration(0)
+	if p != nil {
+		tmpl, delay = p.preview, p.previewDelay
+	}
+
+	data := PreviewData{
+		PageData:    PageData{ShortCode: shortCode, Host: r.Host},
+		Destination: destination,
+		Title:       title,
+	}
+	if u, err := url.Parse(destination); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		data.DestinationHost = u.Hostname()
+		data.FaviconURL = (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/favicon.ico"}).String()
+		data.Delay = int((delay + time.Second - 1) / time.Second)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		if p != nil {
+			p.log.Error("Failed to render %s: %v", tmpl.Name(), err)
+		}
+		http.Redirect(w, r, destination, http.StatusFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// Favicon answers /favicon.ico with the configured icon, or 204 No Content
+// when there is none, so browsers stop asking without a 404 in the logs.
+func (p *RedirectPages) Favicon(w http.ResponseWriter, r *http.Request) {
+	if p == nil || p.favicon == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", p.faviconType)
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(p.favicon)
+}
+
+// Robots answers /robots.txt with the configured file, or one that allows
+// everything.
+func (p *RedirectPages) Robots(w http.ResponseWriter, r *http.Request) {
+	robots := defaultRobots
+	if p != nil {
+		robots = p.robots
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(robots)
+}
+
+// faviconType picks the icon's content type from its extension, falling
+// back to sniffing the bytes for an unfamiliar one.
+func faviconType(path string, icon []byte) string {
+	switch filepath.Ext(path) {
+	case ".ico":
+		return "image/x-icon"
+	case ".png":
+		return "image/png"
+	case ".svg":
+		return "image/svg+xml"
+	}
+	return http.DetectContentType(icon)
+}
+
+// render executes tmpl into a buffer before writing anything, so a template
+// that fails part-way still yields a clean plain-text answer.
+func (p *RedirectPages) render(w http.ResponseWriter, r *http.Request, tmpl *template.Template, status int, shortCode, fallback string) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, PageData{ShortCode: shortCode, Host: r.Host}); err != nil {
+		p.log.Error("Failed to render %s: %v", tmpl.Name(), err)
+		if status == http.StatusOK {
+			status = http.StatusNotFound
+		}
+		http.Error(w, fallback, status)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
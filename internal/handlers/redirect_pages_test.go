@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/config"
+	"github.com/Varun5711/shorternit/internal/logger"
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+// writeTemplate writes body to a file in dir and returns its path.
+func writeTemplate(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newPagesTestHandler builds a redirect handler serving live, expired and
+// capped links, with pages loaded from cfg.
+func newPagesTestHandler(t *testing.T, cfg config.RedirectPagesConfig) *RedirectHandler {
+	t.Helper()
+	pages, err := LoadRedirectPages(cfg, logger.New("redirect-test"))
+	if err != nil {
+		t.Fatalf("LoadRedirectPages: %v", err)
+	}
+	h, _ := newLimitTestHandler(map[string]*pb.URL{
+		"live":   {ShortCode: "live", LongUrl: "https://example.com"},
+		"old":    {ShortCode: "old", LongUrl: "https://example.com", ExpiresAt: time.Now().Add(-time.Hour).Unix()},
+		"capped": {ShortCode: "capped", LongUrl: "https://example.com", MaxClicks: 1},
+	})
+	h.pages = pages
+	return h
+}
+
+func get(h *RedirectHandler, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.HandleRedirect(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func brandedConfig(t *testing.T) config.RedirectPagesConfig {
+	dir := t.TempDir()
+	return config.RedirectPagesConfig{
+		NotFoundTemplate: writeTemplate(t, dir, "404.html", `<h1>No link {{.ShortCode}} on {{.Host}}</h1>`),
+		ExpiredTemplate:  writeTemplate(t, dir, "expired.html", `<h1>Link {{.ShortCode}} has expired</h1>`),
+		LandingTemplate:  writeTemplate(t, dir, "landing.html", `<h1>Welcome to {{.Host}}</h1>`),
+	}
+}
+
+func TestRedirectPages_RenderTemplates(t *testing.T) {
+	h := newPagesTestHandler(t, brandedConfig(t))
+	get(h, "/capped") // uses up the only click
+
+	for _, tc := range []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/missing", http.StatusNotFound, "<h1>No link missing on example.com</h1>"},
+		{"/old", http.StatusGone, "<h1>Link old has expired</h1>"},
+		{"/capped", http.StatusGone, "<h1>Link capped has expired</h1>"},
+		{"/", http.StatusOK, "<h1>Welcome to example.com</h1>"},
+	} {
+		rec := get(h, tc.path)
+		if rec.Code != tc.code {
+			t.Errorf("%s: expected %d, got %d", tc.path, tc.code, rec.Code)
+		}
+		if got := rec.Body.String(); got != tc.body {
+			t.Errorf("%s: expected body %q, got %q", tc.path, tc.body, got)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+			t.Errorf("%s: expected an HTML content type, got %q", tc.path, ct)
+		}
+	}
+
+	if rec := get(h, "/live"); rec.Code != http.StatusFound {
+		t.Errorf("expected a live link to redirect, got %d", rec.Code)
+	}
+}
+
+func TestRedirectPages_EscapesShortCode(t *testing.T) {
+	h := newPagesTestHandler(t, brandedConfig(t))
+
+	body := get(h, "/<script>alert(1)</script>").Body.String()
+	if strings.Contains(body, "<script>") {
+		t.Errorf("expected the short code to be escaped, got %q", body)
+	}
+	if !strings.Contains(body, "&lt;script&gt;") {
+		t.Errorf("expected the escaped short code in the page, got %q", body)
+	}
+}
+
+func TestRedirectPages_PlainTextFallback(t *testing.T) {
+	h := newPagesTestHandler(t, config.RedirectPagesConfig{})
+	get(h, "/capped")
+
+	for _, tc := range []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/missing", http.StatusNotFound, "404 page not found\n"},
+		{"/old", http.StatusGone, "This link has expired\n"},
+		{"/capped", http.StatusGone, "This link has reached its click limit\n"},
+		{"/", http.StatusNotFound, "404 page not found\n"},
+	} {
+		rec := get(h, tc.path)
+		if rec.Code != tc.code || rec.Body.String() != tc.body {
+			t.Errorf("%s: expected %d %q, got %d %q", tc.path, tc.code, tc.body, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestRedirectPages_RootURL(t *testing.T) {
+	cfg := brandedConfig(t)
+	cfg.RootURL = "https://marketing.example.com/"
+	h := newPagesTestHandler(t, cfg)
+
+	rec := get(h, "/")
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != cfg.RootURL {
+		t.Errorf("expected a redirect to %s, got %d %q", cfg.RootURL, rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+// TestRedirectPages_ReservedPathsSkipLookup verifies that the root, the
+// favicon and robots.txt are answered without asking the URL service.
+func TestRedirectPages_ReservedPathsSkipLookup(t *testing.T) {
+	for _, cfg := range []config.RedirectPagesConfig{{}, brandedConfig(t)} {
+		h := newPagesTestHandler(t, cfg)
+		client := h.grpcClient.(*fakeURLClient)
+
+		for _, path := range []string{"/", "/favicon.ico", "/robots.txt"} {
+			get(h, path)
+		}
+		if client.calls != 0 {
+			t.Errorf("expected no URL service lookups, got %d", client.calls)
+		}
+	}
+}
+
+func TestRedirectPages_DefaultFaviconAndRobots(t *testing.T) {
+	h := newPagesTestHandler(t, config.RedirectPagesConfig{})
+
+	if rec := get(h, "/favicon.ico"); rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+		t.Errorf("expected an empty 204 favicon, got %d %q", rec.Code, rec.Body.String())
+	}
+	rec := get(h, "/robots.txt")
+	if rec.Code != http.StatusOK || rec.Body.String() != "User-agent: *\nDisallow:\n" {
+		t.Errorf("expected the allow-all robots.txt, got %d %q", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("expected a plain-text robots.txt, got %q", ct)
+	}
+}
+
+func TestRedirectPages_ConfiguredFaviconAndRobots(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.RedirectPagesConfig{
+		FaviconFile: writeTemplate(t, dir, "favicon.svg", `<svg xmlns="http://www.w3.org/2000/svg"/>`),
+		RobotsFile:  writeTemplate(t, dir, "robots.txt", "User-agent: *\nDisallow: /\n"),
+	}
+	h := newPagesTestHandler(t, cfg)
+
+	rec := get(h, "/favicon.ico")
+	if rec.Code != http.StatusOK || rec.Body.String() != `<svg xmlns="http://www.w3.org/2000/svg"/>` {
+		t.Errorf("expected the configured favicon, got %d %q", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "image/svg+xml" {
+		t.Errorf("expected an SVG content type, got %q", ct)
+	}
+	if rec := get(h, "/robots.txt"); rec.Body.String() != "User-agent: *\nDisallow: /\n" {
+		t.Errorf("expected the configured robots.txt, got %q", rec.Body.String())
+	}
+}
+
+func TestLoadRedirectPages_RejectsBadTemplates(t *testing.T) {
+	dir := t.TempDir()
+	log := logger.New("redirect-test")
+
+	if _, err := LoadRedirectPages(config.RedirectPagesConfig{NotFoundTemplate: filepath.Join(dir, "missing.html")}, log); err == nil {
+		t.Error("expected an error for a missing template file")
+	}
+	broken := writeTemplate(t, dir, "broken.html", `{{.ShortCode`)
+	if _, err := LoadRedirectPages(config.RedirectPagesConfig{ExpiredTemplate: broken}, log); err == nil {
+		t.Error("expected an error for a template that does not parse")
+	}
+	if _, err := LoadRedirectPages(config.RedirectPagesConfig{FaviconFile: filepath.Join(dir, "missing.ico")}, log); err == nil {
+		t.Error("expected an error for a missing favicon file")
+	}
+}
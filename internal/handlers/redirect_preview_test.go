@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/config"
+	"github.com/Varun5711/shorternit/internal/logger"
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+func newPreviewTestHandler() (*RedirectHandler, *recordingPublisher) {
+	h, _ := newLimitTestHandler(map[string]*pb.URL{
+		"peek":  {ShortCode: "peek", LongUrl: "https://example.com/a?b=1&c=2", Preview: true, Title: "Tom & <Jerry>"},
+		"plain": {ShortCode: "plain", LongUrl: "https://example.com"},
+	})
+	published := &recordingPublisher{}
+	h.clickProducer = published
+	return h, published
+}
+
+// TestHandleRedirect_PreviewPage verifies that a preview link answers 200
+// with a page naming its destination, escaped, and still records the click,
+// while a plain link redirects.
+func TestHandleRedirect_PreviewPage(t *testing.T) {
+	h, published := newPreviewTestHandler()
+
+	// The second request is served from the cache, which has no title.
+	for i := 0; i < 2; i++ {
+		rec := get(h, "/peek")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200 for a preview link, got %d", rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+			t.Errorf("expected an HTML page, got %q", ct)
+		}
+		if loc := rec.Header().Get("Location"); loc != "" {
+			t.Errorf("expected no Location on the preview page, got %q", loc)
+		}
+		body := rec.Body.String()
+		for _, want := range []string{
+			`href="https://example.com/a?b=1&amp;c=2"`,
+			`Tom &amp; &lt;Jerry&gt;`,
+			`src="https://example.com/favicon.ico"`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("expected the page to contain %s, got:\n%s", want, body)
+			}
+		}
+		if strings.Contains(body, "refresh") {
+			t.Error("expected no auto-redirect without a delay")
+		}
+	}
+	if len(published.events) != 2 {
+		t.Errorf("expected both previews recorded as clicks, got %d", len(published.events))
+	}
+
+	if rec := get(h, "/plain"); rec.Code != http.StatusFound {
+		t.Errorf("expected 302 for a link without preview, got %d", rec.Code)
+	}
+}
+
+// TestHandleRedirect_PreviewRequested verifies that ?preview=1 shows the
+// page for any link.
+func TestHandleRedirect_PreviewRequested(t *testing.T) {
+	h, _ := newPreviewTestHandler()
+
+	for path, want := range map[string]int{
+		"/plain?preview=1":    http.StatusOK,
+		"/plain?preview=true": http.StatusOK,
+		"/plain?preview=0":    http.StatusFound,
+		"/plain?preview=yes":  http.StatusFound,
+	} {
+		if rec := get(h, path); rec.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, rec.Code)
+		}
+	}
+}
+
+// TestRedirectPages_PreviewDelayAndTemplate verifies the configured delay
+// and template, and that a destination that is not http(s) is never
+// followed automatically.
+func TestRedirectPages_PreviewDelayAndTemplate(t *testing.T) {
+	pages, err := LoadRedirectPages(config.RedirectPagesConfig{PreviewDelay: 5 * time.Second}, logger.New("redirect-test"))
+	if err != nil {
+		t.Fatalf("LoadRedirectPages: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	pages.Preview(rec, httptest.NewRequest(http.MethodGet, "/abc", nil), "abc", "https://example.com/x", "")
+	if want := `content="5; url=https://example.com/x"`; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("expected the page to contain %s, got:\n%s", want, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	pages.Preview(rec, httptest.NewRequest(http.MethodGet, "/abc", nil), "abc", "javascript:alert(1)", "")
+	if body := rec.Body.String(); strings.Contains(body, "refresh") || strings.Contains(body, `href="javascript`) {
+		t.Errorf("expected an unsafe destination to be neither followed nor linked, got:\n%s", body)
+	}
+
+	cfg := config.RedirectPagesConfig{
+		PreviewTemplate: writeTemplate(t, t.TempDir(), "preview.html", `<a href="{{.Destination}}">{{.DestinationHost}}</a> via {{.ShortCode}}`),
+	}
+	if pages, err = LoadRedirectPages(cfg, logger.New("redirect-test")); err != nil {
+		t.Fatalf("LoadRedirectPages: %v", err)
+	}
+	rec = httptest.NewRecorder()
+	pages.Preview(rec, httptest.NewRequest(http.MethodGet, "/abc", nil), "abc", "https://example.com/x", "")
+	if want := `<a href="https://example.com/x">example.com</a> via abc`; rec.Body.String() != want {
+		t.Errorf("expected %q, got %q", want, rec.Body.String())
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/auth"
+	"github.com/Varun5711/shorternit/internal/cache"
+	"github.com/Varun5711/shorternit/internal/middleware"
+	userpb "github.com/Varun5711/shorternit/proto/user"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+)
+
+// TokenValidator validates the token of an owner asking for a fresh lookup
+// of their link. It is satisfied by userpb.UserServiceClient; tests
+// substitute a fixed answer.
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, in *userpb.ValidateTokenRequest, opts ...grpc.CallOption) (*userpb.ValidateTokenResponse, error)
+}
+
+// ownerCheckTimeout bounds the token check of a refresh, which holds up
+// the redirect.
+const ownerCheckTimeout = 2 * time.Second
+
+// ownerRefresh reports whether r asks for entry, a cached link, to be looked
+// up afresh: r is sent with Cache-Control: no-cache, as a browser's hard
+// reload is, and carries the token of the link's owner, either way
+// middleware.Token reads it. The owner can then check an edit at once
+// instead of waiting for the cached entry to expire: the entry looked up
+// replaces the cached one, which is dropped if the link is gone.
+//
+// Anyone else's no-cache is ignored, so it cannot be used to send every
+// request to the URL service. Only a token naming the owner is sent to the
+// user service to be validated; others are turned away unverified, so a
+// stream of made-up tokens costs nothing beyond the parse.
+func (h *RedirectHandler) ownerRefresh(r *http.Request, entry *cache.URLEntry) bool {
+	if h.owners == nil || entry.UserID == "" || !noCache(r.Header) {
+		return false
+	}
+	token := middleware.Token(r)
+	if token == "" || tokenUserID(token) != entry.UserID {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), ownerCheckTimeout)
+	defer cancel()
+	resp, err := h.owners.ValidateToken(ctx, &userpb.ValidateTokenRequest{Token: token})
+	if err != nil {
+		h.log.Warn("Failed to validate refresh token: %v", err)
+		return false
+	}
+	return resp.Valid && resp.UserId == entry.UserID
+}
+
+// tokenUserID returns the user ID token claims without checking its
+// signature, or "" if it is not a JWT. It only decides whether a token is
+// worth validating.
+func tokenUserID(token string) string {
+	var claims auth.Claims
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err != nil {
+		return ""
+	}
+	return claims.UserID
+}
+
+// noCache reports whether header asks caches to revalidate, with
+// Cache-Control: no-cache or the older Pragma: no-cache.
+func noCache(header http.Header) bool {
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(directive), "no-cache") {
+				return true
+			}
+		}
+	}
+	return strings.EqualFold(strings.TrimSpace(header.Get("Pragma")), "no-cache")
+}
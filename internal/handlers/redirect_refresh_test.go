@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/auth"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	userpb "github.com/Varun5711/shorternit/proto/user"
+	"google.golang.org/grpc"
+)
+
+// jwtValidator validates tokens as the user service would, counting the
+// calls.
+type jwtValidator struct {
+	jwt   *auth.JWTManager
+	calls int
+}
+
+func (v *jwtValidator) ValidateToken(ctx context.Context, in *userpb.ValidateTokenRequest, opts ...grpc.CallOption) (*userpb.ValidateTokenResponse, error) {
+	v.calls++
+	claims, err := v.jwt.ValidateToken(in.Token)
+	if err != nil {
+		return &userpb.ValidateTokenResponse{Valid: false}, nil
+	}
+	return &userpb.ValidateTokenResponse{Valid: true, UserId: claims.UserID}, nil
+}
+
+// refreshAs redirects abc with Cache-Control: no-cache and token, if any,
+// and returns the response.
+func refreshAs(h *RedirectHandler, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+	req.Header.Set("Cache-Control", "no-cache")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.HandleRedirect(rec, req)
+	return rec
+}
+
+// TestHandleRedirect_OwnerRefresh verifies that a link's owner, and only
+// its owner, can bypass the cached entry after an edit: the fresh lookup
+// is served and cached in place of the stale one.
+func TestHandleRedirect_OwnerRefresh(t *testing.T) {
+	link := &pb.URL{ShortCode: "abc", LongUrl: "https://old.example", UserId: "alice"}
+	h, _ := newLimitTestHandler(map[string]*pb.URL{"abc": link})
+	backend := h.grpcClient.(*fakeURLClient)
+	jwt := auth.NewJWTManager("refresh-test-secret", time.Hour)
+	owners := &jwtValidator{jwt: jwt}
+	h.owners = owners
+
+	token := func(m *auth.JWTManager, userID string) string {
+		tok, _, err := m.GenerateToken(userID, userID+"@example.com", "user")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+	alice, bob := token(jwt, "alice"), token(jwt, "bob")
+	forged := token(auth.NewJWTManager("some-other-secret", time.Hour), "alice")
+
+	if loc := get(h, "/abc").Header().Get("Location"); loc != "https://old.example" {
+		t.Fatalf("expected the original destination, got %q", loc)
+	}
+	link.LongUrl = "https://new.example"
+
+	for _, tc := range []struct {
+		name       string
+		token      string
+		wantChecks int
+	}{
+		{"anonymous", "", 0},
+		{"other user", bob, 0},
+		{"forged owner", forged, 1},
+	} {
+		owners.calls = 0
+		if loc := refreshAs(h, tc.token).Header().Get("Location"); loc != "https://old.example" {
+			t.Errorf("%s: expected the cached destination, got %q", tc.name, loc)
+		}
+		if owners.calls != tc.wantChecks {
+			t.Errorf("%s: expected %d token checks, got %d", tc.name, tc.wantChecks, owners.calls)
+		}
+	}
+	if backend.calls != 1 {
+		t.Fatalf("expected no lookups past the cache, got %d", backend.calls-1)
+	}
+
+	if loc := refreshAs(h, alice).Header().Get("Location"); loc != "https://new.example" {
+		t.Fatalf("expected the owner to get the edited destination, got %q", loc)
+	}
+	if loc := get(h, "/abc").Header().Get("Location"); loc != "https://new.example" {
+		t.Errorf("expected the refreshed entry cached, got %q", loc)
+	}
+	if backend.calls != 2 {
+		t.Errorf("expected one fresh lookup, got %d", backend.calls-1)
+	}
+
+	// A link deleted since it was cached is dropped from the cache.
+	delete(backend.urls, "abc")
+	if rec := refreshAs(h, alice); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected the deleted link not found, got %d", rec.Code)
+	}
+	if rec := get(h, "/abc"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected the stale entry gone, got %d to %q", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+func TestNoCache(t *testing.T) {
+	for _, tc := range []struct {
+		header, value string
+		want          bool
+	}{
+		{"Cache-Control", "no-cache", true},
+		{"Cache-Control", "max-age=0, No-Cache", true},
+		{"Pragma", "no-cache", true},
+		{"Cache-Control", "max-age=0", false},
+		{"Cache-Control", "no-cache-please", false},
+	} {
+		header := http.Header{}
+		header.Set(tc.header, tc.value)
+		if got := noCache(header); got != tc.want {
+			t.Errorf("%s: %s: expected %v, got %v", tc.header, tc.value, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeHeaderValue(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   string
+		max  int
+		want string
+	}{
+		{"unchanged", "Mozilla/5.0 (X11; Linux x86_64)", 512, "Mozilla/5.0 (X11; Linux x86_64)"},
+		{"control characters", "evil\r\nX-Injected: 1\x00\x1b[31m\t", 512, "evilX-Injected: 1[31m"},
+		{"invalid UTF-8", "caf\xc3\xa9 \xff\xfe", 512, "café "},
+		{"truncated", strings.Repeat("a", 600), 512, strings.Repeat("a", 512)},
+		{"truncated on a character boundary", "ab" + strings.Repeat("é", 3), 5, "abé"},
+		{"empty", "", 512, ""},
+	} {
+		got := sanitizeHeaderValue(tc.in, tc.max)
+		if got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("%s: result %q is not valid UTF-8", tc.name, got)
+		}
+	}
+}
+
+// TestHandleRedirect_SanitizesClickEvent verifies that oversized and garbage
+// header values are cleaned before the click event is published.
+func TestHandleRedirect_SanitizesClickEvent(t *testing.T) {
+	h, _, published := newDedupTestHandler(false)
+
+	req := httptest.NewRequest(http.MethodGet, "/abc?"+strings.Repeat("q=1&", 1000), nil)
+	req.Header.Set("User-Agent", "Bot\x01/"+strings.Repeat("x", 1<<20))
+	req.Header.Set("Referer", "https://ref.example/\x7f"+strings.Repeat("p", 5000))
+	rec := httptest.NewRecorder()
+	h.HandleRedirect(rec, req)
+
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected 302, got %d", rec.Code)
+	}
+	if len(published.events) != 1 {
+		t.Fatalf("expected 1 published click, got %d", len(published.events))
+	}
+	ev := published.events[0]
+	if len(ev.UserAgent) != maxUserAgentLen || !strings.HasPrefix(ev.UserAgent, "Bot/xxx") {
+		t.Errorf("expected a clean %d-byte user agent, got %d bytes starting %q", maxUserAgentLen, len(ev.UserAgent), ev.UserAgent[:10])
+	}
+	if len(ev.Referer) != maxRefererLen || strings.ContainsRune(ev.Referer, '\x7f') {
+		t.Errorf("expected a clean %d-byte referer, got %d bytes", maxRefererLen, len(ev.Referer))
+	}
+	if len(ev.QueryParams) != maxQueryParamsLen {
+		t.Errorf("expected the query string cut to %d bytes, got %d", maxQueryParamsLen, len(ev.QueryParams))
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/cache"
+	"github.com/Varun5711/shorternit/internal/config"
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+// TestHandleRedirect_BeforeActivation verifies that a link whose active_from
+// is in the future answers 404 and does not consume a click.
+func TestHandleRedirect_BeforeActivation(t *testing.T) {
+	h, counter := newLimitTestHandler(map[string]*pb.URL{
+		"soon": {
+			ShortCode:  "soon",
+			LongUrl:    "https://example.com",
+			MaxClicks:  1,
+			ActiveFrom: time.Now().Add(time.Hour).Unix(),
+		},
+	})
+
+	if code := redirect(h, "soon"); code != http.StatusNotFound {
+		t.Fatalf("expected 404 before activation, got %d", code)
+	}
+	if len(counter.counts) != 0 {
+		t.Errorf("expected no click counted before activation, got %v", counter.counts)
+	}
+}
+
+// TestHandleRedirect_AfterActivation verifies that once active_from has
+// passed the link redirects normally.
+func TestHandleRedirect_AfterActivation(t *testing.T) {
+	h, _ := newLimitTestHandler(map[string]*pb.URL{
+		"live": {
+			ShortCode:  "live",
+			LongUrl:    "https://example.com",
+			ActiveFrom: time.Now().Add(-time.Minute).Unix(),
+		},
+	})
+
+	if code := redirect(h, "live"); code != http.StatusFound {
+		t.Fatalf("expected 302 after activation, got %d", code)
+	}
+}
+
+// TestHandleRedirect_ActivationFromCache ensures the cache fast path enforces
+// active_from on its own, without consulting the URL service.
+func TestHandleRedirect_ActivationFromCache(t *testing.T) {
+	client := &fakeURLClient{}
+	h, _ := newLimitTestHandler(nil)
+	h.grpcClient = client
+
+	_ = h.cache.SetURL(context.Background(), "url:soon", cache.URLEntry{
+		LongURL:    "https://example.com",
+		ActiveFrom: time.Now().Add(time.Hour).Unix(),
+	})
+	_ = h.cache.SetURL(context.Background(), "url:live", cache.URLEntry{
+		LongURL:    "https://example.com",
+		ActiveFrom: time.Now().Add(-time.Hour).Unix(),
+	})
+
+	if code := redirect(h, "soon"); code != http.StatusNotFound {
+		t.Errorf("expected 404 for cached link before activation, got %d", code)
+	}
+	if code := redirect(h, "live"); code != http.StatusFound {
+		t.Errorf("expected 302 for cached link after activation, got %d", code)
+	}
+	if client.calls != 0 {
+		t.Errorf("expected no gRPC calls on cache hits, got %d", client.calls)
+	}
+}
+
+// TestHandleRedirect_BeforeActivationLooksUnknown verifies that a cached link
+// not active yet gets the very response an unknown code does, so it does not
+// give away that the code is taken.
+func TestHandleRedirect_BeforeActivationLooksUnknown(t *testing.T) {
+	for _, cfg := range []config.RedirectPagesConfig{{}, brandedConfig(t)} {
+		h := newPagesTestHandler(t, cfg)
+		_ = h.cache.SetURL(context.Background(), "url:soon", cache.URLEntry{
+			LongURL:    "https://example.com",
+			ActiveFrom: time.Now().Add(time.Hour).Unix(),
+		})
+
+		soon, missing := get(h, "/soon"), get(h, "/missing")
+		if soon.Code != http.StatusNotFound || missing.Code != http.StatusNotFound {
+			t.Fatalf("expected 404 for both, got %d and %d", soon.Code, missing.Code)
+		}
+		// The pages name the code asked for; the rest must match.
+		want := strings.ReplaceAll(missing.Body.String(), "missing", "soon")
+		if got := soon.Body.String(); got != want {
+			t.Errorf("expected the not-found page %q, got %q", want, got)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/config"
+	grpcClient "github.com/Varun5711/shorternit/internal/grpc"
+	"github.com/Varun5711/shorternit/internal/middleware"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// fixedURLService is a url-service that knows one link.
+type fixedURLService struct {
+	pb.UnimplementedURLServiceServer
+	url *pb.URL
+}
+
+func (s *fixedURLService) GetURL(ctx context.Context, req *pb.GetURLRequest) (*pb.GetURLResponse, error) {
+	if req.ShortCode != s.url.ShortCode {
+		return &pb.GetURLResponse{}, nil
+	}
+	return &pb.GetURLResponse{Found: true, Url: s.url}, nil
+}
+
+// recordSpans installs a tracer provider that keeps every ended span in
+// memory, and the W3C propagator, for the rest of the test. Instrumentation
+// picks up the provider when it is created, so call it first.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return recorder
+}
+
+// findSpan waits up to a second for an ended span matching name and kind:
+// a gRPC server span ends after the client has its reply.
+func findSpan(t *testing.T, recorder *tracetest.SpanRecorder, name string, kind trace.SpanKind) sdktrace.ReadOnlySpan {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		for _, span := range recorder.Ended() {
+			if strings.HasSuffix(span.Name(), name) && span.SpanKind() == kind {
+				return span
+			}
+		}
+		if time.Now().After(deadline) {
+			var names []string
+			for _, span := range recorder.Ended() {
+				names = append(names, span.Name()+" ("+span.SpanKind().String()+")")
+			}
+			t.Fatalf("expected a %s span %s, got %v", kind, name, names)
+		}
+	}
+}
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key string) string {
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+// TestHandleRedirect_Trace verifies that a redirect that misses the cache
+// produces one trace: the request's span, with the cache lookup and the
+// url-service call as its children, and the url-service's own span under
+// the call, all tagged with the request ID.
+func TestHandleRedirect_Trace(t *testing.T) {
+	recorder := recordSpans(t)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	opts, err := grpcClient.ServerOptions(config.GRPCConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	srv := grpc.NewServer(opts...)
+	pb.RegisterURLServiceServer(srv, &fixedURLService{url: &pb.URL{ShortCode: "abc", LongUrl: "https://example.com"}})
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	client, err := grpcClient.NewURLServiceClient(lis.Addr().String(), config.GRPCConfig{DialTimeout: 2 * time.Second}, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h, _ := newLimitTestHandler(nil)
+	h.grpcClient = client
+	handler := middleware.RequestID(middleware.Tracing("redirect-service")(http.HandlerFunc(h.HandleRedirect)))
+
+	req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+	req.Header.Set("X-Request-ID", "trace-test")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected 302, got %d", rec.Code)
+	}
+
+	root := findSpan(t, recorder, "redirect-service", trace.SpanKindServer)
+	lookup := findSpan(t, recorder, "cache.Get", trace.SpanKindInternal)
+	call := findSpan(t, recorder, "/GetURL", trace.SpanKindClient)
+	served := findSpan(t, recorder, "/GetURL", trace.SpanKindServer)
+
+	traceID := root.SpanContext().TraceID()
+	if got := rec.Header().Get("X-Trace-ID"); got != traceID.String() {
+		t.Errorf("expected X-Trace-ID %s, got %q", traceID, got)
+	}
+	for _, tc := range []struct {
+		span   sdktrace.ReadOnlySpan
+		parent trace.SpanContext
+	}{
+		{lookup, root.SpanContext()},
+		{call, root.SpanContext()},
+		{served, call.SpanContext()},
+	} {
+		if tc.span.SpanContext().TraceID() != traceID {
+			t.Errorf("%s (%s): expected trace %s, got %s", tc.span.Name(), tc.span.SpanKind(), traceID, tc.span.SpanContext().TraceID())
+		}
+		if tc.span.Parent().SpanID() != tc.parent.SpanID() {
+			t.Errorf("%s (%s): expected parent %s, got %s", tc.span.Name(), tc.span.SpanKind(), tc.parent.SpanID(), tc.span.Parent().SpanID())
+		}
+	}
+	if got := spanAttribute(lookup, "cache.hit"); got != "false" {
+		t.Errorf("expected a cache miss recorded, got cache.hit=%q", got)
+	}
+	for _, span := range []sdktrace.ReadOnlySpan{root, served} {
+		if got := spanAttribute(span, string(middleware.RequestIDAttribute)); got != "trace-test" {
+			t.Errorf("%s (%s): expected request.id trace-test, got %q", span.Name(), span.SpanKind(), got)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/cache"
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+// TestPickVariant_MatchesWeights verifies that over many draws each variant
+// is served in proportion to its weight.
+func TestPickVariant_MatchesWeights(t *testing.T) {
+	variants := []cache.URLVariant{
+		{LongURL: "https://a.example", Weight: 1},
+		{LongURL: "https://b.example", Weight: 3},
+		{LongURL: "https://c.example", Weight: 6},
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	const draws = 100000
+	counts := make([]int, len(variants))
+	for i := 0; i < draws; i++ {
+		counts[pickVariant(variants, rng.IntN)-1]++
+	}
+
+	for i, want := range []float64{0.1, 0.3, 0.6} {
+		got := float64(counts[i]) / draws
+		if math.Abs(got-want) > 0.01 {
+			t.Errorf("variant %d: expected share %.2f, got %.3f", i+1, want, got)
+		}
+	}
+}
+
+// TestPickVariant_Boundaries pins the mapping of the extreme random values
+// onto the first and last variant.
+func TestPickVariant_Boundaries(t *testing.T) {
+	variants := []cache.URLVariant{{Weight: 2}, {Weight: 5}}
+
+	if got := pickVariant(variants, func(int) int { return 0 }); got != 1 {
+		t.Errorf("expected r=0 to pick variant 1, got %d", got)
+	}
+	if got := pickVariant(variants, func(int) int { return 1 }); got != 1 {
+		t.Errorf("expected r=1 to pick variant 1, got %d", got)
+	}
+	if got := pickVariant(variants, func(int) int { return 2 }); got != 2 {
+		t.Errorf("expected r=2 to pick variant 2, got %d", got)
+	}
+	if got := pickVariant(variants, func(n int) int { return n - 1 }); got != 2 {
+		t.Errorf("expected r=total-1 to pick variant 2, got %d", got)
+	}
+}
+
+// TestHandleRedirect_SplitLink verifies that a split link redirects to its
+// variants, both on the gRPC path and from cache.
+func TestHandleRedirect_SplitLink(t *testing.T) {
+	h, _ := newLimitTestHandler(map[string]*pb.URL{
+		"split": {
+			ShortCode: "split",
+			LongUrl:   "https://a.example",
+			Variants: []*pb.URLVariant{
+				{LongUrl: "https://a.example", Weight: 1},
+				{LongUrl: "https://b.example", Weight: 1},
+			},
+		},
+	})
+
+	seen := make(map[string]int)
+	for i := 0; i < 200; i++ {
+		rec := httptest.NewRecorder()
+		h.HandleRedirect(rec, httptest.NewRequest(http.MethodGet, "/split", nil))
+		if rec.Code != http.StatusFound {
+			t.Fatalf("expected 302, got %d", rec.Code)
+		}
+		seen[rec.Header().Get("Location")]++
+	}
+
+	if len(seen) != 2 || seen["https://a.example"] == 0 || seen["https://b.example"] == 0 {
+		t.Errorf("expected both variants to be served, got %v", seen)
+	}
+}
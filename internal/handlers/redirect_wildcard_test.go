@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/wildcard"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc"
+)
+
+// wildcardURLClient adds each domain's wildcard rules to fakeURLClient and
+// counts how often they are fetched.
+type wildcardURLClient struct {
+	*fakeURLClient
+	rules map[string][]*pb.WildcardRule
+	loads int
+}
+
+func (c *wildcardURLClient) GetWildcardRules(ctx context.Context, in *pb.GetWildcardRulesRequest, opts ...grpc.CallOption) (*pb.GetWildcardRulesResponse, error) {
+	c.loads++
+	return &pb.GetWildcardRulesResponse{Rules: c.rules[in.Domain]}, nil
+}
+
+func newWildcardTestHandler() (*RedirectHandler, *wildcardURLClient, *recordingPublisher) {
+	h, _ := newLimitTestHandler(map[string]*pb.URL{
+		"promo-real": {ShortCode: "promo-real", LongUrl: "https://example.com/real"},
+	})
+	client := &wildcardURLClient{
+		fakeURLClient: h.grpcClient.(*fakeURLClient),
+		rules: map[string][]*pb.WildcardRule{
+			"": {
+				{Prefix: "promo-", Destination: "https://shop.example/promo", SuffixMode: wildcard.SuffixPath},
+				{Prefix: "promo-vip-", Destination: "https://shop.example/vip?utm_source=sms", SuffixMode: wildcard.SuffixQuery, QueryParam: "member"},
+			},
+			"go.acme.com": {
+				{Prefix: "acme-", Destination: "https://acme.example/offers", SuffixMode: wildcard.SuffixPath},
+			},
+		},
+	}
+	h.grpcClient = client
+	h.defaultHost = "tiny.link"
+	h.wildcards = wildcard.NewCache(wildcardRules(client), time.Minute)
+	published := &recordingPublisher{}
+	h.clickProducer = published
+	return h, client, published
+}
+
+func getOnHost(h *RedirectHandler, host, code string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/"+code, nil)
+	req.Host = host
+	rec := httptest.NewRecorder()
+	h.HandleRedirect(rec, req)
+	return rec
+}
+
+// TestHandleRedirect_Wildcard verifies that a code that is not a link is
+// redirected through the longest matching wildcard of its domain, with the
+// rest of the code substituted, and recorded as a click of the code.
+func TestHandleRedirect_Wildcard(t *testing.T) {
+	h, client, published := newWildcardTestHandler()
+
+	cases := []struct {
+		host string
+		code string
+		want string // Location, "" for 404
+	}{
+		{"tiny.link", "promo-summer", "https://shop.example/promo/summer"},
+		{"tiny.link", "promo-vip-alice", "https://shop.example/vip?member=alice&utm_source=sms"},
+		{"tiny.link", "promo-real", "https://example.com/real"}, // the link wins
+		{"tiny.link", "promo-", ""},
+		{"tiny.link", "acme-deal", ""}, // another domain's rule
+		{"go.acme.com", "acme-deal", "https://acme.example/offers/deal"},
+		{"go.acme.com", "promo-summer", ""},
+	}
+	for pass := 0; pass < 2; pass++ {
+		for _, tc := range cases {
+			rec := getOnHost(h, tc.host, tc.code)
+			if tc.want == "" {
+				if rec.Code != http.StatusNotFound {
+					t.Errorf("pass %d: %s on %s: expected 404, got %d", pass, tc.code, tc.host, rec.Code)
+				}
+				continue
+			}
+			if rec.Code != http.StatusFound || rec.Header().Get("Location") != tc.want {
+				t.Errorf("pass %d: %s on %s: expected 302 to %s, got %d to %q", pass, tc.code, tc.host, tc.want, rec.Code, rec.Header().Get("Location"))
+			}
+		}
+	}
+
+	if client.loads != 2 {
+		t.Errorf("expected each domain's rules fetched once, got %d fetches", client.loads)
+	}
+	if len(published.events) != 8 || published.events[0].ShortCode != "promo-summer" || published.events[0].OriginalURL != "https://shop.example/promo/summer" {
+		t.Errorf("expected every redirect recorded under its own code, got %d events, first %+v", len(published.events), published.events[0])
+	}
+}
+
+// TestHandleRedirect_WildcardDisabled verifies that without a rule cache a
+// code that is not a link is 404 without fetching any rules.
+func TestHandleRedirect_WildcardDisabled(t *testing.T) {
+	h, client, _ := newWildcardTestHandler()
+	h.wildcards = nil
+
+	if rec := getOnHost(h, "tiny.link", "promo-summer"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 with wildcards disabled, got %d", rec.Code)
+	}
+	if client.loads != 0 {
+		t.Errorf("expected no rules fetched, got %d", client.loads)
+	}
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/analytics"
+	"github.com/Varun5711/shorternit/internal/clickhouse"
+	"github.com/Varun5711/shorternit/internal/config"
+	"github.com/Varun5711/shorternit/internal/database"
+	"github.com/Varun5711/shorternit/internal/events"
+	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/Varun5711/shorternit/internal/redis"
+)
+
+// StatsHandler serves GET /api/admin/stats, gathering the gateway's view of
+// backpressure in one place: the PostgreSQL, Redis and ClickHouse connection
+// pools, how far the click stream's consumer groups are behind, and how
+// often the stats cache is hit. It also serves GET /api/admin/pipeline, which
+// judges the click pipeline's health against the alert thresholds.
+type StatsHandler struct {
+	db         *database.DBManager
+	redis      *redis.RedisClient
+	stream     *events.Stream
+	analytics  *analytics.Service
+	clickhouse *clickhouse.Client
+	alerts     config.PipelineAlertConfig
+	log        *logger.Logger
+}
+
+// NewStatsHandler creates a StatsHandler over the gateway's clients and the
+// click stream the workers consume.
+func NewStatsHandler(db *database.DBManager, rc *redis.RedisClient, stream *events.Stream, svc *analytics.Service, ch *clickhouse.Client, alerts config.PipelineAlertConfig) *StatsHandler {
+	return &StatsHandler{
+		db:         db,
+		redis:      rc,
+		stream:     stream,
+		analytics:  svc,
+		clickhouse: ch,
+		alerts:     alerts,
+		log:        logger.New("stats-handler"),
+	}
+}
+
+// GetStats answers with a JSON snapshot of every pool and the stream lag.
+// Pool stats are read in-process; the stream is read from Redis, and if that
+// fails the stream section carries the error instead, so the rest of the
+// snapshot is still served while Redis is struggling.
+func (h *StatsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	stream := map[string]interface{}{"name": h.stream.Name()}
+	if length, err := h.stream.Length(r.Context()); err != nil {
+		h.log.Error("Failed to read stream length: %v", err)
+		stream["error"] = err.Error()
+	} else if groups, err := h.stream.Lag(r.Context()); err != nil {
+		h.log.Error("Failed to read stream lag: %v", err)
+		stream["error"] = err.Error()
+	} else {
+		stream["length"] = length
+		stream["groups"] = groups
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"database":    h.db.Stats(),
+		"redis":       h.redis.Stats(),
+		"clickhouse":  h.clickhouse.Stats(),
+		"stream":      stream,
+		"stats_cache": h.analytics.CacheStats(),
+	})
+}
+
+// pipelineGroup is one consumer group's entry in GET /api/admin/pipeline.
+// Alerts lists the thresholds it is past; it is empty while healthy.
+type pipelineGroup struct {
+	events.GroupLag
+	Workers []pipelineWorker `json:"workers"`
+	Alerts  []string         `json:"alerts"`
+}
+
+// pipelineWorker is when one consumer last flushed a batch.
+type pipelineWorker struct {
+	Consumer          string    `json:"consumer"`
+	LastFlush         time.Time `json:"last_flush"`
+	SecondsSinceFlush int64     `json:"seconds_since_flush"`
+}
+
+// GetPipeline answers with the health of every consumer group of the click
+// stream: its pending count, the age of its oldest unacknowledged entry and
+// when each of its workers last flushed a batch. The status is "degraded",
+// with 503 Service Unavailable, when any group is past a threshold of
+// h.alerts or the stream cannot be read, so the endpoint can be polled by
+// an alerting probe; otherwise it is "ok" with 200.
+func (h *StatsHandler) GetPipeline(w http.ResponseWriter, r *http.Request) {
+	lags, err := h.stream.Lag(r.Context())
+	if err != nil {
+		h.log.Error("Failed to read stream lag: %v", err)
+		respondJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"stream": h.stream.Name(),
+			"status": "degraded",
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	now := time.Now()
+	status, code := "ok", http.StatusOK
+	groups := make([]pipelineGroup, 0, len(lags))
+	for _, lag := range lags {
+		flushes, err := h.stream.LastFlushes(r.Context(), lag.Group)
+		if err != nil {
+			h.log.Error("Failed to read last flushes: %v", err)
+		}
+		group := checkPipelineGroup(lag, flushes, h.alerts, now)
+		if len(group.Alerts) > 0 {
+			status, code = "degraded", http.StatusServiceUnavailable
+		}
+		groups = append(groups, group)
+	}
+
+	respondJSON(w, code, map[string]interface{}{
+		"stream": h.stream.Name(),
+		"status": status,
+		"groups": groups,
+	})
+}
+
+// checkPipelineGroup reports lag and the workers' flushes, checked against
+// alerts at now. The flush check uses the group's most recent flush, so a
+// worker that was scaled away does not keep the group degraded, and only
+// applies while the group has entries left: an idle group has nothing to
+// flush.
+func checkPipelineGroup(lag events.GroupLag, flushes map[string]time.Time, alerts config.PipelineAlertConfig, now time.Time) pipelineGroup {
+	group := pipelineGroup{GroupLag: lag, Workers: []pipelineWorker{}, Alerts: []string{}}
+
+	var latest time.Time
+	for consumer, at := range flushes {
+		group.Workers = append(group.Workers, pipelineWorker{
+			Consumer:          consumer,
+			LastFlush:         at.UTC(),
+			SecondsSinceFlush: int64(now.Sub(at) / time.Second),
+		})
+		if at.After(latest) {
+			latest = at
+		}
+	}
+	slices.SortFunc(group.Workers, func(a, b pipelineWorker) int {
+		return b.LastFlush.Compare(a.LastFlush)
+	})
+
+	if alerts.MaxPending > 0 && lag.Pending > alerts.MaxPending {
+		group.Alerts = append(group.Alerts, fmt.Sprintf("%d entries pending, more than %d", lag.Pending, alerts.MaxPending))
+	}
+	if age := time.Duration(lag.OldestPendingSeconds) * time.Second; alerts.MaxPendingAge > 0 && age > alerts.MaxPendingAge {
+		group.Alerts = append(group.Alerts, fmt.Sprintf("oldest pending entry is %s old, more than %s", age, alerts.MaxPendingAge))
+	}
+	if alerts.MaxFlushAge > 0 && lag.Lag > 0 {
+		if latest.IsZero() {
+			group.Alerts = append(group.Alerts, "no worker has flushed a batch")
+		} else if since := now.Sub(latest).Truncate(time.Second); since > alerts.MaxFlushAge {
+			group.Alerts = append(group.Alerts, fmt.Sprintf("last flush was %s ago, more than %s", since, alerts.MaxFlushAge))
+		}
+	}
+	return group
+}
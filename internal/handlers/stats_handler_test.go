@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/config"
+	"github.com/Varun5711/shorternit/internal/events"
+)
+
+func TestCheckPipelineGroup(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	alerts := config.PipelineAlertConfig{MaxPending: 100, MaxPendingAge: time.Minute, MaxFlushAge: time.Minute}
+	recent := map[string]time.Time{
+		"worker-1": now.Add(-10 * time.Second),
+		"worker-2": now.Add(-time.Hour), // scaled away
+	}
+
+	tests := []struct {
+		name    string
+		lag     events.GroupLag
+		flushes map[string]time.Time
+		alerts  config.PipelineAlertConfig
+		want    []string
+	}{
+		{"healthy", events.GroupLag{Pending: 5, Lag: 5, OldestPendingSeconds: 3}, recent, alerts, nil},
+		{"too many pending", events.GroupLag{Pending: 500, Lag: 500}, recent, alerts, []string{"500 entries pending"}},
+		{"old pending entry", events.GroupLag{Pending: 1, Lag: 1, OldestPendingSeconds: 120}, recent, alerts, []string{"oldest pending entry is 2m0s old"}},
+		{"stale flush", events.GroupLag{Lag: 3}, map[string]time.Time{"worker-1": now.Add(-5 * time.Minute)}, alerts, []string{"last flush was 5m0s ago"}},
+		{"never flushed", events.GroupLag{Lag: 3}, nil, alerts, []string{"no worker has flushed"}},
+		{"idle", events.GroupLag{}, map[string]time.Time{"worker-1": now.Add(-time.Hour)}, alerts, nil},
+		{"checks disabled", events.GroupLag{Pending: 500, Lag: 500, OldestPendingSeconds: 600}, nil, config.PipelineAlertConfig{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := checkPipelineGroup(tt.lag, tt.flushes, tt.alerts, now)
+			if len(group.Alerts) != len(tt.want) {
+				t.Fatalf("expected alerts %v, got %v", tt.want, group.Alerts)
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(group.Alerts[i], want) {
+					t.Errorf("expected alert %d to start with %q, got %q", i, want, group.Alerts[i])
+				}
+			}
+		})
+	}
+
+	group := checkPipelineGroup(events.GroupLag{}, recent, alerts, now)
+	if len(group.Workers) != 2 || group.Workers[0].Consumer != "worker-1" || group.Workers[0].SecondsSinceFlush != 10 {
+		t.Errorf("expected worker-1 listed first, flushed 10s ago, got %+v", group.Workers)
+	}
+}
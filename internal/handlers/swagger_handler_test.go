@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// specPath is the gateway's OpenAPI spec relative to this package.
+const specPath = "../../api/openapi/api-gateway.yaml"
+
+// TestSwaggerHandler_ServesSpec verifies that /openapi.yaml returns the
+// shipped spec as YAML.
+func TestSwaggerHandler_ServesSpec(t *testing.T) {
+	mux := http.NewServeMux()
+	NewSwaggerHandler(specPath).RegisterRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.yaml", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-yaml" {
+		t.Errorf("expected YAML content type, got %q", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"openapi: 3.", "/api/auth/login:", "/api/urls/custom:", "/api/analytics/{shortCode}/stats:", "/api/search:"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected spec to contain %q", want)
+		}
+	}
+}
+
+// TestSwaggerHandler_ServesUI verifies that /docs, with or without a trailing
+// slash, serves the Swagger UI pointed at the spec.
+func TestSwaggerHandler_ServesUI(t *testing.T) {
+	mux := http.NewServeMux()
+	NewSwaggerHandler(specPath).RegisterRoutes(mux)
+
+	for _, path := range []string{"/docs", "/docs/"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", path, rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+			t.Errorf("%s: expected HTML, got %q", path, ct)
+		}
+		if body := rec.Body.String(); !strings.Contains(body, "SwaggerUIBundle") || !strings.Contains(body, `url: "/openapi.yaml"`) {
+			t.Errorf("%s: expected the Swagger UI loading /openapi.yaml", path)
+		}
+	}
+}
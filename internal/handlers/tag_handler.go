@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+// GetTags handles GET /api/tags, returning the authenticated user's distinct
+// tags with the number of links carrying each, most used first. Clients use
+// it to build the tag filter for GET /api/urls?tag=<tag>.
+func (h *HTTPHandler) GetTags(w http.ResponseWriter, r *http.Request) {
+	grpcResp, err := h.grpcClient.GetTags(r.Context(), &pb.GetTagsRequest{
+		UserId: middleware.GetUserID(r.Context()),
+	})
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to get tags")
+		return
+	}
+
+	tags := make([]models.TagCount, len(grpcResp.Tags))
+	for i, tc := range grpcResp.Tags {
+		tags[i] = models.TagCount{Tag: tc.Tag, Count: tc.Count}
+	}
+
+	respondJSON(w, http.StatusOK, models.TagListResponse{Tags: tags})
+}
+
+// UpdateURLTags handles PUT /api/urls/{code}/tags, replacing the tags on one
+// of the authenticated user's links and returning the normalized result.
+func (h *HTTPHandler) UpdateURLTags(w http.ResponseWriter, r *http.Request) {
+	shortCode := r.PathValue("code")
+	if shortCode == "" {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "short code is required")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	var req models.UpdateTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, models.ErrCodeInvalidJSON, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	grpcResp, err := h.grpcClient.UpdateURLTags(r.Context(), &pb.UpdateURLTagsRequest{
+		ShortCode: shortCode,
+		UserId:    middleware.GetUserID(r.Context()),
+		Tags:      req.Tags,
+	})
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to update tags")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.UpdateTagsRequest{Tags: grpcResp.Tags})
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/Varun5711/shorternit/internal/validation"
+)
+
+// minPasswordLength is the shortest password the user-service accepts.
+const minPasswordLength = 8
+
+// writeValidationError answers r with 400 and every problem res recorded.
+func writeValidationError(w http.ResponseWriter, r *http.Request, res *validation.Result) {
+	middleware.WriteErrorResponse(w, r, http.StatusBadRequest, res.Response())
+}
+
+// validateRegister checks a registration body. The user-service checks the
+// same rules, and whether the email is taken, but stops at the first.
+func validateRegister(req RegisterRequest) *validation.Result {
+	res := &validation.Result{}
+	if strings.TrimSpace(req.Name) == "" {
+		res.Add("name", "name is required")
+	}
+	if req.Email == "" {
+		res.Add("email", "email is required")
+	} else {
+		res.Check("email", validation.ValidateEmail(req.Email))
+	}
+	switch {
+	case req.Password == "":
+		res.Add("password", "password is required")
+	case len(req.Password) < minPasswordLength:
+		res.Add("password", fmt.Sprintf("password must be at least %d characters", minPasswordLength))
+	}
+	return res
+}
+
+// validateLogin checks that a login body has both credentials. Their format
+// is not checked: a malformed email simply matches no account.
+func validateLogin(req LoginRequest) *validation.Result {
+	res := &validation.Result{}
+	if req.Email == "" {
+		res.Add("email", "email is required")
+	}
+	if req.Password == "" {
+		res.Add("password", "password is required")
+	}
+	return res
+}
+
+// validateCreateURL checks the body of a new link. With A/B variants
+// long_url may be left out; the url-service then uses the first variant's.
+func validateCreateURL(req models.CreateURLRequest) *validation.Result {
+	res := &validation.Result{}
+	switch {
+	case req.LongURL == "" && len(req.Variants) == 0:
+		res.Add("long_url", "long_url is required")
+	case req.LongURL != "":
+		checkURL(res, "long_url", req.LongURL)
+	}
+	for i, v := range req.Variants {
+		checkURL(res, fmt.Sprintf("variants[%d].long_url", i), v.LongURL)
+	}
+	for i, rule := range req.GeoRules {
+		checkURL(res, fmt.Sprintf("geo_rules[%d].long_url", i), rule.LongURL)
+	}
+	if req.BackupURL != "" {
+		checkURL(res, "backup_url", req.BackupURL)
+	}
+	checkLinkLimits(res, req.MaxClicks, req.Tags)
+	return res
+}
+
+// validateCreateCustomURL checks the body of a new link with a custom
+// alias. Whether the alias is free is left to the url-service.
+func validateCreateCustomURL(req models.CreateCustomURLRequest) *validation.Result {
+	res := &validation.Result{}
+	if req.Alias == "" {
+		res.Add("alias", "alias is required")
+	} else {
+		res.Check("alias", validation.ValidateAlias(req.Alias))
+	}
+	if req.LongURL == "" {
+		res.Add("long_url", "long_url is required")
+	} else {
+		checkURL(res, "long_url", req.LongURL)
+	}
+	if req.BackupURL != "" {
+		checkURL(res, "backup_url", req.BackupURL)
+	}
+	checkLinkLimits(res, req.MaxClicks, req.Tags)
+	return res
+}
+
+// checkURL records field as invalid unless value is an http(s) URL.
+func checkURL(res *validation.Result, field, value string) {
+	if !isValidURL(value) {
+		res.AddCode(field, models.ErrCodeInvalidURL, "invalid URL format")
+	}
+}
+
+// checkLinkLimits checks the fields every new link has in common.
+func checkLinkLimits(res *validation.Result, maxClicks int64, tags []string) {
+	if maxClicks < 0 {
+		res.Add("max_clicks", "max_clicks must not be negative")
+	}
+	if _, err := validation.NormalizeTags(tags); err != nil {
+		res.Add("tags", err.Error())
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/models"
+)
+
+// fieldsOf returns the fields an error envelope reports, in order.
+func fieldsOf(body models.ErrorResponse) []string {
+	fields := make([]string, len(body.Errors))
+	for i, e := range body.Errors {
+		fields[i] = e.Field
+	}
+	return fields
+}
+
+// TestValidation_ReportsEveryProblem verifies that each validated endpoint
+// answers 400 with all of a body's problems, not just the first, before
+// calling a backend (whose clients are nil here).
+func TestValidation_ReportsEveryProblem(t *testing.T) {
+	auth := NewAuthHandler(nil, nil)
+	h := &HTTPHandler{}
+
+	for _, tc := range []struct {
+		name    string
+		handler http.HandlerFunc
+		body    string
+		want    []string
+	}{
+		{"register", auth.Register, `{"name":" ","email":"jane","password":"short"}`, []string{"name", "email", "password"}},
+		{"login", auth.Login, `{}`, []string{"email", "password"}},
+		{"create", h.CreateURL,
+			`{"long_url":"ftp://x","variants":[{"long_url":"https://a.example"},{"long_url":"nope"}],"geo_rules":[{"country_code":"DE","long_url":"mailto:x"}],"max_clicks":-1,"tags":["no spaces"]}`,
+			[]string{"long_url", "variants[1].long_url", "geo_rules[0].long_url", "max_clicks", "tags"}},
+		{"anonymous create", h.CreateAnonymousURL, `{"domain":"go.example.com"}`, []string{"long_url", "domain"}},
+		{"custom create", h.CreateCustomURL, `{"alias":"a!","long_url":"","backup_url":"nope","max_clicks":-5}`, []string{"alias", "long_url", "backup_url", "max_clicks"}},
+	} {
+		rec := httptest.NewRecorder()
+		tc.handler(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", tc.name, rec.Code)
+			continue
+		}
+		body := decodeError(t, rec)
+		if got := fieldsOf(body); strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("%s: expected errors on %v, got %v", tc.name, tc.want, got)
+		}
+		if body.Code != models.ErrCodeInvalidRequest {
+			t.Errorf("%s: expected code %s for mixed problems, got %s", tc.name, models.ErrCodeInvalidRequest, body.Code)
+		}
+	}
+}
+
+// TestValidation_SharedCode verifies that problems of one kind keep their
+// specific code in the envelope.
+func TestValidation_SharedCode(t *testing.T) {
+	h := &HTTPHandler{}
+	rec := httptest.NewRecorder()
+	h.CreateURL(rec, httptest.NewRequest(http.MethodPost, "/api/urls", strings.NewReader(`{"long_url":"nope","variants":[{"long_url":"ftp://x"}]}`)))
+
+	body := decodeError(t, rec)
+	if body.Code != models.ErrCodeInvalidURL || len(body.Errors) != 2 {
+		t.Fatalf("expected two INVALID_URL problems, got %+v", body)
+	}
+	for _, e := range body.Errors {
+		if e.Code != models.ErrCodeInvalidURL {
+			t.Errorf("expected %s on %s, got %s", models.ErrCodeInvalidURL, e.Field, e.Code)
+		}
+	}
+}
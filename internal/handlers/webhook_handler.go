@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateWebhook handles POST /api/webhooks. It registers a click webhook on
+// one of the authenticated user's short links and returns the signing secret,
+// which is not retrievable afterwards.
+func (h *HTTPHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	var req models.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, models.ErrCodeInvalidJSON, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	if req.ShortCode == "" {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "short_code is required")
+		return
+	}
+
+	if !isValidURL(req.TargetURL) {
+		writeError(w, r, models.ErrCodeInvalidURL, http.StatusBadRequest, "invalid target_url format")
+		return
+	}
+
+	grpcResp, err := h.grpcClient.RegisterWebhook(r.Context(), &pb.RegisterWebhookRequest{
+		ShortCode:          req.ShortCode,
+		UserId:             middleware.GetUserID(r.Context()),
+		TargetUrl:          req.TargetURL,
+		ClickThreshold:     req.ClickThreshold,
+		RateLimitPerMinute: req.RateLimitPerMinute,
+	})
+	if err != nil {
+		respondWebhookError(w, r, err, "failed to register webhook")
+		return
+	}
+
+	res := models.CreateWebhookResponse{
+		Webhook: *webhookFromProto(grpcResp.Webhook),
+		Secret:  grpcResp.Secret,
+	}
+
+	respondJSON(w, http.StatusCreated, res)
+}
+
+// ListWebhooks handles GET /api/webhooks?short_code=<code>, returning the
+// authenticated user's webhooks on that link including their failure state.
+func (h *HTTPHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	shortCode := r.URL.Query().Get("short_code")
+	if shortCode == "" {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "short_code is required")
+		return
+	}
+
+	grpcResp, err := h.grpcClient.ListWebhooks(r.Context(), &pb.ListWebhooksRequest{
+		ShortCode: shortCode,
+		UserId:    middleware.GetUserID(r.Context()),
+	})
+	if err != nil {
+		respondWebhookError(w, r, err, "failed to list webhooks")
+		return
+	}
+
+	webhooks := make([]*models.Webhook, len(grpcResp.Webhooks))
+	for i, wh := range grpcResp.Webhooks {
+		webhooks[i] = webhookFromProto(wh)
+	}
+
+	respondJSON(w, http.StatusOK, models.ListWebhooksResponse{Webhooks: webhooks})
+}
+
+// DeleteWebhook handles DELETE /api/webhooks/{id}. Only the owner can delete
+// a webhook; any other caller gets 404.
+func (h *HTTPHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/webhooks/")
+	if id == "" || strings.Contains(id, "/") {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "webhook id is required")
+		return
+	}
+
+	_, err := h.grpcClient.DeleteWebhook(r.Context(), &pb.DeleteWebhookRequest{
+		Id:     id,
+		UserId: middleware.GetUserID(r.Context()),
+	})
+	if err != nil {
+		respondWebhookError(w, r, err, "failed to delete webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondWebhookError writes the response for a failed webhook RPC. It
+// differs from respondGRPCError only for ResourceExhausted: the per-link cap
+// is a fixed quota, not a rate, so retrying will not help until a webhook is
+// deleted and 422 fits better than 429.
+func respondWebhookError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	if st := status.Convert(err); st.Code() == codes.ResourceExhausted {
+		writeError(w, r, models.ErrCodePreconditionFailed, http.StatusUnprocessableEntity, st.Message())
+		return
+	}
+	respondGRPCError(w, r, err, fallback)
+}
+
+// webhookFromProto converts a protobuf Webhook into the JSON response model.
+func webhookFromProto(wh *pb.Webhook) *models.Webhook {
+	var lastDelivered *time.Time
+	if wh.LastDeliveredAt > 0 {
+		t := time.Unix(wh.LastDeliveredAt, 0)
+		lastDelivered = &t
+	}
+	return &models.Webhook{
+		ID:                 wh.Id,
+		ShortCode:          wh.ShortCode,
+		TargetURL:          wh.TargetUrl,
+		ClickThreshold:     wh.ClickThreshold,
+		RateLimitPerMinute: wh.RateLimitPerMinute,
+		FailureCount:       wh.FailureCount,
+		Disabled:           wh.Disabled,
+		LastDeliveredAt:    lastDelivered,
+		CreatedAt:          time.Unix(wh.CreatedAt, 0),
+	}
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestRespondWebhookError_StatusMapping pins the HTTP status for each gRPC
+// code the webhook RPCs return.
+func TestRespondWebhookError_StatusMapping(t *testing.T) {
+	cases := []struct {
+		code codes.Code
+		want int
+	}{
+		{codes.InvalidArgument, http.StatusBadRequest},
+		{codes.Unauthenticated, http.StatusUnauthorized},
+		{codes.PermissionDenied, http.StatusForbidden},
+		{codes.NotFound, http.StatusNotFound},
+		{codes.ResourceExhausted, http.StatusUnprocessableEntity},
+		{codes.Unimplemented, http.StatusNotImplemented},
+		{codes.Internal, http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		respondWebhookError(rec, httptest.NewRequest(http.MethodGet, "/api/webhooks", nil), status.Error(tc.code, "boom"), "failed")
+		if rec.Code != tc.want {
+			t.Errorf("%v: expected %d, got %d", tc.code, tc.want, rec.Code)
+		}
+	}
+}
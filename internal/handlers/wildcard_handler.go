@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/middleware"
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+// CreateWildcardRule handles POST /api/wildcards. It claims a wildcard
+// alias such as "promo-*" for the authenticated user, so every code
+// starting with its prefix that is not a link of its own redirects to the
+// destination with the rest of the code added.
+func (h *HTTPHandler) CreateWildcardRule(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	var req models.CreateWildcardRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, models.ErrCodeInvalidJSON, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	if req.Pattern == "" || req.Destination == "" {
+		writeError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "pattern and destination are required")
+		return
+	}
+
+	grpcResp, err := h.grpcClient.CreateWildcardRule(r.Context(), &pb.CreateWildcardRuleRequest{
+		UserId:      middleware.GetUserID(r.Context()),
+		Pattern:     req.Pattern,
+		Destination: req.Destination,
+		Domain:      req.Domain,
+		SuffixMode:  req.SuffixMode,
+		QueryParam:  req.QueryParam,
+	})
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to create wildcard")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, wildcardRuleFromProto(grpcResp.Rule))
+}
+
+// ListWildcardRules handles GET /api/wildcards, returning the authenticated
+// user's wildcard aliases on every domain.
+func (h *HTTPHandler) ListWildcardRules(w http.ResponseWriter, r *http.Request) {
+	grpcResp, err := h.grpcClient.ListWildcardRules(r.Context(), &pb.ListWildcardRulesRequest{
+		UserId: middleware.GetUserID(r.Context()),
+	})
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to list wildcards")
+		return
+	}
+
+	rules := make([]*models.WildcardRule, len(grpcResp.Rules))
+	for i, rule := range grpcResp.Rules {
+		rules[i] = wildcardRuleFromProto(rule)
+	}
+
+	respondJSON(w, http.StatusOK, models.ListWildcardRulesResponse{Rules: rules})
+}
+
+// DeleteWildcardRule handles DELETE /api/wildcards/{prefix}, where prefix is
+// the pattern without its "*". A rule on a custom domain is named with
+// ?domain=.
+func (h *HTTPHandler) DeleteWildcardRule(w http.ResponseWriter, r *http.Request) {
+	_, err := h.grpcClient.DeleteWildcardRule(r.Context(), &pb.DeleteWildcardRuleRequest{
+		UserId: middleware.GetUserID(r.Context()),
+		Prefix: r.PathValue("prefix"),
+		Domain: r.URL.Query().Get("domain"),
+	})
+	if err != nil {
+		respondGRPCError(w, r, err, "failed to delete wildcard")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// wildcardRuleFromProto converts a protobuf WildcardRule into the JSON
+// response model.
+func wildcardRuleFromProto(r *pb.WildcardRule) *models.WildcardRule {
+	return &models.WildcardRule{
+		Domain:      r.Domain,
+		Prefix:      r.Prefix,
+		Pattern:     r.Prefix + "*",
+		Destination: r.Destination,
+		SuffixMode:  r.SuffixMode,
+		QueryParam:  r.QueryParam,
+		CreatedAt:   time.Unix(r.CreatedAt, 0),
+	}
+}
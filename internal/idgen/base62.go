@@ -1,6 +1,9 @@
 package idgen
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // base62Chars defines the 62-character alphabet used for encoding. The order
 // is digits (0-9), uppercase letters (A-Z), then lowercase letters (a-z).
@@ -74,6 +77,25 @@ func Encode(num int64) string {
 	return string(res)
 }
 
+// EncodePadded is Encode left-padded with '0' to at least minLength
+// characters, so that the very first IDs do not become one- or two-character
+// short codes. The padding is reversible without a marker: Encode never
+// emits a leading '0' (except for zero itself), and a leading '0' adds
+// nothing to Decode's result. Codes already minLength or longer are
+// returned unchanged.
+//
+// Examples:
+//
+//	EncodePadded(61, 6)         => "00000z"
+//	EncodePadded(1234567890, 4) => "1LY7VK"
+func EncodePadded(num int64, minLength int) string {
+	code := Encode(num)
+	if len(code) >= minLength {
+		return code
+	}
+	return strings.Repeat("0", minLength-len(code)) + code
+}
+
 // Decode converts a base62-encoded string back to its int64 value. It
 // processes each character left-to-right, multiplying the accumulator by 62
 // and adding the character's positional value (Horner's method).
@@ -196,6 +196,31 @@ func BenchmarkEncodeSmall(b *testing.B) {
 	}
 }
 
+// TestEncodePadded verifies that padded codes are always at least the
+// minimum length, still decode to the original ID, and that codes already
+// that long are left unchanged.
+func TestEncodePadded(t *testing.T) {
+	for _, minLength := range []int{0, 1, 6, 11} {
+		for _, id := range []int64{0, 1, 61, 62, 3843, 1234567890, math.MaxInt64} {
+			code := EncodePadded(id, minLength)
+			if len(code) < minLength {
+				t.Errorf("EncodePadded(%d, %d) = %q; shorter than %d", id, minLength, code, minLength)
+			}
+			if plain := Encode(id); len(plain) >= minLength && code != plain {
+				t.Errorf("EncodePadded(%d, %d) = %q; want unchanged %q", id, minLength, code, plain)
+			}
+			decoded, err := Decode(code)
+			if err != nil || decoded != id {
+				t.Errorf("Decode(%q) = %d, %v; want %d", code, decoded, err, id)
+			}
+		}
+	}
+
+	if code := EncodePadded(61, 6); code != "00000z" {
+		t.Errorf("EncodePadded(61, 6) = %q; want %q", code, "00000z")
+	}
+}
+
 // BenchmarkDecodeSmall measures decoding throughput for a short base62 string.
 func BenchmarkDecodeSmall(b *testing.B) {
 	encoded := Encode(125)
@@ -0,0 +1,168 @@
+package idgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrNoFreeWorkerID is returned by AllocateWorkerID when every worker ID of
+// the datacenter is leased.
+var ErrNoFreeWorkerID = errors.New("no free snowflake worker ID")
+
+// renewLeaseScript extends the lease if this holder still has it, and takes
+// it back if it lapsed, say while Redis was unreachable for longer than the
+// TTL, and nobody else has claimed it since. It returns 0 when someone has.
+const renewLeaseScript = `
+	if redis.call("GET", KEYS[1]) == ARGV[1] then
+		return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	end
+	if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+		return 1
+	end
+	return 0
+`
+
+// releaseLeaseScript deletes the lease only if this holder still has it,
+// like lock.DistributedLock's Release.
+const releaseLeaseScript = `
+	if redis.call("GET", KEYS[1]) == ARGV[1] then
+		return redis.call("DEL", KEYS[1])
+	end
+	return 0
+`
+
+// WorkerLease is a Snowflake worker ID claimed in Redis, so replicas that
+// share a configuration still get distinct IDs. The lease is a key with a
+// TTL, kept alive by a heartbeat for as long as the process runs; a
+// replica that dies without releasing it frees the ID once the TTL passes.
+type WorkerLease struct {
+	client *redis.Client
+	key    string
+	token  string // unique to this holder, like a lock value
+	id     int64
+	ttl    time.Duration
+
+	lost     chan struct{} // closed when another holder takes the ID
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// workerLeaseKey is the Redis key of the lease on workerID in datacenterID.
+func workerLeaseKey(datacenterID, workerID int64) string {
+	return fmt.Sprintf("snowflake:worker:%d:%d", datacenterID, workerID)
+}
+
+// AllocateWorkerID claims the lowest worker ID of datacenterID that no other
+// process holds, for ttl at a time, and starts renewing the claim every
+// third of ttl until Release. It returns ErrNoFreeWorkerID when all 32 are
+// taken.
+func AllocateWorkerID(ctx context.Context, client *redis.Client, datacenterID int64, ttl time.Duration) (*WorkerLease, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("worker ID lease TTL must be positive")
+	}
+	token := uuid.NewString()
+	for id := int64(0); id <= maxWorkerID; id++ {
+		key := workerLeaseKey(datacenterID, id)
+		ok, err := client.SetNX(ctx, key, token, ttl).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to lease snowflake worker ID: %w", err)
+		}
+		if !ok {
+			continue
+		}
+		l := &WorkerLease{
+			client: client,
+			key:    key,
+			token:  token,
+			id:     id,
+			ttl:    ttl,
+			lost:   make(chan struct{}),
+			stop:   make(chan struct{}),
+			done:   make(chan struct{}),
+		}
+		go l.heartbeat()
+		return l, nil
+	}
+	return nil, ErrNoFreeWorkerID
+}
+
+// ID returns the leased worker ID.
+func (l *WorkerLease) ID() int64 {
+	return l.id
+}
+
+// Lost is closed if another process takes the ID over, which can only
+// happen after this one failed to renew it for a whole TTL. IDs generated
+// from then on may collide with theirs.
+func (l *WorkerLease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+func (l *WorkerLease) heartbeat() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), l.ttl/3)
+			held, err := l.renew(ctx)
+			cancel()
+			if err == nil && !held {
+				close(l.lost)
+				return
+			}
+			// On an error the lease is retried at the next tick, and
+			// taken back then if it lapsed in the meantime.
+		}
+	}
+}
+
+// renew extends the lease, reporting false if another holder has it.
+func (l *WorkerLease) renew(ctx context.Context) (bool, error) {
+	n, err := l.client.Eval(ctx, renewLeaseScript, []string{l.key}, l.token, l.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to renew snowflake worker ID lease: %w", err)
+	}
+	return n == 1, nil
+}
+
+// Release stops the heartbeat and frees the ID for the next replica. It
+// is safe to call on a nil lease and more than once.
+func (l *WorkerLease) Release(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.stopOnce.Do(func() { close(l.stop) })
+	<-l.done
+	if err := l.client.Eval(ctx, releaseLeaseScript, []string{l.key}, l.token).Err(); err != nil {
+		return fmt.Errorf("failed to release snowflake worker ID lease: %w", err)
+	}
+	return nil
+}
+
+// PodOrdinal returns the ordinal a StatefulSet appends to its pod names,
+// 2 for "url-service-2". It reports false for a name without one, such as
+// a Deployment's "url-service-7d9f8b6c4-x2kqz".
+func PodOrdinal(podName string) (int64, bool) {
+	i := strings.LastIndexByte(podName, '-')
+	if i < 0 {
+		return 0, false
+	}
+	ordinal, err := strconv.ParseInt(podName[i+1:], 10, 64)
+	if err != nil || ordinal < 0 {
+		return 0, false
+	}
+	return ordinal, true
+}
@@ -0,0 +1,175 @@
+package idgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// leaseStore answers the commands WorkerLease sends from a map instead of a
+// Redis server. TTLs are not kept; a test expires a lease by deleting it.
+type leaseStore struct {
+	mu   sync.Mutex
+	keys map[string]string
+}
+
+func (s *leaseStore) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (s *leaseStore) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		args := cmd.Args()
+		switch c := cmd.(type) {
+		case *redis.BoolCmd: // SET key token PX ttl NX
+			key, token := args[1].(string), args[2].(string)
+			_, taken := s.keys[key]
+			if !taken {
+				s.keys[key] = token
+			}
+			c.SetVal(!taken)
+		case *redis.Cmd: // EVAL script 1 key token [ttl]
+			key, token := args[3].(string), args[4].(string)
+			held := s.keys[key] == token
+			switch args[1] {
+			case renewLeaseScript:
+				if _, taken := s.keys[key]; !taken {
+					s.keys[key], held = token, true
+				}
+			case releaseLeaseScript:
+				if held {
+					delete(s.keys, key)
+				}
+			}
+			if held {
+				c.SetVal(int64(1))
+			} else {
+				c.SetVal(int64(0))
+			}
+		default:
+			cmd.SetErr(fmt.Errorf("unexpected command %v", args))
+			return cmd.Err()
+		}
+		return nil
+	}
+}
+
+func (s *leaseStore) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func newLeaseClient() (*redis.Client, *leaseStore) {
+	store := &leaseStore{keys: make(map[string]string)}
+	client := redis.NewClient(&redis.Options{})
+	client.AddHook(store)
+	return client, store
+}
+
+func TestAllocateWorkerID_DistinctIDs(t *testing.T) {
+	ctx := context.Background()
+	client, store := newLeaseClient()
+
+	var leases []*WorkerLease
+	for want := int64(0); want <= maxWorkerID; want++ {
+		lease, err := AllocateWorkerID(ctx, client, 1, time.Minute)
+		if err != nil {
+			t.Fatalf("lease %d: %v", want, err)
+		}
+		if lease.ID() != want {
+			t.Errorf("expected worker ID %d, got %d", want, lease.ID())
+		}
+		leases = append(leases, lease)
+	}
+	if _, err := AllocateWorkerID(ctx, client, 1, time.Minute); !errors.Is(err, ErrNoFreeWorkerID) {
+		t.Errorf("expected ErrNoFreeWorkerID with every ID leased, got %v", err)
+	}
+	if lease, err := AllocateWorkerID(ctx, client, 2, time.Minute); err != nil || lease.ID() != 0 {
+		t.Errorf("expected another datacenter to start at 0, got %v, %v", lease, err)
+	}
+
+	if err := leases[3].Release(ctx); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if _, ok := store.keys[workerLeaseKey(1, 3)]; ok {
+		t.Error("expected the released lease to be deleted")
+	}
+	lease, err := AllocateWorkerID(ctx, client, 1, time.Minute)
+	if err != nil || lease.ID() != 3 {
+		t.Fatalf("expected the released ID 3 to be leased again, got %v, %v", lease, err)
+	}
+	if err := leases[3].Release(ctx); err != nil {
+		t.Errorf("expected a second Release to be a no-op, got %v", err)
+	}
+	if store.keys[workerLeaseKey(1, 3)] != lease.token {
+		t.Error("expected a stale Release to leave the new holder's lease alone")
+	}
+}
+
+func TestWorkerLease_Renew(t *testing.T) {
+	ctx := context.Background()
+	client, store := newLeaseClient()
+	lease, err := AllocateWorkerID(ctx, client, 1, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lease.Release(ctx)
+	key := workerLeaseKey(1, lease.ID())
+
+	if held, err := lease.renew(ctx); err != nil || !held {
+		t.Errorf("expected the lease to be renewed, got %v, %v", held, err)
+	}
+
+	delete(store.keys, key)
+	if held, _ := lease.renew(ctx); !held || store.keys[key] != lease.token {
+		t.Error("expected a lapsed lease to be taken back")
+	}
+
+	store.keys[key] = "someone-else"
+	if held, _ := lease.renew(ctx); held {
+		t.Error("expected a lease taken over by another holder to be reported lost")
+	}
+}
+
+func TestWorkerLease_HeartbeatReportsLoss(t *testing.T) {
+	ctx := context.Background()
+	client, store := newLeaseClient()
+	lease, err := AllocateWorkerID(ctx, client, 1, 30*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lease.Release(ctx)
+
+	store.mu.Lock()
+	store.keys[workerLeaseKey(1, lease.ID())] = "someone-else"
+	store.mu.Unlock()
+
+	select {
+	case <-lease.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("expected the heartbeat to report the lost lease")
+	}
+}
+
+func TestPodOrdinal(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		want int64
+		ok   bool
+	}{
+		{"url-service-0", 0, true},
+		{"url-service-12", 12, true},
+		{"url-service-7d9f8b6c4-x2kqz", 0, false},
+		{"url-service", 0, false},
+		{"", 0, false},
+	} {
+		got, ok := PodOrdinal(tc.name)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("PodOrdinal(%q): expected %d, %v, got %d, %v", tc.name, tc.want, tc.ok, got, ok)
+		}
+	}
+}
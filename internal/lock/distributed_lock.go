@@ -32,6 +32,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -47,6 +48,17 @@ var (
 	ErrLockNotHeld = errors.New("lock is not held")
 )
 
+// Locker is a lock bound to a single key. Code that takes a lock depends on
+// Locker rather than on *DistributedLock, so its tests can substitute the
+// in-process locks from the locktest package.
+type Locker interface {
+	// Acquire reports whether the lock was taken; false with a nil error
+	// means another holder owns it.
+	Acquire(ctx context.Context) (bool, error)
+	// Release frees the lock if this Locker still holds it.
+	Release(ctx context.Context) error
+}
+
 // DistributedLock represents a single lock instance bound to a Redis key.
 // Each instance carries a unique value so that Release can verify ownership
 // before deleting the key (see the Lua script in Release).
@@ -116,9 +128,9 @@ func (l *DistributedLock) Release(ctx context.Context) error {
 	return nil
 }
 
-// generateLockValue produces a timestamp-based token that is unique enough for
-// single-Redis deployments. In a high-contention or multi-Redis (Redlock)
-// scenario this should be replaced with a UUID or crypto-random string.
+// generateLockValue produces a random token, so two holders racing for the
+// same key -- even in the same microsecond on different replicas -- never
+// share one and cannot release each other's lock.
 func generateLockValue() string {
-	return time.Now().Format("20060102150405.000000")
+	return uuid.New().String()
 }
@@ -0,0 +1,112 @@
+// Package locktest provides in-process lock.Locker implementations for tests.
+//
+// A Table stands in for the Redis keyspace: locks created from the same
+// Table on the same key exclude each other exactly as DistributedLocks on a
+// shared Redis instance do, including the rule that only the holder may
+// release a lock. Locks may carry a TTL, measured on the Table's own clock so
+// tests can move time forward with Advance instead of sleeping.
+package locktest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/lock"
+)
+
+// Table holds the keys currently locked.
+type Table struct {
+	mu      sync.Mutex
+	held    map[string]hold
+	skipped time.Duration // added to time.Now by Advance
+}
+
+// hold is a held key: its holder and when it expires (zero for never).
+type hold struct {
+	holder  *Lock
+	expires time.Time
+}
+
+// NewTable returns a Table with no keys held.
+func NewTable() *Table {
+	return &Table{held: make(map[string]hold)}
+}
+
+// Lock returns a new lock on key that never expires. Each call returns a
+// distinct holder, as lock.NewDistributedLock does.
+func (t *Table) Lock(key string) *Lock {
+	return &Lock{table: t, key: key}
+}
+
+// LockWithTTL returns a new lock on key that expires ttl after it is
+// acquired.
+func (t *Table) LockWithTTL(key string, ttl time.Duration) *Lock {
+	return &Lock{table: t, key: key, ttl: ttl}
+}
+
+// Advance moves the Table's clock forward by d, expiring the locks whose TTL
+// has run out.
+func (t *Table) Advance(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.skipped += d
+}
+
+// Held reports whether key is currently locked.
+func (t *Table) Held(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.holder(key) != nil
+}
+
+// holder returns the live holder of key, or nil. t.mu must be held.
+func (t *Table) holder(key string) *Lock {
+	h, ok := t.held[key]
+	if !ok {
+		return nil
+	}
+	if !h.expires.IsZero() && !t.now().Before(h.expires) {
+		delete(t.held, key)
+		return nil
+	}
+	return h.holder
+}
+
+func (t *Table) now() time.Time {
+	return time.Now().Add(t.skipped)
+}
+
+// Lock is one holder's lock on a key of a Table. It satisfies lock.Locker.
+type Lock struct {
+	table *Table
+	key   string
+	ttl   time.Duration // zero for no expiry
+}
+
+// Acquire takes the key if no other holder owns it.
+func (l *Lock) Acquire(ctx context.Context) (bool, error) {
+	l.table.mu.Lock()
+	defer l.table.mu.Unlock()
+	if l.table.holder(l.key) != nil {
+		return false, nil
+	}
+	h := hold{holder: l}
+	if l.ttl > 0 {
+		h.expires = l.table.now().Add(l.ttl)
+	}
+	l.table.held[l.key] = h
+	return true, nil
+}
+
+// Release frees the key, or returns lock.ErrLockNotHeld if l does not own it
+// (including when its TTL has run out).
+func (l *Lock) Release(ctx context.Context) error {
+	l.table.mu.Lock()
+	defer l.table.mu.Unlock()
+	if l.table.holder(l.key) != l {
+		return lock.ErrLockNotHeld
+	}
+	delete(l.table.held, l.key)
+	return nil
+}
@@ -4,12 +4,14 @@
 // codebase can log with minimal ceremony while still getting the performance
 // benefits of Zap under the hood.
 //
-// Configuration is driven by two environment variables:
+// Configuration is driven by three environment variables:
 //
-//	LOG_LEVEL  -- DEBUG, WARN, ERROR; defaults to INFO.
-//	LOG_FORMAT -- "text" for human-readable console output;
-//	              anything else (including empty) produces JSON,
-//	              which is what the production ELK stack expects.
+//	LOG_LEVEL       -- DEBUG, WARN, ERROR; defaults to INFO.
+//	LOG_FORMAT      -- "text" for human-readable console output;
+//	                   anything else (including empty) produces JSON,
+//	                   which is what the production ELK stack expects.
+//	LOG_SAMPLE_RATE -- N to emit 1 in N DebugSampled calls per key;
+//	                   defaults to 1, which emits them all.
 //
 // An optional extra WriteSyncer can be provided at construction time to
 // duplicate log output to a secondary sink (e.g., Elasticsearch) without
@@ -17,8 +19,12 @@
 package logger
 
 import (
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -31,6 +37,7 @@ type Logger struct {
 	zap     *zap.Logger
 	sugar   *zap.SugaredLogger
 	service string
+	sampler *sampler
 }
 
 // New creates a Logger that writes to stdout only.
@@ -94,7 +101,15 @@ func newLogger(service string, extraSyncer zapcore.WriteSyncer) *Logger {
 		cores = append(cores, zapcore.NewCore(jsonEnc, extraSyncer, level))
 	}
 
-	core := zapcore.NewTee(cores...)
+	sampleRate, err := strconv.Atoi(os.Getenv("LOG_SAMPLE_RATE"))
+	if err != nil {
+		sampleRate = 1
+	}
+	return newWithCore(service, zapcore.NewTee(cores...), sampleRate)
+}
+
+// newWithCore wraps core in a Logger, which tests use to capture output.
+func newWithCore(service string, core zapcore.Core, sampleRate int) *Logger {
 	z := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
 	z = z.With(zap.String("service", service))
 
@@ -102,6 +117,7 @@ func newLogger(service string, extraSyncer zapcore.WriteSyncer) *Logger {
 		zap:     z,
 		sugar:   z.Sugar(),
 		service: service,
+		sampler: newSampler(sampleRate),
 	}
 }
 
@@ -114,6 +130,7 @@ func (l *Logger) With(key string, value interface{}) *Logger {
 		zap:     newZap,
 		sugar:   newZap.Sugar(),
 		service: l.service,
+		sampler: l.sampler,
 	}
 }
 
@@ -122,6 +139,23 @@ func (l *Logger) Debug(format string, args ...interface{}) {
 	l.sugar.Debugf(format, args...)
 }
 
+// DebugSampled logs a message at DEBUG level for only 1 in LOG_SAMPLE_RATE
+// calls with the same key, starting with the first, and tags it with the
+// rate so readers can scale what they see back up. It is meant for lines
+// on hot paths, such as the redirect's cache hits, that would otherwise
+// flood the logs whenever DEBUG is on. key names the call site, not the
+// request: a counter is kept per key for the life of the process. Calls
+// made while DEBUG is off are not counted.
+func (l *Logger) DebugSampled(key, format string, args ...interface{}) {
+	if !l.zap.Core().Enabled(zapcore.DebugLevel) {
+		return
+	}
+	if !l.sampler.sample(key) {
+		return
+	}
+	l.zap.Debug(fmt.Sprintf(format, args...), zap.Int("sample_rate", l.sampler.rate))
+}
+
 // Info logs a message at INFO level using Printf-style formatting.
 func (l *Logger) Info(format string, args ...interface{}) {
 	l.sugar.Infof(format, args...)
@@ -155,3 +189,29 @@ func (l *Logger) Sync() error {
 func (l *Logger) Zap() *zap.Logger {
 	return l.zap
 }
+
+// sampler counts calls per key to pick the 1 in rate that are logged. The
+// counters are shared by a Logger and the children With derives from it.
+type sampler struct {
+	rate   int
+	counts sync.Map // key -> *atomic.Uint64
+}
+
+func newSampler(rate int) *sampler {
+	if rate < 1 {
+		rate = 1
+	}
+	return &sampler{rate: rate}
+}
+
+// sample reports whether this call for key is one to log.
+func (s *sampler) sample(key string) bool {
+	if s.rate == 1 {
+		return true
+	}
+	c, ok := s.counts.Load(key)
+	if !ok {
+		c, _ = s.counts.LoadOrStore(key, new(atomic.Uint64))
+	}
+	return (c.(*atomic.Uint64).Add(1)-1)%uint64(s.rate) == 0
+}
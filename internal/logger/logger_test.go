@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestDebugSampled_EmitsOneInN(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := newWithCore("test", core, 10)
+
+	for i := 0; i < 1000; i++ {
+		log.DebugSampled("hit", "Cache hit for %d", i)
+		log.With("request_id", i).DebugSampled("miss", "Cache miss for %d", i)
+	}
+	log.Warn("never sampled")
+
+	if got := logs.FilterMessageSnippet("Cache hit").Len(); got != 100 {
+		t.Errorf("expected 100 of 1000 hits, got %d", got)
+	}
+	if got := logs.FilterMessageSnippet("Cache miss").Len(); got != 100 {
+		t.Errorf("expected 100 of 1000 misses across child loggers, got %d", got)
+	}
+	if got := logs.FilterMessageSnippet("Cache hit").All()[0]; got.Message != "Cache hit for 0" || got.ContextMap()["sample_rate"] != int64(10) {
+		t.Errorf("expected the first call to be logged with its rate, got %q %v", got.Message, got.ContextMap())
+	}
+	if got := logs.FilterLevelExact(zapcore.WarnLevel).Len(); got != 1 {
+		t.Errorf("expected the warning to be logged, got %d", got)
+	}
+}
+
+func TestDebugSampled_RateOneLogsAll(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := newWithCore("test", core, 0)
+
+	for i := 0; i < 5; i++ {
+		log.DebugSampled("hit", "Cache hit")
+	}
+	if logs.Len() != 5 {
+		t.Errorf("expected every call logged, got %d", logs.Len())
+	}
+}
+
+func TestDebugSampled_DisabledLevelNotCounted(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	log := newWithCore("test", core, 10)
+
+	for i := 0; i < 5; i++ {
+		log.DebugSampled("hit", "Cache hit")
+	}
+	if logs.Len() != 0 {
+		t.Errorf("expected nothing logged below the level, got %d", logs.Len())
+	}
+	if _, ok := log.sampler.counts.Load("hit"); ok {
+		t.Error("expected calls below the level not to be counted")
+	}
+}
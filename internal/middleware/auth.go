@@ -7,7 +7,7 @@
 //  2. RequestID  - assign a correlation ID for distributed tracing
 //  3. Tracing    - start an OpenTelemetry span
 //  4. CORS       - handle preflight and set access-control headers
-//  5. RateLimit  - enforce per-IP request limits
+//  5. RateLimit  - enforce per-IP and per-route request limits
 //  6. Auth       - validate JWT and inject user_id into context
 //
 // This ordering ensures that panic recovery and observability wrap everything,
@@ -17,11 +17,13 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/Varun5711/shorternit/internal/models"
 	pb "github.com/Varun5711/shorternit/proto/user"
 )
 
@@ -33,6 +35,14 @@ type contextKey string
 // stored after successful JWT validation.
 const UserIDKey contextKey = "user_id"
 
+// RoleKey is the context key under which the authenticated user's role is
+// stored alongside UserIDKey.
+const RoleKey contextKey = "role"
+
+// TokenCookie is the cookie the gateway sets on login and registration so
+// browsers can authenticate without managing the Authorization header.
+const TokenCookie = "tiny_token"
+
 // AuthMiddleware validates JWT tokens by calling the gRPC user service's
 // ValidateToken RPC. It is intentionally stateless on the gateway side:
 // the user service is the single source of truth for token validity, which
@@ -53,43 +63,195 @@ func NewAuthMiddleware(userClient pb.UserServiceClient) *AuthMiddleware {
 }
 
 // RequireAuth wraps a handler to enforce JWT authentication. It extracts the
-// Bearer token from the Authorization header, validates it via the gRPC user
-// service, and injects the resulting user_id into the request context. Requests
-// without a valid token receive a 401 Unauthorized response and are not
-// forwarded to the wrapped handler.
+// token from the Authorization header or, failing that, the TokenCookie
+// cookie, validates it via the gRPC user service, and injects the resulting
+// user_id into the request context. Requests without a valid token receive a
+// 401 Unauthorized response and are not forwarded to the wrapped handler.
 func (m *AuthMiddleware) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return m.requireAuth(next, false)
+}
+
+// RequireFreshAuth is RequireAuth for actions an account that has been
+// disabled must not keep performing with a token it already holds, such as
+// creating links. The user service re-reads the account from the database
+// instead of trusting the token's claims, at the cost of a lookup per
+// request.
+func (m *AuthMiddleware) RequireFreshAuth(next http.HandlerFunc) http.HandlerFunc {
+	return m.requireAuth(next, true)
+}
+
+func (m *AuthMiddleware) requireAuth(next http.HandlerFunc, recheck bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header required", http.StatusUnauthorized)
+		if extractToken(r) == "" {
+			WriteError(w, r, models.ErrCodeUnauthorized, http.StatusUnauthorized, "Authentication required")
 			return
 		}
 
-		// Accept both "Bearer <token>" and a bare token for flexibility.
-		token := authHeader
-		if strings.HasPrefix(authHeader, "Bearer ") {
-			token = authHeader[7:]
-		}
-
-		// Apply a tight timeout to the validation RPC so a slow user service
-		// does not hold up the entire request pipeline.
-		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-		defer cancel()
-
-		resp, err := m.userClient.ValidateToken(ctx, &pb.ValidateTokenRequest{
-			Token: token,
-		})
-		if err != nil || !resp.Valid {
+		resp, err := m.validate(r, recheck)
+		if err != nil {
 			m.log.Error("Invalid token: %v", err)
-			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			WriteError(w, r, models.ErrCodeUnauthorized, http.StatusUnauthorized, "Invalid or expired token")
 			return
 		}
 
 		// Store the validated user ID in the context so downstream handlers
 		// can retrieve it via GetUserID without re-parsing the token.
-		ctx = context.WithValue(r.Context(), UserIDKey, resp.UserId)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, r.WithContext(withIdentity(r.Context(), resp)))
+	}
+}
+
+// RequireRole returns a wrapper that admits only users holding role, for
+// routes such as the admin API. A request without a valid token gets 401,
+// and one whose user holds another role gets 403.
+//
+// The role in the token's claims turns most requests away without a
+// database lookup. Requests the claims admit are validated again with a
+// re-check against the database, so a user who has since been demoted or
+// disabled is refused even though their token still names the role.
+func (m *AuthMiddleware) RequireRole(role string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if extractToken(r) == "" {
+				WriteError(w, r, models.ErrCodeUnauthorized, http.StatusUnauthorized, "Authentication required")
+				return
+			}
+
+			// First against the token's claims, then against the database.
+			for _, recheck := range []bool{false, true} {
+				resp, err := m.validate(r, recheck)
+				if err != nil {
+					m.log.Error("Invalid token: %v", err)
+					WriteError(w, r, models.ErrCodeUnauthorized, http.StatusUnauthorized, "Invalid or expired token")
+					return
+				}
+				if resp.Role != role {
+					WriteError(w, r, models.ErrCodeForbidden, http.StatusForbidden, "Forbidden")
+					return
+				}
+				r = r.WithContext(withIdentity(r.Context(), resp))
+			}
+			next(w, r)
+		}
+	}
+}
+
+// HasRole reports whether the user behind r's token holds role, validating
+// the token with a re-check against the database as RequireRole does. It
+// lets a handler behind OptionalAuth grant a role its exceptions without
+// trusting the role in the token's claims. A token that fails validation
+// holds no role.
+func (m *AuthMiddleware) HasRole(r *http.Request, role string) bool {
+	resp, err := m.validate(r, true)
+	if err != nil {
+		m.log.Error("Invalid token: %v", err)
+		return false
+	}
+	return resp.Role == role
+}
+
+// OptionalAuth is RequireAuth for public routes: a request with a valid token
+// carries its user_id into the context, while a request without one -- or
+// with an invalid one -- is served anonymously instead of being rejected.
+// It lets middleware such as RateLimiter.PerUser tell signed-in callers apart
+// on routes that do not require a login.
+func (m *AuthMiddleware) OptionalAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if extractToken(r) == "" {
+			next(w, r)
+			return
+		}
+
+		resp, err := m.validate(r, false)
+		if err != nil {
+			next(w, r)
+			return
+		}
+		next(w, r.WithContext(withIdentity(r.Context(), resp)))
+	}
+}
+
+// withIdentity returns ctx carrying the user ID and role of a validated
+// token.
+func withIdentity(ctx context.Context, resp *pb.ValidateTokenResponse) context.Context {
+	ctx = context.WithValue(ctx, UserIDKey, resp.UserId)
+	return context.WithValue(ctx, RoleKey, resp.Role)
+}
+
+// validate resolves the request's token into the user's identity via the
+// gRPC user service. With recheck, the user service reads the role and
+// disabled status from the database instead of the token's claims.
+func (m *AuthMiddleware) validate(r *http.Request, recheck bool) (*pb.ValidateTokenResponse, error) {
+	token := extractToken(r)
+
+	// Apply a tight timeout to the validation RPC so a slow user service
+	// does not hold up the entire request pipeline.
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := m.userClient.ValidateToken(ctx, &pb.ValidateTokenRequest{
+		Token:   token,
+		Recheck: recheck,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Valid {
+		return nil, errors.New("token rejected by user service")
+	}
+	return resp, nil
+}
+
+// extractToken returns the request's token: the Authorization header if it
+// is set, accepting both "Bearer <token>" and a bare token, and otherwise the
+// TokenCookie cookie. It returns "" if the request carries neither.
+func extractToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+	if cookie, err := r.Cookie(TokenCookie); err == nil {
+		return cookie.Value
 	}
+	return ""
+}
+
+// Token returns the request's token the way the auth middleware reads it,
+// for handlers that forward the token to the user service themselves.
+func Token(r *http.Request) string {
+	return extractToken(r)
+}
+
+// SetTokenCookie stores token in the TokenCookie cookie. It is HttpOnly so
+// scripts cannot read it, Secure so it only travels over HTTPS, and
+// SameSite=Lax so other sites cannot make state-changing requests with it.
+// It expires with the token at expiresAt, in Unix seconds, or at the end of
+// the browser session if expiresAt is 0.
+func SetTokenCookie(w http.ResponseWriter, token string, expiresAt int64) {
+	cookie := &http.Cookie{
+		Name:     TokenCookie,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if expiresAt > 0 {
+		cookie.Expires = time.Unix(expiresAt, 0)
+	}
+	http.SetCookie(w, cookie)
+}
+
+// ClearTokenCookie removes the TokenCookie cookie, for a browser whose
+// token has been revoked.
+func ClearTokenCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     TokenCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
 }
 
 // GetUserID retrieves the authenticated user's ID from the context. Returns
@@ -101,3 +263,12 @@ func GetUserID(ctx context.Context) string {
 	}
 	return ""
 }
+
+// GetRole retrieves the authenticated user's role from the context, or ""
+// if the request was not authenticated.
+func GetRole(ctx context.Context) string {
+	if role, ok := ctx.Value(RoleKey).(string); ok {
+		return role
+	}
+	return ""
+}
@@ -0,0 +1,226 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/user"
+	"google.golang.org/grpc"
+)
+
+// account is what the fake user service knows about a token's user: the
+// role the token was issued with, and the role and status in the database
+// now.
+type account struct {
+	userID      string
+	claimedRole string
+	role        string
+	disabled    bool
+}
+
+// userDirectory is a UserServiceClient that validates tokens from an
+// in-memory table, counting the validations that re-checked the database.
+type userDirectory struct {
+	pb.UserServiceClient
+	tokens   map[string]account
+	rechecks int
+}
+
+func (d *userDirectory) ValidateToken(ctx context.Context, in *pb.ValidateTokenRequest, opts ...grpc.CallOption) (*pb.ValidateTokenResponse, error) {
+	a, ok := d.tokens[in.Token]
+	if !ok {
+		return &pb.ValidateTokenResponse{Valid: false}, nil
+	}
+	if !in.Recheck {
+		return &pb.ValidateTokenResponse{Valid: true, UserId: a.userID, Role: a.claimedRole}, nil
+	}
+	d.rechecks++
+	if a.disabled {
+		return &pb.ValidateTokenResponse{Valid: false}, nil
+	}
+	return &pb.ValidateTokenResponse{Valid: true, UserId: a.userID, Role: a.role}, nil
+}
+
+func newTestDirectory() *userDirectory {
+	return &userDirectory{tokens: map[string]account{
+		"user-token":     {userID: "u1", claimedRole: "user", role: "user"},
+		"admin-token":    {userID: "a1", claimedRole: "admin", role: "admin"},
+		"demoted-token":  {userID: "a2", claimedRole: "admin", role: "user"},
+		"disabled-admin": {userID: "a3", claimedRole: "admin", role: "admin", disabled: true},
+		"disabled-user":  {userID: "u2", claimedRole: "user", role: "user", disabled: true},
+	}}
+}
+
+// serve runs handler for a request carrying token (none if empty) and
+// returns the status code and whether the handler was reached.
+func serve(handler http.HandlerFunc, token string) (int, bool) {
+	reached := false
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/urls", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req.WithContext(context.WithValue(req.Context(), contextKey("reached"), &reached)))
+	return rec.Code, reached
+}
+
+func reach(w http.ResponseWriter, r *http.Request) {
+	*r.Context().Value(contextKey("reached")).(*bool) = true
+}
+
+func TestRequireRole_RejectsNonAdmins(t *testing.T) {
+	dir := newTestDirectory()
+	admin := NewAuthMiddleware(dir).RequireRole("admin")(reach)
+
+	for _, tc := range []struct {
+		token string
+		want  int
+	}{
+		{"", http.StatusUnauthorized},
+		{"forged", http.StatusUnauthorized},
+		{"user-token", http.StatusForbidden},
+	} {
+		code, reached := serve(admin, tc.token)
+		if code != tc.want {
+			t.Errorf("token %q: expected %d, got %d", tc.token, tc.want, code)
+		}
+		if reached {
+			t.Errorf("token %q: expected the handler not to run", tc.token)
+		}
+	}
+	if dir.rechecks != 0 {
+		t.Errorf("expected non-admins to be refused from the claims alone, got %d re-checks", dir.rechecks)
+	}
+}
+
+// TestRequireRole_ErrorCodes verifies that a missing or invalid token is
+// UNAUTHORIZED and a valid one without the role is FORBIDDEN.
+func TestRequireRole_ErrorCodes(t *testing.T) {
+	admin := NewAuthMiddleware(newTestDirectory()).RequireRole("admin")(reach)
+
+	for token, want := range map[string]string{
+		"":           models.ErrCodeUnauthorized,
+		"forged":     models.ErrCodeUnauthorized,
+		"user-token": models.ErrCodeForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/urls", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		admin(rec, req)
+
+		var body models.ErrorResponse
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("token %q: failed to decode the error: %v", token, err)
+		}
+		if body.Code != want || body.RequestID == "" {
+			t.Errorf("token %q: expected code %s with a request ID, got %+v", token, want, body)
+		}
+	}
+}
+
+func TestRequireRole_AdmitsAdmins(t *testing.T) {
+	dir := newTestDirectory()
+	var userID, role string
+	admin := NewAuthMiddleware(dir).RequireRole("admin")(func(w http.ResponseWriter, r *http.Request) {
+		userID, role = GetUserID(r.Context()), GetRole(r.Context())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/urls", nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
+	rec := httptest.NewRecorder()
+	admin(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if userID != "a1" || role != "admin" {
+		t.Errorf("expected the admin's identity in the context, got %q/%q", userID, role)
+	}
+	if dir.rechecks != 1 {
+		t.Errorf("expected the admin to be re-checked once, got %d", dir.rechecks)
+	}
+}
+
+// TestRequireRole_RechecksTheDatabase verifies that a token still naming
+// the admin role is refused once the user has been demoted or disabled.
+func TestRequireRole_RechecksTheDatabase(t *testing.T) {
+	admin := NewAuthMiddleware(newTestDirectory()).RequireRole("admin")(reach)
+
+	if code, reached := serve(admin, "demoted-token"); code != http.StatusForbidden || reached {
+		t.Errorf("expected a demoted admin to get 403, got %d (reached %v)", code, reached)
+	}
+	if code, reached := serve(admin, "disabled-admin"); code != http.StatusUnauthorized || reached {
+		t.Errorf("expected a disabled admin to get 401, got %d (reached %v)", code, reached)
+	}
+}
+
+func TestRequireFreshAuth_RefusesDisabledAccounts(t *testing.T) {
+	m := NewAuthMiddleware(newTestDirectory())
+
+	if code, reached := serve(m.RequireAuth(reach), "disabled-user"); code != http.StatusOK || !reached {
+		t.Errorf("expected RequireAuth to trust the claims, got %d (reached %v)", code, reached)
+	}
+	if code, reached := serve(m.RequireFreshAuth(reach), "disabled-user"); code != http.StatusUnauthorized || reached {
+		t.Errorf("expected RequireFreshAuth to refuse a disabled account, got %d (reached %v)", code, reached)
+	}
+	if code, reached := serve(m.RequireFreshAuth(reach), "user-token"); code != http.StatusOK || !reached {
+		t.Errorf("expected RequireFreshAuth to admit an active account, got %d (reached %v)", code, reached)
+	}
+}
+
+func TestExtractToken(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		header string
+		cookie string
+		want   string
+	}{
+		{"neither", "", "", ""},
+		{"header only", "Bearer header-token", "", "header-token"},
+		{"bare header", "header-token", "", "header-token"},
+		{"cookie only", "", "cookie-token", "cookie-token"},
+		{"both", "Bearer header-token", "cookie-token", "header-token"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/urls", nil)
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+		if tc.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: TokenCookie, Value: tc.cookie})
+		}
+		if got := extractToken(req); got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
+
+// TestRequireAuth_AcceptsCookie verifies that a browser holding only the
+// token cookie is authenticated, and that the header wins over the cookie.
+func TestRequireAuth_AcceptsCookie(t *testing.T) {
+	m := NewAuthMiddleware(newTestDirectory())
+	var userID string
+	handler := m.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		userID = GetUserID(r.Context())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/urls", nil)
+	req.AddCookie(&http.Cookie{Name: TokenCookie, Value: "user-token"})
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusOK || userID != "u1" {
+		t.Errorf("expected the cookie to authenticate u1, got %d and %q", rec.Code, userID)
+	}
+
+	req.Header.Set("Authorization", "Bearer admin-token")
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusOK || userID != "a1" {
+		t.Errorf("expected the header to take precedence, got %d and %q", rec.Code, userID)
+	}
+}
@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ParseTrustedProxies parses the TRUSTED_PROXIES entries, each a CIDR prefix
+// ("10.0.0.0/8") or a single address ("192.0.2.7"), for ClientIP.
+func ParseTrustedProxies(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: not an IP address or CIDR prefix", entry)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// ClientIP returns the address of the client that made r.
+//
+// Forwarding headers are only believed when the connection itself comes
+// from a trusted proxy; otherwise anyone could pick the IP they are rate
+// limited and geo-targeted as. X-Forwarded-For is then read from right to
+// left, since each proxy appends the address it received the request from:
+// trusted hops are skipped and the first untrusted address is the client.
+// Everything to its left was supplied by the client and is ignored. A
+// malformed entry ends the walk at the last hop that could be verified.
+// X-Real-IP is the fallback when no X-Forwarded-For is present.
+//
+// Entries may be bare IPv4 or IPv6 addresses, carry a port, or use the
+// bracketed "[v6]:port" form. IPv4-mapped IPv6 addresses are reported as
+// IPv4, and the IPv6 loopback as 127.0.0.1, so GeoIP lookups and analytics
+// group local requests consistently.
+func ClientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	peer, ok := parseIP(r.RemoteAddr)
+	if !ok {
+		return r.RemoteAddr
+	}
+	if !isTrusted(peer, trustedProxies) {
+		return formatIP(peer)
+	}
+
+	if hops := forwardedFor(r); len(hops) > 0 {
+		client := peer
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, ok := parseIP(hops[i])
+			if !ok {
+				break
+			}
+			client = addr
+			if !isTrusted(addr, trustedProxies) {
+				break
+			}
+		}
+		return formatIP(client)
+	}
+
+	if realIP, ok := parseIP(r.Header.Get("X-Real-IP")); ok {
+		return formatIP(realIP)
+	}
+	return formatIP(peer)
+}
+
+// forwardedFor returns the X-Forwarded-For hops in order, across every
+// instance of the header.
+func forwardedFor(r *http.Request) []string {
+	var hops []string
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	return hops
+}
+
+// parseIP parses an address with or without a port, including the
+// bracketed IPv6 form, dropping any zone.
+func parseIP(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return netip.Addr{}, false
+	}
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"))
+	if err != nil {
+		addrPort, err := netip.ParseAddrPort(s)
+		if err != nil {
+			return netip.Addr{}, false
+		}
+		addr = addrPort.Addr()
+	}
+	return addr.WithZone("").Unmap(), true
+}
+
+func isTrusted(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatIP(addr netip.Addr) string {
+	if addr == netip.IPv6Loopback() {
+		return "127.0.0.1"
+	}
+	return addr.String()
+}
@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+// testProxies trusts a private load balancer range and one IPv6 proxy.
+var testProxies = []netip.Prefix{
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("2001:db8:ffff::/48"),
+}
+
+func clientIPRequest(remoteAddr string, headers map[string]string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.RemoteAddr = remoteAddr
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return req
+}
+
+func TestClientIP(t *testing.T) {
+	cases := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{
+			name:       "direct connection",
+			remoteAddr: "192.168.1.50:12345",
+			want:       "192.168.1.50",
+		},
+		{
+			name:       "remote addr without port",
+			remoteAddr: "192.168.1.50",
+			want:       "192.168.1.50",
+		},
+		{
+			name:       "spoofed XFF from an untrusted peer is ignored",
+			remoteAddr: "198.51.100.7:4000",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4"},
+			want:       "198.51.100.7",
+		},
+		{
+			name:       "spoofed X-Real-IP from an untrusted peer is ignored",
+			remoteAddr: "198.51.100.7:4000",
+			headers:    map[string]string{"X-Real-IP": "1.2.3.4"},
+			want:       "198.51.100.7",
+		},
+		{
+			name:       "single proxy",
+			remoteAddr: "10.0.0.5:4000",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.195"},
+			want:       "203.0.113.195",
+		},
+		{
+			name:       "spoofed entry left of the real client is ignored",
+			remoteAddr: "10.0.0.5:4000",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4, 203.0.113.195"},
+			want:       "203.0.113.195",
+		},
+		{
+			name:       "multiple trusted proxies are skipped",
+			remoteAddr: "10.0.0.5:4000",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.195, 10.1.2.3, 10.4.5.6"},
+			want:       "203.0.113.195",
+		},
+		{
+			name:       "untrusted hop stops the walk",
+			remoteAddr: "10.0.0.5:4000",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.195, 70.41.3.18, 10.4.5.6"},
+			want:       "70.41.3.18",
+		},
+		{
+			name:       "whitespace is trimmed",
+			remoteAddr: "10.0.0.5:4000",
+			headers:    map[string]string{"X-Forwarded-For": "  203.0.113.195  ,  10.4.5.6 "},
+			want:       "203.0.113.195",
+		},
+		{
+			name:       "malformed entry ends at the last verified hop",
+			remoteAddr: "10.0.0.5:4000",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.195, not-an-ip, 10.4.5.6"},
+			want:       "10.4.5.6",
+		},
+		{
+			name:       "entry with a port",
+			remoteAddr: "10.0.0.5:4000",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.195:51234"},
+			want:       "203.0.113.195",
+		},
+		{
+			name:       "X-Real-IP from a trusted peer",
+			remoteAddr: "10.0.0.5:4000",
+			headers:    map[string]string{"X-Real-IP": "192.168.1.100"},
+			want:       "192.168.1.100",
+		},
+		{
+			name:       "XFF takes precedence over X-Real-IP",
+			remoteAddr: "10.0.0.5:4000",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.195", "X-Real-IP": "192.168.1.100"},
+			want:       "203.0.113.195",
+		},
+		{
+			name:       "IPv6 remote addr",
+			remoteAddr: "[2001:db8::1]:443",
+			want:       "2001:db8::1",
+		},
+		{
+			name:       "IPv6 loopback is reported as IPv4",
+			remoteAddr: "[::1]:12345",
+			want:       "127.0.0.1",
+		},
+		{
+			name:       "IPv4-mapped IPv6 is unmapped",
+			remoteAddr: "[::ffff:10.0.0.5]:4000",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.195"},
+			want:       "203.0.113.195",
+		},
+		{
+			name:       "IPv6 client behind an IPv6 proxy",
+			remoteAddr: "[2001:db8:ffff::2]:4000",
+			headers:    map[string]string{"X-Forwarded-For": "2001:db8:1::7"},
+			want:       "2001:db8:1::7",
+		},
+		{
+			name:       "bracketed IPv6 entry with a port",
+			remoteAddr: "10.0.0.5:4000",
+			headers:    map[string]string{"X-Forwarded-For": "[2001:db8:1::7]:51234, 10.4.5.6"},
+			want:       "2001:db8:1::7",
+		},
+		{
+			name:       "bracketed IPv6 entry without a port",
+			remoteAddr: "10.0.0.5:4000",
+			headers:    map[string]string{"X-Forwarded-For": "[2001:db8:1::7]"},
+			want:       "2001:db8:1::7",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ClientIP(clientIPRequest(tc.remoteAddr, tc.headers), testProxies)
+			if got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+// TestClientIP_MultipleHeaderLines verifies that XFF split over several
+// header lines is read as one chain.
+func TestClientIP_MultipleHeaderLines(t *testing.T) {
+	req := clientIPRequest("10.0.0.5:4000", nil)
+	req.Header.Add("X-Forwarded-For", "1.2.3.4")
+	req.Header.Add("X-Forwarded-For", "203.0.113.195, 10.4.5.6")
+
+	if got := ClientIP(req, testProxies); got != "203.0.113.195" {
+		t.Errorf("expected %q, got %q", "203.0.113.195", got)
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	prefixes, err := ParseTrustedProxies([]string{"10.0.0.0/8", " 192.0.2.7 ", "2001:db8::/32", "::ffff:198.51.100.1", ""})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"10.0.0.0/8", "192.0.2.7/32", "2001:db8::/32", "198.51.100.1/32"}
+	if len(prefixes) != len(want) {
+		t.Fatalf("expected %v, got %v", want, prefixes)
+	}
+	for i, p := range prefixes {
+		if p.String() != want[i] {
+			t.Errorf("entry %d: expected %s, got %s", i, want[i], p)
+		}
+	}
+
+	if _, err := ParseTrustedProxies([]string{"10.0.0.0/99"}); err == nil {
+		t.Error("expected an invalid prefix to be rejected")
+	}
+}
@@ -35,8 +35,11 @@ func CORS(allowedOrigins []string) func(http.Handler) http.Handler {
 			}
 
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, Idempotency-Key")
 			w.Header().Set("Access-Control-Max-Age", "3600")
+			// Let browser clients read the pagination headers of list
+			// responses.
+			w.Header().Set("Access-Control-Expose-Headers", "Link, X-Total-Count")
 
 			// Short-circuit preflight requests so they do not hit auth or
 			// rate-limiting middleware further down the chain.
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/google/uuid"
+)
+
+// WriteError answers r with status and the gateway's JSON error envelope:
+// code (one of the models.ErrCode* values), message, and the request's
+// correlation ID. Every error the API gateway returns goes through here,
+// whether a handler or a middleware rejected the request.
+func WriteError(w http.ResponseWriter, r *http.Request, code string, status int, message string) {
+	WriteErrorResponse(w, r, status, models.ErrorResponse{Code: code, Message: message})
+}
+
+// WriteErrorResponse is WriteError for an envelope with more than a code and
+// message, such as the suggestions for a taken alias. It fills in the
+// request ID.
+func WriteErrorResponse(w http.ResponseWriter, r *http.Request, status int, resp models.ErrorResponse) {
+	resp.RequestID = requestIDOf(w, r)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// requestIDOf returns the ID of the request being answered. Inside
+// RequestID it is in r's context; middleware running outside it, such as
+// Recovery and the rate limiter, usually finds it on the response header
+// RequestID already set. Services without the RequestID middleware get a
+// fresh ID, echoed in the response header so the client and the log line
+// still agree.
+func requestIDOf(w http.ResponseWriter, r *http.Request) string {
+	if id := GetRequestID(r.Context()); id != "" {
+		return id
+	}
+	if id := w.Header().Get("X-Request-ID"); id != "" {
+		return id
+	}
+	id := r.Header.Get("X-Request-ID")
+	if id == "" {
+		id = uuid.New().String()
+	}
+	w.Header().Set("X-Request-ID", id)
+	return id
+}
@@ -0,0 +1,233 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/lock"
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// IdempotencyKeyHeader is the request header carrying a client-chosen key
+// that makes retries of the same request safe.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength bounds the key so it cannot bloat Redis keys.
+const maxIdempotencyKeyLength = 255
+
+// storedResponse is the response remembered for an idempotency key, along
+// with a fingerprint of the request that produced it.
+type storedResponse struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// responseStore persists responses by idempotency key. get returns nil and
+// no error when the key is unknown.
+type responseStore interface {
+	get(ctx context.Context, key string) (*storedResponse, error)
+	set(ctx context.Context, key string, resp *storedResponse, ttl time.Duration) error
+}
+
+// Idempotency makes retried POSTs safe: a request carrying an
+// Idempotency-Key header runs once, and later requests from the same user
+// with the same key receive the stored response instead of running again.
+// Keys are scoped per user, so it must run inside RequireAuth.
+//
+// Concurrent requests with one key are serialised by a distributed lock:
+// the holder runs the handler while the others poll until its response is
+// stored, answering 409 Conflict if it does not appear in time. Reusing a key
+// with a different request body is rejected with 422. Only responses below
+// 500 are stored, so a request that failed server-side can be retried under
+// the same key. Like the rate limiter it fails open: if Redis is unavailable
+// the request is handled without idempotency.
+type Idempotency struct {
+	store        responseStore
+	newLock      func(key string) lock.Locker
+	ttl          time.Duration // How long a stored response is replayed.
+	lockTTL      time.Duration // Upper bound on one request holding its key.
+	pollInterval time.Duration // How often waiting duplicates check for the response.
+}
+
+// NewIdempotency creates Idempotency middleware that stores responses in
+// Redis for ttl.
+func NewIdempotency(redisClient *redis.Client, ttl time.Duration) *Idempotency {
+	lockTTL := 10 * time.Second
+	return &Idempotency{
+		store: redisResponseStore{client: redisClient},
+		newLock: func(key string) lock.Locker {
+			return lock.NewDistributedLock(redisClient, key, lockTTL)
+		},
+		ttl:          ttl,
+		lockTTL:      lockTTL,
+		pollInterval: 50 * time.Millisecond,
+	}
+}
+
+// Wrap returns next guarded by the Idempotency-Key handling described on
+// Idempotency. Requests without the header pass straight through.
+func (m *Idempotency) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			WriteError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		// Read the body to fingerprint it, then hand the handler a fresh
+		// reader over the same bytes.
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+		if err != nil {
+			WriteError(w, r, models.ErrCodeInvalidRequest, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.Path+"\n"), body...))
+		fingerprint := hex.EncodeToString(sum[:])
+
+		storeKey := "idempotency:" + GetUserID(r.Context()) + ":" + key
+		ctx := r.Context()
+		deadline := time.Now().Add(m.lockTTL)
+
+		for {
+			stored, err := m.store.get(ctx, storeKey)
+			if err != nil {
+				next(w, r)
+				return
+			}
+			if stored != nil {
+				replay(w, r, stored, fingerprint)
+				return
+			}
+
+			l := m.newLock("lock:" + storeKey)
+			acquired, err := l.Acquire(ctx)
+			if err != nil {
+				next(w, r)
+				return
+			}
+			if acquired {
+				m.runOnce(w, r, next, l, storeKey, fingerprint)
+				return
+			}
+
+			// Another request with this key is in flight; wait for it to
+			// store its response and replay that.
+			if time.Now().After(deadline) {
+				w.Header().Set("Retry-After", "1")
+				WriteError(w, r, models.ErrCodeRequestInProgress, http.StatusConflict, "a request with this Idempotency-Key is already in progress")
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(m.pollInterval):
+			}
+		}
+	}
+}
+
+// runOnce runs next while holding l, storing its response under storeKey.
+func (m *Idempotency) runOnce(w http.ResponseWriter, r *http.Request, next http.HandlerFunc, l lock.Locker, storeKey, fingerprint string) {
+	defer func() { _ = l.Release(context.WithoutCancel(r.Context())) }()
+
+	// The previous holder may have stored its response between our lookup
+	// and acquiring the lock.
+	if stored, err := m.store.get(r.Context(), storeKey); err == nil && stored != nil {
+		replay(w, r, stored, fingerprint)
+		return
+	}
+
+	cw := &captureWriter{ResponseWriter: w}
+	next(cw, r)
+
+	if cw.status == 0 || cw.status >= http.StatusInternalServerError {
+		return
+	}
+	_ = m.store.set(context.WithoutCancel(r.Context()), storeKey, &storedResponse{
+		Fingerprint: fingerprint,
+		Status:      cw.status,
+		ContentType: cw.Header().Get("Content-Type"),
+		Body:        cw.body.Bytes(),
+	}, m.ttl)
+}
+
+// replay writes a stored response, unless it was produced by a different
+// request body, which means the client reused the key by mistake.
+func replay(w http.ResponseWriter, r *http.Request, stored *storedResponse, fingerprint string) {
+	if stored.Fingerprint != fingerprint {
+		WriteError(w, r, models.ErrCodeIdempotencyKeyUsed, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request")
+		return
+	}
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(stored.Status)
+	_, _ = w.Write(stored.Body)
+}
+
+// captureWriter passes a response through while recording its status and
+// body.
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (c *captureWriter) WriteHeader(status int) {
+	if c.status == 0 {
+		c.status = status
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *captureWriter) Write(b []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
+
+// redisResponseStore keeps stored responses as JSON strings in Redis.
+type redisResponseStore struct {
+	client *redis.Client
+}
+
+func (s redisResponseStore) get(ctx context.Context, key string) (*storedResponse, error) {
+	data, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var resp storedResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (s redisResponseStore) set(ctx context.Context, key string, resp *storedResponse, ttl time.Duration) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, key, data, ttl).Err()
+}
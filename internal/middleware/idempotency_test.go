@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/lock"
+	"github.com/Varun5711/shorternit/internal/lock/locktest"
+)
+
+// memoryStore is an in-process responseStore.
+type memoryStore struct {
+	mu    sync.Mutex
+	items map[string]*storedResponse
+}
+
+func (s *memoryStore) get(ctx context.Context, key string) (*storedResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.items[key], nil
+}
+
+func (s *memoryStore) set(ctx context.Context, key string, resp *storedResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[key] = resp
+	return nil
+}
+
+func newTestIdempotency() *Idempotency {
+	locks := locktest.NewTable()
+	return &Idempotency{
+		store:        &memoryStore{items: map[string]*storedResponse{}},
+		newLock:      func(key string) lock.Locker { return locks.Lock(key) },
+		ttl:          time.Hour,
+		lockTTL:      5 * time.Second,
+		pollInterval: time.Millisecond,
+	}
+}
+
+// creator counts its calls and answers each with a distinct short code,
+// taking delay to do so.
+type creator struct {
+	calls atomic.Int32
+	delay time.Duration
+}
+
+func (c *creator) create(w http.ResponseWriter, r *http.Request) {
+	n := c.calls.Add(1)
+	time.Sleep(c.delay)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintf(w, `{"short_code":"code%d"}`, n)
+}
+
+func postWithKey(handler http.HandlerFunc, user, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/urls", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), UserIDKey, user))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+// TestIdempotency_ReplaysRepeatedRequest verifies that a retry with the same
+// key returns the original response without running the handler again.
+func TestIdempotency_ReplaysRepeatedRequest(t *testing.T) {
+	c := &creator{}
+	handler := newTestIdempotency().Wrap(c.create)
+	body := `{"long_url":"https://example.com"}`
+
+	first := postWithKey(handler, "user-1", "key-1", body)
+	second := postWithKey(handler, "user-1", "key-1", body)
+
+	if got := c.calls.Load(); got != 1 {
+		t.Fatalf("expected the handler to run once, ran %d times", got)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Errorf("expected the replay to match %d %s, got %d %s", first.Code, first.Body, second.Code, second.Body)
+	}
+	if second.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected the replay to keep the content type, got %q", second.Header().Get("Content-Type"))
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("expected the replay to be marked with Idempotent-Replayed")
+	}
+}
+
+// TestIdempotency_ScopesKeys verifies that requests without a key, and the
+// same key from another user, are not deduplicated.
+func TestIdempotency_ScopesKeys(t *testing.T) {
+	c := &creator{}
+	handler := newTestIdempotency().Wrap(c.create)
+	body := `{"long_url":"https://example.com"}`
+
+	postWithKey(handler, "user-1", "", body)
+	postWithKey(handler, "user-1", "", body)
+	postWithKey(handler, "user-1", "key-1", body)
+	postWithKey(handler, "user-2", "key-1", body)
+
+	if got := c.calls.Load(); got != 4 {
+		t.Errorf("expected 4 handler runs, got %d", got)
+	}
+}
+
+// TestIdempotency_RejectsDifferentBody verifies that reusing a key for a
+// different request is refused rather than replaying the wrong link.
+func TestIdempotency_RejectsDifferentBody(t *testing.T) {
+	c := &creator{}
+	handler := newTestIdempotency().Wrap(c.create)
+
+	postWithKey(handler, "user-1", "key-1", `{"long_url":"https://example.com/a"}`)
+	rec := postWithKey(handler, "user-1", "key-1", `{"long_url":"https://example.com/b"}`)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422, got %d", rec.Code)
+	}
+	if got := c.calls.Load(); got != 1 {
+		t.Errorf("expected the handler to run once, ran %d times", got)
+	}
+}
+
+// TestIdempotency_DoesNotStoreServerErrors verifies that a failed request
+// can be retried under the same key.
+func TestIdempotency_DoesNotStoreServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	handler := newTestIdempotency().Wrap(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	postWithKey(handler, "user-1", "key-1", "{}")
+	rec := postWithKey(handler, "user-1", "key-1", "{}")
+
+	if rec.Code != http.StatusCreated || calls.Load() != 2 {
+		t.Errorf("expected the retry to run and succeed, got %d after %d calls", rec.Code, calls.Load())
+	}
+}
+
+// TestIdempotency_ConcurrentRequests verifies that identical requests racing
+// each other create one link and all receive its response.
+func TestIdempotency_ConcurrentRequests(t *testing.T) {
+	c := &creator{delay: 20 * time.Millisecond}
+	handler := newTestIdempotency().Wrap(c.create)
+	body := `{"long_url":"https://example.com"}`
+
+	const n = 10
+	recs := make([]*httptest.ResponseRecorder, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recs[i] = postWithKey(handler, "user-1", "key-1", body)
+		}()
+	}
+	wg.Wait()
+
+	if got := c.calls.Load(); got != 1 {
+		t.Fatalf("expected the handler to run once, ran %d times", got)
+	}
+	for i, rec := range recs {
+		if rec.Code != http.StatusCreated || rec.Body.String() != `{"short_code":"code1"}` {
+			t.Errorf("request %d: expected the single created link, got %d %s", i, rec.Code, rec.Body)
+		}
+	}
+}
@@ -3,68 +3,206 @@ package middleware
 import (
 	"context"
 	"fmt"
-	"net"
 	"net/http"
+	"net/netip"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Varun5711/shorternit/internal/clock"
+	"github.com/Varun5711/shorternit/internal/models"
 	"github.com/redis/go-redis/v9"
 )
 
-// RateLimiter enforces per-IP request limits using a Redis-backed sliding
-// window algorithm. Each request adds an entry to a sorted set keyed by client
-// IP, with the score set to the current timestamp in nanoseconds. Expired
-// entries (outside the window) are pruned on every request. This approach
-// avoids the burst problem of fixed-window counters while remaining simple to
-// implement and reason about.
+// RateLimiter enforces request limits in Redis with one of two algorithms.
+// The sliding window (SlidingWindow) adds an entry per request to a sorted
+// set keyed by client, with the score set to the current timestamp in
+// nanoseconds, and prunes entries outside the window on every request. It
+// is precise, at the cost of four Redis commands and a sorted set per
+// client. The fixed window (FixedWindow) counts requests in a plain counter
+// per client and window, one INCR and EXPIRE per request, but lets a
+// client make up to twice its limit across the boundary of two windows. It
+// suits the redirect service, where throughput matters more than an exact
+// limit.
+//
+// A default limit, keyed by client IP, applies to every request. Routes that
+// need a different limit register it by path prefix with Limit or
+// LimitPerUser; the longest matching prefix overrides the default. Limit
+// buckets are enforced by Middleware, in front of everything else; LimitPerUser
+// buckets need the validated user ID, so they are enforced by PerUser, mounted
+// behind the auth middleware on the routes they cover.
 type RateLimiter struct {
-	redis     *redis.Client
-	limit     int           // Maximum number of requests allowed per window.
-	window    time.Duration // Sliding window duration (e.g. 1 minute).
-	keyPrefix string        // Redis key prefix to namespace rate-limit keys.
+	counter        requestCounter // Request counts per client key.
+	defaultLimit   routeLimit     // Applies to paths no route matches.
+	routes         []routeLimit   // Per-prefix overrides, longest prefix first.
+	keyPrefix      string         // Redis key prefix to namespace rate-limit keys.
+	trustedProxies []netip.Prefix // Proxies whose forwarding headers identify the client (see ClientIP).
+}
+
+// routeLimit is one rate-limit bucket: the requests it covers, how many of
+// them a client may make per window, and how clients are told apart.
+type routeLimit struct {
+	prefix  string // Path prefix the bucket covers; empty for the default.
+	limit   int
+	window  time.Duration
+	perUser bool // Key by the authenticated user rather than by IP.
 }
 
-// NewRateLimiter creates a RateLimiter that allows at most limit requests per
-// window duration. The Redis client should be shared with other components
-// (e.g. the cache layer) to avoid connection pool fragmentation.
-func NewRateLimiter(redisClient *redis.Client, limit int, window time.Duration) *RateLimiter {
+// bucket is the name reported in X-RateLimit-Bucket.
+func (l routeLimit) bucket() string {
+	if l.prefix == "" {
+		return "default"
+	}
+	return l.prefix
+}
+
+// requestCounter counts a client's requests within a window. It is
+// satisfied by redisWindow and fixedWindow; tests substitute an in-process
+// implementation.
+type requestCounter interface {
+	allow(ctx context.Context, key string, limit int, window time.Duration) (allowed bool, remaining int, reset time.Time)
+}
+
+// The rate-limiting algorithms NewRateLimiter accepts.
+const (
+	SlidingWindow = "sliding_window"
+	FixedWindow   = "fixed_window"
+)
+
+// NewRateLimiter creates a RateLimiter that allows each client IP at most
+// limit requests per window duration by default, counted with algorithm,
+// SlidingWindow or FixedWindow; any other is an error. The Redis client
+// should be shared with other components (e.g. the cache layer) to avoid
+// connection pool fragmentation. Clients are identified by ClientIP with the
+// given trusted proxies.
+func NewRateLimiter(redisClient *redis.Client, algorithm string, limit int, window time.Duration, trustedProxies []netip.Prefix) (*RateLimiter, error) {
+	var counter requestCounter
+	switch algorithm {
+	case SlidingWindow:
+		counter = redisWindow{client: redisClient, clock: clock.Real{}}
+	case FixedWindow:
+		counter = fixedWindow{counts: redisCounts{client: redisClient}, clock: clock.Real{}}
+	default:
+		return nil, fmt.Errorf("unknown rate limit algorithm %q (want %s or %s)", algorithm, SlidingWindow, FixedWindow)
+	}
 	return &RateLimiter{
-		redis:     redisClient,
-		limit:     limit,
-		window:    window,
-		keyPrefix: "ratelimit:",
+		counter:        counter,
+		defaultLimit:   routeLimit{limit: limit, window: window},
+		keyPrefix:      "ratelimit:",
+		trustedProxies: trustedProxies,
+	}, nil
+}
+
+// Limit overrides the default limit for requests whose path starts with
+// prefix, counting them per client IP in a bucket of their own. It suits
+// unauthenticated routes such as login, where the IP is the only identity
+// available. It returns rl so registrations can be chained, and must be
+// called before the limiter serves requests.
+func (rl *RateLimiter) Limit(prefix string, limit int, window time.Duration) *RateLimiter {
+	return rl.addRoute(routeLimit{prefix: prefix, limit: limit, window: window})
+}
+
+// LimitPerUser is Limit for routes that know their caller: requests are
+// counted per user ID, so users behind one NAT do not share a quota, and a
+// user cannot reset their quota by signing in again for a fresh token.
+// Anonymous requests are counted per IP. The bucket is enforced by PerUser,
+// which the routes must be wrapped in; Middleware lets them through.
+func (rl *RateLimiter) LimitPerUser(prefix string, limit int, window time.Duration) *RateLimiter {
+	return rl.addRoute(routeLimit{prefix: prefix, limit: limit, window: window, perUser: true})
+}
+
+func (rl *RateLimiter) addRoute(route routeLimit) *RateLimiter {
+	rl.routes = append(rl.routes, route)
+	sort.SliceStable(rl.routes, func(i, j int) bool {
+		return len(rl.routes[i].prefix) > len(rl.routes[j].prefix)
+	})
+	return rl
+}
+
+// routeFor returns the bucket governing path.
+func (rl *RateLimiter) routeFor(path string) routeLimit {
+	for _, route := range rl.routes {
+		if strings.HasPrefix(path, route.prefix) {
+			return route
+		}
+	}
+	return rl.defaultLimit
+}
+
+// clientKey returns the Redis key counting r's requests against route. The
+// default bucket keeps the original "ratelimit:<ip>" keys.
+func (rl *RateLimiter) clientKey(r *http.Request, route routeLimit) string {
+	client := ClientIP(r, rl.trustedProxies)
+	if route.perUser {
+		if userID := GetUserID(r.Context()); userID != "" {
+			client = "user:" + userID
+		}
+	}
+	if route.prefix == "" {
+		return rl.keyPrefix + client
 	}
+	return rl.keyPrefix + route.prefix + ":" + client
 }
 
 // Middleware returns an http.Handler middleware that enforces the configured
-// rate limit. It sets standard rate-limit response headers on every request
+// rate limits. It sets standard rate-limit response headers on every request
 // (X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset) so clients
-// can self-throttle, and returns 429 Too Many Requests with a Retry-After
-// header when the limit is exceeded.
+// can self-throttle, plus X-RateLimit-Bucket naming the limit that applied,
+// and returns 429 Too Many Requests with a Retry-After header when the limit
+// is exceeded. Requests in a LimitPerUser bucket are passed on for PerUser
+// to count.
 func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		clientIP := getClientIP(r)
-		key := rl.keyPrefix + clientIP
+		route := rl.routeFor(r.URL.Path)
+		if route.perUser || rl.enforce(w, r, route) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
 
-		allowed, remaining, resetTime := rl.allowRequest(r.Context(), key)
+// PerUser wraps the handler of a route registered with LimitPerUser and
+// counts its requests per user, as set in the context by RequireAuth or
+// OptionalAuth; it must therefore run inside them. Requests in any other
+// bucket were already counted by Middleware and pass straight through.
+func (rl *RateLimiter) PerUser(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		route := rl.routeFor(r.URL.Path)
+		if !route.perUser || rl.enforce(w, r, route) {
+			next(w, r)
+		}
+	}
+}
 
-		// Always set rate-limit headers so well-behaved clients can
-		// monitor their quota even when they are not yet throttled.
-		w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", rl.limit))
-		w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
-		w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", resetTime.Unix()))
+// enforce counts r against route and sets the rate-limit headers. It reports
+// whether the request may proceed, having answered 429 if not.
+func (rl *RateLimiter) enforce(w http.ResponseWriter, r *http.Request, route routeLimit) bool {
+	key := rl.clientKey(r, route)
 
-		if !allowed {
-			w.Header().Set("Retry-After", fmt.Sprintf("%d", int(time.Until(resetTime).Seconds())))
-			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
-			return
-		}
+	allowed, remaining, resetTime := rl.counter.allow(r.Context(), key, route.limit, route.window)
 
-		next.ServeHTTP(w, r)
-	})
+	// Always set rate-limit headers so well-behaved clients can
+	// monitor their quota even when they are not yet throttled.
+	w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", route.limit))
+	w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
+	w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", resetTime.Unix()))
+	w.Header().Set("X-RateLimit-Bucket", route.bucket())
+
+	if !allowed {
+		w.Header().Set("Retry-After", fmt.Sprintf("%d", int(time.Until(resetTime).Seconds())))
+		WriteError(w, r, models.ErrCodeRateLimited, http.StatusTooManyRequests, "Rate limit exceeded")
+		return false
+	}
+	return true
 }
 
-// allowRequest executes the sliding-window rate-limit check as a single Redis
+// redisWindow is the Redis sorted-set implementation of the sliding window.
+type redisWindow struct {
+	client *redis.Client
+	clock  clock.Clock
+}
+
+// allow executes the sliding-window rate-limit check as a single Redis
 // pipeline for atomicity and reduced round trips. The pipeline performs four
 // operations in order:
 //  1. ZREMRANGEBYSCORE - prune entries older than the window
@@ -74,11 +212,11 @@ func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
 //
 // On Redis failure the request is allowed (fail-open), which trades a brief
 // period of unenforced limits for service availability.
-func (rl *RateLimiter) allowRequest(ctx context.Context, key string) (bool, int, time.Time) {
-	now := time.Now()
-	windowStart := now.Add(-rl.window)
+func (rw redisWindow) allow(ctx context.Context, key string, limit int, window time.Duration) (bool, int, time.Time) {
+	now := rw.clock.Now()
+	windowStart := now.Add(-window)
 
-	pipe := rl.redis.Pipeline()
+	pipe := rw.client.Pipeline()
 
 	// Remove entries that have fallen outside the sliding window.
 	pipe.ZRemRangeByScore(ctx, key, "0", fmt.Sprintf("%d", windowStart.UnixNano()))
@@ -97,67 +235,94 @@ func (rl *RateLimiter) allowRequest(ctx context.Context, key string) (bool, int,
 
 	// Set a TTL equal to the window so Redis reclaims memory for IPs that
 	// stop sending traffic.
-	pipe.Expire(ctx, key, rl.window)
+	pipe.Expire(ctx, key, window)
 
 	_, err := pipe.Exec(ctx)
 	if err != nil {
 		// Fail open: if Redis is unreachable, allow the request rather
 		// than blocking all traffic.
-		return true, rl.limit, now.Add(rl.window)
+		return true, limit, now.Add(window)
 	}
 
 	count := int(zcard.Val())
 
-	if count >= rl.limit {
+	if count >= limit {
 		// Determine when the oldest entry in the window will expire, so
 		// the client knows when to retry.
 		oldestKey := key
-		results, _ := rl.redis.ZRange(ctx, oldestKey, 0, 0).Result()
+		results, _ := rw.client.ZRange(ctx, oldestKey, 0, 0).Result()
 		var resetTime time.Time
 		if len(results) > 0 {
 			var oldestTimestamp int64
 			_, _ = fmt.Sscanf(results[0], "%d", &oldestTimestamp)
-			resetTime = time.Unix(0, oldestTimestamp).Add(rl.window)
+			resetTime = time.Unix(0, oldestTimestamp).Add(window)
 		} else {
-			resetTime = now.Add(rl.window)
+			resetTime = now.Add(window)
 		}
 
 		return false, 0, resetTime
 	}
 
 	// Subtract 1 from remaining to account for the entry we just added.
-	remaining := rl.limit - count - 1
+	remaining := limit - count - 1
 	if remaining < 0 {
 		remaining = 0
 	}
 
-	resetTime := now.Add(rl.window)
+	resetTime := now.Add(window)
 
 	return true, remaining, resetTime
 }
 
-// getClientIP extracts the client's real IP address from proxy headers or the
-// TCP connection. It validates parsed IPs with net.ParseIP to guard against
-// spoofed X-Forwarded-For values containing non-IP strings. Priority:
-// X-Forwarded-For (first entry) > X-Real-IP > RemoteAddr.
-func getClientIP(r *http.Request) string {
-	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
-		parts := strings.Split(forwarded, ",")
-		ip := strings.TrimSpace(parts[0])
-		if net.ParseIP(ip) != nil {
-			return ip
-		}
-	}
+// fixedWindow is the fixed-window requestCounter. Windows are aligned to
+// multiples of their length, so every client's window resets at the same
+// moment, and each has its own counter key, which expires once the window
+// is over rather than being reset.
+type fixedWindow struct {
+	counts windowCounts
+	clock  clock.Clock
+}
 
-	if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
-		if net.ParseIP(realIP) != nil {
-			return realIP
-		}
-	}
+// windowCounts increments request counters. It is satisfied by redisCounts;
+// tests substitute an in-process implementation.
+type windowCounts interface {
+	// incr adds one to the counter at key and returns its new value. A new
+	// counter starts at zero and is forgotten after ttl.
+	incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+}
+
+// allow counts the request in the counter of the current window, denying it
+// once the counter is past limit. Denied requests are counted too, so a
+// client hammering the limit does not get in again before the window ends.
+// As with the sliding window, a Redis failure allows the request.
+func (fw fixedWindow) allow(ctx context.Context, key string, limit int, window time.Duration) (bool, int, time.Time) {
+	start := fw.clock.Now().Truncate(window)
+	reset := start.Add(window)
 
-	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	count, err := fw.counts.incr(ctx, key+":"+strconv.FormatInt(start.Unix(), 10), window)
 	if err != nil {
-		return r.RemoteAddr
+		return true, limit, reset
+	}
+	if count > int64(limit) {
+		return false, 0, reset
+	}
+	return true, limit - int(count), reset
+}
+
+// redisCounts keeps request counters in Redis.
+type redisCounts struct {
+	client *redis.Client
+}
+
+// incr sends INCR and EXPIRE in one pipeline, a single round trip. The
+// expiry is renewed on every request, which is harmless: a window's key is
+// not used once the window is over.
+func (rc redisCounts) incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	pipe := rc.client.Pipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
 	}
-	return host
+	return incr.Val(), nil
 }
@@ -0,0 +1,368 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clock/clocktest"
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// memoryWindow is an in-process requestCounter that counts requests per key
+// and never forgets them, which is enough for tests within one window.
+type memoryWindow struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (m *memoryWindow) allow(ctx context.Context, key string, limit int, window time.Duration) (bool, int, time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	reset := time.Now().Add(window)
+	if m.counts[key] >= limit {
+		return false, 0, reset
+	}
+	m.counts[key]++
+	return true, limit - m.counts[key], reset
+}
+
+// newTestRateLimiter mirrors the gateway's configuration: a default of 100
+// per IP, 2 login attempts per IP, and 3 analytics requests per user.
+func newTestRateLimiter() *RateLimiter {
+	rl := &RateLimiter{
+		counter:        &memoryWindow{counts: map[string]int{}},
+		defaultLimit:   routeLimit{limit: 100, window: time.Minute},
+		keyPrefix:      "ratelimit:",
+		trustedProxies: testProxies,
+	}
+	return rl.Limit("/api/auth/login", 2, time.Minute).
+		LimitPerUser("/api/analytics/", 3, time.Minute)
+}
+
+// limitedRequest sends a request for path from ip, with token as its bearer
+// token if non-empty, and returns the response.
+func limitedRequest(t *testing.T, handler http.Handler, path, ip, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = ip + ":40000"
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+}
+
+func TestRateLimiter_AuthRoutesLimitedPerIP(t *testing.T) {
+	handler := newTestRateLimiter().Middleware(okHandler())
+
+	for i := 0; i < 2; i++ {
+		if rec := limitedRequest(t, handler, "/api/auth/login", "192.0.2.1", ""); rec.Code != http.StatusOK {
+			t.Fatalf("attempt %d: expected 200, got %d", i+1, rec.Code)
+		}
+	}
+	rec := limitedRequest(t, handler, "/api/auth/login", "192.0.2.1", "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 after the auth limit, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("X-RateLimit-Bucket"); got != "/api/auth/login" {
+		t.Errorf("expected bucket /api/auth/login, got %q", got)
+	}
+	if got := rec.Header().Get("X-RateLimit-Limit"); got != "2" {
+		t.Errorf("expected limit 2, got %q", got)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After on a 429")
+	}
+	var body models.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.Code != models.ErrCodeRateLimited {
+		t.Errorf("expected code %s, got %+v (%v)", models.ErrCodeRateLimited, body, err)
+	}
+
+	// Another IP has its own quota, and the throttled IP can still use
+	// routes under the default limit.
+	if rec := limitedRequest(t, handler, "/api/auth/login", "192.0.2.2", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected a different IP to be allowed, got %d", rec.Code)
+	}
+	if rec := limitedRequest(t, handler, "/api/urls", "192.0.2.1", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected the default bucket to be unaffected, got %d", rec.Code)
+	}
+}
+
+// tokenUsers stands in for the auth middleware: it resolves the bearer
+// tokens it knows into user IDs and serves other requests anonymously.
+func tokenUsers(users map[string]string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if userID, ok := users[token]; ok {
+			r = r.WithContext(context.WithValue(r.Context(), UserIDKey, userID))
+		}
+		next(w, r)
+	}
+}
+
+// newPerUserHandler wires rl as the gateway does for the analytics routes:
+// Middleware in front, then authentication, then PerUser.
+func newPerUserHandler(rl *RateLimiter) http.Handler {
+	users := map[string]string{"alice-token": "alice", "alice-token-2": "alice", "bob-token": "bob"}
+	return rl.Middleware(tokenUsers(users, rl.PerUser(okHandler().ServeHTTP)))
+}
+
+func TestRateLimiter_APIRoutesLimitedPerUser(t *testing.T) {
+	handler := newPerUserHandler(newTestRateLimiter())
+
+	// Two users behind the same NAT address get separate quotas.
+	for i := 0; i < 3; i++ {
+		if rec := limitedRequest(t, handler, "/api/analytics/abc", "192.0.2.1", "alice-token"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i+1, rec.Code)
+		}
+	}
+	rec := limitedRequest(t, handler, "/api/analytics/abc/timeline", "192.0.2.1", "alice-token")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 after the analytics limit, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("X-RateLimit-Bucket"); got != "/api/analytics/" {
+		t.Errorf("expected bucket /api/analytics/, got %q", got)
+	}
+
+	if rec := limitedRequest(t, handler, "/api/analytics/abc", "192.0.2.1", "bob-token"); rec.Code != http.StatusOK {
+		t.Errorf("expected another user on the same IP to be allowed, got %d", rec.Code)
+	}
+}
+
+// TestRateLimiter_PerUserKeyedByUserNotToken verifies that a fresh token for
+// the same user does not reset the quota, and that neither does an invalid
+// token: both are counted against an identity the auth middleware vouched
+// for rather than against the raw header.
+func TestRateLimiter_PerUserKeyedByUserNotToken(t *testing.T) {
+	handler := newPerUserHandler(newTestRateLimiter())
+
+	for i := 0; i < 3; i++ {
+		if rec := limitedRequest(t, handler, "/api/analytics/abc", "192.0.2.1", "alice-token"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i+1, rec.Code)
+		}
+	}
+	if rec := limitedRequest(t, handler, "/api/analytics/abc", "192.0.2.1", "alice-token-2"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected a second token for the same user to share the quota, got %d", rec.Code)
+	}
+
+	// Made-up tokens are anonymous, so they share the IP's quota.
+	for i := 0; i < 3; i++ {
+		limitedRequest(t, handler, "/api/analytics/abc", "192.0.2.9", fmt.Sprintf("forged-%d", i))
+	}
+	if rec := limitedRequest(t, handler, "/api/analytics/abc", "192.0.2.9", "forged-3"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected unvalidated tokens to be limited per IP, got %d", rec.Code)
+	}
+}
+
+func TestRateLimiter_DefaultBucket(t *testing.T) {
+	handler := newTestRateLimiter().Middleware(okHandler())
+
+	rec := limitedRequest(t, handler, "/api/urls", "192.0.2.1", "alice-token")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("X-RateLimit-Bucket"); got != "default" {
+		t.Errorf("expected the default bucket, got %q", got)
+	}
+	if got := rec.Header().Get("X-RateLimit-Limit"); got != "100" {
+		t.Errorf("expected the default limit, got %q", got)
+	}
+	if got, _ := strconv.Atoi(rec.Header().Get("X-RateLimit-Remaining")); got != 99 {
+		t.Errorf("expected 99 remaining, got %d", got)
+	}
+}
+
+func TestRateLimiter_LongestPrefixWins(t *testing.T) {
+	rl := newTestRateLimiter().Limit("/api/", 50, time.Minute)
+
+	cases := map[string]string{
+		"/api/auth/login":   "/api/auth/login",
+		"/api/auth/profile": "/api/",
+		"/api/analytics/x":  "/api/analytics/",
+		"/abc123":           "default",
+	}
+	for path, want := range cases {
+		if got := rl.routeFor(path).bucket(); got != want {
+			t.Errorf("%s: expected bucket %q, got %q", path, want, got)
+		}
+	}
+}
+
+func TestRateLimiter_KeysAreSeparatedByBucket(t *testing.T) {
+	rl := newTestRateLimiter()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.1:40000"
+
+	if got := rl.clientKey(req, rl.routeFor("/abc123")); got != "ratelimit:192.0.2.1" {
+		t.Errorf("expected the default bucket to keep its key, got %q", got)
+	}
+	if got := rl.clientKey(req, rl.routeFor("/api/auth/login")); got != "ratelimit:/api/auth/login:192.0.2.1" {
+		t.Errorf("unexpected auth key %q", got)
+	}
+	// Without a user, a per-user bucket falls back to the IP.
+	if got := rl.clientKey(req, rl.routeFor("/api/analytics/x")); got != "ratelimit:/api/analytics/:192.0.2.1" {
+		t.Errorf("unexpected anonymous analytics key %q", got)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), UserIDKey, "alice"))
+	if got := rl.clientKey(req, rl.routeFor("/api/analytics/x")); got != "ratelimit:/api/analytics/:user:alice" {
+		t.Errorf("unexpected analytics key %q", got)
+	}
+}
+
+// TestRateLimiter_SpoofedForwardedFor verifies that a client cannot escape
+// the login limit by rotating X-Forwarded-For: the header is ignored unless
+// the connection comes from a trusted proxy, and with no trusted proxies at
+// all (TRUST_PROXY off) it is ignored even from the load balancer's range.
+func TestRateLimiter_SpoofedForwardedFor(t *testing.T) {
+	attempt := func(handler http.Handler, peer, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/auth/login", nil)
+		req.RemoteAddr = peer + ":40000"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Untrusted peer: every forged address counts against 192.0.2.1.
+	handler := newTestRateLimiter().Middleware(okHandler())
+	for i := 0; i < 2; i++ {
+		if code := attempt(handler, "192.0.2.1", fmt.Sprintf("198.51.100.%d", i)); code != http.StatusOK {
+			t.Fatalf("untrusted peer: attempt %d: expected 200, got %d", i+1, code)
+		}
+	}
+	if code := attempt(handler, "192.0.2.1", "198.51.100.9"); code != http.StatusTooManyRequests {
+		t.Errorf("untrusted peer: expected a new forged address to be limited, got %d", code)
+	}
+
+	// Trusted load balancer: each forwarded client has its own quota.
+	handler = newTestRateLimiter().Middleware(okHandler())
+	for i := 0; i < 3; i++ {
+		if code := attempt(handler, "10.0.0.5", fmt.Sprintf("198.51.100.%d", i)); code != http.StatusOK {
+			t.Errorf("trusted peer: client %d: expected 200, got %d", i+1, code)
+		}
+	}
+
+	// Proxy trust off: the load balancer's own address is the client.
+	rl := newTestRateLimiter()
+	rl.trustedProxies = nil
+	handler = rl.Middleware(okHandler())
+	for i := 0; i < 2; i++ {
+		_ = attempt(handler, "10.0.0.5", fmt.Sprintf("198.51.100.%d", i))
+	}
+	if code := attempt(handler, "10.0.0.5", "198.51.100.9"); code != http.StatusTooManyRequests {
+		t.Errorf("trust off: expected forwarded addresses to be ignored, got %d", code)
+	}
+}
+
+// memoryCounts is an in-process windowCounts. Expiry is not modelled; each
+// window has its own key, so tests never reuse one.
+type memoryCounts struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func (m *memoryCounts) incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts[key]++
+	return m.counts[key], nil
+}
+
+func TestFixedWindow_LimitAndReset(t *testing.T) {
+	clk := clocktest.NewFake(time.Date(2025, 1, 1, 12, 0, 10, 0, time.UTC))
+	fw := fixedWindow{counts: &memoryCounts{counts: map[string]int64{}}, clock: clk}
+	ctx := context.Background()
+	windowEnd := time.Date(2025, 1, 1, 12, 1, 0, 0, time.UTC)
+
+	for i := 1; i <= 3; i++ {
+		allowed, remaining, reset := fw.allow(ctx, "k", 3, time.Minute)
+		if !allowed || remaining != 3-i {
+			t.Fatalf("request %d: got allowed=%v remaining=%d, want true %d", i, allowed, remaining, 3-i)
+		}
+		if !reset.Equal(windowEnd) {
+			t.Errorf("request %d: expected the window to reset at %s, got %s", i, windowEnd, reset)
+		}
+	}
+	if allowed, remaining, _ := fw.allow(ctx, "k", 3, time.Minute); allowed || remaining != 0 {
+		t.Fatalf("expected the 4th request denied, got allowed=%v remaining=%d", allowed, remaining)
+	}
+	if allowed, _, _ := fw.allow(ctx, "other", 3, time.Minute); !allowed {
+		t.Error("expected another client to have its own count")
+	}
+
+	// Late in the same window still denied; the next window starts afresh.
+	clk.Set(windowEnd.Add(-time.Second))
+	if allowed, _, _ := fw.allow(ctx, "k", 3, time.Minute); allowed {
+		t.Error("expected the limit to hold until the window ends")
+	}
+	clk.Set(windowEnd)
+	allowed, remaining, reset := fw.allow(ctx, "k", 3, time.Minute)
+	if !allowed || remaining != 2 {
+		t.Fatalf("expected a fresh window, got allowed=%v remaining=%d", allowed, remaining)
+	}
+	if want := windowEnd.Add(time.Minute); !reset.Equal(want) {
+		t.Errorf("expected the new window to reset at %s, got %s", want, reset)
+	}
+}
+
+func TestNewRateLimiter_Algorithms(t *testing.T) {
+	for _, algorithm := range []string{SlidingWindow, FixedWindow} {
+		if _, err := NewRateLimiter(nil, algorithm, 10, time.Minute, nil); err != nil {
+			t.Errorf("%s: %v", algorithm, err)
+		}
+	}
+	if _, err := NewRateLimiter(nil, "leaky_bucket", 10, time.Minute, nil); err == nil {
+		t.Error("expected an unknown algorithm to be rejected")
+	}
+}
+
+// benchmarkAlgorithm measures one algorithm against the Redis at
+// REDIS_TEST_ADDR, skipping when it is not set. Every request is a
+// different client, so none is ever denied.
+func benchmarkAlgorithm(b *testing.B, algorithm string) {
+	addr := os.Getenv("REDIS_TEST_ADDR")
+	if addr == "" {
+		b.Skip("REDIS_TEST_ADDR not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	defer client.Close()
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		b.Fatalf("Redis at %s: %v", addr, err)
+	}
+
+	rl, err := NewRateLimiter(client, algorithm, 1<<30, time.Minute, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	rl.keyPrefix = fmt.Sprintf("ratelimit-bench:%d:", time.Now().UnixNano())
+	handler := rl.Middleware(okHandler())
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		rec := httptest.NewRecorder()
+		for i := 0; pb.Next(); i++ {
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			req.RemoteAddr = fmt.Sprintf("10.%d.%d.%d:40000", i>>16&255, i>>8&255, i&255)
+			handler.ServeHTTP(rec, req)
+		}
+	})
+}
+
+func BenchmarkRateLimiter_SlidingWindow(b *testing.B) { benchmarkAlgorithm(b, SlidingWindow) }
+
+func BenchmarkRateLimiter_FixedWindow(b *testing.B) { benchmarkAlgorithm(b, FixedWindow) }
@@ -1,26 +1,60 @@
 package middleware
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"runtime/debug"
 
 	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/Varun5711/shorternit/internal/models"
 )
 
+// panicResponse is the JSON body returned for a recovered panic: the error
+// envelope with the panic and its stack added, which are only filled in when
+// debug output is enabled.
+type panicResponse struct {
+	models.ErrorResponse
+	Panic string `json:"panic,omitempty"`
+	Stack string `json:"stack,omitempty"`
+}
+
 // Recovery returns middleware that catches panics in downstream handlers and
 // converts them into 500 Internal Server Error responses instead of crashing
-// the process. The full stack trace is logged for debugging. This should be
+// the process. The panic and its stack trace are logged together with the
+// request ID, and the client receives the INTERNAL error envelope with that
+// ID so it can quote it when reporting the failure. The panic message and
+// stack are only echoed to the client when debugOutput is set. This should be
 // the outermost middleware in the chain so it can recover panics from every
 // layer, including other middleware.
-func Recovery(log *logger.Logger) func(http.Handler) http.Handler {
+func Recovery(log *logger.Logger, debugOutput bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
 				if err := recover(); err != nil {
-					// Log the panic value and stack trace so operators can
-					// diagnose the root cause without losing the process.
-					log.Error("Panic recovered: %v\nStack trace:\n%s", err, debug.Stack())
-					http.Error(w, "Internal server error", http.StatusInternalServerError)
+					// http.ErrAbortHandler is a deliberate abort of a response
+					// that has already started streaming; let net/http drop
+					// the connection instead of appending a 500 to the body.
+					if err == http.ErrAbortHandler {
+						panic(err)
+					}
+
+					stack := debug.Stack()
+					requestID := requestIDOf(w, r)
+					log.Error("Panic recovered [request_id=%s]: %v\nStack trace:\n%s", requestID, err, stack)
+
+					resp := panicResponse{ErrorResponse: models.ErrorResponse{
+						Code:      models.ErrCodeInternal,
+						Message:   "internal server error",
+						RequestID: requestID,
+					}}
+					if debugOutput {
+						resp.Panic = fmt.Sprint(err)
+						resp.Stack = string(stack)
+					}
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusInternalServerError)
+					_ = json.NewEncoder(w).Encode(resp)
 				}
 			}()
 
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/Varun5711/shorternit/internal/models"
+)
+
+func panicking(w http.ResponseWriter, r *http.Request) {
+	panic("secret failure detail")
+}
+
+func recoverPanic(t *testing.T, handler http.Handler) (*httptest.ResponseRecorder, panicResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/boom", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected application/json, got %q", ct)
+	}
+	var body panicResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response is not JSON: %v: %s", err, rec.Body.String())
+	}
+	return rec, body
+}
+
+// TestRecovery_ReturnsJSONWithRequestID verifies that a recovered panic
+// yields the INTERNAL error envelope carrying the ID RequestID assigned, without
+// leaking the panic to the client.
+func TestRecovery_ReturnsJSONWithRequestID(t *testing.T) {
+	handler := Recovery(logger.New("test"), false)(RequestID(http.HandlerFunc(panicking)))
+
+	rec, body := recoverPanic(t, handler)
+
+	if body.Code != models.ErrCodeInternal {
+		t.Errorf("expected code %q, got %q", models.ErrCodeInternal, body.Code)
+	}
+	if body.RequestID == "" || body.RequestID != rec.Header().Get("X-Request-ID") {
+		t.Errorf("expected request_id to match X-Request-ID %q, got %q", rec.Header().Get("X-Request-ID"), body.RequestID)
+	}
+	if strings.Contains(rec.Body.String(), "secret failure detail") || body.Stack != "" {
+		t.Errorf("panic detail leaked to the client: %s", rec.Body.String())
+	}
+}
+
+// TestRecovery_AssignsRequestID verifies that without the RequestID
+// middleware the response still carries an ID matching the body.
+func TestRecovery_AssignsRequestID(t *testing.T) {
+	rec, body := recoverPanic(t, Recovery(logger.New("test"), false)(http.HandlerFunc(panicking)))
+
+	if body.RequestID == "" || body.RequestID != rec.Header().Get("X-Request-ID") {
+		t.Errorf("expected request_id to match X-Request-ID %q, got %q", rec.Header().Get("X-Request-ID"), body.RequestID)
+	}
+}
+
+// TestRecovery_DebugOutput verifies that debug mode includes the panic
+// message and stack trace in the response.
+func TestRecovery_DebugOutput(t *testing.T) {
+	_, body := recoverPanic(t, Recovery(logger.New("test"), true)(http.HandlerFunc(panicking)))
+
+	if body.Panic != "secret failure detail" {
+		t.Errorf("expected the panic message, got %q", body.Panic)
+	}
+	if !strings.Contains(body.Stack, "panicking") {
+		t.Errorf("expected the stack to name the panicking handler, got %q", body.Stack)
+	}
+}
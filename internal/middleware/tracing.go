@@ -4,9 +4,15 @@ import (
 	"net/http"
 
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
+// RequestIDAttribute is the span attribute holding a request's X-Request-ID,
+// set on its HTTP server span and on the server span of every RPC made for
+// it, so a trace can be found from the ID a client quotes.
+const RequestIDAttribute = attribute.Key("request.id")
+
 // Tracing returns middleware that wraps each request in an OpenTelemetry span
 // using the otelhttp instrumentation library. The serviceName parameter
 // becomes the span name prefix, making it easy to identify the API gateway
@@ -14,20 +20,24 @@ import (
 //
 // The middleware also exposes the trace ID to clients via the X-Trace-ID
 // response header, enabling frontend applications and API consumers to
-// correlate their requests with backend traces for debugging.
+// correlate their requests with backend traces for debugging. It must run
+// inside RequestID, whose ID is recorded on the span as RequestIDAttribute.
 func Tracing(serviceName string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		// otelhttp.NewHandler automatically starts a span, records HTTP
 		// metrics, and propagates the trace context to downstream services.
-		handler := otelhttp.NewHandler(next, serviceName)
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			handler.ServeHTTP(w, r)
-			// Extract the trace ID after the handler runs so it reflects
-			// the span that was actually created for this request.
+		return otelhttp.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The span lives in the context otelhttp hands to the wrapped
+			// handler, and the header must be set before next writes the
+			// response.
 			span := trace.SpanFromContext(r.Context())
+			if id := GetRequestID(r.Context()); id != "" {
+				span.SetAttributes(RequestIDAttribute.String(id))
+			}
 			if span.SpanContext().HasTraceID() {
 				w.Header().Set("X-Trace-ID", span.SpanContext().TraceID().String())
 			}
-		})
+			next.ServeHTTP(w, r)
+		}), serviceName)
 	}
 }
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// AnalyticsShare is a share token granting read-only access to one link's
+// analytics: whoever holds it passes it as ?share= to the link's
+// /api/analytics/{code}/* endpoints instead of signing in.
+type AnalyticsShare struct {
+	Token     string     `json:"token"`
+	ShortCode string     `json:"short_code"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // nil if it works until revoked
+}
+
+// CreateAnalyticsShareRequest is the optional body of POST
+// /api/urls/{code}/analytics/shares. TTLSeconds of 0 makes a token that
+// works until it is revoked.
+type CreateAnalyticsShareRequest struct {
+	TTLSeconds int64 `json:"ttl_seconds,omitempty"`
+}
+
+// ListAnalyticsSharesResponse lists a link's share tokens, newest first.
+type ListAnalyticsSharesResponse struct {
+	Shares []*AnalyticsShare `json:"shares"`
+}
+
+// RevokeAnalyticsSharesResponse is returned by DELETE
+// /api/urls/{code}/analytics/shares.
+type RevokeAnalyticsSharesResponse struct {
+	Revoked int32 `json:"revoked"`
+}
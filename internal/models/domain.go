@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Domain is a custom domain a user has registered for branded short links
+// (e.g. "go.acme.com"). It can only be used for links once Verified, which
+// the owner proves by publishing VerificationToken in a DNS TXT record at
+// VerificationRecord.
+type Domain struct {
+	Domain             string     `json:"domain"`
+	UserID             string     `json:"-"`
+	Verified           bool       `json:"verified"`
+	VerificationRecord string     `json:"verification_record"`
+	VerificationToken  string     `json:"verification_token"`
+	CreatedAt          time.Time  `json:"created_at"`
+	VerifiedAt         *time.Time `json:"verified_at,omitempty"`
+}
+
+// RegisterDomainRequest is the REST API request body for claiming a custom
+// domain. The response carries the TXT record to publish before verifying.
+type RegisterDomainRequest struct {
+	Domain string `json:"domain"`
+}
+
+// ListDomainsResponse wraps the caller's custom domains.
+type ListDomainsResponse struct {
+	Domains []*Domain `json:"domains"`
+}
@@ -0,0 +1,61 @@
+package models
+
+// ErrorResponse is the envelope of every error the API gateway returns.
+// Code is a stable, machine-readable ErrCode* value that clients can switch
+// on; Message is for humans and may be reworded at any time. RequestID is
+// the X-Request-ID of the failed request, to quote when reporting it.
+// Suggestions lists alternatives the client could retry with, such as free
+// aliases when the requested one is taken. Errors lists every invalid field
+// of a request body that failed validation, so a form can flag them all at
+// once.
+type ErrorResponse struct {
+	Code        string       `json:"code"`
+	Message     string       `json:"message"`
+	RequestID   string       `json:"request_id,omitempty"`
+	Suggestions []string     `json:"suggestions,omitempty"`
+	Errors      []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError is one problem with one field of a request body. Field is the
+// field's JSON name, with an index for an element of a list
+// ("variants[1].long_url"), and Code one of the 400 ErrCode* values.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error codes, listed with the HTTP status each is returned with. The
+// backend services name the domain-specific ones (ALIAS_TAKEN,
+// ALIAS_RECLAIMED, URL_NOT_FOUND, QUOTA_EXCEEDED, INVALID_URL,
+// SELF_REFERENTIAL_URL) as the reason of an
+// ErrorInfo detail; the gateway derives the rest from the gRPC status code.
+const (
+	ErrCodeInvalidRequest     = "INVALID_REQUEST"        // 400: a missing or malformed field
+	ErrCodeInvalidJSON        = "INVALID_JSON"           // 400: a body that is not valid JSON
+	ErrCodeInvalidURL         = "INVALID_URL"            // 400: a destination that is not an http(s) URL
+	ErrCodeSelfReferentialURL = "SELF_REFERENTIAL_URL"   // 400: a destination that leads back to this shortener
+	ErrCodeUnauthorized       = "UNAUTHORIZED"           // 401: a missing, invalid or expired token
+	ErrCodeInvalidCredentials = "INVALID_CREDENTIALS"    // 401: a wrong email or password
+	ErrCodeForbidden          = "FORBIDDEN"              // 403: the caller may not do this
+	ErrCodeAccountDisabled    = "ACCOUNT_DISABLED"       // 403: the account has been disabled
+	ErrCodeNotFound           = "NOT_FOUND"              // 404: a user, domain or webhook that does not exist
+	ErrCodeURLNotFound        = "URL_NOT_FOUND"          // 404: a short code that does not exist
+	ErrCodeMethodNotAllowed   = "METHOD_NOT_ALLOWED"     // 405
+	ErrCodeConflict           = "CONFLICT"               // 409: the resource already exists
+	ErrCodeAliasTaken         = "ALIAS_TAKEN"            // 409: the custom alias is in use; see suggestions
+	ErrCodeAliasReclaimed     = "ALIAS_RECLAIMED"        // 409: a link to reactivate lost its alias to someone else; see suggestions
+	ErrCodeRequestInProgress  = "REQUEST_IN_PROGRESS"    // 409: the Idempotency-Key's first request has not finished
+	ErrCodePayloadTooLarge    = "PAYLOAD_TOO_LARGE"      // 413
+	ErrCodePreconditionFailed = "PRECONDITION_FAILED"    // 422: the request is valid but not in the current state
+	ErrCodeIdempotencyKeyUsed = "IDEMPOTENCY_KEY_REUSED" // 422: the Idempotency-Key came with a different request
+	ErrCodeRateLimited        = "RATE_LIMITED"           // 429: too many requests; see Retry-After
+	ErrCodeQuotaExceeded      = "QUOTA_EXCEEDED"         // 429: the user's link quota is used up
+	ErrCodeLoginLocked        = "LOGIN_LOCKED"           // 429: too many failed logins; see Retry-After
+	ErrCodeCodesExhausted     = "SHORT_CODE_EXHAUSTED"   // 429: every short code generated for a link was taken; retry
+	ErrCodeInternal           = "INTERNAL"               // 500
+	ErrCodeNotImplemented     = "NOT_IMPLEMENTED"        // 501: a feature disabled by configuration
+	ErrCodeBadGateway         = "BAD_GATEWAY"            // 502: an upstream store failed
+	ErrCodeUnavailable        = "SERVICE_UNAVAILABLE"    // 503: a backend is down
+	ErrCodeTimeout            = "TIMEOUT"                // 504: a backend was too slow
+)
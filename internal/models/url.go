@@ -18,66 +18,314 @@ import "time"
 //
 // ExpiresAt is a pointer so that URLs without an explicit TTL are represented
 // as NULL in the database and omitted from JSON responses (omitempty).
+//
+// MaxClicks turns the link into a burn-after-N link: once N redirects have
+// been served, further visits get 410 Gone. Zero means unlimited.
+//
+// ActiveFrom schedules the link: it can be created (and shared) ahead of a
+// launch but only starts redirecting once that time has passed. Nil means
+// the link is active immediately.
+//
+// Tags are user-defined labels for organizing and filtering links, stored
+// lowercase and deduplicated.
+//
+// Domain is the verified custom domain the link is served on (e.g.
+// "go.acme.com"). Empty means the default base URL. A link only redirects on
+// its own domain.
+//
+// Variants turn the link into an A/B split: each redirect goes to one of
+// them, chosen at random by weight. LongURL is then the first variant's
+// destination. A link with no variants always redirects to LongURL.
+//
+// GeoRules send visitors from the listed countries to their own destination,
+// ahead of the variants and LongURL.
+//
+// Preview makes the link show an interstitial page naming its destination,
+// with a button to continue, instead of redirecting straight to it.
+//
+// BackupURL is served instead of LongURL while the redirect service's health
+// checks find LongURL down. It is only allowed on single-destination links.
+//
+// Disabled links answer 410 Gone instead of redirecting until their owner
+// enables them again. Unlike deletion it keeps the code, clicks and
+// analytics; unlike expiry it is undone at will. It is stored inverted, as
+// the is_active column, so a new link is enabled by default.
+//
+// Title, Description and ImageURL preview the destination page. They are
+// fetched in the background after creation when link previews are enabled,
+// and are empty until then or when the page offers none.
 type URL struct {
-	ShortCode string     `json:"short_code"`
-	ShortURL  string     `json:"short_url,omitempty"`
-	LongURL   string     `json:"long_url"`
-	Clicks    int64      `json:"clicks"`
-	CreatedAt time.Time  `json:"created_at"`
-	ExpiresAt *time.Time `json:"expires_at,omitempty"`
-	QRCode    string     `json:"qr_code,omitempty"`
-	UserID    string     `json:"user_id,omitempty"`
+	ShortCode  string       `json:"short_code"`
+	ShortURL   string       `json:"short_url,omitempty"`
+	LongURL    string       `json:"long_url"`
+	Clicks     int64        `json:"clicks"`
+	MaxClicks  int64        `json:"max_clicks,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	ActiveFrom *time.Time   `json:"active_from,omitempty"`
+	ExpiresAt  *time.Time   `json:"expires_at,omitempty"`
+	Tags       []string     `json:"tags,omitempty"`
+	QRCode     string       `json:"qr_code,omitempty"`
+	UserID     string       `json:"user_id,omitempty"`
+	Domain     string       `json:"domain,omitempty"`
+	Variants   []URLVariant `json:"variants,omitempty"`
+	GeoRules   []GeoRule    `json:"geo_rules,omitempty"`
+	Preview    bool         `json:"preview,omitempty"`
+	BackupURL  string       `json:"backup_url,omitempty"`
+	Disabled   bool         `json:"disabled,omitempty"`
+
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+}
+
+// URLVariant is one weighted destination of an A/B split link. Weights are
+// relative: variants weighted 3 and 1 get 75% and 25% of redirects.
+type URLVariant struct {
+	LongURL string `json:"long_url"`
+	Weight  int32  `json:"weight"`
+}
+
+// GeoRule redirects visitors from one country to a dedicated destination.
+// CountryCode is an ISO 3166-1 alpha-2 code, normalized to uppercase.
+type GeoRule struct {
+	CountryCode string `json:"country_code"`
+	LongURL     string `json:"long_url"`
 }
 
 // CreateURLRequest is the REST API request body for creating a new shortened
-// URL with a system-generated short code.
+// URL with a system-generated short code. MaxClicks of 1 creates a one-time
+// link; omit it (or send 0) for an unlimited link. ActiveFrom, when set, must
+// be earlier than ExpiresAt. Tags are normalized to lowercase and deduplicated.
+// Domain, when set, must be a custom domain the caller has verified. Two or
+// more Variants create an A/B split link; LongURL may then be omitted and
+// defaults to the first variant. GeoRules override the destination for
+// visitors from the listed countries. Preview serves the link through an
+// interstitial page rather than a redirect. BackupURL is redirected to while
+// LongURL is down, and cannot be combined with Variants or GeoRules. The QR
+// code is rendered on its first request unless GenerateQR asks for it at
+// creation.
 type CreateURLRequest struct {
-	LongURL   string     `json:"long_url"`
-	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	LongURL    string       `json:"long_url"`
+	ActiveFrom *time.Time   `json:"active_from,omitempty"`
+	ExpiresAt  *time.Time   `json:"expires_at,omitempty"`
+	MaxClicks  int64        `json:"max_clicks,omitempty"`
+	Tags       []string     `json:"tags,omitempty"`
+	Domain     string       `json:"domain,omitempty"`
+	Variants   []URLVariant `json:"variants,omitempty"`
+	GeoRules   []GeoRule    `json:"geo_rules,omitempty"`
+	Preview    bool         `json:"preview,omitempty"`
+	BackupURL  string       `json:"backup_url,omitempty"`
+	GenerateQR bool         `json:"generate_qr,omitempty"`
 }
 
 // CreateURLResponse is the REST API response returned after successfully
-// creating a shortened URL. It includes the generated QR code (base64-encoded
-// PNG) so clients can display it without a second round-trip.
+// creating a shortened URL. QRCode is where to fetch the link's QR code, or
+// the image itself as a data URI when it was generated eagerly and is kept in
+// the database.
 type CreateURLResponse struct {
-	ShortCode string     `json:"short_code"`
-	ShortURL  string     `json:"short_url"`
-	LongURL   string     `json:"long_url"`
-	CreatedAt time.Time  `json:"created_at"`
-	ExpiresAt *time.Time `json:"expires_at,omitempty"`
-	QRCode    string     `json:"qr_code,omitempty"`
+	ShortCode  string       `json:"short_code"`
+	ShortURL   string       `json:"short_url"`
+	LongURL    string       `json:"long_url"`
+	CreatedAt  time.Time    `json:"created_at"`
+	ActiveFrom *time.Time   `json:"active_from,omitempty"`
+	ExpiresAt  *time.Time   `json:"expires_at,omitempty"`
+	MaxClicks  int64        `json:"max_clicks,omitempty"`
+	Tags       []string     `json:"tags,omitempty"`
+	QRCode     string       `json:"qr_code,omitempty"`
+	Variants   []URLVariant `json:"variants,omitempty"`
+	GeoRules   []GeoRule    `json:"geo_rules,omitempty"`
+	Preview    bool         `json:"preview,omitempty"`
+	BackupURL  string       `json:"backup_url,omitempty"`
 }
 
 // CreateCustomURLRequest is the REST API request body for creating a shortened
 // URL with a user-chosen alias (e.g., "my-link") instead of a random code.
+// Preview, BackupURL and GenerateQR work as in CreateURLRequest.
 type CreateCustomURLRequest struct {
-	Alias     string     `json:"alias"`
-	LongURL   string     `json:"long_url"`
-	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Alias      string     `json:"alias"`
+	LongURL    string     `json:"long_url"`
+	ActiveFrom *time.Time `json:"active_from,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	MaxClicks  int64      `json:"max_clicks,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	Domain     string     `json:"domain,omitempty"`
+	Preview    bool       `json:"preview,omitempty"`
+	BackupURL  string     `json:"backup_url,omitempty"`
+	GenerateQR bool       `json:"generate_qr,omitempty"`
 }
 
 // CreateCustomURLResponse mirrors CreateURLResponse but is returned by the
 // custom-alias endpoint. The ShortCode field contains the user-chosen alias.
 type CreateCustomURLResponse struct {
+	ShortCode  string     `json:"short_code"`
+	ShortURL   string     `json:"short_url"`
+	LongURL    string     `json:"long_url"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ActiveFrom *time.Time `json:"active_from,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	MaxClicks  int64      `json:"max_clicks,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	QRCode     string     `json:"qr_code,omitempty"`
+	Preview    bool       `json:"preview,omitempty"`
+	BackupURL  string     `json:"backup_url,omitempty"`
+}
+
+// ReactivateURLRequest is the optional body of POST
+// /api/urls/{code}/reactivate. Without ExpiresAt the link gets the user's
+// default expiry, counted from now.
+type ReactivateURLRequest struct {
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// ReactivateURLResponse describes a link brought back by POST
+// /api/urls/{code}/reactivate.
+type ReactivateURLResponse struct {
 	ShortCode string     `json:"short_code"`
 	ShortURL  string     `json:"short_url"`
 	LongURL   string     `json:"long_url"`
-	CreatedAt time.Time  `json:"created_at"`
 	ExpiresAt *time.Time `json:"expires_at,omitempty"`
-	QRCode    string     `json:"qr_code,omitempty"`
+}
+
+// SetURLActiveRequest is the body of PUT /api/urls/{code}/active. Active is
+// required: false disables the link, true enables it again.
+type SetURLActiveRequest struct {
+	Active *bool `json:"active"`
+}
+
+// SetURLActiveResponse reports a link's state after PUT
+// /api/urls/{code}/active.
+type SetURLActiveResponse struct {
+	ShortCode string `json:"short_code"`
+	Active    bool   `json:"active"`
+}
+
+// BulkDeleteRequest is the body of POST /api/urls/bulk-delete.
+type BulkDeleteRequest struct {
+	ShortCodes []string `json:"short_codes"`
+}
+
+// BulkDeleteResult reports on one short code of a bulk delete. Status is
+// "deleted", "not_found", or "forbidden" for someone else's link, which is
+// left alone.
+type BulkDeleteResult struct {
+	ShortCode string `json:"short_code"`
+	Status    string `json:"status"`
+}
+
+// BulkDeleteResponse answers POST /api/urls/bulk-delete with one result
+// per distinct requested code, in request order.
+type BulkDeleteResponse struct {
+	Results []BulkDeleteResult `json:"results"`
+	Deleted int                `json:"deleted"`
 }
 
 // ListURLsResponse wraps a page of URL results along with pagination metadata
 // so the client knows whether additional pages are available.
 type ListURLsResponse struct {
-	URLs    []URL `json:"urls"`
-	Total   int32 `json:"total,omitempty"`
-	HasMore bool  `json:"has_more,omitempty"`
+	URLs         []URL `json:"urls"`
+	Total        int32 `json:"total,omitempty"`
+	HasMore      bool  `json:"has_more,omitempty"`
+	TotalClicks  int64 `json:"total_clicks,omitempty"`
+	ActiveCount  int32 `json:"active_count,omitempty"`
+	ExpiredCount int32 `json:"expired_count,omitempty"`
+}
+
+// URLListSummary aggregates every URL matching a paginated list's filter,
+// not just the page returned. Total, TotalClicks and ActiveCount cover the
+// listed (non-expired) URLs; ExpiredCount counts the matching URLs left out
+// because their expiry has passed but the cleanup job has not removed them.
+type URLListSummary struct {
+	Total        int32
+	TotalClicks  int64
+	ActiveCount  int32
+	ExpiredCount int32
+}
+
+// ExportedURL is one record of a bulk export (GET /api/urls/export). The
+// same fields, in this order, form the CSV columns, so the shape is kept
+// deliberately flat and stable for backups and account migration.
+type ExportedURL struct {
+	ShortCode string     `json:"short_code"`
+	ShortURL  string     `json:"short_url"`
+	LongURL   string     `json:"long_url"`
+	Clicks    int64      `json:"clicks"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Tags      []string   `json:"tags,omitempty"`
+}
+
+// ImportRowResult reports the outcome of one CSV row of a bulk import
+// (POST /api/urls/import). Row is the 1-based line number in the uploaded
+// file. Exactly one of ShortCode or Error is set.
+type ImportRowResult struct {
+	Row       int    `json:"row"`
+	LongURL   string `json:"long_url"`
+	Alias     string `json:"alias,omitempty"`
+	ShortCode string `json:"short_code,omitempty"`
+	ShortURL  string `json:"short_url,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ImportURLsResponse summarizes a bulk import. It is returned with 201 when
+// every row was created and 207 Multi-Status when some rows failed.
+type ImportURLsResponse struct {
+	Created int               `json:"created"`
+	Failed  int               `json:"failed"`
+	Results []ImportRowResult `json:"results"`
+}
+
+// TagCount is one entry of GET /api/tags: a distinct tag and how many of the
+// user's links carry it.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
+
+// TagListResponse wraps the user's tags for building a filter UI.
+type TagListResponse struct {
+	Tags []TagCount `json:"tags"`
+}
+
+// UpdateTagsRequest is the body of PUT /api/urls/{code}/tags. The given tags
+// replace the link's current tags; an empty list clears them.
+type UpdateTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// URLStats is the counters and timestamps of one link as its row holds
+// them, served by the url-service's GetURLStats without the analytics
+// stack. Clicks are those flushed to the database; UpdatedAt is when the
+// row was last written, which includes click flushes.
+type URLStats struct {
+	ShortCode string
+	UserID    string
+	Clicks    int64
+	MaxClicks int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	ExpiresAt *time.Time
+}
+
+// UserURLStats summarizes one user's links for the admin API
+// (GET /api/admin/users/{id}/stats).
+type UserURLStats struct {
+	UserID string `json:"user_id"`
+	URLs   int32  `json:"urls"`   // Every link the user owns.
+	Active int32  `json:"active"` // Links currently redirecting.
+	Clicks int64  `json:"clicks"` // Clicks across all of them.
+}
+
+// RegenerateQRRequest is the optional body of POST
+// /api/urls/{code}/qr/regenerate. Zero values give the default 256x256
+// black-on-white image.
+type RegenerateQRRequest struct {
+	Size       int    `json:"size,omitempty"`       // pixels, 64 to 1024
+	Foreground string `json:"foreground,omitempty"` // "#rrggbb"
+	Background string `json:"background,omitempty"` // "#rrggbb"
 }
 
-// ErrorResponse is a generic envelope for API errors, providing both a
-// machine-readable error code string and an optional human-readable message.
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
+// RegenerateQRResponse points at a link's new QR code image.
+type RegenerateQRResponse struct {
+	ShortCode string `json:"short_code"`
+	QRCode    string `json:"qr_code"`
 }
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// URL audit trail actions.
+const (
+	URLEventCreate  = "create"
+	URLEventUpdate  = "update"
+	URLEventDelete  = "delete"
+	URLEventRestore = "restore" // an expired or deleted link brought back by its owner
+	URLEventDisable = "disable" // redirects stopped by the owner, the link kept
+	URLEventEnable  = "enable"  // a disabled link redirecting again
+)
+
+// URLEvent is one entry of a URL's audit trail: a change to the link, who
+// made it and when. BeforeURL and AfterURL are the destination on either
+// side of the change, empty on create (and restore) and delete respectively.
+// An empty ActorID is a change made by the system rather than a user.
+type URLEvent struct {
+	ID         int64     `json:"id"`
+	ShortCode  string    `json:"short_code"`
+	Action     string    `json:"action"`
+	ActorID    string    `json:"actor_id,omitempty"`
+	BeforeURL  string    `json:"before_url,omitempty"`
+	AfterURL   string    `json:"after_url,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// URLHistoryResponse is the body of GET /api/urls/{code}/history, oldest
+// event first.
+type URLHistoryResponse struct {
+	ShortCode string     `json:"short_code"`
+	Events    []URLEvent `json:"events"`
+}
@@ -11,14 +11,27 @@ import "time"
 // Timestamps are managed by the storage layer (set at INSERT time and updated
 // on profile changes).
 type User struct {
-	ID           string    `json:"id"`
-	Email        string    `json:"email"`
-	Name         string    `json:"name"`
-	PasswordHash string    `json:"-"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           string     `json:"id"`
+	Email        string     `json:"email"`
+	Name         string     `json:"name"`
+	PasswordHash string     `json:"-"`
+	Role         string     `json:"role"`                  // RoleUser or RoleAdmin.
+	DisabledAt   *time.Time `json:"disabled_at,omitempty"` // Set while an admin has disabled the account.
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// DefaultURLTTL is how long links the user creates without an expiry
+	// live: nil uses the global DEFAULT_URL_TTL and 0 never expires.
+	DefaultURLTTL *time.Duration `json:"-"`
 }
 
+// Roles a user can hold. Every account starts as RoleUser; RoleAdmin is
+// granted by updating the users table directly.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 // CreateUserRequest carries the fields needed to register a new user. The
 // plain-text Password is accepted here and hashed (bcrypt) in the service
 // layer before being passed to storage.
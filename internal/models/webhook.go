@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Webhook is a user-registered HTTP callback attached to a single short link.
+// The pipeline-worker POSTs a signed JSON payload to TargetURL after a click
+// has been enriched, either on every click or on every ClickThreshold-th
+// click. Secret is the HMAC key used to sign each delivery and is only ever
+// returned to the owner at registration time (hence json:"-").
+//
+// FailureCount tracks consecutive failed deliveries; once it reaches the
+// worker's configured maximum the webhook is Disabled and no longer fires
+// until the owner re-registers it.
+type Webhook struct {
+	ID                 string     `json:"id"`
+	ShortCode          string     `json:"short_code"`
+	UserID             string     `json:"user_id"`
+	TargetURL          string     `json:"target_url"`
+	Secret             string     `json:"-"`
+	ClickThreshold     int32      `json:"click_threshold"`
+	RateLimitPerMinute int32      `json:"rate_limit_per_minute"`
+	FailureCount       int32      `json:"failure_count"`
+	Disabled           bool       `json:"disabled"`
+	LastDeliveredAt    *time.Time `json:"last_delivered_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// CreateWebhookRequest is the REST API request body for registering a
+// webhook on one of the caller's short links. ClickThreshold and
+// RateLimitPerMinute default to 1 and 60 when omitted.
+type CreateWebhookRequest struct {
+	ShortCode          string `json:"short_code"`
+	TargetURL          string `json:"target_url"`
+	ClickThreshold     int32  `json:"click_threshold,omitempty"`
+	RateLimitPerMinute int32  `json:"rate_limit_per_minute,omitempty"`
+}
+
+// CreateWebhookResponse is returned once after registration. It is the only
+// response that carries the signing secret; receivers must store it to
+// verify the X-Webhook-Signature header on deliveries.
+type CreateWebhookResponse struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+// ListWebhooksResponse wraps the webhooks registered on a short link.
+type ListWebhooksResponse struct {
+	Webhooks []*Webhook `json:"webhooks"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// WildcardRule is a wildcard alias: every code starting with Prefix on
+// Domain that is not a link of its own redirects to Destination, with the
+// rest of the code added as a path segment or, with SuffixMode "query", as
+// the value of QueryParam. Pattern is Prefix followed by "*".
+type WildcardRule struct {
+	Domain      string    `json:"domain,omitempty"`
+	Prefix      string    `json:"prefix"`
+	Pattern     string    `json:"pattern"`
+	UserID      string    `json:"-"`
+	Destination string    `json:"destination"`
+	SuffixMode  string    `json:"suffix_mode"`
+	QueryParam  string    `json:"query_param,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CreateWildcardRuleRequest is the REST API request body for claiming a
+// wildcard alias such as "promo-*". SuffixMode defaults to "path", and
+// QueryParam, for the "query" mode, to "code".
+type CreateWildcardRuleRequest struct {
+	Pattern     string `json:"pattern"`
+	Destination string `json:"destination"`
+	Domain      string `json:"domain,omitempty"`
+	SuffixMode  string `json:"suffix_mode,omitempty"`
+	QueryParam  string `json:"query_param,omitempty"`
+}
+
+// ListWildcardRulesResponse wraps the caller's wildcard aliases.
+type ListWildcardRulesResponse struct {
+	Rules []*WildcardRule `json:"rules"`
+}
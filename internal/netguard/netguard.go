@@ -0,0 +1,70 @@
+// Package netguard keeps outbound requests to user-supplied URLs -- webhook
+// deliveries, link preview fetches and failover probes -- off internal
+// networks.
+//
+// Checking a URL's host once is not enough: its DNS can be re-pointed at an
+// internal address afterwards (DNS rebinding), or the server can redirect
+// there. NewTransport's dialer therefore checks every resolved IP right
+// before it connects.
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned by NewTransport's dialer for an address
+// that is not public.
+var ErrForbiddenAddress = errors.New("destination is not a public address")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which
+// netip does not classify as private but is not publicly routable either.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// IsPublicIP reports whether addr is a globally routable unicast address.
+// Loopback, RFC 1918 / unique-local private ranges, link-local (including
+// the 169.254.169.254 cloud metadata endpoint), CGNAT, multicast and
+// unspecified addresses are all rejected. IPv4-mapped IPv6 addresses are
+// judged by their IPv4 form.
+func IsPublicIP(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!addr.IsLoopback() &&
+		!addr.IsLinkLocalUnicast() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// NewTransport returns an http.Transport that only connects to public
+// addresses, with timeout bounding each dial and TLS handshake. Proxies are
+// disabled because through one the address dialled would be the proxy's
+// rather than the destination's.
+func NewTransport(timeout time.Duration, maxIdleConnsPerHost int) *http.Transport {
+	dialer := &net.Dialer{Timeout: timeout, Control: dialControl}
+	return &http.Transport{
+		Proxy:               nil,
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: timeout,
+		MaxIdleConnsPerHost: maxIdleConnsPerHost,
+	}
+}
+
+// dialControl is a net.Dialer Control hook that refuses connections to
+// non-public addresses. It runs on the resolved IP about to be dialled.
+func dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !IsPublicIP(addr) {
+		return fmt.Errorf("%w: refusing to connect to %s", ErrForbiddenAddress, host)
+	}
+	return nil
+}
@@ -0,0 +1,379 @@
+// Package preview fetches link previews: the title, description and image of
+// a short link's destination page, which link lists show in place of the raw
+// URL.
+//
+// Destinations are user-supplied, so a Fetcher is as careful as a webhook
+// delivery: it only connects to public addresses, re-checked on every dial
+// so that neither a redirect nor a re-pointed DNS name reaches an internal
+// host; it follows at most MaxRedirects redirects, reads at most MaxBytes of
+// the page and gives up after Timeout. Every URL it fetches, redirect targets
+// included, must be allowed to it by its host's robots.txt.
+//
+// A Queue runs the fetches in the background on a bounded pool, so creating
+// a link never waits on its destination.
+package preview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Varun5711/shorternit/internal/netguard"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+// UserAgent identifies preview fetches to destination sites. Its product
+// token, "TinyPreview", is the user-agent robots.txt rules can name.
+const UserAgent = "TinyPreview/1.0 (+https://github.com/Varun5711/tiny)"
+
+// robotsAgent is the product token of UserAgent, lowercased for matching
+// robots.txt groups.
+const robotsAgent = "tinypreview"
+
+// Limits on the stored fields, in runes for the text and bytes for the
+// image URL. Longer titles and descriptions are cut; a longer image URL is
+// dropped.
+const (
+	maxTitle       = 300
+	maxDescription = 1000
+	maxImageURL    = 2048
+)
+
+var (
+	// ErrUnsupportedURL is returned for destinations that are not absolute
+	// http(s) URLs.
+	ErrUnsupportedURL = errors.New("preview: not an http(s) URL")
+
+	// ErrDisallowed is returned when robots.txt does not allow the page to
+	// be fetched, or could not be read because the server failed.
+	ErrDisallowed = errors.New("preview: disallowed by robots.txt")
+
+	// ErrTooManyRedirects is returned when the destination redirects more
+	// than MaxRedirects times.
+	ErrTooManyRedirects = errors.New("preview: too many redirects")
+
+	// ErrNotHTML is returned when the destination is not an HTML page.
+	ErrNotHTML = errors.New("preview: not an HTML page")
+)
+
+// Metadata is what a page offers for its preview. Any field may be empty.
+type Metadata struct {
+	Title       string
+	Description string
+	ImageURL    string // absolute http(s) URL
+}
+
+// Config bounds each fetch. Zero fields fall back to the defaults noted.
+type Config struct {
+	Timeout      time.Duration // whole fetch, robots.txt included (5s)
+	MaxBytes     int64         // of the page read for its <head> (512 KiB)
+	MaxRedirects int           // redirects followed (3)
+}
+
+// Fetcher fetches previews. It is safe for concurrent use.
+type Fetcher struct {
+	cfg          Config
+	client       *http.Client // fetches pages; its CheckRedirect enforces the limits above
+	robotsClient *http.Client // fetches robots.txt, sharing client's transport
+
+	mu     sync.Mutex
+	robots map[string]robotsEntry // by scheme://host
+}
+
+// robotsEntry is a host's cached robots.txt rules.
+type robotsEntry struct {
+	rules   robotsRules
+	expires time.Time
+}
+
+// robotsTTL is how long a host's robots.txt is cached, and maxRobotsHosts
+// how many hosts are cached before the cache is emptied.
+const (
+	robotsTTL      = time.Hour
+	maxRobotsHosts = 1024
+)
+
+// NewFetcher returns a Fetcher whose connections are restricted to public
+// addresses.
+func NewFetcher(cfg Config) *Fetcher {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = 512 << 10
+	}
+	if cfg.MaxRedirects < 0 {
+		cfg.MaxRedirects = 0
+	} else if cfg.MaxRedirects == 0 {
+		cfg.MaxRedirects = 3
+	}
+
+	transport := netguard.NewTransport(cfg.Timeout, 2)
+
+	f := &Fetcher{cfg: cfg, robots: make(map[string]robotsEntry)}
+	f.client = &http.Client{Transport: transport, CheckRedirect: f.checkRedirect}
+	f.robotsClient = &http.Client{Transport: transport, CheckRedirect: checkRobotsRedirect}
+	return f
+}
+
+// Fetch reads the preview of the page at rawURL. It returns an error when
+// the page cannot or may not be fetched, and empty Metadata when it is
+// fetched but offers nothing.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (Metadata, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Metadata{}, ErrUnsupportedURL
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, f.cfg.Timeout)
+	defer cancel()
+
+	if err := f.checkRobots(ctx, u); err != nil {
+		return Metadata{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return Metadata{}, ErrUnsupportedURL
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Metadata{}, fmt.Errorf("preview: destination returned %d", resp.StatusCode)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return Metadata{}, ErrNotHTML
+	}
+
+	body, err := charset.NewReader(io.LimitReader(resp.Body, f.cfg.MaxBytes), contentType)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("preview: %w", err)
+	}
+	// Relative image URLs resolve against the page actually served.
+	return parseHead(body, resp.Request.URL), nil
+}
+
+// checkRedirect limits the redirects a page fetch follows and checks each
+// target against its host's robots.txt.
+func (f *Fetcher) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > f.cfg.MaxRedirects {
+		return ErrTooManyRedirects
+	}
+	return f.checkRobots(req.Context(), req.URL)
+}
+
+// checkRobotsRedirect follows the up to five redirects RFC 9309 asks
+// crawlers to follow for robots.txt.
+func checkRobotsRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > 5 {
+		return ErrTooManyRedirects
+	}
+	return nil
+}
+
+// checkRobots returns ErrDisallowed unless u's host allows UserAgent to
+// fetch it.
+func (f *Fetcher) checkRobots(ctx context.Context, u *url.URL) error {
+	rules, err := f.robotsFor(ctx, u)
+	if err != nil {
+		return err
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	if !rules.allows(path) {
+		return ErrDisallowed
+	}
+	return nil
+}
+
+// robotsFor returns the robots.txt rules of u's host, from the cache when
+// they were read within robotsTTL.
+func (f *Fetcher) robotsFor(ctx context.Context, u *url.URL) (robotsRules, error) {
+	origin := u.Scheme + "://" + u.Host
+	now := time.Now()
+
+	f.mu.Lock()
+	entry, ok := f.robots[origin]
+	f.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.rules, nil
+	}
+
+	rules, err := f.fetchRobots(ctx, origin)
+	if err != nil {
+		return robotsRules{}, err
+	}
+
+	f.mu.Lock()
+	if len(f.robots) >= maxRobotsHosts {
+		f.robots = make(map[string]robotsEntry)
+	}
+	f.robots[origin] = robotsEntry{rules: rules, expires: now.Add(robotsTTL)}
+	f.mu.Unlock()
+	return rules, nil
+}
+
+// maxRobotsBytes is how much of a robots.txt is read; RFC 9309 asks
+// crawlers to read at least 500 KiB, but rules for a preview fetcher are
+// found long before that.
+const maxRobotsBytes = 64 << 10
+
+// fetchRobots reads origin's robots.txt. As RFC 9309 prescribes, a missing
+// one (4xx) allows everything, and one the server fails to serve (5xx)
+// disallows everything.
+func (f *Fetcher) fetchRobots(ctx context.Context, origin string) (robotsRules, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return robotsRules{}, ErrUnsupportedURL
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	resp, err := f.robotsClient.Do(req)
+	if err != nil {
+		return robotsRules{}, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return parseRobots(io.LimitReader(resp.Body, maxRobotsBytes), robotsAgent), nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return robotsRules{}, nil
+	default:
+		return robotsRules{disallowAll: true}, nil
+	}
+}
+
+// parseHead extracts the preview from an HTML page, reading no further than
+// the end of its <head>. Open Graph tags win over Twitter cards, which win
+// over <title> and <meta name="description">.
+func parseHead(r io.Reader, base *url.URL) Metadata {
+	z := html.NewTokenizer(r)
+	props := make(map[string]string)
+	var title strings.Builder
+	inTitle, titleDone := false, false
+
+loop:
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			// io.EOF, or the page cut off at MaxBytes.
+			break loop
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Title:
+				inTitle = !titleDone
+			case atom.Meta:
+				if hasAttr {
+					readMeta(z, props)
+				}
+			case atom.Body:
+				break loop
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Title:
+				inTitle, titleDone = false, true
+			case atom.Head:
+				break loop
+			}
+		case html.TextToken:
+			if inTitle {
+				title.Write(z.Text())
+			}
+		}
+	}
+
+	first := func(keys ...string) string {
+		for _, k := range keys {
+			if v := props[k]; strings.TrimSpace(v) != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	return Metadata{
+		Title:       clean(first("og:title", "twitter:title", "title"), maxTitle, title.String()),
+		Description: clean(first("og:description", "twitter:description", "description"), maxDescription, ""),
+		ImageURL:    resolveImage(base, first("og:image", "og:image:url", "og:image:secure_url", "twitter:image")),
+	}
+}
+
+// readMeta records a <meta> tag's content under its property or name,
+// keeping the first of each.
+func readMeta(z *html.Tokenizer, props map[string]string) {
+	var key, content string
+	for {
+		k, v, more := z.TagAttr()
+		switch string(k) {
+		case "property", "name":
+			if key == "" {
+				key = strings.ToLower(strings.TrimSpace(string(v)))
+			}
+		case "content":
+			content = string(v)
+		}
+		if !more {
+			break
+		}
+	}
+	if _, seen := props[key]; key != "" && !seen {
+		props[key] = content
+	}
+}
+
+// clean collapses whitespace in s, or fallback when s is empty, and cuts it
+// to at most max runes. Invalid UTF-8 is dropped, as PostgreSQL rejects it.
+func clean(s string, max int, fallback string) string {
+	if strings.TrimSpace(s) == "" {
+		s = fallback
+	}
+	s = strings.Join(strings.Fields(strings.ToValidUTF8(s, "")), " ")
+	if utf8.RuneCountInString(s) > max {
+		s = string([]rune(s)[:max])
+	}
+	return s
+}
+
+// resolveImage makes ref absolute against the page's URL, returning "" for
+// anything but a reasonably short http(s) URL.
+func resolveImage(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	if s := u.String(); len(s) <= maxImageURL {
+		return s
+	}
+	return ""
+}
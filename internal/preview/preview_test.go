@@ -0,0 +1,284 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/Varun5711/shorternit/internal/netguard"
+)
+
+// newTestFetcher builds a Fetcher for httptest servers. Those listen on
+// loopback, so the SSRF-guarded transport is swapped for a plain one.
+func newTestFetcher(cfg Config) *Fetcher {
+	f := NewFetcher(cfg)
+	transport := &http.Transport{}
+	f.client.Transport = transport
+	f.robotsClient.Transport = transport
+	return f
+}
+
+// newSite serves robots (404 when empty) at /robots.txt and the given
+// handlers at their paths.
+func newSite(t *testing.T, robots string, pages map[string]http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		if robots == "" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(robots))
+	})
+	for path, h := range pages {
+		mux.HandleFunc(path, h)
+	}
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func htmlPage(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+func TestFetch_PrefersOpenGraph(t *testing.T) {
+	srv := newSite(t, "", map[string]http.HandlerFunc{
+		"/article": htmlPage(`<!doctype html><html><head>
+			<title>Plain   title</title>
+			<meta name="description" content="Plain description">
+			<meta property="og:title" content="Tom &amp; Jerry">
+			<meta property="og:description" content="  The   whole
+				story ">
+			<meta property="og:image" content="/img/cover.png">
+			</head><body><meta property="og:title" content="Not in head"></body></html>`),
+	})
+
+	meta, err := newTestFetcher(Config{}).Fetch(context.Background(), srv.URL+"/article")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	want := Metadata{
+		Title:       "Tom & Jerry",
+		Description: "The whole story",
+		ImageURL:    srv.URL + "/img/cover.png",
+	}
+	if meta != want {
+		t.Errorf("expected %+v, got %+v", want, meta)
+	}
+}
+
+func TestFetch_FallsBackToTitleTag(t *testing.T) {
+	srv := newSite(t, "", map[string]http.HandlerFunc{
+		"/": htmlPage(`<html><head><title>
+			Hello,
+			world</title><meta name="description" content="Greetings"></head></html>`),
+	})
+
+	meta, err := newTestFetcher(Config{}).Fetch(context.Background(), srv.URL+"/")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if meta.Title != "Hello, world" || meta.Description != "Greetings" || meta.ImageURL != "" {
+		t.Errorf("unexpected metadata %+v", meta)
+	}
+}
+
+func TestFetch_StopsAtMaxBytes(t *testing.T) {
+	padding := strings.Repeat(" ", 4096)
+	srv := newSite(t, "", map[string]http.HandlerFunc{
+		"/": htmlPage(`<html><head><title>Early</title>` + padding + `<meta property="og:title" content="Late"></head></html>`),
+	})
+
+	meta, err := newTestFetcher(Config{MaxBytes: 1024}).Fetch(context.Background(), srv.URL+"/")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if meta.Title != "Early" {
+		t.Errorf("expected only the first 1 KiB to be read, got title %q", meta.Title)
+	}
+}
+
+func TestFetch_RejectsNonHTML(t *testing.T) {
+	srv := newSite(t, "", map[string]http.HandlerFunc{
+		"/file.pdf": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/pdf")
+			_, _ = w.Write([]byte("%PDF-1.7"))
+		},
+	})
+
+	if _, err := newTestFetcher(Config{}).Fetch(context.Background(), srv.URL+"/file.pdf"); !errors.Is(err, ErrNotHTML) {
+		t.Errorf("expected ErrNotHTML, got %v", err)
+	}
+}
+
+func TestFetch_RejectsUnsupportedURLs(t *testing.T) {
+	f := newTestFetcher(Config{})
+	for _, u := range []string{"ftp://example.com/", "mailto:a@example.com", "/relative"} {
+		if _, err := f.Fetch(context.Background(), u); !errors.Is(err, ErrUnsupportedURL) {
+			t.Errorf("%s: expected ErrUnsupportedURL, got %v", u, err)
+		}
+	}
+}
+
+func TestFetch_LimitsRedirects(t *testing.T) {
+	hops := 0
+	srv := newSite(t, "", map[string]http.HandlerFunc{
+		"/loop": func(w http.ResponseWriter, r *http.Request) {
+			hops++
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		},
+	})
+
+	_, err := newTestFetcher(Config{MaxRedirects: 2}).Fetch(context.Background(), srv.URL+"/loop")
+	if !errors.Is(err, ErrTooManyRedirects) {
+		t.Errorf("expected ErrTooManyRedirects, got %v", err)
+	}
+	if hops != 3 {
+		t.Errorf("expected the first request and 2 redirects, got %d requests", hops)
+	}
+}
+
+func TestFetch_FollowsRedirects(t *testing.T) {
+	srv := newSite(t, "", map[string]http.HandlerFunc{
+		"/short": func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "/blog/post", http.StatusMovedPermanently)
+		},
+		"/blog/post": htmlPage(`<head><meta property="og:image" content="cover.png"></head>`),
+	})
+
+	meta, err := newTestFetcher(Config{}).Fetch(context.Background(), srv.URL+"/short")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if meta.ImageURL != srv.URL+"/blog/cover.png" {
+		t.Errorf("expected the image resolved against the final URL, got %q", meta.ImageURL)
+	}
+}
+
+func TestFetch_RespectsRobots(t *testing.T) {
+	fetched := false
+	srv := newSite(t, "User-agent: TinyPreview\nDisallow: /private\n", map[string]http.HandlerFunc{
+		"/private/page": func(w http.ResponseWriter, r *http.Request) {
+			fetched = true
+			htmlPage(`<title>Secret</title>`)(w, r)
+		},
+		"/public": func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "/private/page", http.StatusFound)
+		},
+	})
+	f := newTestFetcher(Config{})
+
+	if _, err := f.Fetch(context.Background(), srv.URL+"/private/page"); !errors.Is(err, ErrDisallowed) {
+		t.Errorf("expected ErrDisallowed, got %v", err)
+	}
+	if _, err := f.Fetch(context.Background(), srv.URL+"/public"); !errors.Is(err, ErrDisallowed) {
+		t.Errorf("expected a redirect into a disallowed path to be refused, got %v", err)
+	}
+	if fetched {
+		t.Error("expected the disallowed page never to be requested")
+	}
+}
+
+func TestFetch_RobotsServerErrorDisallows(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("/", htmlPage(`<title>Page</title>`))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	if _, err := newTestFetcher(Config{}).Fetch(context.Background(), srv.URL+"/"); !errors.Is(err, ErrDisallowed) {
+		t.Errorf("expected ErrDisallowed, got %v", err)
+	}
+}
+
+func TestFetch_RefusesPrivateAddresses(t *testing.T) {
+	srv := newSite(t, "", map[string]http.HandlerFunc{"/": htmlPage(`<title>Internal</title>`)})
+
+	// NewFetcher's own transport: the loopback test server is off limits.
+	if _, err := NewFetcher(Config{}).Fetch(context.Background(), srv.URL+"/"); !errors.Is(err, netguard.ErrForbiddenAddress) {
+		t.Errorf("expected netguard.ErrForbiddenAddress, got %v", err)
+	}
+}
+
+// memoryStore records saved previews by short code.
+type memoryStore struct {
+	mu    sync.Mutex
+	saved map[string]Metadata
+}
+
+func (s *memoryStore) SavePreview(ctx context.Context, shortCode, title, description, imageURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saved[shortCode] = Metadata{Title: title, Description: description, ImageURL: imageURL}
+	return nil
+}
+
+func (s *memoryStore) get(shortCode string) (Metadata, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.saved[shortCode]
+	return m, ok
+}
+
+func TestQueue_SavesFetchedPreviews(t *testing.T) {
+	srv := newSite(t, "", map[string]http.HandlerFunc{
+		"/":     htmlPage(`<title>Home</title>`),
+		"/bare": htmlPage(`<p>No head at all</p>`),
+	})
+	store := &memoryStore{saved: make(map[string]Metadata)}
+	q := NewQueue(newTestFetcher(Config{}), store, 1, 10, logger.New("preview-test"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(done)
+	}()
+
+	q.Enqueue("bare", srv.URL+"/bare")
+	q.Enqueue("home", srv.URL+"/")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if m, ok := store.get("home"); ok {
+			if m.Title != "Home" {
+				t.Errorf("expected title %q, got %q", "Home", m.Title)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("preview was not saved")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// The single worker took "bare" first, so it has been processed too.
+	if _, ok := store.get("bare"); ok {
+		t.Error("expected a page without a preview not to be saved")
+	}
+
+	cancel()
+	<-done
+}
+
+func TestQueue_DropsWhenFull(t *testing.T) {
+	q := NewQueue(NewFetcher(Config{}), &memoryStore{}, 1, 1, logger.New("preview-test"))
+
+	if !q.Enqueue("a", "https://example.com/a") {
+		t.Fatal("expected the first link to be queued")
+	}
+	if q.Enqueue("b", "https://example.com/b") {
+		t.Error("expected Enqueue to drop rather than block when the queue is full")
+	}
+}
@@ -0,0 +1,99 @@
+package preview
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Varun5711/shorternit/internal/logger"
+)
+
+// Store saves fetched previews. storage.PostgresStorage satisfies it; tests
+// use an in-memory fake.
+type Store interface {
+	SavePreview(ctx context.Context, shortCode, title, description, imageURL string) error
+}
+
+// job is a link whose destination's preview is to be fetched.
+type job struct {
+	shortCode string
+	longURL   string
+}
+
+// Queue fetches the previews of newly created links in the background.
+// Previews are best effort: a link whose fetch fails, or that is enqueued
+// while the queue is full, simply keeps showing its URL.
+type Queue struct {
+	fetcher *Fetcher
+	store   Store
+	workers int
+	jobs    chan job
+	log     *logger.Logger
+}
+
+// NewQueue returns a Queue that runs fetches on workers goroutines once Run
+// is called, buffering up to size links.
+func NewQueue(fetcher *Fetcher, store Store, workers, size int, log *logger.Logger) *Queue {
+	if workers <= 0 {
+		workers = 4
+	}
+	if size <= 0 {
+		size = 1000
+	}
+	return &Queue{
+		fetcher: fetcher,
+		store:   store,
+		workers: workers,
+		jobs:    make(chan job, size),
+		log:     log,
+	}
+}
+
+// Enqueue schedules a fetch of longURL's preview for shortCode. It never
+// blocks: when the queue is full the fetch is dropped and false is returned.
+func (q *Queue) Enqueue(shortCode, longURL string) bool {
+	select {
+	case q.jobs <- job{shortCode: shortCode, longURL: longURL}:
+		return true
+	default:
+		q.log.Warn("Preview queue full, dropping fetch for %s", shortCode)
+		return false
+	}
+}
+
+// Run fetches queued previews until ctx is cancelled, then waits for the
+// fetches in flight. Links still queued at that point are left without a
+// preview.
+func (q *Queue) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < q.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case j := <-q.jobs:
+					q.process(ctx, j)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// process fetches and saves one preview. A page offering no preview is not
+// saved, leaving nothing to distinguish it from one not yet fetched.
+func (q *Queue) process(ctx context.Context, j job) {
+	meta, err := q.fetcher.Fetch(ctx, j.longURL)
+	if err != nil {
+		q.log.Debug("No preview for %s: %v", j.shortCode, err)
+		return
+	}
+	if meta == (Metadata{}) {
+		return
+	}
+	if err := q.store.SavePreview(ctx, j.shortCode, meta.Title, meta.Description, meta.ImageURL); err != nil {
+		q.log.Error("Failed to save preview for %s: %v", j.shortCode, err)
+	}
+}
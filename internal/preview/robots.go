@@ -0,0 +1,145 @@
+package preview
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// robotsRules are the rules of a robots.txt that apply to one user agent.
+type robotsRules struct {
+	rules       []robotsRule
+	disallowAll bool // the robots.txt could not be read because the server failed
+}
+
+// robotsRule is one Allow or Disallow line. Its pattern is a path prefix
+// in which "*" matches any run of characters and a trailing "$" anchors the
+// end of the path.
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// robotsGroup is a run of User-agent lines and the rules following them.
+type robotsGroup struct {
+	agents []string
+	rules  []robotsRule
+}
+
+// parseRobots reads a robots.txt and returns the rules for agent, a
+// lowercase product token: those of every group naming it, or if none does,
+// of every group for "*". Lines it does not understand are ignored.
+func parseRobots(r io.Reader, agent string) robotsRules {
+	var groups []*robotsGroup
+	var current *robotsGroup
+	inRules := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// A User-agent line after rules starts the next group.
+			if current == nil || inRules {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+				inRules = false
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			// An empty Disallow allows everything, the same as no rule.
+			if value != "" {
+				current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: value})
+			}
+		}
+	}
+
+	var named, wildcard robotsRules
+	found := false
+	for _, g := range groups {
+		switch {
+		case containsAgent(g.agents, agent):
+			named.rules = append(named.rules, g.rules...)
+			found = true
+		case containsAgent(g.agents, "*"):
+			wildcard.rules = append(wildcard.rules, g.rules...)
+		}
+	}
+	if found {
+		return named
+	}
+	return wildcard
+}
+
+// containsAgent reports whether agents names agent.
+func containsAgent(agents []string, agent string) bool {
+	for _, a := range agents {
+		if a == agent {
+			return true
+		}
+	}
+	return false
+}
+
+// allows reports whether path (with its query, if any) may be fetched. The
+// longest matching rule decides, Allow winning a tie; a path no rule
+// matches is allowed.
+func (r robotsRules) allows(path string) bool {
+	if r.disallowAll {
+		return false
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+
+	allowed, longest := true, -1
+	for _, rule := range r.rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > longest || (n == longest && rule.allow) {
+			allowed, longest = rule.allow, n
+		}
+	}
+	return allowed
+}
+
+// robotsMatch reports whether pattern matches path from its start.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = pattern[:len(pattern)-1]
+	}
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			// The last part must end the path.
+			return strings.HasSuffix(path[pos:], part)
+		}
+		idx := strings.Index(path[pos:], part)
+		if idx < 0 {
+			return false
+		}
+		pos += idx + len(part)
+	}
+	return !anchored || pos == len(path)
+}
@@ -0,0 +1,96 @@
+package preview
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRobots_SelectsGroup(t *testing.T) {
+	const robots = `
+# Everyone else stays out of /admin.
+User-agent: *
+Disallow: /admin
+
+User-agent: OtherBot
+User-agent: TinyPreview
+Disallow: /drafts
+Allow: /drafts/public
+
+User-agent: tinypreview
+Disallow: /tmp
+`
+	rules := parseRobots(strings.NewReader(robots), robotsAgent)
+
+	for path, want := range map[string]bool{
+		"/admin":               true, // only the "*" group disallows it
+		"/drafts/x":            false,
+		"/drafts/public/x":     true,
+		"/tmp/file":            false, // groups naming the agent are merged
+		"/":                    true,
+		"/robots.txt":          true,
+		"/drafts?preview=true": false,
+	} {
+		if got := rules.allows(path); got != want {
+			t.Errorf("allows(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestParseRobots_FallsBackToWildcard(t *testing.T) {
+	rules := parseRobots(strings.NewReader("User-agent: *\nDisallow: /\n\nUser-agent: OtherBot\nDisallow:\n"), robotsAgent)
+	if rules.allows("/page") {
+		t.Error("expected the * group to apply when no group names TinyPreview")
+	}
+
+	rules = parseRobots(strings.NewReader("User-agent: *\nDisallow:\n"), robotsAgent)
+	if !rules.allows("/page") {
+		t.Error("expected an empty Disallow to allow everything")
+	}
+}
+
+func TestRobotsRules_LongestMatchWins(t *testing.T) {
+	rules := robotsRules{rules: []robotsRule{
+		{allow: false, pattern: "/shop"},
+		{allow: true, pattern: "/shop/items"},
+		{allow: false, pattern: "/*.php$"},
+		{allow: true, pattern: "/page"},
+		{allow: false, pattern: "/page"},
+	}}
+
+	for path, want := range map[string]bool{
+		"/shop":          false,
+		"/shop/items/1":  true,
+		"/index.php":     false,
+		"/index.php?x=1": true, // $ anchors the end, and the query follows it
+		"/dir/a.php":     false,
+		"/page":          true, // Allow wins a tie
+	} {
+		if got := rules.allows(path); got != want {
+			t.Errorf("allows(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	if (robotsRules{disallowAll: true}).allows("/") {
+		t.Error("expected disallowAll to refuse every path")
+	}
+}
+
+func TestRobotsMatch(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/", "/anything", true},
+		{"/a", "/b", false},
+		{"/a*c", "/abbbc/d", true},
+		{"/a*c$", "/abbbc/d", false},
+		{"/a*c$", "/abbbc", true},
+		{"/exact$", "/exact", true},
+		{"/exact$", "/exactly", false},
+		{"*.gif$", "/img/x.gif", true},
+	} {
+		if got := robotsMatch(tc.pattern, tc.path); got != tc.want {
+			t.Errorf("robotsMatch(%q, %q) = %v, want %v", tc.pattern, tc.path, got, tc.want)
+		}
+	}
+}
@@ -3,11 +3,17 @@
 // responses and API payloads, and an ASCII-art representation for terminal
 // display in the TUI client. Both use the skip2/go-qrcode library under
 // the hood.
+//
+// The package also provides Store, which keeps the PNGs in object storage
+// (S3 or MinIO) or a local directory so that the database only records
+// each image's key, and RedisStore, which caches images the API gateway
+// renders on demand.
 package qrcode
 
 import (
-	"encoding/base64"
 	"fmt"
+	"image/color"
+	"strconv"
 	"strings"
 
 	"github.com/skip2/go-qrcode"
@@ -20,14 +26,86 @@ import (
 // image. Medium error-correction is used as a balance between data density
 // and scan reliability.
 func GenerateQRCode(url string) (string, error) {
-	png, err := qrcode.Encode(url, qrcode.Medium, 256)
+	png, err := GeneratePNG(url)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate QR code: %w", err)
+		return "", err
+	}
+
+	return EncodeDataURI(png), nil
+}
+
+// GeneratePNG encodes the given URL into the same 256x256 PNG as
+// GenerateQRCode, as raw bytes for a Store.
+func GeneratePNG(url string) ([]byte, error) {
+	return GeneratePNGWithOptions(url, Options{})
+}
+
+// Limits of Options.Size, in pixels.
+const (
+	MinSize = 64
+	MaxSize = 1024
+)
+
+// Options customize the image GeneratePNGWithOptions renders. The zero
+// value gives GeneratePNG's 256x256 black-on-white image.
+type Options struct {
+	Size       int    // width and height in pixels, MinSize to MaxSize; 0 = 256
+	Foreground string // "#rrggbb"; "" = black
+	Background string // "#rrggbb"; "" = white
+}
+
+// Validate reports the first option out of range or not a "#rrggbb" color.
+func (o Options) Validate() error {
+	if o.Size != 0 && (o.Size < MinSize || o.Size > MaxSize) {
+		return fmt.Errorf("size must be between %d and %d pixels", MinSize, MaxSize)
+	}
+	if _, err := parseColor(o.Foreground, color.Black); err != nil {
+		return fmt.Errorf("foreground: %w", err)
+	}
+	if _, err := parseColor(o.Background, color.White); err != nil {
+		return fmt.Errorf("background: %w", err)
 	}
+	return nil
+}
 
-	encoded := base64.StdEncoding.EncodeToString(png)
+// GeneratePNGWithOptions encodes the given URL into a PNG QR code with the
+// size and colors of opts, at the same error-correction level as
+// GeneratePNG.
+func GeneratePNGWithOptions(url string, opts Options) ([]byte, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	qr, err := qrcode.New(url, qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+	qr.ForegroundColor, _ = parseColor(opts.Foreground, color.Black)
+	qr.BackgroundColor, _ = parseColor(opts.Background, color.White)
 
-	return fmt.Sprintf("data:image/png;base64,%s", encoded), nil
+	size := opts.Size
+	if size == 0 {
+		size = 256
+	}
+	png, err := qr.PNG(size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+	return png, nil
+}
+
+// parseColor parses a "#rrggbb" color, returning def for "".
+func parseColor(hex string, def color.Color) (color.Color, error) {
+	if hex == "" {
+		return def, nil
+	}
+	if len(hex) != 7 || hex[0] != '#' {
+		return nil, fmt.Errorf("color %q must be #rrggbb", hex)
+	}
+	rgb, err := strconv.ParseUint(hex[1:], 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("color %q must be #rrggbb", hex)
+	}
+	return color.RGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 0xff}, nil
 }
 
 // GenerateQRCodeASCII produces a text-based QR code using full-block Unicode
@@ -0,0 +1,272 @@
+package qrcode
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/config"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/redis/go-redis/v9"
+)
+
+// dataURIPrefix starts every QR code kept inline in the qr_code column.
+const dataURIPrefix = "data:image/png;base64,"
+
+// ErrNotFound is returned by Store.Get for a key that holds no image.
+var ErrNotFound = errors.New("qr code not found")
+
+// Store keeps QR code images outside the database, so the urls table holds
+// only each image's key instead of a few kilobytes of base64 per row.
+type Store interface {
+	// Put stores png under key, replacing any existing object.
+	Put(ctx context.Context, key string, png []byte) error
+
+	// Get returns the image stored under key, or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Delete removes the image stored under key. Deleting a missing key is
+	// not an error.
+	Delete(ctx context.Context, key string) error
+
+	// URL returns the address clients can fetch key from directly, or ""
+	// when the store is not publicly reachable and images must be proxied.
+	URL(key string) string
+}
+
+// NewStore opens the store selected by cfg.Backend: "s3" for S3 or MinIO,
+// "local" for a directory on disk, or "db" (the default) for none, in which
+// case it returns nil and QR codes stay inline in the database.
+func NewStore(cfg config.QRCodeConfig) (Store, error) {
+	switch cfg.Backend {
+	case "", "db":
+		return nil, nil
+	case "local":
+		store, err := NewLocalStore(cfg.LocalDir, cfg.PublicURL)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	case "s3":
+		store, err := NewS3Store(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown QR code store %q (want db, local or s3)", cfg.Backend)
+	}
+}
+
+// RenderedKey returns the key under which the QR code for shortCode on
+// domain is kept once rendered on demand. The image depends only on the short
+// URL, so the key never needs invalidating, even if the code is reused.
+func RenderedKey(domain, shortCode string) string {
+	if domain == "" {
+		return "rendered/" + shortCode + ".png"
+	}
+	return "rendered/" + domain + "/" + shortCode + ".png"
+}
+
+// IsDataURI reports whether a qr_code value is an inline image rather than
+// a Store key.
+func IsDataURI(v string) bool {
+	return strings.HasPrefix(v, dataURIPrefix)
+}
+
+// EncodeDataURI returns png as the data URI GenerateQRCode produces.
+func EncodeDataURI(png []byte) string {
+	return dataURIPrefix + base64.StdEncoding.EncodeToString(png)
+}
+
+// DecodeDataURI returns the PNG held in a data URI from GenerateQRCode.
+func DecodeDataURI(v string) ([]byte, error) {
+	if !IsDataURI(v) {
+		return nil, fmt.Errorf("not a PNG data URI")
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimPrefix(v, dataURIPrefix))
+}
+
+// LocalStore keeps QR code images as files in a directory. It is meant for
+// development and tests.
+type LocalStore struct {
+	dir       string
+	publicURL string
+}
+
+// NewLocalStore creates a LocalStore under dir, creating the directory if
+// needed. publicURL, if set, is where a web server exposes dir.
+func NewLocalStore(dir, publicURL string) (*LocalStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("QR_LOCAL_DIR is required for the local QR code store")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create QR code directory: %w", err)
+	}
+	return &LocalStore{dir: dir, publicURL: strings.TrimSuffix(publicURL, "/")}, nil
+}
+
+func (s *LocalStore) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
+
+func (s *LocalStore) Put(ctx context.Context, key string, png []byte) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to store QR code: %w", err)
+	}
+	if err := os.WriteFile(path, png, 0o644); err != nil {
+		return fmt.Errorf("failed to store QR code: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStore) Get(ctx context.Context, key string) ([]byte, error) {
+	png, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read QR code: %w", err)
+	}
+	return png, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete QR code: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStore) URL(key string) string {
+	if s.publicURL == "" {
+		return ""
+	}
+	return s.publicURL + "/" + key
+}
+
+// S3Store keeps QR code images in an S3-compatible bucket (AWS S3 or
+// MinIO).
+type S3Store struct {
+	client    *minio.Client
+	bucket    string
+	publicURL string
+}
+
+// NewS3Store connects to the bucket described by cfg and checks that it
+// exists, so a misconfigured store fails at startup rather than on the
+// first upload.
+func NewS3Store(ctx context.Context, cfg config.QRCodeConfig) (*S3Store, error) {
+	if cfg.S3Endpoint == "" || cfg.S3Bucket == "" {
+		return nil, fmt.Errorf("QR_S3_ENDPOINT and QR_S3_BUCKET are required for the s3 QR code store")
+	}
+	client, err := minio.New(cfg.S3Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.S3AccessKey, cfg.S3SecretKey, ""),
+		Secure: cfg.S3UseSSL,
+		Region: cfg.S3Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+	exists, err := client.BucketExists(ctx, cfg.S3Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach QR code bucket: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("QR code bucket %q does not exist", cfg.S3Bucket)
+	}
+	return &S3Store{client: client, bucket: cfg.S3Bucket, publicURL: strings.TrimSuffix(cfg.PublicURL, "/")}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, png []byte) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, bytes.NewReader(png), int64(len(png)), minio.PutObjectOptions{
+		ContentType:  "image/png",
+		CacheControl: "public, max-age=31536000, immutable",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload QR code: %w", err)
+	}
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch QR code: %w", err)
+	}
+	defer obj.Close()
+
+	png, err := io.ReadAll(obj)
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch QR code: %w", err)
+	}
+	return png, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete QR code: %w", err)
+	}
+	return nil
+}
+
+func (s *S3Store) URL(key string) string {
+	if s.publicURL == "" {
+		return ""
+	}
+	return s.publicURL + "/" + key
+}
+
+// RedisStore caches QR code images in Redis for a fixed TTL. It backs QR
+// codes rendered on demand when no object store is configured.
+type RedisStore struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewRedisStore creates a RedisStore keeping each image for ttl.
+func NewRedisStore(client *redis.Client, ttl time.Duration) *RedisStore {
+	return &RedisStore{client: client, ttl: ttl}
+}
+
+func (s *RedisStore) Put(ctx context.Context, key string, png []byte) error {
+	if err := s.client.Set(ctx, "qr:"+key, png, s.ttl).Err(); err != nil {
+		return fmt.Errorf("failed to cache QR code: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	png, err := s.client.Get(ctx, "qr:"+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached QR code: %w", err)
+	}
+	return png, nil
+}
+
+func (s *RedisStore) Delete(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, "qr:"+key).Err(); err != nil {
+		return fmt.Errorf("failed to delete cached QR code: %w", err)
+	}
+	return nil
+}
+
+// URL returns "": cached images are always served through the gateway.
+func (s *RedisStore) URL(key string) string {
+	return ""
+}
@@ -0,0 +1,131 @@
+package qrcode
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/config"
+)
+
+// TestLocalStore_RoundTrip verifies that an image can be stored, read back
+// and deleted, and that deleting it twice is not an error.
+func TestLocalStore_RoundTrip(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	ctx := context.Background()
+	png := []byte("\x89PNG fake image")
+
+	if err := store.Put(ctx, "abc/1.png", png); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	got, err := store.Get(ctx, "abc/1.png")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if !bytes.Equal(got, png) {
+		t.Errorf("expected %q, got %q", png, got)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := store.Delete(ctx, "abc/1.png"); err != nil {
+			t.Fatalf("Delete %d failed: %v", i+1, err)
+		}
+	}
+	if _, err := store.Get(ctx, "abc/1.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+// TestLocalStore_URL verifies that images are only addressable when a public
+// URL is configured.
+func TestLocalStore_URL(t *testing.T) {
+	private, err := NewLocalStore(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	if u := private.URL("abc/1.png"); u != "" {
+		t.Errorf("expected no URL without a public URL, got %q", u)
+	}
+
+	public, err := NewLocalStore(t.TempDir(), "https://cdn.example.com/qr/")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	if u := public.URL("abc/1.png"); u != "https://cdn.example.com/qr/abc/1.png" {
+		t.Errorf("unexpected URL %q", u)
+	}
+}
+
+// TestDataURI verifies that GenerateQRCode's data URIs decode back to the
+// PNG GeneratePNG produces, and that store keys are not taken for data URIs.
+func TestDataURI(t *testing.T) {
+	png, err := GeneratePNG("https://tiny.io/abc")
+	if err != nil {
+		t.Fatalf("GeneratePNG failed: %v", err)
+	}
+	uri, err := GenerateQRCode("https://tiny.io/abc")
+	if err != nil {
+		t.Fatalf("GenerateQRCode failed: %v", err)
+	}
+
+	if !IsDataURI(uri) {
+		t.Fatalf("expected a data URI, got %.40q", uri)
+	}
+	decoded, err := DecodeDataURI(uri)
+	if err != nil {
+		t.Fatalf("DecodeDataURI failed: %v", err)
+	}
+	if !bytes.Equal(decoded, png) {
+		t.Error("expected the decoded image to match GeneratePNG")
+	}
+
+	if IsDataURI("abc/1.png") {
+		t.Error("expected a store key not to be a data URI")
+	}
+	if _, err := DecodeDataURI("abc/1.png"); err == nil {
+		t.Error("expected an error decoding a store key")
+	}
+}
+
+// TestNewStore verifies the backend selection.
+func TestNewStore(t *testing.T) {
+	for _, backend := range []string{"", "db"} {
+		store, err := NewStore(config.QRCodeConfig{Backend: backend})
+		if err != nil || store != nil {
+			t.Errorf("backend %q: expected no store, got %v, %v", backend, store, err)
+		}
+	}
+
+	store, err := NewStore(config.QRCodeConfig{Backend: "local", LocalDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("expected a local store, got %v", err)
+	}
+	if _, ok := store.(*LocalStore); !ok {
+		t.Errorf("expected *LocalStore, got %T", store)
+	}
+
+	for _, cfg := range []config.QRCodeConfig{
+		{Backend: "local"},
+		{Backend: "s3", S3Bucket: "qr"},
+		{Backend: "gcs"},
+	} {
+		if _, err := NewStore(cfg); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+}
+
+// TestRenderedKey verifies that rendered images are keyed per domain and
+// cannot collide with uploaded ones, which are keyed <code>/<uuid>.png.
+func TestRenderedKey(t *testing.T) {
+	if k := RenderedKey("", "abc"); k != "rendered/abc.png" {
+		t.Errorf("unexpected key %q", k)
+	}
+	if k := RenderedKey("go.acme.com", "abc"); k != "rendered/go.acme.com/abc.png" {
+		t.Errorf("unexpected key %q", k)
+	}
+}
@@ -0,0 +1,203 @@
+// Package quota limits how many links each user may keep and create.
+//
+// Two limits apply, each 0 for unlimited: the number of a user's links that
+// have not expired, counted in PostgreSQL, and the number created per UTC
+// day, counted in Redis with INCRBY so every url-service replica shares one
+// counter. Both come from the global Limits unless the user's plan (the
+// users.plan column) names an entry in the per-plan overrides.
+//
+// The active-link count is read before the creation is written, so
+// concurrent creations by the same user can overshoot MaxActive by the
+// number in flight. The daily counter is exact. Like the login guard it
+// fails open: if Redis is unavailable creations are only held to MaxActive.
+package quota
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clock"
+	"github.com/redis/go-redis/v9"
+)
+
+// Limits caps one user's links. A zero field is unlimited.
+type Limits struct {
+	MaxActive int64 // links that have not expired
+	Daily     int64 // links created per UTC day
+}
+
+// ParsePlans parses per-plan overrides written as "plan:maxActive:daily",
+// e.g. "pro:10000:1000".
+func ParsePlans(entries []string) (map[string]Limits, error) {
+	plans := make(map[string]Limits, len(entries))
+	for _, entry := range entries {
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid quota plan %q: want plan:maxActive:daily", entry)
+		}
+		maxActive, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || maxActive < 0 {
+			return nil, fmt.Errorf("invalid quota plan %q: bad active link limit", entry)
+		}
+		daily, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil || daily < 0 {
+			return nil, fmt.Errorf("invalid quota plan %q: bad daily limit", entry)
+		}
+		plans[parts[0]] = Limits{MaxActive: maxActive, Daily: daily}
+	}
+	return plans, nil
+}
+
+// ActiveCounter counts a user's links that have not expired. It is
+// satisfied by storage.PostgresStorage.
+type ActiveCounter interface {
+	CountActiveByUser(ctx context.Context, userID string) (int64, error)
+}
+
+// PlanSource looks up a user's plan, "" when the user has none. It is
+// satisfied by storage.UserStorage.
+type PlanSource interface {
+	GetPlan(ctx context.Context, userID string) (string, error)
+}
+
+// dailyCounter holds the per-day creation counters. It is satisfied by
+// redisDailyCounter; tests substitute an in-process implementation.
+type dailyCounter interface {
+	// incrBy adds n to key, setting it to expire after ttl when it is new,
+	// and returns the result.
+	incrBy(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error)
+}
+
+// ExceededError reports a creation refused by a quota.
+type ExceededError struct {
+	Daily bool  // the daily limit, rather than the active-link limit
+	Limit int64 // the limit that was reached
+
+	// RetryAfter is how long until the daily limit resets; zero for the
+	// active-link limit, which only frees up as links are deleted or expire.
+	RetryAfter time.Duration
+}
+
+func (e *ExceededError) Error() string {
+	if e.Daily {
+		return fmt.Sprintf("daily link quota reached: at most %d new links per day", e.Limit)
+	}
+	return fmt.Sprintf("link quota reached: at most %d active links; delete some to create more", e.Limit)
+}
+
+// Enforcer checks creations against the quotas.
+type Enforcer struct {
+	active   ActiveCounter
+	plans    PlanSource
+	counter  dailyCounter
+	defaults Limits
+	byPlan   map[string]Limits
+	clock    clock.Clock
+}
+
+// NewEnforcer creates an Enforcer that counts daily creations in Redis.
+// byPlan overrides defaults for users on the named plans.
+func NewEnforcer(redisClient *redis.Client, active ActiveCounter, plans PlanSource, defaults Limits, byPlan map[string]Limits) *Enforcer {
+	return &Enforcer{
+		active:   active,
+		plans:    plans,
+		counter:  redisDailyCounter{client: redisClient},
+		defaults: defaults,
+		byPlan:   byPlan,
+		clock:    clock.Real{},
+	}
+}
+
+// Reservation is a number of creations counted against a user's daily
+// limit by Reserve.
+type Reservation struct {
+	key string // "" when nothing was counted
+	n   int64
+}
+
+// Reserve checks that userID may create n more links and counts them
+// against today's limit. It returns an *ExceededError if either limit would
+// be passed, in which case nothing is counted. Creations that then fail
+// should be handed back with Release.
+func (e *Enforcer) Reserve(ctx context.Context, userID string, n int64) (*Reservation, error) {
+	limits, err := e.limitsFor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if limits.MaxActive > 0 {
+		count, err := e.active.CountActiveByUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if count+n > limits.MaxActive {
+			return nil, &ExceededError{Limit: limits.MaxActive}
+		}
+	}
+
+	if limits.Daily <= 0 {
+		return &Reservation{}, nil
+	}
+	now := e.clock.Now().UTC()
+	key := "quota:daily:" + userID + ":" + now.Format("20060102")
+	// The key outlives its day by an hour so a late Release still finds it.
+	count, err := e.counter.incrBy(ctx, key, n, untilMidnight(now)+time.Hour)
+	if err != nil {
+		return &Reservation{}, nil
+	}
+	if count > limits.Daily {
+		_, _ = e.counter.incrBy(ctx, key, -n, 0)
+		return nil, &ExceededError{Daily: true, Limit: limits.Daily, RetryAfter: untilMidnight(now)}
+	}
+	return &Reservation{key: key, n: n}, nil
+}
+
+// Release hands back unused of the reserved creations, such as links that
+// failed to save.
+func (e *Enforcer) Release(ctx context.Context, r *Reservation, unused int64) {
+	if r == nil || r.key == "" || unused <= 0 {
+		return
+	}
+	_, _ = e.counter.incrBy(ctx, r.key, -min(unused, r.n), 0)
+}
+
+// limitsFor returns the limits that apply to userID.
+func (e *Enforcer) limitsFor(ctx context.Context, userID string) (Limits, error) {
+	if len(e.byPlan) == 0 {
+		return e.defaults, nil
+	}
+	plan, err := e.plans.GetPlan(ctx, userID)
+	if err != nil {
+		return Limits{}, err
+	}
+	if limits, ok := e.byPlan[plan]; ok {
+		return limits, nil
+	}
+	return e.defaults, nil
+}
+
+// untilMidnight returns the time left in now's UTC day.
+func untilMidnight(now time.Time) time.Duration {
+	y, m, d := now.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC).Sub(now)
+}
+
+// redisDailyCounter keeps the daily counters as plain Redis keys.
+type redisDailyCounter struct {
+	client *redis.Client
+}
+
+func (c redisDailyCounter) incrBy(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error) {
+	pipe := c.client.TxPipeline()
+	incr := pipe.IncrBy(ctx, key, n)
+	if ttl > 0 {
+		pipe.ExpireNX(ctx, key, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
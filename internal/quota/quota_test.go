@@ -0,0 +1,163 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clock/clocktest"
+)
+
+// memoryCounter is an in-process dailyCounter. While err is set every call
+// fails, as with Redis down.
+type memoryCounter struct {
+	mu     sync.Mutex
+	values map[string]int64
+	err    error
+}
+
+func (c *memoryCounter) incrBy(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return 0, c.err
+	}
+	c.values[key] += n
+	return c.values[key], nil
+}
+
+// fixedActive reports the same active-link count for every user.
+type fixedActive int64
+
+func (f fixedActive) CountActiveByUser(ctx context.Context, userID string) (int64, error) {
+	return int64(f), nil
+}
+
+// planTable maps user IDs to plans.
+type planTable map[string]string
+
+func (p planTable) GetPlan(ctx context.Context, userID string) (string, error) {
+	return p[userID], nil
+}
+
+func newTestEnforcer(active int64, defaults Limits, byPlan map[string]Limits, plans planTable) (*Enforcer, *memoryCounter) {
+	counter := &memoryCounter{values: map[string]int64{}}
+	e := &Enforcer{
+		active:   fixedActive(active),
+		plans:    plans,
+		counter:  counter,
+		defaults: defaults,
+		byPlan:   byPlan,
+		clock:    clocktest.NewFake(time.Date(2026, 3, 1, 18, 0, 0, 0, time.UTC)),
+	}
+	return e, counter
+}
+
+func TestReserve_ActiveLimit(t *testing.T) {
+	ctx := context.Background()
+
+	// One below the limit: the last link still fits.
+	e, _ := newTestEnforcer(9, Limits{MaxActive: 10}, nil, nil)
+	if _, err := e.Reserve(ctx, "u1", 1); err != nil {
+		t.Fatalf("expected the 10th link to be allowed, got %v", err)
+	}
+
+	// At the limit: refused, with nothing to wait for.
+	e, _ = newTestEnforcer(10, Limits{MaxActive: 10}, nil, nil)
+	_, err := e.Reserve(ctx, "u1", 1)
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) || exceeded.Daily || exceeded.Limit != 10 || exceeded.RetryAfter != 0 {
+		t.Fatalf("expected the active-link limit to refuse the 11th link, got %v", err)
+	}
+
+	// A batch that would pass the limit is refused as a whole.
+	e, _ = newTestEnforcer(8, Limits{MaxActive: 10}, nil, nil)
+	if _, err := e.Reserve(ctx, "u1", 3); !errors.As(err, &exceeded) {
+		t.Fatalf("expected a batch past the limit to be refused, got %v", err)
+	}
+}
+
+func TestReserve_DailyLimit(t *testing.T) {
+	ctx := context.Background()
+	e, counter := newTestEnforcer(0, Limits{Daily: 3}, nil, nil)
+
+	for i := 0; i < 3; i++ {
+		if _, err := e.Reserve(ctx, "u1", 1); err != nil {
+			t.Fatalf("creation %d: unexpected error: %v", i+1, err)
+		}
+	}
+
+	_, err := e.Reserve(ctx, "u1", 1)
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) || !exceeded.Daily || exceeded.Limit != 3 {
+		t.Fatalf("expected the daily limit to refuse the 4th link, got %v", err)
+	}
+	if exceeded.RetryAfter != 6*time.Hour {
+		t.Errorf("expected the limit to reset at midnight UTC in 6h, got %v", exceeded.RetryAfter)
+	}
+	if got := counter.values["quota:daily:u1:20260301"]; got != 3 {
+		t.Errorf("expected a refused creation not to be counted, got %d", got)
+	}
+
+	// Other users have their own allowance.
+	if _, err := e.Reserve(ctx, "u2", 1); err != nil {
+		t.Errorf("expected another user to be unaffected, got %v", err)
+	}
+}
+
+func TestRelease_ReturnsUnusedCreations(t *testing.T) {
+	ctx := context.Background()
+	e, _ := newTestEnforcer(0, Limits{Daily: 3}, nil, nil)
+
+	r, err := e.Reserve(ctx, "u1", 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e.Release(ctx, r, 2)
+
+	if _, err := e.Reserve(ctx, "u1", 2); err != nil {
+		t.Errorf("expected released creations to be available again, got %v", err)
+	}
+}
+
+func TestReserve_PlanOverridesDefaults(t *testing.T) {
+	ctx := context.Background()
+	byPlan := map[string]Limits{"pro": {MaxActive: 100}}
+	e, _ := newTestEnforcer(50, Limits{MaxActive: 10}, byPlan, planTable{"paid": "pro", "free": "free"})
+
+	if _, err := e.Reserve(ctx, "paid", 1); err != nil {
+		t.Errorf("expected the pro plan's higher limit to apply, got %v", err)
+	}
+	if _, err := e.Reserve(ctx, "free", 1); err == nil {
+		t.Error("expected a plan without an override to get the defaults")
+	}
+}
+
+func TestReserve_FailsOpenWithoutRedis(t *testing.T) {
+	e, counter := newTestEnforcer(0, Limits{Daily: 1}, nil, nil)
+	counter.err = errors.New("connection refused")
+
+	for i := 0; i < 3; i++ {
+		if _, err := e.Reserve(context.Background(), "u1", 1); err != nil {
+			t.Fatalf("expected creations to proceed while Redis is down, got %v", err)
+		}
+	}
+}
+
+func TestParsePlans(t *testing.T) {
+	plans, err := ParsePlans([]string{"pro:10000:1000", "unlimited:0:0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plans["pro"] != (Limits{MaxActive: 10000, Daily: 1000}) || plans["unlimited"] != (Limits{}) {
+		t.Errorf("unexpected plans: %v", plans)
+	}
+
+	for _, bad := range []string{"pro", "pro:1", ":1:1", "pro:x:1", "pro:1:-1"} {
+		if _, err := ParsePlans([]string{bad}); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
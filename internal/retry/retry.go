@@ -0,0 +1,44 @@
+// Package retry retries connecting to a service's dependencies at startup,
+// so a service started seconds before Redis, PostgreSQL or ClickHouse is
+// ready waits for it instead of exiting and crash-looping.
+package retry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/logger"
+)
+
+// maxBackoff caps the wait between two attempts.
+const maxBackoff = 30 * time.Second
+
+// Dial calls fn until it succeeds, at most attempts times. After the first
+// failure it waits backoff, and after each further one twice as long as the
+// last, up to maxBackoff. Every failure that is retried is logged with
+// name, the dependency being connected to. It returns nil once fn succeeds,
+// fn's last error wrapped with name once attempts run out, or ctx's error if
+// ctx ends while waiting. An attempts below 1 is taken as 1.
+func Dial(ctx context.Context, name string, attempts int, backoff time.Duration, fn func() error) error {
+	attempts = max(attempts, 1)
+	wait := backoff
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			return fmt.Errorf("%s unavailable after %d attempts: %w", name, attempts, err)
+		}
+
+		logger.New("startup").Warn("%s unavailable (attempt %d of %d), retrying in %s: %v", name, attempt, attempts, wait, err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s unavailable: %w", name, ctx.Err())
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, maxBackoff)
+	}
+}
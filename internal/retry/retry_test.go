@@ -0,0 +1,56 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDial_RetriesUntilSuccess(t *testing.T) {
+	calls := 0
+	err := Dial(context.Background(), "redis", 5, time.Millisecond, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected success on the third attempt, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestDial_GivesUpAfterAttempts(t *testing.T) {
+	refused := errors.New("connection refused")
+	calls := 0
+	err := Dial(context.Background(), "postgres", 3, time.Millisecond, func() error {
+		calls++
+		return refused
+	})
+	if !errors.Is(err, refused) {
+		t.Fatalf("expected the last error, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestDial_StopsWhenContextEnds(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := Dial(ctx, "clickhouse", 10, time.Hour, func() error {
+		calls++
+		cancel()
+		return errors.New("connection refused")
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected no retry after cancellation, got %d calls", calls)
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Varun5711/shorternit/internal/cache"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SetURLActive handles the gRPC SetURLActive RPC. The caller must own the
+// link. A disabled link stays listed, keeps its code, clicks and analytics,
+// and is served by GetURL flagged Disabled, so the redirect service answers
+// 410 Gone until it is enabled again.
+//
+// The link's cache entry is rewritten with the new state rather than
+// dropped, so the redirect fast path honors it from the next lookup on
+// without a round trip. The shared Redis tier is rewritten first; then the
+// key is published with cache.Invalidate, so every redirect replica drops
+// the entry it holds in its in-process tier and reads the new one. Both are
+// best-effort: if Redis cannot be reached, the replicas have lost their
+// subscription to it too, and empty their in-process tier when they
+// resubscribe.
+func (s *URLService) SetURLActive(ctx context.Context, req *pb.SetURLActiveRequest) (*pb.SetURLActiveResponse, error) {
+	if req.ShortCode == "" || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "short_code and user_id are required")
+	}
+
+	url, err := s.store.GetByShortCode(ctx, req.ShortCode)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get URL: %v", err)
+	}
+	if url == nil {
+		return nil, urlNotFoundError("short code not found")
+	}
+	if url.UserID != req.UserId {
+		return nil, status.Error(codes.PermissionDenied, "you do not own this short code")
+	}
+
+	if err := s.store.SetActive(ctx, req.ShortCode, req.Active, req.UserId); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, urlNotFoundError("short code not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to set URL active: %v", err)
+	}
+
+	url.Disabled = !req.Active
+	cacheKey := "url:" + url.ShortCode
+	_ = s.cache.SetURL(ctx, cacheKey, cache.EntryFromURL(url))
+	_ = s.cache.Invalidate(ctx, cacheKey)
+
+	return &pb.SetURLActiveResponse{ShortCode: url.ShortCode, Active: req.Active}, nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/cache"
+	"github.com/Varun5711/shorternit/internal/cache/cachetest"
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func setActive(s *URLService, code, userID string, active bool) (*pb.SetURLActiveResponse, error) {
+	return s.SetURLActive(context.Background(), &pb.SetURLActiveRequest{ShortCode: code, UserId: userID, Active: active})
+}
+
+// TestSetURLActive_DisableAndEnable verifies that disabling a link keeps it,
+// serves it flagged Disabled, rewrites its cache entry, and records the
+// change, and that enabling it again undoes all of that.
+func TestSetURLActive_DisableAndEnable(t *testing.T) {
+	store := newFakeStore(&models.URL{ShortCode: "launch", LongURL: "https://example.com", UserID: "alice"})
+	s := &URLService{store: store, cache: cachetest.NewL1Only()}
+	ctx := context.Background()
+	_ = s.cache.SetURL(ctx, "url:launch", cache.URLEntry{LongURL: "https://example.com", UserID: "alice"})
+
+	resp, err := setActive(s, "launch", "alice", false)
+	if err != nil {
+		t.Fatalf("SetURLActive: %v", err)
+	}
+	if resp.ShortCode != "launch" || resp.Active {
+		t.Errorf("expected launch disabled, got %+v", resp)
+	}
+	if entry, ok := s.cache.GetURL(ctx, "url:launch"); !ok || !entry.Disabled || entry.LongURL != "https://example.com" {
+		t.Errorf("expected the cached entry marked disabled, got %+v", entry)
+	}
+	got, err := s.GetURL(ctx, &pb.GetURLRequest{ShortCode: "launch"})
+	if err != nil {
+		t.Fatalf("GetURL: %v", err)
+	}
+	if !got.Found || !got.Url.Disabled || got.Url.IsActive {
+		t.Errorf("expected the link found, disabled and inactive, got %+v", got)
+	}
+
+	// Disabling twice changes nothing and records nothing more.
+	if _, err := setActive(s, "launch", "alice", false); err != nil {
+		t.Fatalf("SetURLActive: %v", err)
+	}
+	if _, err := setActive(s, "launch", "alice", true); err != nil {
+		t.Fatalf("SetURLActive: %v", err)
+	}
+	if entry, ok := s.cache.GetURL(ctx, "url:launch"); !ok || entry.Disabled {
+		t.Errorf("expected the cached entry enabled again, got %+v", entry)
+	}
+	if got, _ := s.GetURL(ctx, &pb.GetURLRequest{ShortCode: "launch"}); !got.Url.IsActive || got.Url.Disabled {
+		t.Errorf("expected the link active again, got %+v", got.Url)
+	}
+
+	if len(store.events) != 2 || store.events[0].Action != models.URLEventDisable || store.events[1].Action != models.URLEventEnable {
+		t.Errorf("expected a disable and an enable, got %+v", store.events)
+	}
+	if store.events[0].ActorID != "alice" {
+		t.Errorf("expected the change recorded against alice, got %q", store.events[0].ActorID)
+	}
+}
+
+// TestSetURLActive_Ownership verifies that only the owner may toggle a link.
+func TestSetURLActive_Ownership(t *testing.T) {
+	store := newFakeStore(&models.URL{ShortCode: "launch", UserID: "alice"})
+	s := &URLService{store: store, cache: cachetest.NewL1Only()}
+
+	if _, err := setActive(s, "launch", "bob", false); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied for another user's link, got %v", err)
+	}
+	if store.urls["launch"].Disabled {
+		t.Error("expected a rejected toggle to leave the link enabled")
+	}
+	if _, err := setActive(s, "nope", "alice", false); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for an unknown link, got %v", err)
+	}
+	if _, err := setActive(s, "launch", "", false); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument without a user, got %v", err)
+	}
+}
+
+// TestSetURLActive_ReachesReplicaL1 disables a link a redirect replica holds
+// in its in-process tier, through the Redis at REDIS_TEST_ADDR, and checks
+// that the replica serves it disabled straight away. It is skipped when
+// REDIS_TEST_ADDR is not set.
+func TestSetURLActive_ReachesReplicaL1(t *testing.T) {
+	addr := os.Getenv("REDIS_TEST_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_TEST_ADDR not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { _ = client.Close() })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Fatalf("Redis at %s: %v", addr, err)
+	}
+
+	code := "active-test-" + time.Now().Format("150405.000000000")
+	t.Cleanup(func() { _ = client.Del(context.Background(), "url:"+code).Err() })
+
+	// The replica has the link in L1 and is listening.
+	replica := cache.NewMultiTierCache(100, client, time.Minute)
+	_ = replica.SetURL(ctx, "url:"+code, cache.URLEntry{LongURL: "https://example.com", UserID: "alice"})
+	listenCtx, stopListening := context.WithCancel(ctx)
+	defer stopListening()
+	go replica.ListenInvalidations(listenCtx)
+	// Wait for the subscription, so the invalidation is not published
+	// before the replica hears it.
+	for client.PubSubNumSub(ctx, cache.InvalidationChannel).Val()[cache.InvalidationChannel] == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	store := newFakeStore(&models.URL{ShortCode: code, LongURL: "https://example.com", UserID: "alice"})
+	s := &URLService{store: store, cache: cache.NewMultiTierCache(100, client, time.Minute)}
+	if _, err := setActive(s, code, "alice", false); err != nil {
+		t.Fatalf("SetURLActive: %v", err)
+	}
+
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if entry, ok := replica.GetURL(ctx, "url:"+code); ok && entry.Disabled {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the replica to serve the link disabled")
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/sharelink"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GenerateAnalyticsToken handles the gRPC GenerateAnalyticsToken RPC,
+// minting a share token for one of the caller's links. Whoever holds it
+// can read the link's analytics through the API gateway (?share=) until it
+// expires or is revoked, but nothing else of the caller's.
+func (s *URLService) GenerateAnalyticsToken(ctx context.Context, req *pb.GenerateAnalyticsTokenRequest) (*pb.GenerateAnalyticsTokenResponse, error) {
+	if s.shares == nil {
+		return nil, status.Error(codes.Unimplemented, "analytics sharing is not enabled")
+	}
+	if req.ShortCode == "" || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "short_code and user_id are required")
+	}
+	if req.TtlSeconds < 0 {
+		return nil, status.Error(codes.InvalidArgument, "ttl_seconds must not be negative")
+	}
+	if err := s.checkOwnership(ctx, req.ShortCode, req.UserId); err != nil {
+		return nil, err
+	}
+
+	share, err := s.shares.Create(ctx, req.ShortCode, time.Duration(req.TtlSeconds)*time.Second)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create share token: %v", err)
+	}
+	return &pb.GenerateAnalyticsTokenResponse{Token: analyticsTokenToProto(share)}, nil
+}
+
+// ListAnalyticsTokens handles the gRPC ListAnalyticsTokens RPC, returning
+// the share tokens of one of the caller's links that still work.
+func (s *URLService) ListAnalyticsTokens(ctx context.Context, req *pb.ListAnalyticsTokensRequest) (*pb.ListAnalyticsTokensResponse, error) {
+	if s.shares == nil {
+		return nil, status.Error(codes.Unimplemented, "analytics sharing is not enabled")
+	}
+	if req.ShortCode == "" || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "short_code and user_id are required")
+	}
+	if err := s.checkOwnership(ctx, req.ShortCode, req.UserId); err != nil {
+		return nil, err
+	}
+
+	shares, err := s.shares.List(ctx, req.ShortCode)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list share tokens: %v", err)
+	}
+	tokens := make([]*pb.AnalyticsToken, len(shares))
+	for i, share := range shares {
+		tokens[i] = analyticsTokenToProto(share)
+	}
+	return &pb.ListAnalyticsTokensResponse{Tokens: tokens}, nil
+}
+
+// RevokeAnalyticsToken handles the gRPC RevokeAnalyticsToken RPC, revoking
+// one share token of one of the caller's links, or all of them when no
+// token is given. A token of another link is NotFound.
+func (s *URLService) RevokeAnalyticsToken(ctx context.Context, req *pb.RevokeAnalyticsTokenRequest) (*pb.RevokeAnalyticsTokenResponse, error) {
+	if s.shares == nil {
+		return nil, status.Error(codes.Unimplemented, "analytics sharing is not enabled")
+	}
+	if req.ShortCode == "" || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "short_code and user_id are required")
+	}
+	if err := s.checkOwnership(ctx, req.ShortCode, req.UserId); err != nil {
+		return nil, err
+	}
+
+	if req.Token == "" {
+		revoked, err := s.shares.RevokeAll(ctx, req.ShortCode)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to revoke share tokens: %v", err)
+		}
+		return &pb.RevokeAnalyticsTokenResponse{Revoked: int32(revoked)}, nil
+	}
+	revoked, err := s.shares.Revoke(ctx, req.ShortCode, req.Token)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to revoke share token: %v", err)
+	}
+	if !revoked {
+		return nil, status.Error(codes.NotFound, "share token not found")
+	}
+	return &pb.RevokeAnalyticsTokenResponse{Revoked: 1}, nil
+}
+
+// analyticsTokenToProto maps a share token to its protobuf form.
+func analyticsTokenToProto(share sharelink.Share) *pb.AnalyticsToken {
+	var expiresAt int64
+	if !share.ExpiresAt.IsZero() {
+		expiresAt = share.ExpiresAt.Unix()
+	}
+	return &pb.AnalyticsToken{
+		Token:     share.Token,
+		ShortCode: share.ShortCode,
+		CreatedAt: share.CreatedAt.Unix(),
+		ExpiresAt: expiresAt,
+	}
+}
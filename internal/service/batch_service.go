@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/cache"
+	es "github.com/Varun5711/shorternit/internal/elasticsearch"
+	"github.com/Varun5711/shorternit/internal/idgen"
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/Varun5711/shorternit/internal/storage"
+	"github.com/Varun5711/shorternit/internal/validation"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxBatchCreateItems caps one BatchCreateURLs call so a single request
+// cannot hold a huge INSERT batch open on the primary.
+const maxBatchCreateItems = 500
+
+// BatchCreateURLs handles the gRPC BatchCreateURLs RPC used by bulk import.
+// Every item is validated on its own and all valid items are written with a
+// single SaveBatch call. An invalid item, an alias that turns out to be
+// taken, or a row the database rejects only fails its own result; the call
+// as a whole fails only when the request is malformed.
+// No QR codes are rendered: each is generated on its first request.
+//
+// Custom aliases skip the distributed lock used by CreateCustomURL: the
+// batch INSERT resolves conflicts with ON CONFLICT DO NOTHING, so a
+// concurrent claim on the same alias simply reports that row as taken.
+//
+// The valid items count against the user's link quota together: if they do
+// not all fit, none is created and each reports the quota message.
+func (s *URLService) BatchCreateURLs(ctx context.Context, req *pb.BatchCreateURLsRequest) (*pb.BatchCreateURLsResponse, error) {
+	if req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if len(req.Items) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "items is required")
+	}
+	if len(req.Items) > maxBatchCreateItems {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d items per batch", maxBatchCreateItems)
+	}
+
+	defaultTTL, err := s.defaultTTLFor(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	results := make([]*pb.BatchCreateURLResult, len(req.Items))
+	pending := make([]*models.URL, 0, len(req.Items))
+	pendingIdx := make([]int, 0, len(req.Items))
+	seenAliases := make(map[string]bool)
+	isOwnHost := s.ownHosts(ctx)
+
+	for i, item := range req.Items {
+		url, err := s.prepareBatchItem(item, req.UserId, defaultTTL, now, seenAliases, isOwnHost)
+		if err != nil {
+			results[i] = &pb.BatchCreateURLResult{Error: err.Error()}
+			continue
+		}
+		pending = append(pending, url)
+		pendingIdx = append(pendingIdx, i)
+	}
+
+	if len(pending) > 0 {
+		reservation, err := s.reserveQuota(ctx, req.UserId, int64(len(pending)))
+		if status.Code(err) == codes.ResourceExhausted {
+			for _, i := range pendingIdx {
+				results[i] = &pb.BatchCreateURLResult{Error: status.Convert(err).Message()}
+			}
+			return &pb.BatchCreateURLsResponse{Results: results}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		errs := s.store.SaveBatch(ctx, pending)
+
+		var failed int64
+		for j, url := range pending {
+			i := pendingIdx[j]
+			if errors.Is(errs[j], storage.ErrShortCodeTaken) && req.Items[i].Alias == "" {
+				// A generated code collided: mint another one, as CreateURL
+				// would.
+				if err := s.createWithRetry(ctx, url, false); err != nil {
+					failed++
+					results[i] = &pb.BatchCreateURLResult{Error: status.Convert(err).Message()}
+					continue
+				}
+				errs[j] = nil
+			}
+			if errs[j] != nil {
+				failed++
+			}
+			switch {
+			case errors.Is(errs[j], storage.ErrShortCodeTaken):
+				results[i] = &pb.BatchCreateURLResult{Error: newAliasTakenError(url.ShortCode).Error()}
+				if s.aliasFilter != nil {
+					s.aliasFilter.Add(url.ShortCode)
+				}
+				continue
+			case errs[j] != nil:
+				// The database error text is not meant for end users.
+				results[i] = &pb.BatchCreateURLResult{Error: "failed to save URL"}
+				continue
+			}
+
+			s.afterCreate(ctx, url)
+			results[i] = &pb.BatchCreateURLResult{
+				ShortCode: url.ShortCode,
+				ShortUrl:  s.shortURL(url.Domain, url.ShortCode),
+			}
+		}
+		s.releaseQuota(ctx, reservation, failed)
+	}
+
+	return &pb.BatchCreateURLsResponse{Results: results}, nil
+}
+
+// prepareBatchItem validates one batch item and builds the record to insert.
+// seenAliases rejects an alias repeated within the same batch, which the
+// database would otherwise silently report as "taken" by the batch itself.
+// defaultTTL is the expiry of an item without one, and isOwnHost is passed
+// to validation.ValidateLongURL.
+func (s *URLService) prepareBatchItem(item *pb.BatchCreateURLItem, userID string, defaultTTL time.Duration, now time.Time, seenAliases map[string]bool, isOwnHost validation.HostFunc) (*models.URL, error) {
+	if item.LongUrl == "" {
+		return nil, fmt.Errorf("long_url is required")
+	}
+	if err := validation.ValidateLongURL(item.LongUrl, isOwnHost); err != nil {
+		return nil, err
+	}
+	if item.ExpiresAt > 0 && !time.Unix(item.ExpiresAt, 0).After(now) {
+		return nil, fmt.Errorf("expires_at must be in the future")
+	}
+
+	shortCode := item.Alias
+	if shortCode != "" {
+		if err := validation.ValidateAlias(shortCode); err != nil {
+			return nil, fmt.Errorf("invalid alias: %w", err)
+		}
+		if seenAliases[shortCode] {
+			return nil, fmt.Errorf("alias '%s' is used more than once in this batch", shortCode)
+		}
+		seenAliases[shortCode] = true
+	} else {
+		id, err := s.idGen.NextID()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ID: %w", err)
+		}
+		shortCode = idgen.EncodePadded(id, s.minCodeLen)
+	}
+
+	_, expiresAt, err := s.resolveSchedule(0, item.ExpiresAt, defaultTTL, now)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := validation.NormalizeTags(item.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.URL{
+		ShortCode: shortCode,
+		LongURL:   item.LongUrl,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+		Tags:      tags,
+		UserID:    userID,
+	}, nil
+}
+
+// afterCreate performs the best-effort follow-up writes for a newly stored
+// URL: record the code in the alias filter, index it for search, queue its
+// link preview and warm the redirect cache.
+func (s *URLService) afterCreate(ctx context.Context, url *models.URL) {
+	if s.aliasFilter != nil {
+		s.aliasFilter.Add(url.ShortCode)
+	}
+
+	if s.esClient != nil {
+		_ = s.esClient.IndexURL(ctx, es.URLDocument{
+			ShortCode: url.ShortCode,
+			LongURL:   url.LongURL,
+			UserID:    url.UserID,
+			CreatedAt: url.CreatedAt,
+			ExpiresAt: url.ExpiresAt,
+			Clicks:    0,
+		})
+	}
+	s.queuePreview(url.ShortCode, url.LongURL)
+
+	_ = s.cache.SetURL(ctx, "url:"+url.ShortCode, cache.URLEntry{
+		LongURL:    url.LongURL,
+		MaxClicks:  url.MaxClicks,
+		ActiveFrom: unixOrZero(url.ActiveFrom),
+		ExpiresAt:  unixOrZero(url.ExpiresAt),
+		UserID:     url.UserID,
+	})
+}
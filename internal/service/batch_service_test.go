@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+// TestBatchCreateURLs_PerRowFailures verifies that a taken alias and a row
+// the database rejects fail only their own results, without leaking the
+// database error, while the other rows are created with normalized tags.
+func TestBatchCreateURLs_PerRowFailures(t *testing.T) {
+	store := newFakeStore(&models.URL{ShortCode: "taken", UserID: "bob"})
+	store.saveErrs = map[string]error{"broken": errors.New("pq: value too long")}
+	s := newAliasTestService(store, nil)
+
+	resp, err := s.BatchCreateURLs(context.Background(), &pb.BatchCreateURLsRequest{
+		UserId: "alice",
+		Items: []*pb.BatchCreateURLItem{
+			{LongUrl: "https://example.com/a", Alias: "fresh", Tags: []string{"Work"}},
+			{LongUrl: "https://example.com/b", Alias: "taken"},
+			{LongUrl: "https://example.com/c", Alias: "broken"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Results[0].Error != "" || resp.Results[0].ShortCode != "fresh" {
+		t.Errorf("expected fresh to be created, got %+v", resp.Results[0])
+	}
+	if !reflect.DeepEqual(store.urls["fresh"].Tags, []string{"work"}) {
+		t.Errorf("expected normalized tags, got %v", store.urls["fresh"].Tags)
+	}
+	if store.urls["fresh"].QRCode != "" {
+		t.Error("expected no QR code to be rendered for a batch row")
+	}
+	if !strings.Contains(resp.Results[1].Error, "already taken") {
+		t.Errorf("expected taken alias error, got %q", resp.Results[1].Error)
+	}
+	if resp.Results[2].Error != "failed to save URL" {
+		t.Errorf("expected a generic save error, got %q", resp.Results[2].Error)
+	}
+}
+
+// TestBatchCreateURLs_OverQuota verifies that a batch that does not fit in
+// the user's quota creates nothing and reports the quota on every row.
+func TestBatchCreateURLs_OverQuota(t *testing.T) {
+	store := newFakeStore(&models.URL{ShortCode: "a1", UserID: "alice"})
+	s := newAliasTestService(store, nil)
+	withActiveQuota(s, store, 2)
+
+	resp, err := s.BatchCreateURLs(context.Background(), &pb.BatchCreateURLsRequest{
+		UserId: "alice",
+		Items: []*pb.BatchCreateURLItem{
+			{LongUrl: "https://example.com/a"},
+			{LongUrl: "https://example.com/b"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, res := range resp.Results {
+		if !strings.Contains(res.Error, "link quota reached") {
+			t.Errorf("row %d: expected the quota error, got %+v", i, res)
+		}
+	}
+	if len(store.urls) != 1 {
+		t.Errorf("expected nothing to be stored, got %d links", len(store.urls))
+	}
+}
+
+// TestBatchCreateURLs_RetriesGeneratedCodes verifies that a generated code
+// that collides is replaced like in CreateURL, and that a row whose codes
+// keep colliding fails alone with the retryable exhaustion error.
+func TestBatchCreateURLs_RetriesGeneratedCodes(t *testing.T) {
+	store := newFakeStore()
+	s := newAliasTestService(store, nil)
+	items := []*pb.BatchCreateURLItem{
+		{LongUrl: "https://example.com/a"},
+		{LongUrl: "https://example.com/b"},
+	}
+
+	// Both rows collide in the batch insert, and the first once more on
+	// retry.
+	store.collide = 3
+	resp, err := s.BatchCreateURLs(context.Background(), &pb.BatchCreateURLsRequest{UserId: "alice", Items: items})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, res := range resp.Results {
+		if res.Error != "" || store.urls[res.ShortCode] == nil {
+			t.Errorf("row %d: expected it created, got %+v", i, res)
+		}
+	}
+
+	// Both rows collide in the batch insert, then the first on every retry.
+	store.collide = 2 + defaultShortCodeAttempts
+	resp, err = s.BatchCreateURLs(context.Background(), &pb.BatchCreateURLsRequest{UserId: "alice", Items: items})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(resp.Results[0].Error, "could not allocate a unique short code") {
+		t.Errorf("expected the first row to run out of codes, got %+v", resp.Results[0])
+	}
+	if resp.Results[1].Error != "" {
+		t.Errorf("expected the second row created, got %+v", resp.Results[1])
+	}
+	if len(store.urls) != 3 {
+		t.Errorf("expected 3 links in all, got %d", len(store.urls))
+	}
+}
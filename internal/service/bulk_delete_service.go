@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxBulkDeleteCodes caps one DeleteURLs call, keeping its DELETE and the
+// cache evictions that follow it short.
+const maxBulkDeleteCodes = 100
+
+// Statuses of a short code in a DeleteURLs response.
+const (
+	bulkDeleteDeleted   = "deleted"
+	bulkDeleteNotFound  = "not_found"
+	bulkDeleteForbidden = "forbidden"
+)
+
+// DeleteURLs handles the gRPC DeleteURLs RPC, deleting many of the caller's
+// links in one statement. Codes owned by someone else are left alone and
+// reported as forbidden, and codes that do not exist as not_found; neither
+// fails the call. Repeated codes are reported once. Each deleted link is
+// then removed from the cache, search index and Redis counters like
+// DeleteURL does.
+func (s *URLService) DeleteURLs(ctx context.Context, req *pb.DeleteURLsRequest) (*pb.DeleteURLsResponse, error) {
+	if req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if len(req.ShortCodes) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "short_codes is required")
+	}
+
+	shortCodes := make([]string, 0, len(req.ShortCodes))
+	seen := make(map[string]bool, len(req.ShortCodes))
+	for _, code := range req.ShortCodes {
+		if code == "" {
+			return nil, status.Error(codes.InvalidArgument, "short_codes must not contain an empty code")
+		}
+		if !seen[code] {
+			seen[code] = true
+			shortCodes = append(shortCodes, code)
+		}
+	}
+	if len(shortCodes) > maxBulkDeleteCodes {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d short codes per call", maxBulkDeleteCodes)
+	}
+
+	deleted, err := s.store.DeleteManyByUser(ctx, req.UserId, shortCodes)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to delete URLs: %v", err)
+	}
+
+	gone := make(map[string]bool, len(deleted))
+	for _, url := range deleted {
+		gone[url.ShortCode] = true
+		s.afterDelete(ctx, url.ShortCode, url.QRCode)
+	}
+
+	results := make([]*pb.DeleteURLsResult, len(shortCodes))
+	for i, code := range shortCodes {
+		result := &pb.DeleteURLsResult{ShortCode: code, Status: bulkDeleteDeleted}
+		if !gone[code] {
+			// Whatever is still there after deleting the caller's links
+			// belongs to someone else.
+			exists, err := s.store.AliasExistsPrimary(ctx, code)
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to check short code: %v", err)
+			}
+			result.Status = bulkDeleteNotFound
+			if exists {
+				result.Status = bulkDeleteForbidden
+			}
+		}
+		results[i] = result
+	}
+	return &pb.DeleteURLsResponse{Results: results}, nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/cache/cachetest"
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestDeleteURLs_MixedOwnership deletes a mix of the caller's links,
+// another user's link, a missing code and a repeated code, and checks each
+// code's status and that only the caller's links are gone.
+func TestDeleteURLs_MixedOwnership(t *testing.T) {
+	store := newFakeStore(
+		&models.URL{ShortCode: "mine1", UserID: "alice", LongURL: "https://a.example"},
+		&models.URL{ShortCode: "mine2", UserID: "alice", LongURL: "https://b.example"},
+		&models.URL{ShortCode: "theirs", UserID: "bob", LongURL: "https://c.example"},
+	)
+	s := &URLService{store: store, cache: cachetest.NewL1Only()}
+	ctx := context.Background()
+
+	resp, err := s.DeleteURLs(ctx, &pb.DeleteURLsRequest{
+		UserId:     "alice",
+		ShortCodes: []string{"mine1", "theirs", "nope", "mine2", "mine1"},
+	})
+	if err != nil {
+		t.Fatalf("DeleteURLs: %v", err)
+	}
+
+	want := []struct{ code, status string }{
+		{"mine1", bulkDeleteDeleted},
+		{"theirs", bulkDeleteForbidden},
+		{"nope", bulkDeleteNotFound},
+		{"mine2", bulkDeleteDeleted},
+	}
+	if len(resp.Results) != len(want) {
+		t.Fatalf("expected %d results, got %+v", len(want), resp.Results)
+	}
+	for i, w := range want {
+		if got := resp.Results[i]; got.ShortCode != w.code || got.Status != w.status {
+			t.Errorf("result %d: expected %s %s, got %s %s", i, w.code, w.status, got.ShortCode, got.Status)
+		}
+	}
+
+	if _, ok := store.urls["theirs"]; !ok || len(store.urls) != 1 {
+		t.Errorf("expected only bob's link left, got %v", store.urls)
+	}
+	if len(store.events) != 2 || store.events[0].ActorID != "alice" || store.events[1].ActorID != "alice" {
+		t.Errorf("expected two deletes by alice, got %+v", store.events)
+	}
+}
+
+// TestDeleteURLs_Validation verifies the arguments DeleteURLs refuses.
+func TestDeleteURLs_Validation(t *testing.T) {
+	s := &URLService{store: newFakeStore(), cache: cachetest.NewL1Only()}
+	ctx := context.Background()
+
+	tooMany := make([]string, maxBulkDeleteCodes+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("code%d", i)
+	}
+	for _, tc := range []struct {
+		name string
+		req  *pb.DeleteURLsRequest
+	}{
+		{"no user", &pb.DeleteURLsRequest{ShortCodes: []string{"abc"}}},
+		{"no codes", &pb.DeleteURLsRequest{UserId: "alice"}},
+		{"empty code", &pb.DeleteURLsRequest{UserId: "alice", ShortCodes: []string{"abc", ""}}},
+		{"too many codes", &pb.DeleteURLsRequest{UserId: "alice", ShortCodes: tooMany}},
+	} {
+		if _, err := s.DeleteURLs(ctx, tc.req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: expected InvalidArgument, got %v", tc.name, err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/cache"
+	"github.com/Varun5711/shorternit/internal/clock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// userTTLMaxAge is how long a user's default expiry is cached. The setting
+// is changed through the user service, so a new value can take up to this
+// long to reach links created here.
+const userTTLMaxAge = time.Minute
+
+// userTTLSource reads a user's own default link expiry. It is satisfied by
+// *storage.UserStorage.
+type userTTLSource interface {
+	GetDefaultURLTTL(ctx context.Context, userID string) (time.Duration, bool, error)
+}
+
+// userTTLEntry is a cached answer of userTTLSource.
+type userTTLEntry struct {
+	ttl     time.Duration
+	set     bool
+	fetched time.Time
+}
+
+// userTTLs caches users' default expiries in process, so creating a link
+// does not read the users table every time.
+type userTTLs struct {
+	source userTTLSource
+	cache  *cache.LRUCache
+	clock  clock.Clock
+	mu     sync.Mutex // serialises misses so concurrent creates share a fetch
+}
+
+func newUserTTLs(source userTTLSource) *userTTLs {
+	return &userTTLs{
+		source: source,
+		cache:  cache.NewLRUCache(10000),
+		clock:  clock.Real{},
+	}
+}
+
+// get returns the user's default expiry, and whether they have chosen one.
+func (u *userTTLs) get(ctx context.Context, userID string) (time.Duration, bool, error) {
+	if e, ok := u.lookup(userID); ok {
+		return e.ttl, e.set, nil
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if e, ok := u.lookup(userID); ok {
+		return e.ttl, e.set, nil
+	}
+	ttl, set, err := u.source.GetDefaultURLTTL(ctx, userID)
+	if err != nil {
+		return 0, false, err
+	}
+	u.cache.Set(userID, userTTLEntry{ttl: ttl, set: set, fetched: u.clock.Now()})
+	return ttl, set, nil
+}
+
+func (u *userTTLs) lookup(userID string) (userTTLEntry, bool) {
+	v, ok := u.cache.Get(userID)
+	if !ok {
+		return userTTLEntry{}, false
+	}
+	e := v.(userTTLEntry)
+	if u.clock.Now().Sub(e.fetched) >= userTTLMaxAge {
+		return userTTLEntry{}, false
+	}
+	return e, true
+}
+
+// defaultTTLFor returns the expiry applied to a link userID creates without
+// one: the user's own default when they have set it, where 0 means never,
+// otherwise the service-wide defaultTTL.
+func (s *URLService) defaultTTLFor(ctx context.Context, userID string) (time.Duration, error) {
+	if s.userTTLs == nil || userID == "" {
+		return s.defaultTTL, nil
+	}
+	ttl, set, err := s.userTTLs.get(ctx, userID)
+	if err != nil {
+		return 0, status.Errorf(codes.Internal, "failed to look up default expiry: %v", err)
+	}
+	if !set {
+		return s.defaultTTL, nil
+	}
+	return ttl, nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clock/clocktest"
+	pb "github.com/Varun5711/shorternit/proto/url"
+)
+
+// fakeTTLSource serves users' default expiries from a map, counting reads.
+type fakeTTLSource struct {
+	ttls  map[string]time.Duration
+	calls int
+}
+
+func (f *fakeTTLSource) GetDefaultURLTTL(ctx context.Context, userID string) (time.Duration, bool, error) {
+	f.calls++
+	ttl, ok := f.ttls[userID]
+	return ttl, ok, nil
+}
+
+func newTTLTestService(source *fakeTTLSource) *URLService {
+	s := newAliasTestService(newFakeStore(), nil)
+	s.defaultTTL = 24 * time.Hour
+	s.userTTLs = newUserTTLs(source)
+	return s
+}
+
+// expiresIn returns how long after its creation the link in resp expires,
+// or 0 if it never does.
+func expiresIn(resp *pb.CreateURLResponse) time.Duration {
+	if resp.ExpiresAt == 0 {
+		return 0
+	}
+	return time.Duration(resp.ExpiresAt-resp.CreatedAt) * time.Second
+}
+
+func TestCreateURL_UserDefaultTTLOverridesGlobal(t *testing.T) {
+	source := &fakeTTLSource{ttls: map[string]time.Duration{
+		"alice": 7 * 24 * time.Hour,
+		"bob":   0,
+	}}
+	s := newTTLTestService(source)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		userID string
+		want   time.Duration
+	}{
+		{"alice", 7 * 24 * time.Hour},
+		{"bob", 0},
+		{"carol", 24 * time.Hour},
+		{"", 24 * time.Hour},
+	} {
+		resp, err := s.CreateURL(ctx, &pb.CreateURLRequest{LongUrl: "https://example.com", UserId: tc.userID})
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tc.userID, err)
+		}
+		if got := expiresIn(resp); got != tc.want {
+			t.Errorf("%q: expected the link to expire after %v, got %v", tc.userID, tc.want, got)
+		}
+	}
+}
+
+func TestCreateURL_ExplicitExpiryBeatsUserDefault(t *testing.T) {
+	s := newTTLTestService(&fakeTTLSource{ttls: map[string]time.Duration{"alice": 0}})
+	expiresAt := time.Now().Add(time.Hour).Unix()
+
+	resp, err := s.CreateURL(context.Background(), &pb.CreateURLRequest{
+		LongUrl:   "https://example.com",
+		UserId:    "alice",
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ExpiresAt != expiresAt {
+		t.Errorf("expected expires_at %d, got %d", expiresAt, resp.ExpiresAt)
+	}
+}
+
+func TestUserTTLs_CachesLookups(t *testing.T) {
+	source := &fakeTTLSource{ttls: map[string]time.Duration{"alice": time.Hour}}
+	ttls := newUserTTLs(source)
+	clk := clocktest.NewFake(time.Now())
+	ttls.clock = clk
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := ttls.get(ctx, "alice"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if source.calls != 1 {
+		t.Errorf("expected one lookup, got %d", source.calls)
+	}
+
+	clk.Advance(userTTLMaxAge)
+	if _, _, err := ttls.get(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if source.calls != 2 {
+		t.Errorf("expected a stale entry to be looked up again, got %d lookups", source.calls)
+	}
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/Varun5711/shorternit/internal/shorturl"
+	"github.com/Varun5711/shorternit/internal/storage"
+	"github.com/Varun5711/shorternit/internal/validation"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// domainVerificationPrefix is prepended to a custom domain to form the DNS
+// name that must carry the verification TXT record.
+const domainVerificationPrefix = "_tiny-verify."
+
+// txtLookup resolves the TXT records at a DNS name. It is satisfied by
+// net.Resolver.LookupTXT; tests substitute a static answer.
+type txtLookup func(ctx context.Context, name string) ([]string, error)
+
+// domainStore is the subset of storage.DomainStorage the URL service needs.
+// Tests substitute an in-memory implementation.
+type domainStore interface {
+	CreateDomain(ctx context.Context, d *models.Domain) (*models.Domain, error)
+	GetDomain(ctx context.Context, domain string) (*models.Domain, error)
+	ListDomains(ctx context.Context, userID string) ([]*models.Domain, error)
+	MarkDomainVerified(ctx context.Context, domain, userID string) (*models.Domain, error)
+}
+
+// RegisterDomain handles the gRPC RegisterDomain RPC. It claims a custom
+// domain for the caller and returns the TXT record that proves ownership;
+// the domain cannot be used for links until VerifyDomain succeeds.
+// Registering a domain the caller already holds returns the existing claim.
+func (s *URLService) RegisterDomain(ctx context.Context, req *pb.RegisterDomainRequest) (*pb.RegisterDomainResponse, error) {
+	if s.domains == nil {
+		return nil, status.Error(codes.Unimplemented, "custom domains are not enabled")
+	}
+	if req.UserId == "" {
+		return nil, status.Error(codes.Unauthenticated, "user_id is required")
+	}
+	domain, err := validation.NormalizeDomain(req.Domain)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if domain == s.defaultDomain() {
+		return nil, status.Error(codes.InvalidArgument, "the default short link domain cannot be registered")
+	}
+
+	token, err := generateVerificationToken()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate verification token: %v", err)
+	}
+
+	d, err := s.domains.CreateDomain(ctx, &models.Domain{
+		Domain:            domain,
+		UserID:            req.UserId,
+		VerificationToken: token,
+	})
+	if errors.Is(err, storage.ErrDomainTaken) {
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to register domain: %v", err)
+	}
+
+	return &pb.RegisterDomainResponse{Domain: domainToProto(d)}, nil
+}
+
+// ListDomains handles the gRPC ListDomains RPC, returning the caller's
+// domains with their verification state.
+func (s *URLService) ListDomains(ctx context.Context, req *pb.ListDomainsRequest) (*pb.ListDomainsResponse, error) {
+	if s.domains == nil {
+		return nil, status.Error(codes.Unimplemented, "custom domains are not enabled")
+	}
+	if req.UserId == "" {
+		return nil, status.Error(codes.Unauthenticated, "user_id is required")
+	}
+
+	domains, err := s.domains.ListDomains(ctx, req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list domains: %v", err)
+	}
+
+	pbDomains := make([]*pb.Domain, len(domains))
+	for i, d := range domains {
+		pbDomains[i] = domainToProto(d)
+	}
+	return &pb.ListDomainsResponse{Domains: pbDomains}, nil
+}
+
+// VerifyDomain handles the gRPC VerifyDomain RPC. It looks up the TXT
+// records at _tiny-verify.<domain> and marks the domain verified when one of
+// them equals the verification token. A domain registered to someone else is
+// reported as NotFound so its existence is not confirmed. Verifying an
+// already verified domain is a no-op.
+func (s *URLService) VerifyDomain(ctx context.Context, req *pb.VerifyDomainRequest) (*pb.VerifyDomainResponse, error) {
+	if s.domains == nil {
+		return nil, status.Error(codes.Unimplemented, "custom domains are not enabled")
+	}
+	if req.UserId == "" {
+		return nil, status.Error(codes.Unauthenticated, "user_id is required")
+	}
+	domain, err := validation.NormalizeDomain(req.Domain)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	d, err := s.domains.GetDomain(ctx, domain)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get domain: %v", err)
+	}
+	if d == nil || d.UserID != req.UserId {
+		return nil, status.Error(codes.NotFound, "domain not found")
+	}
+	if d.Verified {
+		return &pb.VerifyDomainResponse{Domain: domainToProto(d)}, nil
+	}
+
+	// A lookup error (typically NXDOMAIN while the record is not published
+	// yet) is the owner's to fix, so it is reported like a missing record.
+	records, _ := s.lookupTXT(ctx, domainVerificationPrefix+domain)
+	found := false
+	for _, record := range records {
+		if strings.TrimSpace(record) == d.VerificationToken {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"TXT record %s%s with value %s not found", domainVerificationPrefix, domain, d.VerificationToken)
+	}
+
+	verified, err := s.domains.MarkDomainVerified(ctx, domain, req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to verify domain: %v", err)
+	}
+	if verified == nil {
+		return nil, status.Error(codes.NotFound, "domain not found")
+	}
+
+	return &pb.VerifyDomainResponse{Domain: domainToProto(verified)}, nil
+}
+
+// resolveLinkDomain validates the custom domain requested for a new link and
+// returns its normalized form. An empty domain, or the default domain itself,
+// yields "" so the link is served on the default base URL. Any other domain
+// must be registered to userID and verified.
+func (s *URLService) resolveLinkDomain(ctx context.Context, domain, userID string) (string, error) {
+	if domain == "" {
+		return "", nil
+	}
+	domain, err := validation.NormalizeDomain(domain)
+	if err != nil {
+		return "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	if domain == s.defaultDomain() {
+		return "", nil
+	}
+	if userID == "" {
+		return "", status.Error(codes.InvalidArgument, "a custom domain requires an authenticated user")
+	}
+	if s.domains == nil {
+		return "", status.Error(codes.Unimplemented, "custom domains are not enabled")
+	}
+
+	d, err := s.domains.GetDomain(ctx, domain)
+	if err != nil {
+		return "", status.Errorf(codes.Internal, "failed to get domain: %v", err)
+	}
+	if d == nil || d.UserID != userID {
+		return "", status.Errorf(codes.PermissionDenied, "domain %s is not registered to you", domain)
+	}
+	if !d.Verified {
+		return "", status.Errorf(codes.FailedPrecondition, "domain %s has not been verified", domain)
+	}
+	return domain, nil
+}
+
+// defaultDomain returns the host of the default base URL, e.g. "tiny.link".
+func (s *URLService) defaultDomain() string {
+	u, err := url.Parse(s.baseURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// ownHosts returns the validation.HostFunc that recognises this shortener in
+// a destination URL: the default base URL's host and port, or a verified
+// custom domain on any port. It returns nil, skipping the check, when
+// chaining short links is allowed. A domain that cannot be looked up is not
+// taken for one of ours, so a database hiccup does not refuse every link.
+func (s *URLService) ownHosts(ctx context.Context) validation.HostFunc {
+	if s.allowChain {
+		return nil
+	}
+	var baseHost, basePort string
+	if u, err := url.Parse(s.baseURL); err == nil {
+		baseHost, basePort = validation.HostPort(u)
+	}
+	return func(host, port string) bool {
+		if host == baseHost && port == basePort {
+			return true
+		}
+		if s.domains == nil || host == baseHost {
+			return false
+		}
+		d, err := s.domains.GetDomain(ctx, host)
+		return err == nil && d != nil && d.Verified
+	}
+}
+
+// shortURL builds the public URL of a short code. Links on a custom domain
+// use that domain with the default base URL's scheme; all others use the
+// default base URL.
+func (s *URLService) shortURL(domain, shortCode string) string {
+	return shorturl.BuildDomainShortURL(s.baseURL, domain, shortCode)
+}
+
+// domainToProto maps a Domain model to its protobuf form, including the DNS
+// name the verification record must be published at.
+func domainToProto(d *models.Domain) *pb.Domain {
+	var verifiedAt int64
+	if d.VerifiedAt != nil {
+		verifiedAt = d.VerifiedAt.Unix()
+	}
+	return &pb.Domain{
+		Domain:             d.Domain,
+		Verified:           d.Verified,
+		VerificationRecord: domainVerificationPrefix + d.Domain,
+		VerificationToken:  d.VerificationToken,
+		CreatedAt:          d.CreatedAt.Unix(),
+		VerifiedAt:         verifiedAt,
+	}
+}
+
+// generateVerificationToken returns 16 random bytes hex-encoded, used as the
+// value of a domain's verification TXT record.
+func generateVerificationToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "tiny-verify=" + hex.EncodeToString(b), nil
+}
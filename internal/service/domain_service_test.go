@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeDomainStore is an in-memory domainStore keyed by domain name.
+type fakeDomainStore struct {
+	domains map[string]*models.Domain
+}
+
+func newFakeDomainStore(domains ...*models.Domain) *fakeDomainStore {
+	f := &fakeDomainStore{domains: make(map[string]*models.Domain)}
+	for _, d := range domains {
+		f.domains[d.Domain] = d
+	}
+	return f
+}
+
+func (f *fakeDomainStore) CreateDomain(ctx context.Context, d *models.Domain) (*models.Domain, error) {
+	f.domains[d.Domain] = d
+	return d, nil
+}
+
+func (f *fakeDomainStore) GetDomain(ctx context.Context, domain string) (*models.Domain, error) {
+	return f.domains[domain], nil
+}
+
+func (f *fakeDomainStore) ListDomains(ctx context.Context, userID string) ([]*models.Domain, error) {
+	var out []*models.Domain
+	for _, d := range f.domains {
+		if d.UserID == userID {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeDomainStore) MarkDomainVerified(ctx context.Context, domain, userID string) (*models.Domain, error) {
+	d := f.domains[domain]
+	if d == nil || d.UserID != userID {
+		return nil, nil
+	}
+	d.Verified = true
+	return d, nil
+}
+
+// TestCreateURL_CustomDomain verifies that a link can only be created on a
+// verified domain owned by the caller, and that its short URL and stored
+// record use that domain.
+func TestCreateURL_CustomDomain(t *testing.T) {
+	store := newFakeStore()
+	s := newAliasTestService(store, nil)
+	s.domains = newFakeDomainStore(
+		&models.Domain{Domain: "go.acme.com", UserID: "alice", Verified: true},
+		&models.Domain{Domain: "new.acme.com", UserID: "alice"},
+	)
+
+	resp, err := s.CreateURL(context.Background(), &pb.CreateURLRequest{
+		LongUrl: "https://example.com",
+		UserId:  "alice",
+		Domain:  "Go.Acme.com",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "http://go.acme.com/" + resp.ShortCode; resp.ShortUrl != want {
+		t.Errorf("expected short URL %s, got %s", want, resp.ShortUrl)
+	}
+	if got := store.urls[resp.ShortCode].Domain; got != "go.acme.com" {
+		t.Errorf("expected stored domain go.acme.com, got %q", got)
+	}
+
+	cases := []struct {
+		name   string
+		userID string
+		domain string
+		want   codes.Code
+	}{
+		{"unverified", "alice", "new.acme.com", codes.FailedPrecondition},
+		{"other user", "bob", "go.acme.com", codes.PermissionDenied},
+		{"unregistered", "alice", "other.com", codes.PermissionDenied},
+		{"anonymous", "", "go.acme.com", codes.InvalidArgument},
+		{"malformed", "alice", "https://go.acme.com", codes.InvalidArgument},
+	}
+	for _, tc := range cases {
+		_, err := s.CreateURL(context.Background(), &pb.CreateURLRequest{
+			LongUrl: "https://example.com",
+			UserId:  tc.userID,
+			Domain:  tc.domain,
+		})
+		if status.Code(err) != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+// TestCreateCustomURL_CustomDomain verifies that a custom alias on a
+// verified domain is served from that domain and stored with it.
+func TestCreateCustomURL_CustomDomain(t *testing.T) {
+	store := newFakeStore()
+	s := newAliasTestService(store, nil)
+	s.domains = newFakeDomainStore(&models.Domain{Domain: "go.acme.com", UserID: "alice", Verified: true})
+
+	resp, err := s.CreateCustomURL(context.Background(), &pb.CreateCustomURLRequest{
+		Alias:   "launch",
+		LongUrl: "https://example.com",
+		UserId:  "alice",
+		Domain:  "go.acme.com",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ShortUrl != "http://go.acme.com/launch" {
+		t.Errorf("expected short URL http://go.acme.com/launch, got %s", resp.ShortUrl)
+	}
+	if got := store.urls["launch"].Domain; got != "go.acme.com" {
+		t.Errorf("expected stored domain go.acme.com, got %q", got)
+	}
+}
+
+// TestVerifyDomain_ChecksTXTRecord verifies that a domain is only marked
+// verified when the expected TXT record is published, and that another
+// user's domain is reported as not found.
+func TestVerifyDomain_ChecksTXTRecord(t *testing.T) {
+	domains := newFakeDomainStore(&models.Domain{Domain: "go.acme.com", UserID: "alice", VerificationToken: "tok"})
+	var records []string
+	var lookedUp string
+	s := &URLService{
+		domains: domains,
+		lookupTXT: func(ctx context.Context, name string) ([]string, error) {
+			lookedUp = name
+			if records == nil {
+				return nil, errors.New("no such host")
+			}
+			return records, nil
+		},
+	}
+	req := &pb.VerifyDomainRequest{UserId: "alice", Domain: "go.acme.com"}
+
+	if _, err := s.VerifyDomain(context.Background(), req); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition without a record, got %v", err)
+	}
+	records = []string{"v=spf1 -all", "wrong"}
+	if _, err := s.VerifyDomain(context.Background(), req); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition with a wrong record, got %v", err)
+	}
+	if domains.domains["go.acme.com"].Verified {
+		t.Fatal("expected domain to stay unverified")
+	}
+
+	_, err := s.VerifyDomain(context.Background(), &pb.VerifyDomainRequest{UserId: "bob", Domain: "go.acme.com"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for another user's domain, got %v", err)
+	}
+
+	records = []string{"tok"}
+	resp, err := s.VerifyDomain(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Domain.Verified || lookedUp != "_tiny-verify.go.acme.com" {
+		t.Errorf("expected verified domain via _tiny-verify.go.acme.com, got %v (looked up %s)", resp.Domain, lookedUp)
+	}
+}
+
+// TestGetURL_ScopedByDomain verifies that a link is only resolved on the
+// domain it was created for.
+func TestGetURL_ScopedByDomain(t *testing.T) {
+	s := &URLService{store: newFakeStore(
+		&models.URL{ShortCode: "abc", LongURL: "https://example.com", Domain: "go.acme.com"},
+		&models.URL{ShortCode: "def", LongURL: "https://example.org"},
+	)}
+
+	cases := []struct {
+		shortCode string
+		domain    string
+		found     bool
+	}{
+		{"abc", "go.acme.com", true},
+		{"abc", "", false},
+		{"abc", "evil.com", false},
+		{"def", "", true},
+		{"def", "go.acme.com", false},
+	}
+	for _, tc := range cases {
+		resp, err := s.GetURL(context.Background(), &pb.GetURLRequest{ShortCode: tc.shortCode, Domain: tc.domain})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Found != tc.found {
+			t.Errorf("GetURL(%s on %q): expected found=%v, got %v", tc.shortCode, tc.domain, tc.found, resp.Found)
+		}
+	}
+}
+
+// TestGetURL_SetsShortURL verifies that GetURL returns the same short URL
+// as the list, on the default domain and on a custom one.
+func TestGetURL_SetsShortURL(t *testing.T) {
+	s := &URLService{baseURL: "https://tiny.link/", store: newFakeStore(
+		&models.URL{ShortCode: "abc", LongURL: "https://example.com", Domain: "go.acme.com"},
+		&models.URL{ShortCode: "def", LongURL: "https://example.org"},
+	)}
+
+	for _, tc := range []struct{ shortCode, domain, want string }{
+		{"abc", "go.acme.com", "https://go.acme.com/abc"},
+		{"def", "", "https://tiny.link/def"},
+	} {
+		resp, err := s.GetURL(context.Background(), &pb.GetURLRequest{ShortCode: tc.shortCode, Domain: tc.domain})
+		if err != nil || !resp.Found {
+			t.Fatalf("GetURL(%s): expected the link, got %v, %v", tc.shortCode, resp, err)
+		}
+		if resp.Url.ShortUrl != tc.want {
+			t.Errorf("GetURL(%s): expected short URL %q, got %q", tc.shortCode, tc.want, resp.Url.ShortUrl)
+		}
+	}
+}
+
+// TestCreateURL_SelfReferentialDestination verifies that destinations on
+// the default base URL or a verified custom domain are refused, including
+// as a variant, a geo rule or a batch item, and accepted once chaining is
+// allowed.
+func TestCreateURL_SelfReferentialDestination(t *testing.T) {
+	store := newFakeStore()
+	s := newAliasTestService(store, nil)
+	s.domains = newFakeDomainStore(
+		&models.Domain{Domain: "go.acme.com", UserID: "alice", Verified: true},
+		&models.Domain{Domain: "new.acme.com", UserID: "alice"},
+	)
+	ctx := context.Background()
+
+	refused := []*pb.CreateURLRequest{
+		{LongUrl: "http://tiny.test/abc"},
+		{LongUrl: "http://TINY.test:80/"},
+		{LongUrl: "https://go.acme.com/launch"},
+		{Variants: []*pb.URLVariant{{LongUrl: "https://a.example", Weight: 1}, {LongUrl: "http://tiny.test/b", Weight: 1}}},
+		{LongUrl: "https://a.example", GeoRules: []*pb.GeoRule{{CountryCode: "FR", LongUrl: "https://go.acme.com/fr"}}},
+	}
+	for _, req := range refused {
+		req.UserId = "alice"
+		_, err := s.CreateURL(ctx, req)
+		if status.Code(err) != codes.InvalidArgument || errorReason(err) != models.ErrCodeSelfReferentialURL {
+			t.Errorf("%+v: expected InvalidArgument with %s, got %v", req, models.ErrCodeSelfReferentialURL, err)
+		}
+	}
+	_, err := s.CreateCustomURL(ctx, &pb.CreateCustomURLRequest{Alias: "loop", LongUrl: "http://tiny.test/loop", UserId: "alice"})
+	if errorReason(err) != models.ErrCodeSelfReferentialURL {
+		t.Errorf("expected a custom alias pointing at itself refused, got %v", err)
+	}
+	batch, err := s.BatchCreateURLs(ctx, &pb.BatchCreateURLsRequest{UserId: "alice", Items: []*pb.BatchCreateURLItem{
+		{LongUrl: "https://example.com"},
+		{LongUrl: "http://tiny.test/abc"},
+	}})
+	if err != nil || batch.Results[0].Error != "" || batch.Results[1].Error == "" {
+		t.Errorf("expected only the chained batch item refused, got %+v, %v", batch, err)
+	}
+
+	for _, longURL := range []string{"http://tiny.test:8080/app", "https://new.acme.com/", "https://example.com"} {
+		if _, err := s.CreateURL(ctx, &pb.CreateURLRequest{LongUrl: longURL, UserId: "alice"}); err != nil {
+			t.Errorf("%s: expected a destination off our hosts accepted, got %v", longURL, err)
+		}
+	}
+	if _, err := s.CreateURL(ctx, &pb.CreateURLRequest{LongUrl: "ftp://example.com"}); errorReason(err) != models.ErrCodeInvalidURL {
+		t.Errorf("expected INVALID_URL for a non-web destination, got %v", err)
+	}
+
+	s.allowChain = true
+	if _, err := s.CreateURL(ctx, &pb.CreateURLRequest{LongUrl: "https://go.acme.com/launch", UserId: "alice"}); err != nil {
+		t.Errorf("expected a chained link accepted when allowed, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultExportPageSize = 500
+	maxExportPageSize     = 1000
+)
+
+// ExportURLs handles the gRPC ExportURLs RPC. It returns one page of the
+// user's URLs and an opaque cursor for the next one, walking the table with
+// keyset pagination so the API gateway can stream an account of any size
+// without either side holding it all in memory. Expired links are left out:
+// the cleanup job deletes them on its own schedule, so including them would
+// make the export's contents depend on when it last ran. An empty
+// next_cursor means the export is complete.
+func (s *URLService) ExportURLs(ctx context.Context, req *pb.ExportURLsRequest) (*pb.ExportURLsResponse, error) {
+	if req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultExportPageSize
+	}
+	if limit > maxExportPageSize {
+		limit = maxExportPageSize
+	}
+
+	var afterCreatedAt time.Time
+	var afterShortCode string
+	if req.Cursor != "" {
+		var err error
+		afterCreatedAt, afterShortCode, err = decodeExportCursor(req.Cursor)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid cursor")
+		}
+	}
+
+	urls, err := s.store.ListByUserIDAfter(ctx, req.UserId, afterCreatedAt, afterShortCode, limit)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to export URLs: %v", err)
+	}
+
+	now := s.now()
+	pbURLs := make([]*pb.URL, len(urls))
+	for i, url := range urls {
+		pbURLs[i] = s.urlToProto(url, now)
+	}
+
+	// A short page means the walk reached the end; otherwise hand back the
+	// position of the last row so the next call resumes right after it.
+	var nextCursor string
+	if int32(len(urls)) == limit {
+		last := urls[len(urls)-1]
+		nextCursor = encodeExportCursor(last.CreatedAt, last.ShortCode)
+	}
+
+	return &pb.ExportURLsResponse{
+		Urls:       pbURLs,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// encodeExportCursor packs a keyset position into a URL-safe token. created_at
+// is kept at microsecond precision to match PostgreSQL's timestamptz, so the
+// row-value comparison resumes exactly after the last row.
+func encodeExportCursor(createdAt time.Time, shortCode string) string {
+	raw := strconv.FormatInt(createdAt.UnixMicro(), 10) + ":" + shortCode
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeExportCursor is the inverse of encodeExportCursor.
+func decodeExportCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+
+	micros, shortCode, ok := strings.Cut(string(raw), ":")
+	if !ok || shortCode == "" {
+		return time.Time{}, "", fmt.Errorf("malformed cursor")
+	}
+
+	usec, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	return time.UnixMicro(usec), shortCode, nil
+}
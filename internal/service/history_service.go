@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetURLHistory handles the gRPC GetURLHistory RPC. The caller must own the
+// link and sees its trail since it was created. With admin set the ownership
+// check is skipped and the trail goes back through earlier, deleted links
+// that used the same short code, so an admin can still read a deleted one.
+func (s *URLService) GetURLHistory(ctx context.Context, req *pb.GetURLHistoryRequest) (*pb.GetURLHistoryResponse, error) {
+	if req.ShortCode == "" || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "short_code and user_id are required")
+	}
+
+	if !req.Admin {
+		if err := s.checkOwnership(ctx, req.ShortCode, req.UserId); err != nil {
+			return nil, err
+		}
+	}
+
+	events, err := s.store.ListEvents(ctx, req.ShortCode, req.Admin)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get URL history: %v", err)
+	}
+	if len(events) == 0 && req.Admin {
+		return nil, urlNotFoundError("short code not found")
+	}
+
+	resp := &pb.GetURLHistoryResponse{Events: make([]*pb.URLEvent, len(events))}
+	for i, ev := range events {
+		resp.Events[i] = &pb.URLEvent{
+			Id:         ev.ID,
+			ShortCode:  ev.ShortCode,
+			Action:     ev.Action,
+			ActorId:    ev.ActorID,
+			BeforeUrl:  ev.BeforeURL,
+			AfterUrl:   ev.AfterURL,
+			OccurredAt: ev.OccurredAt.Unix(),
+		}
+	}
+	return resp, nil
+}
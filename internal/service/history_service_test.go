@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/cache/cachetest"
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// actions lists the action of each event, in order.
+func actions(events []*pb.URLEvent) []string {
+	out := make([]string, len(events))
+	for i, ev := range events {
+		out[i] = ev.Action
+	}
+	return out
+}
+
+// TestGetURLHistory_CreateThenEdit verifies that creating a link and then
+// editing it yields two entries, oldest first, attributed to the owner.
+func TestGetURLHistory_CreateThenEdit(t *testing.T) {
+	s := newAliasTestService(newFakeStore(), nil)
+	ctx := context.Background()
+
+	created, err := s.CreateURL(ctx, &pb.CreateURLRequest{LongUrl: "https://example.com", UserId: "alice"})
+	if err != nil {
+		t.Fatalf("CreateURL: %v", err)
+	}
+	code := created.ShortCode
+	if _, err := s.UpdateURLTags(ctx, &pb.UpdateURLTagsRequest{ShortCode: code, UserId: "alice", Tags: []string{"launch"}}); err != nil {
+		t.Fatalf("UpdateURLTags: %v", err)
+	}
+
+	resp, err := s.GetURLHistory(ctx, &pb.GetURLHistoryRequest{ShortCode: code, UserId: "alice"})
+	if err != nil {
+		t.Fatalf("GetURLHistory: %v", err)
+	}
+	if len(resp.Events) != 2 {
+		t.Fatalf("expected 2 events, got %v", actions(resp.Events))
+	}
+	first, second := resp.Events[0], resp.Events[1]
+	if first.Action != models.URLEventCreate || first.AfterUrl != "https://example.com" || first.BeforeUrl != "" {
+		t.Errorf("expected the create first, got %+v", first)
+	}
+	if second.Action != models.URLEventUpdate || second.BeforeUrl != "https://example.com" {
+		t.Errorf("expected the edit second, got %+v", second)
+	}
+	for _, ev := range resp.Events {
+		if ev.ActorId != "alice" || ev.ShortCode != code || ev.OccurredAt == 0 {
+			t.Errorf("expected an event by alice on %s with a time, got %+v", code, ev)
+		}
+	}
+	if first.Id >= second.Id || first.OccurredAt > second.OccurredAt {
+		t.Errorf("expected the events in order, got %+v", resp.Events)
+	}
+}
+
+// TestGetURLHistory_Access verifies that only the owner reads a link's
+// trail, and that an admin also sees the deleted link that used the code
+// before.
+func TestGetURLHistory_Access(t *testing.T) {
+	store := newFakeStore()
+	s := &URLService{store: store, cache: cachetest.NewL1Only()}
+	ctx := context.Background()
+
+	_ = store.Save(ctx, &models.URL{ShortCode: "promo", LongURL: "https://old.example", UserID: "bob"})
+	if _, err := s.DeleteURL(ctx, &pb.DeleteURLRequest{ShortCode: "promo", UserId: "bob"}); err != nil {
+		t.Fatalf("DeleteURL: %v", err)
+	}
+	_ = store.Save(ctx, &models.URL{ShortCode: "promo", LongURL: "https://new.example", UserID: "alice"})
+
+	for _, tc := range []struct {
+		name   string
+		userID string
+		want   codes.Code
+	}{
+		{"other user", "bob", codes.PermissionDenied},
+		{"no user", "", codes.InvalidArgument},
+	} {
+		_, err := s.GetURLHistory(ctx, &pb.GetURLHistoryRequest{ShortCode: "promo", UserId: tc.userID})
+		if status.Code(err) != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+	if _, err := s.GetURLHistory(ctx, &pb.GetURLHistoryRequest{ShortCode: "nope", UserId: "alice"}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for a missing link, got %v", err)
+	}
+
+	owner, err := s.GetURLHistory(ctx, &pb.GetURLHistoryRequest{ShortCode: "promo", UserId: "alice"})
+	if err != nil {
+		t.Fatalf("owner: %v", err)
+	}
+	if got := actions(owner.Events); len(got) != 1 || owner.Events[0].ActorId != "alice" {
+		t.Errorf("expected the owner to see only their link's create, got %v", got)
+	}
+
+	admin, err := s.GetURLHistory(ctx, &pb.GetURLHistoryRequest{ShortCode: "promo", UserId: "admin-id", Admin: true})
+	if err != nil {
+		t.Fatalf("admin: %v", err)
+	}
+	want := []string{models.URLEventCreate, models.URLEventDelete, models.URLEventCreate}
+	if got := actions(admin.Events); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("expected the admin to see %v, got %v", want, got)
+	}
+	if _, err := s.GetURLHistory(ctx, &pb.GetURLHistoryRequest{ShortCode: "nope", UserId: "admin-id", Admin: true}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for a code that never existed, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Varun5711/shorternit/internal/qrcode"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RegenerateQR handles the gRPC RegenerateQR RPC, rendering the QR code of
+// one of the caller's links again with the size and colors requested and
+// storing it in place of the old one. The new image goes where qrCodeFor
+// would put it: under a fresh object store key, so clients and CDNs that
+// cached the old image by its address cannot serve it, or inline in the
+// database without a store. The old uploaded image is deleted once the new
+// one is recorded.
+func (s *URLService) RegenerateQR(ctx context.Context, req *pb.RegenerateQRRequest) (*pb.RegenerateQRResponse, error) {
+	if req.ShortCode == "" || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "short_code and user_id are required")
+	}
+	opts := qrcode.Options{Size: int(req.Size), Foreground: req.Foreground, Background: req.Background}
+	if err := opts.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	url, err := s.store.GetByShortCode(ctx, req.ShortCode)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get URL: %v", err)
+	}
+	if url == nil {
+		return nil, urlNotFoundError("URL not found")
+	}
+	if url.UserID != req.UserId {
+		return nil, status.Error(codes.PermissionDenied, "you do not own this short code")
+	}
+
+	png, err := qrcode.GeneratePNGWithOptions(s.shortURL(url.Domain, url.ShortCode), opts)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate QR code: %v", err)
+	}
+	qrCode := qrcode.EncodeDataURI(png)
+	if s.qrStore != nil {
+		key := url.ShortCode + "/" + uuid.NewString() + ".png"
+		if err := s.qrStore.Put(ctx, key, png); err != nil {
+			return nil, status.Errorf(codes.Unavailable, "failed to store QR code: %v", err)
+		}
+		qrCode = key
+	}
+
+	previous, err := s.store.UpdateQRCode(ctx, url.ShortCode, req.UserId, qrCode)
+	if err != nil {
+		s.discardQRCode(ctx, qrCode)
+		return nil, status.Errorf(codes.Internal, "failed to save QR code: %v", err)
+	}
+	s.discardQRCode(ctx, previous)
+
+	return &pb.RegenerateQRResponse{
+		ShortCode: url.ShortCode,
+		QrCode:    qrCode,
+		Domain:    url.Domain,
+	}, nil
+}
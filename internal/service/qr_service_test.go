@@ -0,0 +1,86 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/Varun5711/shorternit/internal/qrcode"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestRegenerateQR_ReplacesStoredImage verifies that regenerating with new
+// options stores a different image under a new key and deletes the old one.
+func TestRegenerateQR_ReplacesStoredImage(t *testing.T) {
+	ctx := context.Background()
+	qrStore, err := qrcode.NewLocalStore(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, _ := qrcode.GeneratePNG("http://tiny.test/abc")
+	if err := qrStore.Put(ctx, "abc/old.png", original); err != nil {
+		t.Fatal(err)
+	}
+	store := newFakeStore(&models.URL{ShortCode: "abc", UserID: "alice", QRCode: "abc/old.png"})
+	s := newAliasTestService(store, nil)
+	s.qrStore = qrStore
+
+	resp, err := s.RegenerateQR(ctx, &pb.RegenerateQRRequest{
+		ShortCode: "abc", UserId: "alice", Size: 512, Foreground: "#1a2b3c", Background: "#fafafa",
+	})
+	if err != nil {
+		t.Fatalf("RegenerateQR: %v", err)
+	}
+	if resp.QrCode == "abc/old.png" || store.urls["abc"].QRCode != resp.QrCode {
+		t.Fatalf("expected a new key recorded, got %q (stored %q)", resp.QrCode, store.urls["abc"].QRCode)
+	}
+	png, err := qrStore.Get(ctx, resp.QrCode)
+	if err != nil {
+		t.Fatalf("expected the new image uploaded: %v", err)
+	}
+	if bytes.Equal(png, original) {
+		t.Error("expected the new options to change the image")
+	}
+	if _, err := qrStore.Get(ctx, "abc/old.png"); !errors.Is(err, qrcode.ErrNotFound) {
+		t.Errorf("expected the old image deleted, got %v", err)
+	}
+}
+
+// TestRegenerateQR_Refusals covers the requests RegenerateQR turns down and
+// the inline image it records without an object store.
+func TestRegenerateQR_Refusals(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore(&models.URL{ShortCode: "abc", UserID: "alice"})
+	s := newAliasTestService(store, nil)
+
+	if _, err := s.RegenerateQR(ctx, &pb.RegenerateQRRequest{ShortCode: "abc", UserId: "bob"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied for another user's link, got %v", err)
+	}
+	if _, err := s.RegenerateQR(ctx, &pb.RegenerateQRRequest{ShortCode: "nope", UserId: "alice"}); errorReason(err) != models.ErrCodeURLNotFound {
+		t.Errorf("expected URL_NOT_FOUND for an unknown code, got %v", err)
+	}
+	for _, req := range []*pb.RegenerateQRRequest{
+		{ShortCode: "abc", UserId: "alice", Size: 10},
+		{ShortCode: "abc", UserId: "alice", Foreground: "red"},
+		{ShortCode: "abc", UserId: "alice", Background: "#12345g"},
+	} {
+		if _, err := s.RegenerateQR(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%+v: expected InvalidArgument, got %v", req, err)
+		}
+	}
+	if store.urls["abc"].QRCode != "" {
+		t.Fatal("expected refused requests to leave the QR code alone")
+	}
+
+	resp, err := s.RegenerateQR(ctx, &pb.RegenerateQRRequest{ShortCode: "abc", UserId: "alice", Foreground: "#003366"})
+	if err != nil {
+		t.Fatalf("RegenerateQR: %v", err)
+	}
+	if !qrcode.IsDataURI(resp.QrCode) || store.urls["abc"].QRCode != resp.QrCode {
+		t.Errorf("expected an inline image recorded, got %.40q", resp.QrCode)
+	}
+}
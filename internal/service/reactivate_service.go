@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/Varun5711/shorternit/internal/storage"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ReactivateURL handles the gRPC ReactivateURL RPC, bringing back one of the
+// caller's links after it expired or was deleted. Links are hard-deleted, by
+// the user or by the expiry cleanup, so what is left of them is the audit
+// trail: the caller must own the latest link recorded under the short code,
+// and it comes back with the last destination recorded there. An expired
+// link the cleanup has not removed yet keeps everything but its expiry; one
+// already removed keeps only its destination.
+//
+// Once a link is gone its alias is free, and someone else may have claimed
+// it. That is AlreadyExists with ALIAS_RECLAIMED and a few alternatives,
+// whether the new link is still live or has gone too. The check and the
+// restore run under the alias lock CreateCustomURL takes, and the insert
+// still refuses a code taken in between, such as a generated one, which
+// takes no lock, so the race is reported the same way.
+//
+// The new expiry is req.ExpiresAt, or the user's default expiry counted
+// from now. A reactivated link counts against the user's quota like a new
+// one.
+func (s *URLService) ReactivateURL(ctx context.Context, req *pb.ReactivateURLRequest) (*pb.ReactivateURLResponse, error) {
+	if req.ShortCode == "" || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "short_code and user_id are required")
+	}
+	now := s.now()
+	if req.ExpiresAt < 0 || (req.ExpiresAt > 0 && !time.Unix(req.ExpiresAt, 0).After(now)) {
+		return nil, status.Error(codes.InvalidArgument, "expires_at must be in the future")
+	}
+
+	defaultTTL, err := s.defaultTTLFor(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	_, expiresAt, err := s.resolveSchedule(0, req.ExpiresAt, defaultTTL, now)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	release, err := s.acquireAliasLock(ctx, req.ShortCode)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to reactivate URL: %v", err)
+	}
+	defer release()
+
+	live, err := s.store.GetByShortCode(ctx, req.ShortCode)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get URL: %v", err)
+	}
+	if live != nil {
+		if live.UserID == req.UserId {
+			return nil, status.Error(codes.FailedPrecondition, "link has not expired")
+		}
+		return nil, newAliasReclaimedError(req.ShortCode).GRPCStatus().Err()
+	}
+
+	events, err := s.store.ListEvents(ctx, req.ShortCode, true)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get URL history: %v", err)
+	}
+	longURL, err := lastLinkOf(events, req.UserId)
+	if err != nil {
+		if errors.Is(err, errAliasReclaimed) {
+			return nil, newAliasReclaimedError(req.ShortCode).GRPCStatus().Err()
+		}
+		return nil, urlNotFoundError("no expired or deleted link to reactivate")
+	}
+
+	reservation, err := s.reserveQuota(ctx, req.UserId, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	url := &models.URL{
+		ShortCode: req.ShortCode,
+		LongURL:   longURL,
+		UserID:    req.UserId,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+	if err := s.store.Restore(ctx, url); err != nil {
+		s.releaseQuota(ctx, reservation, 1)
+		if errors.Is(err, storage.ErrShortCodeTaken) {
+			return nil, newAliasReclaimedError(req.ShortCode).GRPCStatus().Err()
+		}
+		return nil, status.Errorf(codes.Internal, "failed to reactivate URL: %v", err)
+	}
+
+	if s.aliasFilter != nil {
+		s.aliasFilter.Add(url.ShortCode)
+	}
+	// Drop whatever was cached while the link was expired, so the next
+	// redirect reads the new expiry.
+	_ = s.cache.Delete(ctx, "url:"+url.ShortCode)
+	s.queuePreview(url.ShortCode, url.LongURL)
+
+	return &pb.ReactivateURLResponse{
+		ShortCode: url.ShortCode,
+		ShortUrl:  s.shortURL(url.Domain, url.ShortCode),
+		LongUrl:   url.LongURL,
+		ExpiresAt: unixOrZero(url.ExpiresAt),
+	}, nil
+}
+
+// errAliasReclaimed is returned by lastLinkOf when the user's link was
+// followed by someone else's under the same short code.
+var errAliasReclaimed = errors.New("alias reclaimed")
+
+// lastLinkOf reads a short code's full audit trail, oldest first, and
+// returns the last destination of the latest link on it, which must have
+// been created by userID. It fails with errAliasReclaimed if userID only
+// created an earlier one.
+func lastLinkOf(events []*models.URLEvent, userID string) (string, error) {
+	var owner, longURL string
+	ownedEarlier := false
+	for _, ev := range events {
+		if ev.Action == models.URLEventCreate {
+			ownedEarlier = ownedEarlier || owner == userID
+			owner, longURL = ev.ActorID, ""
+		}
+		if ev.AfterURL != "" {
+			longURL = ev.AfterURL
+		} else if ev.BeforeURL != "" {
+			longURL = ev.BeforeURL
+		}
+	}
+	switch {
+	case owner == userID && longURL != "":
+		return longURL, nil
+	case ownedEarlier:
+		return "", errAliasReclaimed
+	default:
+		return "", fmt.Errorf("no link by %s", userID)
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// expireLink moves the expiry of code into the past, as if time had run out.
+func expireLink(store *fakeStore, code string) {
+	past := time.Now().Add(-time.Minute)
+	store.urls[code].ExpiresAt = &past
+}
+
+func reactivate(s *URLService, code, userID string) (*pb.ReactivateURLResponse, error) {
+	return s.ReactivateURL(context.Background(), &pb.ReactivateURLRequest{ShortCode: code, UserId: userID})
+}
+
+// TestReactivateURL_ExpiredInPlace verifies that an expired link the cleanup
+// has not removed keeps its settings and gets a new expiry.
+func TestReactivateURL_ExpiredInPlace(t *testing.T) {
+	store := newFakeStore()
+	s := newAliasTestService(store, nil)
+	s.defaultTTL = time.Hour
+	ctx := context.Background()
+
+	if _, err := s.CreateCustomURL(ctx, &pb.CreateCustomURLRequest{Alias: "launch", LongUrl: "https://example.com", UserId: "alice", Tags: []string{"promo"}}); err != nil {
+		t.Fatalf("CreateCustomURL: %v", err)
+	}
+	expireLink(store, "launch")
+
+	resp, err := reactivate(s, "launch", "alice")
+	if err != nil {
+		t.Fatalf("ReactivateURL: %v", err)
+	}
+	if resp.LongUrl != "https://example.com" || resp.ShortUrl != "http://tiny.test/launch" {
+		t.Errorf("expected the link back, got %+v", resp)
+	}
+	if got := time.Until(time.Unix(resp.ExpiresAt, 0)); got < 59*time.Minute || got > time.Hour {
+		t.Errorf("expected the default expiry from now, got %v", got)
+	}
+	if u, _ := store.GetByShortCode(ctx, "launch"); u == nil || len(u.Tags) != 1 {
+		t.Errorf("expected the live link with its tags, got %+v", u)
+	}
+	if last := store.events[len(store.events)-1]; last.Action != models.URLEventRestore || last.ActorID != "alice" {
+		t.Errorf("expected a restore by alice, got %+v", last)
+	}
+
+	if _, err := reactivate(s, "launch", "alice"); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition for a live link, got %v", err)
+	}
+}
+
+// TestReactivateURL_AfterCleanup verifies that a link already removed by the
+// cleanup comes back with its last destination.
+func TestReactivateURL_AfterCleanup(t *testing.T) {
+	store := newFakeStore()
+	s := newAliasTestService(store, nil)
+	ctx := context.Background()
+
+	if _, err := s.CreateCustomURL(ctx, &pb.CreateCustomURLRequest{Alias: "launch", LongUrl: "https://example.com", UserId: "alice"}); err != nil {
+		t.Fatalf("CreateCustomURL: %v", err)
+	}
+	expireLink(store, "launch")
+	_, _ = store.DeleteExpiredURLs(ctx)
+
+	expiresAt := time.Now().Add(24 * time.Hour).Unix()
+	resp, err := s.ReactivateURL(ctx, &pb.ReactivateURLRequest{ShortCode: "launch", UserId: "alice", ExpiresAt: expiresAt})
+	if err != nil {
+		t.Fatalf("ReactivateURL: %v", err)
+	}
+	if resp.LongUrl != "https://example.com" || resp.ExpiresAt != expiresAt {
+		t.Errorf("expected the link back until %d, got %+v", expiresAt, resp)
+	}
+	if u, _ := store.GetByShortCode(ctx, "launch"); u == nil || u.UserID != "alice" {
+		t.Errorf("expected alice's live link, got %+v", u)
+	}
+
+	if _, err := reactivate(s, "other", "alice"); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for a code alice never held, got %v", err)
+	}
+}
+
+// TestReactivateURL_AliasReclaimed covers the race the RPC exists for:
+// alice's link expires and is cleaned up, bob claims the alias, and alice's
+// reactivation is refused with alternatives, even after bob's link is gone
+// too.
+func TestReactivateURL_AliasReclaimed(t *testing.T) {
+	store := newFakeStore()
+	s := newAliasTestService(store, nil)
+	ctx := context.Background()
+
+	if _, err := s.CreateCustomURL(ctx, &pb.CreateCustomURLRequest{Alias: "launch", LongUrl: "https://alice.example", UserId: "alice"}); err != nil {
+		t.Fatalf("CreateCustomURL: %v", err)
+	}
+	expireLink(store, "launch")
+	_, _ = store.DeleteExpiredURLs(ctx)
+	if _, err := s.CreateCustomURL(ctx, &pb.CreateCustomURLRequest{Alias: "launch", LongUrl: "https://bob.example", UserId: "bob"}); err != nil {
+		t.Fatalf("bob's CreateCustomURL: %v", err)
+	}
+
+	checkReclaimed := func(when string) {
+		t.Helper()
+		_, err := reactivate(s, "launch", "alice")
+		if status.Code(err) != codes.AlreadyExists || errorReason(err) != models.ErrCodeAliasReclaimed {
+			t.Fatalf("%s: expected AlreadyExists with %s, got %v", when, models.ErrCodeAliasReclaimed, err)
+		}
+		if u := store.urls["launch"]; u != nil && u.UserID != "bob" {
+			t.Errorf("%s: expected bob's link untouched, got %+v", when, u)
+		}
+	}
+	checkReclaimed("bob's link live")
+
+	if _, err := s.DeleteURL(ctx, &pb.DeleteURLRequest{ShortCode: "launch", UserId: "bob"}); err != nil {
+		t.Fatalf("DeleteURL: %v", err)
+	}
+	checkReclaimed("bob's link deleted")
+}
+
+// TestReactivateURL_ClaimedBeforeInsert verifies that a code taken after
+// the trail was read, here by an expired row of someone else's that left no
+// trail, is reported as reclaimed rather than overwritten.
+func TestReactivateURL_ClaimedBeforeInsert(t *testing.T) {
+	store := newFakeStore()
+	s := newAliasTestService(store, nil)
+	ctx := context.Background()
+
+	if _, err := s.CreateCustomURL(ctx, &pb.CreateCustomURLRequest{Alias: "launch", LongUrl: "https://alice.example", UserId: "alice"}); err != nil {
+		t.Fatalf("CreateCustomURL: %v", err)
+	}
+	if _, err := s.DeleteURL(ctx, &pb.DeleteURLRequest{ShortCode: "launch", UserId: "alice"}); err != nil {
+		t.Fatalf("DeleteURL: %v", err)
+	}
+	past := time.Now().Add(-time.Minute)
+	store.urls["launch"] = &models.URL{ShortCode: "launch", LongURL: "https://bob.example", UserID: "bob", ExpiresAt: &past}
+
+	_, err := reactivate(s, "launch", "alice")
+	if status.Code(err) != codes.AlreadyExists || errorReason(err) != models.ErrCodeAliasReclaimed {
+		t.Fatalf("expected AlreadyExists with %s, got %v", models.ErrCodeAliasReclaimed, err)
+	}
+	if store.urls["launch"].UserID != "bob" {
+		t.Errorf("expected bob's row untouched, got %+v", store.urls["launch"])
+	}
+}
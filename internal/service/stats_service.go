@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetURLStats handles the gRPC GetURLStats RPC. It reads the link's row
+// alone, so it stays cheap enough for the TUI to poll: the click count is
+// the row's plus those still pending in Redis (see clickdelta), with no
+// ClickHouse query. The caller must own the link. An expired link the
+// cleanup-worker has not removed yet is still reported, flagged Expired.
+func (s *URLService) GetURLStats(ctx context.Context, req *pb.GetURLStatsRequest) (*pb.GetURLStatsResponse, error) {
+	if req.ShortCode == "" || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "short_code and user_id are required")
+	}
+
+	stats, err := s.store.GetURLStats(ctx, req.ShortCode)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get URL stats: %v", err)
+	}
+	if stats == nil {
+		return nil, urlNotFoundError("short code not found")
+	}
+	if stats.UserID != req.UserId {
+		return nil, status.Error(codes.PermissionDenied, "you do not own this short code")
+	}
+
+	pending := s.pendingClicks(ctx, stats.ShortCode)[stats.ShortCode]
+	return &pb.GetURLStatsResponse{
+		ShortCode:     stats.ShortCode,
+		TotalClicks:   stats.Clicks + pending,
+		PendingClicks: pending,
+		MaxClicks:     stats.MaxClicks,
+		CreatedAt:     stats.CreatedAt.Unix(),
+		UpdatedAt:     stats.UpdatedAt.Unix(),
+		ExpiresAt:     unixOrZero(stats.ExpiresAt),
+		Expired:       stats.ExpiresAt != nil && !stats.ExpiresAt.After(s.now()),
+	}, nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clock/clocktest"
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestGetURLStats_Counters verifies that the stats add the pending clicks to
+// the stored count and report the link's timestamps.
+func TestGetURLStats_Counters(t *testing.T) {
+	created := time.Now().Add(-time.Hour).Truncate(time.Second)
+	expires := created.Add(48 * time.Hour)
+	s := &URLService{
+		store: newFakeStore(&models.URL{
+			ShortCode: "abc", UserID: "alice", Clicks: 10, MaxClicks: 100,
+			CreatedAt: created, ExpiresAt: &expires,
+		}),
+		clickDeltas: fixedDeltas{deltas: map[string]int64{"abc": 3}},
+	}
+
+	resp, err := s.GetURLStats(context.Background(), &pb.GetURLStatsRequest{ShortCode: "abc", UserId: "alice"})
+	if err != nil {
+		t.Fatalf("GetURLStats: %v", err)
+	}
+	if resp.TotalClicks != 13 || resp.PendingClicks != 3 {
+		t.Errorf("expected 13 clicks with 3 pending, got %d with %d", resp.TotalClicks, resp.PendingClicks)
+	}
+	if resp.MaxClicks != 100 || resp.CreatedAt != created.Unix() || resp.UpdatedAt != created.Unix() || resp.ExpiresAt != expires.Unix() {
+		t.Errorf("unexpected counters or timestamps: %+v", resp)
+	}
+	if resp.Expired {
+		t.Error("expected a live link not to be flagged expired")
+	}
+}
+
+// TestGetURLStats_Expired verifies that an expired link's stats are still
+// served to its owner, flagged as expired from the moment it expires.
+func TestGetURLStats_Expired(t *testing.T) {
+	clk := clocktest.NewFake(time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC))
+	expires := clk.Now().Add(time.Hour)
+	s := &URLService{
+		store: newFakeStore(&models.URL{ShortCode: "old", UserID: "alice", Clicks: 7, ExpiresAt: &expires}),
+		clock: clk,
+	}
+	ctx := context.Background()
+	req := &pb.GetURLStatsRequest{ShortCode: "old", UserId: "alice"}
+
+	clk.Advance(time.Hour - time.Second)
+	if resp, err := s.GetURLStats(ctx, req); err != nil || resp.Expired {
+		t.Fatalf("expected the link not to have expired yet, got %+v, %v", resp, err)
+	}
+
+	clk.Advance(time.Second)
+	resp, err := s.GetURLStats(ctx, req)
+	if err != nil {
+		t.Fatalf("GetURLStats: %v", err)
+	}
+	if !resp.Expired || resp.TotalClicks != 7 {
+		t.Errorf("expected 7 clicks flagged expired, got %+v", resp)
+	}
+}
+
+// TestGetURLStats_Access verifies that only the owner reads a link's stats.
+func TestGetURLStats_Access(t *testing.T) {
+	s := &URLService{store: newFakeStore(&models.URL{ShortCode: "abc", UserID: "alice"})}
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name      string
+		shortCode string
+		userID    string
+		want      codes.Code
+	}{
+		{"owner", "abc", "alice", codes.OK},
+		{"other user", "abc", "bob", codes.PermissionDenied},
+		{"no user", "abc", "", codes.InvalidArgument},
+		{"no code", "", "alice", codes.InvalidArgument},
+		{"unknown code", "nope", "alice", codes.NotFound},
+	} {
+		_, err := s.GetURLStats(ctx, &pb.GetURLStatsRequest{ShortCode: tc.shortCode, UserId: tc.userID})
+		if status.Code(err) != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Varun5711/shorternit/internal/validation"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetTags handles the gRPC GetTags RPC, returning the user's distinct tags
+// with how many links carry each, for building a tag filter.
+func (s *URLService) GetTags(ctx context.Context, req *pb.GetTagsRequest) (*pb.GetTagsResponse, error) {
+	if req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	counts, err := s.store.GetTagCounts(ctx, req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get tags: %v", err)
+	}
+
+	tags := make([]*pb.TagCount, len(counts))
+	for i, tc := range counts {
+		tags[i] = &pb.TagCount{Tag: tc.Tag, Count: tc.Count}
+	}
+
+	return &pb.GetTagsResponse{Tags: tags}, nil
+}
+
+// UpdateURLTags handles the gRPC UpdateURLTags RPC. The caller must own the
+// link; the given tags are normalized and replace the current ones.
+func (s *URLService) UpdateURLTags(ctx context.Context, req *pb.UpdateURLTagsRequest) (*pb.UpdateURLTagsResponse, error) {
+	if req.ShortCode == "" || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "short_code and user_id are required")
+	}
+
+	tags, err := validation.NormalizeTags(req.Tags)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := s.checkOwnership(ctx, req.ShortCode, req.UserId); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.UpdateTags(ctx, req.ShortCode, tags, req.UserId); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, urlNotFoundError("short code not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to update tags: %v", err)
+	}
+
+	return &pb.UpdateURLTagsResponse{Tags: tags}, nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/models"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestUpdateURLTags_Ownership verifies that only the owner can retag a link
+// and that a missing link is reported as NotFound.
+func TestUpdateURLTags_Ownership(t *testing.T) {
+	store := newFakeStore(&models.URL{ShortCode: "abc", UserID: "alice", Tags: []string{"old"}})
+	s := &URLService{store: store}
+
+	cases := []struct {
+		name      string
+		shortCode string
+		userID    string
+		want      codes.Code
+	}{
+		{"other user", "abc", "bob", codes.PermissionDenied},
+		{"missing link", "nope", "alice", codes.NotFound},
+	}
+
+	for _, tc := range cases {
+		_, err := s.UpdateURLTags(context.Background(), &pb.UpdateURLTagsRequest{
+			ShortCode: tc.shortCode,
+			UserId:    tc.userID,
+			Tags:      []string{"new"},
+		})
+		if status.Code(err) != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+
+	if got := store.urls["abc"].Tags; !reflect.DeepEqual(got, []string{"old"}) {
+		t.Errorf("expected tags untouched after rejected updates, got %v", got)
+	}
+}
+
+// TestUpdateURLTags_NormalizesAndStores checks that the owner's tags are
+// normalized before being stored and echoed back.
+func TestUpdateURLTags_NormalizesAndStores(t *testing.T) {
+	store := newFakeStore(&models.URL{ShortCode: "abc", UserID: "alice"})
+	s := &URLService{store: store}
+
+	resp, err := s.UpdateURLTags(context.Background(), &pb.UpdateURLTagsRequest{
+		ShortCode: "abc",
+		UserId:    "alice",
+		Tags:      []string{" Work ", "work", "Q3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"work", "q3"}
+	if !reflect.DeepEqual(resp.Tags, want) {
+		t.Errorf("expected response tags %v, got %v", want, resp.Tags)
+	}
+	if !reflect.DeepEqual(store.urls["abc"].Tags, want) {
+		t.Errorf("expected stored tags %v, got %v", want, store.urls["abc"].Tags)
+	}
+
+	_, err = s.UpdateURLTags(context.Background(), &pb.UpdateURLTagsRequest{
+		ShortCode: "abc",
+		UserId:    "alice",
+		Tags:      []string{"no spaces allowed"},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for an invalid tag, got %v", err)
+	}
+}
+
+// TestListURLs_FilterByTag verifies that the tag filter is normalized before
+// reaching storage, only returns the user's matching links, and rejects
+// invalid or user-less filters.
+func TestListURLs_FilterByTag(t *testing.T) {
+	store := newFakeStore(
+		&models.URL{ShortCode: "a", UserID: "alice", Tags: []string{"work"}},
+		&models.URL{ShortCode: "b", UserID: "alice", Tags: []string{"home"}},
+		&models.URL{ShortCode: "c", UserID: "bob", Tags: []string{"work"}},
+	)
+	s := &URLService{store: store}
+
+	resp, err := s.ListURLs(context.Background(), &pb.ListURLsRequest{UserId: "alice", Tag: "WORK"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.listedUserID != "alice" || store.listedTag != "work" {
+		t.Errorf("expected storage filter (alice, work), got (%s, %s)", store.listedUserID, store.listedTag)
+	}
+	if len(resp.Urls) != 1 || resp.Urls[0].ShortCode != "a" || resp.Total != 1 {
+		t.Errorf("expected only link a, got %v (total %d)", resp.Urls, resp.Total)
+	}
+
+	cases := []struct {
+		name string
+		req  *pb.ListURLsRequest
+	}{
+		{"invalid tag", &pb.ListURLsRequest{UserId: "alice", Tag: "bad tag!"}},
+		{"no user", &pb.ListURLsRequest{Tag: "work"}},
+	}
+	for _, tc := range cases {
+		if _, err := s.ListURLs(context.Background(), tc.req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: expected InvalidArgument, got %v", tc.name, err)
+		}
+	}
+}
+
+// TestGetTags_ReturnsCounts checks that per-tag counts from storage are
+// returned in order and that a user_id is required.
+func TestGetTags_ReturnsCounts(t *testing.T) {
+	store := newFakeStore()
+	store.tagCounts = []models.TagCount{{Tag: "work", Count: 3}, {Tag: "q3", Count: 1}}
+	s := &URLService{store: store}
+
+	resp, err := s.GetTags(context.Background(), &pb.GetTagsRequest{UserId: "alice"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Tags) != 2 ||
+		resp.Tags[0].Tag != "work" || resp.Tags[0].Count != 3 ||
+		resp.Tags[1].Tag != "q3" || resp.Tags[1].Count != 1 {
+		t.Errorf("unexpected tag counts: %v", resp.Tags)
+	}
+
+	if _, err := s.GetTags(context.Background(), &pb.GetTagsRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument without user_id, got %v", err)
+	}
+}
@@ -15,22 +15,34 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"strings"
 	"time"
 
+	"github.com/Varun5711/shorternit/internal/bloom"
 	"github.com/Varun5711/shorternit/internal/cache"
+	"github.com/Varun5711/shorternit/internal/clickdelta"
+	"github.com/Varun5711/shorternit/internal/clicklimit"
+	"github.com/Varun5711/shorternit/internal/clock"
 	es "github.com/Varun5711/shorternit/internal/elasticsearch"
 	"github.com/Varun5711/shorternit/internal/idgen"
 	"github.com/Varun5711/shorternit/internal/lock"
 	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/Varun5711/shorternit/internal/preview"
 	"github.com/Varun5711/shorternit/internal/qrcode"
+	"github.com/Varun5711/shorternit/internal/quota"
+	"github.com/Varun5711/shorternit/internal/sharelink"
 	"github.com/Varun5711/shorternit/internal/storage"
 	"github.com/Varun5711/shorternit/internal/validation"
 	pb "github.com/Varun5711/shorternit/proto/url"
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 // URLService implements the gRPC URLServiceServer interface, orchestrating
@@ -43,113 +55,314 @@ import (
 // basis. Reads check the cache first and fall back to the database.
 type URLService struct {
 	pb.UnimplementedURLServiceServer
-	store       storage.Storage     // Primary persistence (PostgreSQL via the Storage interface).
-	idGen       *idgen.Generator    // Snowflake-based ID generator for globally unique short codes.
-	cache       *cache.Cache        // Redis-backed cache mapping short codes to long URLs.
-	redisClient *redis.Client       // Raw Redis client used for distributed locking (custom aliases).
-	esClient    *es.Client          // Elasticsearch client for full-text search indexing; may be nil.
-	baseURL     string              // Public-facing base URL prepended to short codes (e.g., "https://tiny.io").
-	defaultTTL  time.Duration       // Default time-to-live applied when the caller does not specify an expiry.
-}
-
-// NewURLService constructs a URLService with all required dependencies. The
-// esClient parameter may be nil if Elasticsearch is not configured, in which
-// case indexing calls are silently skipped.
-func NewURLService(store storage.Storage, idGen *idgen.Generator, urlCache *cache.Cache, redisClient *redis.Client, esClient *es.Client, baseURL string, defaultTTL time.Duration) *URLService {
-	return &URLService{
+	store       storage.Storage              // Primary persistence (PostgreSQL via the Storage interface).
+	idGen       *idgen.Generator             // Snowflake-based ID generator for globally unique short codes.
+	cache       *cache.Cache                 // Redis-backed cache mapping short codes to long URLs.
+	redisClient *redis.Client                // Raw Redis client; clears click-limit counters of deleted links.
+	clickDeltas clickDeltaReader             // Clicks not flushed to the database yet; may be nil.
+	lockAlias   func(key string) lock.Locker // Locks a custom alias while its availability is checked.
+	esClient    *es.Client                   // Elasticsearch client for full-text search indexing; may be nil.
+	aliasFilter *bloom.Filter                // Bloom filter of known short codes used to skip availability checks; may be nil.
+	webhooks    *storage.WebhookStorage      // Per-link click webhook registrations; may be nil.
+	domains     domainStore                  // Users' custom domains; may be nil.
+	wildcards   wildcardStore                // Users' wildcard aliases; may be nil.
+	lookupTXT   txtLookup                    // Resolves domain verification TXT records.
+	qrStore     qrcode.Store                 // Object storage for QR code images; nil keeps them inline in the database.
+	quotas      *quota.Enforcer              // Per-user link quotas; may be nil.
+	previews    *preview.Queue               // Background fetches of destination titles; nil when previews are disabled.
+	baseURL     string                       // Public-facing base URL prepended to short codes (e.g., "https://tiny.io").
+	minCodeLen  int                          // Generated short codes are padded to at least this length.
+	defaultTTL  time.Duration                // Default time-to-live applied when the caller does not specify an expiry.
+	allowChain  bool                         // Accept destinations that are themselves short links of this service.
+	codeTries   int                          // Short codes minted per link before giving up on collisions; 0 means defaultShortCodeAttempts.
+	userTTLs    *userTTLs                    // Users' own default expiries, overriding defaultTTL; may be nil.
+	shares      *sharelink.Store             // Share tokens for links' analytics; nil without Redis.
+	clock       clock.Clock                  // Tells the time schedules and expiries are judged by; nil means the wall clock.
+}
+
+// URLServiceOptions are the optional dependencies and the settings of a
+// URLService. Every dependency may be left nil.
+type URLServiceOptions struct {
+	ESClient    *es.Client               // Nil silently skips search indexing.
+	AliasFilter *bloom.Filter            // Nil sends every custom alias down the lock-and-check path.
+	Webhooks    *storage.WebhookStorage  // Nil makes the webhook RPCs return Unimplemented.
+	Domains     *storage.DomainStorage   // Nil makes the custom domain RPCs return Unimplemented.
+	Wildcards   *storage.WildcardStorage // Nil makes the wildcard alias RPCs return Unimplemented.
+	QRStore     qrcode.Store             // Nil keeps QR codes inline in the qr_code column.
+	Quotas      *quota.Enforcer          // Nil lets users create links without limit.
+	Previews    *preview.Queue           // Nil leaves links without a title, description or image.
+	Users       *storage.UserStorage     // Nil applies DefaultTTL instead of users' own default expiries.
+	BaseURL     string                   // Public-facing base URL prepended to short codes.
+	MinCodeLen  int                      // Generated short codes are left-padded to this length.
+	DefaultTTL  time.Duration            // Expiry applied when the caller does not specify one.
+	AllowChain  bool                     // Accept destinations that are this shortener's own links.
+	CodeTries   int                      // Short codes minted for one link when they collide (see createWithRetry).
+}
+
+// NewURLService constructs a URLService around its primary store, ID
+// generator, cache and Redis client, with the optional dependencies and
+// settings in opts.
+func NewURLService(store storage.Storage, idGen *idgen.Generator, urlCache *cache.Cache, redisClient *redis.Client, opts URLServiceOptions) *URLService {
+	s := &URLService{
 		store:       store,
 		idGen:       idGen,
 		cache:       urlCache,
 		redisClient: redisClient,
-		esClient:    esClient,
-		baseURL:     baseURL,
-		defaultTTL:  defaultTTL,
+		clickDeltas: clickdelta.NewCounter(redisClient, 0),
+		lockAlias: func(key string) lock.Locker {
+			return lock.NewDistributedLock(redisClient, key, 5*time.Second)
+		},
+		esClient:    opts.ESClient,
+		aliasFilter: opts.AliasFilter,
+		webhooks:    opts.Webhooks,
+		lookupTXT:   net.DefaultResolver.LookupTXT,
+		clock:       clock.Real{},
+		qrStore:     opts.QRStore,
+		quotas:      opts.Quotas,
+		previews:    opts.Previews,
+		baseURL:     opts.BaseURL,
+		minCodeLen:  opts.MinCodeLen,
+		defaultTTL:  opts.DefaultTTL,
+		allowChain:  opts.AllowChain,
+		codeTries:   opts.CodeTries,
+	}
+	// Assign only a non-nil pointer so the interface field stays nil-comparable.
+	if opts.Domains != nil {
+		s.domains = opts.Domains
+	}
+	if opts.Wildcards != nil {
+		s.wildcards = opts.Wildcards
+	}
+	if opts.Users != nil {
+		s.userTTLs = newUserTTLs(opts.Users)
+	}
+	if redisClient != nil {
+		s.shares = sharelink.NewStore(redisClient)
 	}
+	return s
 }
 
 // CreateURL handles the gRPC CreateURL RPC. The flow is:
-//  1. Generate a globally unique Snowflake ID and base62-encode it into a short code.
-//  2. Determine the expiration time from the request or fall back to defaultTTL.
-//  3. Generate a QR code image (base64 PNG) pointing to the short URL.
-//  4. Persist the URL record to PostgreSQL via the Storage interface.
-//  5. Index the document in Elasticsearch (best-effort, errors are swallowed).
+//  1. Validate the destination (or weighted A/B variants) and any per-country
+//     geo rules.
+//  2. Determine the activation and expiration times from the request, falling
+//     back to the user's own default expiry, or defaultTTL if they have none,
+//     for the latter.
+//  3. Check the optional custom domain and the user's link quota.
+//  4. Generate a globally unique Snowflake ID, base62-encode it into a short
+//     code and, when GenerateQr is set, generate a QR code image pointing to
+//     the short URL on that domain (otherwise the image is rendered by the
+//     API gateway on its first request). Persist the URL record to
+//     PostgreSQL via the Storage interface, retrying with a fresh ID if the
+//     code is already taken (see createWithRetry), and hand the quota back
+//     if that fails.
+//  5. Index the document in Elasticsearch (best-effort, errors are swallowed)
+//     and queue the fetch of the destination's link preview.
 //  6. Warm the Redis cache so the first redirect is served without a DB hit.
 func (s *URLService) CreateURL(ctx context.Context, req *pb.CreateURLRequest) (*pb.CreateURLResponse, error) {
-	if req.LongUrl == "" {
+	longURL, variants, err := resolveVariants(req.LongUrl, req.Variants)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if longURL == "" {
 		return nil, status.Error(codes.InvalidArgument, "long_url is required")
 	}
-
-	id, err := s.idGen.NextID()
+	geoRules, err := resolveGeoRules(req.GeoRules)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	backupURL, err := resolveBackup(req.BackupUrl, longURL, len(variants) > 0 || len(geoRules) > 0)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	destinations := []string{longURL}
+	if backupURL != "" {
+		destinations = append(destinations, backupURL)
+	}
+	for _, v := range variants {
+		destinations = append(destinations, v.LongURL)
+	}
+	for _, rule := range geoRules {
+		destinations = append(destinations, rule.LongURL)
+	}
+	if err := s.validateDestinations(ctx, destinations...); err != nil {
+		return nil, err
+	}
+	if req.MaxClicks < 0 {
+		return nil, status.Error(codes.InvalidArgument, "max_clicks must not be negative")
+	}
+	tags, err := validation.NormalizeTags(req.Tags)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to generate ID: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
-	shortCode := idgen.Encode(id)
-	createdAt := time.Now()
+	createdAt := s.now()
 
-	var expiresAt *time.Time
-	if req.ExpiresAt > 0 {
-		t := time.Unix(req.ExpiresAt, 0)
-		expiresAt = &t
-	} else if s.defaultTTL > 0 {
-		t := createdAt.Add(s.defaultTTL)
-		expiresAt = &t
+	defaultTTL, err := s.defaultTTLFor(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	activeFrom, expiresAt, err := s.resolveSchedule(req.ActiveFrom, req.ExpiresAt, defaultTTL, createdAt)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	domain, err := s.resolveLinkDomain(ctx, req.Domain, req.UserId)
+	if err != nil {
+		return nil, err
 	}
 
-	shortURL := fmt.Sprintf("%s/%s", s.baseURL, shortCode)
-	qrCodeData, err := qrcode.GenerateQRCode(shortURL)
+	reservation, err := s.reserveQuota(ctx, req.UserId, 1)
 	if err != nil {
-		qrCodeData = ""
+		return nil, err
 	}
 
 	url := &models.URL{
-		ShortCode: shortCode,
-		LongURL:   req.LongUrl,
-		Clicks:    0,
-		CreatedAt: createdAt,
-		ExpiresAt: expiresAt,
-		QRCode:    qrCodeData,
-		UserID:    req.UserId,
+		LongURL:    longURL,
+		Clicks:     0,
+		MaxClicks:  req.MaxClicks,
+		CreatedAt:  createdAt,
+		ActiveFrom: activeFrom,
+		ExpiresAt:  expiresAt,
+		Tags:       tags,
+		UserID:     req.UserId,
+		Domain:     domain,
+		Variants:   variants,
+		GeoRules:   geoRules,
+		Preview:    req.Preview,
+		BackupURL:  backupURL,
+	}
+
+	if err := s.createWithRetry(ctx, url, req.GenerateQr); err != nil {
+		s.releaseQuota(ctx, reservation, 1)
+		return nil, err
 	}
+	shortCode := url.ShortCode
+	shortURL := s.shortURL(domain, shortCode)
 
-	if err := s.store.Save(ctx, url); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to save URL: %v", err)
+	if s.aliasFilter != nil {
+		s.aliasFilter.Add(shortCode)
 	}
 
 	if s.esClient != nil {
 		_ = s.esClient.IndexURL(ctx, es.URLDocument{
 			ShortCode: shortCode,
-			LongURL:   req.LongUrl,
+			LongURL:   longURL,
 			UserID:    req.UserId,
 			CreatedAt: createdAt,
 			ExpiresAt: expiresAt,
 			Clicks:    0,
 		})
 	}
+	s.queuePreview(shortCode, longURL)
 
 	cacheKey := "url:" + shortCode
-	_ = s.cache.Set(ctx, cacheKey, req.LongUrl)
-
-	var expiresAtUnix int64
-	if expiresAt != nil {
-		expiresAtUnix = expiresAt.Unix()
-	}
+	_ = s.cache.SetURL(ctx, cacheKey, cache.URLEntry{
+		LongURL:    longURL,
+		MaxClicks:  req.MaxClicks,
+		ActiveFrom: unixOrZero(activeFrom),
+		ExpiresAt:  unixOrZero(expiresAt),
+		Domain:     domain,
+		Variants:   variantsToCache(variants),
+		GeoRules:   geoRulesToCache(geoRules),
+		Preview:    req.Preview,
+		BackupURL:  backupURL,
+		UserID:     req.UserId,
+	})
 
 	return &pb.CreateURLResponse{
-		ShortCode: shortCode,
-		ShortUrl:  shortURL,
-		LongUrl:   req.LongUrl,
-		CreatedAt: createdAt.Unix(),
-		ExpiresAt: expiresAtUnix,
-		QrCode:    url.QRCode,
+		ShortCode:  shortCode,
+		ShortUrl:   shortURL,
+		LongUrl:    longURL,
+		CreatedAt:  createdAt.Unix(),
+		ExpiresAt:  unixOrZero(expiresAt),
+		QrCode:     url.QRCode,
+		MaxClicks:  req.MaxClicks,
+		ActiveFrom: unixOrZero(activeFrom),
+		Tags:       tags,
+		Variants:   variantsToProto(variants),
+		GeoRules:   geoRulesToProto(geoRules),
+		Preview:    req.Preview,
+		BackupUrl:  backupURL,
 	}, nil
 }
 
+// defaultShortCodeAttempts is how many short codes createWithRetry mints
+// for one link when the service was not configured with a number.
+const defaultShortCodeAttempts = 3
+
+// errShortCodesExhausted is returned when every short code minted for a link
+// was already taken. It is rare and transient, so the caller is told to
+// retry rather than given an internal error.
+var errShortCodesExhausted = withReason(
+	status.New(codes.ResourceExhausted, "could not allocate a unique short code, please retry"),
+	models.ErrCodeCodesExhausted,
+).Err()
+
+// createWithRetry mints a short code for url from a new Snowflake ID,
+// renders its QR code when generateQR is set, and saves it. A minted code
+// can still collide with a row already in the database, for instance after
+// a change to the code alphabet or padding, or an ID reused because of a
+// clock or worker-ID mistake. Such a collision is retried with a fresh ID,
+// up to codeTries codes in all, after which errShortCodesExhausted is
+// returned. CreateURL and BatchCreateURLs both create generated codes
+// through it; the returned error is a gRPC status.
+func (s *URLService) createWithRetry(ctx context.Context, url *models.URL, generateQR bool) error {
+	attempts := s.codeTries
+	if attempts <= 0 {
+		attempts = defaultShortCodeAttempts
+	}
+	for attempt := 1; ; attempt++ {
+		id, err := s.idGen.NextID()
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to generate ID: %v", err)
+		}
+		url.ShortCode = idgen.EncodePadded(id, s.minCodeLen)
+		url.QRCode = ""
+		if generateQR {
+			url.QRCode = s.qrCodeFor(ctx, url.ShortCode, s.shortURL(url.Domain, url.ShortCode))
+		}
+
+		err = s.store.Save(ctx, url)
+		if err == nil {
+			return nil
+		}
+		s.discardQRCode(ctx, url.QRCode)
+		if !errors.Is(err, storage.ErrShortCodeTaken) {
+			return status.Errorf(codes.Internal, "failed to save URL: %v", err)
+		}
+		if s.aliasFilter != nil {
+			s.aliasFilter.Add(url.ShortCode)
+		}
+		if attempt >= attempts {
+			return errShortCodesExhausted
+		}
+	}
+}
+
 // GetURL handles the gRPC GetURL RPC. It looks up a URL by short code in
-// PostgreSQL. If the URL exists and has not expired, it is returned wrapped
-// in a protobuf response with Found=true. A missing or expired URL returns
-// Found=false with a nil URL -- no gRPC error is raised for "not found" so
-// the caller can distinguish "missing" from "server failure".
+// PostgreSQL. If the URL exists, has not expired and its active_from time
+// has passed, it is returned wrapped in a protobuf response with Found=true.
+// A missing, expired or not-yet-active URL returns Found=false with a nil
+// URL -- no gRPC error is raised for "not found" so the caller can
+// distinguish "missing" from "server failure". Owners still see scheduled
+// links, with IsActive=false, through ListURLs. A code that exists but has
+// expired also sets Expired, so the redirect service can say so rather than
+// answer as if the link never existed. A link its owner has disabled is
+// found, flagged Disabled and not IsActive, for the redirect service to
+// answer 410 Gone and cache as such.
+//
+// Lookups are scoped by (domain, short_code): a link on a custom domain is
+// only found when req.Domain names that domain, and a default-domain link
+// only when req.Domain is empty.
+//
+// Clicks include those still pending in Redis (see clickdelta), so they
+// reflect a visit as soon as it is redirected.
+//
+// With req.UserId set the link is looked up for its owner instead, as the
+// gateway's detail endpoint does: it is found on whichever domain it is
+// served, a scheduled link comes back with IsActive=false, and a link owned
+// by anyone else is PermissionDenied.
 func (s *URLService) GetURL(ctx context.Context, req *pb.GetURLRequest) (*pb.GetURLResponse, error) {
 	if req.ShortCode == "" {
 		return nil, status.Error(codes.InvalidArgument, "short_code is required")
@@ -161,6 +374,20 @@ func (s *URLService) GetURL(ctx context.Context, req *pb.GetURLRequest) (*pb.Get
 	}
 
 	if url == nil {
+		// GetByShortCode skips expired rows; the code may still exist.
+		exists, err := s.store.AliasExists(ctx, req.ShortCode)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get URL: %v", err)
+		}
+		return &pb.GetURLResponse{Found: false, Expired: exists}, nil
+	}
+
+	now := s.now()
+	if req.UserId != "" {
+		if url.UserID != req.UserId {
+			return nil, status.Error(codes.PermissionDenied, "you do not own this short code")
+		}
+	} else if url.Domain != req.Domain || !isActivated(url.ActiveFrom, now) {
 		return &pb.GetURLResponse{
 			Found: false,
 			Url:   nil,
@@ -168,12 +395,29 @@ func (s *URLService) GetURL(ctx context.Context, req *pb.GetURLRequest) (*pb.Get
 	}
 
 	pbURL := &pb.URL{
-		ShortCode: url.ShortCode,
-		LongUrl:   url.LongURL,
-		Clicks:    url.Clicks,
-		CreatedAt: url.CreatedAt.Unix(),
-		UpdatedAt: url.CreatedAt.Unix(),
-		IsActive:  true,
+		ShortCode:  url.ShortCode,
+		ShortUrl:   s.shortURL(url.Domain, url.ShortCode),
+		LongUrl:    url.LongURL,
+		Clicks:     url.Clicks + s.pendingClicks(ctx, url.ShortCode)[url.ShortCode],
+		MaxClicks:  url.MaxClicks,
+		CreatedAt:  url.CreatedAt.Unix(),
+		UpdatedAt:  url.CreatedAt.Unix(),
+		ExpiresAt:  unixOrZero(url.ExpiresAt),
+		IsActive:   !url.Disabled && isActivated(url.ActiveFrom, now),
+		ActiveFrom: unixOrZero(url.ActiveFrom),
+		Tags:       url.Tags,
+		Domain:     url.Domain,
+		Variants:   variantsToProto(url.Variants),
+		GeoRules:   geoRulesToProto(url.GeoRules),
+		QrCode:     url.QRCode,
+		Preview:    url.Preview,
+		BackupUrl:  url.BackupURL,
+		UserId:     url.UserID,
+		Disabled:   url.Disabled,
+
+		Title:       url.Title,
+		Description: url.Description,
+		ImageUrl:    url.ImageURL,
 	}
 
 	return &pb.GetURLResponse{
@@ -183,9 +427,13 @@ func (s *URLService) GetURL(ctx context.Context, req *pb.GetURLRequest) (*pb.Get
 }
 
 // ListURLs handles the gRPC ListURLs RPC with server-side pagination. When
-// UserId is set on the request, only URLs belonging to that user are returned;
-// otherwise all URLs are listed. Limit is clamped to [1, 1000] and offset
-// defaults to 0 to prevent unbounded queries.
+// UserId is set on the request, only URLs belonging to that user are
+// returned, optionally narrowed to those carrying Tag; otherwise all URLs are
+// listed. The response's click, active and expired totals summarize every
+// matching URL, not only the page. Click counts include the clicks the
+// analytics-worker has not flushed yet; for the totals, those of the URLs on
+// the page. Limit is clamped to [1, 1000] and offset defaults to 0 to prevent
+// unbounded queries.
 func (s *URLService) ListURLs(ctx context.Context, req *pb.ListURLsRequest) (*pb.ListURLsResponse, error) {
 	limit := req.Limit
 	if limit <= 0 {
@@ -201,74 +449,124 @@ func (s *URLService) ListURLs(ctx context.Context, req *pb.ListURLsRequest) (*pb
 	}
 
 	var urls []*models.URL
-	var total int32
+	var summary models.URLListSummary
 	var err error
 
-	if req.UserId != "" {
-		urls, total, err = s.store.ListByUserIDPaginated(ctx, req.UserId, limit, offset)
-	} else {
-		urls, total, err = s.store.ListPaginated(ctx, limit, offset)
+	switch {
+	case req.Tag != "":
+		if req.UserId == "" {
+			return nil, status.Error(codes.InvalidArgument, "tag filter requires user_id")
+		}
+		tag, tagErr := validation.NormalizeTag(req.Tag)
+		if tagErr != nil {
+			return nil, status.Error(codes.InvalidArgument, tagErr.Error())
+		}
+		urls, summary, err = s.store.ListByUserIDAndTag(ctx, req.UserId, tag, limit, offset)
+	case req.UserId != "":
+		urls, summary, err = s.store.ListByUserIDPaginated(ctx, req.UserId, limit, offset)
+	default:
+		urls, summary, err = s.store.ListPaginated(ctx, limit, offset)
 	}
 
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to list URLs: %v", err)
 	}
 
-	pbURLs := make([]*pb.URL, len(urls))
+	shortCodes := make([]string, len(urls))
 	for i, url := range urls {
-		var expiresAtUnix int64
-		if url.ExpiresAt != nil {
-			expiresAtUnix = url.ExpiresAt.Unix()
-		}
+		shortCodes[i] = url.ShortCode
+	}
+	pending := s.pendingClicks(ctx, shortCodes...)
+	for _, n := range pending {
+		summary.TotalClicks += n
+	}
 
-		pbURLs[i] = &pb.URL{
-			ShortCode: url.ShortCode,
-			ShortUrl:  fmt.Sprintf("%s/%s", s.baseURL, url.ShortCode),
-			LongUrl:   url.LongURL,
-			Clicks:    url.Clicks,
-			CreatedAt: url.CreatedAt.Unix(),
-			UpdatedAt: url.CreatedAt.Unix(),
-			IsActive:  true,
-			ExpiresAt: expiresAtUnix,
-		}
+	now := s.now()
+	pbURLs := make([]*pb.URL, len(urls))
+	for i, url := range urls {
+		pbURLs[i] = s.urlToProto(url, now)
+		pbURLs[i].Clicks += pending[url.ShortCode]
 	}
 
-	hasMore := (offset + limit) < total
+	hasMore := (offset + limit) < summary.Total
 
 	return &pb.ListURLsResponse{
-		Urls:    pbURLs,
-		Total:   total,
-		HasMore: hasMore,
+		Urls:         pbURLs,
+		Total:        summary.Total,
+		HasMore:      hasMore,
+		TotalClicks:  summary.TotalClicks,
+		ActiveCount:  summary.ActiveCount,
+		ExpiredCount: summary.ExpiredCount,
 	}, nil
 }
 
 // DeleteURL handles the gRPC DeleteURL RPC. It removes the URL from
-// PostgreSQL, Elasticsearch, and the Redis cache in that order. If the short
-// code does not exist in PostgreSQL, Success=false is returned without a gRPC
-// error. Secondary store deletions are best-effort -- their errors are
-// intentionally ignored so a cache/search outage does not block the user.
+// PostgreSQL, Elasticsearch, and the Redis cache in that order, then drops
+// the link's click-limit counter so a reused alias starts from zero. If the
+// short code does not exist in PostgreSQL, Success=false is returned without
+// a gRPC error. The user_id is recorded as the actor in the audit trail; a
+// link owned by someone else is PermissionDenied and nothing is removed,
+// unless admin is set to delete any user's link. Secondary store deletions
+// are best-effort -- their errors are intentionally ignored so a
+// cache/search outage does not block the user.
 func (s *URLService) DeleteURL(ctx context.Context, req *pb.DeleteURLRequest) (*pb.DeleteURLResponse, error) {
-	if req.ShortCode == "" {
-		return nil, status.Error(codes.InvalidArgument, "short_code is required")
+	if req.ShortCode == "" || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "short_code and user_id are required")
+	}
+
+	if !req.Admin {
+		if err := s.checkOwnership(ctx, req.ShortCode, req.UserId); err != nil {
+			if status.Code(err) == codes.NotFound {
+				return &pb.DeleteURLResponse{Success: false}, nil
+			}
+			return nil, err
+		}
+	}
+
+	// Note the QR code's key before the row holding it goes away.
+	var qrCodeData string
+	if s.qrStore != nil {
+		if url, err := s.store.GetByShortCode(ctx, req.ShortCode); err == nil && url != nil {
+			qrCodeData = url.QRCode
+		}
 	}
 
-	if err := s.store.Delete(ctx, req.ShortCode); err != nil {
+	if err := s.store.Delete(ctx, req.ShortCode, req.UserId); err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			return &pb.DeleteURLResponse{Success: false}, nil
 		}
 		return nil, status.Errorf(codes.Internal, "failed to delete URL: %v", err)
 	}
 
+	s.afterDelete(ctx, req.ShortCode, qrCodeData)
+
+	return &pb.DeleteURLResponse{
+		Success: true,
+	}, nil
+}
+
+// afterDelete removes what is left of a deleted link outside the database:
+// its stored QR code, search document, cache entry, click-limit and
+// click-delta counters, and analytics share tokens, so a new link reusing
+// the alias starts from zero and cannot be read with the old link's tokens.
+// All of it is best-effort.
+func (s *URLService) afterDelete(ctx context.Context, shortCode, qrCodeData string) {
+	s.discardQRCode(ctx, qrCodeData)
+
 	if s.esClient != nil {
-		_ = s.esClient.DeleteURL(ctx, req.ShortCode)
+		_ = s.esClient.DeleteURL(ctx, shortCode)
 	}
 
-	cacheKey := "url:" + req.ShortCode
+	cacheKey := "url:" + shortCode
 	_ = s.cache.Delete(ctx, cacheKey)
 
-	return &pb.DeleteURLResponse{
-		Success: true,
-	}, nil
+	if s.redisClient != nil {
+		_ = s.redisClient.Del(ctx, clicklimit.Key(shortCode), clickdelta.Key(shortCode)).Err()
+	}
+
+	if s.shares != nil {
+		_, _ = s.shares.RevokeAll(ctx, shortCode)
+	}
 }
 
 // IncrementClicks handles the gRPC IncrementClicks RPC. It atomically
@@ -290,7 +588,7 @@ func (s *URLService) IncrementClicks(ctx context.Context, req *pb.IncrementClick
 	}
 
 	if url == nil {
-		return nil, status.Error(codes.NotFound, "URL not found")
+		return nil, urlNotFoundError("URL not found")
 	}
 
 	return &pb.IncrementClicksResponse{
@@ -304,11 +602,16 @@ func (s *URLService) IncrementClicks(ctx context.Context, req *pb.IncrementClick
 //
 //  1. A Redis-based distributed lock prevents concurrent requests for the
 //     same alias from racing.
-//  2. A strongly-consistent read against the PostgreSQL primary (not a read
-//     replica) confirms the alias is truly available before INSERT.
+//  2. Storage.AliasExistsPrimary, a strongly-consistent read that never
+//     goes to a replica, confirms the alias is truly available before
+//     the INSERT.
+//
+// Both layers are skipped when the alias Bloom filter proves the alias has
+// never been seen; the unique constraint on short_code is the final arbiter.
 //
 // If the alias is already taken, the response includes suggested alternatives
-// generated by the validation package.
+// generated by the validation package. The link counts against the user's
+// quota as in CreateURL; a failed creation hands it back.
 func (s *URLService) CreateCustomURL(ctx context.Context, req *pb.CreateCustomURLRequest) (*pb.CreateCustomURLResponse, error) {
 	if req.Alias == "" {
 		return nil, status.Error(codes.InvalidArgument, "alias is required")
@@ -317,61 +620,165 @@ func (s *URLService) CreateCustomURL(ctx context.Context, req *pb.CreateCustomUR
 	if req.LongUrl == "" {
 		return nil, status.Error(codes.InvalidArgument, "long_url is required")
 	}
+	backupURL, err := resolveBackup(req.BackupUrl, req.LongUrl, false)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	destinations := []string{req.LongUrl}
+	if backupURL != "" {
+		destinations = append(destinations, backupURL)
+	}
+	if err := s.validateDestinations(ctx, destinations...); err != nil {
+		return nil, err
+	}
+	if req.MaxClicks < 0 {
+		return nil, status.Error(codes.InvalidArgument, "max_clicks must not be negative")
+	}
+	tags, err := validation.NormalizeTags(req.Tags)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 
-	var expiresAt *time.Time
-	if req.ExpiresAt > 0 {
-		t := time.Unix(req.ExpiresAt, 0)
-		expiresAt = &t
-	} else if s.defaultTTL > 0 {
-		t := time.Now().Add(s.defaultTTL)
-		expiresAt = &t
+	defaultTTL, err := s.defaultTTLFor(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	activeFrom, expiresAt, err := s.resolveSchedule(req.ActiveFrom, req.ExpiresAt, defaultTTL, s.now())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	domain, err := s.resolveLinkDomain(ctx, req.Domain, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	reservation, err := s.reserveQuota(ctx, req.UserId, 1)
+	if err != nil {
+		return nil, err
 	}
 
-	result, err := s.createCustomURLInternal(ctx, req.Alias, req.LongUrl, expiresAt, req.UserId)
+	result, err := s.createCustomURLInternal(ctx, &models.URL{
+		ShortCode:  req.Alias,
+		LongURL:    req.LongUrl,
+		MaxClicks:  req.MaxClicks,
+		ActiveFrom: activeFrom,
+		ExpiresAt:  expiresAt,
+		Tags:       tags,
+		UserID:     req.UserId,
+		Domain:     domain,
+		Preview:    req.Preview,
+		BackupURL:  backupURL,
+	}, req.GenerateQr)
 	if err != nil {
+		s.releaseQuota(ctx, reservation, 1)
 		if strings.Contains(err.Error(), "invalid alias") {
 			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
-		if strings.Contains(err.Error(), "already taken") {
-			return nil, status.Error(codes.AlreadyExists, err.Error())
+		var taken *aliasTakenError
+		if errors.As(err, &taken) {
+			return nil, taken.GRPCStatus().Err()
 		}
 		return nil, status.Errorf(codes.Internal, "failed to create custom URL: %v", err)
 	}
 
-	var expiresAtUnix int64
-	if expiresAt != nil {
-		expiresAtUnix = expiresAt.Unix()
-	}
-
-	qrCode, _ := s.getQRCode(ctx, result.ShortCode)
-
 	return &pb.CreateCustomURLResponse{
-		ShortCode: result.ShortCode,
-		ShortUrl:  result.ShortURL,
-		LongUrl:   result.LongURL,
-		CreatedAt: result.CreatedAt.Unix(),
-		ExpiresAt: expiresAtUnix,
-		QrCode:    qrCode,
+		ShortCode:  result.ShortCode,
+		ShortUrl:   result.ShortURL,
+		LongUrl:    result.LongURL,
+		CreatedAt:  result.CreatedAt.Unix(),
+		ExpiresAt:  unixOrZero(expiresAt),
+		QrCode:     result.QRCode,
+		MaxClicks:  req.MaxClicks,
+		ActiveFrom: unixOrZero(activeFrom),
+		Tags:       tags,
+		Preview:    req.Preview,
+		BackupUrl:  backupURL,
 	}, nil
 }
 
-// createCustomURLInternal contains the core logic for custom alias creation,
-// separated from the gRPC handler so error classification (InvalidArgument vs.
-// AlreadyExists vs. Internal) can be handled at the handler level. The method:
+// createCustomURLInternal contains the core logic for creating url under
+// the custom alias in its ShortCode, rendering its QR code when generateQR
+// is set. It is separated from the gRPC handler so error classification
+// (InvalidArgument vs. AlreadyExists vs. Internal) can be handled at the
+// handler level. The method:
 //
 //  1. Validates the alias format (length, allowed characters).
-//  2. Acquires a Redis distributed lock keyed to the alias with a 5-second TTL.
-//  3. Asserts that the storage layer is PostgresStorage (custom aliases need
-//     direct access to AliasExistsPrimary for strong consistency).
-//  4. Checks alias availability on the primary database.
-//  5. Persists the URL and warms the cache.
-func (s *URLService) createCustomURLInternal(ctx context.Context, alias, longURL string, expiresAt *time.Time, userID string) (*CreateURLResult, error) {
+//  2. Consults the alias Bloom filter. When it reports the alias as
+//     definitely absent, the lock and primary lookup are skipped and the
+//     INSERT goes straight to the database, whose unique constraint still
+//     rejects a concurrent or cross-replica duplicate.
+//  3. Otherwise takes the alias lock (lockAlias; in production a Redis
+//     distributed lock with a 5-second TTL) and checks availability with
+//     AliasExistsPrimary, so a Bloom false positive costs exactly what
+//     every request cost before.
+//  4. Persists the URL, records it in the filter, queues its link preview
+//     and warms the cache.
+func (s *URLService) createCustomURLInternal(ctx context.Context, url *models.URL, generateQR bool) (*CreateURLResult, error) {
+	alias := url.ShortCode
 	if err := validation.ValidateAlias(alias); err != nil {
 		return nil, fmt.Errorf("invalid alias: %w", err)
 	}
 
-	lockKey := fmt.Sprintf("lock:alias:%s", alias)
-	distributedLock := lock.NewDistributedLock(s.redisClient, lockKey, 5*time.Second)
+	if s.aliasFilter == nil || s.aliasFilter.MightContain(alias) {
+		release, err := s.acquireAliasLock(ctx, alias)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+
+		exists, err := s.store.AliasExistsPrimary(ctx, alias)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check availability: %w", err)
+		}
+
+		if exists {
+			return nil, newAliasTakenError(alias)
+		}
+	}
+
+	shortURL := s.shortURL(url.Domain, alias)
+	url.QRCode = ""
+	if generateQR {
+		url.QRCode = s.qrCodeFor(ctx, alias, shortURL)
+	}
+
+	err := s.store.CreateCustomURL(ctx, url)
+	if err != nil {
+		s.discardQRCode(ctx, url.QRCode)
+		if errors.Is(err, storage.ErrShortCodeTaken) {
+			// The filter missed a code created by another replica (or a
+			// concurrent request won the race); remember it for next time.
+			if s.aliasFilter != nil {
+				s.aliasFilter.Add(alias)
+			}
+			return nil, newAliasTakenError(alias)
+		}
+		return nil, fmt.Errorf("failed to create custom URL: %w", err)
+	}
+
+	if s.aliasFilter != nil {
+		s.aliasFilter.Add(alias)
+	}
+	s.queuePreview(alias, url.LongURL)
+
+	cacheKey := "url:" + alias
+	_ = s.cache.SetURL(ctx, cacheKey, cache.EntryFromURL(url))
+
+	return &CreateURLResult{
+		ShortCode: alias,
+		ShortURL:  shortURL,
+		LongURL:   url.LongURL,
+		CreatedAt: s.now(),
+		QRCode:    url.QRCode,
+	}, nil
+}
+
+// acquireAliasLock takes the lock on claiming alias and returns the func
+// that releases it. Failing to get it is an error, as is finding it held
+// by another request.
+func (s *URLService) acquireAliasLock(ctx context.Context, alias string) (func(), error) {
+	distributedLock := s.lockAlias(fmt.Sprintf("lock:alias:%s", alias))
 
 	acquired, err := distributedLock.Acquire(ctx)
 	if err != nil {
@@ -380,43 +787,80 @@ func (s *URLService) createCustomURLInternal(ctx context.Context, alias, longURL
 	if !acquired {
 		return nil, fmt.Errorf("alias is being claimed by another request, please try again")
 	}
-	defer func() { _ = distributedLock.Release(ctx) }()
+	return func() {
+		// Release on a context detached from the RPC so the lock is
+		// still freed when the server is force-stopped during shutdown
+		// and the request context has already been cancelled.
+		releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*time.Second)
+		defer cancel()
+		_ = distributedLock.Release(releaseCtx)
+	}, nil
+}
 
-	postgresStore, ok := s.store.(*storage.PostgresStorage)
-	if !ok {
-		return nil, fmt.Errorf("storage layer doesn't support custom aliases")
-	}
+// aliasTakenError is the user-facing "already taken" error, including a few
+// generated alternatives. CreateCustomURL turns it into codes.AlreadyExists
+// with the alternatives attached as an ErrorInfo detail, so the gateway can
+// return them as a list rather than parse the message. reclaimed marks the
+// ReactivateURL variant: the caller's own link is gone because someone else
+// claimed its alias after it expired or was deleted.
+type aliasTakenError struct {
+	alias       string
+	suggestions []string
+	reclaimed   bool
+}
 
-	exists, err := postgresStore.AliasExistsPrimary(ctx, alias)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check availability: %w", err)
-	}
+func newAliasTakenError(alias string) *aliasTakenError {
+	return &aliasTakenError{alias: alias, suggestions: validation.SuggestAlternatives(alias, 3)}
+}
 
-	if exists {
-		suggestions := validation.SuggestAlternatives(alias, 3)
-		return nil, fmt.Errorf("alias '%s' is already taken. Try: %v", alias, suggestions)
+func newAliasReclaimedError(alias string) *aliasTakenError {
+	e := newAliasTakenError(alias)
+	e.reclaimed = true
+	return e
+}
+
+func (e *aliasTakenError) Error() string {
+	if e.reclaimed {
+		return fmt.Sprintf("alias '%s' has been claimed by another user since your link ended. Try: %v", e.alias, e.suggestions)
 	}
+	return fmt.Sprintf("alias '%s' is already taken. Try: %v", e.alias, e.suggestions)
+}
 
-	shortURL := fmt.Sprintf("%s/%s", s.baseURL, alias)
-	qrCodeData, err := qrcode.GenerateQRCode(shortURL)
+// GRPCStatus lets status.Convert and status.FromError see e as
+// AlreadyExists carrying its suggestions.
+func (e *aliasTakenError) GRPCStatus() *status.Status {
+	reason := models.ErrCodeAliasTaken
+	if e.reclaimed {
+		reason = models.ErrCodeAliasReclaimed
+	}
+	st := status.New(codes.AlreadyExists, e.Error())
+	withInfo, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   "url-service",
+		Metadata: map[string]string{"suggestions": strings.Join(e.suggestions, ",")},
+	})
 	if err != nil {
-		qrCodeData = ""
+		return st
 	}
+	return withInfo
+}
 
-	err = postgresStore.CreateCustomURL(ctx, alias, longURL, expiresAt, qrCodeData, userID)
+// withReason attaches an ErrorInfo detail to st naming reason, one of the
+// models.ErrCode* values, which the gateway reports as the error code in
+// place of the generic one for st's gRPC code. st is returned unchanged if
+// the detail cannot be added.
+func withReason(st *status.Status, reason string) *status.Status {
+	withInfo, err := st.WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: "url-service"})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create custom URL: %w", err)
+		return st
 	}
+	return withInfo
+}
 
-	cacheKey := "url:" + alias
-	_ = s.cache.Set(ctx, cacheKey, longURL)
-
-	return &CreateURLResult{
-		ShortCode: alias,
-		ShortURL:  shortURL,
-		LongURL:   longURL,
-		CreatedAt: time.Now(),
-	}, nil
+// urlNotFoundError is the NotFound returned for a short code that does not
+// exist, reported by the gateway as URL_NOT_FOUND.
+func urlNotFoundError(message string) error {
+	return withReason(status.New(codes.NotFound, message), models.ErrCodeURLNotFound).Err()
 }
 
 // CreateURLResult is an internal value object returned by
@@ -427,15 +871,348 @@ type CreateURLResult struct {
 	ShortURL  string
 	LongURL   string
 	CreatedAt time.Time
+	QRCode    string
+}
+
+// urlToProto maps a stored URL to its list representation, including the
+// fully qualified short URL and whether it is active at now.
+func (s *URLService) urlToProto(url *models.URL, now time.Time) *pb.URL {
+	return &pb.URL{
+		ShortCode:  url.ShortCode,
+		ShortUrl:   s.shortURL(url.Domain, url.ShortCode),
+		LongUrl:    url.LongURL,
+		Clicks:     url.Clicks,
+		MaxClicks:  url.MaxClicks,
+		CreatedAt:  url.CreatedAt.Unix(),
+		UpdatedAt:  url.CreatedAt.Unix(),
+		IsActive:   !url.Disabled && isActivated(url.ActiveFrom, now),
+		ExpiresAt:  unixOrZero(url.ExpiresAt),
+		ActiveFrom: unixOrZero(url.ActiveFrom),
+		Tags:       url.Tags,
+		Domain:     url.Domain,
+		Preview:    url.Preview,
+		BackupUrl:  url.BackupURL,
+		Disabled:   url.Disabled,
+
+		Title:       url.Title,
+		Description: url.Description,
+		ImageUrl:    url.ImageURL,
+	}
+}
+
+// resolveSchedule turns the request's active_from and expires_at (Unix
+// seconds, 0 = unset) into the window during which the link redirects. When no
+// expiry is given, defaultTTL (see defaultTTLFor) is counted from the
+// activation time rather than from now, so a link scheduled further out than
+// the TTL is not born expired; a defaultTTL of 0 leaves the link without an
+// expiry. An activation at or after the expiry is rejected.
+func (s *URLService) resolveSchedule(activeFromUnix, expiresAtUnix int64, defaultTTL time.Duration, now time.Time) (activeFrom, expiresAt *time.Time, err error) {
+	if activeFromUnix < 0 {
+		return nil, nil, fmt.Errorf("active_from must not be negative")
+	}
+
+	start := now
+	if activeFromUnix > 0 {
+		t := time.Unix(activeFromUnix, 0)
+		activeFrom = &t
+		if t.After(start) {
+			start = t
+		}
+	}
+
+	if expiresAtUnix > 0 {
+		t := time.Unix(expiresAtUnix, 0)
+		expiresAt = &t
+	} else if defaultTTL > 0 {
+		t := start.Add(defaultTTL)
+		expiresAt = &t
+	}
+
+	if activeFrom != nil && expiresAt != nil && !activeFrom.Before(*expiresAt) {
+		return nil, nil, fmt.Errorf("active_from must be before expires_at")
+	}
+	return activeFrom, expiresAt, nil
+}
+
+// Limits on A/B split links. They keep the per-redirect variant pick cheap
+// and the weights small enough that their sum cannot overflow.
+const (
+	maxVariantsPerURL = 10
+	maxVariantWeight  = 10000
+)
+
+// resolveVariants validates the weighted destinations of an A/B split link
+// and returns the link's primary long URL with the variants to store. With no
+// variants the request's long URL is used unchanged. A single variant is
+// just a destination, so it becomes the long URL and no split is stored.
+// Otherwise the primary long URL is the first variant's; a long URL given
+// alongside variants must match it.
+func resolveVariants(longURL string, variants []*pb.URLVariant) (string, []models.URLVariant, error) {
+	if len(variants) == 0 {
+		return longURL, nil, nil
+	}
+	if len(variants) > maxVariantsPerURL {
+		return "", nil, fmt.Errorf("at most %d variants are allowed per link", maxVariantsPerURL)
+	}
+
+	resolved := make([]models.URLVariant, len(variants))
+	for i, v := range variants {
+		if v.GetLongUrl() == "" {
+			return "", nil, fmt.Errorf("variant %d: long_url is required", i+1)
+		}
+		if v.GetWeight() < 1 || v.GetWeight() > maxVariantWeight {
+			return "", nil, fmt.Errorf("variant %d: weight must be between 1 and %d", i+1, maxVariantWeight)
+		}
+		resolved[i] = models.URLVariant{LongURL: v.GetLongUrl(), Weight: v.GetWeight()}
+	}
+
+	if longURL != "" && longURL != resolved[0].LongURL {
+		return "", nil, fmt.Errorf("long_url must be omitted or match the first variant")
+	}
+	if len(resolved) == 1 {
+		return resolved[0].LongURL, nil, nil
+	}
+	return resolved[0].LongURL, resolved, nil
+}
+
+// variantsToProto maps stored A/B variants to their protobuf form.
+func variantsToProto(variants []models.URLVariant) []*pb.URLVariant {
+	if len(variants) == 0 {
+		return nil
+	}
+	out := make([]*pb.URLVariant, len(variants))
+	for i, v := range variants {
+		out[i] = &pb.URLVariant{LongUrl: v.LongURL, Weight: v.Weight}
+	}
+	return out
+}
+
+// variantsToCache maps stored A/B variants to the redirect cache entry form.
+func variantsToCache(variants []models.URLVariant) []cache.URLVariant {
+	if len(variants) == 0 {
+		return nil
+	}
+	out := make([]cache.URLVariant, len(variants))
+	for i, v := range variants {
+		out[i] = cache.URLVariant{LongURL: v.LongURL, Weight: v.Weight}
+	}
+	return out
+}
+
+// maxGeoRulesPerURL caps the per-country destinations of one link.
+const maxGeoRulesPerURL = 50
+
+// resolveGeoRules validates the per-country destinations of a geo-targeted
+// link. Country codes are normalized to uppercase ISO 3166-1 alpha-2 codes
+// and may appear only once. "XX" is rejected because the GeoIP enricher
+// reports it for visitors it cannot place.
+func resolveGeoRules(rules []*pb.GeoRule) ([]models.GeoRule, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	if len(rules) > maxGeoRulesPerURL {
+		return nil, fmt.Errorf("at most %d geo rules are allowed per link", maxGeoRulesPerURL)
+	}
+
+	resolved := make([]models.GeoRule, len(rules))
+	seen := make(map[string]bool, len(rules))
+	for i, rule := range rules {
+		code := strings.ToUpper(strings.TrimSpace(rule.GetCountryCode()))
+		if !isCountryCode(code) || code == "XX" {
+			return nil, fmt.Errorf("geo rule %d: invalid country code %q", i+1, rule.GetCountryCode())
+		}
+		if seen[code] {
+			return nil, fmt.Errorf("geo rule %d: duplicate country code %s", i+1, code)
+		}
+		if rule.GetLongUrl() == "" {
+			return nil, fmt.Errorf("geo rule %d: long_url is required", i+1)
+		}
+		seen[code] = true
+		resolved[i] = models.GeoRule{CountryCode: code, LongURL: rule.GetLongUrl()}
+	}
+	return resolved, nil
+}
+
+// resolveBackup validates the backup destination of a link, "" for none.
+// The health checks only watch the long URL, so a link whose destination
+// varies per visit (multi is set for A/B splits and geo rules) cannot have
+// one, and a backup that is the long URL itself could never take over.
+func resolveBackup(backupURL, longURL string, multi bool) (string, error) {
+	if backupURL == "" {
+		return "", nil
+	}
+	if multi {
+		return "", errors.New("backup_url cannot be combined with variants or geo_rules")
+	}
+	if backupURL == longURL {
+		return "", errors.New("backup_url must differ from long_url")
+	}
+	return backupURL, nil
+}
+
+// isCountryCode reports whether code is two uppercase ASCII letters.
+func isCountryCode(code string) bool {
+	return len(code) == 2 &&
+		code[0] >= 'A' && code[0] <= 'Z' &&
+		code[1] >= 'A' && code[1] <= 'Z'
+}
+
+// geoRulesToProto maps stored geo rules to their protobuf form.
+func geoRulesToProto(rules []models.GeoRule) []*pb.GeoRule {
+	if len(rules) == 0 {
+		return nil
+	}
+	out := make([]*pb.GeoRule, len(rules))
+	for i, rule := range rules {
+		out[i] = &pb.GeoRule{CountryCode: rule.CountryCode, LongUrl: rule.LongURL}
+	}
+	return out
+}
+
+// geoRulesToCache maps stored geo rules to the redirect cache entry form,
+// keyed by country code.
+func geoRulesToCache(rules []models.GeoRule) map[string]string {
+	if len(rules) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(rules))
+	for _, rule := range rules {
+		out[rule.CountryCode] = rule.LongURL
+	}
+	return out
+}
+
+// isActivated reports whether a link with the given activation time may
+// redirect at now. Links without active_from are active from creation.
+func isActivated(activeFrom *time.Time, now time.Time) bool {
+	return activeFrom == nil || !activeFrom.After(now)
+}
+
+// validateDestinations checks the destinations of a new link with
+// validation.ValidateLongURL. One that leads back to this shortener is
+// refused as SELF_REFERENTIAL_URL unless chaining is allowed.
+func (s *URLService) validateDestinations(ctx context.Context, longURLs ...string) error {
+	isOwnHost := s.ownHosts(ctx)
+	for _, longURL := range longURLs {
+		switch err := validation.ValidateLongURL(longURL, isOwnHost); {
+		case errors.Is(err, validation.ErrLongURLSelfReferring):
+			return withReason(status.New(codes.InvalidArgument, err.Error()), models.ErrCodeSelfReferentialURL).Err()
+		case err != nil:
+			return withReason(status.New(codes.InvalidArgument, err.Error()), models.ErrCodeInvalidURL).Err()
+		}
+	}
+	return nil
+}
+
+// clickDeltaReader reads the clicks of links that the analytics-worker has
+// not flushed to the database yet. It is satisfied by *clickdelta.Counter.
+type clickDeltaReader interface {
+	Get(ctx context.Context, shortCodes ...string) (map[string]int64, error)
+}
+
+// now returns the current time by the service's clock, or the wall clock
+// when it has none.
+func (s *URLService) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+	return s.clock.Now()
+}
+
+// pendingClicks returns the unflushed clicks of shortCodes, to be added to
+// their database counts. Without a delta reader, or when Redis cannot be
+// read, it returns none: the database count is stale but never wrong.
+func (s *URLService) pendingClicks(ctx context.Context, shortCodes ...string) map[string]int64 {
+	if s.clickDeltas == nil {
+		return nil
+	}
+	deltas, err := s.clickDeltas.Get(ctx, shortCodes...)
+	if err != nil {
+		return nil
+	}
+	return deltas
+}
+
+// unixOrZero converts an optional timestamp to Unix seconds, mapping nil to
+// the proto convention of 0 = unset.
+func unixOrZero(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.Unix()
+}
+
+// qrCodeFor renders the QR code for shortURL and returns the value to store
+// in the qr_code column. With a QR store configured the image is uploaded
+// and the value is its key; each upload gets a fresh key, so an attempt
+// whose row is never written cannot overwrite the image of an existing link
+// with the same short code. Without a store, or if the upload fails, the
+// image is kept inline as a data URI. It returns "" if the image cannot be
+// generated at all.
+func (s *URLService) qrCodeFor(ctx context.Context, shortCode, shortURL string) string {
+	if s.qrStore == nil {
+		qrCodeData, err := qrcode.GenerateQRCode(shortURL)
+		if err != nil {
+			return ""
+		}
+		return qrCodeData
+	}
+
+	png, err := qrcode.GeneratePNG(shortURL)
+	if err != nil {
+		return ""
+	}
+	key := shortCode + "/" + uuid.NewString() + ".png"
+	if err := s.qrStore.Put(ctx, key, png); err != nil {
+		return qrcode.EncodeDataURI(png)
+	}
+	return key
+}
+
+// discardQRCode deletes the uploaded image behind a qr_code value whose row
+// was not written or has been deleted. Inline values need no cleanup.
+func (s *URLService) discardQRCode(ctx context.Context, qrCodeData string) {
+	if s.qrStore == nil || qrCodeData == "" || qrcode.IsDataURI(qrCodeData) {
+		return
+	}
+	_ = s.qrStore.Delete(context.WithoutCancel(ctx), qrCodeData)
+}
+
+// queuePreview schedules the fetch of a new link's preview, if previews
+// are enabled.
+func (s *URLService) queuePreview(shortCode, longURL string) {
+	if s.previews != nil {
+		s.previews.Enqueue(shortCode, longURL)
+	}
+}
+
+// reserveQuota counts n new links by userID against the user's quota.
+// Anonymous links are not subject to it. A refusal is ResourceExhausted,
+// with a RetryInfo detail when the daily limit is what was reached.
+func (s *URLService) reserveQuota(ctx context.Context, userID string, n int64) (*quota.Reservation, error) {
+	if s.quotas == nil || userID == "" {
+		return nil, nil
+	}
+	reservation, err := s.quotas.Reserve(ctx, userID, n)
+	if err == nil {
+		return reservation, nil
+	}
+	var exceeded *quota.ExceededError
+	if !errors.As(err, &exceeded) {
+		return nil, status.Errorf(codes.Internal, "failed to check link quota: %v", err)
+	}
+	st := withReason(status.New(codes.ResourceExhausted, exceeded.Error()), models.ErrCodeQuotaExceeded)
+	if exceeded.RetryAfter > 0 {
+		if withRetry, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(exceeded.RetryAfter)}); err == nil {
+			st = withRetry
+		}
+	}
+	return nil, st.Err()
 }
 
-// getQRCode retrieves the stored QR code for a given short code from the
-// database. It is used after custom alias creation to attach the QR code to
-// the response (the QR code is generated and stored during insertion).
-func (s *URLService) getQRCode(ctx context.Context, shortCode string) (string, error) {
-	url, err := s.store.GetByShortCode(ctx, shortCode)
-	if err != nil || url == nil {
-		return "", err
+// releaseQuota hands back unused of the links reserved by reserveQuota.
+func (s *URLService) releaseQuota(ctx context.Context, reservation *quota.Reservation, unused int64) {
+	if s.quotas != nil {
+		s.quotas.Release(context.WithoutCancel(ctx), reservation, unused)
 	}
-	return url.QRCode, nil
 }
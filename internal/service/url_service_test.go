@@ -0,0 +1,1218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/bloom"
+	"github.com/Varun5711/shorternit/internal/cache"
+	"github.com/Varun5711/shorternit/internal/cache/cachetest"
+	"github.com/Varun5711/shorternit/internal/clock/clocktest"
+	"github.com/Varun5711/shorternit/internal/idgen"
+	"github.com/Varun5711/shorternit/internal/lock"
+	"github.com/Varun5711/shorternit/internal/lock/locktest"
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/Varun5711/shorternit/internal/qrcode"
+	"github.com/Varun5711/shorternit/internal/quota"
+	"github.com/Varun5711/shorternit/internal/storage"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeStore is an in-memory storage.Storage holding links by short code.
+// It mirrors PostgresStorage's observable behaviour (expired links hidden,
+// newest-first listings, duplicate aliases refused, an audit event per
+// write) so service logic can be tested without a database.
+type fakeStore struct {
+	urls      map[string]*models.URL
+	tagCounts []models.TagCount
+	saveErr   error            // fails every Save
+	collide   int              // how many Saves report their short code taken
+	saveErrs  map[string]error // per-short-code SaveBatch failures
+	events    []*models.URLEvent
+
+	listedUserID string
+	listedTag    string
+}
+
+var _ storage.Storage = (*fakeStore)(nil)
+
+func newFakeStore(urls ...*models.URL) *fakeStore {
+	f := &fakeStore{urls: make(map[string]*models.URL)}
+	for _, u := range urls {
+		f.urls[u.ShortCode] = u
+	}
+	return f
+}
+
+func (f *fakeStore) Save(ctx context.Context, url *models.URL) error {
+	if f.saveErr != nil {
+		return f.saveErr
+	}
+	if _, taken := f.urls[url.ShortCode]; taken || f.collide > 0 {
+		f.collide = max(f.collide-1, 0)
+		return fmt.Errorf("failed to save URL: %w", storage.ErrShortCodeTaken)
+	}
+	f.urls[url.ShortCode] = url
+	f.record(url.ShortCode, models.URLEventCreate, url.UserID, "", url.LongURL)
+	return nil
+}
+
+// record appends an event to the audit trail, numbered in order.
+func (f *fakeStore) record(shortCode, action, actorID, beforeURL, afterURL string) {
+	f.events = append(f.events, &models.URLEvent{
+		ID:         int64(len(f.events) + 1),
+		ShortCode:  shortCode,
+		Action:     action,
+		ActorID:    actorID,
+		BeforeURL:  beforeURL,
+		AfterURL:   afterURL,
+		OccurredAt: time.Now(),
+	})
+}
+
+// ListEvents mirrors PostgresStorage: without includePrevious, only the
+// events since the code's latest create.
+func (f *fakeStore) ListEvents(ctx context.Context, shortCode string, includePrevious bool) ([]*models.URLEvent, error) {
+	var out []*models.URLEvent
+	for _, ev := range f.events {
+		if ev.ShortCode != shortCode {
+			continue
+		}
+		if ev.Action == models.URLEventCreate && !includePrevious {
+			out = out[:0]
+		}
+		out = append(out, ev)
+	}
+	return out, nil
+}
+
+// GetByShortCode mirrors PostgresStorage by skipping expired links.
+func (f *fakeStore) GetByShortCode(ctx context.Context, shortCode string) (*models.URL, error) {
+	u := f.urls[shortCode]
+	if u == nil || (u.ExpiresAt != nil && !u.ExpiresAt.After(time.Now())) {
+		return nil, nil
+	}
+	return u, nil
+}
+
+// GetURLStats mirrors PostgresStorage by including expired links.
+func (f *fakeStore) GetURLStats(ctx context.Context, shortCode string) (*models.URLStats, error) {
+	u := f.urls[shortCode]
+	if u == nil {
+		return nil, nil
+	}
+	return &models.URLStats{
+		ShortCode: u.ShortCode,
+		UserID:    u.UserID,
+		Clicks:    u.Clicks,
+		MaxClicks: u.MaxClicks,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.CreatedAt,
+		ExpiresAt: u.ExpiresAt,
+	}, nil
+}
+
+func (f *fakeStore) AliasExists(ctx context.Context, alias string) (bool, error) {
+	_, ok := f.urls[alias]
+	return ok, nil
+}
+
+func (f *fakeStore) AliasExistsPrimary(ctx context.Context, alias string) (bool, error) {
+	return f.AliasExists(ctx, alias)
+}
+
+// CreateCustomURL mirrors PostgresStorage, including its translation of the
+// unique-constraint violation.
+func (f *fakeStore) CreateCustomURL(ctx context.Context, url *models.URL) error {
+	if _, ok := f.urls[url.ShortCode]; ok {
+		return fmt.Errorf("failed to create custom URL: %w", storage.ErrShortCodeTaken)
+	}
+	url.CreatedAt = time.Now()
+	f.urls[url.ShortCode] = url
+	f.record(url.ShortCode, models.URLEventCreate, url.UserID, "", url.LongURL)
+	return nil
+}
+
+func (f *fakeStore) IncrementClicks(ctx context.Context, shortCode string) error {
+	u, ok := f.urls[shortCode]
+	if !ok {
+		return fmt.Errorf("URL with short code %s not found", shortCode)
+	}
+	u.Clicks++
+	return nil
+}
+
+func (f *fakeStore) DeleteExpiredURLs(ctx context.Context) ([]string, error) {
+	var codes []string
+	for code, u := range f.urls {
+		if u.ExpiresAt != nil && u.ExpiresAt.Before(time.Now()) {
+			delete(f.urls, code)
+			codes = append(codes, code)
+		}
+	}
+	return codes, nil
+}
+
+// live returns the unexpired links owned by userID ("" matches every
+// link), newest first with ties broken by short code like the database's
+// keyset order.
+func (f *fakeStore) live(userID string) []*models.URL {
+	var out []*models.URL
+	for _, u := range f.urls {
+		if u.ExpiresAt != nil && !u.ExpiresAt.After(time.Now()) {
+			continue
+		}
+		if userID != "" && u.UserID != userID {
+			continue
+		}
+		out = append(out, u)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.After(out[j].CreatedAt)
+		}
+		return out[i].ShortCode > out[j].ShortCode
+	})
+	return out
+}
+
+// page applies limit and offset to urls and returns the page with the
+// total count.
+func page(urls []*models.URL, limit, offset int32) ([]*models.URL, models.URLListSummary, error) {
+	summary := models.URLListSummary{Total: int32(len(urls))}
+	for _, u := range urls {
+		summary.TotalClicks += u.Clicks
+		summary.ActiveCount++
+	}
+	if offset >= summary.Total {
+		return nil, summary, nil
+	}
+	end := offset + limit
+	if end > summary.Total {
+		end = summary.Total
+	}
+	return urls[offset:end], summary, nil
+}
+
+func (f *fakeStore) List(ctx context.Context) ([]*models.URL, error) {
+	return f.live(""), nil
+}
+
+func (f *fakeStore) ListByUserID(ctx context.Context, userID string) ([]*models.URL, error) {
+	return f.live(userID), nil
+}
+
+func (f *fakeStore) ListPaginated(ctx context.Context, limit, offset int32) ([]*models.URL, models.URLListSummary, error) {
+	return page(f.live(""), limit, offset)
+}
+
+func (f *fakeStore) ListByUserIDPaginated(ctx context.Context, userID string, limit, offset int32) ([]*models.URL, models.URLListSummary, error) {
+	return page(f.live(userID), limit, offset)
+}
+
+func (f *fakeStore) ListByUserIDAfter(ctx context.Context, userID string, afterCreatedAt time.Time, afterShortCode string, limit int32) ([]*models.URL, error) {
+	var out []*models.URL
+	for _, u := range f.live(userID) {
+		if !afterCreatedAt.IsZero() && !(u.CreatedAt.Before(afterCreatedAt) ||
+			(u.CreatedAt.Equal(afterCreatedAt) && u.ShortCode < afterShortCode)) {
+			continue
+		}
+		if int32(len(out)) == limit {
+			break
+		}
+		out = append(out, u)
+	}
+	return out, nil
+}
+
+func (f *fakeStore) Delete(ctx context.Context, shortCode, actorID string) error {
+	u, ok := f.urls[shortCode]
+	if !ok {
+		return fmt.Errorf("URL with short code %s not found", shortCode)
+	}
+	delete(f.urls, shortCode)
+	f.record(shortCode, models.URLEventDelete, actorID, u.LongURL, "")
+	return nil
+}
+
+func (f *fakeStore) DeleteManyByUser(ctx context.Context, userID string, shortCodes []string) ([]*models.URL, error) {
+	var deleted []*models.URL
+	for _, shortCode := range shortCodes {
+		u, ok := f.urls[shortCode]
+		if !ok || u.UserID != userID {
+			continue
+		}
+		delete(f.urls, shortCode)
+		f.record(shortCode, models.URLEventDelete, userID, u.LongURL, "")
+		deleted = append(deleted, u)
+	}
+	return deleted, nil
+}
+
+func (f *fakeStore) UpdateQRCode(ctx context.Context, shortCode, userID, qrCode string) (string, error) {
+	u, ok := f.urls[shortCode]
+	if !ok || u.UserID != userID {
+		return "", fmt.Errorf("URL with short code %s not found", shortCode)
+	}
+	previous := u.QRCode
+	u.QRCode = qrCode
+	return previous, nil
+}
+
+// Restore mirrors PostgresStorage: an expired link still held by its owner
+// is renewed in place, any other holder of the code refuses it.
+func (f *fakeStore) Restore(ctx context.Context, url *models.URL) error {
+	if u, ok := f.urls[url.ShortCode]; ok {
+		if u.UserID != url.UserID || u.ExpiresAt == nil || u.ExpiresAt.After(time.Now()) {
+			return fmt.Errorf("failed to restore URL: %w", storage.ErrShortCodeTaken)
+		}
+		u.ExpiresAt = url.ExpiresAt
+		url.LongURL, url.Domain = u.LongURL, u.Domain
+	} else {
+		f.urls[url.ShortCode] = url
+	}
+	f.record(url.ShortCode, models.URLEventRestore, url.UserID, "", url.LongURL)
+	return nil
+}
+
+func (f *fakeStore) UpdateTags(ctx context.Context, shortCode string, tags []string, actorID string) error {
+	u, ok := f.urls[shortCode]
+	if !ok {
+		return fmt.Errorf("short code not found")
+	}
+	u.Tags = tags
+	f.record(shortCode, models.URLEventUpdate, actorID, u.LongURL, u.LongURL)
+	return nil
+}
+
+func (f *fakeStore) SetActive(ctx context.Context, shortCode string, active bool, actorID string) error {
+	u, ok := f.urls[shortCode]
+	if !ok {
+		return fmt.Errorf("short code not found")
+	}
+	if u.Disabled == !active {
+		return nil
+	}
+	u.Disabled = !active
+	action := models.URLEventDisable
+	if active {
+		action = models.URLEventEnable
+	}
+	f.record(shortCode, action, actorID, u.LongURL, u.LongURL)
+	return nil
+}
+
+func (f *fakeStore) GetTagCounts(ctx context.Context, userID string) ([]models.TagCount, error) {
+	return f.tagCounts, nil
+}
+
+func (f *fakeStore) ListByUserIDAndTag(ctx context.Context, userID, tag string, limit, offset int32) ([]*models.URL, models.URLListSummary, error) {
+	f.listedUserID, f.listedTag = userID, tag
+
+	var out []*models.URL
+	for _, u := range f.urls {
+		if u.UserID != userID {
+			continue
+		}
+		for _, t := range u.Tags {
+			if t == tag {
+				out = append(out, u)
+				break
+			}
+		}
+	}
+	return out, models.URLListSummary{Total: int32(len(out))}, nil
+}
+
+func (f *fakeStore) CountActiveByUser(ctx context.Context, userID string) (int64, error) {
+	var n int64
+	for _, u := range f.urls {
+		if u.UserID == userID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// SaveBatch mirrors PostgresStorage: taken codes report ErrShortCodeTaken
+// and rows listed in saveErrs fail on their own.
+func (f *fakeStore) SaveBatch(ctx context.Context, urls []*models.URL) []error {
+	errs := make([]error, len(urls))
+	for i, u := range urls {
+		if err := f.saveErrs[u.ShortCode]; err != nil {
+			errs[i] = err
+			continue
+		}
+		if _, ok := f.urls[u.ShortCode]; ok || f.collide > 0 {
+			f.collide = max(f.collide-1, 0)
+			errs[i] = storage.ErrShortCodeTaken
+			continue
+		}
+		f.urls[u.ShortCode] = u
+		f.record(u.ShortCode, models.URLEventCreate, u.UserID, "", u.LongURL)
+	}
+	return errs
+}
+
+// newAliasTestService wires a URLService for alias-filter tests around store
+// and filter, with a cache whose Redis tier is unreachable.
+func newAliasTestService(store *fakeStore, filter *bloom.Filter) *URLService {
+	idGen, err := idgen.NewGenerator(0, 0)
+	if err != nil {
+		panic(err)
+	}
+	return &URLService{
+		store:       store,
+		idGen:       idGen,
+		cache:       cachetest.NewL1Only(),
+		lockAlias:   withAliasLocks(locktest.NewTable(), nil),
+		aliasFilter: filter,
+		baseURL:     "http://tiny.test",
+	}
+}
+
+// withAliasLocks returns a lockAlias seam taking locks from table and, when
+// taken is non-nil, appending each requested key to it.
+func withAliasLocks(table *locktest.Table, taken *[]string) func(key string) lock.Locker {
+	return func(key string) lock.Locker {
+		if taken != nil {
+			*taken = append(*taken, key)
+		}
+		return table.Lock(key)
+	}
+}
+
+// TestCreateCustomURL_InMemoryStore verifies that a custom alias is stored
+// with its settings and audit event, and that claiming it again is refused.
+func TestCreateCustomURL_InMemoryStore(t *testing.T) {
+	store := newFakeStore()
+	filter := bloom.New(100, 0.01)
+	s := newAliasTestService(store, filter)
+
+	resp, err := s.CreateCustomURL(context.Background(), &pb.CreateCustomURLRequest{
+		Alias:     "launch",
+		LongUrl:   "https://example.com/launch",
+		UserId:    "alice",
+		MaxClicks: 10,
+		Tags:      []string{"Work"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ShortCode != "launch" || resp.ShortUrl != "http://tiny.test/launch" {
+		t.Errorf("unexpected response %s %s", resp.ShortCode, resp.ShortUrl)
+	}
+	u := store.urls["launch"]
+	if u == nil {
+		t.Fatal("expected the alias to be stored")
+	}
+	if u.LongURL != "https://example.com/launch" || u.UserID != "alice" || u.MaxClicks != 10 || len(u.Tags) != 1 || u.Tags[0] != "work" {
+		t.Errorf("unexpected stored URL %+v", u)
+	}
+	if !filter.MightContain("launch") {
+		t.Error("expected the alias to be added to the filter")
+	}
+	if len(store.events) != 1 || store.events[0].Action != models.URLEventCreate || store.events[0].ActorID != "alice" {
+		t.Errorf("expected one create event by alice, got %+v", store.events)
+	}
+
+	_, err = s.CreateCustomURL(context.Background(), &pb.CreateCustomURLRequest{
+		Alias: "launch", LongUrl: "https://example.com/other", UserId: "bob",
+	})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("expected AlreadyExists, got %v", err)
+	}
+	if store.urls["launch"].UserID != "alice" {
+		t.Error("expected the original link to be kept")
+	}
+}
+
+// TestCreateCustomURL_FilterFalsePositive verifies that an alias the filter
+// wrongly reports as present takes the lock and primary check, then is
+// created, and that a concurrent claim holding the lock is refused.
+func TestCreateCustomURL_FilterFalsePositive(t *testing.T) {
+	store := newFakeStore()
+	filter := bloom.New(100, 0.01)
+	filter.Add("fresh")
+	filter.Add("contended")
+	s := newAliasTestService(store, filter)
+	locks := locktest.NewTable()
+	var taken []string
+	s.lockAlias = withAliasLocks(locks, &taken)
+
+	if _, err := s.CreateCustomURL(context.Background(), &pb.CreateCustomURLRequest{
+		Alias: "fresh", LongUrl: "https://example.com",
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(taken) != 1 || taken[0] != "lock:alias:fresh" {
+		t.Errorf("expected the alias lock to be taken, got %v", taken)
+	}
+	if locks.Held("lock:alias:fresh") {
+		t.Error("expected the alias lock to be released")
+	}
+	if store.urls["fresh"] == nil {
+		t.Error("expected the alias to be created")
+	}
+
+	other := locks.Lock("lock:alias:contended")
+	if ok, _ := other.Acquire(context.Background()); !ok {
+		t.Fatal("failed to hold the lock")
+	}
+	_, err := s.CreateCustomURL(context.Background(), &pb.CreateCustomURLRequest{
+		Alias: "contended", LongUrl: "https://example.com",
+	})
+	if err == nil || !strings.Contains(err.Error(), "being claimed") {
+		t.Errorf("expected the claim to be refused while locked, got %v", err)
+	}
+	if store.urls["contended"] != nil {
+		t.Error("expected nothing to be stored while another request holds the lock")
+	}
+}
+
+// TestCreateCustomURL_FilterMissOnTakenAlias verifies that an alias the
+// filter does not know about skips the lock, is still refused by the
+// store's uniqueness check, and is then recorded in the filter. An eagerly
+// rendered QR code for it is discarded.
+func TestCreateCustomURL_FilterMissOnTakenAlias(t *testing.T) {
+	dir := t.TempDir()
+	qrStore, err := qrcode.NewLocalStore(dir, "")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	store := newFakeStore(&models.URL{ShortCode: "taken", LongURL: "https://example.com/first"})
+	filter := bloom.New(100, 0.01)
+	s := newAliasTestService(store, filter)
+	s.qrStore = qrStore
+	var taken []string
+	s.lockAlias = withAliasLocks(locktest.NewTable(), &taken)
+
+	_, err = s.CreateCustomURL(context.Background(), &pb.CreateCustomURLRequest{
+		Alias: "taken", LongUrl: "https://example.com/second", GenerateQr: true,
+	})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("expected AlreadyExists, got %v", err)
+	}
+	if len(taken) != 0 {
+		t.Errorf("expected a filter miss to skip the lock, got %v", taken)
+	}
+	if !filter.MightContain("taken") {
+		t.Error("expected the taken alias to be added to the filter")
+	}
+	if got := store.urls["taken"].LongURL; got != "https://example.com/first" {
+		t.Errorf("expected the existing link to be kept, got %s", got)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to list images: %v", err)
+	}
+	for _, e := range entries {
+		images, _ := os.ReadDir(filepath.Join(dir, e.Name()))
+		if len(images) != 0 {
+			t.Errorf("expected the image to be discarded, found %d under %s", len(images), e.Name())
+		}
+	}
+}
+
+// TestCreateURL_AddsCodeToFilter verifies that a generated short code is
+// recorded in the alias filter, so a later custom alias with the same value
+// takes the exact availability check instead of skipping it.
+func TestCreateURL_AddsCodeToFilter(t *testing.T) {
+	store := newFakeStore()
+	filter := bloom.New(100, 0.01)
+	s := newAliasTestService(store, filter)
+
+	resp, err := s.CreateURL(context.Background(), &pb.CreateURLRequest{LongUrl: "https://example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := store.urls[resp.ShortCode]; !ok {
+		t.Fatalf("expected %s to be stored", resp.ShortCode)
+	}
+	if !filter.MightContain(resp.ShortCode) {
+		t.Errorf("expected %s to be added to the filter", resp.ShortCode)
+	}
+}
+
+// TestCreateURL_PadsShortCode verifies that generated short codes are
+// padded to the configured minimum length and still decode.
+func TestCreateURL_PadsShortCode(t *testing.T) {
+	s := newAliasTestService(newFakeStore(), nil)
+	s.minCodeLen = 16
+
+	resp, err := s.CreateURL(context.Background(), &pb.CreateURLRequest{LongUrl: "https://example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.ShortCode) != 16 || resp.ShortCode[0] != '0' {
+		t.Errorf("expected a 16-character code padded with '0', got %q", resp.ShortCode)
+	}
+	if _, err := idgen.Decode(resp.ShortCode); err != nil {
+		t.Errorf("expected %q to decode: %v", resp.ShortCode, err)
+	}
+}
+
+// TestAliasTakenError_CarriesSuggestions verifies that a taken alias is
+// reported as AlreadyExists with the generated alternatives attached as an
+// ErrorInfo detail rather than only in the message.
+func TestAliasTakenError_CarriesSuggestions(t *testing.T) {
+	st := status.Convert(newAliasTakenError("taken"))
+	if st.Code() != codes.AlreadyExists {
+		t.Fatalf("expected AlreadyExists, got %v", st.Code())
+	}
+
+	var info *errdetails.ErrorInfo
+	for _, detail := range st.Details() {
+		if d, ok := detail.(*errdetails.ErrorInfo); ok {
+			info = d
+		}
+	}
+	if info == nil || info.Metadata["suggestions"] != "taken-1,taken-2,taken-3" {
+		t.Errorf("expected the suggested alternatives in an ErrorInfo detail, got %v", info)
+	}
+	if got := errorReason(newAliasTakenError("taken")); got != models.ErrCodeAliasTaken {
+		t.Errorf("expected reason %s, got %q", models.ErrCodeAliasTaken, got)
+	}
+}
+
+// errorReason returns the reason of err's ErrorInfo detail, if it has one.
+func errorReason(err error) string {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+// TestCheckOwnership_NotFoundReason verifies that a missing short code is
+// NotFound with the URL_NOT_FOUND reason, which the gateway reports as the
+// error code.
+func TestCheckOwnership_NotFoundReason(t *testing.T) {
+	s := newAliasTestService(newFakeStore(), nil)
+
+	err := s.checkOwnership(context.Background(), "nope", "alice")
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+	if got := errorReason(err); got != models.ErrCodeURLNotFound {
+		t.Errorf("expected reason %s, got %q", models.ErrCodeURLNotFound, got)
+	}
+}
+
+// TestResolveSchedule_RejectsActivationAfterExpiry verifies that a link which
+// would expire before (or exactly when) it activates is refused.
+func TestResolveSchedule_RejectsActivationAfterExpiry(t *testing.T) {
+	s := &URLService{}
+	now := time.Now()
+
+	cases := []struct {
+		name       string
+		activeFrom int64
+		expiresAt  int64
+	}{
+		{"after expiry", now.Add(2 * time.Hour).Unix(), now.Add(time.Hour).Unix()},
+		{"equal to expiry", now.Add(time.Hour).Unix(), now.Add(time.Hour).Unix()},
+		{"negative", -1, 0},
+	}
+
+	for _, tc := range cases {
+		if _, _, err := s.resolveSchedule(tc.activeFrom, tc.expiresAt, s.defaultTTL, now); err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+	}
+}
+
+// TestResolveSchedule_DefaultTTLCountsFromActivation checks that the default
+// expiry starts at active_from, so a link scheduled beyond the TTL is not
+// created already expired.
+func TestResolveSchedule_DefaultTTLCountsFromActivation(t *testing.T) {
+	s := &URLService{defaultTTL: 24 * time.Hour}
+	now := time.Now()
+	start := now.Add(48 * time.Hour).Truncate(time.Second)
+
+	activeFrom, expiresAt, err := s.resolveSchedule(start.Unix(), 0, s.defaultTTL, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if activeFrom == nil || !activeFrom.Equal(start) {
+		t.Fatalf("expected active_from %v, got %v", start, activeFrom)
+	}
+	if expiresAt == nil || !expiresAt.Equal(start.Add(24*time.Hour)) {
+		t.Errorf("expected expiry 24h after activation, got %v", expiresAt)
+	}
+}
+
+// TestResolveSchedule_Immediate keeps the old behaviour for links without
+// active_from: no activation time and expiry counted from now.
+func TestResolveSchedule_Immediate(t *testing.T) {
+	s := &URLService{defaultTTL: time.Hour}
+	now := time.Now()
+
+	activeFrom, expiresAt, err := s.resolveSchedule(0, 0, s.defaultTTL, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if activeFrom != nil {
+		t.Errorf("expected no active_from, got %v", activeFrom)
+	}
+	if expiresAt == nil || !expiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("expected expiry one hour from now, got %v", expiresAt)
+	}
+}
+
+// TestExportCursor_RoundTrip checks that a cursor decodes back to the exact
+// keyset position, including sub-second precision, and that garbage is
+// rejected.
+func TestExportCursor_RoundTrip(t *testing.T) {
+	createdAt := time.Date(2025, 3, 1, 12, 0, 0, 123456000, time.UTC)
+
+	gotTime, gotCode, err := decodeExportCursor(encodeExportCursor(createdAt, "abc123"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !gotTime.Equal(createdAt) || gotCode != "abc123" {
+		t.Errorf("expected (%v, abc123), got (%v, %s)", createdAt, gotTime, gotCode)
+	}
+
+	if _, _, err := decodeExportCursor("not a cursor"); err == nil {
+		t.Error("expected an error for a malformed cursor")
+	}
+}
+
+// TestGetURL_NotFoundBeforeActivation verifies that a scheduled link is not
+// resolvable until its active_from time, and is afterwards.
+func TestGetURL_NotFoundBeforeActivation(t *testing.T) {
+	clk := clocktest.NewFake(time.Now())
+	soon := clk.Now().Add(time.Hour)
+	s := &URLService{
+		store: newFakeStore(&models.URL{ShortCode: "soon", LongURL: "https://example.com", ActiveFrom: &soon}),
+		clock: clk,
+	}
+
+	resp, err := s.GetURL(context.Background(), &pb.GetURLRequest{ShortCode: "soon"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Found || resp.Url != nil {
+		t.Errorf("expected a not-yet-active link to be not found, got %+v", resp)
+	}
+
+	clk.Advance(time.Hour)
+	resp, err = s.GetURL(context.Background(), &pb.GetURLRequest{ShortCode: "soon"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Found || !resp.Url.IsActive {
+		t.Errorf("expected an activated link to be found and active, got %+v", resp)
+	}
+}
+
+// TestGetURL_ReportsExpiredLinks verifies that an expired link is not found
+// but flagged as expired, unlike a code that never existed.
+func TestGetURL_ReportsExpiredLinks(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	s := &URLService{store: newFakeStore(
+		&models.URL{ShortCode: "old", LongURL: "https://example.com", ExpiresAt: &past},
+	)}
+
+	resp, err := s.GetURL(context.Background(), &pb.GetURLRequest{ShortCode: "old"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Found || !resp.Expired {
+		t.Errorf("expected an expired link to be reported as expired, got %+v", resp)
+	}
+
+	resp, err = s.GetURL(context.Background(), &pb.GetURLRequest{ShortCode: "never"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Found || resp.Expired {
+		t.Errorf("expected an unknown code to be plainly not found, got %+v", resp)
+	}
+}
+
+// TestGetURL_ForOwner verifies that a lookup on behalf of a user finds
+// their links on any domain and before activation, and refuses anyone
+// else's.
+func TestGetURL_ForOwner(t *testing.T) {
+	soon := time.Now().Add(time.Hour)
+	s := &URLService{store: newFakeStore(
+		&models.URL{ShortCode: "soon", LongURL: "https://example.com", UserID: "alice", ActiveFrom: &soon, Domain: "go.acme.com"},
+	)}
+	ctx := context.Background()
+
+	resp, err := s.GetURL(ctx, &pb.GetURLRequest{ShortCode: "soon", UserId: "alice"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Found || resp.Url.IsActive || resp.Url.Domain != "go.acme.com" {
+		t.Errorf("expected alice's scheduled link, inactive, got %+v", resp)
+	}
+
+	if _, err := s.GetURL(ctx, &pb.GetURLRequest{ShortCode: "soon", UserId: "bob"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied for another user's link, got %v", err)
+	}
+	if resp, err := s.GetURL(ctx, &pb.GetURLRequest{ShortCode: "none", UserId: "alice"}); err != nil || resp.Found {
+		t.Errorf("expected an unknown code to be not found, got %+v, %v", resp, err)
+	}
+}
+
+// fixedDeltas is a clickDeltaReader with fixed pending clicks, or failing
+// with err.
+type fixedDeltas struct {
+	deltas map[string]int64
+	err    error
+}
+
+func (f fixedDeltas) Get(ctx context.Context, shortCodes ...string) (map[string]int64, error) {
+	return f.deltas, f.err
+}
+
+// TestClickCounts_IncludePendingClicks verifies that GetURL and ListURLs
+// report clicks the analytics-worker has not flushed yet on top of the
+// database count, and fall back to the database count alone when the
+// deltas cannot be read.
+func TestClickCounts_IncludePendingClicks(t *testing.T) {
+	s := &URLService{
+		store: newFakeStore(
+			&models.URL{ShortCode: "abc", LongURL: "https://example.com", UserID: "alice", Clicks: 10},
+			&models.URL{ShortCode: "xyz", LongURL: "https://example.org", UserID: "alice", Clicks: 2},
+		),
+		clickDeltas: fixedDeltas{deltas: map[string]int64{"abc": 3}},
+	}
+	ctx := context.Background()
+
+	resp, err := s.GetURL(ctx, &pb.GetURLRequest{ShortCode: "abc"})
+	if err != nil || resp.Url == nil {
+		t.Fatalf("GetURL: %+v, %v", resp, err)
+	}
+	if resp.Url.Clicks != 13 {
+		t.Errorf("expected 10 stored + 3 pending clicks, got %d", resp.Url.Clicks)
+	}
+
+	list, err := s.ListURLs(ctx, &pb.ListURLsRequest{UserId: "alice"})
+	if err != nil {
+		t.Fatalf("ListURLs: %v", err)
+	}
+	clicks := make(map[string]int64)
+	for _, u := range list.Urls {
+		clicks[u.ShortCode] = u.Clicks
+	}
+	if clicks["abc"] != 13 || clicks["xyz"] != 2 || list.TotalClicks != 15 {
+		t.Errorf("expected abc=13, xyz=2 and 15 in total, got %v and %d", clicks, list.TotalClicks)
+	}
+
+	s.clickDeltas = fixedDeltas{err: errors.New("redis down")}
+	if resp, err := s.GetURL(ctx, &pb.GetURLRequest{ShortCode: "abc"}); err != nil || resp.Url.Clicks != 10 {
+		t.Errorf("expected the stored count when Redis fails, got %+v, %v", resp, err)
+	}
+}
+
+// TestDeleteURL_Ownership verifies that a user can delete only their own
+// links, that an admin request deletes any, and that each delete is
+// recorded against the caller.
+func TestDeleteURL_Ownership(t *testing.T) {
+	store := newFakeStore(
+		&models.URL{ShortCode: "mine", UserID: "alice"},
+		&models.URL{ShortCode: "theirs", UserID: "bob"},
+	)
+	s := &URLService{store: store, cache: cachetest.NewL1Only()}
+	ctx := context.Background()
+
+	if _, err := s.DeleteURL(ctx, &pb.DeleteURLRequest{ShortCode: "theirs", UserId: "alice"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied for another user's link, got %v", err)
+	}
+	if _, ok := store.urls["theirs"]; !ok {
+		t.Fatal("expected a rejected delete to leave the link in place")
+	}
+
+	for _, tc := range []struct {
+		name      string
+		shortCode string
+		userID    string
+		admin     bool
+		want      bool
+	}{
+		{"owner", "mine", "alice", false, true},
+		{"already deleted", "mine", "alice", false, false},
+		{"missing link", "nope", "alice", false, false},
+		{"admin", "theirs", "admin-id", true, true},
+	} {
+		resp, err := s.DeleteURL(ctx, &pb.DeleteURLRequest{ShortCode: tc.shortCode, UserId: tc.userID, Admin: tc.admin})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if resp.Success != tc.want {
+			t.Errorf("%s: expected success %v, got %v", tc.name, tc.want, resp.Success)
+		}
+	}
+	if len(store.urls) != 0 {
+		t.Errorf("expected both links deleted, %d left", len(store.urls))
+	}
+
+	if len(store.events) != 2 || store.events[0].ActorID != "alice" || store.events[1].ActorID != "admin-id" {
+		t.Errorf("expected deletes by alice and admin-id, got %+v", store.events)
+	}
+	if _, err := s.DeleteURL(ctx, &pb.DeleteURLRequest{ShortCode: "mine"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument without a user, got %v", err)
+	}
+}
+
+// TestResolveVariants verifies that a split needs at least two valid
+// variants, that a single variant collapses to a plain link, and that a
+// long_url given alongside variants must match the first one.
+func TestResolveVariants(t *testing.T) {
+	longURL, variants, err := resolveVariants("https://example.com", nil)
+	if err != nil || longURL != "https://example.com" || variants != nil {
+		t.Errorf("expected no variants to leave the link unchanged, got (%s, %v, %v)", longURL, variants, err)
+	}
+
+	longURL, variants, err = resolveVariants("", []*pb.URLVariant{{LongUrl: "https://a.example", Weight: 5}})
+	if err != nil || longURL != "https://a.example" || variants != nil {
+		t.Errorf("expected a single variant to collapse to a plain link, got (%s, %v, %v)", longURL, variants, err)
+	}
+
+	longURL, variants, err = resolveVariants("https://a.example", []*pb.URLVariant{
+		{LongUrl: "https://a.example", Weight: 3},
+		{LongUrl: "https://b.example", Weight: 1},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if longURL != "https://a.example" || len(variants) != 2 || variants[1].Weight != 1 {
+		t.Errorf("expected a two-way split led by a.example, got (%s, %v)", longURL, variants)
+	}
+
+	tooMany := make([]*pb.URLVariant, maxVariantsPerURL+1)
+	for i := range tooMany {
+		tooMany[i] = &pb.URLVariant{LongUrl: "https://a.example", Weight: 1}
+	}
+	cases := map[string]struct {
+		longURL  string
+		variants []*pb.URLVariant
+	}{
+		"mismatched long_url": {"https://other.example", []*pb.URLVariant{{LongUrl: "https://a.example", Weight: 1}, {LongUrl: "https://b.example", Weight: 1}}},
+		"zero weight":         {"", []*pb.URLVariant{{LongUrl: "https://a.example", Weight: 0}, {LongUrl: "https://b.example", Weight: 1}}},
+		"weight too large":    {"", []*pb.URLVariant{{LongUrl: "https://a.example", Weight: maxVariantWeight + 1}}},
+		"missing long_url":    {"", []*pb.URLVariant{{Weight: 1}, {LongUrl: "https://b.example", Weight: 1}}},
+		"too many":            {"", tooMany},
+	}
+	for name, tc := range cases {
+		if _, _, err := resolveVariants(tc.longURL, tc.variants); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// TestResolveGeoRules verifies that country codes are normalized to
+// uppercase and that malformed, unknown or duplicate codes are rejected.
+func TestResolveGeoRules(t *testing.T) {
+	rules, err := resolveGeoRules([]*pb.GeoRule{
+		{CountryCode: " de ", LongUrl: "https://example.com/gdpr"},
+		{CountryCode: "FR", LongUrl: "https://example.fr"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 2 || rules[0].CountryCode != "DE" || rules[1].CountryCode != "FR" {
+		t.Errorf("expected normalized rules for DE and FR, got %v", rules)
+	}
+
+	cases := map[string][]*pb.GeoRule{
+		"three letters":    {{CountryCode: "DEU", LongUrl: "https://example.com"}},
+		"digits":           {{CountryCode: "D1", LongUrl: "https://example.com"}},
+		"unknown country":  {{CountryCode: "XX", LongUrl: "https://example.com"}},
+		"duplicate":        {{CountryCode: "DE", LongUrl: "https://a.example"}, {CountryCode: "de", LongUrl: "https://b.example"}},
+		"missing long_url": {{CountryCode: "DE"}},
+	}
+	for name, rules := range cases {
+		if _, err := resolveGeoRules(rules); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// TestCreateURL_BackupURL verifies that a backup destination is stored,
+// cached and returned, and refused on a link that is split or geo-targeted
+// or when it repeats the long URL.
+func TestCreateURL_BackupURL(t *testing.T) {
+	store := newFakeStore()
+	s := newAliasTestService(store, nil)
+	ctx := context.Background()
+
+	resp, err := s.CreateURL(ctx, &pb.CreateURLRequest{LongUrl: "https://primary.example", BackupUrl: "https://backup.example", UserId: "alice"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.BackupUrl != "https://backup.example" || store.urls[resp.ShortCode].BackupURL != "https://backup.example" {
+		t.Errorf("expected the backup to be stored and returned, got %q", resp.BackupUrl)
+	}
+	if entry, ok := s.cache.GetURL(ctx, "url:"+resp.ShortCode); !ok || entry.BackupURL != "https://backup.example" {
+		t.Errorf("expected the cached entry to carry the backup, got %+v", entry)
+	}
+
+	custom, err := s.CreateCustomURL(ctx, &pb.CreateCustomURLRequest{Alias: "status-page", LongUrl: "https://primary.example", BackupUrl: "https://backup.example", UserId: "alice"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if custom.BackupUrl != "https://backup.example" || store.urls["status-page"].BackupURL != "https://backup.example" {
+		t.Errorf("expected the custom link's backup to be stored and returned, got %q", custom.BackupUrl)
+	}
+
+	cases := map[string]*pb.CreateURLRequest{
+		"same as long_url": {LongUrl: "https://primary.example", BackupUrl: "https://primary.example"},
+		"not a URL":        {LongUrl: "https://primary.example", BackupUrl: "ftp://backup.example"},
+		"with variants": {BackupUrl: "https://backup.example", Variants: []*pb.URLVariant{
+			{LongUrl: "https://a.example", Weight: 1}, {LongUrl: "https://b.example", Weight: 1},
+		}},
+		"with geo rules": {LongUrl: "https://primary.example", BackupUrl: "https://backup.example", GeoRules: []*pb.GeoRule{
+			{CountryCode: "DE", LongUrl: "https://primary.de"},
+		}},
+	}
+	for name, req := range cases {
+		req.UserId = "alice"
+		if _, err := s.CreateURL(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: expected InvalidArgument, got %v", name, err)
+		}
+	}
+}
+
+// TestCreateURL_LazyQRCode verifies that no QR code is rendered unless the
+// caller asks for it, and that an eagerly rendered image is uploaded to the
+// QR store and its key returned.
+func TestCreateURL_LazyQRCode(t *testing.T) {
+	dir := t.TempDir()
+	qrStore, err := qrcode.NewLocalStore(dir, "")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	store := newFakeStore()
+	s := newAliasTestService(store, nil)
+	s.qrStore = qrStore
+	ctx := context.Background()
+
+	lazy, err := s.CreateURL(ctx, &pb.CreateURLRequest{LongUrl: "https://example.com", UserId: "alice"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lazy.QrCode != "" || store.urls[lazy.ShortCode].QRCode != "" {
+		t.Errorf("expected no QR code, got %q", lazy.QrCode)
+	}
+	if _, err := os.Stat(filepath.Join(dir, lazy.ShortCode)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected nothing uploaded for %s, got %v", lazy.ShortCode, err)
+	}
+
+	eager, err := s.CreateURL(ctx, &pb.CreateURLRequest{LongUrl: "https://example.com", UserId: "alice", GenerateQr: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(eager.QrCode, eager.ShortCode+"/") || store.urls[eager.ShortCode].QRCode != eager.QrCode {
+		t.Fatalf("expected the stored key to be returned, got %q", eager.QrCode)
+	}
+	if _, err := qrStore.Get(ctx, eager.QrCode); err != nil {
+		t.Errorf("expected the image to be uploaded, got %v", err)
+	}
+}
+
+// TestCreateURL_DiscardsQRCodeOfUnsavedLink verifies that an image uploaded
+// for a link whose insert then fails is deleted again.
+func TestCreateURL_DiscardsQRCodeOfUnsavedLink(t *testing.T) {
+	dir := t.TempDir()
+	qrStore, err := qrcode.NewLocalStore(dir, "")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	store := newFakeStore()
+	store.saveErr = errors.New("connection reset")
+	s := newAliasTestService(store, nil)
+	s.qrStore = qrStore
+
+	_, err = s.CreateURL(context.Background(), &pb.CreateURLRequest{
+		LongUrl: "https://example.com", UserId: "alice", GenerateQr: true,
+	})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("expected Internal, got %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to list images: %v", err)
+	}
+	for _, e := range entries {
+		images, _ := os.ReadDir(filepath.Join(dir, e.Name()))
+		if len(images) != 0 {
+			t.Errorf("expected the image to be discarded, found %d under %s", len(images), e.Name())
+		}
+	}
+}
+
+// TestCreateURL_RetriesTakenShortCode simulates a minted short code that is
+// already in the database: the create is retried with a fresh ID and
+// succeeds, saving the link once under the new code.
+func TestCreateURL_RetriesTakenShortCode(t *testing.T) {
+	store := newFakeStore()
+	store.collide = 1
+	s := newAliasTestService(store, nil)
+
+	resp, err := s.CreateURL(context.Background(), &pb.CreateURLRequest{LongUrl: "https://example.com", UserId: "alice"})
+	if err != nil {
+		t.Fatalf("expected the create to succeed on retry, got %v", err)
+	}
+	if got := store.urls[resp.ShortCode]; got == nil || got.LongURL != "https://example.com" {
+		t.Fatalf("expected %s to be saved, got %+v", resp.ShortCode, store.urls)
+	}
+	if len(store.urls) != 1 || len(store.events) != 1 {
+		t.Errorf("expected exactly one link and create event, got %d and %d", len(store.urls), len(store.events))
+	}
+	if !strings.HasSuffix(resp.ShortUrl, "/"+resp.ShortCode) {
+		t.Errorf("expected the short URL to use the code that was saved, got %s", resp.ShortUrl)
+	}
+}
+
+// TestCreateURL_GivesUpAfterRepeatedCollisions verifies that the retries are
+// bounded by the configured number of attempts and end in a retryable
+// ResourceExhausted error with its own reason.
+func TestCreateURL_GivesUpAfterRepeatedCollisions(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		codeTries int
+		attempts  int
+	}{
+		{"default", 0, defaultShortCodeAttempts},
+		{"configured", 5, 5},
+	} {
+		store := newFakeStore()
+		store.collide = tc.attempts
+		s := newAliasTestService(store, nil)
+		s.codeTries = tc.codeTries
+
+		_, err := s.CreateURL(context.Background(), &pb.CreateURLRequest{LongUrl: "https://example.com", UserId: "alice"})
+		if status.Code(err) != codes.ResourceExhausted || errorReason(err) != models.ErrCodeCodesExhausted ||
+			!strings.Contains(err.Error(), "please retry") {
+			t.Fatalf("%s: expected SHORT_CODE_EXHAUSTED, got %v", tc.name, err)
+		}
+		if len(store.urls) != 0 {
+			t.Errorf("%s: expected nothing saved, got %d links", tc.name, len(store.urls))
+		}
+
+		// One collision fewer leaves the last attempt free.
+		store.collide = tc.attempts - 1
+		if _, err := s.CreateURL(context.Background(), &pb.CreateURLRequest{LongUrl: "https://example.com", UserId: "alice"}); err != nil {
+			t.Errorf("%s: expected the last attempt to succeed, got %v", tc.name, err)
+		}
+	}
+}
+
+// withActiveQuota gives s a quota of maxActive links per user and no daily
+// limit, so the enforcer never touches Redis.
+func withActiveQuota(s *URLService, store *fakeStore, maxActive int64) {
+	s.quotas = quota.NewEnforcer(nil, store, nil, quota.Limits{MaxActive: maxActive}, nil)
+}
+
+// TestCreateURL_AtQuota verifies that a user may fill their quota exactly
+// and that anonymous links do not count against anyone's.
+func TestCreateURL_AtQuota(t *testing.T) {
+	store := newFakeStore(&models.URL{ShortCode: "a1", UserID: "alice"})
+	s := newAliasTestService(store, nil)
+	withActiveQuota(s, store, 2)
+	ctx := context.Background()
+
+	if _, err := s.CreateURL(ctx, &pb.CreateURLRequest{LongUrl: "https://example.com", UserId: "alice"}); err != nil {
+		t.Fatalf("expected the link that fills the quota to be created, got %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := s.CreateURL(ctx, &pb.CreateURLRequest{LongUrl: "https://example.com"}); err != nil {
+			t.Fatalf("expected anonymous links to be unlimited, got %v", err)
+		}
+	}
+}
+
+// TestCreateURL_OverQuota verifies that creations past the quota are
+// refused with ResourceExhausted before anything is stored, on both create
+// paths.
+func TestCreateURL_OverQuota(t *testing.T) {
+	store := newFakeStore(
+		&models.URL{ShortCode: "a1", UserID: "alice"},
+		&models.URL{ShortCode: "a2", UserID: "alice"},
+	)
+	s := newAliasTestService(store, nil)
+	withActiveQuota(s, store, 2)
+	ctx := context.Background()
+
+	_, err := s.CreateURL(ctx, &pb.CreateURLRequest{LongUrl: "https://example.com", UserId: "alice"})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+	if !strings.Contains(status.Convert(err).Message(), "at most 2 active links") {
+		t.Errorf("expected the message to name the limit, got %q", status.Convert(err).Message())
+	}
+	if got := errorReason(err); got != models.ErrCodeQuotaExceeded {
+		t.Errorf("expected reason %s, got %q", models.ErrCodeQuotaExceeded, got)
+	}
+
+	_, err = s.CreateCustomURL(ctx, &pb.CreateCustomURLRequest{LongUrl: "https://example.com", Alias: "my-link", UserId: "alice"})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted for a custom alias, got %v", err)
+	}
+	if len(store.urls) != 2 {
+		t.Errorf("expected nothing to be stored, got %d links", len(store.urls))
+	}
+}
+
+// offlineRedis fails every command at once, without dialing.
+type offlineRedis struct{}
+
+func (offlineRedis) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (offlineRedis) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		cmd.SetErr(errors.New("redis offline"))
+		return cmd.Err()
+	}
+}
+
+func (offlineRedis) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+// BenchmarkCreateURL compares the create path with the QR code rendered at
+// creation against the default, where it is left for its first request.
+func BenchmarkCreateURL(b *testing.B) {
+	for _, generateQR := range []bool{true, false} {
+		name := "lazy_qr"
+		if generateQR {
+			name = "eager_qr"
+		}
+		b.Run(name, func(b *testing.B) {
+			s := newAliasTestService(newFakeStore(), bloom.New(b.N+1, 0.01))
+			// Fail cache writes without a network round trip, so the
+			// timings are the create path's own.
+			rc := redis.NewClient(&redis.Options{})
+			rc.AddHook(offlineRedis{})
+			s.cache = cache.NewMultiTierCache(100, rc, time.Minute)
+			ctx := context.Background()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.CreateURL(ctx, &pb.CreateURLRequest{
+					LongUrl:    "https://example.com",
+					UserId:     "alice",
+					GenerateQr: generateQR,
+				}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -2,13 +2,18 @@ package service
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/Varun5711/shorternit/internal/auth"
 	usermodel "github.com/Varun5711/shorternit/internal/models/user"
 	"github.com/Varun5711/shorternit/internal/storage"
+	"github.com/Varun5711/shorternit/internal/validation"
 	pb "github.com/Varun5711/shorternit/proto/user"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 // UserService implements the gRPC UserServiceServer interface, handling user
@@ -21,18 +26,57 @@ import (
 // Passwords are hashed with bcrypt before storage and never leave the server.
 type UserService struct {
 	pb.UnimplementedUserServiceServer
-	userStorage *storage.UserStorage // PostgreSQL-backed user persistence.
-	jwtManager  *auth.JWTManager     // Handles JWT creation and validation.
+	userStorage userStore          // PostgreSQL-backed user persistence.
+	jwtManager  *auth.JWTManager   // Handles JWT creation and validation.
+	loginGuard  *auth.LoginGuard   // Locks out repeated failed logins; nil disables.
+	gateways    *auth.GatewayTrust // Callers whose forwarded client IP is believed; nil trusts none.
+	sessions    sessionTracker     // Records sessions and revokes them; nil disables both.
+}
+
+// userStore is the subset of storage.UserStorage the user service needs.
+// Tests substitute an in-memory implementation.
+type userStore interface {
+	CreateUser(ctx context.Context, req *usermodel.CreateUserRequest, passwordHash string) (*usermodel.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*usermodel.User, error)
+	GetUserByID(ctx context.Context, userID string) (*usermodel.User, error)
+	GetAccess(ctx context.Context, userID string) (role string, disabled bool, err error)
+	SetDisabled(ctx context.Context, userID string, disabled bool) (*usermodel.User, error)
+	UpdateUser(ctx context.Context, userID string, name, email string, defaultTTL *time.Duration) (*usermodel.User, error)
+}
+
+// sessionTracker is the subset of auth.SessionTracker the user service
+// needs. Tests substitute an in-memory implementation.
+type sessionTracker interface {
+	Generation(ctx context.Context, userID string) (int64, error)
+	Record(ctx context.Context, userID string, s auth.Session) error
+	List(ctx context.Context, userID string) ([]auth.Session, error)
+	RevokeAll(ctx context.Context, userID string) (int, error)
 }
 
 // NewUserService creates a UserService with its required dependencies.
-func NewUserService(userStorage *storage.UserStorage, jwtManager *auth.JWTManager) *UserService {
-	return &UserService{
+// loginGuard may be nil, in which case failed logins are not throttled.
+// gateways names the callers allowed to forward the client's IP for the
+// per-IP lockout; every other caller is counted by its own address.
+// sessions may be nil, in which case sessions are neither listed nor
+// revocable.
+func NewUserService(userStorage *storage.UserStorage, jwtManager *auth.JWTManager, loginGuard *auth.LoginGuard, gateways *auth.GatewayTrust, sessions *auth.SessionTracker) *UserService {
+	s := &UserService{
 		userStorage: userStorage,
 		jwtManager:  jwtManager,
+		loginGuard:  loginGuard,
+		gateways:    gateways,
 	}
+	if sessions != nil {
+		s.sessions = sessions
+	}
+	return s
 }
 
+// dummyPasswordHash is a bcrypt hash (at bcrypt.DefaultCost) that Login
+// checks the password against when the email is unknown, so that the
+// response takes as long as for a wrong password.
+const dummyPasswordHash = "$2a$10$z7tgcfluKErTbP.9eFDIMeBoOju04v/eviTyIR1wWNUnfxXAK7N7O"
+
 // Register handles the gRPC Register RPC. The flow is:
 //  1. Validate required fields and enforce a minimum password length (8 chars).
 //  2. Check that no account with the same email exists (read-path query).
@@ -77,7 +121,7 @@ func (s *UserService) Register(ctx context.Context, req *pb.RegisterRequest) (*p
 		return nil, status.Errorf(codes.Internal, "failed to create user: %v", err)
 	}
 
-	token, _, err := s.jwtManager.GenerateToken(user.ID, user.Email)
+	token, _, err := s.issueToken(ctx, user)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to generate token: %v", err)
 	}
@@ -96,7 +140,19 @@ func (s *UserService) Register(ctx context.Context, req *pb.RegisterRequest) (*p
 // success. Both "user not found" and "wrong password" return the same
 // user-facing message ("invalid email or password") to prevent email
 // enumeration attacks, but they use different gRPC status codes (NotFound vs.
-// Unauthenticated) so server-side observability can distinguish the two.
+// Unauthenticated) so server-side observability can distinguish the two. An
+// unknown email is still checked against a dummy hash so both cases take the
+// same time.
+//
+// Failed logins are counted per email and per client IP (forwarded by the
+// gateway in ClientIPMetadataKey metadata, and otherwise the caller's own
+// address; see auth.GatewayTrust). While either is locked out,
+// Login answers ResourceExhausted with a RetryInfo detail before looking the
+// user up, so the lockout looks the same whether or not the account exists.
+//
+// An account an admin has disabled answers PermissionDenied, but only once
+// the password has matched, so the status does not reveal the account to
+// someone guessing.
 func (s *UserService) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
 	if req.Email == "" {
 		return nil, status.Error(codes.InvalidArgument, "email is required")
@@ -105,19 +161,37 @@ func (s *UserService) Login(ctx context.Context, req *pb.LoginRequest) (*pb.Logi
 		return nil, status.Error(codes.InvalidArgument, "password is required")
 	}
 
+	clientIP := s.gateways.ClientIP(ctx)
+	if s.loginGuard != nil {
+		if remaining, err := s.loginGuard.CheckLocked(ctx, req.Email, clientIP); err != nil {
+			return nil, loginLockedError(remaining)
+		}
+	}
+
 	user, err := s.userStorage.GetUserByEmail(ctx, req.Email)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
 	}
 	if user == nil {
+		_ = auth.CheckPassword(dummyPasswordHash, req.Password)
+		s.recordLoginFailure(ctx, req.Email, clientIP)
 		return nil, status.Error(codes.NotFound, "invalid email or password")
 	}
 
 	if err := auth.CheckPassword(user.PasswordHash, req.Password); err != nil {
+		s.recordLoginFailure(ctx, req.Email, clientIP)
 		return nil, status.Error(codes.Unauthenticated, "invalid email or password")
 	}
 
-	token, expiresAt, err := s.jwtManager.GenerateToken(user.ID, user.Email)
+	if s.loginGuard != nil {
+		s.loginGuard.RecordSuccess(ctx, req.Email)
+	}
+
+	if user.DisabledAt != nil {
+		return nil, status.Error(codes.PermissionDenied, "account disabled")
+	}
+
+	token, expiresAt, err := s.issueToken(ctx, user)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to generate token: %v", err)
 	}
@@ -131,6 +205,74 @@ func (s *UserService) Login(ctx context.Context, req *pb.LoginRequest) (*pb.Logi
 	}, nil
 }
 
+// issueToken signs a token for user at their current session generation
+// and records the session with the device and IP the gateway forwarded.
+// Sessions are tracked on a best-effort basis, like login lockouts: if
+// Redis is unavailable the token is issued at generation 0 and is not
+// listed. Should the user have signed out everywhere before, it is then
+// refused once Redis is back, and they sign in again.
+func (s *UserService) issueToken(ctx context.Context, user *usermodel.User) (string, time.Time, error) {
+	var generation int64
+	if s.sessions != nil {
+		generation, _ = s.sessions.Generation(ctx, user.ID)
+	}
+
+	token, claims, err := s.jwtManager.IssueToken(user.ID, user.Email, user.Role, generation)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if s.sessions != nil {
+		_ = s.sessions.Record(ctx, user.ID, auth.Session{
+			ID:         claims.ID,
+			Device:     auth.ClientDevice(ctx),
+			IP:         s.gateways.ClientIP(ctx),
+			IssuedAt:   claims.IssuedAt.Time,
+			ExpiresAt:  claims.ExpiresAt.Time,
+			Generation: generation,
+		})
+	}
+	return token, claims.ExpiresAt.Time, nil
+}
+
+// parseToken validates token as jwtManager.ValidateToken does and also
+// refuses a token its user has since revoked by signing out everywhere.
+// The revocation check fails open: while Redis is unavailable, a token
+// that is otherwise valid is accepted.
+func (s *UserService) parseToken(ctx context.Context, token string) (*auth.Claims, error) {
+	claims, err := s.jwtManager.ValidateToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if s.sessions != nil {
+		generation, err := s.sessions.Generation(ctx, claims.UserID)
+		if err == nil && claims.Generation < generation {
+			return nil, errTokenRevoked
+		}
+	}
+	return claims, nil
+}
+
+// errTokenRevoked is returned by parseToken for a token from an earlier
+// session generation.
+var errTokenRevoked = errors.New("token has been revoked")
+
+func (s *UserService) recordLoginFailure(ctx context.Context, email, clientIP string) {
+	if s.loginGuard != nil {
+		s.loginGuard.RecordFailure(ctx, email, clientIP)
+	}
+}
+
+// loginLockedError is the status Login returns during a lockout. The
+// RetryInfo detail tells the gateway how long to ask the client to wait.
+func loginLockedError(retryAfter time.Duration) error {
+	st := status.New(codes.ResourceExhausted, "too many failed login attempts, try again later")
+	if withRetry, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)}); err == nil {
+		st = withRetry
+	}
+	return st.Err()
+}
+
 // GetProfile handles the gRPC GetProfile RPC. It validates the JWT, extracts
 // the user ID from the token claims, and fetches the full user record from
 // PostgreSQL. This is a token-authenticated endpoint -- the user ID is derived
@@ -141,7 +283,7 @@ func (s *UserService) GetProfile(ctx context.Context, req *pb.GetProfileRequest)
 		return nil, status.Error(codes.InvalidArgument, "token is required")
 	}
 
-	claims, err := s.jwtManager.ValidateToken(req.Token)
+	claims, err := s.parseToken(ctx, req.Token)
 	if err != nil {
 		return nil, status.Error(codes.Unauthenticated, "invalid token")
 	}
@@ -154,65 +296,254 @@ func (s *UserService) GetProfile(ctx context.Context, req *pb.GetProfileRequest)
 		return nil, status.Error(codes.NotFound, "user not found")
 	}
 
-	return &pb.GetProfileResponse{
-		User: &pb.User{
-			Id:        user.ID,
-			Email:     user.Email,
-			Name:      user.Name,
-			CreatedAt: user.CreatedAt.Unix(),
-			UpdatedAt: user.UpdatedAt.Unix(),
-		},
-	}, nil
+	return &pb.GetProfileResponse{User: userToPB(user)}, nil
 }
 
 // UpdateProfile handles the gRPC UpdateProfile RPC. Like GetProfile, it
 // extracts the user ID from the JWT so a user can only modify their own
-// profile. The updated name and email are written to PostgreSQL and the
-// refreshed record is returned.
+// profile. An empty name, email or default_url_ttl keeps the current one.
+// The updated fields are written to PostgreSQL and the refreshed record is
+// returned; an email another account already uses is AlreadyExists. An
+// update that changes nothing returns the current record without writing,
+// so updated_at keeps recording the last real change.
 func (s *UserService) UpdateProfile(ctx context.Context, req *pb.UpdateProfileRequest) (*pb.UpdateProfileResponse, error) {
 	if req.Token == "" {
 		return nil, status.Error(codes.InvalidArgument, "token is required")
 	}
+	if req.Name == "" && req.Email == "" && req.DefaultUrlTtl == "" {
+		return nil, status.Error(codes.InvalidArgument, "name, email or default_url_ttl is required")
+	}
+	if req.Email != "" {
+		if err := validation.ValidateEmail(req.Email); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	var newTTL *time.Duration
+	if req.DefaultUrlTtl != "" {
+		var err error
+		if newTTL, err = validation.ParseDefaultURLTTL(req.DefaultUrlTtl); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
 
-	claims, err := s.jwtManager.ValidateToken(req.Token)
+	claims, err := s.parseToken(ctx, req.Token)
 	if err != nil {
 		return nil, status.Error(codes.Unauthenticated, "invalid token")
 	}
 
-	user, err := s.userStorage.UpdateUser(ctx, claims.UserID, req.Name, req.Email)
+	current, err := s.userStorage.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+	if current == nil {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	name, email := req.Name, req.Email
+	if name == "" {
+		name = current.Name
+	}
+	if email == "" {
+		email = current.Email
+	}
+	defaultTTL := current.DefaultURLTTL
+	if req.DefaultUrlTtl != "" {
+		defaultTTL = newTTL
+	}
+	if name == current.Name && email == current.Email && sameTTL(defaultTTL, current.DefaultURLTTL) {
+		return &pb.UpdateProfileResponse{User: userToPB(current)}, nil
+	}
+
+	user, err := s.userStorage.UpdateUser(ctx, claims.UserID, name, email, defaultTTL)
+	if errors.Is(err, storage.ErrEmailTaken) {
+		return nil, status.Error(codes.AlreadyExists, "user with this email already exists")
+	}
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to update user: %v", err)
 	}
+	if user == nil {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
 
-	return &pb.UpdateProfileResponse{
-		User: &pb.User{
-			Id:        user.ID,
-			Email:     user.Email,
-			Name:      user.Name,
-			CreatedAt: user.CreatedAt.Unix(),
-			UpdatedAt: user.UpdatedAt.Unix(),
-		},
-	}, nil
+	return &pb.UpdateProfileResponse{User: userToPB(user)}, nil
 }
 
 // ValidateToken handles the gRPC ValidateToken RPC. It is a lightweight
-// stateless check -- no database call is made. The JWT signature and expiry
-// are verified, and if valid, the embedded user ID and expiration are returned.
-// Invalid or expired tokens return Valid=false with no gRPC error, allowing
-// the API gateway to distinguish "bad token" from "server error".
+// check -- no database call is made. The JWT signature and expiry are
+// verified, as is that the user has not signed out everywhere since the
+// token was issued (one Redis read), and if valid, the embedded user ID,
+// role and expiration are returned. Invalid or expired tokens return
+// Valid=false with no gRPC error, allowing the API gateway to distinguish
+// "bad token" from "server error".
+//
+// With Recheck set, the role and disabled status are read from the primary
+// instead of the claims, for sensitive actions that must see a demotion or a
+// disabled account before the token expires. A token whose account has been
+// disabled or deleted is then Valid=false.
 func (s *UserService) ValidateToken(ctx context.Context, req *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
 	if req.Token == "" {
 		return &pb.ValidateTokenResponse{Valid: false}, nil
 	}
 
-	claims, err := s.jwtManager.ValidateToken(req.Token)
+	claims, err := s.parseToken(ctx, req.Token)
 	if err != nil {
 		return &pb.ValidateTokenResponse{Valid: false}, nil
 	}
 
+	role := claimedRole(claims)
+	if req.Recheck {
+		current, disabled, err := s.userStorage.GetAccess(ctx, claims.UserID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to check user: %v", err)
+		}
+		if current == "" || disabled {
+			return &pb.ValidateTokenResponse{Valid: false}, nil
+		}
+		role = current
+	}
+
 	return &pb.ValidateTokenResponse{
 		Valid:     true,
 		UserId:    claims.UserID,
 		ExpiresAt: claims.ExpiresAt.Unix(),
+		Role:      role,
 	}, nil
 }
+
+// SetUserDisabled handles the gRPC SetUserDisabled RPC, with which an admin
+// disables an abusive account or re-enables it. The caller's admin role is
+// re-checked against the database rather than taken from the token, so a
+// demoted admin cannot keep using it. Admins cannot disable themselves,
+// which would lock the last admin out. A disabled account cannot log in and
+// its tokens fail every re-checked validation; tokens it already holds keep
+// working elsewhere until they expire.
+func (s *UserService) SetUserDisabled(ctx context.Context, req *pb.SetUserDisabledRequest) (*pb.SetUserDisabledResponse, error) {
+	if req.Token == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+	if req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	claims, err := s.parseToken(ctx, req.Token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	role, disabled, err := s.userStorage.GetAccess(ctx, claims.UserID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to check user: %v", err)
+	}
+	if role == "" || disabled {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	if role != usermodel.RoleAdmin {
+		return nil, status.Error(codes.PermissionDenied, "admin role required")
+	}
+	if req.Disabled && req.UserId == claims.UserID {
+		return nil, status.Error(codes.InvalidArgument, "cannot disable your own account")
+	}
+
+	user, err := s.userStorage.SetDisabled(ctx, req.UserId, req.Disabled)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to update user: %v", err)
+	}
+	if user == nil {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	return &pb.SetUserDisabledResponse{User: userToPB(user)}, nil
+}
+
+// ListSessions handles the gRPC ListSessions RPC, listing the sessions of
+// the token's user that have neither expired nor been revoked, newest
+// first. The session of the token itself is marked current.
+func (s *UserService) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	claims, err := s.sessionClaims(ctx, req.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.sessions.List(ctx, claims.UserID)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to list sessions: %v", err)
+	}
+
+	resp := &pb.ListSessionsResponse{Sessions: make([]*pb.Session, 0, len(sessions))}
+	for _, session := range sessions {
+		resp.Sessions = append(resp.Sessions, &pb.Session{
+			Id:        session.ID,
+			Device:    session.Device,
+			IpAddress: session.IP,
+			IssuedAt:  session.IssuedAt.Unix(),
+			ExpiresAt: session.ExpiresAt.Unix(),
+			Current:   session.ID == claims.ID,
+		})
+	}
+	return resp, nil
+}
+
+// RevokeAllSessions handles the gRPC RevokeAllSessions RPC, signing the
+// token's user out everywhere. Every token issued to them so far, the one
+// in the request included, is refused from then on; tokens issued by
+// later logins work as usual.
+func (s *UserService) RevokeAllSessions(ctx context.Context, req *pb.RevokeAllSessionsRequest) (*pb.RevokeAllSessionsResponse, error) {
+	claims, err := s.sessionClaims(ctx, req.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := s.sessions.RevokeAll(ctx, claims.UserID)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to revoke sessions: %v", err)
+	}
+	return &pb.RevokeAllSessionsResponse{Revoked: int32(revoked)}, nil
+}
+
+// sessionClaims validates the token of a session RPC.
+func (s *UserService) sessionClaims(ctx context.Context, token string) (*auth.Claims, error) {
+	if s.sessions == nil {
+		return nil, status.Error(codes.Unimplemented, "sessions are not tracked")
+	}
+	if token == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+	claims, err := s.parseToken(ctx, token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	return claims, nil
+}
+
+// claimedRole is the role a token was issued with. Tokens issued before
+// roles existed carry none, and belong to ordinary users.
+func claimedRole(claims *auth.Claims) string {
+	if claims.Role == "" {
+		return usermodel.RoleUser
+	}
+	return claims.Role
+}
+
+// sameTTL reports whether two default link lifetimes are equal, nil
+// meaning the global default.
+func sameTTL(a, b *time.Duration) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// userToPB maps a stored user to its protobuf message.
+func userToPB(user *usermodel.User) *pb.User {
+	u := &pb.User{
+		Id:            user.ID,
+		Email:         user.Email,
+		Name:          user.Name,
+		Role:          user.Role,
+		CreatedAt:     user.CreatedAt.Unix(),
+		UpdatedAt:     user.UpdatedAt.Unix(),
+		DefaultUrlTtl: validation.FormatDefaultURLTTL(user.DefaultURLTTL),
+	}
+	if user.DisabledAt != nil {
+		u.DisabledAt = user.DisabledAt.Unix()
+	}
+	return u
+}
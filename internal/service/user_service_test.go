@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/auth"
+	usermodel "github.com/Varun5711/shorternit/internal/models/user"
+	"github.com/Varun5711/shorternit/internal/storage"
+	pb "github.com/Varun5711/shorternit/proto/user"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TestDummyPasswordHashCost verifies that the hash checked for unknown
+// emails costs as much to compare as a real password hash.
+func TestDummyPasswordHashCost(t *testing.T) {
+	cost, err := bcrypt.Cost([]byte(dummyPasswordHash))
+	if err != nil {
+		t.Fatalf("dummy hash is not a bcrypt hash: %v", err)
+	}
+	if cost != bcrypt.DefaultCost {
+		t.Errorf("expected cost %d to match HashPassword, got %d", bcrypt.DefaultCost, cost)
+	}
+}
+
+func TestLoginLockedError_CarriesRetryInfo(t *testing.T) {
+	st, _ := status.FromError(loginLockedError(90 * time.Second))
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", st.Code())
+	}
+
+	var retry *errdetails.RetryInfo
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retry = info
+		}
+	}
+	if retry == nil {
+		t.Fatal("expected a RetryInfo detail")
+	}
+	if got := retry.GetRetryDelay().AsDuration(); got != 90*time.Second {
+		t.Errorf("expected a 90s retry delay, got %v", got)
+	}
+}
+
+// TestClaimedRole_DefaultsToUser verifies that a token issued before roles
+// existed is treated as an ordinary user's, never an admin's.
+func TestClaimedRole_DefaultsToUser(t *testing.T) {
+	if got := claimedRole(&auth.Claims{UserID: "u1"}); got != "user" {
+		t.Errorf("expected a role-less token to be a user's, got %q", got)
+	}
+	if got := claimedRole(&auth.Claims{UserID: "a1", Role: "admin"}); got != "admin" {
+		t.Errorf("expected the claimed role, got %q", got)
+	}
+}
+
+// fakeUserStore is an in-memory userStore keyed by user ID. Only the methods
+// UpdateProfile uses are implemented.
+type fakeUserStore struct {
+	userStore
+	users   map[string]*usermodel.User
+	updates int
+}
+
+func (f *fakeUserStore) GetUserByID(ctx context.Context, userID string) (*usermodel.User, error) {
+	u, ok := f.users[userID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *u
+	return &copied, nil
+}
+
+// UpdateUser mirrors UserStorage: an email held by another user is
+// ErrEmailTaken, and every write bumps updated_at.
+func (f *fakeUserStore) UpdateUser(ctx context.Context, userID string, name, email string, defaultTTL *time.Duration) (*usermodel.User, error) {
+	f.updates++
+	for id, u := range f.users {
+		if id != userID && u.Email == email {
+			return nil, storage.ErrEmailTaken
+		}
+	}
+	u, ok := f.users[userID]
+	if !ok {
+		return nil, nil
+	}
+	u.Name, u.Email, u.DefaultURLTTL, u.UpdatedAt = name, email, defaultTTL, time.Now()
+	copied := *u
+	return &copied, nil
+}
+
+// newProfileTestService returns a UserService over two users, alice and
+// bob, and a token for alice.
+func newProfileTestService(t *testing.T) (*UserService, *fakeUserStore, string) {
+	t.Helper()
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := &fakeUserStore{users: map[string]*usermodel.User{
+		"alice": {ID: "alice", Name: "Alice", Email: "alice@example.com", CreatedAt: created, UpdatedAt: created},
+		"bob":   {ID: "bob", Name: "Bob", Email: "bob@example.com", CreatedAt: created, UpdatedAt: created},
+	}}
+	jwt := auth.NewJWTManager("test-secret", time.Hour)
+	token, _, err := jwt.GenerateToken("alice", "alice@example.com", usermodel.RoleUser)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	return &UserService{userStorage: store, jwtManager: jwt}, store, token
+}
+
+func TestUpdateProfile_UpdatesGivenFields(t *testing.T) {
+	s, store, token := newProfileTestService(t)
+
+	resp, err := s.UpdateProfile(context.Background(), &pb.UpdateProfileRequest{Token: token, Email: "alice@new.example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.User.Name != "Alice" || resp.User.Email != "alice@new.example.com" {
+		t.Errorf("expected only the email to change, got %+v", resp.User)
+	}
+	if store.users["alice"].Email != "alice@new.example.com" {
+		t.Error("expected the new email to be stored")
+	}
+}
+
+func TestUpdateProfile_EmailConflict(t *testing.T) {
+	s, store, token := newProfileTestService(t)
+
+	_, err := s.UpdateProfile(context.Background(), &pb.UpdateProfileRequest{Token: token, Email: "bob@example.com"})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Errorf("expected AlreadyExists, got %v", err)
+	}
+	if store.users["alice"].Email != "alice@example.com" {
+		t.Error("expected the email to be left unchanged")
+	}
+}
+
+func TestUpdateProfile_RejectsInvalidRequests(t *testing.T) {
+	s, store, token := newProfileTestService(t)
+
+	for _, req := range []*pb.UpdateProfileRequest{
+		{Token: token, Email: "not-an-email"},
+		{Token: token, Email: "alice@example"},
+		{Token: token, DefaultUrlTtl: "30s"},
+		{Token: token},
+		{Name: "Alice"},
+	} {
+		if _, err := s.UpdateProfile(context.Background(), req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%+v: expected InvalidArgument, got %v", req, err)
+		}
+	}
+	if store.updates != 0 {
+		t.Errorf("expected no writes, got %d", store.updates)
+	}
+}
+
+// TestUpdateProfile_NoOpKeepsUpdatedAt verifies that re-submitting the
+// current name and email does not write, so updated_at is unchanged.
+func TestUpdateProfile_NoOpKeepsUpdatedAt(t *testing.T) {
+	s, store, token := newProfileTestService(t)
+	before := store.users["alice"].UpdatedAt
+
+	resp, err := s.UpdateProfile(context.Background(), &pb.UpdateProfileRequest{Token: token, Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.updates != 0 {
+		t.Errorf("expected no write for an unchanged profile, got %d", store.updates)
+	}
+	if resp.User.UpdatedAt != before.Unix() {
+		t.Errorf("expected updated_at %d, got %d", before.Unix(), resp.User.UpdatedAt)
+	}
+}
+
+// TestUpdateProfile_DefaultURLTTL verifies that a user can choose their own
+// default link lifetime, switch to never expiring and back to the global
+// default, while name and email are kept.
+func TestUpdateProfile_DefaultURLTTL(t *testing.T) {
+	s, store, token := newProfileTestService(t)
+
+	for _, tc := range []struct{ value, want string }{
+		{"168h", "168h0m0s"},
+		{"never", "never"},
+		{"default", ""},
+	} {
+		resp, err := s.UpdateProfile(context.Background(), &pb.UpdateProfileRequest{Token: token, DefaultUrlTtl: tc.value})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.value, err)
+		}
+		if resp.User.DefaultUrlTtl != tc.want || resp.User.Name != "Alice" || resp.User.Email != "alice@example.com" {
+			t.Errorf("%s: expected default_url_ttl %q with the name and email kept, got %+v", tc.value, tc.want, resp.User)
+		}
+	}
+	if store.updates != 3 {
+		t.Errorf("expected 3 writes, got %d", store.updates)
+	}
+}
+
+func (f *fakeUserStore) GetUserByEmail(ctx context.Context, email string) (*usermodel.User, error) {
+	for _, u := range f.users {
+		if u.Email == email {
+			copied := *u
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+// fakeSessionTracker is an in-memory sessionTracker with the semantics of
+// auth.SessionTracker.
+type fakeSessionTracker struct {
+	generations map[string]int64
+	sessions    map[string][]auth.Session
+}
+
+func newFakeSessionTracker() *fakeSessionTracker {
+	return &fakeSessionTracker{generations: map[string]int64{}, sessions: map[string][]auth.Session{}}
+}
+
+func (f *fakeSessionTracker) Generation(ctx context.Context, userID string) (int64, error) {
+	return f.generations[userID], nil
+}
+
+func (f *fakeSessionTracker) Record(ctx context.Context, userID string, s auth.Session) error {
+	f.sessions[userID] = append(f.sessions[userID], s)
+	return nil
+}
+
+func (f *fakeSessionTracker) List(ctx context.Context, userID string) ([]auth.Session, error) {
+	var live []auth.Session
+	for _, s := range f.sessions[userID] {
+		if s.Generation >= f.generations[userID] && s.ExpiresAt.After(time.Now()) {
+			live = append([]auth.Session{s}, live...)
+		}
+	}
+	return live, nil
+}
+
+func (f *fakeSessionTracker) RevokeAll(ctx context.Context, userID string) (int, error) {
+	live, _ := f.List(ctx, userID)
+	f.generations[userID]++
+	delete(f.sessions, userID)
+	return len(live), nil
+}
+
+// newSessionTestService returns a UserService tracking sessions, over one
+// user, alice, whose password is "correct horse".
+func newSessionTestService(t *testing.T) *UserService {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("GenerateFromPassword: %v", err)
+	}
+	store := &fakeUserStore{users: map[string]*usermodel.User{
+		"alice": {ID: "alice", Name: "Alice", Email: "alice@example.com", Role: usermodel.RoleUser, PasswordHash: string(hash)},
+	}}
+	return &UserService{
+		userStorage: store,
+		jwtManager:  auth.NewJWTManager("test-secret", time.Hour),
+		sessions:    newFakeSessionTracker(),
+	}
+}
+
+func login(t *testing.T, s *UserService, device string) string {
+	t.Helper()
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(auth.ClientDeviceMetadataKey, device))
+	resp, err := s.Login(ctx, &pb.LoginRequest{Email: "alice@example.com", Password: "correct horse"})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	return resp.Token
+}
+
+func valid(t *testing.T, s *UserService, token string) bool {
+	t.Helper()
+	resp, err := s.ValidateToken(context.Background(), &pb.ValidateTokenRequest{Token: token})
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	return resp.Valid
+}
+
+func TestListSessions_MarksCurrent(t *testing.T) {
+	s := newSessionTestService(t)
+	login(t, s, "laptop")
+	phone := login(t, s, "phone")
+
+	resp, err := s.ListSessions(context.Background(), &pb.ListSessionsRequest{Token: phone})
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(resp.Sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %+v", resp.Sessions)
+	}
+	for _, session := range resp.Sessions {
+		if session.Current != (session.Device == "phone") {
+			t.Errorf("expected only the phone session to be current, got %+v", session)
+		}
+		if session.Id == "" || session.IssuedAt == 0 || session.ExpiresAt <= session.IssuedAt {
+			t.Errorf("expected an ID and issue and expiry times, got %+v", session)
+		}
+	}
+}
+
+// TestRevokeAllSessions_InvalidatesEarlierTokens verifies that signing out
+// everywhere refuses every token issued before, the caller's included,
+// while a token from a later login works.
+func TestRevokeAllSessions_InvalidatesEarlierTokens(t *testing.T) {
+	s := newSessionTestService(t)
+	ctx := context.Background()
+	laptop := login(t, s, "laptop")
+	phone := login(t, s, "phone")
+
+	resp, err := s.RevokeAllSessions(ctx, &pb.RevokeAllSessionsRequest{Token: phone})
+	if err != nil {
+		t.Fatalf("RevokeAllSessions: %v", err)
+	}
+	if resp.Revoked != 2 {
+		t.Errorf("expected 2 sessions revoked, got %d", resp.Revoked)
+	}
+
+	for name, token := range map[string]string{"laptop": laptop, "phone": phone} {
+		if valid(t, s, token) {
+			t.Errorf("expected the %s token to be refused after revoke-all", name)
+		}
+	}
+	_, err = s.GetProfile(ctx, &pb.GetProfileRequest{Token: laptop})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected GetProfile with a revoked token to be Unauthenticated, got %v", err)
+	}
+	_, err = s.ListSessions(ctx, &pb.ListSessionsRequest{Token: phone})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected ListSessions with a revoked token to be Unauthenticated, got %v", err)
+	}
+
+	fresh := login(t, s, "laptop")
+	if !valid(t, s, fresh) {
+		t.Fatal("expected a token issued after revoke-all to be valid")
+	}
+	sessions, err := s.ListSessions(ctx, &pb.ListSessionsRequest{Token: fresh})
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(sessions.Sessions) != 1 || !sessions.Sessions[0].Current {
+		t.Errorf("expected only the new session, got %+v", sessions.Sessions)
+	}
+}
+
+func TestSessionRPCs_WithoutTracking(t *testing.T) {
+	s, _, token := newProfileTestService(t)
+	_, err := s.ListSessions(context.Background(), &pb.ListSessionsRequest{Token: token})
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("expected Unimplemented without a session tracker, got %v", err)
+	}
+	if !valid(t, s, token) {
+		t.Error("expected tokens to validate without a session tracker")
+	}
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/Varun5711/shorternit/internal/storage"
+	"github.com/Varun5711/shorternit/internal/webhook"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxWebhooksPerLink caps how many webhooks one short code can carry so a
+// single popular link cannot fan every click out to an unbounded number of
+// receivers.
+const maxWebhooksPerLink = 10
+
+// RegisterWebhook handles the gRPC RegisterWebhook RPC. Only the owner of a
+// short link may attach webhooks to it. A random signing secret is generated
+// server-side and returned exactly once in the response; it is never exposed
+// by ListWebhooks.
+func (s *URLService) RegisterWebhook(ctx context.Context, req *pb.RegisterWebhookRequest) (*pb.RegisterWebhookResponse, error) {
+	if s.webhooks == nil {
+		return nil, status.Error(codes.Unimplemented, "webhooks are not enabled")
+	}
+	if req.ShortCode == "" {
+		return nil, status.Error(codes.InvalidArgument, "short_code is required")
+	}
+	if req.UserId == "" {
+		return nil, status.Error(codes.Unauthenticated, "user_id is required")
+	}
+	if err := webhook.ValidateTargetURL(ctx, req.TargetUrl); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid target_url: %v", err)
+	}
+	if req.ClickThreshold < 0 || req.RateLimitPerMinute < 0 {
+		return nil, status.Error(codes.InvalidArgument, "click_threshold and rate_limit_per_minute must not be negative")
+	}
+
+	if err := s.checkOwnership(ctx, req.ShortCode, req.UserId); err != nil {
+		return nil, err
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate secret: %v", err)
+	}
+
+	threshold := req.ClickThreshold
+	if threshold == 0 {
+		threshold = 1
+	}
+	rateLimit := req.RateLimitPerMinute
+	if rateLimit == 0 {
+		rateLimit = 60
+	}
+
+	wh, err := s.webhooks.CreateWebhook(ctx, &models.Webhook{
+		ShortCode:          req.ShortCode,
+		UserID:             req.UserId,
+		TargetURL:          req.TargetUrl,
+		Secret:             secret,
+		ClickThreshold:     threshold,
+		RateLimitPerMinute: rateLimit,
+	}, maxWebhooksPerLink)
+	if errors.Is(err, storage.ErrWebhookLimitReached) {
+		return nil, status.Errorf(codes.ResourceExhausted, "a link can have at most %d webhooks", maxWebhooksPerLink)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to register webhook: %v", err)
+	}
+
+	return &pb.RegisterWebhookResponse{
+		Webhook: webhookToProto(wh),
+		Secret:  secret,
+	}, nil
+}
+
+// ListWebhooks handles the gRPC ListWebhooks RPC, returning the caller's
+// webhooks on a short link including their failure count and disabled flag
+// so the owner can see when a receiver has been switched off.
+func (s *URLService) ListWebhooks(ctx context.Context, req *pb.ListWebhooksRequest) (*pb.ListWebhooksResponse, error) {
+	if s.webhooks == nil {
+		return nil, status.Error(codes.Unimplemented, "webhooks are not enabled")
+	}
+	if req.ShortCode == "" {
+		return nil, status.Error(codes.InvalidArgument, "short_code is required")
+	}
+	if req.UserId == "" {
+		return nil, status.Error(codes.Unauthenticated, "user_id is required")
+	}
+
+	webhooks, err := s.webhooks.ListWebhooks(ctx, req.ShortCode, req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list webhooks: %v", err)
+	}
+
+	pbWebhooks := make([]*pb.Webhook, len(webhooks))
+	for i, wh := range webhooks {
+		pbWebhooks[i] = webhookToProto(wh)
+	}
+
+	return &pb.ListWebhooksResponse{Webhooks: pbWebhooks}, nil
+}
+
+// DeleteWebhook handles the gRPC DeleteWebhook RPC. The delete is scoped to
+// the caller's user ID, so a webhook that exists but belongs to someone else
+// is reported as NotFound rather than PermissionDenied to avoid confirming
+// its existence. The webhook's click threshold counter is dropped with it.
+func (s *URLService) DeleteWebhook(ctx context.Context, req *pb.DeleteWebhookRequest) (*pb.DeleteWebhookResponse, error) {
+	if s.webhooks == nil {
+		return nil, status.Error(codes.Unimplemented, "webhooks are not enabled")
+	}
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	if req.UserId == "" {
+		return nil, status.Error(codes.Unauthenticated, "user_id is required")
+	}
+
+	deleted, err := s.webhooks.DeleteWebhook(ctx, req.Id, req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to delete webhook: %v", err)
+	}
+	if !deleted {
+		return nil, status.Error(codes.NotFound, "webhook not found")
+	}
+
+	if s.redisClient != nil {
+		_ = s.redisClient.Del(ctx, webhook.ClicksKey(req.Id)).Err()
+	}
+
+	return &pb.DeleteWebhookResponse{Success: true}, nil
+}
+
+// checkOwnership returns NotFound if the short code does not exist and
+// PermissionDenied if it belongs to a different user.
+func (s *URLService) checkOwnership(ctx context.Context, shortCode, userID string) error {
+	u, err := s.store.GetByShortCode(ctx, shortCode)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to get URL: %v", err)
+	}
+	if u == nil {
+		return urlNotFoundError("short code not found")
+	}
+	if u.UserID != userID {
+		return status.Error(codes.PermissionDenied, "you do not own this short code")
+	}
+	return nil
+}
+
+// webhookToProto maps a Webhook domain model to its protobuf form. The
+// signing secret is deliberately omitted.
+func webhookToProto(wh *models.Webhook) *pb.Webhook {
+	var lastDelivered int64
+	if wh.LastDeliveredAt != nil {
+		lastDelivered = wh.LastDeliveredAt.Unix()
+	}
+	return &pb.Webhook{
+		Id:                 wh.ID,
+		ShortCode:          wh.ShortCode,
+		TargetUrl:          wh.TargetURL,
+		ClickThreshold:     wh.ClickThreshold,
+		RateLimitPerMinute: wh.RateLimitPerMinute,
+		FailureCount:       wh.FailureCount,
+		Disabled:           wh.Disabled,
+		CreatedAt:          wh.CreatedAt.Unix(),
+		LastDeliveredAt:    lastDelivered,
+	}
+}
+
+// generateWebhookSecret returns 32 random bytes hex-encoded, used as the
+// HMAC-SHA256 key for signing deliveries.
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
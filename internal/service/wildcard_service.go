@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/Varun5711/shorternit/internal/storage"
+	"github.com/Varun5711/shorternit/internal/validation"
+	"github.com/Varun5711/shorternit/internal/wildcard"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// wildcardStore is the subset of storage.WildcardStorage the URL service
+// needs. Tests substitute an in-memory implementation.
+type wildcardStore interface {
+	CreateWildcardRule(ctx context.Context, r *models.WildcardRule) (*models.WildcardRule, error)
+	ListWildcardRules(ctx context.Context, userID string) ([]*models.WildcardRule, error)
+	ListDomainWildcardRules(ctx context.Context, domain string) ([]*models.WildcardRule, error)
+	DeleteWildcardRule(ctx context.Context, domain, prefix, userID string) (bool, error)
+}
+
+// CreateWildcardRule handles the gRPC CreateWildcardRule RPC. It claims the
+// prefix of a pattern such as "promo-*" for the caller on the default or one
+// of their verified custom domains (see validation.ParseWildcardPattern for
+// what a pattern may be). The destination is checked like a link's. A
+// prefix overlapping another user's rule on the domain is AlreadyExists;
+// claiming one the caller already holds replaces it.
+func (s *URLService) CreateWildcardRule(ctx context.Context, req *pb.CreateWildcardRuleRequest) (*pb.CreateWildcardRuleResponse, error) {
+	if s.wildcards == nil {
+		return nil, status.Error(codes.Unimplemented, "wildcard aliases are not enabled")
+	}
+	if req.UserId == "" {
+		return nil, status.Error(codes.Unauthenticated, "user_id is required")
+	}
+	prefix, err := validation.ParseWildcardPattern(req.Pattern)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	mode, param := req.SuffixMode, req.QueryParam
+	if mode == "" {
+		mode = wildcard.SuffixPath
+	}
+	if err := wildcard.CheckSuffixMode(mode); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	switch {
+	case mode == wildcard.SuffixPath:
+		param = ""
+	case param == "":
+		param = wildcard.DefaultQueryParam
+	default:
+		if err := validation.ValidateQueryParam(param); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	if err := s.validateDestinations(ctx, req.Destination); err != nil {
+		return nil, err
+	}
+	domain, err := s.resolveLinkDomain(ctx, req.Domain, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := s.wildcards.CreateWildcardRule(ctx, &models.WildcardRule{
+		Domain:      domain,
+		Prefix:      prefix,
+		UserID:      req.UserId,
+		Destination: req.Destination,
+		SuffixMode:  mode,
+		QueryParam:  param,
+	})
+	if errors.Is(err, storage.ErrWildcardTaken) {
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create wildcard rule: %v", err)
+	}
+	return &pb.CreateWildcardRuleResponse{Rule: wildcardRuleToProto(rule)}, nil
+}
+
+// ListWildcardRules handles the gRPC ListWildcardRules RPC, returning the
+// caller's rules on every domain.
+func (s *URLService) ListWildcardRules(ctx context.Context, req *pb.ListWildcardRulesRequest) (*pb.ListWildcardRulesResponse, error) {
+	if s.wildcards == nil {
+		return nil, status.Error(codes.Unimplemented, "wildcard aliases are not enabled")
+	}
+	if req.UserId == "" {
+		return nil, status.Error(codes.Unauthenticated, "user_id is required")
+	}
+
+	rules, err := s.wildcards.ListWildcardRules(ctx, req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list wildcard rules: %v", err)
+	}
+	return &pb.ListWildcardRulesResponse{Rules: wildcardRulesToProto(rules)}, nil
+}
+
+// DeleteWildcardRule handles the gRPC DeleteWildcardRule RPC. A rule that
+// does not exist or is someone else's is NotFound. The redirect service
+// keeps resolving through it until its cached copy of the domain's rules
+// expires.
+func (s *URLService) DeleteWildcardRule(ctx context.Context, req *pb.DeleteWildcardRuleRequest) (*pb.DeleteWildcardRuleResponse, error) {
+	if s.wildcards == nil {
+		return nil, status.Error(codes.Unimplemented, "wildcard aliases are not enabled")
+	}
+	if req.UserId == "" {
+		return nil, status.Error(codes.Unauthenticated, "user_id is required")
+	}
+	domain, err := s.ruleDomain(req.Domain)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := s.wildcards.DeleteWildcardRule(ctx, domain, req.Prefix, req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to delete wildcard rule: %v", err)
+	}
+	if !deleted {
+		return nil, status.Error(codes.NotFound, "wildcard rule not found")
+	}
+	return &pb.DeleteWildcardRuleResponse{Deleted: true}, nil
+}
+
+// GetWildcardRules handles the gRPC GetWildcardRules RPC, returning every
+// rule on a domain for the redirect service to match codes against. Without
+// wildcard storage there are none.
+func (s *URLService) GetWildcardRules(ctx context.Context, req *pb.GetWildcardRulesRequest) (*pb.GetWildcardRulesResponse, error) {
+	if s.wildcards == nil {
+		return &pb.GetWildcardRulesResponse{}, nil
+	}
+	domain, err := s.ruleDomain(req.Domain)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.wildcards.ListDomainWildcardRules(ctx, domain)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get wildcard rules: %v", err)
+	}
+	return &pb.GetWildcardRulesResponse{Rules: wildcardRulesToProto(rules)}, nil
+}
+
+// ruleDomain normalizes the domain of a rule being looked up, "" or the
+// default domain itself yielding "".
+func (s *URLService) ruleDomain(domain string) (string, error) {
+	if domain == "" {
+		return "", nil
+	}
+	domain, err := validation.NormalizeDomain(domain)
+	if err != nil {
+		return "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	if domain == s.defaultDomain() {
+		return "", nil
+	}
+	return domain, nil
+}
+
+// wildcardRuleToProto maps a WildcardRule model to its protobuf form.
+func wildcardRuleToProto(r *models.WildcardRule) *pb.WildcardRule {
+	return &pb.WildcardRule{
+		Domain:      r.Domain,
+		Prefix:      r.Prefix,
+		Destination: r.Destination,
+		SuffixMode:  r.SuffixMode,
+		QueryParam:  r.QueryParam,
+		CreatedAt:   r.CreatedAt.Unix(),
+	}
+}
+
+func wildcardRulesToProto(rules []*models.WildcardRule) []*pb.WildcardRule {
+	out := make([]*pb.WildcardRule, len(rules))
+	for i, r := range rules {
+		out[i] = wildcardRuleToProto(r)
+	}
+	return out
+}
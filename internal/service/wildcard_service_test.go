@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/Varun5711/shorternit/internal/storage"
+	"github.com/Varun5711/shorternit/internal/wildcard"
+	pb "github.com/Varun5711/shorternit/proto/url"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeWildcardStore is an in-memory wildcardStore that refuses overlapping
+// rules of different users as the PostgreSQL query does.
+type fakeWildcardStore struct {
+	rules []*models.WildcardRule
+}
+
+func (f *fakeWildcardStore) CreateWildcardRule(ctx context.Context, r *models.WildcardRule) (*models.WildcardRule, error) {
+	for i, existing := range f.rules {
+		if existing.Domain != r.Domain || !wildcard.Overlap(existing.Prefix, r.Prefix) {
+			continue
+		}
+		if existing.UserID != r.UserID {
+			return nil, storage.ErrWildcardTaken
+		}
+		if existing.Prefix == r.Prefix {
+			f.rules[i] = r
+			return r, nil
+		}
+	}
+	f.rules = append(f.rules, r)
+	return r, nil
+}
+
+func (f *fakeWildcardStore) ListWildcardRules(ctx context.Context, userID string) ([]*models.WildcardRule, error) {
+	var out []*models.WildcardRule
+	for _, r := range f.rules {
+		if r.UserID == userID {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeWildcardStore) ListDomainWildcardRules(ctx context.Context, domain string) ([]*models.WildcardRule, error) {
+	var out []*models.WildcardRule
+	for _, r := range f.rules {
+		if r.Domain == domain {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeWildcardStore) DeleteWildcardRule(ctx context.Context, domain, prefix, userID string) (bool, error) {
+	for i, r := range f.rules {
+		if r.Domain == domain && r.Prefix == prefix && r.UserID == userID {
+			f.rules = append(f.rules[:i], f.rules[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func newWildcardTestService() (*URLService, *fakeWildcardStore) {
+	rules := &fakeWildcardStore{}
+	s := newAliasTestService(newFakeStore(), nil)
+	s.wildcards = rules
+	s.domains = newFakeDomainStore(&models.Domain{Domain: "go.acme.com", UserID: "alice", Verified: true})
+	return s, rules
+}
+
+// TestCreateWildcardRule verifies the defaults of a new rule, that it can
+// be scoped to the caller's domain, and that another user cannot claim an
+// overlapping prefix.
+func TestCreateWildcardRule(t *testing.T) {
+	s, rules := newWildcardTestService()
+	ctx := context.Background()
+
+	resp, err := s.CreateWildcardRule(ctx, &pb.CreateWildcardRuleRequest{
+		UserId: "alice", Pattern: "promo-*", Destination: "https://shop.example/promo",
+	})
+	if err != nil {
+		t.Fatalf("CreateWildcardRule: %v", err)
+	}
+	if r := resp.Rule; r.Prefix != "promo-" || r.SuffixMode != wildcard.SuffixPath || r.QueryParam != "" || r.Domain != "" {
+		t.Errorf("expected a path rule for promo- on the default domain, got %+v", r)
+	}
+
+	resp, err = s.CreateWildcardRule(ctx, &pb.CreateWildcardRuleRequest{
+		UserId: "alice", Pattern: "spring_*", Destination: "https://shop.example", Domain: "go.acme.com", SuffixMode: wildcard.SuffixQuery,
+	})
+	if err != nil {
+		t.Fatalf("CreateWildcardRule: %v", err)
+	}
+	if r := resp.Rule; r.Domain != "go.acme.com" || r.QueryParam != wildcard.DefaultQueryParam {
+		t.Errorf("expected a query rule with the default parameter on go.acme.com, got %+v", r)
+	}
+
+	// Alice may nest her own rules; Bob may not claim inside hers, nor
+	// around them, but may use the same prefix on another domain.
+	if _, err := s.CreateWildcardRule(ctx, &pb.CreateWildcardRuleRequest{UserId: "alice", Pattern: "promo-vip-*", Destination: "https://shop.example/vip"}); err != nil {
+		t.Errorf("expected a nested rule of the same user to be accepted, got %v", err)
+	}
+	for _, pattern := range []string{"promo-bob-*", "promo-*"} {
+		_, err := s.CreateWildcardRule(ctx, &pb.CreateWildcardRuleRequest{UserId: "bob", Pattern: pattern, Destination: "https://bob.example"})
+		if status.Code(err) != codes.AlreadyExists {
+			t.Errorf("%s: expected AlreadyExists, got %v", pattern, err)
+		}
+	}
+	if _, err := s.CreateWildcardRule(ctx, &pb.CreateWildcardRuleRequest{UserId: "bob", Pattern: "spring_*", Destination: "https://bob.example"}); err != nil {
+		t.Errorf("expected a prefix taken on another domain to be free, got %v", err)
+	}
+	if len(rules.rules) != 4 {
+		t.Errorf("expected 4 rules stored, got %d", len(rules.rules))
+	}
+}
+
+func TestCreateWildcardRule_Invalid(t *testing.T) {
+	s, _ := newWildcardTestService()
+
+	cases := []struct {
+		name string
+		req  *pb.CreateWildcardRuleRequest
+		want codes.Code
+	}{
+		{"anonymous", &pb.CreateWildcardRuleRequest{Pattern: "promo-*", Destination: "https://shop.example"}, codes.Unauthenticated},
+		{"too broad", &pb.CreateWildcardRuleRequest{UserId: "alice", Pattern: "p-*", Destination: "https://shop.example"}, codes.InvalidArgument},
+		{"no separator", &pb.CreateWildcardRuleRequest{UserId: "alice", Pattern: "promo*", Destination: "https://shop.example"}, codes.InvalidArgument},
+		{"no star", &pb.CreateWildcardRuleRequest{UserId: "alice", Pattern: "promo-", Destination: "https://shop.example"}, codes.InvalidArgument},
+		{"bad destination", &pb.CreateWildcardRuleRequest{UserId: "alice", Pattern: "promo-*", Destination: "ftp://shop.example"}, codes.InvalidArgument},
+		{"bad mode", &pb.CreateWildcardRuleRequest{UserId: "alice", Pattern: "promo-*", Destination: "https://shop.example", SuffixMode: "fragment"}, codes.InvalidArgument},
+		{"bad param", &pb.CreateWildcardRuleRequest{UserId: "alice", Pattern: "promo-*", Destination: "https://shop.example", SuffixMode: "query", QueryParam: "a&b"}, codes.InvalidArgument},
+		{"other's domain", &pb.CreateWildcardRuleRequest{UserId: "bob", Pattern: "promo-*", Destination: "https://shop.example", Domain: "go.acme.com"}, codes.PermissionDenied},
+	}
+	for _, tc := range cases {
+		if _, err := s.CreateWildcardRule(context.Background(), tc.req); status.Code(err) != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+// TestWildcardRules_ListGetDelete verifies that users list their own rules,
+// the redirect service gets a domain's, and only a rule's owner deletes it.
+func TestWildcardRules_ListGetDelete(t *testing.T) {
+	s, _ := newWildcardTestService()
+	ctx := context.Background()
+	for _, req := range []*pb.CreateWildcardRuleRequest{
+		{UserId: "alice", Pattern: "promo-*", Destination: "https://shop.example"},
+		{UserId: "alice", Pattern: "acme-*", Destination: "https://acme.example", Domain: "go.acme.com"},
+		{UserId: "bob", Pattern: "bob-*", Destination: "https://bob.example"},
+	} {
+		if _, err := s.CreateWildcardRule(ctx, req); err != nil {
+			t.Fatalf("CreateWildcardRule(%s): %v", req.Pattern, err)
+		}
+	}
+
+	list, err := s.ListWildcardRules(ctx, &pb.ListWildcardRulesRequest{UserId: "alice"})
+	if err != nil || len(list.Rules) != 2 {
+		t.Fatalf("expected alice's 2 rules, got %v, %v", list, err)
+	}
+	got, err := s.GetWildcardRules(ctx, &pb.GetWildcardRulesRequest{})
+	if err != nil || len(got.Rules) != 2 {
+		t.Fatalf("expected 2 rules on the default domain, got %v, %v", got, err)
+	}
+	got, err = s.GetWildcardRules(ctx, &pb.GetWildcardRulesRequest{Domain: "tiny.test"})
+	if err != nil || len(got.Rules) != 2 {
+		t.Errorf("expected the default domain's own host to mean the default domain, got %v, %v", got, err)
+	}
+
+	if _, err := s.DeleteWildcardRule(ctx, &pb.DeleteWildcardRuleRequest{UserId: "bob", Prefix: "promo-"}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound deleting another user's rule, got %v", err)
+	}
+	if _, err := s.DeleteWildcardRule(ctx, &pb.DeleteWildcardRuleRequest{UserId: "alice", Prefix: "acme-"}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for a rule on another domain, got %v", err)
+	}
+	if _, err := s.DeleteWildcardRule(ctx, &pb.DeleteWildcardRuleRequest{UserId: "alice", Prefix: "acme-", Domain: "GO.acme.com"}); err != nil {
+		t.Errorf("DeleteWildcardRule: %v", err)
+	}
+	if got, _ := s.GetWildcardRules(ctx, &pb.GetWildcardRulesRequest{Domain: "go.acme.com"}); len(got.Rules) != 0 {
+		t.Errorf("expected the deleted rule gone, got %v", got.Rules)
+	}
+}
@@ -0,0 +1,235 @@
+// Package sharelink keeps the share tokens that grant read-only access to
+// one link's analytics, so its owner can hand a dashboard to someone
+// without an account, or without handing over their own.
+//
+// A token is a random string rather than a signed claim so that it can be
+// revoked: it works only while its Redis key exists. Each token also sits
+// in a per-link index, which lists the link's tokens and revokes them all
+// when the link is deleted, so a short code claimed again later does not
+// inherit its predecessor's readers.
+package sharelink
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clock"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrNotFound is returned by Resolve for a token that does not exist, has
+// expired or was revoked.
+var ErrNotFound = errors.New("share token not found")
+
+// Share is a token granting read-only access to ShortCode's analytics.
+type Share struct {
+	Token     string    `json:"token"`
+	ShortCode string    `json:"short_code"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"` // Zero if the token never expires.
+}
+
+// expired reports whether s has stopped working at now.
+func (s Share) expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !s.ExpiresAt.After(now)
+}
+
+// shareStore holds the state behind Store. It is satisfied by
+// redisShareStore; tests substitute an in-process implementation.
+type shareStore interface {
+	// put stores s, keeping its token working for ttl, or for good if ttl
+	// is 0.
+	put(ctx context.Context, s Share, ttl time.Duration) error
+	// get returns the share of token and whether it exists.
+	get(ctx context.Context, token string) (Share, bool, error)
+	// all returns the shares indexed under shortCode, expired ones included.
+	all(ctx context.Context, shortCode string) ([]Share, error)
+	remove(ctx context.Context, shortCode string, tokens ...string) error
+}
+
+// Store mints, resolves and revokes share tokens.
+type Store struct {
+	store shareStore
+	clock clock.Clock // stamps new tokens and tells which have expired
+}
+
+// NewStore creates a Store that keeps its tokens in Redis.
+func NewStore(redisClient *redis.Client) *Store {
+	return &Store{store: redisShareStore{client: redisClient}, clock: clock.Real{}}
+}
+
+// Create mints a token for shortCode that works for ttl, or until revoked
+// if ttl is 0.
+func (s *Store) Create(ctx context.Context, shortCode string, ttl time.Duration) (Share, error) {
+	token, err := newToken()
+	if err != nil {
+		return Share{}, err
+	}
+	share := Share{Token: token, ShortCode: shortCode, CreatedAt: s.clock.Now().UTC()}
+	if ttl > 0 {
+		share.ExpiresAt = share.CreatedAt.Add(ttl)
+	}
+	if err := s.store.put(ctx, share, ttl); err != nil {
+		return Share{}, err
+	}
+	return share, nil
+}
+
+// Resolve returns the short code token grants access to, or ErrNotFound.
+func (s *Store) Resolve(ctx context.Context, token string) (string, error) {
+	if token == "" {
+		return "", ErrNotFound
+	}
+	share, ok, err := s.store.get(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	if !ok || share.expired(s.clock.Now()) {
+		return "", ErrNotFound
+	}
+	return share.ShortCode, nil
+}
+
+// List returns shortCode's tokens, newest first. Expired tokens are left
+// out and forgotten.
+func (s *Store) List(ctx context.Context, shortCode string) ([]Share, error) {
+	shares, err := s.store.all(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	live := shares[:0]
+	var stale []string
+	for _, share := range shares {
+		if share.expired(now) {
+			stale = append(stale, share.Token)
+			continue
+		}
+		live = append(live, share)
+	}
+	if len(stale) > 0 {
+		_ = s.store.remove(ctx, shortCode, stale...)
+	}
+
+	slices.SortFunc(live, func(a, b Share) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return live, nil
+}
+
+// Revoke revokes token if it is one of shortCode's, and reports whether it
+// was.
+func (s *Store) Revoke(ctx context.Context, shortCode, token string) (bool, error) {
+	share, ok, err := s.store.get(ctx, token)
+	if err != nil {
+		return false, err
+	}
+	if !ok || share.ShortCode != shortCode {
+		return false, nil
+	}
+	if err := s.store.remove(ctx, shortCode, token); err != nil {
+		return false, err
+	}
+	return !share.expired(s.clock.Now()), nil
+}
+
+// RevokeAll revokes every token of shortCode and returns how many were
+// still working.
+func (s *Store) RevokeAll(ctx context.Context, shortCode string) (int, error) {
+	shares, err := s.List(ctx, shortCode)
+	if err != nil {
+		return 0, err
+	}
+	if len(shares) == 0 {
+		return 0, nil
+	}
+	tokens := make([]string, len(shares))
+	for i, share := range shares {
+		tokens[i] = share.Token
+	}
+	if err := s.store.remove(ctx, shortCode, tokens...); err != nil {
+		return 0, err
+	}
+	return len(shares), nil
+}
+
+// newToken returns 16 random bytes hex-encoded.
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// redisShareStore keeps each share as JSON in its own key, which expires
+// with the token, and again in a hash of the link's shares keyed by token.
+// The hash does not expire, as a link's tokens may never do; List prunes
+// it.
+type redisShareStore struct {
+	client *redis.Client
+}
+
+func tokenKey(token string) string    { return "analytics:share:" + token }
+func linkKey(shortCode string) string { return "analytics:shares:" + shortCode }
+
+func (s redisShareStore) put(ctx context.Context, share Share, ttl time.Duration) error {
+	data, err := json.Marshal(share)
+	if err != nil {
+		return err
+	}
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, tokenKey(share.Token), data, ttl)
+	pipe.HSet(ctx, linkKey(share.ShortCode), share.Token, data)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (s redisShareStore) get(ctx context.Context, token string) (Share, bool, error) {
+	data, err := s.client.Get(ctx, tokenKey(token)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return Share{}, false, nil
+	}
+	if err != nil {
+		return Share{}, false, err
+	}
+	var share Share
+	if err := json.Unmarshal(data, &share); err != nil {
+		return Share{}, false, nil
+	}
+	return share, true, nil
+}
+
+func (s redisShareStore) all(ctx context.Context, shortCode string) ([]Share, error) {
+	fields, err := s.client.HGetAll(ctx, linkKey(shortCode)).Result()
+	if err != nil {
+		return nil, err
+	}
+	shares := make([]Share, 0, len(fields))
+	for _, data := range fields {
+		var share Share
+		if err := json.Unmarshal([]byte(data), &share); err != nil {
+			continue
+		}
+		shares = append(shares, share)
+	}
+	return shares, nil
+}
+
+func (s redisShareStore) remove(ctx context.Context, shortCode string, tokens ...string) error {
+	keys := make([]string, len(tokens))
+	for i, token := range tokens {
+		keys[i] = tokenKey(token)
+	}
+	pipe := s.client.TxPipeline()
+	pipe.Del(ctx, keys...)
+	pipe.HDel(ctx, linkKey(shortCode), tokens...)
+	_, err := pipe.Exec(ctx)
+	return err
+}
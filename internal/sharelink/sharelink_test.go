@@ -0,0 +1,129 @@
+package sharelink
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clock/clocktest"
+)
+
+// memoryShareStore is an in-process shareStore. Key expiry is not
+// modelled; Store checks ExpiresAt itself.
+type memoryShareStore struct {
+	mu     sync.Mutex
+	tokens map[string]Share
+	links  map[string]map[string]Share
+}
+
+func newMemoryShareStore() *memoryShareStore {
+	return &memoryShareStore{tokens: map[string]Share{}, links: map[string]map[string]Share{}}
+}
+
+func (s *memoryShareStore) put(ctx context.Context, share Share, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[share.Token] = share
+	if s.links[share.ShortCode] == nil {
+		s.links[share.ShortCode] = map[string]Share{}
+	}
+	s.links[share.ShortCode][share.Token] = share
+	return nil
+}
+
+func (s *memoryShareStore) get(ctx context.Context, token string) (Share, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	share, ok := s.tokens[token]
+	return share, ok, nil
+}
+
+func (s *memoryShareStore) all(ctx context.Context, shortCode string) ([]Share, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var shares []Share
+	for _, share := range s.links[shortCode] {
+		shares = append(shares, share)
+	}
+	return shares, nil
+}
+
+func (s *memoryShareStore) remove(ctx context.Context, shortCode string, tokens ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, token := range tokens {
+		delete(s.tokens, token)
+		delete(s.links[shortCode], token)
+	}
+	return nil
+}
+
+func TestStore_ResolvesTokenToItsLinkOnly(t *testing.T) {
+	store := &Store{store: newMemoryShareStore(), clock: clocktest.NewFake(time.Now())}
+	ctx := context.Background()
+
+	share, err := store.Create(ctx, "abc", time.Hour)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if share.ExpiresAt.IsZero() {
+		t.Error("expected a token created with a TTL to expire")
+	}
+
+	code, err := store.Resolve(ctx, share.Token)
+	if err != nil || code != "abc" {
+		t.Fatalf("Resolve = %q, %v; want abc", code, err)
+	}
+	if _, err := store.Resolve(ctx, "unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unknown token, got %v", err)
+	}
+
+	// Another link cannot revoke it.
+	if revoked, _ := store.Revoke(ctx, "xyz", share.Token); revoked {
+		t.Error("expected a token not to be revocable through another link")
+	}
+	if revoked, err := store.Revoke(ctx, "abc", share.Token); err != nil || !revoked {
+		t.Fatalf("Revoke = %v, %v; want true", revoked, err)
+	}
+	if _, err := store.Resolve(ctx, share.Token); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a revoked token not to resolve, got %v", err)
+	}
+}
+
+func TestStore_ExpiredTokens(t *testing.T) {
+	mem := newMemoryShareStore()
+	clk := clocktest.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	store := &Store{store: mem, clock: clk}
+	ctx := context.Background()
+
+	old, _ := store.Create(ctx, "abc", time.Hour)
+	clk.Advance(time.Hour)
+	forever, _ := store.Create(ctx, "abc", 0)
+	clk.Advance(time.Hour)
+	recent, _ := store.Create(ctx, "abc", time.Hour)
+
+	if _, err := store.Resolve(ctx, old.Token); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected an expired token not to resolve, got %v", err)
+	}
+
+	shares, err := store.List(ctx, "abc")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(shares) != 2 || shares[0].Token != recent.Token || shares[1].Token != forever.Token {
+		t.Fatalf("expected the recent token then the one that never expires, got %+v", shares)
+	}
+	if _, ok := mem.links["abc"][old.Token]; ok {
+		t.Error("expected the expired token to be forgotten")
+	}
+
+	revoked, err := store.RevokeAll(ctx, "abc")
+	if err != nil || revoked != 2 {
+		t.Fatalf("RevokeAll = %d, %v; want 2", revoked, err)
+	}
+	if _, err := store.Resolve(ctx, forever.Token); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected RevokeAll to revoke every token, got %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+// Package shorturl builds the public URL of a short code.
+//
+// The URL service, the API gateway and the TUI all show short URLs, and
+// each used to join the configured base URL (BASE_URL) and the code on its
+// own. Building them here keeps them identical everywhere, whether the base
+// URL has a trailing slash or serves the redirects under a subpath.
+package shorturl
+
+import (
+	"net/url"
+	"strings"
+)
+
+// BuildShortURL returns the short URL of code under baseURL, e.g.
+// "https://tiny.link/abc123". Trailing slashes on baseURL are dropped, so
+// "https://tiny.link/" and "https://tiny.link" give the same URL, and a
+// subpath such as "https://example.com/s" is kept.
+func BuildShortURL(baseURL, code string) string {
+	return strings.TrimRight(baseURL, "/") + "/" + url.PathEscape(code)
+}
+
+// BuildDomainShortURL returns the short URL of code on a custom domain.
+// Custom domains serve their links at the root, with the scheme of
+// baseURL, or https if it has none. An empty domain means the default
+// domain, and gives BuildShortURL(baseURL, code).
+func BuildDomainShortURL(baseURL, domain, code string) string {
+	if domain == "" {
+		return BuildShortURL(baseURL, code)
+	}
+	scheme := "https"
+	if u, err := url.Parse(baseURL); err == nil && u.Scheme != "" {
+		scheme = u.Scheme
+	}
+	return BuildShortURL(scheme+"://"+domain, code)
+}
@@ -0,0 +1,37 @@
+package shorturl
+
+import "testing"
+
+func TestBuildShortURL(t *testing.T) {
+	for _, tc := range []struct {
+		baseURL string
+		want    string
+	}{
+		{"https://tiny.link", "https://tiny.link/abc123"},
+		{"https://tiny.link/", "https://tiny.link/abc123"},
+		{"https://tiny.link//", "https://tiny.link/abc123"},
+		{"https://example.com/s", "https://example.com/s/abc123"},
+		{"https://example.com/s/", "https://example.com/s/abc123"},
+		{"http://localhost:8081", "http://localhost:8081/abc123"},
+	} {
+		if got := BuildShortURL(tc.baseURL, "abc123"); got != tc.want {
+			t.Errorf("BuildShortURL(%q): expected %q, got %q", tc.baseURL, tc.want, got)
+		}
+	}
+}
+
+func TestBuildDomainShortURL(t *testing.T) {
+	for _, tc := range []struct {
+		baseURL, domain string
+		want            string
+	}{
+		{"https://tiny.link/", "", "https://tiny.link/abc123"},
+		{"https://example.com/s/", "go.acme.com", "https://go.acme.com/abc123"},
+		{"http://localhost:8081", "go.acme.com", "http://go.acme.com/abc123"},
+		{"", "go.acme.com", "https://go.acme.com/abc123"},
+	} {
+		if got := BuildDomainShortURL(tc.baseURL, tc.domain, "abc123"); got != tc.want {
+			t.Errorf("BuildDomainShortURL(%q, %q): expected %q, got %q", tc.baseURL, tc.domain, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Varun5711/shorternit/internal/database"
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrDomainTaken is returned by CreateDomain when the domain has already been
+// verified by another user.
+var ErrDomainTaken = errors.New("domain is already registered by another user")
+
+// DomainStorage provides PostgreSQL-backed persistence for users' custom
+// domains. The url-service uses it to register and verify domains and to
+// check that a link's domain belongs to the user creating it.
+type DomainStorage struct {
+	db *database.DBManager
+}
+
+// NewDomainStorage creates a DomainStorage backed by the given DBManager.
+func NewDomainStorage(db *database.DBManager) *DomainStorage {
+	return &DomainStorage{db: db}
+}
+
+// domainColumns is the shared SELECT list for scanning a full Domain row.
+const domainColumns = `domain, user_id, verification_token, verified, verified_at, created_at`
+
+// scanDomain reads one row selected with domainColumns into a Domain.
+func scanDomain(row pgx.Row) (*models.Domain, error) {
+	var d models.Domain
+	err := row.Scan(
+		&d.Domain,
+		&d.UserID,
+		&d.VerificationToken,
+		&d.Verified,
+		&d.VerifiedAt,
+		&d.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// CreateDomain registers d.Domain for d.UserID on the primary. Registering a
+// domain the user already holds returns the existing row unchanged, so the
+// verification token stays stable across retries. An unverified claim by
+// another user is taken over with the new token -- otherwise anyone could
+// squat a domain they do not control -- while a verified one is refused
+// with ErrDomainTaken.
+func (s *DomainStorage) CreateDomain(ctx context.Context, d *models.Domain) (*models.Domain, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	// The conditional upsert only overwrites someone else's unverified
+	// claim; for every other conflict it returns no row.
+	query := `
+		INSERT INTO domains (domain, user_id, verification_token)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (domain) DO UPDATE
+		SET user_id = EXCLUDED.user_id,
+			verification_token = EXCLUDED.verification_token,
+			created_at = NOW()
+		WHERE domains.verified = FALSE AND domains.user_id <> EXCLUDED.user_id
+		RETURNING ` + domainColumns
+
+	created, err := scanDomain(s.db.Write().QueryRow(ctx, query, d.Domain, d.UserID, d.VerificationToken))
+	if err == nil {
+		return created, nil
+	}
+	if err != pgx.ErrNoRows {
+		return nil, fmt.Errorf("failed to create domain: %w", err)
+	}
+
+	existing, err := scanDomain(s.db.Write().QueryRow(ctx,
+		`SELECT `+domainColumns+` FROM domains WHERE domain = $1`, d.Domain))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if existing.UserID != d.UserID {
+		return nil, ErrDomainTaken
+	}
+	return existing, nil
+}
+
+// GetDomain fetches a domain by name from the primary, so a domain verified
+// moments ago can be used immediately. Returns (nil, nil) when it is not
+// registered.
+func (s *DomainStorage) GetDomain(ctx context.Context, domain string) (*models.Domain, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	d, err := scanDomain(s.db.Write().QueryRow(ctx,
+		`SELECT `+domainColumns+` FROM domains WHERE domain = $1`, domain))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	return d, nil
+}
+
+// ListDomains returns every domain registered by userID, oldest first.
+func (s *DomainStorage) ListDomains(ctx context.Context, userID string) ([]*models.Domain, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	query := `
+		SELECT ` + domainColumns + `
+		FROM domains
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := s.db.Read().Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+	defer rows.Close()
+
+	var domains []*models.Domain
+	for rows.Next() {
+		d, err := scanDomain(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan domain: %w", err)
+		}
+		domains = append(domains, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return domains, nil
+}
+
+// MarkDomainVerified records that userID passed the DNS challenge for
+// domain and returns the updated row. It returns (nil, nil) when the domain
+// is no longer registered to userID, e.g. because the claim was taken over
+// between the DNS check and this write.
+func (s *DomainStorage) MarkDomainVerified(ctx context.Context, domain, userID string) (*models.Domain, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE domains
+		SET verified = TRUE, verified_at = COALESCE(verified_at, NOW())
+		WHERE domain = $1 AND user_id = $2
+		RETURNING ` + domainColumns
+
+	d, err := scanDomain(s.db.Write().QueryRow(ctx, query, domain, userID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify domain: %w", err)
+	}
+	return d, nil
+}
@@ -0,0 +1,536 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clock"
+	"github.com/Varun5711/shorternit/internal/models"
+)
+
+// MemoryStorage is an in-process implementation of the Storage interface,
+// selected with STORAGE_BACKEND=memory. It keeps every URL and audit event
+// in maps guarded by a mutex, so it needs no database and loses everything
+// when the process exits. It exists for local experiments and for tests of
+// code that depends on Storage; production runs on PostgresStorage.
+//
+// It reproduces PostgresStorage's observable behaviour: expired URLs are
+// invisible to every read and are only removed by DeleteExpiredURLs,
+// listings are newest first, a taken short code is refused, and each write
+// records its audit event. URLs are copied on the way in and out, so a
+// caller mutating a returned URL cannot change the stored one, just as it
+// could not change a database row.
+type MemoryStorage struct {
+	mu     sync.RWMutex
+	urls   map[string]*models.URL
+	events []*models.URLEvent
+	clock  clock.Clock
+}
+
+// NewMemoryStorage returns an empty MemoryStorage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		urls:  make(map[string]*models.URL),
+		clock: clock.Real{},
+	}
+}
+
+// copyURL returns a copy of u that shares no slices or pointers with it.
+func copyURL(u *models.URL) *models.URL {
+	c := *u
+	c.Tags = append([]string(nil), u.Tags...)
+	c.Variants = append([]models.URLVariant(nil), u.Variants...)
+	c.GeoRules = append([]models.GeoRule(nil), u.GeoRules...)
+	if u.ActiveFrom != nil {
+		t := *u.ActiveFrom
+		c.ActiveFrom = &t
+	}
+	if u.ExpiresAt != nil {
+		t := *u.ExpiresAt
+		c.ExpiresAt = &t
+	}
+	return &c
+}
+
+// expired reports whether u has passed its expiry. s.mu must be held.
+func (s *MemoryStorage) expired(u *models.URL) bool {
+	return u.ExpiresAt != nil && !u.ExpiresAt.After(s.clock.Now())
+}
+
+// record appends an audit event. s.mu must be held for writing.
+func (s *MemoryStorage) record(shortCode, action, actorID, beforeURL, afterURL string, at time.Time) {
+	if at.IsZero() {
+		at = s.clock.Now()
+	}
+	s.events = append(s.events, &models.URLEvent{
+		ID:         int64(len(s.events) + 1),
+		ShortCode:  shortCode,
+		Action:     action,
+		ActorID:    actorID,
+		BeforeURL:  beforeURL,
+		AfterURL:   afterURL,
+		OccurredAt: at,
+	})
+}
+
+// insert stores a copy of url unless its short code is taken. s.mu must be
+// held for writing.
+func (s *MemoryStorage) insert(url *models.URL) error {
+	if _, ok := s.urls[url.ShortCode]; ok {
+		return ErrShortCodeTaken
+	}
+	s.urls[url.ShortCode] = copyURL(url)
+	s.record(url.ShortCode, models.URLEventCreate, url.UserID, "", url.LongURL, url.CreatedAt)
+	return nil
+}
+
+// Save stores a new URL with its variants and geo rules. Like the primary
+// key on urls.short_code, it refuses a short code that is already taken,
+// even by an expired URL the cleanup has not removed yet.
+func (s *MemoryStorage) Save(ctx context.Context, url *models.URL) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.insert(url); err != nil {
+		return fmt.Errorf("failed to save URL: %w", err)
+	}
+	return nil
+}
+
+// SaveBatch stores each URL in turn and returns one error per input URL:
+// nil when it was stored, ErrShortCodeTaken when the short code was taken.
+func (s *MemoryStorage) SaveBatch(ctx context.Context, urls []*models.URL) []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	errs := make([]error, len(urls))
+	for i, url := range urls {
+		errs[i] = s.insert(url)
+	}
+	return errs
+}
+
+// GetByShortCode returns the URL with the given short code, or nil (with no
+// error) when it does not exist or has expired.
+func (s *MemoryStorage) GetByShortCode(ctx context.Context, shortCode string) (*models.URL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.urls[shortCode]
+	if !ok || s.expired(u) {
+		return nil, nil
+	}
+	return copyURL(u), nil
+}
+
+// GetURLStats returns the counters of the URL with the given short code,
+// expired or not, or nil when it does not exist. Edits are not tracked, so
+// UpdatedAt is the creation time.
+func (s *MemoryStorage) GetURLStats(ctx context.Context, shortCode string) (*models.URLStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.urls[shortCode]
+	if !ok {
+		return nil, nil
+	}
+	c := copyURL(u)
+	return &models.URLStats{
+		ShortCode: c.ShortCode,
+		UserID:    c.UserID,
+		Clicks:    c.Clicks,
+		MaxClicks: c.MaxClicks,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.CreatedAt,
+		ExpiresAt: c.ExpiresAt,
+	}, nil
+}
+
+// IncrementClicks adds one to a URL's click counter. It returns an error if
+// the short code does not exist.
+func (s *MemoryStorage) IncrementClicks(ctx context.Context, shortCode string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.urls[shortCode]
+	if !ok {
+		return fmt.Errorf("URL with short code %s not found", shortCode)
+	}
+	u.Clicks++
+	return nil
+}
+
+// live returns copies of the unexpired URLs that keep reports true for,
+// ordered by (created_at, short_code) descending like the keyset index.
+// s.mu must be held.
+func (s *MemoryStorage) live(keep func(u *models.URL) bool) []*models.URL {
+	var urls []*models.URL
+	for _, u := range s.urls {
+		if !s.expired(u) && keep(u) {
+			urls = append(urls, copyURL(u))
+		}
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		if !urls[i].CreatedAt.Equal(urls[j].CreatedAt) {
+			return urls[i].CreatedAt.After(urls[j].CreatedAt)
+		}
+		return urls[i].ShortCode > urls[j].ShortCode
+	})
+	return urls
+}
+
+func ownedBy(userID string) func(u *models.URL) bool {
+	return func(u *models.URL) bool { return u.UserID == userID }
+}
+
+func everyURL(*models.URL) bool { return true }
+
+// page returns the limit URLs starting at offset, with the summary of all
+// of them. expired is how many matching URLs were left out for having
+// expired.
+func (s *MemoryStorage) page(urls []*models.URL, expired int32, limit, offset int32) ([]*models.URL, models.URLListSummary, error) {
+	now := s.clock.Now()
+	summary := models.URLListSummary{Total: int32(len(urls)), ExpiredCount: expired}
+	for _, u := range urls {
+		summary.TotalClicks += u.Clicks
+		if !u.Disabled && (u.ActiveFrom == nil || !u.ActiveFrom.After(now)) {
+			summary.ActiveCount++
+		}
+	}
+	if offset >= summary.Total {
+		return nil, summary, nil
+	}
+	end := summary.Total
+	if limit >= 0 && offset+limit < summary.Total {
+		end = offset + limit
+	}
+	return urls[offset:end], summary, nil
+}
+
+// expiredMatching counts the expired URLs that keep would select.
+func (s *MemoryStorage) expiredMatching(keep func(u *models.URL) bool) int32 {
+	var n int32
+	for _, u := range s.urls {
+		if s.expired(u) && keep(u) {
+			n++
+		}
+	}
+	return n
+}
+
+// List returns every unexpired URL, newest first.
+func (s *MemoryStorage) List(ctx context.Context) ([]*models.URL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.live(everyURL), nil
+}
+
+// ListByUserID returns the user's unexpired URLs, newest first.
+func (s *MemoryStorage) ListByUserID(ctx context.Context, userID string) ([]*models.URL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.live(ownedBy(userID)), nil
+}
+
+// AliasExists reports whether the short code is taken, by an expired URL
+// or not.
+func (s *MemoryStorage) AliasExists(ctx context.Context, alias string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.urls[alias]
+	return ok, nil
+}
+
+// AliasExistsPrimary is AliasExists: a MemoryStorage has no replicas to lag.
+func (s *MemoryStorage) AliasExistsPrimary(ctx context.Context, alias string) (bool, error) {
+	return s.AliasExists(ctx, alias)
+}
+
+// CreateCustomURL stores a URL under a user-chosen alias, stamped with the
+// current time. Like Save, it refuses an alias that is already taken.
+func (s *MemoryStorage) CreateCustomURL(ctx context.Context, url *models.URL) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	url.CreatedAt = s.clock.Now()
+	if err := s.insert(url); err != nil {
+		return fmt.Errorf("failed to create custom URL: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a URL and records the delete by actorID. It returns an
+// error if the short code does not exist.
+func (s *MemoryStorage) Delete(ctx context.Context, shortCode, actorID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.urls[shortCode]
+	if !ok {
+		return fmt.Errorf("URL with short code %s not found", shortCode)
+	}
+	delete(s.urls, shortCode)
+	s.record(shortCode, models.URLEventDelete, actorID, u.LongURL, "", time.Time{})
+	return nil
+}
+
+// DeleteManyByUser removes the URLs among shortCodes that userID owns,
+// recording a delete by userID for each, and returns copies of them.
+func (s *MemoryStorage) DeleteManyByUser(ctx context.Context, userID string, shortCodes []string) ([]*models.URL, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted []*models.URL
+	for _, shortCode := range shortCodes {
+		u, ok := s.urls[shortCode]
+		if !ok || u.UserID != userID {
+			continue
+		}
+		delete(s.urls, shortCode)
+		s.record(shortCode, models.URLEventDelete, userID, u.LongURL, "", time.Time{})
+		deleted = append(deleted, copyURL(u))
+	}
+	return deleted, nil
+}
+
+// Restore brings back an expired or deleted link: an expired URL still
+// held by url.UserID gets url.ExpiresAt, otherwise url is stored anew. It
+// returns ErrShortCodeTaken if any other URL holds the short code.
+func (s *MemoryStorage) Restore(ctx context.Context, url *models.URL) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u, ok := s.urls[url.ShortCode]; ok {
+		if u.UserID != url.UserID || !s.expired(u) {
+			return fmt.Errorf("failed to restore URL: %w", ErrShortCodeTaken)
+		}
+		u.ExpiresAt = copyURL(url).ExpiresAt
+		url.LongURL, url.Domain = u.LongURL, u.Domain
+	} else {
+		s.urls[url.ShortCode] = copyURL(url)
+	}
+	s.record(url.ShortCode, models.URLEventRestore, url.UserID, "", url.LongURL, time.Time{})
+	return nil
+}
+
+// DeleteExpiredURLs removes every URL whose expiry has passed and returns
+// their short codes.
+func (s *MemoryStorage) DeleteExpiredURLs(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var shortCodes []string
+	for code, u := range s.urls {
+		if s.expired(u) {
+			delete(s.urls, code)
+			shortCodes = append(shortCodes, code)
+		}
+	}
+	return shortCodes, nil
+}
+
+// ListPaginated returns a page of unexpired URLs, newest first, and the
+// summary of every URL.
+func (s *MemoryStorage) ListPaginated(ctx context.Context, limit, offset int32) ([]*models.URL, models.URLListSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.page(s.live(everyURL), s.expiredMatching(everyURL), limit, offset)
+}
+
+// ListByUserIDPaginated returns a page of the user's unexpired URLs, newest
+// first, and the summary of all the user's URLs.
+func (s *MemoryStorage) ListByUserIDPaginated(ctx context.Context, userID string, limit, offset int32) ([]*models.URL, models.URLListSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.page(s.live(ownedBy(userID)), s.expiredMatching(ownedBy(userID)), limit, offset)
+}
+
+// ListByUserIDAndTag returns a page of the user's unexpired URLs carrying
+// tag, newest first, and the summary of all the user's URLs carrying it.
+func (s *MemoryStorage) ListByUserIDAndTag(ctx context.Context, userID, tag string, limit, offset int32) ([]*models.URL, models.URLListSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tagged := func(u *models.URL) bool {
+		if u.UserID != userID {
+			return false
+		}
+		for _, t := range u.Tags {
+			if t == tag {
+				return true
+			}
+		}
+		return false
+	}
+	return s.page(s.live(tagged), s.expiredMatching(tagged), limit, offset)
+}
+
+// GetTagCounts returns each tag on the user's unexpired URLs with the
+// number of URLs carrying it, most used first (ties alphabetical).
+func (s *MemoryStorage) GetTagCounts(ctx context.Context, userID string) ([]models.TagCount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int64)
+	for _, u := range s.live(ownedBy(userID)) {
+		for _, t := range u.Tags {
+			counts[t]++
+		}
+	}
+	var out []models.TagCount
+	for tag, n := range counts {
+		out = append(out, models.TagCount{Tag: tag, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Tag < out[j].Tag
+	})
+	return out, nil
+}
+
+// UpdateTags replaces a URL's tags and records the update by actorID. It
+// returns an error if the short code does not exist.
+func (s *MemoryStorage) UpdateTags(ctx context.Context, shortCode string, tags []string, actorID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.urls[shortCode]
+	if !ok {
+		return fmt.Errorf("URL with short code %s not found", shortCode)
+	}
+	u.Tags = append([]string(nil), tags...)
+	s.record(shortCode, models.URLEventUpdate, actorID, u.LongURL, u.LongURL, time.Time{})
+	return nil
+}
+
+// SetActive enables or disables the URL with shortCode, recording the change
+// unless the URL already was in that state.
+func (s *MemoryStorage) SetActive(ctx context.Context, shortCode string, active bool, actorID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.urls[shortCode]
+	if !ok {
+		return fmt.Errorf("URL with short code %s not found", shortCode)
+	}
+	if u.Disabled == !active {
+		return nil
+	}
+	u.Disabled = !active
+	action := models.URLEventDisable
+	if active {
+		action = models.URLEventEnable
+	}
+	s.record(shortCode, action, actorID, u.LongURL, u.LongURL, time.Time{})
+	return nil
+}
+
+// ListByUserIDAfter returns up to limit of the user's unexpired URLs in
+// (created_at, short_code) descending order, starting strictly after the
+// given position. A zero afterCreatedAt starts from the newest URL.
+func (s *MemoryStorage) ListByUserIDAfter(ctx context.Context, userID string, afterCreatedAt time.Time, afterShortCode string, limit int32) ([]*models.URL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	urls := s.live(func(u *models.URL) bool {
+		if u.UserID != userID {
+			return false
+		}
+		if afterCreatedAt.IsZero() {
+			return true
+		}
+		return u.CreatedAt.Before(afterCreatedAt) ||
+			(u.CreatedAt.Equal(afterCreatedAt) && u.ShortCode < afterShortCode)
+	})
+	if int32(len(urls)) > limit {
+		urls = urls[:limit]
+	}
+	return urls, nil
+}
+
+// ListEvents returns the audit trail of a short code, oldest first; unless
+// includePrevious is set, only the events since its latest create.
+func (s *MemoryStorage) ListEvents(ctx context.Context, shortCode string, includePrevious bool) ([]*models.URLEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var events []*models.URLEvent
+	for _, ev := range s.events {
+		if ev.ShortCode != shortCode {
+			continue
+		}
+		if ev.Action == models.URLEventCreate && !includePrevious {
+			events = events[:0]
+		}
+		c := *ev
+		events = append(events, &c)
+	}
+	return events, nil
+}
+
+// CountActiveByUser returns the number of the user's unexpired URLs.
+func (s *MemoryStorage) CountActiveByUser(ctx context.Context, userID string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var n int64
+	for _, u := range s.urls {
+		if u.UserID == userID && !s.expired(u) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// ScanShortCodes calls fn with every short code created at or after since,
+// stopping at the first error fn returns. fn runs without the lock held, on
+// a snapshot of the codes.
+func (s *MemoryStorage) ScanShortCodes(ctx context.Context, since time.Time, fn func(shortCode string) error) error {
+	s.mu.RLock()
+	var codes []string
+	for code, u := range s.urls {
+		if !u.CreatedAt.Before(since) {
+			codes = append(codes, code)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, code := range codes {
+		if err := fn(code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateQRCode replaces the qr_code of userID's URL and returns the previous
+// value.
+func (s *MemoryStorage) UpdateQRCode(ctx context.Context, shortCode, userID, qrCode string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.urls[shortCode]
+	if !ok || u.UserID != userID {
+		return "", fmt.Errorf("URL with short code %s not found", shortCode)
+	}
+	previous := u.QRCode
+	u.QRCode = qrCode
+	return previous, nil
+}
+
+// SavePreview stores the preview fetched from a URL's destination. A URL
+// deleted in the meantime is not an error.
+func (s *MemoryStorage) SavePreview(ctx context.Context, shortCode, title, description, imageURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u, ok := s.urls[shortCode]; ok {
+		u.Title, u.Description, u.ImageURL = title, description, imageURL
+	}
+	return nil
+}
@@ -0,0 +1,358 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clock/clocktest"
+	"github.com/Varun5711/shorternit/internal/models"
+)
+
+// newClockedMemoryStorage returns a MemoryStorage reading the time from a
+// fake clock, and the clock.
+func newClockedMemoryStorage() (*MemoryStorage, *clocktest.Fake) {
+	clk := clocktest.NewFake(time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC))
+	s := NewMemoryStorage()
+	s.clock = clk
+	return s, clk
+}
+
+func TestMemoryStorage_SaveAndGet(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+
+	url := &models.URL{
+		ShortCode: "abc",
+		LongURL:   "https://example.com",
+		UserID:    "alice",
+		CreatedAt: time.Now(),
+		Tags:      []string{"work"},
+		Variants:  []models.URLVariant{{LongURL: "https://a.example", Weight: 1}, {LongURL: "https://b.example", Weight: 1}},
+	}
+	if err := s.Save(ctx, url); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := s.Save(ctx, &models.URL{ShortCode: "abc", LongURL: "https://other.example"}); !errors.Is(err, ErrShortCodeTaken) {
+		t.Errorf("expected a taken short code to be refused, got %v", err)
+	}
+
+	got, err := s.GetByShortCode(ctx, "abc")
+	if err != nil || got == nil {
+		t.Fatalf("GetByShortCode: %v, %v", got, err)
+	}
+	if got.LongURL != url.LongURL || got.UserID != "alice" || len(got.Variants) != 2 {
+		t.Errorf("unexpected URL %+v", got)
+	}
+	got.Tags[0] = "changed"
+	if again, _ := s.GetByShortCode(ctx, "abc"); again.Tags[0] != "work" {
+		t.Error("expected a returned URL not to share its tags with the store")
+	}
+
+	if got, err := s.GetByShortCode(ctx, "missing"); got != nil || err != nil {
+		t.Errorf("expected nil, nil for a missing code, got %v, %v", got, err)
+	}
+}
+
+func TestMemoryStorage_ListsNewestFirst(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	base := time.Now()
+	for i, code := range []string{"a", "b", "c", "d"} {
+		owner := "alice"
+		if code == "c" {
+			owner = "bob"
+		}
+		if err := s.Save(ctx, &models.URL{ShortCode: code, LongURL: "https://example.com", UserID: owner, CreatedAt: base.Add(time.Duration(i) * time.Minute)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	codes := func(urls []*models.URL) []string {
+		var out []string
+		for _, u := range urls {
+			out = append(out, u.ShortCode)
+		}
+		return out
+	}
+	equal := func(got, want []string) bool {
+		if len(got) != len(want) {
+			return false
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	all, _ := s.List(ctx)
+	if got := codes(all); !equal(got, []string{"d", "c", "b", "a"}) {
+		t.Errorf("List: got %v", got)
+	}
+	mine, _ := s.ListByUserID(ctx, "alice")
+	if got := codes(mine); !equal(got, []string{"d", "b", "a"}) {
+		t.Errorf("ListByUserID: got %v", got)
+	}
+	page, summary, _ := s.ListByUserIDPaginated(ctx, "alice", 2, 1)
+	if got := codes(page); summary.Total != 3 || !equal(got, []string{"b", "a"}) {
+		t.Errorf("ListByUserIDPaginated: got %v of %d", got, summary.Total)
+	}
+	after, _ := s.ListByUserIDAfter(ctx, "alice", mine[0].CreatedAt, mine[0].ShortCode, 1)
+	if got := codes(after); !equal(got, []string{"b"}) {
+		t.Errorf("ListByUserIDAfter: got %v", got)
+	}
+}
+
+func TestMemoryStorage_IncrementClicks(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	if err := s.Save(ctx, &models.URL{ShortCode: "abc", LongURL: "https://example.com", CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := s.IncrementClicks(ctx, "abc"); err != nil {
+			t.Fatalf("IncrementClicks: %v", err)
+		}
+	}
+	if got, _ := s.GetByShortCode(ctx, "abc"); got.Clicks != 3 {
+		t.Errorf("expected 3 clicks, got %d", got.Clicks)
+	}
+	if err := s.IncrementClicks(ctx, "missing"); err == nil {
+		t.Error("expected an error for a missing code")
+	}
+}
+
+func TestMemoryStorage_GetURLStatsIncludesExpired(t *testing.T) {
+	ctx := context.Background()
+	s, clk := newClockedMemoryStorage()
+	now := clk.Now()
+	expires := now.Add(time.Hour)
+	if err := s.Save(ctx, &models.URL{ShortCode: "abc", UserID: "alice", CreatedAt: now, ExpiresAt: &expires}); err != nil {
+		t.Fatal(err)
+	}
+	_ = s.IncrementClicks(ctx, "abc")
+
+	clk.Advance(2 * time.Hour)
+	if got, _ := s.GetByShortCode(ctx, "abc"); got != nil {
+		t.Fatal("expected the link to have expired")
+	}
+	stats, err := s.GetURLStats(ctx, "abc")
+	if err != nil || stats == nil {
+		t.Fatalf("GetURLStats: %+v, %v", stats, err)
+	}
+	if stats.Clicks != 1 || stats.UserID != "alice" || !stats.ExpiresAt.Equal(expires) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if stats, _ := s.GetURLStats(ctx, "missing"); stats != nil {
+		t.Errorf("expected no stats for a missing code, got %+v", stats)
+	}
+}
+
+func TestMemoryStorage_Delete(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	if err := s.CreateCustomURL(ctx, &models.URL{ShortCode: "promo", LongURL: "https://example.com", UserID: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateCustomURL(ctx, &models.URL{ShortCode: "promo", LongURL: "https://other.example", UserID: "bob"}); !errors.Is(err, ErrShortCodeTaken) {
+		t.Errorf("expected the alias to be refused, got %v", err)
+	}
+
+	if err := s.Delete(ctx, "promo", "alice"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if got, _ := s.GetByShortCode(ctx, "promo"); got != nil {
+		t.Error("expected the deleted URL to be gone")
+	}
+	if err := s.Delete(ctx, "promo", "alice"); err == nil {
+		t.Error("expected deleting it twice to fail")
+	}
+
+	events, _ := s.ListEvents(ctx, "promo", false)
+	if len(events) != 2 || events[0].Action != models.URLEventCreate || events[1].Action != models.URLEventDelete || events[1].ActorID != "alice" {
+		t.Errorf("expected a create and a delete by alice, got %+v", events)
+	}
+}
+
+// TestMemoryStorage_DeleteManyByUser verifies that only the caller's links
+// among the codes are deleted, each with a delete event.
+func TestMemoryStorage_DeleteManyByUser(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	for _, u := range []struct{ code, owner string }{{"a1", "alice"}, {"a2", "alice"}, {"b1", "bob"}} {
+		if err := s.CreateCustomURL(ctx, &models.URL{ShortCode: u.code, LongURL: "https://example.com", UserID: u.owner}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, err := s.DeleteManyByUser(ctx, "alice", []string{"a1", "b1", "missing", "a2"})
+	if err != nil {
+		t.Fatalf("DeleteManyByUser: %v", err)
+	}
+	if len(deleted) != 2 || deleted[0].ShortCode != "a1" || deleted[1].ShortCode != "a2" {
+		t.Errorf("expected a1 and a2 deleted, got %+v", deleted)
+	}
+	if got, _ := s.GetByShortCode(ctx, "b1"); got == nil {
+		t.Error("expected bob's link left in place")
+	}
+	events, _ := s.ListEvents(ctx, "a2", false)
+	if len(events) != 2 || events[1].Action != models.URLEventDelete || events[1].ActorID != "alice" {
+		t.Errorf("expected a delete by alice, got %+v", events)
+	}
+}
+
+func TestMemoryStorage_Expiry(t *testing.T) {
+	ctx := context.Background()
+	s, clk := newClockedMemoryStorage()
+	now := clk.Now()
+	expiresAt := now.Add(time.Hour)
+	for _, u := range []*models.URL{
+		{ShortCode: "brief", LongURL: "https://example.com", UserID: "alice", CreatedAt: now, ExpiresAt: &expiresAt, Tags: []string{"promo"}},
+		{ShortCode: "forever", LongURL: "https://example.com", UserID: "alice", CreatedAt: now, Tags: []string{"promo"}},
+	} {
+		if err := s.Save(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if deleted, _ := s.DeleteExpiredURLs(ctx); len(deleted) != 0 {
+		t.Errorf("expected nothing to expire yet, deleted %v", deleted)
+	}
+
+	clk.Advance(2 * time.Hour)
+	if got, _ := s.GetByShortCode(ctx, "brief"); got != nil {
+		t.Error("expected an expired URL to be hidden")
+	}
+	if all, _ := s.List(ctx); len(all) != 1 || all[0].ShortCode != "forever" {
+		t.Errorf("expected only the unexpired URL to be listed, got %d", len(all))
+	}
+	if n, _ := s.CountActiveByUser(ctx, "alice"); n != 1 {
+		t.Errorf("expected 1 active URL, got %d", n)
+	}
+	if counts, _ := s.GetTagCounts(ctx, "alice"); len(counts) != 1 || counts[0].Count != 1 {
+		t.Errorf("expected the expired URL's tag not to count, got %+v", counts)
+	}
+	if taken, _ := s.AliasExists(ctx, "brief"); !taken {
+		t.Error("expected an expired code to stay taken until it is cleaned up")
+	}
+
+	deleted, err := s.DeleteExpiredURLs(ctx)
+	if err != nil || len(deleted) != 1 || deleted[0] != "brief" {
+		t.Fatalf("expected brief to be deleted, got %v, %v", deleted, err)
+	}
+	if taken, _ := s.AliasExists(ctx, "brief"); taken {
+		t.Error("expected the cleaned-up code to be free again")
+	}
+}
+
+func TestMemoryStorage_Restore(t *testing.T) {
+	ctx := context.Background()
+	s, clk := newClockedMemoryStorage()
+	now := clk.Now()
+	expiresAt := now.Add(time.Hour)
+	if err := s.Save(ctx, &models.URL{ShortCode: "brief", LongURL: "https://example.com", UserID: "alice", CreatedAt: now, ExpiresAt: &expiresAt, Tags: []string{"promo"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	renewed := now.Add(3 * time.Hour)
+	if err := s.Restore(ctx, &models.URL{ShortCode: "brief", UserID: "alice", ExpiresAt: &renewed}); !errors.Is(err, ErrShortCodeTaken) {
+		t.Errorf("expected a live URL to be refused, got %v", err)
+	}
+
+	clk.Advance(2 * time.Hour)
+	if err := s.Restore(ctx, &models.URL{ShortCode: "brief", UserID: "bob", ExpiresAt: &renewed}); !errors.Is(err, ErrShortCodeTaken) {
+		t.Errorf("expected someone else's expired URL to be refused, got %v", err)
+	}
+	url := &models.URL{ShortCode: "brief", UserID: "alice", ExpiresAt: &renewed}
+	if err := s.Restore(ctx, url); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	got, _ := s.GetByShortCode(ctx, "brief")
+	if got == nil || len(got.Tags) != 1 || !got.ExpiresAt.Equal(renewed) || url.LongURL != "https://example.com" {
+		t.Errorf("expected the expired URL renewed in place, got %+v", got)
+	}
+
+	if err := s.Delete(ctx, "brief", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Restore(ctx, &models.URL{ShortCode: "brief", LongURL: "https://example.com", UserID: "alice", CreatedAt: clk.Now()}); err != nil {
+		t.Fatalf("Restore after delete: %v", err)
+	}
+	events, _ := s.ListEvents(ctx, "brief", false)
+	var actions []string
+	for _, ev := range events {
+		actions = append(actions, ev.Action)
+	}
+	if len(actions) != 4 || actions[3] != models.URLEventRestore {
+		t.Errorf("expected create, restore, delete, restore, got %v", actions)
+	}
+}
+
+// TestMemoryStorage_ListSummary checks the list aggregates against the rows
+// themselves: they cover every matching URL, not just the page, and count
+// expired and not-yet-active links apart.
+func TestMemoryStorage_ListSummary(t *testing.T) {
+	ctx := context.Background()
+	s, clk := newClockedMemoryStorage()
+	now := clk.Now()
+	expired := now.Add(-time.Minute)
+	scheduled := now.Add(time.Hour)
+	for i, u := range []*models.URL{
+		{ShortCode: "a", Clicks: 3},
+		{ShortCode: "b", Clicks: 5, Tags: []string{"work"}},
+		{ShortCode: "c", Clicks: 0, ActiveFrom: &scheduled, Tags: []string{"work"}},
+		{ShortCode: "d", Clicks: 7, ExpiresAt: &expired, Tags: []string{"work"}},
+		{ShortCode: "e", Clicks: 11, UserID: "bob"},
+	} {
+		if u.UserID == "" {
+			u.UserID = "alice"
+		}
+		u.LongURL = "https://example.com"
+		u.CreatedAt = now.Add(time.Duration(i) * time.Minute)
+		if err := s.Save(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// summarize recomputes the aggregates from the full listing.
+	summarize := func(urls []*models.URL) models.URLListSummary {
+		var want models.URLListSummary
+		for _, u := range urls {
+			want.Total++
+			want.TotalClicks += u.Clicks
+			if u.ActiveFrom == nil || !u.ActiveFrom.After(now) {
+				want.ActiveCount++
+			}
+		}
+		return want
+	}
+
+	mine, _ := s.ListByUserID(ctx, "alice")
+	want := summarize(mine)
+	want.ExpiredCount = 1
+	if want != (models.URLListSummary{Total: 3, TotalClicks: 8, ActiveCount: 2, ExpiredCount: 1}) {
+		t.Fatalf("unexpected fixture summary %+v", want)
+	}
+	for _, offset := range []int32{0, 2, 10} {
+		_, got, err := s.ListByUserIDPaginated(ctx, "alice", 1, offset)
+		if err != nil || got != want {
+			t.Errorf("ListByUserIDPaginated at offset %d: expected %+v, got %+v, %v", offset, want, got, err)
+		}
+	}
+
+	all, _ := s.List(ctx)
+	want = summarize(all)
+	want.ExpiredCount = 1
+	if _, got, _ := s.ListPaginated(ctx, 2, 0); got != want {
+		t.Errorf("ListPaginated: expected %+v, got %+v", want, got)
+	}
+
+	want = models.URLListSummary{Total: 2, TotalClicks: 5, ActiveCount: 1, ExpiredCount: 1}
+	if _, got, _ := s.ListByUserIDAndTag(ctx, "alice", "work", 1, 0); got != want {
+		t.Errorf("ListByUserIDAndTag: expected %+v, got %+v", want, got)
+	}
+}
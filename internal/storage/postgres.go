@@ -4,12 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/Varun5711/shorternit/internal/database"
 	"github.com/Varun5711/shorternit/internal/models"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // PostgresStorage is the production implementation of the Storage interface,
@@ -32,56 +32,244 @@ func NewPostgresStorage(db *database.DBManager) *PostgresStorage {
 // Save inserts a new URL record into the urls table on the primary database.
 // All fields are caller-provided except updated_at, which is set to NOW() at
 // insert time. The write goes through db.Write() to ensure it hits the primary.
+// A/B variants and geo rules are inserted in the same transaction, so a split
+// or geo-targeted link is never visible without its destinations, and so is
+// the create event of the audit trail, attributed to the link's owner. A
+// short code that is already taken fails with ErrShortCodeTaken.
 func (s *PostgresStorage) Save(ctx context.Context, url *models.URL) error {
-	// INSERT a complete URL row. $1-$8 map to the URL struct fields plus the
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	// INSERT a complete URL row. $1-$14 map to the URL struct fields plus the
 	// current timestamp for updated_at.
 	query := `
-		INSERT INTO urls (short_code, long_url, clicks, expires_at, qr_code, user_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO urls (short_code, long_url, clicks, max_clicks, active_from, expires_at, tags, qr_code, user_id, domain, preview, backup_url, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
 
-	_, err := s.db.Write().Exec(ctx, query,
+	args := []any{
 		url.ShortCode,
 		url.LongURL,
 		url.Clicks,
+		url.MaxClicks,
+		url.ActiveFrom,
 		url.ExpiresAt,
+		tagsOrEmpty(url.Tags),
 		url.QRCode,
 		url.UserID,
+		url.Domain,
+		url.Preview,
+		url.BackupURL,
 		url.CreatedAt,
 		time.Now(),
-	)
+	}
 
+	tx, err := s.db.Write().Begin(ctx)
 	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return fmt.Errorf("failed to save URL: %w", ErrShortCodeTaken)
+		}
 		return fmt.Errorf("failed to save URL: %w", err)
 	}
 
+	if len(url.Variants) > 0 {
+		longURLs := make([]string, len(url.Variants))
+		weights := make([]int32, len(url.Variants))
+		for i, v := range url.Variants {
+			longURLs[i], weights[i] = v.LongURL, v.Weight
+		}
+
+		// WITH ORDINALITY numbers the unnested rows from 1, which becomes
+		// each variant's position.
+		_, err = tx.Exec(ctx, `
+			INSERT INTO url_variants (short_code, position, long_url, weight)
+			SELECT $1, v.position, v.long_url, v.weight
+			FROM unnest($2::text[], $3::int[]) WITH ORDINALITY AS v(long_url, weight, position)
+		`, url.ShortCode, longURLs, weights)
+		if err != nil {
+			return fmt.Errorf("failed to save URL variants: %w", err)
+		}
+	}
+
+	if len(url.GeoRules) > 0 {
+		countries := make([]string, len(url.GeoRules))
+		longURLs := make([]string, len(url.GeoRules))
+		for i, rule := range url.GeoRules {
+			countries[i], longURLs[i] = rule.CountryCode, rule.LongURL
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO geo_rules (short_code, country_code, long_url)
+			SELECT $1, g.country_code, g.long_url
+			FROM unnest($2::text[], $3::text[]) AS g(country_code, long_url)
+		`, url.ShortCode, countries, longURLs)
+		if err != nil {
+			return fmt.Errorf("failed to save URL geo rules: %w", err)
+		}
+	}
+
+	if err := s.RecordEvent(ctx, tx, &models.URLEvent{
+		ShortCode:  url.ShortCode,
+		Action:     models.URLEventCreate,
+		ActorID:    url.UserID,
+		AfterURL:   url.LongURL,
+		OccurredAt: url.CreatedAt,
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit URL: %w", err)
+	}
 	return nil
 }
 
+// saveBatchQuery inserts one URL row, skipping it when the short code is
+// already taken so a conflict does not abort the surrounding batch. The
+// same statement records the create event of each row inserted, so the
+// rows affected are the events written: one per new link.
+const saveBatchQuery = `
+	WITH inserted AS (
+		INSERT INTO urls (short_code, long_url, clicks, max_clicks, active_from, expires_at, tags, qr_code, user_id, domain, preview, backup_url, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (short_code) DO NOTHING
+		RETURNING short_code, long_url, user_id, created_at
+	)
+	INSERT INTO url_events (short_code, action, actor_id, after_url, occurred_at)
+	SELECT short_code, 'create', COALESCE(user_id, ''), long_url, created_at FROM inserted
+`
+
+// SaveBatch inserts many URL records and returns one error per input URL:
+// nil when the row was inserted, ErrShortCodeTaken when the short code
+// already existed, or the database error for that row.
+//
+// All rows normally go to the primary in a single round-trip. pgx runs a
+// batch as one implicit transaction, though, so a single failing row rolls
+// back every other row too; in that case the rows are retried one at a time
+// so each gets its own outcome.
+func (s *PostgresStorage) SaveBatch(ctx context.Context, urls []*models.URL) []error {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	now := time.Now()
+
+	batch := &pgx.Batch{}
+	for _, url := range urls {
+		batch.Queue(saveBatchQuery, saveBatchArgs(url, now)...)
+	}
+
+	errs := make([]error, len(urls))
+	results := s.db.Write().SendBatch(ctx, batch)
+	var batchErr error
+	for i := range urls {
+		tag, err := results.Exec()
+		if err != nil {
+			batchErr = err
+			break
+		}
+		if tag.RowsAffected() == 0 {
+			errs[i] = ErrShortCodeTaken
+		}
+	}
+	if err := results.Close(); batchErr == nil {
+		batchErr = err
+	}
+	if batchErr == nil {
+		return errs
+	}
+
+	for i, url := range urls {
+		tag, err := s.db.Write().Exec(ctx, saveBatchQuery, saveBatchArgs(url, now)...)
+		switch {
+		case err != nil:
+			errs[i] = fmt.Errorf("failed to save URL: %w", err)
+		case tag.RowsAffected() == 0:
+			errs[i] = ErrShortCodeTaken
+		default:
+			errs[i] = nil
+		}
+	}
+	return errs
+}
+
+// saveBatchArgs returns the saveBatchQuery parameters for url.
+func saveBatchArgs(url *models.URL, now time.Time) []any {
+	return []any{
+		url.ShortCode,
+		url.LongURL,
+		url.Clicks,
+		url.MaxClicks,
+		url.ActiveFrom,
+		url.ExpiresAt,
+		tagsOrEmpty(url.Tags),
+		url.QRCode,
+		url.UserID,
+		url.Domain,
+		url.Preview,
+		url.BackupURL,
+		url.CreatedAt,
+		now,
+	}
+}
+
 // GetByShortCode fetches a single URL by its short code from a read replica.
 // Expired URLs (expires_at <= NOW()) are excluded at the query level so
 // callers never see stale links. Returns (nil, nil) when no matching row
 // exists, allowing the service layer to distinguish "not found" from a real
 // database error without sentinel error types.
 func (s *PostgresStorage) GetByShortCode(ctx context.Context, shortCode string) (*models.URL, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
 	// SELECT the URL only if it has not expired. COALESCE guards against NULL
-	// qr_code values so the Go string field is always populated (empty string
-	// rather than a scan error).
+	// qr_code, user_id and preview values so the Go string fields are always populated
+	// (empty string rather than a scan error). The ARRAY subqueries return
+	// the A/B variants in position order (empty for a single destination)
+	// and the geo rules by country code.
 	query := `
-		SELECT short_code, long_url, clicks, created_at, expires_at, COALESCE(qr_code, '')
+		SELECT short_code, long_url, clicks, max_clicks, created_at, active_from, expires_at, tags, COALESCE(qr_code, ''), COALESCE(user_id, ''), domain, preview, backup_url, NOT is_active,
+			COALESCE(title, ''), COALESCE(description, ''), COALESCE(image_url, ''),
+			ARRAY(SELECT v.long_url FROM url_variants v WHERE v.short_code = urls.short_code ORDER BY v.position),
+			ARRAY(SELECT v.weight FROM url_variants v WHERE v.short_code = urls.short_code ORDER BY v.position),
+			ARRAY(SELECT g.country_code::text FROM geo_rules g WHERE g.short_code = urls.short_code ORDER BY g.country_code),
+			ARRAY(SELECT g.long_url FROM geo_rules g WHERE g.short_code = urls.short_code ORDER BY g.country_code)
 		FROM urls
 		WHERE short_code = $1
 		AND (expires_at IS NULL OR expires_at > NOW())
 	`
 
 	var url models.URL
+	var variantURLs []string
+	var variantWeights []int32
+	var geoCountries, geoURLs []string
 	err := s.db.Read().QueryRow(ctx, query, shortCode).Scan(
 		&url.ShortCode,
 		&url.LongURL,
 		&url.Clicks,
+		&url.MaxClicks,
 		&url.CreatedAt,
+		&url.ActiveFrom,
 		&url.ExpiresAt,
+		&url.Tags,
 		&url.QRCode,
+		&url.UserID,
+		&url.Domain,
+		&url.Preview,
+		&url.BackupURL,
+		&url.Disabled,
+		&url.Title,
+		&url.Description,
+		&url.ImageURL,
+		&variantURLs,
+		&variantWeights,
+		&geoCountries,
+		&geoURLs,
 	)
 
 	if err == pgx.ErrNoRows {
@@ -92,14 +280,125 @@ func (s *PostgresStorage) GetByShortCode(ctx context.Context, shortCode string)
 		return nil, fmt.Errorf("failed to get URL: %w", err)
 	}
 
+	for i := range variantURLs {
+		url.Variants = append(url.Variants, models.URLVariant{LongURL: variantURLs[i], Weight: variantWeights[i]})
+	}
+	for i := range geoCountries {
+		url.GeoRules = append(url.GeoRules, models.GeoRule{CountryCode: geoCountries[i], LongURL: geoURLs[i]})
+	}
+
 	return &url, nil
 }
 
+// GetURLStats reads the counters and timestamps of a URL from its row,
+// including an expired one the cleanup-worker has not removed yet. It
+// returns (nil, nil) if no row has the short code.
+func (s *PostgresStorage) GetURLStats(ctx context.Context, shortCode string) (*models.URLStats, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	query := `
+		SELECT short_code, COALESCE(user_id, ''), clicks, max_clicks, created_at, updated_at, expires_at
+		FROM urls
+		WHERE short_code = $1
+	`
+
+	var stats models.URLStats
+	err := s.db.Read().QueryRow(ctx, query, shortCode).Scan(
+		&stats.ShortCode,
+		&stats.UserID,
+		&stats.Clicks,
+		&stats.MaxClicks,
+		&stats.CreatedAt,
+		&stats.UpdatedAt,
+		&stats.ExpiresAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get URL stats: %w", err)
+	}
+	return &stats, nil
+}
+
+// ListTopByClicks returns up to limit non-expired URLs with the most clicks,
+// most clicked first, each with its owner, A/B variants and geo rules as
+// loaded by GetByShortCode. It is used to warm the redirect cache after a
+// deploy; the owner lets them bypass a warmed entry as they would any other.
+func (s *PostgresStorage) ListTopByClicks(ctx context.Context, limit int) ([]*models.URL, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	query := `
+		SELECT short_code, long_url, COALESCE(user_id, ''), clicks, max_clicks, created_at, active_from, expires_at, domain, preview, backup_url, NOT is_active,
+			ARRAY(SELECT v.long_url FROM url_variants v WHERE v.short_code = urls.short_code ORDER BY v.position),
+			ARRAY(SELECT v.weight FROM url_variants v WHERE v.short_code = urls.short_code ORDER BY v.position),
+			ARRAY(SELECT g.country_code::text FROM geo_rules g WHERE g.short_code = urls.short_code ORDER BY g.country_code),
+			ARRAY(SELECT g.long_url FROM geo_rules g WHERE g.short_code = urls.short_code ORDER BY g.country_code)
+		FROM urls
+		WHERE (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY clicks DESC, short_code
+		LIMIT $1
+	`
+
+	rows, err := s.db.Read().Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list top URLs: %w", err)
+	}
+	defer rows.Close()
+
+	var urls []*models.URL
+	for rows.Next() {
+		var url models.URL
+		var variantURLs []string
+		var variantWeights []int32
+		var geoCountries, geoURLs []string
+		err := rows.Scan(
+			&url.ShortCode,
+			&url.LongURL,
+			&url.UserID,
+			&url.Clicks,
+			&url.MaxClicks,
+			&url.CreatedAt,
+			&url.ActiveFrom,
+			&url.ExpiresAt,
+			&url.Domain,
+			&url.Preview,
+			&url.BackupURL,
+			&url.Disabled,
+			&variantURLs,
+			&variantWeights,
+			&geoCountries,
+			&geoURLs,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		for i := range variantURLs {
+			url.Variants = append(url.Variants, models.URLVariant{LongURL: variantURLs[i], Weight: variantWeights[i]})
+		}
+		for i := range geoCountries {
+			url.GeoRules = append(url.GeoRules, models.GeoRule{CountryCode: geoCountries[i], LongURL: geoURLs[i]})
+		}
+		urls = append(urls, &url)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return urls, nil
+}
+
 // IncrementClicks atomically increments the click counter for a URL on the
 // primary database. The UPDATE also bumps updated_at so downstream consumers
 // (analytics, replication) can detect the change. If no row matches the short
 // code, an error is returned (the URL may have been deleted or never existed).
 func (s *PostgresStorage) IncrementClicks(ctx context.Context, shortCode string) error {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
 	// Atomic increment: clicks = clicks + 1 avoids read-modify-write races
 	// when multiple redirects happen concurrently.
 	query := `
@@ -125,10 +424,13 @@ func (s *PostgresStorage) IncrementClicks(ctx context.Context, shortCode string)
 // reading from a replica. This method returns an unbounded result set -- for
 // production paginated access, use ListPaginated instead.
 func (s *PostgresStorage) List(ctx context.Context) ([]*models.URL, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
 	// SELECT all active URLs. COALESCE on qr_code and user_id converts NULLs
 	// to empty strings to avoid pgx scan errors on Go string fields.
 	query := `
-		SELECT short_code, long_url, clicks, created_at, expires_at, COALESCE(qr_code, ''), COALESCE(user_id, '')
+		SELECT short_code, long_url, clicks, created_at, expires_at, COALESCE(qr_code, ''), COALESCE(user_id, ''), domain
 		FROM urls
 		WHERE (expires_at IS NULL OR expires_at > NOW())
 		ORDER BY created_at DESC
@@ -151,6 +453,7 @@ func (s *PostgresStorage) List(ctx context.Context) ([]*models.URL, error) {
 			&url.ExpiresAt,
 			&url.QRCode,
 			&url.UserID,
+			&url.Domain,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
@@ -165,97 +468,473 @@ func (s *PostgresStorage) List(ctx context.Context) ([]*models.URL, error) {
 	return urls, nil
 }
 
-// Delete hard-deletes a URL record from the primary database. Returns an
-// error if the short code does not exist (RowsAffected == 0).
-func (s *PostgresStorage) Delete(ctx context.Context, shortCode string) error {
+// Delete hard-deletes a URL record from the primary database and records
+// the delete event, by actorID, in the same transaction. Returns an error if
+// the short code does not exist.
+func (s *PostgresStorage) Delete(ctx context.Context, shortCode, actorID string) error {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	tx, err := s.db.Write().Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
 	// Hard DELETE by short_code. No soft-delete is used because expired URLs
 	// are already cleaned up by DeleteExpiredURLs.
-	query := `DELETE FROM urls WHERE short_code = $1`
-	cmdTag, err := s.db.Write().Exec(ctx, query, shortCode)
+	var longURL string
+	err = tx.QueryRow(ctx, `DELETE FROM urls WHERE short_code = $1 RETURNING long_url`, shortCode).Scan(&longURL)
+	if err == pgx.ErrNoRows {
+		return fmt.Errorf("URL with short code %s not found", shortCode)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to delete URL: %w", err)
 	}
-	if cmdTag.RowsAffected() == 0 {
-		return fmt.Errorf("URL with short code %s not found", shortCode)
+
+	if err := s.RecordEvent(ctx, tx, &models.URLEvent{
+		ShortCode: shortCode,
+		Action:    models.URLEventDelete,
+		ActorID:   actorID,
+		BeforeURL: longURL,
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit URL deletion: %w", err)
 	}
 	return nil
 }
 
-// ListPaginated returns a single page of non-expired URLs along with the
-// total count of matching rows, both served from a read replica. The total
-// count is fetched first (a separate COUNT query) so the client can render
-// pagination controls. Results are sorted newest-first.
-func (s *PostgresStorage) ListPaginated(ctx context.Context, limit, offset int32) ([]*models.URL, int32, error) {
-	var total int32
-	// COUNT all active (non-expired) URLs to support client-side pagination.
-	countQuery := `SELECT COUNT(*) FROM urls WHERE (expires_at IS NULL OR expires_at > NOW())`
-	if err := s.db.Read().QueryRow(ctx, countQuery).Scan(&total); err != nil {
-		return nil, 0, fmt.Errorf("failed to count URLs: %w", err)
+// DeleteManyByUser hard-deletes the links among shortCodes owned by userID
+// in one statement, and records their delete events in the same
+// transaction. Expired links are deleted too, like Delete does.
+func (s *PostgresStorage) DeleteManyByUser(ctx context.Context, userID string, shortCodes []string) ([]*models.URL, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	tx, err := s.db.Write().Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer func() { _ = tx.Rollback(ctx) }()
 
-	query := `
-		SELECT short_code, long_url, clicks, created_at, expires_at, COALESCE(qr_code, ''), COALESCE(user_id, '')
-		FROM urls
-		WHERE (expires_at IS NULL OR expires_at > NOW())
+	rows, err := tx.Query(ctx, `
+		DELETE FROM urls
+		WHERE short_code = ANY($1) AND user_id = $2
+		RETURNING short_code, long_url, COALESCE(qr_code, '')
+	`, shortCodes, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete URLs: %w", err)
+	}
+	var deleted []*models.URL
+	for rows.Next() {
+		url := &models.URL{UserID: userID}
+		if err := rows.Scan(&url.ShortCode, &url.LongURL, &url.QRCode); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		deleted = append(deleted, url)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to delete URLs: %w", err)
+	}
+
+	for _, url := range deleted {
+		if err := s.RecordEvent(ctx, tx, &models.URLEvent{
+			ShortCode: url.ShortCode,
+			Action:    models.URLEventDelete,
+			ActorID:   userID,
+			BeforeURL: url.LongURL,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit URL deletion: %w", err)
+	}
+	return deleted, nil
+}
+
+// Restore brings back an expired or deleted link on the primary database,
+// in one transaction with its restore event. An expired row the cleanup has
+// not removed yet is updated in place, provided it is still url.UserID's;
+// otherwise url is inserted, and the primary key refuses a short code that
+// someone else has claimed in the meantime with ErrShortCodeTaken.
+func (s *PostgresStorage) Restore(ctx context.Context, url *models.URL) error {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	tx, err := s.db.Write().Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	err = tx.QueryRow(ctx, `
+		UPDATE urls SET expires_at = $3, updated_at = NOW()
+		WHERE short_code = $1 AND user_id = $2
+		AND expires_at IS NOT NULL AND expires_at <= NOW()
+		RETURNING long_url, domain
+	`, url.ShortCode, url.UserID, url.ExpiresAt).Scan(&url.LongURL, &url.Domain)
+	if err == pgx.ErrNoRows {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO urls (short_code, long_url, clicks, max_clicks, expires_at, tags, user_id, domain, created_at, updated_at)
+			VALUES ($1, $2, 0, 0, $3, '{}', $4, $5, $6, NOW())
+		`, url.ShortCode, url.LongURL, url.ExpiresAt, url.UserID, url.Domain, url.CreatedAt)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return fmt.Errorf("failed to restore URL: %w", ErrShortCodeTaken)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore URL: %w", err)
+	}
+
+	if err := s.RecordEvent(ctx, tx, &models.URLEvent{
+		ShortCode: url.ShortCode,
+		Action:    models.URLEventRestore,
+		ActorID:   url.UserID,
+		AfterURL:  url.LongURL,
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit URL restore: %w", err)
+	}
+	return nil
+}
+
+// ListPaginated returns a single page of non-expired URLs, newest-first,
+// along with the summary of every URL, both served from a read replica.
+func (s *PostgresStorage) ListPaginated(ctx context.Context, limit, offset int32) ([]*models.URL, models.URLListSummary, error) {
+	return s.listPage(ctx, "TRUE", limit, offset)
+}
+
+// ListByUserIDPaginated returns a single page of non-expired URLs owned by
+// the specified user, along with the summary of all the user's URLs. Like
+// ListPaginated, it runs against a read replica.
+func (s *PostgresStorage) ListByUserIDPaginated(ctx context.Context, userID string, limit, offset int32) ([]*models.URL, models.URLListSummary, error) {
+	return s.listPage(ctx, "user_id = $1", limit, offset, userID)
+}
+
+// listPage runs the paginated listing for every URL matching filter, a
+// WHERE condition over args ($1..$n). The summary is computed in the same
+// query as window aggregates over all matching rows -- expired ones included
+// so they can be counted -- before the outer query drops the expired rows
+// and applies LIMIT/OFFSET. A page past the end has no rows to carry the
+// aggregates, so only then is the summary fetched on its own.
+func (s *PostgresStorage) listPage(ctx context.Context, filter string, limit, offset int32, args ...interface{}) ([]*models.URL, models.URLListSummary, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	var summary models.URLListSummary
+	matched := `SELECT *, (expires_at IS NULL OR expires_at > NOW()) AS live FROM urls WHERE ` + filter
+	query := fmt.Sprintf(`
+		SELECT short_code, long_url, clicks, max_clicks, created_at, active_from, expires_at, tags, COALESCE(qr_code, ''), COALESCE(user_id, ''), domain, preview, backup_url, NOT is_active,
+			COALESCE(title, ''), COALESCE(description, ''), COALESCE(image_url, ''),
+			total, total_clicks, active_count, expired_count
+		FROM (
+			SELECT *,
+				(COUNT(*) FILTER (WHERE live) OVER ())::int AS total,
+				COALESCE(SUM(clicks) FILTER (WHERE live) OVER (), 0)::bigint AS total_clicks,
+				(COUNT(*) FILTER (WHERE live AND is_active AND (active_from IS NULL OR active_from <= NOW())) OVER ())::int AS active_count,
+				(COUNT(*) FILTER (WHERE NOT live) OVER ())::int AS expired_count
+			FROM (%s) matched
+		) summarized
+		WHERE live
 		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
-	`
-	rows, err := s.db.Read().Query(ctx, query, limit, offset)
+		LIMIT $%d OFFSET $%d
+	`, matched, len(args)+1, len(args)+2)
+	rows, err := s.db.Read().Query(ctx, query, append(args, limit, offset)...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list URLs: %w", err)
+		return nil, summary, fmt.Errorf("failed to list URLs: %w", err)
 	}
 	defer rows.Close()
 
 	var urls []*models.URL
 	for rows.Next() {
 		var url models.URL
-		if err := rows.Scan(&url.ShortCode, &url.LongURL, &url.Clicks, &url.CreatedAt, &url.ExpiresAt, &url.QRCode, &url.UserID); err != nil {
-			return nil, 0, fmt.Errorf("failed to scan row: %w", err)
+		if err := rows.Scan(&url.ShortCode, &url.LongURL, &url.Clicks, &url.MaxClicks, &url.CreatedAt, &url.ActiveFrom, &url.ExpiresAt, &url.Tags, &url.QRCode, &url.UserID, &url.Domain, &url.Preview, &url.BackupURL, &url.Disabled, &url.Title, &url.Description, &url.ImageURL,
+			&summary.Total, &summary.TotalClicks, &summary.ActiveCount, &summary.ExpiredCount); err != nil {
+			return nil, summary, fmt.Errorf("failed to scan row: %w", err)
 		}
 		urls = append(urls, &url)
 	}
 	if err = rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("error iterating rows: %w", err)
+		return nil, summary, fmt.Errorf("error iterating rows: %w", err)
+	}
+	if len(urls) > 0 {
+		return urls, summary, nil
 	}
-	return urls, total, nil
+
+	summaryQuery := `
+		SELECT
+			(COUNT(*) FILTER (WHERE live))::int,
+			COALESCE(SUM(clicks) FILTER (WHERE live), 0)::bigint,
+			(COUNT(*) FILTER (WHERE live AND is_active AND (active_from IS NULL OR active_from <= NOW())))::int,
+			(COUNT(*) FILTER (WHERE NOT live))::int
+		FROM (` + matched + `) matched
+	`
+	if err := s.db.Read().QueryRow(ctx, summaryQuery, args...).Scan(&summary.Total, &summary.TotalClicks, &summary.ActiveCount, &summary.ExpiredCount); err != nil {
+		return nil, summary, fmt.Errorf("failed to count URLs: %w", err)
+	}
+	return nil, summary, nil
 }
 
-// ListByUserIDPaginated returns a single page of non-expired URLs owned by
-// the specified user, along with the total count. Like ListPaginated, both
-// queries run against a read replica.
-func (s *PostgresStorage) ListByUserIDPaginated(ctx context.Context, userID string, limit, offset int32) ([]*models.URL, int32, error) {
-	var total int32
-	// COUNT only URLs belonging to this user that have not expired.
-	countQuery := `SELECT COUNT(*) FROM urls WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > NOW())`
-	if err := s.db.Read().QueryRow(ctx, countQuery, userID).Scan(&total); err != nil {
-		return nil, 0, fmt.Errorf("failed to count URLs: %w", err)
+// CountActiveByUser returns how many non-expired URLs userID owns, for the
+// per-user link quota. It reads from the primary so links the user has just
+// created are counted.
+func (s *PostgresStorage) CountActiveByUser(ctx context.Context, userID string) (int64, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	var count int64
+	query := `SELECT COUNT(*) FROM urls WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > NOW())`
+	if err := s.db.Write().QueryRow(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count URLs: %w", err)
+	}
+	return count, nil
+}
+
+// ListByUserIDAndTag returns a page of non-expired URLs owned by userID that
+// carry the given tag, along with the summary of all such URLs. Tags are
+// stored normalized, so tag must already be lowercase. The containment
+// operator (tags @> ARRAY[tag]) is what lets idx_urls_tags serve the filter.
+func (s *PostgresStorage) ListByUserIDAndTag(ctx context.Context, userID, tag string, limit, offset int32) ([]*models.URL, models.URLListSummary, error) {
+	return s.listPage(ctx, "user_id = $1 AND tags @> ARRAY[$2::text]", limit, offset, userID, tag)
+}
+
+// GetTagCounts returns every distinct tag on the user's non-expired URLs with
+// the number of URLs carrying it, most used first (ties alphabetical).
+func (s *PostgresStorage) GetTagCounts(ctx context.Context, userID string) ([]models.TagCount, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	query := `
+		SELECT tag, COUNT(*)
+		FROM urls, unnest(tags) AS tag
+		WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > NOW())
+		GROUP BY tag
+		ORDER BY COUNT(*) DESC, tag
+	`
+	rows, err := s.db.Read().Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag counts: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []models.TagCount
+	for rows.Next() {
+		var tc models.TagCount
+		if err := rows.Scan(&tc.Tag, &tc.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		counts = append(counts, tc)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return counts, nil
+}
+
+// UpdateTags replaces the tags of a URL on the primary database and records
+// the update event, by actorID, in the same transaction. Returns an error if
+// the short code does not exist.
+func (s *PostgresStorage) UpdateTags(ctx context.Context, shortCode string, tags []string, actorID string) error {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	tx, err := s.db.Write().Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var longURL string
+	query := `UPDATE urls SET tags = $2, updated_at = NOW() WHERE short_code = $1 RETURNING long_url`
+	err = tx.QueryRow(ctx, query, shortCode, tagsOrEmpty(tags)).Scan(&longURL)
+	if err == pgx.ErrNoRows {
+		return fmt.Errorf("URL with short code %s not found", shortCode)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update tags: %w", err)
+	}
+
+	// Tags leave the destination as it was.
+	if err := s.RecordEvent(ctx, tx, &models.URLEvent{
+		ShortCode: shortCode,
+		Action:    models.URLEventUpdate,
+		ActorID:   actorID,
+		BeforeURL: longURL,
+		AfterURL:  longURL,
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit tags: %w", err)
+	}
+	return nil
+}
+
+// SetActive sets is_active on a URL, recording the change in the same
+// transaction. The old value is read under the row lock, so of two
+// concurrent disables only one is recorded.
+func (s *PostgresStorage) SetActive(ctx context.Context, shortCode string, active bool, actorID string) error {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	tx, err := s.db.Write().Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	query := `
+		UPDATE urls u SET is_active = $2, updated_at = NOW()
+		FROM (SELECT short_code, is_active FROM urls WHERE short_code = $1 FOR UPDATE) old
+		WHERE u.short_code = old.short_code
+		RETURNING u.long_url, old.is_active
+	`
+	var longURL string
+	var wasActive bool
+	err = tx.QueryRow(ctx, query, shortCode, active).Scan(&longURL, &wasActive)
+	if err == pgx.ErrNoRows {
+		return fmt.Errorf("URL with short code %s not found", shortCode)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set URL active: %w", err)
 	}
+	if wasActive == active {
+		return nil
+	}
+
+	action := models.URLEventDisable
+	if active {
+		action = models.URLEventEnable
+	}
+	if err := s.RecordEvent(ctx, tx, &models.URLEvent{
+		ShortCode: shortCode,
+		Action:    action,
+		ActorID:   actorID,
+		BeforeURL: longURL,
+		AfterURL:  longURL,
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit URL active: %w", err)
+	}
+	return nil
+}
+
+// UpdateQRCode replaces the qr_code of userID's URL and returns the previous
+// value, read under the row lock so a concurrent update cannot hand two
+// callers the same old image to clean up.
+func (s *PostgresStorage) UpdateQRCode(ctx context.Context, shortCode, userID, qrCode string) (string, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
 
 	query := `
-		SELECT short_code, long_url, clicks, created_at, expires_at, COALESCE(qr_code, ''), COALESCE(user_id, '')
+		UPDATE urls u SET qr_code = $3, updated_at = NOW()
+		FROM (SELECT short_code, qr_code FROM urls WHERE short_code = $1 FOR UPDATE) old
+		WHERE u.short_code = old.short_code AND u.user_id = $2
+		RETURNING COALESCE(old.qr_code, '')
+	`
+	var previous string
+	err := s.db.Write().QueryRow(ctx, query, shortCode, userID, qrCode).Scan(&previous)
+	if err == pgx.ErrNoRows {
+		return "", fmt.Errorf("URL with short code %s not found", shortCode)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to update QR code: %w", err)
+	}
+	return previous, nil
+}
+
+// SavePreview stores the preview fetched from a URL's destination: its page
+// title, description and image URL. It returns nil when the URL has been
+// deleted in the meantime, since the preview is then simply not needed.
+func (s *PostgresStorage) SavePreview(ctx context.Context, shortCode, title, description, imageURL string) error {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	_, err := s.db.Write().Exec(ctx,
+		`UPDATE urls SET title = $2, description = $3, image_url = $4 WHERE short_code = $1`,
+		shortCode, title, description, imageURL,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save preview: %w", err)
+	}
+	return nil
+}
+
+// tagsOrEmpty maps a nil tag slice to an empty one; pgx encodes nil as NULL,
+// which the NOT NULL tags column rejects.
+func tagsOrEmpty(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// ListByUserIDAfter returns up to limit non-expired URLs owned by userID in
+// (created_at, short_code) descending order, starting strictly after the
+// given position. A zero afterCreatedAt starts from the newest URL.
+//
+// Unlike ListByUserIDPaginated this is keyset pagination: each page is an
+// index range scan on idx_urls_user_created, so walking a large account costs
+// the same per page no matter how deep the walk is, and there is no COUNT.
+func (s *PostgresStorage) ListByUserIDAfter(ctx context.Context, userID string, afterCreatedAt time.Time, afterShortCode string, limit int32) ([]*models.URL, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	query := `
+		SELECT short_code, long_url, clicks, max_clicks, created_at, active_from, expires_at, tags, COALESCE(qr_code, ''), COALESCE(user_id, ''), domain
 		FROM urls
 		WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > NOW())
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
+		ORDER BY created_at DESC, short_code DESC
+		LIMIT $2
+	`
+	args := []any{userID, limit}
+	if !afterCreatedAt.IsZero() {
+		// Row-value comparison keeps ties on created_at stable by falling
+		// through to short_code.
+		query = `
+		SELECT short_code, long_url, clicks, max_clicks, created_at, active_from, expires_at, tags, COALESCE(qr_code, ''), COALESCE(user_id, ''), domain
+		FROM urls
+		WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > NOW())
+			AND (created_at, short_code) < ($3, $4)
+		ORDER BY created_at DESC, short_code DESC
+		LIMIT $2
 	`
-	rows, err := s.db.Read().Query(ctx, query, userID, limit, offset)
+		args = append(args, afterCreatedAt, afterShortCode)
+	}
+
+	rows, err := s.db.Read().Query(ctx, query, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list URLs: %w", err)
+		return nil, fmt.Errorf("failed to list URLs: %w", err)
 	}
 	defer rows.Close()
 
 	var urls []*models.URL
 	for rows.Next() {
 		var url models.URL
-		if err := rows.Scan(&url.ShortCode, &url.LongURL, &url.Clicks, &url.CreatedAt, &url.ExpiresAt, &url.QRCode, &url.UserID); err != nil {
-			return nil, 0, fmt.Errorf("failed to scan row: %w", err)
+		if err := rows.Scan(&url.ShortCode, &url.LongURL, &url.Clicks, &url.MaxClicks, &url.CreatedAt, &url.ActiveFrom, &url.ExpiresAt, &url.Tags, &url.QRCode, &url.UserID, &url.Domain); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 		urls = append(urls, &url)
 	}
 	if err = rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("error iterating rows: %w", err)
+		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
-	return urls, total, nil
+	return urls, nil
 }
 
 // AliasExists checks whether a short code / custom alias already exists in
@@ -263,6 +942,9 @@ func (s *PostgresStorage) ListByUserIDPaginated(ctx context.Context, userID stri
 // stale under replication lag. Use AliasExistsPrimary when strong consistency
 // is required.
 func (p *PostgresStorage) AliasExists(ctx context.Context, alias string) (bool, error) {
+	ctx, cancel := p.db.QueryContext(ctx)
+	defer cancel()
+
 	var exists bool
 	// EXISTS subquery: returns true if at least one row matches, without
 	// transferring any row data -- efficient for existence checks.
@@ -277,6 +959,9 @@ func (p *PostgresStorage) AliasExists(ctx context.Context, alias string) (bool,
 // flow where a distributed lock is held: a stale replica read could falsely
 // report the alias as available, leading to a duplicate-key error on INSERT.
 func (p *PostgresStorage) AliasExistsPrimary(ctx context.Context, alias string) (bool, error) {
+	ctx, cancel := p.db.QueryContext(ctx)
+	defer cancel()
+
 	var exists bool
 	// Same EXISTS query as AliasExists, but routed to the primary for strong
 	// consistency.
@@ -285,50 +970,125 @@ func (p *PostgresStorage) AliasExistsPrimary(ctx context.Context, alias string)
 	return exists, err
 }
 
+// ScanShortCodes streams every short code created at or after since to fn,
+// one row at a time, so callers can build in-memory indexes (such as the
+// alias Bloom filter) without materialising the whole table as a slice. A
+// zero since scans the whole table. Iteration stops at the first error
+// returned by fn. The scan runs on a read replica; codes inserted during
+// replication lag are picked up by a later scan that overlaps this one.
+// A full scan can outlast DB_QUERY_TIMEOUT, so it is bounded by ctx alone.
+func (p *PostgresStorage) ScanShortCodes(ctx context.Context, since time.Time, fn func(shortCode string) error) error {
+	// Only the short_code column is selected to keep the transfer small even
+	// for tables with millions of rows.
+	query := `SELECT short_code FROM urls WHERE created_at >= $1`
+
+	rows, err := p.db.Read().Query(ctx, query, since)
+	if err != nil {
+		return fmt.Errorf("failed to scan short codes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var shortCode string
+		if err := rows.Scan(&shortCode); err != nil {
+			return fmt.Errorf("failed to scan short code: %w", err)
+		}
+		if err := fn(shortCode); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating rows: %w", err)
+	}
+	return nil
+}
+
 // CreateCustomURL inserts a URL with a user-chosen alias as the short code.
-// Unlike Save (which takes a fully-populated URL struct), this method lets
-// PostgreSQL generate the timestamps via NOW() and uses RETURNING to capture
-// the server-side created_at. If the alias violates the unique constraint on
-// short_code, the duplicate-key error is translated into a user-friendly
-// "alias already taken" message.
-func (p *PostgresStorage) CreateCustomURL(ctx context.Context, alias, longURL string, expiresAt *time.Time, qrCode, userID string) error {
+// Unlike Save, this method lets PostgreSQL generate the timestamps via NOW()
+// and uses RETURNING to capture the server-side created_at into
+// url.CreatedAt. If the alias violates the unique constraint on short_code,
+// it fails with an error wrapping ErrShortCodeTaken. The create event is
+// recorded in the same transaction.
+func (p *PostgresStorage) CreateCustomURL(ctx context.Context, url *models.URL) error {
+	ctx, cancel := p.db.QueryContext(ctx)
+	defer cancel()
+
+	tx, err := p.db.Write().Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
 	// INSERT with server-generated timestamps. RETURNING created_at lets us
 	// capture the exact timestamp without a follow-up SELECT.
 	query := `
-		INSERT INTO urls (short_code, long_url, expires_at, qr_code, user_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		INSERT INTO urls (short_code, long_url, active_from, expires_at, max_clicks, tags, qr_code, user_id, domain, preview, backup_url, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW(), NOW())
 		RETURNING created_at
 	`
 
 	var createdAt time.Time
-	err := p.db.Write().QueryRow(ctx, query, alias, longURL, expiresAt, qrCode, userID).Scan(&createdAt)
+	err = tx.QueryRow(ctx, query, url.ShortCode, url.LongURL, url.ActiveFrom, url.ExpiresAt, url.MaxClicks, tagsOrEmpty(url.Tags), url.QRCode, url.UserID, url.Domain, url.Preview, url.BackupURL).Scan(&createdAt)
 
 	if err != nil {
-		if strings.Contains(err.Error(), "duplicate key") {
-			return errors.New("alias already taken")
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return fmt.Errorf("failed to create custom URL: %w", ErrShortCodeTaken)
 		}
 		return err
 	}
 
+	if err := p.RecordEvent(ctx, tx, &models.URLEvent{
+		ShortCode:  url.ShortCode,
+		Action:     models.URLEventCreate,
+		ActorID:    url.UserID,
+		AfterURL:   url.LongURL,
+		OccurredAt: createdAt,
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit URL: %w", err)
+	}
+	url.CreatedAt = createdAt
 	return nil
 }
 
 // DeleteExpiredURLs bulk-deletes all URL records whose expiration timestamp
 // has passed. It is designed to be called periodically by a background cleanup
-// goroutine. Returns the number of rows removed so the caller can log or
-// meter the cleanup volume.
-func (p *PostgresStorage) DeleteExpiredURLs(ctx context.Context) (int64, error) {
+// goroutine. Returns the short codes removed so the caller can evict them
+// from the cache and log or meter the cleanup volume. Like ScanShortCodes it
+// is bounded by ctx alone, since a backlog of expired links can take longer
+// to delete than DB_QUERY_TIMEOUT allows.
+func (p *PostgresStorage) DeleteExpiredURLs(ctx context.Context) ([]string, error) {
 	// DELETE all rows where expires_at is in the past. URLs with a NULL
 	// expires_at live forever and are excluded by the IS NOT NULL guard.
 	query := `
 		DELETE FROM urls
 		WHERE expires_at IS NOT NULL AND expires_at < NOW()
+		RETURNING short_code
 	`
 
-	cmdTag, err := p.db.Write().Exec(ctx, query)
+	rows, err := p.db.Write().Query(ctx, query)
 	if err != nil {
-		return 0, fmt.Errorf("failed to delete expired URLs: %w", err)
+		return nil, fmt.Errorf("failed to delete expired URLs: %w", err)
+	}
+	defer rows.Close()
+
+	var shortCodes []string
+	for rows.Next() {
+		var shortCode string
+		if err := rows.Scan(&shortCode); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		shortCodes = append(shortCodes, shortCode)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to delete expired URLs: %w", err)
 	}
 
-	return cmdTag.RowsAffected(), nil
+	return shortCodes, nil
 }
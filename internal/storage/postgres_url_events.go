@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// insertEventQuery appends one url_events row. An empty destination is
+// stored as NULL, and a zero occurred_at as the transaction's NOW().
+const insertEventQuery = `
+	INSERT INTO url_events (short_code, action, actor_id, before_url, after_url, occurred_at)
+	VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), COALESCE($6, NOW()))
+`
+
+// RecordEvent appends ev to the audit trail as part of tx, so the entry is
+// committed or rolled back together with the change it describes. ev.ID is
+// assigned by the database and ignored here.
+func (s *PostgresStorage) RecordEvent(ctx context.Context, tx pgx.Tx, ev *models.URLEvent) error {
+	var occurredAt any
+	if !ev.OccurredAt.IsZero() {
+		occurredAt = ev.OccurredAt
+	}
+	if _, err := tx.Exec(ctx, insertEventQuery, ev.ShortCode, ev.Action, ev.ActorID, ev.BeforeURL, ev.AfterURL, occurredAt); err != nil {
+		return fmt.Errorf("failed to record URL event: %w", err)
+	}
+	return nil
+}
+
+// ListEvents returns the audit trail of a short code, oldest first. A short
+// code freed by deleting its link can be reused by someone else, so unless
+// includePrevious is set only the events since the latest create are
+// returned: those of the link that holds the code now.
+func (s *PostgresStorage) ListEvents(ctx context.Context, shortCode string, includePrevious bool) ([]*models.URLEvent, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	// id rather than occurred_at orders the trail: Save records its create
+	// at the application's clock, the other changes at the database's.
+	query := `
+		SELECT id, short_code, action, actor_id, COALESCE(before_url, ''), COALESCE(after_url, ''), occurred_at
+		FROM url_events
+		WHERE short_code = $1
+		AND ($2 OR id >= COALESCE((SELECT MAX(id) FROM url_events WHERE short_code = $1 AND action = 'create'), 0))
+		ORDER BY id
+	`
+
+	rows, err := s.db.Read().Query(ctx, query, shortCode, includePrevious)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list URL events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.URLEvent
+	for rows.Next() {
+		var ev models.URLEvent
+		if err := rows.Scan(&ev.ID, &ev.ShortCode, &ev.Action, &ev.ActorID, &ev.BeforeURL, &ev.AfterURL, &ev.OccurredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan URL event: %w", err)
+		}
+		events = append(events, &ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list URL events: %w", err)
+	}
+	return events, nil
+}
@@ -7,22 +7,30 @@ import (
 	"github.com/Varun5711/shorternit/internal/models"
 )
 
+// listByUserIDQuery selects a user's non-expired URLs, newest first. Its
+// filter and order are the leading columns of idx_urls_user_created
+// (migration 000006), so Postgres reads just that user's index range
+// already in order, with no sort, however many URLs other users own. Only
+// the columns a listing shows are read. COALESCE converts NULL qr_code and
+// user_id values to empty strings so pgx can scan them into Go string
+// fields without error.
+const listByUserIDQuery = `
+	SELECT short_code, long_url, clicks, created_at, expires_at, COALESCE(qr_code, ''), COALESCE(user_id, '')
+	FROM urls
+	WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > NOW())
+	ORDER BY created_at DESC, short_code DESC
+`
+
 // ListByUserID returns all non-expired URLs owned by the given user, ordered
 // by creation time (newest first). The query runs against a read replica and
-// filters out expired rows at the database level. For paginated access, use
+// filters out expired rows at the database level; it relies on
+// idx_urls_user_created (see listByUserIDQuery). For paginated access, use
 // ListByUserIDPaginated instead to avoid unbounded result sets.
 func (s *PostgresStorage) ListByUserID(ctx context.Context, userID string) ([]*models.URL, error) {
-	// SELECT all active URLs for a specific user. COALESCE converts NULL
-	// qr_code and user_id values to empty strings so pgx can scan them into
-	// Go string fields without error.
-	query := `
-		SELECT short_code, long_url, clicks, created_at, expires_at, COALESCE(qr_code, ''), COALESCE(user_id, '')
-		FROM urls
-		WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > NOW())
-		ORDER BY created_at DESC
-	`
-
-	rows, err := s.db.Read().Query(ctx, query, userID)
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	rows, err := s.db.Read().Query(ctx, listByUserIDQuery, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list URLs by user: %w", err)
 	}
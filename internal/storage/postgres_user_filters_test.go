@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/cache/cachetest"
+	"github.com/Varun5711/shorternit/internal/database"
+	"github.com/Varun5711/shorternit/internal/database/migrate"
+	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/Varun5711/shorternit/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migratedDB creates a database on the server behind POSTGRES_TEST_DSN,
+// which must name a role allowed to create databases, applies every
+// migration to it and returns a PostgresStorage over it. The database is
+// dropped when the test ends.
+func migratedDB(t *testing.T, ctx context.Context) (*PostgresStorage, *database.DBManager) {
+	t.Helper()
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+	admin, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(admin.Close)
+
+	name := fmt.Sprintf("storage_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(ctx, "CREATE DATABASE "+name); err != nil {
+		t.Fatalf("failed to create %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		_, _ = admin.Exec(context.Background(), "DROP DATABASE IF EXISTS "+name)
+	})
+
+	db, err := database.NewDBManager(ctx, database.Config{PrimaryDSN: withDatabase(t, dsn, name), MaxConns: 4})
+	if err != nil {
+		t.Fatalf("failed to connect to %s: %v", name, err)
+	}
+	t.Cleanup(db.Close)
+
+	all, err := migrations.Load(migrations.Postgres, "postgres")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := migrate.NewRunner(db.Primary(), logger.New("storage-test")).Up(ctx, all); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return NewPostgresStorage(db), db
+}
+
+// withDatabase returns dsn, in URL or keyword/value form, pointed at the
+// database name.
+func withDatabase(t *testing.T, dsn, name string) string {
+	t.Helper()
+	if !strings.HasPrefix(dsn, "postgres://") && !strings.HasPrefix(dsn, "postgresql://") {
+		return dsn + " dbname=" + name
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	u.Path = "/" + name
+	return u.String()
+}
+
+// TestListByUserID_IndexRange seeds 200 users with 100 URLs each and checks
+// that listing one user's URLs returns just their live ones, newest first,
+// from a range of idx_urls_user_created rather than a scan of every row.
+func TestListByUserID_IndexRange(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	s, db := migratedDB(t, ctx)
+
+	// Every third URL has expired.
+	seed := `
+		INSERT INTO urls (short_code, long_url, user_id, created_at, expires_at)
+		SELECT 'c' || g, 'https://example.com/' || g, 'user' || (g % 200),
+			NOW() - g * INTERVAL '1 second',
+			CASE WHEN g % 3 = 0 THEN NOW() - INTERVAL '1 hour' END
+		FROM generate_series(1, 20000) g
+	`
+	if _, err := db.Primary().Exec(ctx, seed); err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+	if _, err := db.Primary().Exec(ctx, "ANALYZE urls"); err != nil {
+		t.Fatalf("failed to analyze: %v", err)
+	}
+
+	urls, err := s.ListByUserID(ctx, "user7")
+	if err != nil {
+		t.Fatalf("ListByUserID: %v", err)
+	}
+	if len(urls) != 67 {
+		t.Fatalf("expected user7's 67 live URLs, got %d", len(urls))
+	}
+	for i, u := range urls {
+		if u.UserID != "user7" {
+			t.Fatalf("expected only user7's URLs, got %+v", u)
+		}
+		if i > 0 && u.CreatedAt.After(urls[i-1].CreatedAt) {
+			t.Fatalf("expected newest first, got %s after %s", u.ShortCode, urls[i-1].ShortCode)
+		}
+	}
+
+	rows, err := db.Primary().Query(ctx, "EXPLAIN "+listByUserIDQuery, "user7")
+	if err != nil {
+		t.Fatalf("EXPLAIN: %v", err)
+	}
+	var plan []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			t.Fatalf("failed to scan plan: %v", err)
+		}
+		plan = append(plan, line)
+	}
+	rows.Close()
+	text := strings.Join(plan, "\n")
+	if !strings.Contains(text, "idx_urls_user_created") || strings.Contains(text, "Seq Scan") || strings.Contains(text, "Sort") {
+		t.Errorf("expected an ordered range of idx_urls_user_created, got plan:\n%s", text)
+	}
+}
+
+// TestListTopByClicks_WarmsOwner verifies that the links cache warming loads
+// carry their owner into the cached entries, so the owner's Cache-Control:
+// no-cache bypass works on them as on any other cached link.
+func TestListTopByClicks_WarmsOwner(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	s, db := migratedDB(t, ctx)
+
+	seed := `
+		INSERT INTO urls (short_code, long_url, user_id, clicks) VALUES
+			('hot', 'https://example.com/hot', 'alice', 100),
+			('anon', 'https://example.com/anon', NULL, 50)
+	`
+	if _, err := db.Primary().Exec(ctx, seed); err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+
+	c := cachetest.NewL1Only()
+	if warmed, err := c.Warm(ctx, s, 10); err != nil || warmed != 2 {
+		t.Fatalf("expected 2 warmed entries, got %d, %v", warmed, err)
+	}
+	if entry, ok := c.GetURL(ctx, "url:hot"); !ok || entry.UserID != "alice" {
+		t.Errorf("expected hot cached with its owner alice, got %+v", entry)
+	}
+	if entry, ok := c.GetURL(ctx, "url:anon"); !ok || entry.UserID != "" {
+		t.Errorf("expected anon cached without an owner, got %+v", entry)
+	}
+}
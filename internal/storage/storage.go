@@ -11,11 +11,16 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/Varun5711/shorternit/internal/models"
 )
 
+// ErrShortCodeTaken is reported by Save, SaveBatch, CreateCustomURL and
+// Restore for a URL whose short code already exists.
+var ErrShortCodeTaken = errors.New("short code already taken")
+
 // Storage is the primary repository interface for URL operations.
 //
 // Every method accepts a context.Context to support request-scoped deadlines,
@@ -26,14 +31,23 @@ import (
 // Read vs. write routing is an implementation detail -- callers interact only
 // with this interface and never choose which database replica to hit.
 type Storage interface {
-	// Save persists a new shortened URL record. The caller is responsible for
-	// populating the ShortCode (via Snowflake ID generation) and timestamps
-	// before calling Save.
+	// Save persists a new shortened URL record, including its A/B variants
+	// and geo rules.
+	// The caller is responsible for populating the ShortCode (via Snowflake ID
+	// generation) and timestamps before calling Save. A short code that
+	// already exists fails with an error wrapping ErrShortCodeTaken.
 	Save(ctx context.Context, url *models.URL) error
 
-	// GetByShortCode retrieves a URL by its short code. Returns (nil, nil) if
-	// no matching, non-expired URL exists -- this lets the service layer
-	// distinguish "not found" from a real database error.
+	// SaveBatch persists many URL records at once and returns one error per
+	// URL: nil when inserted, ErrShortCodeTaken when the short code already
+	// exists, or that row's database error. Used by bulk import; the caller
+	// prepares each record as for Save.
+	SaveBatch(ctx context.Context, urls []*models.URL) []error
+
+	// GetByShortCode retrieves a URL, with its A/B variants and geo rules, by
+	// its short code. Returns (nil, nil) if no matching, non-expired URL
+	// exists -- this lets the service layer distinguish "not found" from a
+	// real database error.
 	GetByShortCode(ctx context.Context, shortCode string) (*models.URL, error)
 
 	// IncrementClicks atomically bumps the click counter for the given short
@@ -60,25 +74,81 @@ type Storage interface {
 	// read could allow duplicate aliases.
 	AliasExistsPrimary(ctx context.Context, alias string) (bool, error)
 
-	// CreateCustomURL inserts a URL record that uses a user-chosen alias
-	// instead of a Snowflake-generated short code. The alias must have been
-	// validated and locked before calling this method.
-	CreateCustomURL(ctx context.Context, alias, longURL string, expiresAt *time.Time, qrCode, userID string) error
+	// CreateCustomURL inserts a URL record whose ShortCode is a user-chosen
+	// alias instead of a Snowflake-generated short code. The alias must have
+	// been validated and locked before calling this method, and the tags
+	// must already be normalized. Unlike Save, the store stamps the creation
+	// time itself and writes it to url.CreatedAt. An alias that already
+	// exists fails with an error wrapping ErrShortCodeTaken.
+	CreateCustomURL(ctx context.Context, url *models.URL) error
+
+	// Delete hard-deletes a URL record by short code and records the delete
+	// event against actorID. Returns an error if the short code does not
+	// exist.
+	Delete(ctx context.Context, shortCode, actorID string) error
 
-	// Delete hard-deletes a URL record by short code. Returns an error if the
-	// short code does not exist.
-	Delete(ctx context.Context, shortCode string) error
+	// DeleteManyByUser hard-deletes those of shortCodes that userID owns,
+	// recording a delete event for each against userID, and returns the
+	// deleted links' short codes, long URLs and QR codes. Codes that do not
+	// exist or belong to someone else are skipped.
+	DeleteManyByUser(ctx context.Context, userID string, shortCodes []string) ([]*models.URL, error)
+
+	// Restore brings back url.UserID's link with url.ShortCode after it
+	// expired or was deleted, and records the restore event against its
+	// owner. An expired row still in place keeps everything but its expiry,
+	// which becomes url.ExpiresAt, and url.LongURL and url.Domain are set
+	// from it; otherwise url is inserted anew. A short code held by any
+	// other row fails with an error wrapping ErrShortCodeTaken.
+	Restore(ctx context.Context, url *models.URL) error
 
 	// DeleteExpiredURLs removes all URL records whose expiration time has
-	// passed. Returns the number of rows deleted. This is typically called by
+	// passed. Returns the short codes deleted. This is typically called by
 	// a background cleanup job on a scheduled interval.
-	DeleteExpiredURLs(ctx context.Context) (int64, error)
+	DeleteExpiredURLs(ctx context.Context) ([]string, error)
 
-	// ListPaginated returns a page of non-expired URLs along with the total
-	// count of matching records. limit and offset control pagination.
-	ListPaginated(ctx context.Context, limit, offset int32) ([]*models.URL, int32, error)
+	// ListPaginated returns a page of non-expired URLs along with a summary
+	// of all matching records (total count, clicks, active and expired
+	// counts). limit and offset control pagination.
+	ListPaginated(ctx context.Context, limit, offset int32) ([]*models.URL, models.URLListSummary, error)
 
 	// ListByUserIDPaginated returns a page of non-expired URLs owned by the
-	// given user along with the total count.
-	ListByUserIDPaginated(ctx context.Context, userID string, limit, offset int32) ([]*models.URL, int32, error)
+	// given user along with the summary of all the user's URLs.
+	ListByUserIDPaginated(ctx context.Context, userID string, limit, offset int32) ([]*models.URL, models.URLListSummary, error)
+
+	// ListByUserIDAndTag returns a page of non-expired URLs owned by the
+	// given user that carry tag (normalized, lowercase), with the summary of
+	// all the user's URLs carrying it.
+	ListByUserIDAndTag(ctx context.Context, userID, tag string, limit, offset int32) ([]*models.URL, models.URLListSummary, error)
+
+	// GetTagCounts returns the user's distinct tags with the number of
+	// non-expired URLs carrying each.
+	GetTagCounts(ctx context.Context, userID string) ([]models.TagCount, error)
+
+	// UpdateTags replaces the tags of a URL and records the update event
+	// against actorID. Returns an error if the short code does not exist.
+	UpdateTags(ctx context.Context, shortCode string, tags []string, actorID string) error
+
+	// SetActive enables (active) or disables a URL and records the enable or
+	// disable event against actorID. Setting the state a URL already has
+	// changes nothing. Returns an error if the short code does not exist.
+	SetActive(ctx context.Context, shortCode string, active bool, actorID string) error
+
+	// UpdateQRCode replaces the qr_code of userID's URL with qrCode and
+	// returns the value it replaced. Returns an error if the short code does
+	// not exist or belongs to someone else.
+	UpdateQRCode(ctx context.Context, shortCode, userID, qrCode string) (string, error)
+
+	// ListByUserIDAfter returns up to limit non-expired URLs owned by the
+	// given user, newest first, strictly after the (afterCreatedAt,
+	// afterShortCode) position. A zero afterCreatedAt starts at the newest.
+	ListByUserIDAfter(ctx context.Context, userID string, afterCreatedAt time.Time, afterShortCode string, limit int32) ([]*models.URL, error)
+
+	// ListEvents returns the audit trail of a short code, oldest first. Only
+	// the events of the current link are returned unless includePrevious is
+	// set, which adds those of earlier, deleted links with the same code.
+	ListEvents(ctx context.Context, shortCode string, includePrevious bool) ([]*models.URLEvent, error)
+
+	// GetURLStats reads the counters and timestamps of a URL, expired or
+	// not. Returns (nil, nil) if the short code does not exist.
+	GetURLStats(ctx context.Context, shortCode string) (*models.URLStats, error)
 }
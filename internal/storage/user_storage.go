@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,8 +10,17 @@ import (
 	usermodel "github.com/Varun5711/shorternit/internal/models/user"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// ErrEmailTaken is returned by UpdateUser when another account already uses
+// the new email.
+var ErrEmailTaken = errors.New("email already in use")
+
+// uniqueViolation is the PostgreSQL error code for a unique constraint
+// violation.
+const uniqueViolation = "23505"
+
 // UserStorage provides PostgreSQL-backed persistence for user accounts. Like
 // PostgresStorage, it uses database.DBManager to route writes to the primary
 // and reads to replicas, though in practice user lookups (login, profile) are
@@ -30,6 +40,9 @@ func NewUserStorage(db *database.DBManager) *UserStorage {
 // RETURNING is used to capture the inserted row so the caller receives the
 // exact values written (including server-side timestamp precision).
 func (s *UserStorage) CreateUser(ctx context.Context, req *usermodel.CreateUserRequest, passwordHash string) (*usermodel.User, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
 	userID := uuid.New().String()
 	now := time.Now()
 
@@ -38,7 +51,7 @@ func (s *UserStorage) CreateUser(ctx context.Context, req *usermodel.CreateUserR
 	query := `
 		INSERT INTO users (id, email, name, password_hash, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, email, name, created_at, updated_at
+		RETURNING id, email, name, role, created_at, updated_at
 	`
 
 	var user usermodel.User
@@ -53,6 +66,7 @@ func (s *UserStorage) CreateUser(ctx context.Context, req *usermodel.CreateUserR
 		&user.ID,
 		&user.Email,
 		&user.Name,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -69,10 +83,13 @@ func (s *UserStorage) CreateUser(ctx context.Context, req *usermodel.CreateUserR
 // "not found" from a database error. The password_hash is included in the
 // result because this method is used during login to verify credentials.
 func (s *UserStorage) GetUserByEmail(ctx context.Context, email string) (*usermodel.User, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
 	// SELECT the full user row including password_hash (needed for login
 	// credential verification).
 	query := `
-		SELECT id, email, name, password_hash, created_at, updated_at
+		SELECT id, email, name, password_hash, role, disabled_at, created_at, updated_at
 		FROM users
 		WHERE email = $1
 	`
@@ -83,6 +100,8 @@ func (s *UserStorage) GetUserByEmail(ctx context.Context, email string) (*usermo
 		&user.Email,
 		&user.Name,
 		&user.PasswordHash,
+		&user.Role,
+		&user.DisabledAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -103,21 +122,28 @@ func (s *UserStorage) GetUserByEmail(ctx context.Context, email string) (*usermo
 // method is used for profile retrieval where credentials are not needed.
 // Returns (nil, nil) when the user does not exist.
 func (s *UserStorage) GetUserByID(ctx context.Context, userID string) (*usermodel.User, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
 	// SELECT user profile fields (no password_hash -- not needed for profile
 	// display).
 	query := `
-		SELECT id, email, name, created_at, updated_at
+		SELECT id, email, name, role, disabled_at, created_at, updated_at, default_url_ttl_seconds
 		FROM users
 		WHERE id = $1
 	`
 
 	var user usermodel.User
+	var ttlSeconds *int64
 	err := s.db.Read().QueryRow(ctx, query, userID).Scan(
 		&user.ID,
 		&user.Email,
 		&user.Name,
+		&user.Role,
+		&user.DisabledAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&ttlSeconds,
 	)
 
 	if err == pgx.ErrNoRows {
@@ -127,35 +153,169 @@ func (s *UserStorage) GetUserByID(ctx context.Context, userID string) (*usermode
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
+	user.DefaultURLTTL = ttlFromSeconds(ttlSeconds)
+
+	return &user, nil
+}
+
+// GetPlan returns the plan the user is on, which selects their link quota.
+// Returns "" when the user does not exist.
+func (s *UserStorage) GetPlan(ctx context.Context, userID string) (string, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	var plan string
+	err := s.db.Read().QueryRow(ctx, `SELECT plan FROM users WHERE id = $1`, userID).Scan(&plan)
+	if err == pgx.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get plan: %w", err)
+	}
+	return plan, nil
+}
+
+// GetAccess returns the user's current role and whether the account is
+// disabled. It reads the primary rather than a replica because it backs the
+// re-check of sensitive actions, where a role change or a disable made a
+// moment ago must already count. Returns role "" when the user does not
+// exist.
+func (s *UserStorage) GetAccess(ctx context.Context, userID string) (role string, disabled bool, err error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	err = s.db.Write().QueryRow(ctx,
+		`SELECT role, disabled_at IS NOT NULL FROM users WHERE id = $1`, userID,
+	).Scan(&role, &disabled)
+	if err == pgx.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get access: %w", err)
+	}
+	return role, disabled, nil
+}
+
+// SetDisabled disables the user's account, or re-enables it when disabled is
+// false, and returns the updated row. Disabling an already disabled account
+// keeps the original disabled_at. Returns (nil, nil) when the user does not
+// exist.
+func (s *UserStorage) SetDisabled(ctx context.Context, userID string, disabled bool) (*usermodel.User, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE users
+		SET disabled_at = CASE WHEN $2 THEN COALESCE(disabled_at, NOW()) END, updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, email, name, role, disabled_at, created_at, updated_at, default_url_ttl_seconds
+	`
+
+	var user usermodel.User
+	var ttlSeconds *int64
+	err := s.db.Write().QueryRow(ctx, query, userID, disabled).Scan(
+		&user.ID,
+		&user.Email,
+		&user.Name,
+		&user.Role,
+		&user.DisabledAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&ttlSeconds,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to set disabled: %w", err)
+	}
+	user.DefaultURLTTL = ttlFromSeconds(ttlSeconds)
 
 	return &user, nil
 }
 
-// UpdateUser modifies a user's name and email on the primary database. The
+// UpdateUser modifies a user's name, email and default link lifetime on the
+// primary database; a nil defaultTTL goes back to the global default. The
 // updated_at column is set to NOW() by PostgreSQL so the timestamp reflects
 // the exact write time. RETURNING gives back the full updated row, avoiding a
-// second SELECT round-trip.
-func (s *UserStorage) UpdateUser(ctx context.Context, userID string, name, email string) (*usermodel.User, error) {
-	// UPDATE name and email, bump updated_at, and return the refreshed row.
+// second SELECT round-trip. It returns (nil, nil) when no user has userID,
+// and ErrEmailTaken when the email belongs to another account.
+func (s *UserStorage) UpdateUser(ctx context.Context, userID string, name, email string, defaultTTL *time.Duration) (*usermodel.User, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	// UPDATE the profile fields, bump updated_at, and return the refreshed
+	// row.
 	query := `
 		UPDATE users
-		SET name = $1, email = $2, updated_at = NOW()
-		WHERE id = $3
-		RETURNING id, email, name, created_at, updated_at
+		SET name = $1, email = $2, default_url_ttl_seconds = $3, updated_at = NOW()
+		WHERE id = $4
+		RETURNING id, email, name, role, disabled_at, created_at, updated_at, default_url_ttl_seconds
 	`
 
 	var user usermodel.User
-	err := s.db.Write().QueryRow(ctx, query, name, email, userID).Scan(
+	var ttlSeconds *int64
+	err := s.db.Write().QueryRow(ctx, query, name, email, ttlToSeconds(defaultTTL), userID).Scan(
 		&user.ID,
 		&user.Email,
 		&user.Name,
+		&user.Role,
+		&user.DisabledAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&ttlSeconds,
 	)
 
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return nil, ErrEmailTaken
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
+	user.DefaultURLTTL = ttlFromSeconds(ttlSeconds)
 
 	return &user, nil
 }
+
+// GetDefaultURLTTL returns how long links the user creates without an
+// expiry live. set is false when the user has not chosen one, or does not
+// exist, and the global default applies; a set ttl of 0 never expires.
+func (s *UserStorage) GetDefaultURLTTL(ctx context.Context, userID string) (ttl time.Duration, set bool, err error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	var ttlSeconds *int64
+	err = s.db.Read().QueryRow(ctx, `SELECT default_url_ttl_seconds FROM users WHERE id = $1`, userID).Scan(&ttlSeconds)
+	if err == pgx.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get default URL TTL: %w", err)
+	}
+	if ttlSeconds == nil {
+		return 0, false, nil
+	}
+	return time.Duration(*ttlSeconds) * time.Second, true, nil
+}
+
+// ttlFromSeconds converts the default_url_ttl_seconds column to a duration.
+func ttlFromSeconds(seconds *int64) *time.Duration {
+	if seconds == nil {
+		return nil
+	}
+	ttl := time.Duration(*seconds) * time.Second
+	return &ttl
+}
+
+// ttlToSeconds converts a default link lifetime to its column value.
+func ttlToSeconds(ttl *time.Duration) *int64 {
+	if ttl == nil {
+		return nil
+	}
+	seconds := int64(*ttl / time.Second)
+	return &seconds
+}
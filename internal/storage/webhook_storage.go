@@ -0,0 +1,250 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Varun5711/shorternit/internal/database"
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrWebhookLimitReached is returned by CreateWebhook when the short code
+// already carries the maximum number of webhooks.
+var ErrWebhookLimitReached = errors.New("webhook limit reached for this short code")
+
+// WebhookStorage provides PostgreSQL-backed persistence for per-link
+// webhooks. The url-service uses it to register, list, and delete webhooks;
+// the pipeline-worker uses it to look up active webhooks for each click
+// batch and to record delivery outcomes.
+type WebhookStorage struct {
+	db *database.DBManager
+}
+
+// NewWebhookStorage creates a WebhookStorage backed by the given DBManager.
+func NewWebhookStorage(db *database.DBManager) *WebhookStorage {
+	return &WebhookStorage{db: db}
+}
+
+// webhookColumns is the shared SELECT list for scanning a full Webhook row.
+const webhookColumns = `id, short_code, user_id, target_url, secret, click_threshold,
+	rate_limit_per_minute, failure_count, disabled, last_delivered_at, created_at`
+
+// scanWebhook reads one row selected with webhookColumns into a Webhook.
+func scanWebhook(row pgx.Row) (*models.Webhook, error) {
+	var wh models.Webhook
+	err := row.Scan(
+		&wh.ID,
+		&wh.ShortCode,
+		&wh.UserID,
+		&wh.TargetURL,
+		&wh.Secret,
+		&wh.ClickThreshold,
+		&wh.RateLimitPerMinute,
+		&wh.FailureCount,
+		&wh.Disabled,
+		&wh.LastDeliveredAt,
+		&wh.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &wh, nil
+}
+
+// CreateWebhook inserts a new webhook on the primary, refusing with
+// ErrWebhookLimitReached once the short code already has maxPerLink webhooks.
+// The count and insert run in one transaction that first locks the parent
+// urls row, so concurrent registrations on the same link are serialized and
+// cannot both slip under the limit. A UUID is generated for the ID so
+// webhook IDs cannot be enumerated. The caller is responsible for checking
+// that userID owns the short code before calling.
+func (s *WebhookStorage) CreateWebhook(ctx context.Context, wh *models.Webhook, maxPerLink int) (*models.Webhook, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	tx, err := s.db.Write().Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var existing int
+	err = tx.QueryRow(ctx, `
+		SELECT COUNT(w.id)
+		FROM (SELECT short_code FROM urls WHERE short_code = $1 FOR UPDATE) u
+		LEFT JOIN webhooks w ON w.short_code = u.short_code
+	`, wh.ShortCode).Scan(&existing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count webhooks: %w", err)
+	}
+	if existing >= maxPerLink {
+		return nil, ErrWebhookLimitReached
+	}
+
+	// INSERT with server-side timestamps; RETURNING gives back the full row
+	// including defaults so the response reflects exactly what was stored.
+	query := `
+		INSERT INTO webhooks (id, short_code, user_id, target_url, secret, click_threshold, rate_limit_per_minute)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + webhookColumns
+
+	created, err := scanWebhook(tx.QueryRow(ctx, query,
+		uuid.New().String(),
+		wh.ShortCode,
+		wh.UserID,
+		wh.TargetURL,
+		wh.Secret,
+		wh.ClickThreshold,
+		wh.RateLimitPerMinute,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit webhook: %w", err)
+	}
+
+	return created, nil
+}
+
+// ListWebhooks returns every webhook (active or disabled) that userID has
+// registered on shortCode, newest first. Scoping by user_id means a caller
+// can never see another user's webhook targets or failure state.
+func (s *WebhookStorage) ListWebhooks(ctx context.Context, shortCode, userID string) ([]*models.Webhook, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM webhooks
+		WHERE short_code = $1 AND user_id = $2
+		ORDER BY created_at DESC
+	`
+
+	rows, err := s.db.Read().Query(ctx, query, shortCode, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []*models.Webhook
+	for rows.Next() {
+		wh, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, wh)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return webhooks, nil
+}
+
+// ListActiveByShortCodes returns the non-disabled webhooks for a set of short
+// codes, grouped by short code. The pipeline-worker calls this once per click
+// batch with the distinct codes in the batch, so the lookup cost is one query
+// per batch rather than one per click.
+func (s *WebhookStorage) ListActiveByShortCodes(ctx context.Context, shortCodes []string) (map[string][]*models.Webhook, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	result := make(map[string][]*models.Webhook)
+	if len(shortCodes) == 0 {
+		return result, nil
+	}
+
+	// ANY($1) binds the whole slice as a single text[] parameter and is
+	// served by the partial idx_webhooks_short_code index.
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM webhooks
+		WHERE short_code = ANY($1) AND disabled = FALSE
+	`
+
+	rows, err := s.db.Read().Query(ctx, query, shortCodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		wh, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		result[wh.ShortCode] = append(result[wh.ShortCode], wh)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return result, nil
+}
+
+// DeleteWebhook removes a webhook owned by userID. It returns false when no
+// row matched, which covers both "does not exist" and "belongs to someone
+// else" without revealing which.
+func (s *WebhookStorage) DeleteWebhook(ctx context.Context, id, userID string) (bool, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	query := `DELETE FROM webhooks WHERE id = $1 AND user_id = $2`
+
+	cmdTag, err := s.db.Write().Exec(ctx, query, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	return cmdTag.RowsAffected() > 0, nil
+}
+
+// RecordSuccess resets the consecutive failure counter and stamps the last
+// delivery time after a webhook returned a 2xx.
+func (s *WebhookStorage) RecordSuccess(ctx context.Context, id string) error {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE webhooks
+		SET failure_count = 0, last_delivered_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := s.db.Write().Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to record webhook success: %w", err)
+	}
+	return nil
+}
+
+// RecordFailure increments the consecutive failure counter and disables the
+// webhook in the same statement once the counter reaches maxFailures, so
+// concurrent workers cannot race past the limit. It reports whether the
+// webhook is now disabled.
+func (s *WebhookStorage) RecordFailure(ctx context.Context, id string, maxFailures int) (bool, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE webhooks
+		SET failure_count = failure_count + 1,
+		    disabled = (failure_count + 1 >= $2)
+		WHERE id = $1
+		RETURNING disabled
+	`
+
+	var disabled bool
+	err := s.db.Write().QueryRow(ctx, query, id, maxFailures).Scan(&disabled)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to record webhook failure: %w", err)
+	}
+	return disabled, nil
+}
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Varun5711/shorternit/internal/database"
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrWildcardTaken is returned by CreateWildcardRule when another user holds
+// a rule on the domain whose prefix overlaps the new one.
+var ErrWildcardTaken = errors.New("wildcard overlaps another user's wildcard")
+
+// WildcardStorage provides PostgreSQL-backed persistence for wildcard
+// aliases. The url-service uses it to manage users' rules and to hand each
+// domain's rules to the redirect service.
+type WildcardStorage struct {
+	db *database.DBManager
+}
+
+// NewWildcardStorage creates a WildcardStorage backed by the given DBManager.
+func NewWildcardStorage(db *database.DBManager) *WildcardStorage {
+	return &WildcardStorage{db: db}
+}
+
+// wildcardColumns is the shared SELECT list for scanning a full
+// WildcardRule row.
+const wildcardColumns = `domain, prefix, user_id, destination, suffix_mode, query_param, created_at`
+
+// scanWildcardRule reads one row selected with wildcardColumns into a
+// WildcardRule.
+func scanWildcardRule(row pgx.Row) (*models.WildcardRule, error) {
+	var r models.WildcardRule
+	err := row.Scan(
+		&r.Domain,
+		&r.Prefix,
+		&r.UserID,
+		&r.Destination,
+		&r.SuffixMode,
+		&r.QueryParam,
+		&r.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	r.Pattern = r.Prefix + "*"
+	return &r, nil
+}
+
+// CreateWildcardRule stores r on the primary. Claiming a prefix the user
+// already holds on the domain replaces that rule's destination. The rule is
+// refused with ErrWildcardTaken when another user's rule on the domain
+// overlaps it, one prefix starting with the other, as the longer one would
+// otherwise take over part of the shorter one's codes.
+func (s *WildcardStorage) CreateWildcardRule(ctx context.Context, r *models.WildcardRule) (*models.WildcardRule, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	// The overlap check and the upsert run as one statement; the upsert
+	// only overwrites the user's own rule, returning no row otherwise.
+	query := `
+		INSERT INTO wildcard_rules (domain, prefix, user_id, destination, suffix_mode, query_param)
+		SELECT $1, $2, $3, $4, $5, $6
+		WHERE NOT EXISTS (
+			SELECT 1 FROM wildcard_rules
+			WHERE domain = $1 AND user_id <> $3
+			  AND (starts_with($2, prefix) OR starts_with(prefix, $2))
+		)
+		ON CONFLICT (domain, prefix) DO UPDATE
+		SET destination = EXCLUDED.destination,
+			suffix_mode = EXCLUDED.suffix_mode,
+			query_param = EXCLUDED.query_param
+		WHERE wildcard_rules.user_id = EXCLUDED.user_id
+		RETURNING ` + wildcardColumns
+
+	created, err := scanWildcardRule(s.db.Write().QueryRow(ctx, query,
+		r.Domain, r.Prefix, r.UserID, r.Destination, r.SuffixMode, r.QueryParam))
+	if err == pgx.ErrNoRows {
+		return nil, ErrWildcardTaken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wildcard rule: %w", err)
+	}
+	return created, nil
+}
+
+// ListWildcardRules returns every rule of userID, by domain and prefix.
+func (s *WildcardStorage) ListWildcardRules(ctx context.Context, userID string) ([]*models.WildcardRule, error) {
+	return s.list(ctx, `WHERE user_id = $1`, userID)
+}
+
+// ListDomainWildcardRules returns every rule on domain, "" being the
+// default domain, by prefix.
+func (s *WildcardStorage) ListDomainWildcardRules(ctx context.Context, domain string) ([]*models.WildcardRule, error) {
+	return s.list(ctx, `WHERE domain = $1`, domain)
+}
+
+func (s *WildcardStorage) list(ctx context.Context, where string, arg string) ([]*models.WildcardRule, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	query := `SELECT ` + wildcardColumns + ` FROM wildcard_rules ` + where + ` ORDER BY domain, prefix`
+	rows, err := s.db.Read().Query(ctx, query, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wildcard rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*models.WildcardRule
+	for rows.Next() {
+		r, err := scanWildcardRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan wildcard rule: %w", err)
+		}
+		rules = append(rules, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return rules, nil
+}
+
+// DeleteWildcardRule removes userID's rule for prefix on domain and reports
+// whether there was one.
+func (s *WildcardStorage) DeleteWildcardRule(ctx context.Context, domain, prefix, userID string) (bool, error) {
+	ctx, cancel := s.db.QueryContext(ctx)
+	defer cancel()
+
+	tag, err := s.db.Write().Exec(ctx,
+		`DELETE FROM wildcard_rules WHERE domain = $1 AND prefix = $2 AND user_id = $3`, domain, prefix, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete wildcard rule: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,35 @@
+// Package timeseries fills the gaps in bucketed time series. Analytics
+// queries only return buckets that saw clicks, which makes a chart of them
+// skip quiet days entirely; Fill adds the missing buckets with zero counts.
+// Filling happens in Go after the query, so it works the same for the
+// PostgreSQL and ClickHouse backends.
+package timeseries
+
+import "time"
+
+// Fill returns one point per step-sized bucket from start's bucket through
+// end's, in order. A bucket with a point in points keeps it; any other gets
+// empty(bucket). Buckets are aligned with time.Truncate, as date_trunc and
+// ClickHouse's toStartOfHour/toDate align them for UTC timestamps. Points
+// outside the range are dropped, and bucket(p) reports a point's bucket.
+func Fill[T any](points []T, start, end time.Time, step time.Duration, bucket func(T) time.Time, empty func(time.Time) T) []T {
+	if step <= 0 || end.Before(start) {
+		return points
+	}
+
+	byBucket := make(map[int64]T, len(points))
+	for _, p := range points {
+		byBucket[bucket(p).Truncate(step).Unix()] = p
+	}
+
+	first, last := start.UTC().Truncate(step), end.UTC().Truncate(step)
+	filled := make([]T, 0, int(last.Sub(first)/step)+1)
+	for t := first; !t.After(last); t = t.Add(step) {
+		if p, ok := byBucket[t.Unix()]; ok {
+			filled = append(filled, p)
+		} else {
+			filled = append(filled, empty(t))
+		}
+	}
+	return filled
+}
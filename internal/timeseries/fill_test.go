@@ -0,0 +1,76 @@
+package timeseries
+
+import (
+	"testing"
+	"time"
+)
+
+type point struct {
+	at     time.Time
+	clicks int
+}
+
+func fillDays(points []point, start, end time.Time) []point {
+	return Fill(points, start, end, 24*time.Hour,
+		func(p point) time.Time { return p.at },
+		func(t time.Time) point { return point{at: t} })
+}
+
+func date(day int) time.Time {
+	return time.Date(2025, time.January, day, 0, 0, 0, 0, time.UTC)
+}
+
+// TestFill_SevenDays verifies that a 7-day range with clicks on 2 days
+// returns 7 points, zero on the quiet days.
+func TestFill_SevenDays(t *testing.T) {
+	points := []point{{date(3), 5}, {date(6), 2}}
+
+	// The range ends mid-day: the partial last day still gets its bucket.
+	got := fillDays(points, date(1), date(7).Add(15*time.Hour))
+
+	if len(got) != 7 {
+		t.Fatalf("expected 7 points, got %d", len(got))
+	}
+	want := []int{0, 0, 5, 0, 0, 2, 0}
+	for i, p := range got {
+		if !p.at.Equal(date(i + 1)) {
+			t.Errorf("point %d: expected %s, got %s", i, date(i+1), p.at)
+		}
+		if p.clicks != want[i] {
+			t.Errorf("point %d: expected %d clicks, got %d", i, want[i], p.clicks)
+		}
+	}
+}
+
+func TestFill_AlignsStartAndDropsOutOfRange(t *testing.T) {
+	points := []point{{date(1), 9}, {date(2), 1}, {date(5), 4}}
+
+	got := fillDays(points, date(2).Add(10*time.Hour), date(3))
+
+	if len(got) != 2 || got[0].clicks != 1 || got[1].clicks != 0 {
+		t.Errorf("expected the 2nd and 3rd only, got %+v", got)
+	}
+}
+
+func TestFill_Hourly(t *testing.T) {
+	start := date(1)
+	points := []point{{start.Add(2 * time.Hour), 3}}
+
+	got := Fill(points, start, start.Add(23*time.Hour), time.Hour,
+		func(p point) time.Time { return p.at },
+		func(t time.Time) point { return point{at: t} })
+
+	if len(got) != 24 || got[2].clicks != 3 {
+		t.Errorf("expected 24 hourly points with 3 clicks at 02:00, got %d points", len(got))
+	}
+}
+
+func TestFill_EmptyRange(t *testing.T) {
+	points := []point{{date(1), 1}}
+	if got := fillDays(points, date(2), date(1)); len(got) != 1 {
+		t.Errorf("expected points returned unchanged for an inverted range, got %+v", got)
+	}
+	if got := fillDays(nil, date(1), date(1)); len(got) != 1 || got[0].clicks != 0 {
+		t.Errorf("expected a single zero point, got %+v", got)
+	}
+}
@@ -26,7 +26,7 @@ import (
 // tracing instrumentation compiles in but produces zero overhead.
 type Config struct {
 	Enabled        bool
-	JaegerEndpoint string // Full URL of the OTLP/HTTP collector (e.g., http://jaeger:4318/v1/traces).
+	JaegerEndpoint string // URL of the OTLP/HTTP collector (e.g., http://jaeger:4318 or https://collector/v1/traces).
 	ServiceName    string
 	ServiceVersion string
 	// SampleRate controls trace sampling: 1.0 = always, 0.0 = never,
@@ -37,11 +37,15 @@ type Config struct {
 // InitTracer creates and globally registers an OpenTelemetry TracerProvider.
 //
 // When tracing is disabled (cfg.Enabled == false), it installs a bare
-// TracerProvider with no exporter so that span creation calls throughout the
-// code become no-ops without requiring nil checks everywhere.
+// TracerProvider with no exporter that samples nothing, so that span creation
+// calls throughout the code -- the HTTP and gRPC handlers, the cache and the
+// database pools all start spans -- record nothing, without requiring nil
+// checks everywhere. Spans still get trace IDs, so X-Trace-ID is set either
+// way.
 //
 // When enabled, the function:
-//  1. Creates an OTLP/HTTP exporter pointed at cfg.JaegerEndpoint.
+//  1. Creates an OTLP/HTTP exporter pointed at cfg.JaegerEndpoint, sending
+//     to /v1/traces when the URL has no path, over TLS for an https URL.
 //  2. Builds an OTel resource tagged with the service name and version
 //     (following OTel semantic conventions) so Jaeger can group traces.
 //  3. Selects a sampler based on cfg.SampleRate -- AlwaysSample for 1.0,
@@ -50,7 +54,7 @@ type Config struct {
 //     that trace context propagates across HTTP and gRPC boundaries.
 func InitTracer(cfg Config) (*sdktrace.TracerProvider, error) {
 	if !cfg.Enabled {
-		tp := sdktrace.NewTracerProvider()
+		tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.NeverSample()))
 		otel.SetTracerProvider(tp)
 		return tp, nil
 	}
@@ -58,7 +62,6 @@ func InitTracer(cfg Config) (*sdktrace.TracerProvider, error) {
 	exporter, err := otlptracehttp.New(
 		context.Background(),
 		otlptracehttp.WithEndpointURL(cfg.JaegerEndpoint),
-		otlptracehttp.WithInsecure(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
@@ -0,0 +1,55 @@
+package validation
+
+import (
+	"errors"
+	"net/netip"
+	"regexp"
+	"strings"
+)
+
+// MaxDomainLength is the longest host name DNS allows.
+const MaxDomainLength = 253
+
+// Sentinel errors for custom domain validation failures.
+var (
+	ErrDomainEmpty   = errors.New("domain must not be empty")
+	ErrDomainTooLong = errors.New("domain must be at most 253 characters")
+	ErrDomainInvalid = errors.New("domain must be a host name like go.example.com, without scheme, port or path")
+)
+
+// domainLabelRegex matches one normalized DNS label: letters, digits and
+// inner hyphens, at most 63 characters.
+var domainLabelRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// NormalizeDomain trims and lowercases a custom domain, drops a trailing dot
+// and validates it as a fully qualified host name. IP addresses, single-label
+// names such as "localhost", and anything with a scheme, port or path are
+// rejected, so the result can be compared directly against a request's Host.
+func NormalizeDomain(domain string) (string, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if domain == "" {
+		return "", ErrDomainEmpty
+	}
+	if len(domain) > MaxDomainLength {
+		return "", ErrDomainTooLong
+	}
+	if _, err := netip.ParseAddr(domain); err == nil {
+		return "", ErrDomainInvalid
+	}
+
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return "", ErrDomainInvalid
+	}
+	for _, label := range labels {
+		if !domainLabelRegex.MatchString(label) {
+			return "", ErrDomainInvalid
+		}
+	}
+	// A numeric top-level label would make the name indistinguishable from
+	// an IPv4 address in some resolvers.
+	if strings.Trim(labels[len(labels)-1], "0123456789") == "" {
+		return "", ErrDomainInvalid
+	}
+	return domain, nil
+}
@@ -0,0 +1,43 @@
+package validation
+
+import "testing"
+
+// TestNormalizeDomain_Valid verifies domains are trimmed, lowercased and
+// stripped of a trailing dot.
+func TestNormalizeDomain_Valid(t *testing.T) {
+	cases := map[string]string{
+		"go.acme.com":      "go.acme.com",
+		" Go.ACME.com. ":   "go.acme.com",
+		"links.my-shop.io": "links.my-shop.io",
+		"x1.co":            "x1.co",
+	}
+	for in, want := range cases {
+		got, err := NormalizeDomain(in)
+		if err != nil || got != want {
+			t.Errorf("NormalizeDomain(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+}
+
+// TestNormalizeDomain_Invalid covers each rejection rule.
+func TestNormalizeDomain_Invalid(t *testing.T) {
+	cases := []string{
+		"",
+		"localhost",
+		"https://go.acme.com",
+		"go.acme.com:8080",
+		"go.acme.com/path",
+		"10.0.0.1",
+		"::1",
+		"-bad.acme.com",
+		"bad-.acme.com",
+		"go..acme.com",
+		"go_acme.com",
+		"acme.123",
+	}
+	for _, in := range cases {
+		if _, err := NormalizeDomain(in); err == nil {
+			t.Errorf("expected %q to be rejected", in)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package validation
+
+import (
+	"errors"
+	"regexp"
+)
+
+// MaxEmailLength is the width of the users.email column.
+const MaxEmailLength = 255
+
+// Sentinel errors for email validation failures.
+var (
+	ErrEmailEmpty   = errors.New("email must not be empty")
+	ErrEmailTooLong = errors.New("email must be at most 255 characters")
+	ErrEmailInvalid = errors.New("email must be an address like name@example.com")
+)
+
+// emailRegex is the users table's email_valid check constraint, so an
+// address accepted here is never rejected by the database.
+var emailRegex = regexp.MustCompile(`^[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}$`)
+
+// ValidateEmail checks that email is an address the users table accepts.
+func ValidateEmail(email string) error {
+	if email == "" {
+		return ErrEmailEmpty
+	}
+	if len(email) > MaxEmailLength {
+		return ErrEmailTooLong
+	}
+	if !emailRegex.MatchString(email) {
+		return ErrEmailInvalid
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateEmail(t *testing.T) {
+	cases := []struct {
+		email string
+		want  error
+	}{
+		{"jane@example.com", nil},
+		{"jane.doe+links@mail.example.co.uk", nil},
+		{"", ErrEmailEmpty},
+		{strings.Repeat("a", 250) + "@x.com", ErrEmailTooLong},
+		{"jane", ErrEmailInvalid},
+		{"jane@example", ErrEmailInvalid},
+		{"jane doe@example.com", ErrEmailInvalid},
+		{"@example.com", ErrEmailInvalid},
+		{"jane@example.c", ErrEmailInvalid},
+	}
+	for _, tc := range cases {
+		if err := ValidateEmail(tc.email); !errors.Is(err, tc.want) {
+			t.Errorf("ValidateEmail(%q) = %v, want %v", tc.email, err, tc.want)
+		}
+	}
+}
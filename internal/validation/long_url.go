@@ -0,0 +1,53 @@
+package validation
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// Sentinel errors for destination URL validation failures.
+var (
+	ErrLongURLInvalid       = errors.New("long_url must be an http or https URL with a host")
+	ErrLongURLSelfReferring = errors.New("long_url must not point to this URL shortener")
+)
+
+// HostFunc reports whether host, with port, is one this shortener serves
+// short links on. host is lowercased without a trailing dot, and port is
+// the URL's explicit port or its scheme's default.
+type HostFunc func(host, port string) bool
+
+// ValidateLongURL checks that longURL is an http or https URL with a host
+// and, unless isOwnHost is nil, that it does not lead back to the
+// shortener: a link to another short link, or to itself, makes visitors
+// take extra hops or loop, and hides the real destination from anyone
+// vetting the link.
+func ValidateLongURL(longURL string, isOwnHost HostFunc) error {
+	u, err := url.Parse(longURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrLongURLInvalid
+	}
+	if isOwnHost != nil {
+		if host, port := HostPort(u); isOwnHost(host, port) {
+			return ErrLongURLSelfReferring
+		}
+	}
+	return nil
+}
+
+// HostPort returns the host of u in the form HostFunc expects: lowercased,
+// without a trailing dot, and with the default port of u's scheme when it
+// has none of its own.
+func HostPort(u *url.URL) (host, port string) {
+	host = strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	port = u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "http":
+			port = "80"
+		case "https":
+			port = "443"
+		}
+	}
+	return host, port
+}
@@ -0,0 +1,39 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestValidateLongURL checks the format rules and that destinations on the
+// shortener's own host and port are refused, whatever their case, trailing
+// dot or spelled-out default port.
+func TestValidateLongURL(t *testing.T) {
+	isOwnHost := func(host, port string) bool {
+		return host == "tiny.link" && port == "443"
+	}
+	cases := []struct {
+		longURL string
+		want    error
+	}{
+		{"https://example.com/page", nil},
+		{"http://tiny.link/abc", nil}, // another port
+		{"https://tiny.link.example.com/abc", nil},
+		{"https://tiny.link/abc", ErrLongURLSelfReferring},
+		{"https://TINY.link./abc", ErrLongURLSelfReferring},
+		{"https://tiny.link:443/abc", ErrLongURLSelfReferring},
+		{"ftp://example.com/file", ErrLongURLInvalid},
+		{"javascript:alert(1)", ErrLongURLInvalid},
+		{"https://", ErrLongURLInvalid},
+		{"example.com", ErrLongURLInvalid},
+	}
+	for _, tc := range cases {
+		if err := ValidateLongURL(tc.longURL, isOwnHost); !errors.Is(err, tc.want) {
+			t.Errorf("ValidateLongURL(%q) = %v, want %v", tc.longURL, err, tc.want)
+		}
+	}
+
+	if err := ValidateLongURL("https://tiny.link/abc", nil); err != nil {
+		t.Errorf("expected no host check without isOwnHost, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package validation
+
+import (
+	"fmt"
+
+	"github.com/Varun5711/shorternit/internal/models"
+)
+
+// Result collects every problem found with a request body, rather than
+// stopping at the first, so a client can show them all together. The zero
+// Result is valid and empty.
+type Result struct {
+	errors []models.FieldError
+}
+
+// Add records that field is invalid, with a models.ErrCodeInvalidRequest
+// code.
+func (r *Result) Add(field, message string) {
+	r.AddCode(field, models.ErrCodeInvalidRequest, message)
+}
+
+// AddCode records that field is invalid, with code, one of the 400
+// models.ErrCode* values.
+func (r *Result) AddCode(field, code, message string) {
+	r.errors = append(r.errors, models.FieldError{Field: field, Code: code, Message: message})
+}
+
+// Check records err against field if it is not nil.
+func (r *Result) Check(field string, err error) {
+	if err != nil {
+		r.Add(field, err.Error())
+	}
+}
+
+// Valid reports whether nothing was recorded.
+func (r *Result) Valid() bool {
+	return len(r.errors) == 0
+}
+
+// Errors returns the problems recorded, in the order they were found.
+func (r *Result) Errors() []models.FieldError {
+	return r.errors
+}
+
+// Response returns the error envelope reporting r. Its code is that of the
+// problems found when they all share one, else models.ErrCodeInvalidRequest,
+// and its message that of the only problem, or a count of them.
+func (r *Result) Response() models.ErrorResponse {
+	resp := models.ErrorResponse{Code: models.ErrCodeInvalidRequest, Errors: r.errors}
+	if len(r.errors) == 0 {
+		return resp
+	}
+	resp.Code = r.errors[0].Code
+	for _, e := range r.errors[1:] {
+		if e.Code != resp.Code {
+			resp.Code = models.ErrCodeInvalidRequest
+			break
+		}
+	}
+	if len(r.errors) == 1 {
+		resp.Message = r.errors[0].Message
+	} else {
+		resp.Message = fmt.Sprintf("the request has %d problems", len(r.errors))
+	}
+	return resp
+}
@@ -0,0 +1,34 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/Varun5711/shorternit/internal/models"
+)
+
+func TestResult_Response(t *testing.T) {
+	var res Result
+	if !res.Valid() {
+		t.Fatal("expected the zero Result to be valid")
+	}
+
+	res.AddCode("long_url", models.ErrCodeInvalidURL, "invalid URL format")
+	if resp := res.Response(); resp.Code != models.ErrCodeInvalidURL || resp.Message != "invalid URL format" {
+		t.Errorf("expected the only problem's code and message, got %+v", resp)
+	}
+
+	res.AddCode("variants[0].long_url", models.ErrCodeInvalidURL, "invalid URL format")
+	if resp := res.Response(); resp.Code != models.ErrCodeInvalidURL || len(resp.Errors) != 2 {
+		t.Errorf("expected the shared code and both problems, got %+v", resp)
+	}
+
+	res.Check("tags", nil)
+	res.Check("tags", ErrTooManyTags)
+	resp := res.Response()
+	if resp.Code != models.ErrCodeInvalidRequest || resp.Message != "the request has 3 problems" {
+		t.Errorf("expected a generic code and a count for mixed problems, got %+v", resp)
+	}
+	if len(resp.Errors) != 3 || resp.Errors[2].Field != "tags" || resp.Errors[2].Message != ErrTooManyTags.Error() {
+		t.Errorf("expected the problems in the order found, got %+v", resp.Errors)
+	}
+}
@@ -0,0 +1,68 @@
+package validation
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Limits on link tags. They keep the tags column small enough to index and
+// the tag filter UI readable.
+const (
+	MaxTagsPerURL = 10
+	MaxTagLength  = 32
+)
+
+// Sentinel errors for tag validation failures.
+var (
+	ErrTooManyTags     = errors.New("at most 10 tags are allowed per link")
+	ErrTagEmpty        = errors.New("tags must not be empty")
+	ErrTagTooLong      = errors.New("tags must be at most 32 characters")
+	ErrTagInvalidChars = errors.New("tags can only contain letters, numbers, hyphens, and underscores")
+)
+
+// tagRegex matches a normalized (lowercase) tag.
+var tagRegex = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// NormalizeTag trims and lowercases a single tag and validates it. It is
+// used both for stored tags and for the tag filter on list requests, so a
+// filter can only ever name a tag that could have been stored.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", ErrTagEmpty
+	}
+	if len(tag) > MaxTagLength {
+		return "", ErrTagTooLong
+	}
+	if !tagRegex.MatchString(tag) {
+		return "", ErrTagInvalidChars
+	}
+	return tag, nil
+}
+
+// NormalizeTags normalizes each tag with NormalizeTag, drops duplicates while
+// keeping the first-seen order, and enforces the per-link limit. Normalizing
+// before storage means "Work", "work " and "WORK" are one tag for filtering
+// and counting. A nil or empty input yields an empty, non-nil slice.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+
+	for _, tag := range tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > MaxTagsPerURL {
+		return nil, ErrTooManyTags
+	}
+	return normalized, nil
+}
@@ -0,0 +1,65 @@
+package validation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestNormalizeTags_LowercasesAndDedupes verifies tags are trimmed,
+// lowercased and deduplicated in first-seen order.
+func TestNormalizeTags_LowercasesAndDedupes(t *testing.T) {
+	got, err := NormalizeTags([]string{" Work", "work", "Q3-launch", "WORK ", "misc_2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"work", "q3-launch", "misc_2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+// TestNormalizeTags_Empty ensures a missing tag list normalizes to an empty
+// slice rather than nil, so it is stored as an empty array.
+func TestNormalizeTags_Empty(t *testing.T) {
+	got, err := NormalizeTags(nil)
+	if err != nil || got == nil || len(got) != 0 {
+		t.Errorf("expected empty non-nil slice, got %v (err %v)", got, err)
+	}
+}
+
+// TestNormalizeTags_Invalid covers each rejection rule.
+func TestNormalizeTags_Invalid(t *testing.T) {
+	tooMany := make([]string, MaxTagsPerURL+1)
+	for i := range tooMany {
+		tooMany[i] = "t" + string(rune('a'+i))
+	}
+
+	cases := []struct {
+		tags []string
+		want error
+	}{
+		{[]string{"ok", " "}, ErrTagEmpty},
+		{[]string{strings.Repeat("a", MaxTagLength+1)}, ErrTagTooLong},
+		{[]string{"has space"}, ErrTagInvalidChars},
+		{[]string{"émoji"}, ErrTagInvalidChars},
+		{tooMany, ErrTooManyTags},
+	}
+
+	for _, tc := range cases {
+		if _, err := NormalizeTags(tc.tags); err != tc.want {
+			t.Errorf("NormalizeTags(%q): expected %v, got %v", tc.tags, tc.want, err)
+		}
+	}
+}
+
+// TestNormalizeTag covers the single-tag form used by the list filter.
+func TestNormalizeTag(t *testing.T) {
+	if got, err := NormalizeTag("  Q3-Launch "); err != nil || got != "q3-launch" {
+		t.Errorf("expected q3-launch, got %q (err %v)", got, err)
+	}
+	if _, err := NormalizeTag("bad tag"); err != ErrTagInvalidChars {
+		t.Errorf("expected ErrTagInvalidChars, got %v", err)
+	}
+}
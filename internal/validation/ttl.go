@@ -0,0 +1,52 @@
+package validation
+
+import (
+	"errors"
+	"time"
+)
+
+// Values of a user's default link lifetime besides a duration.
+const (
+	DefaultURLTTLNever  = "never"   // links never expire
+	DefaultURLTTLGlobal = "default" // links get the global DEFAULT_URL_TTL
+)
+
+// MinDefaultURLTTL is the shortest default link lifetime a user may choose;
+// the users table stores it in whole seconds.
+const MinDefaultURLTTL = time.Minute
+
+// ErrDefaultURLTTLInvalid is returned for a default link lifetime that is
+// neither a keyword nor a duration of at least MinDefaultURLTTL.
+var ErrDefaultURLTTLInvalid = errors.New(`default_url_ttl must be a duration of at least 1m such as "168h", "never" or "default"`)
+
+// ParseDefaultURLTTL parses a user's default link lifetime: nil for
+// "default", 0 for "never", otherwise a Go duration of at least
+// MinDefaultURLTTL, truncated to whole seconds.
+func ParseDefaultURLTTL(value string) (*time.Duration, error) {
+	var ttl time.Duration
+	switch value {
+	case DefaultURLTTLGlobal:
+		return nil, nil
+	case DefaultURLTTLNever:
+	default:
+		d, err := time.ParseDuration(value)
+		if err != nil || d < MinDefaultURLTTL {
+			return nil, ErrDefaultURLTTLInvalid
+		}
+		ttl = d.Truncate(time.Second)
+	}
+	return &ttl, nil
+}
+
+// FormatDefaultURLTTL is the inverse of ParseDefaultURLTTL, except that the
+// global default is "" rather than "default".
+func FormatDefaultURLTTL(ttl *time.Duration) string {
+	switch {
+	case ttl == nil:
+		return ""
+	case *ttl == 0:
+		return DefaultURLTTLNever
+	default:
+		return ttl.String()
+	}
+}
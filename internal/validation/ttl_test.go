@@ -0,0 +1,52 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseDefaultURLTTL(t *testing.T) {
+	week := 7 * 24 * time.Hour
+	cases := []struct {
+		value string
+		want  *time.Duration
+		err   error
+	}{
+		{"default", nil, nil},
+		{"never", new(time.Duration), nil},
+		{"168h", &week, nil},
+		{"1m", ptr(time.Minute), nil},
+		{"90.5s", ptr(90 * time.Second), nil},
+		{"30s", nil, ErrDefaultURLTTLInvalid},
+		{"-1h", nil, ErrDefaultURLTTLInvalid},
+		{"a week", nil, ErrDefaultURLTTLInvalid},
+	}
+	for _, tc := range cases {
+		got, err := ParseDefaultURLTTL(tc.value)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("ParseDefaultURLTTL(%q): expected error %v, got %v", tc.value, tc.err, err)
+			continue
+		}
+		if (got == nil) != (tc.want == nil) || (got != nil && *got != *tc.want) {
+			t.Errorf("ParseDefaultURLTTL(%q): expected %v, got %v", tc.value, tc.want, got)
+		}
+	}
+}
+
+func TestFormatDefaultURLTTL(t *testing.T) {
+	for _, tc := range []struct {
+		ttl  *time.Duration
+		want string
+	}{
+		{nil, ""},
+		{new(time.Duration), "never"},
+		{ptr(168 * time.Hour), "168h0m0s"},
+	} {
+		if got := FormatDefaultURLTTL(tc.ttl); got != tc.want {
+			t.Errorf("FormatDefaultURLTTL(%v): expected %q, got %q", tc.ttl, tc.want, got)
+		}
+	}
+}
+
+func ptr(d time.Duration) *time.Duration { return &d }
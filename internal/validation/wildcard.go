@@ -0,0 +1,52 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+)
+
+// MinWildcardPrefixLength is the shortest prefix a wildcard alias may claim.
+const MinWildcardPrefixLength = 4
+
+// Sentinel errors for wildcard alias validation failures.
+var (
+	ErrWildcardNoStar      = errors.New("wildcard must end with a single *, as in promo-*")
+	ErrWildcardTooShort    = errors.New("wildcard prefix must be at least 4 characters")
+	ErrWildcardNoSeparator = errors.New("wildcard prefix must contain a hyphen or underscore")
+	ErrWildcardQueryParam  = errors.New("query parameter can only contain letters, numbers, hyphens, and underscores")
+)
+
+// ParseWildcardPattern validates a wildcard alias such as "promo-*" and
+// returns its prefix, "promo-". The pattern must end with its only "*", and
+// the prefix must be a valid alias (see ValidateAlias) of at least
+// MinWildcardPrefixLength characters containing a hyphen or underscore.
+//
+// The separator keeps wildcards clear of generated short codes, which are
+// base62 and never contain one, so a mistyped link can never resolve
+// through someone's wildcard. Together with the minimum length it stops a
+// single rule from claiming a large share of the alias space.
+func ParseWildcardPattern(pattern string) (string, error) {
+	prefix, ok := strings.CutSuffix(strings.TrimSpace(pattern), "*")
+	if !ok || strings.Contains(prefix, "*") {
+		return "", ErrWildcardNoStar
+	}
+	if len(prefix) < MinWildcardPrefixLength {
+		return "", ErrWildcardTooShort
+	}
+	if err := ValidateAlias(prefix); err != nil {
+		return "", err
+	}
+	if !strings.ContainsAny(prefix, "-_") {
+		return "", ErrWildcardNoSeparator
+	}
+	return prefix, nil
+}
+
+// ValidateQueryParam checks the name of the query parameter a wildcard rule
+// passes the rest of the code in.
+func ValidateQueryParam(name string) error {
+	if name == "" || len(name) > 50 || !aliasRegex.MatchString(name) {
+		return ErrWildcardQueryParam
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseWildcardPattern(t *testing.T) {
+	valid := map[string]string{
+		"promo-*":       "promo-",
+		"  spring_25* ": "spring_25",
+		"ab-c*":         "ab-c",
+	}
+	for pattern, want := range valid {
+		prefix, err := ParseWildcardPattern(pattern)
+		if err != nil || prefix != want {
+			t.Errorf("ParseWildcardPattern(%q) = %q, %v; want %q", pattern, prefix, err, want)
+		}
+	}
+
+	invalid := map[string]error{
+		"promo-":   ErrWildcardNoStar,
+		"pro*mo-*": ErrWildcardNoStar,
+		"*":        ErrWildcardTooShort,
+		"ab-*":     ErrWildcardTooShort,
+		"promo*":   ErrWildcardNoSeparator,
+		"pro mo-*": ErrAliasInvalidChars,
+		"porn-*":   ErrAliasProfanity,
+	}
+	for pattern, want := range invalid {
+		if _, err := ParseWildcardPattern(pattern); !errors.Is(err, want) {
+			t.Errorf("ParseWildcardPattern(%q): expected %v, got %v", pattern, want, err)
+		}
+	}
+}
@@ -0,0 +1,373 @@
+// Package webhook delivers per-link click notifications to user-registered
+// HTTP endpoints.
+//
+// The pipeline-worker hands every enriched click to the Dispatcher together
+// with the active webhooks for that click's short code. The Dispatcher
+// decides whether each webhook should fire (click threshold and per-minute
+// rate limit, both tracked in Redis so they hold across worker replicas),
+// then queues the delivery onto a bounded pool of goroutines. Delivery never
+// blocks the stream consumer: a slow or unreachable receiver only occupies a
+// delivery goroutine, and when the queue is full new deliveries are dropped
+// rather than stalling ClickHouse ingestion.
+//
+// Targets are user-supplied, so deliveries only ever connect to public
+// addresses: ValidateTargetURL screens them at registration and the
+// delivery client's dialer re-checks every resolved IP it connects to.
+//
+// Each delivery is a JSON POST signed with HMAC-SHA256 over the raw body
+// using the webhook's secret. Receivers verify the X-Webhook-Signature header
+// ("sha256=<hex>") before trusting the payload. Failed deliveries are retried
+// with exponential backoff; a delivery that exhausts its retries counts as one
+// failure against the webhook, and the webhook is disabled once consecutive
+// failures reach the configured maximum.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clock"
+	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the request body, prefixed
+// with "sha256=" in the style popularised by GitHub webhooks.
+const SignatureHeader = "X-Webhook-Signature"
+
+// Store is the subset of storage.WebhookStorage the Dispatcher needs to
+// record delivery outcomes. It is an interface so tests can observe failure
+// accounting without a database.
+type Store interface {
+	RecordSuccess(ctx context.Context, id string) error
+	RecordFailure(ctx context.Context, id string, maxFailures int) (bool, error)
+}
+
+// Config controls delivery concurrency, retry behaviour, and the automatic
+// disable threshold.
+type Config struct {
+	Workers        int           // number of concurrent delivery goroutines
+	QueueSize      int           // pending deliveries buffered before new ones are dropped
+	MaxRetries     int           // retries after the first attempt (0 = single attempt)
+	InitialBackoff time.Duration // delay before the first retry; doubles each retry
+	Timeout        time.Duration // per-attempt HTTP timeout
+	MaxFailures    int           // consecutive failed deliveries before the webhook is disabled
+}
+
+// Payload is the JSON body POSTed to a webhook. It carries the enriched
+// geo/device fields produced by the pipeline-worker so receivers do not need
+// to call back into the analytics API.
+type Payload struct {
+	Event       string    `json:"event"`
+	WebhookID   string    `json:"webhook_id"`
+	EventID     string    `json:"event_id"`
+	ShortCode   string    `json:"short_code"`
+	OriginalURL string    `json:"original_url"`
+	ClickedAt   time.Time `json:"clicked_at"`
+	ClickCount  int64     `json:"click_count,omitempty"`
+	Country     string    `json:"country,omitempty"`
+	CountryCode string    `json:"country_code,omitempty"`
+	Region      string    `json:"region,omitempty"`
+	City        string    `json:"city,omitempty"`
+	Browser     string    `json:"browser,omitempty"`
+	OS          string    `json:"os,omitempty"`
+	DeviceType  string    `json:"device_type,omitempty"`
+	Referer     string    `json:"referer,omitempty"`
+}
+
+// delivery is one queued POST: the target webhook and its encoded body.
+type delivery struct {
+	webhook *models.Webhook
+	body    []byte
+}
+
+// Dispatcher fans click payloads out to webhooks on a bounded worker pool.
+type Dispatcher struct {
+	store       Store
+	redisClient *redis.Client // threshold and rate-limit counters; nil disables both checks
+	httpClient  *http.Client
+	cfg         Config
+	queue       chan delivery
+	clock       clock.Clock // picks the rate-limit window
+	log         *logger.Logger
+	wg          sync.WaitGroup
+
+	// deliverCtx parents every delivery. It is independent of the context
+	// passed to Start so that stopping intake does not abort POSTs already
+	// in flight; Shutdown cancels it only once its deadline has passed.
+	deliverCtx    context.Context
+	cancelDeliver context.CancelFunc
+}
+
+// NewDispatcher creates a Dispatcher. Zero-valued Config fields fall back to
+// conservative defaults (4 workers, 1000 queued deliveries, 3 retries from
+// 500ms, 5s timeout, disable after 10 consecutive failures).
+func NewDispatcher(store Store, redisClient *redis.Client, cfg Config, log *logger.Logger) *Dispatcher {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1000
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = 500 * time.Millisecond
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.MaxFailures <= 0 {
+		cfg.MaxFailures = 10
+	}
+
+	deliverCtx, cancelDeliver := context.WithCancel(context.Background())
+
+	return &Dispatcher{
+		store:         store,
+		redisClient:   redisClient,
+		httpClient:    newSafeHTTPClient(cfg.Timeout),
+		cfg:           cfg,
+		queue:         make(chan delivery, cfg.QueueSize),
+		clock:         clock.Real{},
+		log:           log,
+		deliverCtx:    deliverCtx,
+		cancelDeliver: cancelDeliver,
+	}
+}
+
+// Start launches the delivery goroutines. When ctx is cancelled each
+// goroutine finishes its current delivery, drains whatever is still queued,
+// and exits; call Shutdown to wait for that with a deadline. Enqueue must not
+// be called after ctx is cancelled.
+func (d *Dispatcher) Start(ctx context.Context) {
+	for i := 0; i < d.cfg.Workers; i++ {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					d.drain()
+					return
+				case job := <-d.queue:
+					d.deliver(d.deliverCtx, job)
+				}
+			}
+		}()
+	}
+}
+
+// drain delivers queued jobs until the queue is empty.
+func (d *Dispatcher) drain() {
+	for {
+		select {
+		case job := <-d.queue:
+			d.deliver(d.deliverCtx, job)
+		default:
+			return
+		}
+	}
+}
+
+// Shutdown waits for the delivery goroutines to drain the queue and exit
+// after Start's context is cancelled. If ctx expires first, in-flight and
+// remaining deliveries are cancelled, without counting as receiver failures,
+// and ctx's error is returned once the goroutines have exited.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		d.cancelDeliver()
+		return nil
+	case <-ctx.Done():
+		d.cancelDeliver()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Enqueue schedules a delivery of payload to wh if the webhook's click
+// threshold and rate limit allow it. It never blocks: when the queue is full
+// the delivery is dropped and false is returned.
+func (d *Dispatcher) Enqueue(ctx context.Context, wh *models.Webhook, payload Payload) bool {
+	count, fire := d.shouldFire(ctx, wh)
+	if !fire {
+		return false
+	}
+
+	payload.Event = "click"
+	payload.WebhookID = wh.ID
+	payload.ClickCount = count
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		d.log.Error("Failed to marshal webhook payload for %s: %v", wh.ID, err)
+		return false
+	}
+
+	select {
+	case d.queue <- delivery{webhook: wh, body: body}:
+		return true
+	default:
+		d.log.Warn("Webhook queue full, dropping delivery for %s", wh.ID)
+		return false
+	}
+}
+
+// clicksIdleTTL is how long a webhook's threshold counter survives without a
+// click. Webhooks removed along with their link or owner are never deleted
+// one by one, so their counters are left to expire.
+const clicksIdleTTL = 30 * 24 * time.Hour
+
+// ClicksKey returns the Redis key of webhook id's threshold counter. The
+// url-service deletes it when the webhook is deleted.
+func ClicksKey(id string) string {
+	return "webhook:clicks:" + id
+}
+
+// shouldFire applies the click threshold and the per-minute rate limit.
+//
+// The threshold counter (ClicksKey) is incremented on every click and the
+// webhook fires when it is a multiple of ClickThreshold, so a threshold of
+// 100 fires on the 100th, 200th, ... click. Each click also pushes its
+// expiry out to clicksIdleTTL, so a webhook idle that long starts counting
+// again from zero. The rate-limit counter is a fixed one-minute window
+// ("webhook:rate:<id>:<minute>") that expires on its own. If Redis is
+// unavailable both checks fail open, since a missed notification is worse
+// than an extra one.
+func (d *Dispatcher) shouldFire(ctx context.Context, wh *models.Webhook) (int64, bool) {
+	if d.redisClient == nil {
+		return 0, true
+	}
+
+	threshold := int64(wh.ClickThreshold)
+	if threshold <= 0 {
+		threshold = 1
+	}
+
+	clicksKey := ClicksKey(wh.ID)
+	pipe := d.redisClient.TxPipeline()
+	clicks := pipe.Incr(ctx, clicksKey)
+	pipe.Expire(ctx, clicksKey, clicksIdleTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, true
+	}
+	count := clicks.Val()
+	if count%threshold != 0 {
+		return count, false
+	}
+
+	if wh.RateLimitPerMinute > 0 {
+		window := strconv.FormatInt(d.clock.Now().Unix()/60, 10)
+		key := "webhook:rate:" + wh.ID + ":" + window
+
+		pipe := d.redisClient.TxPipeline()
+		incr := pipe.Incr(ctx, key)
+		pipe.Expire(ctx, key, 2*time.Minute)
+		if _, err := pipe.Exec(ctx); err == nil && incr.Val() > int64(wh.RateLimitPerMinute) {
+			return count, false
+		}
+	}
+
+	return count, true
+}
+
+// deliver POSTs one payload, retrying with exponential backoff, and records
+// the outcome. Only the final result of the retry sequence counts toward the
+// webhook's consecutive-failure total; a delivery abandoned because ctx was
+// cancelled is not the receiver's fault and is not counted.
+func (d *Dispatcher) deliver(ctx context.Context, job delivery) {
+	backoff := d.cfg.InitialBackoff
+	var err error
+
+	for attempt := 0; attempt <= d.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		if err = d.post(ctx, job); err == nil {
+			if d.store != nil {
+				_ = d.store.RecordSuccess(ctx, job.webhook.ID)
+			}
+			return
+		}
+	}
+
+	if ctx.Err() != nil {
+		d.log.Warn("Webhook %s delivery abandoned during shutdown: %v", job.webhook.ID, err)
+		return
+	}
+
+	d.log.Warn("Webhook %s delivery failed after %d attempts: %v", job.webhook.ID, d.cfg.MaxRetries+1, err)
+
+	if d.store != nil {
+		disabled, recErr := d.store.RecordFailure(ctx, job.webhook.ID, d.cfg.MaxFailures)
+		if recErr != nil {
+			d.log.Error("Failed to record webhook failure for %s: %v", job.webhook.ID, recErr)
+		}
+		if disabled {
+			d.log.Warn("Webhook %s disabled after %d consecutive failures", job.webhook.ID, d.cfg.MaxFailures)
+		}
+	}
+}
+
+// post performs a single signed HTTP POST. Any non-2xx status is an error.
+func (d *Dispatcher) post(ctx context.Context, job delivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.webhook.TargetURL, bytes.NewReader(job.body))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Tiny-Webhooks/1.0")
+	req.Header.Set("X-Webhook-ID", job.webhook.ID)
+	req.Header.Set(SignatureHeader, Sign(job.webhook.Secret, job.body))
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the X-Webhook-Signature value for body: "sha256=" followed by
+// the hex-encoded HMAC-SHA256 of body keyed with secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the valid X-Webhook-Signature for body
+// under secret. It uses a constant-time comparison so receivers built on this
+// package do not leak timing information.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
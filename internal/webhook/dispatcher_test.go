@@ -0,0 +1,268 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/logger"
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeStore records delivery outcomes in memory.
+type fakeStore struct {
+	mu        sync.Mutex
+	successes int
+	failures  int
+}
+
+func (s *fakeStore) RecordSuccess(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.successes++
+	s.failures = 0
+	return nil
+}
+
+func (s *fakeStore) RecordFailure(ctx context.Context, id string, maxFailures int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures++
+	return s.failures >= maxFailures, nil
+}
+
+// newTestDispatcher builds a Dispatcher for httptest receivers. Those listen
+// on loopback, so the SSRF-guarded client is swapped for a plain one.
+func newTestDispatcher(store Store, maxRetries int) *Dispatcher {
+	d := NewDispatcher(store, nil, Config{
+		Workers:        1,
+		MaxRetries:     maxRetries,
+		InitialBackoff: time.Millisecond,
+		Timeout:        time.Second,
+		MaxFailures:    2,
+	}, logger.New("webhook-test"))
+	d.httpClient = &http.Client{Timeout: time.Second}
+	return d
+}
+
+// TestSignAndVerify confirms that Verify accepts the signature produced by
+// Sign and rejects a tampered body or wrong secret.
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"short_code":"abc123"}`)
+	sig := Sign("secret", body)
+
+	if !Verify("secret", body, sig) {
+		t.Error("expected signature to verify")
+	}
+	if Verify("other", body, sig) {
+		t.Error("expected signature with wrong secret to fail")
+	}
+	if Verify("secret", []byte(`{"short_code":"xyz"}`), sig) {
+		t.Error("expected signature over tampered body to fail")
+	}
+}
+
+// TestDeliver_SignsPayload checks that a delivery carries a valid signature
+// header and the enriched fields in its JSON body.
+func TestDeliver_SignsPayload(t *testing.T) {
+	var gotSig string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSig = r.Header.Get(SignatureHeader)
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	store := &fakeStore{}
+	d := newTestDispatcher(store, 0)
+	wh := &models.Webhook{ID: "wh-1", TargetURL: server.URL, Secret: "s3cret"}
+
+	body, _ := json.Marshal(Payload{Event: "click", ShortCode: "abc123", Country: "India", DeviceType: "mobile"})
+	d.deliver(context.Background(), delivery{webhook: wh, body: body})
+
+	if !Verify("s3cret", gotBody, gotSig) {
+		t.Errorf("expected valid signature, got %q", gotSig)
+	}
+
+	var p Payload
+	if err := json.Unmarshal(gotBody, &p); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if p.Country != "India" || p.DeviceType != "mobile" {
+		t.Errorf("expected enriched fields in payload, got %+v", p)
+	}
+	if store.successes != 1 {
+		t.Errorf("expected 1 recorded success, got %d", store.successes)
+	}
+}
+
+// TestDeliver_RetriesUntilSuccess verifies that transient 5xx responses are
+// retried and that an eventual success is recorded as such.
+func TestDeliver_RetriesUntilSuccess(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	store := &fakeStore{}
+	d := newTestDispatcher(store, 3)
+	wh := &models.Webhook{ID: "wh-1", TargetURL: server.URL, Secret: "s"}
+
+	d.deliver(context.Background(), delivery{webhook: wh, body: []byte(`{}`)})
+
+	if atomic.LoadInt32(&attempts) != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+	if store.successes != 1 || store.failures != 0 {
+		t.Errorf("expected success recorded, got successes=%d failures=%d", store.successes, store.failures)
+	}
+}
+
+// TestDeliver_DisablesAfterRepeatedFailures ensures each exhausted retry
+// sequence counts as one failure and that the store is told to disable the
+// webhook once MaxFailures is reached.
+func TestDeliver_DisablesAfterRepeatedFailures(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	store := &fakeStore{}
+	d := newTestDispatcher(store, 1)
+	wh := &models.Webhook{ID: "wh-1", TargetURL: server.URL, Secret: "s"}
+
+	d.deliver(context.Background(), delivery{webhook: wh, body: []byte(`{}`)})
+	d.deliver(context.Background(), delivery{webhook: wh, body: []byte(`{}`)})
+
+	if atomic.LoadInt32(&attempts) != 4 {
+		t.Errorf("expected 4 attempts (2 deliveries x 2 tries), got %d", attempts)
+	}
+	if store.failures != 2 {
+		t.Errorf("expected 2 recorded failures, got %d", store.failures)
+	}
+}
+
+// TestEnqueue_DropsWhenQueueFull checks that Enqueue never blocks the caller
+// when no worker is draining the queue.
+func TestEnqueue_DropsWhenQueueFull(t *testing.T) {
+	d := NewDispatcher(nil, nil, Config{QueueSize: 1}, logger.New("webhook-test"))
+	wh := &models.Webhook{ID: "wh-1", TargetURL: "http://example.invalid", Secret: "s"}
+
+	if !d.Enqueue(context.Background(), wh, Payload{ShortCode: "abc"}) {
+		t.Fatal("expected first enqueue to succeed")
+	}
+	if d.Enqueue(context.Background(), wh, Payload{ShortCode: "abc"}) {
+		t.Error("expected second enqueue to be dropped")
+	}
+}
+
+// TestShutdown_DrainsQueue verifies that deliveries still queued when Start's
+// context is cancelled are sent before Shutdown returns.
+func TestShutdown_DrainsQueue(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer server.Close()
+
+	store := &fakeStore{}
+	d := newTestDispatcher(store, 0)
+	wh := &models.Webhook{ID: "wh-1", TargetURL: server.URL, Secret: "s"}
+	for i := 0; i < 3; i++ {
+		if !d.Enqueue(context.Background(), wh, Payload{ShortCode: "abc"}) {
+			t.Fatal("expected enqueue to succeed")
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.Start(ctx)
+
+	if err := d.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if atomic.LoadInt32(&hits) != 3 || store.successes != 3 {
+		t.Errorf("expected 3 drained deliveries, got %d hits and %d successes", hits, store.successes)
+	}
+}
+
+// TestShutdown_DeadlineAbortsWithoutFailure checks that a delivery still
+// running at the shutdown deadline is cancelled and not counted against the
+// webhook.
+func TestShutdown_DeadlineAbortsWithoutFailure(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	store := &fakeStore{}
+	d := newTestDispatcher(store, 0)
+	d.httpClient = &http.Client{}
+	wh := &models.Webhook{ID: "wh-1", TargetURL: server.URL, Secret: "s"}
+	d.Enqueue(context.Background(), wh, Payload{ShortCode: "abc"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.Start(ctx)
+	cancel()
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelShutdown()
+	if err := d.Shutdown(shutdownCtx); err == nil {
+		t.Fatal("expected the shutdown deadline to be reported")
+	}
+	if store.failures != 0 || store.successes != 0 {
+		t.Errorf("expected no recorded outcome, got successes=%d failures=%d", store.successes, store.failures)
+	}
+}
+
+// TestShouldFire_ClicksCounterExpires verifies, against the Redis at
+// REDIS_TEST_ADDR, that the threshold counter counts clicks and is given an
+// expiry rather than living forever. It is skipped when REDIS_TEST_ADDR is
+// not set.
+func TestShouldFire_ClicksCounterExpires(t *testing.T) {
+	addr := os.Getenv("REDIS_TEST_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_TEST_ADDR not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Fatalf("Redis at %s: %v", addr, err)
+	}
+
+	wh := &models.Webhook{ID: "wh-test-" + time.Now().Format("150405.000000000"), ClickThreshold: 2}
+	t.Cleanup(func() { _ = client.Del(context.Background(), ClicksKey(wh.ID)).Err() })
+	d := NewDispatcher(&fakeStore{}, client, Config{Workers: 1}, logger.New("webhook-test"))
+
+	if _, fire := d.shouldFire(ctx, wh); fire {
+		t.Error("expected the first click under a threshold of 2 not to fire")
+	}
+	if count, fire := d.shouldFire(ctx, wh); !fire || count != 2 {
+		t.Errorf("expected the second click to fire with count 2, got %d, %v", count, fire)
+	}
+	if ttl := client.TTL(ctx, ClicksKey(wh.ID)).Val(); ttl <= 0 || ttl > clicksIdleTTL {
+		t.Errorf("expected the counter to expire within %v, got TTL %v", clicksIdleTTL, ttl)
+	}
+}
@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/netguard"
+)
+
+// ErrForbiddenTarget is returned for webhook targets that are not plain
+// http(s) URLs or that point at a non-public address.
+var ErrForbiddenTarget = errors.New("webhook target must be an http(s) URL on a public address")
+
+// ValidateTargetURL checks a webhook target at registration time: it must be
+// an absolute http(s) URL whose host is a public IP or a DNS name that
+// resolves only to public IPs. The answer can change after registration, so
+// the Dispatcher re-checks every connection it makes (see newSafeHTTPClient).
+func ValidateTargetURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrForbiddenTarget
+	}
+
+	host := u.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !netguard.IsPublicIP(addr) {
+			return ErrForbiddenTarget
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve webhook host %q: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if !netguard.IsPublicIP(addr) {
+			return ErrForbiddenTarget
+		}
+	}
+	return nil
+}
+
+// newSafeHTTPClient returns the client used for deliveries, which only
+// connects to public addresses (see netguard.NewTransport).
+func newSafeHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: netguard.NewTransport(timeout, 4),
+	}
+}
@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/models"
+	"github.com/Varun5711/shorternit/internal/netguard"
+)
+
+// TestValidateTargetURL_PublicIP verifies that a target on a public IP is
+// accepted without a DNS lookup.
+func TestValidateTargetURL_PublicIP(t *testing.T) {
+	if err := ValidateTargetURL(context.Background(), "https://93.184.216.34/hook"); err != nil {
+		t.Errorf("expected a public IP target to be accepted, got %v", err)
+	}
+}
+
+// TestDeliver_RefusesLoopback verifies that the production delivery client
+// will not connect to an internal address even when the stored target points
+// there, e.g. after DNS rebinding.
+func TestDeliver_RefusesLoopback(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer server.Close()
+
+	d := newTestDispatcher(nil, 0)
+	d.httpClient = newSafeHTTPClient(time.Second)
+	wh := &models.Webhook{ID: "wh-1", TargetURL: server.URL, Secret: "s"}
+
+	err := d.post(context.Background(), delivery{webhook: wh, body: []byte(`{}`)})
+	if !errors.Is(err, netguard.ErrForbiddenAddress) {
+		t.Errorf("expected netguard.ErrForbiddenAddress, got %v", err)
+	}
+	if atomic.LoadInt32(&hits) != 0 {
+		t.Errorf("expected the loopback receiver not to be reached, got %d hits", hits)
+	}
+}
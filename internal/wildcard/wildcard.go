@@ -0,0 +1,167 @@
+// Package wildcard resolves short codes through wildcard aliases: a rule
+// claims a prefix such as "promo-", and any code starting with it that is
+// not a link of its own redirects to the rule's destination with the rest
+// of the code, "summer" for "promo-summer", appended as a path segment or a
+// query parameter. This lets a campaign hand out any number of codes
+// without creating each link.
+//
+// The redirect service only consults the rules after a code's own lookup
+// misses, so a link always wins over a rule that also matches it.
+package wildcard
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clock"
+)
+
+// How a rule adds the rest of the code to its destination.
+const (
+	SuffixPath  = "path"  // as a last path segment: /promo + summer = /promo/summer
+	SuffixQuery = "query" // as the value of QueryParam
+)
+
+// DefaultQueryParam is the query parameter of a SuffixQuery rule that does
+// not name one.
+const DefaultQueryParam = "code"
+
+// ErrSuffixMode is returned by CheckSuffixMode for an unknown mode.
+var ErrSuffixMode = errors.New(`suffix mode must be "path" or "query"`)
+
+// CheckSuffixMode reports whether mode is SuffixPath or SuffixQuery.
+func CheckSuffixMode(mode string) error {
+	if mode != SuffixPath && mode != SuffixQuery {
+		return ErrSuffixMode
+	}
+	return nil
+}
+
+// Rule sends every code starting with Prefix to Destination.
+type Rule struct {
+	Prefix      string
+	Destination string
+	SuffixMode  string // SuffixPath or SuffixQuery
+	QueryParam  string // for SuffixQuery; DefaultQueryParam if empty
+}
+
+// suffixRegex is the character set of aliases. A suffix outside it is not
+// matched, so nothing but letters, digits, hyphens and underscores is ever
+// spliced into a destination.
+var suffixRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Match returns the rule of rules whose prefix is the longest one code
+// starts with, and the rest of code after it. A code equal to a prefix, or
+// whose rest is not alias characters, matches nothing.
+func Match(rules []Rule, code string) (Rule, string, bool) {
+	var best Rule
+	found := false
+	for _, r := range rules {
+		if len(code) > len(r.Prefix) && strings.HasPrefix(code, r.Prefix) && (!found || len(r.Prefix) > len(best.Prefix)) {
+			best, found = r, true
+		}
+	}
+	if !found {
+		return Rule{}, "", false
+	}
+	suffix := code[len(best.Prefix):]
+	if !suffixRegex.MatchString(suffix) {
+		return Rule{}, "", false
+	}
+	return best, suffix, true
+}
+
+// Expand returns r's destination with suffix added according to its
+// SuffixMode. The destination's own query and fragment are kept.
+func (r Rule) Expand(suffix string) (string, error) {
+	u, err := url.Parse(r.Destination)
+	if err != nil {
+		return "", err
+	}
+	switch r.SuffixMode {
+	case SuffixQuery:
+		param := r.QueryParam
+		if param == "" {
+			param = DefaultQueryParam
+		}
+		q := u.Query()
+		q.Set(param, suffix)
+		u.RawQuery = q.Encode()
+	default:
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + suffix
+		u.RawPath = ""
+	}
+	return u.String(), nil
+}
+
+// Overlap reports whether one of two prefixes starts with the other, so
+// that some code would match both.
+func Overlap(a, b string) bool {
+	return strings.HasPrefix(a, b) || strings.HasPrefix(b, a)
+}
+
+// LoadFunc returns the rules of a domain, "" being the default domain.
+type LoadFunc func(ctx context.Context, domain string) ([]Rule, error)
+
+// Cache keeps each domain's rules in memory for ttl after loading them, so
+// a burst of unknown codes costs one load per domain rather than one per
+// request. A domain without rules is cached as such. When a reload fails
+// the rules loaded last are kept for another ttl.
+type Cache struct {
+	load  LoadFunc
+	ttl   time.Duration
+	clock clock.Clock
+
+	mu      sync.Mutex // serialises loads so concurrent misses share one
+	domains map[string]cachedRules
+}
+
+type cachedRules struct {
+	rules  []Rule
+	loaded time.Time
+}
+
+// NewCache creates a Cache loading rules with load.
+func NewCache(load LoadFunc, ttl time.Duration) *Cache {
+	return &Cache{load: load, ttl: ttl, clock: clock.Real{}, domains: make(map[string]cachedRules)}
+}
+
+// Match returns the rule of domain that code matches (see Match) and the
+// rest of code after its prefix. A nil *Cache matches nothing.
+func (c *Cache) Match(ctx context.Context, domain, code string) (Rule, string, bool, error) {
+	if c == nil {
+		return Rule{}, "", false, nil
+	}
+	rules, err := c.rules(ctx, domain)
+	if err != nil {
+		return Rule{}, "", false, err
+	}
+	rule, suffix, ok := Match(rules, code)
+	return rule, suffix, ok, nil
+}
+
+func (c *Cache) rules(ctx context.Context, domain string) ([]Rule, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.domains[domain]
+	if ok && c.clock.Now().Sub(cached.loaded) < c.ttl {
+		return cached.rules, nil
+	}
+	rules, err := c.load(ctx, domain)
+	if err != nil {
+		if !ok {
+			return nil, err
+		}
+		// Keep serving the old rules, and wait ttl before trying again.
+		c.domains[domain] = cachedRules{rules: cached.rules, loaded: c.clock.Now()}
+		return cached.rules, nil
+	}
+	c.domains[domain] = cachedRules{rules: rules, loaded: c.clock.Now()}
+	return rules, nil
+}
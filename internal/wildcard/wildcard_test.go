@@ -0,0 +1,114 @@
+package wildcard
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Varun5711/shorternit/internal/clock/clocktest"
+)
+
+func TestMatch_LongestPrefixWins(t *testing.T) {
+	rules := []Rule{
+		{Prefix: "promo-", Destination: "https://shop.example/promo"},
+		{Prefix: "promo-vip-", Destination: "https://shop.example/vip"},
+		{Prefix: "spring_", Destination: "https://shop.example/spring"},
+	}
+
+	tests := []struct {
+		code   string
+		prefix string
+		suffix string
+		ok     bool
+	}{
+		{"promo-summer", "promo-", "summer", true},
+		{"promo-vip-alice", "promo-vip-", "alice", true},
+		{"promo-vip-", "promo-", "vip-", true},
+		{"spring_25", "spring_", "25", true},
+		{"promo-", "", "", false},       // a prefix alone is not a code
+		{"promo-a.b", "", "", false},    // not alias characters
+		{"promo-a%2Fb", "", "", false},  // nor escapes
+		{"autumn-1", "", "", false},     // no rule
+		{"PROMO-summer", "", "", false}, // prefixes are case-sensitive, like codes
+	}
+	for _, tt := range tests {
+		rule, suffix, ok := Match(rules, tt.code)
+		if ok != tt.ok || rule.Prefix != tt.prefix || suffix != tt.suffix {
+			t.Errorf("Match(%q) = %q, %q, %v; want %q, %q, %v", tt.code, rule.Prefix, suffix, ok, tt.prefix, tt.suffix, tt.ok)
+		}
+	}
+}
+
+func TestRule_Expand(t *testing.T) {
+	tests := []struct {
+		rule Rule
+		want string
+	}{
+		{Rule{Destination: "https://shop.example/promo", SuffixMode: SuffixPath}, "https://shop.example/promo/summer"},
+		{Rule{Destination: "https://shop.example/promo/?utm_source=sms", SuffixMode: SuffixPath}, "https://shop.example/promo/summer?utm_source=sms"},
+		{Rule{Destination: "https://shop.example", SuffixMode: SuffixPath}, "https://shop.example/summer"},
+		{Rule{Destination: "https://shop.example/landing?utm_source=sms#top", SuffixMode: SuffixQuery}, "https://shop.example/landing?code=summer&utm_source=sms#top"},
+		{Rule{Destination: "https://shop.example/landing?coupon=old", SuffixMode: SuffixQuery, QueryParam: "coupon"}, "https://shop.example/landing?coupon=summer"},
+	}
+	for _, tt := range tests {
+		got, err := tt.rule.Expand("summer")
+		if err != nil || got != tt.want {
+			t.Errorf("Expand(%q, %s) = %q, %v; want %q", tt.rule.Destination, tt.rule.SuffixMode, got, err, tt.want)
+		}
+	}
+}
+
+func TestOverlap(t *testing.T) {
+	if !Overlap("promo-", "promo-vip-") || !Overlap("promo-vip-", "promo-") || !Overlap("promo-", "promo-") {
+		t.Error("expected nested prefixes to overlap")
+	}
+	if Overlap("promo-", "prom_") {
+		t.Error("expected diverging prefixes not to overlap")
+	}
+}
+
+func TestCache_ReloadsAfterTTL(t *testing.T) {
+	clk := clocktest.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	loads := 0
+	var failing bool
+	c := NewCache(func(ctx context.Context, domain string) ([]Rule, error) {
+		loads++
+		if failing {
+			return nil, errors.New("url-service unavailable")
+		}
+		if domain == "go.acme.com" {
+			return []Rule{{Prefix: "acme-", Destination: "https://acme.example"}}, nil
+		}
+		return nil, nil
+	}, time.Minute)
+	c.clock = clk
+	ctx := context.Background()
+
+	for range 3 {
+		if _, _, ok, err := c.Match(ctx, "", "acme-1"); ok || err != nil {
+			t.Fatalf("expected no rule on the default domain, got %v, %v", ok, err)
+		}
+		if _, suffix, ok, err := c.Match(ctx, "go.acme.com", "acme-1"); !ok || suffix != "1" || err != nil {
+			t.Fatalf("expected acme-1 to match on go.acme.com, got %q, %v, %v", suffix, ok, err)
+		}
+	}
+	if loads != 2 {
+		t.Errorf("expected one load per domain, got %d", loads)
+	}
+
+	// Past the TTL a failed reload keeps the rules loaded last.
+	clk.Advance(2 * time.Minute)
+	failing = true
+	if _, _, ok, err := c.Match(ctx, "go.acme.com", "acme-1"); !ok || err != nil {
+		t.Errorf("expected the cached rules to be kept when a reload fails, got %v, %v", ok, err)
+	}
+	if _, _, _, err := c.Match(ctx, "other.example", "acme-1"); err == nil {
+		t.Error("expected an error for a domain never loaded")
+	}
+
+	var nilCache *Cache
+	if _, _, ok, err := nilCache.Match(ctx, "", "acme-1"); ok || err != nil {
+		t.Errorf("expected a nil cache to match nothing, got %v, %v", ok, err)
+	}
+}
@@ -0,0 +1,2 @@
+ALTER TABLE analytics.click_events
+    ADD COLUMN IF NOT EXISTS variant UInt16 DEFAULT 0 AFTER query_params;
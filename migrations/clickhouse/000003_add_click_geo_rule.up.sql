@@ -0,0 +1,2 @@
+ALTER TABLE analytics.click_events
+    ADD COLUMN IF NOT EXISTS geo_rule String DEFAULT '' AFTER variant;
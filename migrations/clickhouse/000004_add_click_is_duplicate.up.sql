@@ -0,0 +1,2 @@
+ALTER TABLE analytics.click_events
+    ADD COLUMN IF NOT EXISTS is_duplicate UInt8 DEFAULT 0 AFTER geo_rule;
@@ -0,0 +1,46 @@
+ALTER TABLE analytics.click_events
+    ADD COLUMN IF NOT EXISTS sample_rate UInt32 DEFAULT 1 AFTER is_duplicate;
+
+-- Sampled links store one event per sample_rate clicks, so the rollups sum
+-- sample_rate instead of counting rows. Rows already aggregated are unsampled
+-- and stay correct.
+ALTER TABLE analytics.daily_clicks_by_url MODIFY QUERY
+SELECT
+    short_code,
+    clicked_date,
+    sum(sample_rate) AS click_count,
+    uniq(ip_address) AS unique_visitors
+FROM analytics.click_events
+GROUP BY short_code, clicked_date;
+
+ALTER TABLE analytics.clicks_by_country MODIFY QUERY
+SELECT
+    short_code,
+    clicked_date,
+    country,
+    country_code,
+    sum(sample_rate) AS click_count
+FROM analytics.click_events
+WHERE country != ''
+GROUP BY short_code, clicked_date, country, country_code;
+
+ALTER TABLE analytics.clicks_by_device MODIFY QUERY
+SELECT
+    short_code,
+    clicked_date,
+    device_type,
+    browser,
+    os,
+    sum(sample_rate) AS click_count
+FROM analytics.click_events
+GROUP BY short_code, clicked_date, device_type, browser, os;
+
+ALTER TABLE analytics.hourly_clicks MODIFY QUERY
+SELECT
+    short_code,
+    toStartOfHour(clicked_at) AS clicked_hour,
+    toDate(clicked_at) AS clicked_date,
+    sum(sample_rate) AS click_count,
+    uniq(ip_address) AS unique_visitors
+FROM analytics.click_events
+GROUP BY short_code, clicked_hour, clicked_date;
@@ -0,0 +1,2 @@
+ALTER TABLE analytics.click_events
+    ADD COLUMN IF NOT EXISTS is_failover UInt8 DEFAULT 0 AFTER sample_rate;
@@ -0,0 +1,35 @@
+-- ANALYTICS_FIELDS can leave a click's address and device blank. A blank
+-- address is not a visitor, and a blank device is not a device, so the
+-- rollups skip them rather than counting every such click as one visitor or
+-- one "unknown" device. Rows already aggregated were recorded in full and
+-- stay correct.
+ALTER TABLE analytics.daily_clicks_by_url MODIFY QUERY
+SELECT
+    short_code,
+    clicked_date,
+    sum(sample_rate) AS click_count,
+    uniqIf(ip_address, ip_address != '') AS unique_visitors
+FROM analytics.click_events
+GROUP BY short_code, clicked_date;
+
+ALTER TABLE analytics.clicks_by_device MODIFY QUERY
+SELECT
+    short_code,
+    clicked_date,
+    device_type,
+    browser,
+    os,
+    sum(sample_rate) AS click_count
+FROM analytics.click_events
+WHERE device_type != ''
+GROUP BY short_code, clicked_date, device_type, browser, os;
+
+ALTER TABLE analytics.hourly_clicks MODIFY QUERY
+SELECT
+    short_code,
+    toStartOfHour(clicked_at) AS clicked_hour,
+    toDate(clicked_at) AS clicked_date,
+    sum(sample_rate) AS click_count,
+    uniqIf(ip_address, ip_address != '') AS unique_visitors
+FROM analytics.click_events
+GROUP BY short_code, clicked_hour, clicked_date;
@@ -0,0 +1,9 @@
+-- Raw click events are now kept for ANALYTICS_RETENTION_DAYS (default 180),
+-- enforced by a daily deletion in the pipeline-worker, instead of this fixed
+-- TTL, which would go on deleting them at 180 days whatever the setting.
+-- The rollup tables never had a TTL and keep their aggregates after the raw
+-- events behind them are deleted.
+--
+-- REMOVE TTL fails on a table without one, but it is the only statement
+-- here, so the migration is recorded as soon as it succeeds.
+ALTER TABLE analytics.click_events REMOVE TTL;
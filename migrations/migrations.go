@@ -0,0 +1,81 @@
+// Package migrations embeds the SQL migration files so binaries can apply
+// them without the source tree, and reads them in version order.
+//
+// Migrations are plain SQL files named {version}_{description}.up.sql. The
+// runners that apply them live with their databases: internal/database/migrate
+// for PostgreSQL and internal/clickhouse/migrate for ClickHouse.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// Postgres holds the PostgreSQL migrations, under the "postgres" directory.
+//
+//go:embed postgres/*.sql
+var Postgres embed.FS
+
+// ClickHouse holds the ClickHouse migrations, under the "clickhouse"
+// directory.
+//
+//go:embed clickhouse/*.sql
+var ClickHouse embed.FS
+
+// Migration is one migration file.
+type Migration struct {
+	Version uint64
+	Name    string // file name, e.g. "000002_add_click_variant.up.sql"
+	SQL     string
+}
+
+// fileName matches up migration files and captures their version.
+var fileName = regexp.MustCompile(`^(\d+)_[A-Za-z0-9_]+\.up\.sql$`)
+
+// Load reads the up migrations in dir of fsys, sorted by version. Other
+// files, such as down migrations, are ignored. Two files with the same
+// version are an error.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	var migrations []Migration
+	for _, e := range entries {
+		m := fileName.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", e.Name(), err)
+		}
+		sql, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", e.Name(), err)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: e.Name(), SQL: string(sql)})
+	}
+
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		switch {
+		case a.Version < b.Version:
+			return -1
+		case a.Version > b.Version:
+			return 1
+		}
+		return 0
+	})
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", migrations[i].Version, migrations[i-1].Name, migrations[i].Name)
+		}
+	}
+	return migrations, nil
+}
@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad_OrdersUpMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"db/000002_add_column.up.sql": {Data: []byte("ALTER TABLE t ADD COLUMN b INT;")},
+		"db/000001_init.up.sql":       {Data: []byte("CREATE TABLE t (a INT);")},
+		"db/000001_init.down.sql":     {Data: []byte("DROP TABLE t;")},
+		"db/README.md":                {Data: []byte("notes")},
+		"db/000010_add_index.up.sql":  {Data: []byte("CREATE INDEX t_a ON t (a);")},
+	}
+	all, err := Load(fsys, "db")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, m := range all {
+		names = append(names, m.Name)
+	}
+	want := []string{"000001_init.up.sql", "000002_add_column.up.sql", "000010_add_index.up.sql"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("expected %v, got %v", want, names)
+	}
+}
+
+func TestLoad_RejectsDuplicateVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"db/000002_one.up.sql": {Data: []byte("SELECT 1")},
+		"db/0002_other.up.sql": {Data: []byte("SELECT 2")},
+	}
+	if _, err := Load(fsys, "db"); err == nil {
+		t.Error("expected an error for two migrations with version 2")
+	}
+}
+
+// TestEmbeddedMigrations checks the repository's migrations: they load, are
+// numbered without gaps, and none is empty.
+func TestEmbeddedMigrations(t *testing.T) {
+	for dir, fsys := range map[string]fs.FS{"postgres": Postgres, "clickhouse": ClickHouse} {
+		all, err := Load(fsys, dir)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", dir, err)
+		}
+		if len(all) == 0 {
+			t.Fatalf("%s: expected embedded migrations", dir)
+		}
+		for i, m := range all {
+			if m.Version != uint64(i+1) {
+				t.Errorf("%s: expected version %d, got %s", dir, i+1, m.Name)
+			}
+			if m.SQL == "" {
+				t.Errorf("%s: %s is empty", dir, m.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+    id VARCHAR(50) PRIMARY KEY,
+    short_code VARCHAR(50) NOT NULL REFERENCES urls(short_code) ON DELETE CASCADE,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    target_url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    click_threshold INTEGER DEFAULT 1 NOT NULL,
+    rate_limit_per_minute INTEGER DEFAULT 60 NOT NULL,
+    failure_count INTEGER DEFAULT 0 NOT NULL,
+    disabled BOOLEAN DEFAULT FALSE NOT NULL,
+    last_delivered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    CONSTRAINT target_url_not_empty CHECK (length(target_url) > 0),
+    CONSTRAINT click_threshold_positive CHECK (click_threshold > 0),
+    CONSTRAINT rate_limit_positive CHECK (rate_limit_per_minute > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_short_code ON webhooks(short_code)
+WHERE disabled = FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_user_id ON webhooks(user_id);
+
+CREATE TRIGGER update_webhooks_updated_at
+    BEFORE UPDATE ON webhooks
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE webhooks IS 'Per-link HTTP callbacks fired by the pipeline-worker on clicks';
+COMMENT ON COLUMN webhooks.secret IS 'HMAC-SHA256 key used to sign delivery bodies (X-Webhook-Signature)';
+COMMENT ON COLUMN webhooks.click_threshold IS 'Fire on every Nth click (1 = every click)';
+COMMENT ON COLUMN webhooks.rate_limit_per_minute IS 'Maximum deliveries per minute; excess clicks are skipped';
+COMMENT ON COLUMN webhooks.failure_count IS 'Consecutive failed deliveries; reset on success';
+COMMENT ON COLUMN webhooks.disabled IS 'Set once failure_count reaches the configured maximum';
+COMMENT ON INDEX idx_webhooks_short_code IS 'Partial index for the active-webhook lookup on every click batch';
@@ -0,0 +1,5 @@
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS max_clicks BIGINT DEFAULT 0 NOT NULL;
+
+ALTER TABLE urls ADD CONSTRAINT max_clicks_non_negative CHECK (max_clicks >= 0);
+
+COMMENT ON COLUMN urls.max_clicks IS 'Redirect cap for burn-after-N links (0 = unlimited); enforced by the redirect-service via a Redis counter';
@@ -0,0 +1,6 @@
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS active_from TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE urls ADD CONSTRAINT active_from_before_expiry
+    CHECK (active_from IS NULL OR expires_at IS NULL OR active_from < expires_at);
+
+COMMENT ON COLUMN urls.active_from IS 'Optional scheduled activation time (NULL = active immediately); redirects return 404 before this time';
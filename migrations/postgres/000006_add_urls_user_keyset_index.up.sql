@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_urls_user_created ON urls(user_id, created_at DESC, short_code DESC);
+
+COMMENT ON INDEX idx_urls_user_created IS 'Keyset pagination over a user''s URLs (bulk export)';
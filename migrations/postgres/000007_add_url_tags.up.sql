@@ -0,0 +1,6 @@
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS tags TEXT[] DEFAULT '{}' NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_urls_tags ON urls USING GIN (tags);
+
+COMMENT ON COLUMN urls.tags IS 'User-defined labels, normalized to lowercase (max 10 per link)';
+COMMENT ON INDEX idx_urls_tags IS 'Supports filtering a user''s URLs by tag (tags @> ARRAY[tag])';
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS domains (
+    domain VARCHAR(253) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    verification_token VARCHAR(64) NOT NULL,
+    verified BOOLEAN DEFAULT FALSE NOT NULL,
+    verified_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    CONSTRAINT domain_lowercase CHECK (domain = lower(domain))
+);
+
+CREATE INDEX IF NOT EXISTS idx_domains_user_id ON domains(user_id);
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS domain VARCHAR(253) DEFAULT '' NOT NULL;
+
+COMMENT ON TABLE domains IS 'Custom domains registered by users for branded short links';
+COMMENT ON COLUMN domains.verification_token IS 'Value the owner publishes in a TXT record at _tiny-verify.<domain>';
+COMMENT ON COLUMN domains.verified IS 'Set once the TXT record has been found; only verified domains can be used for links';
+COMMENT ON COLUMN urls.domain IS 'Custom domain the link is served on (empty = default base URL)';
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS url_variants (
+    short_code VARCHAR(50) NOT NULL REFERENCES urls(short_code) ON DELETE CASCADE,
+    position SMALLINT NOT NULL,
+    long_url TEXT NOT NULL,
+    weight INTEGER NOT NULL,
+    PRIMARY KEY (short_code, position),
+    CONSTRAINT variant_long_url_not_empty CHECK (length(long_url) > 0),
+    CONSTRAINT variant_weight_positive CHECK (weight > 0)
+);
+
+COMMENT ON TABLE url_variants IS 'Weighted A/B destinations of a split link; links without rows redirect to urls.long_url';
+COMMENT ON COLUMN url_variants.position IS '1-based order as given at creation; recorded as the variant on click events';
+COMMENT ON COLUMN url_variants.weight IS 'Relative share of redirects sent to this destination';
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS geo_rules (
+    short_code VARCHAR(50) NOT NULL REFERENCES urls(short_code) ON DELETE CASCADE,
+    country_code CHAR(2) NOT NULL,
+    long_url TEXT NOT NULL,
+    PRIMARY KEY (short_code, country_code),
+    CONSTRAINT geo_rule_country_code_upper CHECK (country_code ~ '^[A-Z]{2}$'),
+    CONSTRAINT geo_rule_long_url_not_empty CHECK (length(long_url) > 0)
+);
+
+COMMENT ON TABLE geo_rules IS 'Per-country destinations of a geo-targeted link; visitors from other countries get the default destination';
+COMMENT ON COLUMN geo_rules.country_code IS 'ISO 3166-1 alpha-2 code, uppercase; recorded as the geo rule on click events';
@@ -0,0 +1,4 @@
+ALTER TABLE urls ALTER COLUMN short_code TYPE VARCHAR(50);
+ALTER TABLE url_analytics ALTER COLUMN short_code TYPE VARCHAR(50);
+
+COMMENT ON COLUMN urls.short_code IS 'Base62-encoded Snowflake ID or custom alias (up to 50 characters)';
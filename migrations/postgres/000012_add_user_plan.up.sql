@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS plan VARCHAR(32) DEFAULT 'free' NOT NULL;
+
+COMMENT ON COLUMN users.plan IS 'Selects the link quota (QUOTA_PLANS); plans without an entry get the global limits';
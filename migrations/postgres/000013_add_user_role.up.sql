@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(16) DEFAULT 'user' NOT NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP WITH TIME ZONE;
+
+COMMENT ON COLUMN users.role IS 'Access level: user, or admin for the /api/admin endpoints';
+COMMENT ON COLUMN users.disabled_at IS 'When an admin disabled the account (NULL if active); disabled accounts cannot log in';
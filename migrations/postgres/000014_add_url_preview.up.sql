@@ -0,0 +1,7 @@
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS title TEXT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS description TEXT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS image_url TEXT;
+
+COMMENT ON COLUMN urls.title IS 'Destination page title, fetched in the background when PREVIEW_ENABLED (NULL until then)';
+COMMENT ON COLUMN urls.description IS 'Destination page description (og:description or meta description)';
+COMMENT ON COLUMN urls.image_url IS 'Destination page preview image (og:image), as an absolute URL';
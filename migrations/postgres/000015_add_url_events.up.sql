@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS url_events (
+    id BIGSERIAL PRIMARY KEY,
+    short_code VARCHAR(50) NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    actor_id VARCHAR(50) DEFAULT '' NOT NULL,
+    before_url TEXT,
+    after_url TEXT,
+    occurred_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    CONSTRAINT url_event_action CHECK (action IN ('create', 'update', 'delete', 'restore'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_url_events_short_code ON url_events(short_code, id);
+
+COMMENT ON TABLE url_events IS 'Audit trail of changes to links, written in the same transaction as each change; kept after the link is deleted';
+COMMENT ON COLUMN url_events.actor_id IS 'User who made the change (empty = the system)';
+COMMENT ON COLUMN url_events.before_url IS 'Destination before the change (NULL on create)';
+COMMENT ON COLUMN url_events.after_url IS 'Destination after the change (NULL on delete)';
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS default_url_ttl_seconds BIGINT;
+
+COMMENT ON COLUMN users.default_url_ttl_seconds IS 'Lifetime of links the user creates without expires_at: NULL uses DEFAULT_URL_TTL, 0 never expires';
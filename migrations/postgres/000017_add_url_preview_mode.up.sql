@@ -0,0 +1,3 @@
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS preview BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN urls.preview IS 'Show an interstitial page naming the destination instead of redirecting straight to it';
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS wildcard_rules (
+    domain VARCHAR(253) DEFAULT '' NOT NULL,
+    prefix VARCHAR(50) NOT NULL,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    destination TEXT NOT NULL,
+    suffix_mode VARCHAR(8) DEFAULT 'path' NOT NULL,
+    query_param VARCHAR(50) DEFAULT '' NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (domain, prefix),
+    CONSTRAINT wildcard_rule_suffix_mode CHECK (suffix_mode IN ('path', 'query'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_wildcard_rules_user_id ON wildcard_rules(user_id);
+
+COMMENT ON TABLE wildcard_rules IS 'Wildcard aliases: codes starting with prefix that are not links of their own redirect to destination';
+COMMENT ON COLUMN wildcard_rules.domain IS 'Custom domain the rule answers on (empty = default base URL)';
+COMMENT ON COLUMN wildcard_rules.suffix_mode IS 'How the rest of the code is added to destination: as a path segment or as query_param';
+COMMENT ON COLUMN wildcard_rules.query_param IS 'Query parameter of the query mode (empty = code)';
@@ -0,0 +1,3 @@
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS backup_url TEXT NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN urls.backup_url IS 'Destination served while long_url fails its health checks; empty for none';
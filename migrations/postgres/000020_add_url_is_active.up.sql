@@ -0,0 +1,6 @@
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS is_active BOOLEAN NOT NULL DEFAULT TRUE;
+
+COMMENT ON COLUMN urls.is_active IS 'False while the owner has disabled the link: it answers 410 Gone but keeps its code and stats';
+
+ALTER TABLE url_events DROP CONSTRAINT IF EXISTS url_event_action;
+ALTER TABLE url_events ADD CONSTRAINT url_event_action CHECK (action IN ('create', 'update', 'delete', 'restore', 'disable', 'enable'));
@@ -30,7 +30,30 @@ type CreateURLRequest struct {
 	// Optional: User ID (for authenticated requests)
 	UserId string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	// Optional: Expiration timestamp (Unix seconds, 0 = never expires)
-	ExpiresAt     int64 `protobuf:"varint,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	ExpiresAt int64 `protobuf:"varint,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// Optional: Stop redirecting after this many clicks (0 = unlimited)
+	MaxClicks int64 `protobuf:"varint,5,opt,name=max_clicks,json=maxClicks,proto3" json:"max_clicks,omitempty"`
+	// Optional: Only start redirecting at this time (Unix seconds, 0 = immediately)
+	ActiveFrom int64 `protobuf:"varint,6,opt,name=active_from,json=activeFrom,proto3" json:"active_from,omitempty"`
+	// Optional: Labels for organizing links (normalized to lowercase, max 10)
+	Tags []string `protobuf:"bytes,7,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Optional: Verified custom domain owned by user_id (empty = default base URL)
+	Domain string `protobuf:"bytes,8,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Optional: Weighted A/B destinations; with two or more, each redirect picks one
+	// at random by weight and long_url defaults to the first
+	Variants []*URLVariant `protobuf:"bytes,9,rep,name=variants,proto3" json:"variants,omitempty"`
+	// Optional: Per-country destinations that override long_url (and variants) for
+	// visitors from that country
+	GeoRules []*GeoRule `protobuf:"bytes,10,rep,name=geo_rules,json=geoRules,proto3" json:"geo_rules,omitempty"`
+	// Optional: Render the QR code now and return it in qr_code, instead of on
+	// the first request for it
+	GenerateQr bool `protobuf:"varint,11,opt,name=generate_qr,json=generateQr,proto3" json:"generate_qr,omitempty"`
+	// Optional: Show an interstitial page naming the destination instead of
+	// redirecting straight to it
+	Preview bool `protobuf:"varint,12,opt,name=preview,proto3" json:"preview,omitempty"`
+	// Optional: Where to redirect while long_url fails its health checks
+	// (not allowed with variants or geo_rules)
+	BackupUrl     string `protobuf:"bytes,13,opt,name=backup_url,json=backupUrl,proto3" json:"backup_url,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -86,6 +109,177 @@ func (x *CreateURLRequest) GetExpiresAt() int64 {
 	return 0
 }
 
+func (x *CreateURLRequest) GetMaxClicks() int64 {
+	if x != nil {
+		return x.MaxClicks
+	}
+	return 0
+}
+
+func (x *CreateURLRequest) GetActiveFrom() int64 {
+	if x != nil {
+		return x.ActiveFrom
+	}
+	return 0
+}
+
+func (x *CreateURLRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *CreateURLRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *CreateURLRequest) GetVariants() []*URLVariant {
+	if x != nil {
+		return x.Variants
+	}
+	return nil
+}
+
+func (x *CreateURLRequest) GetGeoRules() []*GeoRule {
+	if x != nil {
+		return x.GeoRules
+	}
+	return nil
+}
+
+func (x *CreateURLRequest) GetGenerateQr() bool {
+	if x != nil {
+		return x.GenerateQr
+	}
+	return false
+}
+
+func (x *CreateURLRequest) GetPreview() bool {
+	if x != nil {
+		return x.Preview
+	}
+	return false
+}
+
+func (x *CreateURLRequest) GetBackupUrl() string {
+	if x != nil {
+		return x.BackupUrl
+	}
+	return ""
+}
+
+// URLVariant is one weighted destination of an A/B split link
+type URLVariant struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	LongUrl string                 `protobuf:"bytes,1,opt,name=long_url,json=longUrl,proto3" json:"long_url,omitempty"`
+	// Relative share of traffic (positive)
+	Weight        int32 `protobuf:"varint,2,opt,name=weight,proto3" json:"weight,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *URLVariant) Reset() {
+	*x = URLVariant{}
+	mi := &file_proto_url_url_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *URLVariant) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*URLVariant) ProtoMessage() {}
+
+func (x *URLVariant) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use URLVariant.ProtoReflect.Descriptor instead.
+func (*URLVariant) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *URLVariant) GetLongUrl() string {
+	if x != nil {
+		return x.LongUrl
+	}
+	return ""
+}
+
+func (x *URLVariant) GetWeight() int32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+// GeoRule sends visitors from one country to a dedicated destination
+type GeoRule struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ISO 3166-1 alpha-2 country code (e.g. "DE")
+	CountryCode   string `protobuf:"bytes,1,opt,name=country_code,json=countryCode,proto3" json:"country_code,omitempty"`
+	LongUrl       string `protobuf:"bytes,2,opt,name=long_url,json=longUrl,proto3" json:"long_url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GeoRule) Reset() {
+	*x = GeoRule{}
+	mi := &file_proto_url_url_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GeoRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GeoRule) ProtoMessage() {}
+
+func (x *GeoRule) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GeoRule.ProtoReflect.Descriptor instead.
+func (*GeoRule) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GeoRule) GetCountryCode() string {
+	if x != nil {
+		return x.CountryCode
+	}
+	return ""
+}
+
+func (x *GeoRule) GetLongUrl() string {
+	if x != nil {
+		return x.LongUrl
+	}
+	return ""
+}
+
 type CreateURLResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// The generated short code (e.g., "abc123")
@@ -99,14 +293,28 @@ type CreateURLResponse struct {
 	// When this URL expires (Unix timestamp in seconds, 0 = never expires)
 	ExpiresAt int64 `protobuf:"varint,5,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
 	// QR code as base64 data URI (e.g., "data:image/png;base64,...")
-	QrCode        string `protobuf:"bytes,6,opt,name=qr_code,json=qrCode,proto3" json:"qr_code,omitempty"`
+	QrCode string `protobuf:"bytes,6,opt,name=qr_code,json=qrCode,proto3" json:"qr_code,omitempty"`
+	// Click cap (0 = unlimited)
+	MaxClicks int64 `protobuf:"varint,7,opt,name=max_clicks,json=maxClicks,proto3" json:"max_clicks,omitempty"`
+	// Scheduled activation time (Unix seconds, 0 = active immediately)
+	ActiveFrom int64 `protobuf:"varint,8,opt,name=active_from,json=activeFrom,proto3" json:"active_from,omitempty"`
+	// Normalized tags
+	Tags []string `protobuf:"bytes,9,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Weighted A/B destinations (empty for a single-destination link)
+	Variants []*URLVariant `protobuf:"bytes,10,rep,name=variants,proto3" json:"variants,omitempty"`
+	// Per-country destinations (empty when the link is not geo-targeted)
+	GeoRules []*GeoRule `protobuf:"bytes,11,rep,name=geo_rules,json=geoRules,proto3" json:"geo_rules,omitempty"`
+	// Whether the link shows the interstitial page
+	Preview bool `protobuf:"varint,12,opt,name=preview,proto3" json:"preview,omitempty"`
+	// Destination served while long_url is down (empty = none)
+	BackupUrl     string `protobuf:"bytes,13,opt,name=backup_url,json=backupUrl,proto3" json:"backup_url,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CreateURLResponse) Reset() {
 	*x = CreateURLResponse{}
-	mi := &file_proto_url_url_proto_msgTypes[1]
+	mi := &file_proto_url_url_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -118,7 +326,7 @@ func (x *CreateURLResponse) String() string {
 func (*CreateURLResponse) ProtoMessage() {}
 
 func (x *CreateURLResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_url_url_proto_msgTypes[1]
+	mi := &file_proto_url_url_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -131,7 +339,7 @@ func (x *CreateURLResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateURLResponse.ProtoReflect.Descriptor instead.
 func (*CreateURLResponse) Descriptor() ([]byte, []int) {
-	return file_proto_url_url_proto_rawDescGZIP(), []int{1}
+	return file_proto_url_url_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *CreateURLResponse) GetShortCode() string {
@@ -176,17 +384,73 @@ func (x *CreateURLResponse) GetQrCode() string {
 	return ""
 }
 
+func (x *CreateURLResponse) GetMaxClicks() int64 {
+	if x != nil {
+		return x.MaxClicks
+	}
+	return 0
+}
+
+func (x *CreateURLResponse) GetActiveFrom() int64 {
+	if x != nil {
+		return x.ActiveFrom
+	}
+	return 0
+}
+
+func (x *CreateURLResponse) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *CreateURLResponse) GetVariants() []*URLVariant {
+	if x != nil {
+		return x.Variants
+	}
+	return nil
+}
+
+func (x *CreateURLResponse) GetGeoRules() []*GeoRule {
+	if x != nil {
+		return x.GeoRules
+	}
+	return nil
+}
+
+func (x *CreateURLResponse) GetPreview() bool {
+	if x != nil {
+		return x.Preview
+	}
+	return false
+}
+
+func (x *CreateURLResponse) GetBackupUrl() string {
+	if x != nil {
+		return x.BackupUrl
+	}
+	return ""
+}
+
 type GetURLRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// The short code to lookup
-	ShortCode     string `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	ShortCode string `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	// The custom domain the request arrived on (empty = default base URL)
+	// Links only resolve on the domain they were created for
+	Domain string `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Optional: look the link up on behalf of its owner. The domain is then
+	// ignored, a link not yet active is returned too, and another user's link
+	// is PERMISSION_DENIED
+	UserId        string `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetURLRequest) Reset() {
 	*x = GetURLRequest{}
-	mi := &file_proto_url_url_proto_msgTypes[2]
+	mi := &file_proto_url_url_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -198,7 +462,7 @@ func (x *GetURLRequest) String() string {
 func (*GetURLRequest) ProtoMessage() {}
 
 func (x *GetURLRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_url_url_proto_msgTypes[2]
+	mi := &file_proto_url_url_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -211,7 +475,7 @@ func (x *GetURLRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetURLRequest.ProtoReflect.Descriptor instead.
 func (*GetURLRequest) Descriptor() ([]byte, []int) {
-	return file_proto_url_url_proto_rawDescGZIP(), []int{2}
+	return file_proto_url_url_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *GetURLRequest) GetShortCode() string {
@@ -221,19 +485,35 @@ func (x *GetURLRequest) GetShortCode() string {
 	return ""
 }
 
+func (x *GetURLRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *GetURLRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
 type GetURLResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// The URL object (will be nil if not found)
 	Url *URL `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
 	// Whether the URL was found
-	Found         bool `protobuf:"varint,2,opt,name=found,proto3" json:"found,omitempty"`
+	Found bool `protobuf:"varint,2,opt,name=found,proto3" json:"found,omitempty"`
+	// Whether the short code exists but has expired; found is false
+	Expired       bool `protobuf:"varint,3,opt,name=expired,proto3" json:"expired,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetURLResponse) Reset() {
 	*x = GetURLResponse{}
-	mi := &file_proto_url_url_proto_msgTypes[3]
+	mi := &file_proto_url_url_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -245,7 +525,7 @@ func (x *GetURLResponse) String() string {
 func (*GetURLResponse) ProtoMessage() {}
 
 func (x *GetURLResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_url_url_proto_msgTypes[3]
+	mi := &file_proto_url_url_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -258,7 +538,7 @@ func (x *GetURLResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetURLResponse.ProtoReflect.Descriptor instead.
 func (*GetURLResponse) Descriptor() ([]byte, []int) {
-	return file_proto_url_url_proto_rawDescGZIP(), []int{3}
+	return file_proto_url_url_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *GetURLResponse) GetUrl() *URL {
@@ -275,6 +555,13 @@ func (x *GetURLResponse) GetFound() bool {
 	return false
 }
 
+func (x *GetURLResponse) GetExpired() bool {
+	if x != nil {
+		return x.Expired
+	}
+	return false
+}
+
 type ListURLsRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Pagination: how many to return (default: 100, max: 1000)
@@ -282,14 +569,16 @@ type ListURLsRequest struct {
 	// Pagination: offset for next page
 	Offset int32 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
 	// Optional: Filter by user ID
-	UserId        string `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	UserId string `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Optional: Only URLs carrying this tag (requires user_id)
+	Tag           string `protobuf:"bytes,4,opt,name=tag,proto3" json:"tag,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ListURLsRequest) Reset() {
 	*x = ListURLsRequest{}
-	mi := &file_proto_url_url_proto_msgTypes[4]
+	mi := &file_proto_url_url_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -301,7 +590,7 @@ func (x *ListURLsRequest) String() string {
 func (*ListURLsRequest) ProtoMessage() {}
 
 func (x *ListURLsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_url_url_proto_msgTypes[4]
+	mi := &file_proto_url_url_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -314,7 +603,7 @@ func (x *ListURLsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListURLsRequest.ProtoReflect.Descriptor instead.
 func (*ListURLsRequest) Descriptor() ([]byte, []int) {
-	return file_proto_url_url_proto_rawDescGZIP(), []int{4}
+	return file_proto_url_url_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *ListURLsRequest) GetLimit() int32 {
@@ -338,6 +627,13 @@ func (x *ListURLsRequest) GetUserId() string {
 	return ""
 }
 
+func (x *ListURLsRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
 type ListURLsResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Array of URLs
@@ -345,14 +641,20 @@ type ListURLsResponse struct {
 	// Total count (for pagination)
 	Total int32 `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
 	// Whether there are more results
-	HasMore       bool `protobuf:"varint,3,opt,name=has_more,json=hasMore,proto3" json:"has_more,omitempty"`
+	HasMore bool `protobuf:"varint,3,opt,name=has_more,json=hasMore,proto3" json:"has_more,omitempty"`
+	// Clicks summed over every listed (non-expired) URL, not just this page
+	TotalClicks int64 `protobuf:"varint,4,opt,name=total_clicks,json=totalClicks,proto3" json:"total_clicks,omitempty"`
+	// How many listed URLs have reached their active_from (is_active)
+	ActiveCount int32 `protobuf:"varint,5,opt,name=active_count,json=activeCount,proto3" json:"active_count,omitempty"`
+	// How many URLs match the filter but have expired and are not listed
+	ExpiredCount  int32 `protobuf:"varint,6,opt,name=expired_count,json=expiredCount,proto3" json:"expired_count,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ListURLsResponse) Reset() {
 	*x = ListURLsResponse{}
-	mi := &file_proto_url_url_proto_msgTypes[5]
+	mi := &file_proto_url_url_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -364,7 +666,7 @@ func (x *ListURLsResponse) String() string {
 func (*ListURLsResponse) ProtoMessage() {}
 
 func (x *ListURLsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_url_url_proto_msgTypes[5]
+	mi := &file_proto_url_url_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -377,7 +679,7 @@ func (x *ListURLsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListURLsResponse.ProtoReflect.Descriptor instead.
 func (*ListURLsResponse) Descriptor() ([]byte, []int) {
-	return file_proto_url_url_proto_rawDescGZIP(), []int{5}
+	return file_proto_url_url_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *ListURLsResponse) GetUrls() []*URL {
@@ -401,28 +703,54 @@ func (x *ListURLsResponse) GetHasMore() bool {
 	return false
 }
 
-type DeleteURLRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ShortCode     string                 `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+func (x *ListURLsResponse) GetTotalClicks() int64 {
+	if x != nil {
+		return x.TotalClicks
+	}
+	return 0
+}
+
+func (x *ListURLsResponse) GetActiveCount() int32 {
+	if x != nil {
+		return x.ActiveCount
+	}
+	return 0
+}
+
+func (x *ListURLsResponse) GetExpiredCount() int32 {
+	if x != nil {
+		return x.ExpiredCount
+	}
+	return 0
+}
+
+type ExportURLsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Owner of the URLs to export (required)
+	UserId string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Opaque cursor from the previous response (empty = first page)
+	Cursor string `protobuf:"bytes,2,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	// Page size (default: 500, max: 1000)
+	Limit         int32 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteURLRequest) Reset() {
-	*x = DeleteURLRequest{}
-	mi := &file_proto_url_url_proto_msgTypes[6]
+func (x *ExportURLsRequest) Reset() {
+	*x = ExportURLsRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteURLRequest) String() string {
+func (x *ExportURLsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteURLRequest) ProtoMessage() {}
+func (*ExportURLsRequest) ProtoMessage() {}
 
-func (x *DeleteURLRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_url_url_proto_msgTypes[6]
+func (x *ExportURLsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -433,40 +761,3131 @@ func (x *DeleteURLRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteURLRequest.ProtoReflect.Descriptor instead.
-func (*DeleteURLRequest) Descriptor() ([]byte, []int) {
-	return file_proto_url_url_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use ExportURLsRequest.ProtoReflect.Descriptor instead.
+func (*ExportURLsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *DeleteURLRequest) GetShortCode() string {
+func (x *ExportURLsRequest) GetUserId() string {
 	if x != nil {
-		return x.ShortCode
+		return x.UserId
 	}
 	return ""
 }
 
-type DeleteURLResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *ExportURLsRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
 }
 
-func (x *DeleteURLResponse) Reset() {
+func (x *ExportURLsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ExportURLsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// One page of URLs, newest first
+	Urls []*URL `protobuf:"bytes,1,rep,name=urls,proto3" json:"urls,omitempty"`
+	// Cursor for the next page (empty when this was the last page)
+	NextCursor    string `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportURLsResponse) Reset() {
+	*x = ExportURLsResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportURLsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportURLsResponse) ProtoMessage() {}
+
+func (x *ExportURLsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportURLsResponse.ProtoReflect.Descriptor instead.
+func (*ExportURLsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ExportURLsResponse) GetUrls() []*URL {
+	if x != nil {
+		return x.Urls
+	}
+	return nil
+}
+
+func (x *ExportURLsResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type BatchCreateURLsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Owner of the created URLs
+	UserId string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// URLs to create (max: 500 per call)
+	Items         []*BatchCreateURLItem `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchCreateURLsRequest) Reset() {
+	*x = BatchCreateURLsRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCreateURLsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCreateURLsRequest) ProtoMessage() {}
+
+func (x *BatchCreateURLsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCreateURLsRequest.ProtoReflect.Descriptor instead.
+func (*BatchCreateURLsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *BatchCreateURLsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *BatchCreateURLsRequest) GetItems() []*BatchCreateURLItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type BatchCreateURLItem struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The original long URL to shorten
+	LongUrl string `protobuf:"bytes,1,opt,name=long_url,json=longUrl,proto3" json:"long_url,omitempty"`
+	// Optional: Custom alias (empty = generate a short code)
+	Alias string `protobuf:"bytes,2,opt,name=alias,proto3" json:"alias,omitempty"`
+	// Optional: Expiration timestamp (Unix seconds, 0 = default TTL)
+	ExpiresAt int64 `protobuf:"varint,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// Optional: Tags to attach (normalized like CreateURLRequest.tags)
+	Tags          []string `protobuf:"bytes,4,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchCreateURLItem) Reset() {
+	*x = BatchCreateURLItem{}
+	mi := &file_proto_url_url_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCreateURLItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCreateURLItem) ProtoMessage() {}
+
+func (x *BatchCreateURLItem) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCreateURLItem.ProtoReflect.Descriptor instead.
+func (*BatchCreateURLItem) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *BatchCreateURLItem) GetLongUrl() string {
+	if x != nil {
+		return x.LongUrl
+	}
+	return ""
+}
+
+func (x *BatchCreateURLItem) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *BatchCreateURLItem) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+func (x *BatchCreateURLItem) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type BatchCreateURLsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// One result per request item, in the same order
+	Results       []*BatchCreateURLResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchCreateURLsResponse) Reset() {
+	*x = BatchCreateURLsResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCreateURLsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCreateURLsResponse) ProtoMessage() {}
+
+func (x *BatchCreateURLsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCreateURLsResponse.ProtoReflect.Descriptor instead.
+func (*BatchCreateURLsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *BatchCreateURLsResponse) GetResults() []*BatchCreateURLResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type BatchCreateURLResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The created short code (empty when error is set)
+	ShortCode string `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	// The full short URL (empty when error is set)
+	ShortUrl string `protobuf:"bytes,2,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	// Why this item was not created (empty on success)
+	Error         string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchCreateURLResult) Reset() {
+	*x = BatchCreateURLResult{}
+	mi := &file_proto_url_url_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCreateURLResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCreateURLResult) ProtoMessage() {}
+
+func (x *BatchCreateURLResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCreateURLResult.ProtoReflect.Descriptor instead.
+func (*BatchCreateURLResult) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *BatchCreateURLResult) GetShortCode() string {
+	if x != nil {
+		return x.ShortCode
+	}
+	return ""
+}
+
+func (x *BatchCreateURLResult) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *BatchCreateURLResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type GetTagsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTagsRequest) Reset() {
+	*x = GetTagsRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTagsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTagsRequest) ProtoMessage() {}
+
+func (x *GetTagsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTagsRequest.ProtoReflect.Descriptor instead.
+func (*GetTagsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetTagsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type TagCount struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Tag   string                 `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	// Number of the user's URLs carrying this tag
+	Count         int64 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TagCount) Reset() {
+	*x = TagCount{}
+	mi := &file_proto_url_url_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TagCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TagCount) ProtoMessage() {}
+
+func (x *TagCount) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TagCount.ProtoReflect.Descriptor instead.
+func (*TagCount) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *TagCount) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *TagCount) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type GetTagsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Most used first
+	Tags          []*TagCount `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTagsResponse) Reset() {
+	*x = GetTagsResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTagsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTagsResponse) ProtoMessage() {}
+
+func (x *GetTagsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTagsResponse.ProtoReflect.Descriptor instead.
+func (*GetTagsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *GetTagsResponse) GetTags() []*TagCount {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type UpdateURLTagsRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ShortCode string                 `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	// Must own the URL
+	UserId string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Replaces the current tags (empty = clear)
+	Tags          []string `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateURLTagsRequest) Reset() {
+	*x = UpdateURLTagsRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateURLTagsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateURLTagsRequest) ProtoMessage() {}
+
+func (x *UpdateURLTagsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateURLTagsRequest.ProtoReflect.Descriptor instead.
+func (*UpdateURLTagsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *UpdateURLTagsRequest) GetShortCode() string {
+	if x != nil {
+		return x.ShortCode
+	}
+	return ""
+}
+
+func (x *UpdateURLTagsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UpdateURLTagsRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type UpdateURLTagsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The normalized tags now stored on the URL
+	Tags          []string `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateURLTagsResponse) Reset() {
+	*x = UpdateURLTagsResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateURLTagsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateURLTagsResponse) ProtoMessage() {}
+
+func (x *UpdateURLTagsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateURLTagsResponse.ProtoReflect.Descriptor instead.
+func (*UpdateURLTagsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *UpdateURLTagsResponse) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type DeleteURLRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ShortCode string                 `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	// The caller, recorded in the audit trail; must own the URL unless admin is set
+	UserId string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Skip the ownership check: an admin is deleting any user's link
+	Admin         bool `protobuf:"varint,3,opt,name=admin,proto3" json:"admin,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteURLRequest) Reset() {
+	*x = DeleteURLRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteURLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteURLRequest) ProtoMessage() {}
+
+func (x *DeleteURLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteURLRequest.ProtoReflect.Descriptor instead.
+func (*DeleteURLRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *DeleteURLRequest) GetShortCode() string {
+	if x != nil {
+		return x.ShortCode
+	}
+	return ""
+}
+
+func (x *DeleteURLRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *DeleteURLRequest) GetAdmin() bool {
+	if x != nil {
+		return x.Admin
+	}
+	return false
+}
+
+type DeleteURLResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteURLResponse) Reset() {
 	*x = DeleteURLResponse{}
-	mi := &file_proto_url_url_proto_msgTypes[7]
+	mi := &file_proto_url_url_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteURLResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteURLResponse) ProtoMessage() {}
+
+func (x *DeleteURLResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteURLResponse.ProtoReflect.Descriptor instead.
+func (*DeleteURLResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *DeleteURLResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+type IncrementClicksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ShortCode     string                 `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IncrementClicksRequest) Reset() {
+	*x = IncrementClicksRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IncrementClicksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IncrementClicksRequest) ProtoMessage() {}
+
+func (x *IncrementClicksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IncrementClicksRequest.ProtoReflect.Descriptor instead.
+func (*IncrementClicksRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *IncrementClicksRequest) GetShortCode() string {
+	if x != nil {
+		return x.ShortCode
+	}
+	return ""
+}
+
+type IncrementClicksResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The new click count
+	Clicks        int64 `protobuf:"varint,1,opt,name=clicks,proto3" json:"clicks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IncrementClicksResponse) Reset() {
+	*x = IncrementClicksResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IncrementClicksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IncrementClicksResponse) ProtoMessage() {}
+
+func (x *IncrementClicksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IncrementClicksResponse.ProtoReflect.Descriptor instead.
+func (*IncrementClicksResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *IncrementClicksResponse) GetClicks() int64 {
+	if x != nil {
+		return x.Clicks
+	}
+	return 0
+}
+
+type CreateCustomURLRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The custom alias/slug (e.g., "my-brand")
+	Alias string `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`
+	// The original long URL to shorten
+	LongUrl string `protobuf:"bytes,2,opt,name=long_url,json=longUrl,proto3" json:"long_url,omitempty"`
+	// Optional: Expiration timestamp (Unix seconds, 0 = never expires)
+	ExpiresAt int64 `protobuf:"varint,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// Optional: User ID (for authenticated requests)
+	UserId string `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Optional: Stop redirecting after this many clicks (0 = unlimited)
+	MaxClicks int64 `protobuf:"varint,5,opt,name=max_clicks,json=maxClicks,proto3" json:"max_clicks,omitempty"`
+	// Optional: Only start redirecting at this time (Unix seconds, 0 = immediately)
+	ActiveFrom int64 `protobuf:"varint,6,opt,name=active_from,json=activeFrom,proto3" json:"active_from,omitempty"`
+	// Optional: Labels for organizing links (normalized to lowercase, max 10)
+	Tags []string `protobuf:"bytes,7,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Optional: Verified custom domain owned by user_id (empty = default base URL)
+	Domain string `protobuf:"bytes,8,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Optional: Render the QR code now and return it in qr_code, instead of on
+	// the first request for it
+	GenerateQr bool `protobuf:"varint,9,opt,name=generate_qr,json=generateQr,proto3" json:"generate_qr,omitempty"`
+	// Optional: Show an interstitial page naming the destination instead of
+	// redirecting straight to it
+	Preview bool `protobuf:"varint,10,opt,name=preview,proto3" json:"preview,omitempty"`
+	// Optional: Where to redirect while long_url fails its health checks
+	BackupUrl     string `protobuf:"bytes,11,opt,name=backup_url,json=backupUrl,proto3" json:"backup_url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCustomURLRequest) Reset() {
+	*x = CreateCustomURLRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCustomURLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCustomURLRequest) ProtoMessage() {}
+
+func (x *CreateCustomURLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCustomURLRequest.ProtoReflect.Descriptor instead.
+func (*CreateCustomURLRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *CreateCustomURLRequest) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *CreateCustomURLRequest) GetLongUrl() string {
+	if x != nil {
+		return x.LongUrl
+	}
+	return ""
+}
+
+func (x *CreateCustomURLRequest) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+func (x *CreateCustomURLRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreateCustomURLRequest) GetMaxClicks() int64 {
+	if x != nil {
+		return x.MaxClicks
+	}
+	return 0
+}
+
+func (x *CreateCustomURLRequest) GetActiveFrom() int64 {
+	if x != nil {
+		return x.ActiveFrom
+	}
+	return 0
+}
+
+func (x *CreateCustomURLRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *CreateCustomURLRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *CreateCustomURLRequest) GetGenerateQr() bool {
+	if x != nil {
+		return x.GenerateQr
+	}
+	return false
+}
+
+func (x *CreateCustomURLRequest) GetPreview() bool {
+	if x != nil {
+		return x.Preview
+	}
+	return false
+}
+
+func (x *CreateCustomURLRequest) GetBackupUrl() string {
+	if x != nil {
+		return x.BackupUrl
+	}
+	return ""
+}
+
+type CreateCustomURLResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The custom alias (same as request)
+	ShortCode string `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	// The full short URL (e.g., "http://short.ly/my-brand")
+	ShortUrl string `protobuf:"bytes,2,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	// The original long URL (echo back for confirmation)
+	LongUrl string `protobuf:"bytes,3,opt,name=long_url,json=longUrl,proto3" json:"long_url,omitempty"`
+	// When this URL was created (Unix timestamp in seconds)
+	CreatedAt int64 `protobuf:"varint,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// When this URL expires (Unix timestamp in seconds, 0 = never expires)
+	ExpiresAt int64 `protobuf:"varint,5,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// QR code as base64 data URI (e.g., "data:image/png;base64,...")
+	QrCode string `protobuf:"bytes,6,opt,name=qr_code,json=qrCode,proto3" json:"qr_code,omitempty"`
+	// Click cap (0 = unlimited)
+	MaxClicks int64 `protobuf:"varint,7,opt,name=max_clicks,json=maxClicks,proto3" json:"max_clicks,omitempty"`
+	// Scheduled activation time (Unix seconds, 0 = active immediately)
+	ActiveFrom int64 `protobuf:"varint,8,opt,name=active_from,json=activeFrom,proto3" json:"active_from,omitempty"`
+	// Normalized tags
+	Tags []string `protobuf:"bytes,9,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Whether the link shows the interstitial page
+	Preview bool `protobuf:"varint,10,opt,name=preview,proto3" json:"preview,omitempty"`
+	// Destination served while long_url is down (empty = none)
+	BackupUrl     string `protobuf:"bytes,11,opt,name=backup_url,json=backupUrl,proto3" json:"backup_url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCustomURLResponse) Reset() {
+	*x = CreateCustomURLResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCustomURLResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCustomURLResponse) ProtoMessage() {}
+
+func (x *CreateCustomURLResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCustomURLResponse.ProtoReflect.Descriptor instead.
+func (*CreateCustomURLResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *CreateCustomURLResponse) GetShortCode() string {
+	if x != nil {
+		return x.ShortCode
+	}
+	return ""
+}
+
+func (x *CreateCustomURLResponse) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *CreateCustomURLResponse) GetLongUrl() string {
+	if x != nil {
+		return x.LongUrl
+	}
+	return ""
+}
+
+func (x *CreateCustomURLResponse) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *CreateCustomURLResponse) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+func (x *CreateCustomURLResponse) GetQrCode() string {
+	if x != nil {
+		return x.QrCode
+	}
+	return ""
+}
+
+func (x *CreateCustomURLResponse) GetMaxClicks() int64 {
+	if x != nil {
+		return x.MaxClicks
+	}
+	return 0
+}
+
+func (x *CreateCustomURLResponse) GetActiveFrom() int64 {
+	if x != nil {
+		return x.ActiveFrom
+	}
+	return 0
+}
+
+func (x *CreateCustomURLResponse) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *CreateCustomURLResponse) GetPreview() bool {
+	if x != nil {
+		return x.Preview
+	}
+	return false
+}
+
+func (x *CreateCustomURLResponse) GetBackupUrl() string {
+	if x != nil {
+		return x.BackupUrl
+	}
+	return ""
+}
+
+// URL represents a shortened URL
+// This is like your DTO/Entity in NestJS
+type URL struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The short code (Base62 encoded ID)
+	ShortCode string `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	// The original long URL
+	LongUrl string `protobuf:"bytes,2,opt,name=long_url,json=longUrl,proto3" json:"long_url,omitempty"`
+	// Number of times this URL has been clicked
+	Clicks int64 `protobuf:"varint,3,opt,name=clicks,proto3" json:"clicks,omitempty"`
+	// When this URL was created (Unix timestamp in seconds)
+	CreatedAt int64 `protobuf:"varint,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// When this URL was last updated
+	UpdatedAt int64 `protobuf:"varint,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Whether this URL is active (false while deleted, disabled or not yet activated)
+	IsActive bool `protobuf:"varint,6,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	// Optional: When this URL expires (Unix timestamp, 0 = never expires)
+	ExpiresAt int64 `protobuf:"varint,7,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// The full short URL (e.g., "http://short.ly/abc123")
+	ShortUrl string `protobuf:"bytes,8,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	// Stop redirecting after this many clicks (0 = unlimited)
+	MaxClicks int64 `protobuf:"varint,9,opt,name=max_clicks,json=maxClicks,proto3" json:"max_clicks,omitempty"`
+	// Optional: When this URL starts redirecting (Unix timestamp, 0 = immediately)
+	ActiveFrom int64 `protobuf:"varint,10,opt,name=active_from,json=activeFrom,proto3" json:"active_from,omitempty"`
+	// Labels for organizing links (lowercase)
+	Tags []string `protobuf:"bytes,11,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Custom domain the link is served on (empty = default base URL)
+	Domain string `protobuf:"bytes,12,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Weighted A/B destinations (empty for a single-destination link)
+	Variants []*URLVariant `protobuf:"bytes,13,rep,name=variants,proto3" json:"variants,omitempty"`
+	// Per-country destinations (empty when the link is not geo-targeted)
+	GeoRules []*GeoRule `protobuf:"bytes,14,rep,name=geo_rules,json=geoRules,proto3" json:"geo_rules,omitempty"`
+	// QR code as a PNG data URI, or the object store key it was uploaded under
+	QrCode string `protobuf:"bytes,15,opt,name=qr_code,json=qrCode,proto3" json:"qr_code,omitempty"`
+	// Destination page title, once the link preview has been fetched
+	Title string `protobuf:"bytes,16,opt,name=title,proto3" json:"title,omitempty"`
+	// Destination page description (og:description or meta description)
+	Description string `protobuf:"bytes,17,opt,name=description,proto3" json:"description,omitempty"`
+	// Destination page preview image (og:image)
+	ImageUrl string `protobuf:"bytes,18,opt,name=image_url,json=imageUrl,proto3" json:"image_url,omitempty"`
+	// Whether the link shows an interstitial page naming its destination
+	// instead of redirecting straight to it
+	Preview bool `protobuf:"varint,19,opt,name=preview,proto3" json:"preview,omitempty"`
+	// Destination served while long_url fails its health checks (empty = none)
+	BackupUrl string `protobuf:"bytes,20,opt,name=backup_url,json=backupUrl,proto3" json:"backup_url,omitempty"`
+	// The user who owns the link (empty for links created anonymously)
+	UserId string `protobuf:"bytes,21,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Whether the owner has disabled the link, which then answers 410 Gone
+	Disabled      bool `protobuf:"varint,22,opt,name=disabled,proto3" json:"disabled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *URL) Reset() {
+	*x = URL{}
+	mi := &file_proto_url_url_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *URL) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*URL) ProtoMessage() {}
+
+func (x *URL) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use URL.ProtoReflect.Descriptor instead.
+func (*URL) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *URL) GetShortCode() string {
+	if x != nil {
+		return x.ShortCode
+	}
+	return ""
+}
+
+func (x *URL) GetLongUrl() string {
+	if x != nil {
+		return x.LongUrl
+	}
+	return ""
+}
+
+func (x *URL) GetClicks() int64 {
+	if x != nil {
+		return x.Clicks
+	}
+	return 0
+}
+
+func (x *URL) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *URL) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+func (x *URL) GetIsActive() bool {
+	if x != nil {
+		return x.IsActive
+	}
+	return false
+}
+
+func (x *URL) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+func (x *URL) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *URL) GetMaxClicks() int64 {
+	if x != nil {
+		return x.MaxClicks
+	}
+	return 0
+}
+
+func (x *URL) GetActiveFrom() int64 {
+	if x != nil {
+		return x.ActiveFrom
+	}
+	return 0
+}
+
+func (x *URL) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *URL) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *URL) GetVariants() []*URLVariant {
+	if x != nil {
+		return x.Variants
+	}
+	return nil
+}
+
+func (x *URL) GetGeoRules() []*GeoRule {
+	if x != nil {
+		return x.GeoRules
+	}
+	return nil
+}
+
+func (x *URL) GetQrCode() string {
+	if x != nil {
+		return x.QrCode
+	}
+	return ""
+}
+
+func (x *URL) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *URL) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *URL) GetImageUrl() string {
+	if x != nil {
+		return x.ImageUrl
+	}
+	return ""
+}
+
+func (x *URL) GetPreview() bool {
+	if x != nil {
+		return x.Preview
+	}
+	return false
+}
+
+func (x *URL) GetBackupUrl() string {
+	if x != nil {
+		return x.BackupUrl
+	}
+	return ""
+}
+
+func (x *URL) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *URL) GetDisabled() bool {
+	if x != nil {
+		return x.Disabled
+	}
+	return false
+}
+
+// Webhook is a per-link click notification target
+// The signing secret is never included; it is only returned once on registration
+type Webhook struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ShortCode string                 `protobuf:"bytes,2,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	// The receiver URL that click payloads are POSTed to
+	TargetUrl string `protobuf:"bytes,3,opt,name=target_url,json=targetUrl,proto3" json:"target_url,omitempty"`
+	// Fire on every Nth click (1 = every click)
+	ClickThreshold int32 `protobuf:"varint,4,opt,name=click_threshold,json=clickThreshold,proto3" json:"click_threshold,omitempty"`
+	// Maximum deliveries per minute (0 = unlimited)
+	RateLimitPerMinute int32 `protobuf:"varint,5,opt,name=rate_limit_per_minute,json=rateLimitPerMinute,proto3" json:"rate_limit_per_minute,omitempty"`
+	// Consecutive failed deliveries since the last success
+	FailureCount int32 `protobuf:"varint,6,opt,name=failure_count,json=failureCount,proto3" json:"failure_count,omitempty"`
+	// Set automatically once failure_count reaches the configured maximum
+	Disabled bool `protobuf:"varint,7,opt,name=disabled,proto3" json:"disabled,omitempty"`
+	// When this webhook was registered (Unix timestamp in seconds)
+	CreatedAt int64 `protobuf:"varint,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Last successful delivery (Unix timestamp in seconds, 0 = never)
+	LastDeliveredAt int64 `protobuf:"varint,9,opt,name=last_delivered_at,json=lastDeliveredAt,proto3" json:"last_delivered_at,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Webhook) Reset() {
+	*x = Webhook{}
+	mi := &file_proto_url_url_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Webhook) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Webhook) ProtoMessage() {}
+
+func (x *Webhook) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Webhook.ProtoReflect.Descriptor instead.
+func (*Webhook) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *Webhook) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Webhook) GetShortCode() string {
+	if x != nil {
+		return x.ShortCode
+	}
+	return ""
+}
+
+func (x *Webhook) GetTargetUrl() string {
+	if x != nil {
+		return x.TargetUrl
+	}
+	return ""
+}
+
+func (x *Webhook) GetClickThreshold() int32 {
+	if x != nil {
+		return x.ClickThreshold
+	}
+	return 0
+}
+
+func (x *Webhook) GetRateLimitPerMinute() int32 {
+	if x != nil {
+		return x.RateLimitPerMinute
+	}
+	return 0
+}
+
+func (x *Webhook) GetFailureCount() int32 {
+	if x != nil {
+		return x.FailureCount
+	}
+	return 0
+}
+
+func (x *Webhook) GetDisabled() bool {
+	if x != nil {
+		return x.Disabled
+	}
+	return false
+}
+
+func (x *Webhook) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *Webhook) GetLastDeliveredAt() int64 {
+	if x != nil {
+		return x.LastDeliveredAt
+	}
+	return 0
+}
+
+type RegisterWebhookRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The short code to watch (must be owned by user_id)
+	ShortCode string `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	UserId    string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	TargetUrl string `protobuf:"bytes,3,opt,name=target_url,json=targetUrl,proto3" json:"target_url,omitempty"`
+	// Optional: Fire on every Nth click (default: 1)
+	ClickThreshold int32 `protobuf:"varint,4,opt,name=click_threshold,json=clickThreshold,proto3" json:"click_threshold,omitempty"`
+	// Optional: Maximum deliveries per minute (default: 60)
+	RateLimitPerMinute int32 `protobuf:"varint,5,opt,name=rate_limit_per_minute,json=rateLimitPerMinute,proto3" json:"rate_limit_per_minute,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *RegisterWebhookRequest) Reset() {
+	*x = RegisterWebhookRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterWebhookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterWebhookRequest) ProtoMessage() {}
+
+func (x *RegisterWebhookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterWebhookRequest.ProtoReflect.Descriptor instead.
+func (*RegisterWebhookRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *RegisterWebhookRequest) GetShortCode() string {
+	if x != nil {
+		return x.ShortCode
+	}
+	return ""
+}
+
+func (x *RegisterWebhookRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *RegisterWebhookRequest) GetTargetUrl() string {
+	if x != nil {
+		return x.TargetUrl
+	}
+	return ""
+}
+
+func (x *RegisterWebhookRequest) GetClickThreshold() int32 {
+	if x != nil {
+		return x.ClickThreshold
+	}
+	return 0
+}
+
+func (x *RegisterWebhookRequest) GetRateLimitPerMinute() int32 {
+	if x != nil {
+		return x.RateLimitPerMinute
+	}
+	return 0
+}
+
+type RegisterWebhookResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Webhook *Webhook               `protobuf:"bytes,1,opt,name=webhook,proto3" json:"webhook,omitempty"`
+	// HMAC-SHA256 key for verifying the X-Webhook-Signature header
+	// Only returned here; store it on the receiver side
+	Secret        string `protobuf:"bytes,2,opt,name=secret,proto3" json:"secret,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterWebhookResponse) Reset() {
+	*x = RegisterWebhookResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterWebhookResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterWebhookResponse) ProtoMessage() {}
+
+func (x *RegisterWebhookResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterWebhookResponse.ProtoReflect.Descriptor instead.
+func (*RegisterWebhookResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *RegisterWebhookResponse) GetWebhook() *Webhook {
+	if x != nil {
+		return x.Webhook
+	}
+	return nil
+}
+
+func (x *RegisterWebhookResponse) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+type ListWebhooksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ShortCode     string                 `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWebhooksRequest) Reset() {
+	*x = ListWebhooksRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWebhooksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWebhooksRequest) ProtoMessage() {}
+
+func (x *ListWebhooksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWebhooksRequest.ProtoReflect.Descriptor instead.
+func (*ListWebhooksRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ListWebhooksRequest) GetShortCode() string {
+	if x != nil {
+		return x.ShortCode
+	}
+	return ""
+}
+
+func (x *ListWebhooksRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type ListWebhooksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Webhooks      []*Webhook             `protobuf:"bytes,1,rep,name=webhooks,proto3" json:"webhooks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWebhooksResponse) Reset() {
+	*x = ListWebhooksResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWebhooksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWebhooksResponse) ProtoMessage() {}
+
+func (x *ListWebhooksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWebhooksResponse.ProtoReflect.Descriptor instead.
+func (*ListWebhooksResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ListWebhooksResponse) GetWebhooks() []*Webhook {
+	if x != nil {
+		return x.Webhooks
+	}
+	return nil
+}
+
+type DeleteWebhookRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteWebhookRequest) Reset() {
+	*x = DeleteWebhookRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteWebhookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteWebhookRequest) ProtoMessage() {}
+
+func (x *DeleteWebhookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteWebhookRequest.ProtoReflect.Descriptor instead.
+func (*DeleteWebhookRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *DeleteWebhookRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DeleteWebhookRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type DeleteWebhookResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteWebhookResponse) Reset() {
+	*x = DeleteWebhookResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteWebhookResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteWebhookResponse) ProtoMessage() {}
+
+func (x *DeleteWebhookResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteWebhookResponse.ProtoReflect.Descriptor instead.
+func (*DeleteWebhookResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *DeleteWebhookResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+// Domain is a custom domain registered by a user for branded short links
+type Domain struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Lowercase host name, e.g. "go.acme.com"
+	Domain string `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Whether the DNS challenge has been passed; only verified domains can be used
+	Verified bool `protobuf:"varint,2,opt,name=verified,proto3" json:"verified,omitempty"`
+	// DNS name that must carry a TXT record with verification_token
+	VerificationRecord string `protobuf:"bytes,3,opt,name=verification_record,json=verificationRecord,proto3" json:"verification_record,omitempty"`
+	// Value of the TXT record that proves ownership
+	VerificationToken string `protobuf:"bytes,4,opt,name=verification_token,json=verificationToken,proto3" json:"verification_token,omitempty"`
+	// When the domain was registered (Unix timestamp in seconds)
+	CreatedAt int64 `protobuf:"varint,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// When the domain was verified (Unix timestamp in seconds, 0 = not yet)
+	VerifiedAt    int64 `protobuf:"varint,6,opt,name=verified_at,json=verifiedAt,proto3" json:"verified_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Domain) Reset() {
+	*x = Domain{}
+	mi := &file_proto_url_url_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Domain) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Domain) ProtoMessage() {}
+
+func (x *Domain) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Domain.ProtoReflect.Descriptor instead.
+func (*Domain) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *Domain) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *Domain) GetVerified() bool {
+	if x != nil {
+		return x.Verified
+	}
+	return false
+}
+
+func (x *Domain) GetVerificationRecord() string {
+	if x != nil {
+		return x.VerificationRecord
+	}
+	return ""
+}
+
+func (x *Domain) GetVerificationToken() string {
+	if x != nil {
+		return x.VerificationToken
+	}
+	return ""
+}
+
+func (x *Domain) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *Domain) GetVerifiedAt() int64 {
+	if x != nil {
+		return x.VerifiedAt
+	}
+	return 0
+}
+
+type RegisterDomainRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Domain        string                 `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterDomainRequest) Reset() {
+	*x = RegisterDomainRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterDomainRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterDomainRequest) ProtoMessage() {}
+
+func (x *RegisterDomainRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterDomainRequest.ProtoReflect.Descriptor instead.
+func (*RegisterDomainRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *RegisterDomainRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *RegisterDomainRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+type RegisterDomainResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Domain        *Domain                `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterDomainResponse) Reset() {
+	*x = RegisterDomainResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterDomainResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterDomainResponse) ProtoMessage() {}
+
+func (x *RegisterDomainResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterDomainResponse.ProtoReflect.Descriptor instead.
+func (*RegisterDomainResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *RegisterDomainResponse) GetDomain() *Domain {
+	if x != nil {
+		return x.Domain
+	}
+	return nil
+}
+
+type ListDomainsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDomainsRequest) Reset() {
+	*x = ListDomainsRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDomainsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDomainsRequest) ProtoMessage() {}
+
+func (x *ListDomainsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDomainsRequest.ProtoReflect.Descriptor instead.
+func (*ListDomainsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *ListDomainsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type ListDomainsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Domains       []*Domain              `protobuf:"bytes,1,rep,name=domains,proto3" json:"domains,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDomainsResponse) Reset() {
+	*x = ListDomainsResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDomainsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDomainsResponse) ProtoMessage() {}
+
+func (x *ListDomainsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDomainsResponse.ProtoReflect.Descriptor instead.
+func (*ListDomainsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *ListDomainsResponse) GetDomains() []*Domain {
+	if x != nil {
+		return x.Domains
+	}
+	return nil
+}
+
+type VerifyDomainRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Domain        string                 `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyDomainRequest) Reset() {
+	*x = VerifyDomainRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyDomainRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyDomainRequest) ProtoMessage() {}
+
+func (x *VerifyDomainRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyDomainRequest.ProtoReflect.Descriptor instead.
+func (*VerifyDomainRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *VerifyDomainRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *VerifyDomainRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+type VerifyDomainResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Domain        *Domain                `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyDomainResponse) Reset() {
+	*x = VerifyDomainResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyDomainResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyDomainResponse) ProtoMessage() {}
+
+func (x *VerifyDomainResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyDomainResponse.ProtoReflect.Descriptor instead.
+func (*VerifyDomainResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *VerifyDomainResponse) GetDomain() *Domain {
+	if x != nil {
+		return x.Domain
+	}
+	return nil
+}
+
+// URLEvent is one entry of a URL's audit trail
+type URLEvent struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	ShortCode string                 `protobuf:"bytes,2,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	// "create", "update" or "delete"
+	Action string `protobuf:"bytes,3,opt,name=action,proto3" json:"action,omitempty"`
+	// User who made the change ("" = the system)
+	ActorId string `protobuf:"bytes,4,opt,name=actor_id,json=actorId,proto3" json:"actor_id,omitempty"`
+	// Destination before the change ("" on create)
+	BeforeUrl string `protobuf:"bytes,5,opt,name=before_url,json=beforeUrl,proto3" json:"before_url,omitempty"`
+	// Destination after the change ("" on delete)
+	AfterUrl string `protobuf:"bytes,6,opt,name=after_url,json=afterUrl,proto3" json:"after_url,omitempty"`
+	// When the change was made (Unix timestamp in seconds)
+	OccurredAt    int64 `protobuf:"varint,7,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *URLEvent) Reset() {
+	*x = URLEvent{}
+	mi := &file_proto_url_url_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *URLEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*URLEvent) ProtoMessage() {}
+
+func (x *URLEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use URLEvent.ProtoReflect.Descriptor instead.
+func (*URLEvent) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *URLEvent) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *URLEvent) GetShortCode() string {
+	if x != nil {
+		return x.ShortCode
+	}
+	return ""
+}
+
+func (x *URLEvent) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *URLEvent) GetActorId() string {
+	if x != nil {
+		return x.ActorId
+	}
+	return ""
+}
+
+func (x *URLEvent) GetBeforeUrl() string {
+	if x != nil {
+		return x.BeforeUrl
+	}
+	return ""
+}
+
+func (x *URLEvent) GetAfterUrl() string {
+	if x != nil {
+		return x.AfterUrl
+	}
+	return ""
+}
+
+func (x *URLEvent) GetOccurredAt() int64 {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return 0
+}
+
+type GetURLHistoryRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ShortCode string                 `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	// Must own the URL unless admin is set
+	UserId string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Skip the ownership check and include earlier, deleted links that used the same short code
+	Admin         bool `protobuf:"varint,3,opt,name=admin,proto3" json:"admin,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetURLHistoryRequest) Reset() {
+	*x = GetURLHistoryRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetURLHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetURLHistoryRequest) ProtoMessage() {}
+
+func (x *GetURLHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetURLHistoryRequest.ProtoReflect.Descriptor instead.
+func (*GetURLHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *GetURLHistoryRequest) GetShortCode() string {
+	if x != nil {
+		return x.ShortCode
+	}
+	return ""
+}
+
+func (x *GetURLHistoryRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetURLHistoryRequest) GetAdmin() bool {
+	if x != nil {
+		return x.Admin
+	}
+	return false
+}
+
+type GetURLHistoryResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Oldest first
+	Events        []*URLEvent `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetURLHistoryResponse) Reset() {
+	*x = GetURLHistoryResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetURLHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetURLHistoryResponse) ProtoMessage() {}
+
+func (x *GetURLHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetURLHistoryResponse.ProtoReflect.Descriptor instead.
+func (*GetURLHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *GetURLHistoryResponse) GetEvents() []*URLEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type ReactivateURLRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ShortCode string                 `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	// Must have owned the link when it expired or was deleted
+	UserId string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// New expiration timestamp (Unix seconds, 0 = the user's default expiry)
+	ExpiresAt     int64 `protobuf:"varint,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReactivateURLRequest) Reset() {
+	*x = ReactivateURLRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReactivateURLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReactivateURLRequest) ProtoMessage() {}
+
+func (x *ReactivateURLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReactivateURLRequest.ProtoReflect.Descriptor instead.
+func (*ReactivateURLRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *ReactivateURLRequest) GetShortCode() string {
+	if x != nil {
+		return x.ShortCode
+	}
+	return ""
+}
+
+func (x *ReactivateURLRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ReactivateURLRequest) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type ReactivateURLResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ShortCode string                 `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	ShortUrl  string                 `protobuf:"bytes,2,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	LongUrl   string                 `protobuf:"bytes,3,opt,name=long_url,json=longUrl,proto3" json:"long_url,omitempty"`
+	// When the reactivated link expires (Unix seconds, 0 = never)
+	ExpiresAt     int64 `protobuf:"varint,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReactivateURLResponse) Reset() {
+	*x = ReactivateURLResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReactivateURLResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReactivateURLResponse) ProtoMessage() {}
+
+func (x *ReactivateURLResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReactivateURLResponse.ProtoReflect.Descriptor instead.
+func (*ReactivateURLResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *ReactivateURLResponse) GetShortCode() string {
+	if x != nil {
+		return x.ShortCode
+	}
+	return ""
+}
+
+func (x *ReactivateURLResponse) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *ReactivateURLResponse) GetLongUrl() string {
+	if x != nil {
+		return x.LongUrl
+	}
+	return ""
+}
+
+func (x *ReactivateURLResponse) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type DeleteURLsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The caller; only their own links are deleted
+	UserId string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Short codes to delete (max: 100 per call)
+	ShortCodes    []string `protobuf:"bytes,2,rep,name=short_codes,json=shortCodes,proto3" json:"short_codes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteURLsRequest) Reset() {
+	*x = DeleteURLsRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteURLsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteURLsRequest) ProtoMessage() {}
+
+func (x *DeleteURLsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteURLsRequest.ProtoReflect.Descriptor instead.
+func (*DeleteURLsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *DeleteURLsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *DeleteURLsRequest) GetShortCodes() []string {
+	if x != nil {
+		return x.ShortCodes
+	}
+	return nil
+}
+
+type DeleteURLsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// One result per distinct requested short code, in request order
+	Results       []*DeleteURLsResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteURLsResponse) Reset() {
+	*x = DeleteURLsResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteURLsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteURLsResponse) ProtoMessage() {}
+
+func (x *DeleteURLsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteURLsResponse.ProtoReflect.Descriptor instead.
+func (*DeleteURLsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *DeleteURLsResponse) GetResults() []*DeleteURLsResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type DeleteURLsResult struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ShortCode string                 `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	// "deleted", "not_found", or "forbidden" for someone else's link
+	Status        string `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteURLsResult) Reset() {
+	*x = DeleteURLsResult{}
+	mi := &file_proto_url_url_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteURLsResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteURLsResult) ProtoMessage() {}
+
+func (x *DeleteURLsResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteURLsResult.ProtoReflect.Descriptor instead.
+func (*DeleteURLsResult) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *DeleteURLsResult) GetShortCode() string {
+	if x != nil {
+		return x.ShortCode
+	}
+	return ""
+}
+
+func (x *DeleteURLsResult) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type RegenerateQRRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ShortCode string                 `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	// Must own the link
+	UserId string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Width and height of the image in pixels (0 = 256)
+	Size int32 `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	// Colors as "#rrggbb" ("" = black on white)
+	Foreground    string `protobuf:"bytes,4,opt,name=foreground,proto3" json:"foreground,omitempty"`
+	Background    string `protobuf:"bytes,5,opt,name=background,proto3" json:"background,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegenerateQRRequest) Reset() {
+	*x = RegenerateQRRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegenerateQRRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegenerateQRRequest) ProtoMessage() {}
+
+func (x *RegenerateQRRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegenerateQRRequest.ProtoReflect.Descriptor instead.
+func (*RegenerateQRRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *RegenerateQRRequest) GetShortCode() string {
+	if x != nil {
+		return x.ShortCode
+	}
+	return ""
+}
+
+func (x *RegenerateQRRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *RegenerateQRRequest) GetSize() int32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *RegenerateQRRequest) GetForeground() string {
+	if x != nil {
+		return x.Foreground
+	}
+	return ""
+}
+
+func (x *RegenerateQRRequest) GetBackground() string {
+	if x != nil {
+		return x.Background
+	}
+	return ""
+}
+
+type RegenerateQRResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ShortCode string                 `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	// The new image: an inline data URI, or its object store key
+	QrCode string `protobuf:"bytes,2,opt,name=qr_code,json=qrCode,proto3" json:"qr_code,omitempty"`
+	// Custom domain of the link ("" = default)
+	Domain        string `protobuf:"bytes,3,opt,name=domain,proto3" json:"domain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegenerateQRResponse) Reset() {
+	*x = RegenerateQRResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegenerateQRResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegenerateQRResponse) ProtoMessage() {}
+
+func (x *RegenerateQRResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegenerateQRResponse.ProtoReflect.Descriptor instead.
+func (*RegenerateQRResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *RegenerateQRResponse) GetShortCode() string {
+	if x != nil {
+		return x.ShortCode
+	}
+	return ""
+}
+
+func (x *RegenerateQRResponse) GetQrCode() string {
+	if x != nil {
+		return x.QrCode
+	}
+	return ""
+}
+
+func (x *RegenerateQRResponse) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+// AnalyticsToken grants read-only access to one link's analytics
+type AnalyticsToken struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Token     string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	ShortCode string                 `protobuf:"bytes,2,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	// When the token was minted (Unix timestamp in seconds)
+	CreatedAt int64 `protobuf:"varint,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// When the token stops working (Unix timestamp in seconds, 0 = never)
+	ExpiresAt     int64 `protobuf:"varint,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AnalyticsToken) Reset() {
+	*x = AnalyticsToken{}
+	mi := &file_proto_url_url_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnalyticsToken) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalyticsToken) ProtoMessage() {}
+
+func (x *AnalyticsToken) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalyticsToken.ProtoReflect.Descriptor instead.
+func (*AnalyticsToken) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *AnalyticsToken) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *AnalyticsToken) GetShortCode() string {
+	if x != nil {
+		return x.ShortCode
+	}
+	return ""
+}
+
+func (x *AnalyticsToken) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *AnalyticsToken) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type GenerateAnalyticsTokenRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ShortCode string                 `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	// Must own the link
+	UserId string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// How long the token works for, in seconds (0 = until revoked)
+	TtlSeconds    int64 `protobuf:"varint,3,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerateAnalyticsTokenRequest) Reset() {
+	*x = GenerateAnalyticsTokenRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateAnalyticsTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateAnalyticsTokenRequest) ProtoMessage() {}
+
+func (x *GenerateAnalyticsTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateAnalyticsTokenRequest.ProtoReflect.Descriptor instead.
+func (*GenerateAnalyticsTokenRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *GenerateAnalyticsTokenRequest) GetShortCode() string {
+	if x != nil {
+		return x.ShortCode
+	}
+	return ""
+}
+
+func (x *GenerateAnalyticsTokenRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GenerateAnalyticsTokenRequest) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+type GenerateAnalyticsTokenResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         *AnalyticsToken        `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerateAnalyticsTokenResponse) Reset() {
+	*x = GenerateAnalyticsTokenResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateAnalyticsTokenResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateAnalyticsTokenResponse) ProtoMessage() {}
+
+func (x *GenerateAnalyticsTokenResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateAnalyticsTokenResponse.ProtoReflect.Descriptor instead.
+func (*GenerateAnalyticsTokenResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *GenerateAnalyticsTokenResponse) GetToken() *AnalyticsToken {
+	if x != nil {
+		return x.Token
+	}
+	return nil
+}
+
+type ListAnalyticsTokensRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ShortCode string                 `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	// Must own the link
+	UserId        string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAnalyticsTokensRequest) Reset() {
+	*x = ListAnalyticsTokensRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAnalyticsTokensRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAnalyticsTokensRequest) ProtoMessage() {}
+
+func (x *ListAnalyticsTokensRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAnalyticsTokensRequest.ProtoReflect.Descriptor instead.
+func (*ListAnalyticsTokensRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *ListAnalyticsTokensRequest) GetShortCode() string {
+	if x != nil {
+		return x.ShortCode
+	}
+	return ""
+}
+
+func (x *ListAnalyticsTokensRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type ListAnalyticsTokensResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Newest first
+	Tokens        []*AnalyticsToken `protobuf:"bytes,1,rep,name=tokens,proto3" json:"tokens,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAnalyticsTokensResponse) Reset() {
+	*x = ListAnalyticsTokensResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAnalyticsTokensResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAnalyticsTokensResponse) ProtoMessage() {}
+
+func (x *ListAnalyticsTokensResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAnalyticsTokensResponse.ProtoReflect.Descriptor instead.
+func (*ListAnalyticsTokensResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *ListAnalyticsTokensResponse) GetTokens() []*AnalyticsToken {
+	if x != nil {
+		return x.Tokens
+	}
+	return nil
+}
+
+type RevokeAnalyticsTokenRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ShortCode string                 `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	// Must own the link
+	UserId string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// The token to revoke ("" = every token of the link)
+	Token         string `protobuf:"bytes,3,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeAnalyticsTokenRequest) Reset() {
+	*x = RevokeAnalyticsTokenRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeAnalyticsTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeAnalyticsTokenRequest) ProtoMessage() {}
+
+func (x *RevokeAnalyticsTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeAnalyticsTokenRequest.ProtoReflect.Descriptor instead.
+func (*RevokeAnalyticsTokenRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *RevokeAnalyticsTokenRequest) GetShortCode() string {
+	if x != nil {
+		return x.ShortCode
+	}
+	return ""
+}
+
+func (x *RevokeAnalyticsTokenRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *RevokeAnalyticsTokenRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type RevokeAnalyticsTokenResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// How many tokens were revoked
+	Revoked       int32 `protobuf:"varint,1,opt,name=revoked,proto3" json:"revoked,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeAnalyticsTokenResponse) Reset() {
+	*x = RevokeAnalyticsTokenResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeAnalyticsTokenResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeAnalyticsTokenResponse) ProtoMessage() {}
+
+func (x *RevokeAnalyticsTokenResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeAnalyticsTokenResponse.ProtoReflect.Descriptor instead.
+func (*RevokeAnalyticsTokenResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *RevokeAnalyticsTokenResponse) GetRevoked() int32 {
+	if x != nil {
+		return x.Revoked
+	}
+	return 0
+}
+
+// WildcardRule sends every code starting with prefix that is not a link of its own to
+// destination, with the rest of the code added to it
+type WildcardRule struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Custom domain the rule answers on ("" = default)
+	Domain string `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	// E.g. "promo-"; the pattern is the prefix followed by "*"
+	Prefix      string `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Destination string `protobuf:"bytes,3,opt,name=destination,proto3" json:"destination,omitempty"`
+	// How the rest of the code is added: "path" (a last path segment) or "query"
+	SuffixMode string `protobuf:"bytes,4,opt,name=suffix_mode,json=suffixMode,proto3" json:"suffix_mode,omitempty"`
+	// Query parameter of the "query" mode
+	QueryParam string `protobuf:"bytes,5,opt,name=query_param,json=queryParam,proto3" json:"query_param,omitempty"`
+	// When the rule was created (Unix timestamp in seconds)
+	CreatedAt     int64 `protobuf:"varint,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WildcardRule) Reset() {
+	*x = WildcardRule{}
+	mi := &file_proto_url_url_proto_msgTypes[57]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteURLResponse) String() string {
+func (x *WildcardRule) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteURLResponse) ProtoMessage() {}
+func (*WildcardRule) ProtoMessage() {}
 
-func (x *DeleteURLResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_url_url_proto_msgTypes[7]
+func (x *WildcardRule) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[57]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -477,40 +3896,402 @@ func (x *DeleteURLResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteURLResponse.ProtoReflect.Descriptor instead.
-func (*DeleteURLResponse) Descriptor() ([]byte, []int) {
-	return file_proto_url_url_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use WildcardRule.ProtoReflect.Descriptor instead.
+func (*WildcardRule) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{57}
 }
 
-func (x *DeleteURLResponse) GetSuccess() bool {
+func (x *WildcardRule) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *WildcardRule) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *WildcardRule) GetDestination() string {
+	if x != nil {
+		return x.Destination
+	}
+	return ""
+}
+
+func (x *WildcardRule) GetSuffixMode() string {
+	if x != nil {
+		return x.SuffixMode
+	}
+	return ""
+}
+
+func (x *WildcardRule) GetQueryParam() string {
+	if x != nil {
+		return x.QueryParam
+	}
+	return ""
+}
+
+func (x *WildcardRule) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+type CreateWildcardRuleRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// E.g. "promo-*": a prefix of at least 4 alias characters, including a hyphen or
+	// underscore, followed by "*"
+	Pattern     string `protobuf:"bytes,2,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	Destination string `protobuf:"bytes,3,opt,name=destination,proto3" json:"destination,omitempty"`
+	// Custom domain, which must be verified and the caller's ("" = default)
+	Domain string `protobuf:"bytes,4,opt,name=domain,proto3" json:"domain,omitempty"`
+	// "path" or "query" ("" = path)
+	SuffixMode string `protobuf:"bytes,5,opt,name=suffix_mode,json=suffixMode,proto3" json:"suffix_mode,omitempty"`
+	// Query parameter of the "query" mode ("" = code)
+	QueryParam    string `protobuf:"bytes,6,opt,name=query_param,json=queryParam,proto3" json:"query_param,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateWildcardRuleRequest) Reset() {
+	*x = CreateWildcardRuleRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[58]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateWildcardRuleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateWildcardRuleRequest) ProtoMessage() {}
+
+func (x *CreateWildcardRuleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[58]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateWildcardRuleRequest.ProtoReflect.Descriptor instead.
+func (*CreateWildcardRuleRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *CreateWildcardRuleRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreateWildcardRuleRequest) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *CreateWildcardRuleRequest) GetDestination() string {
+	if x != nil {
+		return x.Destination
+	}
+	return ""
+}
+
+func (x *CreateWildcardRuleRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *CreateWildcardRuleRequest) GetSuffixMode() string {
+	if x != nil {
+		return x.SuffixMode
+	}
+	return ""
+}
+
+func (x *CreateWildcardRuleRequest) GetQueryParam() string {
+	if x != nil {
+		return x.QueryParam
+	}
+	return ""
+}
+
+type CreateWildcardRuleResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rule          *WildcardRule          `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateWildcardRuleResponse) Reset() {
+	*x = CreateWildcardRuleResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[59]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateWildcardRuleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateWildcardRuleResponse) ProtoMessage() {}
+
+func (x *CreateWildcardRuleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[59]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateWildcardRuleResponse.ProtoReflect.Descriptor instead.
+func (*CreateWildcardRuleResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{59}
+}
+
+func (x *CreateWildcardRuleResponse) GetRule() *WildcardRule {
+	if x != nil {
+		return x.Rule
+	}
+	return nil
+}
+
+type ListWildcardRulesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWildcardRulesRequest) Reset() {
+	*x = ListWildcardRulesRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[60]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWildcardRulesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWildcardRulesRequest) ProtoMessage() {}
+
+func (x *ListWildcardRulesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[60]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWildcardRulesRequest.ProtoReflect.Descriptor instead.
+func (*ListWildcardRulesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{60}
+}
+
+func (x *ListWildcardRulesRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type ListWildcardRulesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rules         []*WildcardRule        `protobuf:"bytes,1,rep,name=rules,proto3" json:"rules,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWildcardRulesResponse) Reset() {
+	*x = ListWildcardRulesResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[61]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWildcardRulesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWildcardRulesResponse) ProtoMessage() {}
+
+func (x *ListWildcardRulesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[61]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWildcardRulesResponse.ProtoReflect.Descriptor instead.
+func (*ListWildcardRulesResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{61}
+}
+
+func (x *ListWildcardRulesResponse) GetRules() []*WildcardRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+type DeleteWildcardRuleRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Prefix string                 `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// Custom domain of the rule ("" = default)
+	Domain        string `protobuf:"bytes,3,opt,name=domain,proto3" json:"domain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteWildcardRuleRequest) Reset() {
+	*x = DeleteWildcardRuleRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[62]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteWildcardRuleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteWildcardRuleRequest) ProtoMessage() {}
+
+func (x *DeleteWildcardRuleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[62]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteWildcardRuleRequest.ProtoReflect.Descriptor instead.
+func (*DeleteWildcardRuleRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{62}
+}
+
+func (x *DeleteWildcardRuleRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *DeleteWildcardRuleRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *DeleteWildcardRuleRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+type DeleteWildcardRuleResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Deleted       bool                   `protobuf:"varint,1,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteWildcardRuleResponse) Reset() {
+	*x = DeleteWildcardRuleResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[63]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteWildcardRuleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteWildcardRuleResponse) ProtoMessage() {}
+
+func (x *DeleteWildcardRuleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[63]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteWildcardRuleResponse.ProtoReflect.Descriptor instead.
+func (*DeleteWildcardRuleResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{63}
+}
+
+func (x *DeleteWildcardRuleResponse) GetDeleted() bool {
 	if x != nil {
-		return x.Success
+		return x.Deleted
 	}
 	return false
 }
 
-type IncrementClicksRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ShortCode     string                 `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+type GetWildcardRulesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Custom domain ("" = default)
+	Domain        string `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *IncrementClicksRequest) Reset() {
-	*x = IncrementClicksRequest{}
-	mi := &file_proto_url_url_proto_msgTypes[8]
+func (x *GetWildcardRulesRequest) Reset() {
+	*x = GetWildcardRulesRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[64]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *IncrementClicksRequest) String() string {
+func (x *GetWildcardRulesRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*IncrementClicksRequest) ProtoMessage() {}
+func (*GetWildcardRulesRequest) ProtoMessage() {}
 
-func (x *IncrementClicksRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_url_url_proto_msgTypes[8]
+func (x *GetWildcardRulesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[64]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -521,41 +4302,40 @@ func (x *IncrementClicksRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use IncrementClicksRequest.ProtoReflect.Descriptor instead.
-func (*IncrementClicksRequest) Descriptor() ([]byte, []int) {
-	return file_proto_url_url_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use GetWildcardRulesRequest.ProtoReflect.Descriptor instead.
+func (*GetWildcardRulesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{64}
 }
 
-func (x *IncrementClicksRequest) GetShortCode() string {
+func (x *GetWildcardRulesRequest) GetDomain() string {
 	if x != nil {
-		return x.ShortCode
+		return x.Domain
 	}
 	return ""
 }
 
-type IncrementClicksResponse struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// The new click count
-	Clicks        int64 `protobuf:"varint,1,opt,name=clicks,proto3" json:"clicks,omitempty"`
+type GetWildcardRulesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rules         []*WildcardRule        `protobuf:"bytes,1,rep,name=rules,proto3" json:"rules,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *IncrementClicksResponse) Reset() {
-	*x = IncrementClicksResponse{}
-	mi := &file_proto_url_url_proto_msgTypes[9]
+func (x *GetWildcardRulesResponse) Reset() {
+	*x = GetWildcardRulesResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[65]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *IncrementClicksResponse) String() string {
+func (x *GetWildcardRulesResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*IncrementClicksResponse) ProtoMessage() {}
+func (*GetWildcardRulesResponse) ProtoMessage() {}
 
-func (x *IncrementClicksResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_url_url_proto_msgTypes[9]
+func (x *GetWildcardRulesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[65]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -566,47 +4346,42 @@ func (x *IncrementClicksResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use IncrementClicksResponse.ProtoReflect.Descriptor instead.
-func (*IncrementClicksResponse) Descriptor() ([]byte, []int) {
-	return file_proto_url_url_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use GetWildcardRulesResponse.ProtoReflect.Descriptor instead.
+func (*GetWildcardRulesResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{65}
 }
 
-func (x *IncrementClicksResponse) GetClicks() int64 {
+func (x *GetWildcardRulesResponse) GetRules() []*WildcardRule {
 	if x != nil {
-		return x.Clicks
+		return x.Rules
 	}
-	return 0
+	return nil
 }
 
-type CreateCustomURLRequest struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// The custom alias/slug (e.g., "my-brand")
-	Alias string `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`
-	// The original long URL to shorten
-	LongUrl string `protobuf:"bytes,2,opt,name=long_url,json=longUrl,proto3" json:"long_url,omitempty"`
-	// Optional: Expiration timestamp (Unix seconds, 0 = never expires)
-	ExpiresAt int64 `protobuf:"varint,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
-	// Optional: User ID (for authenticated requests)
-	UserId        string `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+type GetURLStatsRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ShortCode string                 `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	// The caller; only the link's owner may read its stats
+	UserId        string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CreateCustomURLRequest) Reset() {
-	*x = CreateCustomURLRequest{}
-	mi := &file_proto_url_url_proto_msgTypes[10]
+func (x *GetURLStatsRequest) Reset() {
+	*x = GetURLStatsRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[66]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreateCustomURLRequest) String() string {
+func (x *GetURLStatsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreateCustomURLRequest) ProtoMessage() {}
+func (*GetURLStatsRequest) ProtoMessage() {}
 
-func (x *CreateCustomURLRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_url_url_proto_msgTypes[10]
+func (x *GetURLStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[66]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -617,72 +4392,60 @@ func (x *CreateCustomURLRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreateCustomURLRequest.ProtoReflect.Descriptor instead.
-func (*CreateCustomURLRequest) Descriptor() ([]byte, []int) {
-	return file_proto_url_url_proto_rawDescGZIP(), []int{10}
-}
-
-func (x *CreateCustomURLRequest) GetAlias() string {
-	if x != nil {
-		return x.Alias
-	}
-	return ""
+// Deprecated: Use GetURLStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetURLStatsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{66}
 }
 
-func (x *CreateCustomURLRequest) GetLongUrl() string {
+func (x *GetURLStatsRequest) GetShortCode() string {
 	if x != nil {
-		return x.LongUrl
+		return x.ShortCode
 	}
 	return ""
 }
 
-func (x *CreateCustomURLRequest) GetExpiresAt() int64 {
-	if x != nil {
-		return x.ExpiresAt
-	}
-	return 0
-}
-
-func (x *CreateCustomURLRequest) GetUserId() string {
+func (x *GetURLStatsRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
 	}
 	return ""
 }
 
-type CreateCustomURLResponse struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// The custom alias (same as request)
-	ShortCode string `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
-	// The full short URL (e.g., "http://short.ly/my-brand")
-	ShortUrl string `protobuf:"bytes,2,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
-	// The original long URL (echo back for confirmation)
-	LongUrl string `protobuf:"bytes,3,opt,name=long_url,json=longUrl,proto3" json:"long_url,omitempty"`
-	// When this URL was created (Unix timestamp in seconds)
-	CreatedAt int64 `protobuf:"varint,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	// When this URL expires (Unix timestamp in seconds, 0 = never expires)
-	ExpiresAt int64 `protobuf:"varint,5,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
-	// QR code as base64 data URI (e.g., "data:image/png;base64,...")
-	QrCode        string `protobuf:"bytes,6,opt,name=qr_code,json=qrCode,proto3" json:"qr_code,omitempty"`
+type GetURLStatsResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ShortCode string                 `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	// Every click so far, including those not flushed to the database yet
+	TotalClicks int64 `protobuf:"varint,2,opt,name=total_clicks,json=totalClicks,proto3" json:"total_clicks,omitempty"`
+	// Of total_clicks, those still pending in Redis
+	PendingClicks int64 `protobuf:"varint,3,opt,name=pending_clicks,json=pendingClicks,proto3" json:"pending_clicks,omitempty"`
+	// Click cap (0 = unlimited)
+	MaxClicks int64 `protobuf:"varint,4,opt,name=max_clicks,json=maxClicks,proto3" json:"max_clicks,omitempty"`
+	// Unix timestamps; expires_at is 0 for a link that never expires
+	CreatedAt int64 `protobuf:"varint,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// When the link's row was last written, click flushes included
+	UpdatedAt int64 `protobuf:"varint,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	ExpiresAt int64 `protobuf:"varint,7,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// Whether the link has passed expires_at
+	Expired       bool `protobuf:"varint,8,opt,name=expired,proto3" json:"expired,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CreateCustomURLResponse) Reset() {
-	*x = CreateCustomURLResponse{}
-	mi := &file_proto_url_url_proto_msgTypes[11]
+func (x *GetURLStatsResponse) Reset() {
+	*x = GetURLStatsResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[67]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreateCustomURLResponse) String() string {
+func (x *GetURLStatsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreateCustomURLResponse) ProtoMessage() {}
+func (*GetURLStatsResponse) ProtoMessage() {}
 
-func (x *CreateCustomURLResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_url_url_proto_msgTypes[11]
+func (x *GetURLStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[67]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -693,92 +4456,93 @@ func (x *CreateCustomURLResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreateCustomURLResponse.ProtoReflect.Descriptor instead.
-func (*CreateCustomURLResponse) Descriptor() ([]byte, []int) {
-	return file_proto_url_url_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use GetURLStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetURLStatsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{67}
 }
 
-func (x *CreateCustomURLResponse) GetShortCode() string {
+func (x *GetURLStatsResponse) GetShortCode() string {
 	if x != nil {
 		return x.ShortCode
 	}
 	return ""
 }
 
-func (x *CreateCustomURLResponse) GetShortUrl() string {
+func (x *GetURLStatsResponse) GetTotalClicks() int64 {
 	if x != nil {
-		return x.ShortUrl
+		return x.TotalClicks
 	}
-	return ""
+	return 0
 }
 
-func (x *CreateCustomURLResponse) GetLongUrl() string {
+func (x *GetURLStatsResponse) GetPendingClicks() int64 {
 	if x != nil {
-		return x.LongUrl
+		return x.PendingClicks
 	}
-	return ""
+	return 0
 }
 
-func (x *CreateCustomURLResponse) GetCreatedAt() int64 {
+func (x *GetURLStatsResponse) GetMaxClicks() int64 {
+	if x != nil {
+		return x.MaxClicks
+	}
+	return 0
+}
+
+func (x *GetURLStatsResponse) GetCreatedAt() int64 {
 	if x != nil {
 		return x.CreatedAt
 	}
 	return 0
 }
 
-func (x *CreateCustomURLResponse) GetExpiresAt() int64 {
+func (x *GetURLStatsResponse) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+func (x *GetURLStatsResponse) GetExpiresAt() int64 {
 	if x != nil {
 		return x.ExpiresAt
 	}
 	return 0
 }
 
-func (x *CreateCustomURLResponse) GetQrCode() string {
+func (x *GetURLStatsResponse) GetExpired() bool {
 	if x != nil {
-		return x.QrCode
+		return x.Expired
 	}
-	return ""
+	return false
 }
 
-// URL represents a shortened URL
-// This is like your DTO/Entity in NestJS
-type URL struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// The short code (Base62 encoded ID)
-	ShortCode string `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
-	// The original long URL
-	LongUrl string `protobuf:"bytes,2,opt,name=long_url,json=longUrl,proto3" json:"long_url,omitempty"`
-	// Number of times this URL has been clicked
-	Clicks int64 `protobuf:"varint,3,opt,name=clicks,proto3" json:"clicks,omitempty"`
-	// When this URL was created (Unix timestamp in seconds)
-	CreatedAt int64 `protobuf:"varint,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	// When this URL was last updated
-	UpdatedAt int64 `protobuf:"varint,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
-	// Whether this URL is active (soft delete)
-	IsActive bool `protobuf:"varint,6,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
-	// Optional: When this URL expires (Unix timestamp, 0 = never expires)
-	ExpiresAt int64 `protobuf:"varint,7,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
-	// The full short URL (e.g., "http://short.ly/abc123")
-	ShortUrl      string `protobuf:"bytes,8,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+type SetURLActiveRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ShortCode string                 `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	// The caller; only the link's owner may enable or disable it
+	UserId string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// true enables the link, false disables it
+	Active        bool `protobuf:"varint,3,opt,name=active,proto3" json:"active,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *URL) Reset() {
-	*x = URL{}
-	mi := &file_proto_url_url_proto_msgTypes[12]
+func (x *SetURLActiveRequest) Reset() {
+	*x = SetURLActiveRequest{}
+	mi := &file_proto_url_url_proto_msgTypes[68]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *URL) String() string {
+func (x *SetURLActiveRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*URL) ProtoMessage() {}
+func (*SetURLActiveRequest) ProtoMessage() {}
 
-func (x *URL) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_url_url_proto_msgTypes[12]
+func (x *SetURLActiveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[68]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -789,77 +4553,115 @@ func (x *URL) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use URL.ProtoReflect.Descriptor instead.
-func (*URL) Descriptor() ([]byte, []int) {
-	return file_proto_url_url_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use SetURLActiveRequest.ProtoReflect.Descriptor instead.
+func (*SetURLActiveRequest) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{68}
 }
 
-func (x *URL) GetShortCode() string {
+func (x *SetURLActiveRequest) GetShortCode() string {
 	if x != nil {
 		return x.ShortCode
 	}
 	return ""
 }
 
-func (x *URL) GetLongUrl() string {
+func (x *SetURLActiveRequest) GetUserId() string {
 	if x != nil {
-		return x.LongUrl
+		return x.UserId
 	}
 	return ""
 }
 
-func (x *URL) GetClicks() int64 {
+func (x *SetURLActiveRequest) GetActive() bool {
 	if x != nil {
-		return x.Clicks
+		return x.Active
 	}
-	return 0
+	return false
 }
 
-func (x *URL) GetCreatedAt() int64 {
-	if x != nil {
-		return x.CreatedAt
-	}
-	return 0
+type SetURLActiveResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ShortCode     string                 `protobuf:"bytes,1,opt,name=short_code,json=shortCode,proto3" json:"short_code,omitempty"`
+	Active        bool                   `protobuf:"varint,2,opt,name=active,proto3" json:"active,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *URL) GetUpdatedAt() int64 {
-	if x != nil {
-		return x.UpdatedAt
-	}
-	return 0
+func (x *SetURLActiveResponse) Reset() {
+	*x = SetURLActiveResponse{}
+	mi := &file_proto_url_url_proto_msgTypes[69]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *URL) GetIsActive() bool {
+func (x *SetURLActiveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetURLActiveResponse) ProtoMessage() {}
+
+func (x *SetURLActiveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_url_url_proto_msgTypes[69]
 	if x != nil {
-		return x.IsActive
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return false
+	return mi.MessageOf(x)
 }
 
-func (x *URL) GetExpiresAt() int64 {
+// Deprecated: Use SetURLActiveResponse.ProtoReflect.Descriptor instead.
+func (*SetURLActiveResponse) Descriptor() ([]byte, []int) {
+	return file_proto_url_url_proto_rawDescGZIP(), []int{69}
+}
+
+func (x *SetURLActiveResponse) GetShortCode() string {
 	if x != nil {
-		return x.ExpiresAt
+		return x.ShortCode
 	}
-	return 0
+	return ""
 }
 
-func (x *URL) GetShortUrl() string {
+func (x *SetURLActiveResponse) GetActive() bool {
 	if x != nil {
-		return x.ShortUrl
+		return x.Active
 	}
-	return ""
+	return false
 }
 
 var File_proto_url_url_proto protoreflect.FileDescriptor
 
 const file_proto_url_url_proto_rawDesc = "" +
 	"\n" +
-	"\x13proto/url/url.proto\x12\x03url\"e\n" +
+	"\x13proto/url/url.proto\x12\x03url\"\x83\x03\n" +
 	"\x10CreateURLRequest\x12\x19\n" +
 	"\blong_url\x18\x01 \x01(\tR\alongUrl\x12\x17\n" +
 	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1d\n" +
 	"\n" +
-	"expires_at\x18\x04 \x01(\x03R\texpiresAt\"\xc1\x01\n" +
+	"expires_at\x18\x04 \x01(\x03R\texpiresAt\x12\x1d\n" +
+	"\n" +
+	"max_clicks\x18\x05 \x01(\x03R\tmaxClicks\x12\x1f\n" +
+	"\vactive_from\x18\x06 \x01(\x03R\n" +
+	"activeFrom\x12\x12\n" +
+	"\x04tags\x18\a \x03(\tR\x04tags\x12\x16\n" +
+	"\x06domain\x18\b \x01(\tR\x06domain\x12+\n" +
+	"\bvariants\x18\t \x03(\v2\x0f.url.URLVariantR\bvariants\x12)\n" +
+	"\tgeo_rules\x18\n" +
+	" \x03(\v2\f.url.GeoRuleR\bgeoRules\x12\x1f\n" +
+	"\vgenerate_qr\x18\v \x01(\bR\n" +
+	"generateQr\x12\x18\n" +
+	"\apreview\x18\f \x01(\bR\apreview\x12\x1d\n" +
+	"\n" +
+	"backup_url\x18\r \x01(\tR\tbackupUrl\"?\n" +
+	"\n" +
+	"URLVariant\x12\x19\n" +
+	"\blong_url\x18\x01 \x01(\tR\alongUrl\x12\x16\n" +
+	"\x06weight\x18\x02 \x01(\x05R\x06weight\"G\n" +
+	"\aGeoRule\x12!\n" +
+	"\fcountry_code\x18\x01 \x01(\tR\vcountryCode\x12\x19\n" +
+	"\blong_url\x18\x02 \x01(\tR\alongUrl\"\xa6\x03\n" +
 	"\x11CreateURLResponse\x12\x1d\n" +
 	"\n" +
 	"short_code\x18\x01 \x01(\tR\tshortCode\x12\x1b\n" +
@@ -869,37 +4671,107 @@ const file_proto_url_url_proto_rawDesc = "" +
 	"created_at\x18\x04 \x01(\x03R\tcreatedAt\x12\x1d\n" +
 	"\n" +
 	"expires_at\x18\x05 \x01(\x03R\texpiresAt\x12\x17\n" +
-	"\aqr_code\x18\x06 \x01(\tR\x06qrCode\".\n" +
+	"\aqr_code\x18\x06 \x01(\tR\x06qrCode\x12\x1d\n" +
+	"\n" +
+	"max_clicks\x18\a \x01(\x03R\tmaxClicks\x12\x1f\n" +
+	"\vactive_from\x18\b \x01(\x03R\n" +
+	"activeFrom\x12\x12\n" +
+	"\x04tags\x18\t \x03(\tR\x04tags\x12+\n" +
+	"\bvariants\x18\n" +
+	" \x03(\v2\x0f.url.URLVariantR\bvariants\x12)\n" +
+	"\tgeo_rules\x18\v \x03(\v2\f.url.GeoRuleR\bgeoRules\x12\x18\n" +
+	"\apreview\x18\f \x01(\bR\apreview\x12\x1d\n" +
+	"\n" +
+	"backup_url\x18\r \x01(\tR\tbackupUrl\"_\n" +
 	"\rGetURLRequest\x12\x1d\n" +
 	"\n" +
-	"short_code\x18\x01 \x01(\tR\tshortCode\"B\n" +
+	"short_code\x18\x01 \x01(\tR\tshortCode\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\"\\\n" +
 	"\x0eGetURLResponse\x12\x1a\n" +
 	"\x03url\x18\x01 \x01(\v2\b.url.URLR\x03url\x12\x14\n" +
-	"\x05found\x18\x02 \x01(\bR\x05found\"X\n" +
+	"\x05found\x18\x02 \x01(\bR\x05found\x12\x18\n" +
+	"\aexpired\x18\x03 \x01(\bR\aexpired\"j\n" +
 	"\x0fListURLsRequest\x12\x14\n" +
 	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
 	"\x06offset\x18\x02 \x01(\x05R\x06offset\x12\x17\n" +
-	"\auser_id\x18\x03 \x01(\tR\x06userId\"a\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\x12\x10\n" +
+	"\x03tag\x18\x04 \x01(\tR\x03tag\"\xcc\x01\n" +
 	"\x10ListURLsResponse\x12\x1c\n" +
 	"\x04urls\x18\x01 \x03(\v2\b.url.URLR\x04urls\x12\x14\n" +
 	"\x05total\x18\x02 \x01(\x05R\x05total\x12\x19\n" +
-	"\bhas_more\x18\x03 \x01(\bR\ahasMore\"1\n" +
+	"\bhas_more\x18\x03 \x01(\bR\ahasMore\x12!\n" +
+	"\ftotal_clicks\x18\x04 \x01(\x03R\vtotalClicks\x12!\n" +
+	"\factive_count\x18\x05 \x01(\x05R\vactiveCount\x12#\n" +
+	"\rexpired_count\x18\x06 \x01(\x05R\fexpiredCount\"Z\n" +
+	"\x11ExportURLsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06cursor\x18\x02 \x01(\tR\x06cursor\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\"S\n" +
+	"\x12ExportURLsResponse\x12\x1c\n" +
+	"\x04urls\x18\x01 \x03(\v2\b.url.URLR\x04urls\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"`\n" +
+	"\x16BatchCreateURLsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12-\n" +
+	"\x05items\x18\x02 \x03(\v2\x17.url.BatchCreateURLItemR\x05items\"x\n" +
+	"\x12BatchCreateURLItem\x12\x19\n" +
+	"\blong_url\x18\x01 \x01(\tR\alongUrl\x12\x14\n" +
+	"\x05alias\x18\x02 \x01(\tR\x05alias\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\x03R\texpiresAt\x12\x12\n" +
+	"\x04tags\x18\x04 \x03(\tR\x04tags\"N\n" +
+	"\x17BatchCreateURLsResponse\x123\n" +
+	"\aresults\x18\x01 \x03(\v2\x19.url.BatchCreateURLResultR\aresults\"h\n" +
+	"\x14BatchCreateURLResult\x12\x1d\n" +
+	"\n" +
+	"short_code\x18\x01 \x01(\tR\tshortCode\x12\x1b\n" +
+	"\tshort_url\x18\x02 \x01(\tR\bshortUrl\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\")\n" +
+	"\x0eGetTagsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"2\n" +
+	"\bTagCount\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\"4\n" +
+	"\x0fGetTagsResponse\x12!\n" +
+	"\x04tags\x18\x01 \x03(\v2\r.url.TagCountR\x04tags\"b\n" +
+	"\x14UpdateURLTagsRequest\x12\x1d\n" +
+	"\n" +
+	"short_code\x18\x01 \x01(\tR\tshortCode\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04tags\x18\x03 \x03(\tR\x04tags\"+\n" +
+	"\x15UpdateURLTagsResponse\x12\x12\n" +
+	"\x04tags\x18\x01 \x03(\tR\x04tags\"`\n" +
 	"\x10DeleteURLRequest\x12\x1d\n" +
 	"\n" +
-	"short_code\x18\x01 \x01(\tR\tshortCode\"-\n" +
+	"short_code\x18\x01 \x01(\tR\tshortCode\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05admin\x18\x03 \x01(\bR\x05admin\"-\n" +
 	"\x11DeleteURLResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\"7\n" +
 	"\x16IncrementClicksRequest\x12\x1d\n" +
 	"\n" +
 	"short_code\x18\x01 \x01(\tR\tshortCode\"1\n" +
 	"\x17IncrementClicksResponse\x12\x16\n" +
-	"\x06clicks\x18\x01 \x01(\x03R\x06clicks\"\x81\x01\n" +
+	"\x06clicks\x18\x01 \x01(\x03R\x06clicks\"\xc7\x02\n" +
 	"\x16CreateCustomURLRequest\x12\x14\n" +
 	"\x05alias\x18\x01 \x01(\tR\x05alias\x12\x19\n" +
 	"\blong_url\x18\x02 \x01(\tR\alongUrl\x12\x1d\n" +
 	"\n" +
 	"expires_at\x18\x03 \x01(\x03R\texpiresAt\x12\x17\n" +
-	"\auser_id\x18\x04 \x01(\tR\x06userId\"\xc7\x01\n" +
+	"\auser_id\x18\x04 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"max_clicks\x18\x05 \x01(\x03R\tmaxClicks\x12\x1f\n" +
+	"\vactive_from\x18\x06 \x01(\x03R\n" +
+	"activeFrom\x12\x12\n" +
+	"\x04tags\x18\a \x03(\tR\x04tags\x12\x16\n" +
+	"\x06domain\x18\b \x01(\tR\x06domain\x12\x1f\n" +
+	"\vgenerate_qr\x18\t \x01(\bR\n" +
+	"generateQr\x12\x18\n" +
+	"\apreview\x18\n" +
+	" \x01(\bR\apreview\x12\x1d\n" +
+	"\n" +
+	"backup_url\x18\v \x01(\tR\tbackupUrl\"\xd4\x02\n" +
 	"\x17CreateCustomURLResponse\x12\x1d\n" +
 	"\n" +
 	"short_code\x18\x01 \x01(\tR\tshortCode\x12\x1b\n" +
@@ -909,7 +4781,16 @@ const file_proto_url_url_proto_rawDesc = "" +
 	"created_at\x18\x04 \x01(\x03R\tcreatedAt\x12\x1d\n" +
 	"\n" +
 	"expires_at\x18\x05 \x01(\x03R\texpiresAt\x12\x17\n" +
-	"\aqr_code\x18\x06 \x01(\tR\x06qrCode\"\xee\x01\n" +
+	"\aqr_code\x18\x06 \x01(\tR\x06qrCode\x12\x1d\n" +
+	"\n" +
+	"max_clicks\x18\a \x01(\x03R\tmaxClicks\x12\x1f\n" +
+	"\vactive_from\x18\b \x01(\x03R\n" +
+	"activeFrom\x12\x12\n" +
+	"\x04tags\x18\t \x03(\tR\x04tags\x12\x18\n" +
+	"\apreview\x18\n" +
+	" \x01(\bR\apreview\x12\x1d\n" +
+	"\n" +
+	"backup_url\x18\v \x01(\tR\tbackupUrl\"\x8e\x05\n" +
 	"\x03URL\x12\x1d\n" +
 	"\n" +
 	"short_code\x18\x01 \x01(\tR\tshortCode\x12\x19\n" +
@@ -922,7 +4803,231 @@ const file_proto_url_url_proto_rawDesc = "" +
 	"\tis_active\x18\x06 \x01(\bR\bisActive\x12\x1d\n" +
 	"\n" +
 	"expires_at\x18\a \x01(\x03R\texpiresAt\x12\x1b\n" +
-	"\tshort_url\x18\b \x01(\tR\bshortUrl2\x8c\x03\n" +
+	"\tshort_url\x18\b \x01(\tR\bshortUrl\x12\x1d\n" +
+	"\n" +
+	"max_clicks\x18\t \x01(\x03R\tmaxClicks\x12\x1f\n" +
+	"\vactive_from\x18\n" +
+	" \x01(\x03R\n" +
+	"activeFrom\x12\x12\n" +
+	"\x04tags\x18\v \x03(\tR\x04tags\x12\x16\n" +
+	"\x06domain\x18\f \x01(\tR\x06domain\x12+\n" +
+	"\bvariants\x18\r \x03(\v2\x0f.url.URLVariantR\bvariants\x12)\n" +
+	"\tgeo_rules\x18\x0e \x03(\v2\f.url.GeoRuleR\bgeoRules\x12\x17\n" +
+	"\aqr_code\x18\x0f \x01(\tR\x06qrCode\x12\x14\n" +
+	"\x05title\x18\x10 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x11 \x01(\tR\vdescription\x12\x1b\n" +
+	"\timage_url\x18\x12 \x01(\tR\bimageUrl\x12\x18\n" +
+	"\apreview\x18\x13 \x01(\bR\apreview\x12\x1d\n" +
+	"\n" +
+	"backup_url\x18\x14 \x01(\tR\tbackupUrl\x12\x17\n" +
+	"\auser_id\x18\x15 \x01(\tR\x06userId\x12\x1a\n" +
+	"\bdisabled\x18\x16 \x01(\bR\bdisabled\"\xbf\x02\n" +
+	"\aWebhook\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"short_code\x18\x02 \x01(\tR\tshortCode\x12\x1d\n" +
+	"\n" +
+	"target_url\x18\x03 \x01(\tR\ttargetUrl\x12'\n" +
+	"\x0fclick_threshold\x18\x04 \x01(\x05R\x0eclickThreshold\x121\n" +
+	"\x15rate_limit_per_minute\x18\x05 \x01(\x05R\x12rateLimitPerMinute\x12#\n" +
+	"\rfailure_count\x18\x06 \x01(\x05R\ffailureCount\x12\x1a\n" +
+	"\bdisabled\x18\a \x01(\bR\bdisabled\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\b \x01(\x03R\tcreatedAt\x12*\n" +
+	"\x11last_delivered_at\x18\t \x01(\x03R\x0flastDeliveredAt\"\xcb\x01\n" +
+	"\x16RegisterWebhookRequest\x12\x1d\n" +
+	"\n" +
+	"short_code\x18\x01 \x01(\tR\tshortCode\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"target_url\x18\x03 \x01(\tR\ttargetUrl\x12'\n" +
+	"\x0fclick_threshold\x18\x04 \x01(\x05R\x0eclickThreshold\x121\n" +
+	"\x15rate_limit_per_minute\x18\x05 \x01(\x05R\x12rateLimitPerMinute\"Y\n" +
+	"\x17RegisterWebhookResponse\x12&\n" +
+	"\awebhook\x18\x01 \x01(\v2\f.url.WebhookR\awebhook\x12\x16\n" +
+	"\x06secret\x18\x02 \x01(\tR\x06secret\"M\n" +
+	"\x13ListWebhooksRequest\x12\x1d\n" +
+	"\n" +
+	"short_code\x18\x01 \x01(\tR\tshortCode\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\"@\n" +
+	"\x14ListWebhooksResponse\x12(\n" +
+	"\bwebhooks\x18\x01 \x03(\v2\f.url.WebhookR\bwebhooks\"?\n" +
+	"\x14DeleteWebhookRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\"1\n" +
+	"\x15DeleteWebhookResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"\xdc\x01\n" +
+	"\x06Domain\x12\x16\n" +
+	"\x06domain\x18\x01 \x01(\tR\x06domain\x12\x1a\n" +
+	"\bverified\x18\x02 \x01(\bR\bverified\x12/\n" +
+	"\x13verification_record\x18\x03 \x01(\tR\x12verificationRecord\x12-\n" +
+	"\x12verification_token\x18\x04 \x01(\tR\x11verificationToken\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\x03R\tcreatedAt\x12\x1f\n" +
+	"\vverified_at\x18\x06 \x01(\x03R\n" +
+	"verifiedAt\"H\n" +
+	"\x15RegisterDomainRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\"=\n" +
+	"\x16RegisterDomainResponse\x12#\n" +
+	"\x06domain\x18\x01 \x01(\v2\v.url.DomainR\x06domain\"-\n" +
+	"\x12ListDomainsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"<\n" +
+	"\x13ListDomainsResponse\x12%\n" +
+	"\adomains\x18\x01 \x03(\v2\v.url.DomainR\adomains\"F\n" +
+	"\x13VerifyDomainRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\";\n" +
+	"\x14VerifyDomainResponse\x12#\n" +
+	"\x06domain\x18\x01 \x01(\v2\v.url.DomainR\x06domain\"\xc9\x01\n" +
+	"\bURLEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1d\n" +
+	"\n" +
+	"short_code\x18\x02 \x01(\tR\tshortCode\x12\x16\n" +
+	"\x06action\x18\x03 \x01(\tR\x06action\x12\x19\n" +
+	"\bactor_id\x18\x04 \x01(\tR\aactorId\x12\x1d\n" +
+	"\n" +
+	"before_url\x18\x05 \x01(\tR\tbeforeUrl\x12\x1b\n" +
+	"\tafter_url\x18\x06 \x01(\tR\bafterUrl\x12\x1f\n" +
+	"\voccurred_at\x18\a \x01(\x03R\n" +
+	"occurredAt\"d\n" +
+	"\x14GetURLHistoryRequest\x12\x1d\n" +
+	"\n" +
+	"short_code\x18\x01 \x01(\tR\tshortCode\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05admin\x18\x03 \x01(\bR\x05admin\">\n" +
+	"\x15GetURLHistoryResponse\x12%\n" +
+	"\x06events\x18\x01 \x03(\v2\r.url.URLEventR\x06events\"m\n" +
+	"\x14ReactivateURLRequest\x12\x1d\n" +
+	"\n" +
+	"short_code\x18\x01 \x01(\tR\tshortCode\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\x03R\texpiresAt\"\x8d\x01\n" +
+	"\x15ReactivateURLResponse\x12\x1d\n" +
+	"\n" +
+	"short_code\x18\x01 \x01(\tR\tshortCode\x12\x1b\n" +
+	"\tshort_url\x18\x02 \x01(\tR\bshortUrl\x12\x19\n" +
+	"\blong_url\x18\x03 \x01(\tR\alongUrl\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\x03R\texpiresAt\"M\n" +
+	"\x11DeleteURLsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1f\n" +
+	"\vshort_codes\x18\x02 \x03(\tR\n" +
+	"shortCodes\"E\n" +
+	"\x12DeleteURLsResponse\x12/\n" +
+	"\aresults\x18\x01 \x03(\v2\x15.url.DeleteURLsResultR\aresults\"I\n" +
+	"\x10DeleteURLsResult\x12\x1d\n" +
+	"\n" +
+	"short_code\x18\x01 \x01(\tR\tshortCode\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\"\xa1\x01\n" +
+	"\x13RegenerateQRRequest\x12\x1d\n" +
+	"\n" +
+	"short_code\x18\x01 \x01(\tR\tshortCode\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x05R\x04size\x12\x1e\n" +
+	"\n" +
+	"foreground\x18\x04 \x01(\tR\n" +
+	"foreground\x12\x1e\n" +
+	"\n" +
+	"background\x18\x05 \x01(\tR\n" +
+	"background\"f\n" +
+	"\x14RegenerateQRResponse\x12\x1d\n" +
+	"\n" +
+	"short_code\x18\x01 \x01(\tR\tshortCode\x12\x17\n" +
+	"\aqr_code\x18\x02 \x01(\tR\x06qrCode\x12\x16\n" +
+	"\x06domain\x18\x03 \x01(\tR\x06domain\"\x83\x01\n" +
+	"\x0eAnalyticsToken\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x1d\n" +
+	"\n" +
+	"short_code\x18\x02 \x01(\tR\tshortCode\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\x03R\texpiresAt\"x\n" +
+	"\x1dGenerateAnalyticsTokenRequest\x12\x1d\n" +
+	"\n" +
+	"short_code\x18\x01 \x01(\tR\tshortCode\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1f\n" +
+	"\vttl_seconds\x18\x03 \x01(\x03R\n" +
+	"ttlSeconds\"K\n" +
+	"\x1eGenerateAnalyticsTokenResponse\x12)\n" +
+	"\x05token\x18\x01 \x01(\v2\x13.url.AnalyticsTokenR\x05token\"T\n" +
+	"\x1aListAnalyticsTokensRequest\x12\x1d\n" +
+	"\n" +
+	"short_code\x18\x01 \x01(\tR\tshortCode\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\"J\n" +
+	"\x1bListAnalyticsTokensResponse\x12+\n" +
+	"\x06tokens\x18\x01 \x03(\v2\x13.url.AnalyticsTokenR\x06tokens\"k\n" +
+	"\x1bRevokeAnalyticsTokenRequest\x12\x1d\n" +
+	"\n" +
+	"short_code\x18\x01 \x01(\tR\tshortCode\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05token\x18\x03 \x01(\tR\x05token\"8\n" +
+	"\x1cRevokeAnalyticsTokenResponse\x12\x18\n" +
+	"\arevoked\x18\x01 \x01(\x05R\arevoked\"\xc1\x01\n" +
+	"\fWildcardRule\x12\x16\n" +
+	"\x06domain\x18\x01 \x01(\tR\x06domain\x12\x16\n" +
+	"\x06prefix\x18\x02 \x01(\tR\x06prefix\x12 \n" +
+	"\vdestination\x18\x03 \x01(\tR\vdestination\x12\x1f\n" +
+	"\vsuffix_mode\x18\x04 \x01(\tR\n" +
+	"suffixMode\x12\x1f\n" +
+	"\vquery_param\x18\x05 \x01(\tR\n" +
+	"queryParam\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\x03R\tcreatedAt\"\xca\x01\n" +
+	"\x19CreateWildcardRuleRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x18\n" +
+	"\apattern\x18\x02 \x01(\tR\apattern\x12 \n" +
+	"\vdestination\x18\x03 \x01(\tR\vdestination\x12\x16\n" +
+	"\x06domain\x18\x04 \x01(\tR\x06domain\x12\x1f\n" +
+	"\vsuffix_mode\x18\x05 \x01(\tR\n" +
+	"suffixMode\x12\x1f\n" +
+	"\vquery_param\x18\x06 \x01(\tR\n" +
+	"queryParam\"C\n" +
+	"\x1aCreateWildcardRuleResponse\x12%\n" +
+	"\x04rule\x18\x01 \x01(\v2\x11.url.WildcardRuleR\x04rule\"3\n" +
+	"\x18ListWildcardRulesRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"D\n" +
+	"\x19ListWildcardRulesResponse\x12'\n" +
+	"\x05rules\x18\x01 \x03(\v2\x11.url.WildcardRuleR\x05rules\"d\n" +
+	"\x19DeleteWildcardRuleRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06prefix\x18\x02 \x01(\tR\x06prefix\x12\x16\n" +
+	"\x06domain\x18\x03 \x01(\tR\x06domain\"6\n" +
+	"\x1aDeleteWildcardRuleResponse\x12\x18\n" +
+	"\adeleted\x18\x01 \x01(\bR\adeleted\"1\n" +
+	"\x17GetWildcardRulesRequest\x12\x16\n" +
+	"\x06domain\x18\x01 \x01(\tR\x06domain\"C\n" +
+	"\x18GetWildcardRulesResponse\x12'\n" +
+	"\x05rules\x18\x01 \x03(\v2\x11.url.WildcardRuleR\x05rules\"L\n" +
+	"\x12GetURLStatsRequest\x12\x1d\n" +
+	"\n" +
+	"short_code\x18\x01 \x01(\tR\tshortCode\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\"\x94\x02\n" +
+	"\x13GetURLStatsResponse\x12\x1d\n" +
+	"\n" +
+	"short_code\x18\x01 \x01(\tR\tshortCode\x12!\n" +
+	"\ftotal_clicks\x18\x02 \x01(\x03R\vtotalClicks\x12%\n" +
+	"\x0epending_clicks\x18\x03 \x01(\x03R\rpendingClicks\x12\x1d\n" +
+	"\n" +
+	"max_clicks\x18\x04 \x01(\x03R\tmaxClicks\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x06 \x01(\x03R\tupdatedAt\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\a \x01(\x03R\texpiresAt\x12\x18\n" +
+	"\aexpired\x18\b \x01(\bR\aexpired\"e\n" +
+	"\x13SetURLActiveRequest\x12\x1d\n" +
+	"\n" +
+	"short_code\x18\x01 \x01(\tR\tshortCode\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06active\x18\x03 \x01(\bR\x06active\"M\n" +
+	"\x14SetURLActiveResponse\x12\x1d\n" +
+	"\n" +
+	"short_code\x18\x01 \x01(\tR\tshortCode\x12\x16\n" +
+	"\x06active\x18\x02 \x01(\bR\x06active2\xcc\x10\n" +
 	"\n" +
 	"URLService\x12:\n" +
 	"\tCreateURL\x12\x15.url.CreateURLRequest\x1a\x16.url.CreateURLResponse\x121\n" +
@@ -930,7 +5035,32 @@ const file_proto_url_url_proto_rawDesc = "" +
 	"\bListURLs\x12\x14.url.ListURLsRequest\x1a\x15.url.ListURLsResponse\x12:\n" +
 	"\tDeleteURL\x12\x15.url.DeleteURLRequest\x1a\x16.url.DeleteURLResponse\x12L\n" +
 	"\x0fIncrementClicks\x12\x1b.url.IncrementClicksRequest\x1a\x1c.url.IncrementClicksResponse\x12L\n" +
-	"\x0fCreateCustomURL\x12\x1b.url.CreateCustomURLRequest\x1a\x1c.url.CreateCustomURLResponseB+Z)github.com/Varun5711/shorternit/proto/urlb\x06proto3"
+	"\x0fCreateCustomURL\x12\x1b.url.CreateCustomURLRequest\x1a\x1c.url.CreateCustomURLResponse\x12L\n" +
+	"\x0fRegisterWebhook\x12\x1b.url.RegisterWebhookRequest\x1a\x1c.url.RegisterWebhookResponse\x12C\n" +
+	"\fListWebhooks\x12\x18.url.ListWebhooksRequest\x1a\x19.url.ListWebhooksResponse\x12F\n" +
+	"\rDeleteWebhook\x12\x19.url.DeleteWebhookRequest\x1a\x1a.url.DeleteWebhookResponse\x12=\n" +
+	"\n" +
+	"ExportURLs\x12\x16.url.ExportURLsRequest\x1a\x17.url.ExportURLsResponse\x12L\n" +
+	"\x0fBatchCreateURLs\x12\x1b.url.BatchCreateURLsRequest\x1a\x1c.url.BatchCreateURLsResponse\x124\n" +
+	"\aGetTags\x12\x13.url.GetTagsRequest\x1a\x14.url.GetTagsResponse\x12F\n" +
+	"\rUpdateURLTags\x12\x19.url.UpdateURLTagsRequest\x1a\x1a.url.UpdateURLTagsResponse\x12I\n" +
+	"\x0eRegisterDomain\x12\x1a.url.RegisterDomainRequest\x1a\x1b.url.RegisterDomainResponse\x12@\n" +
+	"\vListDomains\x12\x17.url.ListDomainsRequest\x1a\x18.url.ListDomainsResponse\x12C\n" +
+	"\fVerifyDomain\x12\x18.url.VerifyDomainRequest\x1a\x19.url.VerifyDomainResponse\x12F\n" +
+	"\rGetURLHistory\x12\x19.url.GetURLHistoryRequest\x1a\x1a.url.GetURLHistoryResponse\x12F\n" +
+	"\rReactivateURL\x12\x19.url.ReactivateURLRequest\x1a\x1a.url.ReactivateURLResponse\x12=\n" +
+	"\n" +
+	"DeleteURLs\x12\x16.url.DeleteURLsRequest\x1a\x17.url.DeleteURLsResponse\x12C\n" +
+	"\fRegenerateQR\x12\x18.url.RegenerateQRRequest\x1a\x19.url.RegenerateQRResponse\x12a\n" +
+	"\x16GenerateAnalyticsToken\x12\".url.GenerateAnalyticsTokenRequest\x1a#.url.GenerateAnalyticsTokenResponse\x12X\n" +
+	"\x13ListAnalyticsTokens\x12\x1f.url.ListAnalyticsTokensRequest\x1a .url.ListAnalyticsTokensResponse\x12[\n" +
+	"\x14RevokeAnalyticsToken\x12 .url.RevokeAnalyticsTokenRequest\x1a!.url.RevokeAnalyticsTokenResponse\x12U\n" +
+	"\x12CreateWildcardRule\x12\x1e.url.CreateWildcardRuleRequest\x1a\x1f.url.CreateWildcardRuleResponse\x12R\n" +
+	"\x11ListWildcardRules\x12\x1d.url.ListWildcardRulesRequest\x1a\x1e.url.ListWildcardRulesResponse\x12U\n" +
+	"\x12DeleteWildcardRule\x12\x1e.url.DeleteWildcardRuleRequest\x1a\x1f.url.DeleteWildcardRuleResponse\x12O\n" +
+	"\x10GetWildcardRules\x12\x1c.url.GetWildcardRulesRequest\x1a\x1d.url.GetWildcardRulesResponse\x12@\n" +
+	"\vGetURLStats\x12\x17.url.GetURLStatsRequest\x1a\x18.url.GetURLStatsResponse\x12C\n" +
+	"\fSetURLActive\x12\x18.url.SetURLActiveRequest\x1a\x19.url.SetURLActiveResponseB+Z)github.com/Varun5711/shorternit/proto/urlb\x06proto3"
 
 var (
 	file_proto_url_url_proto_rawDescOnce sync.Once
@@ -944,42 +5074,167 @@ func file_proto_url_url_proto_rawDescGZIP() []byte {
 	return file_proto_url_url_proto_rawDescData
 }
 
-var file_proto_url_url_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_proto_url_url_proto_msgTypes = make([]protoimpl.MessageInfo, 70)
 var file_proto_url_url_proto_goTypes = []any{
-	(*CreateURLRequest)(nil),        // 0: url.CreateURLRequest
-	(*CreateURLResponse)(nil),       // 1: url.CreateURLResponse
-	(*GetURLRequest)(nil),           // 2: url.GetURLRequest
-	(*GetURLResponse)(nil),          // 3: url.GetURLResponse
-	(*ListURLsRequest)(nil),         // 4: url.ListURLsRequest
-	(*ListURLsResponse)(nil),        // 5: url.ListURLsResponse
-	(*DeleteURLRequest)(nil),        // 6: url.DeleteURLRequest
-	(*DeleteURLResponse)(nil),       // 7: url.DeleteURLResponse
-	(*IncrementClicksRequest)(nil),  // 8: url.IncrementClicksRequest
-	(*IncrementClicksResponse)(nil), // 9: url.IncrementClicksResponse
-	(*CreateCustomURLRequest)(nil),  // 10: url.CreateCustomURLRequest
-	(*CreateCustomURLResponse)(nil), // 11: url.CreateCustomURLResponse
-	(*URL)(nil),                     // 12: url.URL
+	(*CreateURLRequest)(nil),               // 0: url.CreateURLRequest
+	(*URLVariant)(nil),                     // 1: url.URLVariant
+	(*GeoRule)(nil),                        // 2: url.GeoRule
+	(*CreateURLResponse)(nil),              // 3: url.CreateURLResponse
+	(*GetURLRequest)(nil),                  // 4: url.GetURLRequest
+	(*GetURLResponse)(nil),                 // 5: url.GetURLResponse
+	(*ListURLsRequest)(nil),                // 6: url.ListURLsRequest
+	(*ListURLsResponse)(nil),               // 7: url.ListURLsResponse
+	(*ExportURLsRequest)(nil),              // 8: url.ExportURLsRequest
+	(*ExportURLsResponse)(nil),             // 9: url.ExportURLsResponse
+	(*BatchCreateURLsRequest)(nil),         // 10: url.BatchCreateURLsRequest
+	(*BatchCreateURLItem)(nil),             // 11: url.BatchCreateURLItem
+	(*BatchCreateURLsResponse)(nil),        // 12: url.BatchCreateURLsResponse
+	(*BatchCreateURLResult)(nil),           // 13: url.BatchCreateURLResult
+	(*GetTagsRequest)(nil),                 // 14: url.GetTagsRequest
+	(*TagCount)(nil),                       // 15: url.TagCount
+	(*GetTagsResponse)(nil),                // 16: url.GetTagsResponse
+	(*UpdateURLTagsRequest)(nil),           // 17: url.UpdateURLTagsRequest
+	(*UpdateURLTagsResponse)(nil),          // 18: url.UpdateURLTagsResponse
+	(*DeleteURLRequest)(nil),               // 19: url.DeleteURLRequest
+	(*DeleteURLResponse)(nil),              // 20: url.DeleteURLResponse
+	(*IncrementClicksRequest)(nil),         // 21: url.IncrementClicksRequest
+	(*IncrementClicksResponse)(nil),        // 22: url.IncrementClicksResponse
+	(*CreateCustomURLRequest)(nil),         // 23: url.CreateCustomURLRequest
+	(*CreateCustomURLResponse)(nil),        // 24: url.CreateCustomURLResponse
+	(*URL)(nil),                            // 25: url.URL
+	(*Webhook)(nil),                        // 26: url.Webhook
+	(*RegisterWebhookRequest)(nil),         // 27: url.RegisterWebhookRequest
+	(*RegisterWebhookResponse)(nil),        // 28: url.RegisterWebhookResponse
+	(*ListWebhooksRequest)(nil),            // 29: url.ListWebhooksRequest
+	(*ListWebhooksResponse)(nil),           // 30: url.ListWebhooksResponse
+	(*DeleteWebhookRequest)(nil),           // 31: url.DeleteWebhookRequest
+	(*DeleteWebhookResponse)(nil),          // 32: url.DeleteWebhookResponse
+	(*Domain)(nil),                         // 33: url.Domain
+	(*RegisterDomainRequest)(nil),          // 34: url.RegisterDomainRequest
+	(*RegisterDomainResponse)(nil),         // 35: url.RegisterDomainResponse
+	(*ListDomainsRequest)(nil),             // 36: url.ListDomainsRequest
+	(*ListDomainsResponse)(nil),            // 37: url.ListDomainsResponse
+	(*VerifyDomainRequest)(nil),            // 38: url.VerifyDomainRequest
+	(*VerifyDomainResponse)(nil),           // 39: url.VerifyDomainResponse
+	(*URLEvent)(nil),                       // 40: url.URLEvent
+	(*GetURLHistoryRequest)(nil),           // 41: url.GetURLHistoryRequest
+	(*GetURLHistoryResponse)(nil),          // 42: url.GetURLHistoryResponse
+	(*ReactivateURLRequest)(nil),           // 43: url.ReactivateURLRequest
+	(*ReactivateURLResponse)(nil),          // 44: url.ReactivateURLResponse
+	(*DeleteURLsRequest)(nil),              // 45: url.DeleteURLsRequest
+	(*DeleteURLsResponse)(nil),             // 46: url.DeleteURLsResponse
+	(*DeleteURLsResult)(nil),               // 47: url.DeleteURLsResult
+	(*RegenerateQRRequest)(nil),            // 48: url.RegenerateQRRequest
+	(*RegenerateQRResponse)(nil),           // 49: url.RegenerateQRResponse
+	(*AnalyticsToken)(nil),                 // 50: url.AnalyticsToken
+	(*GenerateAnalyticsTokenRequest)(nil),  // 51: url.GenerateAnalyticsTokenRequest
+	(*GenerateAnalyticsTokenResponse)(nil), // 52: url.GenerateAnalyticsTokenResponse
+	(*ListAnalyticsTokensRequest)(nil),     // 53: url.ListAnalyticsTokensRequest
+	(*ListAnalyticsTokensResponse)(nil),    // 54: url.ListAnalyticsTokensResponse
+	(*RevokeAnalyticsTokenRequest)(nil),    // 55: url.RevokeAnalyticsTokenRequest
+	(*RevokeAnalyticsTokenResponse)(nil),   // 56: url.RevokeAnalyticsTokenResponse
+	(*WildcardRule)(nil),                   // 57: url.WildcardRule
+	(*CreateWildcardRuleRequest)(nil),      // 58: url.CreateWildcardRuleRequest
+	(*CreateWildcardRuleResponse)(nil),     // 59: url.CreateWildcardRuleResponse
+	(*ListWildcardRulesRequest)(nil),       // 60: url.ListWildcardRulesRequest
+	(*ListWildcardRulesResponse)(nil),      // 61: url.ListWildcardRulesResponse
+	(*DeleteWildcardRuleRequest)(nil),      // 62: url.DeleteWildcardRuleRequest
+	(*DeleteWildcardRuleResponse)(nil),     // 63: url.DeleteWildcardRuleResponse
+	(*GetWildcardRulesRequest)(nil),        // 64: url.GetWildcardRulesRequest
+	(*GetWildcardRulesResponse)(nil),       // 65: url.GetWildcardRulesResponse
+	(*GetURLStatsRequest)(nil),             // 66: url.GetURLStatsRequest
+	(*GetURLStatsResponse)(nil),            // 67: url.GetURLStatsResponse
+	(*SetURLActiveRequest)(nil),            // 68: url.SetURLActiveRequest
+	(*SetURLActiveResponse)(nil),           // 69: url.SetURLActiveResponse
 }
 var file_proto_url_url_proto_depIdxs = []int32{
-	12, // 0: url.GetURLResponse.url:type_name -> url.URL
-	12, // 1: url.ListURLsResponse.urls:type_name -> url.URL
-	0,  // 2: url.URLService.CreateURL:input_type -> url.CreateURLRequest
-	2,  // 3: url.URLService.GetURL:input_type -> url.GetURLRequest
-	4,  // 4: url.URLService.ListURLs:input_type -> url.ListURLsRequest
-	6,  // 5: url.URLService.DeleteURL:input_type -> url.DeleteURLRequest
-	8,  // 6: url.URLService.IncrementClicks:input_type -> url.IncrementClicksRequest
-	10, // 7: url.URLService.CreateCustomURL:input_type -> url.CreateCustomURLRequest
-	1,  // 8: url.URLService.CreateURL:output_type -> url.CreateURLResponse
-	3,  // 9: url.URLService.GetURL:output_type -> url.GetURLResponse
-	5,  // 10: url.URLService.ListURLs:output_type -> url.ListURLsResponse
-	7,  // 11: url.URLService.DeleteURL:output_type -> url.DeleteURLResponse
-	9,  // 12: url.URLService.IncrementClicks:output_type -> url.IncrementClicksResponse
-	11, // 13: url.URLService.CreateCustomURL:output_type -> url.CreateCustomURLResponse
-	8,  // [8:14] is the sub-list for method output_type
-	2,  // [2:8] is the sub-list for method input_type
-	2,  // [2:2] is the sub-list for extension type_name
-	2,  // [2:2] is the sub-list for extension extendee
-	0,  // [0:2] is the sub-list for field type_name
+	1,  // 0: url.CreateURLRequest.variants:type_name -> url.URLVariant
+	2,  // 1: url.CreateURLRequest.geo_rules:type_name -> url.GeoRule
+	1,  // 2: url.CreateURLResponse.variants:type_name -> url.URLVariant
+	2,  // 3: url.CreateURLResponse.geo_rules:type_name -> url.GeoRule
+	25, // 4: url.GetURLResponse.url:type_name -> url.URL
+	25, // 5: url.ListURLsResponse.urls:type_name -> url.URL
+	25, // 6: url.ExportURLsResponse.urls:type_name -> url.URL
+	11, // 7: url.BatchCreateURLsRequest.items:type_name -> url.BatchCreateURLItem
+	13, // 8: url.BatchCreateURLsResponse.results:type_name -> url.BatchCreateURLResult
+	15, // 9: url.GetTagsResponse.tags:type_name -> url.TagCount
+	1,  // 10: url.URL.variants:type_name -> url.URLVariant
+	2,  // 11: url.URL.geo_rules:type_name -> url.GeoRule
+	26, // 12: url.RegisterWebhookResponse.webhook:type_name -> url.Webhook
+	26, // 13: url.ListWebhooksResponse.webhooks:type_name -> url.Webhook
+	33, // 14: url.RegisterDomainResponse.domain:type_name -> url.Domain
+	33, // 15: url.ListDomainsResponse.domains:type_name -> url.Domain
+	33, // 16: url.VerifyDomainResponse.domain:type_name -> url.Domain
+	40, // 17: url.GetURLHistoryResponse.events:type_name -> url.URLEvent
+	47, // 18: url.DeleteURLsResponse.results:type_name -> url.DeleteURLsResult
+	50, // 19: url.GenerateAnalyticsTokenResponse.token:type_name -> url.AnalyticsToken
+	50, // 20: url.ListAnalyticsTokensResponse.tokens:type_name -> url.AnalyticsToken
+	57, // 21: url.CreateWildcardRuleResponse.rule:type_name -> url.WildcardRule
+	57, // 22: url.ListWildcardRulesResponse.rules:type_name -> url.WildcardRule
+	57, // 23: url.GetWildcardRulesResponse.rules:type_name -> url.WildcardRule
+	0,  // 24: url.URLService.CreateURL:input_type -> url.CreateURLRequest
+	4,  // 25: url.URLService.GetURL:input_type -> url.GetURLRequest
+	6,  // 26: url.URLService.ListURLs:input_type -> url.ListURLsRequest
+	19, // 27: url.URLService.DeleteURL:input_type -> url.DeleteURLRequest
+	21, // 28: url.URLService.IncrementClicks:input_type -> url.IncrementClicksRequest
+	23, // 29: url.URLService.CreateCustomURL:input_type -> url.CreateCustomURLRequest
+	27, // 30: url.URLService.RegisterWebhook:input_type -> url.RegisterWebhookRequest
+	29, // 31: url.URLService.ListWebhooks:input_type -> url.ListWebhooksRequest
+	31, // 32: url.URLService.DeleteWebhook:input_type -> url.DeleteWebhookRequest
+	8,  // 33: url.URLService.ExportURLs:input_type -> url.ExportURLsRequest
+	10, // 34: url.URLService.BatchCreateURLs:input_type -> url.BatchCreateURLsRequest
+	14, // 35: url.URLService.GetTags:input_type -> url.GetTagsRequest
+	17, // 36: url.URLService.UpdateURLTags:input_type -> url.UpdateURLTagsRequest
+	34, // 37: url.URLService.RegisterDomain:input_type -> url.RegisterDomainRequest
+	36, // 38: url.URLService.ListDomains:input_type -> url.ListDomainsRequest
+	38, // 39: url.URLService.VerifyDomain:input_type -> url.VerifyDomainRequest
+	41, // 40: url.URLService.GetURLHistory:input_type -> url.GetURLHistoryRequest
+	43, // 41: url.URLService.ReactivateURL:input_type -> url.ReactivateURLRequest
+	45, // 42: url.URLService.DeleteURLs:input_type -> url.DeleteURLsRequest
+	48, // 43: url.URLService.RegenerateQR:input_type -> url.RegenerateQRRequest
+	51, // 44: url.URLService.GenerateAnalyticsToken:input_type -> url.GenerateAnalyticsTokenRequest
+	53, // 45: url.URLService.ListAnalyticsTokens:input_type -> url.ListAnalyticsTokensRequest
+	55, // 46: url.URLService.RevokeAnalyticsToken:input_type -> url.RevokeAnalyticsTokenRequest
+	58, // 47: url.URLService.CreateWildcardRule:input_type -> url.CreateWildcardRuleRequest
+	60, // 48: url.URLService.ListWildcardRules:input_type -> url.ListWildcardRulesRequest
+	62, // 49: url.URLService.DeleteWildcardRule:input_type -> url.DeleteWildcardRuleRequest
+	64, // 50: url.URLService.GetWildcardRules:input_type -> url.GetWildcardRulesRequest
+	66, // 51: url.URLService.GetURLStats:input_type -> url.GetURLStatsRequest
+	68, // 52: url.URLService.SetURLActive:input_type -> url.SetURLActiveRequest
+	3,  // 53: url.URLService.CreateURL:output_type -> url.CreateURLResponse
+	5,  // 54: url.URLService.GetURL:output_type -> url.GetURLResponse
+	7,  // 55: url.URLService.ListURLs:output_type -> url.ListURLsResponse
+	20, // 56: url.URLService.DeleteURL:output_type -> url.DeleteURLResponse
+	22, // 57: url.URLService.IncrementClicks:output_type -> url.IncrementClicksResponse
+	24, // 58: url.URLService.CreateCustomURL:output_type -> url.CreateCustomURLResponse
+	28, // 59: url.URLService.RegisterWebhook:output_type -> url.RegisterWebhookResponse
+	30, // 60: url.URLService.ListWebhooks:output_type -> url.ListWebhooksResponse
+	32, // 61: url.URLService.DeleteWebhook:output_type -> url.DeleteWebhookResponse
+	9,  // 62: url.URLService.ExportURLs:output_type -> url.ExportURLsResponse
+	12, // 63: url.URLService.BatchCreateURLs:output_type -> url.BatchCreateURLsResponse
+	16, // 64: url.URLService.GetTags:output_type -> url.GetTagsResponse
+	18, // 65: url.URLService.UpdateURLTags:output_type -> url.UpdateURLTagsResponse
+	35, // 66: url.URLService.RegisterDomain:output_type -> url.RegisterDomainResponse
+	37, // 67: url.URLService.ListDomains:output_type -> url.ListDomainsResponse
+	39, // 68: url.URLService.VerifyDomain:output_type -> url.VerifyDomainResponse
+	42, // 69: url.URLService.GetURLHistory:output_type -> url.GetURLHistoryResponse
+	44, // 70: url.URLService.ReactivateURL:output_type -> url.ReactivateURLResponse
+	46, // 71: url.URLService.DeleteURLs:output_type -> url.DeleteURLsResponse
+	49, // 72: url.URLService.RegenerateQR:output_type -> url.RegenerateQRResponse
+	52, // 73: url.URLService.GenerateAnalyticsToken:output_type -> url.GenerateAnalyticsTokenResponse
+	54, // 74: url.URLService.ListAnalyticsTokens:output_type -> url.ListAnalyticsTokensResponse
+	56, // 75: url.URLService.RevokeAnalyticsToken:output_type -> url.RevokeAnalyticsTokenResponse
+	59, // 76: url.URLService.CreateWildcardRule:output_type -> url.CreateWildcardRuleResponse
+	61, // 77: url.URLService.ListWildcardRules:output_type -> url.ListWildcardRulesResponse
+	63, // 78: url.URLService.DeleteWildcardRule:output_type -> url.DeleteWildcardRuleResponse
+	65, // 79: url.URLService.GetWildcardRules:output_type -> url.GetWildcardRulesResponse
+	67, // 80: url.URLService.GetURLStats:output_type -> url.GetURLStatsResponse
+	69, // 81: url.URLService.SetURLActive:output_type -> url.SetURLActiveResponse
+	53, // [53:82] is the sub-list for method output_type
+	24, // [24:53] is the sub-list for method input_type
+	24, // [24:24] is the sub-list for extension type_name
+	24, // [24:24] is the sub-list for extension extendee
+	0,  // [0:24] is the sub-list for field type_name
 }
 
 func init() { file_proto_url_url_proto_init() }
@@ -993,7 +5248,7 @@ func file_proto_url_url_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_url_url_proto_rawDesc), len(file_proto_url_url_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   13,
+			NumMessages:   70,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,12 +19,35 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	URLService_CreateURL_FullMethodName       = "/url.URLService/CreateURL"
-	URLService_GetURL_FullMethodName          = "/url.URLService/GetURL"
-	URLService_ListURLs_FullMethodName        = "/url.URLService/ListURLs"
-	URLService_DeleteURL_FullMethodName       = "/url.URLService/DeleteURL"
-	URLService_IncrementClicks_FullMethodName = "/url.URLService/IncrementClicks"
-	URLService_CreateCustomURL_FullMethodName = "/url.URLService/CreateCustomURL"
+	URLService_CreateURL_FullMethodName              = "/url.URLService/CreateURL"
+	URLService_GetURL_FullMethodName                 = "/url.URLService/GetURL"
+	URLService_ListURLs_FullMethodName               = "/url.URLService/ListURLs"
+	URLService_DeleteURL_FullMethodName              = "/url.URLService/DeleteURL"
+	URLService_IncrementClicks_FullMethodName        = "/url.URLService/IncrementClicks"
+	URLService_CreateCustomURL_FullMethodName        = "/url.URLService/CreateCustomURL"
+	URLService_RegisterWebhook_FullMethodName        = "/url.URLService/RegisterWebhook"
+	URLService_ListWebhooks_FullMethodName           = "/url.URLService/ListWebhooks"
+	URLService_DeleteWebhook_FullMethodName          = "/url.URLService/DeleteWebhook"
+	URLService_ExportURLs_FullMethodName             = "/url.URLService/ExportURLs"
+	URLService_BatchCreateURLs_FullMethodName        = "/url.URLService/BatchCreateURLs"
+	URLService_GetTags_FullMethodName                = "/url.URLService/GetTags"
+	URLService_UpdateURLTags_FullMethodName          = "/url.URLService/UpdateURLTags"
+	URLService_RegisterDomain_FullMethodName         = "/url.URLService/RegisterDomain"
+	URLService_ListDomains_FullMethodName            = "/url.URLService/ListDomains"
+	URLService_VerifyDomain_FullMethodName           = "/url.URLService/VerifyDomain"
+	URLService_GetURLHistory_FullMethodName          = "/url.URLService/GetURLHistory"
+	URLService_ReactivateURL_FullMethodName          = "/url.URLService/ReactivateURL"
+	URLService_DeleteURLs_FullMethodName             = "/url.URLService/DeleteURLs"
+	URLService_RegenerateQR_FullMethodName           = "/url.URLService/RegenerateQR"
+	URLService_GenerateAnalyticsToken_FullMethodName = "/url.URLService/GenerateAnalyticsToken"
+	URLService_ListAnalyticsTokens_FullMethodName    = "/url.URLService/ListAnalyticsTokens"
+	URLService_RevokeAnalyticsToken_FullMethodName   = "/url.URLService/RevokeAnalyticsToken"
+	URLService_CreateWildcardRule_FullMethodName     = "/url.URLService/CreateWildcardRule"
+	URLService_ListWildcardRules_FullMethodName      = "/url.URLService/ListWildcardRules"
+	URLService_DeleteWildcardRule_FullMethodName     = "/url.URLService/DeleteWildcardRule"
+	URLService_GetWildcardRules_FullMethodName       = "/url.URLService/GetWildcardRules"
+	URLService_GetURLStats_FullMethodName            = "/url.URLService/GetURLStats"
+	URLService_SetURLActive_FullMethodName           = "/url.URLService/SetURLActive"
 )
 
 // URLServiceClient is the client API for URLService service.
@@ -55,6 +78,78 @@ type URLServiceClient interface {
 	// CreateCustomURL creates a URL with a custom alias
 	// Like: @Post('/urls/custom') in NestJS
 	CreateCustomURL(ctx context.Context, in *CreateCustomURLRequest, opts ...grpc.CallOption) (*CreateCustomURLResponse, error)
+	// RegisterWebhook attaches a click webhook to a short link owned by the caller
+	// Like: @Post('/webhooks') in NestJS
+	RegisterWebhook(ctx context.Context, in *RegisterWebhookRequest, opts ...grpc.CallOption) (*RegisterWebhookResponse, error)
+	// ListWebhooks returns the caller's webhooks on a short link
+	// Like: @Get('/webhooks') in NestJS
+	ListWebhooks(ctx context.Context, in *ListWebhooksRequest, opts ...grpc.CallOption) (*ListWebhooksResponse, error)
+	// DeleteWebhook removes one of the caller's webhooks
+	// Like: @Delete('/webhooks/:id') in NestJS
+	DeleteWebhook(ctx context.Context, in *DeleteWebhookRequest, opts ...grpc.CallOption) (*DeleteWebhookResponse, error)
+	// ExportURLs pages through all of a user's URLs with an opaque keyset cursor
+	// Used by the API gateway's bulk export; callers loop until next_cursor is empty
+	ExportURLs(ctx context.Context, in *ExportURLsRequest, opts ...grpc.CallOption) (*ExportURLsResponse, error)
+	// BatchCreateURLs creates many URLs for one user in a single call
+	// Used by the API gateway's bulk import; each item succeeds or fails independently
+	BatchCreateURLs(ctx context.Context, in *BatchCreateURLsRequest, opts ...grpc.CallOption) (*BatchCreateURLsResponse, error)
+	// GetTags returns a user's distinct tags with usage counts
+	// Like: @Get('/tags') in NestJS
+	GetTags(ctx context.Context, in *GetTagsRequest, opts ...grpc.CallOption) (*GetTagsResponse, error)
+	// UpdateURLTags replaces the tags on a URL owned by the caller
+	// Like: @Put('/urls/:code/tags') in NestJS
+	UpdateURLTags(ctx context.Context, in *UpdateURLTagsRequest, opts ...grpc.CallOption) (*UpdateURLTagsResponse, error)
+	// RegisterDomain claims a custom domain for the caller and returns its DNS challenge
+	// Like: @Post('/domains') in NestJS
+	RegisterDomain(ctx context.Context, in *RegisterDomainRequest, opts ...grpc.CallOption) (*RegisterDomainResponse, error)
+	// ListDomains returns the caller's custom domains
+	// Like: @Get('/domains') in NestJS
+	ListDomains(ctx context.Context, in *ListDomainsRequest, opts ...grpc.CallOption) (*ListDomainsResponse, error)
+	// VerifyDomain checks the DNS challenge and marks the domain usable for links
+	// Like: @Post('/domains/:domain/verify') in NestJS
+	VerifyDomain(ctx context.Context, in *VerifyDomainRequest, opts ...grpc.CallOption) (*VerifyDomainResponse, error)
+	// GetURLHistory returns the audit trail of a URL: who created, changed or deleted it and when
+	// Like: @Get('/urls/:code/history') in NestJS
+	GetURLHistory(ctx context.Context, in *GetURLHistoryRequest, opts ...grpc.CallOption) (*GetURLHistoryResponse, error)
+	// ReactivateURL brings back one of the caller's links after it expired or was deleted,
+	// unless its short code has been claimed by someone else since
+	// Like: @Post('/urls/:code/reactivate') in NestJS
+	ReactivateURL(ctx context.Context, in *ReactivateURLRequest, opts ...grpc.CallOption) (*ReactivateURLResponse, error)
+	// DeleteURLs deletes many of the caller's links at once, reporting on each code
+	// Like: @Post('/urls/bulk-delete') in NestJS
+	DeleteURLs(ctx context.Context, in *DeleteURLsRequest, opts ...grpc.CallOption) (*DeleteURLsResponse, error)
+	// RegenerateQR renders one of the caller's links' QR code again, with new size and colors
+	// Like: @Post('/urls/:code/qr/regenerate') in NestJS
+	RegenerateQR(ctx context.Context, in *RegenerateQRRequest, opts ...grpc.CallOption) (*RegenerateQRResponse, error)
+	// GenerateAnalyticsToken mints a share token granting read-only access to one of the
+	// caller's links' analytics, without signing in
+	// Like: @Post('/urls/:code/analytics/shares') in NestJS
+	GenerateAnalyticsToken(ctx context.Context, in *GenerateAnalyticsTokenRequest, opts ...grpc.CallOption) (*GenerateAnalyticsTokenResponse, error)
+	// ListAnalyticsTokens returns the share tokens of one of the caller's links that are still valid
+	// Like: @Get('/urls/:code/analytics/shares') in NestJS
+	ListAnalyticsTokens(ctx context.Context, in *ListAnalyticsTokensRequest, opts ...grpc.CallOption) (*ListAnalyticsTokensResponse, error)
+	// RevokeAnalyticsToken revokes one share token of one of the caller's links, or all of them
+	// Like: @Delete('/urls/:code/analytics/shares/:token') in NestJS
+	RevokeAnalyticsToken(ctx context.Context, in *RevokeAnalyticsTokenRequest, opts ...grpc.CallOption) (*RevokeAnalyticsTokenResponse, error)
+	// CreateWildcardRule claims a wildcard alias such as "promo-*" for the caller
+	// Like: @Post('/wildcards') in NestJS
+	CreateWildcardRule(ctx context.Context, in *CreateWildcardRuleRequest, opts ...grpc.CallOption) (*CreateWildcardRuleResponse, error)
+	// ListWildcardRules returns the caller's wildcard aliases
+	// Like: @Get('/wildcards') in NestJS
+	ListWildcardRules(ctx context.Context, in *ListWildcardRulesRequest, opts ...grpc.CallOption) (*ListWildcardRulesResponse, error)
+	// DeleteWildcardRule removes one of the caller's wildcard aliases
+	// Like: @Delete('/wildcards/:prefix') in NestJS
+	DeleteWildcardRule(ctx context.Context, in *DeleteWildcardRuleRequest, opts ...grpc.CallOption) (*DeleteWildcardRuleResponse, error)
+	// GetWildcardRules returns every wildcard alias on a domain, for the redirect service
+	// to resolve codes that are not links of their own
+	GetWildcardRules(ctx context.Context, in *GetWildcardRulesRequest, opts ...grpc.CallOption) (*GetWildcardRulesResponse, error)
+	// GetURLStats returns the click count and timestamps of one of the caller's links,
+	// read from its row rather than the analytics stack, for the TUI and other gRPC consumers
+	GetURLStats(ctx context.Context, in *GetURLStatsRequest, opts ...grpc.CallOption) (*GetURLStatsResponse, error)
+	// SetURLActive disables one of the caller's links, so it answers 410 Gone,
+	// or enables it again, keeping its code and stats either way
+	// Like: @Put('/urls/:code/active') in NestJS
+	SetURLActive(ctx context.Context, in *SetURLActiveRequest, opts ...grpc.CallOption) (*SetURLActiveResponse, error)
 }
 
 type uRLServiceClient struct {
@@ -125,6 +220,236 @@ func (c *uRLServiceClient) CreateCustomURL(ctx context.Context, in *CreateCustom
 	return out, nil
 }
 
+func (c *uRLServiceClient) RegisterWebhook(ctx context.Context, in *RegisterWebhookRequest, opts ...grpc.CallOption) (*RegisterWebhookResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterWebhookResponse)
+	err := c.cc.Invoke(ctx, URLService_RegisterWebhook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) ListWebhooks(ctx context.Context, in *ListWebhooksRequest, opts ...grpc.CallOption) (*ListWebhooksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWebhooksResponse)
+	err := c.cc.Invoke(ctx, URLService_ListWebhooks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) DeleteWebhook(ctx context.Context, in *DeleteWebhookRequest, opts ...grpc.CallOption) (*DeleteWebhookResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteWebhookResponse)
+	err := c.cc.Invoke(ctx, URLService_DeleteWebhook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) ExportURLs(ctx context.Context, in *ExportURLsRequest, opts ...grpc.CallOption) (*ExportURLsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExportURLsResponse)
+	err := c.cc.Invoke(ctx, URLService_ExportURLs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) BatchCreateURLs(ctx context.Context, in *BatchCreateURLsRequest, opts ...grpc.CallOption) (*BatchCreateURLsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchCreateURLsResponse)
+	err := c.cc.Invoke(ctx, URLService_BatchCreateURLs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) GetTags(ctx context.Context, in *GetTagsRequest, opts ...grpc.CallOption) (*GetTagsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTagsResponse)
+	err := c.cc.Invoke(ctx, URLService_GetTags_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) UpdateURLTags(ctx context.Context, in *UpdateURLTagsRequest, opts ...grpc.CallOption) (*UpdateURLTagsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateURLTagsResponse)
+	err := c.cc.Invoke(ctx, URLService_UpdateURLTags_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) RegisterDomain(ctx context.Context, in *RegisterDomainRequest, opts ...grpc.CallOption) (*RegisterDomainResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterDomainResponse)
+	err := c.cc.Invoke(ctx, URLService_RegisterDomain_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) ListDomains(ctx context.Context, in *ListDomainsRequest, opts ...grpc.CallOption) (*ListDomainsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDomainsResponse)
+	err := c.cc.Invoke(ctx, URLService_ListDomains_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) VerifyDomain(ctx context.Context, in *VerifyDomainRequest, opts ...grpc.CallOption) (*VerifyDomainResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyDomainResponse)
+	err := c.cc.Invoke(ctx, URLService_VerifyDomain_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) GetURLHistory(ctx context.Context, in *GetURLHistoryRequest, opts ...grpc.CallOption) (*GetURLHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetURLHistoryResponse)
+	err := c.cc.Invoke(ctx, URLService_GetURLHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) ReactivateURL(ctx context.Context, in *ReactivateURLRequest, opts ...grpc.CallOption) (*ReactivateURLResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReactivateURLResponse)
+	err := c.cc.Invoke(ctx, URLService_ReactivateURL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) DeleteURLs(ctx context.Context, in *DeleteURLsRequest, opts ...grpc.CallOption) (*DeleteURLsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteURLsResponse)
+	err := c.cc.Invoke(ctx, URLService_DeleteURLs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) RegenerateQR(ctx context.Context, in *RegenerateQRRequest, opts ...grpc.CallOption) (*RegenerateQRResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegenerateQRResponse)
+	err := c.cc.Invoke(ctx, URLService_RegenerateQR_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) GenerateAnalyticsToken(ctx context.Context, in *GenerateAnalyticsTokenRequest, opts ...grpc.CallOption) (*GenerateAnalyticsTokenResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GenerateAnalyticsTokenResponse)
+	err := c.cc.Invoke(ctx, URLService_GenerateAnalyticsToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) ListAnalyticsTokens(ctx context.Context, in *ListAnalyticsTokensRequest, opts ...grpc.CallOption) (*ListAnalyticsTokensResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAnalyticsTokensResponse)
+	err := c.cc.Invoke(ctx, URLService_ListAnalyticsTokens_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) RevokeAnalyticsToken(ctx context.Context, in *RevokeAnalyticsTokenRequest, opts ...grpc.CallOption) (*RevokeAnalyticsTokenResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeAnalyticsTokenResponse)
+	err := c.cc.Invoke(ctx, URLService_RevokeAnalyticsToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) CreateWildcardRule(ctx context.Context, in *CreateWildcardRuleRequest, opts ...grpc.CallOption) (*CreateWildcardRuleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateWildcardRuleResponse)
+	err := c.cc.Invoke(ctx, URLService_CreateWildcardRule_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) ListWildcardRules(ctx context.Context, in *ListWildcardRulesRequest, opts ...grpc.CallOption) (*ListWildcardRulesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWildcardRulesResponse)
+	err := c.cc.Invoke(ctx, URLService_ListWildcardRules_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) DeleteWildcardRule(ctx context.Context, in *DeleteWildcardRuleRequest, opts ...grpc.CallOption) (*DeleteWildcardRuleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteWildcardRuleResponse)
+	err := c.cc.Invoke(ctx, URLService_DeleteWildcardRule_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) GetWildcardRules(ctx context.Context, in *GetWildcardRulesRequest, opts ...grpc.CallOption) (*GetWildcardRulesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetWildcardRulesResponse)
+	err := c.cc.Invoke(ctx, URLService_GetWildcardRules_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) GetURLStats(ctx context.Context, in *GetURLStatsRequest, opts ...grpc.CallOption) (*GetURLStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetURLStatsResponse)
+	err := c.cc.Invoke(ctx, URLService_GetURLStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) SetURLActive(ctx context.Context, in *SetURLActiveRequest, opts ...grpc.CallOption) (*SetURLActiveResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetURLActiveResponse)
+	err := c.cc.Invoke(ctx, URLService_SetURLActive_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // URLServiceServer is the server API for URLService service.
 // All implementations must embed UnimplementedURLServiceServer
 // for forward compatibility.
@@ -153,6 +478,78 @@ type URLServiceServer interface {
 	// CreateCustomURL creates a URL with a custom alias
 	// Like: @Post('/urls/custom') in NestJS
 	CreateCustomURL(context.Context, *CreateCustomURLRequest) (*CreateCustomURLResponse, error)
+	// RegisterWebhook attaches a click webhook to a short link owned by the caller
+	// Like: @Post('/webhooks') in NestJS
+	RegisterWebhook(context.Context, *RegisterWebhookRequest) (*RegisterWebhookResponse, error)
+	// ListWebhooks returns the caller's webhooks on a short link
+	// Like: @Get('/webhooks') in NestJS
+	ListWebhooks(context.Context, *ListWebhooksRequest) (*ListWebhooksResponse, error)
+	// DeleteWebhook removes one of the caller's webhooks
+	// Like: @Delete('/webhooks/:id') in NestJS
+	DeleteWebhook(context.Context, *DeleteWebhookRequest) (*DeleteWebhookResponse, error)
+	// ExportURLs pages through all of a user's URLs with an opaque keyset cursor
+	// Used by the API gateway's bulk export; callers loop until next_cursor is empty
+	ExportURLs(context.Context, *ExportURLsRequest) (*ExportURLsResponse, error)
+	// BatchCreateURLs creates many URLs for one user in a single call
+	// Used by the API gateway's bulk import; each item succeeds or fails independently
+	BatchCreateURLs(context.Context, *BatchCreateURLsRequest) (*BatchCreateURLsResponse, error)
+	// GetTags returns a user's distinct tags with usage counts
+	// Like: @Get('/tags') in NestJS
+	GetTags(context.Context, *GetTagsRequest) (*GetTagsResponse, error)
+	// UpdateURLTags replaces the tags on a URL owned by the caller
+	// Like: @Put('/urls/:code/tags') in NestJS
+	UpdateURLTags(context.Context, *UpdateURLTagsRequest) (*UpdateURLTagsResponse, error)
+	// RegisterDomain claims a custom domain for the caller and returns its DNS challenge
+	// Like: @Post('/domains') in NestJS
+	RegisterDomain(context.Context, *RegisterDomainRequest) (*RegisterDomainResponse, error)
+	// ListDomains returns the caller's custom domains
+	// Like: @Get('/domains') in NestJS
+	ListDomains(context.Context, *ListDomainsRequest) (*ListDomainsResponse, error)
+	// VerifyDomain checks the DNS challenge and marks the domain usable for links
+	// Like: @Post('/domains/:domain/verify') in NestJS
+	VerifyDomain(context.Context, *VerifyDomainRequest) (*VerifyDomainResponse, error)
+	// GetURLHistory returns the audit trail of a URL: who created, changed or deleted it and when
+	// Like: @Get('/urls/:code/history') in NestJS
+	GetURLHistory(context.Context, *GetURLHistoryRequest) (*GetURLHistoryResponse, error)
+	// ReactivateURL brings back one of the caller's links after it expired or was deleted,
+	// unless its short code has been claimed by someone else since
+	// Like: @Post('/urls/:code/reactivate') in NestJS
+	ReactivateURL(context.Context, *ReactivateURLRequest) (*ReactivateURLResponse, error)
+	// DeleteURLs deletes many of the caller's links at once, reporting on each code
+	// Like: @Post('/urls/bulk-delete') in NestJS
+	DeleteURLs(context.Context, *DeleteURLsRequest) (*DeleteURLsResponse, error)
+	// RegenerateQR renders one of the caller's links' QR code again, with new size and colors
+	// Like: @Post('/urls/:code/qr/regenerate') in NestJS
+	RegenerateQR(context.Context, *RegenerateQRRequest) (*RegenerateQRResponse, error)
+	// GenerateAnalyticsToken mints a share token granting read-only access to one of the
+	// caller's links' analytics, without signing in
+	// Like: @Post('/urls/:code/analytics/shares') in NestJS
+	GenerateAnalyticsToken(context.Context, *GenerateAnalyticsTokenRequest) (*GenerateAnalyticsTokenResponse, error)
+	// ListAnalyticsTokens returns the share tokens of one of the caller's links that are still valid
+	// Like: @Get('/urls/:code/analytics/shares') in NestJS
+	ListAnalyticsTokens(context.Context, *ListAnalyticsTokensRequest) (*ListAnalyticsTokensResponse, error)
+	// RevokeAnalyticsToken revokes one share token of one of the caller's links, or all of them
+	// Like: @Delete('/urls/:code/analytics/shares/:token') in NestJS
+	RevokeAnalyticsToken(context.Context, *RevokeAnalyticsTokenRequest) (*RevokeAnalyticsTokenResponse, error)
+	// CreateWildcardRule claims a wildcard alias such as "promo-*" for the caller
+	// Like: @Post('/wildcards') in NestJS
+	CreateWildcardRule(context.Context, *CreateWildcardRuleRequest) (*CreateWildcardRuleResponse, error)
+	// ListWildcardRules returns the caller's wildcard aliases
+	// Like: @Get('/wildcards') in NestJS
+	ListWildcardRules(context.Context, *ListWildcardRulesRequest) (*ListWildcardRulesResponse, error)
+	// DeleteWildcardRule removes one of the caller's wildcard aliases
+	// Like: @Delete('/wildcards/:prefix') in NestJS
+	DeleteWildcardRule(context.Context, *DeleteWildcardRuleRequest) (*DeleteWildcardRuleResponse, error)
+	// GetWildcardRules returns every wildcard alias on a domain, for the redirect service
+	// to resolve codes that are not links of their own
+	GetWildcardRules(context.Context, *GetWildcardRulesRequest) (*GetWildcardRulesResponse, error)
+	// GetURLStats returns the click count and timestamps of one of the caller's links,
+	// read from its row rather than the analytics stack, for the TUI and other gRPC consumers
+	GetURLStats(context.Context, *GetURLStatsRequest) (*GetURLStatsResponse, error)
+	// SetURLActive disables one of the caller's links, so it answers 410 Gone,
+	// or enables it again, keeping its code and stats either way
+	// Like: @Put('/urls/:code/active') in NestJS
+	SetURLActive(context.Context, *SetURLActiveRequest) (*SetURLActiveResponse, error)
 	mustEmbedUnimplementedURLServiceServer()
 }
 
@@ -181,6 +578,75 @@ func (UnimplementedURLServiceServer) IncrementClicks(context.Context, *Increment
 func (UnimplementedURLServiceServer) CreateCustomURL(context.Context, *CreateCustomURLRequest) (*CreateCustomURLResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method CreateCustomURL not implemented")
 }
+func (UnimplementedURLServiceServer) RegisterWebhook(context.Context, *RegisterWebhookRequest) (*RegisterWebhookResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RegisterWebhook not implemented")
+}
+func (UnimplementedURLServiceServer) ListWebhooks(context.Context, *ListWebhooksRequest) (*ListWebhooksResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListWebhooks not implemented")
+}
+func (UnimplementedURLServiceServer) DeleteWebhook(context.Context, *DeleteWebhookRequest) (*DeleteWebhookResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteWebhook not implemented")
+}
+func (UnimplementedURLServiceServer) ExportURLs(context.Context, *ExportURLsRequest) (*ExportURLsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ExportURLs not implemented")
+}
+func (UnimplementedURLServiceServer) BatchCreateURLs(context.Context, *BatchCreateURLsRequest) (*BatchCreateURLsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method BatchCreateURLs not implemented")
+}
+func (UnimplementedURLServiceServer) GetTags(context.Context, *GetTagsRequest) (*GetTagsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetTags not implemented")
+}
+func (UnimplementedURLServiceServer) UpdateURLTags(context.Context, *UpdateURLTagsRequest) (*UpdateURLTagsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdateURLTags not implemented")
+}
+func (UnimplementedURLServiceServer) RegisterDomain(context.Context, *RegisterDomainRequest) (*RegisterDomainResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RegisterDomain not implemented")
+}
+func (UnimplementedURLServiceServer) ListDomains(context.Context, *ListDomainsRequest) (*ListDomainsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListDomains not implemented")
+}
+func (UnimplementedURLServiceServer) VerifyDomain(context.Context, *VerifyDomainRequest) (*VerifyDomainResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method VerifyDomain not implemented")
+}
+func (UnimplementedURLServiceServer) GetURLHistory(context.Context, *GetURLHistoryRequest) (*GetURLHistoryResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetURLHistory not implemented")
+}
+func (UnimplementedURLServiceServer) ReactivateURL(context.Context, *ReactivateURLRequest) (*ReactivateURLResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReactivateURL not implemented")
+}
+func (UnimplementedURLServiceServer) DeleteURLs(context.Context, *DeleteURLsRequest) (*DeleteURLsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteURLs not implemented")
+}
+func (UnimplementedURLServiceServer) RegenerateQR(context.Context, *RegenerateQRRequest) (*RegenerateQRResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RegenerateQR not implemented")
+}
+func (UnimplementedURLServiceServer) GenerateAnalyticsToken(context.Context, *GenerateAnalyticsTokenRequest) (*GenerateAnalyticsTokenResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GenerateAnalyticsToken not implemented")
+}
+func (UnimplementedURLServiceServer) ListAnalyticsTokens(context.Context, *ListAnalyticsTokensRequest) (*ListAnalyticsTokensResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListAnalyticsTokens not implemented")
+}
+func (UnimplementedURLServiceServer) RevokeAnalyticsToken(context.Context, *RevokeAnalyticsTokenRequest) (*RevokeAnalyticsTokenResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RevokeAnalyticsToken not implemented")
+}
+func (UnimplementedURLServiceServer) CreateWildcardRule(context.Context, *CreateWildcardRuleRequest) (*CreateWildcardRuleResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateWildcardRule not implemented")
+}
+func (UnimplementedURLServiceServer) ListWildcardRules(context.Context, *ListWildcardRulesRequest) (*ListWildcardRulesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListWildcardRules not implemented")
+}
+func (UnimplementedURLServiceServer) DeleteWildcardRule(context.Context, *DeleteWildcardRuleRequest) (*DeleteWildcardRuleResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteWildcardRule not implemented")
+}
+func (UnimplementedURLServiceServer) GetWildcardRules(context.Context, *GetWildcardRulesRequest) (*GetWildcardRulesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetWildcardRules not implemented")
+}
+func (UnimplementedURLServiceServer) GetURLStats(context.Context, *GetURLStatsRequest) (*GetURLStatsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetURLStats not implemented")
+}
+func (UnimplementedURLServiceServer) SetURLActive(context.Context, *SetURLActiveRequest) (*SetURLActiveResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetURLActive not implemented")
+}
 func (UnimplementedURLServiceServer) mustEmbedUnimplementedURLServiceServer() {}
 func (UnimplementedURLServiceServer) testEmbeddedByValue()                    {}
 
@@ -310,36 +776,542 @@ func _URLService_CreateCustomURL_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
-// URLService_ServiceDesc is the grpc.ServiceDesc for URLService service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var URLService_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "url.URLService",
-	HandlerType: (*URLServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "CreateURL",
-			Handler:    _URLService_CreateURL_Handler,
-		},
-		{
-			MethodName: "GetURL",
-			Handler:    _URLService_GetURL_Handler,
-		},
-		{
-			MethodName: "ListURLs",
-			Handler:    _URLService_ListURLs_Handler,
-		},
-		{
-			MethodName: "DeleteURL",
-			Handler:    _URLService_DeleteURL_Handler,
-		},
-		{
-			MethodName: "IncrementClicks",
-			Handler:    _URLService_IncrementClicks_Handler,
-		},
-		{
-			MethodName: "CreateCustomURL",
-			Handler:    _URLService_CreateCustomURL_Handler,
+func _URLService_RegisterWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterWebhookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).RegisterWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_RegisterWebhook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).RegisterWebhook(ctx, req.(*RegisterWebhookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_ListWebhooks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWebhooksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).ListWebhooks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_ListWebhooks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).ListWebhooks(ctx, req.(*ListWebhooksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_DeleteWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteWebhookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).DeleteWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_DeleteWebhook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).DeleteWebhook(ctx, req.(*DeleteWebhookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_ExportURLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportURLsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).ExportURLs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_ExportURLs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).ExportURLs(ctx, req.(*ExportURLsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_BatchCreateURLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCreateURLsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).BatchCreateURLs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_BatchCreateURLs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).BatchCreateURLs(ctx, req.(*BatchCreateURLsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_GetTags_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTagsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).GetTags(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_GetTags_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).GetTags(ctx, req.(*GetTagsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_UpdateURLTags_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateURLTagsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).UpdateURLTags(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_UpdateURLTags_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).UpdateURLTags(ctx, req.(*UpdateURLTagsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_RegisterDomain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterDomainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).RegisterDomain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_RegisterDomain_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).RegisterDomain(ctx, req.(*RegisterDomainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_ListDomains_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDomainsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).ListDomains(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_ListDomains_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).ListDomains(ctx, req.(*ListDomainsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_VerifyDomain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyDomainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).VerifyDomain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_VerifyDomain_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).VerifyDomain(ctx, req.(*VerifyDomainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_GetURLHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetURLHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).GetURLHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_GetURLHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).GetURLHistory(ctx, req.(*GetURLHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_ReactivateURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReactivateURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).ReactivateURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_ReactivateURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).ReactivateURL(ctx, req.(*ReactivateURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_DeleteURLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteURLsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).DeleteURLs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_DeleteURLs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).DeleteURLs(ctx, req.(*DeleteURLsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_RegenerateQR_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegenerateQRRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).RegenerateQR(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_RegenerateQR_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).RegenerateQR(ctx, req.(*RegenerateQRRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_GenerateAnalyticsToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GenerateAnalyticsTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).GenerateAnalyticsToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_GenerateAnalyticsToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).GenerateAnalyticsToken(ctx, req.(*GenerateAnalyticsTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_ListAnalyticsTokens_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAnalyticsTokensRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).ListAnalyticsTokens(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_ListAnalyticsTokens_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).ListAnalyticsTokens(ctx, req.(*ListAnalyticsTokensRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_RevokeAnalyticsToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeAnalyticsTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).RevokeAnalyticsToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_RevokeAnalyticsToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).RevokeAnalyticsToken(ctx, req.(*RevokeAnalyticsTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_CreateWildcardRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateWildcardRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).CreateWildcardRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_CreateWildcardRule_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).CreateWildcardRule(ctx, req.(*CreateWildcardRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_ListWildcardRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWildcardRulesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).ListWildcardRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_ListWildcardRules_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).ListWildcardRules(ctx, req.(*ListWildcardRulesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_DeleteWildcardRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteWildcardRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).DeleteWildcardRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_DeleteWildcardRule_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).DeleteWildcardRule(ctx, req.(*DeleteWildcardRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_GetWildcardRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWildcardRulesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).GetWildcardRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_GetWildcardRules_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).GetWildcardRules(ctx, req.(*GetWildcardRulesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_GetURLStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetURLStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).GetURLStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_GetURLStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).GetURLStats(ctx, req.(*GetURLStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_SetURLActive_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetURLActiveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).SetURLActive(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_SetURLActive_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).SetURLActive(ctx, req.(*SetURLActiveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// URLService_ServiceDesc is the grpc.ServiceDesc for URLService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var URLService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "url.URLService",
+	HandlerType: (*URLServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateURL",
+			Handler:    _URLService_CreateURL_Handler,
+		},
+		{
+			MethodName: "GetURL",
+			Handler:    _URLService_GetURL_Handler,
+		},
+		{
+			MethodName: "ListURLs",
+			Handler:    _URLService_ListURLs_Handler,
+		},
+		{
+			MethodName: "DeleteURL",
+			Handler:    _URLService_DeleteURL_Handler,
+		},
+		{
+			MethodName: "IncrementClicks",
+			Handler:    _URLService_IncrementClicks_Handler,
+		},
+		{
+			MethodName: "CreateCustomURL",
+			Handler:    _URLService_CreateCustomURL_Handler,
+		},
+		{
+			MethodName: "RegisterWebhook",
+			Handler:    _URLService_RegisterWebhook_Handler,
+		},
+		{
+			MethodName: "ListWebhooks",
+			Handler:    _URLService_ListWebhooks_Handler,
+		},
+		{
+			MethodName: "DeleteWebhook",
+			Handler:    _URLService_DeleteWebhook_Handler,
+		},
+		{
+			MethodName: "ExportURLs",
+			Handler:    _URLService_ExportURLs_Handler,
+		},
+		{
+			MethodName: "BatchCreateURLs",
+			Handler:    _URLService_BatchCreateURLs_Handler,
+		},
+		{
+			MethodName: "GetTags",
+			Handler:    _URLService_GetTags_Handler,
+		},
+		{
+			MethodName: "UpdateURLTags",
+			Handler:    _URLService_UpdateURLTags_Handler,
+		},
+		{
+			MethodName: "RegisterDomain",
+			Handler:    _URLService_RegisterDomain_Handler,
+		},
+		{
+			MethodName: "ListDomains",
+			Handler:    _URLService_ListDomains_Handler,
+		},
+		{
+			MethodName: "VerifyDomain",
+			Handler:    _URLService_VerifyDomain_Handler,
+		},
+		{
+			MethodName: "GetURLHistory",
+			Handler:    _URLService_GetURLHistory_Handler,
+		},
+		{
+			MethodName: "ReactivateURL",
+			Handler:    _URLService_ReactivateURL_Handler,
+		},
+		{
+			MethodName: "DeleteURLs",
+			Handler:    _URLService_DeleteURLs_Handler,
+		},
+		{
+			MethodName: "RegenerateQR",
+			Handler:    _URLService_RegenerateQR_Handler,
+		},
+		{
+			MethodName: "GenerateAnalyticsToken",
+			Handler:    _URLService_GenerateAnalyticsToken_Handler,
+		},
+		{
+			MethodName: "ListAnalyticsTokens",
+			Handler:    _URLService_ListAnalyticsTokens_Handler,
+		},
+		{
+			MethodName: "RevokeAnalyticsToken",
+			Handler:    _URLService_RevokeAnalyticsToken_Handler,
+		},
+		{
+			MethodName: "CreateWildcardRule",
+			Handler:    _URLService_CreateWildcardRule_Handler,
+		},
+		{
+			MethodName: "ListWildcardRules",
+			Handler:    _URLService_ListWildcardRules_Handler,
+		},
+		{
+			MethodName: "DeleteWildcardRule",
+			Handler:    _URLService_DeleteWildcardRule_Handler,
+		},
+		{
+			MethodName: "GetWildcardRules",
+			Handler:    _URLService_GetWildcardRules_Handler,
+		},
+		{
+			MethodName: "GetURLStats",
+			Handler:    _URLService_GetURLStats_Handler,
+		},
+		{
+			MethodName: "SetURLActive",
+			Handler:    _URLService_SetURLActive_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
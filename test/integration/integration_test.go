@@ -298,6 +298,323 @@ func TestRedirect(t *testing.T) {
 	}
 }
 
+// TestDeleteURL creates a short URL, deletes it through the API gateway, and
+// asserts that a second delete and the redirect both answer 404. The link is
+// never followed before the delete, so no redirect-service replica holds it
+// in its in-process cache.
+func TestDeleteURL(t *testing.T) {
+	if authToken == "" {
+		t.Skip("no auth token available")
+	}
+
+	payload := map[string]string{
+		"long_url": "https://example.com/delete-test-" + fmt.Sprint(time.Now().UnixNano()),
+	}
+	body, _ := json.Marshal(payload)
+
+	req, _ := http.NewRequest(http.MethodPost, apiGatewayURL+"/api/urls", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authToken)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("create URL request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var createResult map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&createResult)
+
+	shortCode, ok := createResult["short_code"].(string)
+	if !ok {
+		t.Fatal("no short_code in create response")
+	}
+
+	deleteURL := func() int {
+		req, _ := http.NewRequest(http.MethodDelete, apiGatewayURL+"/api/urls/"+shortCode, nil)
+		req.Header.Set("Authorization", "Bearer "+authToken)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("delete URL request failed: %v", err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := deleteURL(); code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", code)
+	}
+	if code := deleteURL(); code != http.StatusNotFound {
+		t.Errorf("expected status 404 deleting it again, got %d", code)
+	}
+
+	noRedirectClient := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	redirectResp, err := noRedirectClient.Get(redirectURL + "/" + shortCode)
+	if err != nil {
+		t.Fatalf("redirect request failed: %v", err)
+	}
+	defer func() { _ = redirectResp.Body.Close() }()
+
+	if redirectResp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404 after delete, got %d", redirectResp.StatusCode)
+	}
+}
+
+// TestURLDetail creates a tagged short URL and fetches it through the
+// detail endpoint, which must return its destination, tags, expiry and QR
+// code, and answer 404 for a code that does not exist.
+func TestURLDetail(t *testing.T) {
+	if authToken == "" {
+		t.Skip("no auth token available")
+	}
+
+	longURL := "https://example.com/detail-test-" + fmt.Sprint(time.Now().UnixNano())
+	body, _ := json.Marshal(map[string]interface{}{"long_url": longURL, "tags": []string{"detail"}})
+
+	req, _ := http.NewRequest(http.MethodPost, apiGatewayURL+"/api/urls", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authToken)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("create URL request failed: %v", err)
+	}
+	var createResult map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&createResult)
+	_ = resp.Body.Close()
+
+	shortCode, ok := createResult["short_code"].(string)
+	if !ok {
+		t.Fatal("no short_code in create response")
+	}
+
+	getDetail := func(code string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, apiGatewayURL+"/api/urls/"+code, nil)
+		req.Header.Set("Authorization", "Bearer "+authToken)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("detail request failed: %v", err)
+		}
+		return resp
+	}
+
+	resp = getDetail(shortCode)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	var detail struct {
+		ShortCode string   `json:"short_code"`
+		LongURL   string   `json:"long_url"`
+		Tags      []string `json:"tags"`
+		ExpiresAt string   `json:"expires_at"`
+		QRCode    string   `json:"qr_code"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&detail)
+
+	if detail.ShortCode != shortCode || detail.LongURL != longURL {
+		t.Errorf("expected %s -> %s, got %+v", shortCode, longURL, detail)
+	}
+	if len(detail.Tags) != 1 || detail.Tags[0] != "detail" {
+		t.Errorf("expected the detail tag, got %v", detail.Tags)
+	}
+	if detail.ExpiresAt == "" || detail.QRCode == "" {
+		t.Errorf("expected an expiry and a QR code, got %+v", detail)
+	}
+
+	missing := getDetail("no-such-code-" + fmt.Sprint(time.Now().UnixNano()))
+	_ = missing.Body.Close()
+	if missing.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown code, got %d", missing.StatusCode)
+	}
+}
+
+// TestClickReachesBothWorkers verifies that one click fans out to both
+// stream consumers: the analytics-worker increments the link's click count
+// in PostgreSQL and the pipeline-worker stores a ClickHouse row for it. With
+// the two workers in one consumer group only one of these would happen.
+// Both workers must be running; they are polled for up to 30 seconds.
+func TestURLHistory(t *testing.T) {
+	if authToken == "" {
+		t.Skip("no auth token available")
+	}
+
+	longURL := "https://example.com/history-test-" + fmt.Sprint(time.Now().UnixNano())
+	body, _ := json.Marshal(map[string]string{"long_url": longURL})
+
+	req, _ := http.NewRequest(http.MethodPost, apiGatewayURL+"/api/urls", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authToken)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("create URL request failed: %v", err)
+	}
+	var createResult map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&createResult)
+	_ = resp.Body.Close()
+
+	shortCode, ok := createResult["short_code"].(string)
+	if !ok {
+		t.Fatal("no short_code in create response")
+	}
+
+	body, _ = json.Marshal(map[string][]string{"tags": {"history"}})
+	req, _ = http.NewRequest(http.MethodPut, apiGatewayURL+"/api/urls/"+shortCode+"/tags", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authToken)
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("update tags request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	req, _ = http.NewRequest(http.MethodGet, apiGatewayURL+"/api/urls/"+shortCode+"/history", nil)
+	req.Header.Set("Authorization", "Bearer "+authToken)
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("history request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	var history struct {
+		Events []struct {
+			Action   string `json:"action"`
+			AfterURL string `json:"after_url"`
+		} `json:"events"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&history)
+
+	if len(history.Events) != 2 || history.Events[0].Action != "create" || history.Events[1].Action != "update" {
+		t.Fatalf("expected create then update, got %+v", history.Events)
+	}
+	if history.Events[0].AfterURL != longURL {
+		t.Errorf("expected the create to record %s, got %s", longURL, history.Events[0].AfterURL)
+	}
+}
+
+func TestClickReachesBothWorkers(t *testing.T) {
+	if authToken == "" {
+		t.Skip("no auth token available")
+	}
+
+	payload := map[string]string{
+		"long_url": "https://example.com/fan-out-" + fmt.Sprint(time.Now().UnixNano()),
+	}
+	body, _ := json.Marshal(payload)
+
+	req, _ := http.NewRequest(http.MethodPost, apiGatewayURL+"/api/urls", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authToken)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("create URL request failed: %v", err)
+	}
+	var createResult map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&createResult)
+	_ = resp.Body.Close()
+
+	shortCode, ok := createResult["short_code"].(string)
+	if !ok {
+		t.Fatal("no short_code in create response")
+	}
+
+	noRedirectClient := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	redirectResp, err := noRedirectClient.Get(redirectURL + "/" + shortCode)
+	if err != nil {
+		t.Fatalf("redirect request failed: %v", err)
+	}
+	_ = redirectResp.Body.Close()
+
+	var counted, stored bool
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) && !(counted && stored) {
+		if !counted {
+			counted = clickCount(t, shortCode) == 1
+		}
+		if !stored {
+			stored = clickEventRows(t, shortCode) == 1
+		}
+		time.Sleep(time.Second)
+	}
+
+	if !counted {
+		t.Error("expected the analytics-worker to count the click in PostgreSQL")
+	}
+	if !stored {
+		t.Error("expected the pipeline-worker to store the click in ClickHouse")
+	}
+}
+
+// clickCount returns the PostgreSQL click count of the test user's link
+// shortCode, as listed by GET /api/urls, or -1 if it is not listed.
+func clickCount(t *testing.T, shortCode string) int64 {
+	req, _ := http.NewRequest(http.MethodGet, apiGatewayURL+"/api/urls", nil)
+	req.Header.Set("Authorization", "Bearer "+authToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("list URLs request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var result struct {
+		URLs []struct {
+			ShortCode string `json:"short_code"`
+			Clicks    int64  `json:"clicks"`
+		} `json:"urls"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	for _, u := range result.URLs {
+		if u.ShortCode == shortCode {
+			return u.Clicks
+		}
+	}
+	return -1
+}
+
+// clickEventRows returns how many ClickHouse click events
+// GET /api/analytics/clicks reports for shortCode.
+func clickEventRows(t *testing.T, shortCode string) int {
+	req, _ := http.NewRequest(http.MethodGet, apiGatewayURL+"/api/analytics/clicks?short_code="+shortCode, nil)
+	req.Header.Set("Authorization", "Bearer "+authToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("click events request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var result struct {
+		Total int `json:"total"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return result.Total
+}
+
 // TestUnauthorizedAccess verifies that requests without an Authorization
 // header are rejected with 401, ensuring the auth middleware is active.
 func TestUnauthorizedAccess(t *testing.T) {
@@ -356,3 +673,59 @@ func TestNotFoundShortCode(t *testing.T) {
 		t.Errorf("expected status 404, got %d", resp.StatusCode)
 	}
 }
+
+// TestUpdateProfile changes the test user's name and email, then tries to
+// take the email of a second account and expects 409. It runs last because
+// it changes testUserEmail, which it updates for any later login.
+func TestUpdateProfile(t *testing.T) {
+	if authToken == "" {
+		t.Skip("no auth token available")
+	}
+
+	client := &http.Client{}
+	update := func(payload map[string]string) (int, map[string]interface{}) {
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest(http.MethodPut, apiGatewayURL+"/api/auth/profile", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+authToken)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("update profile request failed: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		var result map[string]interface{}
+		_ = json.NewDecoder(resp.Body).Decode(&result)
+		return resp.StatusCode, result
+	}
+
+	newEmail := fmt.Sprintf("updated-%d@example.com", time.Now().UnixNano())
+	code, result := update(map[string]string{"name": "Updated User", "email": newEmail})
+	if code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if result["name"] != "Updated User" || result["email"] != newEmail {
+		t.Errorf("expected the updated name and email, got %v", result)
+	}
+	testUserEmail = newEmail
+
+	otherEmail := fmt.Sprintf("other-%d@example.com", time.Now().UnixNano())
+	body, _ := json.Marshal(map[string]string{
+		"email":    otherEmail,
+		"password": testUserPassword,
+		"name":     "Other User",
+	})
+	resp, err := http.Post(apiGatewayURL+"/api/auth/register", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("registration request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if code, _ := update(map[string]string{"email": otherEmail}); code != http.StatusConflict {
+		t.Errorf("expected status 409 for another account's email, got %d", code)
+	}
+	if code, _ := update(map[string]string{"email": "not-an-email"}); code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid email, got %d", code)
+	}
+}